@@ -3,22 +3,43 @@ package bootstrap
 import (
 	"net/http"
 
+	"github.com/MurtadaNazar/go-api-template/internal/platform/database"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/metrics"
+
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 
 	"github.com/gin-gonic/gin"
 )
 
-// HealthCheckHandler returns a simple DB ping health check
+// HealthCheckHandler returns a DB ping health check along with connection pool statistics
 func HealthCheckHandler(db *gorm.DB, log *zap.SugaredLogger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		requestID, _ := c.Get("RequestID")
 		sqlDB, _ := db.DB()
+		pool := database.NewPoolStats(sqlDB.Stats())
 		if err := sqlDB.PingContext(c.Request.Context()); err != nil {
 			log.Warnw("Health check failed", "error", err, "request_id", requestID)
-			c.JSON(http.StatusInternalServerError, gin.H{"status": "db down", "error": err.Error()})
+			c.JSON(http.StatusInternalServerError, gin.H{"status": "db down", "error": err.Error(), "db_pool": pool})
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "db_pool": pool})
+	}
+}
+
+// MetricsHandler serves connection pool statistics and application metrics in the Prometheus text format
+func MetricsHandler(db *gorm.DB, log *zap.SugaredLogger) gin.HandlerFunc {
+	ReportComponent(Component{Name: "metrics", Status: ComponentActive, Endpoint: "/metrics"})
+	return func(c *gin.Context) {
+		sqlDB, _ := db.DB()
+		c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		c.Status(http.StatusOK)
+		if err := database.WritePoolMetrics(c.Writer, sqlDB.Stats()); err != nil {
+			log.Warnw("Writing metrics failed", "error", err)
+			return
+		}
+		if err := metrics.Write(c.Writer); err != nil {
+			log.Warnw("Writing metrics failed", "error", err)
+		}
 	}
 }
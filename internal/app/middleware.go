@@ -1,20 +1,40 @@
 package bootstrap
 
 import (
-	"go_platform_template/internal/platform/http/middleware"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/http/middleware"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
 // SetupMiddleware adds all your prebuilt middlewares to the Gin engine
-func SetupMiddleware(r *gin.Engine, log *zap.SugaredLogger) {
+func SetupMiddleware(r *gin.Engine, cfg *config.Config, log *zap.SugaredLogger) {
+	// Only these proxies may set the client IP through X-Forwarded-For. Unset, no peer is
+	// trusted and the client IP is the remote address, so clients cannot pick their own IP
+	// to dodge per-IP login limits and bans, or to get another address banned
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Errorf("Invalid TRUSTED_PROXIES, trusting no proxy: %v", err)
+		_ = r.SetTrustedProxies(nil)
+	}
+
+	r.Use(middleware.RequestIDMiddleware())
+	// Calling application from X-Client-Id or the User-Agent, for per-client logs and metrics
+	if cfg.Client.Enabled {
+		r.Use(middleware.ClientIdentificationMiddleware(cfg.Client))
+	}
 	r.Use(
-		middleware.RequestIDMiddleware(),
 		middleware.LoggerMiddleware(log),
 		middleware.RecoveryMiddleware(log),
+	)
+	// Server-Timing header and timing logs with DB and storage time, development only
+	if cfg.GinMode == "debug" || cfg.GinMode == "development" {
+		r.Use(middleware.ServerTimingMiddleware(log))
+	}
+	r.Use(
+		middleware.LocalizationMiddleware(cfg.Localization),
 		middleware.ErrorHandlerMiddleware(log), // Global error handler
-		middleware.CORSMiddleware(),
+		middleware.CORSMiddleware(cfg.CORS),
 		middleware.RateLimitMiddleware(),
 		// middleware.JWTAuthMiddleware(nil), // for global JWT if needed, or per-route
 	)
@@ -0,0 +1,88 @@
+package bootstrap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/http/middleware"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/ipban"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestSetupMiddleware_ClientIP(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		wantIP         string
+	}{
+		{name: "forwarded header ignored without trusted proxies", wantIP: "198.51.100.9"},
+		{name: "forwarded header ignored with invalid proxies", trustedProxies: []string{"not-an-ip"}, wantIP: "198.51.100.9"},
+		{name: "forwarded header honoured from a trusted proxy", trustedProxies: []string{"198.51.100.0/24"}, wantIP: "203.0.113.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			cfg := &config.Config{TrustedProxies: tt.trustedProxies, CORS: config.CORSConfig{AllowOrigins: []string{"*"}}}
+			SetupMiddleware(r, cfg, zap.NewNop().Sugar())
+			var got string
+			r.GET("/ip", func(c *gin.Context) { got = c.ClientIP() })
+			req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+			req.RemoteAddr = "198.51.100.9:4000"
+			req.Header.Set("X-Forwarded-For", "203.0.113.7")
+
+			// Act
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			// Assert
+			if got != tt.wantIP {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.wantIP)
+			}
+		})
+	}
+}
+
+func TestSetupMiddleware_IPBansIgnoreForgedForwardedFor(t *testing.T) {
+	// Arrange: failures on /fail count against the client IP, as failed logins do
+	gin.SetMode(gin.TestMode)
+	log := zap.NewNop().Sugar()
+	bans, err := ipban.NewGuard(ipban.NewMemoryStore(), config.IPBanConfig{Threshold: 2, Window: time.Minute, Duration: time.Hour}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	SetupMiddleware(r, &config.Config{CORS: config.CORSConfig{AllowOrigins: []string{"*"}}}, log)
+	r.Use(middleware.BlockBannedIPs(bans))
+	r.POST("/fail", func(c *gin.Context) {
+		bans.Record(c.Request.Context(), c.ClientIP(), ipban.ReasonFailedLogin)
+		c.Status(http.StatusUnauthorized)
+	})
+	r.GET("/ping", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	send := func(method, path, remoteIP, forwardedFor string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = remoteIP + ":4000"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	attacker, victim := "198.51.100.9", "203.0.113.7"
+
+	// Act: an untrusted peer fails while claiming to be the victim
+	send(http.MethodPost, "/fail", attacker, victim)
+	send(http.MethodPost, "/fail", attacker, victim)
+
+	// Assert: the attacker is banned, whatever address it claims next, and the victim is not
+	if code := send(http.MethodGet, "/ping", attacker, "192.0.2.44"); code != http.StatusForbidden {
+		t.Errorf("attacker with a new forged X-Forwarded-For: status = %d, want %d", code, http.StatusForbidden)
+	}
+	if code := send(http.MethodGet, "/ping", victim, ""); code != http.StatusNoContent {
+		t.Errorf("victim: status = %d, want %d", code, http.StatusNoContent)
+	}
+}
@@ -0,0 +1,48 @@
+package bootstrap
+
+import (
+	"net/http"
+
+	authService "github.com/MurtadaNazar/go-api-template/internal/domain/auth/service"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ListSigningKeysHandler godoc
+// @Summary List the keys that verify access tokens on this instance (requires signing_keys:manage)
+// @Description The current key signs new tokens; the others verify tokens they signed until they are retired.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} response.SuccessResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Router /admin/signing-keys [get]
+func ListSigningKeysHandler(jwtManager *authService.JWTManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, response.NewSuccessResponse(jwtManager.SigningKeys(), c.GetString("RequestID")))
+	}
+}
+
+// ReloadSigningKeysHandler godoc
+// @Summary Read the signing keys again on this instance (requires signing_keys:manage)
+// @Description Picks up keys added to or removed from JWT_KEYS_DIR right away instead of at the next signing-key-reload run. Other instances reload on their own schedule.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} response.SuccessResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /admin/signing-keys/reload [post]
+func ReloadSigningKeysHandler(jwtManager *authService.JWTManager, reload func() error, log *zap.SugaredLogger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := reload(); err != nil {
+			log.Errorw("Reloading JWT signing keys failed", "error", err)
+			_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to reload signing keys; the previous keys stay in use"))
+			return
+		}
+		c.JSON(http.StatusOK, response.NewSuccessResponse(jwtManager.SigningKeys(), c.GetString("RequestID")))
+	}
+}
@@ -9,7 +9,7 @@ import (
 	"sync"
 	"time"
 
-	"go_platform_template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/gin-gonic/gin"
@@ -36,9 +36,11 @@ import (
 //   - /swagger/*any: Serves Swagger UI and JSON documentation
 func SetupSwagger(r *gin.Engine, cfg *config.Config, logger *zap.SugaredLogger) {
 	// Only setup swagger in debug/development modes
-	if cfg.GinMode != "debug" && cfg.GinMode != "development" {
+	if !swaggerEnabled(cfg) {
+		ReportComponent(Component{Name: "swagger", Status: ComponentDisabled, Detail: "only served when GIN_MODE is debug or development"})
 		return
 	}
+	ReportComponent(Component{Name: "swagger", Status: ComponentActive, Endpoint: "/swagger/index.html"})
 
 	// Swagger URL points to the generated JSON (docs.go imports generated swagger from docs/)
 	url := ginSwagger.URL("/swagger/doc.json")
@@ -58,6 +60,11 @@ func SetupSwagger(r *gin.Engine, cfg *config.Config, logger *zap.SugaredLogger)
 	go watchSwaggerBatch(time.Second, "./internal", logger)
 }
 
+// swaggerEnabled reports whether API docs are served, which is only in debug/development modes
+func swaggerEnabled(cfg *config.Config) bool {
+	return cfg.GinMode == "debug" || cfg.GinMode == "development"
+}
+
 // generateSwagger executes the `swag init` command to generate Swagger documentation.
 // It configures the command to:
 //   - Use cmd/server/main.go as the entry point (-g flag)
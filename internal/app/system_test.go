@@ -0,0 +1,46 @@
+package bootstrap
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSystemHandler_ListsLatestReportPerComponent(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	ReportComponent(Component{Name: "test-minio", Status: ComponentActive, Endpoint: "minio:9000/uploads"})
+	ReportComponent(Component{Name: "test-minio", Status: ComponentDegraded, Detail: "connection refused"})
+	r := gin.New()
+	r.GET("/admin/system", SystemHandler())
+
+	// Act
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/system", nil))
+
+	// Assert
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	var body struct {
+		Data SystemInfo `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	var reports []Component
+	for _, c := range body.Data.Components {
+		if c.Name == "test-minio" {
+			reports = append(reports, c)
+		}
+	}
+	if len(reports) != 1 || reports[0].Status != ComponentDegraded || reports[0].Detail != "connection refused" {
+		t.Errorf("test-minio reports = %+v, want one degraded report", reports)
+	}
+	if body.Data.GoVersion == "" || body.Data.StartedAt.IsZero() {
+		t.Errorf("system info = %+v, want the Go version and start time", body.Data)
+	}
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/shared/id"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Activity is one entry of a user's activity feed, such as a profile update or an upload
+// swagger:model Activity
+type Activity struct {
+	// format: uuid
+	ID uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+
+	// User whose feed the entry belongs to
+	// format: uuid
+	UserID uuid.UUID `gorm:"type:uuid;not null;index:idx_activities_user_created,priority:1" json:"-"`
+
+	// What happened
+	// enum: profile.updated,password.changed,file.uploaded
+	// example: profile.updated
+	Type string `gorm:"type:varchar(50);not null" json:"type" example:"profile.updated"`
+
+	// Details of the event, e.g. the changed field names or the uploaded file
+	// example: {"fields":["first_name","timezone"]}
+	Data map[string]any `gorm:"type:text;serializer:json" json:"data,omitempty" swaggertype:"object"`
+
+	// User who caused the event when it was not the user themselves, e.g. an admin
+	// format: uuid
+	ActorID *uuid.UUID `gorm:"type:uuid" json:"actor_id,omitempty"`
+
+	// format: date-time
+	CreatedAt time.Time `gorm:"not null;index:idx_activities_user_created,priority:2;index" json:"created_at"`
+}
+
+// BeforeCreate hook to generate UUID before inserting
+func (a *Activity) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = id.New()
+	}
+	return nil
+}
+
+// TableName sets the insert table name for this struct type
+func (Activity) TableName() string {
+	return "activities"
+}
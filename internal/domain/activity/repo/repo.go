@@ -0,0 +1,51 @@
+package repo
+
+import (
+	"context"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/activity/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ActivityRepo stores the activity feeds of users
+type ActivityRepo interface {
+	Create(ctx context.Context, activity *model.Activity) error
+	// ListByUser returns a user's activities, newest first
+	ListByUser(ctx context.Context, userID string, offset, limit int) ([]model.Activity, error)
+	DeleteByUser(ctx context.Context, userID string) error
+	// DeleteBefore removes activities older than cutoff
+	DeleteBefore(ctx context.Context, cutoff time.Time) error
+}
+
+type activityRepo struct {
+	db *gorm.DB
+}
+
+func NewActivityRepo(db *gorm.DB) ActivityRepo {
+	return &activityRepo{db: db}
+}
+
+func (r *activityRepo) Create(ctx context.Context, activity *model.Activity) error {
+	return r.db.WithContext(ctx).Create(activity).Error
+}
+
+func (r *activityRepo) ListByUser(ctx context.Context, userID string, offset, limit int) ([]model.Activity, error) {
+	var activities []model.Activity
+	query := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at desc, id desc")
+	if limit > 0 {
+		query = query.Offset(offset).Limit(limit)
+	}
+	if err := query.Find(&activities).Error; err != nil {
+		return nil, err
+	}
+	return activities, nil
+}
+
+func (r *activityRepo) DeleteByUser(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&model.Activity{}).Error
+}
+
+func (r *activityRepo) DeleteBefore(ctx context.Context, cutoff time.Time) error {
+	return r.db.WithContext(ctx).Where("created_at < ?", cutoff).Delete(&model.Activity{}).Error
+}
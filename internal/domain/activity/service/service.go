@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/activity/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/activity/repo"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/events"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/clock"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// maxPageSize caps how many activities one List call returns
+const maxPageSize = 100
+
+// feedEvents are the event types that appear in activity feeds
+var feedEvents = map[string]bool{
+	events.ProfileUpdated:  true,
+	events.PasswordChanged: true,
+	events.FileUploaded:    true,
+}
+
+// Service records domain events in users' activity feeds
+type Service struct {
+	repo      repo.ActivityRepo
+	retention time.Duration
+	clock     clock.Clock
+	logger    *zap.SugaredLogger
+}
+
+// NewService returns a Service keeping activities for retention; 0 keeps them forever
+func NewService(r repo.ActivityRepo, retention time.Duration, logger *zap.SugaredLogger) *Service {
+	return &Service{repo: r, retention: retention, clock: clock.System(), logger: logger}
+}
+
+// SetClock replaces the clock used for activity times and retention, for tests
+func (s *Service) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// Subscribe records the events published on bus
+func (s *Service) Subscribe(bus *events.Bus) {
+	bus.Subscribe(s.Record)
+}
+
+// Record adds event to the feed of the user it is about, and drops the feed of an
+// anonymized account. Other events are ignored.
+func (s *Service) Record(ctx context.Context, event events.Event) {
+	userID, err := uuid.Parse(event.UserID)
+	if err != nil {
+		return
+	}
+	// The change the event describes is done; finish recording it even if the request ends
+	ctx = context.WithoutCancel(ctx)
+
+	if event.Type == events.AccountAnonymized {
+		if err := s.repo.DeleteByUser(ctx, event.UserID); err != nil {
+			s.logger.Errorw("failed to delete activity of anonymized account", "user_id", event.UserID, "error", err)
+		}
+		return
+	}
+	if !feedEvents[event.Type] {
+		return
+	}
+
+	activity := &model.Activity{
+		UserID:    userID,
+		Type:      event.Type,
+		Data:      event.Data,
+		CreatedAt: s.clock.Now(),
+	}
+	if actorID, err := uuid.Parse(actor.UserID(ctx)); err == nil && actorID != userID {
+		activity.ActorID = &actorID
+	}
+	if err := s.repo.Create(ctx, activity); err != nil {
+		s.logger.Errorw("failed to record activity", "user_id", event.UserID, "type", event.Type, "error", err)
+	}
+}
+
+// List returns a page of userID's activities, newest first
+func (s *Service) List(ctx context.Context, userID string, offset, limit int) ([]model.Activity, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, apperrors.NewAppError(apperrors.BadRequestError, "Invalid user ID")
+	}
+	if offset < 0 || limit < 1 || limit > maxPageSize {
+		return nil, apperrors.NewAppError(apperrors.BadRequestError, "offset must not be negative and limit must be between 1 and 100")
+	}
+
+	activities, err := s.repo.ListByUser(ctx, userID, offset, limit)
+	if err != nil {
+		s.logger.Errorw("failed to fetch activity", "user_id", userID, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to fetch activity")
+	}
+	if activities == nil {
+		activities = []model.Activity{}
+	}
+	return activities, nil
+}
+
+// Cleanup deletes activities older than the retention period; run it as a background job
+func (s *Service) Cleanup(ctx context.Context) error {
+	if s.retention <= 0 {
+		return nil
+	}
+	return s.repo.DeleteBefore(ctx, s.clock.Now().Add(-s.retention))
+}
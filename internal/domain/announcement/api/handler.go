@@ -0,0 +1,262 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/announcement/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/announcement/service"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/validation"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type AnnouncementHandler struct {
+	service   service.AnnouncementService
+	validator *validation.Validator
+	logger    *zap.SugaredLogger
+}
+
+func NewAnnouncementHandler(s service.AnnouncementService, logger *zap.SugaredLogger) *AnnouncementHandler {
+	return &AnnouncementHandler{
+		service:   s,
+		validator: validation.Default(),
+		logger:    logger,
+	}
+}
+
+// Create godoc
+// @Summary Send an announcement (requires announcements:manage)
+// @Description Emails the announcement to every user in the segment who has not opted out. Delivery runs in the background in throttled batches; follow it with GET /admin/announcements/{id}. With dry_run only the number of recipients is returned.
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param announcement body dto.CreateAnnouncementRequest true "Announcement and the segment to send it to"
+// @Success 200 {object} response.SuccessResponse "Dry run: the number of recipients"
+// @Success 202 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /admin/announcements/ [post]
+func (h *AnnouncementHandler) Create(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+	adminID, err := uuid.Parse(c.GetString("userID"))
+	if err != nil {
+		_ = c.Error(apperrors.NewAppError(apperrors.UnauthorizedError, "User authentication required"))
+		return
+	}
+
+	var req dto.CreateAnnouncementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.NewAppErrorWithDetails(
+			apperrors.BadRequestError,
+			"Invalid request payload",
+			err.Error(),
+		))
+		return
+	}
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	if req.DryRun {
+		recipients, err := h.service.Recipients(c.Request.Context(), req.Segment)
+		if err != nil {
+			h.fail(c, err, "failed to count announcement recipients", "Failed to count recipients")
+			return
+		}
+		c.JSON(http.StatusOK, response.NewSuccessResponse(dto.RecipientsResponse{Recipients: recipients}, requestID))
+		return
+	}
+
+	announcement, err := h.service.Create(c.Request.Context(), adminID, req)
+	if err != nil {
+		h.fail(c, err, "failed to create announcement", "Failed to create announcement")
+		return
+	}
+
+	h.logger.Infow("announcement queued", "announcement_id", announcement.ID,
+		"recipients", announcement.Recipients, "admin_id", adminID, "request_id", requestID)
+	c.Header("Location", strings.TrimSuffix(c.Request.URL.Path, "/")+"/"+announcement.ID.String())
+	c.JSON(http.StatusAccepted, response.NewSuccessResponse(announcement, requestID))
+}
+
+// List godoc
+// @Summary List announcements, newest first (requires announcements:manage)
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param offset query int false "Offset for pagination"
+// @Param limit query int false "Limit for pagination"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /admin/announcements/ [get]
+func (h *AnnouncementHandler) List(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+	offset, limit, err := pagination(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	announcements, err := h.service.List(c.Request.Context(), offset, limit)
+	if err != nil {
+		h.fail(c, err, "failed to list announcements", "Failed to fetch announcements")
+		return
+	}
+	c.JSON(http.StatusOK, response.NewSuccessResponse(announcements, requestID))
+}
+
+// Get godoc
+// @Summary Get an announcement with its delivery counts (requires announcements:manage)
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Announcement ID"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /admin/announcements/{id} [get]
+func (h *AnnouncementHandler) Get(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+	id, ok := announcementID(c)
+	if !ok {
+		return
+	}
+
+	announcement, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		h.fail(c, err, "failed to get announcement", "Failed to fetch announcement")
+		return
+	}
+	c.JSON(http.StatusOK, response.NewSuccessResponse(announcement, requestID))
+}
+
+// Deliveries godoc
+// @Summary List an announcement's recipients and their delivery status (requires announcements:manage)
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Announcement ID"
+// @Param status query string false "Only deliveries with this status (pending, sending, sent, failed, skipped or cancelled)"
+// @Param offset query int false "Offset for pagination"
+// @Param limit query int false "Limit for pagination"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /admin/announcements/{id}/deliveries [get]
+func (h *AnnouncementHandler) Deliveries(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+	id, ok := announcementID(c)
+	if !ok {
+		return
+	}
+	offset, limit, err := pagination(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	deliveries, err := h.service.Deliveries(c.Request.Context(), id, c.Query("status"), offset, limit)
+	if err != nil {
+		h.fail(c, err, "failed to list announcement deliveries", "Failed to fetch deliveries")
+		return
+	}
+	c.JSON(http.StatusOK, response.NewSuccessResponse(deliveries, requestID))
+}
+
+// Cancel godoc
+// @Summary Cancel an announcement (requires announcements:manage)
+// @Description Recipients who have not been emailed yet are not sent to. Emails already sent are not affected.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Announcement ID"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse "Already completed or cancelled"
+// @Failure 500 {object} response.ErrorResponse
+// @Router /admin/announcements/{id}/cancel [post]
+func (h *AnnouncementHandler) Cancel(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+	id, ok := announcementID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Cancel(c.Request.Context(), id); err != nil {
+		h.fail(c, err, "failed to cancel announcement", "Failed to cancel announcement")
+		return
+	}
+
+	h.logger.Infow("announcement cancelled", "announcement_id", id, "admin_id", c.GetString("userID"), "request_id", requestID)
+	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{"message": "Announcement cancelled"}, requestID))
+}
+
+// Unsubscribe godoc
+// @Summary Stop receiving announcements
+// @Description Opens the unsubscribe link from an announcement email. POST is the one-click unsubscribe mail clients send for the List-Unsubscribe header.
+// @Tags Announcements
+// @Produce json
+// @Param token query string true "Token from the unsubscribe link"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /announcements/unsubscribe [get]
+// @Router /announcements/unsubscribe [post]
+func (h *AnnouncementHandler) Unsubscribe(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+	if err := h.service.Unsubscribe(c.Request.Context(), c.Query("token")); err != nil {
+		h.fail(c, err, "failed to unsubscribe from announcements", "Failed to unsubscribe")
+		return
+	}
+	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{"message": "You will no longer receive announcements"}, requestID))
+}
+
+// fail reports err as is when it is an AppError and as an internal error otherwise
+func (h *AnnouncementHandler) fail(c *gin.Context, err error, logMessage, message string) {
+	if appErr, ok := apperrors.IsAppError(err); ok {
+		_ = c.Error(appErr)
+		return
+	}
+	h.logger.Errorw(logMessage, "error", err, "request_id", c.GetString("RequestID"))
+	_ = c.Error(apperrors.NewAppError(apperrors.InternalError, message))
+}
+
+func announcementID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		_ = c.Error(apperrors.NewAppError(apperrors.NotFoundError, "Announcement not found"))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func pagination(c *gin.Context) (int, int, error) {
+	offset := 0
+	limit := 50
+	if v := c.Query("offset"); v != "" {
+		if _, err := fmt.Sscan(v, &offset); err != nil {
+			return 0, 0, apperrors.NewAppErrorWithDetails(apperrors.BadRequestError, "Invalid offset value", err.Error())
+		}
+	}
+	if v := c.Query("limit"); v != "" {
+		if _, err := fmt.Sscan(v, &limit); err != nil {
+			return 0, 0, apperrors.NewAppErrorWithDetails(apperrors.BadRequestError, "Invalid limit value", err.Error())
+		}
+	}
+	return offset, limit, nil
+}
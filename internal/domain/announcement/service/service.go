@@ -0,0 +1,444 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/announcement/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/announcement/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/announcement/repo"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/email"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/metrics"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/clock"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// staleClaim is how long a delivery may stay claimed before another run retries it
+	staleClaim = 15 * time.Minute
+	// maxErrorLength keeps send errors within the delivery's error column
+	maxErrorLength = 512
+	// unsubscribePurpose separates unsubscribe tokens from other HMACs made with the same secret
+	unsubscribePurpose = "announcements-unsubscribe:"
+)
+
+var emailsSent = metrics.NewCounter("announcement_emails_total",
+	"Announcement emails by result: sent, retried, failed or skipped.", "result")
+
+// OptOut records that a user no longer wants announcements
+type OptOut func(ctx context.Context, userID uuid.UUID) error
+
+// AnnouncementService emails announcements to segments of users in throttled batches
+type AnnouncementService interface {
+	// Recipients counts the users a segment would reach, without creating anything
+	Recipients(ctx context.Context, segment model.Segment) (int64, error)
+	// Create queues an announcement for every user in the segment who has not opted out
+	Create(ctx context.Context, createdBy uuid.UUID, req dto.CreateAnnouncementRequest) (*model.Announcement, error)
+	List(ctx context.Context, offset, limit int) ([]model.Announcement, error)
+	// Get returns an announcement with its deliveries counted by status
+	Get(ctx context.Context, id uuid.UUID) (*dto.AnnouncementResponse, error)
+	// Deliveries pages through an announcement's recipients, optionally of one status
+	Deliveries(ctx context.Context, id uuid.UUID, status string, offset, limit int) ([]model.Delivery, error)
+	// Cancel stops an announcement; emails already sent are not affected
+	Cancel(ctx context.Context, id uuid.UUID) error
+	// Deliver sends pending deliveries until none are left or ctx ends; it is run by the
+	// announcement-delivery job
+	Deliver(ctx context.Context) error
+	// Unsubscribe opts the user named by an unsubscribe token out of announcements
+	Unsubscribe(ctx context.Context, token string) error
+}
+
+type announcementService struct {
+	repo    repo.AnnouncementRepo
+	sender  email.Sender
+	cfg     config.AnnouncementConfig
+	optOut  OptOut
+	trigger func()
+	clock   clock.Clock
+	logger  *zap.SugaredLogger
+}
+
+// ServiceOption customizes an AnnouncementService created by NewAnnouncementService
+type ServiceOption func(*announcementService)
+
+// WithDeliveryTrigger calls trigger after an announcement is created, so delivery starts
+// without waiting for the next scheduled run
+func WithDeliveryTrigger(trigger func()) ServiceOption {
+	return func(s *announcementService) {
+		s.trigger = trigger
+	}
+}
+
+// WithClock replaces the time source used for delivery timestamps
+func WithClock(c clock.Clock) ServiceOption {
+	return func(s *announcementService) {
+		s.clock = c
+	}
+}
+
+func NewAnnouncementService(announcementRepo repo.AnnouncementRepo, sender email.Sender, cfg config.AnnouncementConfig, optOut OptOut, logger *zap.SugaredLogger, opts ...ServiceOption) AnnouncementService {
+	s := &announcementService{
+		repo:   announcementRepo,
+		sender: sender,
+		cfg:    cfg,
+		optOut: optOut,
+		clock:  clock.System(),
+		logger: logger,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *announcementService) Recipients(ctx context.Context, segment model.Segment) (int64, error) {
+	segment, err := normalizeSegment(segment)
+	if err != nil {
+		return 0, err
+	}
+	return s.repo.CountRecipients(ctx, segment)
+}
+
+func (s *announcementService) Create(ctx context.Context, createdBy uuid.UUID, req dto.CreateAnnouncementRequest) (*model.Announcement, error) {
+	segment, err := normalizeSegment(req.Segment)
+	if err != nil {
+		return nil, err
+	}
+	count, err := s.repo.CountRecipients(ctx, segment)
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, apperrors.NewAppError(apperrors.ValidationError, "No users match the segment")
+	}
+
+	announcement := &model.Announcement{
+		Subject:   req.Subject,
+		Body:      req.Body,
+		Segment:   segment,
+		Status:    model.StatusQueued,
+		CreatedBy: createdBy,
+		CreatedAt: s.clock.Now(),
+	}
+	if err := s.repo.Create(ctx, announcement); err != nil {
+		return nil, err
+	}
+	if s.trigger != nil {
+		s.trigger()
+	}
+	return announcement, nil
+}
+
+func (s *announcementService) List(ctx context.Context, offset, limit int) ([]model.Announcement, error) {
+	return s.repo.List(ctx, offset, limit)
+}
+
+func (s *announcementService) Get(ctx context.Context, id uuid.UUID) (*dto.AnnouncementResponse, error) {
+	announcement, err := s.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.repo.DeliveryCounts(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.AnnouncementResponse{Announcement: *announcement, Deliveries: counts}, nil
+}
+
+func (s *announcementService) Deliveries(ctx context.Context, id uuid.UUID, status string, offset, limit int) ([]model.Delivery, error) {
+	switch model.DeliveryStatus(status) {
+	case "", model.DeliveryPending, model.DeliverySending, model.DeliverySent,
+		model.DeliveryFailed, model.DeliverySkipped, model.DeliveryCancelled:
+	default:
+		return nil, apperrors.NewAppErrorWithDetails(
+			apperrors.BadRequestError,
+			"Invalid status value",
+			"Use one of pending, sending, sent, failed, skipped or cancelled",
+		)
+	}
+	if _, err := s.find(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.ListDeliveries(ctx, id, model.DeliveryStatus(status), offset, limit)
+}
+
+func (s *announcementService) Cancel(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.find(ctx, id); err != nil {
+		return err
+	}
+	cancelled, err := s.repo.Cancel(ctx, id, s.clock.Now())
+	if err != nil {
+		return err
+	}
+	if !cancelled {
+		return apperrors.NewAppError(apperrors.ConflictError, "Announcement has already finished")
+	}
+	return nil
+}
+
+func (s *announcementService) Deliver(ctx context.Context) error {
+	started := s.clock.Now()
+	released, err := s.repo.ReleaseStale(ctx, started.Add(-staleClaim))
+	if err != nil {
+		return err
+	}
+	if released > 0 {
+		s.logger.Warnw("released stale announcement deliveries", "count", released)
+	}
+
+	batchSize := s.cfg.BatchSize
+	if batchSize < 1 {
+		batchSize = 100
+	}
+	throttle := newThrottle(s.cfg.RatePerSecond)
+	// Deliveries put back for a retry during this run wait for the next one
+	for ctx.Err() == nil {
+		batch, err := s.repo.ClaimDeliveries(ctx, started, batchSize, s.clock.Now())
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		if err := s.sendBatch(ctx, batch, throttle); err != nil {
+			return err
+		}
+	}
+
+	completeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	completed, err := s.repo.CompleteFinished(completeCtx, s.clock.Now())
+	if err != nil {
+		return err
+	}
+	if completed > 0 {
+		s.logger.Infow("announcements completed", "count", completed)
+	}
+	return nil
+}
+
+// sendBatch sends one claimed batch, skipping recipients who opted out or were deleted
+// since the announcement was created. Deliveries left unsent when ctx ends go back to pending.
+func (s *announcementService) sendBatch(ctx context.Context, batch []model.Delivery, throttle *throttle) error {
+	userIDs := make([]uuid.UUID, 0, len(batch))
+	announcements := make(map[uuid.UUID]*model.Announcement)
+	for _, delivery := range batch {
+		userIDs = append(userIDs, delivery.UserID)
+		if _, ok := announcements[delivery.AnnouncementID]; ok {
+			continue
+		}
+		announcement, err := s.repo.FindByID(ctx, delivery.AnnouncementID)
+		if err != nil {
+			s.release(ctx, batch)
+			return err
+		}
+		announcements[delivery.AnnouncementID] = announcement
+	}
+	subscribed, err := s.repo.Subscribed(ctx, userIDs)
+	if err != nil {
+		s.release(ctx, batch)
+		return err
+	}
+	sending := make([]uuid.UUID, 0, len(announcements))
+	for id, announcement := range announcements {
+		if announcement != nil && announcement.Status == model.StatusQueued {
+			sending = append(sending, id)
+		}
+	}
+	if err := s.repo.MarkSending(ctx, sending); err != nil {
+		s.release(ctx, batch)
+		return err
+	}
+
+	for i := range batch {
+		delivery := &batch[i]
+		announcement := announcements[delivery.AnnouncementID]
+		switch {
+		case announcement == nil || announcement.Status == model.StatusCancelled:
+			delivery.Status = model.DeliveryCancelled
+		case !subscribed[delivery.UserID]:
+			delivery.Status = model.DeliverySkipped
+			emailsSent.Inc("skipped")
+		default:
+			if err := throttle.wait(ctx); err != nil {
+				s.release(ctx, batch[i:])
+				return nil
+			}
+			err := s.sender.Send(ctx, s.message(announcement, delivery))
+			if err != nil && ctx.Err() != nil {
+				s.release(ctx, batch[i:])
+				return nil
+			}
+			s.record(delivery, err)
+		}
+		delivery.UpdatedAt = s.clock.Now()
+		s.save(ctx, delivery)
+	}
+	return nil
+}
+
+// record sets the delivery's status after a send: sent, back to pending for another
+// attempt, or failed once the attempts are used up
+func (s *announcementService) record(delivery *model.Delivery, err error) {
+	if err == nil {
+		now := s.clock.Now()
+		delivery.Status = model.DeliverySent
+		delivery.SentAt = &now
+		delivery.Error = ""
+		emailsSent.Inc("sent")
+		return
+	}
+
+	delivery.Attempts++
+	delivery.Error = err.Error()
+	if len(delivery.Error) > maxErrorLength {
+		delivery.Error = delivery.Error[:maxErrorLength]
+	}
+	if delivery.Attempts < s.cfg.MaxAttempts {
+		delivery.Status = model.DeliveryPending
+		emailsSent.Inc("retried")
+		return
+	}
+	delivery.Status = model.DeliveryFailed
+	emailsSent.Inc("failed")
+	s.logger.Warnw("announcement email failed", "announcement_id", delivery.AnnouncementID,
+		"user_id", delivery.UserID, "attempts", delivery.Attempts, "error", err)
+}
+
+// save stores a delivery's outcome even after ctx has ended, so a sent email is not sent again
+func (s *announcementService) save(ctx context.Context, delivery *model.Delivery) {
+	saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+	if err := s.repo.UpdateDelivery(saveCtx, delivery); err != nil {
+		s.logger.Errorw("failed to save announcement delivery", "announcement_id", delivery.AnnouncementID,
+			"user_id", delivery.UserID, "status", delivery.Status, "error", err)
+	}
+}
+
+// release returns claimed deliveries to pending without counting an attempt
+func (s *announcementService) release(ctx context.Context, deliveries []model.Delivery) {
+	for i := range deliveries {
+		deliveries[i].Status = model.DeliveryPending
+		deliveries[i].UpdatedAt = s.clock.Now()
+		s.save(ctx, &deliveries[i])
+	}
+}
+
+func (s *announcementService) message(announcement *model.Announcement, delivery *model.Delivery) email.Message {
+	unsubscribe := s.unsubscribeURL(delivery.UserID)
+	return email.Message{
+		To:      delivery.Email,
+		Subject: announcement.Subject,
+		Body:    announcement.Body + "\n\n--\nTo stop receiving these announcements, visit " + unsubscribe,
+		Headers: map[string]string{
+			"List-Unsubscribe":      "<" + unsubscribe + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	}
+}
+
+func (s *announcementService) Unsubscribe(ctx context.Context, token string) error {
+	userID, ok := s.parseUnsubscribeToken(token)
+	if !ok {
+		return apperrors.NewAppError(apperrors.BadRequestError, "Invalid unsubscribe link")
+	}
+	return s.optOut(ctx, userID)
+}
+
+func (s *announcementService) unsubscribeURL(userID uuid.UUID) string {
+	separator := "?"
+	if strings.Contains(s.cfg.UnsubscribeURL, "?") {
+		separator = "&"
+	}
+	return s.cfg.UnsubscribeURL + separator + "token=" + url.QueryEscape(s.unsubscribeToken(userID))
+}
+
+// unsubscribeToken names the user and signs it, so links keep working without being stored
+func (s *announcementService) unsubscribeToken(userID uuid.UUID) string {
+	return userID.String() + "." + base64.RawURLEncoding.EncodeToString(s.unsubscribeMAC(userID))
+}
+
+func (s *announcementService) parseUnsubscribeToken(token string) (uuid.UUID, bool) {
+	rawID, rawMAC, ok := strings.Cut(token, ".")
+	if !ok {
+		return uuid.Nil, false
+	}
+	userID, err := uuid.Parse(rawID)
+	if err != nil {
+		return uuid.Nil, false
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(rawMAC)
+	if err != nil || !hmac.Equal(mac, s.unsubscribeMAC(userID)) {
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+func (s *announcementService) unsubscribeMAC(userID uuid.UUID) []byte {
+	mac := hmac.New(sha256.New, []byte(s.cfg.UnsubscribeSecret))
+	mac.Write([]byte(unsubscribePurpose + userID.String()))
+	return mac.Sum(nil)
+}
+
+func (s *announcementService) find(ctx context.Context, id uuid.UUID) (*model.Announcement, error) {
+	announcement, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if announcement == nil {
+		return nil, apperrors.NewAppError(apperrors.NotFoundError, "Announcement not found")
+	}
+	return announcement, nil
+}
+
+// normalizeSegment defaults the segment to active users and checks its date range
+func normalizeSegment(segment model.Segment) (model.Segment, error) {
+	if len(segment.Statuses) == 0 {
+		segment.Statuses = []string{"active"}
+	}
+	if segment.RegisteredAfter != nil && segment.RegisteredBefore != nil &&
+		!segment.RegisteredAfter.Before(*segment.RegisteredBefore) {
+		return segment, apperrors.NewAppError(apperrors.ValidationError, "registered_after must be before registered_before")
+	}
+	return segment, nil
+}
+
+// throttle spaces sends evenly to stay under a rate per second; a zero rate never waits
+type throttle struct {
+	interval time.Duration
+	next     time.Time
+}
+
+func newThrottle(ratePerSecond float64) *throttle {
+	t := &throttle{}
+	if ratePerSecond > 0 {
+		t.interval = time.Duration(float64(time.Second) / ratePerSecond)
+	}
+	return t
+}
+
+func (t *throttle) wait(ctx context.Context) error {
+	if t.interval == 0 {
+		return ctx.Err()
+	}
+	if delay := time.Until(t.next); delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	t.next = time.Now().Add(t.interval)
+	return nil
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeleteMe godoc
+// @Summary Delete your account
+// @Description Schedules your account for deletion after ACCOUNT_DELETION_GRACE_PERIOD and signs you out everywhere at once. When the grace period ends, your personal data is anonymized. Logging in again before then cancels the deletion.
+// @Tags Auth
+// @Security BearerAuth
+// @Produce json
+// @Success 202 {object} dto.AccountDeletionResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse "Impersonating"
+// @Router /me [delete]
+func (h *AuthHandler) DeleteMe(c *gin.Context) {
+	ctx := actor.WithClientIP(c.Request.Context(), c.ClientIP())
+	scheduledAt, err := h.service.ScheduleAccountDeletion(ctx, c.GetString("userID"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	h.clearRefreshCookie(c)
+	c.JSON(http.StatusAccepted, response.NewSuccessResponse(dto.AccountDeletionResponse{
+		DeletionScheduledAt: scheduledAt,
+	}, c.GetString("RequestID")))
+}
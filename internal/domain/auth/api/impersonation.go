@@ -0,0 +1,40 @@
+package api
+
+import (
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Impersonate godoc
+// @Summary Act as another user (requires users:impersonate)
+// @Description Returns a short-lived access token for the user with an impersonator_id claim naming the admin. It cannot be refreshed; /me reports impersonated: true while it is used. Admin accounts cannot be impersonated.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param userID path string true "User ID"
+// @Success 200 {object} dto.ImpersonationResponse
+// @Failure 400 {object} response.ErrorResponse "Impersonating yourself or an inactive account"
+// @Failure 403 {object} response.ErrorResponse "Forbidden, the user is an admin, or already impersonating"
+// @Failure 404 {object} response.ErrorResponse "User not found"
+// @Router /admin/impersonate/{userID} [post]
+func (h *AuthHandler) Impersonate(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+	ctx := c.Request.Context()
+
+	token, expiresAt, err := h.service.Impersonate(ctx, c.Param("userID"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(dto.ImpersonationResponse{
+		AccessToken:    token,
+		ExpiresAt:      expiresAt,
+		UserID:         c.Param("userID"),
+		ImpersonatorID: actor.UserID(ctx),
+	}, requestID))
+}
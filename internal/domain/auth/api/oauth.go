@@ -0,0 +1,111 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/model"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// oauthStateCookie binds a provider callback to the browser that started the login
+	oauthStateCookie = "oauth_state"
+	oauthStateMaxAge = 600 // seconds
+)
+
+// OAuthStart godoc
+// @Summary Start social login
+// @Description Redirects to the provider's sign-in page. The provider redirects back to the callback route.
+// @Tags Auth
+// @Param provider path string true "Login provider: google, github or the name of a provider in OAUTH_OIDC_PROVIDERS"
+// @Success 302 "Redirect to the provider"
+// @Failure 404 {object} response.ErrorResponse "Unknown provider"
+// @Router /auth/oauth/{provider} [get]
+func (h *AuthHandler) OAuthStart(c *gin.Context) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to start login"))
+		return
+	}
+	state := base64.RawURLEncoding.EncodeToString(buf)
+
+	target, err := h.service.OAuthLoginURL(c.Request.Context(), c.Param("provider"), state)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	// Scoped to this route so the callback below it receives the cookie
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oauthStateCookie, state, oauthStateMaxAge, c.Request.URL.Path, "", isHTTPS(c), true)
+	c.Redirect(http.StatusFound, target)
+}
+
+// OAuthCallback godoc
+// @Summary Finish social login
+// @Description Exchanges the provider's authorization code for access and refresh tokens. The provider account is linked to the user with the same verified email.
+// @Tags Auth
+// @Produce json
+// @Param provider path string true "Login provider: google, github or the name of a provider in OAUTH_OIDC_PROVIDERS"
+// @Param code query string true "Authorization code"
+// @Param state query string true "State from the start request"
+// @Success 200 {object} model.LoginResponse
+// @Failure 400 {object} response.ErrorResponse "Bad request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Router /auth/oauth/{provider}/callback [get]
+func (h *AuthHandler) OAuthCallback(c *gin.Context) {
+	requestIDVal, _ := c.Get("RequestID")
+	requestID, ok := requestIDVal.(string)
+	if !ok {
+		requestID = "unknown"
+	}
+	provider := c.Param("provider")
+
+	if reason := c.Query("error"); reason != "" {
+		h.logger.Warnw("oauth login denied at provider", "provider", provider, "error", reason, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppErrorWithDetails(apperrors.UnauthorizedError, "Login was cancelled", reason))
+		return
+	}
+
+	expected, _ := c.Cookie(oauthStateCookie)
+	state := c.Query("state")
+	startPath := strings.TrimSuffix(c.Request.URL.Path, "/callback")
+	c.SetCookie(oauthStateCookie, "", -1, startPath, "", isHTTPS(c), true)
+	if expected == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(state)) != 1 {
+		h.logger.Warnw("oauth state mismatch", "provider", provider, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.BadRequestError, "Invalid or expired login state; start the login again"))
+		return
+	}
+
+	code := c.Query("code")
+	if code == "" {
+		_ = c.Error(apperrors.NewAppError(apperrors.BadRequestError, "Missing authorization code"))
+		return
+	}
+
+	access, refresh, err := h.service.LoginWithOAuth(loginContext(c), provider, code)
+	if err != nil {
+		if appErr, ok := apperrors.IsAppError(err); ok {
+			_ = c.Error(appErr)
+			return
+		}
+		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Login failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(model.LoginResponse{
+		AccessToken:  access,
+		RefreshToken: h.deliverRefreshToken(c, refresh),
+	}, requestID))
+}
+
+// isHTTPS reports whether the client reached us over TLS, directly or through a proxy
+func isHTTPS(c *gin.Context) bool {
+	return c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestPhoneVerification godoc
+// @Summary Send a code to verify your phone number
+// @Description Texts a one-time code to the phone number on your account. Confirm it with POST /me/phone/confirm. A verified phone is required to turn on SMS two-factor authentication.
+// @Tags Auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse "No phone number or SMS disabled"
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse "Already verified"
+// @Router /me/phone/verify [post]
+func (h *AuthHandler) RequestPhoneVerification(c *gin.Context) {
+	ctx := actor.WithClientIP(c.Request.Context(), c.ClientIP())
+	if err := h.service.RequestPhoneVerification(ctx, c.GetString("userID")); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{
+		"message": "a verification code has been sent to your phone",
+	}, c.GetString("RequestID")))
+}
+
+// ConfirmPhoneVerification godoc
+// @Summary Verify your phone number
+// @Description Confirms the phone number on your account with the code texted by POST /me/phone/verify. Changing the phone number clears the verification.
+// @Tags Auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body dto.PhoneVerificationRequest true "Code"
+// @Success 200 {object} dto.PhoneVerificationResponse
+// @Failure 400 {object} response.ErrorResponse "Bad request"
+// @Failure 401 {object} response.ErrorResponse "Invalid or expired code"
+// @Router /me/phone/confirm [post]
+func (h *AuthHandler) ConfirmPhoneVerification(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+
+	var req dto.PhoneVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.NewAppErrorWithDetails(apperrors.BadRequestError, "Invalid request payload", err.Error()))
+		return
+	}
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	ctx := actor.WithClientIP(c.Request.Context(), c.ClientIP())
+	verifiedAt, err := h.service.VerifyPhone(ctx, c.GetString("userID"), req.Code)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(dto.PhoneVerificationResponse{
+		PhoneVerifiedAt: verifiedAt,
+	}, requestID))
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetRefreshCookie makes the login endpoints deliver refresh tokens in an HttpOnly cookie
+// instead of the response body, and /refresh and /logout read them from it. maxAge should
+// match the refresh token lifetime; it is used when a token's own expiry cannot be read.
+func (h *AuthHandler) SetRefreshCookie(cfg config.RefreshCookieConfig, maxAge time.Duration) {
+	h.refreshCookie = cfg
+	h.refreshCookieMaxAge = maxAge
+}
+
+// deliverRefreshToken returns the refresh token for the response body, or sets the cookie
+// and returns "" so the token is left out of the body. The cookie expires with the token,
+// so remember-me sessions and sessions near their maximum lifetime get the right Max-Age.
+func (h *AuthHandler) deliverRefreshToken(c *gin.Context, token string) string {
+	if !h.refreshCookie.Enabled {
+		return token
+	}
+	maxAge := h.refreshCookieMaxAge
+	if h.service != nil {
+		if expiresAt, err := h.service.RefreshExpiry(token); err == nil {
+			maxAge = time.Until(expiresAt)
+		}
+	}
+	h.writeRefreshCookie(c, token, int(maxAge.Seconds()))
+	return ""
+}
+
+func (h *AuthHandler) clearRefreshCookie(c *gin.Context) {
+	if h.refreshCookie.Enabled {
+		h.writeRefreshCookie(c, "", -1)
+	}
+}
+
+func (h *AuthHandler) writeRefreshCookie(c *gin.Context, value string, maxAge int) {
+	sameSite := http.SameSiteStrictMode
+	switch h.refreshCookie.SameSite {
+	case "lax":
+		sameSite = http.SameSiteLaxMode
+	case "none":
+		sameSite = http.SameSiteNoneMode
+	}
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     h.refreshCookie.Name,
+		Value:    value,
+		Path:     h.refreshCookie.Path,
+		Domain:   h.refreshCookie.Domain,
+		MaxAge:   maxAge,
+		Secure:   h.refreshCookie.Secure,
+		HttpOnly: true,
+		SameSite: sameSite,
+	})
+}
+
+// requestRefreshToken reads the refresh token from the cookie when cookie mode is on and
+// the request carries one, otherwise from the JSON body, so non-browser clients keep
+// working. On failure the error is already added to c.
+func (h *AuthHandler) requestRefreshToken(c *gin.Context, requestID, operation string) (string, bool) {
+	if h.refreshCookie.Enabled {
+		if token, err := c.Cookie(h.refreshCookie.Name); err == nil && token != "" {
+			return token, true
+		}
+	}
+
+	var req dto.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warnw("invalid "+operation+" request", "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppErrorWithDetails(
+			apperrors.BadRequestError,
+			"Invalid request payload",
+			err.Error(),
+		))
+		return "", false
+	}
+
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		h.logger.Warnw("validation error on "+operation, "error", err, "request_id", requestID)
+		_ = c.Error(err)
+		return "", false
+	}
+	return req.RefreshToken, true
+}
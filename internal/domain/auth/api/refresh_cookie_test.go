@@ -0,0 +1,102 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+var testRefreshCookie = config.RefreshCookieConfig{
+	Enabled: true, Name: "refresh_token", Path: "/api/v1", SameSite: "strict", Secure: true,
+}
+
+func newCookieTestContext(body string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/refresh", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	return c, w
+}
+
+func TestDeliverRefreshToken(t *testing.T) {
+	// Arrange
+	h := NewAuthHandler(nil, zap.NewNop().Sugar())
+	h.SetRefreshCookie(testRefreshCookie, time.Hour)
+	c, w := newCookieTestContext("")
+
+	// Act
+	body := h.deliverRefreshToken(c, "refresh-token-value")
+
+	// Assert
+	if body != "" {
+		t.Errorf("body token = %q, want it left out", body)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("cookies = %v, want one", cookies)
+	}
+	got := cookies[0]
+	if got.Value != "refresh-token-value" || got.MaxAge != 3600 || got.Path != "/api/v1" ||
+		!got.HttpOnly || !got.Secure || got.SameSite != http.SameSiteStrictMode {
+		t.Errorf("cookie = %+v, want an HttpOnly, Secure, SameSite=Strict cookie for an hour", got)
+	}
+}
+
+func TestDeliverRefreshToken_Disabled(t *testing.T) {
+	// Arrange
+	h := NewAuthHandler(nil, zap.NewNop().Sugar())
+	c, w := newCookieTestContext("")
+
+	// Act
+	body := h.deliverRefreshToken(c, "refresh-token-value")
+
+	// Assert
+	if body != "refresh-token-value" || len(w.Result().Cookies()) != 0 {
+		t.Errorf("body token = %q with %d cookies, want the token in the body only", body, len(w.Result().Cookies()))
+	}
+}
+
+func TestRequestRefreshToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		cookie string
+		body   string
+		want   string
+		wantOK bool
+	}{
+		{name: "cookie", cookie: "cookie-refresh-token", want: "cookie-refresh-token", wantOK: true},
+		{name: "cookie wins over body", cookie: "cookie-refresh-token", body: `{"refresh_token":"body-refresh-token"}`, want: "cookie-refresh-token", wantOK: true},
+		{name: "body without cookie", body: `{"refresh_token":"body-refresh-token"}`, want: "body-refresh-token", wantOK: true},
+		{name: "neither", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			h := NewAuthHandler(nil, zap.NewNop().Sugar())
+			h.SetRefreshCookie(testRefreshCookie, time.Hour)
+			c, _ := newCookieTestContext(tt.body)
+			if tt.cookie != "" {
+				c.Request.AddCookie(&http.Cookie{Name: "refresh_token", Value: tt.cookie})
+			}
+
+			// Act
+			got, ok := h.requestRefreshToken(c, "req-1", "refresh")
+
+			// Assert
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("requestRefreshToken() = %q, %v; want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+			if !ok && len(c.Errors) == 0 {
+				t.Error("no error recorded for a missing token")
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"errors"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/service"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClientToken godoc
+// @Summary Get a service client token (client credentials grant)
+// @Description Exchanges a registered service client's ID and secret for an access token limited to the requested scopes, or to all of the client's scopes when scope is empty. The client authenticates with HTTP Basic or client_id/client_secret. There is no refresh token. Errors use the OAuth2 format.
+// @Tags Auth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "Must be client_credentials"
+// @Param client_id formData string false "Client ID when not using HTTP Basic"
+// @Param client_secret formData string false "Client secret when not using HTTP Basic"
+// @Param scope formData string false "Requested scopes, space separated"
+// @Success 200 {object} dto.ClientTokenResponse
+// @Failure 400 "OAuth error response (unsupported_grant_type, invalid_scope)"
+// @Failure 401 "invalid_client"
+// @Router /auth/token [post]
+func (h *AuthHandler) ClientToken(c *gin.Context) {
+	var req dto.ClientTokenRequest
+	_ = c.ShouldBind(&req)
+	if id, secret, ok := c.Request.BasicAuth(); ok {
+		// Basic credentials are form-encoded (RFC 6749 section 2.3.1)
+		req.ClientID, _ = url.QueryUnescape(id)
+		req.ClientSecret, _ = url.QueryUnescape(secret)
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	ctx := actor.WithClientIP(c.Request.Context(), c.ClientIP())
+	resp, err := h.service.IssueClientToken(ctx, req)
+	if err != nil {
+		var tokenErr *service.TokenError
+		if !errors.As(err, &tokenErr) {
+			_ = c.Error(err)
+			return
+		}
+		status := http.StatusBadRequest
+		if tokenErr.Code == "invalid_client" {
+			status = http.StatusUnauthorized
+			c.Header("WWW-Authenticate", `Basic realm="auth"`)
+		}
+		c.JSON(status, gin.H{"error": tokenErr.Code, "error_description": tokenErr.Description})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// ListServiceClients godoc
+// @Summary List service clients (requires service_clients:manage)
+// @Description Returns the clients registered for the client credentials grant. Secrets are never returned.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} model.ServiceClient
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Router /admin/service-clients [get]
+func (h *AuthHandler) ListServiceClients(c *gin.Context) {
+	clients, err := h.service.ListServiceClients(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(clients, c.GetString("RequestID")))
+}
+
+// CreateServiceClient godoc
+// @Summary Register a service client (requires service_clients:manage)
+// @Description Registers a client for the client credentials grant. The response holds the client secret, which is shown only this once.
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param client body dto.CreateServiceClientRequest true "Client"
+// @Success 201 {object} dto.CreateServiceClientResponse
+// @Failure 400 {object} response.ErrorResponse "Invalid client"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 409 {object} response.ErrorResponse "Client ID already exists"
+// @Router /admin/service-clients [post]
+func (h *AuthHandler) CreateServiceClient(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+
+	var req dto.CreateServiceClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.NewAppErrorWithDetails(apperrors.BadRequestError, "Invalid request payload", err.Error()))
+		return
+	}
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	created, err := h.service.CreateServiceClient(c.Request.Context(), req)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.NewSuccessResponse(created, requestID))
+}
+
+// DeleteServiceClient godoc
+// @Summary Remove a service client (requires service_clients:manage)
+// @Description The client can no longer get tokens. Tokens already issued to it stay valid until they expire.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param clientID path string true "Client ID"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Client not found"
+// @Router /admin/service-clients/{clientID} [delete]
+func (h *AuthHandler) DeleteServiceClient(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+
+	if err := h.service.DeleteServiceClient(c.Request.Context(), c.Param("clientID")); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{"message": "service client deleted"}, requestID))
+}
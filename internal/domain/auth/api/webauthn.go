@@ -0,0 +1,196 @@
+package api
+
+import (
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/model"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PasskeyRegisterBegin godoc
+// @Summary Start adding a passkey
+// @Description Returns the options for navigator.credentials.create(). Send the created credential to the finish route before the timeout.
+// @Tags Auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} dto.PasskeyCreationOptions
+// @Failure 400 {object} response.ErrorResponse "Passkeys are not enabled"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Router /auth/webauthn/register/begin [post]
+func (h *AuthHandler) PasskeyRegisterBegin(c *gin.Context) {
+	requestIDVal, _ := c.Get("RequestID")
+	requestID, ok := requestIDVal.(string)
+	if !ok {
+		requestID = "unknown"
+	}
+
+	options, err := h.service.BeginPasskeyRegistration(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(options, requestID))
+}
+
+// PasskeyRegisterFinish godoc
+// @Summary Finish adding a passkey
+// @Description Verifies the credential created by the browser and stores the passkey
+// @Tags Auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body dto.PasskeyRegistrationRequest true "Created credential (credential.toJSON())"
+// @Success 201 {object} model.WebAuthnCredential
+// @Failure 400 {object} response.ErrorResponse "Bad request"
+// @Failure 401 {object} response.ErrorResponse "Passkey verification failed"
+// @Failure 409 {object} response.ErrorResponse "Passkey already registered"
+// @Router /auth/webauthn/register/finish [post]
+func (h *AuthHandler) PasskeyRegisterFinish(c *gin.Context) {
+	requestIDVal, _ := c.Get("RequestID")
+	requestID, ok := requestIDVal.(string)
+	if !ok {
+		requestID = "unknown"
+	}
+
+	var req dto.PasskeyRegistrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warnw("invalid passkey registration request", "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppErrorWithDetails(apperrors.BadRequestError, "Invalid request payload", err.Error()))
+		return
+	}
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	credential, err := h.service.FinishPasskeyRegistration(c.Request.Context(), c.GetString("userID"), &req)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.NewSuccessResponse(credential, requestID))
+}
+
+// PasskeyLoginBegin godoc
+// @Summary Start passkey login
+// @Description Returns the options for navigator.credentials.get(). Any passkey registered to this site can answer.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} dto.PasskeyRequestOptions
+// @Failure 400 {object} response.ErrorResponse "Passkeys are not enabled"
+// @Router /auth/webauthn/login/begin [post]
+func (h *AuthHandler) PasskeyLoginBegin(c *gin.Context) {
+	requestIDVal, _ := c.Get("RequestID")
+	requestID, ok := requestIDVal.(string)
+	if !ok {
+		requestID = "unknown"
+	}
+
+	options, err := h.service.BeginPasskeyLogin(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(options, requestID))
+}
+
+// PasskeyLoginFinish godoc
+// @Summary Finish passkey login
+// @Description Verifies the passkey assertion and returns access and refresh tokens. SMS two-factor is not required, as the passkey verified the user.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body dto.PasskeyLoginRequest true "Assertion (credential.toJSON())"
+// @Success 200 {object} model.LoginResponse
+// @Failure 400 {object} response.ErrorResponse "Bad request"
+// @Failure 401 {object} response.ErrorResponse "Passkey verification failed"
+// @Failure 403 {object} response.ErrorResponse "Account is inactive"
+// @Router /auth/webauthn/login/finish [post]
+func (h *AuthHandler) PasskeyLoginFinish(c *gin.Context) {
+	requestIDVal, _ := c.Get("RequestID")
+	requestID, ok := requestIDVal.(string)
+	if !ok {
+		requestID = "unknown"
+	}
+
+	var req dto.PasskeyLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warnw("invalid passkey login request", "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppErrorWithDetails(apperrors.BadRequestError, "Invalid request payload", err.Error()))
+		return
+	}
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	access, refresh, err := h.service.LoginWithPasskey(loginContext(c), &req)
+	if err != nil {
+		if appErr, ok := apperrors.IsAppError(err); ok {
+			_ = c.Error(appErr)
+			return
+		}
+		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Login failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(model.LoginResponse{
+		AccessToken:  access,
+		RefreshToken: h.deliverRefreshToken(c, refresh),
+	}, requestID))
+}
+
+// ListPasskeys godoc
+// @Summary List my passkeys
+// @Tags Auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} model.WebAuthnCredential
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Router /auth/webauthn/credentials [get]
+func (h *AuthHandler) ListPasskeys(c *gin.Context) {
+	requestIDVal, _ := c.Get("RequestID")
+	requestID, ok := requestIDVal.(string)
+	if !ok {
+		requestID = "unknown"
+	}
+
+	credentials, err := h.service.ListPasskeys(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(credentials, requestID))
+}
+
+// DeletePasskey godoc
+// @Summary Remove one of my passkeys
+// @Tags Auth
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Passkey ID"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 404 {object} response.ErrorResponse "Passkey not found"
+// @Router /auth/webauthn/credentials/{id} [delete]
+func (h *AuthHandler) DeletePasskey(c *gin.Context) {
+	requestIDVal, _ := c.Get("RequestID")
+	requestID, ok := requestIDVal.(string)
+	if !ok {
+		requestID = "unknown"
+	}
+
+	if err := h.service.DeletePasskey(c.Request.Context(), c.GetString("userID"), c.Param("id")); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{"message": "passkey removed"}, requestID))
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/shared/id"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ActionToken is a hashed single-use token sent to a user to confirm an action, such as
+// verifying an email address or resetting a password. See the actiontoken package.
+type ActionToken struct {
+	// ID is the unique identifier for the token record
+	// format: uuid
+	// readOnly: true
+	ID uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+
+	// Purpose is the action the token confirms; a token is only accepted for its purpose
+	Purpose string `gorm:"type:varchar(40);not null;index:idx_action_tokens_purpose_subject" json:"purpose"`
+
+	// Subject is who the token was issued for, usually a user ID or an email address
+	Subject string `gorm:"size:255;not null;index:idx_action_tokens_purpose_subject" json:"subject"`
+
+	// TokenHash is the SHA-256 hash of the token; the token itself is never stored
+	// writeOnly: true
+	TokenHash string `gorm:"size:64;not null;uniqueIndex" json:"-"`
+
+	// Data holds JSON-encoded values the action needs, such as the address being verified
+	Data string `gorm:"type:text" json:"-"`
+
+	// ExpiresAt indicates when the token becomes invalid
+	// format: date-time
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+
+	// ConsumedAt indicates when the token was used; used tokens are never accepted again
+	// format: date-time
+	ConsumedAt *time.Time `json:"consumed_at,omitempty"`
+
+	// CreatedAt indicates when the token was issued
+	// format: date-time
+	// readOnly: true
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BeforeCreate hook to generate UUID before inserting
+func (t *ActionToken) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = id.New()
+	}
+	return nil
+}
+
+// TableName overrides the default table name
+func (ActionToken) TableName() string {
+	return "action_tokens"
+}
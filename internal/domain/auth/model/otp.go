@@ -0,0 +1,67 @@
+package model
+
+import (
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/shared/id"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OTPPurpose identifies the flow a one-time code was issued for
+type OTPPurpose string
+
+const (
+	// OTPPurposeLogin is a passwordless login code
+	OTPPurposeLogin OTPPurpose = "login"
+	// OTPPurposeTwoFactor confirms a password login for users with SMS two-factor enabled
+	OTPPurposeTwoFactor OTPPurpose = "two_factor"
+	// OTPPurposePhoneVerification confirms that a user owns the phone number on their account
+	OTPPurposePhoneVerification OTPPurpose = "phone_verification"
+)
+
+// OTPCode is a hashed one-time code delivered over SMS
+type OTPCode struct {
+	// ID is the unique identifier for the code record
+	// format: uuid
+	// readOnly: true
+	ID uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+
+	// UserID is the UUID of the user the code was sent to
+	// format: uuid
+	UserID uuid.UUID `gorm:"type:uuid;not null;index:idx_otp_codes_user_purpose" json:"user_id"`
+
+	// Purpose of the code
+	// enum: login,two_factor,phone_verification
+	Purpose OTPPurpose `gorm:"type:varchar(20);not null;index:idx_otp_codes_user_purpose" json:"purpose"`
+
+	// CodeHash is the SHA-256 hash of the code; the code itself is never stored
+	// writeOnly: true
+	CodeHash string `gorm:"size:64;not null" json:"-"`
+
+	// Attempts counts failed verifications against this code
+	Attempts int `gorm:"not null;default:0" json:"attempts"`
+
+	// ExpiresAt indicates when the code becomes invalid
+	// format: date-time
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+
+	// CreatedAt indicates when the code was issued
+	// format: date-time
+	// readOnly: true
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BeforeCreate hook to generate UUID before inserting
+func (c *OTPCode) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = id.New()
+	}
+	return nil
+}
+
+// TableName overrides the default table name
+func (OTPCode) TableName() string {
+	return "otp_codes"
+}
@@ -0,0 +1,111 @@
+package model
+
+import (
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/shared/id"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WebAuthnCeremony identifies the flow a WebAuthn challenge was issued for
+type WebAuthnCeremony string
+
+const (
+	// WebAuthnRegister adds a passkey to a signed-in user
+	WebAuthnRegister WebAuthnCeremony = "register"
+	// WebAuthnLogin signs a user in with a passkey
+	WebAuthnLogin WebAuthnCeremony = "login"
+)
+
+// WebAuthnCredential is a passkey registered to a user
+// swagger:model WebAuthnCredential
+type WebAuthnCredential struct {
+	// ID is the unique identifier for the passkey record
+	// format: uuid
+	// readOnly: true
+	ID uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+
+	// UserID is the UUID of the passkey owner
+	// format: uuid
+	UserID uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+
+	// Name is a label chosen by the user, e.g. "Work laptop"
+	// example: Work laptop
+	Name string `gorm:"size:100" json:"name" example:"Work laptop"`
+
+	// CredentialID is the authenticator's ID for the passkey
+	CredentialID []byte `gorm:"type:bytea;not null;uniqueIndex" json:"-"`
+
+	// PublicKey is the passkey's public key in PKIX form
+	PublicKey []byte `gorm:"type:bytea;not null" json:"-"`
+
+	// Algorithm is the COSE algorithm of PublicKey
+	Algorithm int64 `gorm:"not null" json:"-"`
+
+	// SignCount is the last signature counter seen, used to detect cloned authenticators
+	SignCount uint32 `gorm:"not null;default:0" json:"-"`
+
+	// LastUsedAt indicates the last login with this passkey
+	// format: date-time
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+
+	// CreatedAt indicates when the passkey was registered
+	// format: date-time
+	// readOnly: true
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BeforeCreate hook to generate UUID before inserting
+func (c *WebAuthnCredential) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = id.New()
+	}
+	return nil
+}
+
+// TableName overrides the default table name
+func (WebAuthnCredential) TableName() string {
+	return "webauthn_credentials"
+}
+
+// WebAuthnChallenge is an outstanding registration or login ceremony. Each challenge can
+// be answered once.
+type WebAuthnChallenge struct {
+	// ID is the unique identifier for the challenge record
+	// format: uuid
+	ID uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+
+	// ChallengeHash is the SHA-256 hash of the challenge sent to the browser
+	ChallengeHash string `gorm:"size:64;not null;uniqueIndex" json:"-"`
+
+	// Ceremony the challenge was issued for
+	// enum: register,login
+	Ceremony WebAuthnCeremony `gorm:"type:varchar(20);not null" json:"ceremony"`
+
+	// UserID is the user adding a passkey; empty for login, where the passkey names the user
+	// format: uuid
+	UserID *uuid.UUID `gorm:"type:uuid" json:"user_id,omitempty"`
+
+	// ExpiresAt indicates when the challenge becomes invalid
+	// format: date-time
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+
+	// CreatedAt indicates when the challenge was issued
+	// format: date-time
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BeforeCreate hook to generate UUID before inserting
+func (c *WebAuthnChallenge) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = id.New()
+	}
+	return nil
+}
+
+// TableName overrides the default table name
+func (WebAuthnChallenge) TableName() string {
+	return "webauthn_challenges"
+}
@@ -0,0 +1,71 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	githubAuthURL  = "https://github.com/login/oauth/authorize"
+	githubTokenURL = "https://github.com/login/oauth/access_token"
+	githubAPIBase  = "https://api.github.com"
+)
+
+// GitHub signs users in with their GitHub account
+type GitHub struct {
+	client
+	apiBase string
+}
+
+func NewGitHub(clientID, clientSecret, redirectURL string) *GitHub {
+	return &GitHub{
+		client:  newClient(clientID, clientSecret, redirectURL, githubAuthURL, githubTokenURL, "read:user", "user:email"),
+		apiBase: githubAPIBase,
+	}
+}
+
+func (g *GitHub) Name() string { return "github" }
+
+func (g *GitHub) AuthCodeURL(ctx context.Context, state string) (string, error) {
+	return g.authCodeURL(state), nil
+}
+
+func (g *GitHub) Exchange(ctx context.Context, code string) (*Identity, error) {
+	token, err := g.exchange(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	var user struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := g.get(ctx, g.apiBase+"/user", token, &user); err != nil {
+		return nil, fmt.Errorf("github user: %w", err)
+	}
+
+	// The profile email is optional and may be unverified; use the verified primary address
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := g.get(ctx, g.apiBase+"/user/emails", token, &emails); err != nil {
+		return nil, fmt.Errorf("github emails: %w", err)
+	}
+	identity := &Identity{Provider: g.Name(), Subject: strconv.FormatInt(user.ID, 10)}
+	for _, e := range emails {
+		if e.Primary && e.Verified {
+			identity.Email = e.Email
+		}
+	}
+	if identity.Email == "" {
+		return nil, ErrEmailUnverified
+	}
+
+	first, last, _ := strings.Cut(strings.TrimSpace(user.Name), " ")
+	identity.FirstName, identity.LastName = first, strings.TrimSpace(last)
+	return identity, nil
+}
@@ -0,0 +1,288 @@
+package oauth
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	userModel "github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/jwk"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// keysRefreshInterval limits how often an unknown key ID makes the provider's JWKS be
+// fetched again, so forged tokens cannot make us hammer the provider
+const keysRefreshInterval = time.Minute
+
+// OIDC signs users in with an external OpenID Connect identity provider such as
+// Keycloak, Auth0 or Entra ID. Its endpoints and signing keys are discovered from the
+// issuer on first use and cached, so the service starts while the provider is down.
+type OIDC struct {
+	client
+	cfg config.OIDCLoginProvider
+
+	mu          sync.Mutex
+	metadata    *oidcMetadata
+	keys        map[string]crypto.PublicKey
+	keysFetched time.Time
+}
+
+// oidcMetadata is the part of the provider's discovery document that login needs
+type oidcMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+func NewOIDC(cfg config.OIDCLoginProvider, redirectURL string) *OIDC {
+	scopes := cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	}
+	return &OIDC{
+		client: newClient(cfg.ClientID, cfg.ClientSecret, redirectURL, "", "", scopes...),
+		cfg:    cfg,
+	}
+}
+
+func (o *OIDC) Name() string { return o.cfg.Name }
+
+func (o *OIDC) AuthCodeURL(ctx context.Context, state string) (string, error) {
+	c, _, err := o.discovered(ctx)
+	if err != nil {
+		return "", err
+	}
+	return c.authCodeURL(state), nil
+}
+
+func (o *OIDC) Exchange(ctx context.Context, code string) (*Identity, error) {
+	c, metadata, err := o.discovered(ctx)
+	if err != nil {
+		return nil, err
+	}
+	token, err := c.exchangeTokens(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if token.IDToken == "" {
+		return nil, errors.New("token exchange: no id_token in response")
+	}
+	claims, err := o.verifyIDToken(ctx, metadata, token.IDToken)
+	if err != nil {
+		return nil, fmt.Errorf("id token: %w", err)
+	}
+
+	// Some providers leave the profile out of the ID token; fetch it from userinfo
+	if claimString(claims, "email") == "" && metadata.UserInfoEndpoint != "" {
+		var info map[string]interface{}
+		if err := c.get(ctx, metadata.UserInfoEndpoint, token.AccessToken, &info); err != nil {
+			return nil, fmt.Errorf("%s userinfo: %w", o.Name(), err)
+		}
+		// Userinfo for another subject would let a mixed-up response pick the account
+		if claimString(info, "sub") != claimString(claims, "sub") {
+			return nil, errors.New("userinfo subject does not match the id token")
+		}
+		for key, value := range info {
+			if _, ok := claims[key]; !ok {
+				claims[key] = value
+			}
+		}
+	}
+
+	email := claimString(claims, "email")
+	if email == "" || !(o.cfg.TrustEmail || claimBool(claims, "email_verified")) {
+		return nil, ErrEmailUnverified
+	}
+	identity := &Identity{
+		Provider:  o.Name(),
+		Subject:   claimString(claims, "sub"),
+		Email:     email,
+		FirstName: claimString(claims, "given_name"),
+		LastName:  claimString(claims, "family_name"),
+		Role:      o.role(claims),
+	}
+	if identity.FirstName == "" && identity.LastName == "" {
+		first, last, _ := strings.Cut(strings.TrimSpace(claimString(claims, "name")), " ")
+		identity.FirstName, identity.LastName = first, strings.TrimSpace(last)
+	}
+	return identity, nil
+}
+
+// discovered returns the client pointed at the provider's endpoints, fetching the
+// discovery document on first use. A failed fetch is retried on the next login.
+func (o *OIDC) discovered(ctx context.Context) (client, *oidcMetadata, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.metadata == nil {
+		var metadata oidcMetadata
+		if err := o.getJSON(ctx, strings.TrimRight(o.cfg.Issuer, "/")+"/.well-known/openid-configuration", &metadata); err != nil {
+			return client{}, nil, fmt.Errorf("%s discovery: %w", o.Name(), err)
+		}
+		if metadata.Issuer != o.cfg.Issuer {
+			return client{}, nil, fmt.Errorf("%s discovery: issuer %q does not match %q", o.Name(), metadata.Issuer, o.cfg.Issuer)
+		}
+		if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+			return client{}, nil, fmt.Errorf("%s discovery: document is missing endpoints", o.Name())
+		}
+		o.metadata = &metadata
+	}
+	c := o.client
+	c.authURL = o.metadata.AuthorizationEndpoint
+	c.tokenURL = o.metadata.TokenEndpoint
+	return c, o.metadata, nil
+}
+
+// verifyIDToken checks the ID token's signature, issuer, audience and lifetime
+func (o *OIDC) verifyIDToken(ctx context.Context, metadata *oidcMetadata, raw string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return o.key(ctx, metadata.JWKSURI, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", jwk.AlgorithmEdDSA}),
+		jwt.WithIssuer(o.cfg.Issuer),
+		jwt.WithAudience(o.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, err
+	}
+	// A token issued to several clients must name us as the one it was issued for
+	if audience, _ := claims.GetAudience(); len(audience) > 1 && claimString(claims, "azp") != o.cfg.ClientID {
+		return nil, errors.New("token was issued to another client")
+	}
+	if claimString(claims, "sub") == "" {
+		return nil, errors.New("token has no subject")
+	}
+	return claims, nil
+}
+
+// key returns the provider's signing key with the given ID, fetching the JWKS again
+// when the provider may have rotated its keys
+func (o *OIDC) key(ctx context.Context, jwksURI, kid string) (crypto.PublicKey, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if key, ok := o.lookupKey(kid); ok {
+		return key, nil
+	}
+	if time.Since(o.keysFetched) < keysRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	var set jwk.Set
+	if err := o.getJSON(ctx, jwksURI, &set); err != nil {
+		return nil, fmt.Errorf("fetch signing keys: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		// Keys of other types, such as EC, are skipped rather than failing the set
+		if pub, err := k.PublicKey(); err == nil {
+			keys[k.KeyID] = pub
+		}
+	}
+	o.keys = keys
+	o.keysFetched = time.Now()
+
+	if key, ok := o.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// lookupKey finds a cached key; a token without a key ID is accepted when the
+// provider publishes a single key
+func (o *OIDC) lookupKey(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(o.keys) == 1 {
+		for _, key := range o.keys {
+			return key, true
+		}
+	}
+	key, ok := o.keys[kid]
+	return key, ok
+}
+
+// role maps the roles claim to one of this service's roles; "" when the provider does
+// not manage roles
+func (o *OIDC) role(claims map[string]interface{}) string {
+	if o.cfg.RolesClaim == "" {
+		return ""
+	}
+	values := claimStrings(claims, o.cfg.RolesClaim)
+	for _, mapping := range o.cfg.RoleMappings {
+		if slices.Contains(values, mapping.Value) {
+			return mapping.Role
+		}
+	}
+	if o.cfg.DefaultRole != "" {
+		return o.cfg.DefaultRole
+	}
+	return string(userModel.UserTypeRegular)
+}
+
+func (o *OIDC) getJSON(ctx context.Context, endpoint string, v interface{}) error {
+	return o.get(ctx, endpoint, "", v)
+}
+
+func claimString(claims map[string]interface{}, name string) string {
+	value, _ := claims[name].(string)
+	return value
+}
+
+// claimBool reads a boolean claim; some providers send "true" as a string
+func claimBool(claims map[string]interface{}, name string) bool {
+	switch value := claims[name].(type) {
+	case bool:
+		return value
+	case string:
+		return value == "true"
+	default:
+		return false
+	}
+}
+
+// claimStrings reads a string or list of strings claim. name is looked up as is first,
+// since namespaced claims such as https://example.com/roles contain dots, then as a
+// dotted path into nested objects, e.g. realm_access.roles.
+func claimStrings(claims map[string]interface{}, name string) []string {
+	value, ok := claims[name]
+	if !ok {
+		var current interface{} = claims
+		for _, part := range strings.Split(name, ".") {
+			object, isObject := current.(map[string]interface{})
+			if !isObject {
+				return nil
+			}
+			current = object[part]
+		}
+		value = current
+	}
+
+	switch value := value.(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
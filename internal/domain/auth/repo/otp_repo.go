@@ -0,0 +1,65 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/model"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type OTPRepo interface {
+	Create(ctx context.Context, code *model.OTPCode) error
+	FindActive(ctx context.Context, userID uuid.UUID, purpose model.OTPPurpose) (*model.OTPCode, error)
+	IncrementAttempts(ctx context.Context, id uuid.UUID) error
+	DeleteForUser(ctx context.Context, userID uuid.UUID, purpose model.OTPPurpose) error
+	DeleteExpired(ctx context.Context) error
+}
+
+type otpRepo struct {
+	db *gorm.DB
+}
+
+func NewOTPRepo(db *gorm.DB) OTPRepo {
+	return &otpRepo{db: db}
+}
+
+func (r *otpRepo) Create(ctx context.Context, code *model.OTPCode) error {
+	return r.db.WithContext(ctx).Create(code).Error
+}
+
+// FindActive returns the latest unexpired code for the user and purpose, or nil if none exists
+func (r *otpRepo) FindActive(ctx context.Context, userID uuid.UUID, purpose model.OTPPurpose) (*model.OTPCode, error) {
+	var code model.OTPCode
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND purpose = ? AND expires_at > ?", userID, purpose, time.Now()).
+		Order("created_at DESC").
+		First(&code).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+func (r *otpRepo) IncrementAttempts(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&model.OTPCode{}).
+		Where("id = ?", id).
+		UpdateColumn("attempts", gorm.Expr("attempts + 1")).Error
+}
+
+func (r *otpRepo) DeleteForUser(ctx context.Context, userID uuid.UUID, purpose model.OTPPurpose) error {
+	return r.db.WithContext(ctx).
+		Where("user_id = ? AND purpose = ?", userID, purpose).
+		Delete(&model.OTPCode{}).Error
+}
+
+func (r *otpRepo) DeleteExpired(ctx context.Context) error {
+	return r.db.WithContext(ctx).Where("expires_at < ?", time.Now()).
+		Delete(&model.OTPCode{}).Error
+}
@@ -0,0 +1,55 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/model"
+
+	"gorm.io/gorm"
+)
+
+type ServiceClientRepo interface {
+	Create(ctx context.Context, client *model.ServiceClient) error
+	FindByClientID(ctx context.Context, clientID string) (*model.ServiceClient, error)
+	List(ctx context.Context) ([]model.ServiceClient, error)
+	Delete(ctx context.Context, clientID string) (bool, error)
+}
+
+type serviceClientRepo struct {
+	db *gorm.DB
+}
+
+func NewServiceClientRepo(db *gorm.DB) ServiceClientRepo {
+	return &serviceClientRepo{db: db}
+}
+
+func (r *serviceClientRepo) Create(ctx context.Context, client *model.ServiceClient) error {
+	return r.db.WithContext(ctx).Create(client).Error
+}
+
+// FindByClientID returns the client registered under clientID, or nil if there is none
+func (r *serviceClientRepo) FindByClientID(ctx context.Context, clientID string) (*model.ServiceClient, error) {
+	var client model.ServiceClient
+	err := r.db.WithContext(ctx).Where("client_id = ?", clientID).First(&client).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+// List returns all registered clients, oldest first
+func (r *serviceClientRepo) List(ctx context.Context) ([]model.ServiceClient, error) {
+	var clients []model.ServiceClient
+	err := r.db.WithContext(ctx).Order("created_at").Find(&clients).Error
+	return clients, err
+}
+
+// Delete removes the client registered under clientID and reports whether there was one
+func (r *serviceClientRepo) Delete(ctx context.Context, clientID string) (bool, error) {
+	result := r.db.WithContext(ctx).Where("client_id = ?", clientID).Delete(&model.ServiceClient{})
+	return result.RowsAffected > 0, result.Error
+}
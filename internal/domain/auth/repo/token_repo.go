@@ -3,10 +3,11 @@ package repo
 import (
 	"context"
 	"errors"
-	"go_platform_template/internal/domain/auth/model"
-	apperrors "go_platform_template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/model"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
 	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -16,6 +17,15 @@ type TokenRepo interface {
 	RevokeToken(ctx context.Context, token string) error
 	RevokeAllUserTokens(ctx context.Context, userID string) error
 	DeleteExpiredTokens(ctx context.Context) error
+	// ListActive returns unrevoked, unexpired tokens, newest first; an empty userID lists every user's
+	ListActive(ctx context.Context, userID string, offset, limit int) ([]model.RefreshToken, error)
+	// RevokeByID revokes an active token and returns it, or nil if there is none with that ID
+	RevokeByID(ctx context.Context, id string) (*model.RefreshToken, error)
+	// RevokeUserTokens revokes all of a user's active tokens and returns the ones it revoked
+	RevokeUserTokens(ctx context.Context, userID string) ([]model.RefreshToken, error)
+	// KnownFingerprints returns the distinct non-empty fingerprints of a user's tokens,
+	// including revoked, expired and cleaned up ones
+	KnownFingerprints(ctx context.Context, userID string) ([]string, error)
 }
 
 type tokenRepo struct {
@@ -68,3 +78,72 @@ func (r *tokenRepo) DeleteExpiredTokens(ctx context.Context) error {
 	return r.db.WithContext(ctx).Where("expires_at < ?", time.Now()).
 		Delete(&model.RefreshToken{}).Error
 }
+
+func (r *tokenRepo) ListActive(ctx context.Context, userID string, offset, limit int) ([]model.RefreshToken, error) {
+	query := r.db.WithContext(ctx).Where("is_revoked = ? AND expires_at > ?", false, time.Now())
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	var tokens []model.RefreshToken
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&tokens).Error
+	return tokens, err
+}
+
+func (r *tokenRepo) RevokeByID(ctx context.Context, id string) (*model.RefreshToken, error) {
+	var revoked *model.RefreshToken
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var token model.RefreshToken
+		err := tx.Where("id = ? AND is_revoked = ? AND expires_at > ?", id, false, time.Now()).First(&token).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		result := tx.Model(&model.RefreshToken{}).
+			Where("id = ? AND is_revoked = ?", id, false).
+			Update("is_revoked", true)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		token.IsRevoked = true
+		revoked = &token
+		return nil
+	})
+	return revoked, err
+}
+
+func (r *tokenRepo) RevokeUserTokens(ctx context.Context, userID string) ([]model.RefreshToken, error) {
+	var revoked []model.RefreshToken
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var tokens []model.RefreshToken
+		if err := tx.Where("user_id = ? AND is_revoked = ? AND expires_at > ?", userID, false, time.Now()).
+			Find(&tokens).Error; err != nil {
+			return err
+		}
+		if len(tokens) == 0 {
+			return nil
+		}
+		ids := make([]uuid.UUID, len(tokens))
+		for i := range tokens {
+			ids[i] = tokens[i].ID
+			tokens[i].IsRevoked = true
+		}
+		if err := tx.Model(&model.RefreshToken{}).
+			Where("id IN ? AND is_revoked = ?", ids, false).
+			Update("is_revoked", true).Error; err != nil {
+			return err
+		}
+		revoked = tokens
+		return nil
+	})
+	return revoked, err
+}
+
+func (r *tokenRepo) KnownFingerprints(ctx context.Context, userID string) ([]string, error) {
+	var fingerprints []string
+	err := r.db.WithContext(ctx).Unscoped().Model(&model.RefreshToken{}).
+		Where("user_id = ? AND fingerprint <> ''", userID).
+		Distinct().Pluck("fingerprint", &fingerprints).Error
+	return fingerprints, err
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	authModel "github.com/MurtadaNazar/go-api-template/internal/domain/auth/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func TestAuthService_ScheduleAccountDeletion(t *testing.T) {
+	// Arrange
+	clk := testutil.NewFakeClock(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+	logger := zap.NewNop().Sugar()
+	jwtManager := NewJWTManager("test-signing-key-must-be-long-enough-for-jwt", "test-refresh-key-must-be-long-enough", 15*time.Minute, 7*day)
+	jwtManager.SetClock(clk)
+	user := &model.User{ID: uuid.New(), UserType: model.UserTypeRegular, Status: "active"}
+	userRepo := &testutil.MockUserRepo{
+		FindByIDFn: func(ctx context.Context, id string) (*model.User, error) { return user, nil },
+	}
+	tokens := &testutil.MockTokenRepo{Tokens: map[string]*authModel.RefreshToken{}}
+	service := NewAuthService(userRepo, jwtManager, NewTokenStore(tokens, logger), logger)
+	service.SetAccountDeletionGrace(30 * day)
+	_, refresh, err := service.issueTokens(context.Background(), user, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hooked string
+	service.OnUserTokensRevoked(func(userID string) { hooked = userID })
+
+	// Act
+	scheduledAt, err := service.ScheduleAccountDeletion(context.Background(), user.ID.String())
+	clk.Advance(day)
+	again, _ := service.ScheduleAccountDeletion(context.Background(), user.ID.String())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("ScheduleAccountDeletion() error = %v", err)
+	}
+	if want := time.Date(2024, 2, 14, 12, 0, 0, 0, time.UTC); !scheduledAt.Equal(want) || !again.Equal(want) {
+		t.Errorf("scheduled at %v, then %v; want %v both times", scheduledAt, again, want)
+	}
+	if !tokens.Tokens[refresh].IsRevoked || hooked != user.ID.String() {
+		t.Errorf("refresh token revoked = %v, hook ran for %q; want the session ended", tokens.Tokens[refresh].IsRevoked, hooked)
+	}
+
+	// Logging in again takes the deletion back
+	if _, _, err := service.issueTokens(context.Background(), user, false); err != nil {
+		t.Fatalf("issueTokens() error = %v", err)
+	}
+	if user.DeletionScheduled() {
+		t.Errorf("deletion still scheduled at %v after login", user.DeletionScheduledAt)
+	}
+}
+
+func TestAuthService_ScheduleAccountDeletion_WhileImpersonating(t *testing.T) {
+	// Arrange
+	service, _, owner := newTokenAdminService(t)
+	ctx := actor.WithImpersonatorID(context.Background(), uuid.NewString())
+
+	// Act
+	_, err := service.ScheduleAccountDeletion(ctx, owner.String())
+
+	// Assert
+	if appErr, ok := apperrors.IsAppError(err); !ok || appErr.Type != apperrors.ForbiddenError {
+		t.Errorf("ScheduleAccountDeletion() error = %v, want ForbiddenError", err)
+	}
+}
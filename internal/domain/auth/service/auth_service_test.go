@@ -2,13 +2,20 @@ package service
 
 import (
 	"context"
-	"go_platform_template/internal/domain/user/model"
-	apperrors "go_platform_template/internal/shared/errors"
-	"go_platform_template/internal/testutil"
+	authModel "github.com/MurtadaNazar/go-api-template/internal/domain/auth/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/risk"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+	"regexp"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestAuthService_Login_Success(t *testing.T) {
@@ -23,7 +30,7 @@ func TestAuthService_Login_UserNotFound(t *testing.T) {
 	mockRepo := &testutil.MockUserRepo{}
 	logger := zap.NewNop().Sugar()
 	jwtManager := NewJWTManager("test-signing-key-must-be-long-enough-for-jwt", "test-refresh-key-must-be-long-enough", 15*time.Minute, 7*24*time.Hour)
-	tokenStore := &TokenStore{repo: nil, logger: logger}
+	tokenStore := NewTokenStore(nil, logger)
 	service := NewAuthService(mockRepo, jwtManager, tokenStore, logger)
 
 	mockRepo.GetByEmailOrUsernameFn = func(ctx context.Context, emailOrUsername string) (*model.User, error) {
@@ -52,7 +59,7 @@ func TestAuthService_Login_InactiveUser(t *testing.T) {
 	mockRepo := &testutil.MockUserRepo{}
 	logger := zap.NewNop().Sugar()
 	jwtManager := NewJWTManager("test-signing-key-must-be-long-enough-for-jwt", "test-refresh-key-must-be-long-enough", 15*time.Minute, 7*24*time.Hour)
-	tokenStore := &TokenStore{repo: nil, logger: logger}
+	tokenStore := NewTokenStore(nil, logger)
 	service := NewAuthService(mockRepo, jwtManager, tokenStore, logger)
 
 	inactiveUser := testutil.TestUser()
@@ -84,7 +91,7 @@ func TestAuthService_Login_InvalidPassword(t *testing.T) {
 	mockRepo := &testutil.MockUserRepo{}
 	logger := zap.NewNop().Sugar()
 	jwtManager := NewJWTManager("test-signing-key-must-be-long-enough-for-jwt", "test-refresh-key-must-be-long-enough", 15*time.Minute, 7*24*time.Hour)
-	tokenStore := &TokenStore{repo: nil, logger: logger}
+	tokenStore := NewTokenStore(nil, logger)
 	service := NewAuthService(mockRepo, jwtManager, tokenStore, logger)
 
 	testUser := testutil.TestUser()
@@ -114,7 +121,7 @@ func TestAuthService_Login_RepositoryError(t *testing.T) {
 	mockRepo := &testutil.MockUserRepo{}
 	logger := zap.NewNop().Sugar()
 	jwtManager := NewJWTManager("test-signing-key-must-be-long-enough-for-jwt", "test-refresh-key-must-be-long-enough", 15*time.Minute, 7*24*time.Hour)
-	tokenStore := &TokenStore{repo: nil, logger: logger}
+	tokenStore := NewTokenStore(nil, logger)
 	service := NewAuthService(mockRepo, jwtManager, tokenStore, logger)
 
 	mockRepo.GetByEmailOrUsernameFn = func(ctx context.Context, emailOrUsername string) (*model.User, error) {
@@ -137,6 +144,126 @@ func TestAuthService_Login_RepositoryError(t *testing.T) {
 	}
 }
 
+func TestAuthService_Login_TwoFactorRequiresCode(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockRepo := &testutil.MockUserRepo{}
+	logger := zap.NewNop().Sugar()
+	jwtManager := NewJWTManager("test-signing-key-must-be-long-enough-for-jwt", "test-refresh-key-must-be-long-enough", 15*time.Minute, 7*24*time.Hour)
+	tokenStore := NewTokenStore(nil, logger)
+	service := NewAuthService(mockRepo, jwtManager, tokenStore, logger)
+
+	sender := &testutil.MockSMSSender{}
+	service.SetOTPService(newTestOTPService(&testutil.MockOTPRepo{}, sender))
+
+	phone := "+14155552671"
+	testUser := testutil.TestUser()
+	verifiedAt := time.Now()
+	testUser.Phone = &phone
+	testUser.PhoneVerifiedAt = &verifiedAt
+	testUser.SMSTwoFactor = true
+	hashed, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	testUser.Password = string(hashed)
+	mockRepo.GetByEmailOrUsernameFn = func(ctx context.Context, emailOrUsername string) (*model.User, error) {
+		return testUser, nil
+	}
+
+	// Act
+	access, refresh, err := service.Login(ctx, testUser.Email, "password")
+
+	// Assert
+	if access != "" || refresh != "" {
+		t.Error("Login() should not issue tokens before the SMS code is verified")
+	}
+	appErr, ok := apperrors.IsAppError(err)
+	if !ok || appErr.Type != apperrors.UnauthorizedError {
+		t.Fatalf("Login() error = %v, want UnauthorizedError", err)
+	}
+	if len(sender.Messages) != 1 {
+		t.Errorf("Login() sent %d SMS messages, want 1", len(sender.Messages))
+	}
+}
+
+func TestAuthService_RequestLoginOTP_UnverifiedPhone(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockRepo := &testutil.MockUserRepo{}
+	logger := zap.NewNop().Sugar()
+	jwtManager := NewJWTManager("test-signing-key-must-be-long-enough-for-jwt", "test-refresh-key-must-be-long-enough", 15*time.Minute, 7*24*time.Hour)
+	tokenStore := NewTokenStore(nil, logger)
+	service := NewAuthService(mockRepo, jwtManager, tokenStore, logger)
+
+	sender := &testutil.MockSMSSender{}
+	service.SetOTPService(newTestOTPService(&testutil.MockOTPRepo{}, sender))
+
+	phone := "+14155552671"
+	testUser := testutil.TestUser()
+	testUser.Phone = &phone
+	mockRepo.GetByPhoneFn = func(ctx context.Context, p string) (*model.User, error) {
+		return testUser, nil
+	}
+
+	// Act
+	err := service.RequestLoginOTP(ctx, phone)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("RequestLoginOTP() error = %v, want nil as for an unknown number", err)
+	}
+	if len(sender.Messages) != 0 {
+		t.Errorf("RequestLoginOTP() sent %d SMS messages to an unverified phone, want 0", len(sender.Messages))
+	}
+}
+
+func TestAuthService_LoginWithOTP_UnverifiedPhone(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockRepo := &testutil.MockUserRepo{}
+	logger := zap.NewNop().Sugar()
+	jwtManager := NewJWTManager("test-signing-key-must-be-long-enough-for-jwt", "test-refresh-key-must-be-long-enough", 15*time.Minute, 7*24*time.Hour)
+	tokenStore := NewTokenStore(nil, logger)
+	service := NewAuthService(mockRepo, jwtManager, tokenStore, logger)
+
+	var stored *authModel.OTPCode
+	otpRepo := &testutil.MockOTPRepo{
+		CreateFn: func(ctx context.Context, code *authModel.OTPCode) error {
+			code.ID = uuid.New()
+			code.CreatedAt = time.Now()
+			stored = code
+			return nil
+		},
+		FindActiveFn: func(ctx context.Context, id uuid.UUID, purpose authModel.OTPPurpose) (*authModel.OTPCode, error) {
+			return stored, nil
+		},
+	}
+	sender := &testutil.MockSMSSender{}
+	otp := newTestOTPService(otpRepo, sender)
+	service.SetOTPService(otp)
+
+	phone := "+14155552671"
+	testUser := testutil.TestUser()
+	testUser.Phone = &phone
+	mockRepo.GetByPhoneFn = func(ctx context.Context, p string) (*model.User, error) {
+		return testUser, nil
+	}
+	// A valid login code for the user, as if sent before the number was changed
+	if err := otp.Send(ctx, testUser.ID, phone, authModel.OTPPurposeLogin); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	code := regexp.MustCompile(`\d{6}`).FindString(sender.Messages[0])
+
+	// Act
+	access, refresh, err := service.LoginWithOTP(ctx, phone, code)
+
+	// Assert
+	if access != "" || refresh != "" {
+		t.Error("LoginWithOTP() should not issue tokens for an unverified phone")
+	}
+	if err != apperrors.ErrInvalidOTP {
+		t.Errorf("LoginWithOTP() error = %v, want ErrInvalidOTP", err)
+	}
+}
+
 func TestAuthService_Login_WithUsername(t *testing.T) {
 	// Arrange
 	ctx := context.Background()
@@ -161,3 +288,49 @@ func TestAuthService_Login_WithUsername(t *testing.T) {
 		t.Error("GetByEmailOrUsername() should find user by username")
 	}
 }
+
+func TestAuthService_Authenticate_RiskScoring(t *testing.T) {
+	// Arrange
+	user := testutil.TestUser()
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct-horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user.Password = string(hash)
+	updates := 0
+	mockRepo := &testutil.MockUserRepo{
+		GetByEmailOrUsernameFn: func(ctx context.Context, identifier string) (*model.User, error) { return user, nil },
+		UpdateFn: func(ctx context.Context, u *model.User) error {
+			updates++
+			return nil
+		},
+	}
+	logger := zap.NewNop().Sugar()
+	jwtManager := NewJWTManager("test-signing-key-must-be-long-enough-for-jwt", "test-refresh-key-must-be-long-enough", 15*time.Minute, 7*24*time.Hour)
+	service := NewAuthService(mockRepo, jwtManager, NewTokenStore(nil, logger), logger)
+	velocity := risk.NewVelocityRule(time.Hour, map[risk.Kind]int{risk.KindLogin: 1}, 40)
+	service.SetRiskEngine(risk.NewEngine(config.RiskConfig{FlagScore: 30, BlockScore: 100}, nil, logger, velocity))
+	ctx := actor.WithClientIP(context.Background(), "203.0.113.7")
+
+	// Act
+	_, first := service.Authenticate(ctx, user.Email, "correct-horse", "")
+	flaggedAfterFirst := user.FlaggedForReview()
+	_, wrong := service.Authenticate(ctx, user.Email, "wrong", "")
+	flaggedAfterWrong := user.FlaggedForReview()
+	_, second := service.Authenticate(ctx, user.Email, "correct-horse", "")
+	_, third := service.Authenticate(ctx, user.Email, "correct-horse", "")
+
+	// Assert
+	if first != nil || flaggedAfterFirst {
+		t.Errorf("first login = %v, flagged %v; want an unflagged success", first, flaggedAfterFirst)
+	}
+	if wrong == nil || flaggedAfterWrong {
+		t.Errorf("wrong password = %v, flagged %v; want a failure that does not flag", wrong, flaggedAfterWrong)
+	}
+	if second != nil || third != nil || !user.FlaggedForReview() {
+		t.Errorf("logins beyond the velocity limit = %v, %v, flagged %v; want flagged successes", second, third, user.FlaggedForReview())
+	}
+	if updates != 1 {
+		t.Errorf("user saved %d times, want once", updates)
+	}
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestAuthService_IssueClientToken(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+	jwtManager := NewJWTManager("test-signing-key-must-be-long-enough-for-jwt", "test-refresh-key-must-be-long-enough", 15*time.Minute, 7*day)
+	service := NewAuthService(&testutil.MockUserRepo{}, jwtManager, NewTokenStore(&testutil.MockTokenRepo{}, logger), logger)
+	service.SetServiceClients(&testutil.MockServiceClientRepo{})
+	created, err := service.CreateServiceClient(context.Background(), dto.CreateServiceClientRequest{
+		ClientID: "billing-worker",
+		Name:     "Billing worker",
+		Scopes:   []string{"users:list", "users:history"},
+	})
+	if err != nil {
+		t.Fatalf("CreateServiceClient() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		grantType string
+		secret    string
+		scope     string
+		wantScope string
+		wantCode  string
+	}{
+		{name: "all scopes", grantType: "client_credentials", secret: created.ClientSecret, wantScope: "users:history users:list"},
+		{name: "narrowed scope", grantType: "client_credentials", secret: created.ClientSecret, scope: "users:list", wantScope: "users:list"},
+		{name: "scope not granted", grantType: "client_credentials", secret: created.ClientSecret, scope: "users:delete", wantCode: "invalid_scope"},
+		{name: "wrong secret", grantType: "client_credentials", secret: "guess", wantCode: "invalid_client"},
+		{name: "other grant", grantType: "password", secret: created.ClientSecret, wantCode: "unsupported_grant_type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			req := dto.ClientTokenRequest{GrantType: tt.grantType, ClientID: "billing-worker", ClientSecret: tt.secret, Scope: tt.scope}
+
+			// Act
+			resp, err := service.IssueClientToken(context.Background(), req)
+
+			// Assert
+			if tt.wantCode != "" {
+				var tokenErr *TokenError
+				if !errors.As(err, &tokenErr) || tokenErr.Code != tt.wantCode {
+					t.Fatalf("error = %v, want %s", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("IssueClientToken() error = %v", err)
+			}
+			if resp.Scope != tt.wantScope {
+				t.Errorf("scope = %q, want %q", resp.Scope, tt.wantScope)
+			}
+			claims, err := jwtManager.ValidateAccessToken(resp.AccessToken)
+			if err != nil {
+				t.Fatalf("token does not validate: %v", err)
+			}
+			if claims.ClientID != "billing-worker" || claims.Scope != tt.wantScope || claims.Role != ServiceClientRole {
+				t.Errorf("claims = %+v, want client billing-worker with scope %q", claims, tt.wantScope)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+
+	"github.com/google/uuid"
+)
+
+// Impersonate issues a short-lived access token acting as userID for the admin in ctx,
+// for support staff reproducing what a user sees. The token carries the admin in its
+// impersonator_id claim and cannot be refreshed. Admin accounts cannot be impersonated,
+// and an impersonation token cannot start another impersonation.
+func (s *AuthService) Impersonate(ctx context.Context, userID string) (string, time.Time, error) {
+	adminID, err := uuid.Parse(actor.UserID(ctx))
+	if err != nil {
+		return "", time.Time{}, apperrors.NewAppError(apperrors.UnauthorizedError, "Authentication required")
+	}
+	if actor.ImpersonatorID(ctx) != "" {
+		return "", time.Time{}, apperrors.NewAppError(apperrors.ForbiddenError, "Cannot impersonate while impersonating")
+	}
+	if userID == adminID.String() {
+		return "", time.Time{}, apperrors.NewAppError(apperrors.BadRequestError, "Cannot impersonate yourself")
+	}
+	if _, err := uuid.Parse(userID); err != nil {
+		return "", time.Time{}, apperrors.ErrUserNotFound
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		s.logger.Errorw("failed to fetch user", "user_id", userID, "error", err)
+		return "", time.Time{}, apperrors.NewAppError(apperrors.InternalError, "Failed to impersonate user")
+	}
+	if user == nil {
+		return "", time.Time{}, apperrors.ErrUserNotFound
+	}
+	if user.IsAdmin() {
+		return "", time.Time{}, apperrors.NewAppError(apperrors.ForbiddenError, "Admin accounts cannot be impersonated")
+	}
+	if !user.IsActive() {
+		return "", time.Time{}, apperrors.NewAppError(apperrors.BadRequestError, "Account is not active")
+	}
+
+	prefs := Preferences{TimeZone: user.TimeZone, Locale: user.Locale}
+	token, expiresAt, err := s.jwt.GenerateImpersonationToken(ctx, user.ID, string(user.UserType), prefs, adminID)
+	if err != nil {
+		s.logger.Errorw("failed to generate impersonation token", "user_id", userID, "error", err)
+		return "", time.Time{}, apperrors.NewAppError(apperrors.InternalError, "Failed to impersonate user")
+	}
+
+	s.logger.Infow("impersonation started",
+		"audit", true,
+		"user_id", user.ID,
+		"by", adminID,
+		"ip", actor.ClientIP(ctx),
+		"expires_at", expiresAt,
+	)
+	return token, expiresAt, nil
+}
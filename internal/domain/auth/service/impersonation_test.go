@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func TestAuthService_Impersonate(t *testing.T) {
+	adminID := uuid.New()
+	regular := &model.User{ID: uuid.New(), UserType: model.UserTypeRegular, Status: "active"}
+	otherAdmin := &model.User{ID: uuid.New(), UserType: model.UserTypeAdmin, Status: "active"}
+	suspended := &model.User{ID: uuid.New(), UserType: model.UserTypeRegular, Status: "suspended"}
+	users := map[string]*model.User{adminID.String(): {ID: adminID, UserType: model.UserTypeAdmin, Status: "active"}}
+	for _, u := range []*model.User{regular, otherAdmin, suspended} {
+		users[u.ID.String()] = u
+	}
+
+	tests := []struct {
+		name          string
+		anonymous     bool
+		impersonating bool
+		userID        string
+		wantType      apperrors.ErrorType
+	}{
+		{name: "regular user", userID: regular.ID.String()},
+		{name: "yourself", userID: adminID.String(), wantType: apperrors.BadRequestError},
+		{name: "admin account", userID: otherAdmin.ID.String(), wantType: apperrors.ForbiddenError},
+		{name: "inactive account", userID: suspended.ID.String(), wantType: apperrors.BadRequestError},
+		{name: "unknown user", userID: uuid.NewString(), wantType: apperrors.NotFoundError},
+		{name: "already impersonating", impersonating: true, userID: regular.ID.String(), wantType: apperrors.ForbiddenError},
+		{name: "anonymous", anonymous: true, userID: regular.ID.String(), wantType: apperrors.UnauthorizedError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			logger := zap.NewNop().Sugar()
+			userRepo := &testutil.MockUserRepo{
+				FindByIDFn: func(ctx context.Context, id string) (*model.User, error) { return users[id], nil },
+			}
+			jwtManager := NewJWTManager("test-signing-key-must-be-long-enough-for-jwt", "test-refresh-key-must-be-long-enough", 15*time.Minute, 7*24*time.Hour)
+			jwtManager.SetImpersonationExpiry(5 * time.Minute)
+			service := NewAuthService(userRepo, jwtManager, NewTokenStore(&testutil.MockTokenRepo{}, logger), logger)
+			ctx := context.Background()
+			if tt.impersonating {
+				ctx = actor.WithImpersonatorID(ctx, uuid.NewString())
+			}
+			if !tt.anonymous {
+				ctx = actor.WithUserID(ctx, adminID.String())
+			}
+
+			// Act
+			token, _, err := service.Impersonate(ctx, tt.userID)
+
+			// Assert
+			if tt.wantType != "" {
+				if appErr, ok := apperrors.IsAppError(err); !ok || appErr.Type != tt.wantType {
+					t.Fatalf("Impersonate() error = %v, want %s", err, tt.wantType)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Impersonate() error = %v", err)
+			}
+			claims, err := jwtManager.ValidateAccessToken(token)
+			if err != nil {
+				t.Fatalf("ValidateAccessToken() error = %v", err)
+			}
+			if claims.UserID.String() != tt.userID || claims.ImpersonatorID != adminID.String() {
+				t.Errorf("claims = %+v, want %s impersonated by %s", claims, tt.userID, adminID)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/jwk"
+)
+
+// LoadSigningKey reads the access token signing key from JWT_PRIVATE_KEY, or
+// JWT_PRIVATE_KEY_FILE when that is empty. It returns nil for HS256, and an error when the
+// key does not match JWT_ALGORITHM.
+func LoadSigningKey(cfg config.JWTConfig) (crypto.Signer, error) {
+	if cfg.Algorithm == "" || cfg.Algorithm == "HS256" {
+		return nil, nil
+	}
+
+	raw := []byte(cfg.PrivateKey)
+	source := "JWT_PRIVATE_KEY"
+	if len(raw) == 0 {
+		var err error
+		if raw, err = os.ReadFile(cfg.PrivateKeyFile); err != nil {
+			return nil, err
+		}
+		source = cfg.PrivateKeyFile
+	}
+	return parseSigningKey(raw, source, cfg.Algorithm)
+}
+
+// LoadSigningKeys reads every access token key pair: the one from LoadSigningKey when set,
+// then the *.pem files in JWT_KEYS_DIR in file name order. The last one signs new tokens
+// (see JWTManager.SetSigningKeys). It returns none for HS256.
+func LoadSigningKeys(cfg config.JWTConfig) ([]crypto.Signer, error) {
+	if cfg.Algorithm == "" || cfg.Algorithm == "HS256" {
+		return nil, nil
+	}
+
+	var keys []crypto.Signer
+	if cfg.PrivateKey != "" || cfg.PrivateKeyFile != "" {
+		key, err := LoadSigningKey(cfg)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if cfg.KeysDir == "" {
+		return keys, nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(cfg.KeysDir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		key, err := parseSigningKey(raw, path, cfg.Algorithm)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no *.pem keys in %s", cfg.KeysDir)
+	}
+	return keys, nil
+}
+
+// parseSigningKey parses a PEM private key read from source and checks it can sign
+// algorithm tokens
+func parseSigningKey(raw []byte, source, algorithm string) (crypto.Signer, error) {
+	key, err := jwk.ParsePrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", source, err)
+	}
+
+	switch key.(type) {
+	case *rsa.PrivateKey:
+		if algorithm == jwk.AlgorithmRS256 {
+			return key, nil
+		}
+	case ed25519.PrivateKey:
+		if algorithm == jwk.AlgorithmEdDSA {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("%s holds a %T, which cannot sign %s tokens", source, key, algorithm)
+}
@@ -1,59 +1,377 @@
 package service
 
 import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/MurtadaNazar/go-api-template/internal/shared/clock"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/jwk"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
-	apperrors "go_platform_template/internal/shared/errors"
 )
 
 type JWTManager struct {
-	accessSecret   string
+	keysMu sync.RWMutex
+	// accessKeys verify access tokens by kid and are replaced, never changed in place;
+	// currentKey signs new ones. Both hold the access secret unless SetSigningKeys is called.
+	accessKeys     map[string]accessKey
+	currentKey     accessKey
 	refreshSecret  string
 	accessExpires  time.Duration
 	refreshExpires time.Duration
+	// impersonationExpires is the lifetime of impersonation tokens; accessExpires unless set
+	impersonationExpires time.Duration
+	// guestExpires is the lifetime of guest tokens; accessExpires unless set
+	guestExpires time.Duration
+	// rememberMeExpires is the refresh token lifetime of remember-me logins; refreshExpires
+	// unless set
+	rememberMeExpires time.Duration
+	// sessionMaxLifetime caps how long refreshes can keep a session alive; 0 means no cap
+	sessionMaxLifetime time.Duration
+	clock              clock.Clock
+	// enricher adds application claims to access tokens; nil when none is set
+	enricher ClaimsEnricher
+	// issuer and audience are stamped on every token and required when validating;
+	// empty values are neither set nor checked
+	issuer   string
+	audience []string
+	// leeway tolerates clock skew when checking exp, nbf and iat
+	leeway time.Duration
+	// parser is shared by every validation; it reads clock on each call
+	parser *jwt.Parser
 }
 
+// accessKey signs and verifies access tokens: a key pair, or a shared secret for HS256
+type accessKey struct {
+	id     string
+	method jwt.SigningMethod
+	// sign is a crypto.Signer for key pairs and the secret for HS256; verify is the public
+	// key or the same secret
+	sign   interface{}
+	verify interface{}
+}
+
+// secretKey names an HS256 secret after its hash, which says nothing about the secret
+func secretKey(secret string) accessKey {
+	sum := sha256.Sum256([]byte(secret))
+	return accessKey{
+		id:     base64.RawURLEncoding.EncodeToString(sum[:8]),
+		method: jwt.SigningMethodHS256,
+		sign:   []byte(secret),
+		verify: []byte(secret),
+	}
+}
+
+// pairKey names a key pair after its public key thumbprint
+func pairKey(key crypto.Signer) (accessKey, error) {
+	var method jwt.SigningMethod
+	switch key.(type) {
+	case *rsa.PrivateKey:
+		method = jwt.SigningMethodRS256
+	case ed25519.PrivateKey:
+		method = jwt.SigningMethodEdDSA
+	default:
+		return accessKey{}, fmt.Errorf("unsupported signing key type %T", key)
+	}
+	return accessKey{id: jwk.KeyID(key.Public()), method: method, sign: key, verify: key.Public()}, nil
+}
+
+// SigningKeyInfo describes an access token key without its key material
+type SigningKeyInfo struct {
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg" example:"RS256"`
+	// Current is set on the key that signs new tokens; the others only verify
+	Current bool `json:"current"`
+}
+
+// ClaimsEnricher returns extra claims for a user's access token, such as a tenant ID or
+// plan. It runs on login and on every refresh, so changes reach the user with their next
+// token. Returning an error fails the login or refresh.
+type ClaimsEnricher func(ctx context.Context, userID uuid.UUID, role string) (map[string]interface{}, error)
+
 func NewJWTManager(accessSecret, refreshSecret string, accessExp, refreshExp time.Duration) *JWTManager {
-	return &JWTManager{
-		accessSecret:   accessSecret,
-		refreshSecret:  refreshSecret,
-		accessExpires:  accessExp,
-		refreshExpires: refreshExp,
+	current := secretKey(accessSecret)
+	m := &JWTManager{
+		accessKeys:           map[string]accessKey{current.id: current},
+		currentKey:           current,
+		refreshSecret:        refreshSecret,
+		accessExpires:        accessExp,
+		refreshExpires:       refreshExp,
+		impersonationExpires: accessExp,
+		guestExpires:         accessExp,
+		rememberMeExpires:    refreshExp,
+		clock:                clock.System(),
+	}
+	m.parser = m.newParser()
+	return m
+}
+
+// newParser builds the parser for the current issuer, audience and leeway
+func (m *JWTManager) newParser() *jwt.Parser {
+	opts := []jwt.ParserOption{
+		jwt.WithTimeFunc(func() time.Time { return m.clock.Now() }),
+		jwt.WithLeeway(m.leeway),
+	}
+	if m.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(m.issuer))
+	}
+	if len(m.audience) > 0 {
+		opts = append(opts, jwt.WithAudience(m.audience...))
+	}
+	return jwt.NewParser(opts...)
+}
+
+// SetIssuerAndAudience stamps issuer as "iss" and audience as "aud" on every token issued
+// from now on, and rejects tokens without them. A token passes the audience check when it
+// names any of audience. Call it at startup: tokens issued before it was set fail.
+func (m *JWTManager) SetIssuerAndAudience(issuer string, audience []string) {
+	m.issuer = issuer
+	m.audience = audience
+	m.parser = m.newParser()
+}
+
+// SetLeeway accepts tokens up to d past their expiry or before their issue time, for
+// servers whose clocks drift apart
+func (m *JWTManager) SetLeeway(d time.Duration) {
+	m.leeway = max(d, 0)
+	m.parser = m.newParser()
+}
+
+// registeredClaims returns the standard claims of a token issued at now that expires at
+// expiresAt
+func (m *JWTManager) registeredClaims(now, expiresAt time.Time) jwt.RegisteredClaims {
+	claims := jwt.RegisteredClaims{
+		Issuer:    m.issuer,
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+		IssuedAt:  jwt.NewNumericDate(now),
+	}
+	if len(m.audience) > 0 {
+		claims.Audience = jwt.ClaimStrings(m.audience)
+	}
+	return claims
+}
+
+// SetClock replaces the clock used to stamp and check token expiry
+func (m *JWTManager) SetClock(c clock.Clock) {
+	m.clock = c
+}
+
+// SetImpersonationExpiry sets how long impersonation tokens are valid. Non-positive
+// durations keep the access token lifetime.
+func (m *JWTManager) SetImpersonationExpiry(d time.Duration) {
+	if d > 0 {
+		m.impersonationExpires = d
+	}
+}
+
+// SetGuestExpiry sets how long guest tokens are valid. Non-positive durations keep the
+// access token lifetime.
+func (m *JWTManager) SetGuestExpiry(d time.Duration) {
+	if d > 0 {
+		m.guestExpires = d
+	}
+}
+
+// SetSessionLifetimes sets the refresh token lifetime of remember-me logins and the
+// absolute lifetime of a session, which refreshes cannot extend past. A non-positive
+// rememberMe keeps the refresh token lifetime; a non-positive maxLifetime means no cap.
+func (m *JWTManager) SetSessionLifetimes(rememberMe, maxLifetime time.Duration) {
+	if rememberMe > 0 {
+		m.rememberMeExpires = rememberMe
+	}
+	m.sessionMaxLifetime = max(maxLifetime, 0)
+}
+
+// refreshExpiry returns when a refresh token issued now for session expires: one refresh
+// or remember-me lifetime from now, but not after the session's maximum lifetime. It
+// returns the zero time once the session has reached that.
+func (m *JWTManager) refreshExpiry(session Session) time.Time {
+	now := m.clock.Now()
+	lifetime := m.refreshExpires
+	if session.RememberMe {
+		lifetime = m.rememberMeExpires
+	}
+	expiresAt := now.Add(lifetime)
+	if m.sessionMaxLifetime > 0 {
+		if limit := session.StartedAt.Add(m.sessionMaxLifetime); limit.Before(expiresAt) {
+			expiresAt = limit
+		}
+	}
+	if !expiresAt.After(now) {
+		return time.Time{}
+	}
+	return expiresAt
+}
+
+// SetClaimsEnricher adds the claims returned by enricher to every access token. They are
+// read back into Claims.Extra and put on the Gin context by middleware.JWTAuth.
+func (m *JWTManager) SetClaimsEnricher(enricher ClaimsEnricher) {
+	m.enricher = enricher
+}
+
+// SetSigningKey signs access tokens with key instead of the access secret: RS256 for an
+// RSA key, EdDSA for an Ed25519 key. The public half is published through PublicKeys.
+// Refresh tokens are only read by this service and stay HS256.
+func (m *JWTManager) SetSigningKey(key crypto.Signer) error {
+	return m.SetSigningKeys([]crypto.Signer{key})
+}
+
+// SetSigningKeys replaces the access token keys with key pairs, as SetSigningKey does for
+// one. The last key signs new tokens; every key verifies tokens whose "kid" names it.
+// Keys left out are retired, so tokens they signed stop validating. It is safe to call
+// while tokens are being issued, to rotate keys without a restart.
+func (m *JWTManager) SetSigningKeys(keys []crypto.Signer) error {
+	if len(keys) == 0 {
+		return errors.New("no signing keys")
+	}
+	ring := make(map[string]accessKey, len(keys))
+	var current accessKey
+	for _, key := range keys {
+		k, err := pairKey(key)
+		if err != nil {
+			return err
+		}
+		ring[k.id] = k
+		current = k
+	}
+
+	m.keysMu.Lock()
+	defer m.keysMu.Unlock()
+	m.accessKeys = ring
+	m.currentKey = current
+	return nil
+}
+
+// SetPreviousSecrets keeps accepting access tokens signed with earlier HS256 secrets, so
+// JWT_SIGNING_KEY can change without signing everyone out. Once their tokens have expired
+// the secrets can be dropped. It has no effect after SetSigningKeys.
+func (m *JWTManager) SetPreviousSecrets(secrets []string) {
+	m.keysMu.Lock()
+	defer m.keysMu.Unlock()
+	if m.currentKey.method != jwt.SigningMethodHS256 {
+		return
+	}
+	ring := make(map[string]accessKey, len(m.accessKeys)+len(secrets))
+	for id, k := range m.accessKeys {
+		ring[id] = k
+	}
+	for _, secret := range secrets {
+		if secret != "" {
+			k := secretKey(secret)
+			ring[k.id] = k
+		}
 	}
+	m.accessKeys = ring
+}
+
+// SigningKeys lists the keys that verify access tokens, the current one first
+func (m *JWTManager) SigningKeys() []SigningKeyInfo {
+	m.keysMu.RLock()
+	defer m.keysMu.RUnlock()
+	infos := make([]SigningKeyInfo, 0, len(m.accessKeys))
+	for id, k := range m.accessKeys {
+		infos = append(infos, SigningKeyInfo{KeyID: id, Algorithm: k.method.Alg(), Current: id == m.currentKey.id})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Current != infos[j].Current {
+			return infos[i].Current
+		}
+		return infos[i].KeyID < infos[j].KeyID
+	})
+	return infos
+}
+
+// PublicKeys returns the keys that verify access tokens, or none when they are signed
+// with the shared secret
+func (m *JWTManager) PublicKeys() []jwk.Key {
+	m.keysMu.RLock()
+	defer m.keysMu.RUnlock()
+	var keys []jwk.Key
+	for _, k := range m.accessKeys {
+		if k.method == jwt.SigningMethodHS256 {
+			continue
+		}
+		key, err := jwk.New(k.verify)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].KeyID < keys[j].KeyID })
+	return keys
 }
 
 type Claims struct {
 	UserID uuid.UUID `json:"user_id"`
 	Role   string    `json:"role"`
+	// TimeZone and Locale carry the user's saved preferences to the localization middleware
+	TimeZone string `json:"tz,omitempty"`
+	Locale   string `json:"locale,omitempty"`
+	// Extra holds the claims added by the ClaimsEnricher, kept under "ext" so they cannot
+	// clash with standard claims. Read back from a token, numbers are float64.
+	Extra map[string]interface{} `json:"ext,omitempty"`
+	// ImpersonatorID is the admin acting as UserID; only set on impersonation tokens
+	ImpersonatorID string `json:"impersonator_id,omitempty"`
+	// Guest marks tokens of anonymous clients, whose UserID names no account
+	Guest bool `json:"guest,omitempty"`
+	// ClientID is set on tokens of service clients (client credentials grant), whose
+	// UserID is the client's record ID and names no account
+	ClientID string `json:"client_id,omitempty"`
+	// Scope lists the permissions of a service client token, space separated
+	Scope string `json:"scope,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func (m *JWTManager) GenerateTokens(userID uuid.UUID, role string) (accessToken, refreshToken string, err error) {
-	now := time.Now()
+// Preferences are the user settings embedded in access tokens
+type Preferences struct {
+	TimeZone string
+	Locale   string
+}
+
+// GenerateTokens issues an access token and a refresh token valid for the refresh token lifetime
+func (m *JWTManager) GenerateTokens(ctx context.Context, userID uuid.UUID, role string, prefs Preferences) (accessToken, refreshToken string, err error) {
+	return m.generateTokens(ctx, userID, role, prefs, m.clock.Now().Add(m.refreshExpires))
+}
+
+// generateTokens issues an access token and a refresh token that expires at refreshExpiresAt
+func (m *JWTManager) generateTokens(ctx context.Context, userID uuid.UUID, role string, prefs Preferences, refreshExpiresAt time.Time) (accessToken, refreshToken string, err error) {
+	now := m.clock.Now()
+
+	var extra map[string]interface{}
+	if m.enricher != nil {
+		if extra, err = m.enricher(ctx, userID, role); err != nil {
+			return "", "", fmt.Errorf("enrich claims: %w", err)
+		}
+	}
 
 	// Access token
-	access := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
-		UserID: userID,
-		Role:   role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(now.Add(m.accessExpires)),
-			IssuedAt:  jwt.NewNumericDate(now),
-		},
+	accessToken, err = m.signAccessToken(Claims{
+		UserID:           userID,
+		Role:             role,
+		TimeZone:         prefs.TimeZone,
+		Locale:           prefs.Locale,
+		Extra:            extra,
+		RegisteredClaims: m.registeredClaims(now, now.Add(m.accessExpires)),
 	})
-	accessToken, err = access.SignedString([]byte(m.accessSecret))
 	if err != nil {
 		return "", "", err
 	}
 
 	// Refresh token
 	refresh := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
-		UserID: userID,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(now.Add(m.refreshExpires)),
-			IssuedAt:  jwt.NewNumericDate(now),
-		},
+		UserID:           userID,
+		RegisteredClaims: m.registeredClaims(now, refreshExpiresAt),
 	})
 	refreshToken, err = refresh.SignedString([]byte(m.refreshSecret))
 	if err != nil {
@@ -63,17 +381,121 @@ func (m *JWTManager) GenerateTokens(userID uuid.UUID, role string) (accessToken,
 	return accessToken, refreshToken, nil
 }
 
+// GenerateImpersonationToken issues an access token for userID that records
+// impersonatorID as the admin behind it. It has no refresh token, so the session ends
+// once the token expires; the expiry is returned with it.
+func (m *JWTManager) GenerateImpersonationToken(ctx context.Context, userID uuid.UUID, role string, prefs Preferences, impersonatorID uuid.UUID) (string, time.Time, error) {
+	now := m.clock.Now()
+	expiresAt := now.Add(m.impersonationExpires)
+
+	var extra map[string]interface{}
+	if m.enricher != nil {
+		var err error
+		if extra, err = m.enricher(ctx, userID, role); err != nil {
+			return "", time.Time{}, fmt.Errorf("enrich claims: %w", err)
+		}
+	}
+
+	token, err := m.signAccessToken(Claims{
+		UserID:           userID,
+		Role:             role,
+		TimeZone:         prefs.TimeZone,
+		Locale:           prefs.Locale,
+		Extra:            extra,
+		ImpersonatorID:   impersonatorID.String(),
+		RegisteredClaims: m.registeredClaims(now, expiresAt),
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// GenerateGuestToken issues an access token with the guest role for a new, random guest
+// ID. It has no refresh token; the expiry is returned with it.
+func (m *JWTManager) GenerateGuestToken() (string, uuid.UUID, time.Time, error) {
+	now := m.clock.Now()
+	expiresAt := now.Add(m.guestExpires)
+	guestID := uuid.New()
+	token, err := m.signAccessToken(Claims{
+		UserID:           guestID,
+		Role:             GuestRole,
+		Guest:            true,
+		RegisteredClaims: m.registeredClaims(now, expiresAt),
+	})
+	if err != nil {
+		return "", uuid.Nil, time.Time{}, err
+	}
+	return token, guestID, expiresAt, nil
+}
+
+// GenerateClientToken issues an access token for service client clientID, whose record ID
+// is id, limited to scopes. It lasts the access token lifetime and has no refresh token.
+func (m *JWTManager) GenerateClientToken(id uuid.UUID, clientID string, scopes []string) (string, time.Time, error) {
+	now := m.clock.Now()
+	expiresAt := now.Add(m.accessExpires)
+	token, err := m.signAccessToken(Claims{
+		UserID:           id,
+		Role:             ServiceClientRole,
+		ClientID:         clientID,
+		Scope:            strings.Join(scopes, " "),
+		RegisteredClaims: m.registeredClaims(now, expiresAt),
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// signAccessToken signs claims with the current access key, naming it in "kid"
+func (m *JWTManager) signAccessToken(claims Claims) (string, error) {
+	m.keysMu.RLock()
+	key := m.currentKey
+	m.keysMu.RUnlock()
+
+	access := jwt.NewWithClaims(key.method, claims)
+	access.Header["kid"] = key.id
+	return access.SignedString(key.sign)
+}
+
+// ValidateAccessToken accepts tokens signed with any access key that is not retired. The
+// key is picked by "kid"; tokens without one predate key names and need the current key.
 func (m *JWTManager) ValidateAccessToken(tokenString string) (*Claims, error) {
-	return m.validateToken(tokenString, m.accessSecret)
+	m.keysMu.RLock()
+	keys, current := m.accessKeys, m.currentKey
+	m.keysMu.RUnlock()
+
+	return m.validateToken(tokenString, func(t *jwt.Token) (accessKey, error) {
+		kid, ok := t.Header["kid"].(string)
+		if !ok {
+			return current, nil
+		}
+		key, ok := keys[kid]
+		if !ok {
+			return accessKey{}, fmt.Errorf("unknown or retired signing key %q", kid)
+		}
+		return key, nil
+	})
 }
 
 func (m *JWTManager) ValidateRefreshToken(tokenString string) (*Claims, error) {
-	return m.validateToken(tokenString, m.refreshSecret)
+	refresh := accessKey{method: jwt.SigningMethodHS256, verify: []byte(m.refreshSecret)}
+	return m.validateToken(tokenString, func(*jwt.Token) (accessKey, error) { return refresh, nil })
 }
 
-func (m *JWTManager) validateToken(tokenString, secret string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(t *jwt.Token) (interface{}, error) {
-		return []byte(secret), nil
+// validateToken only accepts tokens signed with the method of the key chosen by keyFor, so
+// a token signed with HS256 using a public key as the secret cannot pass for an RS256 or
+// EdDSA one
+func (m *JWTManager) validateToken(tokenString string, keyFor func(*jwt.Token) (accessKey, error)) (*Claims, error) {
+	token, err := m.parser.ParseWithClaims(tokenString, &Claims{}, func(t *jwt.Token) (interface{}, error) {
+		key, err := keyFor(t)
+		if err != nil {
+			return nil, err
+		}
+		if t.Method.Alg() != key.method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %s", t.Method.Alg())
+		}
+		return key.verify, nil
 	})
 	if err != nil {
 		return nil, err
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var testLoginLimits = config.LoginLimitConfig{
+	MaxFailures:   3,
+	IPMaxFailures: 5,
+	BackoffBase:   time.Second,
+	BackoffMax:    4 * time.Second,
+	Lockout:       15 * time.Minute,
+	Window:        15 * time.Minute,
+}
+
+// newLimitedAuthService returns an AuthService with login limits that knows user by email,
+// whose password is "correct-horse"
+func newLimitedAuthService(t *testing.T, user *model.User) (*AuthService, *testutil.FakeClock) {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct-horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user.Password = string(hash)
+
+	logger := zap.NewNop().Sugar()
+	userRepo := &testutil.MockUserRepo{
+		GetByEmailOrUsernameFn: func(ctx context.Context, identifier string) (*model.User, error) {
+			if identifier == user.Email {
+				return user, nil
+			}
+			return nil, nil
+		},
+		FindByIDFn: func(ctx context.Context, id string) (*model.User, error) {
+			if id == user.ID.String() {
+				return user, nil
+			}
+			return nil, nil
+		},
+	}
+	clk := testutil.NewFakeClock(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+	store := NewMemoryLoginAttemptStore()
+	store.SetClock(clk)
+	limiter := NewLoginLimiter(store, testLoginLimits, logger)
+	limiter.SetClock(clk)
+	jwtManager := NewJWTManager("test-signing-key-must-be-long-enough-for-jwt", "test-refresh-key-must-be-long-enough", 15*time.Minute, 7*24*time.Hour)
+	service := NewAuthService(userRepo, jwtManager, NewTokenStore(&testutil.MockTokenRepo{}, logger), logger)
+	service.SetLoginLimiter(limiter)
+	return service, clk
+}
+
+// throttled returns the Retry-After seconds of a TooManyRequestsError, or 0 for any other result
+func throttled(err error) int {
+	if appErr, ok := apperrors.IsAppError(err); ok && appErr.Type == apperrors.TooManyRequestsError {
+		return appErr.RetryAfter
+	}
+	return 0
+}
+
+func TestAuthService_Authenticate_BacksOffAfterFailures(t *testing.T) {
+	// Arrange
+	user := testutil.TestUser()
+	service, clk := newLimitedAuthService(t, user)
+	ctx := actor.WithClientIP(context.Background(), "203.0.113.7")
+
+	// Act
+	_, first := service.Authenticate(ctx, user.Email, "wrong", "")
+	_, tooSoon := service.Authenticate(ctx, user.Email, "correct-horse", "")
+	clk.Advance(time.Second)
+	_, second := service.Authenticate(ctx, user.Email, "wrong", "")
+	_, stillWaiting := service.Authenticate(ctx, user.Email, "correct-horse", "")
+
+	// Assert
+	if appErr, ok := apperrors.IsAppError(first); !ok || appErr.Type != apperrors.UnauthorizedError {
+		t.Fatalf("first attempt error = %v, want invalid credentials", first)
+	}
+	if got := throttled(tooSoon); got != 1 {
+		t.Errorf("retry after one failure: Retry-After = %d, want 1", got)
+	}
+	if throttled(second) != 0 {
+		t.Errorf("retry after the backoff was throttled: %v", second)
+	}
+	if got := throttled(stillWaiting); got != 2 {
+		t.Errorf("retry after two failures: Retry-After = %d, want the doubled 2", got)
+	}
+}
+
+func TestAuthService_Authenticate_LocksOutAccount(t *testing.T) {
+	// Arrange
+	user := testutil.TestUser()
+	service, clk := newLimitedAuthService(t, user)
+	ctx := context.Background()
+	for i := 0; i < testLoginLimits.MaxFailures; i++ {
+		if _, err := service.Authenticate(ctx, user.Email, "wrong", ""); throttled(err) != 0 {
+			t.Fatalf("attempt %d throttled: %v", i+1, err)
+		}
+		clk.Advance(testLoginLimits.BackoffMax)
+	}
+
+	// Act
+	_, locked := service.Authenticate(ctx, user.Email, "correct-horse", "")
+	clk.Advance(testLoginLimits.Lockout)
+	_, unlocked := service.Authenticate(ctx, user.Email, "correct-horse", "")
+
+	// Assert
+	wantWait := int((testLoginLimits.Lockout - testLoginLimits.BackoffMax) / time.Second)
+	if got := throttled(locked); got != wantWait {
+		t.Errorf("correct password during lockout: Retry-After = %d, want %d", got, wantWait)
+	}
+	if unlocked != nil {
+		t.Errorf("Authenticate() after the lockout error = %v", unlocked)
+	}
+}
+
+func TestAuthService_Authenticate_SuccessResetsAccount(t *testing.T) {
+	// Arrange
+	user := testutil.TestUser()
+	service, clk := newLimitedAuthService(t, user)
+	ctx := context.Background()
+	for i := 0; i < testLoginLimits.MaxFailures-1; i++ {
+		_, _ = service.Authenticate(ctx, user.Email, "wrong", "")
+		clk.Advance(testLoginLimits.BackoffMax)
+	}
+	if _, err := service.Authenticate(ctx, user.Email, "correct-horse", ""); err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+
+	// Act
+	_, _ = service.Authenticate(ctx, user.Email, "wrong", "")
+	clk.Advance(testLoginLimits.BackoffBase)
+	_, err := service.Authenticate(ctx, user.Email, "correct-horse", "")
+
+	// Assert
+	if err != nil {
+		t.Errorf("one failure after a successful login still throttles: %v", err)
+	}
+}
+
+func TestAuthService_Authenticate_LocksOutClientIP(t *testing.T) {
+	// Arrange
+	user := testutil.TestUser()
+	service, clk := newLimitedAuthService(t, user)
+	attacker := actor.WithClientIP(context.Background(), "203.0.113.7")
+	for i := 0; i < testLoginLimits.IPMaxFailures; i++ {
+		_, _ = service.Authenticate(attacker, "guess@example.com", "wrong", "")
+		clk.Advance(testLoginLimits.BackoffMax)
+	}
+
+	// Act
+	_, blocked := service.Authenticate(attacker, user.Email, "correct-horse", "")
+	_, other := service.Authenticate(actor.WithClientIP(context.Background(), "198.51.100.1"), user.Email, "correct-horse", "")
+
+	// Assert
+	if throttled(blocked) == 0 {
+		t.Errorf("locked out IP was let through: %v", blocked)
+	}
+	if other != nil {
+		t.Errorf("another IP was throttled: %v", other)
+	}
+}
+
+func TestAuthService_UnlockAccount(t *testing.T) {
+	// Arrange
+	user := testutil.TestUser()
+	service, _ := newLimitedAuthService(t, user)
+	ctx := context.Background()
+	for i := 0; i < testLoginLimits.MaxFailures; i++ {
+		_, _ = service.Authenticate(ctx, user.Email, "wrong", "")
+	}
+
+	// Act
+	err := service.UnlockAccount(ctx, user.ID.String())
+	_, loginErr := service.Authenticate(ctx, user.Email, "correct-horse", "")
+	unknownErr := service.UnlockAccount(ctx, "00000000-0000-0000-0000-000000000000")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("UnlockAccount() error = %v", err)
+	}
+	if loginErr != nil {
+		t.Errorf("Authenticate() after unlock error = %v", loginErr)
+	}
+	if appErr, ok := apperrors.IsAppError(unknownErr); !ok || appErr.Type != apperrors.NotFoundError {
+		t.Errorf("UnlockAccount() for unknown user error = %v, want not found", unknownErr)
+	}
+}
+
+func TestMemoryLoginAttemptStore_ForgetsAfterTTL(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	clk := testutil.NewFakeClock(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+	store := NewMemoryLoginAttemptStore()
+	store.SetClock(clk)
+	_, _ = store.RecordFailure(ctx, "k", clk.Now(), time.Minute)
+	second, _ := store.RecordFailure(ctx, "k", clk.Now(), time.Minute)
+
+	// Act
+	clk.Advance(time.Minute)
+	expired, _ := store.Get(ctx, "k")
+	fresh, _ := store.RecordFailure(ctx, "k", clk.Now(), time.Minute)
+
+	// Assert
+	if second.Failures != 2 {
+		t.Errorf("failures = %d, want 2", second.Failures)
+	}
+	if expired.Failures != 0 || fresh.Failures != 1 {
+		t.Errorf("after the ttl: Get = %d failures, next failure = %d; want 0 and 1", expired.Failures, fresh.Failures)
+	}
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	authModel "github.com/MurtadaNazar/go-api-template/internal/domain/auth/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/oauth"
+	authRepo "github.com/MurtadaNazar/go-api-template/internal/domain/auth/repo"
+	userModel "github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// oauthLogin holds the social login setup of an AuthService
+type oauthLogin struct {
+	providers   map[string]oauth.Provider
+	identities  authRepo.OAuthRepo
+	allowSignup bool
+}
+
+// SetOAuth enables login through OAuth2 providers. Provider accounts are linked to the
+// user with the same verified email; with allowSignup a user is created when none exists.
+func (s *AuthService) SetOAuth(providers map[string]oauth.Provider, identities authRepo.OAuthRepo, allowSignup bool) {
+	s.oauth = &oauthLogin{providers: providers, identities: identities, allowSignup: allowSignup}
+}
+
+// OAuthLoginURL returns the provider page that starts a login; state is checked on the callback
+func (s *AuthService) OAuthLoginURL(ctx context.Context, provider, state string) (string, error) {
+	p, err := s.oauthProvider(provider)
+	if err != nil {
+		return "", err
+	}
+	target, err := p.AuthCodeURL(ctx, state)
+	if err != nil {
+		s.logger.Errorw("failed to build oauth login url", "provider", provider, "error", err)
+		return "", apperrors.NewAppError(apperrors.InternalError, "Login with "+provider+" is unavailable")
+	}
+	return target, nil
+}
+
+// LoginWithOAuth exchanges the code from a provider callback for tokens
+func (s *AuthService) LoginWithOAuth(ctx context.Context, provider, code string) (string, string, error) {
+	p, err := s.oauthProvider(provider)
+	if err != nil {
+		return "", "", err
+	}
+
+	identity, err := p.Exchange(ctx, code)
+	if errors.Is(err, oauth.ErrEmailUnverified) {
+		s.logger.Warnw("oauth login without verified email", "provider", provider)
+		return "", "", apperrors.NewAppError(apperrors.UnauthorizedError, "The provider account has no verified email")
+	}
+	if err != nil {
+		s.logger.Warnw("oauth code exchange failed", "provider", provider, "error", err)
+		return "", "", apperrors.NewAppError(apperrors.UnauthorizedError, "Login with "+provider+" failed")
+	}
+
+	user, err := s.oauthUser(ctx, identity)
+	if err != nil {
+		return "", "", err
+	}
+	if err := s.syncOAuthRole(ctx, user, identity); err != nil {
+		return "", "", err
+	}
+	if !user.IsActive() {
+		s.logger.Warnw("inactive user oauth login attempt", "user_id", user.ID, "provider", provider)
+		return "", "", apperrors.NewAppError(apperrors.ForbiddenError, "Account is inactive")
+	}
+
+	return s.issueTokens(ctx, user, false)
+}
+
+func (s *AuthService) oauthProvider(name string) (oauth.Provider, error) {
+	if s.oauth == nil {
+		return nil, apperrors.NewAppError(apperrors.BadRequestError, "Social login is not enabled")
+	}
+	p, ok := s.oauth.providers[name]
+	if !ok {
+		return nil, apperrors.NewAppError(apperrors.NotFoundError, "Unknown login provider")
+	}
+	return p, nil
+}
+
+// oauthUser finds the user linked to identity, linking or creating one by email on first login
+func (s *AuthService) oauthUser(ctx context.Context, identity *oauth.Identity) (*userModel.User, error) {
+	link, err := s.oauth.identities.FindBySubject(ctx, identity.Provider, identity.Subject)
+	if err != nil {
+		s.logger.Errorw("failed to fetch oauth identity", "provider", identity.Provider, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Login failed")
+	}
+	if link != nil {
+		user, err := s.userRepo.FindByID(ctx, link.UserID.String())
+		if err != nil {
+			s.logger.Errorw("failed to fetch linked user", "user_id", link.UserID, "error", err)
+			return nil, apperrors.NewAppError(apperrors.InternalError, "Login failed")
+		}
+		if user == nil {
+			return nil, apperrors.NewAppError(apperrors.UnauthorizedError, "Account not found")
+		}
+		return user, nil
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, identity.Email)
+	if err != nil {
+		s.logger.Errorw("failed to fetch user by email", "provider", identity.Provider, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Login failed")
+	}
+	if user == nil {
+		if !s.oauth.allowSignup {
+			return nil, apperrors.NewAppError(apperrors.UnauthorizedError, "No account is registered with this email")
+		}
+		if user, err = s.signUpOAuth(ctx, identity); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.oauth.identities.Create(ctx, &authModel.OAuthIdentity{
+		UserID:   user.ID,
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	}); err != nil {
+		s.logger.Errorw("failed to link oauth identity", "user_id", user.ID, "provider", identity.Provider, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Login failed")
+	}
+	s.logger.Infow("oauth identity linked", "user_id", user.ID, "provider", identity.Provider)
+	return user, nil
+}
+
+// syncOAuthRole gives user the role its provider assigned, for providers that manage roles
+func (s *AuthService) syncOAuthRole(ctx context.Context, user *userModel.User, identity *oauth.Identity) error {
+	if identity.Role == "" || string(user.UserType) == identity.Role {
+		return nil
+	}
+	previous := user.UserType
+	user.UserType = userModel.UserType(identity.Role)
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		s.logger.Errorw("failed to apply provider role", "user_id", user.ID, "provider", identity.Provider, "error", err)
+		return apperrors.NewAppError(apperrors.InternalError, "Login failed")
+	}
+	s.logger.Infow("role changed by identity provider", "user_id", user.ID, "provider", identity.Provider,
+		"from", previous, "to", user.UserType)
+	return nil
+}
+
+// signUpOAuth creates a user for a provider account, with the provider's role if it assigns one. The password is random and
+// unknown to anyone, so the account can only log in through a provider until one is set.
+func (s *AuthService) signUpOAuth(ctx context.Context, identity *oauth.Identity) (*userModel.User, error) {
+	username, err := s.freeUsername(ctx, identity.Email)
+	if err != nil {
+		s.logger.Errorw("failed to pick username", "provider", identity.Provider, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to create account")
+	}
+	password := make([]byte, 32)
+	if _, err := rand.Read(password); err != nil {
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to create account")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(hex.EncodeToString(password)), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to create account")
+	}
+
+	user := &userModel.User{
+		FirstName: identity.FirstName,
+		LastName:  identity.LastName,
+		Username:  username,
+		Email:     identity.Email,
+		Password:  string(hash),
+		UserType:  userModel.UserTypeRegular,
+		Status:    "active",
+	}
+	if identity.Role != "" {
+		user.UserType = userModel.UserType(identity.Role)
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		if appErr, ok := apperrors.IsAppError(err); ok {
+			return nil, appErr
+		}
+		s.logger.Errorw("failed to create oauth user", "provider", identity.Provider, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to create account")
+	}
+	s.logger.Infow("user registered through oauth", "user_id", user.ID, "provider", identity.Provider)
+	return user, nil
+}
+
+// freeUsername derives an unused username from the local part of email
+func (s *AuthService) freeUsername(ctx context.Context, email string) (string, error) {
+	local, _, _ := strings.Cut(email, "@")
+	base := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return -1
+	}, local)
+	if len(base) > 40 {
+		base = base[:40]
+	}
+	for len(base) < 3 {
+		base += "_"
+	}
+
+	candidate := base
+	for attempt := 0; attempt < 5; attempt++ {
+		existing, err := s.userRepo.FindByUsername(ctx, candidate)
+		if err != nil {
+			return "", err
+		}
+		if existing == nil {
+			return candidate, nil
+		}
+		n, err := rand.Int(rand.Reader, big.NewInt(10000))
+		if err != nil {
+			return "", err
+		}
+		candidate = fmt.Sprintf("%s_%04d", base, n.Int64())
+	}
+	return "", errors.New("no free username after 5 attempts")
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/oauth"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+
+	"go.uber.org/zap"
+)
+
+// fakeProvider returns a fixed identity or error from Exchange
+type fakeProvider struct {
+	identity *oauth.Identity
+	err      error
+}
+
+func (p *fakeProvider) Name() string { return "google" }
+
+func (p *fakeProvider) AuthCodeURL(ctx context.Context, state string) (string, error) {
+	return "https://accounts.example.com/auth?state=" + state, nil
+}
+
+func (p *fakeProvider) Exchange(ctx context.Context, code string) (*oauth.Identity, error) {
+	return p.identity, p.err
+}
+
+// newOAuthAuthService returns an AuthService with provider enabled and the given users stored
+func newOAuthAuthService(provider *fakeProvider, allowSignup bool, users ...*model.User) (*AuthService, *testutil.MockUserRepo, *testutil.MockOAuthRepo) {
+	logger := zap.NewNop().Sugar()
+	userRepo := &testutil.MockUserRepo{
+		FindByIDFn: func(ctx context.Context, id string) (*model.User, error) {
+			for _, u := range users {
+				if u.ID.String() == id {
+					return u, nil
+				}
+			}
+			return nil, nil
+		},
+		GetByEmailFn: func(ctx context.Context, email string) (*model.User, error) {
+			for _, u := range users {
+				if u.Email == email {
+					return u, nil
+				}
+			}
+			return nil, nil
+		},
+	}
+	identities := &testutil.MockOAuthRepo{}
+	jwtManager := NewJWTManager("test-signing-key-must-be-long-enough-for-jwt", "test-refresh-key-must-be-long-enough", 15*time.Minute, 7*24*time.Hour)
+	service := NewAuthService(userRepo, jwtManager, NewTokenStore(&testutil.MockTokenRepo{}, logger), logger)
+	service.SetOAuth(map[string]oauth.Provider{"google": provider}, identities, allowSignup)
+	return service, userRepo, identities
+}
+
+func TestAuthService_LoginWithOAuth_LinksExistingUserByEmail(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	user := testutil.TestUser()
+	provider := &fakeProvider{identity: &oauth.Identity{Provider: "google", Subject: "g-123", Email: user.Email}}
+	service, userRepo, identities := newOAuthAuthService(provider, true, user)
+	userRepo.CreateFn = func(ctx context.Context, u *model.User) error {
+		t.Error("a new user was created for an existing email")
+		return nil
+	}
+
+	// Act
+	access, refresh, err := service.LoginWithOAuth(ctx, "google", "code")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("LoginWithOAuth() error = %v", err)
+	}
+	if access == "" || refresh == "" {
+		t.Error("LoginWithOAuth() returned empty tokens")
+	}
+	if len(identities.Identities) != 1 || identities.Identities[0].UserID != user.ID {
+		t.Errorf("identities = %+v, want one link to %s", identities.Identities, user.ID)
+	}
+}
+
+func TestAuthService_LoginWithOAuth_UsesLinkAfterEmailChange(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	user := testutil.TestUser()
+	provider := &fakeProvider{identity: &oauth.Identity{Provider: "google", Subject: "g-123", Email: user.Email}}
+	service, _, identities := newOAuthAuthService(provider, false, user)
+	if _, _, err := service.LoginWithOAuth(ctx, "google", "code"); err != nil {
+		t.Fatalf("first LoginWithOAuth() error = %v", err)
+	}
+	provider.identity = &oauth.Identity{Provider: "google", Subject: "g-123", Email: "renamed@example.com"}
+
+	// Act
+	_, _, err := service.LoginWithOAuth(ctx, "google", "code")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("LoginWithOAuth() after email change error = %v", err)
+	}
+	if len(identities.Identities) != 1 {
+		t.Errorf("identities = %+v, want the existing link reused", identities.Identities)
+	}
+}
+
+func TestAuthService_LoginWithOAuth_SignsUpNewUser(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	provider := &fakeProvider{identity: &oauth.Identity{Provider: "google", Subject: "g-9", Email: "jane.doe+work@example.com", FirstName: "Jane", LastName: "Doe"}}
+	service, userRepo, identities := newOAuthAuthService(provider, true)
+	var created *model.User
+	userRepo.CreateFn = func(ctx context.Context, u *model.User) error {
+		created = u
+		return nil
+	}
+
+	// Act
+	_, _, err := service.LoginWithOAuth(ctx, "google", "code")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("LoginWithOAuth() error = %v", err)
+	}
+	if created == nil || created.Username != "janedoework" || created.FirstName != "Jane" || created.UserType != model.UserTypeRegular {
+		t.Fatalf("created user = %+v, want a regular user named after the email", created)
+	}
+	if created.Password == "" {
+		t.Error("created user has no password hash")
+	}
+	if len(identities.Identities) != 1 {
+		t.Errorf("identities = %+v, want the new user linked", identities.Identities)
+	}
+}
+
+func TestAuthService_LoginWithOAuth_AppliesProviderRole(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	user := testutil.TestUser()
+	provider := &fakeProvider{identity: &oauth.Identity{Provider: "google", Subject: "kc-1", Email: user.Email, Role: "admin"}}
+	service, userRepo, _ := newOAuthAuthService(provider, false, user)
+	var updated *model.User
+	userRepo.UpdateFn = func(ctx context.Context, u *model.User) error {
+		updated = u
+		return nil
+	}
+
+	// Act
+	_, _, err := service.LoginWithOAuth(ctx, "google", "code")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("LoginWithOAuth() error = %v", err)
+	}
+	if updated == nil || updated.UserType != "admin" {
+		t.Errorf("updated user = %+v, want the provider's admin role saved", updated)
+	}
+}
+
+func TestAuthService_LoginWithOAuth_Rejections(t *testing.T) {
+	cases := []struct {
+		name        string
+		provider    string
+		exchangeErr error
+		allowSignup bool
+		want        apperrors.ErrorType
+	}{
+		{"unknown provider", "gitlab", nil, true, apperrors.NotFoundError},
+		{"unverified email", "google", oauth.ErrEmailUnverified, true, apperrors.UnauthorizedError},
+		{"signup disabled", "google", nil, false, apperrors.UnauthorizedError},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			provider := &fakeProvider{identity: &oauth.Identity{Provider: "google", Subject: "g-1", Email: "new@example.com"}, err: tc.exchangeErr}
+			service, _, _ := newOAuthAuthService(provider, tc.allowSignup)
+
+			// Act
+			_, _, err := service.LoginWithOAuth(ctx, tc.provider, "code")
+
+			// Assert
+			if appErr, ok := apperrors.IsAppError(err); !ok || appErr.Type != tc.want {
+				t.Errorf("LoginWithOAuth() error = %v, want %s", err, tc.want)
+			}
+		})
+	}
+}
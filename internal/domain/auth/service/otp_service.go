@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/repo"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/sms"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/clock"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// otpResendInterval stops a client from triggering a new SMS on every request
+const otpResendInterval = 30 * time.Second
+
+// OTPService issues and verifies one-time codes delivered by SMS
+type OTPService struct {
+	repo        repo.OTPRepo
+	sender      sms.Sender
+	length      int
+	ttl         time.Duration
+	maxAttempts int
+	clock       clock.Clock
+	logger      *zap.SugaredLogger
+}
+
+func NewOTPService(r repo.OTPRepo, sender sms.Sender, cfg config.SMSConfig, logger *zap.SugaredLogger) *OTPService {
+	return &OTPService{
+		repo:        r,
+		sender:      sender,
+		length:      cfg.OTPLength,
+		ttl:         cfg.OTPTTL,
+		maxAttempts: cfg.OTPMaxAttempts,
+		clock:       clock.System(),
+		logger:      logger,
+	}
+}
+
+// SetClock replaces the clock used for code expiry and resend throttling
+func (s *OTPService) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// Send replaces any outstanding code for the user and purpose and texts a new one to phone
+func (s *OTPService) Send(ctx context.Context, userID uuid.UUID, phone string, purpose model.OTPPurpose) error {
+	existing, err := s.repo.FindActive(ctx, userID, purpose)
+	if err != nil {
+		return err
+	}
+	if existing != nil && s.clock.Now().Sub(existing.CreatedAt) < otpResendInterval {
+		s.logger.Warnw("otp resend throttled", "user_id", userID, "purpose", purpose)
+		return nil
+	}
+
+	code, err := generateNumericCode(s.length)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteForUser(ctx, userID, purpose); err != nil {
+		return err
+	}
+	if err := s.repo.Create(ctx, &model.OTPCode{
+		UserID:    userID,
+		Purpose:   purpose,
+		CodeHash:  hashOTP(code),
+		ExpiresAt: s.clock.Now().Add(s.ttl),
+	}); err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("Your verification code is %s. It expires in %d minutes.", code, int(s.ttl.Minutes()))
+	if err := s.sender.Send(ctx, phone, message); err != nil {
+		return fmt.Errorf("send otp: %w", err)
+	}
+
+	s.logger.Infow("otp sent", "user_id", userID, "purpose", purpose)
+	return nil
+}
+
+// Verify checks code against the user's active code. A code can be used once and is
+// discarded after too many wrong guesses.
+func (s *OTPService) Verify(ctx context.Context, userID uuid.UUID, purpose model.OTPPurpose, code string) error {
+	active, err := s.repo.FindActive(ctx, userID, purpose)
+	if err != nil {
+		return err
+	}
+	if active == nil || !active.ExpiresAt.After(s.clock.Now()) {
+		return apperrors.ErrInvalidOTP
+	}
+
+	if active.Attempts >= s.maxAttempts {
+		s.logger.Warnw("otp attempts exhausted", "user_id", userID, "purpose", purpose)
+		_ = s.repo.DeleteForUser(ctx, userID, purpose)
+		return apperrors.ErrInvalidOTP
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashOTP(code)), []byte(active.CodeHash)) != 1 {
+		if err := s.repo.IncrementAttempts(ctx, active.ID); err != nil {
+			return err
+		}
+		return apperrors.ErrInvalidOTP
+	}
+
+	return s.repo.DeleteForUser(ctx, userID, purpose)
+}
+
+func hashOTP(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateNumericCode(length int) (string, error) {
+	code := make([]byte, length)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		code[i] = byte('0' + n.Int64())
+	}
+	return string(code), nil
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/model"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func newTestOTPService(repo *testutil.MockOTPRepo, sender *testutil.MockSMSSender) *OTPService {
+	cfg := config.SMSConfig{OTPLength: 6, OTPTTL: 5 * time.Minute, OTPMaxAttempts: 3}
+	return NewOTPService(repo, sender, cfg, zap.NewNop().Sugar())
+}
+
+func TestOTPService_SendThenVerify(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	userID := uuid.New()
+	var stored *model.OTPCode
+	repo := &testutil.MockOTPRepo{
+		CreateFn: func(ctx context.Context, code *model.OTPCode) error {
+			code.ID = uuid.New()
+			code.CreatedAt = time.Now()
+			stored = code
+			return nil
+		},
+		FindActiveFn: func(ctx context.Context, id uuid.UUID, purpose model.OTPPurpose) (*model.OTPCode, error) {
+			return stored, nil
+		},
+	}
+	sender := &testutil.MockSMSSender{}
+	service := newTestOTPService(repo, sender)
+
+	// Act
+	err := service.Send(ctx, userID, "+14155552671", model.OTPPurposeLogin)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Send() error = %v, want nil", err)
+	}
+	if len(sender.Messages) != 1 {
+		t.Fatalf("Send() sent %d messages, want 1", len(sender.Messages))
+	}
+	code := regexp.MustCompile(`\d{6}`).FindString(sender.Messages[0])
+	if code == "" {
+		t.Fatalf("Send() message %q does not contain a 6 digit code", sender.Messages[0])
+	}
+	if stored.CodeHash == code {
+		t.Error("Send() stored the plain code, want a hash")
+	}
+
+	// Act - a second send within the resend interval is throttled
+	if err := service.Send(ctx, userID, "+14155552671", model.OTPPurposeLogin); err != nil {
+		t.Fatalf("Send() second call error = %v, want nil", err)
+	}
+	if len(sender.Messages) != 1 {
+		t.Errorf("Send() second call sent a message, want throttled")
+	}
+
+	// Act - verify the texted code
+	if err := service.Verify(ctx, userID, model.OTPPurposeLogin, code); err != nil {
+		t.Errorf("Verify() error = %v, want nil", err)
+	}
+}
+
+func TestOTPService_Verify_WrongCode(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	active := &model.OTPCode{ID: uuid.New(), CodeHash: hashOTP("123456"), ExpiresAt: time.Now().Add(time.Minute)}
+	incremented := false
+	repo := &testutil.MockOTPRepo{
+		FindActiveFn: func(ctx context.Context, id uuid.UUID, purpose model.OTPPurpose) (*model.OTPCode, error) {
+			return active, nil
+		},
+		IncrementAttemptsFn: func(ctx context.Context, id uuid.UUID) error {
+			incremented = true
+			return nil
+		},
+	}
+	service := newTestOTPService(repo, &testutil.MockSMSSender{})
+
+	// Act
+	err := service.Verify(ctx, uuid.New(), model.OTPPurposeLogin, "654321")
+
+	// Assert
+	if err != apperrors.ErrInvalidOTP {
+		t.Errorf("Verify() error = %v, want ErrInvalidOTP", err)
+	}
+	if !incremented {
+		t.Error("Verify() should count the failed attempt")
+	}
+}
+
+func TestOTPService_Verify_AttemptsExhausted(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	active := &model.OTPCode{ID: uuid.New(), CodeHash: hashOTP("123456"), Attempts: 3, ExpiresAt: time.Now().Add(time.Minute)}
+	deleted := false
+	repo := &testutil.MockOTPRepo{
+		FindActiveFn: func(ctx context.Context, id uuid.UUID, purpose model.OTPPurpose) (*model.OTPCode, error) {
+			return active, nil
+		},
+		DeleteForUserFn: func(ctx context.Context, id uuid.UUID, purpose model.OTPPurpose) error {
+			deleted = true
+			return nil
+		},
+	}
+	service := newTestOTPService(repo, &testutil.MockSMSSender{})
+
+	// Act - even the correct code is rejected once attempts are used up
+	err := service.Verify(ctx, uuid.New(), model.OTPPurposeLogin, "123456")
+
+	// Assert
+	if err != apperrors.ErrInvalidOTP {
+		t.Errorf("Verify() error = %v, want ErrInvalidOTP", err)
+	}
+	if !deleted {
+		t.Error("Verify() should discard an exhausted code")
+	}
+}
+
+func TestOTPService_Send_ResendAfterInterval(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	clk := testutil.NewFakeClock(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+	var stored *model.OTPCode
+	repo := &testutil.MockOTPRepo{
+		CreateFn: func(ctx context.Context, code *model.OTPCode) error {
+			code.CreatedAt = clk.Now()
+			stored = code
+			return nil
+		},
+		FindActiveFn: func(ctx context.Context, id uuid.UUID, purpose model.OTPPurpose) (*model.OTPCode, error) {
+			return stored, nil
+		},
+	}
+	sender := &testutil.MockSMSSender{}
+	service := newTestOTPService(repo, sender)
+	service.SetClock(clk)
+	if err := service.Send(ctx, uuid.New(), "+14155552671", model.OTPPurposeLogin); err != nil {
+		t.Fatalf("Send() error = %v, want nil", err)
+	}
+
+	// Act
+	clk.Advance(otpResendInterval)
+	err := service.Send(ctx, uuid.New(), "+14155552671", model.OTPPurposeLogin)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Send() error = %v, want nil", err)
+	}
+	if len(sender.Messages) != 2 {
+		t.Errorf("Send() sent %d messages, want a new code once the interval passed", len(sender.Messages))
+	}
+	if want := clk.Now().Add(5 * time.Minute); !stored.ExpiresAt.Equal(want) {
+		t.Errorf("code expires at %v, want %v", stored.ExpiresAt, want)
+	}
+}
+
+func TestOTPService_Verify_Expired(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	clk := testutil.NewFakeClock(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+	active := &model.OTPCode{ID: uuid.New(), CodeHash: hashOTP("123456"), ExpiresAt: clk.Now().Add(time.Minute)}
+	repo := &testutil.MockOTPRepo{
+		FindActiveFn: func(ctx context.Context, id uuid.UUID, purpose model.OTPPurpose) (*model.OTPCode, error) {
+			return active, nil
+		},
+	}
+	service := newTestOTPService(repo, &testutil.MockSMSSender{})
+	service.SetClock(clk)
+
+	// Act
+	clk.Advance(time.Minute)
+	err := service.Verify(ctx, uuid.New(), model.OTPPurposeLogin, "123456")
+
+	// Assert
+	if err != apperrors.ErrInvalidOTP {
+		t.Errorf("Verify() error = %v, want ErrInvalidOTP for an expired code", err)
+	}
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	authModel "github.com/MurtadaNazar/go-api-template/internal/domain/auth/model"
+	userModel "github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+
+	"github.com/google/uuid"
+)
+
+// RequestPhoneVerification texts a code to the phone number on userID's account, which
+// VerifyPhone exchanges for a verified phone
+func (s *AuthService) RequestPhoneVerification(ctx context.Context, userID string) error {
+	if s.otp == nil {
+		return apperrors.NewAppError(apperrors.BadRequestError, "SMS verification is not enabled")
+	}
+	user, err := s.phoneOwner(ctx, userID, "Failed to send verification code")
+	if err != nil {
+		return err
+	}
+	if user.PhoneVerified() {
+		return apperrors.NewAppError(apperrors.ConflictError, "Phone number is already verified")
+	}
+
+	if err := s.otp.Send(ctx, user.ID, *user.Phone, authModel.OTPPurposePhoneVerification); err != nil {
+		s.logger.Errorw("failed to send phone verification otp", "user_id", user.ID, "error", err)
+		return apperrors.NewAppError(apperrors.InternalError, "Failed to send verification code")
+	}
+	return nil
+}
+
+// VerifyPhone marks the phone number on userID's account as verified when code is the
+// one RequestPhoneVerification sent to it, and returns when it was verified
+func (s *AuthService) VerifyPhone(ctx context.Context, userID, code string) (time.Time, error) {
+	if s.otp == nil {
+		return time.Time{}, apperrors.NewAppError(apperrors.BadRequestError, "SMS verification is not enabled")
+	}
+	user, err := s.phoneOwner(ctx, userID, "Failed to verify phone number")
+	if err != nil {
+		return time.Time{}, err
+	}
+	if user.PhoneVerified() {
+		return *user.PhoneVerifiedAt, nil
+	}
+
+	if err := s.otp.Verify(ctx, user.ID, authModel.OTPPurposePhoneVerification, code); err != nil {
+		return time.Time{}, s.otpError(user, err)
+	}
+	verifiedAt := s.jwt.clock.Now().Truncate(time.Second)
+	user.PhoneVerifiedAt = &verifiedAt
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		s.logger.Errorw("failed to mark phone verified", "user_id", user.ID, "error", err)
+		return time.Time{}, apperrors.NewAppError(apperrors.InternalError, "Failed to verify phone number")
+	}
+	s.logger.Infow("phone number verified", "audit", true, "user_id", user.ID, "ip", actor.ClientIP(ctx))
+	return verifiedAt, nil
+}
+
+// phoneOwner loads userID and checks that the account has a phone number
+func (s *AuthService) phoneOwner(ctx context.Context, userID, failure string) (*userModel.User, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, apperrors.ErrUserNotFound
+	}
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		s.logger.Errorw("failed to fetch user", "user_id", userID, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, failure)
+	}
+	if user == nil {
+		return nil, apperrors.ErrUserNotFound
+	}
+	if !user.HasPhone() {
+		return nil, apperrors.NewAppError(apperrors.BadRequestError, "Add a phone number to your account first")
+	}
+	return user, nil
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	authModel "github.com/MurtadaNazar/go-api-template/internal/domain/auth/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func TestAuthService_VerifyPhone(t *testing.T) {
+	// Arrange
+	clk := testutil.NewFakeClock(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+	logger := zap.NewNop().Sugar()
+	jwtManager := NewJWTManager("test-signing-key-must-be-long-enough-for-jwt", "test-refresh-key-must-be-long-enough", 15*time.Minute, 7*day)
+	jwtManager.SetClock(clk)
+	phone := "+14155552671"
+	user := &model.User{ID: uuid.New(), Phone: &phone, UserType: model.UserTypeRegular, Status: "active"}
+	updated := false
+	userRepo := &testutil.MockUserRepo{
+		FindByIDFn: func(ctx context.Context, id string) (*model.User, error) { return user, nil },
+		UpdateFn: func(ctx context.Context, u *model.User) error {
+			updated = true
+			return nil
+		},
+	}
+	var stored *authModel.OTPCode
+	otpRepo := &testutil.MockOTPRepo{
+		CreateFn: func(ctx context.Context, code *authModel.OTPCode) error {
+			code.CreatedAt = clk.Now()
+			stored = code
+			return nil
+		},
+		FindActiveFn: func(ctx context.Context, id uuid.UUID, purpose authModel.OTPPurpose) (*authModel.OTPCode, error) {
+			if stored == nil || stored.Purpose != purpose {
+				return nil, nil
+			}
+			return stored, nil
+		},
+	}
+	sender := &testutil.MockSMSSender{}
+	otp := newTestOTPService(otpRepo, sender)
+	otp.SetClock(clk)
+	service := NewAuthService(userRepo, jwtManager, NewTokenStore(nil, logger), logger)
+	service.SetOTPService(otp)
+
+	// Act
+	err := service.RequestPhoneVerification(context.Background(), user.ID.String())
+	if err != nil {
+		t.Fatalf("RequestPhoneVerification() error = %v", err)
+	}
+	code := regexp.MustCompile(`\d{6}`).FindString(sender.Messages[0])
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+	_, wrongErr := service.VerifyPhone(context.Background(), user.ID.String(), wrong)
+	verifiedAt, err := service.VerifyPhone(context.Background(), user.ID.String(), code)
+
+	// Assert
+	if wrongErr != apperrors.ErrInvalidOTP {
+		t.Errorf("VerifyPhone() with a wrong code error = %v, want ErrInvalidOTP", wrongErr)
+	}
+	if err != nil {
+		t.Fatalf("VerifyPhone() error = %v", err)
+	}
+	if !verifiedAt.Equal(clk.Now()) || !user.PhoneVerified() || !updated {
+		t.Errorf("verified at %v, PhoneVerified() = %v, saved = %v; want verified now and saved", verifiedAt, user.PhoneVerified(), updated)
+	}
+	if err := service.RequestPhoneVerification(context.Background(), user.ID.String()); err == nil {
+		t.Error("RequestPhoneVerification() for a verified phone error = nil, want conflict")
+	}
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	authModel "github.com/MurtadaNazar/go-api-template/internal/domain/auth/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const day = 24 * time.Hour
+
+// newSessionService returns a service with 7 day refresh tokens, 30 day remember-me
+// tokens and sessions capped at 40 days
+func newSessionService(t *testing.T) (*AuthService, *testutil.MockTokenRepo, *testutil.FakeClock) {
+	t.Helper()
+	clk := testutil.NewFakeClock(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+	logger := zap.NewNop().Sugar()
+	jwtManager := NewJWTManager("test-signing-key-must-be-long-enough-for-jwt", "test-refresh-key-must-be-long-enough", 15*time.Minute, 7*day)
+	jwtManager.SetClock(clk)
+	jwtManager.SetSessionLifetimes(30*day, 40*day)
+	tokens := &testutil.MockTokenRepo{Tokens: map[string]*authModel.RefreshToken{}}
+	store := NewTokenStore(tokens, logger)
+	store.SetClock(clk)
+	userRepo := &testutil.MockUserRepo{
+		FindByIDFn: func(ctx context.Context, id string) (*model.User, error) { return nil, nil },
+	}
+	return NewAuthService(userRepo, jwtManager, store, logger), tokens, clk
+}
+
+func TestAuthService_IssueTokens_RememberMe(t *testing.T) {
+	tests := []struct {
+		name       string
+		rememberMe bool
+		want       time.Duration
+	}{
+		{name: "regular login", rememberMe: false, want: 7 * day},
+		{name: "remember me", rememberMe: true, want: 30 * day},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			service, tokens, clk := newSessionService(t)
+			user := &model.User{ID: uuid.New(), UserType: model.UserTypeRegular}
+
+			// Act
+			_, refresh, err := service.issueTokens(context.Background(), user, tt.rememberMe)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("issueTokens() error = %v", err)
+			}
+			stored := tokens.Tokens[refresh]
+			if want := clk.Now().Add(tt.want); !stored.ExpiresAt.Equal(want) || stored.RememberMe != tt.rememberMe {
+				t.Errorf("stored token expires %v, remember me %v; want %v, %v", stored.ExpiresAt, stored.RememberMe, want, tt.rememberMe)
+			}
+			expiresAt, err := service.RefreshExpiry(refresh)
+			if err != nil || !expiresAt.Equal(stored.ExpiresAt) {
+				t.Errorf("RefreshExpiry() = %v, %v; want the stored expiry %v", expiresAt, err, stored.ExpiresAt)
+			}
+		})
+	}
+}
+
+func TestAuthService_Refresh_CappedAtSessionMaxLifetime(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	service, tokens, clk := newSessionService(t)
+	loginAt := clk.Now()
+	_, refresh, err := service.issueTokens(ctx, &model.User{ID: uuid.New(), UserType: model.UserTypeRegular}, true)
+	if err != nil {
+		t.Fatalf("issueTokens() error = %v", err)
+	}
+
+	// Act - a refresh early on extends the session by a full remember-me lifetime
+	clk.Advance(5 * day)
+	_, refresh, err = service.Refresh(ctx, refresh)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if got, want := tokens.Tokens[refresh].ExpiresAt, clk.Now().Add(30*day); !got.Equal(want) {
+		t.Errorf("refreshed token expires %v, want %v", got, want)
+	}
+
+	// Act - a later refresh cannot extend it past 40 days after login
+	clk.Advance(20 * day)
+	_, refresh, err = service.Refresh(ctx, refresh)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	stored := tokens.Tokens[refresh]
+	if want := loginAt.Add(40 * day); !stored.ExpiresAt.Equal(want) || !stored.SessionStartedAt.Equal(loginAt) {
+		t.Errorf("refreshed token expires %v, session started %v; want %v, %v", stored.ExpiresAt, stored.SessionStartedAt, want, loginAt)
+	}
+
+	// Act - once the session reaches its maximum lifetime the user has to log in again
+	clk.Advance(15 * day)
+	_, _, err = service.Refresh(ctx, refresh)
+
+	// Assert
+	if err == nil {
+		t.Error("Refresh() after the maximum session lifetime succeeded, want an error")
+	}
+}
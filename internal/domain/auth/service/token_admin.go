@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	authModel "github.com/MurtadaNazar/go-api-template/internal/domain/auth/model"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/metrics"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/webhook"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+
+	"github.com/google/uuid"
+)
+
+// maxTokenPage caps how many refresh tokens one listing returns
+const maxTokenPage = 100
+
+var tokensRevoked = metrics.NewCounter("refresh_tokens_revoked_total",
+	"Refresh tokens revoked by an admin, by scope (token or user).", "scope")
+
+// ListRefreshTokens lists active refresh tokens for incident response, optionally only
+// one user's. Only metadata is returned; token values never leave the database.
+func (s *AuthService) ListRefreshTokens(ctx context.Context, userID string, offset, limit int) ([]authModel.RefreshToken, error) {
+	if userID != "" {
+		if _, err := uuid.Parse(userID); err != nil {
+			return nil, apperrors.NewAppErrorWithDetails(apperrors.BadRequestError, "Invalid user_id", err.Error())
+		}
+	}
+	if offset < 0 || limit < 1 || limit > maxTokenPage {
+		return nil, apperrors.NewAppError(apperrors.BadRequestError, "offset must be 0 or more and limit between 1 and 100")
+	}
+	tokens, err := s.tokenStore.ListActive(ctx, userID, offset, limit)
+	if err != nil {
+		s.logger.Errorw("failed to list refresh tokens", "user_id", userID, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to fetch refresh tokens")
+	}
+	return tokens, nil
+}
+
+// RevokeRefreshToken revokes one refresh token by its record ID. Access tokens already
+// issued from it stay valid until they expire.
+func (s *AuthService) RevokeRefreshToken(ctx context.Context, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return apperrors.ErrTokenNotFound
+	}
+	token, err := s.tokenStore.RevokeByID(ctx, id)
+	if err != nil {
+		s.logger.Errorw("failed to revoke refresh token", "token_id", id, "error", err)
+		return apperrors.NewAppError(apperrors.InternalError, "Failed to revoke refresh token")
+	}
+	if token == nil {
+		return apperrors.ErrTokenNotFound
+	}
+	s.auditRevocation(ctx, "token", *token)
+	return nil
+}
+
+// OnUserTokensRevoked registers hook to run with the user ID after
+// RevokeUserRefreshTokens signs a user out everywhere, e.g. to drop cached access tokens
+func (s *AuthService) OnUserTokensRevoked(hook func(userID string)) {
+	s.revokeHooks = append(s.revokeHooks, hook)
+}
+
+// RevokeUserRefreshTokens signs a user out everywhere by revoking all of their refresh
+// tokens, returning how many were revoked
+func (s *AuthService) RevokeUserRefreshTokens(ctx context.Context, userID string) (int, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return 0, apperrors.ErrUserNotFound
+	}
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		s.logger.Errorw("failed to fetch user", "user_id", userID, "error", err)
+		return 0, apperrors.NewAppError(apperrors.InternalError, "Failed to revoke refresh tokens")
+	}
+	if user == nil {
+		return 0, apperrors.ErrUserNotFound
+	}
+	tokens, err := s.tokenStore.RevokeUser(ctx, userID)
+	if err != nil {
+		s.logger.Errorw("failed to revoke refresh tokens", "user_id", userID, "error", err)
+		return 0, apperrors.NewAppError(apperrors.InternalError, "Failed to revoke refresh tokens")
+	}
+	for _, token := range tokens {
+		s.auditRevocation(ctx, "user", token)
+	}
+	for _, hook := range s.revokeHooks {
+		hook(userID)
+	}
+	return len(tokens), nil
+}
+
+// auditRevocation records who revoked a token, from where and why, one event per token
+func (s *AuthService) auditRevocation(ctx context.Context, scope string, token authModel.RefreshToken) {
+	tokensRevoked.Inc(scope)
+	s.logger.Infow("refresh token revoked",
+		"audit", true,
+		"scope", scope,
+		"token_id", token.ID,
+		"user_id", token.UserID,
+		"by", actor.UserID(ctx),
+		"ip", actor.ClientIP(ctx),
+	)
+	s.webhooks.Publish(webhook.EventTokenRevoked, map[string]any{
+		"token_id":   token.ID,
+		"user_id":    token.UserID,
+		"scope":      scope,
+		"revoked_by": actor.UserID(ctx),
+	})
+}
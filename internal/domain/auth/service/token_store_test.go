@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func TestTokenStore_Validate_Rotates(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	clk := testutil.NewFakeClock(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+	store := NewTokenStore(&testutil.MockTokenRepo{}, zap.NewNop().Sugar())
+	store.SetClock(clk)
+	userID := uuid.New()
+	if err := store.Save(ctx, "refresh-1", userID, "user", clk.Now().Add(time.Hour), Session{}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// Act
+	data, err := store.Validate(ctx, "refresh-1", true)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Validate() error = %v, want nil", err)
+	}
+	if data.UserID != userID || data.Role != "user" {
+		t.Errorf("Validate() = %+v, want the saved user and role", data)
+	}
+	if _, err := store.Validate(ctx, "refresh-1", true); err != apperrors.ErrInvalidRefreshToken {
+		t.Errorf("Validate() after rotation error = %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestTokenStore_Validate_Expired(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	clk := testutil.NewFakeClock(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+	store := NewTokenStore(&testutil.MockTokenRepo{}, zap.NewNop().Sugar())
+	store.SetClock(clk)
+	if err := store.Save(ctx, "refresh-1", uuid.New(), "user", clk.Now().Add(time.Hour), Session{}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// Act
+	clk.Advance(time.Hour)
+	_, err := store.Validate(ctx, "refresh-1", false)
+
+	// Assert
+	if err != apperrors.ErrInvalidRefreshToken {
+		t.Errorf("Validate() error = %v, want ErrInvalidRefreshToken", err)
+	}
+}
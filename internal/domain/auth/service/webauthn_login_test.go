@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/webauthn"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const passkeyOrigin = "https://app.example.com"
+
+// newPasskeyAuthService returns an AuthService with passkeys enabled and the given users stored
+func newPasskeyAuthService(users ...*model.User) (*AuthService, *testutil.MockWebAuthnRepo, *testutil.FakeClock) {
+	logger := zap.NewNop().Sugar()
+	userRepo := &testutil.MockUserRepo{
+		FindByIDFn: func(ctx context.Context, id string) (*model.User, error) {
+			for _, u := range users {
+				if u.ID.String() == id {
+					return u, nil
+				}
+			}
+			return nil, nil
+		},
+	}
+	clk := testutil.NewFakeClock(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+	jwtManager := NewJWTManager("test-signing-key-must-be-long-enough-for-jwt", "test-refresh-key-must-be-long-enough", 15*time.Minute, 7*24*time.Hour)
+	jwtManager.SetClock(clk)
+	store := &testutil.MockWebAuthnRepo{}
+	service := NewAuthService(userRepo, jwtManager, NewTokenStore(&testutil.MockTokenRepo{}, logger), logger)
+	service.SetWebAuthn(&webauthn.RelyingParty{ID: "example.com", Name: "Example", Origins: []string{passkeyOrigin}}, store, 5*time.Minute)
+	return service, store, clk
+}
+
+// registerPasskey runs a full registration ceremony for user with authenticator
+func registerPasskey(t *testing.T, service *AuthService, user *model.User, authenticator *testutil.FakeAuthenticator) {
+	t.Helper()
+	ctx := context.Background()
+	options, err := service.BeginPasskeyRegistration(ctx, user.ID.String())
+	if err != nil {
+		t.Fatalf("BeginPasskeyRegistration() error = %v", err)
+	}
+	challenge, _ := base64.RawURLEncoding.DecodeString(options.Challenge)
+	clientData, attestation := authenticator.Register(challenge)
+	_, err = service.FinishPasskeyRegistration(ctx, user.ID.String(), &dto.PasskeyRegistrationRequest{
+		Name: "Laptop",
+		ID:   base64.RawURLEncoding.EncodeToString(authenticator.CredentialID),
+		Type: "public-key",
+		Response: dto.PasskeyAttestationResponse{
+			ClientDataJSON:    base64.RawURLEncoding.EncodeToString(clientData),
+			AttestationObject: base64.RawURLEncoding.EncodeToString(attestation),
+		},
+	})
+	if err != nil {
+		t.Fatalf("FinishPasskeyRegistration() error = %v", err)
+	}
+}
+
+// passkeyAssertion begins a login and answers it with authenticator
+func passkeyAssertion(t *testing.T, service *AuthService, authenticator *testutil.FakeAuthenticator, userHandle []byte) *dto.PasskeyLoginRequest {
+	t.Helper()
+	options, err := service.BeginPasskeyLogin(context.Background())
+	if err != nil {
+		t.Fatalf("BeginPasskeyLogin() error = %v", err)
+	}
+	challenge, _ := base64.RawURLEncoding.DecodeString(options.Challenge)
+	clientData, authData, sig := authenticator.Assert(challenge)
+	return &dto.PasskeyLoginRequest{
+		ID:   base64.RawURLEncoding.EncodeToString(authenticator.CredentialID),
+		Type: "public-key",
+		Response: dto.PasskeyAssertionResponse{
+			ClientDataJSON:    base64.RawURLEncoding.EncodeToString(clientData),
+			AuthenticatorData: base64.RawURLEncoding.EncodeToString(authData),
+			Signature:         base64.RawURLEncoding.EncodeToString(sig),
+			UserHandle:        base64.RawURLEncoding.EncodeToString(userHandle),
+		},
+	}
+}
+
+func TestAuthService_Passkey_RegisterThenLogin(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	user := testutil.TestUser()
+	service, store, clk := newPasskeyAuthService(user)
+	authenticator := testutil.NewFakeAuthenticator(t, "example.com", passkeyOrigin)
+	registerPasskey(t, service, user, authenticator)
+	req := passkeyAssertion(t, service, authenticator, user.ID[:])
+
+	// Act
+	access, refresh, err := service.LoginWithPasskey(ctx, req)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("LoginWithPasskey() error = %v", err)
+	}
+	if access == "" || refresh == "" {
+		t.Error("LoginWithPasskey() returned empty tokens")
+	}
+	if len(store.Credentials) != 1 {
+		t.Fatalf("stored %d passkeys, want 1", len(store.Credentials))
+	}
+	stored := store.Credentials[0]
+	if stored.SignCount != 1 || stored.LastUsedAt == nil || !stored.LastUsedAt.Equal(clk.Now()) {
+		t.Errorf("passkey usage = count %d, last used %v", stored.SignCount, stored.LastUsedAt)
+	}
+	if len(store.Challenges) != 0 {
+		t.Errorf("%d challenges left, want all consumed", len(store.Challenges))
+	}
+}
+
+func TestAuthService_Passkey_RegisterExcludesExistingPasskeys(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	user := testutil.TestUser()
+	service, _, _ := newPasskeyAuthService(user)
+	registerPasskey(t, service, user, testutil.NewFakeAuthenticator(t, "example.com", passkeyOrigin))
+
+	// Act
+	options, err := service.BeginPasskeyRegistration(ctx, user.ID.String())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("BeginPasskeyRegistration() error = %v", err)
+	}
+	if len(options.ExcludeCredentials) != 1 {
+		t.Errorf("excludeCredentials = %v, want the registered passkey", options.ExcludeCredentials)
+	}
+	if options.User.ID != base64.RawURLEncoding.EncodeToString(user.ID[:]) || options.RP.ID != "example.com" {
+		t.Errorf("options = %+v", options)
+	}
+}
+
+func TestAuthService_LoginWithPasskey_Rejections(t *testing.T) {
+	cases := []struct {
+		name    string
+		prepare func(t *testing.T, service *AuthService, clk *testutil.FakeClock, a *testutil.FakeAuthenticator, user *model.User) *dto.PasskeyLoginRequest
+	}{
+		{"replayed challenge", func(t *testing.T, service *AuthService, clk *testutil.FakeClock, a *testutil.FakeAuthenticator, user *model.User) *dto.PasskeyLoginRequest {
+			req := passkeyAssertion(t, service, a, user.ID[:])
+			if _, _, err := service.LoginWithPasskey(context.Background(), req); err != nil {
+				t.Fatalf("first LoginWithPasskey() error = %v", err)
+			}
+			return req
+		}},
+		{"expired challenge", func(t *testing.T, service *AuthService, clk *testutil.FakeClock, a *testutil.FakeAuthenticator, user *model.User) *dto.PasskeyLoginRequest {
+			req := passkeyAssertion(t, service, a, user.ID[:])
+			clk.Advance(6 * time.Minute)
+			return req
+		}},
+		{"another user's handle", func(t *testing.T, service *AuthService, clk *testutil.FakeClock, a *testutil.FakeAuthenticator, user *model.User) *dto.PasskeyLoginRequest {
+			other := uuid.New()
+			return passkeyAssertion(t, service, a, other[:])
+		}},
+		{"sign count went backwards", func(t *testing.T, service *AuthService, clk *testutil.FakeClock, a *testutil.FakeAuthenticator, user *model.User) *dto.PasskeyLoginRequest {
+			if _, _, err := service.LoginWithPasskey(context.Background(), passkeyAssertion(t, service, a, user.ID[:])); err != nil {
+				t.Fatalf("first LoginWithPasskey() error = %v", err)
+			}
+			a.SignCount = 0
+			return passkeyAssertion(t, service, a, user.ID[:])
+		}},
+		{"unknown passkey", func(t *testing.T, service *AuthService, clk *testutil.FakeClock, a *testutil.FakeAuthenticator, user *model.User) *dto.PasskeyLoginRequest {
+			return passkeyAssertion(t, service, testutil.NewFakeAuthenticator(t, "example.com", passkeyOrigin), user.ID[:])
+		}},
+		{"registration challenge", func(t *testing.T, service *AuthService, clk *testutil.FakeClock, a *testutil.FakeAuthenticator, user *model.User) *dto.PasskeyLoginRequest {
+			options, err := service.BeginPasskeyRegistration(context.Background(), user.ID.String())
+			if err != nil {
+				t.Fatalf("BeginPasskeyRegistration() error = %v", err)
+			}
+			challenge, _ := base64.RawURLEncoding.DecodeString(options.Challenge)
+			clientData, authData, sig := a.Assert(challenge)
+			return &dto.PasskeyLoginRequest{
+				ID:   base64.RawURLEncoding.EncodeToString(a.CredentialID),
+				Type: "public-key",
+				Response: dto.PasskeyAssertionResponse{
+					ClientDataJSON:    base64.RawURLEncoding.EncodeToString(clientData),
+					AuthenticatorData: base64.RawURLEncoding.EncodeToString(authData),
+					Signature:         base64.RawURLEncoding.EncodeToString(sig),
+				},
+			}
+		}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			user := testutil.TestUser()
+			service, _, clk := newPasskeyAuthService(user)
+			authenticator := testutil.NewFakeAuthenticator(t, "example.com", passkeyOrigin)
+			registerPasskey(t, service, user, authenticator)
+			req := tc.prepare(t, service, clk, authenticator, user)
+
+			// Act
+			_, _, err := service.LoginWithPasskey(context.Background(), req)
+
+			// Assert
+			appErr, ok := apperrors.IsAppError(err)
+			if !ok || appErr.Type != apperrors.UnauthorizedError {
+				t.Errorf("LoginWithPasskey() error = %v, want unauthorized", err)
+			}
+		})
+	}
+}
+
+func TestAuthService_DeletePasskey_OnlyOwner(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	user := testutil.TestUser()
+	service, store, _ := newPasskeyAuthService(user)
+	registerPasskey(t, service, user, testutil.NewFakeAuthenticator(t, "example.com", passkeyOrigin))
+	passkeyID := store.Credentials[0].ID.String()
+
+	// Act
+	err := service.DeletePasskey(ctx, uuid.New().String(), passkeyID)
+
+	// Assert
+	appErr, ok := apperrors.IsAppError(err)
+	if !ok || appErr.Type != apperrors.NotFoundError {
+		t.Errorf("DeletePasskey() by another user error = %v, want not found", err)
+	}
+	if err := service.DeletePasskey(ctx, user.ID.String(), passkeyID); err != nil {
+		t.Fatalf("DeletePasskey() error = %v", err)
+	}
+	if len(store.Credentials) != 0 {
+		t.Error("passkey was not deleted")
+	}
+}
+
+func TestAuthService_Passkey_Disabled(t *testing.T) {
+	// Arrange
+	logger := zap.NewNop().Sugar()
+	jwtManager := NewJWTManager("test-signing-key-must-be-long-enough-for-jwt", "test-refresh-key-must-be-long-enough", 15*time.Minute, 7*24*time.Hour)
+	service := NewAuthService(&testutil.MockUserRepo{}, jwtManager, NewTokenStore(&testutil.MockTokenRepo{}, logger), logger)
+
+	// Act
+	_, err := service.BeginPasskeyLogin(context.Background())
+
+	// Assert
+	appErr, ok := apperrors.IsAppError(err)
+	if !ok || appErr.Type != apperrors.BadRequestError {
+		t.Errorf("BeginPasskeyLogin() error = %v, want bad request", err)
+	}
+}
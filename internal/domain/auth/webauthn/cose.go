@@ -0,0 +1,103 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// COSE algorithm identifiers (RFC 9053) accepted for passkeys
+const (
+	AlgES256 int64 = -7
+	AlgEdDSA int64 = -8
+	AlgRS256 int64 = -257
+)
+
+// SupportedAlgorithms lists the accepted algorithms in order of preference
+var SupportedAlgorithms = []int64{AlgES256, AlgEdDSA, AlgRS256}
+
+// COSE key parameters (RFC 9052 section 7 and RFC 9053)
+const (
+	coseKty    = 1
+	coseAlg    = 3
+	coseCrv    = -1 // EC2 and OKP curve
+	coseX      = -2 // EC2 and OKP x coordinate
+	coseY      = -3 // EC2 y coordinate
+	coseRSAN   = -1 // RSA modulus
+	coseRSAE   = -2 // RSA public exponent
+	ktyOKP     = 1
+	ktyEC2     = 2
+	ktyRSA     = 3
+	crvP256    = 1
+	crvEd25519 = 6
+)
+
+// parseCOSEKey converts a decoded COSE_Key into a public key and its algorithm
+func parseCOSEKey(raw interface{}) (crypto.PublicKey, int64, error) {
+	m, ok := raw.(map[interface{}]interface{})
+	if !ok {
+		return nil, 0, errors.New("credential public key is not a COSE key")
+	}
+	kty, _ := m[int64(coseKty)].(int64)
+	alg, _ := m[int64(coseAlg)].(int64)
+
+	switch {
+	case kty == ktyEC2 && alg == AlgES256:
+		crv, _ := m[int64(coseCrv)].(int64)
+		x, _ := m[int64(coseX)].([]byte)
+		y, _ := m[int64(coseY)].([]byte)
+		if crv != crvP256 || len(x) != 32 || len(y) != 32 {
+			return nil, 0, errors.New("ES256 key is not a P-256 point")
+		}
+		// Parsing the uncompressed point rejects coordinates that are not on the curve
+		if _, err := ecdh.P256().NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+			return nil, 0, errors.New("ES256 key is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, alg, nil
+
+	case kty == ktyOKP && alg == AlgEdDSA:
+		crv, _ := m[int64(coseCrv)].(int64)
+		x, _ := m[int64(coseX)].([]byte)
+		if crv != crvEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, 0, errors.New("EdDSA key is not an Ed25519 key")
+		}
+		return ed25519.PublicKey(x), alg, nil
+
+	case kty == ktyRSA && alg == AlgRS256:
+		n, _ := m[int64(coseRSAN)].([]byte)
+		e, _ := m[int64(coseRSAE)].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, 0, errors.New("RS256 key must have a modulus of at least 2048 bits")
+		}
+		exp := new(big.Int).SetBytes(e)
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}, alg, nil
+	}
+	return nil, 0, fmt.Errorf("unsupported credential key (kty %d, alg %d)", kty, alg)
+}
+
+// verifySignature checks sig over data with a key from parseCOSEKey
+func verifySignature(key crypto.PublicKey, alg int64, data, sig []byte) error {
+	digest := sha256.Sum256(data)
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		if alg == AlgES256 && ecdsa.VerifyASN1(k, digest[:], sig) {
+			return nil
+		}
+	case ed25519.PublicKey:
+		if alg == AlgEdDSA && ed25519.Verify(k, data, sig) {
+			return nil
+		}
+	case *rsa.PublicKey:
+		if alg == AlgRS256 && rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig) == nil {
+			return nil
+		}
+	}
+	return errors.New("signature does not match the credential")
+}
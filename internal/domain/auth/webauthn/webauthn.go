@@ -0,0 +1,206 @@
+// Package webauthn verifies the browser side of passkey registration and login
+// (WebAuthn Level 2). Attestation statements are not checked, which matches requesting
+// attestation "none": the service trusts the key it is given, not the device make.
+package webauthn
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Authenticator data flags (WebAuthn section 6.1)
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttestedData = 0x40
+)
+
+// RelyingParty checks ceremonies for one site. Passkeys are bound to ID, a domain, and
+// are only accepted from the listed web origins.
+type RelyingParty struct {
+	ID      string
+	Name    string
+	Origins []string
+}
+
+// ClientData is the browser-produced JSON an authenticator signs over
+type ClientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// ParseClientData decodes clientDataJSON, e.g. to find the challenge before verifying
+func ParseClientData(raw []byte) (*ClientData, error) {
+	var cd ClientData
+	if err := json.Unmarshal(raw, &cd); err != nil {
+		return nil, fmt.Errorf("client data is not JSON: %w", err)
+	}
+	return &cd, nil
+}
+
+// ChallengeBytes decodes the challenge the browser signed
+func (cd *ClientData) ChallengeBytes() ([]byte, error) {
+	return DecodeBase64URL(cd.Challenge)
+}
+
+// Credential is a newly registered passkey
+type Credential struct {
+	ID []byte
+	// PublicKey is the credential key in PKIX (DER) form
+	PublicKey []byte
+	Algorithm int64
+	SignCount uint32
+}
+
+// VerifyRegistration checks the response to a navigator.credentials.create() call made
+// with challenge and returns the new credential
+func (rp *RelyingParty) VerifyRegistration(clientDataJSON, attestationObject, challenge []byte) (*Credential, error) {
+	if err := rp.checkClientData(clientDataJSON, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+
+	decoded, _, err := decodeCBOR(attestationObject)
+	if err != nil {
+		return nil, fmt.Errorf("attestation object: %w", err)
+	}
+	attestation, ok := decoded.(map[interface{}]interface{})
+	if !ok {
+		return nil, errors.New("attestation object is not a map")
+	}
+	rawAuthData, ok := attestation["authData"].([]byte)
+	if !ok {
+		return nil, errors.New("attestation object has no authData")
+	}
+
+	authData, err := rp.checkAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if authData.credentialID == nil {
+		return nil, errors.New("authenticator data has no attested credential")
+	}
+	key, alg, err := parseCOSEKey(authData.publicKey)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Credential{ID: authData.credentialID, PublicKey: der, Algorithm: alg, SignCount: authData.signCount}, nil
+}
+
+// VerifyAssertion checks the response to a navigator.credentials.get() call made with
+// challenge against a stored credential key and returns the authenticator's sign count
+func (rp *RelyingParty) VerifyAssertion(publicKey []byte, alg int64, clientDataJSON, authenticatorData, signature, challenge []byte) (uint32, error) {
+	if err := rp.checkClientData(clientDataJSON, "webauthn.get", challenge); err != nil {
+		return 0, err
+	}
+	authData, err := rp.checkAuthenticatorData(authenticatorData)
+	if err != nil {
+		return 0, err
+	}
+
+	key, err := x509.ParsePKIXPublicKey(publicKey)
+	if err != nil {
+		return 0, fmt.Errorf("stored credential key: %w", err)
+	}
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := append(slices.Clip(authenticatorData), clientDataHash[:]...)
+	if err := verifySignature(key, alg, signed, signature); err != nil {
+		return 0, err
+	}
+	return authData.signCount, nil
+}
+
+func (rp *RelyingParty) checkClientData(raw []byte, ceremony string, challenge []byte) error {
+	cd, err := ParseClientData(raw)
+	if err != nil {
+		return err
+	}
+	if cd.Type != ceremony {
+		return fmt.Errorf("client data type is %q, want %q", cd.Type, ceremony)
+	}
+	got, err := cd.ChallengeBytes()
+	if err != nil || subtle.ConstantTimeCompare(got, challenge) != 1 {
+		return errors.New("client data challenge does not match")
+	}
+	if !slices.Contains(rp.Origins, cd.Origin) {
+		return fmt.Errorf("origin %q is not allowed", cd.Origin)
+	}
+	return nil
+}
+
+// authenticatorData is the parsed binary structure from WebAuthn section 6.1
+type authenticatorData struct {
+	rpIDHash     []byte
+	flags        byte
+	signCount    uint32
+	credentialID []byte
+	publicKey    interface{}
+}
+
+func (rp *RelyingParty) checkAuthenticatorData(data []byte) (*authenticatorData, error) {
+	ad, err := parseAuthenticatorData(data)
+	if err != nil {
+		return nil, err
+	}
+	want := sha256.Sum256([]byte(rp.ID))
+	if !bytes.Equal(ad.rpIDHash, want[:]) {
+		return nil, errors.New("credential is scoped to another relying party")
+	}
+	// Passkeys replace the password, so the device must also verify the user (PIN,
+	// biometrics), not just register a tap
+	if ad.flags&flagUserPresent == 0 || ad.flags&flagUserVerified == 0 {
+		return nil, errors.New("user presence and verification are required")
+	}
+	return ad, nil
+}
+
+func parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	if len(data) < 37 {
+		return nil, errors.New("authenticator data is too short")
+	}
+	ad := &authenticatorData{
+		rpIDHash:  data[:32],
+		flags:     data[32],
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	if ad.flags&flagAttestedData == 0 {
+		return ad, nil
+	}
+
+	// AAGUID (16 bytes), credential ID length (2 bytes), credential ID, COSE key
+	rest := data[37:]
+	if len(rest) < 18 {
+		return nil, errors.New("attested credential data is too short")
+	}
+	idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if idLen == 0 || idLen > 1023 || len(rest) < idLen {
+		return nil, errors.New("invalid credential ID length")
+	}
+	ad.credentialID = rest[:idLen]
+	key, _, err := decodeCBOR(rest[idLen:])
+	if err != nil {
+		return nil, fmt.Errorf("credential public key: %w", err)
+	}
+	ad.publicKey = key
+	return ad, nil
+}
+
+// DecodeBase64URL decodes the unpadded base64url used for binary WebAuthn fields,
+// tolerating padding some clients add
+func DecodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
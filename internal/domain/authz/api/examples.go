@@ -0,0 +1,18 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/authz/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/authz/model"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/examples"
+)
+
+// Examples are the bodies of the role routes, served under /docs/examples
+var Examples = []examples.Route{
+	{Method: http.MethodGet, Path: "/roles/", Response: []model.Role{}},
+	{Method: http.MethodGet, Path: "/roles/{name}", Response: model.Role{}},
+	{Method: http.MethodPost, Path: "/roles/", Request: dto.RoleCreateRequest{}, Response: model.Role{}},
+	{Method: http.MethodPut, Path: "/roles/{name}", Request: dto.RoleUpdateRequest{}, Response: model.Role{}},
+	{Method: http.MethodGet, Path: "/permissions", Response: []model.Permission{}},
+}
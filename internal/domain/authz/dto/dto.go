@@ -0,0 +1,39 @@
+package dto
+
+// RoleCreateRequest represents the payload for creating a role
+// swagger:model
+type RoleCreateRequest struct {
+	// Name users are assigned through user_type
+	// Required: true
+	// Example: support
+	Name string `json:"name" validate:"required,max=20" example:"support"`
+
+	// Description of who the role is for
+	// Example: Customer support staff
+	Description string `json:"description" validate:"omitempty,max=255" example:"Customer support staff"`
+
+	// Permissions granted by the role
+	// Example: ["users:list","users:history"]
+	Permissions []string `json:"permissions" validate:"omitempty,dive,required,max=64" example:"users:list,users:history"`
+
+	// Inherits names a role whose permissions and role checks this role includes
+	// Example: user
+	Inherits string `json:"inherits" validate:"omitempty,max=20" example:"user"`
+}
+
+// RoleUpdateRequest represents the payload for changing a role. Omitted fields are left
+// unchanged; an empty permissions list revokes every permission.
+// swagger:model
+type RoleUpdateRequest struct {
+	// Description of who the role is for
+	// Example: Customer support staff
+	Description *string `json:"description" validate:"omitempty,max=255" example:"Customer support staff"`
+
+	// Permissions granted by the role, replacing the current set
+	// Example: ["users:list"]
+	Permissions *[]string `json:"permissions" validate:"omitempty,dive,required,max=64" example:"users:list"`
+
+	// Inherits replaces the inherited role; an empty string stops inheriting
+	// Example: user
+	Inherits *string `json:"inherits" validate:"omitempty,max=20" example:"user"`
+}
@@ -0,0 +1,167 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/shared/id"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Permission keys are written "<resource>:<action>"
+const (
+	PermUsersList            = "users:list"
+	PermUsersCreate          = "users:create"
+	PermUsersDelete          = "users:delete"
+	PermUsersHistory         = "users:history"
+	PermUsersUnlock          = "users:unlock"
+	PermUsersReview          = "users:review"
+	PermUsersStatus          = "users:status"
+	PermUsersMetrics         = "users:metrics"
+	PermUsersMerge           = "users:merge"
+	PermUsersBatch           = "users:batch"
+	PermUsersTags            = "users:tags"
+	PermUsersNotes           = "users:notes"
+	PermProfileFieldsManage  = "profile_fields:manage"
+	PermRolesManage          = "roles:manage"
+	PermJobsManage           = "jobs:manage"
+	PermConfigManage         = "config:manage"
+	PermDeprecationsView     = "deprecations:view"
+	PermSystemView           = "system:view"
+	PermSigningKeysManage    = "signing_keys:manage"
+	PermIPBansManage         = "ip_bans:manage"
+	PermAnnouncementsManage  = "announcements:manage"
+	PermTokensManage         = "tokens:manage"
+	PermUsersImpersonate     = "users:impersonate"
+	PermServiceClientsManage = "service_clients:manage"
+)
+
+// Built-in roles. They match the user types users are created with and cannot be deleted.
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+// DefaultPermissions are seeded on startup. Add an entry here when a route needs a new
+// permission; the admin role picks it up on the next start.
+var DefaultPermissions = []Permission{
+	{Key: PermUsersList, Description: "List and search all users"},
+	{Key: PermUsersCreate, Description: "Create users with any role"},
+	{Key: PermUsersDelete, Description: "Delete any user"},
+	{Key: PermUsersHistory, Description: "View the change history of any user"},
+	{Key: PermUsersUnlock, Description: "Unlock accounts locked after failed logins"},
+	{Key: PermUsersReview, Description: "Find and clear accounts flagged by abuse detection"},
+	{Key: PermUsersStatus, Description: "Suspend, deactivate and reactivate accounts"},
+	{Key: PermUsersMetrics, Description: "View registration and account metrics"},
+	{Key: PermUsersMerge, Description: "Merge duplicate accounts into one"},
+	{Key: PermUsersBatch, Description: "Delete many users or change their role or status in one request"},
+	{Key: PermUsersTags, Description: "Tag users and list the tags in use"},
+	{Key: PermUsersNotes, Description: "Read and write admin notes on user accounts"},
+	{Key: PermProfileFieldsManage, Description: "Create, change and remove custom profile fields"},
+	{Key: PermRolesManage, Description: "Create, change and remove roles"},
+	{Key: PermJobsManage, Description: "View and trigger background jobs"},
+	{Key: PermConfigManage, Description: "Export and import settings snapshots"},
+	{Key: PermDeprecationsView, Description: "See which clients still call deprecated endpoints"},
+	{Key: PermSystemView, Description: "See which subsystems are running"},
+	{Key: PermSigningKeysManage, Description: "List and reload the keys that sign access tokens"},
+	{Key: PermIPBansManage, Description: "List and lift bans of client IPs"},
+	{Key: PermAnnouncementsManage, Description: "Send email announcements to groups of users and follow their delivery"},
+	{Key: PermTokensManage, Description: "List active refresh tokens and revoke them"},
+	{Key: PermUsersImpersonate, Description: "Get a short-lived token acting as another user"},
+	{Key: PermServiceClientsManage, Description: "Register and remove service clients of the client credentials grant"},
+}
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,19}$`)
+
+// Permission is an action that can be granted to roles
+// swagger:model
+type Permission struct {
+	// readOnly: true
+	ID uuid.UUID `gorm:"type:uuid;primaryKey" json:"-"`
+
+	// Key checked by the permission middleware
+	// example: users:delete
+	Key string `gorm:"type:varchar(64);uniqueIndex;not null" json:"key" example:"users:delete"`
+
+	// example: Delete any user
+	Description string `gorm:"type:varchar(255)" json:"description" example:"Delete any user"`
+}
+
+// BeforeCreate hook to generate UUID before inserting
+func (p *Permission) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = id.New()
+	}
+	return nil
+}
+
+// TableName sets the insert table name for this struct type
+func (Permission) TableName() string {
+	return "permissions"
+}
+
+// Role is a named set of permissions. Users reference roles by name through user_type.
+// swagger:model
+type Role struct {
+	// readOnly: true
+	ID uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+
+	// Name stored in users.user_type
+	// example: support
+	Name string `gorm:"type:varchar(20);uniqueIndex;not null" json:"name" example:"support"`
+
+	// example: Customer support staff
+	Description string `gorm:"type:varchar(255)" json:"description" example:"Customer support staff"`
+
+	// Built-in roles cannot be deleted
+	// readOnly: true
+	System bool `gorm:"default:false" json:"system"`
+
+	// Inherits names the role this role includes: its users pass RequireRole for it and
+	// get its permissions, and those of the roles it inherits in turn
+	// example: user
+	Inherits string `gorm:"type:varchar(20)" json:"inherits,omitempty" example:"user"`
+
+	Permissions []Permission `gorm:"many2many:role_permissions" json:"permissions"`
+
+	// format: date-time
+	// readOnly: true
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	// format: date-time
+	// readOnly: true
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// BeforeCreate hook to generate UUID before inserting
+func (r *Role) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = id.New()
+	}
+	return nil
+}
+
+// TableName sets the insert table name for this struct type
+func (Role) TableName() string {
+	return "roles"
+}
+
+// ValidateRoleName checks that name fits in users.user_type and is safe to use in URLs
+func ValidateRoleName(name string) error {
+	if !roleNamePattern.MatchString(name) {
+		return fmt.Errorf("role name %q must start with a letter and contain at most 20 lowercase letters, digits, '_' or '-'", name)
+	}
+	return nil
+}
+
+// PermissionKeys returns the keys of the role's permissions
+func (r *Role) PermissionKeys() []string {
+	keys := make([]string, len(r.Permissions))
+	for i, p := range r.Permissions {
+		keys[i] = p.Key
+	}
+	return keys
+}
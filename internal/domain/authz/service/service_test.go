@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/authz/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/authz/model"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/cache"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+)
+
+// rolesRepo returns a mock repo holding roles in memory, keyed by name
+func rolesRepo(roles ...model.Role) (*testutil.MockRoleRepo, map[string]*model.Role) {
+	byName := make(map[string]*model.Role, len(roles))
+	for i := range roles {
+		byName[roles[i].Name] = &roles[i]
+	}
+	m := &testutil.MockRoleRepo{
+		FindByNameFn: func(ctx context.Context, name string) (*model.Role, error) {
+			if role, ok := byName[name]; ok {
+				copied := *role
+				copied.Permissions = slices.Clone(role.Permissions)
+				return &copied, nil
+			}
+			return nil, nil
+		},
+		UpdateFn: func(ctx context.Context, role *model.Role) error {
+			byName[role.Name] = role
+			return nil
+		},
+		FindPermissionsFn: func(ctx context.Context, keys []string) ([]model.Permission, error) {
+			var found []model.Permission
+			for _, p := range model.DefaultPermissions {
+				if slices.Contains(keys, p.Key) {
+					found = append(found, p)
+				}
+			}
+			return found, nil
+		},
+	}
+	return m, byName
+}
+
+func TestAuthzService_Can(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockRepo, _ := rolesRepo(model.Role{
+		Name:        "support",
+		Permissions: []model.Permission{{Key: model.PermUsersList}},
+	})
+	service := NewService(mockRepo, zap.NewNop().Sugar())
+
+	cases := []struct {
+		role, permission string
+		want             bool
+	}{
+		{"support", model.PermUsersList, true},
+		{"support", model.PermUsersDelete, false},
+		{"admin", model.PermRolesManage, true},
+		{"unknown", model.PermUsersList, false},
+		{"", model.PermUsersList, false},
+	}
+
+	for _, tc := range cases {
+		// Act
+		got, err := service.Can(ctx, tc.role, tc.permission)
+
+		// Assert
+		if err != nil {
+			t.Fatalf("Can(%q, %q) error = %v", tc.role, tc.permission, err)
+		}
+		if got != tc.want {
+			t.Errorf("Can(%q, %q) = %v, want %v", tc.role, tc.permission, got, tc.want)
+		}
+	}
+}
+
+func TestAuthzService_Hierarchy(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockRepo, _ := rolesRepo(
+		model.Role{Name: "moderator", Inherits: "user", Permissions: []model.Permission{{Key: model.PermUsersReview}}},
+		model.Role{Name: "user", Permissions: []model.Permission{{Key: model.PermUsersHistory}}},
+		model.Role{Name: "support"},
+	)
+	// "lead" only exists in configuration
+	service := NewService(mockRepo, zap.NewNop().Sugar(), WithHierarchy(map[string][]string{"lead": {"moderator", "support"}}))
+
+	cases := []struct {
+		role, required string
+		want           bool
+	}{
+		{"moderator", "user", true},
+		{"lead", "user", true},
+		{"lead", "support", true},
+		{"user", "moderator", false},
+		{"support", "user", false},
+	}
+
+	for _, tc := range cases {
+		// Act
+		got, err := service.HasRole(ctx, tc.role, tc.required)
+
+		// Assert
+		if err != nil || got != tc.want {
+			t.Errorf("HasRole(%q, %q) = %v, %v, want %v", tc.role, tc.required, got, err, tc.want)
+		}
+	}
+	if ok, _ := service.Can(ctx, "lead", model.PermUsersHistory); !ok {
+		t.Error("lead cannot view history granted to user two levels down")
+	}
+	if ok, _ := service.Can(ctx, "user", model.PermUsersReview); ok {
+		t.Error("user got a permission of the moderator role it is inherited by")
+	}
+	if roles, _ := service.EffectiveRoles(ctx, "lead"); !slices.Equal(roles, []string{"lead", "moderator", "support", "user"}) {
+		t.Errorf("EffectiveRoles(lead) = %v", roles)
+	}
+}
+
+func TestAuthzService_UpdateRole_RejectsInheritanceCycle(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockRepo, _ := rolesRepo(model.Role{Name: "moderator", Inherits: "user"}, model.Role{Name: "user"})
+	service := NewService(mockRepo, zap.NewNop().Sugar())
+	moderator := "moderator"
+
+	// Act
+	_, err := service.UpdateRole(ctx, "user", &dto.RoleUpdateRequest{Inherits: &moderator})
+
+	// Assert
+	if appErr, ok := apperrors.IsAppError(err); !ok || appErr.Type != apperrors.ValidationError {
+		t.Errorf("UpdateRole() error = %v, want validation error", err)
+	}
+}
+
+func TestAuthzService_UpdateRole_InvalidatesCachedPermissions(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockRepo, _ := rolesRepo(model.Role{Name: "support"})
+	lookups := 0
+	find := mockRepo.FindByNameFn
+	mockRepo.FindByNameFn = func(ctx context.Context, name string) (*model.Role, error) {
+		lookups++
+		return find(ctx, name)
+	}
+	service := NewService(mockRepo, zap.NewNop().Sugar(), WithCache(cache.NewMemory(), time.Minute))
+
+	if ok, _ := service.Can(ctx, "support", model.PermUsersList); ok {
+		t.Fatal("support can list users before the grant")
+	}
+	_, _ = service.Can(ctx, "support", model.PermUsersList)
+	if lookups != 1 {
+		t.Fatalf("role looked up %d times, want 1 (cached)", lookups)
+	}
+
+	// Act
+	permissions := []string{model.PermUsersList}
+	if _, err := service.UpdateRole(ctx, "support", &dto.RoleUpdateRequest{Permissions: &permissions}); err != nil {
+		t.Fatalf("UpdateRole() error = %v", err)
+	}
+	ok, err := service.Can(ctx, "support", model.PermUsersList)
+
+	// Assert
+	if err != nil || !ok {
+		t.Errorf("Can() after grant = %v, %v, want true", ok, err)
+	}
+}
+
+func TestAuthzService_CreateRole_RejectsUnknownPermission(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockRepo, _ := rolesRepo()
+	created := false
+	mockRepo.CreateFn = func(ctx context.Context, role *model.Role) error {
+		created = true
+		return nil
+	}
+	service := NewService(mockRepo, zap.NewNop().Sugar())
+
+	// Act
+	_, err := service.CreateRole(ctx, &dto.RoleCreateRequest{
+		Name:        "support",
+		Permissions: []string{model.PermUsersList, "users:teleport"},
+	})
+
+	// Assert
+	appErr, ok := apperrors.IsAppError(err)
+	if !ok || appErr.Type != apperrors.ValidationError {
+		t.Fatalf("CreateRole() error = %v, want validation error", err)
+	}
+	if created {
+		t.Error("role was stored despite an unknown permission")
+	}
+}
+
+func TestAuthzService_UpdateRole_AdminKeepsAllPermissions(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockRepo, _ := rolesRepo(model.Role{Name: model.RoleAdmin, System: true, Permissions: model.DefaultPermissions})
+	service := NewService(mockRepo, zap.NewNop().Sugar())
+	none := []string{}
+
+	// Act
+	_, err := service.UpdateRole(ctx, model.RoleAdmin, &dto.RoleUpdateRequest{Permissions: &none})
+
+	// Assert
+	if appErr, ok := apperrors.IsAppError(err); !ok || appErr.Type != apperrors.ValidationError {
+		t.Errorf("UpdateRole(admin) error = %v, want validation error", err)
+	}
+}
+
+func TestAuthzService_DeleteRole(t *testing.T) {
+	cases := []struct {
+		name  string
+		role  model.Role
+		users int64
+		want  apperrors.ErrorType
+	}{
+		{"built-in role", model.Role{Name: model.RoleUser, System: true}, 0, apperrors.ForbiddenError},
+		{"role in use", model.Role{Name: "support"}, 3, apperrors.ConflictError},
+		{"unused role", model.Role{Name: "support"}, 0, ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			mockRepo, _ := rolesRepo(tc.role)
+			deleted := false
+			mockRepo.CountUsersFn = func(ctx context.Context, name string) (int64, error) {
+				return tc.users, nil
+			}
+			mockRepo.DeleteFn = func(ctx context.Context, name string) error {
+				deleted = true
+				return nil
+			}
+			service := NewService(mockRepo, zap.NewNop().Sugar())
+
+			// Act
+			err := service.DeleteRole(ctx, tc.role.Name)
+
+			// Assert
+			if tc.want == "" {
+				if err != nil || !deleted {
+					t.Errorf("DeleteRole() error = %v, deleted = %v", err, deleted)
+				}
+				return
+			}
+			if appErr, ok := apperrors.IsAppError(err); !ok || appErr.Type != tc.want {
+				t.Errorf("DeleteRole() error = %v, want %s", err, tc.want)
+			}
+			if deleted {
+				t.Error("role was deleted")
+			}
+		})
+	}
+}
+
+func TestAuthzService_SeedDefaults(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	custom := model.Role{Name: model.RoleUser, System: true, Permissions: []model.Permission{{Key: model.PermUsersList}}}
+	mockRepo, byName := rolesRepo(custom)
+	var upserted []model.Permission
+	mockRepo.UpsertPermissionsFn = func(ctx context.Context, permissions []model.Permission) error {
+		upserted = permissions
+		return nil
+	}
+	mockRepo.ListPermissionsFn = func(ctx context.Context) ([]model.Permission, error) {
+		return upserted, nil
+	}
+	mockRepo.CreateFn = func(ctx context.Context, role *model.Role) error {
+		byName[role.Name] = role
+		return nil
+	}
+	service := NewService(mockRepo, zap.NewNop().Sugar())
+
+	// Act
+	err := service.SeedDefaults(ctx)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("SeedDefaults() error = %v", err)
+	}
+	admin := byName[model.RoleAdmin]
+	if admin == nil || !admin.System || len(admin.Permissions) != len(model.DefaultPermissions) {
+		t.Errorf("admin role = %+v, want a system role with every permission", admin)
+	}
+	if got := byName[model.RoleUser].PermissionKeys(); !slices.Equal(got, []string{model.PermUsersList}) {
+		t.Errorf("user role permissions = %v, want the existing grant kept", got)
+	}
+}
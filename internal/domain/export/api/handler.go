@@ -0,0 +1,112 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/export/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/export/service"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type ExportHandler struct {
+	service service.ExportService
+	logger  *zap.SugaredLogger
+}
+
+func NewExportHandler(s service.ExportService, logger *zap.SugaredLogger) *ExportHandler {
+	return &ExportHandler{
+		service: s,
+		logger:  logger,
+	}
+}
+
+// Create godoc
+// @Summary Start an export
+// @Description Queues an export that is produced in the background. Poll the URL in the Location header until its status is completed, then download the file from download_url. Some kinds need a permission, e.g. users needs users:list.
+// @Tags Exports
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param export body dto.CreateExportRequest true "Kind of export and its params"
+// @Success 202 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 429 {object} response.ErrorResponse "Too many unfinished exports"
+// @Failure 500 {object} response.ErrorResponse
+// @Router /exports/ [post]
+func (h *ExportHandler) Create(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+	userID, err := uuid.Parse(c.GetString("userID"))
+	if err != nil {
+		_ = c.Error(apperrors.NewAppError(apperrors.UnauthorizedError, "User authentication required"))
+		return
+	}
+
+	var req dto.CreateExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.NewAppErrorWithDetails(
+			apperrors.ValidationError,
+			"Invalid request payload",
+			err.Error(),
+		))
+		return
+	}
+
+	export, err := h.service.Create(c.Request.Context(), userID, c.GetString("role"), req)
+	if err != nil {
+		if appErr, ok := apperrors.IsAppError(err); ok {
+			_ = c.Error(appErr)
+			return
+		}
+		h.logger.Errorw("failed to create export", "kind", req.Kind, "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to create export"))
+		return
+	}
+
+	h.logger.Infow("export queued", "export_id", export.ID, "kind", export.Kind, "user_id", userID, "request_id", requestID)
+	c.Header("Location", strings.TrimSuffix(c.Request.URL.Path, "/")+"/"+export.ID.String())
+	c.JSON(http.StatusAccepted, response.NewSuccessResponse(dto.ExportResponse{Export: *export}, requestID))
+}
+
+// Get godoc
+// @Summary Get an export's status
+// @Description Returns one of your exports. Once completed it carries a signed download_url that works without authentication for a short time; request the export again for a new one until the file expires.
+// @Tags Exports
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Export ID"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /exports/{id} [get]
+func (h *ExportHandler) Get(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+	userID, err := uuid.Parse(c.GetString("userID"))
+	if err != nil {
+		_ = c.Error(apperrors.NewAppError(apperrors.UnauthorizedError, "User authentication required"))
+		return
+	}
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		_ = c.Error(apperrors.NewAppError(apperrors.NotFoundError, "Export not found"))
+		return
+	}
+
+	export, err := h.service.Get(c.Request.Context(), userID, id)
+	if err != nil {
+		if appErr, ok := apperrors.IsAppError(err); ok {
+			_ = c.Error(appErr)
+			return
+		}
+		h.logger.Errorw("failed to get export", "export_id", id, "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to fetch export"))
+		return
+	}
+	c.JSON(http.StatusOK, response.NewSuccessResponse(export, requestID))
+}
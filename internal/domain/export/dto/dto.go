@@ -0,0 +1,27 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/export/model"
+)
+
+// CreateExportRequest asks for an export to be produced in the background
+// swagger:model CreateExportRequest
+type CreateExportRequest struct {
+	// Kind of export
+	// example: users
+	Kind string `json:"kind" binding:"required" example:"users"`
+	// Params are the kind's options; users takes the filters and sort of GET /users/export
+	Params map[string]string `json:"params,omitempty"`
+}
+
+// ExportResponse is an export with, once it has completed, a URL to download its file
+// swagger:model ExportResponse
+type ExportResponse struct {
+	model.Export
+	// DownloadURL is a signed URL that works without authentication until DownloadURLExpiresAt;
+	// poll the export again for a fresh one
+	DownloadURL          string     `json:"download_url,omitempty"`
+	DownloadURLExpiresAt *time.Time `json:"download_url_expires_at,omitempty"`
+}
@@ -0,0 +1,91 @@
+package model
+
+import (
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/shared/id"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Status is where an export is in its lifecycle
+type Status string
+
+const (
+	// StatusPending exports wait for a worker
+	StatusPending Status = "pending"
+	// StatusRunning exports are being written by a worker
+	StatusRunning Status = "running"
+	// StatusCompleted exports can be downloaded until they expire
+	StatusCompleted Status = "completed"
+	// StatusFailed exports stopped with an error and have no file
+	StatusFailed Status = "failed"
+)
+
+// Export is a file produced in the background for the user who requested it
+// swagger:model Export
+type Export struct {
+	// ID is the unique identifier for the export
+	// example: 123e4567-e89b-12d3-a456-426614174000
+	// format: uuid
+	ID uuid.UUID `gorm:"type:uuid;primaryKey" json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
+
+	// UserID is the user who requested the export; only they can see and download it
+	// format: uuid
+	UserID uuid.UUID `gorm:"type:uuid;not null;index:idx_exports_user_status,priority:1" json:"user_id"`
+
+	// Kind selects what is exported
+	// example: users
+	Kind string `gorm:"type:varchar(50);not null" json:"kind" example:"users"`
+
+	// Params are the kind's options, such as filters
+	Params map[string]string `gorm:"type:text;serializer:json" json:"params,omitempty"`
+
+	// Status is pending, running, completed or failed
+	// example: completed
+	Status Status `gorm:"type:varchar(20);not null;index:idx_exports_user_status,priority:2;index:idx_exports_status" json:"status" example:"completed"`
+
+	// ObjectName is where the file is stored
+	ObjectName string `gorm:"type:varchar(1024)" json:"-"`
+
+	// ContentType of the file
+	// example: text/csv
+	ContentType string `gorm:"type:varchar(255)" json:"content_type,omitempty" example:"text/csv"`
+
+	// Size of the file in bytes
+	// example: 52480
+	Size int64 `gorm:"not null;default:0" json:"size" example:"52480"`
+
+	// Rows is the number of records written
+	// example: 1200
+	Rows int64 `gorm:"not null;default:0" json:"rows" example:"1200"`
+
+	// Error says why a failed export stopped
+	Error string `gorm:"type:varchar(512)" json:"error,omitempty"`
+
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+
+	// ExpiresAt is when the file of a finished export is deleted
+	ExpiresAt *time.Time `gorm:"index" json:"expires_at,omitempty"`
+}
+
+// BeforeCreate is a GORM hook that generates a UUID for the export if not already set
+func (e *Export) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == uuid.Nil {
+		e.ID = id.New()
+	}
+	return
+}
+
+// TableName specifies the table name for the Export model
+func (Export) TableName() string {
+	return "exports"
+}
+
+// Finished reports whether the export stopped, successfully or not
+func (e *Export) Finished() bool {
+	return e.Status == StatusCompleted || e.Status == StatusFailed
+}
@@ -0,0 +1,113 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/export/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ExportRepo interface {
+	Create(ctx context.Context, export *model.Export) error
+	// FindByID returns nil, nil when the export does not exist
+	FindByID(ctx context.Context, id uuid.UUID) (*model.Export, error)
+	// CountUnfinished counts the user's pending and running exports
+	CountUnfinished(ctx context.Context, userID uuid.UUID) (int64, error)
+	// Claim moves a pending export to running and reports whether this caller got it, so
+	// only one worker across instances produces each export
+	Claim(ctx context.Context, id uuid.UUID, startedAt time.Time) (bool, error)
+	// Finish stores the outcome of a running export
+	Finish(ctx context.Context, export *model.Export) error
+	// ListPending returns the oldest pending exports
+	ListPending(ctx context.Context, limit int) ([]model.Export, error)
+	// FailStale marks exports running since before startedBefore as failed, kept until expiresAt
+	FailStale(ctx context.Context, startedBefore, now, expiresAt time.Time, reason string) (int64, error)
+	// ListExpired returns finished exports whose retention ended at or before now
+	ListExpired(ctx context.Context, now time.Time, limit int) ([]model.Export, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type exportRepo struct {
+	db *gorm.DB
+}
+
+func NewExportRepo(db *gorm.DB) ExportRepo {
+	return &exportRepo{db: db}
+}
+
+func (r *exportRepo) Create(ctx context.Context, export *model.Export) error {
+	return r.db.WithContext(ctx).Create(export).Error
+}
+
+func (r *exportRepo) FindByID(ctx context.Context, id uuid.UUID) (*model.Export, error) {
+	var export model.Export
+	err := r.db.WithContext(ctx).First(&export, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *exportRepo) CountUnfinished(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.Export{}).
+		Where("user_id = ? AND status IN ?", userID, []model.Status{model.StatusPending, model.StatusRunning}).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *exportRepo) Claim(ctx context.Context, id uuid.UUID, startedAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.Export{}).
+		Where("id = ? AND status = ?", id, model.StatusPending).
+		Updates(map[string]interface{}{"status": model.StatusRunning, "started_at": startedAt})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *exportRepo) Finish(ctx context.Context, export *model.Export) error {
+	return r.db.WithContext(ctx).Model(export).
+		Select("status", "object_name", "content_type", "size", "rows", "error", "completed_at", "expires_at").
+		Updates(export).Error
+}
+
+func (r *exportRepo) ListPending(ctx context.Context, limit int) ([]model.Export, error) {
+	var exports []model.Export
+	err := r.db.WithContext(ctx).
+		Where("status = ?", model.StatusPending).
+		Order("created_at").
+		Limit(limit).
+		Find(&exports).Error
+	return exports, err
+}
+
+func (r *exportRepo) FailStale(ctx context.Context, startedBefore, now, expiresAt time.Time, reason string) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&model.Export{}).
+		Where("status = ? AND started_at < ?", model.StatusRunning, startedBefore).
+		Updates(map[string]interface{}{
+			"status":       model.StatusFailed,
+			"error":        reason,
+			"completed_at": now,
+			"expires_at":   expiresAt,
+		})
+	return result.RowsAffected, result.Error
+}
+
+func (r *exportRepo) ListExpired(ctx context.Context, now time.Time, limit int) ([]model.Export, error) {
+	var exports []model.Export
+	err := r.db.WithContext(ctx).
+		Where("status IN ? AND expires_at <= ?", []model.Status{model.StatusCompleted, model.StatusFailed}, now).
+		Order("expires_at").
+		Limit(limit).
+		Find(&exports).Error
+	return exports, err
+}
+
+func (r *exportRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&model.Export{}, "id = ?", id).Error
+}
@@ -0,0 +1,350 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/export/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/export/model"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var testConfig = config.ExportConfig{
+	Workers:    1,
+	Timeout:    time.Minute,
+	Retention:  time.Hour,
+	URLExpiry:  15 * time.Minute,
+	MaxPending: 2,
+}
+
+// exportStore is an in-memory ExportRepo
+type exportStore struct {
+	mu      sync.Mutex
+	exports map[uuid.UUID]model.Export
+}
+
+func (s *exportStore) Create(ctx context.Context, export *model.Export) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.exports == nil {
+		s.exports = map[uuid.UUID]model.Export{}
+	}
+	export.ID = uuid.New()
+	s.exports[export.ID] = *export
+	return nil
+}
+
+func (s *exportStore) FindByID(ctx context.Context, id uuid.UUID) (*model.Export, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	export, ok := s.exports[id]
+	if !ok {
+		return nil, nil
+	}
+	return &export, nil
+}
+
+func (s *exportStore) CountUnfinished(ctx context.Context, userID uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var count int64
+	for _, e := range s.exports {
+		if e.UserID == userID && !e.Finished() {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *exportStore) Claim(ctx context.Context, id uuid.UUID, startedAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	export, ok := s.exports[id]
+	if !ok || export.Status != model.StatusPending {
+		return false, nil
+	}
+	export.Status = model.StatusRunning
+	export.StartedAt = &startedAt
+	s.exports[id] = export
+	return true, nil
+}
+
+func (s *exportStore) Finish(ctx context.Context, export *model.Export) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.exports[export.ID] = *export
+	return nil
+}
+
+func (s *exportStore) ListPending(ctx context.Context, limit int) ([]model.Export, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var pending []model.Export
+	for _, e := range s.exports {
+		if e.Status == model.StatusPending {
+			pending = append(pending, e)
+		}
+	}
+	return pending, nil
+}
+
+func (s *exportStore) FailStale(ctx context.Context, startedBefore, now, expiresAt time.Time, reason string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var count int64
+	for id, e := range s.exports {
+		if e.Status == model.StatusRunning && e.StartedAt.Before(startedBefore) {
+			e.Status, e.Error, e.CompletedAt, e.ExpiresAt = model.StatusFailed, reason, &now, &expiresAt
+			s.exports[id] = e
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *exportStore) ListExpired(ctx context.Context, now time.Time, limit int) ([]model.Export, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var expired []model.Export
+	for _, e := range s.exports {
+		if e.Finished() && !e.ExpiresAt.After(now) {
+			expired = append(expired, e)
+		}
+	}
+	return expired, nil
+}
+
+func (s *exportStore) Delete(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.exports, id)
+	return nil
+}
+
+// memoryStorage keeps uploaded objects and signs URLs as storage://<name>
+type memoryStorage struct {
+	objects map[string]string
+	expiry  time.Duration
+}
+
+func (m *memoryStorage) PutObject(ctx context.Context, objectName string, reader io.Reader, size int64, contentType string) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) != size {
+		return errors.New("size does not match content")
+	}
+	m.objects[objectName] = string(data)
+	return nil
+}
+
+func (m *memoryStorage) GetSignedURL(ctx context.Context, objectName string, expiry time.Duration) (string, error) {
+	m.expiry = expiry
+	return "storage://" + objectName, nil
+}
+
+func (m *memoryStorage) RemoveObject(ctx context.Context, objectName string) error {
+	delete(m.objects, objectName)
+	return nil
+}
+
+// newTestService returns a service with a "report" kind that writes its "rows" param and
+// needs the reports:view permission, which only the "analyst" role has
+func newTestService() (*exportService, *exportStore, *memoryStorage, *testutil.FakeClock) {
+	store := &exportStore{}
+	storage := &memoryStorage{objects: map[string]string{}}
+	clk := testutil.NewFakeClock(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+	s := NewExportService(store, storage, testConfig, zap.NewNop().Sugar(),
+		WithClock(clk),
+		WithPermissionCheck(func(ctx context.Context, role, permission string) (bool, error) {
+			return role == "analyst" && permission == "reports:view", nil
+		}),
+	).(*exportService)
+	s.Register("report", Producer{
+		ContentType: "text/csv",
+		Extension:   "csv",
+		Permission:  "reports:view",
+		Validate: func(params map[string]string) error {
+			if params["rows"] == "" {
+				return apperrors.NewAppError(apperrors.BadRequestError, "rows is required")
+			}
+			return nil
+		},
+		Write: func(ctx context.Context, req Request, w io.Writer) (int64, error) {
+			if req.Params["rows"] == "fail" {
+				return 0, apperrors.NewAppError(apperrors.BadRequestError, "Report source is unavailable")
+			}
+			_, err := io.WriteString(w, "id\n"+req.Params["rows"]+"\n")
+			return 1, err
+		},
+	})
+	return s, store, storage, clk
+}
+
+func errorType(err error) apperrors.ErrorType {
+	if appErr, ok := apperrors.IsAppError(err); ok {
+		return appErr.Type
+	}
+	return ""
+}
+
+func TestExportService_Create_Rejections(t *testing.T) {
+	tests := []struct {
+		name    string
+		role    string
+		req     dto.CreateExportRequest
+		wantErr apperrors.ErrorType
+	}{
+		{name: "unknown kind", role: "analyst", req: dto.CreateExportRequest{Kind: "audit"}, wantErr: apperrors.ValidationError},
+		{name: "missing permission", role: "user", req: dto.CreateExportRequest{Kind: "report", Params: map[string]string{"rows": "1"}}, wantErr: apperrors.ForbiddenError},
+		{name: "invalid params", role: "analyst", req: dto.CreateExportRequest{Kind: "report"}, wantErr: apperrors.BadRequestError},
+		{name: "accepted", role: "analyst", req: dto.CreateExportRequest{Kind: "report", Params: map[string]string{"rows": "1"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			s, _, _, _ := newTestService()
+
+			// Act
+			export, err := s.Create(context.Background(), uuid.New(), tt.role, tt.req)
+
+			// Assert
+			if got := errorType(err); got != tt.wantErr || (tt.wantErr == "") != (err == nil) {
+				t.Fatalf("Create() error = %v, want type %q", err, tt.wantErr)
+			}
+			if err == nil && export.Status != model.StatusPending {
+				t.Errorf("status = %s, want pending", export.Status)
+			}
+		})
+	}
+}
+
+func TestExportService_Create_LimitsUnfinished(t *testing.T) {
+	// Arrange
+	s, _, _, _ := newTestService()
+	userID := uuid.New()
+	req := dto.CreateExportRequest{Kind: "report", Params: map[string]string{"rows": "1"}}
+	for i := 0; i < testConfig.MaxPending; i++ {
+		if _, err := s.Create(context.Background(), userID, "analyst", req); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	// Act
+	_, err := s.Create(context.Background(), userID, "analyst", req)
+	_, otherErr := s.Create(context.Background(), uuid.New(), "analyst", req)
+
+	// Assert
+	if errorType(err) != apperrors.TooManyRequestsError {
+		t.Errorf("Create() over the limit error = %v, want TOO_MANY_REQUESTS", err)
+	}
+	if otherErr != nil {
+		t.Errorf("Create() for another user error = %v", otherErr)
+	}
+}
+
+func TestExportService_RunAndDownload(t *testing.T) {
+	// Arrange
+	s, _, storage, clk := newTestService()
+	userID := uuid.New()
+	export, err := s.Create(context.Background(), userID, "analyst", dto.CreateExportRequest{Kind: "report", Params: map[string]string{"rows": "42"}})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// Act
+	s.run(context.Background(), export.ID)
+	s.run(context.Background(), export.ID)
+	got, err := s.Get(context.Background(), userID, export.ID)
+	clk.Advance(testConfig.Retention - 5*time.Minute)
+	late, _ := s.Get(context.Background(), userID, export.ID)
+	_, otherErr := s.Get(context.Background(), uuid.New(), export.ID)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Status != model.StatusCompleted || got.Rows != 1 || got.Size != int64(len("id\n42\n")) {
+		t.Errorf("export = %s with %d rows, %d bytes; want completed with 1 row", got.Status, got.Rows, got.Size)
+	}
+	if content := storage.objects[got.ObjectName]; content != "id\n42\n" || got.DownloadURL != "storage://"+got.ObjectName {
+		t.Errorf("stored %q, download URL %q", content, got.DownloadURL)
+	}
+	if late.DownloadURL == "" || storage.expiry != 5*time.Minute {
+		t.Errorf("URL expiry near retention end = %s, want the 5m left", storage.expiry)
+	}
+	if errorType(otherErr) != apperrors.NotFoundError {
+		t.Errorf("Get() by another user error = %v, want NOT_FOUND", otherErr)
+	}
+}
+
+func TestExportService_RunRecordsFailure(t *testing.T) {
+	// Arrange
+	s, store, storage, _ := newTestService()
+	userID := uuid.New()
+	export, err := s.Create(context.Background(), userID, "analyst", dto.CreateExportRequest{Kind: "report", Params: map[string]string{"rows": "fail"}})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// Act
+	s.run(context.Background(), export.ID)
+	got, _ := store.FindByID(context.Background(), export.ID)
+	resp, _ := s.Get(context.Background(), userID, export.ID)
+
+	// Assert
+	if got.Status != model.StatusFailed || got.Error != "Report source is unavailable" || got.ExpiresAt == nil {
+		t.Errorf("export = %s %q, expires %v; want failed with the producer's message", got.Status, got.Error, got.ExpiresAt)
+	}
+	if len(storage.objects) != 0 || resp.DownloadURL != "" {
+		t.Errorf("failed export stored %d objects, download URL %q", len(storage.objects), resp.DownloadURL)
+	}
+}
+
+func TestExportService_Cleanup(t *testing.T) {
+	// Arrange
+	s, store, storage, clk := newTestService()
+	ctx := context.Background()
+	req := dto.CreateExportRequest{Kind: "report", Params: map[string]string{"rows": "1"}}
+	done, _ := s.Create(ctx, uuid.New(), "analyst", req)
+	s.run(ctx, done.ID)
+	stuck, _ := s.Create(ctx, uuid.New(), "analyst", req)
+	_, _ = store.Claim(ctx, stuck.ID, clk.Now())
+	<-s.queue
+	<-s.queue
+	clk.Advance(testConfig.Retention + time.Minute)
+	pending, _ := s.Create(ctx, uuid.New(), "analyst", req)
+	<-s.queue
+
+	// Act
+	err := s.Cleanup(ctx)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Cleanup() error = %v", err)
+	}
+	if e, _ := store.FindByID(ctx, done.ID); e != nil || len(storage.objects) != 0 {
+		t.Errorf("expired export kept: %v, %d objects", e, len(storage.objects))
+	}
+	if e, _ := store.FindByID(ctx, stuck.ID); e == nil || e.Status != model.StatusFailed || e.Error != staleReason {
+		t.Errorf("stale export = %+v, want failed and kept until its retention ends", e)
+	}
+	select {
+	case id := <-s.queue:
+		if id != pending.ID {
+			t.Errorf("queued %s, want the pending export", id)
+		}
+	default:
+		t.Error("pending export was not queued again")
+	}
+}
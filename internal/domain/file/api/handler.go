@@ -1,12 +1,13 @@
 package api
 
 import (
-	"go_platform_template/internal/domain/file/dto"
-	"go_platform_template/internal/domain/file/model"
-	"go_platform_template/internal/domain/file/service"
-	"go_platform_template/internal/platform/validation"
-	apperrors "go_platform_template/internal/shared/errors"
-	"go_platform_template/internal/shared/response"
+	"errors"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/service"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/validation"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
 	"net/http"
 	"path/filepath"
 	"strings"
@@ -26,7 +27,7 @@ type FileHandler struct {
 func NewFileHandler(s *service.FileService, logger *zap.SugaredLogger) *FileHandler {
 	return &FileHandler{
 		service:   s,
-		validator: validation.New(),
+		validator: validation.Default(),
 		logger:    logger,
 	}
 }
@@ -76,17 +77,17 @@ func (h *FileHandler) Upload(c *gin.Context) {
 		return
 	}
 
-	file, err := c.FormFile("file")
+	file, err := readFilePart(c.Request, "file", h.service.MaxUploadSize(model.FileType(fType)))
 	if err != nil {
 		h.logger.Warnw("file not provided in upload", "error", err, "request_id", requestID)
 		_ = c.Error(apperrors.NewAppError(apperrors.BadRequestError, "File not provided"))
 		return
 	}
+	defer file.release()
 
-	// Validate file using service validation
-	contentType := file.Header.Get("Content-Type")
-	if err := h.service.ValidateUpload(file.Filename, file.Size, contentType, model.FileType(fType)); err != nil {
-		h.logger.Warnw("file validation failed", "filename", file.Filename, "error", err, "request_id", requestID)
+	// Validate file using service validation; a file over the limit was read one byte past it
+	if err := h.service.ValidateUpload(file.filename, file.size, file.contentType, model.FileType(fType)); err != nil {
+		h.logger.Warnw("file validation failed", "filename", file.filename, "error", err, "request_id", requestID)
 		_ = c.Error(apperrors.NewAppErrorWithDetails(
 			apperrors.BadRequestError,
 			"File validation failed",
@@ -95,42 +96,33 @@ func (h *FileHandler) Upload(c *gin.Context) {
 		return
 	}
 
-	src, err := file.Open()
-	if err != nil {
-		h.logger.Errorw("failed to open uploaded file", "filename", file.Filename, "error", err, "request_id", requestID)
-		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to open file"))
-		return
-	}
-	defer func() {
-		if err := src.Close(); err != nil {
-			h.logger.Warnf("failed to close file source: %v", err)
-		}
-	}()
-
-	// Generate secure object name with timestamp to prevent collisions
-	ext := filepath.Ext(file.Filename)
-	baseName := strings.TrimSuffix(file.Filename, ext)
-	timestamp := time.Now().Format("20060102-150405")
-	objectName := userID.String() + "/" + baseName + "_" + timestamp + ext
-
 	// Upload file
 	uploaded, err := h.service.Upload(
+		c.Request.Context(),
 		userID, // pass uuid.UUID instead of string
 		model.FileType(fType),
-		src,
-		objectName,
-		file.Size,
-		contentType,
-		file.Filename,
+		file.reader(),
+		newObjectName(userID, file.filename),
+		file.size,
+		file.contentType,
+		file.filename,
 	)
+	if errors.Is(err, service.ErrInfected) {
+		_ = c.Error(apperrors.NewAppErrorWithDetails(
+			apperrors.ValidationError,
+			"File rejected",
+			"The virus scan found malware in the file",
+		))
+		return
+	}
 	if err != nil {
-		h.logger.Errorw("failed to upload file", "user_id", userID, "filename", file.Filename, "error", err, "request_id", requestID)
+		h.logger.Errorw("failed to upload file", "user_id", userID, "filename", file.filename, "error", err, "request_id", requestID)
 		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to upload file"))
 		return
 	}
 
 	// Generate signed URL
-	url, err := h.service.GetSignedURL(uploaded.Path, 15*time.Minute)
+	url, err := h.service.GetSignedURL(c.Request.Context(), uploaded.Path, 15*time.Minute)
 	if err != nil {
 		h.logger.Errorw("failed to generate signed URL", "file_path", uploaded.Path, "error", err, "request_id", requestID)
 		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to generate access URL"))
@@ -150,11 +142,62 @@ func (h *FileHandler) Upload(c *gin.Context) {
 		Size:         uploaded.Size,
 		OriginalName: uploaded.OriginalName,
 		MimeType:     uploaded.MimeType,
+		SHA256:       uploaded.SHA256,
+		ScanStatus:   string(uploaded.ScanStatus),
 		UploadedAt:   uploaded.UploadedAt,
 		ExpiresIn:    "15 minutes",
 	}, requestIDStr))
 }
 
+// newObjectName returns where a file uploaded by userID is stored, with a timestamp to
+// prevent collisions
+func newObjectName(userID uuid.UUID, filename string) string {
+	ext := filepath.Ext(filename)
+	baseName := strings.TrimSuffix(filename, ext)
+	timestamp := time.Now().Format("20060102-150405")
+	return userID.String() + "/" + baseName + "_" + timestamp + ext
+}
+
+// thumbnailInfos describes the thumbnails of file with signed URLs valid for 15 minutes;
+// thumbnails whose URL cannot be signed are left out
+func (h *FileHandler) thumbnailInfos(c *gin.Context, file *model.File) []dto.ThumbnailInfo {
+	var infos []dto.ThumbnailInfo
+	for _, thumbnail := range file.Thumbnails {
+		url, err := h.service.GetSignedURL(c.Request.Context(), thumbnail.Path, 15*time.Minute)
+		if err != nil {
+			h.logger.Warnw("failed to generate signed URL for thumbnail", "file_id", file.ID, "path", thumbnail.Path, "error", err, "request_id", c.GetString("RequestID"))
+			continue
+		}
+		infos = append(infos, dto.ThumbnailInfo{Size: thumbnail.Size, Width: thumbnail.Width, Height: thumbnail.Height, URL: url})
+	}
+	return infos
+}
+
+// fileInfo describes file with its public URL, or signed URLs valid for 15 minutes; the
+// URL is empty when it cannot be signed
+func (h *FileHandler) fileInfo(c *gin.Context, file *model.File) dto.FileInfo {
+	url, err := h.service.FileURL(c.Request.Context(), file, 15*time.Minute)
+	if err != nil {
+		h.logger.Warnw("failed to generate signed URL for file", "file_id", file.ID, "error", err, "request_id", c.GetString("RequestID"))
+		url = ""
+	}
+	return dto.FileInfo{
+		ID:           file.ID.String(),
+		Path:         file.Path,
+		Folder:       file.Folder,
+		Visibility:   string(file.Visibility),
+		Type:         string(file.Type),
+		Size:         file.Size,
+		OriginalName: file.OriginalName,
+		MimeType:     file.MimeType,
+		SHA256:       file.SHA256,
+		ScanStatus:   string(file.ScanStatus),
+		UploadedAt:   file.UploadedAt,
+		URL:          url,
+		Thumbnails:   h.thumbnailInfos(c, file),
+	}
+}
+
 // GetFile godoc
 // @Summary Get file by filename
 // @Description Get a temporary signed URL to access a file
@@ -179,7 +222,7 @@ func (h *FileHandler) GetFile(c *gin.Context) {
 	}
 
 	// Verify file exists before generating URL
-	exists, err := h.service.FileExists(objectName)
+	exists, err := h.service.FileExists(c.Request.Context(), objectName)
 	if err != nil {
 		h.logger.Errorw("failed to check file existence", "filename", objectName, "error", err, "request_id", requestID)
 		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to check file existence"))
@@ -191,7 +234,21 @@ func (h *FileHandler) GetFile(c *gin.Context) {
 		return
 	}
 
-	url, err := h.service.GetSignedURL(objectName, 15*time.Minute)
+	requestIDStr, ok := requestID.(string)
+	if !ok {
+		requestIDStr = "unknown"
+	}
+
+	// Public files have a stable URL; objects without metadata are treated as private
+	if file, err := h.service.GetFileByPath(c.Request.Context(), objectName); err == nil && file.Visibility == model.VisibilityPublic {
+		c.JSON(http.StatusOK, response.NewSuccessResponse(dto.GetFileResponse{
+			URL:       h.service.PublicURL(file),
+			ExpiresIn: "never",
+		}, requestIDStr))
+		return
+	}
+
+	url, err := h.service.GetSignedURL(c.Request.Context(), objectName, 15*time.Minute)
 	if err != nil {
 		h.logger.Errorw("failed to generate signed URL", "filename", objectName, "error", err, "request_id", requestID)
 		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to generate access URL"))
@@ -199,16 +256,89 @@ func (h *FileHandler) GetFile(c *gin.Context) {
 	}
 
 	h.logger.Infow("file signed URL generated", "filename", objectName, "request_id", requestID)
-	requestIDStr, ok := requestID.(string)
-	if !ok {
-		requestIDStr = "unknown"
-	}
 	c.JSON(http.StatusOK, response.NewSuccessResponse(dto.GetFileResponse{
 		URL:       url,
 		ExpiresIn: "15 minutes",
 	}, requestIDStr))
 }
 
+// VerifyFile godoc
+// @Summary Verify a file's integrity
+// @Description Read a file back from storage and compare its SHA-256 and size with the ones recorded at upload
+// @Tags files
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "File ID"
+// @Success 200 {object} dto.VerifyFileResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /files/{id}/verify [get]
+func (h *FileHandler) VerifyFile(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+	userID, err := uuid.Parse(c.GetString("userID"))
+	if err != nil {
+		_ = c.Error(apperrors.NewAppError(apperrors.UnauthorizedError, "User authentication required"))
+		return
+	}
+	// Registered as /:filename/verify because gin needs one wildcard name per segment
+	fileID := c.Param("filename")
+	if _, err := uuid.Parse(fileID); err != nil {
+		_ = c.Error(apperrors.NewAppError(apperrors.BadRequestError, "Invalid file ID"))
+		return
+	}
+
+	file, err := h.service.GetFileByID(c.Request.Context(), fileID)
+	if err != nil {
+		h.logger.Warnw("file not found for verification", "file_id", fileID, "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.NotFoundError, "File not found"))
+		return
+	}
+	if file.UserID != userID {
+		h.logger.Warnw("unauthorized file verify attempt", "user_id", userID, "file_owner", file.UserID, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.ForbiddenError, "You do not have permission to verify this file"))
+		return
+	}
+	if file.SHA256 == "" {
+		_ = c.Error(apperrors.NewAppErrorWithDetails(
+			apperrors.ConflictError,
+			"No checksum was recorded for this file",
+			"It was uploaded before checksums were stored",
+		))
+		return
+	}
+
+	sum, size, err := h.service.Checksum(c.Request.Context(), file.Path)
+	if errors.Is(err, service.ErrObjectNotFound) {
+		h.logger.Errorw("stored file missing", "file_id", file.ID, "path", file.Path, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.NotFoundError, "Stored file not found"))
+		return
+	}
+	if err != nil {
+		h.logger.Errorw("failed to verify file", "file_id", file.ID, "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to verify file"))
+		return
+	}
+
+	valid := sum == file.SHA256 && size == file.Size
+	if !valid {
+		h.logger.Errorw("file checksum mismatch", "file_id", file.ID, "path", file.Path, "request_id", requestID)
+	}
+	c.JSON(http.StatusOK, response.NewSuccessResponse(dto.VerifyFileResponse{
+		FileID:         file.ID.String(),
+		Valid:          valid,
+		SHA256:         file.SHA256,
+		ComputedSHA256: sum,
+		Size:           file.Size,
+		ComputedSize:   size,
+		ScanStatus:     string(file.ScanStatus),
+		VerifiedAt:     time.Now().UTC(),
+	}, requestID))
+}
+
 // DeleteFile godoc
 // @Summary Delete a file
 // @Description Delete a file and its metadata
@@ -257,7 +387,7 @@ func (h *FileHandler) DeleteFile(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.Delete(objectName); err != nil {
+	if err := h.service.Delete(c.Request.Context(), objectName); err != nil {
 		h.logger.Errorw("failed to delete file", "filename", objectName, "error", err, "request_id", requestID)
 		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to delete file"))
 		return
@@ -302,22 +432,8 @@ func (h *FileHandler) GetUserFiles(c *gin.Context) {
 		Files: make([]dto.FileInfo, len(files)),
 	}
 
-	for i, file := range files {
-		url, err := h.service.GetSignedURL(file.Path, 15*time.Minute)
-		if err != nil {
-			h.logger.Warnw("failed to generate signed URL for file", "file_id", file.ID, "error", err, "request_id", requestID)
-			url = ""
-		}
-		responseData.Files[i] = dto.FileInfo{
-			ID:           file.ID.String(),
-			Path:         file.Path,
-			Type:         string(file.Type),
-			Size:         file.Size,
-			OriginalName: file.OriginalName,
-			MimeType:     file.MimeType,
-			UploadedAt:   file.UploadedAt,
-			URL:          url,
-		}
+	for i := range files {
+		responseData.Files[i] = h.fileInfo(c, &files[i])
 	}
 
 	h.logger.Infow("user files retrieved", "user_id", userID, "count", len(files), "request_id", requestID)
@@ -325,5 +441,5 @@ func (h *FileHandler) GetUserFiles(c *gin.Context) {
 	if !ok {
 		requestIDStr = "unknown"
 	}
-	c.JSON(http.StatusOK, response.NewSuccessResponse(responseData, requestIDStr))
+	c.Render(http.StatusOK, response.PooledJSON{Data: response.NewSuccessResponse(responseData, requestIDStr)})
 }
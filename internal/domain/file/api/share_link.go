@@ -0,0 +1,261 @@
+package api
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/service"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// sharePasswordHeader carries the password of a protected share link
+const sharePasswordHeader = "X-Share-Password"
+
+// CreateShareLink godoc
+// @Summary Create a share link
+// @Description Creates a link that downloads one of your files without signing in, until it expires, is revoked or used up its downloads. It can require a password, sent in the X-Share-Password header. The URL is only returned here. Links follow the file when it is moved.
+// @Tags files
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "File ID"
+// @Param request body dto.CreateShareLinkRequest true "Expiry, password and download limit"
+// @Success 201 {object} dto.ShareLinkResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /files/{id}/share [post]
+func (h *FileHandler) CreateShareLink(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+	file, ok := h.ownedFile(c, "share")
+	if !ok {
+		return
+	}
+
+	var req dto.CreateShareLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.NewAppErrorWithDetails(
+			apperrors.BadRequestError,
+			"Invalid request payload",
+			err.Error(),
+		))
+		return
+	}
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	token, link, err := h.service.CreateShareLink(c.Request.Context(), file, time.Duration(req.ExpiresIn)*time.Second, req.Password, req.MaxDownloads)
+	if errors.Is(err, service.ErrShareTTL) {
+		_ = c.Error(apperrors.NewAppError(apperrors.BadRequestError, "expires_in exceeds the maximum share lifetime"))
+		return
+	}
+	if err != nil {
+		h.logger.Errorw("failed to create share link", "file_id", file.ID, "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to share file"))
+		return
+	}
+
+	h.logger.Infow("share link created", "file_id", file.ID, "link_id", link.ID, "expires_at", link.ExpiresAt, "request_id", requestID)
+	resp := shareLinkResponse(link)
+	prefix, _, _ := strings.Cut(c.Request.URL.Path, "/files/")
+	resp.URL = prefix + "/share/" + token
+	c.JSON(http.StatusCreated, response.NewSuccessResponse(resp, requestID))
+}
+
+// ListShareLinks godoc
+// @Summary List the share links of a file
+// @Description Lists the share links of one of your files, newest first, including revoked and expired ones. Their URLs are not returned.
+// @Tags files
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "File ID"
+// @Success 200 {array} dto.ShareLinkResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /files/{id}/shares [get]
+func (h *FileHandler) ListShareLinks(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+	file, ok := h.ownedFile(c, "list the share links of")
+	if !ok {
+		return
+	}
+
+	links, err := h.service.ListShareLinks(c.Request.Context(), file.ID)
+	if err != nil {
+		h.logger.Errorw("failed to list share links", "file_id", file.ID, "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to list share links"))
+		return
+	}
+	resp := make([]dto.ShareLinkResponse, len(links))
+	for i := range links {
+		resp[i] = shareLinkResponse(&links[i])
+	}
+	c.JSON(http.StatusOK, response.NewSuccessResponse(resp, requestID))
+}
+
+// RevokeShareLink godoc
+// @Summary Revoke a share link
+// @Description Revokes one of your share links; it stops working at once. Revoking a revoked link succeeds.
+// @Tags files
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Share link ID"
+// @Success 200 {object} dto.ShareLinkResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /files/shares/{id} [delete]
+func (h *FileHandler) RevokeShareLink(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+	userID, err := uuid.Parse(c.GetString("userID"))
+	if err != nil {
+		_ = c.Error(apperrors.NewAppError(apperrors.UnauthorizedError, "User authentication required"))
+		return
+	}
+	linkID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		_ = c.Error(apperrors.NewAppError(apperrors.BadRequestError, "Invalid share link ID"))
+		return
+	}
+
+	link, err := h.service.RevokeShareLink(c.Request.Context(), userID, linkID)
+	if errors.Is(err, service.ErrShareLinkNotFound) {
+		_ = c.Error(apperrors.NewAppError(apperrors.NotFoundError, "Share link not found"))
+		return
+	}
+	if err != nil {
+		h.logger.Errorw("failed to revoke share link", "link_id", linkID, "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to revoke share link"))
+		return
+	}
+
+	h.logger.Infow("share link revoked", "link_id", link.ID, "file_id", link.FileID, "user_id", userID, "request_id", requestID)
+	c.JSON(http.StatusOK, response.NewSuccessResponse(shareLinkResponse(link), requestID))
+}
+
+// OpenShareLink godoc
+// @Summary Download a file through a share link
+// @Description Streams the file of a share link from POST /files/{id}/share, or with redirect=true redirects to a signed storage URL valid for 5 minutes. No sign-in is needed; protected links need their password in the X-Share-Password header. Every request counts as a download.
+// @Tags files
+// @Produce octet-stream
+// @Param token path string true "Share link token"
+// @Param redirect query bool false "Redirect to a signed storage URL instead of streaming"
+// @Param X-Share-Password header string false "Password of a protected link"
+// @Success 200 {file} file
+// @Success 302 "Redirect to the signed URL"
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 429 {object} response.ErrorResponse
+// @Router /share/{token} [get]
+func (h *FileHandler) OpenShareLink(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+	link, file, err := h.service.OpenShareLink(c.Request.Context(), c.Param("token"), c.GetHeader(sharePasswordHeader))
+	if errors.Is(err, service.ErrShareLinkNotFound) {
+		_ = c.Error(apperrors.NewAppError(apperrors.NotFoundError, "Share link not found or expired"))
+		return
+	}
+	if errors.Is(err, service.ErrSharePassword) {
+		h.logger.Warnw("share link password rejected", "ip", c.ClientIP(), "request_id", requestID)
+		_ = c.Error(apperrors.NewAppErrorWithDetails(
+			apperrors.UnauthorizedError,
+			"Password required or incorrect",
+			"Send the password of the link in the "+sharePasswordHeader+" header",
+		))
+		return
+	}
+	if err != nil {
+		h.logger.Errorw("failed to open share link", "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to download file"))
+		return
+	}
+	h.logger.Infow("file downloaded through share link", "file_id", file.ID, "link_id", link.ID, "downloads", link.Downloads, "ip", c.ClientIP(), "request_id", requestID)
+
+	c.Header("Cache-Control", "private, no-store")
+	if c.Query("redirect") == "true" {
+		url, err := h.service.GetSignedURL(c.Request.Context(), file.Path, 5*time.Minute)
+		if err != nil {
+			h.logger.Errorw("failed to generate signed URL", "file_id", file.ID, "error", err, "request_id", requestID)
+			_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to download file"))
+			return
+		}
+		c.Redirect(http.StatusFound, url)
+		return
+	}
+
+	content, size, contentType, err := h.service.OpenObject(c.Request.Context(), file.Path)
+	if errors.Is(err, service.ErrObjectNotFound) {
+		h.logger.Errorw("stored file missing", "file_id", file.ID, "path", file.Path, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.NotFoundError, "Stored file not found"))
+		return
+	}
+	if err != nil {
+		h.logger.Errorw("failed to open shared file", "file_id", file.ID, "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to download file"))
+		return
+	}
+	defer content.Close()
+	c.DataFromReader(http.StatusOK, size, contentType, content, map[string]string{
+		"Content-Disposition": mime.FormatMediaType("attachment", map[string]string{"filename": file.OriginalName}),
+	})
+}
+
+// ownedFile returns the file whose ID is the "filename" path parameter, after checking
+// that the caller owns it; otherwise it records the error and returns false. action
+// completes "You do not have permission to ... this file".
+func (h *FileHandler) ownedFile(c *gin.Context, action string) (*model.File, bool) {
+	requestID := c.GetString("RequestID")
+	userID, err := uuid.Parse(c.GetString("userID"))
+	if err != nil {
+		_ = c.Error(apperrors.NewAppError(apperrors.UnauthorizedError, "User authentication required"))
+		return nil, false
+	}
+	// Registered as /:filename/... because gin needs one wildcard name per segment
+	fileID := c.Param("filename")
+	if _, err := uuid.Parse(fileID); err != nil {
+		_ = c.Error(apperrors.NewAppError(apperrors.BadRequestError, "Invalid file ID"))
+		return nil, false
+	}
+	file, err := h.service.GetFileByID(c.Request.Context(), fileID)
+	if err != nil {
+		h.logger.Warnw("file not found", "file_id", fileID, "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.NotFoundError, "File not found"))
+		return nil, false
+	}
+	if file.UserID != userID {
+		h.logger.Warnw("unauthorized file access attempt", "user_id", userID, "file_owner", file.UserID, "action", action, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.ForbiddenError, "You do not have permission to "+action+" this file"))
+		return nil, false
+	}
+	return file, true
+}
+
+// shareLinkResponse describes link, without its URL
+func shareLinkResponse(link *model.ShareLink) dto.ShareLinkResponse {
+	return dto.ShareLinkResponse{
+		ID:                link.ID.String(),
+		FileID:            link.FileID.String(),
+		PasswordProtected: link.PasswordHash != "",
+		MaxDownloads:      link.MaxDownloads,
+		Downloads:         link.Downloads,
+		ExpiresAt:         link.ExpiresAt,
+		RevokedAt:         link.RevokedAt,
+		CreatedAt:         link.CreatedAt,
+	}
+}
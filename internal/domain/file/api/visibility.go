@@ -0,0 +1,119 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/service"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetVisibility godoc
+// @Summary Make a file public or private
+// @Description Public files get a stable URL that never expires and needs no sign-in, GET /public/files/{id}, and may be cached by browsers and CDNs for FILE_PUBLIC_MAX_AGE. Private files are only reachable through signed URLs. Making a file private stops its public URL at once, but copies cached until then may still be served.
+// @Tags files
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "File ID"
+// @Param request body dto.SetVisibilityRequest true "New visibility"
+// @Success 200 {object} dto.FileInfo
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /files/{id}/visibility [put]
+func (h *FileHandler) SetVisibility(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+	file, ok := h.ownedFile(c, "change the visibility of")
+	if !ok {
+		return
+	}
+
+	var req dto.SetVisibilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.NewAppErrorWithDetails(
+			apperrors.BadRequestError,
+			"Invalid request payload",
+			err.Error(),
+		))
+		return
+	}
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	updated, err := h.service.SetVisibility(c.Request.Context(), file, model.Visibility(req.Visibility))
+	if errors.Is(err, service.ErrInvalidVisibility) {
+		_ = c.Error(apperrors.NewAppError(apperrors.BadRequestError, err.Error()))
+		return
+	}
+	if err != nil {
+		h.logger.Errorw("failed to change file visibility", "file_id", file.ID, "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to change file visibility"))
+		return
+	}
+
+	h.logger.Infow("file visibility changed", "file_id", file.ID, "visibility", updated.Visibility, "request_id", requestID)
+	c.JSON(http.StatusOK, response.NewSuccessResponse(h.fileInfo(c, updated), requestID))
+}
+
+// GetPublicFile godoc
+// @Summary Download a public file
+// @Description Streams a file its owner made public. No sign-in is needed and the URL never changes, so browsers and CDNs may cache the response for FILE_PUBLIC_MAX_AGE. Private and unknown files answer 404 alike.
+// @Tags files
+// @Produce octet-stream
+// @Param id path string true "File ID"
+// @Success 200 {file} file
+// @Success 304 "Not modified"
+// @Failure 404 {object} response.ErrorResponse
+// @Router /public/files/{id} [get]
+func (h *FileHandler) GetPublicFile(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+	file, err := h.service.GetPublicFile(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		_ = c.Error(apperrors.NewAppError(apperrors.NotFoundError, "File not found"))
+		return
+	}
+
+	headers := map[string]string{
+		"Cache-Control":       fmt.Sprintf("public, max-age=%d", int(h.service.PublicMaxAge().Seconds())),
+		"Content-Disposition": mime.FormatMediaType("inline", map[string]string{"filename": file.OriginalName}),
+		// Files are served from the API's origin; keep scripts in them, e.g. in SVG
+		// images, from running there
+		"Content-Security-Policy": "default-src 'none'; sandbox",
+		"X-Content-Type-Options":  "nosniff",
+	}
+	if file.SHA256 != "" {
+		headers["ETag"] = `"` + file.SHA256 + `"`
+		if c.GetHeader("If-None-Match") == headers["ETag"] {
+			c.Header("Cache-Control", headers["Cache-Control"])
+			c.Header("ETag", headers["ETag"])
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+
+	content, size, contentType, err := h.service.OpenObject(c.Request.Context(), file.Path)
+	if errors.Is(err, service.ErrObjectNotFound) {
+		h.logger.Errorw("stored file missing", "file_id", file.ID, "path", file.Path, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.NotFoundError, "Stored file not found"))
+		return
+	}
+	if err != nil {
+		h.logger.Errorw("failed to open public file", "file_id", file.ID, "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to download file"))
+		return
+	}
+	defer content.Close()
+	c.DataFromReader(http.StatusOK, size, contentType, content, headers)
+}
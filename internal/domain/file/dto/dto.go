@@ -11,7 +11,7 @@ type UploadRequest struct {
 	// Required: true
 	// Enum: profile_image,cv
 	// Example: profile_image
-	Type string `json:"type" form:"type" validate:"required,oneof=profile_image cv"`
+	Type string `json:"type" form:"type" validate:"required,oneof=profile_image cv" example:"profile_image"`
 
 	// File to upload
 	// Required: true
@@ -50,9 +50,18 @@ type UploadResponse struct {
 	// Example: image/jpeg
 	MimeType string `json:"mime_type" example:"image/jpeg"`
 
+	// SHA256 is the hex-encoded SHA-256 of the stored content
+	// Example: 9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08
+	SHA256 string `json:"sha256,omitempty" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+
+	// ScanStatus is the malware scan result; omitted when scanning is disabled
+	// Enum: clean
+	// Example: clean
+	ScanStatus string `json:"scan_status,omitempty" example:"clean"`
+
 	// UploadedAt is the timestamp when the file was uploaded
 	// Example: 2023-12-01T14:30:52Z
-	UploadedAt time.Time `json:"uploaded_at"`
+	UploadedAt time.Time `json:"uploaded_at" example:"2023-12-01T14:30:52Z"`
 
 	// ExpiresIn is the duration after which the URL expires
 	// Example: 15 minutes
@@ -62,11 +71,11 @@ type UploadResponse struct {
 // GetFileResponse represents the response for file access
 // swagger:model
 type GetFileResponse struct {
-	// URL to access the file (signed URL)
+	// URL to access the file (signed URL, or the stable URL of a public file)
 	// Example: https://minio.example.com/bucket/path?X-Amz-Algorithm=...
 	URL string `json:"url" example:"https://minio.example.com/bucket/path?X-Amz-Algorithm=..."`
 
-	// ExpiresIn is the duration after which the URL expires
+	// ExpiresIn is the duration after which the URL expires; "never" for public files
 	// Example: 15 minutes
 	ExpiresIn string `json:"expires_in" example:"15 minutes"`
 }
@@ -93,6 +102,15 @@ type FileInfo struct {
 	// Example: user-123/profile.jpg
 	Path string `json:"path" example:"user-123/profile.jpg"`
 
+	// Folder the file is in; empty at the top level
+	// Example: projects/2024
+	Folder string `json:"folder" example:"projects/2024"`
+
+	// Visibility of the file; the URL of a public file never expires
+	// Enum: private,public
+	// Example: private
+	Visibility string `json:"visibility" example:"private"`
+
 	// Type of the file
 	// Example: profile_image
 	Type string `json:"type" example:"profile_image"`
@@ -109,13 +127,340 @@ type FileInfo struct {
 	// Example: image/jpeg
 	MimeType string `json:"mime_type" example:"image/jpeg"`
 
+	// SHA256 is the hex-encoded SHA-256 of the stored content
+	// Example: 9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08
+	SHA256 string `json:"sha256,omitempty" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+
+	// ScanStatus is the malware scan result; omitted when scanning is disabled
+	// Enum: clean
+	// Example: clean
+	ScanStatus string `json:"scan_status,omitempty" example:"clean"`
+
 	// UploadedAt is the timestamp when the file was uploaded
 	// Example: 2023-12-01T14:30:52Z
-	UploadedAt time.Time `json:"uploaded_at"`
+	UploadedAt time.Time `json:"uploaded_at" example:"2023-12-01T14:30:52Z"`
 
-	// URL to access the file (signed URL)
+	// URL to access the file: a signed URL valid for 15 minutes, or the stable URL of a
+	// public file
 	// Example: https://minio.example.com/bucket/path?X-Amz-Algorithm=...
 	URL string `json:"url" example:"https://minio.example.com/bucket/path?X-Amz-Algorithm=..."`
+
+	// Thumbnails of a profile image, smallest first; omitted until they are generated
+	Thumbnails []ThumbnailInfo `json:"thumbnails,omitempty"`
+}
+
+// ThumbnailInfo is a scaled-down copy of an image file
+// swagger:model
+type ThumbnailInfo struct {
+	// Size is the longest edge the thumbnail was made for, in pixels
+	// Example: 128
+	Size int `json:"size" example:"128"`
+
+	// Width of the thumbnail in pixels
+	// Example: 128
+	Width int `json:"width" example:"128"`
+
+	// Height of the thumbnail in pixels
+	// Example: 96
+	Height int `json:"height" example:"96"`
+
+	// URL to access the thumbnail (signed URL)
+	// Example: https://minio.example.com/bucket/thumbnails/user-123/profile_20231201-143052_128.jpg?X-Amz-Algorithm=...
+	URL string `json:"url" example:"https://minio.example.com/bucket/thumbnails/user-123/profile_20231201-143052_128.jpg?X-Amz-Algorithm=..."`
+}
+
+// VerifyFileResponse reports whether the stored content still matches the checksum
+// recorded at upload
+// swagger:model
+type VerifyFileResponse struct {
+	// ID of the file
+	// Example: 550e8400-e29b-41d4-a716-446655440000
+	FileID string `json:"file_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+
+	// Valid is true when the computed checksum and size match the recorded ones
+	// Example: true
+	Valid bool `json:"valid" example:"true"`
+
+	// SHA256 recorded at upload
+	// Example: 9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08
+	SHA256 string `json:"sha256" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+
+	// ComputedSHA256 of the content read back from storage
+	// Example: 9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08
+	ComputedSHA256 string `json:"computed_sha256" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+
+	// Size recorded at upload, in bytes
+	// Example: 1024576
+	Size int64 `json:"size" example:"1024576"`
+
+	// ComputedSize of the content read back from storage, in bytes
+	// Example: 1024576
+	ComputedSize int64 `json:"computed_size" example:"1024576"`
+
+	// ScanStatus is the malware scan result from upload; omitted when it was not scanned
+	// Example: clean
+	ScanStatus string `json:"scan_status,omitempty" example:"clean"`
+
+	// VerifiedAt is when the content was read back
+	// Example: 2023-12-01T15:00:00Z
+	VerifiedAt time.Time `json:"verified_at" example:"2023-12-01T15:00:00Z"`
+}
+
+// ShareFileRequest asks for a token that downloads one of the caller's files without
+// signing in
+// swagger:model
+type ShareFileRequest struct {
+	// Path of the file to share
+	// Required: true
+	// Example: user-123/report_20231201-143052.pdf
+	Path string `json:"path" validate:"required,min=1" example:"user-123/report_20231201-143052.pdf"`
+
+	// ExpiresIn is how long the token is valid, in seconds; FILE_SHARE_TTL when omitted
+	// Example: 86400
+	ExpiresIn int64 `json:"expires_in,omitempty" validate:"omitempty,min=1" example:"86400"`
+}
+
+// ShareFileResponse is a token scoped to reading a single file
+// swagger:model
+type ShareFileResponse struct {
+	// Token to pass as the token query parameter of GET /files/shared
+	// Example: eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...
+	Token string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+
+	// URL downloads the file with the token; share it as is
+	// Example: /api/v1/files/shared?token=eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...
+	URL string `json:"url" example:"/api/v1/files/shared?token=eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+
+	// Scope names the only file the token can read
+	// Example: files:read:user-123/report_20231201-143052.pdf
+	Scope string `json:"scope" example:"files:read:user-123/report_20231201-143052.pdf"`
+
+	// ExpiresAt is when the token stops working
+	// Example: 2023-12-02T14:30:52Z
+	ExpiresAt time.Time `json:"expires_at" example:"2023-12-02T14:30:52Z"`
+}
+
+// CreateShareLinkRequest creates a revocable link that downloads one file without
+// signing in
+// swagger:model
+type CreateShareLinkRequest struct {
+	// ExpiresIn is how long the link works, in seconds; FILE_SHARE_TTL when omitted
+	// Example: 86400
+	ExpiresIn int64 `json:"expires_in,omitempty" validate:"omitempty,min=1" example:"86400"`
+
+	// Password downloads must send in the X-Share-Password header; none when omitted
+	// Example: correct-horse
+	Password string `json:"password,omitempty" validate:"omitempty,min=4,max=72" example:"correct-horse"`
+
+	// MaxDownloads is how many times the link can be used; no limit when omitted
+	// Example: 5
+	MaxDownloads int `json:"max_downloads,omitempty" validate:"omitempty,min=1" example:"5"`
+}
+
+// ShareLinkResponse describes a share link
+// swagger:model
+type ShareLinkResponse struct {
+	// Example: 550e8400-e29b-41d4-a716-446655440000
+	ID string `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+
+	// Example: 123e4567-e89b-12d3-a456-426614174000
+	FileID string `json:"file_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+
+	// URL downloads the file; only returned when the link is created, as the token is
+	// not stored
+	// Example: /api/v1/share/q8x7Jb0kVt5m2YzR4nHcLw9pS1aEoUiD3fGhKjTlMnQ
+	URL string `json:"url,omitempty" example:"/api/v1/share/q8x7Jb0kVt5m2YzR4nHcLw9pS1aEoUiD3fGhKjTlMnQ"`
+
+	// PasswordProtected tells whether downloads need the password
+	// Example: true
+	PasswordProtected bool `json:"password_protected" example:"true"`
+
+	// MaxDownloads is how many times the link can be used; 0 for no limit
+	// Example: 5
+	MaxDownloads int `json:"max_downloads" example:"5"`
+
+	// Downloads is how many times the link was used
+	// Example: 2
+	Downloads int `json:"downloads" example:"2"`
+
+	// Example: 2023-12-02T14:30:52Z
+	ExpiresAt time.Time `json:"expires_at" example:"2023-12-02T14:30:52Z"`
+
+	// RevokedAt is when the link was revoked; omitted while it is not
+	// Example: 2023-12-01T16:00:00Z
+	RevokedAt *time.Time `json:"revoked_at,omitempty" example:"2023-12-01T16:00:00Z"`
+
+	// Example: 2023-12-01T14:30:52Z
+	CreatedAt time.Time `json:"created_at" example:"2023-12-01T14:30:52Z"`
+}
+
+// PresignUploadRequest announces a file the client will upload straight to storage
+// swagger:model
+type PresignUploadRequest struct {
+	// Type of the file to upload
+	// Required: true
+	// Enum: profile_image,cv
+	// Example: cv
+	Type string `json:"type" validate:"required,oneof=profile_image cv" example:"cv"`
+
+	// Filename of the file on the client; its extension must match the content type
+	// Required: true
+	// Example: resume.pdf
+	Filename string `json:"filename" validate:"required,max=255" example:"resume.pdf"`
+
+	// ContentType of the file, sent again as the Content-Type of the PUT
+	// Required: true
+	// Example: application/pdf
+	ContentType string `json:"content_type" validate:"required" example:"application/pdf"`
+
+	// Size of the file in bytes; the uploaded object must have exactly this size
+	// Required: true
+	// Example: 1024576
+	Size int64 `json:"size" validate:"required,min=1" example:"1024576"`
+}
+
+// PresignUploadResponse is where to PUT the content of an announced file
+// swagger:model
+type PresignUploadResponse struct {
+	// UploadID to pass to POST /files/complete once the content is uploaded
+	// Example: eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...
+	UploadID string `json:"upload_id" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+
+	// URL to send the content to
+	// Example: https://minio.example.com/bucket/user-123/resume_20231201-143052.pdf?X-Amz-Algorithm=...
+	URL string `json:"url" example:"https://minio.example.com/bucket/user-123/resume_20231201-143052.pdf?X-Amz-Algorithm=..."`
+
+	// Method of the upload request
+	// Example: PUT
+	Method string `json:"method" example:"PUT"`
+
+	// Headers to send with the upload exactly as given
+	Headers map[string]string `json:"headers"`
+
+	// Path the file will have once completed
+	// Example: user-123/resume_20231201-143052.pdf
+	Path string `json:"path" example:"user-123/resume_20231201-143052.pdf"`
+
+	// ExpiresAt is when the URL stops accepting the upload
+	// Example: 2023-12-01T14:45:52Z
+	ExpiresAt time.Time `json:"expires_at" example:"2023-12-01T14:45:52Z"`
+}
+
+// CompleteUploadRequest finishes an upload sent to a presigned URL
+// swagger:model
+type CompleteUploadRequest struct {
+	// UploadID returned by POST /files/presign-upload
+	// Required: true
+	// Example: eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...
+	UploadID string `json:"upload_id" validate:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+}
+
+// CreateUploadRequest announces a file the client will send in chunks
+// swagger:model
+type CreateUploadRequest struct {
+	// Type of the file to upload
+	// Required: true
+	// Enum: profile_image,cv
+	// Example: cv
+	Type string `json:"type" validate:"required,oneof=profile_image cv" example:"cv"`
+
+	// Filename of the file on the client; its extension must match the content type
+	// Required: true
+	// Example: resume.pdf
+	Filename string `json:"filename" validate:"required,max=255" example:"resume.pdf"`
+
+	// ContentType of the file
+	// Required: true
+	// Example: application/pdf
+	ContentType string `json:"content_type" validate:"required" example:"application/pdf"`
+
+	// Size of the file in bytes; the upload completes once this many bytes were received
+	// Required: true
+	// Example: 8388608
+	Size int64 `json:"size" validate:"required,min=1" example:"8388608"`
+}
+
+// ResumableUploadResponse is the state of a resumable upload
+// swagger:model
+type ResumableUploadResponse struct {
+	// ID of the upload, part of its URL
+	// Example: 550e8400-e29b-41d4-a716-446655440000
+	ID string `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+
+	// Offset is how many bytes were received, where the next chunk starts
+	// Example: 5242880
+	Offset int64 `json:"offset" example:"5242880"`
+
+	// Size announced for the file, in bytes
+	// Example: 8388608
+	Size int64 `json:"size" example:"8388608"`
+
+	// MinChunkSize is the smallest chunk accepted before the last one, in bytes
+	// Example: 5242880
+	MinChunkSize int64 `json:"min_chunk_size" example:"5242880"`
+
+	// ExpiresAt is when the upload is discarded unless another chunk arrives
+	// Example: 2023-12-02T14:30:52Z
+	ExpiresAt time.Time `json:"expires_at" example:"2023-12-02T14:30:52Z"`
+
+	// File is the uploaded file, once the last chunk was received
+	File *UploadResponse `json:"file,omitempty"`
+}
+
+// SetVisibilityRequest makes a file public or private
+// swagger:model
+type SetVisibilityRequest struct {
+	// Required: true
+	// Enum: private,public
+	// Example: public
+	Visibility string `json:"visibility" validate:"required,oneof=public private" example:"public"`
+}
+
+// CreateFolderRequest creates a folder, and the folders containing it
+// swagger:model
+type CreateFolderRequest struct {
+	// Path of the folder, with segments separated by slashes
+	// Required: true
+	// Example: projects/2024
+	Path string `json:"path" validate:"required,max=1024" example:"projects/2024"`
+}
+
+// FolderInfo describes a folder
+// swagger:model
+type FolderInfo struct {
+	// Path of the folder
+	// Example: projects/2024
+	Path string `json:"path" example:"projects/2024"`
+
+	// Name is the last segment of the path
+	// Example: 2024
+	Name string `json:"name" example:"2024"`
+}
+
+// FolderContentsResponse lists the folders and a page of the files directly in a folder
+// swagger:model
+type FolderContentsResponse struct {
+	// Path of the listed folder; empty for the top level
+	// Example: projects
+	Path string `json:"path" example:"projects"`
+
+	// Folders directly in the folder, all of them
+	Folders []FolderInfo `json:"folders"`
+
+	// Files directly in the folder, by name; total counts these
+	Files []FileInfo `json:"files"`
+}
+
+// MoveFileRequest moves a file to another folder, renames it, or both
+// swagger:model
+type MoveFileRequest struct {
+	// Folder to move the file to; "" is the top level. Omit to keep the file where it is.
+	// Example: projects/2024
+	Folder *string `json:"folder,omitempty" validate:"omitempty,max=1024" example:"projects/2024"`
+
+	// Name to give the file, with the same extension. Omit to keep its name.
+	// Example: report-final.pdf
+	Name string `json:"name,omitempty" validate:"omitempty,max=255" example:"report-final.pdf"`
 }
 
 // DeleteFileRequest represents the payload for deleting a file
@@ -124,7 +469,7 @@ type DeleteFileRequest struct {
 	// Path of the file to delete
 	// Required: true
 	// Example: user-123/profile_20231201-143052.jpg
-	Path string `json:"path" validate:"required,min=1"`
+	Path string `json:"path" validate:"required,min=1" example:"user-123/profile_20231201-143052.jpg"`
 }
 
 // SuccessResponse represents a generic success response
@@ -150,7 +495,7 @@ type FileQueryParams struct {
 	// in: path
 	// Required: true
 	// Example: user-123/profile_20231201-143052.jpg
-	Filename string `json:"filename"`
+	Filename string `json:"filename" example:"user-123/profile_20231201-143052.jpg"`
 }
 
 // UploadQueryParams represents query parameters for upload operation
@@ -161,5 +506,14 @@ type UploadQueryParams struct {
 	// Required: true
 	// Enum: profile_image,cv
 	// Example: profile_image
-	Type string `json:"type"`
+	Type string `json:"type" example:"profile_image"`
+}
+
+// ArchiveRequest lists the files to download together as one ZIP archive
+// swagger:model
+type ArchiveRequest struct {
+	// IDs of the files, all owned by the caller; repeated IDs are included once
+	// Required: true
+	// Example: ["3fa85f64-5717-4562-b3fc-2c963f66afa6"]
+	FileIDs []string `json:"file_ids" validate:"required,min=1,dive,uuid" example:"3fa85f64-5717-4562-b3fc-2c963f66afa6"`
 }
@@ -3,6 +3,8 @@ package model
 import (
 	"time"
 
+	"github.com/MurtadaNazar/go-api-template/internal/shared/id"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -18,51 +20,109 @@ const (
 	FileTypeCV FileType = "cv"
 )
 
+// ScanStatus is the result of scanning an upload for malware
+// swagger:enum ScanStatus
+type ScanStatus string
+
+const (
+	// ScanClean the scanner found nothing
+	ScanClean ScanStatus = "clean"
+	// ScanInfected the scanner found malware; such uploads are rejected and never stored
+	ScanInfected ScanStatus = "infected"
+)
+
+// Visibility controls who can download a file
+// swagger:enum Visibility
+type Visibility string
+
+const (
+	// VisibilityPrivate only the owner gets URLs to the file, signed and expiring
+	VisibilityPrivate Visibility = "private"
+	// VisibilityPublic anyone can download the file from a stable URL that never expires
+	VisibilityPublic Visibility = "public"
+)
+
+// Thumbnail is a scaled-down copy of an image file
+type Thumbnail struct {
+	// Size is the configured longest edge the thumbnail was made for
+	Size   int    `json:"size"`
+	Path   string `json:"path"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
 // File represents a file stored in the system with metadata
 // swagger:model File
 type File struct {
 	// ID is the unique identifier for the file
 	// example: 123e4567-e89b-12d3-a456-426614174000
 	// format: uuid
-	ID uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	ID uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
 
 	// UserID is the UUID of the user who owns this file
 	// example: 123e4567-e89b-12d3-a456-426614174000
 	// format: uuid
-	UserID uuid.UUID `gorm:"type:uuid;not null;index:idx_files_user_id" json:"user_id"`
+	UserID uuid.UUID `gorm:"type:uuid;not null;index:idx_files_user_type_uploaded,priority:1;index:idx_files_user_folder,priority:1" json:"user_id" example:"123e4567-e89b-12d3-a456-426614174000"`
 
 	// Path where the file is stored in the system
 	// example: /uploads/profile_images/123e4567-e89b-12d3-a456-426614174000.jpg
-	Path string `gorm:"type:varchar(1024);not null;uniqueIndex:idx_files_path" json:"path"`
+	Path string `gorm:"type:varchar(1024);not null;uniqueIndex:idx_files_path" json:"path" example:"/uploads/profile_images/123e4567-e89b-12d3-a456-426614174000.jpg"`
+
+	// Folder is the folder the file is in, without leading or trailing slashes; empty at
+	// the top level. The object is stored under <user>/<folder>/.
+	// example: projects/2024
+	Folder string `gorm:"type:varchar(1024);not null;default:'';index:idx_files_user_folder,priority:2" json:"folder" example:"projects/2024"`
 
 	// Type categorizes the purpose of the file
 	// enum: profile_image,cv
 	// example: profile_image
-	Type FileType `gorm:"type:varchar(50);not null;index:idx_files_type" json:"type"`
+	Type FileType `gorm:"type:varchar(50);not null;index:idx_files_type;index:idx_files_user_type_uploaded,priority:2" json:"type" example:"profile_image"`
 
 	// Size of the file in bytes
 	// example: 2048000
 	// minimum: 0
-	Size int64 `gorm:"type:bigint;not null;default:0" json:"size"`
+	Size int64 `gorm:"type:bigint;not null;default:0" json:"size" example:"2048000"`
 
 	// MimeType indicates the media type of the file
 	// example: image/jpeg
-	MimeType string `gorm:"type:varchar(255);not null" json:"mime_type"`
+	MimeType string `gorm:"type:varchar(255);not null" json:"mime_type" example:"image/jpeg"`
 
 	// OriginalName is the filename as uploaded by the user
 	// example: my_profile_picture.jpg
 	// max length: 512
-	OriginalName string `gorm:"type:varchar(512);not null" json:"original_name"`
+	OriginalName string `gorm:"type:varchar(512);not null" json:"original_name" example:"my_profile_picture.jpg"`
+
+	// Visibility tells whether the file is public; new files are private
+	// enum: private,public
+	// example: private
+	Visibility Visibility `gorm:"type:varchar(20);not null;default:'private'" json:"visibility" example:"private"`
+
+	// SHA256 is the hex-encoded SHA-256 of the stored content; empty for files uploaded
+	// before checksums were recorded
+	// example: 9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08
+	SHA256 string `gorm:"type:char(64)" json:"sha256,omitempty" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+
+	// ScanStatus is the malware scan result; empty when scanning is disabled
+	// enum: clean
+	// example: clean
+	ScanStatus ScanStatus `gorm:"type:varchar(20)" json:"scan_status,omitempty" example:"clean"`
+
+	// Thumbnails are the scaled-down copies of a profile image, stored under thumbnails/
+	Thumbnails []Thumbnail `gorm:"type:text;serializer:json" json:"thumbnails,omitempty"`
+
+	// ThumbnailsAt is when thumbnails were generated, or found impossible to generate;
+	// nil while they are pending and for files that get none
+	ThumbnailsAt *time.Time `json:"thumbnails_at,omitempty"`
 
 	// UploadedAt indicates when the file was uploaded
 	// example: 2023-10-05T14:30:00Z
 	// format: date-time
-	UploadedAt time.Time `gorm:"autoCreateTime" json:"uploaded_at"`
+	UploadedAt time.Time `gorm:"autoCreateTime;index:idx_files_user_type_uploaded,priority:3" json:"uploaded_at" example:"2023-10-05T14:30:00Z"`
 
 	// UpdatedAt shows when the file metadata was last modified
 	// example: 2023-10-05T14:30:00Z
 	// format: date-time
-	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at" example:"2023-10-05T14:30:00Z"`
 
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
@@ -70,7 +130,10 @@ type File struct {
 // BeforeCreate is a GORM hook that generates a UUID for the file if not already set
 func (f *File) BeforeCreate(tx *gorm.DB) (err error) {
 	if f.ID == uuid.Nil {
-		f.ID = uuid.New()
+		f.ID = id.New()
+	}
+	if f.Visibility == "" {
+		f.Visibility = VisibilityPrivate
 	}
 	return
 }
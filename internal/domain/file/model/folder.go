@@ -0,0 +1,81 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/MurtadaNazar/go-api-template/internal/shared/id"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// MaxFolderDepth is how many segments a folder path may have
+	MaxFolderDepth = 16
+	// MaxFolderSegment is the longest name, in characters, of one folder in a path
+	MaxFolderSegment = 128
+)
+
+// ErrInvalidFolder is returned by CleanFolder for paths that cannot name a folder
+var ErrInvalidFolder = errors.New("invalid folder path")
+
+// Folder is a folder a user created. Folders are prefixes of object names, so files are
+// in a folder by the path they are stored under; a row is kept so that empty folders
+// can be listed too. Creating a folder creates its ancestors.
+type Folder struct {
+	ID     uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_file_folders_user_path,priority:1;index:idx_file_folders_user_parent,priority:1" json:"user_id"`
+
+	// Path of the folder without leading or trailing slashes, e.g. projects/2024
+	Path string `gorm:"type:varchar(1024);not null;uniqueIndex:idx_file_folders_user_path,priority:2" json:"path"`
+	// Parent is the path of the folder containing this one; empty at the top level
+	Parent string `gorm:"type:varchar(1024);not null;default:'';index:idx_file_folders_user_parent,priority:2" json:"parent"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BeforeCreate generates the ID of the folder if not already set
+func (f *Folder) BeforeCreate(tx *gorm.DB) error {
+	if f.ID == uuid.Nil {
+		f.ID = id.New()
+	}
+	return nil
+}
+
+// TableName specifies the table of folders
+func (Folder) TableName() string {
+	return "file_folders"
+}
+
+// CleanFolder returns the canonical form of a folder path, without leading or trailing
+// slashes; "" and "/" are the top level. It returns ErrInvalidFolder for empty, "." and
+// ".." segments, control characters, backslashes and paths too long or too deep.
+func CleanFolder(folder string) (string, error) {
+	folder = strings.Trim(folder, "/")
+	if folder == "" {
+		return "", nil
+	}
+	segments := strings.Split(folder, "/")
+	if len(segments) > MaxFolderDepth {
+		return "", ErrInvalidFolder
+	}
+	for _, segment := range segments {
+		if segment == "" || segment == "." || segment == ".." || utf8.RuneCountInString(segment) > MaxFolderSegment ||
+			!utf8.ValidString(segment) || strings.ContainsFunc(segment, func(r rune) bool { return unicode.IsControl(r) || r == '\\' }) {
+			return "", ErrInvalidFolder
+		}
+	}
+	return folder, nil
+}
+
+// ParentFolder returns the folder containing folder; "" for top-level folders
+func ParentFolder(folder string) string {
+	if i := strings.LastIndex(folder, "/"); i >= 0 {
+		return folder[:i]
+	}
+	return ""
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/shared/id"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UploadPart is a chunk of a resumable upload, stored as one part of its multipart
+// upload in storage
+type UploadPart struct {
+	Number int    `json:"number"`
+	ETag   string `json:"etag"`
+	Size   int64  `json:"size"`
+}
+
+// Upload is a resumable upload in progress. Its chunks are stored as the parts of a
+// multipart upload and assembled into the file once all Size bytes were received; the
+// upload is then deleted. Uploads not written to until ExpiresAt are aborted.
+type Upload struct {
+	ID     uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+
+	// Path the file will have once assembled
+	Path         string   `gorm:"type:varchar(1024);not null" json:"path"`
+	Type         FileType `gorm:"type:varchar(50);not null" json:"type"`
+	Size         int64    `gorm:"type:bigint;not null" json:"size"`
+	MimeType     string   `gorm:"type:varchar(255);not null" json:"mime_type"`
+	OriginalName string   `gorm:"type:varchar(512);not null" json:"original_name"`
+
+	// Received is how many bytes were stored so far, the offset of the next chunk
+	Received int64 `gorm:"type:bigint;not null;default:0" json:"received"`
+	// MultipartID identifies the multipart upload in storage
+	MultipartID string       `gorm:"type:varchar(1024);not null" json:"-"`
+	Parts       []UploadPart `gorm:"type:text;serializer:json" json:"-"`
+	// NextPart is the last part number handed out; every chunk gets a new one, so chunks
+	// sent concurrently for the same offset never overwrite each other's part
+	NextPart int `gorm:"not null;default:0" json:"-"`
+
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// BeforeCreate generates the ID of the upload if not already set
+func (u *Upload) BeforeCreate(tx *gorm.DB) error {
+	if u.ID == uuid.Nil {
+		u.ID = id.New()
+	}
+	return nil
+}
+
+// TableName specifies the table of resumable uploads
+func (Upload) TableName() string {
+	return "file_uploads"
+}
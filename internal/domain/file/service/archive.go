@@ -0,0 +1,137 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/model"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/timing"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrArchiveTooManyFiles is returned by PrepareArchive for more files than
+	// FILE_ARCHIVE_MAX_FILES
+	ErrArchiveTooManyFiles = errors.New("too many files for one archive")
+	// ErrArchiveTooLarge is returned by PrepareArchive when the files add up to more than
+	// FILE_ARCHIVE_MAX_SIZE_MB
+	ErrArchiveTooLarge = errors.New("files are too large for one archive")
+	// ErrArchiveFileNotFound is returned, wrapped with the file ID, by PrepareArchive for
+	// files that do not exist
+	ErrArchiveFileNotFound = errors.New("file not found")
+	// ErrArchiveForbidden is returned, wrapped with the file ID, by PrepareArchive for
+	// files of other users
+	ErrArchiveForbidden = errors.New("file belongs to another user")
+)
+
+// PrepareArchive checks that userID may download the files with the given IDs as one
+// archive, and returns them in the order given, without duplicates. Nothing is written
+// before every file passed, so a refused archive can still be answered with an error.
+func (s *FileService) PrepareArchive(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) ([]model.File, error) {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	unique := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	if len(unique) > s.archive.MaxFiles {
+		return nil, ErrArchiveTooManyFiles
+	}
+
+	found, err := s.repo.GetFilesByIDs(ctx, unique)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]model.File, len(found))
+	for _, file := range found {
+		byID[file.ID] = file
+	}
+	files := make([]model.File, 0, len(unique))
+	var total int64
+	for _, id := range unique {
+		file, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrArchiveFileNotFound, id)
+		}
+		if file.UserID != userID {
+			return nil, fmt.Errorf("%w: %s", ErrArchiveForbidden, id)
+		}
+		total += file.Size
+		files = append(files, file)
+	}
+	if total > s.archive.MaxSize {
+		return nil, ErrArchiveTooLarge
+	}
+	return files, nil
+}
+
+// WriteArchive streams files from storage into a ZIP archive written to w, one at a
+// time, so the archive is never held in memory. Files are named by their folder and
+// original name. An error leaves w with a truncated archive.
+func (s *FileService) WriteArchive(ctx context.Context, w io.Writer, files []model.File) error {
+	done := timing.Start(ctx, "storage")
+	defer done()
+
+	archive := zip.NewWriter(w)
+	for i, name := range archiveNames(files) {
+		file := &files[i]
+		object, _, err := s.storage.Get(ctx, file.Path)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", file.ID, err)
+		}
+		method := zip.Store
+		if compressible(file.MimeType) {
+			method = zip.Deflate
+		}
+		entry, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: method, Modified: file.UploadedAt})
+		if err == nil {
+			_, err = io.Copy(entry, object)
+		}
+		object.Close()
+		if err != nil {
+			return fmt.Errorf("archive %s: %w", file.ID, err)
+		}
+	}
+	return archive.Close()
+}
+
+// archiveNames names the files in an archive by their folder and original name, adding
+// " (2)", " (3)", ... to repeated names
+func archiveNames(files []model.File) []string {
+	names := make([]string, len(files))
+	used := make(map[string]bool, len(files))
+	for i, file := range files {
+		base := path.Base(strings.ReplaceAll(file.OriginalName, "\\", "/"))
+		if base == "." || base == "/" || base == ".." {
+			base = path.Base(file.Path)
+		}
+		name := base
+		if file.Folder != "" {
+			name = file.Folder + "/" + base
+		}
+		ext := path.Ext(base)
+		stem := strings.TrimSuffix(name, ext)
+		for n := 2; used[name]; n++ {
+			name = stem + " (" + strconv.Itoa(n) + ")" + ext
+		}
+		used[name] = true
+		names[i] = name
+	}
+	return names
+}
+
+// compressible reports whether content of mimeType is worth compressing; images, PDFs
+// and Office documents already are compressed
+func compressible(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "text/") || mimeType == "application/json" ||
+		mimeType == "application/xml" || mimeType == "application/msword" || mimeType == "image/svg+xml"
+}
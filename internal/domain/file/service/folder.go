@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"path"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/repo"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/storage"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/timing"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// maxFileName is the longest name, in characters, a file can be renamed to
+	maxFileName = 255
+	// maxFolderPage is the most files ListFolder returns at once
+	maxFolderPage = 100
+)
+
+var (
+	// ErrFolderNotFound is returned by ListFolder for folders the user does not have
+	ErrFolderNotFound = errors.New("folder not found")
+	// ErrInvalidFileName is returned by MoveFile for names that are empty, too long or
+	// contain slashes or control characters
+	ErrInvalidFileName = errors.New("invalid file name")
+	// ErrRenameExtension is returned by MoveFile for a name whose extension differs from
+	// the file's; the extension was validated against the content at upload
+	ErrRenameExtension = errors.New("a file cannot be renamed to another extension")
+	// ErrDestinationExists is returned by MoveFile when an object is already stored where
+	// the file would be moved
+	ErrDestinationExists = errors.New("a file already exists at the destination")
+	// ErrMoveConflict is returned by MoveFile when the file was moved or deleted while
+	// it was being copied
+	ErrMoveConflict = errors.New("file was changed during the move")
+)
+
+// SetFolders enables folders, kept in folders
+func (s *FileService) SetFolders(folders repo.FolderRepo) {
+	s.folders = folders
+}
+
+// FoldersEnabled reports whether folders are available
+func (s *FileService) FoldersEnabled() bool {
+	return s.folders != nil
+}
+
+// CreateFolder creates a folder of the user, and the folders containing it, and returns
+// its canonical path. Creating a folder that exists is not an error.
+func (s *FileService) CreateFolder(ctx context.Context, userID uuid.UUID, folder string) (string, error) {
+	folder, err := model.CleanFolder(folder)
+	if err != nil {
+		return "", err
+	}
+	if folder == "" {
+		return "", model.ErrInvalidFolder
+	}
+	return folder, s.ensureFolders(ctx, userID, folder)
+}
+
+// ListFolder returns the folders directly in a folder of the user, a page of the files
+// directly in it, by name, and how many files it has. The top level is "".
+func (s *FileService) ListFolder(ctx context.Context, userID uuid.UUID, folder string, offset, limit int) ([]model.Folder, []model.File, int64, error) {
+	if offset < 0 || limit < 1 || limit > maxFolderPage {
+		return nil, nil, 0, apperrors.NewAppError(apperrors.BadRequestError, "offset must not be negative and limit must be between 1 and 100")
+	}
+	folder, err := model.CleanFolder(folder)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if folder != "" {
+		exists, err := s.folders.Exists(ctx, userID, folder)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		if !exists {
+			return nil, nil, 0, ErrFolderNotFound
+		}
+	}
+	folders, err := s.folders.ListChildren(ctx, userID, folder)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	files, total, err := s.repo.ListFolder(ctx, userID, folder, offset, limit)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return folders, files, total, nil
+}
+
+// MoveFile moves file to folder, when not nil, and renames it to name, when not empty.
+// Storage has no rename, so the object and its thumbnails are copied to their new names
+// before the metadata is updated, and the originals deleted afterwards; signed URLs and
+// share links issued for the old path stop working.
+func (s *FileService) MoveFile(ctx context.Context, file *model.File, folder *string, name string) (*model.File, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	moved := *file
+	if folder != nil {
+		cleaned, err := model.CleanFolder(*folder)
+		if err != nil {
+			return nil, err
+		}
+		moved.Folder = cleaned
+	}
+	base := path.Base(file.Path)
+	if name != "" && name != file.OriginalName {
+		if err := validateFileName(name); err != nil {
+			return nil, err
+		}
+		ext := path.Ext(name)
+		if !strings.EqualFold(ext, path.Ext(file.OriginalName)) {
+			return nil, ErrRenameExtension
+		}
+		// Named like new uploads, with a timestamp to prevent collisions
+		base = strings.TrimSuffix(name, ext) + "_" + time.Now().Format("20060102-150405") + ext
+		moved.OriginalName = name
+	}
+	moved.Path = folderObjectName(file.UserID, moved.Folder, base)
+
+	if moved.Path == file.Path {
+		if _, err := s.repo.MoveFile(ctx, file.Path, &moved); err != nil {
+			return nil, err
+		}
+		return &moved, nil
+	}
+
+	done := timing.Start(ctx, "storage")
+	defer done()
+	if _, err := s.storage.Stat(ctx, moved.Path); err == nil {
+		return nil, ErrDestinationExists
+	} else if !errors.Is(err, storage.ErrNotFound) {
+		return nil, err
+	}
+
+	// Copy the object and its thumbnails; the copies are removed when anything fails
+	var copies []string
+	err := s.storage.Copy(ctx, file.Path, moved.Path)
+	if err == nil {
+		copies = append(copies, moved.Path)
+		moved.Thumbnails = nil
+		for _, thumbnail := range file.Thumbnails {
+			from := thumbnail.Path
+			thumbnail.Path = thumbnailPath(moved.Path, thumbnail.Size, path.Ext(from))
+			if err = s.storage.Copy(ctx, from, thumbnail.Path); err != nil {
+				break
+			}
+			copies = append(copies, thumbnail.Path)
+			moved.Thumbnails = append(moved.Thumbnails, thumbnail)
+		}
+	}
+	if err == nil {
+		var ok bool
+		ok, err = s.repo.MoveFile(ctx, file.Path, &moved)
+		if err == nil && !ok {
+			err = ErrMoveConflict
+		}
+	}
+	if err != nil {
+		s.removeObjects(ctx, copies)
+		return nil, err
+	}
+
+	originals := []string{file.Path}
+	for _, thumbnail := range file.Thumbnails {
+		originals = append(originals, thumbnail.Path)
+	}
+	s.removeObjects(ctx, originals)
+	if moved.Folder != "" && s.FoldersEnabled() {
+		if err := s.ensureFolders(ctx, file.UserID, moved.Folder); err != nil {
+			s.logger.Warnw("failed to record folder of moved file", "folder", moved.Folder, "error", err)
+		}
+	}
+	return &moved, nil
+}
+
+// ensureFolders records folder and the folders containing it
+func (s *FileService) ensureFolders(ctx context.Context, userID uuid.UUID, folder string) error {
+	var folders []model.Folder
+	for p := folder; p != ""; p = model.ParentFolder(p) {
+		folders = append(folders, model.Folder{UserID: userID, Path: p, Parent: model.ParentFolder(p)})
+	}
+	return s.folders.Create(ctx, folders)
+}
+
+// removeObjects deletes objects that are no longer referenced, logging failures
+func (s *FileService) removeObjects(ctx context.Context, objectNames []string) {
+	ctx = context.WithoutCancel(ctx)
+	for _, objectName := range objectNames {
+		if err := s.storage.Delete(ctx, objectName); err != nil {
+			s.logger.Warnw("failed to remove object", "path", objectName, "error", err)
+		}
+	}
+}
+
+// folderObjectName returns where a file named base in a folder of userID is stored
+func folderObjectName(userID uuid.UUID, folder, base string) string {
+	if folder == "" {
+		return userID.String() + "/" + base
+	}
+	return userID.String() + "/" + folder + "/" + base
+}
+
+// validateFileName checks a name a file is renamed to
+func validateFileName(name string) error {
+	if strings.TrimSpace(name) == "" || utf8.RuneCountInString(name) > maxFileName || !utf8.ValidString(name) ||
+		name == "." || name == ".." || strings.ContainsFunc(name, func(r rune) bool { return unicode.IsControl(r) || r == '/' || r == '\\' }) {
+		return ErrInvalidFileName
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/model"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/clock"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/timing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// uploadAudience keeps upload IDs from being accepted as share or access tokens signed
+// with the same key, and the other way round
+const uploadAudience = "file-upload"
+
+// uploadCompleteGrace is how long after the presigned URL expires the upload can still
+// be completed, so that a transfer started just before the expiry is not lost
+const uploadCompleteGrace = time.Hour
+
+var (
+	// ErrInvalidUploadID is returned for malformed, tampered and expired upload IDs
+	ErrInvalidUploadID = errors.New("invalid or expired upload ID")
+	// ErrUploadNotOwned is returned by CompleteUpload when the upload was started by
+	// another user
+	ErrUploadNotOwned = errors.New("upload belongs to another user")
+	// ErrUploadMismatch is returned by CompleteUpload when the stored object differs from
+	// the size or content type announced when the upload was started; the object is removed
+	ErrUploadMismatch = errors.New("uploaded object does not match the announced file")
+)
+
+// UploadClaims are the claims of an upload ID: the file the client announced and where
+// it may store it. Subject is the uploading user.
+type UploadClaims struct {
+	Path        string         `json:"path"`
+	Type        model.FileType `json:"type"`
+	Size        int64          `json:"size"`
+	ContentType string         `json:"content_type"`
+	Name        string         `json:"name"`
+	jwt.RegisteredClaims
+}
+
+// UploadTokens mints and checks upload IDs. They carry everything CompleteUpload needs,
+// so no pending upload is stored.
+type UploadTokens struct {
+	secret string
+	ttl    time.Duration
+	clock  clock.Clock
+}
+
+// NewUploadTokens returns tokens signed with secret whose presigned URLs last ttl
+func NewUploadTokens(secret string, ttl time.Duration) *UploadTokens {
+	return &UploadTokens{secret: secret, ttl: ttl, clock: clock.System()}
+}
+
+// SetClock replaces the clock used for token expiry
+func (t *UploadTokens) SetClock(c clock.Clock) {
+	t.clock = c
+}
+
+// Issue returns an upload ID for the file in claims, uploaded by claims.Subject, and
+// when the presigned URL issued with it expires; the ID stays valid uploadCompleteGrace
+// longer
+func (t *UploadTokens) Issue(claims UploadClaims) (string, time.Time, error) {
+	now := t.clock.Now()
+	urlExpiresAt := now.Add(t.ttl).Truncate(time.Second)
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        uuid.NewString(),
+		Subject:   claims.Subject,
+		Audience:  jwt.ClaimStrings{uploadAudience},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(urlExpiresAt.Add(uploadCompleteGrace)),
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(t.secret))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, urlExpiresAt, nil
+}
+
+// Parse checks an upload ID and returns its claims
+func (t *UploadTokens) Parse(token string) (*UploadClaims, error) {
+	claims := &UploadClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return []byte(t.secret), nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithAudience(uploadAudience),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(t.clock.Now),
+	)
+	if err != nil || claims.Path == "" || claims.Subject == "" {
+		return nil, ErrInvalidUploadID
+	}
+	return claims, nil
+}
+
+// PresignedUpload is where and how a client sends the content of a file to storage
+type PresignedUpload struct {
+	UploadID string
+	URL      string
+	// Headers must be sent with the PUT exactly as given; they are part of the signature
+	Headers   map[string]string
+	Path      string
+	ExpiresAt time.Time
+}
+
+// PresignUpload returns a URL the user can PUT the content of a file to, straight to
+// storage, and the upload ID to complete it with. The file must already be validated.
+func (s *FileService) PresignUpload(ctx context.Context, userID uuid.UUID, fType model.FileType, objectName string, size int64, contentType string, originalName string) (*PresignedUpload, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	uploadID, expiresAt, err := s.uploads.Issue(UploadClaims{
+		Path:             objectName,
+		Type:             fType,
+		Size:             size,
+		ContentType:      contentType,
+		Name:             originalName,
+		RegisteredClaims: jwt.RegisteredClaims{Subject: userID.String()},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Storages that can bind the content type or size reject a PUT with another one;
+	// both are checked by CompleteUpload too, for storages that cannot
+	done := timing.Start(ctx, "storage")
+	signed, err := s.storage.SignURL(ctx, http.MethodPut, objectName, expiresAt.Sub(s.uploads.clock.Now()), contentType, size)
+	done()
+	if err != nil {
+		return nil, err
+	}
+	return &PresignedUpload{
+		UploadID:  uploadID,
+		URL:       signed.URL,
+		Headers:   signed.Headers,
+		Path:      objectName,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// CompleteUpload checks the object of a presigned upload and records its metadata. The
+// object must have the announced size and content type, and pass the malware scan when
+// scanning is enabled; otherwise it is removed. Completing an upload twice returns the
+// file recorded the first time.
+//
+// Returns ErrInvalidUploadID, ErrUploadNotOwned, ErrObjectNotFound when nothing was
+// uploaded yet, ErrUploadMismatch or ErrInfected.
+func (s *FileService) CompleteUpload(ctx context.Context, userID uuid.UUID, uploadID string) (*model.File, error) {
+	claims, err := s.uploads.Parse(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Subject != userID.String() {
+		return nil, ErrUploadNotOwned
+	}
+	if existing, err := s.repo.GetFileByPath(ctx, claims.Path); err == nil {
+		return existing, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	done := timing.Start(ctx, "storage")
+	info, err := s.storage.Stat(ctx, claims.Path)
+	done()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size != claims.Size || info.ContentType != claims.ContentType {
+		s.logger.Warnw("presigned upload does not match its announcement",
+			"user_id", userID, "path", claims.Path,
+			"size", info.Size, "announced_size", claims.Size,
+			"content_type", info.ContentType, "announced_content_type", claims.ContentType,
+		)
+		s.removeRejected(ctx, claims.Path)
+		return nil, ErrUploadMismatch
+	}
+
+	sum, scanStatus, err := s.inspectObject(ctx, claims.Path)
+	if err != nil {
+		if errors.Is(err, ErrInfected) {
+			s.logger.Warnw("infected upload rejected", "user_id", userID, "original_name", claims.Name)
+			s.removeRejected(ctx, claims.Path)
+		}
+		return nil, err
+	}
+
+	file := &model.File{
+		UserID:       userID,
+		Path:         claims.Path,
+		Type:         claims.Type,
+		Size:         info.Size,
+		MimeType:     info.ContentType,
+		OriginalName: claims.Name,
+		SHA256:       sum,
+		ScanStatus:   scanStatus,
+	}
+	if err := s.repo.SaveFileMeta(ctx, file); err != nil {
+		return nil, err
+	}
+
+	s.fileStored(ctx, file)
+	return file, nil
+}
+
+// inspectObject reads an object once, hashing it and scanning it when a scanner is set.
+// It returns the hex-encoded SHA-256 and the scan status, or ErrInfected.
+func (s *FileService) inspectObject(ctx context.Context, objectName string) (string, model.ScanStatus, error) {
+	object, _, _, err := s.OpenObject(ctx, objectName)
+	if err != nil {
+		return "", "", err
+	}
+	defer object.Close()
+
+	hash := sha256.New()
+	content := io.TeeReader(object, hash)
+	var scanStatus model.ScanStatus
+	if s.scanner != nil {
+		done := timing.Start(ctx, "scan")
+		scanStatus, err = s.scanner.Scan(ctx, content)
+		done()
+		if err != nil {
+			return "", "", fmt.Errorf("scan upload: %w", err)
+		}
+		if scanStatus == model.ScanInfected {
+			return "", "", ErrInfected
+		}
+	}
+	// Hash whatever the scanner did not read
+	if _, err := io.Copy(hash, content); err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), scanStatus, nil
+}
+
+// removeRejected deletes an object that failed CompleteUpload's checks, even when the
+// request was cancelled
+func (s *FileService) removeRejected(ctx context.Context, objectName string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := s.storage.Delete(ctx, objectName); err != nil {
+		s.logger.Warnw("failed to remove rejected upload", "path", objectName, "error", err)
+	}
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/model"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/storage"
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func TestUploadTokens_IssueAndParse(t *testing.T) {
+	// Arrange
+	clk := testutil.NewFakeClock(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+	tokens := NewUploadTokens("test-upload-secret", 15*time.Minute)
+	tokens.SetClock(clk)
+	owner := uuid.New()
+
+	// Act
+	token, expiresAt, err := tokens.Issue(UploadClaims{
+		Path:             owner.String() + "/resume_20240115-120000.pdf",
+		Type:             model.FileTypeCV,
+		Size:             2048,
+		ContentType:      "application/pdf",
+		Name:             "resume.pdf",
+		RegisteredClaims: jwt.RegisteredClaims{Subject: owner.String()},
+	})
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	clk.Advance(15*time.Minute + 30*time.Minute)
+	claims, err := tokens.Parse(token)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Parse() within the completion grace error = %v", err)
+	}
+	if claims.Subject != owner.String() || claims.Path != owner.String()+"/resume_20240115-120000.pdf" ||
+		claims.Type != model.FileTypeCV || claims.Size != 2048 || claims.ContentType != "application/pdf" || claims.Name != "resume.pdf" {
+		t.Errorf("Parse() = %+v, want the announced file", claims)
+	}
+	if claims.ID == "" {
+		t.Error("Parse() returned no upload ID")
+	}
+	if want := time.Date(2024, 1, 15, 12, 15, 0, 0, time.UTC); !expiresAt.Equal(want) {
+		t.Errorf("URL expires at %v, want %v", expiresAt, want)
+	}
+}
+
+func TestUploadTokens_Rejects(t *testing.T) {
+	clk := testutil.NewFakeClock(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+	tokens := NewUploadTokens("test-upload-secret", 15*time.Minute)
+	tokens.SetClock(clk)
+	owner := uuid.New()
+	token, _, err := tokens.Issue(UploadClaims{
+		Path:             owner.String() + "/photo_20240115-120000.png",
+		RegisteredClaims: jwt.RegisteredClaims{Subject: owner.String()},
+	})
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+
+	t.Run("other secret", func(t *testing.T) {
+		other := NewUploadTokens("another-secret", 15*time.Minute)
+		other.SetClock(clk)
+		if _, err := other.Parse(token); !errors.Is(err, ErrInvalidUploadID) {
+			t.Errorf("Parse() error = %v, want ErrInvalidUploadID", err)
+		}
+	})
+	t.Run("share token with the same secret", func(t *testing.T) {
+		shares := NewShareTokens(config.FileShareConfig{Secret: "test-upload-secret", DefaultTTL: time.Hour, MaxTTL: time.Hour})
+		shares.SetClock(clk)
+		share, _, err := shares.Issue(owner, owner.String()+"/photo_20240115-120000.png", 0)
+		if err != nil {
+			t.Fatalf("Issue() error = %v", err)
+		}
+		if _, err := tokens.Parse(share); !errors.Is(err, ErrInvalidUploadID) {
+			t.Errorf("Parse() error = %v, want ErrInvalidUploadID", err)
+		}
+	})
+	t.Run("tampered", func(t *testing.T) {
+		if _, err := tokens.Parse(token + "x"); !errors.Is(err, ErrInvalidUploadID) {
+			t.Errorf("Parse() error = %v, want ErrInvalidUploadID", err)
+		}
+	})
+	t.Run("expired", func(t *testing.T) {
+		clk.Advance(15*time.Minute + uploadCompleteGrace + time.Second)
+		if _, err := tokens.Parse(token); !errors.Is(err, ErrInvalidUploadID) {
+			t.Errorf("Parse() error = %v, want ErrInvalidUploadID", err)
+		}
+	})
+}
+
+// expiryRecorder records the expiry storage is asked to sign URLs for
+type expiryRecorder struct {
+	storage.ObjectStorage
+	expiry time.Duration
+}
+
+func (r *expiryRecorder) SignURL(ctx context.Context, method, name string, expiry time.Duration, contentType string, size int64) (*storage.SignedRequest, error) {
+	r.expiry = expiry
+	return r.ObjectStorage.SignURL(ctx, method, name, expiry, contentType, size)
+}
+
+func TestFileService_PresignUpload_ExpiryFollowsClock(t *testing.T) {
+	// Arrange: the clock is far from the wall clock, as in tests and replays
+	owner := uuid.New()
+	svc, _, _ := newFolderTestService(t, owner)
+	recorder := &expiryRecorder{ObjectStorage: svc.storage}
+	svc.storage = recorder
+	svc.uploads = NewUploadTokens("secret-with-enough-length-for-hs256", 15*time.Minute)
+	svc.uploads.SetClock(testutil.NewFakeClock(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)))
+
+	// Act
+	upload, err := svc.PresignUpload(context.Background(), owner, model.FileTypeCV, owner.String()+"/resume.pdf", 1024, "application/pdf", "resume.pdf")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("PresignUpload() error = %v", err)
+	}
+	if recorder.expiry != 15*time.Minute {
+		t.Errorf("signed expiry = %v, want 15m", recorder.expiry)
+	}
+	if want := time.Date(2024, 1, 15, 12, 15, 0, 0, time.UTC); !upload.ExpiresAt.Equal(want) {
+		t.Errorf("ExpiresAt = %v, want %v", upload.ExpiresAt, want)
+	}
+}
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/model"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/storage"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/timing"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MinChunkSize is the smallest chunk accepted before the last one; storage rejects
+	// smaller parts of a multipart upload
+	MinChunkSize = 5 << 20
+	// maxUploadParts is the most parts a multipart upload can have
+	maxUploadParts = 10000
+	// uploadCleanupBatch bounds the expired uploads aborted per cleanup run
+	uploadCleanupBatch = 100
+)
+
+var (
+	// ErrUploadNotFound is returned for resumable uploads that do not exist, expired or
+	// were started by another user
+	ErrUploadNotFound = errors.New("upload not found")
+	// ErrOffsetMismatch is returned by WriteChunk when the chunk does not start where
+	// the upload stands, e.g. because another chunk was stored in the meantime
+	ErrOffsetMismatch = errors.New("chunk offset does not match the upload offset")
+	// ErrChunkSize is returned by WriteChunk for a chunk that goes past the announced
+	// size, or that is smaller than MinChunkSize without being the last one
+	ErrChunkSize = errors.New("invalid chunk size")
+	// ErrUploadParts is returned by WriteChunk when the upload used up its part numbers
+	ErrUploadParts = errors.New("upload has too many chunk attempts")
+)
+
+// ResumableEnabled reports whether resumable uploads are available; the storage must
+// support multipart uploads
+func (s *FileService) ResumableEnabled() bool {
+	return s.resumable != nil && s.multipart != nil
+}
+
+// CreateUpload starts a resumable upload of a file of size bytes, stored at objectName
+// once all of it was received. The file must already be validated.
+func (s *FileService) CreateUpload(ctx context.Context, userID uuid.UUID, fType model.FileType, objectName string, size int64, contentType string, originalName string) (*model.Upload, error) {
+	done := timing.Start(ctx, "storage")
+	multipartID, err := s.multipart.NewMultipart(ctx, objectName, objectOptions(userID, fType, contentType, originalName))
+	done()
+	if err != nil {
+		return nil, err
+	}
+
+	upload := &model.Upload{
+		UserID:       userID,
+		Path:         objectName,
+		Type:         fType,
+		Size:         size,
+		MimeType:     contentType,
+		OriginalName: originalName,
+		MultipartID:  multipartID,
+		ExpiresAt:    time.Now().Add(s.resumableTTL),
+	}
+	if err := s.resumable.Create(ctx, upload); err != nil {
+		s.abortMultipart(ctx, objectName, multipartID)
+		return nil, err
+	}
+	return upload, nil
+}
+
+// GetUpload returns a resumable upload of userID, or ErrUploadNotFound
+func (s *FileService) GetUpload(ctx context.Context, userID uuid.UUID, id string) (*model.Upload, error) {
+	uploadID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrUploadNotFound
+	}
+	upload, err := s.resumable.FindByID(ctx, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if upload == nil || upload.UserID != userID || !upload.ExpiresAt.After(time.Now()) {
+		return nil, ErrUploadNotFound
+	}
+	return upload, nil
+}
+
+// WriteChunk stores length bytes of r as the part of a resumable upload starting at
+// offset, which must be the number of bytes received so far. The chunk that completes
+// the upload assembles the file: it is checked like a presigned upload and recorded,
+// and returned with the upload. Repeating the last chunk with nothing left to send
+// (offset equal to the size, length 0) retries an assembly that failed.
+//
+// Returns ErrUploadNotFound, ErrOffsetMismatch, ErrChunkSize, ErrUploadParts,
+// ErrUploadMismatch or ErrInfected.
+func (s *FileService) WriteChunk(ctx context.Context, userID uuid.UUID, id string, offset int64, r io.Reader, length int64) (*model.Upload, *model.File, error) {
+	upload, err := s.GetUpload(ctx, userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if offset != upload.Received {
+		return upload, nil, ErrOffsetMismatch
+	}
+	if length < 0 || length > upload.Size-upload.Received {
+		return upload, nil, ErrChunkSize
+	}
+	if upload.Received == upload.Size {
+		file, err := s.assemble(ctx, upload)
+		return upload, file, err
+	}
+	if length == 0 || (length < MinChunkSize && offset+length < upload.Size) {
+		return upload, nil, ErrChunkSize
+	}
+
+	part, err := s.resumable.ClaimPart(ctx, upload.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if part > maxUploadParts {
+		return upload, nil, ErrUploadParts
+	}
+
+	done := timing.Start(ctx, "storage")
+	etag, err := s.multipart.PutPart(ctx, upload.Path, upload.MultipartID, part, r, length)
+	done()
+	if err != nil {
+		return nil, nil, fmt.Errorf("store chunk: %w", err)
+	}
+
+	upload.Parts = append(upload.Parts, model.UploadPart{Number: part, ETag: etag, Size: length})
+	upload.Received += length
+	upload.ExpiresAt = time.Now().Add(s.resumableTTL)
+	recorded, err := s.resumable.RecordPart(ctx, upload, offset)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !recorded {
+		// Another chunk for this offset was stored first; its part is the one that counts
+		upload.Received -= length
+		return upload, nil, ErrOffsetMismatch
+	}
+	if upload.Received < upload.Size {
+		return upload, nil, nil
+	}
+	file, err := s.assemble(ctx, upload)
+	return upload, file, err
+}
+
+// AbortUpload cancels a resumable upload of userID and discards what was received
+func (s *FileService) AbortUpload(ctx context.Context, userID uuid.UUID, id string) error {
+	upload, err := s.GetUpload(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	s.abortMultipart(ctx, upload.Path, upload.MultipartID)
+	return s.resumable.Delete(ctx, upload.ID)
+}
+
+// CleanupUploads aborts resumable uploads that expired, discarding their chunks
+func (s *FileService) CleanupUploads(ctx context.Context) error {
+	expired, err := s.resumable.ListExpired(ctx, time.Now(), uploadCleanupBatch)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, upload := range expired {
+		if err := s.multipart.AbortMultipart(ctx, upload.Path, upload.MultipartID); err != nil {
+			errs = append(errs, fmt.Errorf("abort upload %s: %w", upload.ID, err))
+			continue
+		}
+		if err := s.resumable.Delete(ctx, upload.ID); err != nil {
+			errs = append(errs, fmt.Errorf("delete upload %s: %w", upload.ID, err))
+		}
+	}
+	if len(expired) > 0 {
+		s.logger.Infow("aborted expired uploads", "count", len(expired)-len(errs))
+	}
+	return apperrors.Join(errs...)
+}
+
+// assemble joins the parts of a fully received upload into its object, checks it like
+// CompleteUpload does and records the file. An object assembled by an earlier attempt
+// is checked again rather than assembled twice, and a file already recorded is returned.
+func (s *FileService) assemble(ctx context.Context, upload *model.Upload) (*model.File, error) {
+	if existing, err := s.repo.GetFileByPath(ctx, upload.Path); err == nil {
+		if err := s.resumable.Delete(ctx, upload.ID); err != nil {
+			s.logger.Warnw("failed to delete completed upload", "upload_id", upload.ID, "error", err)
+		}
+		return existing, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	done := timing.Start(ctx, "storage")
+	info, err := s.storage.Stat(ctx, upload.Path)
+	if errors.Is(err, storage.ErrNotFound) {
+		parts := make([]storage.Part, len(upload.Parts))
+		for i, part := range upload.Parts {
+			parts[i] = storage.Part{Number: part.Number, ETag: part.ETag}
+		}
+		// Part numbers are handed out in order but recorded in the order chunks finished
+		sort.Slice(parts, func(i, j int) bool { return parts[i].Number < parts[j].Number })
+		opts := objectOptions(upload.UserID, upload.Type, upload.MimeType, upload.OriginalName)
+		if err = s.multipart.CompleteMultipart(ctx, upload.Path, upload.MultipartID, parts, opts); err == nil {
+			info, err = s.storage.Stat(ctx, upload.Path)
+		}
+	}
+	done()
+	if err != nil {
+		return nil, fmt.Errorf("assemble upload: %w", err)
+	}
+
+	if info.Size != upload.Size {
+		s.logger.Warnw("assembled upload does not match its announcement",
+			"user_id", upload.UserID, "path", upload.Path, "size", info.Size, "announced_size", upload.Size)
+		s.discardUpload(ctx, upload)
+		return nil, ErrUploadMismatch
+	}
+	sum, scanStatus, err := s.inspectObject(ctx, upload.Path)
+	if err != nil {
+		if errors.Is(err, ErrInfected) {
+			s.logger.Warnw("infected upload rejected", "user_id", upload.UserID, "original_name", upload.OriginalName)
+			s.discardUpload(ctx, upload)
+		}
+		return nil, err
+	}
+
+	file := &model.File{
+		UserID:       upload.UserID,
+		Path:         upload.Path,
+		Type:         upload.Type,
+		Size:         info.Size,
+		MimeType:     upload.MimeType,
+		OriginalName: upload.OriginalName,
+		SHA256:       sum,
+		ScanStatus:   scanStatus,
+	}
+	if err := s.repo.SaveFileMeta(ctx, file); err != nil {
+		return nil, err
+	}
+	if err := s.resumable.Delete(ctx, upload.ID); err != nil {
+		s.logger.Warnw("failed to delete completed upload", "upload_id", upload.ID, "error", err)
+	}
+
+	s.fileStored(ctx, file)
+	return file, nil
+}
+
+// discardUpload removes the object of a rejected upload and the upload itself
+func (s *FileService) discardUpload(ctx context.Context, upload *model.Upload) {
+	s.removeRejected(ctx, upload.Path)
+	if err := s.resumable.Delete(context.WithoutCancel(ctx), upload.ID); err != nil {
+		s.logger.Warnw("failed to delete rejected upload", "upload_id", upload.ID, "error", err)
+	}
+}
+
+// abortMultipart discards the parts of a multipart upload, even when the request was
+// cancelled
+func (s *FileService) abortMultipart(ctx context.Context, objectName, multipartID string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := s.multipart.AbortMultipart(ctx, objectName, multipartID); err != nil {
+		s.logger.Warnw("failed to abort multipart upload", "path", objectName, "error", err)
+	}
+}
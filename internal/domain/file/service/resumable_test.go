@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/model"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// memoryUploadRepo keeps resumable uploads in a map
+type memoryUploadRepo struct {
+	uploads map[uuid.UUID]*model.Upload
+	claimed int
+}
+
+func (r *memoryUploadRepo) Create(_ context.Context, upload *model.Upload) error {
+	if upload.ID == uuid.Nil {
+		upload.ID = uuid.New()
+	}
+	r.uploads[upload.ID] = upload
+	return nil
+}
+
+func (r *memoryUploadRepo) FindByID(_ context.Context, id uuid.UUID) (*model.Upload, error) {
+	upload, ok := r.uploads[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *upload
+	return &copied, nil
+}
+
+func (r *memoryUploadRepo) ClaimPart(_ context.Context, id uuid.UUID) (int, error) {
+	r.claimed++
+	r.uploads[id].NextPart++
+	return r.uploads[id].NextPart, nil
+}
+
+func (r *memoryUploadRepo) RecordPart(_ context.Context, upload *model.Upload, from int64) (bool, error) {
+	stored := r.uploads[upload.ID]
+	if stored.Received != from {
+		return false, nil
+	}
+	stored.Received, stored.Parts, stored.ExpiresAt = upload.Received, upload.Parts, upload.ExpiresAt
+	return true, nil
+}
+
+func (r *memoryUploadRepo) ListExpired(_ context.Context, now time.Time, limit int) ([]model.Upload, error) {
+	var expired []model.Upload
+	for _, upload := range r.uploads {
+		if !upload.ExpiresAt.After(now) && len(expired) < limit {
+			expired = append(expired, *upload)
+		}
+	}
+	return expired, nil
+}
+
+func (r *memoryUploadRepo) Delete(_ context.Context, id uuid.UUID) error {
+	delete(r.uploads, id)
+	return nil
+}
+
+func TestFileService_WriteChunk_Rejects(t *testing.T) {
+	// Arrange
+	owner := uuid.New()
+	uploads := &memoryUploadRepo{uploads: map[uuid.UUID]*model.Upload{}}
+	svc := &FileService{logger: zap.NewNop().Sugar(), resumable: uploads, resumableTTL: time.Hour}
+	upload := &model.Upload{
+		UserID:    owner,
+		Path:      owner.String() + "/resume_20240115-120000.pdf",
+		Type:      model.FileTypeCV,
+		Size:      8 << 20,
+		Received:  MinChunkSize,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	if err := uploads.Create(context.Background(), upload); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	expired := &model.Upload{UserID: owner, Size: 1024, ExpiresAt: time.Now().Add(-time.Minute)}
+	if err := uploads.Create(context.Background(), expired); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	id := upload.ID.String()
+
+	tests := []struct {
+		name   string
+		userID uuid.UUID
+		id     string
+		offset int64
+		length int64
+		want   error
+	}{
+		{"unknown upload", owner, uuid.NewString(), MinChunkSize, 1024, ErrUploadNotFound},
+		{"malformed id", owner, "not-a-uuid", MinChunkSize, 1024, ErrUploadNotFound},
+		{"other user", uuid.New(), id, MinChunkSize, 1024, ErrUploadNotFound},
+		{"expired", owner, expired.ID.String(), 0, 1024, ErrUploadNotFound},
+		{"offset behind", owner, id, 0, MinChunkSize, ErrOffsetMismatch},
+		{"offset ahead", owner, id, 2 * MinChunkSize, 1024, ErrOffsetMismatch},
+		{"past the size", owner, id, MinChunkSize, 4 << 20, ErrChunkSize},
+		{"small chunk that is not the last", owner, id, MinChunkSize, 1 << 20, ErrChunkSize},
+		{"empty chunk", owner, id, MinChunkSize, 0, ErrChunkSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			_, file, err := svc.WriteChunk(context.Background(), tt.userID, tt.id, tt.offset, strings.NewReader(""), tt.length)
+
+			// Assert
+			if !errors.Is(err, tt.want) {
+				t.Errorf("WriteChunk() error = %v, want %v", err, tt.want)
+			}
+			if file != nil {
+				t.Errorf("WriteChunk() file = %+v, want nil", file)
+			}
+		})
+	}
+	if uploads.claimed != 0 {
+		t.Errorf("claimed %d parts for rejected chunks, want 0", uploads.claimed)
+	}
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/model"
+)
+
+// Scanner checks upload content for malware
+type Scanner interface {
+	// Scan reads r to the end and reports whether it is clean. The error is for scans
+	// that could not complete, not for infected content.
+	Scan(ctx context.Context, r io.Reader) (model.ScanStatus, error)
+}
+
+// clamdChunkSize is the largest INSTREAM chunk sent to clamd; its StreamMaxLength limits
+// the total size separately
+const clamdChunkSize = 64 << 10
+
+// ClamAVScanner scans content with a clamd daemon over TCP using the INSTREAM command
+type ClamAVScanner struct {
+	addr    string
+	timeout time.Duration
+}
+
+// NewClamAVScanner returns a scanner for the clamd listening on addr (host:port); each
+// scan is bounded by timeout
+func NewClamAVScanner(addr string, timeout time.Duration) *ClamAVScanner {
+	return &ClamAVScanner{addr: addr, timeout: timeout}
+}
+
+func (s *ClamAVScanner) Scan(ctx context.Context, r io.Reader) (model.ScanStatus, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return "", fmt.Errorf("connect to clamd: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return "", fmt.Errorf("send to clamd: %w", err)
+	}
+	chunk := make([]byte, clamdChunkSize)
+	size := make([]byte, 4)
+	for {
+		n, readErr := r.Read(chunk)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err := conn.Write(append(size, chunk[:n]...)); err != nil {
+				return "", fmt.Errorf("send to clamd: %w", err)
+			}
+		}
+		if errors.Is(readErr, io.EOF) {
+			break
+		}
+		if readErr != nil {
+			return "", readErr
+		}
+	}
+	// A zero-length chunk ends the stream
+	if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return "", fmt.Errorf("send to clamd: %w", err)
+	}
+
+	reply, err := io.ReadAll(conn)
+	if err != nil {
+		return "", fmt.Errorf("read clamd reply: %w", err)
+	}
+	return parseClamdReply(string(bytes.TrimRight(reply, "\x00\n")))
+}
+
+// parseClamdReply maps "stream: OK" and "stream: <signature> FOUND" to a status; any other
+// reply, such as a size limit error, means the content was not scanned
+func parseClamdReply(reply string) (model.ScanStatus, error) {
+	result := strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case result == "OK":
+		return model.ScanClean, nil
+	case strings.HasSuffix(result, " FOUND"):
+		return model.ScanInfected, nil
+	default:
+		return "", fmt.Errorf("clamd: %s", reply)
+	}
+}
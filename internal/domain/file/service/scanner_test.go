@@ -0,0 +1,104 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/model"
+)
+
+// fakeClamd accepts one INSTREAM session, records the streamed content and answers with
+// reply
+func fakeClamd(t *testing.T, reply string) (addr string, received <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	content := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		if cmd, err := r.ReadString(0); err != nil || cmd != "zINSTREAM\x00" {
+			return
+		}
+		var streamed strings.Builder
+		for {
+			var size uint32
+			if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+				return
+			}
+			if size == 0 {
+				break
+			}
+			if _, err := io.CopyN(&streamed, r, int64(size)); err != nil {
+				return
+			}
+		}
+		content <- streamed.String()
+		_, _ = conn.Write([]byte(reply + "\x00"))
+	}()
+	return ln.Addr().String(), content
+}
+
+func TestClamAVScanner_Scan(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   string
+		want    model.ScanStatus
+		wantErr bool
+	}{
+		{name: "clean", reply: "stream: OK", want: model.ScanClean},
+		{name: "infected", reply: "stream: Eicar-Test-Signature FOUND", want: model.ScanInfected},
+		{name: "size limit", reply: "INSTREAM size limit exceeded. ERROR", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			addr, received := fakeClamd(t, tt.reply)
+			scanner := NewClamAVScanner(addr, 5*time.Second)
+			content := strings.Repeat("x", clamdChunkSize+10)
+
+			// Act
+			status, err := scanner.Scan(context.Background(), strings.NewReader(content))
+
+			// Assert
+			if (err != nil) != tt.wantErr || status != tt.want {
+				t.Fatalf("Scan() = %q, %v; want %q, error %v", status, err, tt.want, tt.wantErr)
+			}
+			if got := <-received; got != content {
+				t.Errorf("clamd received %d bytes, want %d", len(got), len(content))
+			}
+		})
+	}
+}
+
+func TestClamAVScanner_Scan_Unreachable(t *testing.T) {
+	// Arrange
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	// Act
+	_, err = NewClamAVScanner(addr, time.Second).Scan(context.Background(), strings.NewReader("data"))
+
+	// Assert
+	if err == nil {
+		t.Error("Scan() without clamd succeeded, want an error so the upload is refused")
+	}
+}
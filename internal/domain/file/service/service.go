@@ -1,123 +1,142 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"go_platform_template/internal/domain/file/model"
-	"go_platform_template/internal/domain/file/repo"
-	"go_platform_template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/repo"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/events"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/storage"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/timing"
 	"io"
-	"net/url"
+	"net/http"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/minio/minio-go/v7"
-	"github.com/minio/minio-go/v7/pkg/credentials"
 	"go.uber.org/zap"
 )
 
 // FileService handles file operations including upload, download, and signed URL generation
-// It integrates with MinIO for object storage and the database for metadata storage
+// It integrates with object storage for content and the database for metadata storage
 type FileService struct {
-	minioClient *minio.Client
-	bucket      string
-	repo        repo.FileRepo
-	logger      *zap.SugaredLogger
+	storage storage.ObjectStorage
+	// multipart assembles resumable uploads; nil when the storage cannot
+	multipart storage.Multipart
+	repo      repo.FileRepo
+	logger    *zap.SugaredLogger
+	// scanner checks uploads for malware before they are stored; nil disables scanning
+	scanner Scanner
+	// shares mints tokens that download a single file without signing in
+	shares *ShareTokens
+	// uploads mints the IDs of uploads sent straight to storage through presigned URLs
+	uploads *UploadTokens
+	// resumable stores resumable uploads in progress; nil disables them
+	resumable repo.UploadRepo
+	// resumableTTL is how long a resumable upload is kept after its last chunk
+	resumableTTL time.Duration
+	// folders stores the folders users created; nil disables folders
+	folders repo.FolderRepo
+	// shareLinks stores revocable share links; nil disables them
+	shareLinks repo.ShareLinkRepo
+	// publicBaseURL is followed by the file ID in the URLs of public files
+	publicBaseURL string
+	// publicMaxAge is how long public files may be cached
+	publicMaxAge time.Duration
+	// archive limits the files downloaded together as a ZIP archive
+	archive config.FileArchiveConfig
+	// thumbnailSizes are the longest edges of profile image thumbnails; empty disables them
+	thumbnailSizes []int
+	// thumbnails queues images for the thumbnail worker
+	thumbnails chan uuid.UUID
+	// events receive uploads, e.g. for the activity feed; nil sends none
+	events *events.Bus
 }
 
-// FileServiceConfig defines the configuration required for initializing FileService
-type FileServiceConfig struct {
-	Endpoint        string
-	AccessKeyID     string
-	SecretAccessKey string
-	Bucket          string
-	UseSSL          bool
-}
+var (
+	// ErrInfected is returned by Upload when the scanner found malware in the content
+	ErrInfected = errors.New("file is infected")
+	// ErrObjectNotFound is returned by Checksum when the object is missing from storage
+	ErrObjectNotFound = storage.ErrNotFound
+)
 
 // NewFileService creates a new instance of FileService with the provided configuration
-// It initializes the MinIO client and ensures the bucket exists
+// It connects to the storage backend selected by STORAGE_DRIVER, ensuring the bucket
+// or container exists
 //
 // Parameters:
 //   - repo: File repository for metadata operations
-//   - cfg: MinIO configuration from the main application config
+//   - cfg: Storage configuration from the main application config
 //   - logger: Logger for service operations
 //
 // Returns:
 //   - *FileService: Initialized file service instance
-//   - error: Any error encountered during MinIO client initialization or bucket creation
+//   - error: Any error encountered connecting to storage
 func NewFileService(fileRepo repo.FileRepo, cfg *config.Config, logger *zap.SugaredLogger) (*FileService, error) {
-	// Extract MinIO configuration from the main config
-	minioCfg := FileServiceConfig{
-		Endpoint:        cfg.MinIO.MinioEndpoint,
-		AccessKeyID:     cfg.MinIO.MinioAccessKey,
-		SecretAccessKey: cfg.MinIO.MinioSecretKey,
-		Bucket:          cfg.MinIO.MinioBucket,
-		UseSSL:          cfg.MinIO.MinioUseSSL,
-	}
-
-	// Initialize MinIO client
-	minioClient, err := minio.New(minioCfg.Endpoint, &minio.Options{
-		Creds:  credentials.NewStaticV4(minioCfg.AccessKeyID, minioCfg.SecretAccessKey, ""),
-		Secure: minioCfg.UseSSL,
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	// Verify connection and create bucket if it doesn't exist
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	exists, err := minioClient.BucketExists(ctx, minioCfg.Bucket)
+	objects, err := storage.New(ctx, cfg.Storage, cfg.MinIO, logger)
 	if err != nil {
 		return nil, err
 	}
-	if !exists {
-		logger.Infof("Creating MinIO bucket: %s", minioCfg.Bucket)
-		err = minioClient.MakeBucket(ctx, minioCfg.Bucket, minio.MakeBucketOptions{})
-		if err != nil {
-			return nil, err
-		}
+	return NewFileServiceWithStorage(objects, fileRepo, cfg, logger), nil
+}
 
-		// Set bucket policy for public read access (adjust based on your requirements)
-		bucketPolicy := fmt.Sprintf(`{
-			"Version": "2012-10-17",
-			"Statement": [
-				{
-					"Effect": "Allow",
-					"Principal": {"AWS": ["*"]},
-					"Action": [
-						"s3:GetObject",
-						"s3:PutObject",
-						"s3:DeleteObject",
-						"s3:ListBucket"
-					],
-					"Resource": [
-						"arn:aws:s3:::%s",
-						"arn:aws:s3:::%s/*"
-					]
-				}
-			]
-		}`, minioCfg.Bucket, minioCfg.Bucket)
-
-		if err := minioClient.SetBucketPolicy(ctx, minioCfg.Bucket, bucketPolicy); err != nil {
-			logger.Warnf("Failed to set bucket policy: %v", err)
-		}
-	} else {
-		logger.Infof("Using existing MinIO bucket: %s", minioCfg.Bucket)
+// NewFileServiceWithStorage creates a FileService over objects, e.g. a storage built by
+// the caller
+func NewFileServiceWithStorage(objects storage.ObjectStorage, fileRepo repo.FileRepo, cfg *config.Config, logger *zap.SugaredLogger) *FileService {
+	svc := &FileService{
+		storage:       objects,
+		repo:          fileRepo,
+		logger:        logger,
+		shares:        NewShareTokens(cfg.FileShare),
+		uploads:       NewUploadTokens(cfg.FileShare.Secret, cfg.FileUpload.PresignTTL),
+		resumableTTL:  cfg.FileUpload.ResumableTTL,
+		publicBaseURL: cfg.FilePublic.BaseURL,
+		publicMaxAge:  cfg.FilePublic.MaxAge,
+		archive:       cfg.FileArchive,
+	}
+	svc.multipart, _ = objects.(storage.Multipart)
+	if len(cfg.Thumbnails.Sizes) > 0 {
+		svc.thumbnailSizes = cfg.Thumbnails.Sizes
+		svc.thumbnails = make(chan uuid.UUID, thumbnailQueueSize)
 	}
+	if cfg.FileScan.ClamdAddr != "" {
+		svc.scanner = NewClamAVScanner(cfg.FileScan.ClamdAddr, cfg.FileScan.Timeout)
+		logger.Infof("Scanning uploads with clamd at %s", cfg.FileScan.ClamdAddr)
+	}
+	return svc
+}
+
+// Storage returns the object storage the service keeps file content in
+func (s *FileService) Storage() storage.ObjectStorage {
+	return s.storage
+}
 
-	return &FileService{
-		minioClient: minioClient,
-		bucket:      minioCfg.Bucket,
-		repo:        fileRepo,
-		logger:      logger,
-	}, nil
+// SetScanner replaces the malware scanner; nil disables scanning
+func (s *FileService) SetScanner(scanner Scanner) {
+	s.scanner = scanner
 }
 
-// Upload handles file upload to MinIO storage and saves metadata to database
+// SetResumableUploads enables resumable uploads, kept in uploads while in progress
+func (s *FileService) SetResumableUploads(uploads repo.UploadRepo) {
+	s.resumable = uploads
+}
+
+// SetEvents publishes a file.uploaded event on bus for every upload
+func (s *FileService) SetEvents(bus *events.Bus) {
+	s.events = bus
+}
+
+// Upload handles file upload to object storage and saves metadata to database
 //
 // Parameters:
+//   - ctx: Request context; cancelling it aborts the upload
 //   - userID: ID of the user uploading the file
 //   - fType: Type of the file (e.g., image, document, video)
 //   - fileReader: Reader interface for the file content
@@ -127,21 +146,45 @@ func NewFileService(fileRepo repo.FileRepo, cfg *config.Config, logger *zap.Suga
 //   - originalName: Original filename as uploaded by the user
 //
 // Returns:
-//   - *model.File: File metadata including generated path and ID
-//   - error: Any error encountered during upload or metadata save
-func (s *FileService) Upload(userID uuid.UUID, fType model.FileType, fileReader io.Reader, objectName string, size int64, contentType string, originalName string) (*model.File, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+//   - *model.File: File metadata including generated path, ID and SHA-256
+//   - error: Any error encountered during scanning, upload or metadata save; ErrInfected
+//     when the scanner found malware
+func (s *FileService) Upload(ctx context.Context, userID uuid.UUID, fType model.FileType, fileReader io.Reader, objectName string, size int64, contentType string, originalName string) (*model.File, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
-	// Upload file to MinIO
-	_, err := s.minioClient.PutObject(ctx, s.bucket, objectName, fileReader, size, minio.PutObjectOptions{
-		ContentType: contentType,
-		UserMetadata: map[string]string{
-			"uploaded-by":   userID.String(),
-			"file-type":     string(fType),
-			"original-name": originalName,
-		},
-	})
+	// Scan before anything is stored; the content is read twice, so it must be seekable
+	var scanStatus model.ScanStatus
+	if s.scanner != nil {
+		content, ok := fileReader.(io.ReadSeeker)
+		if !ok {
+			data, err := io.ReadAll(fileReader)
+			if err != nil {
+				return nil, err
+			}
+			content = bytes.NewReader(data)
+		}
+		done := timing.Start(ctx, "scan")
+		status, err := s.scanner.Scan(ctx, content)
+		done()
+		if err != nil {
+			return nil, fmt.Errorf("scan upload: %w", err)
+		}
+		if status == model.ScanInfected {
+			s.logger.Warnw("infected upload rejected", "user_id", userID, "original_name", originalName)
+			return nil, ErrInfected
+		}
+		if _, err := content.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		scanStatus, fileReader = status, content
+	}
+
+	// Upload file to storage, hashing the content on the way
+	hash := sha256.New()
+	done := timing.Start(ctx, "storage")
+	err := s.storage.Put(ctx, objectName, io.TeeReader(fileReader, hash), size, objectOptions(userID, fType, contentType, originalName))
+	done()
 	if err != nil {
 		return nil, err
 	}
@@ -154,64 +197,102 @@ func (s *FileService) Upload(userID uuid.UUID, fType model.FileType, fileReader
 		Size:         size,
 		MimeType:     contentType,
 		OriginalName: originalName,
+		SHA256:       hex.EncodeToString(hash.Sum(nil)),
+		ScanStatus:   scanStatus,
 	}
 
 	// Save metadata to database
 	if err := s.repo.SaveFileMeta(ctx, file); err != nil {
-		// If database save fails, attempt to clean up the uploaded file
-		cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		// If database save fails, attempt to clean up the uploaded file, even when the
+		// failure was the request being cancelled
+		cleanupCtx, cleanupCancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
 		defer cleanupCancel()
-		if cleanupErr := s.minioClient.RemoveObject(cleanupCtx, s.bucket, objectName, minio.RemoveObjectOptions{}); cleanupErr != nil {
+		if cleanupErr := s.storage.Delete(cleanupCtx, objectName); cleanupErr != nil {
 			s.logger.Warnf("Failed to cleanup file after metadata save failure: %v", cleanupErr)
 		}
 		return nil, err
 	}
 
+	s.fileStored(ctx, file)
 	return file, nil
 }
 
+// objectOptions describes the object of an uploaded file
+func objectOptions(userID uuid.UUID, fType model.FileType, contentType, originalName string) storage.PutOptions {
+	return storage.PutOptions{
+		ContentType: contentType,
+		Metadata: map[string]string{
+			"uploaded-by":   userID.String(),
+			"file-type":     string(fType),
+			"original-name": originalName,
+		},
+	}
+}
+
+// fileStored announces a file whose metadata was just recorded and queues its
+// thumbnails
+func (s *FileService) fileStored(ctx context.Context, file *model.File) {
+	s.events.Publish(ctx, events.Event{Type: events.FileUploaded, UserID: file.UserID.String(), Data: map[string]any{
+		"file_id": file.ID,
+		"name":    file.OriginalName,
+		"type":    file.Type,
+		"size":    file.Size,
+	}})
+	s.queueThumbnails(file)
+}
+
 // GetSignedURL generates a pre-signed URL for temporary access to a file
 // The signed URL can be used to download the file without requiring authentication
 // for the specified duration
 //
 // Parameters:
+//   - ctx: Request context
 //   - objectName: Name of the object in storage
 //   - expiry: Duration for which the signed URL should be valid
 //
 // Returns:
 //   - string: Pre-signed URL for accessing the file
 //   - error: Any error encountered during URL generation
-func (s *FileService) GetSignedURL(objectName string, expiry time.Duration) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *FileService) GetSignedURL(ctx context.Context, objectName string, expiry time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	reqParams := make(url.Values)
-	url, err := s.minioClient.PresignedGetObject(ctx, s.bucket, objectName, expiry, reqParams)
+	done := timing.Start(ctx, "storage")
+	signed, err := s.storage.SignURL(ctx, http.MethodGet, objectName, expiry, "", 0)
+	done()
 	if err != nil {
 		return "", err
 	}
-	return url.String(), nil
+	return signed.URL, nil
 }
 
-// Delete removes a file from both MinIO storage and the metadata database
+// Delete removes a file and its thumbnails from both object storage and the metadata
+// database
 //
 // Parameters:
+//   - ctx: Request context
 //   - objectName: Name of the object to delete
 //
 // Returns:
 //   - error: Any error encountered during deletion
-func (s *FileService) Delete(objectName string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (s *FileService) Delete(ctx context.Context, objectName string) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	// Delete from MinIO storage
-	err := s.minioClient.RemoveObject(ctx, s.bucket, objectName, minio.RemoveObjectOptions{})
+	// Delete from object storage
+	done := timing.Start(ctx, "storage")
+	err := s.storage.Delete(ctx, objectName)
+	done()
 	if err != nil {
 		return err
 	}
 
+	if file, err := s.repo.GetFileByPath(ctx, objectName); err == nil {
+		s.removeThumbnails(ctx, file)
+	}
+
 	// Delete metadata from database
-	if err := s.repo.DeleteFileMeta(context.Background(), objectName); err != nil {
+	if err := s.repo.DeleteFileMeta(ctx, objectName); err != nil {
 		s.logger.Warnf("Failed to delete file metadata for %s: %v", objectName, err)
 		// Don't return error here as the main storage object was deleted successfully
 	}
@@ -219,21 +300,44 @@ func (s *FileService) Delete(objectName string) error {
 	return nil
 }
 
-// FileExists checks if a file exists in MinIO storage
+// PutObject stores content under objectName without recording file metadata, for objects
+// such as exports whose lifetime is tracked elsewhere
+func (s *FileService) PutObject(ctx context.Context, objectName string, reader io.Reader, size int64, contentType string) error {
+	done := timing.Start(ctx, "storage")
+	err := s.storage.Put(ctx, objectName, reader, size, storage.PutOptions{ContentType: contentType})
+	done()
+	return err
+}
+
+// RemoveObject deletes an object stored with PutObject
+func (s *FileService) RemoveObject(ctx context.Context, objectName string) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	done := timing.Start(ctx, "storage")
+	err := s.storage.Delete(ctx, objectName)
+	done()
+	return err
+}
+
+// FileExists checks if a file exists in object storage
 //
 // Parameters:
+//   - ctx: Request context
 //   - objectName: Name of the object to check
 //
 // Returns:
 //   - bool: true if file exists, false otherwise
 //   - error: Any error encountered during the check
-func (s *FileService) FileExists(objectName string) (bool, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (s *FileService) FileExists(ctx context.Context, objectName string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	_, err := s.minioClient.StatObject(ctx, s.bucket, objectName, minio.StatObjectOptions{})
+	done := timing.Start(ctx, "storage")
+	_, err := s.storage.Stat(ctx, objectName)
+	done()
 	if err != nil {
-		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		if errors.Is(err, storage.ErrNotFound) {
 			return false, nil
 		}
 		return false, err
@@ -242,6 +346,32 @@ func (s *FileService) FileExists(objectName string) (bool, error) {
 	return true, nil
 }
 
+// Checksum reads an object back from storage and returns its SHA-256 (hex-encoded) and
+// size, or ErrObjectNotFound when it is missing
+func (s *FileService) Checksum(ctx context.Context, objectName string) (string, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	done := timing.Start(ctx, "storage")
+	defer done()
+	object, _, err := s.storage.Get(ctx, objectName)
+	if err != nil {
+		return "", 0, err
+	}
+	defer object.Close()
+
+	hash := sha256.New()
+	n, err := io.Copy(hash, object)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), n, nil
+}
+
+func (s *FileService) GetFileByID(ctx context.Context, id string) (*model.File, error) {
+	return s.repo.GetFileByID(ctx, id)
+}
+
 func (s *FileService) GetFileByPath(ctx context.Context, objectName string) (*model.File, error) {
 	return s.repo.GetFileByPath(ctx, objectName)
 }
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/repo"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrShareLinkNotFound is returned for share links that do not exist, were revoked,
+	// expired or used up their downloads, and for links to deleted files
+	ErrShareLinkNotFound = errors.New("share link not found or expired")
+	// ErrSharePassword is returned by OpenShareLink when the link needs a password and
+	// none, or the wrong one, was given
+	ErrSharePassword = errors.New("share link password required or incorrect")
+)
+
+// SetShareLinks enables share links, kept in links
+func (s *FileService) SetShareLinks(links repo.ShareLinkRepo) {
+	s.shareLinks = links
+}
+
+// ShareLinksEnabled reports whether share links are available
+func (s *FileService) ShareLinksEnabled() bool {
+	return s.shareLinks != nil
+}
+
+// CreateShareLink creates a link to file that expires after ttl, or FILE_SHARE_TTL when
+// ttl is 0, and returns it with its token. An empty password leaves the link open to
+// anyone with the token; maxDownloads 0 allows any number of downloads.
+func (s *FileService) CreateShareLink(ctx context.Context, file *model.File, ttl time.Duration, password string, maxDownloads int) (string, *model.ShareLink, error) {
+	if ttl == 0 {
+		ttl = s.shares.cfg.DefaultTTL
+	}
+	if ttl > s.shares.cfg.MaxTTL {
+		return "", nil, ErrShareTTL
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, fmt.Errorf("generate share link token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	link := &model.ShareLink{
+		FileID:       file.ID,
+		UserID:       file.UserID,
+		TokenHash:    hashShareToken(token),
+		ExpiresAt:    s.shares.clock.Now().Add(ttl),
+		MaxDownloads: maxDownloads,
+	}
+	if password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return "", nil, fmt.Errorf("hash share link password: %w", err)
+		}
+		link.PasswordHash = string(hash)
+	}
+	if err := s.shareLinks.Create(ctx, link); err != nil {
+		return "", nil, err
+	}
+	return token, link, nil
+}
+
+// OpenShareLink checks the token and password of a share link, counts a download and
+// returns the link and its file
+func (s *FileService) OpenShareLink(ctx context.Context, token, password string) (*model.ShareLink, *model.File, error) {
+	link, err := s.shareLinks.FindByHash(ctx, hashShareToken(token))
+	if err != nil {
+		return nil, nil, err
+	}
+	now := s.shares.clock.Now()
+	if link == nil || !link.Active(now) {
+		return nil, nil, ErrShareLinkNotFound
+	}
+	// The password is only checked for active links, so guesses cannot probe revoked ones
+	if link.PasswordHash != "" && bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(password)) != nil {
+		return nil, nil, ErrSharePassword
+	}
+	file, err := s.repo.GetFileByID(ctx, link.FileID.String())
+	if err != nil {
+		return nil, nil, ErrShareLinkNotFound
+	}
+
+	// Counted before the download starts; the last download cannot be taken twice
+	claimed, err := s.shareLinks.ClaimDownload(ctx, link.ID, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !claimed {
+		return nil, nil, ErrShareLinkNotFound
+	}
+	link.Downloads++
+	return link, file, nil
+}
+
+// ListShareLinks returns the share links of a file, newest first
+func (s *FileService) ListShareLinks(ctx context.Context, fileID uuid.UUID) ([]model.ShareLink, error) {
+	return s.shareLinks.ListByFile(ctx, fileID)
+}
+
+// RevokeShareLink revokes a share link of userID's files; ErrShareLinkNotFound when the
+// user has no such link
+func (s *FileService) RevokeShareLink(ctx context.Context, userID, linkID uuid.UUID) (*model.ShareLink, error) {
+	link, err := s.shareLinks.FindByID(ctx, linkID)
+	if err != nil {
+		return nil, err
+	}
+	if link == nil || link.UserID != userID {
+		return nil, ErrShareLinkNotFound
+	}
+	if link.RevokedAt == nil {
+		now := s.shares.clock.Now()
+		if err := s.shareLinks.Revoke(ctx, link.ID, now); err != nil {
+			return nil, err
+		}
+		link.RevokedAt = &now
+	}
+	return link, nil
+}
+
+// hashShareToken returns the hash share link tokens are stored as
+func hashShareToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"errors"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestShareTokens_IssueAndParse(t *testing.T) {
+	// Arrange
+	clk := testutil.NewFakeClock(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+	tokens := NewShareTokens(config.FileShareConfig{Secret: "test-share-secret", DefaultTTL: time.Hour, MaxTTL: 24 * time.Hour})
+	tokens.SetClock(clk)
+	owner := uuid.New()
+
+	// Act
+	token, expiresAt, err := tokens.Issue(owner, "user-123/report.pdf", 0)
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	claims, path, err := tokens.Parse(token)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if path != "user-123/report.pdf" || claims.Scope != "files:read:user-123/report.pdf" || claims.Subject != owner.String() {
+		t.Errorf("Parse() = %+v, %q, want the shared path and owner", claims, path)
+	}
+	if want := clk.Now().Add(time.Hour); !expiresAt.Equal(want) {
+		t.Errorf("expires at %v, want %v", expiresAt, want)
+	}
+}
+
+func TestShareTokens_Rejects(t *testing.T) {
+	cfg := config.FileShareConfig{Secret: "test-share-secret", DefaultTTL: time.Hour, MaxTTL: 24 * time.Hour}
+	clk := testutil.NewFakeClock(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+	tokens := NewShareTokens(cfg)
+	tokens.SetClock(clk)
+	token, _, err := tokens.Issue(uuid.New(), "user-123/report.pdf", time.Hour)
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	other := NewShareTokens(config.FileShareConfig{Secret: "another-secret", DefaultTTL: time.Hour, MaxTTL: time.Hour})
+	other.SetClock(clk)
+
+	t.Run("ttl above maximum", func(t *testing.T) {
+		if _, _, err := tokens.Issue(uuid.New(), "user-123/report.pdf", 48*time.Hour); !errors.Is(err, ErrShareTTL) {
+			t.Errorf("Issue() error = %v, want ErrShareTTL", err)
+		}
+	})
+	t.Run("other secret", func(t *testing.T) {
+		if _, _, err := other.Parse(token); !errors.Is(err, ErrInvalidShareToken) {
+			t.Errorf("Parse() error = %v, want ErrInvalidShareToken", err)
+		}
+	})
+	t.Run("expired", func(t *testing.T) {
+		clk.Advance(time.Hour + time.Second)
+		if _, _, err := tokens.Parse(token); !errors.Is(err, ErrInvalidShareToken) {
+			t.Errorf("Parse() error = %v, want ErrInvalidShareToken", err)
+		}
+	})
+}
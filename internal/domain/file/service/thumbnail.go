@@ -0,0 +1,217 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // register the GIF decoder
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/model"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// ThumbnailPrefix starts the object names of thumbnails
+	ThumbnailPrefix = "thumbnails/"
+	// thumbnailQueueSize bounds images waiting in memory for thumbnails; beyond it they
+	// are picked up by the next backfill
+	thumbnailQueueSize = 100
+	// thumbnailBackfillDelay keeps the backfill from queueing images that were just
+	// uploaded and are still on their way through the queue
+	thumbnailBackfillDelay = 5 * time.Minute
+	// thumbnailBackfillBatch bounds the images one backfill run queues
+	thumbnailBackfillBatch = 100
+	// maxThumbnailSource bounds the bytes read from an image
+	maxThumbnailSource = 64 << 20
+	// maxThumbnailPixels bounds the decoded size of an image, so small files that
+	// decode to huge bitmaps are skipped
+	maxThumbnailPixels = 40_000_000
+)
+
+// errNoThumbnail marks images thumbnails cannot be made for, e.g. SVG and WebP
+var errNoThumbnail = errors.New("image format not supported for thumbnails")
+
+// ThumbnailsEnabled reports whether thumbnails are generated for profile images
+func (s *FileService) ThumbnailsEnabled() bool {
+	return len(s.thumbnailSizes) > 0
+}
+
+// StartThumbnails generates the thumbnails of queued images until ctx is cancelled
+func (s *FileService) StartThumbnails(ctx context.Context) {
+	if !s.ThumbnailsEnabled() {
+		return
+	}
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case id := <-s.thumbnails:
+				runCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+				if err := s.GenerateThumbnails(runCtx, id); err != nil {
+					s.logger.Warnw("failed to generate thumbnails", "file_id", id, "error", err)
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
+// BackfillThumbnails queues profile images whose thumbnails were not generated, e.g.
+// because the queue was full or the instance stopped first
+func (s *FileService) BackfillThumbnails(ctx context.Context) error {
+	if !s.ThumbnailsEnabled() {
+		return nil
+	}
+	files, err := s.repo.ListMissingThumbnails(ctx, time.Now().Add(-thumbnailBackfillDelay), thumbnailBackfillBatch)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		s.queueThumbnails(&file)
+	}
+	return nil
+}
+
+// GenerateThumbnails stores a thumbnail of the image file for every configured size
+// and records them. Images that cannot be decoded get no thumbnails and are not tried
+// again.
+func (s *FileService) GenerateThumbnails(ctx context.Context, id uuid.UUID) error {
+	file, err := s.repo.GetFileByID(ctx, id.String())
+	if err != nil {
+		return err
+	}
+	if file.ThumbnailsAt != nil {
+		return nil
+	}
+
+	img, format, err := s.decodeImage(ctx, file.Path)
+	if errors.Is(err, errNoThumbnail) || errors.Is(err, ErrObjectNotFound) {
+		s.logger.Infow("no thumbnails for image", "file_id", file.ID, "mime_type", file.MimeType, "reason", err)
+		return s.repo.SetThumbnails(ctx, file.ID, nil, time.Now())
+	}
+	if err != nil {
+		return err
+	}
+
+	thumbnails := make([]model.Thumbnail, 0, len(s.thumbnailSizes))
+	for _, size := range s.thumbnailSizes {
+		scaled := scaleDown(img, size)
+		var buf bytes.Buffer
+		contentType, ext := "image/jpeg", ".jpg"
+		if format == "png" || format == "gif" {
+			// Keep transparency
+			contentType, ext = "image/png", ".png"
+			err = png.Encode(&buf, scaled)
+		} else {
+			err = jpeg.Encode(&buf, scaled, &jpeg.Options{Quality: 85})
+		}
+		if err != nil {
+			return fmt.Errorf("encode thumbnail: %w", err)
+		}
+
+		objectName := thumbnailPath(file.Path, size, ext)
+		if err := s.PutObject(ctx, objectName, &buf, int64(buf.Len()), contentType); err != nil {
+			return fmt.Errorf("store thumbnail: %w", err)
+		}
+		bounds := scaled.Bounds()
+		thumbnails = append(thumbnails, model.Thumbnail{Size: size, Path: objectName, Width: bounds.Dx(), Height: bounds.Dy()})
+	}
+	return s.repo.SetThumbnails(ctx, file.ID, thumbnails, time.Now())
+}
+
+// queueThumbnails hands an uploaded profile image to the thumbnail worker; when the
+// queue is full it is left to BackfillThumbnails
+func (s *FileService) queueThumbnails(file *model.File) {
+	if !s.ThumbnailsEnabled() || file.Type != model.FileTypeProfileImage {
+		return
+	}
+	select {
+	case s.thumbnails <- file.ID:
+	default:
+		s.logger.Warnw("thumbnail queue full; leaving the image to the backfill", "file_id", file.ID)
+	}
+}
+
+// removeThumbnails deletes the thumbnails of a file from storage
+func (s *FileService) removeThumbnails(ctx context.Context, file *model.File) {
+	for _, thumbnail := range file.Thumbnails {
+		if err := s.RemoveObject(ctx, thumbnail.Path); err != nil {
+			s.logger.Warnw("failed to remove thumbnail", "path", thumbnail.Path, "error", err)
+		}
+	}
+}
+
+// decodeImage reads and decodes the image stored at objectName, returning the name of
+// its format; errNoThumbnail when it is not a JPEG, PNG or GIF, or too large
+func (s *FileService) decodeImage(ctx context.Context, objectName string) (image.Image, string, error) {
+	object, _, _, err := s.OpenObject(ctx, objectName)
+	if err != nil {
+		return nil, "", err
+	}
+	defer object.Close()
+	data, err := io.ReadAll(io.LimitReader(object, maxThumbnailSource))
+	if err != nil {
+		return nil, "", err
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || cfg.Width*cfg.Height > maxThumbnailPixels {
+		return nil, "", errNoThumbnail
+	}
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", errNoThumbnail
+	}
+	return img, format, nil
+}
+
+// thumbnailPath names the thumbnail of size pixels of the object at objectName, e.g.
+// thumbnails/<user>/photo_20231201-143052_128.jpg
+func thumbnailPath(objectName string, size int, ext string) string {
+	base := strings.TrimSuffix(objectName, path.Ext(objectName))
+	return ThumbnailPrefix + base + "_" + strconv.Itoa(size) + ext
+}
+
+// scaleDown shrinks img so that its longest edge is size pixels, averaging the source
+// pixels each thumbnail pixel covers. Images that already fit are returned as they are.
+func scaleDown(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w <= size && h <= size {
+		return img
+	}
+	tw, th := size, max(h*size/w, 1)
+	if h > w {
+		tw, th = max(w*size/h, 1), size
+	}
+
+	dst := image.NewRGBA64(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0, y1 := bounds.Min.Y+y*h/th, bounds.Min.Y+(y+1)*h/th
+		for x := 0; x < tw; x++ {
+			x0, x1 := bounds.Min.X+x*w/tw, bounds.Min.X+(x+1)*w/tw
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := img.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n)})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,47 @@
+package api
+
+import "html/template"
+
+// loginPage is the sign-in form shown by the authorization endpoint. It posts the
+// authorization request back along with the credentials.
+var loginPage = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Sign in</title>
+<style>
+body { font-family: system-ui, sans-serif; background: #f4f5f7; display: flex; justify-content: center; padding-top: 10vh; }
+form { background: #fff; padding: 2rem; border-radius: 8px; box-shadow: 0 1px 4px rgba(0,0,0,.15); width: 20rem; }
+label { display: block; margin-top: 1rem; font-size: .9rem; }
+input { width: 100%; box-sizing: border-box; padding: .5rem; margin-top: .25rem; }
+button { margin-top: 1.5rem; width: 100%; padding: .6rem; }
+.error { color: #b00020; font-size: .9rem; }
+</style>
+</head>
+<body>
+<form method="post" action="">
+<h2>Sign in</h2>
+<p>to continue to <strong>{{.ClientName}}</strong></p>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+{{with .Request}}
+<input type="hidden" name="response_type" value="{{.ResponseType}}">
+<input type="hidden" name="client_id" value="{{.ClientID}}">
+<input type="hidden" name="redirect_uri" value="{{.RedirectURI}}">
+<input type="hidden" name="scope" value="{{.Scope}}">
+<input type="hidden" name="state" value="{{.State}}">
+<input type="hidden" name="nonce" value="{{.Nonce}}">
+<input type="hidden" name="code_challenge" value="{{.CodeChallenge}}">
+<input type="hidden" name="code_challenge_method" value="{{.CodeChallengeMethod}}">
+{{end}}
+<label>Email or username
+<input name="email_or_username" value="{{.EmailOrUsername}}" autocomplete="username" required autofocus></label>
+<label>Password
+<input name="password" type="password" autocomplete="current-password" required></label>
+{{if .AskOTP}}<label>Verification code
+<input name="otp" inputmode="numeric" autocomplete="one-time-code" required></label>{{end}}
+<button type="submit">Sign in</button>
+</form>
+</body>
+</html>
+`))
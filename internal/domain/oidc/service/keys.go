@@ -0,0 +1,55 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"os"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/oidc/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/jwk"
+)
+
+// SigningKey is the RSA key tokens are signed with; relying parties fetch its public
+// half from the JWKS endpoint
+type SigningKey struct {
+	private *rsa.PrivateKey
+	id      string
+}
+
+// LoadSigningKey reads a PEM encoded RSA private key (PKCS#1 or PKCS#8) from path
+func LoadSigningKey(path string) (*SigningKey, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := jwk.ParsePrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("parse %s: key is not an RSA key", path)
+	}
+	return NewSigningKey(key), nil
+}
+
+// GenerateSigningKey creates a temporary 2048-bit key
+func GenerateSigningKey() (*SigningKey, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	return NewSigningKey(key), nil
+}
+
+// NewSigningKey wraps key; its ID is derived from the public key so it is stable across restarts
+func NewSigningKey(key *rsa.PrivateKey) *SigningKey {
+	return &SigningKey{private: key, id: jwk.KeyID(&key.PublicKey)}
+}
+
+// JWK returns the public key in JSON Web Key form
+func (k *SigningKey) JWK() dto.JWK {
+	key, _ := jwk.New(&k.private.PublicKey)
+	return key
+}
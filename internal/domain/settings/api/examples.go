@@ -0,0 +1,14 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/settings/model"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/examples"
+)
+
+// Examples are the bodies of the settings snapshot routes, served under /docs/examples
+var Examples = []examples.Route{
+	{Method: http.MethodGet, Path: "/admin/config/export", Response: model.Snapshot{}, Bare: true},
+	{Method: http.MethodPost, Path: "/admin/config/import", Request: model.Snapshot{}, Response: model.ImportResult{}},
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"github.com/MurtadaNazar/go-api-template/internal/domain/settings/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/settings/service"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/validation"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type SettingsHandler struct {
+	service   service.Service
+	validator *validation.Validator
+	logger    *zap.SugaredLogger
+}
+
+func NewSettingsHandler(s service.Service, logger *zap.SugaredLogger) *SettingsHandler {
+	return &SettingsHandler{
+		service:   s,
+		validator: validation.Default(),
+		logger:    logger,
+	}
+}
+
+// Export godoc
+// @Summary Export runtime settings as a snapshot (requires config:manage)
+// @Description Profile fields and roles, ready to import into another environment.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} model.Snapshot
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /admin/config/export [get]
+func (h *SettingsHandler) Export(c *gin.Context) {
+	snapshot, err := h.service.Export(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="settings-`+snapshot.ExportedAt.Format("20060102-150405")+`.json"`)
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// Import godoc
+// @Summary Import a settings snapshot (requires config:manage)
+// @Description Creates and updates profile fields and roles; nothing is deleted. The snapshot is validated as a whole before any change.
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param dry_run query bool false "Report the changes without applying them"
+// @Param snapshot body model.Snapshot true "Snapshot from GET /admin/config/export"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Router /admin/config/import [post]
+func (h *SettingsHandler) Import(c *gin.Context) {
+	requestIDVal, _ := c.Get("RequestID")
+	requestID, ok := requestIDVal.(string)
+	if !ok {
+		requestID = "unknown"
+	}
+
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+
+	var snapshot model.Snapshot
+	if err := c.ShouldBindJSON(&snapshot); err != nil {
+		h.logger.Warnw("invalid settings snapshot", "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppErrorWithDetails(
+			apperrors.BadRequestError,
+			"Invalid request payload",
+			err.Error(),
+		))
+		return
+	}
+
+	if err := h.validator.ValidateStruct(&snapshot); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	result, err := h.service.Import(c.Request.Context(), &snapshot, dryRun)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(result, requestID))
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"time"
+
+	authzDto "github.com/MurtadaNazar/go-api-template/internal/domain/authz/dto"
+	userDto "github.com/MurtadaNazar/go-api-template/internal/domain/user/dto"
+)
+
+// SnapshotVersion is the format written by Export. Import accepts this version and older.
+const SnapshotVersion = 1
+
+// Snapshot holds the settings administrators change at runtime, in a form that can be
+// moved between environments: no IDs or timestamps, and records keyed by name.
+// swagger:model
+type Snapshot struct {
+	// Format version of the snapshot
+	// example: 1
+	Version int `json:"version" example:"1"`
+
+	// When the snapshot was taken
+	// format: date-time
+	ExportedAt time.Time `json:"exported_at"`
+
+	// Custom profile field definitions
+	ProfileFields []userDto.ProfileFieldRequest `json:"profile_fields" validate:"dive"`
+
+	// Roles and the permissions they grant. The admin role always has every
+	// permission, so its list is omitted.
+	Roles []authzDto.RoleCreateRequest `json:"roles" validate:"dive"`
+}
+
+// Changes lists what an import did (or would do, for a dry run) to one kind of record
+type Changes struct {
+	Created   []string `json:"created"`
+	Updated   []string `json:"updated"`
+	Unchanged []string `json:"unchanged"`
+}
+
+// ImportResult summarizes an import. Records missing from the snapshot are never deleted.
+// swagger:model
+type ImportResult struct {
+	DryRun        bool    `json:"dry_run"`
+	ProfileFields Changes `json:"profile_fields"`
+	Roles         Changes `json:"roles"`
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"go.uber.org/zap"
+
+	authzDto "github.com/MurtadaNazar/go-api-template/internal/domain/authz/dto"
+	authzModel "github.com/MurtadaNazar/go-api-template/internal/domain/authz/model"
+	authzService "github.com/MurtadaNazar/go-api-template/internal/domain/authz/service"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/settings/model"
+	userDto "github.com/MurtadaNazar/go-api-template/internal/domain/user/dto"
+	userModel "github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	userService "github.com/MurtadaNazar/go-api-template/internal/domain/user/service"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+)
+
+// store holds the profile fields and roles of one environment and counts writes
+type store struct {
+	fields map[string]userModel.ProfileField
+	roles  map[string]authzModel.Role
+	writes int
+	// failing makes writes of the profile field or role with this key or name fail
+	failing string
+}
+
+func newSettingsService(st *store) Service {
+	fieldRepo := &testutil.MockProfileFieldRepo{
+		ListFn: func(ctx context.Context) ([]userModel.ProfileField, error) {
+			var fields []userModel.ProfileField
+			for _, f := range st.fields {
+				fields = append(fields, f)
+			}
+			return fields, nil
+		},
+		FindByKeyFn: func(ctx context.Context, key string) (*userModel.ProfileField, error) {
+			if f, ok := st.fields[key]; ok {
+				return &f, nil
+			}
+			return nil, nil
+		},
+		CreateFn: func(ctx context.Context, field *userModel.ProfileField) error {
+			st.writes++
+			st.fields[field.Key] = *field
+			return nil
+		},
+		UpdateFn: func(ctx context.Context, field *userModel.ProfileField) error {
+			if field.Key == st.failing {
+				return errors.New("connection reset")
+			}
+			st.writes++
+			st.fields[field.Key] = *field
+			return nil
+		},
+	}
+	roleRepo := &testutil.MockRoleRepo{
+		ListFn: func(ctx context.Context) ([]authzModel.Role, error) {
+			var roles []authzModel.Role
+			for _, r := range st.roles {
+				roles = append(roles, r)
+			}
+			return roles, nil
+		},
+		FindByNameFn: func(ctx context.Context, name string) (*authzModel.Role, error) {
+			if r, ok := st.roles[name]; ok {
+				return &r, nil
+			}
+			return nil, nil
+		},
+		CreateFn: func(ctx context.Context, role *authzModel.Role) error {
+			if role.Name == st.failing {
+				return errors.New("connection reset")
+			}
+			st.writes++
+			st.roles[role.Name] = *role
+			return nil
+		},
+		UpdateFn: func(ctx context.Context, role *authzModel.Role) error {
+			st.writes++
+			st.roles[role.Name] = *role
+			return nil
+		},
+		ListPermissionsFn: func(ctx context.Context) ([]authzModel.Permission, error) {
+			return authzModel.DefaultPermissions, nil
+		},
+		FindPermissionsFn: func(ctx context.Context, keys []string) ([]authzModel.Permission, error) {
+			var found []authzModel.Permission
+			for _, p := range authzModel.DefaultPermissions {
+				if slices.Contains(keys, p.Key) {
+					found = append(found, p)
+				}
+			}
+			return found, nil
+		},
+	}
+	log := zap.NewNop().Sugar()
+	return NewService(userService.NewProfileFieldService(fieldRepo, log), authzService.NewService(roleRepo, log), log)
+}
+
+func stagingSnapshot() *model.Snapshot {
+	return &model.Snapshot{
+		Version: model.SnapshotVersion,
+		ProfileFields: []userDto.ProfileFieldRequest{
+			{Key: "department", Label: "Department", Type: "string"},
+			{Key: "shirt_size", Label: "Shirt size", Type: "string", Choices: []string{"S", "M", "L", "XL"}},
+		},
+		Roles: []authzDto.RoleCreateRequest{
+			{Name: authzModel.RoleAdmin, Description: "Administrator"},
+			{Name: "support", Description: "Support staff", Permissions: []string{authzModel.PermUsersList, authzModel.PermUsersHistory}},
+		},
+	}
+}
+
+func productionStore() *store {
+	return &store{
+		fields: map[string]userModel.ProfileField{
+			"department": {Key: "department", Label: "Department", Type: userModel.FieldTypeString},
+			"shirt_size": {Key: "shirt_size", Label: "Shirt size", Type: userModel.FieldTypeString, Choices: []string{"S", "M", "L"}},
+		},
+		roles: map[string]authzModel.Role{
+			authzModel.RoleAdmin: {Name: authzModel.RoleAdmin, Description: "Administrator", System: true, Permissions: authzModel.DefaultPermissions},
+		},
+	}
+}
+
+func TestSettingsService_Import(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	st := productionStore()
+	service := newSettingsService(st)
+
+	// Act
+	preview, err := service.Import(ctx, stagingSnapshot(), true)
+	if err != nil {
+		t.Fatalf("Import(dry run) error = %v", err)
+	}
+	writesAfterPreview := st.writes
+	result, err := service.Import(ctx, stagingSnapshot(), false)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if writesAfterPreview != 0 {
+		t.Errorf("dry run wrote %d record(s)", writesAfterPreview)
+	}
+	if !preview.DryRun || !slices.Equal(preview.Roles.Created, result.Roles.Created) {
+		t.Errorf("dry run = %+v, want the same changes as the import %+v", preview, result)
+	}
+	if !slices.Equal(result.ProfileFields.Unchanged, []string{"department"}) || !slices.Equal(result.ProfileFields.Updated, []string{"shirt_size"}) {
+		t.Errorf("profile field changes = %+v", result.ProfileFields)
+	}
+	if !slices.Equal(result.Roles.Unchanged, []string{authzModel.RoleAdmin}) || !slices.Equal(result.Roles.Created, []string{"support"}) {
+		t.Errorf("role changes = %+v", result.Roles)
+	}
+	if got := st.fields["shirt_size"].Choices; len(got) != 4 {
+		t.Errorf("shirt_size choices = %v, want the snapshot's", got)
+	}
+	if role := st.roles["support"]; len(role.PermissionKeys()) != 2 {
+		t.Errorf("support permissions = %v, want the snapshot's", role.PermissionKeys())
+	}
+}
+
+func TestSettingsService_Import_RejectsInvalidSnapshotWithoutWriting(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	st := productionStore()
+	service := newSettingsService(st)
+	snapshot := stagingSnapshot()
+	snapshot.Roles[1].Permissions = append(snapshot.Roles[1].Permissions, "billing:refund")
+
+	// Act
+	_, err := service.Import(ctx, snapshot, false)
+
+	// Assert
+	appErr, ok := apperrors.IsAppError(err)
+	if !ok || appErr.Type != apperrors.ValidationError {
+		t.Fatalf("Import() error = %v, want validation error", err)
+	}
+	if st.writes != 0 {
+		t.Errorf("invalid snapshot wrote %d record(s)", st.writes)
+	}
+}
+
+func TestSettingsService_Import_ReportsEveryFailedRecord(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	st := productionStore()
+	st.failing = "shirt_size"
+	service := newSettingsService(st)
+	snapshot := stagingSnapshot()
+	snapshot.Roles = append(snapshot.Roles, authzDto.RoleCreateRequest{Name: "shirt_size", Description: "Fails too"})
+
+	// Act
+	_, err := service.Import(ctx, snapshot, false)
+
+	// Assert
+	var multi *apperrors.MultiError
+	if !errors.As(err, &multi) || len(multi.Errs) != 2 {
+		t.Fatalf("Import() error = %v, want both failed writes", err)
+	}
+	if _, ok := st.roles["support"]; !ok {
+		t.Error("a failed record stopped the records after it")
+	}
+}
+
+func TestSettingsService_ExportRoundTrips(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	source := productionStore()
+	target := &store{fields: map[string]userModel.ProfileField{}, roles: map[string]authzModel.Role{}}
+
+	// Act
+	snapshot, err := newSettingsService(source).Export(ctx)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if _, err := newSettingsService(target).Import(ctx, snapshot, false); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	again, err := newSettingsService(target).Import(ctx, snapshot, true)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("second Import() error = %v", err)
+	}
+	if len(again.ProfileFields.Created)+len(again.ProfileFields.Updated)+len(again.Roles.Created)+len(again.Roles.Updated) != 0 {
+		t.Errorf("re-importing an exported snapshot reports changes: %+v", again)
+	}
+}
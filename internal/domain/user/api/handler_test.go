@@ -0,0 +1,101 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/service"
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestUserHandler_GetUser_Views(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	reason := "disposable_email: mailinator.com is a disposable email domain"
+	ip := "203.0.113.7"
+	users := &testutil.MockUserRepo{
+		FindByIDFn: func(ctx context.Context, id string) (*model.User, error) {
+			user := testutil.TestUser()
+			user.ReviewReason = &reason
+			user.LastLoginIP = &ip
+			return user, nil
+		},
+	}
+	h := NewUserHandler(service.NewUserService(users, zap.NewNop().Sugar()), zap.NewNop().Sugar())
+	h.SetAdminView(func(ctx context.Context, role string) bool { return role == "admin" })
+	get := func(role string) string {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/users/x", nil)
+		c.Set("role", role)
+		h.GetUser(c)
+		return w.Body.String()
+	}
+
+	// Act
+	self := get("user")
+	admin := get("admin")
+
+	// Assert
+	for _, body := range []string{self, admin} {
+		if !strings.Contains(body, `"username":"testuser"`) || strings.Contains(body, "$2a$") || strings.Contains(body, `"password"`) {
+			t.Errorf("body = %s, want the user without its password", body)
+		}
+	}
+	if strings.Contains(self, "review_reason") || strings.Contains(self, "last_login_ip") {
+		t.Errorf("self view = %s, want no review reason or login IP", self)
+	}
+	if !strings.Contains(admin, `"review_reason":"`+reason+`"`) || !strings.Contains(admin, `"last_login_ip":"`+ip+`"`) {
+		t.Errorf("admin view = %s, want the review reason and login IP", admin)
+	}
+}
+
+func TestUserHandler_UpdateProfile_IgnoresUserType(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	var saved *model.User
+	users := &testutil.MockUserRepo{
+		FindByIDFn: func(ctx context.Context, id string) (*model.User, error) {
+			return testutil.TestUser(), nil
+		},
+		UpdateFn: func(ctx context.Context, user *model.User) error {
+			saved = user
+			return nil
+		},
+	}
+	h := NewUserHandler(service.NewUserService(users, zap.NewNop().Sugar()), zap.NewNop().Sugar())
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPatch, "/api/v1/me/profile",
+		strings.NewReader(`{"first_name":"Jane","user_type":"admin"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("userID", testutil.TestUser().ID.String())
+
+	// Act
+	h.UpdateProfile(c)
+
+	// Assert
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if saved == nil || saved.FirstName != "Jane" || saved.UserType != model.UserTypeRegular {
+		t.Errorf("saved = %+v, want the first name changed and the user type kept", saved)
+	}
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if body.Data["user_type"] != string(model.UserTypeRegular) || body.Data["last_login_ip"] != nil {
+		t.Errorf("data = %v, want the self view with the regular user type", body.Data)
+	}
+}
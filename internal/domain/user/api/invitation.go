@@ -0,0 +1,101 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Invite godoc
+// @Summary Invite someone to register (requires users:create)
+// @Description Emails a single-use link to register with the given role. The response also holds the link, to share it another way when email is off. Inviting the same address again replaces the earlier invitation.
+// @Tags Users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param invitation body dto.InvitationRequest true "Invitation"
+// @Success 201 {object} dto.InvitationResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse "Email already registered"
+// @Router /invitations [post]
+func (h *UserHandler) Invite(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+
+	var req dto.InvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.NewAppErrorWithDetails(apperrors.BadRequestError, "Invalid request payload", err.Error()))
+		return
+	}
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	ctx := actor.WithClientIP(c.Request.Context(), c.ClientIP())
+	invitation, err := h.service.Invite(ctx, &req)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, response.NewSuccessResponse(invitation, requestID))
+}
+
+// RevokeInvitation godoc
+// @Summary Revoke a pending invitation (requires users:create)
+// @Tags Users
+// @Security BearerAuth
+// @Produce json
+// @Param email path string true "Invited email"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Router /invitations/{email} [delete]
+func (h *UserHandler) RevokeInvitation(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+
+	ctx := actor.WithClientIP(c.Request.Context(), c.ClientIP())
+	if err := h.service.RevokeInvitation(ctx, c.Param("email")); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{"message": "invitation revoked"}, requestID))
+}
+
+// AcceptInvite godoc
+// @Summary Accept an invitation
+// @Description Creates the invited account with the email and role of the invitation. The email counts as verified. Works when SIGNUP_ENABLED=false.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body dto.AcceptInviteRequest true "Invitation token and account details"
+// @Success 201 {object} dto.UserResponse
+// @Failure 400 {object} response.ErrorResponse "Invalid or expired invitation"
+// @Failure 409 {object} response.ErrorResponse
+// @Router /auth/accept-invite [post]
+func (h *UserHandler) AcceptInvite(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+
+	var req dto.AcceptInviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.NewAppErrorWithDetails(apperrors.BadRequestError, "Invalid request payload", err.Error()))
+		return
+	}
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	ctx := actor.WithClientIP(c.Request.Context(), c.ClientIP())
+	user, err := h.service.AcceptInvitation(ctx, &req)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.NewSuccessResponse(dto.NewUserResponse(user), requestID))
+}
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/service"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/validation"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ProfileFieldHandler struct {
+	service   service.ProfileFieldService
+	validator *validation.Validator
+	logger    *zap.SugaredLogger
+}
+
+func NewProfileFieldHandler(s service.ProfileFieldService, logger *zap.SugaredLogger) *ProfileFieldHandler {
+	return &ProfileFieldHandler{
+		service:   s,
+		validator: validation.Default(),
+		logger:    logger,
+	}
+}
+
+// List godoc
+// @Summary List custom profile fields
+// @Tags Profile Fields
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} response.SuccessResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /profile-fields [get]
+func (h *ProfileFieldHandler) List(c *gin.Context) {
+	requestIDVal, _ := c.Get("RequestID")
+	requestID, ok := requestIDVal.(string)
+	if !ok {
+		requestID = "unknown"
+	}
+
+	fields, err := h.service.List(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(fields, requestID))
+}
+
+// Create godoc
+// @Summary Define a custom profile field (requires profile_fields:manage)
+// @Tags Profile Fields
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param field body dto.ProfileFieldRequest true "Field definition"
+// @Success 201 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
+// @Router /profile-fields [post]
+func (h *ProfileFieldHandler) Create(c *gin.Context) {
+	requestIDVal, _ := c.Get("RequestID")
+	requestID, ok := requestIDVal.(string)
+	if !ok {
+		requestID = "unknown"
+	}
+
+	var req dto.ProfileFieldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warnw("invalid profile field request", "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppErrorWithDetails(
+			apperrors.BadRequestError,
+			"Invalid request payload",
+			err.Error(),
+		))
+		return
+	}
+
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	field, err := h.service.Create(c.Request.Context(), &req)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.NewSuccessResponse(field, requestID))
+}
+
+// Update godoc
+// @Summary Change a custom profile field definition (requires profile_fields:manage)
+// @Tags Profile Fields
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param key path string true "Field key"
+// @Param field body dto.ProfileFieldRequest true "Field definition"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse "Field is declared in PROFILE_FIELDS"
+// @Router /profile-fields/{key} [put]
+func (h *ProfileFieldHandler) Update(c *gin.Context) {
+	requestIDVal, _ := c.Get("RequestID")
+	requestID, ok := requestIDVal.(string)
+	if !ok {
+		requestID = "unknown"
+	}
+
+	key := c.Param("key")
+	var req dto.ProfileFieldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warnw("invalid profile field request", "key", key, "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppErrorWithDetails(
+			apperrors.BadRequestError,
+			"Invalid request payload",
+			err.Error(),
+		))
+		return
+	}
+	// The key is taken from the path; fields cannot be renamed
+	req.Key = key
+
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	field, err := h.service.Update(c.Request.Context(), key, &req)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(field, requestID))
+}
+
+// Delete godoc
+// @Summary Remove a custom profile field (requires profile_fields:manage)
+// @Tags Profile Fields
+// @Security BearerAuth
+// @Produce json
+// @Param key path string true "Field key"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse "Field is declared in PROFILE_FIELDS"
+// @Router /profile-fields/{key} [delete]
+func (h *ProfileFieldHandler) Delete(c *gin.Context) {
+	requestIDVal, _ := c.Get("RequestID")
+	requestID, ok := requestIDVal.(string)
+	if !ok {
+		requestID = "unknown"
+	}
+
+	if err := h.service.Delete(c.Request.Context(), c.Param("key")); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{"message": "profile field deleted successfully"}, requestID))
+}
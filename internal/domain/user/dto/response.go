@@ -0,0 +1,222 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+
+	"github.com/google/uuid"
+)
+
+// UserResponse is a user as the API returns it. Fields are copied one by one from
+// model.User, so a new column stays out of responses until it is added here.
+// swagger:model
+type UserResponse struct {
+	// Example: 123e4567-e89b-12d3-a456-426614174000
+	ID uuid.UUID `json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
+
+	// Example: John
+	FirstName string `json:"first_name" example:"John"`
+
+	// Example: Michael
+	SecondName string `json:"second_name,omitempty" example:"Michael"`
+
+	// Example: Doe
+	LastName string `json:"last_name" example:"Doe"`
+
+	// Example: johndoe123
+	Username string `json:"username" example:"johndoe123"`
+
+	// Example: john.doe@example.com
+	Email string `json:"email" example:"john.doe@example.com"`
+
+	// When the user confirmed owning Email
+	// Example: 2023-10-05T14:30:00Z
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty" example:"2023-10-05T14:30:00Z"`
+
+	// Phone number in E.164 format
+	// Example: +14155552671
+	Phone *string `json:"phone,omitempty" example:"+14155552671"`
+
+	// When the user confirmed owning Phone
+	// Example: 2023-10-05T14:30:00Z
+	PhoneVerifiedAt *time.Time `json:"phone_verified_at,omitempty" example:"2023-10-05T14:30:00Z"`
+
+	// Example: false
+	SMSTwoFactor bool `json:"sms_two_factor" example:"false"`
+
+	// Example: false
+	AnnouncementsOptOut bool `json:"announcements_opt_out" example:"false"`
+
+	// Example: false
+	LoginAlertsOptOut bool `json:"login_alerts_opt_out" example:"false"`
+
+	// Example: Europe/Berlin
+	TimeZone string `json:"timezone,omitempty" example:"Europe/Berlin"`
+
+	// Example: de-DE
+	Locale string `json:"locale,omitempty" example:"de-DE"`
+
+	// Custom profile fields
+	Metadata model.Metadata `json:"metadata" swaggertype:"object"`
+
+	// Example: user
+	UserType model.UserType `json:"user_type" example:"user"`
+
+	// Example: active
+	Status string `json:"status" example:"active"`
+
+	// When the account will be anonymized, if its owner deleted it
+	// Example: 2023-11-04T14:30:00Z
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty" example:"2023-11-04T14:30:00Z"`
+
+	// Example: 2023-10-05T14:30:00Z
+	CreatedAt time.Time `json:"created_at" example:"2023-10-05T14:30:00Z"`
+
+	// Example: 2023-10-05T14:30:00Z
+	UpdatedAt time.Time `json:"updated_at" example:"2023-10-05T14:30:00Z"`
+}
+
+// AdminUserResponse is the view of a user for callers who manage accounts: the
+// UserResponse plus why the account is blocked or flagged and its last login
+// swagger:model
+type AdminUserResponse struct {
+	UserResponse
+
+	// Why an admin last changed the status
+	// Example: Chargeback under investigation
+	StatusReason *string `json:"status_reason,omitempty" example:"Chargeback under investigation"`
+
+	// Why abuse detection flagged the account for review
+	// Example: disposable_email: mailinator.com is a disposable email domain
+	ReviewReason *string `json:"review_reason,omitempty" example:"disposable_email: mailinator.com is a disposable email domain"`
+
+	// Example: 2023-10-05T14:30:00Z
+	LastLoginAt *time.Time `json:"last_login_at,omitempty" example:"2023-10-05T14:30:00Z"`
+
+	// Example: 203.0.113.7
+	LastLoginIP *string `json:"last_login_ip,omitempty" example:"203.0.113.7"`
+}
+
+// UserMetricsResponse is model.UserMetrics with the newest accounts in the admin view
+// swagger:model
+type UserMetricsResponse struct {
+	// Registrations per UTC day, oldest first, including days without any
+	Registrations []model.DailyCount `json:"registrations"`
+
+	// Accounts per status, deleted ones included
+	// example: {"active":1180,"suspended":12,"deleted":58}
+	ByStatus map[string]int64 `json:"by_status"`
+
+	// Accounts that are not deleted, per role
+	// example: {"user":1175,"admin":5}
+	ByRole map[string]int64 `json:"by_role"`
+
+	// The newest accounts, newest first
+	RecentSignups []*AdminUserResponse `json:"recent_signups"`
+}
+
+// MergeResponse is the account another was merged into and what moved to it
+// swagger:model
+type MergeResponse struct {
+	Target *AdminUserResponse `json:"target"`
+	Moved  *model.MergeReport `json:"moved"`
+}
+
+// Outcomes of a BatchResult
+const (
+	BatchApplied   = "applied"
+	BatchUnchanged = "unchanged"
+	BatchFailed    = "failed"
+	BatchSkipped   = "skipped"
+)
+
+// BatchResult is the outcome of one operation of a BatchRequest
+// swagger:model
+type BatchResult struct {
+	// Position of the operation in the request
+	// Example: 0
+	Index int `json:"index" example:"0"`
+
+	// Example: change_status
+	Op string `json:"op" example:"change_status"`
+
+	// Example: 123e4567-e89b-12d3-a456-426614174000
+	UserID string `json:"user_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+
+	// applied; unchanged when the user already had the role or status; failed when
+	// the operation was rejected; skipped when it was fine but another one failed
+	// Example: applied
+	Result string `json:"result" example:"applied"`
+
+	// Why the operation failed
+	// Example: User not found
+	Error string `json:"error,omitempty" example:"User not found"`
+}
+
+// BatchResponse holds the outcome of every operation of a BatchRequest
+// swagger:model
+type BatchResponse struct {
+	// Whether the operations were written; false when any of them failed
+	// Example: true
+	Applied bool `json:"applied" example:"true"`
+
+	Results []BatchResult `json:"results"`
+}
+
+// NewUserResponse returns the view of u for the user themselves and anyone else who may
+// read the account
+func NewUserResponse(u *model.User) *UserResponse {
+	return &UserResponse{
+		ID:                  u.ID,
+		FirstName:           u.FirstName,
+		SecondName:          u.SecondName,
+		LastName:            u.LastName,
+		Username:            u.Username,
+		Email:               u.Email,
+		EmailVerifiedAt:     u.EmailVerifiedAt,
+		Phone:               u.Phone,
+		PhoneVerifiedAt:     u.PhoneVerifiedAt,
+		SMSTwoFactor:        u.SMSTwoFactor,
+		AnnouncementsOptOut: u.AnnouncementsOptOut,
+		LoginAlertsOptOut:   u.LoginAlertsOptOut,
+		TimeZone:            u.TimeZone,
+		Locale:              u.Locale,
+		Metadata:            u.Metadata,
+		UserType:            u.UserType,
+		Status:              u.Status,
+		DeletionScheduledAt: u.DeletionScheduledAt,
+		CreatedAt:           u.CreatedAt,
+		UpdatedAt:           u.UpdatedAt,
+	}
+}
+
+// NewAdminUserResponse returns the admin view of u
+func NewAdminUserResponse(u *model.User) *AdminUserResponse {
+	return &AdminUserResponse{
+		UserResponse: *NewUserResponse(u),
+		StatusReason: u.StatusReason,
+		ReviewReason: u.ReviewReason,
+		LastLoginAt:  u.LastLoginAt,
+		LastLoginIP:  u.LastLoginIP,
+	}
+}
+
+// NewAdminUserResponses returns the admin view of each of users
+func NewAdminUserResponses(users []*model.User) []*AdminUserResponse {
+	out := make([]*AdminUserResponse, len(users))
+	for i, u := range users {
+		out[i] = NewAdminUserResponse(u)
+	}
+	return out
+}
+
+// NewUserMetricsResponse returns m with its recent signups in the admin view
+func NewUserMetricsResponse(m *model.UserMetrics) *UserMetricsResponse {
+	return &UserMetricsResponse{
+		Registrations: m.Registrations,
+		ByStatus:      m.ByStatus,
+		ByRole:        m.ByRole,
+		RecentSignups: NewAdminUserResponses(m.RecentSignups),
+	}
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestListCursor_RoundTrip(t *testing.T) {
+	// Arrange
+	user := &User{ID: uuid.New(), CreatedAt: time.Date(2024, 5, 1, 12, 30, 0, 123456000, time.UTC)}
+
+	// Act
+	decoded, err := DecodeListCursor(CursorAfter(user).Encode())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("DecodeListCursor() error = %v", err)
+	}
+	if decoded.ID != user.ID || !decoded.CreatedAt.Equal(user.CreatedAt) {
+		t.Errorf("DecodeListCursor() = %+v, want the position of the user", decoded)
+	}
+}
+
+func TestDecodeListCursor_Invalid(t *testing.T) {
+	for _, s := range []string{"", "not base64!", "e30", "eyJ0IjoieCJ9"} {
+		if _, err := DecodeListCursor(s); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("DecodeListCursor(%q) error = %v, want ErrInvalidCursor", s, err)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package model
+
+import "testing"
+
+func TestEmailNormalizer_Normalize(t *testing.T) {
+	tests := []struct {
+		name      string
+		foldGmail bool
+		input     string
+		want      string
+	}{
+		{name: "Lowercases address", input: "John.Doe@Example.COM", want: "john.doe@example.com"},
+		{name: "Trims whitespace", input: "  user@example.com\t", want: "user@example.com"},
+		{name: "Keeps gmail dots without folding", input: "j.doe+news@gmail.com", want: "j.doe+news@gmail.com"},
+		{name: "Folds gmail dots and tags", foldGmail: true, input: "J.Doe+news@Gmail.com", want: "jdoe@gmail.com"},
+		{name: "Folds googlemail domain", foldGmail: true, input: "jdoe@googlemail.com", want: "jdoe@gmail.com"},
+		{name: "Leaves other domains untouched when folding", foldGmail: true, input: "j.doe+x@example.com", want: "j.doe+x@example.com"},
+		{name: "Malformed address is only lowercased", foldGmail: true, input: "Not-An-Email", want: "not-an-email"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EmailNormalizer{FoldGmail: tt.foldGmail}.Normalize(tt.input)
+			if got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalEmail(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: " John.Doe@Example.COM ", want: "John.Doe@example.com"},
+		{input: "user@example.com", want: "user@example.com"},
+		{input: "Not-An-Email", want: "Not-An-Email"},
+	}
+
+	for _, tt := range tests {
+		if got := CanonicalEmail(tt.input); got != tt.want {
+			t.Errorf("CanonicalEmail(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
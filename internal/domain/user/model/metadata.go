@@ -0,0 +1,262 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/shared/id"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// FieldType is the value type of a custom profile field
+// swagger:enum FieldType
+type FieldType string
+
+const (
+	// FieldTypeString free-form text
+	FieldTypeString FieldType = "string"
+	// FieldTypeNumber any JSON number
+	FieldTypeNumber FieldType = "number"
+	// FieldTypeBoolean true or false
+	FieldTypeBoolean FieldType = "boolean"
+	// FieldTypeDate calendar date in YYYY-MM-DD form
+	FieldTypeDate FieldType = "date"
+)
+
+const dateLayout = "2006-01-02"
+
+var profileFieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// Metadata holds custom profile values keyed by ProfileField.Key, stored as JSONB
+type Metadata map[string]interface{}
+
+// Value implements driver.Valuer
+func (m Metadata) Value() (driver.Value, error) {
+	if m == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner
+func (m *Metadata) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*m = Metadata{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported metadata type %T", value)
+	}
+
+	out := Metadata{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return err
+	}
+	*m = out
+	return nil
+}
+
+// ProfileField is an admin-defined custom field that users may carry in Metadata
+// swagger:model ProfileField
+type ProfileField struct {
+	// ID is the unique identifier for the field
+	// format: uuid
+	ID uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+
+	// Key under which the value is stored in user metadata
+	// example: department
+	// pattern: ^[a-z][a-z0-9_]{0,63}$
+	Key string `gorm:"size:64;uniqueIndex;not null" json:"key" example:"department"`
+
+	// Human readable label
+	// example: Department
+	Label string `gorm:"size:100" json:"label,omitempty" example:"Department"`
+
+	// Value type
+	// enum: string,number,boolean,date
+	Type FieldType `gorm:"type:varchar(20);not null" json:"type"`
+
+	// Whether every user written with metadata must provide the field
+	Required bool `gorm:"default:false" json:"required"`
+
+	// Allowed values for string fields; empty means any value
+	// example: ["engineering","sales"]
+	Choices pq.StringArray `gorm:"type:text[]" json:"choices,omitempty" swaggertype:"array,string" example:"engineering,sales"`
+
+	// Whether the field is declared in PROFILE_FIELDS; such fields cannot be changed
+	// through the API
+	// readOnly: true
+	Config bool `gorm:"-" json:"config"`
+
+	// format: date-time
+	// readOnly: true
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	// format: date-time
+	// readOnly: true
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// BeforeCreate hook to generate UUID before inserting
+func (f *ProfileField) BeforeCreate(tx *gorm.DB) error {
+	if f.ID == uuid.Nil {
+		f.ID = id.New()
+	}
+	return nil
+}
+
+// TableName sets the insert table name for this struct type
+func (ProfileField) TableName() string {
+	return "profile_fields"
+}
+
+// Validate checks that the field definition itself is usable
+func (f *ProfileField) Validate() error {
+	if !profileFieldKeyPattern.MatchString(f.Key) {
+		return fmt.Errorf("key %q must start with a lowercase letter and contain only lowercase letters, digits and underscores", f.Key)
+	}
+	switch f.Type {
+	case FieldTypeString, FieldTypeNumber, FieldTypeBoolean, FieldTypeDate:
+	default:
+		return fmt.Errorf("field %q has unsupported type %q", f.Key, f.Type)
+	}
+	if len(f.Choices) > 0 && f.Type != FieldTypeString {
+		return fmt.Errorf("field %q: choices are only supported for string fields", f.Key)
+	}
+	return nil
+}
+
+// checkValue validates a single metadata value against the field definition
+func (f *ProfileField) checkValue(value interface{}) error {
+	switch f.Type {
+	case FieldTypeString:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s must be a string", f.Key)
+		}
+		if len(f.Choices) > 0 && !f.allows(s) {
+			return fmt.Errorf("%s must be one of: %s", f.Key, strings.Join(f.Choices, ", "))
+		}
+	case FieldTypeNumber:
+		switch value.(type) {
+		case float64, float32, int, int64, int32, json.Number:
+		default:
+			return fmt.Errorf("%s must be a number", f.Key)
+		}
+	case FieldTypeBoolean:
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s must be a boolean", f.Key)
+		}
+	case FieldTypeDate:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s must be a date (YYYY-MM-DD)", f.Key)
+		}
+		if _, err := time.Parse(dateLayout, s); err != nil {
+			return fmt.Errorf("%s must be a date (YYYY-MM-DD)", f.Key)
+		}
+	}
+	return nil
+}
+
+func (f *ProfileField) allows(value string) bool {
+	for _, choice := range f.Choices {
+		if choice == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseFilterValue converts a query-string value into the typed value stored for the field
+func (f *ProfileField) ParseFilterValue(raw string) (interface{}, error) {
+	switch f.Type {
+	case FieldTypeNumber:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a number", f.Key)
+		}
+		return n, nil
+	case FieldTypeBoolean:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a boolean", f.Key)
+		}
+		return b, nil
+	}
+	if err := f.checkValue(raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// ValidateMetadata checks metadata against the field schema: unknown keys are rejected,
+// required fields must be present and every value must match its field's type and choices.
+// All problems are reported together, sorted by key.
+func ValidateMetadata(schema []ProfileField, metadata Metadata) error {
+	fields := make(map[string]*ProfileField, len(schema))
+	for i := range schema {
+		fields[schema[i].Key] = &schema[i]
+	}
+
+	var problems []string
+	for key, value := range metadata {
+		field, ok := fields[key]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s is not a known profile field", key))
+			continue
+		}
+		if value == nil {
+			continue
+		}
+		if err := field.checkValue(value); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	for key, field := range fields {
+		if field.Required && metadata[key] == nil {
+			problems = append(problems, fmt.Sprintf("%s is required", key))
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	return errors.New(strings.Join(problems, "; "))
+}
+
+// MergeMetadata applies a partial update to existing metadata; nil values remove keys
+func MergeMetadata(current, patch Metadata) Metadata {
+	merged := make(Metadata, len(current)+len(patch))
+	for k, v := range current {
+		merged[k] = v
+	}
+	for k, v := range patch {
+		if v == nil {
+			delete(merged, k)
+			continue
+		}
+		merged[k] = v
+	}
+	return merged
+}
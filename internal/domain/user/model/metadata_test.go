@@ -0,0 +1,97 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func testSchema() []ProfileField {
+	return []ProfileField{
+		{Key: "department", Type: FieldTypeString, Required: true, Choices: []string{"engineering", "sales"}},
+		{Key: "seniority", Type: FieldTypeNumber},
+		{Key: "remote", Type: FieldTypeBoolean},
+		{Key: "start_date", Type: FieldTypeDate},
+	}
+}
+
+func TestValidateMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata Metadata
+		wantErr  string
+	}{
+		{name: "Valid", metadata: Metadata{"department": "sales", "seniority": 3.0, "remote": true, "start_date": "2024-02-29"}},
+		{name: "Missing required", metadata: Metadata{"remote": false}, wantErr: "department is required"},
+		{name: "Null required", metadata: Metadata{"department": nil}, wantErr: "department is required"},
+		{name: "Unknown key", metadata: Metadata{"department": "sales", "shoe_size": 42.0}, wantErr: "shoe_size is not a known profile field"},
+		{name: "Not a choice", metadata: Metadata{"department": "legal"}, wantErr: "department must be one of: engineering, sales"},
+		{name: "Wrong type", metadata: Metadata{"department": "sales", "seniority": "3"}, wantErr: "seniority must be a number"},
+		{name: "Bad date", metadata: Metadata{"department": "sales", "start_date": "2023-02-29"}, wantErr: "start_date must be a date (YYYY-MM-DD)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateMetadata(testSchema(), tt.metadata)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateMetadata() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ValidateMetadata() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProfileField_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   ProfileField
+		wantErr bool
+	}{
+		{name: "Valid", field: ProfileField{Key: "team_size", Type: FieldTypeNumber}},
+		{name: "Uppercase key", field: ProfileField{Key: "Team", Type: FieldTypeString}, wantErr: true},
+		{name: "Unknown type", field: ProfileField{Key: "team", Type: "object"}, wantErr: true},
+		{name: "Choices on number", field: ProfileField{Key: "team", Type: FieldTypeNumber, Choices: []string{"1"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.field.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMergeMetadata(t *testing.T) {
+	current := Metadata{"department": "sales", "remote": true}
+
+	merged := MergeMetadata(current, Metadata{"department": "engineering", "remote": nil})
+
+	if merged["department"] != "engineering" {
+		t.Errorf("department = %v, want engineering", merged["department"])
+	}
+	if _, ok := merged["remote"]; ok {
+		t.Error("remote should have been removed")
+	}
+	if current["department"] != "sales" {
+		t.Error("MergeMetadata modified the current metadata")
+	}
+}
+
+func TestProfileField_ParseFilterValue(t *testing.T) {
+	schema := testSchema()
+
+	if v, err := schema[1].ParseFilterValue("5"); err != nil || v != 5.0 {
+		t.Errorf("number filter = %v, %v; want 5, nil", v, err)
+	}
+	if v, err := schema[2].ParseFilterValue("true"); err != nil || v != true {
+		t.Errorf("boolean filter = %v, %v; want true, nil", v, err)
+	}
+	if _, err := schema[0].ParseFilterValue("legal"); err == nil {
+		t.Error("string filter outside choices should fail")
+	}
+}
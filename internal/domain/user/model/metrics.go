@@ -0,0 +1,26 @@
+package model
+
+// UserMetrics summarizes the accounts for admin dashboards
+// swagger:model
+type UserMetrics struct {
+	// Registrations per UTC day, oldest first, including days without any
+	Registrations []DailyCount `json:"registrations"`
+
+	// Accounts per status, deleted ones included
+	// example: {"active":1180,"suspended":12,"deleted":58}
+	ByStatus map[string]int64 `json:"by_status"`
+
+	// Accounts that are not deleted, per role
+	// example: {"user":1175,"admin":5}
+	ByRole map[string]int64 `json:"by_role"`
+
+	// The newest accounts, newest first
+	RecentSignups []*User `json:"recent_signups"`
+}
+
+// DailyCount is the number of events on one UTC day
+type DailyCount struct {
+	// example: 2024-01-15
+	Date  string `json:"date" example:"2024-01-15"`
+	Count int64  `json:"count" example:"12"`
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/shared/id"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserNote is a note support staff keep on a user account. The user never sees it.
+// swagger:model UserNote
+type UserNote struct {
+	// format: uuid
+	ID uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+
+	// User the note is about
+	// format: uuid
+	UserID uuid.UUID `gorm:"type:uuid;not null;index:idx_user_notes_user_created,priority:1" json:"user_id"`
+
+	// Admin who wrote the note; null when they were not signed in as a user
+	// format: uuid
+	AuthorID *uuid.UUID `gorm:"type:uuid" json:"author_id,omitempty"`
+
+	// example: Called about a double charge, refund issued
+	Text string `gorm:"type:text;not null" json:"text" example:"Called about a double charge, refund issued"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime;index:idx_user_notes_user_created,priority:2" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// BeforeCreate hook to generate UUID before inserting
+func (n *UserNote) BeforeCreate(tx *gorm.DB) error {
+	if n.ID == uuid.Nil {
+		n.ID = id.New()
+	}
+	return nil
+}
+
+// TableName sets the insert table name for this struct type
+func (UserNote) TableName() string {
+	return "user_notes"
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/shared/id"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PasswordHistory is the bcrypt hash of a password a user has set, kept to refuse reuse
+type PasswordHistory struct {
+	ID     uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID uuid.UUID `gorm:"type:uuid;not null;index:idx_password_history_user_created,priority:1"`
+	// Hash is the bcrypt hash, never the password itself
+	Hash      string    `gorm:"not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index:idx_password_history_user_created,priority:2"`
+}
+
+// BeforeCreate hook to generate UUID before inserting
+func (h *PasswordHistory) BeforeCreate(tx *gorm.DB) error {
+	if h.ID == uuid.Nil {
+		h.ID = id.New()
+	}
+	return nil
+}
+
+// TableName sets the insert table name for this struct type
+func (PasswordHistory) TableName() string {
+	return "password_history"
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/nyaruka/phonenumbers"
+)
+
+// ErrInvalidPhoneNumber is returned when a phone number cannot be parsed or is not dialable
+var ErrInvalidPhoneNumber = errors.New("invalid phone number")
+
+// NormalizePhone validates raw with libphonenumber and returns it in E.164 form (+14155552671).
+// Numbers without a leading "+" are interpreted using defaultRegion (ISO 3166 code, e.g. "US").
+func NormalizePhone(raw, defaultRegion string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", ErrInvalidPhoneNumber
+	}
+
+	num, err := phonenumbers.Parse(raw, strings.ToUpper(defaultRegion))
+	if err != nil || !phonenumbers.IsValidNumber(num) {
+		return "", ErrInvalidPhoneNumber
+	}
+
+	return phonenumbers.Format(num, phonenumbers.E164), nil
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestDiffUser_Update(t *testing.T) {
+	phone := "+14155552671"
+	before := &User{
+		ID:        uuid.New(),
+		FirstName: "Jane",
+		Email:     "jane@example.com",
+		Password:  "hash-1",
+		Metadata:  Metadata{"department": "sales"},
+	}
+	after := *before
+	after.Email = "jane.doe@example.com"
+	after.Phone = &phone
+	after.Password = "hash-2"
+	after.Metadata = Metadata{"department": "engineering"}
+	actor := uuid.New()
+
+	revisions := DiffUser(before, &after, &actor)
+
+	got := make(map[string]UserRevision, len(revisions))
+	for _, r := range revisions {
+		got[r.Field] = r
+		if r.Action != RevisionUpdated || r.UserID != before.ID || r.ActorID == nil || *r.ActorID != actor {
+			t.Errorf("revision %s has wrong metadata: %+v", r.Field, r)
+		}
+	}
+	if len(got) != 4 {
+		t.Fatalf("DiffUser() returned fields %v, want email, phone, password, metadata.department", got)
+	}
+	if r := got["email"]; *r.OldValue != "jane@example.com" || *r.NewValue != "jane.doe@example.com" {
+		t.Errorf("email revision = %s -> %s", *r.OldValue, *r.NewValue)
+	}
+	if r := got["phone"]; r.OldValue != nil || *r.NewValue != phone {
+		t.Errorf("phone revision = %v -> %v, want nil -> %s", r.OldValue, r.NewValue, phone)
+	}
+	if r := got["password"]; *r.OldValue != RedactedValue || *r.NewValue != RedactedValue {
+		t.Error("password revision must not contain hashes")
+	}
+	if r := got["metadata.department"]; *r.NewValue != `"engineering"` {
+		t.Errorf("metadata revision new value = %s", *r.NewValue)
+	}
+}
+
+func TestDiffUser_Create(t *testing.T) {
+	user := &User{ID: uuid.New(), Username: "jane", Email: "jane@example.com"}
+
+	revisions := DiffUser(nil, user, nil)
+
+	if len(revisions) == 0 {
+		t.Fatal("DiffUser() returned no revisions for a new user")
+	}
+	for _, r := range revisions {
+		if r.Action != RevisionCreated || r.OldValue != nil {
+			t.Errorf("creation revision %s = %+v", r.Field, r)
+		}
+	}
+}
+
+func TestDiffUser_NoChanges(t *testing.T) {
+	user := &User{ID: uuid.New(), Username: "jane", Metadata: Metadata{"remote": true}}
+	same := *user
+
+	if revisions := DiffUser(user, &same, nil); len(revisions) != 0 {
+		t.Errorf("DiffUser() = %+v, want no revisions", revisions)
+	}
+}
@@ -0,0 +1,25 @@
+package model
+
+import "testing"
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{from: StatusActive, to: StatusSuspended, want: true},
+		{from: StatusSuspended, to: StatusActive, want: true},
+		{from: StatusSuspended, to: StatusDeleted, want: true},
+		{from: StatusInactive, to: StatusSuspended, want: true},
+		{from: StatusSuspended, to: StatusInactive, want: false},
+		{from: StatusDeleted, to: StatusActive, want: false},
+		{from: StatusActive, to: StatusActive, want: false},
+		{from: "unknown", to: StatusActive, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := CanTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
@@ -4,47 +4,58 @@ import (
 	"strings"
 	"time"
 
+	"github.com/MurtadaNazar/go-api-template/internal/shared/id"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
-// UserType defines the type of user
-// swagger:enum UserType
+// UserType is the name of the user's role, one of the roles stored in the roles table
+// (see the authz domain). Roles other than the two built in are defined at runtime.
 type UserType string
 
 const (
-	// UserTypeRegular regular user type
+	// UserTypeRegular is the built-in role new accounts get
 	UserTypeRegular UserType = "user"
-	// UserTypeAdmin admin user type
+	// UserTypeAdmin is the built-in role holding every permission
 	UserTypeAdmin UserType = "admin"
 )
 
+// Account statuses. Only active accounts can log in; admins move accounts between the
+// first three, and anonymization after a self-service deletion sets StatusDeleted.
+const (
+	StatusActive    = "active"
+	StatusInactive  = "inactive"
+	StatusSuspended = "suspended"
+	StatusDeleted   = "deleted"
+)
+
 // User represents the user entity
 // swagger:model User
 type User struct {
 	// ID is the unique identifier for the user
 	// example: 123e4567-e89b-12d3-a456-426614174000
 	// format: uuid
-	ID uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+	ID uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4();index:idx_users_created_id,priority:2" json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
 
 	// First name of the user
 	// example: John
 	// required: true
 	// min length: 1
 	// max length: 100
-	FirstName string `gorm:"size:100;not null" json:"first_name"`
+	FirstName string `gorm:"size:100;not null" json:"first_name" example:"John"`
 
 	// Middle name of the user (optional)
 	// example: Michael
 	// max length: 100
-	SecondName string `gorm:"size:100" json:"second_name,omitempty"`
+	SecondName string `gorm:"size:100" json:"second_name,omitempty" example:"Michael"`
 
 	// Last name of the user
 	// example: Doe
 	// required: true
 	// min length: 1
 	// max length: 100
-	LastName string `gorm:"size:100;not null" json:"last_name"`
+	LastName string `gorm:"size:100;not null" json:"last_name" example:"Doe"`
 
 	// Username for authentication and display
 	// example: johndoe123
@@ -52,14 +63,65 @@ type User struct {
 	// min length: 3
 	// max length: 50
 	// pattern: ^[a-zA-Z0-9_]+$
-	Username string `gorm:"size:50;uniqueIndex;not null" json:"username"`
+	Username string `gorm:"size:50;uniqueIndex;not null" json:"username" example:"johndoe123"`
 
 	// Email address of the user
 	// example: john.doe@example.com
 	// required: true
 	// format: email
 	// max length: 100
-	Email string `gorm:"size:100;uniqueIndex;not null" json:"email"`
+	// Unique ignoring case through idx_users_lower_email (see CreateEmailIndex)
+	Email string `gorm:"size:100;not null" json:"email" example:"john.doe@example.com"`
+
+	// Canonical form of Email (see EmailNormalizer) used for uniqueness and lookups
+	// readOnly: true
+	NormalizedEmail string `gorm:"size:100;uniqueIndex" json:"-"`
+
+	// When the user confirmed owning Email; cleared when the email changes
+	// example: 2023-10-05T14:30:00Z
+	// format: date-time
+	// readOnly: true
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty" example:"2023-10-05T14:30:00Z"`
+
+	// Phone number in E.164 format (optional)
+	// example: +14155552671
+	// max length: 20
+	Phone *string `gorm:"size:20;uniqueIndex" json:"phone,omitempty" example:"+14155552671"`
+
+	// When the user confirmed owning Phone with a code sent by SMS; cleared when the phone changes
+	// example: 2023-10-05T14:30:00Z
+	// format: date-time
+	// readOnly: true
+	PhoneVerifiedAt *time.Time `json:"phone_verified_at,omitempty" example:"2023-10-05T14:30:00Z"`
+
+	// Whether password logins must be confirmed with a one-time code sent by SMS
+	// example: false
+	// default: false
+	SMSTwoFactor bool `gorm:"default:false" json:"sms_two_factor" example:"false"`
+
+	// Whether the user opted out of announcements sent by admins
+	// example: false
+	// default: false
+	AnnouncementsOptOut bool `gorm:"not null;default:false" json:"announcements_opt_out" example:"false"`
+
+	// Whether the user opted out of emails about logins from new devices
+	// example: false
+	// default: false
+	LoginAlertsOptOut bool `gorm:"not null;default:false" json:"login_alerts_opt_out" example:"false"`
+
+	// Preferred IANA time zone used to localize response timestamps
+	// example: Europe/Berlin
+	// max length: 64
+	TimeZone string `gorm:"size:64" json:"timezone,omitempty" example:"Europe/Berlin"`
+
+	// Preferred BCP 47 locale
+	// example: de-DE
+	// max length: 35
+	Locale string `gorm:"size:35" json:"locale,omitempty" example:"de-DE"`
+
+	// Custom profile values, validated against the admin-defined ProfileField schema
+	// example: {"department":"engineering"}
+	Metadata Metadata `gorm:"type:jsonb;not null;default:'{}';index:idx_users_metadata,type:gin" json:"metadata" example:"department:engineering"`
 
 	// Password for authentication (never exposed in JSON responses)
 	// required: true
@@ -67,34 +129,61 @@ type User struct {
 	// writeOnly: true
 	Password string `gorm:"not null" json:"-"`
 
-	// Type of user account
-	// enum: user,admin
+	// Role of the user account: user, admin or a role defined under /roles
 	// example: user
 	// default: user
-	UserType UserType `gorm:"type:varchar(20);default:'user'" json:"user_type"`
+	UserType UserType `gorm:"type:varchar(20);default:'user'" json:"user_type" example:"user"`
 
-	// Account status
-	// enum: active,inactive,suspended
+	// Account status; deleted accounts were anonymized after their owner deleted them
+	// enum: active,inactive,suspended,deleted
 	// example: active
 	// default: active
-	Status string `gorm:"type:varchar(20);default:'active'" json:"status"`
+	Status string `gorm:"type:varchar(20);default:'active'" json:"status" example:"active"`
+
+	// Why an admin last changed the account status
+	// example: Chargeback under investigation
+	// readOnly: true
+	StatusReason *string `gorm:"type:varchar(500)" json:"status_reason,omitempty" example:"Chargeback under investigation"`
+
+	// Why abuse detection flagged the account for review; empty once reviewed
+	// example: disposable_email: mailinator.com is a disposable email domain
+	// readOnly: true
+	ReviewReason *string `gorm:"type:varchar(500)" json:"review_reason,omitempty" example:"disposable_email: mailinator.com is a disposable email domain"`
+
+	// When the user last logged in, with any login method; shown to holders of users:list
+	// example: 2023-10-05T14:30:00Z
+	// format: date-time
+	// readOnly: true
+	LastLoginAt *time.Time `gorm:"index" json:"last_login_at,omitempty" example:"2023-10-05T14:30:00Z"`
+
+	// Client IP of the last login; shown to holders of users:list
+	// example: 203.0.113.7
+	// readOnly: true
+	LastLoginIP *string `gorm:"type:varchar(45)" json:"last_login_ip,omitempty" example:"203.0.113.7"`
+
+	// When the account will be anonymized after its owner deleted it through DELETE /me;
+	// logging in before then cancels the deletion
+	// example: 2023-11-04T14:30:00Z
+	// format: date-time
+	// readOnly: true
+	DeletionScheduledAt *time.Time `gorm:"index" json:"deletion_scheduled_at,omitempty" example:"2023-11-04T14:30:00Z"`
 
 	// CreatedAt indicates when the user account was created
 	// example: 2023-10-05T14:30:00Z
 	// format: date-time
 	// readOnly: true
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index:idx_users_created_id,priority:1" json:"created_at" example:"2023-10-05T14:30:00Z"`
 
 	// UpdatedAt shows when the user account was last updated
 	// example: 2023-10-05T14:30:00Z
 	// format: date-time
 	// readOnly: true
-	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at" example:"2023-10-05T14:30:00Z"`
 }
 
 // BeforeCreate hook to generate UUID before inserting
 func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
-	u.ID = uuid.New()
+	u.ID = id.New()
 	// Note: Functional indexes are created during migrations, not here
 	return nil
 }
@@ -116,7 +205,33 @@ func (u *User) FullName() string {
 
 // IsActive checks if the user is active
 func (u *User) IsActive() bool {
-	return u.Status == "active"
+	return u.Status == StatusActive
+}
+
+// FlaggedForReview reports whether abuse detection flagged the account
+func (u *User) FlaggedForReview() bool {
+	return u.ReviewReason != nil
+}
+
+// DeletionScheduled reports whether the owner asked to delete the account and has not
+// logged in since
+func (u *User) DeletionScheduled() bool {
+	return u.DeletionScheduledAt != nil
+}
+
+// EmailVerified reports whether the user confirmed their current email address
+func (u *User) EmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// HasPhone reports whether the user has a phone number on file
+func (u *User) HasPhone() bool {
+	return u.Phone != nil && *u.Phone != ""
+}
+
+// PhoneVerified reports whether the user confirmed their current phone number
+func (u *User) PhoneVerified() bool {
+	return u.HasPhone() && u.PhoneVerifiedAt != nil
 }
 
 // IsAdmin checks if the user is an admin
@@ -129,6 +244,19 @@ func (User) TableName() string {
 	return "users"
 }
 
+// SearchText is the lowercased text that user search matches terms against
+const SearchText = "LOWER(username || ' ' || email || ' ' || first_name || ' ' || last_name)"
+
+// CreateSearchIndex adds a trigram index on SearchText, so substring search does not scan
+// the table. It needs the pg_trgm extension, which the database user may not be allowed
+// to create; search still works without the index, only slower.
+func CreateSearchIndex(db *gorm.DB) error {
+	if err := db.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
+		return err
+	}
+	return db.Exec("CREATE INDEX IF NOT EXISTS idx_users_search_trgm ON users USING GIN ((" + SearchText + ") gin_trgm_ops)").Error
+}
+
 // CreateFunctionalIndexes ensures case-insensitive search index
 func CreateFunctionalIndexes(db *gorm.DB) error {
 	return db.Exec(`
@@ -136,3 +264,45 @@ func CreateFunctionalIndexes(db *gorm.DB) error {
 		ON users (LOWER(username));
 	`).Error
 }
+
+// CreateEmailIndex makes emails unique ignoring case with a unique index on LOWER(email),
+// then drops the case-sensitive idx_users_email it replaces. It fails, keeping the old
+// index, while two accounts have emails that differ only in case.
+func CreateEmailIndex(db *gorm.DB) error {
+	if err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_users_lower_email ON users (LOWER(email))").Error; err != nil {
+		return err
+	}
+	return db.Exec("DROP INDEX IF EXISTS idx_users_email").Error
+}
+
+// BackfillNormalizedEmails fills normalized_email for rows created before the column existed.
+// Rows whose normalized form collides with another account are skipped and returned so the
+// duplicates can be resolved manually; the unique index keeps new duplicates out.
+func BackfillNormalizedEmails(db *gorm.DB, normalizer EmailNormalizer) ([]uuid.UUID, error) {
+	var pending []User
+	if err := db.Unscoped().Select("id", "email").
+		Where("normalized_email IS NULL OR normalized_email = ''").
+		Find(&pending).Error; err != nil {
+		return nil, err
+	}
+
+	var conflicts []uuid.UUID
+	for _, u := range pending {
+		err := db.Unscoped().Model(&User{}).Where("id = ?", u.ID).
+			UpdateColumn("normalized_email", normalizer.Normalize(u.Email)).Error
+		if err != nil {
+			conflicts = append(conflicts, u.ID)
+		}
+	}
+	return conflicts, nil
+}
+
+// UserStats counts the accounts that are not deleted, and those of them that logged in
+// within the last 1, 7 and 30 days
+// swagger:model
+type UserStats struct {
+	Total     int64 `gorm:"column:total" json:"total" example:"1250"`
+	Active1d  int64 `gorm:"column:active_1d" json:"active_1d" example:"140"`
+	Active7d  int64 `gorm:"column:active_7d" json:"active_7d" example:"610"`
+	Active30d int64 `gorm:"column:active_30d" json:"active_30d" example:"980"`
+}
@@ -0,0 +1,58 @@
+package repo
+
+import (
+	"context"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+
+	"gorm.io/gorm"
+)
+
+// PasswordHistoryRepo stores the hashes of the passwords users have set
+type PasswordHistoryRepo interface {
+	Create(ctx context.Context, entry *model.PasswordHistory) error
+	// Recent returns a user's newest limit entries, newest first
+	Recent(ctx context.Context, userID string, limit int) ([]model.PasswordHistory, error)
+	// Prune deletes every entry beyond the newest keep of each user
+	Prune(ctx context.Context, keep int) (int64, error)
+	// DeleteByUser deletes every entry of a user
+	DeleteByUser(ctx context.Context, userID string) error
+}
+
+type passwordHistoryRepo struct {
+	db *gorm.DB
+}
+
+func NewPasswordHistoryRepo(db *gorm.DB) PasswordHistoryRepo {
+	return &passwordHistoryRepo{db: db}
+}
+
+func (r *passwordHistoryRepo) Create(ctx context.Context, entry *model.PasswordHistory) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *passwordHistoryRepo) Recent(ctx context.Context, userID string, limit int) ([]model.PasswordHistory, error) {
+	var entries []model.PasswordHistory
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at desc").
+		Limit(limit).
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r *passwordHistoryRepo) Prune(ctx context.Context, keep int) (int64, error) {
+	result := r.db.WithContext(ctx).Exec(`DELETE FROM password_history WHERE id IN (
+		SELECT id FROM (
+			SELECT id, row_number() OVER (PARTITION BY user_id ORDER BY created_at DESC) AS position
+			FROM password_history
+		) ranked WHERE position > ?
+	)`, keep)
+	return result.RowsAffected, result.Error
+}
+
+func (r *passwordHistoryRepo) DeleteByUser(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&model.PasswordHistory{}).Error
+}
@@ -0,0 +1,141 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+
+	"gorm.io/gorm"
+)
+
+// ProfileFieldRepo stores the admin-defined schema for user metadata
+type ProfileFieldRepo interface {
+	List(ctx context.Context) ([]model.ProfileField, error)
+	FindByKey(ctx context.Context, key string) (*model.ProfileField, error)
+	Create(ctx context.Context, field *model.ProfileField) error
+	Update(ctx context.Context, field *model.ProfileField) error
+	Delete(ctx context.Context, key string) error
+}
+
+type profileFieldRepo struct {
+	db *gorm.DB
+}
+
+func NewProfileFieldRepo(db *gorm.DB) ProfileFieldRepo {
+	return &profileFieldRepo{db: db}
+}
+
+func (r *profileFieldRepo) List(ctx context.Context) ([]model.ProfileField, error) {
+	var fields []model.ProfileField
+	if err := r.db.WithContext(ctx).Order("key asc").Find(&fields).Error; err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func (r *profileFieldRepo) FindByKey(ctx context.Context, key string) (*model.ProfileField, error) {
+	var field model.ProfileField
+	if err := r.db.WithContext(ctx).First(&field, "key = ?", key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &field, nil
+}
+
+func (r *profileFieldRepo) Create(ctx context.Context, field *model.ProfileField) error {
+	if err := r.db.WithContext(ctx).Create(field).Error; err != nil {
+		if _, ok := uniqueViolation(err); ok {
+			return apperrors.ErrProfileFieldExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *profileFieldRepo) Update(ctx context.Context, field *model.ProfileField) error {
+	return r.db.WithContext(ctx).Save(field).Error
+}
+
+func (r *profileFieldRepo) Delete(ctx context.Context, key string) error {
+	return r.db.WithContext(ctx).Where("key = ?", key).Delete(&model.ProfileField{}).Error
+}
+
+// declaredFieldRepo adds fields declared in configuration to the ones stored in the
+// database. A declared field hides a stored one with the same key.
+type declaredFieldRepo struct {
+	ProfileFieldRepo
+	declared []model.ProfileField
+}
+
+// WithDeclaredFields returns next with declared added to its fields, marked Config.
+// Creating a field with a declared key fails with ErrProfileFieldExists.
+func WithDeclaredFields(next ProfileFieldRepo, declared []model.ProfileField) ProfileFieldRepo {
+	if len(declared) == 0 {
+		return next
+	}
+	fields := make([]model.ProfileField, len(declared))
+	for i, field := range declared {
+		field.Config = true
+		fields[i] = field
+	}
+	return &declaredFieldRepo{ProfileFieldRepo: next, declared: fields}
+}
+
+func (r *declaredFieldRepo) List(ctx context.Context) ([]model.ProfileField, error) {
+	stored, err := r.ProfileFieldRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fields := slices.Clone(r.declared)
+	for _, field := range stored {
+		if r.find(field.Key) == nil {
+			fields = append(fields, field)
+		}
+	}
+	slices.SortFunc(fields, func(a, b model.ProfileField) int { return strings.Compare(a.Key, b.Key) })
+	return fields, nil
+}
+
+func (r *declaredFieldRepo) FindByKey(ctx context.Context, key string) (*model.ProfileField, error) {
+	if field := r.find(key); field != nil {
+		return field, nil
+	}
+	return r.ProfileFieldRepo.FindByKey(ctx, key)
+}
+
+func (r *declaredFieldRepo) Create(ctx context.Context, field *model.ProfileField) error {
+	if r.find(field.Key) != nil {
+		return apperrors.ErrProfileFieldExists
+	}
+	return r.ProfileFieldRepo.Create(ctx, field)
+}
+
+func (r *declaredFieldRepo) Update(ctx context.Context, field *model.ProfileField) error {
+	if r.find(field.Key) != nil {
+		return apperrors.ErrProfileFieldFromConfig
+	}
+	return r.ProfileFieldRepo.Update(ctx, field)
+}
+
+func (r *declaredFieldRepo) Delete(ctx context.Context, key string) error {
+	if r.find(key) != nil {
+		return apperrors.ErrProfileFieldFromConfig
+	}
+	return r.ProfileFieldRepo.Delete(ctx, key)
+}
+
+// find returns a copy of the declared field with key, or nil
+func (r *declaredFieldRepo) find(key string) *model.ProfileField {
+	for _, field := range r.declared {
+		if field.Key == key {
+			return &field
+		}
+	}
+	return nil
+}
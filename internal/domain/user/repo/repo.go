@@ -3,10 +3,12 @@ package repo
 import (
 	"context"
 	"errors"
-	"go_platform_template/internal/domain/user/model"
-	apperrors "go_platform_template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
 	"strings"
+	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/lib/pq"
 	"gorm.io/gorm"
 )
@@ -16,32 +18,113 @@ type UserRepo interface {
 	FindByID(ctx context.Context, id string) (*model.User, error)
 	FindByUsername(ctx context.Context, username string) (*model.User, error)
 	GetByEmail(ctx context.Context, email string) (*model.User, error)
+	GetByPhone(ctx context.Context, phone string) (*model.User, error)
 	Update(ctx context.Context, user *model.User) error
 	Delete(ctx context.Context, id string) error
+	// ApplyBatch saves updated and deletes deleted in one transaction, so either all of
+	// them are written or none
+	ApplyBatch(ctx context.Context, updated, deleted []*model.User) error
 	List(ctx context.Context, offset, limit int, filters map[string]interface{}, sortBy, sortOrder string) ([]*model.User, error)
+	// ListAfter returns up to limit users matching filters ordered by (created_at, id) in
+	// sortOrder, starting after cursor; a nil cursor starts at the beginning
+	ListAfter(ctx context.Context, cursor *model.ListCursor, limit int, filters map[string]interface{}, sortOrder string) ([]*model.User, error)
+	// StreamList calls fn for every user matching filters, one row at a time, and stops
+	// at the first error fn returns
+	StreamList(ctx context.Context, filters map[string]interface{}, sortBy, sortOrder string, fn func(*model.User) error) error
 	GetByEmailOrUsername(ctx context.Context, identifier string) (*model.User, error)
+	// ListDueForDeletion returns up to limit users whose scheduled deletion is at or before
+	// now and who are not anonymized yet, the longest overdue first
+	ListDueForDeletion(ctx context.Context, now time.Time, limit int) ([]*model.User, error)
+	// RecordLogin sets the last login time and IP of a user without touching other columns
+	RecordLogin(ctx context.Context, id string, at time.Time, ip string) error
+	// Stats counts the users that are not deleted and those active in the days before now
+	Stats(ctx context.Context, now time.Time) (*model.UserStats, error)
+	// CountRegistrations counts the users created at or after since per UTC day, oldest
+	// first; days without registrations are left out
+	CountRegistrations(ctx context.Context, since time.Time) ([]model.DailyCount, error)
+	// CountByStatus counts all users per status
+	CountByStatus(ctx context.Context) (map[string]int64, error)
+	// CountByRole counts the users that are not deleted per role
+	CountByRole(ctx context.Context) (map[string]int64, error)
 }
 
+// MetadataFilter is the List filter key for custom profile fields; its value is a
+// model.Metadata of typed values that a user's metadata must contain
+const MetadataFilter = "metadata"
+
+// SearchFilter is the List filter key for free-text search; its value is a string whose
+// space-separated terms must each appear, ignoring case, in the username, email, first
+// or last name
+const SearchFilter = "search"
+
+// likeEscaper makes LIKE wildcards in search terms match literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// TagFilter is the List filter key that selects users with a tag; its value is a
+// normalized tag string
+const TagFilter = "tag"
+
+// FlaggedFilter is the List filter key that selects users flagged for review (true) or
+// not flagged (false)
+const FlaggedFilter = "flagged"
+
 type userRepo struct {
-	db *gorm.DB
+	db     *gorm.DB
+	emails model.EmailNormalizer
 }
 
-func NewUserRepo(db *gorm.DB) UserRepo {
-	return &userRepo{db: db}
+// RepoOption customizes a UserRepo created by NewUserRepo
+type RepoOption func(*userRepo)
+
+// WithGmailFolding makes the repo treat dotted and "+tag" Gmail aliases as the same address
+func WithGmailFolding(enabled bool) RepoOption {
+	return func(r *userRepo) {
+		r.emails.FoldGmail = enabled
+	}
+}
+
+func NewUserRepo(db *gorm.DB, opts ...RepoOption) UserRepo {
+	r := &userRepo{db: db}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// canonicalizeEmail trims the stored email, lowercases its domain and refreshes its
+// normalized form
+func (r *userRepo) canonicalizeEmail(user *model.User) {
+	user.Email = model.CanonicalEmail(user.Email)
+	user.NormalizedEmail = r.emails.Normalize(user.Email)
+}
+
+// uniqueViolation reports whether err is a PostgreSQL unique constraint violation and,
+// if so, which constraint was hit.
+// GORM's postgres driver runs on pgx, while lib/pq is kept for raw sql.DB usage.
+func uniqueViolation(err error) (string, bool) {
+	var code, constraint string
+	var pgxErr *pgconn.PgError
+	var pqErr *pq.Error
+	switch {
+	case errors.As(err, &pgxErr):
+		code, constraint = pgxErr.Code, pgxErr.ConstraintName
+	case errors.As(err, &pqErr):
+		code, constraint = string(pqErr.Code), pqErr.Constraint
+	}
+	return constraint, code == "23505"
 }
 
 // handleConstraintError converts database constraint errors to user-friendly messages
 func handleConstraintError(err error) error {
-	// Check for PostgreSQL unique constraint violations
-	var pgErr *pq.Error
-	if errors.As(err, &pgErr) {
-		if pgErr.Code == "23505" { // unique_violation
-			if strings.Contains(pgErr.Constraint, "username") {
-				return apperrors.ErrUsernameAlreadyTaken
-			}
-			if strings.Contains(pgErr.Constraint, "email") {
-				return apperrors.ErrEmailAlreadyRegistered
-			}
+	if constraint, ok := uniqueViolation(err); ok {
+		if strings.Contains(constraint, "username") {
+			return apperrors.ErrUsernameAlreadyTaken
+		}
+		if strings.Contains(constraint, "email") {
+			return apperrors.ErrEmailAlreadyRegistered
+		}
+		if strings.Contains(constraint, "phone") {
+			return apperrors.ErrPhoneAlreadyRegistered
 		}
 	}
 	// Return original error if not a constraint violation
@@ -49,6 +132,7 @@ func handleConstraintError(err error) error {
 }
 
 func (r *userRepo) Create(ctx context.Context, user *model.User) error {
+	r.canonicalizeEmail(user)
 	result := r.db.WithContext(ctx).Create(user)
 	if result.Error != nil {
 		return handleConstraintError(result.Error)
@@ -80,7 +164,24 @@ func (r *userRepo) FindByUsername(ctx context.Context, username string) (*model.
 
 func (r *userRepo) GetByEmail(ctx context.Context, email string) (*model.User, error) {
 	var user model.User
-	if err := r.db.WithContext(ctx).Unscoped().Where("email = ?", email).First(&user).Error; err != nil {
+	// Rows created before normalized_email existed are matched case-insensitively, through
+	// idx_users_lower_email, until backfilled
+	normalized := r.emails.Normalize(email)
+	if err := r.db.WithContext(ctx).Unscoped().
+		Where("normalized_email = ? OR (normalized_email IS NULL AND LOWER(email) = LOWER(?))", normalized, strings.TrimSpace(email)).
+		First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetByPhone looks a user up by E.164 phone number
+func (r *userRepo) GetByPhone(ctx context.Context, phone string) (*model.User, error) {
+	var user model.User
+	if err := r.db.WithContext(ctx).Unscoped().Where("phone = ?", phone).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -90,7 +191,87 @@ func (r *userRepo) GetByEmail(ctx context.Context, email string) (*model.User, e
 }
 
 func (r *userRepo) Update(ctx context.Context, user *model.User) error {
-	return r.db.WithContext(ctx).Unscoped().Save(user).Error
+	r.canonicalizeEmail(user)
+	if err := r.db.WithContext(ctx).Unscoped().Save(user).Error; err != nil {
+		return handleConstraintError(err)
+	}
+	return nil
+}
+
+func (r *userRepo) ListDueForDeletion(ctx context.Context, now time.Time, limit int) ([]*model.User, error) {
+	var users []*model.User
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("deletion_scheduled_at <= ? AND status <> ?", now, "deleted").
+		Order("deletion_scheduled_at").
+		Limit(limit).
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *userRepo) RecordLogin(ctx context.Context, id string, at time.Time, ip string) error {
+	return r.db.WithContext(ctx).Unscoped().Model(&model.User{}).Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{"last_login_at": at, "last_login_ip": ip}).Error
+}
+
+func (r *userRepo) Stats(ctx context.Context, now time.Time) (*model.UserStats, error) {
+	var stats model.UserStats
+	err := r.db.WithContext(ctx).Unscoped().Model(&model.User{}).
+		Select(`COUNT(*) AS total,
+			COUNT(*) FILTER (WHERE last_login_at >= ?) AS active_1d,
+			COUNT(*) FILTER (WHERE last_login_at >= ?) AS active_7d,
+			COUNT(*) FILTER (WHERE last_login_at >= ?) AS active_30d`,
+			now.AddDate(0, 0, -1), now.AddDate(0, 0, -7), now.AddDate(0, 0, -30)).
+		Where("status <> ?", model.StatusDeleted).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+func (r *userRepo) CountRegistrations(ctx context.Context, since time.Time) ([]model.DailyCount, error) {
+	var counts []model.DailyCount
+	err := r.db.WithContext(ctx).Unscoped().Model(&model.User{}).
+		Select("to_char(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD') AS date, COUNT(*) AS count").
+		Where("created_at >= ?", since).
+		Group("1").
+		Order("1").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+func (r *userRepo) CountByStatus(ctx context.Context) (map[string]int64, error) {
+	return r.countBy(r.db.WithContext(ctx), "status")
+}
+
+func (r *userRepo) CountByRole(ctx context.Context) (map[string]int64, error) {
+	return r.countBy(r.db.WithContext(ctx).Where("status <> ?", model.StatusDeleted), "user_type")
+}
+
+// countBy counts the users of query per value of column, which must be a trusted name
+func (r *userRepo) countBy(query *gorm.DB, column string) (map[string]int64, error) {
+	var rows []struct {
+		Value string
+		Count int64
+	}
+	err := query.Unscoped().Model(&model.User{}).
+		Select(column + " AS value, COUNT(*) AS count").
+		Group(column).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Value] = row.Count
+	}
+	return counts, nil
 }
 
 // Delete fetches user by ID and deletes it
@@ -105,13 +286,55 @@ func (r *userRepo) Delete(ctx context.Context, id string) error {
 	return r.db.WithContext(ctx).Delete(user).Error
 }
 
-// List fetches users with optional filters, pagination, and sorting
-func (r *userRepo) List(ctx context.Context, offset, limit int, filters map[string]interface{}, sortBy, sortOrder string) ([]*model.User, error) {
-	var users []*model.User
+func (r *userRepo) ApplyBatch(ctx context.Context, updated, deleted []*model.User) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, user := range updated {
+			r.canonicalizeEmail(user)
+			if err := tx.Unscoped().Save(user).Error; err != nil {
+				return handleConstraintError(err)
+			}
+		}
+		for _, user := range deleted {
+			if err := tx.Delete(user).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// listQuery builds the filtered and sorted query shared by List and StreamList
+func (r *userRepo) listQuery(ctx context.Context, filters map[string]interface{}, sortBy, sortOrder string) *gorm.DB {
 	query := r.db.WithContext(ctx).Unscoped().Model(&model.User{})
 
 	// Apply filters
 	for key, val := range filters {
+		if key == MetadataFilter {
+			// Typed containment match, served by the GIN index on metadata
+			query = query.Where("metadata @> ?::jsonb", val)
+			continue
+		}
+		if key == SearchFilter {
+			// Substring match on every term, served by the trigram index on model.SearchText
+			search, _ := val.(string)
+			for _, term := range strings.Fields(strings.ToLower(search)) {
+				query = query.Where(model.SearchText+" LIKE ?", "%"+likeEscaper.Replace(term)+"%")
+			}
+			continue
+		}
+		if key == TagFilter {
+			// Served by the primary key of user_tags, which leads with user_id
+			query = query.Where("EXISTS (SELECT 1 FROM user_tags WHERE user_tags.user_id = users.id AND user_tags.tag = ?)", val)
+			continue
+		}
+		if key == FlaggedFilter {
+			if flagged, _ := val.(bool); flagged {
+				query = query.Where("review_reason IS NOT NULL")
+			} else {
+				query = query.Where("review_reason IS NULL")
+			}
+			continue
+		}
 		query = query.Where(key+" = ?", val)
 	}
 
@@ -142,6 +365,13 @@ func (r *userRepo) List(ctx context.Context, offset, limit int, filters map[stri
 		order := column + " " + direction
 		query = query.Order(order)
 	}
+	return query
+}
+
+// List fetches users with optional filters, pagination, and sorting
+func (r *userRepo) List(ctx context.Context, offset, limit int, filters map[string]interface{}, sortBy, sortOrder string) ([]*model.User, error) {
+	var users []*model.User
+	query := r.listQuery(ctx, filters, sortBy, sortOrder)
 
 	// Apply pagination
 	if limit > 0 {
@@ -154,14 +384,58 @@ func (r *userRepo) List(ctx context.Context, offset, limit int, filters map[stri
 	return users, nil
 }
 
+// ListAfter pages with a keyset instead of an offset, so later pages cost as much as the
+// first. Served by idx_users_created_id.
+func (r *userRepo) ListAfter(ctx context.Context, cursor *model.ListCursor, limit int, filters map[string]interface{}, sortOrder string) ([]*model.User, error) {
+	query := r.listQuery(ctx, filters, "", "")
+	direction, op := "asc", ">"
+	if strings.ToLower(sortOrder) == "desc" {
+		direction, op = "desc", "<"
+	}
+	if cursor != nil {
+		query = query.Where("(created_at, id) "+op+" (?, ?)", cursor.CreatedAt, cursor.ID)
+	}
+
+	var users []*model.User
+	err := query.Order("created_at " + direction + ", id " + direction).Limit(limit).Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// StreamList scans matching users from a cursor instead of loading them all. Cancelling
+// ctx aborts the query.
+func (r *userRepo) StreamList(ctx context.Context, filters map[string]interface{}, sortBy, sortOrder string, fn func(*model.User) error) error {
+	db := r.listQuery(ctx, filters, sortBy, sortOrder)
+	rows, err := db.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user model.User
+		if err := db.ScanRows(rows, &user); err != nil {
+			return err
+		}
+		if err := fn(&user); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
 func (r *userRepo) GetByEmailOrUsername(ctx context.Context, identifier string) (*model.User, error) {
 	if identifier == "" {
 		return nil, nil
 	}
 
+	identifier = strings.TrimSpace(identifier)
 	var user model.User
 	err := r.db.WithContext(ctx).Unscoped().
-		Where("LOWER(email) = LOWER(?) OR LOWER(username) = LOWER(?)", identifier, identifier).
+		Where("normalized_email = ? OR (normalized_email IS NULL AND LOWER(email) = LOWER(?)) OR LOWER(username) = LOWER(?)",
+			r.emails.Normalize(identifier), identifier, identifier).
 		First(&user).Error
 
 	if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -0,0 +1,52 @@
+package repo
+
+import (
+	"context"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+
+	"gorm.io/gorm"
+)
+
+// RevisionRepo stores the append-only change history of users
+type RevisionRepo interface {
+	Create(ctx context.Context, revisions []model.UserRevision) error
+	ListByUser(ctx context.Context, userID string, offset, limit int) ([]model.UserRevision, error)
+	// RedactUser replaces the recorded values of a user's revisions with
+	// model.RedactedValue, keeping which fields changed and when
+	RedactUser(ctx context.Context, userID string) error
+}
+
+type revisionRepo struct {
+	db *gorm.DB
+}
+
+func NewRevisionRepo(db *gorm.DB) RevisionRepo {
+	return &revisionRepo{db: db}
+}
+
+func (r *revisionRepo) Create(ctx context.Context, revisions []model.UserRevision) error {
+	if len(revisions) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&revisions).Error
+}
+
+// ListByUser returns a user's revisions, newest first
+func (r *revisionRepo) ListByUser(ctx context.Context, userID string, offset, limit int) ([]model.UserRevision, error) {
+	var revisions []model.UserRevision
+	query := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at desc, field asc")
+	if limit > 0 {
+		query = query.Offset(offset).Limit(limit)
+	}
+	if err := query.Find(&revisions).Error; err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (r *revisionRepo) RedactUser(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).Exec(`UPDATE user_revisions SET
+		old_value = CASE WHEN old_value IS NULL THEN NULL ELSE ? END,
+		new_value = CASE WHEN new_value IS NULL THEN NULL ELSE ? END
+		WHERE user_id = ?`, model.RedactedValue, model.RedactedValue, userID).Error
+}
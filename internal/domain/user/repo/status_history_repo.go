@@ -0,0 +1,39 @@
+package repo
+
+import (
+	"context"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+
+	"gorm.io/gorm"
+)
+
+// StatusHistoryRepo stores the append-only history of account status changes
+type StatusHistoryRepo interface {
+	Create(ctx context.Context, change *model.StatusChange) error
+	// ListByUser returns the status changes of a user, newest first; a limit of 0 returns all
+	ListByUser(ctx context.Context, userID string, offset, limit int) ([]model.StatusChange, error)
+}
+
+type statusHistoryRepo struct {
+	db *gorm.DB
+}
+
+func NewStatusHistoryRepo(db *gorm.DB) StatusHistoryRepo {
+	return &statusHistoryRepo{db: db}
+}
+
+func (r *statusHistoryRepo) Create(ctx context.Context, change *model.StatusChange) error {
+	return r.db.WithContext(ctx).Create(change).Error
+}
+
+func (r *statusHistoryRepo) ListByUser(ctx context.Context, userID string, offset, limit int) ([]model.StatusChange, error) {
+	changes := []model.StatusChange{}
+	query := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at desc")
+	if limit > 0 {
+		query = query.Offset(offset).Limit(limit)
+	}
+	if err := query.Find(&changes).Error; err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
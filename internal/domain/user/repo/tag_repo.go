@@ -0,0 +1,71 @@
+package repo
+
+import (
+	"context"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TagRepo stores the tags put on users
+type TagRepo interface {
+	// ListByUser returns the tags of a user in alphabetical order
+	ListByUser(ctx context.Context, userID string) ([]string, error)
+	// Add puts tags on a user; tags the user already has are left alone
+	Add(ctx context.Context, userID uuid.UUID, tags []string) error
+	// Remove takes tag off a user and reports whether the user had it
+	Remove(ctx context.Context, userID, tag string) (bool, error)
+	// Counts returns every tag in use with how many users that are not deleted have it,
+	// in alphabetical order
+	Counts(ctx context.Context) ([]model.TagCount, error)
+}
+
+type tagRepo struct {
+	db *gorm.DB
+}
+
+func NewTagRepo(db *gorm.DB) TagRepo {
+	return &tagRepo{db: db}
+}
+
+func (r *tagRepo) ListByUser(ctx context.Context, userID string) ([]string, error) {
+	tags := []string{}
+	err := r.db.WithContext(ctx).Model(&model.UserTag{}).
+		Where("user_id = ?", userID).
+		Order("tag").
+		Pluck("tag", &tags).Error
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func (r *tagRepo) Add(ctx context.Context, userID uuid.UUID, tags []string) error {
+	rows := make([]model.UserTag, len(tags))
+	for i, tag := range tags {
+		rows[i] = model.UserTag{UserID: userID, Tag: tag}
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+}
+
+func (r *tagRepo) Remove(ctx context.Context, userID, tag string) (bool, error) {
+	result := r.db.WithContext(ctx).Where("user_id = ? AND tag = ?", userID, tag).Delete(&model.UserTag{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *tagRepo) Counts(ctx context.Context) ([]model.TagCount, error) {
+	counts := []model.TagCount{}
+	err := r.db.WithContext(ctx).Model(&model.UserTag{}).
+		Select("user_tags.tag AS tag, COUNT(*) AS users").
+		Joins("JOIN users ON users.id = user_tags.user_id").
+		Where("users.status <> ?", model.StatusDeleted).
+		Group("user_tags.tag").
+		Order("user_tags.tag").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
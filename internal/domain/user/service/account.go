@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/cache"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+)
+
+// AccountStatus is what authorization checks need to know about a user on every request
+type AccountStatus struct {
+	Role   model.UserType `json:"role"`
+	Status string         `json:"status"`
+}
+
+// IsActive reports whether the account may still use its tokens
+func (a *AccountStatus) IsActive() bool {
+	return a.Status == "active"
+}
+
+// WithAccountCache caches AccountStatus lookups in c for ttl. Update and Delete drop the
+// cached entry, so role changes and deactivation apply on the next request of this
+// instance; with a process-local cache other replicas see them after at most ttl.
+// A ttl of zero or less disables the shared cache.
+func WithAccountCache(c cache.Cache, ttl time.Duration) ServiceOption {
+	return func(s *userService) {
+		if ttl <= 0 {
+			return
+		}
+		s.accounts = c
+		s.accountTTL = ttl
+	}
+}
+
+func accountCacheKey(id string) string {
+	return "user:account:" + id
+}
+
+// AccountStatus returns the role and status of a user. Lookups are served from the
+// request-scoped cache (cache.WithRequestScope) and then the shared account cache, when
+// configured, before the database is queried.
+func (s *userService) AccountStatus(ctx context.Context, id string) (*AccountStatus, error) {
+	key := accountCacheKey(id)
+	scoped := cache.FromRequest(ctx)
+	if account, _ := s.cachedAccount(ctx, scoped, key); account != nil {
+		return account, nil
+	}
+	if account, encoded := s.cachedAccount(ctx, s.accounts, key); account != nil {
+		if scoped != nil {
+			_ = scoped.Set(ctx, key, encoded, 0)
+		}
+		return account, nil
+	}
+
+	user, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		s.logger.Errorw("failed to fetch user status", "user_id", id, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to fetch user")
+	}
+	if user == nil {
+		return nil, apperrors.NewAppError(apperrors.NotFoundError, "User not found")
+	}
+
+	account := &AccountStatus{Role: user.UserType, Status: user.Status}
+	if encoded, err := json.Marshal(account); err == nil {
+		if scoped != nil {
+			_ = scoped.Set(ctx, key, encoded, 0)
+		}
+		if s.accounts != nil {
+			if err := s.accounts.Set(ctx, key, encoded, s.accountTTL); err != nil {
+				s.logger.Warnw("failed to cache user status", "user_id", id, "error", err)
+			}
+		}
+	}
+	return account, nil
+}
+
+// cachedAccount returns the entry for key in c along with its encoded form, treating
+// cache errors as misses
+func (s *userService) cachedAccount(ctx context.Context, c cache.Cache, key string) (*AccountStatus, []byte) {
+	if c == nil {
+		return nil, nil
+	}
+	encoded, ok, err := c.Get(ctx, key)
+	if err != nil {
+		s.logger.Warnw("user status cache read failed", "key", key, "error", err)
+		return nil, nil
+	}
+	if !ok {
+		return nil, nil
+	}
+	var account AccountStatus
+	if err := json.Unmarshal(encoded, &account); err != nil {
+		return nil, nil
+	}
+	return &account, encoded
+}
+
+// invalidateAccount drops cached status for a user after a change
+func (s *userService) invalidateAccount(ctx context.Context, id string) {
+	key := accountCacheKey(id)
+	if scoped := cache.FromRequest(ctx); scoped != nil {
+		_ = scoped.Delete(ctx, key)
+	}
+	if s.accounts == nil {
+		return
+	}
+	if err := s.accounts.Delete(ctx, key); err != nil {
+		s.logger.Errorw("failed to invalidate cached user status", "user_id", id, "error", err)
+	}
+}
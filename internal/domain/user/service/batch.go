@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+
+	"github.com/google/uuid"
+)
+
+// batchChange is an operation of a batch that passed its checks, with the user as it
+// was and as it will be written
+type batchChange struct {
+	op     dto.BatchOperation
+	before model.User
+	user   *model.User
+}
+
+// Batch deletes users and changes their role or status in one transaction. Every
+// operation is checked first, with the same rules as the single-user routes; if any is
+// rejected, nothing is written and the result of each operation says why. A user may
+// appear only once, and the caller's own account cannot be changed.
+func (s *userService) Batch(ctx context.Context, ops []dto.BatchOperation) (*dto.BatchResponse, error) {
+	resp := &dto.BatchResponse{Results: make([]dto.BatchResult, len(ops))}
+	changes := make([]*batchChange, len(ops))
+	seen := make(map[string]bool, len(ops))
+	failed := false
+	for i, op := range ops {
+		resp.Results[i] = dto.BatchResult{Index: i, Op: op.Op, UserID: op.UserID}
+		var err error
+		if seen[op.UserID] {
+			err = apperrors.NewAppError(apperrors.BadRequestError, "The user already has an earlier operation in this batch")
+		} else {
+			seen[op.UserID] = true
+			changes[i], err = s.planBatchChange(ctx, op)
+		}
+		if err != nil {
+			var appErr *apperrors.AppError
+			if !errors.As(err, &appErr) || appErr.Type == apperrors.InternalError {
+				return nil, err
+			}
+			resp.Results[i].Result = dto.BatchFailed
+			resp.Results[i].Error = appErr.Message
+			failed = true
+		}
+	}
+
+	var updated, deleted []*model.User
+	for i, change := range changes {
+		switch {
+		case change == nil:
+		case failed:
+			resp.Results[i].Result = dto.BatchSkipped
+		case change.op.Op == dto.BatchOpDelete:
+			deleted = append(deleted, change.user)
+			resp.Results[i].Result = dto.BatchApplied
+		case change.before.UserType == change.user.UserType && change.before.Status == change.user.Status:
+			resp.Results[i].Result = dto.BatchUnchanged
+		default:
+			updated = append(updated, change.user)
+			resp.Results[i].Result = dto.BatchApplied
+		}
+	}
+	if failed {
+		return resp, nil
+	}
+
+	if err := s.repo.ApplyBatch(ctx, updated, deleted); err != nil {
+		s.logger.Errorw("failed to apply user batch", "updated", len(updated), "deleted", len(deleted), "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to apply batch")
+	}
+	resp.Applied = true
+
+	by := actorID(ctx)
+	for i, change := range changes {
+		if resp.Results[i].Result != dto.BatchApplied {
+			continue
+		}
+		id := change.user.ID.String()
+		s.invalidateAccount(ctx, id)
+		if change.op.Op == dto.BatchOpDelete {
+			s.recordRevisions(ctx, change.user.ID, []model.UserRevision{{
+				UserID:  change.user.ID,
+				Action:  model.RevisionDeleted,
+				ActorID: by,
+			}})
+		} else {
+			s.recordRevisions(ctx, change.user.ID, model.DiffUser(&change.before, change.user, by))
+			s.recordStatusChange(ctx, change.user, change.before.Status)
+		}
+		s.logger.Infow("batch user operation applied",
+			"audit", true,
+			"op", change.op.Op,
+			"user_id", id,
+			"role", change.op.Role,
+			"status", change.op.Status,
+			"reason", change.op.Reason,
+			"by", actor.UserID(ctx),
+			"ip", actor.ClientIP(ctx),
+		)
+
+		// As in ChangeStatus, the status is what keeps the user out; revoking only ends
+		// sessions sooner
+		if change.op.Op == dto.BatchOpChangeStatus && change.user.Status != model.StatusActive && s.revokeSessions != nil {
+			if err := s.revokeSessions(ctx, id); err != nil {
+				s.logger.Errorw("failed to revoke sessions after batch status change", "user_id", id, "error", err)
+			}
+		}
+	}
+	return resp, nil
+}
+
+// planBatchChange loads the user of op and applies op to it in memory
+func (s *userService) planBatchChange(ctx context.Context, op dto.BatchOperation) (*batchChange, error) {
+	if _, err := uuid.Parse(op.UserID); err != nil {
+		return nil, apperrors.ErrUserNotFound
+	}
+	if op.UserID == actor.UserID(ctx) {
+		return nil, apperrors.NewAppError(apperrors.ForbiddenError, "You cannot change your own account in a batch")
+	}
+	user, err := s.repo.FindByID(ctx, op.UserID)
+	if err != nil {
+		s.logger.Errorw("failed to fetch user for batch", "user_id", op.UserID, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to apply batch")
+	}
+	if user == nil {
+		return nil, apperrors.ErrUserNotFound
+	}
+	if user.Status == model.StatusDeleted {
+		return nil, apperrors.NewAppError(apperrors.ConflictError, "Deleted accounts cannot be changed")
+	}
+	change := &batchChange{op: op, before: *user, user: user}
+
+	switch op.Op {
+	case dto.BatchOpDelete:
+	case dto.BatchOpChangeRole:
+		if op.Role == "" {
+			return nil, apperrors.NewAppError(apperrors.ValidationError, "A role is required")
+		}
+		if err := s.checkRole(ctx, op.Role); err != nil {
+			return nil, err
+		}
+		user.UserType = model.UserType(op.Role)
+	case dto.BatchOpChangeStatus:
+		switch op.Status {
+		case model.StatusActive, model.StatusInactive, model.StatusSuspended:
+		default:
+			return nil, apperrors.NewAppError(apperrors.BadRequestError, "Unknown account status")
+		}
+		if op.Status != model.StatusActive && op.Reason == "" {
+			return nil, apperrors.NewAppError(apperrors.ValidationError, "A reason is required to "+statusVerb(op.Status)+" an account")
+		}
+		if user.Status != op.Status {
+			if err := checkTransition(user.Status, op.Status); err != nil {
+				return nil, err
+			}
+			user.Status = op.Status
+			user.StatusReason = nil
+			if op.Reason != "" {
+				user.StatusReason = &op.Reason
+			}
+		}
+	default:
+		return nil, apperrors.NewAppError(apperrors.BadRequestError, "Unknown batch operation")
+	}
+	return change, nil
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/events"
+)
+
+// deletionBatch is how many accounts AnonymizeDeletedAccounts reads at a time
+const deletionBatch = 100
+
+// AnonymizeDeletedAccounts anonymizes every account whose deletion grace period ended.
+// The row is kept, so records that reference the user stay valid, but everything that
+// identifies the person is replaced or cleared and the status becomes "deleted".
+func (s *userService) AnonymizeDeletedAccounts(ctx context.Context) error {
+	for {
+		users, err := s.repo.ListDueForDeletion(ctx, s.clock.Now(), deletionBatch)
+		if err != nil {
+			return err
+		}
+		for _, user := range users {
+			if err := s.anonymize(ctx, user); err != nil {
+				return err
+			}
+		}
+		if len(users) < deletionBatch {
+			return nil
+		}
+	}
+}
+
+func (s *userService) anonymize(ctx context.Context, user *model.User) error {
+	token := strings.ReplaceAll(user.ID.String(), "-", "")
+	user.FirstName = "Deleted"
+	user.SecondName = ""
+	user.LastName = "User"
+	user.Username = "deleted_" + token
+	user.Email = "deleted+" + token + "@deleted.invalid"
+	user.EmailVerifiedAt = nil
+	user.Phone = nil
+	user.PhoneVerifiedAt = nil
+	user.SMSTwoFactor = false
+	user.AnnouncementsOptOut = true
+	user.TimeZone = ""
+	user.Locale = ""
+	user.Metadata = model.Metadata{}
+	// No bcrypt hash matches an empty string, so the account cannot log in again
+	user.Password = ""
+	user.ReviewReason = nil
+	user.StatusReason = nil
+	user.LastLoginIP = nil
+	from := user.Status
+	user.Status = model.StatusDeleted
+	if err := s.repo.Update(ctx, user); err != nil {
+		s.logger.Errorw("failed to anonymize deleted account", "user_id", user.ID, "error", err)
+		return err
+	}
+	s.invalidateAccount(ctx, user.ID.String())
+
+	if s.passwords != nil {
+		if err := s.passwords.DeleteByUser(ctx, user.ID.String()); err != nil {
+			s.logger.Errorw("failed to delete password history of deleted account", "user_id", user.ID, "error", err)
+		}
+	}
+	if s.notes != nil {
+		if err := s.notes.DeleteByUser(ctx, user.ID.String()); err != nil {
+			s.logger.Errorw("failed to delete notes of deleted account", "user_id", user.ID, "error", err)
+		}
+	}
+	if s.revisions != nil {
+		if err := s.revisions.RedactUser(ctx, user.ID.String()); err != nil {
+			s.logger.Errorw("failed to redact history of deleted account", "user_id", user.ID, "error", err)
+		}
+	}
+	s.recordRevisions(ctx, user.ID, []model.UserRevision{{
+		UserID: user.ID,
+		Action: model.RevisionDeleted,
+	}})
+	s.recordStatusChange(ctx, user, from)
+	s.events.Publish(ctx, events.Event{Type: events.AccountAnonymized, UserID: user.ID.String()})
+	s.logger.Infow("deleted account anonymized", "audit", true, "user_id", user.ID, "scheduled_at", user.DeletionScheduledAt)
+	return nil
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/repo"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+)
+
+// WithRevisions records a field-level history of every user change in r
+func WithRevisions(r repo.RevisionRepo) ServiceOption {
+	return func(s *userService) {
+		s.revisions = r
+	}
+}
+
+// History returns the recorded changes of a user, newest first
+func (s *userService) History(ctx context.Context, id string, offset, limit int) ([]model.UserRevision, error) {
+	if s.revisions == nil {
+		return nil, apperrors.NewAppError(apperrors.NotFoundError, "User history is not enabled")
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, apperrors.NewAppError(apperrors.BadRequestError, "Invalid user ID")
+	}
+
+	revisions, err := s.revisions.ListByUser(ctx, id, offset, limit)
+	if err != nil {
+		s.logger.Errorw("failed to fetch user history", "user_id", id, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to fetch user history")
+	}
+	return revisions, nil
+}
+
+// recordRevisions stores revisions after the change itself succeeded. A failure here is
+// logged rather than returned, since the user change cannot be rolled back at this point.
+func (s *userService) recordRevisions(ctx context.Context, userID uuid.UUID, revisions []model.UserRevision) {
+	if s.revisions == nil || len(revisions) == 0 {
+		return
+	}
+	if err := s.revisions.Create(ctx, revisions); err != nil {
+		s.logger.Errorw("failed to record user revisions", "user_id", userID, "count", len(revisions), "error", err)
+	}
+}
+
+// actorID returns the authenticated caller recorded in ctx, if any
+func actorID(ctx context.Context) *uuid.UUID {
+	id, err := uuid.Parse(actor.UserID(ctx))
+	if err != nil {
+		return nil
+	}
+	return &id
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/repo"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+
+	"github.com/google/uuid"
+)
+
+// WithNotes enables admin notes on user accounts, stored in r
+func WithNotes(r repo.NoteRepo) ServiceOption {
+	return func(s *userService) {
+		s.notes = r
+	}
+}
+
+// Notes returns the notes on a user, newest first
+func (s *userService) Notes(ctx context.Context, userID string) ([]model.UserNote, error) {
+	if _, err := s.notedUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	notes, err := s.notes.ListByUser(ctx, userID)
+	if err != nil {
+		s.logger.Errorw("failed to list user notes", "user_id", userID, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to fetch notes")
+	}
+	return notes, nil
+}
+
+// AddNote writes a note on a user, authored by the caller
+func (s *userService) AddNote(ctx context.Context, userID, text string) (*model.UserNote, error) {
+	user, err := s.notedUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	note := &model.UserNote{UserID: user.ID, AuthorID: actorID(ctx), Text: text}
+	if err := s.notes.Create(ctx, note); err != nil {
+		s.logger.Errorw("failed to create user note", "user_id", userID, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to add note")
+	}
+	s.logger.Infow("user note added",
+		"audit", true,
+		"user_id", userID,
+		"note_id", note.ID,
+		"by", actor.UserID(ctx),
+		"ip", actor.ClientIP(ctx),
+	)
+	return note, nil
+}
+
+// UpdateNote replaces the text of a note. Only its author may edit it.
+func (s *userService) UpdateNote(ctx context.Context, userID, noteID, text string) (*model.UserNote, error) {
+	note, err := s.findNote(ctx, userID, noteID)
+	if err != nil {
+		return nil, err
+	}
+	if author := actorID(ctx); note.AuthorID == nil || author == nil || *note.AuthorID != *author {
+		return nil, apperrors.NewAppError(apperrors.ForbiddenError, "Only the author of a note can edit it")
+	}
+	note.Text = text
+	if err := s.notes.Update(ctx, note); err != nil {
+		s.logger.Errorw("failed to update user note", "user_id", userID, "note_id", noteID, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to update note")
+	}
+	s.logger.Infow("user note updated",
+		"audit", true,
+		"user_id", userID,
+		"note_id", noteID,
+		"by", actor.UserID(ctx),
+		"ip", actor.ClientIP(ctx),
+	)
+	return note, nil
+}
+
+// DeleteNote deletes a note on a user
+func (s *userService) DeleteNote(ctx context.Context, userID, noteID string) error {
+	if _, err := s.findNote(ctx, userID, noteID); err != nil {
+		return err
+	}
+	if err := s.notes.Delete(ctx, noteID); err != nil {
+		s.logger.Errorw("failed to delete user note", "user_id", userID, "note_id", noteID, "error", err)
+		return apperrors.NewAppError(apperrors.InternalError, "Failed to delete note")
+	}
+	s.logger.Infow("user note deleted",
+		"audit", true,
+		"user_id", userID,
+		"note_id", noteID,
+		"by", actor.UserID(ctx),
+		"ip", actor.ClientIP(ctx),
+	)
+	return nil
+}
+
+// findNote loads a note and checks that it is on the user
+func (s *userService) findNote(ctx context.Context, userID, noteID string) (*model.UserNote, error) {
+	if _, err := s.notedUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	errNotFound := apperrors.NewAppError(apperrors.NotFoundError, "Note not found")
+	if _, err := uuid.Parse(noteID); err != nil {
+		return nil, errNotFound
+	}
+	note, err := s.notes.FindByID(ctx, noteID)
+	if err != nil {
+		s.logger.Errorw("failed to fetch user note", "note_id", noteID, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to fetch notes")
+	}
+	if note == nil || note.UserID.String() != userID {
+		return nil, errNotFound
+	}
+	return note, nil
+}
+
+// notedUser loads the user whose notes are read or changed
+func (s *userService) notedUser(ctx context.Context, id string) (*model.User, error) {
+	if s.notes == nil {
+		return nil, apperrors.NewAppError(apperrors.BadRequestError, "Account notes are not enabled")
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, apperrors.ErrUserNotFound
+	}
+	user, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		s.logger.Errorw("failed to fetch user for notes", "user_id", id, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to fetch notes")
+	}
+	if user == nil {
+		return nil, apperrors.ErrUserNotFound
+	}
+	return user, nil
+}
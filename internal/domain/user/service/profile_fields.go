@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/repo"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+)
+
+// ProfileFieldService manages the admin-defined schema for user metadata
+type ProfileFieldService interface {
+	List(ctx context.Context) ([]model.ProfileField, error)
+	Create(ctx context.Context, req *dto.ProfileFieldRequest) (*model.ProfileField, error)
+	Update(ctx context.Context, key string, req *dto.ProfileFieldRequest) (*model.ProfileField, error)
+	Delete(ctx context.Context, key string) error
+}
+
+type profileFieldService struct {
+	repo   repo.ProfileFieldRepo
+	logger *zap.SugaredLogger
+}
+
+func NewProfileFieldService(r repo.ProfileFieldRepo, logger *zap.SugaredLogger) ProfileFieldService {
+	if logger == nil {
+		logger = zap.NewNop().Sugar()
+	}
+	return &profileFieldService{repo: r, logger: logger}
+}
+
+// List returns every defined profile field ordered by key
+func (s *profileFieldService) List(ctx context.Context) ([]model.ProfileField, error) {
+	fields, err := s.repo.List(ctx)
+	if err != nil {
+		s.logger.Errorw("failed to list profile fields", "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to fetch profile fields")
+	}
+	return fields, nil
+}
+
+// Create defines a new profile field
+func (s *profileFieldService) Create(ctx context.Context, req *dto.ProfileFieldRequest) (*model.ProfileField, error) {
+	field := fieldFromRequest(req)
+	if err := field.Validate(); err != nil {
+		return nil, apperrors.NewAppErrorWithDetails(apperrors.ValidationError, "Invalid profile field", err.Error())
+	}
+
+	if err := s.repo.Create(ctx, field); err != nil {
+		if errors.Is(err, apperrors.ErrProfileFieldExists) {
+			return nil, apperrors.NewAppError(apperrors.ConflictError, "Profile field already exists")
+		}
+		s.logger.Errorw("failed to create profile field", "key", field.Key, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to create profile field")
+	}
+
+	s.logger.Infow("profile field created", "key", field.Key, "type", field.Type)
+	return field, nil
+}
+
+// Update changes the definition of an existing field. Values already stored on users
+// are not rewritten; they are checked against the new definition on their next write.
+func (s *profileFieldService) Update(ctx context.Context, key string, req *dto.ProfileFieldRequest) (*model.ProfileField, error) {
+	existing, err := s.repo.FindByKey(ctx, key)
+	if err != nil {
+		s.logger.Errorw("failed to fetch profile field", "key", key, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to update profile field")
+	}
+	if existing == nil {
+		return nil, apperrors.NewAppError(apperrors.NotFoundError, "Profile field not found")
+	}
+	if existing.Config {
+		return nil, apperrors.NewAppError(apperrors.ConflictError, "Profile field is declared in PROFILE_FIELDS and cannot be changed here")
+	}
+
+	field := fieldFromRequest(req)
+	field.ID = existing.ID
+	field.Key = existing.Key
+	field.CreatedAt = existing.CreatedAt
+	if err := field.Validate(); err != nil {
+		return nil, apperrors.NewAppErrorWithDetails(apperrors.ValidationError, "Invalid profile field", err.Error())
+	}
+
+	if err := s.repo.Update(ctx, field); err != nil {
+		s.logger.Errorw("failed to update profile field", "key", key, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to update profile field")
+	}
+
+	s.logger.Infow("profile field updated", "key", key)
+	return field, nil
+}
+
+// Delete removes a field definition. Stored values stay on users but are rejected as
+// unknown the next time that user's metadata is written.
+func (s *profileFieldService) Delete(ctx context.Context, key string) error {
+	existing, err := s.repo.FindByKey(ctx, key)
+	if err != nil {
+		s.logger.Errorw("failed to fetch profile field", "key", key, "error", err)
+		return apperrors.NewAppError(apperrors.InternalError, "Failed to delete profile field")
+	}
+	if existing == nil {
+		return apperrors.NewAppError(apperrors.NotFoundError, "Profile field not found")
+	}
+	if existing.Config {
+		return apperrors.NewAppError(apperrors.ConflictError, "Profile field is declared in PROFILE_FIELDS and cannot be deleted here")
+	}
+
+	if err := s.repo.Delete(ctx, key); err != nil {
+		s.logger.Errorw("failed to delete profile field", "key", key, "error", err)
+		return apperrors.NewAppError(apperrors.InternalError, "Failed to delete profile field")
+	}
+
+	s.logger.Infow("profile field deleted", "key", key)
+	return nil
+}
+
+// DeclaredFields converts the fields of PROFILE_FIELDS, checking them like fields created
+// through the API, for repo.WithDeclaredFields
+func DeclaredFields(declared []config.ProfileField) ([]model.ProfileField, error) {
+	fields := make([]model.ProfileField, 0, len(declared))
+	seen := make(map[string]bool, len(declared))
+	for _, d := range declared {
+		field := model.ProfileField{
+			Key:      strings.TrimSpace(d.Key),
+			Label:    d.Label,
+			Type:     model.FieldType(d.Type),
+			Required: d.Required,
+			Choices:  d.Choices,
+		}
+		if err := field.Validate(); err != nil {
+			return nil, err
+		}
+		if seen[field.Key] {
+			return nil, fmt.Errorf("field %q is declared twice", field.Key)
+		}
+		seen[field.Key] = true
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func fieldFromRequest(req *dto.ProfileFieldRequest) *model.ProfileField {
+	return &model.ProfileField{
+		Key:      strings.TrimSpace(req.Key),
+		Label:    req.Label,
+		Type:     model.FieldType(req.Type),
+		Required: req.Required,
+		Choices:  req.Choices,
+	}
+}
+
+// validateMetadata checks metadata against the current profile field schema
+func (s *userService) validateMetadata(ctx context.Context, metadata model.Metadata) error {
+	var schema []model.ProfileField
+	if s.fields != nil {
+		fields, err := s.fields.List(ctx)
+		if err != nil {
+			s.logger.Errorw("failed to load profile fields", "error", err)
+			return apperrors.NewAppError(apperrors.InternalError, "Failed to validate metadata")
+		}
+		schema = fields
+	}
+
+	if err := model.ValidateMetadata(schema, metadata); err != nil {
+		return apperrors.NewAppErrorWithDetails(apperrors.ValidationError, "Invalid metadata", err.Error())
+	}
+	return nil
+}
+
+// metadataFilter turns raw query values into a typed containment filter
+func (s *userService) metadataFilter(ctx context.Context, raw map[string]string) (model.Metadata, error) {
+	if s.fields == nil {
+		return nil, apperrors.NewAppError(apperrors.BadRequestError, "Metadata filters are not available")
+	}
+	fields, err := s.fields.List(ctx)
+	if err != nil {
+		s.logger.Errorw("failed to load profile fields", "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to fetch users")
+	}
+	byKey := make(map[string]*model.ProfileField, len(fields))
+	for i := range fields {
+		byKey[fields[i].Key] = &fields[i]
+	}
+
+	filter := make(model.Metadata, len(raw))
+	for key, value := range raw {
+		field, ok := byKey[key]
+		if !ok {
+			return nil, apperrors.NewAppError(apperrors.BadRequestError, "Unknown metadata filter: "+key)
+		}
+		typed, err := field.ParseFilterValue(value)
+		if err != nil {
+			return nil, apperrors.NewAppErrorWithDetails(apperrors.BadRequestError, "Invalid metadata filter", err.Error())
+		}
+		filter[key] = typed
+	}
+	return filter, nil
+}
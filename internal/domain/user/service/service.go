@@ -2,41 +2,190 @@ package service
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 
-	"go_platform_template/internal/domain/user/dto"
-	"go_platform_template/internal/domain/user/model"
-	"go_platform_template/internal/domain/user/repo"
-	apperrors "go_platform_template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/actiontoken"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/repo"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/cache"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/email"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/events"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/metrics"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/risk"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/webhook"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/clock"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/locale"
 )
 
 type UserService interface {
 	Register(ctx context.Context, req *dto.UserCreateRequest) (*model.User, error)
+	Signup(ctx context.Context, req *dto.SignupRequest) (*model.User, error)
+	CheckAvailability(ctx context.Context, q *dto.AvailabilityQuery) (*dto.AvailabilityResponse, error)
+	VerifyEmail(ctx context.Context, token string) (*model.User, error)
+	Invite(ctx context.Context, req *dto.InvitationRequest) (*dto.InvitationResponse, error)
+	RevokeInvitation(ctx context.Context, email string) error
+	AcceptInvitation(ctx context.Context, req *dto.AcceptInviteRequest) (*model.User, error)
 	GetByID(ctx context.Context, id string) (*model.User, error)
 	Update(ctx context.Context, id string, req *dto.UserUpdateRequest) (*model.User, error)
 	Delete(ctx context.Context, id string) error
 	List(ctx context.Context, offset, limit int, filters map[string]interface{}, sortBy, sortOrder string) ([]*model.User, error)
+	ListPage(ctx context.Context, cursor string, limit int, filters map[string]interface{}, sortOrder string) ([]*model.User, string, error)
+	Stream(ctx context.Context, filters map[string]interface{}, sortBy, sortOrder string, fn func(*model.User) error) error
+	History(ctx context.Context, id string, offset, limit int) ([]model.UserRevision, error)
+	Stats(ctx context.Context) (*model.UserStats, error)
+	Metrics(ctx context.Context, days int) (*model.UserMetrics, error)
+	AccountStatus(ctx context.Context, id string) (*AccountStatus, error)
+	ClearReview(ctx context.Context, id string) (*model.User, error)
+	ChangeStatus(ctx context.Context, id, status, reason string) (*model.User, error)
+	StatusHistory(ctx context.Context, id string, offset, limit int) ([]model.StatusChange, error)
+	Merge(ctx context.Context, sourceID, targetID string) (*model.User, *model.MergeReport, error)
+	Batch(ctx context.Context, ops []dto.BatchOperation) (*dto.BatchResponse, error)
+	Tags(ctx context.Context, id string) ([]string, error)
+	AddTags(ctx context.Context, id string, tags []string) ([]string, error)
+	RemoveTag(ctx context.Context, id, tag string) ([]string, error)
+	TagCounts(ctx context.Context) ([]model.TagCount, error)
+	Notes(ctx context.Context, userID string) ([]model.UserNote, error)
+	AddNote(ctx context.Context, userID, text string) (*model.UserNote, error)
+	UpdateNote(ctx context.Context, userID, noteID, text string) (*model.UserNote, error)
+	DeleteNote(ctx context.Context, userID, noteID string) error
+	CleanupPasswordHistory(ctx context.Context) error
+	AnonymizeDeletedAccounts(ctx context.Context) error
 }
 
 type userService struct {
-	repo   repo.UserRepo
-	logger *zap.SugaredLogger
+	repo        repo.UserRepo
+	logger      *zap.SugaredLogger
+	phoneRegion string
+	fields      repo.ProfileFieldRepo
+	revisions   repo.RevisionRepo
+	accounts    cache.Cache
+	accountTTL  time.Duration
+	roleExists  func(ctx context.Context, role string) (bool, error)
+	risk        *risk.Engine
+	disposable  *risk.DisposableDomains
+	// passwords keeps the last passwordHistory password hashes; see WithPasswordHistory
+	passwords       repo.PasswordHistoryRepo
+	passwordHistory int
+	// signupCaptcha, verifySender, actionTokens and signup configure Signup; see signup.go
+	signupCaptcha risk.CaptchaVerifier
+	verifySender  email.Sender
+	actionTokens  *actiontoken.Service
+	signup        config.SignupConfig
+	// inviteSender, invitations and invite configure invitations; see invitation.go
+	inviteSender email.Sender
+	invitations  *actiontoken.Service
+	invite       config.InviteConfig
+	// webhooks receive user.registered events; nil sends none
+	webhooks *webhook.Dispatcher
+	// events receive profile and password changes, e.g. for the activity feed; nil sends none
+	events *events.Bus
+	// statusHistory records status changes; nil records none
+	statusHistory repo.StatusHistoryRepo
+	// revokeSessions signs a user out everywhere when they are suspended, deactivated or merged
+	revokeSessions func(ctx context.Context, userID string) error
+	// merger moves records between users for Merge; nil disables merging
+	merger AccountMerger
+	// tags stores the tags put on users; nil disables tagging
+	tags repo.TagRepo
+	// notes stores the notes admins keep on users; nil disables them
+	notes repo.NoteRepo
+	clock clock.Clock
 }
 
-func NewUserService(r repo.UserRepo, logger *zap.SugaredLogger) UserService {
+// disposableRejections counts registrations and email changes refused by WithDisposableEmailBlocking
+var disposableRejections = metrics.NewCounter("disposable_email_rejections_total",
+	"Registrations and email changes rejected for a disposable email domain.", "operation")
+
+// ServiceOption customizes a UserService created by NewUserService
+type ServiceOption func(*userService)
+
+// WithDefaultPhoneRegion sets the region (e.g. "US") used to parse phone numbers given without a "+" prefix
+func WithDefaultPhoneRegion(region string) ServiceOption {
+	return func(s *userService) {
+		s.phoneRegion = region
+	}
+}
+
+// WithProfileFields enables custom profile metadata, validated against the schema in r.
+// Without it users cannot carry metadata.
+func WithProfileFields(r repo.ProfileFieldRepo) ServiceOption {
+	return func(s *userService) {
+		s.fields = r
+	}
+}
+
+// WithRoles lets users be assigned any role for which exists reports true. Without it
+// only the built-in "user" and "admin" roles are accepted.
+func WithRoles(exists func(ctx context.Context, role string) (bool, error)) ServiceOption {
+	return func(s *userService) {
+		s.roleExists = exists
+	}
+}
+
+// WithRiskEngine scores registrations for abuse: risky ones need a CAPTCHA or are
+// rejected, and suspicious accounts are created flagged for review
+func WithRiskEngine(e *risk.Engine) ServiceOption {
+	return func(s *userService) {
+		s.risk = e
+	}
+}
+
+// WithDisposableEmailBlocking rejects registrations and email changes to domains in the list
+func WithDisposableEmailBlocking(domains *risk.DisposableDomains) ServiceOption {
+	return func(s *userService) {
+		s.disposable = domains
+	}
+}
+
+// WithWebhooks publishes a user.registered event for every account created
+func WithWebhooks(d *webhook.Dispatcher) ServiceOption {
+	return func(s *userService) {
+		s.webhooks = d
+	}
+}
+
+// WithEvents publishes profile updates, password changes and account anonymizations on bus
+func WithEvents(bus *events.Bus) ServiceOption {
+	return func(s *userService) {
+		s.events = bus
+	}
+}
+
+func NewUserService(r repo.UserRepo, logger *zap.SugaredLogger, opts ...ServiceOption) UserService {
 	if logger == nil {
 		logger = zap.NewNop().Sugar()
 	}
-	return &userService{
+	s := &userService{
 		repo:   r,
 		logger: logger,
+		clock:  clock.System(),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-// Register creates a new user with hashed password
+// Register creates a new user with hashed password and any role. It serves admins and
+// seeding; public registrations go through Signup, which adds abuse scoring.
 func (s *userService) Register(ctx context.Context, req *dto.UserCreateRequest) (*model.User, error) {
+	return s.create(ctx, req, risk.Assessment{})
+}
+
+// create registers a user, flagged for review when assessment says so
+func (s *userService) create(ctx context.Context, req *dto.UserCreateRequest, assessment risk.Assessment) (*model.User, error) {
+	if err := s.checkDisposable(ctx, req.Email, "register"); err != nil {
+		return nil, err
+	}
+
 	// Ensure username is unique
 	if existing, err := s.repo.FindByUsername(ctx, req.Username); err != nil {
 		s.logger.Errorw("failed to check username uniqueness", "username", req.Username, "error", err)
@@ -55,6 +204,26 @@ func (s *userService) Register(ctx context.Context, req *dto.UserCreateRequest)
 		return nil, apperrors.NewAppError(apperrors.ConflictError, "Email already registered")
 	}
 
+	if err := s.checkRole(ctx, req.UserType); err != nil {
+		return nil, err
+	}
+
+	var phone *string
+	if req.Phone != "" {
+		normalized, err := model.NormalizePhone(req.Phone, s.phoneRegion)
+		if err != nil {
+			s.logger.Warnw("invalid phone number on register", "username", req.Username)
+			return nil, apperrors.NewAppError(apperrors.ValidationError, "Invalid phone number")
+		}
+		phone = &normalized
+	}
+
+	metadata := model.MergeMetadata(nil, req.Metadata)
+	if err := s.validateMetadata(ctx, metadata); err != nil {
+		s.logger.Warnw("invalid metadata on register", "username", req.Username)
+		return nil, err
+	}
+
 	// Hash password
 	hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -68,16 +237,48 @@ func (s *userService) Register(ctx context.Context, req *dto.UserCreateRequest)
 		LastName:   req.LastName,
 		Username:   req.Username,
 		Email:      req.Email,
+		Phone:      phone,
+		TimeZone:   req.TimeZone,
+		Locale:     canonicalLocale(req.Locale),
+		Metadata:   metadata,
 		Password:   string(hashed),
 		UserType:   model.UserType(req.UserType),
 	}
+	if assessment.Action == risk.Flag {
+		reason := assessment.Reason()
+		user.ReviewReason = &reason
+	}
 
+	// The checks above are a fast path only; concurrent signups can still race past
+	// them, so the unique constraints in the database are the source of truth.
 	if err := s.repo.Create(ctx, user); err != nil {
+		switch {
+		case errors.Is(err, apperrors.ErrUsernameAlreadyTaken):
+			s.logger.Warnw("duplicate username on insert", "username", req.Username)
+			return nil, apperrors.NewAppError(apperrors.ConflictError, "Username already taken")
+		case errors.Is(err, apperrors.ErrEmailAlreadyRegistered):
+			s.logger.Warnw("duplicate email on insert", "email", req.Email)
+			return nil, apperrors.NewAppError(apperrors.ConflictError, "Email already registered")
+		case errors.Is(err, apperrors.ErrPhoneAlreadyRegistered):
+			s.logger.Warnw("duplicate phone on insert", "username", req.Username)
+			return nil, apperrors.NewAppError(apperrors.ConflictError, "Phone number already registered")
+		}
 		s.logger.Errorw("failed to create user", "username", req.Username, "error", err)
 		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to register user")
 	}
 
+	s.recordRevisions(ctx, user.ID, model.DiffUser(nil, user, actorID(ctx)))
+	s.recordPassword(ctx, user)
 	s.logger.Infow("user registered", "user_id", user.ID, "username", user.Username)
+	s.webhooks.Publish(webhook.EventUserRegistered, map[string]any{
+		"user_id":  user.ID,
+		"username": user.Username,
+		"email":    user.Email,
+		"role":     user.UserType,
+	})
+	if user.FlaggedForReview() {
+		s.logger.Warnw("account flagged for review", "user_id", user.ID, "reason", *user.ReviewReason)
+	}
 	return user, nil
 }
 
@@ -106,6 +307,7 @@ func (s *userService) Update(ctx context.Context, id string, req *dto.UserUpdate
 		s.logger.Warnw("user not found for update", "user_id", id)
 		return nil, apperrors.NewAppError(apperrors.NotFoundError, "User not found")
 	}
+	before := *user
 
 	// Only update fields provided in DTO
 	if req.FirstName != "" {
@@ -121,9 +323,59 @@ func (s *userService) Update(ctx context.Context, id string, req *dto.UserUpdate
 		user.Username = req.Username
 	}
 	if req.Email != "" {
+		if req.Email != user.Email {
+			if err := s.checkDisposable(ctx, req.Email, "update"); err != nil {
+				return nil, err
+			}
+			user.EmailVerifiedAt = nil
+		}
 		user.Email = req.Email
 	}
+	if req.Phone != "" {
+		normalized, err := model.NormalizePhone(req.Phone, s.phoneRegion)
+		if err != nil {
+			s.logger.Warnw("invalid phone number on update", "user_id", id)
+			return nil, apperrors.NewAppError(apperrors.ValidationError, "Invalid phone number")
+		}
+		if user.Phone == nil || *user.Phone != normalized {
+			// Codes would go to a number nobody has confirmed yet
+			if user.SMSTwoFactor {
+				return nil, apperrors.NewAppError(apperrors.ConflictError, "Turn off SMS two-factor authentication before changing your phone number")
+			}
+			user.PhoneVerifiedAt = nil
+		}
+		user.Phone = &normalized
+	}
+	if req.TimeZone != "" {
+		user.TimeZone = req.TimeZone
+	}
+	if req.Locale != "" {
+		user.Locale = canonicalLocale(req.Locale)
+	}
+	if req.SMSTwoFactor != nil {
+		if *req.SMSTwoFactor && !user.SMSTwoFactor && !user.PhoneVerified() {
+			return nil, apperrors.NewAppError(apperrors.ValidationError, "A verified phone number is required for SMS two-factor authentication")
+		}
+		user.SMSTwoFactor = *req.SMSTwoFactor
+	}
+	if req.AnnouncementsOptOut != nil {
+		user.AnnouncementsOptOut = *req.AnnouncementsOptOut
+	}
+	if req.LoginAlertsOptOut != nil {
+		user.LoginAlertsOptOut = *req.LoginAlertsOptOut
+	}
+	if req.Metadata != nil {
+		metadata := model.MergeMetadata(user.Metadata, req.Metadata)
+		if err := s.validateMetadata(ctx, metadata); err != nil {
+			s.logger.Warnw("invalid metadata on update", "user_id", id)
+			return nil, err
+		}
+		user.Metadata = metadata
+	}
 	if req.Password != "" {
+		if err := s.checkPasswordReuse(ctx, user, req.Password); err != nil {
+			return nil, err
+		}
 		hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 		if err != nil {
 			s.logger.Errorw("failed to hash password", "user_id", id, "error", err)
@@ -132,14 +384,44 @@ func (s *userService) Update(ctx context.Context, id string, req *dto.UserUpdate
 		user.Password = string(hashed)
 	}
 	if req.UserType != "" {
+		if err := s.checkRole(ctx, req.UserType); err != nil {
+			return nil, err
+		}
 		user.UserType = model.UserType(req.UserType)
 	}
 
 	if err := s.repo.Update(ctx, user); err != nil {
+		switch {
+		case errors.Is(err, apperrors.ErrUsernameAlreadyTaken):
+			s.logger.Warnw("duplicate username on update", "user_id", id, "username", user.Username)
+			return nil, apperrors.NewAppError(apperrors.ConflictError, "Username already taken")
+		case errors.Is(err, apperrors.ErrEmailAlreadyRegistered):
+			s.logger.Warnw("duplicate email on update", "user_id", id, "email", user.Email)
+			return nil, apperrors.NewAppError(apperrors.ConflictError, "Email already registered")
+		case errors.Is(err, apperrors.ErrPhoneAlreadyRegistered):
+			s.logger.Warnw("duplicate phone on update", "user_id", id)
+			return nil, apperrors.NewAppError(apperrors.ConflictError, "Phone number already registered")
+		}
 		s.logger.Errorw("failed to update user", "user_id", id, "error", err)
 		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to update user")
 	}
 
+	s.invalidateAccount(ctx, id)
+	revisions := model.DiffUser(&before, user, actorID(ctx))
+	s.recordRevisions(ctx, user.ID, revisions)
+	if user.Password != before.Password {
+		s.recordPassword(ctx, user)
+		s.events.Publish(ctx, events.Event{Type: events.PasswordChanged, UserID: id})
+	}
+	var fields []string
+	for _, revision := range revisions {
+		if revision.Field != "password" {
+			fields = append(fields, revision.Field)
+		}
+	}
+	if len(fields) > 0 {
+		s.events.Publish(ctx, events.Event{Type: events.ProfileUpdated, UserID: id, Data: map[string]any{"fields": fields}})
+	}
 	s.logger.Infow("user updated", "user_id", id)
 	return user, nil
 }
@@ -161,11 +443,159 @@ func (s *userService) Delete(ctx context.Context, id string) error {
 		return apperrors.NewAppError(apperrors.InternalError, "Failed to delete user")
 	}
 
+	s.invalidateAccount(ctx, id)
+	s.recordRevisions(ctx, user.ID, []model.UserRevision{{
+		UserID:  user.ID,
+		Action:  model.RevisionDeleted,
+		ActorID: actorID(ctx),
+	}})
 	s.logger.Infow("user deleted", "user_id", id)
 	return nil
 }
 
-// List fetches users with pagination, filtering, and sorting
+// checkDisposable rejects email when its domain is disposable and blocking is enabled
+func (s *userService) checkDisposable(ctx context.Context, email, operation string) error {
+	domain, ok := s.disposable.Match(email)
+	if !ok {
+		return nil
+	}
+	disposableRejections.Inc(operation)
+	s.logger.Warnw("disposable email rejected", "domain", domain, "operation", operation, "ip", actor.ClientIP(ctx))
+	return apperrors.NewAppErrorWithDetails(
+		apperrors.ValidationError,
+		"Disposable email addresses are not allowed",
+		"email: "+domain+" is a disposable email domain",
+	)
+}
+
+// ClearReview marks a flagged user as reviewed
+func (s *userService) ClearReview(ctx context.Context, id string) (*model.User, error) {
+	user, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		s.logger.Errorw("failed to fetch user for review", "user_id", id, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to clear review flag")
+	}
+	if user == nil {
+		s.logger.Warnw("user not found for review", "user_id", id)
+		return nil, apperrors.NewAppError(apperrors.NotFoundError, "User not found")
+	}
+	if !user.FlaggedForReview() {
+		return user, nil
+	}
+	before := *user
+
+	user.ReviewReason = nil
+	if err := s.repo.Update(ctx, user); err != nil {
+		s.logger.Errorw("failed to clear review flag", "user_id", id, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to clear review flag")
+	}
+
+	s.recordRevisions(ctx, user.ID, model.DiffUser(&before, user, actorID(ctx)))
+	s.logger.Infow("account review cleared", "user_id", id)
+	return user, nil
+}
+
+// List fetches users with pagination, filtering, and sorting.
+// A map[string]string under repo.MetadataFilter is checked against the profile field
+// schema and converted to typed values before querying.
 func (s *userService) List(ctx context.Context, offset, limit int, filters map[string]interface{}, sortBy, sortOrder string) ([]*model.User, error) {
+	if err := s.typeMetadataFilter(ctx, filters); err != nil {
+		return nil, err
+	}
 	return s.repo.List(ctx, offset, limit, filters, sortBy, sortOrder)
 }
+
+// ListPage returns up to limit users matching filters ordered by creation time and ID,
+// starting after cursor, and the cursor of the next page. An empty cursor starts at the
+// beginning; an empty next cursor means there are no more users.
+func (s *userService) ListPage(ctx context.Context, cursor string, limit int, filters map[string]interface{}, sortOrder string) ([]*model.User, string, error) {
+	var after *model.ListCursor
+	if cursor != "" {
+		var err error
+		if after, err = model.DecodeListCursor(cursor); err != nil {
+			return nil, "", apperrors.NewAppError(apperrors.BadRequestError, "Invalid cursor")
+		}
+	}
+	if err := s.typeMetadataFilter(ctx, filters); err != nil {
+		return nil, "", err
+	}
+
+	// One extra row tells whether another page follows
+	users, err := s.repo.ListAfter(ctx, after, limit+1, filters, sortOrder)
+	if err != nil {
+		s.logger.Errorw("failed to list users after cursor", "error", err)
+		return nil, "", apperrors.NewAppError(apperrors.InternalError, "Failed to fetch users")
+	}
+	if len(users) <= limit {
+		return users, "", nil
+	}
+	users = users[:limit]
+	return users, model.CursorAfter(users[limit-1]).Encode(), nil
+}
+
+// typeMetadataFilter checks a map[string]string under repo.MetadataFilter against the
+// profile field schema and replaces it with typed values
+func (s *userService) typeMetadataFilter(ctx context.Context, filters map[string]interface{}) error {
+	raw, ok := filters[repo.MetadataFilter].(map[string]string)
+	if !ok {
+		return nil
+	}
+	metadata, err := s.metadataFilter(ctx, raw)
+	if err != nil {
+		return err
+	}
+	filters[repo.MetadataFilter] = metadata
+	return nil
+}
+
+// Stream calls fn for every user matching filters, in the order List would return them,
+// without holding the whole result in memory. Passwords are cleared before fn sees a user.
+func (s *userService) Stream(ctx context.Context, filters map[string]interface{}, sortBy, sortOrder string, fn func(*model.User) error) error {
+	if err := s.typeMetadataFilter(ctx, filters); err != nil {
+		return err
+	}
+	return s.repo.StreamList(ctx, filters, sortBy, sortOrder, func(u *model.User) error {
+		u.Password = ""
+		return fn(u)
+	})
+}
+
+// Stats counts the accounts and how many of them logged in within the last 1, 7 and 30 days
+func (s *userService) Stats(ctx context.Context) (*model.UserStats, error) {
+	stats, err := s.repo.Stats(ctx, s.clock.Now())
+	if err != nil {
+		s.logger.Errorw("failed to count users", "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to fetch user stats")
+	}
+	return stats, nil
+}
+
+// canonicalLocale stores locales in canonical BCP 47 form (e.g. "en-us" becomes "en-US")
+func canonicalLocale(tag string) string {
+	if canonical, ok := locale.Canonical(tag); ok {
+		return canonical
+	}
+	return tag
+}
+
+// checkRole rejects roles that are not defined; an empty role means the default
+func (s *userService) checkRole(ctx context.Context, role string) error {
+	switch {
+	case role == "":
+		return nil
+	case s.roleExists == nil:
+		if role == string(model.UserTypeRegular) || role == string(model.UserTypeAdmin) {
+			return nil
+		}
+	default:
+		exists, err := s.roleExists(ctx, role)
+		if err != nil {
+			s.logger.Errorw("failed to check role", "role", role, "error", err)
+			return apperrors.NewAppError(apperrors.InternalError, "Failed to check role")
+		}
+		if exists {
+			return nil
+		}
+	}
+	return apperrors.NewAppError(apperrors.ValidationError, "Unknown role: "+role)
+}
@@ -0,0 +1,106 @@
+// Package cache is a small key/value cache with per-entry expiry. Values are bytes so a
+// shared backend such as Redis can replace the in-memory implementation without changing
+// callers.
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/shared/clock"
+)
+
+// Cache stores values for a limited time. Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the value stored under key and whether it was found and not expired
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key; a ttl of zero or less keeps it until deleted
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+}
+
+// sweepThreshold is the entry count at which Set drops expired entries
+const sweepThreshold = 10000
+
+type entry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// Memory is a process-local Cache. Each replica has its own copy, so keep TTLs short
+// when several instances serve the same users.
+type Memory struct {
+	mu      sync.RWMutex
+	entries map[string]entry
+	clock   clock.Clock
+}
+
+func NewMemory() *Memory {
+	return &Memory{entries: make(map[string]entry), clock: clock.System()}
+}
+
+// SetClock replaces the clock used for expiry
+func (m *Memory) SetClock(c clock.Clock) {
+	m.clock = c
+}
+
+func (m *Memory) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.RLock()
+	e, ok := m.entries[key]
+	m.mu.RUnlock()
+	if !ok || m.expired(e) {
+		return nil, false, nil
+	}
+	return e.value, true, nil
+}
+
+func (m *Memory) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	e := entry{value: value}
+	if ttl > 0 {
+		e.expiresAt = m.clock.Now().Add(ttl)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.entries) >= sweepThreshold {
+		for k, old := range m.entries {
+			if m.expired(old) {
+				delete(m.entries, k)
+			}
+		}
+	}
+	if m.entries == nil {
+		m.entries = make(map[string]entry)
+	}
+	m.entries[key] = e
+	return nil
+}
+
+func (m *Memory) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	delete(m.entries, key)
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *Memory) expired(e entry) bool {
+	return !e.expiresAt.IsZero() && !e.expiresAt.After(m.clock.Now())
+}
+
+type requestScopeKey struct{}
+
+// WithRequestScope attaches a cache that lives as long as ctx, so repeated lookups while
+// handling one request are served once
+func WithRequestScope(ctx context.Context) context.Context {
+	// The map is created on first Set; most requests never store anything
+	return context.WithValue(ctx, requestScopeKey{}, &Memory{clock: clock.System()})
+}
+
+// FromRequest returns the request-scoped cache attached by WithRequestScope, or nil
+func FromRequest(ctx context.Context) Cache {
+	if c, ok := ctx.Value(requestScopeKey{}).(*Memory); ok {
+		return c
+	}
+	return nil
+}
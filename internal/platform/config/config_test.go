@@ -0,0 +1,153 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewForTest_Defaults(t *testing.T) {
+	// Arrange
+	t.Setenv("SERVER_ADDR", ":9999")
+	t.Setenv("JWT_ACCESS_EXPIRY", "1h")
+
+	// Act
+	cfg := NewForTest()
+
+	// Assert
+	if cfg.ServerAddr != ":8080" || cfg.JWT.AccessExpiresIn != 15*time.Minute {
+		t.Errorf("ServerAddr = %q, AccessExpiresIn = %v, want the defaults rather than the environment", cfg.ServerAddr, cfg.JWT.AccessExpiresIn)
+	}
+	if cfg.JWT.SigningKey != testDefaults["JWT_SIGNING_KEY"] || cfg.JWT.RefreshKey != testDefaults["JWT_REFRESH_KEY"] {
+		t.Error("JWT keys are not the fixed test keys")
+	}
+	if cfg.LoginLimit.MaxFailures != 5 || cfg.Export.Workers != 2 || len(cfg.CORS.AllowOrigins) == 0 {
+		t.Errorf("nested sections are not populated: %+v %+v %+v", cfg.LoginLimit, cfg.Export, cfg.CORS)
+	}
+}
+
+func TestNewForTest_OverridesAreIsolated(t *testing.T) {
+	// Arrange
+	first := NewForTest(func(c *Config) { c.LoginLimit.MaxFailures = 1 }, func(c *Config) { c.LoginLimit.MaxFailures++ })
+
+	// Act
+	first.CORS.AllowOrigins[0] = "https://changed.example"
+	second := NewForTest()
+
+	// Assert
+	if first.LoginLimit.MaxFailures != 2 {
+		t.Errorf("MaxFailures = %d, want overrides applied in order", first.LoginLimit.MaxFailures)
+	}
+	if second.LoginLimit.MaxFailures != 5 || second.CORS.AllowOrigins[0] != "http://localhost:3000" {
+		t.Error("changes to one test config leaked into the next")
+	}
+	if appConfig != nil {
+		t.Error("NewForTest set the config kept by LoadConfig")
+	}
+}
+
+func TestFromSource_InvalidRequiredValues(t *testing.T) {
+	tests := []struct {
+		name string
+		env  mapSource
+	}{
+		{name: "database url", env: mapSource{"DATABASE_URL": "postgres://%zz"}},
+		{name: "key pair algorithm without key", env: mapSource{"JWT_ALGORITHM": "RS256"}},
+		{name: "log output", env: mapSource{"LOG_OUTPUT": "stdout,syslog"}},
+		{name: "signup captcha without provider", env: mapSource{"SIGNUP_CAPTCHA": "true"}},
+		{name: "role hierarchy", env: mapSource{"ROLE_HIERARCHY": "admin>>user"}},
+		{name: "client cert routes without CA", env: mapSource{"MTLS_ROUTES": "/api/v1/admin"}},
+		{name: "client cert subject", env: mapSource{"MTLS_SUBJECTS": "billing-worker=robot:billing"}},
+		{name: "webhooks", env: mapSource{"WEBHOOKS": "https://hooks.example.com/auth"}},
+		{name: "quota", env: mapSource{"QUOTA_DAILY": "user:1000"}},
+		{name: "thumbnail size", env: mapSource{"FILE_THUMBNAIL_SIZES": "128,huge"}},
+		{name: "storage driver", env: mapSource{"STORAGE_DRIVER": "ftp"}},
+		{name: "azure storage without key", env: mapSource{"STORAGE_DRIVER": "azure", "AZURE_STORAGE_ACCOUNT": "uploads"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			tt.env["JWT_SIGNING_KEY"] = "signing"
+			tt.env["JWT_REFRESH_KEY"] = "refresh"
+
+			// Act
+			cfg, err := fromSource(tt.env)
+
+			// Assert
+			if err == nil || cfg != nil {
+				t.Errorf("fromSource() = %v, %v, want an error", cfg, err)
+			}
+		})
+	}
+}
+
+func TestFromSource_StorageDriver(t *testing.T) {
+	tests := []struct {
+		name         string
+		env          mapSource
+		wantDriver   string
+		wantEndpoint string
+	}{
+		{name: "default", env: mapSource{}, wantDriver: StorageMinIO, wantEndpoint: "localhost:9000"},
+		{name: "local", env: mapSource{"STORAGE_DRIVER": "Local"}, wantDriver: StorageLocal, wantEndpoint: "localhost:9000"},
+		{name: "former variable", env: mapSource{"STORAGE_BACKEND": "s3"}, wantDriver: StorageS3, wantEndpoint: "s3.amazonaws.com"},
+		{name: "driver wins", env: mapSource{"STORAGE_DRIVER": "gcs", "STORAGE_BACKEND": "s3"}, wantDriver: StorageGCS, wantEndpoint: "storage.googleapis.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			tt.env["JWT_SIGNING_KEY"] = "signing"
+			tt.env["JWT_REFRESH_KEY"] = "refresh"
+
+			// Act
+			cfg, err := fromSource(tt.env)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("fromSource() error = %v", err)
+			}
+			if cfg.Storage.Backend != tt.wantDriver || cfg.MinIO.MinioEndpoint != tt.wantEndpoint {
+				t.Errorf("driver = %q, endpoint = %q, want %q, %q", cfg.Storage.Backend, cfg.MinIO.MinioEndpoint, tt.wantDriver, tt.wantEndpoint)
+			}
+			if cfg.Storage.LocalURL != "/api/v1/files/raw" || cfg.Storage.LocalSecret != "signing" {
+				t.Errorf("local URL = %q, secret = %q, want the raw route and the JWT signing key", cfg.Storage.LocalURL, cfg.Storage.LocalSecret)
+			}
+		})
+	}
+}
+
+func TestFromSource_FileArchive(t *testing.T) {
+	// Arrange
+	env := mapSource{"JWT_SIGNING_KEY": "signing", "JWT_REFRESH_KEY": "refresh", "FILE_ARCHIVE_MAX_SIZE_MB": "64"}
+
+	// Act
+	cfg, err := fromSource(env)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("fromSource() error = %v", err)
+	}
+	if cfg.FileArchive.MaxFiles != 100 || cfg.FileArchive.MaxSize != 64<<20 {
+		t.Errorf("FileArchive = %+v, want 100 files and 64 MiB", cfg.FileArchive)
+	}
+}
+
+func TestFromSource_FilePublic(t *testing.T) {
+	// Arrange
+	env := mapSource{
+		"JWT_SIGNING_KEY":      "signing",
+		"JWT_REFRESH_KEY":      "refresh",
+		"FILE_PUBLIC_BASE_URL": "https://cdn.example.com/files/",
+		"FILE_PUBLIC_MAX_AGE":  "168h",
+	}
+
+	// Act
+	cfg, err := fromSource(env)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("fromSource() error = %v", err)
+	}
+	if cfg.FilePublic.BaseURL != "https://cdn.example.com/files" || cfg.FilePublic.MaxAge != 168*time.Hour {
+		t.Errorf("FilePublic = %+v, want the CDN without its trailing slash and a week", cfg.FilePublic)
+	}
+}
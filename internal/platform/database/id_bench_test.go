@@ -0,0 +1,92 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/MurtadaNazar/go-api-template/internal/shared/id"
+
+	_ "github.com/lib/pq"
+)
+
+const benchBatchSize = 1000
+
+// BenchmarkPrimaryKeyInsert compares inserts into a large table keyed by random (v4) and
+// time-ordered (v7) UUIDs. It needs a scratch Postgres database and is skipped otherwise:
+//
+//	BENCH_DATABASE_DSN="host=localhost user=postgres password=postgres dbname=bench sslmode=disable" \
+//	  go test ./internal/platform/database -run '^$' -bench PrimaryKeyInsert -benchtime 50x
+//
+// BENCH_TABLE_ROWS sets how many rows are loaded before timing starts (default 1000000).
+// Each iteration inserts one batch of 1000 rows; compare the rows/s metric.
+func BenchmarkPrimaryKeyInsert(b *testing.B) {
+	dsn := os.Getenv("BENCH_DATABASE_DSN")
+	if dsn == "" {
+		b.Skip("BENCH_DATABASE_DSN not set")
+	}
+	preload := 1000000
+	if v, err := strconv.Atoi(os.Getenv("BENCH_TABLE_ROWS")); err == nil && v >= 0 {
+		preload = v
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, tc := range []struct {
+		name      string
+		generator id.Generator
+	}{
+		{"v4", id.V4},
+		{"v7", id.V7},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			table := "bench_primary_keys_" + tc.name
+			mustExec(b, db, "DROP TABLE IF EXISTS "+table)
+			mustExec(b, db, "CREATE TABLE "+table+" (id uuid PRIMARY KEY, payload text NOT NULL)")
+			defer mustExec(b, db, "DROP TABLE "+table)
+
+			for loaded := 0; loaded < preload; loaded += benchBatchSize {
+				insertBatch(b, db, table, tc.generator)
+			}
+			mustExec(b, db, "VACUUM ANALYZE "+table)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				insertBatch(b, db, table, tc.generator)
+			}
+			b.StopTimer()
+			b.ReportMetric(float64(b.N*benchBatchSize)/b.Elapsed().Seconds(), "rows/s")
+		})
+	}
+}
+
+func insertBatch(b *testing.B, db *sql.DB, table string, g id.Generator) {
+	b.Helper()
+	var query strings.Builder
+	args := make([]interface{}, 0, benchBatchSize*2)
+	query.WriteString("INSERT INTO " + table + " (id, payload) VALUES ")
+	for i := 0; i < benchBatchSize; i++ {
+		if i > 0 {
+			query.WriteString(",")
+		}
+		fmt.Fprintf(&query, "($%d, $%d)", 2*i+1, 2*i+2)
+		args = append(args, g.New(), "benchmark row")
+	}
+	if _, err := db.Exec(query.String(), args...); err != nil {
+		b.Fatal(err)
+	}
+}
+
+func mustExec(b *testing.B, db *sql.DB, query string) {
+	b.Helper()
+	if _, err := db.Exec(query); err != nil {
+		b.Fatal(err)
+	}
+}
@@ -2,15 +2,28 @@ package database
 
 import (
 	"context"
-	userDto "go_platform_template/internal/domain/user/dto"
-	model "go_platform_template/internal/domain/user/model"
-	userRepo "go_platform_template/internal/domain/user/repo"
-	userService "go_platform_template/internal/domain/user/service"
+	authzRepo "github.com/MurtadaNazar/go-api-template/internal/domain/authz/repo"
+	authzService "github.com/MurtadaNazar/go-api-template/internal/domain/authz/service"
+	userDto "github.com/MurtadaNazar/go-api-template/internal/domain/user/dto"
+	model "github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	userRepo "github.com/MurtadaNazar/go-api-template/internal/domain/user/repo"
+	userService "github.com/MurtadaNazar/go-api-template/internal/domain/user/service"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
+// SeedRoles seeds the built-in roles and the permissions routes check. It runs on every
+// start so permissions added in code are granted to the admin role.
+func SeedRoles(db *gorm.DB, logger *zap.SugaredLogger) {
+	svc := authzService.NewService(authzRepo.NewRoleRepo(db), logger)
+	if err := svc.SeedDefaults(context.Background()); err != nil {
+		logger.Errorf("Failed to seed roles: %v", err)
+		return
+	}
+	logger.Info("Roles and permissions seeded successfully")
+}
+
 // SeedAdminUser seeds the system admin user
 func SeedAdminUser(db *gorm.DB, logger *zap.SugaredLogger) {
 	uRepo := userRepo.NewUserRepo(db)
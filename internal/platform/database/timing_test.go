@@ -0,0 +1,44 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MurtadaNazar/go-api-template/internal/shared/timing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type timedRow struct {
+	ID   int
+	Name string
+}
+
+func TestRegisterQueryTiming(t *testing.T) {
+	// Arrange: DryRun builds statements without a server, but runs the callbacks
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 port=1"}), &gorm.Config{
+		DryRun: true, DisableAutomaticPing: true, Logger: logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterQueryTiming(db); err != nil {
+		t.Fatalf("RegisterQueryTiming() error = %v", err)
+	}
+	recorder := &timing.Recorder{}
+	ctx := timing.With(context.Background(), recorder)
+
+	// Act
+	var rows []timedRow
+	db.WithContext(ctx).Find(&rows)
+	db.WithContext(ctx).Create(&timedRow{Name: "a"})
+	db.WithContext(context.Background()).Find(&rows)
+
+	// Assert
+	segments := recorder.Segments()
+	if len(segments) != 1 || segments[0].Name != "db" || segments[0].Count != 2 {
+		t.Errorf("segments = %+v, want the two statements run with the recorder in db", segments)
+	}
+}
@@ -0,0 +1,59 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+
+	"go.uber.org/zap"
+)
+
+// Message is a plain text email to one recipient
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+	// Headers are extra headers such as List-Unsubscribe
+	Headers map[string]string
+}
+
+// Sender delivers email messages
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewSender builds the Sender selected by EMAIL_PROVIDER.
+// It returns nil (and no error) when email is disabled for this deployment.
+func NewSender(cfg config.EmailConfig, log *zap.SugaredLogger) (Sender, error) {
+	switch strings.ToLower(cfg.Provider) {
+	case "", "none":
+		return nil, nil
+	case "log":
+		return NewLogSender(log), nil
+	case "smtp":
+		// Return a nil interface rather than a nil *SMTPSender on error
+		sender, err := NewSMTPSender(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From)
+		if err != nil {
+			return nil, err
+		}
+		return sender, nil
+	default:
+		return nil, fmt.Errorf("unknown email provider %q", cfg.Provider)
+	}
+}
+
+// LogSender writes messages to the application log instead of sending them (development only)
+type LogSender struct {
+	logger *zap.SugaredLogger
+}
+
+func NewLogSender(logger *zap.SugaredLogger) *LogSender {
+	return &LogSender{logger: logger}
+}
+
+func (s *LogSender) Send(ctx context.Context, msg Message) error {
+	s.logger.Infow("email message (log provider)", "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+	return nil
+}
@@ -0,0 +1,45 @@
+package email
+
+import (
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildMessage(t *testing.T) {
+	// Arrange
+	from := &mail.Address{Name: "Example", Address: "no-reply@example.com"}
+	to := &mail.Address{Address: "jane@example.com"}
+	msg := Message{
+		Subject: "Grüße",
+		Body:    "Hello\nSee you soon",
+		Headers: map[string]string{"List-Unsubscribe": "<https://example.com/u?t=1>\r\nBcc: victim@example.com"},
+	}
+
+	// Act
+	data, err := buildMessage(from, to, msg, time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("buildMessage() error = %v", err)
+	}
+	headers, body, _ := strings.Cut(string(data), "\r\n\r\n")
+	for _, want := range []string{
+		`From: "Example" <no-reply@example.com>`,
+		"To: <jane@example.com>",
+		"Subject: =?utf-8?q?Gr=C3=BC=C3=9Fe?=",
+		"Date: Mon, 15 Jan 2024 12:00:00 +0000",
+		"List-Unsubscribe: <https://example.com/u?t=1>Bcc: victim@example.com",
+	} {
+		if !strings.Contains(headers+"\r\n", want+"\r\n") {
+			t.Errorf("headers missing %q:\n%s", want, headers)
+		}
+	}
+	if strings.Contains(headers, "\r\nBcc:") {
+		t.Error("header value injected a Bcc header")
+	}
+	if body != "Hello\r\nSee you soon" {
+		t.Errorf("body = %q", body)
+	}
+}
@@ -0,0 +1,25 @@
+package events
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBus_Publish(t *testing.T) {
+	// Arrange
+	bus := NewBus()
+	var got []string
+	bus.Subscribe(func(ctx context.Context, e Event) { got = append(got, "first:"+e.Type+":"+e.UserID) })
+	bus.Subscribe(func(ctx context.Context, e Event) { got = append(got, "second:"+e.Type+":"+e.UserID) })
+	var nilBus *Bus
+
+	// Act
+	bus.Publish(context.Background(), Event{Type: PasswordChanged, UserID: "u1"})
+	nilBus.Publish(context.Background(), Event{Type: PasswordChanged, UserID: "u2"})
+
+	// Assert
+	want := []string{"first:password.changed:u1", "second:password.changed:u1"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("handlers saw %v, want %v", got, want)
+	}
+}
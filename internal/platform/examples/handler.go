@@ -0,0 +1,78 @@
+package examples
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Route names the DTOs a route reads and writes. Path is relative to the API base path,
+// as in the route's @Router annotation.
+type Route struct {
+	Method string
+	Path   string
+	// Request is the body the route reads, nil when it has none
+	Request interface{}
+	// Response is the data of the SuccessResponse it answers with, nil when it has none
+	Response interface{}
+	// Bare marks routes that answer with Response itself rather than a SuccessResponse
+	Bare bool
+}
+
+// RouteExample is one entry of /docs/examples
+type RouteExample struct {
+	Method   string      `json:"method"`
+	Path     string      `json:"path"`
+	Request  interface{} `json:"request,omitempty"`
+	Response interface{} `json:"response,omitempty"`
+}
+
+// Handler serves the examples of routes under basePath. They are built once, when the
+// handler is created. ?path= narrows the list to one path, e.g. ?path=/api/v1/login.
+func Handler(basePath string, routes ...Route) gin.HandlerFunc {
+	all := make([]RouteExample, 0, len(routes))
+	for _, route := range routes {
+		example := RouteExample{
+			Method:  strings.ToUpper(route.Method),
+			Path:    strings.TrimRight(basePath, "/") + route.Path,
+			Request: Generate(route.Request),
+		}
+		switch {
+		case route.Bare:
+			example.Response = Generate(route.Response)
+		case route.Response != nil:
+			example.Response = envelope(Generate(route.Response))
+		}
+		all = append(all, example)
+	}
+
+	return func(c *gin.Context) {
+		requestID := c.GetString("RequestID")
+		path := c.Query("path")
+		if path == "" {
+			c.JSON(http.StatusOK, response.NewSuccessResponse(all, requestID))
+			return
+		}
+		matching := []RouteExample{}
+		for _, example := range all {
+			if example.Path == path {
+				matching = append(matching, example)
+			}
+		}
+		c.JSON(http.StatusOK, response.NewSuccessResponse(matching, requestID))
+	}
+}
+
+// envelope wraps data the way response.NewSuccessResponse does
+func envelope(data interface{}) object {
+	out, _ := Generate(response.SuccessResponse{}).(object)
+	for i := range out {
+		if out[i].name == "data" {
+			out[i].value = data
+		}
+	}
+	return out
+}
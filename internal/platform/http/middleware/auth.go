@@ -1,35 +1,307 @@
 package middleware
 
 import (
-	"go_platform_template/internal/domain/auth/service"
-	apperrors "go_platform_template/internal/shared/errors"
+	"context"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/service"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/cache"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/quota"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/locale"
+	"slices"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
-func JWTAuth(jwtManager *service.JWTManager) gin.HandlerFunc {
+// AccountCheckMode controls whether JWTAuth re-checks the account behind a valid token
+type AccountCheckMode string
+
+const (
+	// AccountCheckOff trusts the token claims until the token expires
+	AccountCheckOff AccountCheckMode = "off"
+	// AccountCheckStatus rejects inactive and deleted accounts and applies the current
+	// role; when the lookup itself fails the request is let through on its claims
+	AccountCheckStatus AccountCheckMode = "status"
+	// AccountCheckStrict is AccountCheckStatus that also rejects requests whose account
+	// cannot be looked up
+	AccountCheckStrict AccountCheckMode = "strict"
+)
+
+// AccountLookup returns the current role of a user and whether the account is active.
+// Deleted users are reported with a NotFoundError AppError.
+type AccountLookup func(ctx context.Context, userID string) (role string, active bool, err error)
+
+// AuthOption customizes JWTAuth
+type AuthOption func(*authOptions)
+
+type authOptions struct {
+	accountCheck AccountCheckMode
+	lookup       AccountLookup
+	logger       *zap.SugaredLogger
+	allowGuests  bool
+	allowClients bool
+	tokenCache   *TokenCache
+	hierarchy    RoleHierarchy
+	quota        *quota.Limiter
+}
+
+// WithAccountCheck makes JWTAuth look the account up on every request, so suspended or
+// demoted users lose access before their access token expires. Back lookup with a cache
+// (see UserService.AccountStatus) to keep it off the database for most requests.
+func WithAccountCheck(mode AccountCheckMode, lookup AccountLookup, logger *zap.SugaredLogger) AuthOption {
+	return func(o *authOptions) {
+		o.accountCheck = mode
+		o.lookup = lookup
+		o.logger = logger
+	}
+}
+
+// AllowGuests makes JWTAuth accept guest tokens from POST /auth/guest as well as user
+// tokens. Without it guests get 401. Use it on read-only routes open to anonymous
+// clients, and tell guests apart with IsGuest; they have no account, so the account check
+// is skipped for them.
+func AllowGuests() AuthOption {
+	return func(o *authOptions) {
+		o.allowGuests = true
+	}
+}
+
+// AllowServiceClients makes JWTAuth accept client credentials tokens from POST /auth/token
+// as well as user tokens. Without it service clients get 401. Their requests act as
+// "client:<client_id>", skip the account check, and pass RequirePermission only for the
+// permissions in their token's scope; tell them apart with ServiceClientID.
+func AllowServiceClients() AuthOption {
+	return func(o *authOptions) {
+		o.allowClients = true
+	}
+}
+
+// RoleHierarchy decides whether a role includes another through inheritance (see the
+// authz service)
+type RoleHierarchy interface {
+	HasRole(ctx context.Context, role, required string) (bool, error)
+}
+
+// WithRoleHierarchy makes RequireRole on the routes behind this JWTAuth admit roles that
+// inherit a required role, e.g. admins on a route requiring "moderator" when admin
+// inherits moderator. Without it RequireRole compares role names only.
+func WithRoleHierarchy(h RoleHierarchy) AuthOption {
+	return func(o *authOptions) {
+		o.hierarchy = h
+	}
+}
+
+func JWTAuth(jwtManager *service.JWTManager, opts ...AuthOption) gin.HandlerFunc {
+	options := authOptions{accountCheck: AccountCheckOff}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.logger == nil {
+		options.logger = zap.NewNop().Sugar()
+	}
+	checkAccount := options.lookup != nil && options.accountCheck != AccountCheckOff
+
 	return func(c *gin.Context) {
+		// ClientCertAuth already authenticated the request on a route requiring mTLS
+		if c.GetString("authMethod") == AuthMethodClientCert {
+			if options.hierarchy != nil {
+				c.Set("roleHierarchy", options.hierarchy)
+			}
+			c.Next()
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			_ = c.Error(apperrors.NewAppError(apperrors.UnauthorizedError, "missing authorization header"))
+			c.Abort()
 			return
 		}
 
 		token := strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer"))
 		if token == "" {
 			_ = c.Error(apperrors.NewAppError(apperrors.UnauthorizedError, "invalid authorization header"))
+			c.Abort()
 			return
 		}
 
-		claims, err := jwtManager.ValidateAccessToken(token)
-		if err != nil {
-			_ = c.Error(apperrors.NewAppError(apperrors.UnauthorizedError, "invalid or expired token"))
+		claims, cached := options.tokenCache.get(token)
+		if !cached {
+			var err error
+			claims, err = jwtManager.ValidateAccessToken(token)
+			if err != nil {
+				_ = c.Error(apperrors.NewAppError(apperrors.UnauthorizedError, "invalid or expired token"))
+				c.Abort()
+				return
+			}
+			options.tokenCache.add(token, claims)
+		}
+		if claims.Guest && !options.allowGuests {
+			_ = c.Error(apperrors.NewAppError(apperrors.UnauthorizedError, "sign in required"))
+			c.Abort()
+			return
+		}
+		if claims.ClientID != "" && !options.allowClients {
+			_ = c.Error(apperrors.NewAppError(apperrors.UnauthorizedError, "service client tokens are not accepted here"))
+			c.Abort()
+			return
+		}
+
+		role := claims.Role
+		userID := claims.UserID.String()
+		if claims.ClientID != "" {
+			userID = "client:" + claims.ClientID
+		}
+		ctx := actor.WithUserID(c.Request.Context(), userID)
+		ctx = locale.WithPreferences(ctx, claims.TimeZone, claims.Locale)
+		// Lookups repeated while handling this request (e.g. account status) hit the database once
+		ctx = cache.WithRequestScope(ctx)
+
+		if checkAccount && !claims.Guest && claims.ClientID == "" {
+			current, active, err := options.lookup(ctx, userID)
+			switch {
+			case err == nil && !active:
+				_ = c.Error(apperrors.NewAppError(apperrors.UnauthorizedError, "account is not active"))
+				c.Abort()
+				return
+			case err == nil:
+				role = current
+			case isNotFound(err):
+				_ = c.Error(apperrors.NewAppError(apperrors.UnauthorizedError, "account no longer exists"))
+				c.Abort()
+				return
+			case options.accountCheck == AccountCheckStrict:
+				options.logger.Errorw("account check failed, rejecting request", "user_id", claims.UserID, "error", err)
+				_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "could not verify account status"))
+				c.Abort()
+				return
+			default:
+				options.logger.Warnw("account check failed, using token claims", "user_id", claims.UserID, "error", err)
+			}
+		}
+
+		if !claims.Guest && !enforceQuota(c, options.quota, userID, role) {
+			c.Abort()
 			return
 		}
 
-		c.Set("userID", claims.UserID)
-		c.Set("role", claims.Role)
+		c.Set("userID", userID)
+		c.Set("role", role)
+		c.Set("claims", claims.Extra)
+		if options.hierarchy != nil {
+			c.Set("roleHierarchy", options.hierarchy)
+		}
+		if claims.Guest {
+			c.Set("guest", true)
+		}
+		if claims.ClientID != "" {
+			c.Set("clientID", claims.ClientID)
+			c.Set("scopes", strings.Fields(claims.Scope))
+		}
+		if claims.ImpersonatorID != "" {
+			c.Set("impersonatorID", claims.ImpersonatorID)
+			ctx = actor.WithImpersonatorID(ctx, claims.ImpersonatorID)
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// ExtraClaims returns the claims the JWTManager's ClaimsEnricher added to the request's
+// access token, or nil; use it after JWTAuth. They were set when the token was issued,
+// so they can be up to one access token lifetime old.
+func ExtraClaims(c *gin.Context) map[string]interface{} {
+	value, _ := c.Get("claims")
+	extra, _ := value.(map[string]interface{})
+	return extra
+}
+
+// IsGuest reports whether the request was authenticated with a guest token; use it after
+// JWTAuth with AllowGuests
+func IsGuest(c *gin.Context) bool {
+	return c.GetBool("guest")
+}
+
+// ServiceClientID returns the client ID of a request authenticated with a client
+// credentials token, or ""; use it after JWTAuth with AllowServiceClients
+func ServiceClientID(c *gin.Context) string {
+	return c.GetString("clientID")
+}
+
+func isNotFound(err error) bool {
+	appErr, ok := apperrors.IsAppError(err)
+	return ok && appErr.Type == apperrors.NotFoundError
+}
+
+// RequireRole rejects requests whose JWT role is not one of roles, nor inherits one of
+// them when JWTAuth has WithRoleHierarchy; use it after JWTAuth
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+		if value, ok := c.Get("roleHierarchy"); ok && role != "" {
+			hierarchy := value.(RoleHierarchy)
+			for _, allowed := range roles {
+				inherits, err := hierarchy.HasRole(c.Request.Context(), role, allowed)
+				if err != nil {
+					if _, ok := apperrors.IsAppError(err); !ok {
+						err = apperrors.NewAppError(apperrors.InternalError, "Failed to check permissions")
+					}
+					_ = c.Error(err)
+					c.Abort()
+					return
+				}
+				if inherits {
+					c.Next()
+					return
+				}
+			}
+		}
+		_ = c.Error(apperrors.NewAppError(apperrors.ForbiddenError, "insufficient permissions"))
+		c.Abort()
+	}
+}
+
+// PermissionChecker decides whether a role grants a permission (see the authz service)
+type PermissionChecker interface {
+	Can(ctx context.Context, role, permission string) (bool, error)
+}
+
+// RequirePermission rejects requests whose role lacks permission; use it after JWTAuth.
+// Apply it to a route group to guard every route in it. Service client requests need the
+// permission in their token's scope instead.
+func RequirePermission(checker PermissionChecker, permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if value, ok := c.Get("scopes"); ok {
+			if !slices.Contains(value.([]string), permission) {
+				_ = c.Error(apperrors.NewAppError(apperrors.ForbiddenError, "insufficient scope"))
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+		allowed, err := checker.Can(c.Request.Context(), c.GetString("role"), permission)
+		if err != nil {
+			if _, ok := apperrors.IsAppError(err); !ok {
+				err = apperrors.NewAppError(apperrors.InternalError, "Failed to check permissions")
+			}
+			_ = c.Error(err)
+			c.Abort()
+			return
+		}
+		if !allowed {
+			_ = c.Error(apperrors.NewAppError(apperrors.ForbiddenError, "insufficient permissions"))
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/service"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// chainAllocBudget is the most heap allocations the global middleware chain plus JWTAuth
+// may add to an authenticated JSON request; see "Middleware Performance" in the README
+const chainAllocBudget = 90
+
+// benchLogger logs JSON at info level to io.Discard, so encoding is measured but not I/O
+func benchLogger() *zap.SugaredLogger {
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(io.Discard), zap.InfoLevel)
+	return zap.New(core).Sugar()
+}
+
+// benchChain mirrors SetupMiddleware without CORS and rate limiting (both optional, and
+// the limiter would start rejecting the benchmark) and serves:
+//
+//	GET /public  JSON without auth
+//	GET /private JSON behind JWTAuth with the account check
+//	GET /error   an AppError rendered by ErrorHandlerMiddleware
+func benchChain(jwt *service.JWTManager) *gin.Engine {
+	gin.SetMode(gin.ReleaseMode)
+	log := benchLogger()
+	r := gin.New()
+	r.Use(
+		RequestIDMiddleware(),
+		ClientIdentificationMiddleware(config.ClientConfig{Header: "X-Client-Id"}),
+		LoggerMiddleware(log),
+		RecoveryMiddleware(log),
+		LocalizationMiddleware(config.LocalizationConfig{DefaultLocale: "en", TimestampMode: "utc"}),
+		ErrorHandlerMiddleware(log),
+	)
+
+	lookup := func(ctx context.Context, userID string) (string, bool, error) { return "user", true, nil }
+	requireAuth := JWTAuth(jwt, WithAccountCheck(AccountCheckStatus, lookup, log))
+	ok := func(c *gin.Context) {
+		c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{"ok": true}, c.GetString("RequestID")))
+	}
+	r.GET("/public", ok)
+	r.GET("/private", requireAuth, ok)
+	r.GET("/error", func(c *gin.Context) {
+		_ = c.Error(apperrors.NewAppError(apperrors.NotFoundError, "User not found"))
+	})
+	return r
+}
+
+func benchRequests(b testing.TB) (*gin.Engine, map[string]*http.Request) {
+	jwt := service.NewJWTManager("access-secret", "refresh-secret", time.Hour, time.Hour)
+	access, _, err := jwt.GenerateTokens(context.Background(), uuid.New(), "user", service.Preferences{TimeZone: "Europe/Berlin", Locale: "de-DE"})
+	if err != nil {
+		b.Fatal(err)
+	}
+	private := httptest.NewRequest(http.MethodGet, "/private", nil)
+	private.Header.Set("Authorization", "Bearer "+access)
+	return benchChain(jwt), map[string]*http.Request{
+		"public":  httptest.NewRequest(http.MethodGet, "/public", nil),
+		"private": private,
+		"error":   httptest.NewRequest(http.MethodGet, "/error", nil),
+	}
+}
+
+// discardWriter is a ResponseWriter that keeps no body, so the benchmark does not
+// measure the recorder
+type discardWriter struct {
+	header http.Header
+	status int
+}
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardWriter) WriteHeader(status int)      { w.status = status }
+
+func (w *discardWriter) reset() {
+	for k := range w.header {
+		delete(w.header, k)
+	}
+	w.status = 0
+}
+
+// BenchmarkMiddlewareChain measures a request through the full chain; compare runs with
+// benchstat before and after changing a middleware
+func BenchmarkMiddlewareChain(b *testing.B) {
+	r, requests := benchRequests(b)
+	for _, name := range []string{"public", "private", "error"} {
+		req := requests[name]
+		b.Run(name, func(b *testing.B) {
+			w := &discardWriter{header: http.Header{}}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				w.reset()
+				r.ServeHTTP(w, req)
+			}
+		})
+	}
+}
+
+// BenchmarkMiddleware measures each middleware alone in front of an empty handler
+func BenchmarkMiddleware(b *testing.B) {
+	log := benchLogger()
+	jwt := service.NewJWTManager("access-secret", "refresh-secret", time.Hour, time.Hour)
+	access, _, err := jwt.GenerateTokens(context.Background(), uuid.New(), "user", service.Preferences{TimeZone: "Europe/Berlin"})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	cases := []struct {
+		name       string
+		middleware gin.HandlerFunc
+	}{
+		{"request_id", RequestIDMiddleware()},
+		{"logger", LoggerMiddleware(log)},
+		{"recovery", RecoveryMiddleware(log)},
+		{"localization", LocalizationMiddleware(config.LocalizationConfig{DefaultLocale: "en"})},
+		{"error_handler", ErrorHandlerMiddleware(log)},
+		{"jwt_auth", JWTAuth(jwt)},
+	}
+	for _, tc := range cases {
+		b.Run(tc.name, func(b *testing.B) {
+			gin.SetMode(gin.ReleaseMode)
+			r := gin.New()
+			r.GET("/", tc.middleware, func(c *gin.Context) { c.Status(http.StatusNoContent) })
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+access)
+			w := &discardWriter{header: http.Header{}}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				w.reset()
+				r.ServeHTTP(w, req)
+			}
+		})
+	}
+}
+
+// TestMiddlewareChain_AllocationBudget fails when a change makes every authenticated
+// request allocate noticeably more
+func TestMiddlewareChain_AllocationBudget(t *testing.T) {
+	if testing.Short() {
+		t.Skip("allocation budget skipped in -short mode")
+	}
+	r, requests := benchRequests(t)
+	req := requests["private"]
+	w := &discardWriter{header: http.Header{}}
+
+	allocs := testing.AllocsPerRun(200, func() {
+		w.reset()
+		r.ServeHTTP(w, req)
+	})
+
+	if w.status != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.status)
+	}
+	if allocs > chainAllocBudget {
+		t.Errorf("authenticated request allocates %.0f times, budget is %d", allocs, chainAllocBudget)
+	}
+}
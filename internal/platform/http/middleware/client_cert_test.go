@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/service"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestClientCertAuth(t *testing.T) {
+	jwt := service.NewJWTManager("access-secret", "refresh-secret", time.Minute, time.Hour)
+	cfg := config.TLSConfig{
+		ClientCertRoutes: []string{"/internal"},
+		ClientSubjects: map[string]config.ClientSubject{
+			"billing-worker": {Kind: config.ClientSubjectService, ID: "billing", Role: "service"},
+			"alice-laptop":   {Kind: config.ClientSubjectUser, ID: "alice"},
+			"bob-laptop":     {Kind: config.ClientSubjectUser, ID: "bob"},
+		},
+	}
+	lookup := func(ctx context.Context, userID string) (string, bool, error) {
+		return "admin", userID == "alice", nil
+	}
+
+	cases := []struct {
+		name     string
+		path     string
+		cn       string
+		want     int
+		wantUser string
+		wantRole string
+	}{
+		{"service account", "/internal/reports", "billing-worker", http.StatusNoContent, "service:billing", "service"},
+		{"user with current role", "/internal/reports", "alice-laptop", http.StatusNoContent, "alice", "admin"},
+		{"inactive user", "/internal/reports", "bob-laptop", http.StatusUnauthorized, "", ""},
+		{"unknown subject", "/internal/reports", "mallory", http.StatusForbidden, "", ""},
+		{"no certificate", "/internal/reports", "", http.StatusUnauthorized, "", ""},
+		{"JWT route", "/public", "", http.StatusUnauthorized, "", ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.Use(RequestIDMiddleware(), ErrorHandlerMiddleware(zap.NewNop().Sugar()))
+			r.Use(ClientCertAuth(cfg, lookup, zap.NewNop().Sugar()))
+			var userID, role string
+			handler := func(c *gin.Context) {
+				userID, role = c.GetString("userID"), c.GetString("role")
+				c.Status(http.StatusNoContent)
+			}
+			r.GET("/internal/reports", JWTAuth(jwt), handler)
+			r.GET("/public", JWTAuth(jwt), handler)
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.cn != "" {
+				cert := &x509.Certificate{Subject: pkix.Name{CommonName: tc.cn}}
+				req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+			}
+			w := httptest.NewRecorder()
+
+			// Act
+			r.ServeHTTP(w, req)
+
+			// Assert
+			if w.Code != tc.want {
+				t.Fatalf("status = %d, want %d", w.Code, tc.want)
+			}
+			if userID != tc.wantUser || role != tc.wantRole {
+				t.Errorf("userID, role = %q, %q, want %q, %q", userID, role, tc.wantUser, tc.wantRole)
+			}
+		})
+	}
+}
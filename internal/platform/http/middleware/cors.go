@@ -2,20 +2,32 @@
 package middleware
 
 import (
-	"time"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
-func CORSMiddleware() gin.HandlerFunc {
-	config := cors.Config{
-		AllowOrigins:     []string{"http://localhost:3000"}, // frontend URL
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-		MaxAge:           12 * time.Hour,
+// CORSMiddleware builds the CORS policy from the CORS_* settings in config
+func CORSMiddleware(cfg config.CORSConfig) gin.HandlerFunc {
+	corsConfig := cors.Config{
+		AllowOrigins:     cfg.AllowOrigins,
+		AllowMethods:     cfg.AllowMethods,
+		AllowHeaders:     cfg.AllowHeaders,
+		ExposeHeaders:    cfg.ExposeHeaders,
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           cfg.MaxAge,
 	}
-	return cors.New(config)
+
+	// A wildcard origin cannot be combined with credentials, so honour "*" explicitly
+	for _, origin := range cfg.AllowOrigins {
+		if origin == "*" {
+			corsConfig.AllowOrigins = nil
+			corsConfig.AllowAllOrigins = true
+			corsConfig.AllowCredentials = false
+			break
+		}
+	}
+
+	return cors.New(corsConfig)
 }
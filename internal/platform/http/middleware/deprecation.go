@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"github.com/MurtadaNazar/go-api-template/internal/platform/deprecation"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/clientid"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecated marks a route as deprecated: responses carry the Deprecation, Sunset and
+// Link headers from notice, and each call is recorded in tracker with the caller. Put it
+// first among the route's handlers so rejected calls are announced too:
+//
+//	users.GET("/:id/profile", middleware.Deprecated(deprecations, deprecation.Notice{
+//		Since:  time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+//		Sunset: time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC),
+//		Link:   "https://docs.example.com/migrations/profile",
+//	}), requireAuth, uHandler.GetProfile)
+//
+// The call is recorded after the handler chain, so the caller is known by user ID when
+// the route requires authentication and by client IP otherwise.
+func Deprecated(tracker *deprecation.Tracker, notice deprecation.Notice) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		notice.SetHeaders(c.Writer.Header())
+		c.Next()
+
+		client := "ip:" + c.ClientIP()
+		if userID := c.GetString("userID"); userID != "" {
+			client = "user:" + userID
+		}
+		tracker.Record(notice, deprecation.Call{
+			Route:     c.Request.Method + " " + c.FullPath(),
+			Client:    client,
+			App:       clientid.FromContext(c.Request.Context()).String(),
+			UserAgent: c.Request.UserAgent(),
+			RequestID: c.GetString("RequestID"),
+		})
+	}
+}
@@ -1,29 +1,50 @@
 package middleware
 
 import (
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
-// LoggerMiddleware logs HTTP requests using the provided SugaredLogger
+// accessLogFields reuses the field slice of access log entries; zap copies what it keeps
+var accessLogFields = sync.Pool{New: func() interface{} {
+	fields := make([]zap.Field, 0, 9)
+	return &fields
+}}
+
+// LoggerMiddleware logs HTTP requests using the provided SugaredLogger. It writes typed
+// fields through the underlying zap.Logger, which allocates far less per request than
+// the sugared key/value form, and skips the work when info logging is disabled.
 func LoggerMiddleware(logger *zap.SugaredLogger) gin.HandlerFunc {
+	base := logger.Desugar()
 	return func(c *gin.Context) {
 		start := time.Now()
 		c.Next()
 		latency := time.Since(start)
 
-		requestID, _ := c.Get("RequestID")
-
-		logger.Infow("HTTP request",
-			"request_id", requestID,
-			"status", c.Writer.Status(),
-			"method", c.Request.Method,
-			"path", c.Request.URL.Path,
-			"ip", c.ClientIP(),
-			"latency", latency,
+		entry := base.Check(zap.InfoLevel, "HTTP request")
+		if entry == nil {
+			return
+		}
+		fields := accessLogFields.Get().(*[]zap.Field)
+		*fields = append((*fields)[:0],
+			zap.String("request_id", c.GetString("RequestID")),
+			zap.String("client", c.GetString("ClientID")),
+			zap.Int("status", c.Writer.Status()),
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.Request.URL.Path),
+			zap.String("ip", c.ClientIP()),
+			zap.Duration("latency", latency),
 		)
+		// Requests made while impersonating are attributed to the admin as well
+		if impersonator := c.GetString("impersonatorID"); impersonator != "" {
+			*fields = append(*fields, zap.String("user_id", c.GetString("userID")), zap.String("impersonator_id", impersonator))
+		}
+		entry.Write(*fields...)
+		clear(*fields)
+		accessLogFields.Put(fields)
 	}
 }
 
@@ -0,0 +1,248 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/locale"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LocalizationMiddleware resolves the caller's time zone and locale and applies the zone
+// to JSON responses.
+//
+// The zone comes from ?tz= (an IANA name such as Europe/Berlin) or, once JWTAuth has run,
+// the user's saved preference; the locale from ?locale=, Accept-Language, the saved
+// preference or cfg.DefaultLocale. Both are available to handlers through the
+// locale package.
+//
+// When a zone is known, JSON envelopes gain "timezone" and "utc_offset". In "local"
+// mode the envelope timestamp and every "*_at" timestamp in the payload are also
+// converted to that zone; in "utc" mode they stay RFC3339 UTC.
+func LocalizationMiddleware(cfg config.LocalizationConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// c.Query parses the query string into a map; skip it when there is none
+		var tz, rawLocale string
+		if c.Request.URL.RawQuery != "" {
+			tz = strings.TrimSpace(c.Query("tz"))
+			rawLocale = c.Query("locale")
+		}
+
+		var loc *time.Location
+		if tz != "" {
+			parsed, err := locale.LoadLocation(tz)
+			if err != nil {
+				requestID := c.GetString("RequestID")
+				c.AbortWithStatusJSON(http.StatusBadRequest, response.NewErrorResponse(
+					"Invalid tz parameter", "BAD_REQUEST", "tz must be an IANA time zone such as Europe/Berlin", requestID,
+				))
+				return
+			}
+			loc = parsed
+		}
+
+		tag := ""
+		if rawLocale != "" {
+			tag, _ = locale.Canonical(rawLocale)
+		}
+		if tag == "" {
+			tag = locale.ParseAcceptLanguage(c.GetHeader("Accept-Language"))
+		}
+		ctx := locale.WithRequested(c.Request.Context(), loc, tag)
+		// The default is a preference so a user's saved locale can still replace it
+		ctx = locale.WithPreferences(ctx, "", cfg.DefaultLocale)
+		c.Request = c.Request.WithContext(ctx)
+
+		w := writerPool.Get().(*localizingWriter)
+		w.ResponseWriter = c.Writer
+		c.Writer = w
+		// Deferred so a panic recovered further out still sees an unwrapped writer
+		defer func() {
+			c.Writer = w.ResponseWriter
+			// JWTAuth may have applied the user's preferences to the request context
+			w.flush(locale.Location(c.Request.Context()), cfg.TimestampMode == "local")
+			w.release()
+		}()
+		c.Next()
+	}
+}
+
+// maxPooledBuffer is the largest response buffer kept for reuse; bigger ones are left to
+// the garbage collector so one large response does not pin memory
+const maxPooledBuffer = 64 << 10
+
+// writerPool reuses localizingWriters and their buffers across requests
+var writerPool = sync.Pool{New: func() interface{} { return new(localizingWriter) }}
+
+// localizingWriter buffers JSON bodies so the envelope can be rewritten after the
+// handler ran; any other content type is passed straight through
+type localizingWriter struct {
+	gin.ResponseWriter
+	buf         bytes.Buffer
+	decided     bool
+	passthrough bool
+}
+
+// release resets w and returns it to writerPool
+func (w *localizingWriter) release() {
+	if w.buf.Cap() > maxPooledBuffer {
+		return
+	}
+	w.ResponseWriter = nil
+	w.buf.Reset()
+	w.decided, w.passthrough = false, false
+	writerPool.Put(w)
+}
+
+func (w *localizingWriter) decide() {
+	if w.decided {
+		return
+	}
+	w.decided = true
+	w.passthrough = !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json")
+}
+
+func (w *localizingWriter) Write(b []byte) (int, error) {
+	w.decide()
+	if w.passthrough {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.buf.Write(b)
+}
+
+func (w *localizingWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush sends what is buffered and passes the rest of the body straight through: a
+// streamed response cannot be rewritten once part of it is sent, so it is not localized
+func (w *localizingWriter) Flush() {
+	w.decide()
+	if !w.passthrough {
+		w.passthrough = true
+		if w.buf.Len() > 0 {
+			_, _ = w.ResponseWriter.Write(w.buf.Bytes())
+			w.buf.Reset()
+		}
+	}
+	w.ResponseWriter.Flush()
+}
+
+// Written reports buffered bodies as written so later handlers do not write twice
+func (w *localizingWriter) Written() bool {
+	return w.buf.Len() > 0 || w.ResponseWriter.Written()
+}
+
+// flush writes the buffered body, localized when a zone is known
+func (w *localizingWriter) flush(loc *time.Location, convert bool) {
+	if w.passthrough || w.buf.Len() == 0 {
+		return
+	}
+	body := w.buf.Bytes()
+	if loc != nil {
+		if localized, ok := localizeEnvelope(body, loc, convert); ok {
+			body = localized
+		}
+	}
+	_, _ = w.ResponseWriter.Write(body)
+}
+
+// localizeEnvelope adds the zone to a JSON object envelope and optionally converts its
+// timestamps; it reports false when the body is not a JSON object
+func localizeEnvelope(body []byte, loc *time.Location, convert bool) ([]byte, bool) {
+	if !convert {
+		if out, ok := appendZone(body, loc); ok {
+			return out, true
+		}
+	}
+
+	var envelope map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&envelope); err != nil {
+		return nil, false
+	}
+
+	now := time.Now().In(loc)
+	envelope["timezone"] = loc.String()
+	envelope["utc_offset"] = now.Format("-07:00")
+	if convert {
+		if ts, ok := envelope["timestamp"].(string); ok {
+			envelope["timestamp"] = convertTimestamp(ts, loc)
+		}
+		if data, ok := envelope["data"]; ok {
+			envelope["data"] = convertTimestamps(data, loc)
+		}
+	}
+
+	out, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, false
+	}
+	return out, true
+}
+
+// appendZone adds "timezone" and "utc_offset" to the end of a JSON object without decoding
+// it, which is most of the cost of localizing. It reports false when body is not an
+// object or may already have a "timezone" key.
+func appendZone(body []byte, loc *time.Location) ([]byte, bool) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) < 2 || trimmed[0] != '{' || trimmed[len(trimmed)-1] != '}' ||
+		bytes.Contains(trimmed, []byte(`"timezone"`)) || !json.Valid(trimmed) {
+		return nil, false
+	}
+	name, err := json.Marshal(loc.String())
+	if err != nil {
+		return nil, false
+	}
+
+	inner := trimmed[:len(trimmed)-1]
+	out := make([]byte, 0, len(trimmed)+len(name)+40)
+	out = append(out, inner...)
+	if len(bytes.TrimSpace(inner[1:])) > 0 {
+		out = append(out, ',')
+	}
+	out = append(out, `"timezone":`...)
+	out = append(out, name...)
+	out = append(out, `,"utc_offset":"`...)
+	out = time.Now().In(loc).AppendFormat(out, "-07:00")
+	out = append(out, `"}`...)
+	return out, true
+}
+
+// convertTimestamps rewrites RFC3339 values of "*_at" keys anywhere in v
+func convertTimestamps(v interface{}, loc *time.Location) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, inner := range value {
+			if s, ok := inner.(string); ok && strings.HasSuffix(key, "_at") {
+				value[key] = convertTimestamp(s, loc)
+				continue
+			}
+			value[key] = convertTimestamps(inner, loc)
+		}
+		return value
+	case []interface{}:
+		for i, inner := range value {
+			value[i] = convertTimestamps(inner, loc)
+		}
+		return value
+	default:
+		return v
+	}
+}
+
+func convertTimestamp(s string, loc *time.Location) string {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return s
+	}
+	return t.In(loc).Format(time.RFC3339Nano)
+}
@@ -0,0 +1,187 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/locale"
+
+	"github.com/gin-gonic/gin"
+)
+
+func localizationRouter(mode string, seen *string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(LocalizationMiddleware(config.LocalizationConfig{DefaultLocale: "en", TimestampMode: mode}))
+	r.GET("/", func(c *gin.Context) {
+		*seen = locale.Locale(c.Request.Context())
+		c.JSON(http.StatusOK, gin.H{
+			"success":   true,
+			"timestamp": "2024-01-15T12:00:00Z",
+			"data":      []gin.H{{"created_at": "2024-01-15T12:00:00Z", "name": "jane"}},
+		})
+	})
+	return r
+}
+
+func TestLocalizationMiddleware_LocalMode(t *testing.T) {
+	// Arrange
+	var seen string
+	r := localizationRouter("local", &seen)
+	req := httptest.NewRequest(http.MethodGet, "/?tz=Asia/Tokyo", nil)
+	req.Header.Set("Accept-Language", "de-DE,de;q=0.9")
+	w := httptest.NewRecorder()
+
+	// Act
+	r.ServeHTTP(w, req)
+
+	// Assert
+	var body struct {
+		Timezone  string `json:"timezone"`
+		UTCOffset string `json:"utc_offset"`
+		Timestamp string `json:"timestamp"`
+		Data      []struct {
+			CreatedAt string `json:"created_at"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON response: %v", err)
+	}
+	if body.Timezone != "Asia/Tokyo" || body.UTCOffset != "+09:00" {
+		t.Errorf("zone = %s %s, want Asia/Tokyo +09:00", body.Timezone, body.UTCOffset)
+	}
+	if body.Timestamp != "2024-01-15T21:00:00+09:00" || body.Data[0].CreatedAt != "2024-01-15T21:00:00+09:00" {
+		t.Errorf("timestamps not converted: %s, %s", body.Timestamp, body.Data[0].CreatedAt)
+	}
+	if seen != "de-DE" {
+		t.Errorf("locale = %q, want de-DE from Accept-Language", seen)
+	}
+}
+
+func TestLocalizationMiddleware_UTCModeKeepsTimestamps(t *testing.T) {
+	// Arrange
+	var seen string
+	r := localizationRouter("utc", &seen)
+	req := httptest.NewRequest(http.MethodGet, "/?tz=America/New_York", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	r.ServeHTTP(w, req)
+
+	// Assert
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON response: %v", err)
+	}
+	if body["timestamp"] != "2024-01-15T12:00:00Z" {
+		t.Errorf("timestamp = %v, want it unchanged", body["timestamp"])
+	}
+	loc, _ := time.LoadLocation("America/New_York")
+	if want := time.Now().In(loc).Format("-07:00"); body["utc_offset"] != want {
+		t.Errorf("utc_offset = %v, want %s", body["utc_offset"], want)
+	}
+	if seen != "en" {
+		t.Errorf("locale = %q, want the default", seen)
+	}
+}
+
+func TestLocalizationMiddleware_InvalidZone(t *testing.T) {
+	// Arrange
+	var seen string
+	r := localizationRouter("utc", &seen)
+	req := httptest.NewRequest(http.MethodGet, "/?tz=Mars/Olympus", nil)
+	w := httptest.NewRecorder()
+
+	// Act
+	r.ServeHTTP(w, req)
+
+	// Assert
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", w.Code)
+	}
+}
+
+func TestLocalizationMiddleware_NoZoneLeavesBody(t *testing.T) {
+	// Arrange
+	var seen string
+	r := localizationRouter("local", &seen)
+	w := httptest.NewRecorder()
+
+	// Act
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	// Assert
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON response: %v", err)
+	}
+	if _, ok := body["timezone"]; ok {
+		t.Error("timezone added without a requested or saved zone")
+	}
+}
+
+func TestLocalizationMiddleware_FlushPassesThrough(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(LocalizationMiddleware(config.LocalizationConfig{DefaultLocale: "en", TimestampMode: "local"}))
+	r.GET("/", func(c *gin.Context) {
+		c.Header("Content-Type", "application/json")
+		_, _ = c.Writer.WriteString(`{"data":[{"created_at":"2024-01-15T12:00:00Z"}`)
+		c.Writer.Flush()
+		_, _ = c.Writer.WriteString(`]}`)
+	})
+	w := httptest.NewRecorder()
+
+	// Act
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?tz=Asia/Tokyo", nil))
+
+	// Assert
+	if !w.Flushed {
+		t.Error("Flush did not reach the underlying writer")
+	}
+	if want := `{"data":[{"created_at":"2024-01-15T12:00:00Z"}]}`; w.Body.String() != want {
+		t.Errorf("body = %s, want the streamed body unchanged", w.Body.String())
+	}
+}
+
+func TestAppendZone(t *testing.T) {
+	loc, _ := time.LoadLocation("UTC")
+	cases := []struct {
+		name string
+		body string
+		ok   bool
+	}{
+		{"object", `{"data":{"id":1}}`, true},
+		{"empty object", "{}\n", true},
+		{"array", `[1,2]`, false},
+		{"invalid", `{"data":}`, false},
+		{"already zoned", `{"timezone":"UTC"}`, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			out, ok := appendZone([]byte(tc.body), loc)
+
+			// Assert
+			if ok != tc.ok {
+				t.Fatalf("appendZone(%s) ok = %v, want %v", tc.body, ok, tc.ok)
+			}
+			if !ok {
+				return
+			}
+			var body map[string]interface{}
+			if err := json.Unmarshal(out, &body); err != nil {
+				t.Fatalf("appendZone(%s) = %s, invalid JSON: %v", tc.body, out, err)
+			}
+			if body["timezone"] != "UTC" || body["utc_offset"] != "+00:00" {
+				t.Errorf("appendZone(%s) = %s", tc.body, out)
+			}
+		})
+	}
+}
@@ -14,3 +14,13 @@ func RateLimitMiddleware() gin.HandlerFunc {
 	instance := limiter.New(store, rate)
 	return ginmiddleware.NewMiddleware(instance)
 }
+
+// RateLimitByIP limits each client IP to rate, formatted like "10-M" for 10 requests a
+// minute, on the routes it is added to. Each call counts separately.
+func RateLimitByIP(rate string) (gin.HandlerFunc, error) {
+	parsed, err := limiter.NewRateFromFormatted(rate)
+	if err != nil {
+		return nil, err
+	}
+	return ginmiddleware.NewMiddleware(limiter.New(memory.NewStore(), parsed)), nil
+}
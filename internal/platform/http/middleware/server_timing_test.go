@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/timing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func timingRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(
+		ServerTimingMiddleware(zap.NewNop().Sugar()),
+		LocalizationMiddleware(config.LocalizationConfig{DefaultLocale: "en", TimestampMode: "utc"}),
+	)
+	r.GET("/json", func(c *gin.Context) {
+		ctx := c.Request.Context()
+		timing.FromContext(ctx).Add("db", 3*time.Millisecond)
+		timing.FromContext(ctx).Add("db", 2*time.Millisecond)
+		done := timing.Start(ctx, "storage")
+		done()
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+	r.DELETE("/empty", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	return r
+}
+
+func TestServerTimingMiddleware(t *testing.T) {
+	// Arrange
+	r := timingRouter()
+	w := httptest.NewRecorder()
+
+	// Act
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/json", nil))
+
+	// Assert
+	header := w.Header().Get("Server-Timing")
+	if !strings.HasPrefix(header, `db;dur=5.0;desc="2 calls", storage;dur=`) {
+		t.Errorf("Server-Timing = %q, want db and storage segments first", header)
+	}
+	if !regexp.MustCompile(`app;dur=[0-9.]+, total;dur=[0-9.]+, alloc;desc="\d+ KB"$`).MatchString(header) {
+		t.Errorf("Server-Timing = %q, want app, total and alloc", header)
+	}
+	if w.Body.String() != `{"ok":true}` {
+		t.Errorf("body = %s", w.Body.String())
+	}
+}
+
+func TestServerTimingMiddleware_NoBody(t *testing.T) {
+	// Arrange
+	r := timingRouter()
+	w := httptest.NewRecorder()
+
+	// Act
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/empty", nil))
+
+	// Assert
+	if w.Code != http.StatusNoContent {
+		t.Errorf("status = %d, want 204", w.Code)
+	}
+	if header := w.Header().Get("Server-Timing"); !strings.HasPrefix(header, "app;dur=") {
+		t.Errorf("Server-Timing = %q, want it on responses without a body", header)
+	}
+}
@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/service"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/metrics"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/clock"
+)
+
+var tokenCacheLookups = metrics.NewCounter("jwt_token_cache_lookups_total",
+	"Access token validations served from the token cache (hit) or parsed (miss).", "result")
+
+type tokenCacheEntry struct {
+	key       [sha256.Size]byte
+	claims    *service.Claims
+	expiresAt time.Time
+}
+
+// TokenCache remembers the claims of validated access tokens so JWTAuth skips parsing and
+// verifying the signature of tokens it has seen recently. Entries are keyed by a hash of
+// the token, live at most the cache TTL and never past the token's exp, and the least
+// recently used entry is dropped when the cache is full. A nil TokenCache caches nothing.
+//
+// Only token validation is cached; the account check still runs on every request.
+type TokenCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List
+	entries map[[sha256.Size]byte]*list.Element
+	clock   clock.Clock
+}
+
+// NewTokenCache returns a cache of up to size tokens, each kept for at most ttl, or nil
+// when size is 0 or less
+func NewTokenCache(size int, ttl time.Duration) *TokenCache {
+	if size <= 0 {
+		return nil
+	}
+	return &TokenCache{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[[sha256.Size]byte]*list.Element, size),
+		clock:   clock.System(),
+	}
+}
+
+// SetClock replaces the clock used for expiry
+func (t *TokenCache) SetClock(c clock.Clock) {
+	t.clock = c
+}
+
+// WithTokenCache makes JWTAuth look validated tokens up in cache before parsing them
+func WithTokenCache(cache *TokenCache) AuthOption {
+	return func(o *authOptions) {
+		o.tokenCache = cache
+	}
+}
+
+func (t *TokenCache) get(token string) (*service.Claims, bool) {
+	if t == nil {
+		return nil, false
+	}
+	key := sha256.Sum256([]byte(token))
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	elem, ok := t.entries[key]
+	if !ok {
+		tokenCacheLookups.Inc("miss")
+		return nil, false
+	}
+	entry := elem.Value.(*tokenCacheEntry)
+	if !entry.expiresAt.After(t.clock.Now()) {
+		t.order.Remove(elem)
+		delete(t.entries, key)
+		tokenCacheLookups.Inc("miss")
+		return nil, false
+	}
+	t.order.MoveToFront(elem)
+	tokenCacheLookups.Inc("hit")
+	return entry.claims, true
+}
+
+func (t *TokenCache) add(token string, claims *service.Claims) {
+	if t == nil {
+		return
+	}
+	expiresAt := t.clock.Now().Add(t.ttl)
+	if claims.ExpiresAt != nil && claims.ExpiresAt.Time.Before(expiresAt) {
+		expiresAt = claims.ExpiresAt.Time
+	}
+	key := sha256.Sum256([]byte(token))
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if elem, ok := t.entries[key]; ok {
+		elem.Value = &tokenCacheEntry{key: key, claims: claims, expiresAt: expiresAt}
+		t.order.MoveToFront(elem)
+		return
+	}
+	if t.order.Len() >= t.size {
+		oldest := t.order.Back()
+		t.order.Remove(oldest)
+		delete(t.entries, oldest.Value.(*tokenCacheEntry).key)
+	}
+	t.entries[key] = t.order.PushFront(&tokenCacheEntry{key: key, claims: claims, expiresAt: expiresAt})
+}
+
+// InvalidateUser drops the cached tokens of userID, so their next request is validated
+// again. Register it as a hook wherever a user is signed out everywhere.
+func (t *TokenCache) InvalidateUser(userID string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for elem := t.order.Front(); elem != nil; {
+		next := elem.Next()
+		if entry := elem.Value.(*tokenCacheEntry); entry.claims.UserID.String() == userID {
+			t.order.Remove(elem)
+			delete(t.entries, entry.key)
+		}
+		elem = next
+	}
+}
+
+// Purge drops every cached token, e.g. after the signing keys changed
+func (t *TokenCache) Purge() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.order.Init()
+	t.entries = make(map[[sha256.Size]byte]*list.Element, t.size)
+	t.mu.Unlock()
+}
+
+// Len returns how many tokens are cached
+func (t *TokenCache) Len() int {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.order.Len()
+}
@@ -0,0 +1,255 @@
+// Package jobs runs named background jobs on a fixed interval and on demand, and keeps
+// the outcome of their last run so operators can see what happened.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/shared/clock"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrUnknownJob is returned when no job with the given name is registered
+	ErrUnknownJob = errors.New("unknown job")
+	// ErrJobRunning is returned when a job is triggered while a run is still in progress
+	ErrJobRunning = errors.New("job is already running")
+)
+
+// Run results reported in Status.LastResult
+const (
+	ResultSuccess = "success"
+	ResultFailed  = "failed"
+)
+
+// Run triggers reported in Status.LastTrigger
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+// Job is a unit of background work
+type Job struct {
+	// Name identifies the job in the admin API, e.g. "token-cleanup"
+	Name string
+	// Interval between scheduled runs; zero means the job only runs when triggered
+	Interval time.Duration
+	// Timeout bounds a single run; zero means no limit
+	Timeout time.Duration
+	// Run does the work and should stop when ctx is cancelled
+	Run func(ctx context.Context) error
+}
+
+// Status describes a registered job and its most recent run on this instance
+type Status struct {
+	Name         string     `json:"name"`
+	Interval     string     `json:"interval,omitempty"`
+	Running      bool       `json:"running"`
+	Runs         int        `json:"runs"`
+	Failures     int        `json:"failures"`
+	LastStarted  *time.Time `json:"last_started,omitempty"`
+	LastDuration string     `json:"last_duration,omitempty"`
+	LastResult   string     `json:"last_result,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+	LastTrigger  string     `json:"last_trigger,omitempty"`
+	NextRun      *time.Time `json:"next_run,omitempty"`
+}
+
+type entry struct {
+	job    Job
+	status Status
+}
+
+// Scheduler runs registered jobs. Every instance runs its own schedule, so jobs must be
+// safe to run concurrently on several replicas. A job never overlaps with itself on
+// one instance: ticks that arrive while it is running are skipped.
+type Scheduler struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+	order   []string
+	log     *zap.SugaredLogger
+	clock   clock.Clock
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	started bool
+}
+
+func NewScheduler(log *zap.SugaredLogger) *Scheduler {
+	if log == nil {
+		log = zap.NewNop().Sugar()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		entries: make(map[string]*entry),
+		log:     log,
+		clock:   clock.System(),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// SetClock replaces the time source used for run timestamps and durations
+func (s *Scheduler) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// Register adds a job. Jobs registered after Start are scheduled immediately.
+func (s *Scheduler) Register(job Job) error {
+	if job.Name == "" || job.Run == nil {
+		return fmt.Errorf("job needs a name and a run function")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.entries[job.Name]; ok {
+		return fmt.Errorf("job %q is already registered", job.Name)
+	}
+	e := &entry{job: job, status: Status{Name: job.Name}}
+	if job.Interval > 0 {
+		e.status.Interval = job.Interval.String()
+	}
+	s.entries[job.Name] = e
+	s.order = append(s.order, job.Name)
+	if s.started {
+		s.schedule(e)
+	}
+	return nil
+}
+
+// Start begins running jobs on their intervals. The first run of each job happens one
+// interval after Start.
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return
+	}
+	s.started = true
+	for _, name := range s.order {
+		s.schedule(s.entries[name])
+	}
+	s.log.Infof("Job scheduler started with %d job(s)", len(s.order))
+}
+
+// Stop cancels running jobs and waits for them to return
+func (s *Scheduler) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// schedule starts the ticker goroutine of a job; callers hold s.mu
+func (s *Scheduler) schedule(e *entry) {
+	if e.job.Interval <= 0 {
+		return
+	}
+	next := s.clock.Now().Add(e.job.Interval)
+	e.status.NextRun = &next
+
+	ticker := time.NewTicker(e.job.Interval)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case tick := <-ticker.C:
+				s.mu.Lock()
+				next := tick.Add(e.job.Interval)
+				e.status.NextRun = &next
+				s.mu.Unlock()
+				if err := s.trigger(e.job.Name, TriggerSchedule); err != nil && !errors.Is(err, ErrJobRunning) {
+					s.log.Warnw("Scheduled job not started", "job", e.job.Name, "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// Run starts a job now and returns without waiting for it to finish
+func (s *Scheduler) Run(name string) error {
+	return s.trigger(name, TriggerManual)
+}
+
+func (s *Scheduler) trigger(name, trigger string) error {
+	s.mu.Lock()
+	e, ok := s.entries[name]
+	if !ok {
+		s.mu.Unlock()
+		return ErrUnknownJob
+	}
+	if e.status.Running {
+		s.mu.Unlock()
+		return ErrJobRunning
+	}
+	started := s.clock.Now()
+	e.status.Running = true
+	e.status.LastStarted = &started
+	e.status.LastTrigger = trigger
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	go func() {
+		defer s.wg.Done()
+		s.execute(e, trigger, started)
+	}()
+	return nil
+}
+
+// execute runs a job once and records the outcome
+func (s *Scheduler) execute(e *entry, trigger string, started time.Time) {
+	var err error
+	ctx := s.ctx
+	if e.job.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.job.Timeout)
+		defer cancel()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+		s.finish(e, started, err)
+	}()
+
+	s.log.Infow("Job started", "job", e.job.Name, "trigger", trigger)
+	err = e.job.Run(ctx)
+}
+
+func (s *Scheduler) finish(e *entry, started time.Time, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	duration := s.clock.Now().Sub(started)
+	e.status.Running = false
+	e.status.Runs++
+	e.status.LastDuration = duration.String()
+	e.status.LastResult = ResultSuccess
+	e.status.LastError = ""
+	if err != nil {
+		e.status.Failures++
+		e.status.LastResult = ResultFailed
+		e.status.LastError = err.Error()
+		s.log.Errorw("Job failed", "job", e.job.Name, "duration", duration, "error", err)
+	} else {
+		s.log.Infow("Job finished", "job", e.job.Name, "duration", duration)
+	}
+}
+
+// List returns the status of every job in registration order
+func (s *Scheduler) List() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	statuses := make([]Status, 0, len(s.order))
+	for _, name := range s.order {
+		statuses = append(statuses, s.entries[name].status)
+	}
+	return statuses
+}
@@ -1,7 +1,8 @@
 package logger
 
 import (
-	"go_platform_template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"io"
 	"os"
 	"strings"
 
@@ -20,11 +21,13 @@ type Logger struct {
 // It supports:
 //   - JSON structured logs
 //   - Log level from config (debug, info, warn, error)
-//   - Console + file logging
-//   - File rotation via lumberjack
+//   - Output to stdout, stderr and/or a file (LOG_OUTPUT)
+//   - File rotation via lumberjack, paused while the log volume is nearly full
 func InitLogger() *Logger {
-	cfg := config.GetConfig() // Load already initialized config
+	return newLogger(config.GetConfig()) // Load already initialized config
+}
 
+func newLogger(cfg *config.Config) *Logger {
 	// Determine log level
 	var zapLevel zapcore.Level
 	switch strings.ToLower(cfg.LogLevel) {
@@ -40,15 +43,6 @@ func InitLogger() *Logger {
 		zapLevel = zapcore.InfoLevel
 	}
 
-	// Lumberjack log rotation
-	lumberjackLogger := &lumberjack.Logger{
-		Filename:   "logs/app.log", // log file path
-		MaxSize:    50,             // megabytes
-		MaxBackups: 7,              // number of old log files to keep
-		MaxAge:     30,             // days
-		Compress:   true,           // compress old log files
-	}
-
 	// JSON encoder config
 	encoderConfig := zap.NewProductionEncoderConfig()
 	encoderConfig.TimeKey = "timestamp"
@@ -58,16 +52,41 @@ func InitLogger() *Logger {
 	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
 	encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
 	encoderConfig.EncodeCaller = zapcore.ShortCallerEncoder
+	encoder := zapcore.NewJSONEncoder(encoderConfig)
 
-	// Write logs to both console and file
-	writeSyncer := zapcore.NewMultiWriteSyncer(
-		zapcore.AddSync(os.Stdout),
-		zapcore.AddSync(lumberjackLogger),
-	)
+	var streams []zapcore.WriteSyncer
+	if cfg.Log.HasOutput("stdout") {
+		streams = append(streams, zapcore.AddSync(os.Stdout))
+	}
+	if cfg.Log.HasOutput("stderr") {
+		streams = append(streams, zapcore.AddSync(os.Stderr))
+	}
+	writers := streams
+
+	if cfg.Log.HasOutput("file") {
+		// Lumberjack log rotation
+		var file io.Writer = &lumberjack.Logger{
+			Filename:   cfg.Log.FilePath,
+			MaxSize:    cfg.Log.MaxSizeMB, // megabytes
+			MaxBackups: cfg.Log.MaxBackups,
+			MaxAge:     cfg.Log.MaxAgeDays, // days
+			Compress:   cfg.Log.Compress,
+		}
+		if cfg.Log.MinFreePercent > 0 {
+			// The guard reports pausing on the streams only, or stderr when logs go nowhere else
+			noticeTo := zapcore.NewMultiWriteSyncer(streams...)
+			if len(streams) == 0 {
+				noticeTo = zapcore.AddSync(os.Stderr)
+			}
+			notice := zap.New(zapcore.NewCore(encoder, noticeTo, zapcore.InfoLevel)).Sugar()
+			file = newDiskGuard(file, cfg.Log.FilePath, cfg.Log.MinFreePercent, notice)
+		}
+		writers = append(writers, zapcore.AddSync(file))
+	}
 
 	core := zapcore.NewCore(
-		zapcore.NewJSONEncoder(encoderConfig),
-		writeSyncer,
+		encoder,
+		zapcore.NewMultiWriteSyncer(writers...),
 		zapLevel,
 	)
 
@@ -0,0 +1,67 @@
+package quota
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+
+	"go.uber.org/zap"
+)
+
+func newTestLimiter(cfg config.QuotaConfig) (*Limiter, *testutil.FakeClock) {
+	clk := testutil.NewFakeClock(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+	store := NewMemoryStore()
+	store.SetClock(clk)
+	limiter := NewLimiter(store, cfg, zap.NewNop().Sugar())
+	limiter.SetClock(clk)
+	return limiter, clk
+}
+
+func TestLimiter_Count(t *testing.T) {
+	// Arrange
+	limiter, clk := newTestLimiter(config.QuotaConfig{
+		Daily:   map[string]int64{"user": 2},
+		Monthly: map[string]int64{"*": 3},
+	})
+	ctx := context.Background()
+
+	// Act
+	first := limiter.Count(ctx, "u1", "user")
+	limiter.Count(ctx, "u1", "user")
+	overDay := limiter.Count(ctx, "u1", "user")
+	other := limiter.Count(ctx, "u2", "partner")
+	clk.Advance(24 * time.Hour)
+	overMonth := limiter.Count(ctx, "u1", "user")
+
+	// Assert
+	if first == nil || first.Period != Day || first.Remaining() != 1 {
+		t.Errorf("first Count() = %+v, want the daily quota with 1 left", first)
+	}
+	if overDay == nil || !overDay.Exceeded() || overDay.Period != Day {
+		t.Errorf("third Count() = %+v, want the exceeded daily quota", overDay)
+	}
+	if other == nil || other.Limit != 3 || other.Period != Month {
+		t.Errorf("Count() for a role without its own quota = %+v, want the * monthly quota", other)
+	}
+	if overMonth == nil || !overMonth.Exceeded() || overMonth.Period != Month || !overMonth.Reset.Equal(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Count() the next day = %+v, want the exceeded monthly quota resetting on February 1", overMonth)
+	}
+}
+
+func TestLimiter_NilAndUnlimited(t *testing.T) {
+	// Arrange
+	var disabled *Limiter
+	limiter, _ := newTestLimiter(config.QuotaConfig{Daily: map[string]int64{"user": 1}})
+
+	// Act
+	fromNil := disabled.Count(context.Background(), "u1", "user")
+	unlimited := limiter.Count(context.Background(), "u1", "admin")
+
+	// Assert
+	if fromNil != nil || unlimited != nil {
+		t.Errorf("Count() = %+v, %+v; want nil for a nil Limiter and a role without a quota", fromNil, unlimited)
+	}
+}
@@ -0,0 +1,95 @@
+package risk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+)
+
+// CaptchaVerifier checks a CAPTCHA response token from the client
+type CaptchaVerifier interface {
+	Verify(ctx context.Context, token, remoteIP string) (bool, error)
+}
+
+// siteVerifyURLs are the verification endpoints of the supported providers, which share
+// the same request and response format
+var siteVerifyURLs = map[string]string{
+	"turnstile": "https://challenges.cloudflare.com/turnstile/v0/siteverify",
+	"hcaptcha":  "https://api.hcaptcha.com/siteverify",
+	"recaptcha": "https://www.google.com/recaptcha/api/siteverify",
+}
+
+// NewCaptchaVerifier builds the verifier selected by CAPTCHA_PROVIDER.
+// It returns nil (and no error) when no provider is configured.
+func NewCaptchaVerifier(cfg config.RiskConfig) (CaptchaVerifier, error) {
+	provider := strings.ToLower(cfg.CaptchaProvider)
+	if provider == "" || provider == "none" {
+		return nil, nil
+	}
+	endpoint, ok := siteVerifyURLs[provider]
+	if !ok {
+		return nil, fmt.Errorf("unknown CAPTCHA provider %q", cfg.CaptchaProvider)
+	}
+	if cfg.CaptchaSecret == "" {
+		return nil, errors.New("CAPTCHA provider requires CAPTCHA_SECRET")
+	}
+	return NewSiteVerifier(endpoint, cfg.CaptchaSecret), nil
+}
+
+// SiteVerifier posts tokens to a siteverify endpoint, as used by Turnstile, hCaptcha
+// and reCAPTCHA
+type SiteVerifier struct {
+	endpoint string
+	secret   string
+	client   *http.Client
+}
+
+func NewSiteVerifier(endpoint, secret string) *SiteVerifier {
+	return &SiteVerifier{
+		endpoint: endpoint,
+		secret:   secret,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (v *SiteVerifier) Verify(ctx context.Context, token, remoteIP string) (bool, error) {
+	form := url.Values{
+		"secret":   {v.secret},
+		"response": {token},
+	}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("captcha verification request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return false, fmt.Errorf("captcha verification returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	var result struct {
+		Success bool `json:"success"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&result); err != nil {
+		return false, fmt.Errorf("invalid captcha verification response: %w", err)
+	}
+	return result.Success, nil
+}
@@ -0,0 +1,256 @@
+// Package risk scores registrations and logins for signs of automated abuse. Rules such
+// as an IP denylist, per-IP velocity and disposable email domains each add to a score,
+// and the Engine turns the total into an outcome: allow, flag the account for review,
+// require a CAPTCHA, or reject the attempt. Further rules, for example a call to an IP
+// reputation service, plug in by implementing Rule.
+package risk
+
+import (
+	"context"
+	"strings"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+
+	"go.uber.org/zap"
+)
+
+// Kind is the public endpoint an attempt was made on
+type Kind string
+
+const (
+	KindRegister Kind = "register"
+	KindLogin    Kind = "login"
+)
+
+// Attempt is a registration or login to score
+type Attempt struct {
+	Kind Kind
+	IP   string
+	// Email is the address being registered; it is empty on login
+	Email string
+}
+
+// Signal is a rule's finding about an attempt
+type Signal struct {
+	Rule   string
+	Score  int
+	Reason string
+}
+
+// Rule scores one aspect of an attempt. It returns a zero Signal when it has nothing to
+// report. Rules are called for every attempt, in order, and must be safe for concurrent use.
+type Rule interface {
+	Evaluate(ctx context.Context, a Attempt) (Signal, error)
+}
+
+// Action is the outcome of an assessment
+type Action string
+
+const (
+	Allow Action = "allow"
+	// Flag lets the attempt through and marks the account for review
+	Flag Action = "flag"
+	// Captcha rejects the attempt unless it carries a solved CAPTCHA
+	Captcha Action = "captcha"
+	// Block rejects the attempt
+	Block Action = "block"
+)
+
+// Assessment is the combined result of all rules for an attempt
+type Assessment struct {
+	Score   int
+	Signals []Signal
+	Action  Action
+}
+
+// maxReasonLength is the size of the users.review_reason column
+const maxReasonLength = 500
+
+// Reason summarizes the signals, e.g. "disposable_email: mailinator.com is a disposable email domain"
+func (a Assessment) Reason() string {
+	reasons := make([]string, 0, len(a.Signals))
+	for _, s := range a.Signals {
+		reasons = append(reasons, s.Rule+": "+s.Reason)
+	}
+	reason := strings.Join(reasons, "; ")
+	if len(reason) > maxReasonLength {
+		reason = strings.ToValidUTF8(reason[:maxReasonLength], "")
+	}
+	return reason
+}
+
+// Engine runs the rules for an attempt and enforces the outcome
+type Engine struct {
+	cfg     config.RiskConfig
+	rules   []Rule
+	captcha CaptchaVerifier
+	logger  *zap.SugaredLogger
+}
+
+// NewEngine returns an Engine that scores attempts with rules against the thresholds in
+// cfg. captcha may be nil, in which case attempts that would need a CAPTCHA are flagged.
+func NewEngine(cfg config.RiskConfig, captcha CaptchaVerifier, logger *zap.SugaredLogger, rules ...Rule) *Engine {
+	if logger == nil {
+		logger = zap.NewNop().Sugar()
+	}
+	return &Engine{cfg: cfg, rules: rules, captcha: captcha, logger: logger}
+}
+
+// NewDefaultEngine returns an Engine with the built-in rules configured by cfg, scoring
+// email domains in domains, or nil when risk scoring is disabled
+func NewDefaultEngine(cfg config.RiskConfig, domains *DisposableDomains, logger *zap.SugaredLogger) (*Engine, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	denylist, err := NewIPListRule(cfg.IPDenylist, cfg.DenylistScore)
+	if err != nil {
+		return nil, err
+	}
+	captcha, err := NewCaptchaVerifier(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewEngine(cfg, captcha, logger,
+		denylist,
+		NewVelocityRule(cfg.VelocityWindow, map[Kind]int{KindRegister: cfg.RegisterPerIP, KindLogin: cfg.LoginPerIP}, cfg.VelocityScore),
+		NewDisposableEmailRule(domains, cfg.DisposableEmailScore),
+	), nil
+}
+
+// Check scores an attempt and returns an error when it must not proceed: a
+// ForbiddenError with "captcha_required" details when a CAPTCHA is needed and ctx
+// carries no valid token (see WithCaptchaToken), or a ForbiddenError when it is blocked.
+// Otherwise the assessment's Action is Allow or Flag, and the caller flags the account.
+// A nil Engine allows everything. Rule errors are logged and the rule skipped, so an
+// unavailable reputation service does not stop signups.
+func (e *Engine) Check(ctx context.Context, a Attempt) (Assessment, error) {
+	if e == nil {
+		return Assessment{Action: Allow}, nil
+	}
+
+	var assessment Assessment
+	for _, rule := range e.rules {
+		signal, err := rule.Evaluate(ctx, a)
+		if err != nil {
+			e.logger.Errorw("risk rule failed", "kind", a.Kind, "ip", a.IP, "error", err)
+			continue
+		}
+		if signal.Score != 0 {
+			assessment.Score += signal.Score
+			assessment.Signals = append(assessment.Signals, signal)
+		}
+	}
+	assessment.Action = e.action(assessment.Score)
+
+	var err error
+	solved := false
+	switch assessment.Action {
+	case Block:
+		err = apperrors.NewAppErrorWithDetails(
+			apperrors.ForbiddenError,
+			"Request blocked",
+			"blocked: this request looks automated; try again later or contact support",
+		)
+	case Captcha:
+		// Providers accept a token only once, so one already checked by VerifyCaptcha counts
+		solved = captchaSolved(ctx)
+		if !solved && e.captcha == nil {
+			assessment.Action = Flag
+			break
+		}
+		if token := CaptchaToken(ctx); !solved && token != "" {
+			var verifyErr error
+			solved, verifyErr = e.captcha.Verify(ctx, token, a.IP)
+			if verifyErr != nil {
+				e.logger.Errorw("captcha verification failed", "kind", a.Kind, "ip", a.IP, "error", verifyErr)
+			}
+		}
+		if !solved {
+			err = errCaptchaRequired()
+			break
+		}
+		// A solved CAPTCHA proves a human, but the account is still worth a look
+		assessment.Action = Flag
+	}
+
+	if assessment.Score > 0 {
+		e.logger.Warnw("risky attempt",
+			"kind", a.Kind,
+			"ip", a.IP,
+			"score", assessment.Score,
+			"action", assessment.Action,
+			"captcha_solved", solved,
+			"reason", assessment.Reason(),
+		)
+	}
+	return assessment, err
+}
+
+// action maps a score to the strictest outcome whose threshold it reaches
+func (e *Engine) action(score int) Action {
+	switch {
+	case reached(score, e.cfg.BlockScore):
+		return Block
+	case reached(score, e.cfg.CaptchaScore):
+		return Captcha
+	case reached(score, e.cfg.FlagScore):
+		return Flag
+	default:
+		return Allow
+	}
+}
+
+func reached(score, threshold int) bool {
+	return threshold > 0 && score >= threshold
+}
+
+// CaptchaHeader is the request header clients send a solved CAPTCHA's token in
+const CaptchaHeader = "X-Captcha-Token"
+
+type captchaTokenKey struct{}
+
+// WithCaptchaToken returns a copy of ctx carrying the client's CAPTCHA response token
+func WithCaptchaToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, captchaTokenKey{}, token)
+}
+
+// CaptchaToken returns the CAPTCHA token recorded by WithCaptchaToken, or ""
+func CaptchaToken(ctx context.Context) string {
+	token, _ := ctx.Value(captchaTokenKey{}).(string)
+	return token
+}
+
+type captchaSolvedKey struct{}
+
+// VerifyCaptcha requires a solved CAPTCHA regardless of score, for endpoints that always
+// want one. It checks the token recorded by WithCaptchaToken with verifier and returns a
+// copy of ctx that Engine.Check accepts without verifying the token again. The error is
+// a ForbiddenError with "captcha_required" details when the token is missing or invalid.
+func VerifyCaptcha(ctx context.Context, verifier CaptchaVerifier, ip string) (context.Context, error) {
+	token := CaptchaToken(ctx)
+	if token == "" {
+		return ctx, errCaptchaRequired()
+	}
+	solved, err := verifier.Verify(ctx, token, ip)
+	if err != nil {
+		return ctx, err
+	}
+	if !solved {
+		return ctx, errCaptchaRequired()
+	}
+	return context.WithValue(ctx, captchaSolvedKey{}, true), nil
+}
+
+func captchaSolved(ctx context.Context) bool {
+	solved, _ := ctx.Value(captchaSolvedKey{}).(bool)
+	return solved
+}
+
+func errCaptchaRequired() error {
+	return apperrors.NewAppErrorWithDetails(
+		apperrors.ForbiddenError,
+		"CAPTCHA required",
+		"captcha_required: solve the challenge and resend the request with its token in the "+CaptchaHeader+" header",
+	)
+}
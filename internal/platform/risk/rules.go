@@ -0,0 +1,140 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/shared/clock"
+)
+
+// IPListRule scores attempts from denylisted addresses and networks
+type IPListRule struct {
+	prefixes []netip.Prefix
+	score    int
+}
+
+// NewIPListRule parses entries such as "203.0.113.7" or "198.51.100.0/24"
+func NewIPListRule(entries []string, score int) (*IPListRule, error) {
+	r := &IPListRule{score: score}
+	for _, entry := range entries {
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(entry)
+			if addrErr != nil {
+				return nil, fmt.Errorf("invalid denylist entry %q: %w", entry, err)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		r.prefixes = append(r.prefixes, prefix.Masked())
+	}
+	return r, nil
+}
+
+func (r *IPListRule) Evaluate(_ context.Context, a Attempt) (Signal, error) {
+	addr, err := netip.ParseAddr(a.IP)
+	if err != nil {
+		return Signal{}, nil
+	}
+	addr = addr.Unmap()
+	for _, prefix := range r.prefixes {
+		if prefix.Contains(addr) {
+			return Signal{Rule: "ip_denylist", Score: r.score, Reason: a.IP + " is in " + prefix.String()}, nil
+		}
+	}
+	return Signal{}, nil
+}
+
+// DisposableEmailRule scores registrations from disposable email domains
+type DisposableEmailRule struct {
+	domains *DisposableDomains
+	score   int
+}
+
+func NewDisposableEmailRule(domains *DisposableDomains, score int) *DisposableEmailRule {
+	return &DisposableEmailRule{domains: domains, score: score}
+}
+
+func (r *DisposableEmailRule) Evaluate(_ context.Context, a Attempt) (Signal, error) {
+	if a.Kind != KindRegister {
+		return Signal{}, nil
+	}
+	if domain, ok := r.domains.Match(a.Email); ok {
+		return Signal{Rule: "disposable_email", Score: r.score, Reason: domain + " is a disposable email domain"}, nil
+	}
+	return Signal{}, nil
+}
+
+// velocitySweepThreshold is how many counters VelocityRule keeps before it clears expired ones
+const velocitySweepThreshold = 10000
+
+type velocityCounter struct {
+	count     int
+	expiresAt time.Time
+}
+
+// VelocityRule scores attempts from an IP beyond a number per window. Counts are kept in
+// memory, so each instance counts separately; back it with a shared store such as Redis
+// (INCR plus EXPIRE on the first increment) when running several.
+type VelocityRule struct {
+	window time.Duration
+	limits map[Kind]int
+	score  int
+
+	mu       sync.Mutex
+	counters map[string]velocityCounter
+	clock    clock.Clock
+}
+
+// NewVelocityRule allows limits[kind] attempts per IP in each window; a kind without a
+// positive limit is not counted
+func NewVelocityRule(window time.Duration, limits map[Kind]int, score int) *VelocityRule {
+	return &VelocityRule{
+		window:   window,
+		limits:   limits,
+		score:    score,
+		counters: make(map[string]velocityCounter),
+		clock:    clock.System(),
+	}
+}
+
+// SetClock replaces the clock used for windows
+func (r *VelocityRule) SetClock(c clock.Clock) {
+	r.clock = c
+}
+
+func (r *VelocityRule) Evaluate(_ context.Context, a Attempt) (Signal, error) {
+	limit := r.limits[a.Kind]
+	if limit <= 0 || a.IP == "" {
+		return Signal{}, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.clock.Now()
+	if len(r.counters) >= velocitySweepThreshold {
+		for k, c := range r.counters {
+			if !c.expiresAt.After(now) {
+				delete(r.counters, k)
+			}
+		}
+	}
+	key := string(a.Kind) + ":" + a.IP
+	c, ok := r.counters[key]
+	if !ok || !c.expiresAt.After(now) {
+		c = velocityCounter{expiresAt: now.Add(r.window)}
+	}
+	c.count++
+	r.counters[key] = c
+
+	if c.count <= limit {
+		return Signal{}, nil
+	}
+	return Signal{
+		Rule:   "velocity",
+		Score:  r.score,
+		Reason: fmt.Sprintf("%d %s attempts from %s within %s", c.count, a.Kind, a.IP, r.window),
+	}, nil
+}
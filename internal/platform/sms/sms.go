@@ -0,0 +1,45 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+
+	"go.uber.org/zap"
+)
+
+// Sender delivers text messages to E.164 phone numbers
+type Sender interface {
+	Send(ctx context.Context, to, message string) error
+}
+
+// NewSender builds the Sender selected by SMS_PROVIDER.
+// It returns nil (and no error) when SMS is disabled for this deployment.
+func NewSender(cfg config.SMSConfig, log *zap.SugaredLogger) (Sender, error) {
+	switch strings.ToLower(cfg.Provider) {
+	case "", "none":
+		return nil, nil
+	case "log":
+		return NewLogSender(log), nil
+	case "twilio":
+		return NewTwilioSender(cfg.TwilioAccountSID, cfg.TwilioAuthToken, cfg.TwilioFromNumber)
+	default:
+		return nil, fmt.Errorf("unknown SMS provider %q", cfg.Provider)
+	}
+}
+
+// LogSender writes messages to the application log instead of sending them (development only)
+type LogSender struct {
+	logger *zap.SugaredLogger
+}
+
+func NewLogSender(logger *zap.SugaredLogger) *LogSender {
+	return &LogSender{logger: logger}
+}
+
+func (s *LogSender) Send(ctx context.Context, to, message string) error {
+	s.logger.Infow("sms message (log provider)", "to", to, "message", message)
+	return nil
+}
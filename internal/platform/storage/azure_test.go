@@ -0,0 +1,205 @@
+package storage
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// fakeBlobService keeps the blobs of one container the way the Blob service does, for
+// requests authorized with the account's Shared Key
+type fakeBlobService struct {
+	mu      sync.Mutex
+	blobs   map[string][]byte
+	headers map[string]http.Header
+	blocks  map[string][]byte
+}
+
+func (f *fakeBlobService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "SharedKey devaccount:") || r.Header.Get("x-ms-version") != azureVersion {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	query := r.URL.Query()
+	if query.Get("restype") == "container" {
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/uploads/")
+	body, _ := io.ReadAll(r.Body)
+	switch {
+	case r.Method == http.MethodPut && query.Get("comp") == "block":
+		f.blocks[query.Get("blockid")] = body
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut && query.Get("comp") == "blocklist":
+		var list struct {
+			Latest []string `xml:"Latest"`
+		}
+		if err := xml.Unmarshal(body, &list); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var content []byte
+		for _, id := range list.Latest {
+			content = append(content, f.blocks[id]...)
+		}
+		f.blobs[name] = content
+		f.headers[name] = http.Header{"Content-Type": {r.Header.Get("x-ms-blob-content-type")}}
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut:
+		if r.Header.Get("x-ms-blob-type") != "BlockBlob" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.blobs[name] = body
+		f.headers[name] = http.Header{"Content-Type": {r.Header.Get("Content-Type")}}
+		for key, values := range r.Header {
+			if strings.HasPrefix(strings.ToLower(key), "x-ms-meta-") {
+				f.headers[name][key] = values
+			}
+		}
+		w.WriteHeader(http.StatusCreated)
+	default:
+		content, ok := f.blobs[name]
+		if !ok {
+			w.Header().Set("x-ms-error-code", "BlobNotFound")
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodDelete {
+			delete(f.blobs, name)
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		for key, values := range f.headers[name] {
+			w.Header()[key] = values
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		_, _ = w.Write(content)
+	}
+}
+
+func newTestAzureStorage(t *testing.T) *AzureStorage {
+	t.Helper()
+	fake := &fakeBlobService{blobs: map[string][]byte{}, headers: map[string]http.Header{}, blocks: map[string][]byte{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	key := base64.StdEncoding.EncodeToString([]byte("account-key"))
+	s, err := NewAzureStorage(context.Background(), "devaccount", key, "uploads", server.URL, zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatalf("NewAzureStorage() error = %v", err)
+	}
+	return s
+}
+
+func TestAzureStorage_PutGetDelete(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	s := newTestAzureStorage(t)
+	opts := PutOptions{ContentType: "application/pdf", Metadata: map[string]string{"original-name": "cv.pdf"}}
+
+	// Act
+	err := s.Put(ctx, "user-1/cv.pdf", strings.NewReader("%PDF-1.7"), 8, opts)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	object, info, err := s.Get(ctx, "user-1/cv.pdf")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	data, _ := io.ReadAll(object)
+	object.Close()
+	if string(data) != "%PDF-1.7" || info.ContentType != "application/pdf" || info.Metadata["original-name"] != "cv.pdf" {
+		t.Errorf("Get() = %q, %+v", data, info)
+	}
+	if info, err := s.Stat(ctx, "user-1/cv.pdf"); err != nil || info.Size != 8 {
+		t.Errorf("Stat() = %+v, %v", info, err)
+	}
+	if err := s.Delete(ctx, "user-1/cv.pdf"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := s.Stat(ctx, "user-1/cv.pdf"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat() after Delete error = %v, want ErrNotFound", err)
+	}
+	if err := s.Delete(ctx, "user-1/cv.pdf"); err != nil {
+		t.Errorf("Delete() of a missing blob error = %v", err)
+	}
+}
+
+func TestAzureStorage_Multipart(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	s := newTestAzureStorage(t)
+	uploadID, err := s.NewMultipart(ctx, "user-1/video.mp4", PutOptions{})
+	if err != nil {
+		t.Fatalf("NewMultipart() error = %v", err)
+	}
+	var parts []Part
+	for _, chunk := range []string{"first-", "second"} {
+		number := len(parts) + 1
+		etag, err := s.PutPart(ctx, "user-1/video.mp4", uploadID, number, strings.NewReader(chunk), int64(len(chunk)))
+		if err != nil {
+			t.Fatalf("PutPart(%d) error = %v", number, err)
+		}
+		parts = append(parts, Part{Number: number, ETag: etag})
+	}
+
+	// Act
+	err = s.CompleteMultipart(ctx, "user-1/video.mp4", uploadID, parts, PutOptions{ContentType: "video/mp4"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("CompleteMultipart() error = %v", err)
+	}
+	object, info, err := s.Get(ctx, "user-1/video.mp4")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	data, _ := io.ReadAll(object)
+	object.Close()
+	if string(data) != "first-second" || info.ContentType != "video/mp4" {
+		t.Errorf("Get() = %q, %+v", data, info)
+	}
+}
+
+func TestAzureStorage_SignURL(t *testing.T) {
+	// Arrange
+	s := newTestAzureStorage(t)
+	s.clock = func() time.Time { return time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC) }
+
+	// Act
+	signed, err := s.SignURL(context.Background(), http.MethodPut, "user-1/photo.png", 15*time.Minute, "image/png", 3)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("SignURL() error = %v", err)
+	}
+	u, err := url.Parse(signed.URL)
+	if err != nil {
+		t.Fatalf("SignURL() URL = %q: %v", signed.URL, err)
+	}
+	query := u.Query()
+	if u.Path != "/uploads/user-1/photo.png" || query.Get("sp") != "cw" || query.Get("sr") != "b" ||
+		query.Get("se") != "2024-01-15T12:15:00Z" || query.Get("sig") == "" {
+		t.Errorf("SignURL() URL = %s", signed.URL)
+	}
+	if signed.Headers["x-ms-blob-type"] != "BlockBlob" || signed.Headers["Content-Type"] != "image/png" {
+		t.Errorf("SignURL() headers = %v", signed.Headers)
+	}
+}
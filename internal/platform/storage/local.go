@@ -0,0 +1,368 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// rawAudience keeps the tokens of signed URLs from being accepted as other tokens signed
+// with the same secret, and the other way round
+const rawAudience = "storage-raw"
+
+// LocalStorage stores objects as files in a directory, for development and single-node
+// deployments. Objects live under objects/, their content type and metadata in JSON
+// files under meta/, and the parts of multipart uploads under multipart/. Its signed URLs
+// end in a token naming the object, and point at ServeSigned.
+type LocalStorage struct {
+	dir string
+	// baseURL is where ServeSigned is routed, e.g. /api/v1/files/raw
+	baseURL string
+	secret  []byte
+	clock   func() time.Time
+}
+
+// rawClaims are the claims of the token of a signed URL
+type rawClaims struct {
+	Name        string `json:"name"`
+	Method      string `json:"method"`
+	ContentType string `json:"content_type,omitempty"`
+	// MaxSize is the most bytes a PUT may store
+	MaxSize int64 `json:"max_size,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// localMeta is what LocalStorage keeps next to an object
+type localMeta struct {
+	ContentType string            `json:"content_type"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// NewLocalStorage stores objects under dir, creating it when missing. Signed URLs are
+// baseURL followed by a token signed with secret.
+func NewLocalStorage(dir, baseURL, secret string) (*LocalStorage, error) {
+	if secret == "" {
+		return nil, errors.New("local storage requires a secret to sign URLs with")
+	}
+	for _, sub := range []string{"objects", "meta", "multipart"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o750); err != nil {
+			return nil, err
+		}
+	}
+	return &LocalStorage{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/"), secret: []byte(secret), clock: time.Now}, nil
+}
+
+func (s *LocalStorage) Put(_ context.Context, name string, r io.Reader, size int64, opts PutOptions) error {
+	objectPath, metaPath, err := s.paths(name)
+	if err != nil {
+		return err
+	}
+	err = writeFileAtomic(objectPath, func(w io.Writer) error {
+		n, err := io.Copy(w, r)
+		if err == nil && size >= 0 && n != size {
+			err = fmt.Errorf("stored %d bytes, want %d", n, size)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return writeMeta(metaPath, localMeta{ContentType: opts.ContentType, Metadata: opts.Metadata})
+}
+
+func (s *LocalStorage) Get(ctx context.Context, name string) (io.ReadCloser, ObjectInfo, error) {
+	objectPath, _, err := s.paths(name)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	file, err := os.Open(objectPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ObjectInfo{}, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	info, err := s.Stat(ctx, name)
+	if err != nil {
+		file.Close()
+		return nil, ObjectInfo{}, err
+	}
+	return file, info, nil
+}
+
+func (s *LocalStorage) Stat(_ context.Context, name string) (ObjectInfo, error) {
+	objectPath, metaPath, err := s.paths(name)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	stat, err := os.Stat(objectPath)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && stat.IsDir()) {
+		return ObjectInfo{}, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	var meta localMeta
+	if data, err := os.ReadFile(metaPath); err == nil {
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return ObjectInfo{}, fmt.Errorf("read metadata of %s: %w", name, err)
+		}
+	}
+	return ObjectInfo{Size: stat.Size(), ContentType: meta.ContentType, Metadata: meta.Metadata}, nil
+}
+
+func (s *LocalStorage) Delete(_ context.Context, name string) error {
+	objectPath, metaPath, err := s.paths(name)
+	if err != nil {
+		return err
+	}
+	for _, p := range []string{objectPath, metaPath} {
+		if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *LocalStorage) Copy(ctx context.Context, src, dst string) error {
+	object, info, err := s.Get(ctx, src)
+	if err != nil {
+		return err
+	}
+	defer object.Close()
+	return s.Put(ctx, dst, object, info.Size, PutOptions{ContentType: info.ContentType, Metadata: info.Metadata})
+}
+
+// SignURL returns baseURL followed by a token ServeSigned accepts until expiry. A signed
+// PUT must send contentType.
+func (s *LocalStorage) SignURL(_ context.Context, method, name string, expiry time.Duration, contentType string, size int64) (*SignedRequest, error) {
+	if _, _, err := s.paths(name); err != nil {
+		return nil, err
+	}
+	if method != http.MethodPut {
+		method, contentType, size = http.MethodGet, "", 0
+	} else if size <= 0 {
+		return nil, errors.New("a signed PUT needs a positive size")
+	}
+	now := s.clock()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, rawClaims{
+		Name:        name,
+		Method:      method,
+		ContentType: contentType,
+		MaxSize:     size,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{rawAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiry)),
+		},
+	}).SignedString(s.secret)
+	if err != nil {
+		return nil, err
+	}
+	signed := &SignedRequest{URL: s.baseURL + "/" + token}
+	if method == http.MethodPut {
+		signed.Headers = map[string]string{"Content-Type": contentType}
+	}
+	return signed, nil
+}
+
+// ServeSigned serves GET and HEAD requests to signed download URLs, and stores the body
+// of PUT requests to signed upload URLs. The token is the "token" path parameter. A PUT
+// without a Content-Length, or with more bytes than the URL was signed for, is refused
+// with 413 and nothing is stored.
+func (s *LocalStorage) ServeSigned(c *gin.Context) {
+	claims := &rawClaims{}
+	_, err := jwt.ParseWithClaims(c.Param("token"), claims, func(*jwt.Token) (interface{}, error) {
+		return s.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithAudience(rawAudience),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(s.clock),
+	)
+	method := c.Request.Method
+	if method == http.MethodHead {
+		method = http.MethodGet
+	}
+	if err != nil || claims.Method != method || (method == http.MethodPut && c.GetHeader("Content-Type") != claims.ContentType) {
+		_ = c.Error(apperrors.NewAppError(apperrors.ForbiddenError, "Invalid or expired signature"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	if method == http.MethodPut {
+		size := c.Request.ContentLength
+		if size < 0 || size > claims.MaxSize {
+			_ = c.Error(apperrors.NewAppErrorWithDetails(apperrors.PayloadTooLargeError, "Request body too large",
+				fmt.Sprintf("send a Content-Length of at most %d bytes", claims.MaxSize)))
+			return
+		}
+		body := http.MaxBytesReader(c.Writer, c.Request.Body, claims.MaxSize)
+		if err := s.Put(ctx, claims.Name, body, size, PutOptions{ContentType: claims.ContentType}); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				_ = c.Error(apperrors.NewAppError(apperrors.PayloadTooLargeError, "Request body too large"))
+				return
+			}
+			_ = c.Error(apperrors.NewAppErrorWithDetails(apperrors.BadRequestError, "Failed to store object", err.Error()))
+			return
+		}
+		c.Status(http.StatusOK)
+		return
+	}
+
+	object, info, err := s.Get(ctx, claims.Name)
+	if errors.Is(err, ErrNotFound) {
+		_ = c.Error(apperrors.NewAppError(apperrors.NotFoundError, "Object not found"))
+		return
+	}
+	if err != nil {
+		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to read object"))
+		return
+	}
+	defer object.Close()
+	c.Header("Cache-Control", "private, no-store")
+	c.DataFromReader(http.StatusOK, info.Size, info.ContentType, object, nil)
+}
+
+func (s *LocalStorage) NewMultipart(_ context.Context, name string, _ PutOptions) (string, error) {
+	if _, _, err := s.paths(name); err != nil {
+		return "", err
+	}
+	uploadID := uuid.NewString()
+	if err := os.Mkdir(filepath.Join(s.dir, "multipart", uploadID), 0o750); err != nil {
+		return "", err
+	}
+	return uploadID, nil
+}
+
+func (s *LocalStorage) PutPart(_ context.Context, _, uploadID string, number int, r io.Reader, size int64) (string, error) {
+	dir, err := s.multipartDir(uploadID)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	err = writeFileAtomic(filepath.Join(dir, strconv.Itoa(number)), func(w io.Writer) error {
+		n, err := io.Copy(io.MultiWriter(w, hash), r)
+		if err == nil && n != size {
+			err = fmt.Errorf("stored %d bytes, want %d", n, size)
+		}
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// CompleteMultipart concatenates the parts into the object and discards them
+func (s *LocalStorage) CompleteMultipart(_ context.Context, name, uploadID string, parts []Part, opts PutOptions) error {
+	objectPath, metaPath, err := s.paths(name)
+	if err != nil {
+		return err
+	}
+	dir, err := s.multipartDir(uploadID)
+	if err != nil {
+		return err
+	}
+	err = writeFileAtomic(objectPath, func(w io.Writer) error {
+		for _, part := range parts {
+			file, err := os.Open(filepath.Join(dir, strconv.Itoa(part.Number)))
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(w, file)
+			file.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := writeMeta(metaPath, localMeta{ContentType: opts.ContentType, Metadata: opts.Metadata}); err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+func (s *LocalStorage) AbortMultipart(_ context.Context, _, uploadID string) error {
+	if _, err := uuid.Parse(uploadID); err != nil {
+		return nil
+	}
+	return os.RemoveAll(filepath.Join(s.dir, "multipart", uploadID))
+}
+
+// paths returns the files of the object and of its metadata, or ErrInvalidName when name
+// would leave the storage directory
+func (s *LocalStorage) paths(name string) (string, string, error) {
+	if name == "" || path.IsAbs(name) || path.Clean(name) != name || name == ".." || strings.HasPrefix(name, "../") || strings.Contains(name, "\\") {
+		return "", "", fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	return filepath.Join(s.dir, "objects", filepath.FromSlash(name)), filepath.Join(s.dir, "meta", filepath.FromSlash(name)+".json"), nil
+}
+
+// multipartDir returns the directory of an upload's parts, or ErrNotFound
+func (s *LocalStorage) multipartDir(uploadID string) (string, error) {
+	if _, err := uuid.Parse(uploadID); err != nil {
+		return "", fmt.Errorf("%w: upload %q", ErrNotFound, uploadID)
+	}
+	dir := filepath.Join(s.dir, "multipart", uploadID)
+	if _, err := os.Stat(dir); err != nil {
+		return "", fmt.Errorf("%w: upload %s", ErrNotFound, uploadID)
+	}
+	return dir, nil
+}
+
+// writeMeta stores the metadata of an object
+func writeMeta(metaPath string, meta localMeta) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(metaPath, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// writeFileAtomic writes a file through a temporary file renamed into place, so readers
+// never see it half written
+func writeFileAtomic(target string, write func(io.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
@@ -0,0 +1,259 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/http/middleware"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestLocalStorage_PutGetDelete(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	s, err := NewLocalStorage(t.TempDir(), "/api/v1/files/raw", "secret")
+	if err != nil {
+		t.Fatalf("NewLocalStorage() error = %v", err)
+	}
+	opts := PutOptions{ContentType: "text/plain", Metadata: map[string]string{"uploaded-by": "user-1"}}
+
+	// Act
+	err = s.Put(ctx, "user-1/notes.txt", strings.NewReader("hello"), 5, opts)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	object, info, err := s.Get(ctx, "user-1/notes.txt")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	data, _ := io.ReadAll(object)
+	object.Close()
+	if string(data) != "hello" || info.Size != 5 || info.ContentType != "text/plain" || info.Metadata["uploaded-by"] != "user-1" {
+		t.Errorf("Get() = %q, %+v", data, info)
+	}
+	if err := s.Delete(ctx, "user-1/notes.txt"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := s.Stat(ctx, "user-1/notes.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat() after Delete error = %v, want ErrNotFound", err)
+	}
+	if err := s.Delete(ctx, "user-1/notes.txt"); err != nil {
+		t.Errorf("Delete() of a missing object error = %v", err)
+	}
+}
+
+func TestLocalStorage_Copy(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	s, err := NewLocalStorage(t.TempDir(), "/api/v1/files/raw", "secret")
+	if err != nil {
+		t.Fatalf("NewLocalStorage() error = %v", err)
+	}
+	opts := PutOptions{ContentType: "text/plain", Metadata: map[string]string{"uploaded-by": "user-1"}}
+	if err := s.Put(ctx, "user-1/notes.txt", strings.NewReader("hello"), 5, opts); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	// Act
+	err = s.Copy(ctx, "user-1/notes.txt", "user-1/archive/notes.txt")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	info, err := s.Stat(ctx, "user-1/archive/notes.txt")
+	if err != nil || info.Size != 5 || info.ContentType != "text/plain" || info.Metadata["uploaded-by"] != "user-1" {
+		t.Errorf("Stat() of the copy = %+v, %v", info, err)
+	}
+	if _, err := s.Stat(ctx, "user-1/notes.txt"); err != nil {
+		t.Errorf("Stat() of the source error = %v", err)
+	}
+	if err := s.Copy(ctx, "user-1/missing.txt", "user-1/copy.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Copy() of a missing object error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestLocalStorage_RejectsNamesOutsideTheDirectory(t *testing.T) {
+	// Arrange
+	s, err := NewLocalStorage(t.TempDir(), "/api/v1/files/raw", "secret")
+	if err != nil {
+		t.Fatalf("NewLocalStorage() error = %v", err)
+	}
+
+	for _, name := range []string{"", "../escape.txt", "a/../../escape.txt", "/etc/passwd", "a//b", `..\escape.txt`} {
+		// Act
+		err := s.Put(context.Background(), name, strings.NewReader("x"), 1, PutOptions{})
+
+		// Assert
+		if !errors.Is(err, ErrInvalidName) {
+			t.Errorf("Put(%q) error = %v, want ErrInvalidName", name, err)
+		}
+	}
+}
+
+func TestLocalStorage_Multipart(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	s, err := NewLocalStorage(t.TempDir(), "/api/v1/files/raw", "secret")
+	if err != nil {
+		t.Fatalf("NewLocalStorage() error = %v", err)
+	}
+	uploadID, err := s.NewMultipart(ctx, "user-1/video.mp4", PutOptions{})
+	if err != nil {
+		t.Fatalf("NewMultipart() error = %v", err)
+	}
+	var parts []Part
+	for number, chunk := range map[int]string{1: "first-", 2: "second"} {
+		etag, err := s.PutPart(ctx, "user-1/video.mp4", uploadID, number, strings.NewReader(chunk), int64(len(chunk)))
+		if err != nil {
+			t.Fatalf("PutPart(%d) error = %v", number, err)
+		}
+		parts = append(parts, Part{Number: number, ETag: etag})
+	}
+	if parts[0].Number == 2 {
+		parts[0], parts[1] = parts[1], parts[0]
+	}
+
+	// Act
+	err = s.CompleteMultipart(ctx, "user-1/video.mp4", uploadID, parts, PutOptions{ContentType: "video/mp4"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("CompleteMultipart() error = %v", err)
+	}
+	object, info, err := s.Get(ctx, "user-1/video.mp4")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	data, _ := io.ReadAll(object)
+	object.Close()
+	if string(data) != "first-second" || info.ContentType != "video/mp4" {
+		t.Errorf("Get() = %q, %+v", data, info)
+	}
+	if _, err := s.PutPart(ctx, "user-1/video.mp4", uploadID, 3, strings.NewReader("x"), 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("PutPart() after completion error = %v, want ErrNotFound", err)
+	}
+	if err := s.AbortMultipart(ctx, "user-1/video.mp4", uploadID); err != nil {
+		t.Errorf("AbortMultipart() of a completed upload error = %v", err)
+	}
+}
+
+func TestLocalStorage_ServeSigned(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	s, err := NewLocalStorage(t.TempDir(), "/api/v1/files/raw", "secret")
+	if err != nil {
+		t.Fatalf("NewLocalStorage() error = %v", err)
+	}
+	s.clock = func() time.Time { return now }
+	router := gin.New()
+	router.Use(middleware.RequestIDMiddleware(), middleware.ErrorHandlerMiddleware(zap.NewNop().Sugar()))
+	router.Any("/api/v1/files/raw/:token", s.ServeSigned)
+	serve := func(method, target, contentType, body string) int {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	put, err := s.SignURL(ctx, http.MethodPut, "user-1/photo.png", 15*time.Minute, "image/png", 3)
+	if err != nil {
+		t.Fatalf("SignURL(PUT) error = %v", err)
+	}
+	get, err := s.SignURL(ctx, http.MethodGet, "user-1/photo.png", 15*time.Minute, "", 0)
+	if err != nil {
+		t.Fatalf("SignURL(GET) error = %v", err)
+	}
+	other, err := NewLocalStorage(t.TempDir(), "/api/v1/files/raw", "other-secret")
+	if err != nil {
+		t.Fatalf("NewLocalStorage() error = %v", err)
+	}
+	forged, _ := other.SignURL(ctx, http.MethodGet, "user-1/photo.png", 15*time.Minute, "", 0)
+
+	// Act
+	wrongType := serve(http.MethodPut, put.URL, "image/jpeg", "png")
+	stored := serve(http.MethodPut, put.URL, put.Headers["Content-Type"], "png")
+	downloaded := serve(http.MethodGet, get.URL, "", "")
+	putWithGet := serve(http.MethodPut, get.URL, "", "other")
+	otherSecret := serve(http.MethodGet, forged.URL, "", "")
+	head := serve(http.MethodHead, get.URL, "", "")
+	now = now.Add(16 * time.Minute)
+	expired := serve(http.MethodGet, get.URL, "", "")
+
+	// Assert
+	if wrongType == http.StatusOK || putWithGet == http.StatusOK || otherSecret == http.StatusOK || expired == http.StatusOK {
+		t.Errorf("forged requests served: wrong type %d, PUT with GET token %d, other secret %d, expired %d",
+			wrongType, putWithGet, otherSecret, expired)
+	}
+	if stored != http.StatusOK || downloaded != http.StatusOK || head != http.StatusOK {
+		t.Errorf("signed PUT = %d, GET = %d, HEAD = %d, want 200", stored, downloaded, head)
+	}
+	info, err := s.Stat(ctx, "user-1/photo.png")
+	if err != nil || info.ContentType != "image/png" || info.Size != 3 {
+		t.Errorf("Stat() = %+v, %v", info, err)
+	}
+}
+
+func TestLocalStorage_ServeSigned_PutLimit(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	s, err := NewLocalStorage(t.TempDir(), "/api/v1/files/raw", "secret")
+	if err != nil {
+		t.Fatalf("NewLocalStorage() error = %v", err)
+	}
+	router := gin.New()
+	router.Use(middleware.RequestIDMiddleware(), middleware.ErrorHandlerMiddleware(zap.NewNop().Sugar()))
+	router.Any("/api/v1/files/raw/:token", s.ServeSigned)
+	put, err := s.SignURL(ctx, http.MethodPut, "user-1/notes.txt", 15*time.Minute, "text/plain", 4)
+	if err != nil {
+		t.Fatalf("SignURL(PUT) error = %v", err)
+	}
+	if _, err := s.SignURL(ctx, http.MethodPut, "user-1/notes.txt", 15*time.Minute, "text/plain", 0); err == nil {
+		t.Error("SignURL(PUT) without a size error = nil, want an error")
+	}
+
+	tests := []struct {
+		name          string
+		body          string
+		contentLength int64
+	}{
+		{name: "chunked body without a length", body: "0123456789", contentLength: -1},
+		{name: "announced length over the limit", body: "0123456789", contentLength: 10},
+		{name: "more bytes than announced", body: "0123456789", contentLength: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, put.URL, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "text/plain")
+			req.ContentLength = tt.contentLength
+			w := httptest.NewRecorder()
+
+			// Act
+			router.ServeHTTP(w, req)
+
+			// Assert
+			if w.Code != http.StatusRequestEntityTooLarge {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+			}
+			if _, err := s.Stat(ctx, "user-1/notes.txt"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Stat() error = %v, want nothing stored", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"go.uber.org/zap"
+)
+
+// S3Storage stores objects in a bucket of an S3-compatible service: MinIO, Amazon S3
+// or Cloud Storage through its XML API
+type S3Storage struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewS3Storage connects to the bucket of cfg. With createBucket, a missing bucket is
+// created, as for a MinIO started next to the API; otherwise it must exist.
+func NewS3Storage(ctx context.Context, cfg config.MinIOConfig, region string, createBucket bool, logger *zap.SugaredLogger) (*S3Storage, error) {
+	client, err := minio.New(cfg.MinioEndpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.MinioAccessKey, cfg.MinioSecretKey, ""),
+		Secure: cfg.MinioUseSSL,
+		Region: region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Verify connection and create bucket if it doesn't exist
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	exists, err := client.BucketExists(ctx, cfg.MinioBucket)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case exists:
+		logger.Infof("Using existing bucket: %s", cfg.MinioBucket)
+	case !createBucket:
+		return nil, fmt.Errorf("bucket %s does not exist", cfg.MinioBucket)
+	default:
+		logger.Infof("Creating MinIO bucket: %s", cfg.MinioBucket)
+		if err := client.MakeBucket(ctx, cfg.MinioBucket, minio.MakeBucketOptions{}); err != nil {
+			return nil, err
+		}
+
+		// Set bucket policy for public read access (adjust based on your requirements)
+		bucketPolicy := fmt.Sprintf(`{
+			"Version": "2012-10-17",
+			"Statement": [
+				{
+					"Effect": "Allow",
+					"Principal": {"AWS": ["*"]},
+					"Action": [
+						"s3:GetObject",
+						"s3:PutObject",
+						"s3:DeleteObject",
+						"s3:ListBucket"
+					],
+					"Resource": [
+						"arn:aws:s3:::%s",
+						"arn:aws:s3:::%s/*"
+					]
+				}
+			]
+		}`, cfg.MinioBucket, cfg.MinioBucket)
+
+		if err := client.SetBucketPolicy(ctx, cfg.MinioBucket, bucketPolicy); err != nil {
+			logger.Warnf("Failed to set bucket policy: %v", err)
+		}
+	}
+	return &S3Storage{client: client, bucket: cfg.MinioBucket}, nil
+}
+
+func (s *S3Storage) Put(ctx context.Context, name string, r io.Reader, size int64, opts PutOptions) error {
+	_, err := s.client.PutObject(ctx, s.bucket, name, r, size, minio.PutObjectOptions{
+		ContentType:  opts.ContentType,
+		UserMetadata: opts.Metadata,
+	})
+	return err
+}
+
+func (s *S3Storage) Get(ctx context.Context, name string) (io.ReadCloser, ObjectInfo, error) {
+	object, err := s.client.GetObject(ctx, s.bucket, name, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, ObjectInfo{}, s3Error(err)
+	}
+	// GetObject is lazy; Stat sends the request and reports a missing object
+	info, err := object.Stat()
+	if err != nil {
+		object.Close()
+		return nil, ObjectInfo{}, s3Error(err)
+	}
+	return object, s3Info(info), nil
+}
+
+func (s *S3Storage) Stat(ctx context.Context, name string) (ObjectInfo, error) {
+	info, err := s.client.StatObject(ctx, s.bucket, name, minio.StatObjectOptions{})
+	if err != nil {
+		return ObjectInfo{}, s3Error(err)
+	}
+	return s3Info(info), nil
+}
+
+func (s *S3Storage) Delete(ctx context.Context, name string) error {
+	return s.client.RemoveObject(ctx, s.bucket, name, minio.RemoveObjectOptions{})
+}
+
+// Copy copies the object within the bucket, without the content passing through the API
+func (s *S3Storage) Copy(ctx context.Context, src, dst string) error {
+	_, err := s.client.CopyObject(ctx,
+		minio.CopyDestOptions{Bucket: s.bucket, Object: dst},
+		minio.CopySrcOptions{Bucket: s.bucket, Object: src},
+	)
+	return s3Error(err)
+}
+
+func (s *S3Storage) SignURL(ctx context.Context, method, name string, expiry time.Duration, contentType string, _ int64) (*SignedRequest, error) {
+	if method != http.MethodPut {
+		url, err := s.client.PresignedGetObject(ctx, s.bucket, name, expiry, nil)
+		if err != nil {
+			return nil, err
+		}
+		return &SignedRequest{URL: url.String()}, nil
+	}
+	// Signing the content type makes storage reject a PUT with any other one
+	headers := http.Header{"Content-Type": []string{contentType}}
+	url, err := s.client.PresignHeader(ctx, http.MethodPut, s.bucket, name, expiry, nil, headers)
+	if err != nil {
+		return nil, err
+	}
+	return &SignedRequest{URL: url.String(), Headers: map[string]string{"Content-Type": contentType}}, nil
+}
+
+func (s *S3Storage) NewMultipart(ctx context.Context, name string, opts PutOptions) (string, error) {
+	core := minio.Core{Client: s.client}
+	return core.NewMultipartUpload(ctx, s.bucket, name, minio.PutObjectOptions{
+		ContentType:  opts.ContentType,
+		UserMetadata: opts.Metadata,
+	})
+}
+
+func (s *S3Storage) PutPart(ctx context.Context, name, uploadID string, number int, r io.Reader, size int64) (string, error) {
+	core := minio.Core{Client: s.client}
+	part, err := core.PutObjectPart(ctx, s.bucket, name, uploadID, number, r, size, minio.PutObjectPartOptions{})
+	if err != nil {
+		return "", s3Error(err)
+	}
+	return part.ETag, nil
+}
+
+// CompleteMultipart joins the parts; the content type and metadata were set by
+// NewMultipart
+func (s *S3Storage) CompleteMultipart(ctx context.Context, name, uploadID string, parts []Part, _ PutOptions) error {
+	completed := make([]minio.CompletePart, len(parts))
+	for i, part := range parts {
+		completed[i] = minio.CompletePart{PartNumber: part.Number, ETag: part.ETag}
+	}
+	core := minio.Core{Client: s.client}
+	_, err := core.CompleteMultipartUpload(ctx, s.bucket, name, uploadID, completed, minio.PutObjectOptions{})
+	return s3Error(err)
+}
+
+func (s *S3Storage) AbortMultipart(ctx context.Context, name, uploadID string) error {
+	core := minio.Core{Client: s.client}
+	err := core.AbortMultipartUpload(ctx, s.bucket, name, uploadID)
+	if err != nil && minio.ToErrorResponse(err).Code == "NoSuchUpload" {
+		return nil
+	}
+	return err
+}
+
+// s3Error maps the errors of missing objects and uploads to ErrNotFound
+func s3Error(err error) error {
+	if err == nil {
+		return nil
+	}
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchKey", "NoSuchUpload":
+		return fmt.Errorf("%w: %v", ErrNotFound, err)
+	}
+	return err
+}
+
+// s3Info converts minio-go's object info; it returns metadata keys in canonical header
+// form, e.g. Uploaded-By
+func s3Info(info minio.ObjectInfo) ObjectInfo {
+	metadata := make(map[string]string, len(info.UserMetadata))
+	for key, value := range info.UserMetadata {
+		metadata[strings.ToLower(key)] = value
+	}
+	return ObjectInfo{Size: info.Size, ContentType: info.ContentType, Metadata: metadata}
+}
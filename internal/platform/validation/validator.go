@@ -4,32 +4,79 @@ import (
 	"fmt"
 	"strings"
 
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
 	"github.com/go-playground/validator/v10"
-	apperrors "go_platform_template/internal/shared/errors"
 )
 
-// Validator wraps the playground validator for tag-based validation
+// Validator wraps the playground validator for tag-based validation. Besides the
+// playground's own tags it knows the rules in rules.go and any added with Register.
 type Validator struct {
 	validate *validator.Validate
+	// messages describe failures of the custom rules, keyed by tag
+	messages map[string]string
 }
 
-// New creates a new Validator instance
+// Rule is a custom validation tag, used in struct tags like the built-in ones:
+//
+//	Username string `validate:"required,username_policy"`
+type Rule struct {
+	Tag  string
+	Func validator.Func
+	// Message follows the field name when the rule fails, e.g. "must not be a reserved name"
+	Message string
+}
+
+// defaultValidator is shared by every handler so rules registered at bootstrap apply everywhere
+var defaultValidator = New()
+
+// New creates a new Validator instance with the built-in rules
 func New() *Validator {
-	return &Validator{
+	v := &Validator{
 		validate: validator.New(),
+		messages: make(map[string]string),
 	}
+	if err := v.Register(UsernamePolicy(), StrongPassword()); err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// Default returns the validator shared by all handlers
+func Default() *Validator {
+	return defaultValidator
+}
+
+// Register adds rules to the shared validator, replacing rules with the same tag. Call
+// it at bootstrap, before requests are served; registering is not safe while validating.
+func Register(rules ...Rule) error {
+	return defaultValidator.Register(rules...)
+}
+
+// Register adds rules to v, replacing rules with the same tag
+func (v *Validator) Register(rules ...Rule) error {
+	for _, rule := range rules {
+		if rule.Tag == "" || rule.Func == nil {
+			return fmt.Errorf("validation rule %q needs a tag and a function", rule.Tag)
+		}
+		if err := v.validate.RegisterValidation(rule.Tag, rule.Func); err != nil {
+			return fmt.Errorf("register validation rule %q: %w", rule.Tag, err)
+		}
+		v.messages[rule.Tag] = rule.Message
+	}
+	return nil
 }
 
 // ValidateStruct validates a struct using its validation tags
 func (v *Validator) ValidateStruct(data interface{}) error {
 	if err := v.validate.Struct(data); err != nil {
-		return formatValidationError(err)
+		return v.formatValidationError(err)
 	}
 	return nil
 }
 
-// formatValidationError converts validator errors to AppError format
-func formatValidationError(err error) error {
+// formatValidationError converts validator errors to AppError format. Each field error
+// is a Detail so the error handler can translate it.
+func (v *Validator) formatValidationError(err error) error {
 	validationErrors, ok := err.(validator.ValidationErrors)
 	if !ok {
 		return apperrors.NewAppError(apperrors.ValidationError, "validation failed")
@@ -39,59 +86,64 @@ func formatValidationError(err error) error {
 		return apperrors.NewAppError(apperrors.ValidationError, "validation failed")
 	}
 
-	// Build detailed error messages
-	var details strings.Builder
+	parts := make([]apperrors.Detail, len(validationErrors))
 	for i, fieldError := range validationErrors {
-		if i > 0 {
-			details.WriteString("; ")
-		}
-		details.WriteString(formatFieldError(fieldError))
+		parts[i] = v.formatFieldError(fieldError)
 	}
 
-	return apperrors.NewAppErrorWithDetails(
-		apperrors.ValidationError,
-		"validation failed",
-		details.String(),
-	)
+	return apperrors.NewAppErrorWithDetailParts(apperrors.ValidationError, "validation failed", parts...)
 }
 
 // formatFieldError formats a single field validation error
-func formatFieldError(fe validator.FieldError) string {
+func (v *Validator) formatFieldError(fe validator.FieldError) apperrors.Detail {
 	field := fe.Field()
 	tag := fe.Tag()
 	param := fe.Param()
-	value := fe.Value()
+
+	if message := v.messages[tag]; message != "" {
+		return detail("%s "+strings.ReplaceAll(message, "%", "%%"), field)
+	}
 
 	switch tag {
 	case "required":
-		return fmt.Sprintf("%s is required", field)
+		return detail("%s is required", field)
 	case "email":
-		return fmt.Sprintf("%s must be a valid email", field)
+		return detail("%s must be a valid email", field)
 	case "min":
-		return fmt.Sprintf("%s must have a minimum length of %s", field, param)
+		return detail("%s must have a minimum length of %s", field, param)
 	case "max":
-		return fmt.Sprintf("%s must have a maximum length of %s", field, param)
+		return detail("%s must have a maximum length of %s", field, param)
 	case "alphanum":
-		return fmt.Sprintf("%s must be alphanumeric", field)
+		return detail("%s must be alphanumeric", field)
 	case "oneof":
-		return fmt.Sprintf("%s must be one of: %s", field, param)
+		return detail("%s must be one of: %s", field, param)
 	case "len":
-		return fmt.Sprintf("%s must have exactly %s characters", field, param)
+		return detail("%s must have exactly %s characters", field, param)
 	case "numeric":
-		return fmt.Sprintf("%s must be numeric", field)
+		return detail("%s must be numeric", field)
+	case "e164":
+		return detail("%s must be a phone number in E.164 format (e.g. +14155552671)", field)
 	case "url":
-		return fmt.Sprintf("%s must be a valid URL", field)
+		return detail("%s must be a valid URL", field)
 	case "uuid":
-		return fmt.Sprintf("%s must be a valid UUID", field)
+		return detail("%s must be a valid UUID", field)
+	case "timezone":
+		return detail("%s must be an IANA time zone (e.g. Europe/Berlin)", field)
+	case "bcp47_language_tag":
+		return detail("%s must be a BCP 47 language tag (e.g. en-US)", field)
 	case "gte":
-		return fmt.Sprintf("%s must be greater than or equal to %s", field, param)
+		return detail("%s must be greater than or equal to %s", field, param)
 	case "lte":
-		return fmt.Sprintf("%s must be less than or equal to %s", field, param)
+		return detail("%s must be less than or equal to %s", field, param)
 	case "gt":
-		return fmt.Sprintf("%s must be greater than %s", field, param)
+		return detail("%s must be greater than %s", field, param)
 	case "lt":
-		return fmt.Sprintf("%s must be less than %s", field, param)
+		return detail("%s must be less than %s", field, param)
 	default:
-		return fmt.Sprintf("%s validation failed: %s (value: %v)", field, tag, value)
+		return detail("%s validation failed: %s (value: %v)", field, tag, fe.Value())
 	}
 }
+
+func detail(format string, args ...interface{}) apperrors.Detail {
+	return apperrors.Detail{Format: format, Args: args}
+}
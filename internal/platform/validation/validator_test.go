@@ -0,0 +1,85 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/go-playground/validator/v10"
+)
+
+type signup struct {
+	Username string `validate:"required,username_policy,min=3,max=50"`
+	Password string `validate:"required,min=8,strong_password"`
+}
+
+func TestValidator_BuiltInRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   signup
+		wantErr string
+	}{
+		{name: "valid", input: signup{Username: "ada_lovelace", Password: "Analytical1"}},
+		{name: "reserved username", input: signup{Username: "Admin", Password: "Analytical1"}, wantErr: "Username must contain only"},
+		{name: "username with dot", input: signup{Username: "ada.l", Password: "Analytical1"}, wantErr: "Username must contain only"},
+		{name: "username starting with underscore", input: signup{Username: "_ada", Password: "Analytical1"}, wantErr: "Username must contain only"},
+		{name: "weak password", input: signup{Username: "ada", Password: "password123"}, wantErr: "Password must contain at least three"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			v := New()
+
+			// Act
+			err := v.ValidateStruct(&tt.input)
+
+			// Assert
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateStruct() error = %v", err)
+				}
+				return
+			}
+			appErr, ok := apperrors.IsAppError(err)
+			if !ok || !strings.Contains(appErr.Details, tt.wantErr) {
+				t.Errorf("ValidateStruct() error = %v, want details containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidator_RegisterReplacesRule(t *testing.T) {
+	// Arrange
+	v := New()
+	err := v.Register(UsernamePolicy("ada"), Rule{
+		Tag:     "even_length",
+		Message: "must have an even length",
+		Func:    func(fl validator.FieldLevel) bool { return len(fl.Field().String())%2 == 0 },
+	})
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	type input struct {
+		Username string `validate:"username_policy"`
+		Code     string `validate:"even_length"`
+	}
+
+	// Act
+	reserved := v.ValidateStruct(&input{Username: "ada", Code: "ab"})
+	custom := v.ValidateStruct(&input{Username: "admin", Code: "abc"})
+
+	// Assert
+	if reserved == nil {
+		t.Error("a name reserved by the new rule was accepted")
+	}
+	appErr, ok := apperrors.IsAppError(custom)
+	if !ok || appErr.Details != "Code must have an even length" {
+		t.Errorf("ValidateStruct() error = %v, want only the custom rule to fail", custom)
+	}
+}
+
+func TestValidator_RegisterRejectsIncompleteRule(t *testing.T) {
+	if err := New().Register(Rule{Tag: "no_func"}); err == nil {
+		t.Error("Register() accepted a rule without a function")
+	}
+}
@@ -0,0 +1,250 @@
+// Package webhook posts auth events, such as logins and registrations, to external
+// systems. Deliveries are signed with HMAC-SHA256, sent in the background and retried
+// with exponential backoff.
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/metrics"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/clock"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Event types
+const (
+	EventUserRegistered = "user.registered"
+	EventUserLogin      = "user.login"
+	EventTokenRevoked   = "token.revoked"
+)
+
+// Events lists every event type an endpoint can subscribe to
+var Events = []string{EventUserRegistered, EventUserLogin, EventTokenRevoked}
+
+// Headers of each delivery. SignatureHeader is "t=<unix seconds>,v1=<hex HMAC-SHA256 of
+// "<t>.<body>">"; receivers should recompute it and reject old timestamps.
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	EventHeader     = "X-Webhook-Event"
+	IDHeader        = "X-Webhook-Id"
+)
+
+// workers is how many deliveries run at once; queueSize how many may wait
+const (
+	workers   = 4
+	queueSize = 1000
+)
+
+var deliveries = metrics.NewCounter("webhook_deliveries_total",
+	"Webhook deliveries by event and result (delivered, failed or dropped).", "event", "result")
+
+// Event is the JSON body of a delivery
+type Event struct {
+	// ID is the same for every endpoint and attempt, so receivers can drop duplicates
+	ID         string         `json:"id"`
+	Type       string         `json:"type"`
+	OccurredAt time.Time      `json:"occurred_at"`
+	Data       map[string]any `json:"data"`
+}
+
+type delivery struct {
+	endpoint config.WebhookEndpoint
+	event    string
+	id       string
+	body     []byte
+}
+
+// Dispatcher sends events to the endpoints subscribed to them. A nil Dispatcher sends
+// nothing, so services can publish unconditionally.
+type Dispatcher struct {
+	cfg    config.WebhookConfig
+	client *http.Client
+	clock  clock.Clock
+	logger *zap.SugaredLogger
+
+	queue chan delivery
+	// stop abandons retries still waiting when Close runs out of time
+	stop     chan struct{}
+	wg       sync.WaitGroup
+	mu       sync.RWMutex
+	closed   bool
+	stopOnce sync.Once
+}
+
+// NewDispatcher checks the endpoints of cfg and starts the delivery workers. Stop them
+// with Close.
+func NewDispatcher(cfg config.WebhookConfig, logger *zap.SugaredLogger) (*Dispatcher, error) {
+	for _, endpoint := range cfg.Endpoints {
+		u, err := url.Parse(endpoint.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("webhook URL %q must be an absolute http or https URL", endpoint.URL)
+		}
+		if endpoint.Secret == "" {
+			return nil, fmt.Errorf("webhook %s has no secret", endpoint.URL)
+		}
+		for _, event := range endpoint.Events {
+			if !slices.Contains(Events, event) {
+				return nil, fmt.Errorf("webhook %s subscribes to unknown event %q", endpoint.URL, event)
+			}
+		}
+	}
+	d := &Dispatcher{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+		clock:  clock.System(),
+		logger: logger,
+		queue:  make(chan delivery, queueSize),
+		stop:   make(chan struct{}),
+	}
+	for range workers {
+		d.wg.Add(1)
+		go d.work()
+	}
+	return d, nil
+}
+
+// SetClock replaces the clock used for event times and signatures, for tests
+func (d *Dispatcher) SetClock(c clock.Clock) {
+	d.clock = c
+}
+
+// Publish queues eventType with data for every endpoint subscribed to it and returns
+// without waiting for the deliveries. When the queue is full the event is dropped and
+// logged.
+func (d *Dispatcher) Publish(eventType string, data map[string]any) {
+	if d == nil {
+		return
+	}
+	event := Event{ID: uuid.NewString(), Type: eventType, OccurredAt: d.clock.Now().UTC(), Data: data}
+	body, err := json.Marshal(event)
+	if err != nil {
+		d.logger.Errorw("failed to encode webhook event", "event", eventType, "error", err)
+		return
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return
+	}
+	for _, endpoint := range d.cfg.Endpoints {
+		if len(endpoint.Events) > 0 && !slices.Contains(endpoint.Events, eventType) {
+			continue
+		}
+		select {
+		case d.queue <- delivery{endpoint: endpoint, event: eventType, id: event.ID, body: body}:
+		default:
+			deliveries.Inc(eventType, "dropped")
+			d.logger.Errorw("webhook queue full, event dropped", "event", eventType, "event_id", event.ID, "url", endpoint.URL)
+		}
+	}
+}
+
+// Close stops accepting events and waits for queued deliveries, including their retries,
+// until ctx is done; deliveries still pending then are abandoned
+func (d *Dispatcher) Close(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+	}
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		d.stopOnce.Do(func() { close(d.stop) })
+		return ctx.Err()
+	}
+}
+
+func (d *Dispatcher) work() {
+	defer d.wg.Done()
+	for dl := range d.queue {
+		d.deliver(dl)
+	}
+}
+
+// deliver sends dl until the endpoint answers 2xx or the attempts run out
+func (d *Dispatcher) deliver(dl delivery) {
+	wait := d.cfg.BackoffBase
+	for attempt := 1; ; attempt++ {
+		err := d.send(dl)
+		if err == nil {
+			deliveries.Inc(dl.event, "delivered")
+			return
+		}
+		if attempt >= d.cfg.MaxAttempts {
+			deliveries.Inc(dl.event, "failed")
+			d.logger.Errorw("webhook delivery failed, giving up",
+				"event", dl.event, "event_id", dl.id, "url", dl.endpoint.URL, "attempts", attempt, "error", err)
+			return
+		}
+		d.logger.Warnw("webhook delivery failed, retrying",
+			"event", dl.event, "event_id", dl.id, "url", dl.endpoint.URL, "attempt", attempt, "retry_in", wait, "error", err)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-d.stop:
+			timer.Stop()
+			deliveries.Inc(dl.event, "failed")
+			return
+		}
+		wait = min(wait*2, d.cfg.BackoffMax)
+	}
+}
+
+func (d *Dispatcher) send(dl delivery) error {
+	req, err := http.NewRequest(http.MethodPost, dl.endpoint.URL, strings.NewReader(string(dl.body)))
+	if err != nil {
+		return err
+	}
+	timestamp := d.clock.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, dl.event)
+	req.Header.Set(IDHeader, dl.id)
+	req.Header.Set(SignatureHeader, "t="+strconv.FormatInt(timestamp, 10)+",v1="+Sign(dl.endpoint.Secret, timestamp, dl.body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("endpoint returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with secret, the v1
+// part of SignatureHeader
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,100 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+
+	"go.uber.org/zap"
+)
+
+func TestDispatcher_SignsAndRetries(t *testing.T) {
+	// Arrange
+	var (
+		mu       sync.Mutex
+		attempts int
+		received []*http.Request
+		bodies   [][]byte
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		received = append(received, r)
+		bodies = append(bodies, body)
+	}))
+	defer server.Close()
+	d, err := NewDispatcher(config.WebhookConfig{
+		Endpoints: []config.WebhookEndpoint{
+			{URL: server.URL, Events: []string{EventUserLogin}, Secret: "crm-secret"},
+			{URL: server.URL + "/unsubscribed", Events: []string{EventUserRegistered}, Secret: "other-secret"},
+		},
+		MaxAttempts: 3,
+		BackoffBase: time.Millisecond,
+		BackoffMax:  time.Millisecond,
+		Timeout:     time.Second,
+	}, zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatalf("NewDispatcher() error = %v", err)
+	}
+
+	// Act
+	d.Publish(EventUserLogin, map[string]any{"user_id": "42"})
+	if err := d.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// Assert
+	if attempts != 2 || len(received) != 1 {
+		t.Fatalf("attempts = %d, deliveries = %d, want one retry and one delivery", attempts, len(received))
+	}
+	req, body := received[0], bodies[0]
+	if req.Header.Get(EventHeader) != EventUserLogin {
+		t.Errorf("%s = %q, want %q", EventHeader, req.Header.Get(EventHeader), EventUserLogin)
+	}
+	timestamp, signature, _ := strings.Cut(req.Header.Get(SignatureHeader), ",v1=")
+	ts, err := strconv.ParseInt(strings.TrimPrefix(timestamp, "t="), 10, 64)
+	if err != nil || signature != Sign("crm-secret", ts, body) {
+		t.Errorf("signature %q does not match the body", req.Header.Get(SignatureHeader))
+	}
+	var event Event
+	if err := json.Unmarshal(body, &event); err != nil || event.Type != EventUserLogin || event.Data["user_id"] != "42" || event.ID != req.Header.Get(IDHeader) {
+		t.Errorf("event = %+v, want user.login for user 42 with the delivery ID", event)
+	}
+}
+
+func TestNewDispatcher_RejectsInvalidEndpoints(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint config.WebhookEndpoint
+	}{
+		{name: "relative URL", endpoint: config.WebhookEndpoint{URL: "/hooks", Secret: "s"}},
+		{name: "no secret", endpoint: config.WebhookEndpoint{URL: "https://hooks.example.com"}},
+		{name: "unknown event", endpoint: config.WebhookEndpoint{URL: "https://hooks.example.com", Secret: "s", Events: []string{"user.deleted"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			_, err := NewDispatcher(config.WebhookConfig{Endpoints: []config.WebhookEndpoint{tt.endpoint}}, zap.NewNop().Sugar())
+
+			// Assert
+			if err == nil {
+				t.Error("NewDispatcher() error = nil, want an error")
+			}
+		})
+	}
+}
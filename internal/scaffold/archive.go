@@ -0,0 +1,254 @@
+package scaffold
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// OutputMode selects whether a project is written as a directory or packed into an archive
+type OutputMode string
+
+const (
+	OutputDir   OutputMode = "dir"
+	OutputTarGz OutputMode = "tar.gz"
+	OutputZip   OutputMode = "zip"
+)
+
+// Output describes where a generated project ends up. An empty ArchivePath puts the
+// archive next to where the directory would have been, named <project>.tar.gz or .zip.
+type Output struct {
+	Mode        OutputMode
+	ArchivePath string
+}
+
+// IsArchive reports whether the project is packed into an archive
+func (o Output) IsArchive() bool {
+	return o.Mode == OutputTarGz || o.Mode == OutputZip
+}
+
+// ParseOutput validates the -output and -archive flags. With only an archive path the
+// mode follows its extension (.tar.gz, .tgz or .zip).
+func ParseOutput(mode, archivePath string) (Output, error) {
+	out := Output{Mode: OutputMode(strings.ToLower(mode)), ArchivePath: archivePath}
+	if out.Mode == "" || (out.Mode == OutputDir && archivePath != "") {
+		out.Mode = OutputDir
+		if archivePath != "" {
+			out.Mode = outputModeFromPath(archivePath)
+			if out.Mode == "" {
+				return Output{}, fmt.Errorf("cannot tell the archive format of %q: use a .tar.gz, .tgz or .zip name or set -output", archivePath)
+			}
+		}
+	}
+	switch out.Mode {
+	case OutputDir, OutputTarGz, OutputZip:
+	case "tgz":
+		out.Mode = OutputTarGz
+	default:
+		return Output{}, fmt.Errorf("unknown output mode %q (use dir, tar.gz or zip)", mode)
+	}
+	if out.ArchivePath != "" && !isValidPath(out.ArchivePath) {
+		return Output{}, fmt.Errorf("invalid archive path %q", out.ArchivePath)
+	}
+	return out, nil
+}
+
+// outputModeFromPath infers the archive format from a file name, or returns ""
+func outputModeFromPath(p string) OutputMode {
+	name := strings.ToLower(p)
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return OutputTarGz
+	case strings.HasSuffix(name, ".zip"):
+		return OutputZip
+	}
+	return ""
+}
+
+// resolveArchivePath returns the absolute archive file for a project
+func (o Output) resolveArchivePath(projectPath, projectName string) (string, error) {
+	if o.ArchivePath == "" {
+		dir, err := resolveProjectDir(projectPath, projectName)
+		if err != nil {
+			return "", err
+		}
+		return dir + "." + string(o.Mode), nil
+	}
+	expanded, err := expandPath(o.ArchivePath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(expanded)
+}
+
+// CreateProjectArchive generates a project and packs it into an archive instead of
+// leaving a directory behind. The archive holds a single top-level projectName directory.
+func CreateProjectArchive(projectName, moduleName string, selectedFeatures map[string]bool, envVars map[string]string, out Output) (string, error) {
+	if scaffoldFS == nil {
+		return "", fmt.Errorf("scaffold filesystem not initialized - call SetScaffoldFS first")
+	}
+	return createProjectArchive(projectName, out, ".", nil, func(workDir string) error {
+		return createProject(projectName, moduleName, workDir, selectedFeatures, nil, envVars, nil)
+	})
+}
+
+// createProjectArchive runs generate in a temporary directory, packs the project it
+// created and removes the temporary directory again. It returns the archive path.
+func createProjectArchive(projectName string, out Output, projectPath string, report reporter, generate func(workDir string) error) (string, error) {
+	archivePath, err := out.resolveArchivePath(projectPath, projectName)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(archivePath); err == nil {
+		return "", fmt.Errorf("archive '%s' already exists", archivePath)
+	}
+
+	workDir, err := os.MkdirTemp("", "go-platform-template-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	if err := generate(workDir); err != nil {
+		return "", err
+	}
+
+	if err := report.step("Writing "+string(out.Mode)+" archive", func() error {
+		return writeArchive(filepath.Join(workDir, projectName), archivePath, out.Mode)
+	}); err != nil {
+		return "", fmt.Errorf("failed to write archive: %w", err)
+	}
+	return archivePath, nil
+}
+
+// writeArchive packs srcDir into archivePath under a top-level directory named after
+// srcDir. The archive is written to a temporary file first so a failure never leaves a
+// truncated archive behind.
+func writeArchive(srcDir, archivePath string, mode OutputMode) error {
+	if err := os.MkdirAll(filepath.Dir(archivePath), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(archivePath), ".archive-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	switch mode {
+	case OutputTarGz:
+		err = writeTarGz(tmp, srcDir)
+	case OutputZip:
+		err = writeZip(tmp, srcDir)
+	default:
+		err = fmt.Errorf("unknown archive format %q", mode)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), archivePath)
+}
+
+// walkArchive calls fn for every file and directory under srcDir with its slash-separated
+// name inside the archive
+func walkArchive(srcDir string, fn func(name, path string, info fs.FileInfo) error) error {
+	root := filepath.Base(srcDir)
+	return filepath.WalkDir(srcDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		name := root
+		if rel != "." {
+			name += "/" + filepath.ToSlash(rel)
+		}
+		return fn(name, path, info)
+	})
+}
+
+func writeTarGz(w io.Writer, srcDir string) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	err := walkArchive(srcDir, func(name, path string, info fs.FileInfo) error {
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		return copyFileTo(tw, path)
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func writeZip(w io.Writer, srcDir string) error {
+	zw := zip.NewWriter(w)
+
+	err := walkArchive(srcDir, func(name, path string, info fs.FileInfo) error {
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if info.IsDir() {
+			header.Name += "/"
+		} else {
+			header.Method = zip.Deflate
+		}
+		fw, err := zw.CreateHeader(header)
+		if err != nil || info.IsDir() {
+			return err
+		}
+		return copyFileTo(fw, path)
+	})
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func copyFileTo(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
@@ -0,0 +1,137 @@
+package scaffold
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"testing"
+)
+
+func TestParseOutput(t *testing.T) {
+	cases := []struct {
+		mode, archive string
+		want          OutputMode
+		wantErr       bool
+	}{
+		{"dir", "", OutputDir, false},
+		{"", "", OutputDir, false},
+		{"tar.gz", "", OutputTarGz, false},
+		{"TGZ", "", OutputTarGz, false},
+		{"zip", "out/api.bin", OutputZip, false},
+		{"dir", "out/api.tar.gz", OutputTarGz, false},
+		{"dir", "out/api.ZIP", OutputZip, false},
+		{"dir", "out/api.rar", "", true},
+		{"rar", "", "", true},
+	}
+
+	for _, tc := range cases {
+		got, err := ParseOutput(tc.mode, tc.archive)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("ParseOutput(%q, %q) error = %v, wantErr %v", tc.mode, tc.archive, err, tc.wantErr)
+			continue
+		}
+		if !tc.wantErr && got.Mode != tc.want {
+			t.Errorf("ParseOutput(%q, %q) mode = %q, want %q", tc.mode, tc.archive, got.Mode, tc.want)
+		}
+	}
+}
+
+func TestCreateProjectArchive(t *testing.T) {
+	for _, mode := range []OutputMode{OutputTarGz, OutputZip} {
+		t.Run(string(mode), func(t *testing.T) {
+			// Arrange
+			archivePath := filepath.Join(t.TempDir(), "dist", "demo."+string(mode))
+			var workDir string
+			generate := func(dir string) error {
+				workDir = dir
+				writeTestFile(t, filepath.Join(dir, "demo", "go.mod"), "module demo\n")
+				writeTestFile(t, filepath.Join(dir, "demo", "cmd", "api", "main.go"), "package main\n")
+				return nil
+			}
+
+			// Act
+			got, err := createProjectArchive("demo", Output{Mode: mode, ArchivePath: archivePath}, ".", nil, generate)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("createProjectArchive() error = %v", err)
+			}
+			if got != archivePath {
+				t.Errorf("archive path = %q, want %q", got, archivePath)
+			}
+			if _, err := os.Stat(workDir); !os.IsNotExist(err) {
+				t.Errorf("temporary directory %q was not removed", workDir)
+			}
+			want := []string{"demo/", "demo/cmd/", "demo/cmd/api/", "demo/cmd/api/main.go", "demo/go.mod"}
+			if names := archiveNames(t, archivePath, mode); !slices.Equal(names, want) {
+				t.Errorf("archive entries = %v, want %v", names, want)
+			}
+
+			if _, err := createProjectArchive("demo", Output{Mode: mode, ArchivePath: archivePath}, ".", nil, generate); err == nil {
+				t.Error("expected an error when the archive already exists")
+			}
+		})
+	}
+}
+
+func TestOutputDefaultArchivePath(t *testing.T) {
+	base := t.TempDir()
+
+	got, err := Output{Mode: OutputZip}.resolveArchivePath(base, "demo")
+	if err != nil || got != filepath.Join(base, "demo.zip") {
+		t.Errorf("resolveArchivePath() = %q, %v, want %q", got, err, filepath.Join(base, "demo.zip"))
+	}
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func archiveNames(t *testing.T, path string, mode OutputMode) []string {
+	t.Helper()
+	var names []string
+	if mode == OutputZip {
+		zr, err := zip.OpenReader(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer zr.Close()
+		for _, f := range zr.File {
+			names = append(names, f.Name)
+		}
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tr := tar.NewReader(gz)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			names = append(names, header.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
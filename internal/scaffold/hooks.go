@@ -0,0 +1,260 @@
+package scaffold
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// ConfigFileName is looked up in the working directory before the user config directory
+	ConfigFileName = ".scaffold.json"
+	// ConfigEnvVar points at an explicit config file and takes precedence over the defaults
+	ConfigEnvVar = "SCAFFOLD_CONFIG"
+)
+
+// Config holds scaffolder settings read from a JSON config file
+type Config struct {
+	// PostCreate commands run in the new project directory, in order, after generation
+	PostCreate []string `json:"post_create"`
+	// Theme is one of ThemeNames; empty means auto-detect
+	Theme string `json:"theme,omitempty"`
+}
+
+// HookOutputMsg carries one line of post-create hook output to the processing view
+type HookOutputMsg struct {
+	Line string
+}
+
+// userConfigPath is the per-user config file, or "" when the platform has no config dir
+func userConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "go-platform-template", "config.json")
+}
+
+// configCandidates lists config files in lookup order
+func configCandidates() []string {
+	if path := os.Getenv(ConfigEnvVar); path != "" {
+		return []string{path}
+	}
+	candidates := []string{ConfigFileName}
+	if path := userConfigPath(); path != "" {
+		candidates = append(candidates, path)
+	}
+	return candidates
+}
+
+// LoadConfig reads the scaffolder config from $SCAFFOLD_CONFIG, ./.scaffold.json or
+// <user config dir>/go-platform-template/config.json, whichever exists first.
+// A missing file is not an error and yields an empty Config.
+func LoadConfig() (Config, error) {
+	var cfg Config
+
+	if path := os.Getenv(ConfigEnvVar); path != "" {
+		// An explicitly configured file must exist
+		if _, err := os.Stat(path); err != nil {
+			return cfg, fmt.Errorf("config file %s: %w", path, err)
+		}
+	}
+
+	for _, path := range configCandidates() {
+		content, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return cfg, fmt.Errorf("failed to read config file %s: %w", path, err)
+		}
+		if err := json.Unmarshal(content, &cfg); err != nil {
+			return cfg, fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+		return cfg, nil
+	}
+	return cfg, nil
+}
+
+// SaveTheme stores the theme in the config file LoadConfig reads, creating the user
+// config file when none exists. Other keys in the file are kept as they are.
+func SaveTheme(theme string) error {
+	path := ""
+	for _, candidate := range configCandidates() {
+		if _, err := os.Stat(candidate); err == nil {
+			path = candidate
+			break
+		}
+	}
+	if path == "" {
+		path = os.Getenv(ConfigEnvVar)
+	}
+	if path == "" {
+		path = userConfigPath()
+	}
+	if path == "" {
+		return errors.New("no user config directory available")
+	}
+
+	settings := map[string]json.RawMessage{}
+	content, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(content, &settings); err != nil {
+			return fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	value, err := json.Marshal(theme)
+	if err != nil {
+		return err
+	}
+	settings["theme"] = value
+	content, err = json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(content, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// parseHooks splits the TUI hook input into commands separated by ";"
+func parseHooks(input string) []string {
+	var hooks []string
+	for _, part := range strings.Split(input, ";") {
+		if hook := strings.TrimSpace(part); hook != "" {
+			hooks = append(hooks, hook)
+		}
+	}
+	return hooks
+}
+
+// runHooks runs each command through the system shell inside projectDir, passing every
+// output line to output. It stops at the first failing command.
+func runHooks(projectDir string, hooks []string, output func(string)) error {
+	for _, hook := range hooks {
+		output("$ " + hook)
+
+		var cmd *exec.Cmd
+		if runtime.GOOS == "windows" {
+			cmd = exec.Command("cmd", "/C", hook) //nolint:gosec // hooks are supplied by the user running the scaffolder
+		} else {
+			cmd = exec.Command("sh", "-c", hook) //nolint:gosec // hooks are supplied by the user running the scaffolder
+		}
+		cmd.Dir = projectDir
+
+		pr, pw := io.Pipe()
+		cmd.Stdout = pw
+		cmd.Stderr = pw
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			scanner := bufio.NewScanner(pr)
+			for scanner.Scan() {
+				output(scanner.Text())
+			}
+			// Drain anything left after an overlong line so the command never blocks
+			_, _ = io.Copy(io.Discard, pr)
+		}()
+
+		err := cmd.Run()
+		pw.Close()
+		<-done
+
+		if err != nil {
+			return fmt.Errorf("post-create hook %q failed: %w", hook, err)
+		}
+	}
+	return nil
+}
+
+// waitForProgress delivers the next message from a running generation
+func waitForProgress(ch <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-ch
+	}
+}
+
+// maxHookLogLines bounds how much hook output the processing view keeps
+const maxHookLogLines = 200
+
+func (m *Model) viewHooks() string {
+	header := m.renderHeader("Post-create Hooks", 7, 8)
+
+	hint := m.styles.Info.Render("→ Leave empty to skip")
+	if hooks := parseHooks(m.hookInput.Value()); len(hooks) > 0 {
+		hint = m.styles.Success.Render(fmt.Sprintf("✓ %d command(s) will run in the new project", len(hooks)))
+	}
+
+	form := lipgloss.JoinVertical(
+		lipgloss.Left,
+		m.styles.Label.Render("Commands (separated by ;):"),
+		m.hookInput.View(),
+		"",
+		hint,
+		"",
+		m.styles.Help.Render("Defaults come from "+ConfigFileName+" (post_create) or $"+ConfigEnvVar),
+	)
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		header,
+		"",
+		m.renderContainer(form),
+		"",
+	)
+
+	if m.configErr != nil {
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			content,
+			m.styles.Error.Render("Config ignored: "+m.configErr.Error()),
+			"",
+		)
+	}
+
+	content = lipgloss.JoinVertical(
+		lipgloss.Left,
+		content,
+		m.renderKeyboardHelp("Enter", "Next", "CTRL+C", "Cancel"),
+		"",
+		m.renderFooter(),
+	)
+
+	return m.padContent(content)
+}
+
+// renderHookLog shows the most recent hook output lines
+func (m *Model) renderHookLog(lines int) string {
+	log := m.hookLog
+	if len(log) > lines {
+		log = log[len(log)-lines:]
+	}
+	rendered := make([]string, len(log))
+	for i, line := range log {
+		if len(line) > CONTAINER_WIDTH-8 {
+			line = line[:CONTAINER_WIDTH-11] + "..."
+		}
+		rendered[i] = m.styles.Blurred.Render(line)
+	}
+	return strings.Join(rendered, "\n")
+}
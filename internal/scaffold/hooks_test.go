@@ -0,0 +1,65 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseHooks(t *testing.T) {
+	got := parseHooks(" pre-commit install ;; task setup;  ")
+	want := []string{"pre-commit install", "task setup"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseHooks() = %q, want %q", got, want)
+	}
+	if got := parseHooks("   "); got != nil {
+		t.Errorf("parseHooks(blank) = %q, want nil", got)
+	}
+}
+
+func TestRunHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands below use sh syntax")
+	}
+	dir := t.TempDir()
+
+	var output []string
+	err := runHooks(dir, []string{"echo hello > greeting.txt && echo done", "exit 3", "echo never"}, func(line string) {
+		output = append(output, line)
+	})
+
+	if err == nil || !strings.Contains(err.Error(), `"exit 3"`) {
+		t.Fatalf("runHooks() error = %v, want failure of the second hook", err)
+	}
+	if _, statErr := os.Stat(filepath.Join(dir, "greeting.txt")); statErr != nil {
+		t.Error("first hook did not run in the project directory")
+	}
+	want := []string{"$ echo hello > greeting.txt && echo done", "done", "$ exit 3"}
+	if !reflect.DeepEqual(output, want) {
+		t.Errorf("runHooks() output = %q, want %q", output, want)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"post_create": ["go mod tidy"]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(ConfigEnvVar, path)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if !reflect.DeepEqual(cfg.PostCreate, []string{"go mod tidy"}) {
+		t.Errorf("PostCreate = %q", cfg.PostCreate)
+	}
+
+	t.Setenv(ConfigEnvVar, filepath.Join(t.TempDir(), "missing.json"))
+	if _, err := LoadConfig(); err == nil {
+		t.Error("LoadConfig() with a missing explicit file should fail")
+	}
+}
@@ -29,6 +29,10 @@ const (
 	StateBuildTestMenu
 	StateCodeQualityMenu
 	StateDepsMenu
+	StateServices
+	StateServiceFeatures
+	StateHooks
+	StateSettings
 )
 
 type Feature struct {
@@ -60,6 +64,29 @@ type Model struct {
 	envEditing bool
 	envInput   textinput.Model
 
+	// Services (one cmd/<name> binary each, sharing go.mod)
+	services            []Service
+	serviceInput        textinput.Model
+	serviceIndex        int
+	serviceFeatureFocus int
+
+	// Post-create hooks (commands run in the new project after generation)
+	config      Config
+	configErr   error
+	hooks       []string
+	hookInput   textinput.Model
+	hookLog     []string
+	hookWarning string
+	progress    <-chan tea.Msg
+
+	// Output mode chosen on the command line and the archive written, if any
+	output      Output
+	archivePath string
+
+	// Generation progress (per-step status and timing)
+	steps     []ProgressMsg
+	logOffset int
+
 	// UI Components
 	inputs     []textinput.Model
 	focusIndex int
@@ -68,7 +95,10 @@ type Model struct {
 	height     int
 
 	// Theme
-	styles Styles
+	styles      Styles
+	themeName   string
+	themeFocus  int
+	settingsErr error
 
 	// Features
 	features     []Feature
@@ -87,6 +117,7 @@ type Model struct {
 	// Validation
 	projectNameValid bool
 	moduleNameValid  bool
+	moduleWarning    string
 	projectPathValid bool
 
 	// Feature dependencies
@@ -94,9 +125,9 @@ type Model struct {
 }
 
 func NewModel() *Model {
-	// Detect theme from terminal
-	theme := DetectTheme()
-	styles := BuildStyles(theme)
+	// Saved theme choice, auto-detected from the terminal by default
+	cfg, cfgErr := LoadConfig()
+	styles := BuildStyles(ResolveTheme(cfg.Theme))
 
 	inputs := make([]textinput.Model, 3)
 
@@ -141,6 +172,27 @@ func NewModel() *Model {
 	envInput.Cursor.Style = styles.Focused
 	envInput.Width = CONTAINER_WIDTH - 20
 
+	// Service names input
+	serviceInput := textinput.New()
+	serviceInput.Placeholder = DefaultServiceName
+	serviceInput.CharLimit = 200
+	serviceInput.PromptStyle = styles.Focused
+	serviceInput.TextStyle = styles.Focused
+	serviceInput.PlaceholderStyle = styles.Blurred
+	serviceInput.Cursor.Style = styles.Focused
+	serviceInput.Width = CONTAINER_WIDTH - 12
+
+	// Post-create hooks input, prefilled from the config file
+	hookInput := textinput.New()
+	hookInput.Placeholder = "pre-commit install; task setup"
+	hookInput.CharLimit = 500
+	hookInput.PromptStyle = styles.Focused
+	hookInput.TextStyle = styles.Focused
+	hookInput.PlaceholderStyle = styles.Blurred
+	hookInput.Cursor.Style = styles.Focused
+	hookInput.Width = CONTAINER_WIDTH - 12
+	hookInput.SetValue(strings.Join(cfg.PostCreate, "; "))
+
 	s := spinner.New()
 	s.Spinner = spinner.Dot
 	s.Style = styles.Info
@@ -152,6 +204,8 @@ func NewModel() *Model {
 		"Authentication (JWT)": {},
 		"Database":             {},
 		"API Docs":             {},
+		"CORS":                 {},
+		"OIDC Provider":        {"User Management", "Database"},
 		"Docker":               {},
 		"Podman":               {},
 	}
@@ -178,7 +232,7 @@ func NewModel() *Model {
 		},
 		{
 			Name:        "File Storage",
-			Description: "MinIO S3-compatible file storage",
+			Description: "File storage on MinIO, S3, GCS, Azure or local disk",
 			Selected:    true,
 			Default:     true,
 		},
@@ -188,6 +242,18 @@ func NewModel() *Model {
 			Selected:    true,
 			Default:     true,
 		},
+		{
+			Name:        "CORS",
+			Description: "Config-driven CORS middleware",
+			Selected:    true,
+			Default:     true,
+		},
+		{
+			Name:        "OIDC Provider",
+			Description: "Sign in to other apps with your users (OpenID Connect)",
+			Selected:    false,
+			Default:     false,
+		},
 		{
 			Name:        "Docker",
 			Description: "Docker & Docker Compose setup",
@@ -205,6 +271,7 @@ func NewModel() *Model {
 	// Initialize main menu items
 	mainMenu := []MenuItem{
 		{Label: "Create New Project", Description: "Create project from template with feature selection", Action: "create"},
+		{Label: "Settings", Description: "Choose a color theme", Action: "settings"},
 		{Label: "Help", Description: "View keyboard shortcuts and documentation", Action: "help"},
 		{Label: "Exit", Description: "Exit the scaffolder", Action: "exit"},
 	}
@@ -216,6 +283,8 @@ func NewModel() *Model {
 		width:               80,
 		height:              24,
 		styles:              styles,
+		themeName:           cfg.Theme,
+		themeFocus:          themeIndex(cfg.Theme),
 		focusIndex:          0,
 		projectPath:         ".",
 		features:            features,
@@ -228,9 +297,18 @@ func NewModel() *Model {
 		envFocus:            0,
 		envEditing:          false,
 		envInput:            envInput,
+		serviceInput:        serviceInput,
+		config:              cfg,
+		configErr:           cfgErr,
+		hookInput:           hookInput,
 	}
 }
 
+// SetOutput makes the wizard pack generated projects into an archive instead of a directory
+func (m *Model) SetOutput(output Output) {
+	m.output = output
+}
+
 func (m *Model) Init() tea.Cmd {
 	return textinput.Blink
 }
@@ -265,11 +343,23 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if m.featureFocus < 0 {
 					m.featureFocus = len(m.features) - 1
 				}
+			} else if m.state == StateServiceFeatures {
+				m.serviceFeatureFocus--
+				if m.serviceFeatureFocus < 0 {
+					m.serviceFeatureFocus = len(m.availableServiceFeatures()) - 1
+				}
+			} else if m.state == StateSettings {
+				m.themeFocus--
+				if m.themeFocus < 0 {
+					m.themeFocus = len(ThemeNames) - 1
+				}
 			} else if m.state == StateEnvVars && !m.envEditing {
 				m.envFocus--
 				if m.envFocus < 0 {
 					m.envFocus = 7
 				}
+			} else if m.state == StateError {
+				m.scrollLog(-1)
 			}
 
 		case tea.KeyDown:
@@ -283,14 +373,41 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if m.featureFocus >= len(m.features) {
 					m.featureFocus = 0
 				}
+			} else if m.state == StateServiceFeatures {
+				m.serviceFeatureFocus++
+				if m.serviceFeatureFocus >= len(m.availableServiceFeatures()) {
+					m.serviceFeatureFocus = 0
+				}
+			} else if m.state == StateSettings {
+				m.themeFocus++
+				if m.themeFocus >= len(ThemeNames) {
+					m.themeFocus = 0
+				}
 			} else if m.state == StateEnvVars && !m.envEditing {
 				m.envFocus++
 				if m.envFocus > 7 {
 					m.envFocus = 0
 				}
+			} else if m.state == StateError {
+				m.scrollLog(1)
+			}
+
+		case tea.KeyPgUp:
+			if m.state == StateError {
+				m.scrollLog(-stepLogLines)
+			}
+
+		case tea.KeyPgDown:
+			if m.state == StateError {
+				m.scrollLog(stepLogLines)
 			}
 
 		case tea.KeyEscape:
+			if m.state == StateSettings {
+				m.state = StateMainMenu
+				m.settingsErr = nil
+				return m, nil
+			}
 			if m.state == StateEnvVars && m.envEditing {
 				m.envEditing = false
 				m.envInput.Reset()
@@ -312,6 +429,14 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.checkDependents()
 				}
 				m.warning = m.getDependencyWarning()
+			} else if m.state == StateServiceFeatures {
+				m.toggleServiceFeature()
+			} else if m.state == StateServices {
+				return m, m.updateServiceInput(msg)
+			} else if m.state == StateHooks {
+				var cmd tea.Cmd
+				m.hookInput, cmd = m.hookInput.Update(msg)
+				return m, cmd
 			}
 
 		case tea.KeyTab:
@@ -331,8 +456,8 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return m, m.inputs[m.focusIndex].Focus()
 				}
 			} else if m.state == StateEnvVars && !m.envEditing {
-				m.state = StateConfirm
-				return m, nil
+				m.state = StateHooks
+				return m, m.hookInput.Focus()
 			}
 
 		case tea.KeyShiftTab:
@@ -363,6 +488,10 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					case "create":
 						m.state = StateWelcome
 						return m, nil
+					case "settings":
+						m.state = StateSettings
+						m.themeFocus = themeIndex(m.themeName)
+						return m, nil
 					case "help":
 						m.state = StateWelcome // Show help screen
 						m.message = m.getHelpText()
@@ -373,6 +502,10 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					}
 				}
 
+			case StateSettings:
+				m.selectTheme()
+				return m, nil
+
 			case StateWelcome:
 				m.state = StateProjectName
 				m.focusIndex = 0
@@ -403,18 +536,19 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if m.moduleName == "" {
 					m.moduleName = fmt.Sprintf("github.com/example/%s", m.projectName)
 				}
-				if !isValidModuleName(m.moduleName) {
-					m.err = fmt.Errorf("invalid module format: use 'domain.com/org/project'")
+				if reason := moduleNameError(m.moduleName); reason != "" {
+					m.err = fmt.Errorf("invalid module path %q: %s", m.moduleName, reason)
 					m.state = StateError
 					return m, nil
 				}
 				m.moduleNameValid = true
+				m.moduleWarning = ""
 				m.state = StateProjectPath
 				m.focusIndex = 2
 				m.inputs[2].Reset()
 				m.inputs[2].SetValue(".")
 				m.updateInputFocus()
-				return m, m.inputs[2].Focus()
+				return m, tea.Batch(m.inputs[2].Focus(), checkModulePublished(m.moduleName))
 
 			case StateProjectPath:
 				m.projectPath = strings.TrimSpace(m.inputs[2].Value())
@@ -422,7 +556,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.projectPath = "."
 				}
 				if !isValidPath(m.projectPath) {
-					m.err = fmt.Errorf("invalid path: use relative path like '.' or './projects'")
+					m.err = fmt.Errorf("invalid path %q: use a directory such as '.', './projects', '~/code' or an absolute path", m.projectPath)
 					m.state = StateError
 					return m, nil
 				}
@@ -432,6 +566,38 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 
 			case StateFeatures:
+				m.state = StateServices
+				m.warning = ""
+				if len(m.services) > 0 {
+					m.serviceInput.SetValue(serviceNames(m.services))
+				}
+				return m, m.serviceInput.Focus()
+
+			case StateServices:
+				names, err := parseServiceNames(m.serviceInput.Value())
+				if err != nil {
+					m.warning = err.Error()
+					return m, nil
+				}
+				m.warning = ""
+				m.services = newServices(names, m.selectedFeatureSet())
+				m.serviceInput.Blur()
+				if len(m.services) > 1 && len(m.availableServiceFeatures()) > 0 {
+					m.state = StateServiceFeatures
+					m.serviceIndex = 0
+					m.serviceFeatureFocus = 0
+					return m, nil
+				}
+				m.state = StateEnvVars
+				m.envFocus = 0
+				return m, nil
+
+			case StateServiceFeatures:
+				if m.serviceIndex < len(m.services)-1 {
+					m.serviceIndex++
+					m.serviceFeatureFocus = 0
+					return m, nil
+				}
 				m.state = StateEnvVars
 				m.envFocus = 0
 				return m, nil
@@ -468,6 +634,12 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				return m, nil
 
+			case StateHooks:
+				m.hooks = parseHooks(m.hookInput.Value())
+				m.hookInput.Blur()
+				m.state = StateConfirm
+				return m, nil
+
 			case StateConfirm:
 				m.state = StateProcessing
 				return m, tea.Batch(m.spinner.Tick, m.processScaffold())
@@ -482,7 +654,15 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.projectPath = "."
 				m.projectNameValid = false
 				m.moduleNameValid = false
+				m.moduleWarning = ""
 				m.projectPathValid = false
+				m.services = nil
+				m.serviceInput.Reset()
+				m.hooks = nil
+				m.hookLog = nil
+				m.steps = nil
+				m.logOffset = 0
+				m.hookInput.SetValue(strings.Join(m.config.PostCreate, "; "))
 				m.err = nil
 				m.focusIndex = 0
 				return m, nil
@@ -504,6 +684,18 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, m.updateInputs(msg)
 			}
 
+			// Handle service names input
+			if m.state == StateServices {
+				return m, m.updateServiceInput(msg)
+			}
+
+			// Handle post-create hooks input
+			if m.state == StateHooks {
+				var cmd tea.Cmd
+				m.hookInput, cmd = m.hookInput.Update(msg)
+				return m, cmd
+			}
+
 			// Handle env input editing
 			if m.state == StateEnvVars && m.envEditing {
 				var cmd tea.Cmd
@@ -519,13 +711,33 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, cmd
 		}
 
+	case ModuleCheckMsg:
+		m.recordModuleCheck(msg)
+		return m, nil
+
+	case ProgressMsg:
+		m.recordProgress(msg)
+		return m, waitForProgress(m.progress)
+
+	case HookOutputMsg:
+		m.hookLog = append(m.hookLog, msg.Line)
+		if len(m.hookLog) > maxHookLogLines {
+			m.hookLog = m.hookLog[len(m.hookLog)-maxHookLogLines:]
+		}
+		return m, waitForProgress(m.progress)
+
 	case ProcessCompleteMsg:
+		m.progress = nil
 		if msg.Err != nil {
 			m.err = msg.Err
 			m.state = StateError
+			// Start with the end of the log in view, where the failure is
+			m.scrollLog(len(m.failureLog()))
 			return m, nil
 		}
 		m.message = msg.Message
+		m.hookWarning = msg.Warning
+		m.archivePath = msg.ArchivePath
 		m.state = StateSuccess
 		return m, nil
 	}
@@ -575,8 +787,16 @@ func (m *Model) View() string {
 		return m.viewProjectPath()
 	case StateFeatures:
 		return m.viewFeatures()
+	case StateServices:
+		return m.viewServices()
+	case StateServiceFeatures:
+		return m.viewServiceFeatures()
 	case StateEnvVars:
 		return m.viewEnvVars()
+	case StateHooks:
+		return m.viewHooks()
+	case StateSettings:
+		return m.viewSettings()
 	case StateConfirm:
 		return m.viewConfirm()
 	case StateProcessing:
@@ -697,7 +917,7 @@ func (m *Model) viewWelcome() string {
 }
 
 func (m *Model) viewProjectName() string {
-	header := m.renderHeader("Project Name", 1, 6)
+	header := m.renderHeader("Project Name", 1, 8)
 
 	input := m.renderInputField(0)
 
@@ -739,7 +959,7 @@ func (m *Model) viewProjectName() string {
 }
 
 func (m *Model) viewModuleName() string {
-	header := m.renderHeader("Go Module", 2, 6)
+	header := m.renderHeader("Go Module", 2, 8)
 
 	input := m.renderInputField(1)
 
@@ -748,10 +968,10 @@ func (m *Model) viewModuleName() string {
 	defaultModule := fmt.Sprintf("github.com/example/%s", m.projectName)
 
 	if value != "" {
-		if isValidModuleName(value) {
+		if reason := moduleNameError(value); reason == "" {
 			hint = m.styles.Success.Render("✓ Valid module name")
 		} else {
-			hint = m.styles.Error.Render("✗ Invalid: use 'domain.com/org/project' format")
+			hint = m.styles.Error.Render("✗ Invalid: " + reason)
 		}
 	} else {
 		hint = m.styles.Info.Render("→ Will default to: " + defaultModule)
@@ -764,7 +984,7 @@ func (m *Model) viewModuleName() string {
 		"",
 		hint,
 		"",
-		m.styles.Help.Render("Examples: github.com/acme/myapp, gitlab.com/team/project"),
+		m.styles.Help.Render("Examples: github.com/acme/myapp, gitlab.com/Team/project/v2"),
 	)
 
 	footer := m.renderFooter()
@@ -785,7 +1005,7 @@ func (m *Model) viewModuleName() string {
 }
 
 func (m *Model) viewProjectPath() string {
-	header := m.renderHeader("Project Location", 3, 6)
+	header := m.renderHeader("Project Location", 3, 8)
 
 	input := m.renderInputField(2)
 
@@ -802,10 +1022,7 @@ func (m *Model) viewProjectPath() string {
 		hint = m.styles.Info.Render("→ Default: current directory (.)")
 	}
 
-	fullPath := value + "/" + m.projectName
-	if value == "." {
-		fullPath = "./" + m.projectName
-	}
+	fullPath := displayProjectDir(value, m.projectName)
 
 	form := lipgloss.JoinVertical(
 		lipgloss.Left,
@@ -817,6 +1034,9 @@ func (m *Model) viewProjectPath() string {
 		m.styles.Description.Render("Where to create the project:"),
 		m.styles.Info.Render(fullPath),
 	)
+	if m.moduleWarning != "" {
+		form = lipgloss.JoinVertical(lipgloss.Left, form, "", m.styles.Warning.Render("⚠ "+m.moduleWarning))
+	}
 
 	footer := m.renderFooter()
 	helpKeys := m.renderKeyboardHelp("Enter", "Next", "TAB", "Cycle")
@@ -836,7 +1056,7 @@ func (m *Model) viewProjectPath() string {
 }
 
 func (m *Model) viewFeatures() string {
-	header := m.renderHeader("Select Features", 4, 6)
+	header := m.renderHeader("Select Features", 4, 8)
 
 	featuresList := ""
 	for i, feat := range m.features {
@@ -923,7 +1143,7 @@ func (m *Model) viewFeatures() string {
 }
 
 func (m *Model) viewEnvVars() string {
-	header := m.renderHeader("Environment Variables", 5, 6)
+	header := m.renderHeader("Environment Variables", 6, 8)
 
 	envFields := []struct {
 		key   string
@@ -1007,12 +1227,9 @@ func (m *Model) viewEnvVars() string {
 }
 
 func (m *Model) viewConfirm() string {
-	header := m.renderHeader("Review & Confirm", 6, 6)
+	header := m.renderHeader("Review & Confirm", 8, 8)
 
-	fullPath := m.projectPath + "/" + m.projectName
-	if m.projectPath == "." {
-		fullPath = "./" + m.projectName
-	}
+	fullPath := displayProjectDir(m.projectPath, m.projectName)
 
 	selectedFeatures := ""
 	for _, feat := range m.features {
@@ -1029,11 +1246,58 @@ func (m *Model) viewConfirm() string {
 		m.renderKeyValue("Project Name", m.projectName),
 		m.renderKeyValue("Go Module", m.moduleName),
 		m.renderKeyValue("Project Path", fullPath),
+	)
+	if m.output.IsArchive() {
+		target, err := m.output.resolveArchivePath(m.projectPath, m.projectName)
+		if err != nil {
+			target = m.output.ArchivePath
+		}
+		details = lipgloss.JoinVertical(lipgloss.Left, details, m.renderKeyValue("Archive", target))
+	}
+	if m.moduleWarning != "" {
+		details = lipgloss.JoinVertical(lipgloss.Left, details, m.styles.Warning.Render("⚠ "+m.moduleWarning))
+	}
+	details = lipgloss.JoinVertical(
+		lipgloss.Left,
+		details,
 		"",
 		m.styles.Label.Render("Selected Features:"),
 		selectedFeatures,
 	)
 
+	if len(m.services) > 1 {
+		var serviceLines []string
+		for _, svc := range m.services {
+			var feats []string
+			for _, name := range serviceFeatures {
+				if svc.Features[name] {
+					feats = append(feats, name)
+				}
+			}
+			if len(feats) == 0 {
+				feats = append(feats, "health check only")
+			}
+			serviceLines = append(serviceLines, "• cmd/"+svc.Name+": "+strings.Join(feats, ", "))
+		}
+		details = lipgloss.JoinVertical(
+			lipgloss.Left,
+			details,
+			"",
+			m.styles.Label.Render("Services (shared go.mod):"),
+			strings.Join(serviceLines, "\n"),
+		)
+	}
+
+	if len(m.hooks) > 0 {
+		details = lipgloss.JoinVertical(
+			lipgloss.Left,
+			details,
+			"",
+			m.styles.Label.Render("Post-create Hooks:"),
+			"$ "+strings.Join(m.hooks, "\n$ "),
+		)
+	}
+
 	confirmBox := m.styles.ContainerPrimary.Render(
 		lipgloss.JoinVertical(
 			lipgloss.Left,
@@ -1097,16 +1361,22 @@ func (m *Model) viewConfirm() string {
 func (m *Model) viewProcessing() string {
 	header := m.renderHeader("Creating Project", 5, 5)
 
-	content := lipgloss.JoinVertical(
-		lipgloss.Center,
-		"",
-		m.styles.Info.Render(m.spinner.View()+" Processing..."),
-		"",
-		m.styles.Description.Render("Setting up project structure..."),
-		m.styles.Description.Render("Creating directories and files..."),
-		m.styles.Description.Render("Initializing git repository..."),
-		"",
-	)
+	lines := m.renderSteps()
+	if len(lines) == 0 {
+		lines = append(lines, m.styles.Info.Render(m.spinner.View()+" Preparing..."))
+	}
+
+	content := lipgloss.JoinVertical(lipgloss.Left, lines...)
+
+	if len(m.hookLog) > 0 {
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			content,
+			"",
+			m.styles.Label.Render("Post-create hooks:"),
+			m.renderHookLog(8),
+		)
+	}
 
 	fullContent := lipgloss.JoinVertical(
 		lipgloss.Left,
@@ -1123,9 +1393,15 @@ func (m *Model) viewProcessing() string {
 func (m *Model) viewSuccess() string {
 	header := m.renderHeader("Success!", 5, 5)
 
-	fullPath := m.projectPath + "/" + m.projectName
-	if m.projectPath == "." {
-		fullPath = "./" + m.projectName
+	fullPath := displayProjectDir(m.projectPath, m.projectName)
+	location, firstStep := fullPath, fmt.Sprintf("cd %s", fullPath)
+	if m.archivePath != "" {
+		location = m.archivePath
+		extract := fmt.Sprintf("tar -xzf %s", m.archivePath)
+		if m.output.Mode == OutputZip {
+			extract = fmt.Sprintf("unzip %s", m.archivePath)
+		}
+		firstStep = fmt.Sprintf("%s && cd %s", extract, m.projectName)
 	}
 
 	successContent := m.styles.ContainerPrimary.Render(
@@ -1133,17 +1409,27 @@ func (m *Model) viewSuccess() string {
 			lipgloss.Left,
 			m.styles.Success.Render("✓ Project created successfully!"),
 			"",
-			m.renderKeyValue("Location", fullPath),
+			m.renderKeyValue("Location", location),
 			m.renderKeyValue("Module", m.moduleName),
 		),
 	)
 
+	if m.hookWarning != "" {
+		successContent = lipgloss.JoinVertical(
+			lipgloss.Left,
+			successContent,
+			"",
+			m.styles.Error.Render("⚠ "+m.hookWarning),
+			m.renderHookLog(10),
+		)
+	}
+
 	nextSteps := m.renderContainer(
 		lipgloss.JoinVertical(
 			lipgloss.Left,
 			m.styles.Focused.Render("📋 Next Steps:"),
 			"",
-			"1. "+m.styles.Description.Render(fmt.Sprintf("cd %s", fullPath)),
+			"1. "+m.styles.Description.Render(firstStep),
 			"2. "+m.styles.Description.Render("cp .env.example .env"),
 			"3. "+m.styles.Description.Render("make dev-d"),
 			"4. "+m.styles.Description.Render("Visit http://localhost:8080/swagger"),
@@ -1158,6 +1444,8 @@ func (m *Model) viewSuccess() string {
 		"Database":             "✓ PostgreSQL Database Integration",
 		"File Storage":         "✓ MinIO File Storage",
 		"API Docs":             "✓ Auto-Generated Swagger Docs",
+		"CORS":                 "✓ Configurable CORS Policy",
+		"OIDC Provider":        "✓ OpenID Connect Provider",
 		"Docker":               "✓ Docker & Docker Compose Setup",
 		"Podman":               "✓ Podman & Podman Compose Setup",
 	}
@@ -1215,6 +1503,14 @@ func (m *Model) viewError() string {
 	errorBox := m.styles.ContainerPrimary.Render(
 		m.styles.Error.Render("✗ " + m.err.Error()),
 	)
+	if log := m.renderFailureLog(); log != "" {
+		errorBox = lipgloss.JoinVertical(
+			lipgloss.Left,
+			errorBox,
+			"",
+			m.renderContainer(log),
+		)
+	}
 
 	footer := m.renderFooter()
 	helpKeys := m.renderKeyboardHelp("Enter", "Try Again", "CTRL+C", "Exit")
@@ -1310,23 +1606,6 @@ func isValidProjectName(name string) bool {
 	return matched
 }
 
-func isValidModuleName(name string) bool {
-	if len(name) == 0 || !strings.Contains(name, "/") {
-		return false
-	}
-	matched, _ := regexp.MatchString(`^[a-z0-9./_-]+$`, name)
-	return matched
-}
-
-func isValidPath(path string) bool {
-	if len(path) == 0 {
-		return true
-	}
-	// Allow relative paths like ., .., ./path, ../path, path/to/dir
-	matched, _ := regexp.MatchString(`^\.{1,2}(/[a-zA-Z0-9._-]+)*$|^[a-zA-Z0-9._-]+(/[a-zA-Z0-9._-]+)*$|^\./?$`, path)
-	return matched
-}
-
 // enableDependencies ensures required dependencies are selected
 func (m *Model) enableDependencies(featureName string) {
 	deps, ok := m.featureDependencies[featureName]
@@ -1403,13 +1682,22 @@ func (m *Model) getHelpText() string {
   2. Enter project name (lowercase, hyphens/underscores)
   3. Enter Go module path (or press ENTER for default)
   4. Select features you need
-  5. Confirm to create project
+  5. Name your services (comma-separated, e.g. api,worker)
+     and pick which features each one serves
+  6. Optionally list post-create commands (separated by ;)
+  7. Confirm to create project
 
 💡 TIPS
   • Project names: my-project, my_api, api2go
-  • Module format: github.com/org/project
+  • Module format: github.com/org/project (major versions end in /v2, /v3, ...)
   • Features auto-select dependencies
-  • Project created in parent directory (../project-name)
+  • Multiple services share one go.mod, each gets cmd/<name>
+  • Default post-create commands can be set in .scaffold.json:
+    {"post_create": ["pre-commit install", "task setup"]}
+  • Project path accepts relative, absolute and ~ paths
+    (e.g. ../work, ~/code, /srv/apps, C:\Users\me\code)
+  • Settings picks a dark, light, high-contrast or no-color theme;
+    NO_COLOR always turns colors off
 
 Press CTRL+C to return to main menu`
 }
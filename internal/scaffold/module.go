@@ -0,0 +1,129 @@
+package scaffold
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/mod/module"
+)
+
+// defaultModuleProxy is used when GOPROXY is unset, as in the go command
+const defaultModuleProxy = "https://proxy.golang.org"
+
+// moduleProxyTimeout bounds the published-module lookup so a slow network never blocks the wizard
+const moduleProxyTimeout = 3 * time.Second
+
+// ModuleCheckMsg reports the latest published version of a module path; an empty
+// Version means the path is free
+type ModuleCheckMsg struct {
+	Path    string
+	Version string
+	Err     error
+}
+
+// moduleNameError explains why path is not a valid module path, or returns "" when it is.
+// The rules are the go command's (golang.org/x/mod/module.CheckPath): a dotted, lowercase
+// first element, version suffixes such as /v2, and no invalid characters.
+func moduleNameError(path string) string {
+	err := module.CheckPath(path)
+	if err == nil {
+		return ""
+	}
+	var pathErr *module.InvalidPathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Err.Error()
+	}
+	return err.Error()
+}
+
+// moduleProxyURL returns the first HTTP(S) proxy in GOPROXY, or "" when the list starts
+// with "direct" or "off" and no proxy should be asked
+func moduleProxyURL() string {
+	goproxy := os.Getenv("GOPROXY")
+	if goproxy == "" {
+		return defaultModuleProxy
+	}
+	for _, entry := range strings.FieldsFunc(goproxy, func(r rune) bool { return r == ',' || r == '|' }) {
+		entry = strings.TrimSpace(entry)
+		if strings.HasPrefix(entry, "https://") || strings.HasPrefix(entry, "http://") {
+			return strings.TrimSuffix(entry, "/")
+		}
+		if entry == "direct" || entry == "off" {
+			return ""
+		}
+	}
+	return ""
+}
+
+// isPrivateModule reports whether GONOPROXY or GOPRIVATE keep path away from proxies
+func isPrivateModule(path string) bool {
+	for _, env := range []string{"GONOPROXY", "GOPRIVATE"} {
+		if module.MatchPrefixPatterns(os.Getenv(env), path) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupPublishedModule asks proxy for the latest version of path. It returns "" when
+// the proxy does not know the module.
+func lookupPublishedModule(ctx context.Context, client *http.Client, proxy, path string) (string, error) {
+	escaped, err := module.EscapePath(path)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, proxy+"/"+escaped+"/@latest", nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var info struct {
+			Version string
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+			return "", fmt.Errorf("invalid proxy response: %w", err)
+		}
+		return info.Version, nil
+	case http.StatusNotFound, http.StatusGone:
+		return "", nil
+	default:
+		return "", fmt.Errorf("module proxy returned %s", resp.Status)
+	}
+}
+
+// checkModulePublished looks path up on the module proxy in the background. It returns
+// nil when proxies are disabled or the path is private.
+func checkModulePublished(path string) tea.Cmd {
+	proxy := moduleProxyURL()
+	if proxy == "" || isPrivateModule(path) {
+		return nil
+	}
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), moduleProxyTimeout)
+		defer cancel()
+		version, err := lookupPublishedModule(ctx, http.DefaultClient, proxy, path)
+		return ModuleCheckMsg{Path: path, Version: version, Err: err}
+	}
+}
+
+// recordModuleCheck turns a lookup result for the current module path into a warning
+func (m *Model) recordModuleCheck(msg ModuleCheckMsg) {
+	if msg.Path != m.moduleName || msg.Err != nil || msg.Version == "" {
+		return
+	}
+	m.moduleWarning = fmt.Sprintf("%s is already published (latest %s); use another path unless you own it", msg.Path, msg.Version)
+}
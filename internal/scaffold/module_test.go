@@ -0,0 +1,85 @@
+package scaffold
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestModuleNameError(t *testing.T) {
+	valid := []string{
+		"github.com/acme/myapp",
+		"github.com/MurtadaNazar/go-api-template",
+		"gitlab.com/team/project/v2",
+		"example.com/my_api",
+		"example.com",
+	}
+	for _, path := range valid {
+		if reason := moduleNameError(path); reason != "" {
+			t.Errorf("moduleNameError(%q) = %q, want valid", path, reason)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"myapp",
+		"GitHub.com/acme/myapp",
+		"github.com/acme/myapp/",
+		"github.com/acme/my app",
+		"-example.com/app",
+		"github.com/acme/../app",
+	}
+	for _, path := range invalid {
+		if moduleNameError(path) == "" {
+			t.Errorf("moduleNameError(%q) = valid, want an error", path)
+		}
+	}
+}
+
+func TestModuleProxyURL(t *testing.T) {
+	cases := map[string]string{
+		"":                                 defaultModuleProxy,
+		"https://goproxy.io/,direct":       "https://goproxy.io",
+		"direct":                           "",
+		"off":                              "",
+		"https://a.example|https://b.test": "https://a.example",
+	}
+	for goproxy, want := range cases {
+		t.Setenv("GOPROXY", goproxy)
+		if got := moduleProxyURL(); got != want {
+			t.Errorf("moduleProxyURL() with GOPROXY=%q = %q, want %q", goproxy, got, want)
+		}
+	}
+}
+
+func TestLookupPublishedModule(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Uppercase letters are escaped as !lowercase in proxy URLs
+		if r.URL.Path == "/github.com/!acme/app/@latest" {
+			_, _ = w.Write([]byte(`{"Version":"v1.4.0","Time":"2024-01-15T12:00:00Z"}`))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	version, err := lookupPublishedModule(context.Background(), server.Client(), server.URL, "github.com/Acme/app")
+	if err != nil || version != "v1.4.0" {
+		t.Errorf("lookupPublishedModule(published) = %q, %v, want v1.4.0", version, err)
+	}
+
+	version, err = lookupPublishedModule(context.Background(), server.Client(), server.URL, "github.com/acme/unused")
+	if err != nil || version != "" {
+		t.Errorf("lookupPublishedModule(unknown) = %q, %v, want empty", version, err)
+	}
+}
+
+func TestCheckModulePublished_SkipsPrivateModules(t *testing.T) {
+	t.Setenv("GOPROXY", "")
+	t.Setenv("GOPRIVATE", "*.corp.example,github.com/acme")
+
+	if cmd := checkModulePublished("github.com/acme/internal-api"); cmd != nil {
+		t.Error("checkModulePublished() should not query the proxy for GOPRIVATE modules")
+	}
+}
@@ -0,0 +1,114 @@
+package scaffold
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// windowsReservedChars may not appear in Windows file names
+const windowsReservedChars = `<>:"|?*`
+
+// expandPath replaces a leading "~" with the user's home directory. Both "~/" and, on
+// Windows, "~\" are recognized; "~user" forms are left alone.
+func expandPath(p string) (string, error) {
+	if p != "~" && !strings.HasPrefix(p, "~/") && !(runtime.GOOS == "windows" && strings.HasPrefix(p, `~\`)) {
+		return p, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot expand ~: %w", err)
+	}
+	return filepath.Join(home, p[1:]), nil
+}
+
+// isValidPath reports whether p can be used as the project location. Relative and
+// absolute paths, "~" and, on Windows, drive letters, UNC shares and either separator
+// are accepted; control characters and names Windows cannot create are not.
+func isValidPath(p string) bool {
+	if p == "" {
+		return true
+	}
+	expanded, err := expandPath(p)
+	if err != nil {
+		return false
+	}
+	rest := expanded[len(filepath.VolumeName(expanded)):]
+	for _, elem := range strings.FieldsFunc(rest, isPathSeparator) {
+		if !isValidPathElem(elem) {
+			return false
+		}
+	}
+	return true
+}
+
+func isPathSeparator(r rune) bool {
+	return r < 0x80 && os.IsPathSeparator(uint8(r))
+}
+
+func isValidPathElem(elem string) bool {
+	for _, r := range elem {
+		if r < 0x20 || r == 0x7f {
+			return false
+		}
+		if runtime.GOOS == "windows" && strings.ContainsRune(windowsReservedChars, r) {
+			return false
+		}
+	}
+	if runtime.GOOS == "windows" && elem != "." && elem != ".." && strings.TrimRight(elem, ". ") != elem {
+		// Windows silently drops trailing dots and spaces
+		return false
+	}
+	return true
+}
+
+// resolveProjectDir returns the absolute directory a project named projectName is
+// created in under projectPath
+func resolveProjectDir(projectPath, projectName string) (string, error) {
+	if projectPath == "" {
+		projectPath = "."
+	}
+	expanded, err := expandPath(projectPath)
+	if err != nil {
+		return "", err
+	}
+	basePath, err := filepath.Abs(expanded)
+	if err != nil {
+		return "", fmt.Errorf("invalid project path: %w", err)
+	}
+	return filepath.Join(basePath, projectName), nil
+}
+
+// displayProjectDir formats the target directory for the wizard using the platform's
+// separator; "." stays visibly relative as in "./my-api"
+func displayProjectDir(projectPath, projectName string) string {
+	if projectPath == "" || projectPath == "." {
+		return "." + string(filepath.Separator) + projectName
+	}
+	if expanded, err := expandPath(projectPath); err == nil {
+		projectPath = expanded
+	}
+	return filepath.Join(projectPath, projectName)
+}
+
+// slashPath converts a feature.json entry to an io/fs path. Embedded paths always use
+// forward slashes, so backslashes from entries written on Windows are converted too. It
+// reports false for entries that are absolute or leave the feature directory.
+func slashPath(entry string) (string, bool) {
+	p := path.Clean(strings.ReplaceAll(entry, `\`, "/"))
+	return p, p != "." && fs.ValidPath(p)
+}
+
+// featurePath returns where a feature.json entry is copied from in the embedded FS and
+// to under projectDir
+func featurePath(featureDir, projectDir, entry string) (src, dst string, ok bool) {
+	rel, ok := slashPath(entry)
+	if !ok {
+		return "", "", false
+	}
+	return path.Join(featureDir, rel), filepath.Join(projectDir, filepath.FromSlash(rel)), true
+}
@@ -0,0 +1,113 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestIsValidPath(t *testing.T) {
+	valid := []string{".", "..", "./projects", "../work/api", "projects/api", "~", "~/code", "My Projects/2024"}
+	invalid := []string{"bad\x00path", "tab\tdir"}
+
+	switch runtime.GOOS {
+	case "windows":
+		valid = append(valid, `C:\Users\dev\code`, `D:/work`, `C:`, `\\server\share\code`, `.\projects`, `~\code`, `projects\api`)
+		invalid = append(invalid, `C:\work\a<b`, `C:\work\what?`, `dir\trailing.`, `dir\trailing `, `C:\a:b`)
+	default:
+		valid = append(valid, "/home/dev/code", "/tmp", `dir\with\backslashes`, "colons:are:fine")
+	}
+
+	for _, p := range valid {
+		if !isValidPath(p) {
+			t.Errorf("isValidPath(%q) = false, want true on %s", p, runtime.GOOS)
+		}
+	}
+	for _, p := range invalid {
+		if isValidPath(p) {
+			t.Errorf("isValidPath(%q) = true, want false on %s", p, runtime.GOOS)
+		}
+	}
+}
+
+func TestExpandPath(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+
+	cases := map[string]string{
+		"~":         home,
+		"~/code":    filepath.Join(home, "code"),
+		"./~":       "./~",
+		"~other/x":  "~other/x",
+		"code/~/go": "code/~/go",
+	}
+	if runtime.GOOS == "windows" {
+		cases[`~\code\api`] = filepath.Join(home, "code", "api")
+	}
+
+	for in, want := range cases {
+		got, err := expandPath(in)
+		if err != nil || got != want {
+			t.Errorf("expandPath(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+}
+
+func TestResolveProjectDir(t *testing.T) {
+	base := t.TempDir()
+
+	got, err := resolveProjectDir(base, "my-api")
+	if err != nil || got != filepath.Join(base, "my-api") {
+		t.Errorf("resolveProjectDir(absolute) = %q, %v", got, err)
+	}
+
+	cwd, _ := os.Getwd()
+	got, err = resolveProjectDir(".", "my-api")
+	if err != nil || got != filepath.Join(cwd, "my-api") {
+		t.Errorf("resolveProjectDir(.) = %q, %v, want under %q", got, err, cwd)
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		got, err = resolveProjectDir("~/code", "my-api")
+		if err != nil || got != filepath.Join(home, "code", "my-api") {
+			t.Errorf("resolveProjectDir(~/code) = %q, %v", got, err)
+		}
+	}
+}
+
+func TestFeaturePath(t *testing.T) {
+	projectDir := filepath.Join(t.TempDir(), "proj")
+
+	for _, entry := range []string{"internal/domain/auth/api/handler.go", `internal\domain\auth\api\handler.go`, "./internal/domain/auth/api/handler.go"} {
+		src, dst, ok := featurePath("scaffold/features/auth", projectDir, entry)
+		if !ok {
+			t.Fatalf("featurePath(%q) rejected", entry)
+		}
+		if src != "scaffold/features/auth/internal/domain/auth/api/handler.go" {
+			t.Errorf("featurePath(%q) src = %q, want a slash-separated embed path", entry, src)
+		}
+		if want := filepath.Join(projectDir, "internal", "domain", "auth", "api", "handler.go"); dst != want {
+			t.Errorf("featurePath(%q) dst = %q, want %q", entry, dst, want)
+		}
+	}
+
+	for _, entry := range []string{"", ".", "../base/go.mod", "/etc/passwd", `..\..\outside`} {
+		if _, _, ok := featurePath("scaffold/features/auth", projectDir, entry); ok {
+			t.Errorf("featurePath(%q) accepted, want rejected", entry)
+		}
+	}
+}
+
+func TestDisplayProjectDir(t *testing.T) {
+	sep := string(filepath.Separator)
+
+	if got := displayProjectDir(".", "my-api"); got != "."+sep+"my-api" {
+		t.Errorf("displayProjectDir(.) = %q", got)
+	}
+	if got := displayProjectDir("projects", "my-api"); got != "projects"+sep+"my-api" {
+		t.Errorf("displayProjectDir(projects) = %q", got)
+	}
+}
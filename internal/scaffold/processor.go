@@ -6,16 +6,23 @@ import (
 	"io/fs"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"strings"
 	"text/template"
 
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 type ProcessCompleteMsg struct {
 	Message string
 	Err     error
+	// Warning reports a problem after the project itself was created (e.g. a failed hook)
+	Warning string
+	// ArchivePath is the archive the project was packed into, if any
+	ArchivePath string
 }
 
 // scaffoldFS will be set by init in main package
@@ -26,112 +33,157 @@ func SetScaffoldFS(fs fs.FS) {
 	scaffoldFS = fs
 }
 
+// processScaffold generates the project in the background and streams step progress and
+// hook output back to the model; the final message on the channel is a ProcessCompleteMsg.
 func (m *Model) processScaffold() tea.Cmd {
-	return func() tea.Msg {
-		selectedFeatures := make(map[string]bool)
-		for _, feat := range m.features {
-			selectedFeatures[feat.Name] = feat.Selected
+	selectedFeatures := make(map[string]bool)
+	for _, feat := range m.features {
+		selectedFeatures[feat.Name] = feat.Selected
+	}
+	projectName, moduleName, projectPath := m.projectName, m.moduleName, m.projectPath
+	services, envVars, hooks, output := m.services, m.envVars, m.hooks, m.output
+
+	progress := make(chan tea.Msg, 64)
+	m.progress = progress
+	m.hookLog = nil
+	m.steps = nil
+	m.logOffset = 0
+
+	go func() {
+		report := reporter(func(msg ProgressMsg) { progress <- msg })
+		done := ProcessCompleteMsg{
+			Message: fmt.Sprintf("Project '%s' created successfully", projectName),
+		}
+		generate := func(projectPath string) error {
+			if err := createProject(projectName, moduleName, projectPath, selectedFeatures, services, envVars, report); err != nil {
+				return err
+			}
+			if len(hooks) > 0 {
+				projectDir, _ := resolveProjectDir(projectPath, projectName)
+				if err := report.step("Running post-create hooks", func() error {
+					return runHooks(projectDir, hooks, func(line string) {
+						progress <- HookOutputMsg{Line: line}
+					})
+				}); err != nil {
+					done.Warning = err.Error()
+				}
+			}
+			return nil
 		}
 
-		if err := createProject(m.projectName, m.moduleName, m.projectPath, selectedFeatures, m.envVars); err != nil {
-			return ProcessCompleteMsg{Err: err}
+		var err error
+		if output.IsArchive() {
+			// Hooks run in the temporary directory so their results end up in the archive
+			done.ArchivePath, err = createProjectArchive(projectName, output, projectPath, report, generate)
+		} else {
+			err = generate(projectPath)
 		}
-		return ProcessCompleteMsg{
-			Message: fmt.Sprintf("Project '%s' created successfully", m.projectName),
+		if err != nil {
+			progress <- ProcessCompleteMsg{Err: err}
+			return
 		}
-	}
+		progress <- done
+	}()
+
+	return waitForProgress(progress)
 }
 
 func CreateProjectDirect(projectName, moduleName, projectPath string, selectedFeatures map[string]bool, envVars map[string]string) error {
 	if scaffoldFS == nil {
 		return fmt.Errorf("scaffold filesystem not initialized - call SetScaffoldFS first")
 	}
-	return createProject(projectName, moduleName, projectPath, selectedFeatures, envVars)
+	return createProject(projectName, moduleName, projectPath, selectedFeatures, nil, envVars, nil)
 }
 
-func createProject(projectName, moduleName, projectPath string, selectedFeatures map[string]bool, envVars map[string]string) error {
-	// Resolve project path
-	var basePath string
-	if projectPath == "." {
-		basePath, _ = os.Getwd()
-	} else {
-		var err error
-		basePath, err = filepath.Abs(projectPath)
-		if err != nil {
-			return fmt.Errorf("invalid project path: %w", err)
-		}
+// CreateServicesProjectDirect creates a project containing several services (cmd/<name>) that share one go.mod.
+// Each service's features must be a subset of selectedFeatures.
+func CreateServicesProjectDirect(projectName, moduleName, projectPath string, selectedFeatures map[string]bool, services []Service, envVars map[string]string) error {
+	if scaffoldFS == nil {
+		return fmt.Errorf("scaffold filesystem not initialized - call SetScaffoldFS first")
+	}
+	return createProject(projectName, moduleName, projectPath, selectedFeatures, services, envVars, nil)
+}
+
+func createProject(projectName, moduleName, projectPath string, selectedFeatures map[string]bool, services []Service, envVars map[string]string, report reporter) error {
+	if len(services) == 0 {
+		services = []Service{{Name: DefaultServiceName, Features: selectedFeatures}}
 	}
 
-	projectDir := filepath.Join(basePath, projectName)
+	// Resolve project path ("~" expanded, relative paths against the working directory)
+	projectDir, err := resolveProjectDir(projectPath, projectName)
+	if err != nil {
+		return err
+	}
 
 	// Check if directory exists
 	if _, err := os.Stat(projectDir); err == nil {
 		return fmt.Errorf("directory '%s' already exists", projectName)
 	}
 
+	// abort removes the half-written project and reports a failed cleanup alongside the
+	// step's error, so neither is lost
+	abort := func(err error) error {
+		return apperrors.Join(err, os.RemoveAll(projectDir))
+	}
+
 	// Create project directory
-	if err := os.MkdirAll(projectDir, 0755); err != nil {
+	if err := report.step("Creating project directory", func() error { return os.MkdirAll(projectDir, 0755) }); err != nil {
 		return fmt.Errorf("failed to create project directory: %w", err)
 	}
 
 	// Copy base files first (from embedded FS)
-	if err := copyBaseScaffoldFromEmbed(projectDir); err != nil {
-		os.RemoveAll(projectDir)
-		return fmt.Errorf("failed to copy base files: %w", err)
+	if err := report.step("Copying base files", func() error { return copyBaseScaffoldFromEmbed(projectDir) }); err != nil {
+		return abort(fmt.Errorf("failed to copy base files: %w", err))
 	}
 
 	// Copy selected features (from embedded FS)
-	if err := copySelectedFeaturesFromEmbed(projectDir, selectedFeatures); err != nil {
-		os.RemoveAll(projectDir)
-		return fmt.Errorf("failed to copy features: %w", err)
+	if err := report.step("Copying selected features", func() error { return copySelectedFeaturesFromEmbed(projectDir, selectedFeatures) }); err != nil {
+		return abort(fmt.Errorf("failed to copy features: %w", err))
 	}
 
-	// Generate main.go from template
-	if err := generateMainGo(projectDir, moduleName, selectedFeatures); err != nil {
-		os.RemoveAll(projectDir)
-		return fmt.Errorf("failed to generate main.go: %w", err)
+	// Generate main.go and routes for every service
+	if err := report.step("Rendering service entrypoints and routes", func() error { return generateServices(projectDir, moduleName, selectedFeatures, services) }); err != nil {
+		return abort(err)
 	}
 
-	// Generate routes.go from template
-	if err := generateRoutesGo(projectDir, moduleName, selectedFeatures); err != nil {
-		os.RemoveAll(projectDir)
-		return fmt.Errorf("failed to generate routes.go: %w", err)
+	// Generate middleware.go from template
+	if err := report.step("Rendering middleware", func() error { return generateMiddlewareGo(projectDir, moduleName, selectedFeatures) }); err != nil {
+		return abort(fmt.Errorf("failed to generate middleware.go: %w", err))
 	}
 
 	// Replace placeholders
-	if err := replaceModuleNames(projectDir, projectName, moduleName); err != nil {
-		os.RemoveAll(projectDir)
-		return fmt.Errorf("failed to update module names: %w", err)
+	if err := report.step("Rewriting module names", func() error { return replaceModuleNames(projectDir, projectName, moduleName) }); err != nil {
+		return abort(fmt.Errorf("failed to update module names: %w", err))
 	}
 
 	// Process Makefile with container choice
-	if err := processMakefile(projectDir, selectedFeatures); err != nil {
-		os.RemoveAll(projectDir)
-		return fmt.Errorf("failed to process Makefile: %w", err)
+	if err := report.step("Processing Makefile", func() error { return processMakefile(projectDir, selectedFeatures, services) }); err != nil {
+		return abort(fmt.Errorf("failed to process Makefile: %w", err))
 	}
 
 	// Process README with container choice
-	if err := processReadme(projectDir, selectedFeatures); err != nil {
-		os.RemoveAll(projectDir)
-		return fmt.Errorf("failed to process README: %w", err)
+	if err := report.step("Processing README", func() error { return processReadme(projectDir, selectedFeatures) }); err != nil {
+		return abort(fmt.Errorf("failed to process README: %w", err))
+	}
+
+	// Point container builds at the primary service
+	if err := report.step("Updating container entrypoints", func() error { return processServiceEntrypoints(projectDir, services) }); err != nil {
+		return abort(fmt.Errorf("failed to update service entrypoints: %w", err))
 	}
 
 	// Clean up container files based on selection
-	if err := cleanupContainerFiles(projectDir, selectedFeatures); err != nil {
-		os.RemoveAll(projectDir)
-		return fmt.Errorf("failed to cleanup container files: %w", err)
+	if err := report.step("Removing unused container files", func() error { return cleanupContainerFiles(projectDir, selectedFeatures) }); err != nil {
+		return abort(fmt.Errorf("failed to cleanup container files: %w", err))
 	}
 
 	// Process .env file with user-provided values
-	if err := processEnvFile(projectDir, projectName, envVars); err != nil {
-		os.RemoveAll(projectDir)
-		return fmt.Errorf("failed to process .env file: %w", err)
+	if err := report.step("Writing .env", func() error { return processEnvFile(projectDir, projectName, envVars) }); err != nil {
+		return abort(fmt.Errorf("failed to process .env file: %w", err))
 	}
 
 	// Initialize git
-	if err := initializeGit(projectDir); err != nil {
-		os.RemoveAll(projectDir)
-		return fmt.Errorf("failed to initialize git: %w", err)
+	if err := report.step("Initializing git repository", func() error { return initializeGit(projectDir) }); err != nil {
+		return abort(fmt.Errorf("failed to initialize git: %w", err))
 	}
 
 	return nil
@@ -158,6 +210,8 @@ func copySelectedFeaturesFromEmbed(projectDir string, selectedFeatures map[strin
 		"Database":             "database",
 		"File Storage":         "file-storage",
 		"API Docs":             "api-docs",
+		"CORS":                 "cors",
+		"OIDC Provider":        "oidc-provider",
 		"Docker":               "docker",
 		"Podman":               "podman",
 	}
@@ -172,10 +226,11 @@ func copySelectedFeaturesFromEmbed(projectDir string, selectedFeatures map[strin
 			continue
 		}
 
-		featureDir := filepath.Join(scaffoldDir, featureID)
+		// Embedded FS paths always use forward slashes, whatever the OS
+		featureDir := path.Join(scaffoldDir, featureID)
 
 		// Read feature definition from embedded FS
-		featureFile := filepath.Join(featureDir, "feature.json")
+		featureFile := path.Join(featureDir, "feature.json")
 		content, err := fs.ReadFile(scaffoldFS, featureFile)
 		if err != nil {
 			// Feature not set up, skip
@@ -194,8 +249,10 @@ func copySelectedFeaturesFromEmbed(projectDir string, selectedFeatures map[strin
 
 		// Copy directories for this feature
 		for _, dir := range feature.DirectoriesToCopy {
-			srcPath := filepath.Join(featureDir, dir)
-			dstPath := filepath.Join(projectDir, dir)
+			srcPath, dstPath, ok := featurePath(featureDir, projectDir, dir)
+			if !ok {
+				continue
+			}
 
 			if _, err := fs.Stat(scaffoldFS, srcPath); err != nil {
 				continue
@@ -207,8 +264,10 @@ func copySelectedFeaturesFromEmbed(projectDir string, selectedFeatures map[strin
 
 		// Copy files for this feature
 		for _, file := range feature.Files {
-			srcPath := filepath.Join(featureDir, file)
-			dstPath := filepath.Join(projectDir, file)
+			srcPath, dstPath, ok := featurePath(featureDir, projectDir, file)
+			if !ok {
+				continue
+			}
 
 			if _, err := fs.Stat(scaffoldFS, srcPath); err == nil {
 				if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
@@ -246,26 +305,23 @@ func copyDirFromEmbed(srcPath, dstPath string) error {
 		return fmt.Errorf("source path %q not found. available: %v, error: %w", srcPath, available, err)
 	}
 
-	return fs.WalkDir(scaffoldFS, srcPath, func(path string, entry fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Calculate relative path from source
-		relPath, err := filepath.Rel(srcPath, path)
+	return fs.WalkDir(scaffoldFS, srcPath, func(embedded string, entry fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
 		// Skip the source directory itself
-		if relPath == "." {
+		if embedded == srcPath {
 			return nil
 		}
 
+		// Calculate relative path from source; WalkDir yields slash-separated paths
+		relPath := strings.TrimPrefix(embedded, srcPath+"/")
+
 		// Strip .tmpl extension (used to work around go:embed module boundary rules)
 		relPath = strings.TrimSuffix(relPath, ".tmpl")
 
-		targetPath := filepath.Join(dstPath, relPath)
+		targetPath := filepath.Join(dstPath, filepath.FromSlash(relPath))
 
 		if entry.IsDir() {
 			return os.MkdirAll(targetPath, 0755)
@@ -277,7 +333,7 @@ func copyDirFromEmbed(srcPath, dstPath string) error {
 		}
 
 		// Read and write file
-		content, err := fs.ReadFile(scaffoldFS, path)
+		content, err := fs.ReadFile(scaffoldFS, embedded)
 		if err != nil {
 			return err
 		}
@@ -290,15 +346,72 @@ func parseJSON(data []byte, v interface{}) error {
 	return json.Unmarshal(data, v)
 }
 
-func generateMainGo(projectDir, moduleName string, selectedFeatures map[string]bool) error {
+// generateServices writes cmd/<service>/main.go and the route registration for each service.
+// A single service keeps the classic internal/app/routes.go with RegisterRoutes; several
+// services each get internal/app/routes_<service>.go with their own Register<Service>Routes.
+func generateServices(projectDir, moduleName string, selectedFeatures map[string]bool, services []Service) error {
+	multi := len(services) > 1
+
+	for _, svc := range services {
+		features := serviceFeatureSet(selectedFeatures, svc)
+		routesFunc := "RegisterRoutes"
+		routesFile := "routes.go"
+		addrEnv := ""
+		if multi {
+			routesFunc = routesFuncName(svc.Name)
+			routesFile = "routes_" + strings.ReplaceAll(svc.Name, "-", "_") + ".go"
+			addrEnv = addrEnvName(svc.Name)
+		}
+
+		if err := generateMainGo(projectDir, moduleName, svc.Name, routesFunc, addrEnv, features); err != nil {
+			return fmt.Errorf("failed to generate main.go for %s: %w", svc.Name, err)
+		}
+		if err := generateRoutesGo(projectDir, moduleName, routesFile, routesFunc, features); err != nil {
+			return fmt.Errorf("failed to generate routes for %s: %w", svc.Name, err)
+		}
+	}
+
+	if multi {
+		// Base scaffold ships a single-service routes.go; every service now has its own file
+		if err := os.Remove(filepath.Join(projectDir, "internal", "app", "routes.go")); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// serviceFeatureSet overlays a service's own feature choices on the project-wide selection
+func serviceFeatureSet(selectedFeatures map[string]bool, svc Service) map[string]bool {
+	features := make(map[string]bool, len(selectedFeatures))
+	for name, selected := range selectedFeatures {
+		features[name] = selected
+	}
+	for _, name := range serviceFeatures {
+		features[name] = selectedFeatures[name] && svc.Features[name]
+	}
+	return features
+}
+
+func generateMainGo(projectDir, moduleName, serviceName, routesFunc, addrEnv string, selectedFeatures map[string]bool) error {
 	mainGoTemplate := `package main
 
 import (
-{{if .HasDocs}}	_ "{{.Module}}/docs" // Important: import the generated docs
+{{if or .HasUser .HasDatabase}}	"context"
+{{end}}{{if or .AddrEnv .HasUser .HasDatabase}}	"os"
+
+{{end}}{{if .HasDocs}}	_ "{{.Module}}/docs" // Important: import the generated docs
 {{end}}	bootstrap "{{.Module}}/internal/app"
 	"{{.Module}}/internal/platform/config"
 	"{{.Module}}/internal/platform/logger"
-
+{{if .HasUser}}
+	authzRepo "{{.Module}}/internal/domain/authz/repo"
+	authzService "{{.Module}}/internal/domain/authz/service"
+	settingsApi "{{.Module}}/internal/domain/settings/api"
+	settingsService "{{.Module}}/internal/domain/settings/service"
+	userRepo "{{.Module}}/internal/domain/user/repo"
+	userService "{{.Module}}/internal/domain/user/service"
+{{end}}
 	"github.com/gin-gonic/gin"
 )
 
@@ -323,7 +436,24 @@ import (
 func main() {
 	// Load config
 	cfg := config.LoadConfig()
-
+{{if .AddrEnv}}
+	// Services in the same project listen on their own address when {{.AddrEnv}} is set
+	if addr := os.Getenv("{{.AddrEnv}}"); addr != "" {
+		cfg.ServerAddr = addr
+	}
+{{end}}{{if .HasDatabase}}
+	// {{if .HasUser}}"config export|import" works on settings snapshots and {{end}}"anonymize" scrubs
+	// personal data instead of serving; logs go to stderr so command output can be
+	// redirected to a file
+	command := ""
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+	stdout := os.Stdout
+	if {{if .HasUser}}command == "config" || {{end}}command == "anonymize" {
+		os.Stdout = os.Stderr
+	}
+{{end}}
 	// Init logger
 	logr := logger.InitLogger()
 	defer func() { _ = logr.Logger.Sync() }()
@@ -331,13 +461,28 @@ func main() {
 
 {{if .HasDatabase}}	// Init DB
 	db := bootstrap.InitDB(cfg, logr.Sugar)
+{{end}}{{if .HasUser}}
+	if command == "config" {
+		roles := authzService.NewService(authzRepo.NewRoleRepo(db), logr.Sugar)
+		fields := userService.NewProfileFieldService(userRepo.NewProfileFieldRepo(db), logr.Sugar)
+		settings := settingsService.NewService(fields, roles, logr.Sugar)
+		code := settingsApi.RunCommand(context.Background(), settings, os.Args[2:], os.Stdin, stdout, os.Stderr)
+		_ = logr.Logger.Sync()
+		os.Exit(code)
+	}
+{{end}}{{if .HasDatabase}}
+	if command == "anonymize" {
+		code := bootstrap.RunAnonymizeCommand(context.Background(), db, cfg, os.Args[2:], stdout, os.Stderr)
+		_ = logr.Logger.Sync()
+		os.Exit(code)
+	}
 {{end}}
 	// Init Gin
 	r := gin.New()
-	bootstrap.SetupMiddleware(r, logr.Sugar)
+	bootstrap.SetupMiddleware(r, cfg, logr.Sugar)
 
 	// Register domain routes
-{{if .HasDatabase}}	bootstrap.RegisterRoutes(r, db, cfg, logr.Sugar)
+{{if .HasDatabase}}	bootstrap.{{.RoutesFunc}}(r, db, cfg, logr.Sugar)
 {{else}}	// No database features configured
 {{end}}
 {{if .HasDocs}}	// Setup Swagger
@@ -345,33 +490,44 @@ func main() {
 {{end}}
 	// Health check
 {{if .HasDatabase}}	r.GET("/health", bootstrap.HealthCheckHandler(db, logr.Sugar))
+	r.GET("/metrics", bootstrap.MetricsHandler(db, logr.Sugar))
 {{else}}	r.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
 {{end}}
-	// Start server
-{{if .HasDatabase}}	bootstrap.StartServer(r, cfg.ServerAddr, db, logr.Sugar)
-{{else}}	bootstrap.StartServer(r, cfg.ServerAddr, nil, logr.Sugar)
+	// Start server; HTTPS when TLS_CERT_FILE is set
+	tlsConfig, err := bootstrap.ServerTLSConfig(cfg.TLS)
+	if err != nil {
+		logr.Sugar.Fatalf("TLS initialization failed: %v", err)
+	}
+{{if .HasDatabase}}	bootstrap.StartServer(r, cfg.ServerAddr, tlsConfig, db, logr.Sugar)
+{{else}}	bootstrap.StartServer(r, cfg.ServerAddr, tlsConfig, nil, logr.Sugar)
 {{end}}
 }
 `
 
 	data := struct {
 		Module      string
+		RoutesFunc  string
+		AddrEnv     string
 		HasAuth     bool
 		HasUser     bool
 		HasDatabase bool
 		HasFile     bool
 		HasDocs     bool
+		HasCORS     bool
 		HasDocker   bool
 		HasPodman   bool
 	}{
 		Module:      moduleName,
+		RoutesFunc:  routesFunc,
+		AddrEnv:     addrEnv,
 		HasAuth:     selectedFeatures["Authentication (JWT)"],
 		HasUser:     selectedFeatures["User Management"],
 		HasDatabase: selectedFeatures["Database"],
 		HasFile:     selectedFeatures["File Storage"],
 		HasDocs:     selectedFeatures["API Docs"],
+		HasCORS:     selectedFeatures["CORS"],
 		HasDocker:   selectedFeatures["Docker"],
 		HasPodman:   selectedFeatures["Podman"],
 	}
@@ -381,11 +537,11 @@ func main() {
 		return fmt.Errorf("failed to parse main.go template: %w", err)
 	}
 
-	mainGoPath := filepath.Join(projectDir, "cmd", "server", "main.go")
+	mainGoPath := filepath.Join(projectDir, "cmd", serviceName, "main.go")
 
 	// Create directory structure first
 	if err := os.MkdirAll(filepath.Dir(mainGoPath), 0755); err != nil {
-		return fmt.Errorf("failed to create cmd/server directory: %w", err)
+		return fmt.Errorf("failed to create cmd/%s directory: %w", serviceName, err)
 	}
 
 	f, err := os.Create(mainGoPath)
@@ -401,35 +557,88 @@ func main() {
 	return nil
 }
 
-func generateRoutesGo(projectDir, moduleName string, selectedFeatures map[string]bool) error {
+func generateRoutesGo(projectDir, moduleName, fileName, routesFunc string, selectedFeatures map[string]bool) error {
 	routesGoTemplate := `package bootstrap
 
 import (
+{{if .HasAuth}}	"context"
+{{if .HasUser}}{{if .HasFile}}	"io"
+{{end}}{{end}}	"strconv"
 	"time"
 
-	"{{.Module}}/internal/platform/config"
-	"{{.Module}}/internal/platform/http/middleware"
+{{end}}	"{{.Module}}/internal/platform/config"
+	"{{.Module}}/internal/platform/examples"
+	"{{.Module}}/internal/platform/validation"
 {{if .HasAuth}}
+	"{{.Module}}/internal/platform/deprecation"
+	"{{.Module}}/internal/platform/http/middleware"
+	"{{.Module}}/internal/platform/ipban"
+	"{{.Module}}/internal/platform/jobs"
+	"{{.Module}}/internal/platform/quota"
 	authApi "{{.Module}}/internal/domain/auth/api"
+	"{{.Module}}/internal/domain/auth/oauth"
 	authRepo "{{.Module}}/internal/domain/auth/repo"
 	authService "{{.Module}}/internal/domain/auth/service"
+	"{{.Module}}/internal/domain/auth/webauthn"
+	"{{.Module}}/internal/platform/sms"
+	"{{.Module}}/internal/platform/webhook"
+	"{{.Module}}/internal/shared/jwk"
 {{end}}
 {{if .HasUser}}
 	userApi "{{.Module}}/internal/domain/user/api"
 	userRepo "{{.Module}}/internal/domain/user/repo"
 	userService "{{.Module}}/internal/domain/user/service"
+	"{{.Module}}/internal/platform/cache"
+	"{{.Module}}/internal/platform/database"
+	"{{.Module}}/internal/platform/risk"
+{{if .HasAuth}}
+	activityApi "{{.Module}}/internal/domain/activity/api"
+	activityRepo "{{.Module}}/internal/domain/activity/repo"
+	activityService "{{.Module}}/internal/domain/activity/service"
+	announcementApi "{{.Module}}/internal/domain/announcement/api"
+	"{{.Module}}/internal/domain/auth/actiontoken"
+	announcementRepo "{{.Module}}/internal/domain/announcement/repo"
+	announcementService "{{.Module}}/internal/domain/announcement/service"
+	authzApi "{{.Module}}/internal/domain/authz/api"
+	authzModel "{{.Module}}/internal/domain/authz/model"
+	userDto "{{.Module}}/internal/domain/user/dto"
+	"{{.Module}}/internal/platform/email"
+	"{{.Module}}/internal/platform/events"
+	settingsApi "{{.Module}}/internal/domain/settings/api"
+	settingsService "{{.Module}}/internal/domain/settings/service"
+{{end}}{{if .HasOIDC}}
+	oidcApi "{{.Module}}/internal/domain/oidc/api"
+	oidcModel "{{.Module}}/internal/domain/oidc/model"
+	oidcRepo "{{.Module}}/internal/domain/oidc/repo"
+	oidcService "{{.Module}}/internal/domain/oidc/service"
+{{end}}	authzRepo "{{.Module}}/internal/domain/authz/repo"
+	authzService "{{.Module}}/internal/domain/authz/service"
+{{end}}
+{{if .HasFile}}{{if .HasAuth}}
+	exportApi "{{.Module}}/internal/domain/export/api"
+	exportRepo "{{.Module}}/internal/domain/export/repo"
+	exportService "{{.Module}}/internal/domain/export/service"
 {{end}}
-{{if .HasFile}}
 	fileApi "{{.Module}}/internal/domain/file/api"
 	fileRepo "{{.Module}}/internal/domain/file/repo"
 	fileService "{{.Module}}/internal/domain/file/service"
+	"{{.Module}}/internal/platform/storage"
 {{end}}
 	"github.com/gin-gonic/gin"
-	"go.uber.org/zap"
+{{if .HasAuth}}{{if .HasUser}}	"github.com/google/uuid"
+{{end}}{{end}}	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
-func RegisterRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config, log *zap.SugaredLogger) {
+func {{.RoutesFunc}}(r *gin.Engine, db *gorm.DB, cfg *config.Config, log *zap.SugaredLogger) {
+	// Validation rules shared by all handlers; register custom tags here, before the
+	// handlers are created (see internal/platform/validation)
+	if len(cfg.ReservedUsernames) > 0 {
+		if err := validation.Register(validation.UsernamePolicy(cfg.ReservedUsernames...)); err != nil {
+			log.Fatalf("Registering validation rules failed: %v", err)
+		}
+	}
+
 {{if .HasAuth}}	// -----------------------
 	// JWT & Auth setup
 	// -----------------------
@@ -439,40 +648,517 @@ func RegisterRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config, log *zap.Sug
 		cfg.JWT.AccessExpiresIn,
 		cfg.JWT.RefreshExpiresIn,
 	)
+	jwtManager.SetImpersonationExpiry(cfg.JWT.ImpersonationExpiresIn)
+	jwtManager.SetSessionLifetimes(cfg.JWT.RememberMeExpiresIn, cfg.JWT.SessionMaxLifetime)
+	jwtManager.SetGuestExpiry(cfg.JWT.GuestExpiresIn)
+	// Tokens carry and must carry JWT_ISSUER and JWT_AUDIENCE; JWT_LEEWAY absorbs clock skew
+	jwtManager.SetIssuerAndAudience(cfg.JWT.Issuer, cfg.JWT.Audience)
+	jwtManager.SetLeeway(cfg.JWT.Leeway)
+	// Access tokens are signed with JWT_SIGNING_KEY (HS256) unless JWT_ALGORITHM selects key
+	// pairs; JWT_KEYS_DIR adds more for rotation, read again by the signing-key-reload job
+	jwtManager.SetPreviousSecrets(cfg.JWT.PreviousSigningKeys)
+	// Validated access tokens are remembered when JWT_TOKEN_CACHE_SIZE > 0 (nil otherwise);
+	// they are dropped when the keys change, so tokens of a removed key fail at once
+	tokenCache := middleware.NewTokenCache(cfg.JWT.TokenCacheSize, cfg.JWT.TokenCacheTTL)
+	reloadSigningKeys := func() error {
+		keys, err := authService.LoadSigningKeys(cfg.JWT)
+		if err != nil || len(keys) == 0 {
+			return err
+		}
+		if err := jwtManager.SetSigningKeys(keys); err != nil {
+			return err
+		}
+		tokenCache.Purge()
+		return nil
+	}
+	err := reloadSigningKeys()
+	if err != nil {
+		log.Fatalf("Loading the JWT signing keys failed: %v", err)
+	}
+	// Public keys served at /.well-known/jwks.json, joined by the OIDC key when enabled
+	var jwks []func() []jwk.Key
+	if cfg.JWT.Algorithm != "HS256" {
+		jwks = append(jwks, jwtManager.PublicKeys)
+	}
+
+	// Calls to routes marked with middleware.Deprecated, reported at /admin/deprecations
+	deprecations := deprecation.NewTracker(log)
+
+	// Auth events (user.registered, user.login, token.revoked) posted to the endpoints in
+	// WEBHOOKS, signed and retried in the background; none are sent when it is empty
+	var webhooks *webhook.Dispatcher
+	if len(cfg.Webhooks.Endpoints) > 0 {
+		d, err := webhook.NewDispatcher(cfg.Webhooks, log)
+		if err != nil {
+			log.Warnf("Webhook initialization failed, webhooks disabled: %v", err)
+			ReportComponent(Component{Name: "webhooks", Status: ComponentDegraded, Detail: err.Error()})
+		} else {
+			webhooks = d
+			OnShutdown("webhooks", webhooks.Close)
+			ReportComponent(Component{Name: "webhooks", Status: ComponentActive, Detail: strconv.Itoa(len(cfg.Webhooks.Endpoints)) + " endpoints"})
+		}
+	} else {
+		ReportComponent(Component{Name: "webhooks", Status: ComponentDisabled, Detail: "WEBHOOKS is empty"})
+	}
 {{end}}
-{{if .HasUser}}	uRepo := userRepo.NewUserRepo(db)
-	uService := userService.NewUserService(uRepo, log)
-	uHandler := userApi.NewUserHandler(uService, log)
+{{if .HasUser}}	// Short-lived cache of user role/status and role permission lookups, dropped on change
+	accountCache := cache.NewMemory()
+	if cfg.UserCacheTTL > 0 {
+		ReportComponent(Component{Name: "cache", Status: ComponentActive, Endpoint: "memory", Detail: "TTL " + cfg.UserCacheTTL.String()})
+	} else {
+		ReportComponent(Component{Name: "cache", Status: ComponentDisabled, Detail: "USER_CACHE_TTL is 0"})
+	}
+
+	authz := authzService.NewService(authzRepo.NewRoleRepo(db), log,
+		authzService.WithCache(accountCache, cfg.UserCacheTTL),
+		authzService.WithHierarchy(cfg.RoleHierarchy),
+	)
+{{if .HasAuth}}	roleHandler := authzApi.NewRoleHandler(authz, log)
 {{end}}
-{{if .HasAuth}}	tRepo := authRepo.NewTokenRepo(db)
+	// Disposable email domains, refreshed from DISPOSABLE_EMAIL_LIST_URL by a background job.
+	// DISPOSABLE_EMAIL_BLOCK=false leaves them to risk scoring instead of rejecting them.
+	disposableDomains := risk.NewDisposableDomains(cfg.Disposable, log)
+	var blockedDomains *risk.DisposableDomains
+	if cfg.Disposable.Block {
+		blockedDomains = disposableDomains
+	}
+
+	// Abuse scoring of registrations and logins; RISK_ENABLED=false turns it off
+	riskEngine, err := risk.NewDefaultEngine(cfg.Risk, disposableDomains, log)
+	if err != nil {
+		log.Warnf("Risk scoring initialization failed, abuse detection disabled: %v", err)
+	}
+
+	// SIGNUP_CAPTCHA=true asks every signup for a CAPTCHA, not only suspicious ones
+	var signupCaptcha risk.CaptchaVerifier
+	if cfg.Signup.RequireCaptcha {
+		signupCaptcha, err = risk.NewCaptchaVerifier(cfg.Risk)
+		if err != nil {
+			log.Fatalf("Signup CAPTCHA initialization failed: %v", err)
+		}
+	}
+
+	// Outgoing email (signup verification, login alerts, announcements); disabled when EMAIL_PROVIDER=none
+	emailSender, err := email.NewSender(cfg.Email, log)
+	switch {
+	case err != nil:
+		log.Warnf("Email provider initialization failed: %v", err)
+		ReportComponent(Component{Name: "email", Status: ComponentDegraded, Detail: err.Error()})
+	case emailSender == nil:
+		ReportComponent(Component{Name: "email", Status: ComponentDisabled, Detail: "EMAIL_PROVIDER is none"})
+	default:
+		ReportComponent(Component{Name: "email", Status: ComponentActive, Endpoint: cfg.Email.Provider})
+	}
+
+	// Single-use tokens behind emailed links, such as email verification
+	actionTokens := actiontoken.NewService(authRepo.NewActionTokenRepo(db))
+
+	// Profile, password and upload events, recorded in each user's activity feed
+	domainEvents := events.NewBus()
+	activities := activityService.NewService(activityRepo.NewActivityRepo(db), cfg.ActivityRetention, log)
+	activities.Subscribe(domainEvents)
+	activityHandler := activityApi.NewActivityHandler(activities, log)
+
+	uRepo := userRepo.NewUserRepo(db, userRepo.WithGmailFolding(cfg.EmailFoldGmail))
+	tRepo := authRepo.NewTokenRepo(db)
 	tStore := authService.NewTokenStore(tRepo, log)
 	aService := authService.NewAuthService(uRepo, jwtManager, tStore, log)
-	aHandler := authApi.NewAuthHandler(aService, log)
+	declaredFields, err := userService.DeclaredFields(cfg.ProfileFields)
+	if err != nil {
+		log.Fatalf("Invalid PROFILE_FIELDS: %v", err)
+	}
+	pfRepo := userRepo.WithDeclaredFields(userRepo.NewProfileFieldRepo(db), declaredFields)
+	uService := userService.NewUserService(uRepo, log,
+		userService.WithDefaultPhoneRegion(cfg.PhoneRegion),
+		userService.WithProfileFields(pfRepo),
+		userService.WithRevisions(userRepo.NewRevisionRepo(db)),
+		userService.WithAccountCache(accountCache, cfg.UserCacheTTL),
+		userService.WithRoles(authz.RoleExists),
+		userService.WithRiskEngine(riskEngine),
+		userService.WithDisposableEmailBlocking(blockedDomains),
+		userService.WithPasswordHistory(userRepo.NewPasswordHistoryRepo(db), cfg.PasswordHistory),
+		userService.WithSignupCaptcha(signupCaptcha),
+		userService.WithEmailVerification(emailSender, actionTokens, cfg.Signup),
+		userService.WithInvitations(emailSender, actionTokens, cfg.Invite),
+		userService.WithWebhooks(webhooks),
+		userService.WithEvents(domainEvents),
+		userService.WithSessionRevoker(func(ctx context.Context, userID string) error {
+			_, err := aService.RevokeUserRefreshTokens(ctx, userID)
+			return err
+		}),
+		userService.WithAccountMerger(database.NewUserMerger(db)),
+		userService.WithTags(userRepo.NewTagRepo(db)),
+		userService.WithNotes(userRepo.NewNoteRepo(db)),
+		userService.WithStatusHistory(userRepo.NewStatusHistoryRepo(db)),
+	)
+	uHandler := userApi.NewUserHandler(uService, log)
+{{if .HasAuth}}	// The admin view of single-user responses is for holders of users:list
+	uHandler.SetAdminView(func(ctx context.Context, role string) bool {
+		allowed, err := authz.Can(ctx, role, authzModel.PermUsersList)
+		return err == nil && allowed
+	})
+{{end}}	pfService := userService.NewProfileFieldService(pfRepo, log)
+	pfHandler := userApi.NewProfileFieldHandler(pfService, log)
+{{if .HasAuth}}	settingsHandler := settingsApi.NewSettingsHandler(settingsService.NewService(pfService, authz, log), log)
+{{end}}{{end}}
+{{if .HasAuth}}	aHandler := authApi.NewAuthHandler(aService, log)
+	aHandler.SetRefreshCookie(cfg.RefreshCookie, cfg.JWT.RefreshExpiresIn)
+
+	// Requests per user and day or month, by role (QUOTA_DAILY, QUOTA_MONTHLY); counted by
+	// every JWTAuth below
+	var quotas *quota.Limiter
+	if cfg.Quota.Enabled() {
+		quotas = quota.NewLimiter(quota.NewMemoryStore(), cfg.Quota, log)
+		ReportComponent(Component{Name: "quotas", Status: ComponentActive, Detail: "memory store"})
+	} else {
+		ReportComponent(Component{Name: "quotas", Status: ComponentDisabled, Detail: "QUOTA_DAILY and QUOTA_MONTHLY are empty"})
+	}
+	withQuota := middleware.WithQuota(quotas)
+{{if .HasUser}}	// Current role and status of an account, for the JWT account check and client certificates
+	accountLookup := func(ctx context.Context, userID string) (string, bool, error) {
+		account, err := uService.AccountStatus(ctx, userID)
+		if err != nil {
+			return "", false, err
+		}
+		return string(account.Role), account.IsActive(), nil
+	}
+	// Protected routes re-check the account behind the token unless AUTH_ACCOUNT_CHECK=off
+	accountCheck := middleware.WithAccountCheck(middleware.AccountCheckMode(cfg.JWT.AccountCheck), accountLookup, log)
+	// RequireRole also admits roles inheriting the required one (ROLE_HIERARCHY, roles' inherits)
+	roleHierarchy := middleware.WithRoleHierarchy(authz)
+	requireAuth := middleware.JWTAuth(jwtManager, accountCheck, roleHierarchy, withQuota, middleware.WithTokenCache(tokenCache))
+	// Read-only routes open to anonymous clients also accept guest tokens from /auth/guest
+	requireAuthOrGuest := middleware.JWTAuth(jwtManager, accountCheck, roleHierarchy, withQuota, middleware.WithTokenCache(tokenCache), middleware.AllowGuests())
+	// Routes backend services may call with client credentials tokens from /auth/token
+	requireAuthOrClient := middleware.JWTAuth(jwtManager, accountCheck, roleHierarchy, withQuota, middleware.WithTokenCache(tokenCache), middleware.AllowServiceClients())
+{{else}}	var accountLookup middleware.AccountLookup
+	requireAuth := middleware.JWTAuth(jwtManager, withQuota, middleware.WithTokenCache(tokenCache))
+	// Read-only routes open to anonymous clients also accept guest tokens from /auth/guest
+	requireAuthOrGuest := middleware.JWTAuth(jwtManager, withQuota, middleware.WithTokenCache(tokenCache), middleware.AllowGuests())
+{{end}}	// Signing a user out everywhere also forgets their cached access tokens
+	aService.OnUserTokensRevoked(tokenCache.InvalidateUser)
+	if tokenCache != nil {
+		ReportComponent(Component{Name: "token-cache", Status: ComponentActive, Endpoint: "memory", Detail: strconv.Itoa(cfg.JWT.TokenCacheSize) + " tokens, TTL " + cfg.JWT.TokenCacheTTL.String()})
+	} else {
+		ReportComponent(Component{Name: "token-cache", Status: ComponentDisabled, Detail: "JWT_TOKEN_CACHE_SIZE is 0"})
+	}
+
+	// SMS one-time codes (passwordless login and two-factor); disabled when SMS_PROVIDER=none
+	smsSender, err := sms.NewSender(cfg.SMS, log)
+	if err != nil {
+		log.Warnf("SMS provider initialization failed: %v", err)
+		ReportComponent(Component{Name: "sms", Status: ComponentDegraded, Detail: err.Error()})
+	} else if smsSender != nil {
+		aService.SetOTPService(authService.NewOTPService(authRepo.NewOTPRepo(db), smsSender, cfg.SMS, log))
+		ReportComponent(Component{Name: "sms", Status: ComponentActive, Endpoint: cfg.SMS.Provider})
+	} else {
+		ReportComponent(Component{Name: "sms", Status: ComponentDisabled, Detail: "SMS_PROVIDER is none"})
+	}
 
-	// Start background job to clean up expired tokens every 24 hours
-	go authService.StartTokenCleanupJob(tStore, 24*time.Hour)
+	// Social login; a provider is enabled by setting its OAUTH_<PROVIDER>_CLIENT_ID or
+	// listing it in OAUTH_OIDC_PROVIDERS
+	if providers := oauth.NewProviders(cfg.OAuth); len(providers) > 0 {
+		aService.SetOAuth(providers, authRepo.NewOAuthRepo(db), cfg.OAuth.AllowSignup)
+	}
+
+	// Login backoff and lockout per account and client IP; LOGIN_MAX_FAILURES=0 turns them off
+	if cfg.LoginLimit.MaxFailures > 0 {
+		aService.SetLoginLimiter(authService.NewLoginLimiter(authService.NewMemoryLoginAttemptStore(), cfg.LoginLimit, log))
+	}
+	aService.SetRiskEngine(riskEngine)
+
+	// Client IPs reaching IP_BAN_THRESHOLD failed logins and invalid refresh tokens within
+	// IP_BAN_WINDOW are banned from the API; IP_BAN_THRESHOLD=0 turns bans off
+	var ipBans *ipban.Guard
+	if cfg.IPBan.Threshold > 0 {
+		bans, err := ipban.NewGuard(ipban.NewMemoryStore(), cfg.IPBan, log)
+		if err != nil {
+			log.Warnf("IP ban initialization failed, bans disabled: %v", err)
+			ReportComponent(Component{Name: "ip-bans", Status: ComponentDegraded, Detail: err.Error()})
+		} else {
+			ipBans = bans
+			ReportComponent(Component{Name: "ip-bans", Status: ComponentActive, Detail: "memory store"})
+		}
+	} else {
+		ReportComponent(Component{Name: "ip-bans", Status: ComponentDisabled, Detail: "IP_BAN_THRESHOLD is 0"})
+	}
+	aService.SetIPBans(ipBans)
+	aService.SetWebhooks(webhooks)
+	// Client credentials grant for the service clients registered under /admin/service-clients
+	aService.SetServiceClients(authRepo.NewServiceClientRepo(db))
+	aService.SetAccountDeletionGrace(cfg.AccountDeletionGrace)
+{{if .HasUser}}
+	// New-device login emails go through the email provider; users can opt out
+	switch {
+	case !cfg.LoginAlerts:
+		ReportComponent(Component{Name: "login-alerts", Status: ComponentDisabled, Detail: "LOGIN_ALERTS_ENABLED is false"})
+	case emailSender == nil:
+		ReportComponent(Component{Name: "login-alerts", Status: ComponentDisabled, Detail: "EMAIL_PROVIDER is none"})
+	default:
+		aService.SetLoginAlerts(emailSender)
+		ReportComponent(Component{Name: "login-alerts", Status: ComponentActive})
+	}
+{{end}}
+	// Passkey login; enabled by setting WEBAUTHN_RP_ID to the site's domain
+	if cfg.WebAuthn.RPID != "" {
+		rp := &webauthn.RelyingParty{ID: cfg.WebAuthn.RPID, Name: cfg.WebAuthn.RPName, Origins: cfg.WebAuthn.Origins}
+		aService.SetWebAuthn(rp, authRepo.NewWebAuthnRepo(db), cfg.WebAuthn.ChallengeTTL)
+	}
 {{end}}
 {{if .HasFile}}	fRepo := fileRepo.NewFileRepo(db)
 	var fileHandler *fileApi.FileHandler
-	fSvc, err := fileService.NewFileService(fRepo, cfg, log)
+{{if .HasAuth}}	var exportHandler *exportApi.ExportHandler
+{{end}}	fSvc, err := fileService.NewFileService(fRepo, cfg, log)
+	objectStorage := Component{Name: "storage", Endpoint: storage.Location(cfg.Storage, cfg.MinIO), Detail: cfg.Storage.Backend}
 	if err != nil {
-		log.Warnf("FileService initialization failed (MinIO unavailable): %v", err)
-		log.Warn("File upload/download endpoints will be unavailable")
+		objectStorage.Status, objectStorage.Detail = ComponentDegraded, cfg.Storage.Backend+": "+err.Error()
+		log.Warnf("FileService initialization failed (object storage unavailable): %v", err)
+		log.Warn("File upload/download{{if .HasAuth}} and export{{end}} endpoints will be unavailable")
 	} else {
-		fileHandler = fileApi.NewFileHandler(fSvc, log)
+		objectStorage.Status = ComponentActive
+{{if .HasUser}}		fSvc.SetEvents(domainEvents)
+		fSvc.SetResumableUploads(fileRepo.NewUploadRepo(db))
+		fSvc.SetFolders(fileRepo.NewFolderRepo(db))
+		fSvc.SetShareLinks(fileRepo.NewShareLinkRepo(db))
+{{end}}		fileHandler = fileApi.NewFileHandler(fSvc, log)
+	}
+	ReportComponent(objectStorage)
+{{end}}{{if .HasAuth}}
+	// Background jobs; list and trigger them under /api/v1/admin/jobs
+	scheduler := jobs.NewScheduler(log)
+	if err := scheduler.Register(jobs.Job{
+		Name:     "token-cleanup",
+		Interval: 24 * time.Hour,
+		Timeout:  5 * time.Minute,
+		Run:      tStore.CleanupExpiredTokens,
+	}); err != nil {
+		log.Errorf("Failed to register job: %v", err)
+	}
+	if cfg.JWT.KeysDir != "" && cfg.JWT.KeysReloadInterval > 0 {
+		if err := scheduler.Register(jobs.Job{
+			Name:     "signing-key-reload",
+			Interval: cfg.JWT.KeysReloadInterval,
+			Timeout:  time.Minute,
+			Run:      func(context.Context) error { return reloadSigningKeys() },
+		}); err != nil {
+			log.Errorf("Failed to register job: %v", err)
+		}
+	}
+	if cfg.WebAuthn.RPID != "" {
+		if err := scheduler.Register(jobs.Job{
+			Name:     "webauthn-challenge-cleanup",
+			Interval: time.Hour,
+			Timeout:  time.Minute,
+			Run:      aService.CleanupExpiredChallenges,
+		}); err != nil {
+			log.Errorf("Failed to register job: %v", err)
+		}
+	}
+{{if .HasUser}}	if cfg.PasswordHistory > 0 {
+		if err := scheduler.Register(jobs.Job{
+			Name:     "password-history-cleanup",
+			Interval: 24 * time.Hour,
+			Timeout:  5 * time.Minute,
+			Run:      uService.CleanupPasswordHistory,
+		}); err != nil {
+			log.Errorf("Failed to register job: %v", err)
+		}
+	}
+	if err := scheduler.Register(jobs.Job{
+		Name:     "action-token-cleanup",
+		Interval: time.Hour,
+		Timeout:  5 * time.Minute,
+		Run:      actionTokens.Cleanup,
+	}); err != nil {
+		log.Errorf("Failed to register job: %v", err)
+	}
+	// Accounts deleted through DELETE /me are anonymized once their grace period ends
+	if err := scheduler.Register(jobs.Job{
+		Name:     "account-deletion",
+		Interval: time.Hour,
+		Timeout:  10 * time.Minute,
+		Run:      uService.AnonymizeDeletedAccounts,
+	}); err != nil {
+		log.Errorf("Failed to register job: %v", err)
+	}
+	if cfg.ActivityRetention > 0 {
+		if err := scheduler.Register(jobs.Job{
+			Name:     "activity-cleanup",
+			Interval: 24 * time.Hour,
+			Timeout:  5 * time.Minute,
+			Run:      activities.Cleanup,
+		}); err != nil {
+			log.Errorf("Failed to register job: %v", err)
+		}
+	}
+{{end}}{{if .HasFile}}
+	// Large exports produced by background workers and downloaded from MinIO
+	var exports exportService.ExportService
+	if fSvc != nil {
+		exports = exportService.NewExportService(exportRepo.NewExportRepo(db), fSvc, cfg.Export, log{{if .HasUser}},
+			exportService.WithPermissionCheck(authz.Can),
+		{{end}})
+{{if .HasUser}}		exports.Register("users", exportService.Producer{
+			ContentType: "text/csv",
+			Extension:   "csv",
+			Permission:  authzModel.PermUsersList,
+			Validate:    uHandler.ValidateExportParams,
+			Write: func(ctx context.Context, req exportService.Request, w io.Writer) (int64, error) {
+				return uHandler.WriteCSV(ctx, req.Params, w)
+			},
+		})
+{{end}}		exportHandler = exportApi.NewExportHandler(exports, log)
+		if err := scheduler.Register(jobs.Job{
+			Name:     "export-cleanup",
+			Interval: 15 * time.Minute,
+			Timeout:  5 * time.Minute,
+			Run:      exports.Cleanup,
+		}); err != nil {
+			log.Errorf("Failed to register job: %v", err)
+		}
+		if fSvc.ThumbnailsEnabled() {
+			if err := scheduler.Register(jobs.Job{
+				Name:     "thumbnail-backfill",
+				Interval: 15 * time.Minute,
+				Timeout:  time.Minute,
+				Run:      fSvc.BackfillThumbnails,
+			}); err != nil {
+				log.Errorf("Failed to register job: %v", err)
+			}
+		}
+		if err := scheduler.Register(jobs.Job{
+			Name:     "upload-cleanup",
+			Interval: time.Hour,
+			Timeout:  5 * time.Minute,
+			Run:      fSvc.CleanupUploads,
+		}); err != nil {
+			log.Errorf("Failed to register job: %v", err)
+		}
+	}
+{{end}}	if cfg.Disposable.ListURL != "" {
+		if err := scheduler.Register(jobs.Job{
+			Name:     "disposable-email-refresh",
+			Interval: cfg.Disposable.RefreshInterval,
+			Timeout:  time.Minute,
+			Run:      disposableDomains.Refresh,
+		}); err != nil {
+			log.Errorf("Failed to register job: %v", err)
+		}
+	}
+{{if .HasUser}}	// Email announcements to segments of users; disabled when EMAIL_PROVIDER=none
+	var announcementHandler *announcementApi.AnnouncementHandler
+	if emailSender != nil {
+		optOut := func(ctx context.Context, userID uuid.UUID) error {
+			optedOut := true
+			_, err := uService.Update(ctx, userID.String(), &userDto.UserUpdateRequest{AnnouncementsOptOut: &optedOut})
+			return err
+		}
+		announcements := announcementService.NewAnnouncementService(announcementRepo.NewAnnouncementRepo(db), emailSender, cfg.Announcement, optOut, log,
+			announcementService.WithDeliveryTrigger(func() { _ = scheduler.Run("announcement-delivery") }),
+		)
+		announcementHandler = announcementApi.NewAnnouncementHandler(announcements, log)
+		if err := scheduler.Register(jobs.Job{
+			Name:     "announcement-delivery",
+			Interval: time.Minute,
+			Timeout:  10 * time.Minute,
+			Run:      announcements.Deliver,
+		}); err != nil {
+			log.Errorf("Failed to register job: %v", err)
+		}
+	}
+{{end}}{{if .HasOIDC}}
+	// OpenID Connect provider; clients are registered through OIDC_CLIENTS
+	var oidcHandler *oidcApi.OIDCHandler
+	var oidcKey *oidcService.SigningKey
+	if cfg.OIDC.SigningKeyFile != "" {
+		oidcKey, err = oidcService.LoadSigningKey(cfg.OIDC.SigningKeyFile)
+	} else {
+		log.Warn("OIDC_SIGNING_KEY_FILE is not set; using a temporary signing key, issued tokens stop validating on restart")
+		oidcKey, err = oidcService.GenerateSigningKey()
+	}
+	if err == nil {
+		err = db.AutoMigrate(&oidcModel.AuthCode{})
+	}
+	if err != nil {
+		log.Errorf("OIDC provider initialization failed: %v", err)
+		log.Warn("OIDC provider endpoints will be unavailable")
+		ReportComponent(Component{Name: "oidc-provider", Status: ComponentDegraded, Detail: err.Error()})
+	} else {
+		ReportComponent(Component{Name: "oidc-provider", Status: ComponentActive, Endpoint: cfg.OIDC.Issuer})
+		oidcSvc := oidcService.NewService(oidcRepo.NewCodeRepo(db), uRepo, oidcKey, cfg.OIDC, log)
+		oidcHandler = oidcApi.NewOIDCHandler(oidcSvc, aService.Authenticate, log)
+		jwks = append(jwks, func() []jwk.Key { return oidcSvc.JWKS().Keys })
+		if err := scheduler.Register(jobs.Job{
+			Name:     "oidc-code-cleanup",
+			Interval: time.Hour,
+			Timeout:  time.Minute,
+			Run:      oidcSvc.CleanupExpiredCodes,
+		}); err != nil {
+			log.Errorf("Failed to register job: %v", err)
+		}
+	}
+{{end}}	scheduler.Start()
+	ReportComponent(Component{Name: "jobs", Status: ComponentActive, Detail: strconv.Itoa(len(scheduler.List())) + " jobs"})
+	OnShutdown("job scheduler", func(context.Context) error {
+		scheduler.Stop()
+		return nil
+	})
+{{if .HasFile}}	if exports != nil {
+		exports.Start(context.Background())
+	}
+	if fSvc != nil {
+		fSvc.StartThumbnails(context.Background())
+	}
+{{end}}	// The downloaded list replaces the embedded one right away rather than after an interval
+	if cfg.Disposable.ListURL != "" {
+		_ = scheduler.Run("disposable-email-refresh")
+	}
+	if len(jwks) > 0 {
+		r.GET("/.well-known/jwks.json", JWKSHandler(jwks...))
 	}
 {{end}}
+{{if .HasOIDC}}
 	// -----------------------
+	// OpenID Connect provider (served from the issuer root)
+	// -----------------------
+	if oidcHandler != nil {
+		r.GET("/.well-known/openid-configuration", oidcHandler.Discovery)
+		oauth2 := r.Group("/oauth2")
+		{
+			oauth2.GET("/authorize", oidcHandler.AuthorizeForm)
+			oauth2.POST("/authorize", oidcHandler.Authorize)
+			oauth2.POST("/token", oidcHandler.Token)
+			oauth2.GET("/userinfo", oidcHandler.UserInfo)
+			oauth2.POST("/userinfo", oidcHandler.UserInfo)
+		}
+	}
+{{end}}
+{{if or .HasAuth .HasUser .HasFile}}	// -----------------------
 	// API Versioning: v1
 	// -----------------------
 	v1 := r.Group("/api/v1")
-	{
+{{if .HasAuth}}	v1.Use(middleware.BlockBannedIPs(ipBans))
+	// Routes under MTLS_ROUTES authenticate with client certificates instead of JWTs
+	if len(cfg.TLS.ClientCertRoutes) > 0 {
+		v1.Use(middleware.ClientCertAuth(cfg.TLS, accountLookup, log))
+	}
+{{end}}	{
 {{if .HasAuth}}		// -----------------------
 		// Auth routes
 		// -----------------------
 		auth := v1.Group("/")
 		{
 			auth.POST("/login", aHandler.Login)
+			auth.POST("/login/otp/request", aHandler.RequestLoginOTP)
+			auth.POST("/login/otp/verify", aHandler.LoginWithOTP)
+			auth.GET("/auth/oauth/:provider", aHandler.OAuthStart)
+			auth.GET("/auth/oauth/:provider/callback", aHandler.OAuthCallback)
+			auth.POST("/auth/webauthn/login/begin", aHandler.PasskeyLoginBegin)
+			auth.POST("/auth/webauthn/login/finish", aHandler.PasskeyLoginFinish)
+			if cfg.JWT.GuestEnabled {
+				auth.POST("/auth/guest", aHandler.IssueGuestToken)
+			}
+			auth.POST("/auth/token", aHandler.ClientToken)
 			auth.POST("/refresh", aHandler.Refresh)
 			auth.POST("/logout", aHandler.Logout)
 		}
@@ -480,42 +1166,297 @@ func RegisterRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config, log *zap.Sug
 {{if .HasUser}}		// -----------------------
 		// User routes
 		// -----------------------
+		checkLimit, err := middleware.RateLimitByIP(cfg.AvailabilityCheckRate)
+		if err != nil {
+			log.Fatalf("Invalid AVAILABILITY_CHECK_RATE: %v", err)
+		}
 		users := v1.Group("/users")
 		{
-			users.POST("/", uHandler.Register)
-{{if .HasAuth}}			users.GET("/", middleware.JWTAuth(jwtManager), uHandler.ListUsers)
-			users.GET("/:id", middleware.JWTAuth(jwtManager), uHandler.GetUser)
-			users.PUT("/:id", middleware.JWTAuth(jwtManager), uHandler.Update)
-			users.DELETE("/:id", middleware.JWTAuth(jwtManager), uHandler.Delete)
-{{else}}			users.GET("/", uHandler.ListUsers)
+			users.GET("/check", checkLimit, uHandler.CheckAvailability)
+{{if .HasAuth}}			users.POST("/", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersCreate), uHandler.Register)
+			users.GET("/", requireAuthOrClient, middleware.RequirePermission(authz, authzModel.PermUsersList), uHandler.ListUsers)
+			users.GET("/export", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersList), uHandler.ExportUsers)
+			users.GET("/stats", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersList), uHandler.Stats)
+			users.POST("/merge", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersMerge), uHandler.Merge)
+			users.POST("/batch", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersBatch), uHandler.Batch)
+			users.GET("/tags", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersTags), uHandler.ListTags)
+			users.GET("/:id", requireAuth, uHandler.GetUser)
+			users.PUT("/:id", requireAuth, uHandler.Update)
+			users.DELETE("/:id", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersDelete), uHandler.Delete)
+			users.GET("/:id/history", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersHistory), uHandler.History)
+			users.GET("/:id/status-history", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersHistory), uHandler.StatusHistory)
+			users.GET("/:id/tags", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersTags), uHandler.UserTags)
+			users.POST("/:id/tags", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersTags), uHandler.AddTags)
+			users.DELETE("/:id/tags/:tag", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersTags), uHandler.RemoveTag)
+			users.GET("/:id/notes", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersNotes), uHandler.ListNotes)
+			users.POST("/:id/notes", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersNotes), uHandler.AddNote)
+			users.PUT("/:id/notes/:noteId", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersNotes), uHandler.UpdateNote)
+			users.DELETE("/:id/notes/:noteId", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersNotes), uHandler.DeleteNote)
+			users.POST("/:id/unlock", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersUnlock), aHandler.UnlockAccount)
+			users.DELETE("/:id/review", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersReview), uHandler.ClearReview)
+			users.POST("/:id/suspend", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersStatus), uHandler.Suspend)
+			users.POST("/:id/deactivate", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersStatus), uHandler.Deactivate)
+			users.POST("/:id/activate", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersStatus), uHandler.Activate)
+{{else}}			users.POST("/", uHandler.Register)
+			users.GET("/", uHandler.ListUsers)
+			users.GET("/export", uHandler.ExportUsers)
+			users.GET("/stats", uHandler.Stats)
 			users.GET("/:id", uHandler.GetUser)
 			users.PUT("/:id", uHandler.Update)
 			users.DELETE("/:id", uHandler.Delete)
+			users.DELETE("/:id/review", uHandler.ClearReview)
 {{end}}		}
+{{if .HasAuth}}
+		// -----------------------
+		// Invitations, the way in when signup is closed
+		// -----------------------
+		invitations := v1.Group("/invitations")
+		invitations.Use(requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersCreate))
+		{
+			invitations.POST("/", uHandler.Invite)
+			invitations.DELETE("/:email", uHandler.RevokeInvitation)
+		}
 {{end}}
+		// -----------------------
+		// Self-service signup; SIGNUP_ENABLED=false leaves account creation to admins
+		// -----------------------
+		if cfg.Signup.Enabled {
+			v1.POST("/auth/register", uHandler.Signup)
+		}
+		v1.GET("/auth/verify-email", uHandler.VerifyEmail)
+		v1.POST("/auth/verify-email", uHandler.VerifyEmail)
+		v1.POST("/auth/accept-invite", uHandler.AcceptInvite)
+
+		// -----------------------
+		// Custom profile fields (schema for user metadata)
+		// -----------------------
+		profileFields := v1.Group("/profile-fields")
+		{
+{{if .HasAuth}}			profileFields.GET("/", requireAuthOrGuest, pfHandler.List)
+			manageFields := middleware.RequirePermission(authz, authzModel.PermProfileFieldsManage)
+			profileFields.POST("/", requireAuth, manageFields, pfHandler.Create)
+			profileFields.PUT("/:key", requireAuth, manageFields, pfHandler.Update)
+			profileFields.DELETE("/:key", requireAuth, manageFields, pfHandler.Delete)
+{{else}}			// Changing the schema needs an admin role, which requires the Authentication feature
+			profileFields.GET("/", pfHandler.List)
+{{end}}		}
+{{if .HasAuth}}
+		// -----------------------
+		// Role management (RBAC)
+		// -----------------------
+		roles := v1.Group("/roles")
+		roles.Use(requireAuth, middleware.RequirePermission(authz, authzModel.PermRolesManage))
+		{
+			roles.GET("/", roleHandler.List)
+			roles.GET("/:name", roleHandler.Get)
+			roles.POST("/", roleHandler.Create)
+			roles.PUT("/:name", roleHandler.Update)
+			roles.DELETE("/:name", roleHandler.Delete)
+		}
+		v1.GET("/permissions", requireAuth, middleware.RequirePermission(authz, authzModel.PermRolesManage), roleHandler.ListPermissions)
+
+		// -----------------------
+		// Admin: settings snapshots (promote profile fields and roles between environments)
+		// -----------------------
+		adminConfig := v1.Group("/admin/config")
+		adminConfig.Use(requireAuth, middleware.RequirePermission(authz, authzModel.PermConfigManage))
+		{
+			adminConfig.GET("/export", settingsHandler.Export)
+			adminConfig.POST("/import", settingsHandler.Import)
+		}
+{{end}}{{end}}
 {{if .HasAuth}}		// -----------------------
 		// Protected routes
 		// -----------------------
 		protected := v1.Group("/")
-		protected.Use(middleware.JWTAuth(jwtManager))
+		protected.Use(requireAuth)
 		{
 			protected.GET("/me", aHandler.Me)
+			protected.DELETE("/me", aHandler.DeleteMe)
+			protected.POST("/me/phone/verify", aHandler.RequestPhoneVerification)
+			protected.POST("/me/phone/confirm", aHandler.ConfirmPhoneVerification)
+{{if .HasUser}}			protected.GET("/me/activity", activityHandler.ListMine)
+			protected.GET("/me/profile", uHandler.GetProfile)
+			protected.PATCH("/me/profile", uHandler.UpdateProfile)
+{{end}}			protected.POST("/auth/webauthn/register/begin", aHandler.PasskeyRegisterBegin)
+			protected.POST("/auth/webauthn/register/finish", aHandler.PasskeyRegisterFinish)
+			protected.GET("/auth/webauthn/credentials", aHandler.ListPasskeys)
+			protected.DELETE("/auth/webauthn/credentials/:id", aHandler.DeletePasskey)
+		}
+
+		// -----------------------
+		// Admin: background jobs
+		// -----------------------
+		admin := v1.Group("/admin")
+{{if .HasUser}}		admin.Use(requireAuth, middleware.RequirePermission(authz, authzModel.PermJobsManage))
+{{else}}		admin.Use(requireAuth, middleware.RequireRole("admin"))
+{{end}}		{
+			admin.GET("/jobs", ListJobsHandler(scheduler))
+			admin.POST("/jobs/:name/run", RunJobHandler(scheduler))
 		}
+
+		// -----------------------
+		// Admin: access token signing keys
+		// -----------------------
+		signingKeys := v1.Group("/admin/signing-keys")
+{{if .HasUser}}		signingKeys.Use(requireAuth, middleware.RequirePermission(authz, authzModel.PermSigningKeysManage))
+{{else}}		signingKeys.Use(requireAuth, middleware.RequireRole("admin"))
+{{end}}		{
+			signingKeys.GET("/", ListSigningKeysHandler(jwtManager))
+			signingKeys.POST("/reload", ReloadSigningKeysHandler(jwtManager, reloadSigningKeys, log))
+		}
+
+		// -----------------------
+		// Admin: client IPs banned for repeated authentication failures
+		// -----------------------
+		ipBanAdmin := v1.Group("/admin/ip-bans")
+{{if .HasUser}}		ipBanAdmin.Use(requireAuth, middleware.RequirePermission(authz, authzModel.PermIPBansManage))
+{{else}}		ipBanAdmin.Use(requireAuth, middleware.RequireRole("admin"))
+{{end}}		{
+			ipBanAdmin.GET("/", ListIPBansHandler(ipBans, log))
+			ipBanAdmin.DELETE("/:ip", LiftIPBanHandler(ipBans, log))
+		}
+
+		// -----------------------
+		// Admin: clients still calling deprecated endpoints
+		// -----------------------
+{{if .HasUser}}		v1.GET("/admin/deprecations", requireAuth, middleware.RequirePermission(authz, authzModel.PermDeprecationsView), ListDeprecationsHandler(deprecations))
+		v1.GET("/admin/system", requireAuth, middleware.RequirePermission(authz, authzModel.PermSystemView), SystemHandler())
+
+		// -----------------------
+		// Admin: email announcements (only if an email provider is configured)
+		// -----------------------
+		if announcementHandler != nil {
+			adminAnnouncements := v1.Group("/admin/announcements")
+			adminAnnouncements.Use(requireAuth, middleware.RequirePermission(authz, authzModel.PermAnnouncementsManage))
+			{
+				adminAnnouncements.POST("/", announcementHandler.Create)
+				adminAnnouncements.GET("/", announcementHandler.List)
+				adminAnnouncements.GET("/:id", announcementHandler.Get)
+				adminAnnouncements.GET("/:id/deliveries", announcementHandler.Deliveries)
+				adminAnnouncements.POST("/:id/cancel", announcementHandler.Cancel)
+			}
+			// Unsubscribe links are opened from the email, without signing in
+			v1.GET("/announcements/unsubscribe", announcementHandler.Unsubscribe)
+			v1.POST("/announcements/unsubscribe", announcementHandler.Unsubscribe)
+		}
+{{else}}		v1.GET("/admin/deprecations", requireAuth, middleware.RequireRole("admin"), ListDeprecationsHandler(deprecations))
+		v1.GET("/admin/system", requireAuth, middleware.RequireRole("admin"), SystemHandler())
 {{end}}
+		// -----------------------
+		// Admin: refresh token inventory and revocation (incident response)
+		// -----------------------
+{{if .HasUser}}		manageTokens := middleware.RequirePermission(authz, authzModel.PermTokensManage)
+{{else}}		manageTokens := middleware.RequireRole("admin")
+{{end}}		v1.GET("/admin/tokens", requireAuth, manageTokens, aHandler.ListTokens)
+		v1.DELETE("/admin/tokens/:id", requireAuth, manageTokens, aHandler.RevokeToken)
+		v1.DELETE("/admin/users/:id/tokens", requireAuth, manageTokens, aHandler.RevokeUserTokens)
+
+		// -----------------------
+		// Admin: service clients of the client credentials grant
+		// -----------------------
+{{if .HasUser}}		manageClients := middleware.RequirePermission(authz, authzModel.PermServiceClientsManage)
+{{else}}		manageClients := middleware.RequireRole("admin")
+{{end}}		v1.GET("/admin/service-clients", requireAuth, manageClients, aHandler.ListServiceClients)
+		v1.POST("/admin/service-clients", requireAuth, manageClients, aHandler.CreateServiceClient)
+		v1.DELETE("/admin/service-clients/:clientID", requireAuth, manageClients, aHandler.DeleteServiceClient)
+
+		// -----------------------
+		// Admin: impersonation (short-lived tokens acting as a user, for support)
+		// -----------------------
+{{if .HasUser}}		v1.POST("/admin/impersonate/:userID", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersImpersonate), aHandler.Impersonate)
+
+		// -----------------------
+		// Admin: user metrics (registrations per day, accounts per status and role)
+		// -----------------------
+		v1.GET("/admin/metrics/users", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersMetrics), uHandler.Metrics)
+{{else}}		v1.POST("/admin/impersonate/:userID", requireAuth, middleware.RequireRole("admin"), aHandler.Impersonate)
+{{end}}{{end}}
 {{if .HasFile}}		// -----------------------
-		// File routes (only if MinIO available)
+		// File routes (only if object storage is available)
 		// -----------------------
 		if fSvc != nil {
 			files := v1.Group("/files")
-{{if .HasAuth}}			files.Use(middleware.JWTAuth(jwtManager))
+{{if .HasAuth}}			files.Use(requireAuth)
 {{end}}			{
 				files.POST("/upload", fileHandler.Upload)
 				files.GET("/:filename", fileHandler.GetFile)
+				files.GET("/:filename/verify", fileHandler.VerifyFile)
 				files.DELETE("/:filename", fileHandler.DeleteFile)
 				files.GET("/", fileHandler.GetUserFiles)
+				files.POST("/share", fileHandler.ShareFile)
+				files.POST("/presign-upload", fileHandler.PresignUpload)
+				files.POST("/complete", fileHandler.CompleteUpload)
+				files.PUT("/:filename/visibility", fileHandler.SetVisibility)
+				files.POST("/archive", fileHandler.CreateArchive)
+				if fSvc.FoldersEnabled() {
+					files.POST("/folders", fileHandler.CreateFolder)
+					files.GET("/folders", fileHandler.ListFolder)
+					files.POST("/:filename/move", fileHandler.MoveFile)
+				}
+				if fSvc.ShareLinksEnabled() {
+					files.POST("/:filename/share", fileHandler.CreateShareLink)
+					files.GET("/:filename/shares", fileHandler.ListShareLinks)
+					files.DELETE("/shares/:id", fileHandler.RevokeShareLink)
+				}
+				if fSvc.ResumableEnabled() {
+					files.POST("/uploads", fileHandler.CreateUpload)
+					files.GET("/uploads/:id", fileHandler.GetUpload)
+					files.HEAD("/uploads/:id", fileHandler.GetUpload)
+					files.PATCH("/uploads/:id", fileHandler.WriteChunk)
+					files.DELETE("/uploads/:id", fileHandler.DeleteUpload)
+				}
 			}
-		}
+			// Share links are opened by people without an account; the token is the credential
+			v1.GET("/files/shared", fileHandler.DownloadShared)
+			if fSvc.ShareLinksEnabled() {
+				shareLimit, err := middleware.RateLimitByIP(cfg.FileShare.LinkRate)
+				if err != nil {
+					log.Fatalf("Invalid FILE_SHARE_LINK_RATE: %v", err)
+				}
+				v1.GET("/share/:token", shareLimit, fileHandler.OpenShareLink)
+			}
+			// Public files need no sign-in; their URLs are stable and may be cached
+			v1.GET("/public/files/:id", fileHandler.GetPublicFile)
+			v1.HEAD("/public/files/:id", fileHandler.GetPublicFile)
+			// Signed URLs of the local storage driver; the token is the credential
+			if server, ok := fSvc.Storage().(storage.Server); ok {
+				v1.GET("/files/raw/:token", server.ServeSigned)
+				v1.HEAD("/files/raw/:token", server.ServeSigned)
+				v1.PUT("/files/raw/:token", server.ServeSigned)
+			}
+{{if .HasAuth}}
+			// -----------------------
+			// Export routes (produced in the background, downloaded through signed URLs)
+			// -----------------------
+			exportRoutes := v1.Group("/exports")
+			exportRoutes.Use(requireAuth)
+			{
+				exportRoutes.POST("/", exportHandler.Create)
+				exportRoutes.GET("/:id", exportHandler.Get)
+			}
+{{end}}		}
 {{end}}	}
+{{end}}
+	// -----------------------
+	// Example bodies derived from the DTOs, served next to Swagger in development
+	// -----------------------
+	if swaggerEnabled(cfg) {
+		var routeExamples []examples.Route
+{{if .HasAuth}}		routeExamples = append(routeExamples, authApi.Examples...)
+{{end}}{{if .HasUser}}		routeExamples = append(routeExamples, userApi.Examples...)
+{{if .HasAuth}}		routeExamples = append(routeExamples, authzApi.Examples...)
+		routeExamples = append(routeExamples, activityApi.Examples...)
+		routeExamples = append(routeExamples, settingsApi.Examples...)
+		if announcementHandler != nil {
+			routeExamples = append(routeExamples, announcementApi.Examples...)
+		}
+{{end}}{{end}}{{if .HasFile}}		if fSvc != nil {
+			routeExamples = append(routeExamples, fileApi.Examples...)
+{{if .HasAuth}}			routeExamples = append(routeExamples, exportApi.Examples...)
+{{end}}		}
+{{end}}		r.GET("/docs/examples", examples.Handler("/api/v1", routeExamples...))
+	}
 
 	log.Info("Routes registered successfully under /api/v1")
 }
@@ -523,22 +1464,28 @@ func RegisterRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config, log *zap.Sug
 
 	data := struct {
 		Module      string
+		RoutesFunc  string
 		HasAuth     bool
 		HasUser     bool
 		HasDatabase bool
 		HasFile     bool
 		HasDocs     bool
+		HasCORS     bool
 		HasDocker   bool
 		HasPodman   bool
+		HasOIDC     bool
 	}{
 		Module:      moduleName,
+		RoutesFunc:  routesFunc,
 		HasAuth:     selectedFeatures["Authentication (JWT)"],
 		HasUser:     selectedFeatures["User Management"],
 		HasDatabase: selectedFeatures["Database"],
 		HasFile:     selectedFeatures["File Storage"],
 		HasDocs:     selectedFeatures["API Docs"],
+		HasCORS:     selectedFeatures["CORS"],
 		HasDocker:   selectedFeatures["Docker"],
 		HasPodman:   selectedFeatures["Podman"],
+		HasOIDC:     selectedFeatures["OIDC Provider"] && selectedFeatures["Authentication (JWT)"] && selectedFeatures["User Management"],
 	}
 
 	tmpl, err := template.New("routes.go").Parse(routesGoTemplate)
@@ -546,7 +1493,7 @@ func RegisterRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config, log *zap.Sug
 		return fmt.Errorf("failed to parse routes.go template: %w", err)
 	}
 
-	routesGoPath := filepath.Join(projectDir, "internal", "app", "routes.go")
+	routesGoPath := filepath.Join(projectDir, "internal", "app", fileName)
 
 	// Create directory structure first
 	if err := os.MkdirAll(filepath.Dir(routesGoPath), 0755); err != nil {
@@ -566,8 +1513,81 @@ func RegisterRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config, log *zap.Sug
 	return nil
 }
 
+func generateMiddlewareGo(projectDir, moduleName string, selectedFeatures map[string]bool) error {
+	middlewareGoTemplate := `package bootstrap
+
+import (
+	"{{.Module}}/internal/platform/config"
+	"{{.Module}}/internal/platform/http/middleware"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// SetupMiddleware adds all your prebuilt middlewares to the Gin engine
+func SetupMiddleware(r *gin.Engine, cfg *config.Config, log *zap.SugaredLogger) {
+	// Only these proxies may set the client IP through X-Forwarded-For. Unset, no peer is
+	// trusted and the client IP is the remote address, so clients cannot pick their own IP
+	// to dodge per-IP login limits and bans, or to get another address banned
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Errorf("Invalid TRUSTED_PROXIES, trusting no proxy: %v", err)
+		_ = r.SetTrustedProxies(nil)
+	}
+
+	r.Use(middleware.RequestIDMiddleware())
+	// Calling application from X-Client-Id or the User-Agent, for per-client logs and metrics
+	if cfg.Client.Enabled {
+		r.Use(middleware.ClientIdentificationMiddleware(cfg.Client))
+	}
+	r.Use(
+		middleware.LoggerMiddleware(log),
+		middleware.RecoveryMiddleware(log),
+	)
+	// Server-Timing header and timing logs with DB and storage time, development only
+	if cfg.GinMode == "debug" || cfg.GinMode == "development" {
+		r.Use(middleware.ServerTimingMiddleware(log))
+	}
+	r.Use(
+		middleware.LocalizationMiddleware(cfg.Localization),
+		middleware.ErrorHandlerMiddleware(log), // Global error handler
+{{if .HasCORS}}		middleware.CORSMiddleware(cfg.CORS),
+{{end}}		middleware.RateLimitMiddleware(),
+		// middleware.JWTAuthMiddleware(nil), // for global JWT if needed, or per-route
+	)
+}
+`
+
+	data := struct {
+		Module  string
+		HasCORS bool
+	}{
+		Module:  moduleName,
+		HasCORS: selectedFeatures["CORS"],
+	}
+
+	tmpl, err := template.New("middleware.go").Parse(middlewareGoTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to parse middleware.go template: %w", err)
+	}
+
+	middlewareGoPath := filepath.Join(projectDir, "internal", "app", "middleware.go")
+
+	f, err := os.Create(middlewareGoPath)
+	if err != nil {
+		return fmt.Errorf("failed to create middleware.go: %w", err)
+	}
+	defer f.Close()
+
+	if err := tmpl.Execute(f, data); err != nil {
+		return fmt.Errorf("failed to execute middleware.go template: %w", err)
+	}
+
+	return nil
+}
+
 func replaceModuleNames(projectDir, projectName, moduleName string) error {
-	templateModule := "go_platform_template"
+	// Scaffold files import the template's own module path; projects get moduleName instead
+	templateModule := "github.com/MurtadaNazar/go-api-template"
 	templateName := "go-platform-template"
 	templateProject := "{{.ProjectName}}"
 
@@ -645,7 +1665,7 @@ func isConfigFile(path string) bool {
 	return false
 }
 
-func processMakefile(projectDir string, selectedFeatures map[string]bool) error {
+func processMakefile(projectDir string, selectedFeatures map[string]bool, services []Service) error {
 	makefilePath := filepath.Join(projectDir, "Makefile")
 	content, err := os.ReadFile(makefilePath)
 	if err != nil {
@@ -662,9 +1682,52 @@ func processMakefile(projectDir string, selectedFeatures map[string]bool) error
 	result := strings.ReplaceAll(string(content), "{{.ContainerCmd}}", containerCmd)
 	result = strings.ReplaceAll(result, "{{.ComposeFile}}", composeFile)
 
+	if len(services) > 1 {
+		names := make([]string, len(services))
+		for i, svc := range services {
+			names[i] = svc.Name
+		}
+		result += fmt.Sprintf(`
+# Build every service into bin/
+SERVICES=%s
+
+.PHONY: build-services
+build-services:
+	@for svc in $(SERVICES); do \
+		echo "Building $$svc..."; \
+		CGO_ENABLED=0 $(GOBUILD) $(LDFLAGS) -o bin/$$svc ./cmd/$$svc || exit 1; \
+	done
+	@echo "✓ Services built in ./bin"
+`, strings.Join(names, " "))
+	}
+
 	return os.WriteFile(makefilePath, []byte(result), 0600)
 }
 
+// processServiceEntrypoints points the Makefile and container builds at the first service
+func processServiceEntrypoints(projectDir string, services []Service) error {
+	primary := services[0].Name
+	if primary == DefaultServiceName {
+		return nil
+	}
+
+	for _, name := range []string{"Makefile", "Dockerfile", "Containerfile"} {
+		path := filepath.Join(projectDir, name)
+		content, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		result := strings.ReplaceAll(string(content), "cmd/"+DefaultServiceName, "cmd/"+primary)
+		if err := os.WriteFile(path, []byte(result), 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func processReadme(projectDir string, selectedFeatures map[string]bool) error {
 	readmePath := filepath.Join(projectDir, "README.md")
 	content, err := os.ReadFile(readmePath)
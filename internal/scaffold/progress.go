@@ -0,0 +1,132 @@
+package scaffold
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ProgressMsg reports the start (Done == false) or end of one generation step
+type ProgressMsg struct {
+	Step    string
+	Done    bool
+	Elapsed time.Duration
+	Err     error
+}
+
+// reporter receives generation progress; a nil reporter discards it
+type reporter func(ProgressMsg)
+
+// step runs fn as a named step, reporting its start and its outcome with timing
+func (r reporter) step(name string, fn func() error) error {
+	if r == nil {
+		return fn()
+	}
+	start := time.Now()
+	r(ProgressMsg{Step: name})
+	err := fn()
+	r(ProgressMsg{Step: name, Done: true, Elapsed: time.Since(start), Err: err})
+	return err
+}
+
+// stepLogLines is how many log lines the error view shows at once
+const stepLogLines = 10
+
+// recordProgress updates the step list from a progress message
+func (m *Model) recordProgress(msg ProgressMsg) {
+	if !msg.Done {
+		m.steps = append(m.steps, msg)
+		return
+	}
+	for i := len(m.steps) - 1; i >= 0; i-- {
+		if m.steps[i].Step == msg.Step && !m.steps[i].Done {
+			m.steps[i] = msg
+			return
+		}
+	}
+	m.steps = append(m.steps, msg)
+}
+
+// renderSteps lists generation steps with their status and timing
+func (m *Model) renderSteps() []string {
+	lines := make([]string, 0, len(m.steps))
+	for _, step := range m.steps {
+		switch {
+		case !step.Done:
+			lines = append(lines, m.styles.Info.Render(m.spinner.View()+" "+step.Step+"..."))
+		case step.Err != nil:
+			lines = append(lines, m.styles.Error.Render(fmt.Sprintf("✗ %s (%s)", step.Step, formatElapsed(step.Elapsed))))
+		default:
+			lines = append(lines, m.styles.Success.Render("✓ ")+
+				m.styles.Description.Render(step.Step)+
+				m.styles.Blurred.Render(" ("+formatElapsed(step.Elapsed)+")"))
+		}
+	}
+	return lines
+}
+
+// failureLog is the full log shown after a failed generation: every step, the
+// failing step's error and any hook output
+func (m *Model) failureLog() []string {
+	var lines []string
+	for _, step := range m.steps {
+		switch {
+		case step.Err != nil:
+			lines = append(lines, m.styles.Error.Render(fmt.Sprintf("✗ %s (%s)", step.Step, formatElapsed(step.Elapsed))))
+			lines = append(lines, m.styles.Error.Render("  "+step.Err.Error()))
+		case step.Done:
+			lines = append(lines, m.styles.Description.Render(fmt.Sprintf("✓ %s (%s)", step.Step, formatElapsed(step.Elapsed))))
+		default:
+			lines = append(lines, m.styles.Blurred.Render("… "+step.Step))
+		}
+	}
+	for _, line := range m.hookLog {
+		lines = append(lines, m.styles.Blurred.Render("  "+line))
+	}
+	return lines
+}
+
+// scrollLog moves the failure log window by delta lines, clamped to the log size
+func (m *Model) scrollLog(delta int) {
+	maxOffset := len(m.failureLog()) - stepLogLines
+	if maxOffset < 0 {
+		maxOffset = 0
+	}
+	m.logOffset += delta
+	if m.logOffset > maxOffset {
+		m.logOffset = maxOffset
+	}
+	if m.logOffset < 0 {
+		m.logOffset = 0
+	}
+}
+
+// renderFailureLog renders the visible window of the failure log
+func (m *Model) renderFailureLog() string {
+	lines := m.failureLog()
+	if len(lines) == 0 {
+		return ""
+	}
+	end := m.logOffset + stepLogLines
+	if end > len(lines) {
+		end = len(lines)
+	}
+	visible := lines[m.logOffset:end]
+
+	position := m.styles.Help.Render(fmt.Sprintf("Lines %d-%d of %d  •  ↑/↓ to scroll", m.logOffset+1, end, len(lines)))
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		m.styles.Label.Render("Generation log:"),
+		lipgloss.JoinVertical(lipgloss.Left, visible...),
+		"",
+		position,
+	)
+}
+
+func formatElapsed(d time.Duration) string {
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
@@ -0,0 +1,49 @@
+package scaffold
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReporterStep(t *testing.T) {
+	var msgs []ProgressMsg
+	report := reporter(func(msg ProgressMsg) { msgs = append(msgs, msg) })
+	boom := errors.New("boom")
+
+	if err := report.step("Copying base files", func() error { return nil }); err != nil {
+		t.Fatalf("step() error = %v", err)
+	}
+	if err := report.step("Rewriting module names", func() error { return boom }); !errors.Is(err, boom) {
+		t.Fatalf("step() error = %v, want %v", err, boom)
+	}
+
+	if len(msgs) != 4 {
+		t.Fatalf("got %d progress messages, want 4", len(msgs))
+	}
+	if msgs[0].Done || !msgs[1].Done || msgs[1].Err != nil {
+		t.Errorf("first step messages = %+v, %+v", msgs[0], msgs[1])
+	}
+	if !msgs[3].Done || !errors.Is(msgs[3].Err, boom) {
+		t.Errorf("failed step end = %+v, want error", msgs[3])
+	}
+
+	var silent reporter
+	if err := silent.step("noop", func() error { return boom }); !errors.Is(err, boom) {
+		t.Errorf("nil reporter step() error = %v, want %v", err, boom)
+	}
+}
+
+func TestRecordProgress(t *testing.T) {
+	m := &Model{}
+
+	m.recordProgress(ProgressMsg{Step: "Copying base files"})
+	m.recordProgress(ProgressMsg{Step: "Copying base files", Done: true})
+	m.recordProgress(ProgressMsg{Step: "Processing README"})
+
+	if len(m.steps) != 2 {
+		t.Fatalf("steps = %+v, want 2 entries", m.steps)
+	}
+	if !m.steps[0].Done || m.steps[1].Done {
+		t.Errorf("steps = %+v, want first done and second running", m.steps)
+	}
+}
@@ -0,0 +1,280 @@
+package scaffold
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// DefaultServiceName is the binary generated under cmd/ when no services are defined
+const DefaultServiceName = "server"
+
+// Service is one deployable binary in the generated project. All services share the
+// project's go.mod and internal/ packages; each gets its own cmd/<Name>/main.go and
+// route registration limited to its Features.
+type Service struct {
+	Name     string
+	Features map[string]bool
+}
+
+// serviceFeatures are the features that can differ between services of one project.
+// Container setup and middleware such as CORS stay project-wide.
+var serviceFeatures = []string{
+	"Database",
+	"Authentication (JWT)",
+	"User Management",
+	"File Storage",
+	"API Docs",
+	"OIDC Provider",
+}
+
+// serviceNamePattern allows single hyphens between lowercase words, e.g. "api-gateway"
+var serviceNamePattern = regexp.MustCompile(`^[a-z][a-z0-9]*(-[a-z0-9]+)*$`)
+
+func isValidServiceName(name string) bool {
+	return len(name) <= 30 && serviceNamePattern.MatchString(name)
+}
+
+// parseServiceNames splits a comma-separated list such as "api, worker" into unique service names
+func parseServiceNames(input string) ([]string, error) {
+	var names []string
+	seen := make(map[string]bool)
+	// Services share internal/app, so their route functions must differ too, e.g.
+	// "a1b" and "a-1b" would both declare RegisterA1bRoutes
+	funcs := make(map[string]string)
+	for _, raw := range strings.Split(input, ",") {
+		name := strings.TrimSpace(raw)
+		if name == "" {
+			continue
+		}
+		if !isValidServiceName(name) {
+			return nil, fmt.Errorf("invalid service name %q: use lowercase letters and numbers, with single hyphens between words", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate service name %q", name)
+		}
+		if other, ok := funcs[routesFuncName(name)]; ok {
+			return nil, fmt.Errorf("service names %q and %q both generate %s", other, name, routesFuncName(name))
+		}
+		seen[name] = true
+		funcs[routesFuncName(name)] = name
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return []string{DefaultServiceName}, nil
+	}
+	return names, nil
+}
+
+// routesFuncName returns the exported route registration function for a service,
+// e.g. "api-gateway" -> "RegisterApiGatewayRoutes"
+func routesFuncName(service string) string {
+	var b strings.Builder
+	b.WriteString("Register")
+	for _, part := range strings.Split(service, "-") {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	b.WriteString("Routes")
+	return b.String()
+}
+
+// addrEnvName is the env var that overrides SERVER_ADDR for a service, e.g. "worker" -> "WORKER_SERVER_ADDR"
+func addrEnvName(service string) string {
+	return strings.ToUpper(strings.ReplaceAll(service, "-", "_")) + "_SERVER_ADDR"
+}
+
+// newServices creates services that start with every service-level feature selected for the project
+func newServices(names []string, selectedFeatures map[string]bool) []Service {
+	services := make([]Service, 0, len(names))
+	for _, name := range names {
+		features := make(map[string]bool)
+		for _, feat := range serviceFeatures {
+			features[feat] = selectedFeatures[feat]
+		}
+		services = append(services, Service{Name: name, Features: features})
+	}
+	return services
+}
+
+// serviceNames returns the names of services joined for display
+func serviceNames(services []Service) string {
+	names := make([]string, len(services))
+	for i, svc := range services {
+		names[i] = svc.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// selectedFeatureSet returns the project-wide feature selection keyed by feature name
+func (m *Model) selectedFeatureSet() map[string]bool {
+	selected := make(map[string]bool, len(m.features))
+	for _, feat := range m.features {
+		selected[feat.Name] = feat.Selected
+	}
+	return selected
+}
+
+// availableServiceFeatures lists the service-level features enabled for the whole project
+func (m *Model) availableServiceFeatures() []string {
+	selected := m.selectedFeatureSet()
+	var available []string
+	for _, name := range serviceFeatures {
+		if selected[name] {
+			available = append(available, name)
+		}
+	}
+	return available
+}
+
+// toggleServiceFeature flips the focused feature for the current service, keeping
+// feature dependencies consistent within that service
+func (m *Model) toggleServiceFeature() {
+	available := m.availableServiceFeatures()
+	if m.serviceIndex >= len(m.services) || m.serviceFeatureFocus >= len(available) {
+		return
+	}
+	features := m.services[m.serviceIndex].Features
+	name := available[m.serviceFeatureFocus]
+
+	if features[name] {
+		features[name] = false
+		// Drop anything in this service that needed the feature
+		changed := true
+		for changed {
+			changed = false
+			for feat, deps := range m.featureDependencies {
+				if !features[feat] {
+					continue
+				}
+				for _, dep := range deps {
+					if !features[dep] {
+						features[feat] = false
+						changed = true
+					}
+				}
+			}
+		}
+		return
+	}
+
+	var enable func(string)
+	enable = func(feat string) {
+		features[feat] = true
+		for _, dep := range m.featureDependencies[feat] {
+			if !features[dep] {
+				enable(dep)
+			}
+		}
+	}
+	enable(name)
+}
+
+func (m *Model) updateServiceInput(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	m.serviceInput, cmd = m.serviceInput.Update(msg)
+	return cmd
+}
+
+func (m *Model) viewServices() string {
+	header := m.renderHeader("Services", 5, 8)
+
+	hint := m.styles.Info.Render("→ Default: a single service in cmd/" + DefaultServiceName)
+	if value := strings.TrimSpace(m.serviceInput.Value()); value != "" {
+		if names, err := parseServiceNames(value); err != nil {
+			hint = m.styles.Error.Render("✗ " + err.Error())
+		} else if len(names) == 1 {
+			hint = m.styles.Success.Render("✓ Single service: cmd/" + names[0])
+		} else {
+			hint = m.styles.Success.Render(fmt.Sprintf("✓ %d services sharing one go.mod", len(names)))
+		}
+	}
+
+	form := lipgloss.JoinVertical(
+		lipgloss.Left,
+		m.styles.Label.Render("Service Names (comma-separated):"),
+		m.serviceInput.View(),
+		"",
+		hint,
+		"",
+		m.styles.Help.Render("Examples: api, api,worker, gateway,billing"),
+	)
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		header,
+		"",
+		m.renderContainer(form),
+		"",
+	)
+
+	if m.warning != "" {
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			content,
+			m.styles.Error.Render(m.warning),
+			"",
+		)
+	}
+
+	content = lipgloss.JoinVertical(
+		lipgloss.Left,
+		content,
+		m.renderKeyboardHelp("Enter", "Next", "Comma", "Separate services"),
+		"",
+		m.renderFooter(),
+	)
+
+	return m.padContent(content)
+}
+
+func (m *Model) viewServiceFeatures() string {
+	svc := m.services[m.serviceIndex]
+	header := m.renderHeader(
+		fmt.Sprintf("Service %d/%d: %s", m.serviceIndex+1, len(m.services), svc.Name),
+		5, 8,
+	)
+
+	available := m.availableServiceFeatures()
+	var lines []string
+	for i, name := range available {
+		checkbox := m.styles.Blurred.Render("[ ]")
+		if svc.Features[name] {
+			checkbox = m.styles.Success.Render("[✓]")
+		}
+
+		if i == m.serviceFeatureFocus {
+			cursor := m.styles.Focused.Render("▸")
+			lines = append(lines, fmt.Sprintf("  %s %s %s", cursor, checkbox, m.styles.Focused.Render(name)))
+		} else {
+			lines = append(lines, fmt.Sprintf("    %s %s", checkbox, name))
+		}
+	}
+
+	form := lipgloss.JoinVertical(
+		lipgloss.Left,
+		m.styles.Label.Render("Features served by cmd/"+svc.Name+":"),
+		"",
+		strings.Join(lines, "\n"),
+		"",
+		m.styles.Blurred.Render("Shared packages are generated once; unchecked features are not wired into this service."),
+	)
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		header,
+		"",
+		m.renderContainer(form),
+		"",
+		m.styles.Help.Render("SPACE = Toggle  •  UP/DOWN = Navigate  •  ENTER = Next"),
+		"",
+		m.renderFooter(),
+	)
+
+	return m.padContent(content)
+}
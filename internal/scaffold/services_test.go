@@ -0,0 +1,107 @@
+package scaffold
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseServiceNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "Empty defaults to server", input: "  ", want: []string{DefaultServiceName}},
+		{name: "Single service", input: "api", want: []string{"api"}},
+		{name: "Multiple services trimmed", input: " api , worker,", want: []string{"api", "worker"}},
+		{name: "Hyphenated name", input: "api-gateway", want: []string{"api-gateway"}},
+		{name: "Duplicate name", input: "api,api", wantErr: true},
+		{name: "Uppercase rejected", input: "API", wantErr: true},
+		{name: "Leading digit rejected", input: "1api", wantErr: true},
+		{name: "Trailing hyphen rejected", input: "api-", wantErr: true},
+		{name: "Double hyphen rejected", input: "api--gateway", wantErr: true},
+		{name: "Leading hyphen rejected", input: "-api", wantErr: true},
+		{name: "Same route function rejected", input: "a1b,a-1b", wantErr: true},
+		{name: "Digits after hyphen", input: "worker-2", want: []string{"worker-2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseServiceNames(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseServiceNames(%q) error = nil, want error", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseServiceNames(%q) error = %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseServiceNames(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoutesFuncName(t *testing.T) {
+	if got := routesFuncName("api-gateway"); got != "RegisterApiGatewayRoutes" {
+		t.Errorf("routesFuncName() = %q, want RegisterApiGatewayRoutes", got)
+	}
+	if got := addrEnvName("api-gateway"); got != "API_GATEWAY_SERVER_ADDR" {
+		t.Errorf("addrEnvName() = %q, want API_GATEWAY_SERVER_ADDR", got)
+	}
+}
+
+func TestCreateServicesProject_Builds(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a generated project")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not found")
+	}
+
+	// Arrange: the embedded scaffold lives in the main package, so read it from the repo
+	SetScaffoldFS(os.DirFS(filepath.Join("..", "..")))
+	t.Cleanup(func() { SetScaffoldFS(nil) })
+	dir := t.TempDir()
+	features := map[string]bool{
+		"Authentication (JWT)": true,
+		"User Management":      true,
+		"Database":             true,
+		"File Storage":         true,
+	}
+	services := []Service{
+		{Name: "api", Features: features},
+		// Registers no routes under /api/v1
+		{Name: "worker", Features: map[string]bool{"Database": true}},
+	}
+
+	// Act: generate with this repo's module path so its go.mod and go.sum apply
+	err = CreateServicesProjectDirect("demo", "github.com/MurtadaNazar/go-api-template", dir, features, services, map[string]string{})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("CreateServicesProjectDirect() error = %v", err)
+	}
+	projectDir := filepath.Join(dir, "demo")
+	for _, name := range []string{"go.mod", "go.sum"} {
+		content, err := os.ReadFile(filepath.Join("..", "..", name))
+		if err != nil {
+			t.Fatalf("ReadFile(%s) error = %v", name, err)
+		}
+		writeTestFile(t, filepath.Join(projectDir, name), string(content))
+	}
+	cmd := exec.Command(goBin, "build", "./...")
+	cmd.Dir = projectDir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go build ./... in the generated project failed: %v\n%s", err, strings.TrimSpace(string(out)))
+	}
+}
@@ -0,0 +1,96 @@
+package scaffold
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// themeLabels describes each theme in the settings menu
+var themeLabels = map[string]string{
+	ThemeAuto:         "Detect from the terminal background",
+	ThemeDark:         "Bright colors for dark backgrounds",
+	ThemeLight:        "Darker colors for light backgrounds",
+	ThemeHighContrast: "Bright white and yellow, no gray text",
+	ThemeNoColor:      "Bold, underline and reverse video only",
+}
+
+// applyTheme rebuilds all styles, including the text inputs', from the named theme
+func (m *Model) applyTheme(name string) {
+	m.themeName = name
+	m.styles = BuildStyles(ResolveTheme(name))
+
+	m.updateInputFocus()
+	for _, input := range []*textinput.Model{&m.envInput, &m.serviceInput, &m.hookInput} {
+		input.PromptStyle = m.styles.Focused
+		input.TextStyle = m.styles.Focused
+		input.PlaceholderStyle = m.styles.Blurred
+		input.Cursor.Style = m.styles.Focused
+	}
+	m.spinner.Style = m.styles.Info
+}
+
+// themeIndex returns the menu position of a theme name, defaulting to auto
+func themeIndex(name string) int {
+	for i, theme := range ThemeNames {
+		if theme == name {
+			return i
+		}
+	}
+	return 0
+}
+
+// selectTheme applies the focused theme and saves it to the config file
+func (m *Model) selectTheme() {
+	name := ThemeNames[m.themeFocus]
+	m.applyTheme(name)
+	m.config.Theme = name
+	m.settingsErr = SaveTheme(name)
+}
+
+func (m *Model) viewSettings() string {
+	title := m.styles.Title.Render("Settings")
+	subtitle := m.styles.Subtitle.Render("Theme")
+
+	var lines []string
+	for i, name := range ThemeNames {
+		marker := "  "
+		if name == m.themeName || (m.themeName == "" && name == ThemeAuto) {
+			marker = "✓ "
+		}
+		label := fmt.Sprintf("%s%-14s %s", marker, name, themeLabels[name])
+		if i == m.themeFocus {
+			lines = append(lines, m.styles.Focused.Render("▸ "+label))
+		} else {
+			lines = append(lines, m.styles.Blurred.Render("  "+label))
+		}
+	}
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		subtitle,
+		m.renderContainer(lipgloss.JoinVertical(lipgloss.Left, lines...)),
+		"",
+	)
+
+	switch {
+	case colorDisabled():
+		content = lipgloss.JoinVertical(lipgloss.Left, content,
+			m.styles.Warning.Render("⚠ NO_COLOR is set or the terminal has no colors; the no-color theme is used"), "")
+	case m.settingsErr != nil:
+		content = lipgloss.JoinVertical(lipgloss.Left, content,
+			m.styles.Error.Render("Theme applied but not saved: "+m.settingsErr.Error()), "")
+	}
+
+	content = lipgloss.JoinVertical(
+		lipgloss.Left,
+		content,
+		m.renderKeyboardHelp("Enter", "Apply & save", "ESC", "Back"),
+		"",
+		m.renderFooter(),
+	)
+
+	return m.padContent(content)
+}
@@ -0,0 +1,77 @@
+package scaffold
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestSaveTheme_KeepsOtherSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"post_create": ["go mod tidy"], "theme": "dark"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(ConfigEnvVar, path)
+
+	if err := SaveTheme(ThemeHighContrast); err != nil {
+		t.Fatalf("SaveTheme() error = %v", err)
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.Theme != ThemeHighContrast {
+		t.Errorf("Theme = %q, want %q", cfg.Theme, ThemeHighContrast)
+	}
+	if !reflect.DeepEqual(cfg.PostCreate, []string{"go mod tidy"}) {
+		t.Errorf("PostCreate = %q, want it kept", cfg.PostCreate)
+	}
+}
+
+func TestSaveTheme_CreatesUserConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("HOME", home)
+	t.Setenv("AppData", home)
+	t.Chdir(t.TempDir())
+
+	if err := SaveTheme(ThemeLight); err != nil {
+		t.Fatalf("SaveTheme() error = %v", err)
+	}
+
+	content, err := os.ReadFile(userConfigPath())
+	if err != nil {
+		t.Fatalf("user config not written: %v", err)
+	}
+	var saved Config
+	if err := json.Unmarshal(content, &saved); err != nil || saved.Theme != ThemeLight {
+		t.Errorf("saved config = %s, want theme %q", content, ThemeLight)
+	}
+}
+
+func TestResolveTheme_HonorsNoColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+
+	theme := ResolveTheme(ThemeHighContrast)
+
+	if !theme.Plain {
+		t.Error("ResolveTheme() with NO_COLOR set should return the no-color theme")
+	}
+	if _, ok := theme.Primary.(lipgloss.NoColor); !ok {
+		t.Errorf("Primary = %v, want NoColor", theme.Primary)
+	}
+}
+
+func TestThemeIndex(t *testing.T) {
+	if got := themeIndex(ThemeNoColor); ThemeNames[got] != ThemeNoColor {
+		t.Errorf("themeIndex(%q) = %d", ThemeNoColor, got)
+	}
+	if got := themeIndex("unknown"); got != 0 {
+		t.Errorf("themeIndex(unknown) = %d, want 0 (auto)", got)
+	}
+}
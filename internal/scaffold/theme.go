@@ -6,8 +6,21 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
 )
 
+// Theme names accepted in the settings menu and the "theme" config key
+const (
+	ThemeAuto         = "auto"
+	ThemeDark         = "dark"
+	ThemeLight        = "light"
+	ThemeHighContrast = "high-contrast"
+	ThemeNoColor      = "no-color"
+)
+
+// ThemeNames lists the selectable themes in menu order
+var ThemeNames = []string{ThemeAuto, ThemeDark, ThemeLight, ThemeHighContrast, ThemeNoColor}
+
 type Theme struct {
 	Primary   lipgloss.TerminalColor
 	Secondary lipgloss.TerminalColor
@@ -17,24 +30,63 @@ type Theme struct {
 	Text      lipgloss.TerminalColor
 	Muted     lipgloss.TerminalColor
 	Border    lipgloss.TerminalColor
+	// OnPrimary is the text color on a Primary background
+	OnPrimary lipgloss.TerminalColor
+	// Plain themes rely on bold, underline and reverse video instead of color
+	Plain bool
 }
 
 func DetectTheme() Theme {
-	isDark := isDarkBackground()
-
-	if isDark {
-		return Theme{
-			Primary:   lipgloss.Color("13"), // Bright Magenta
-			Secondary: lipgloss.Color("14"), // Bright Cyan
-			Success:   lipgloss.Color("10"), // Bright Green
-			Error:     lipgloss.Color("9"),  // Bright Red
-			Warning:   lipgloss.Color("11"), // Bright Yellow
-			Text:      lipgloss.Color("15"), // Bright White
-			Muted:     lipgloss.Color("8"),  // Bright Black (Gray)
-			Border:    lipgloss.Color("8"),  // Bright Black (Gray)
-		}
+	if isDarkBackground() {
+		return darkTheme()
+	}
+	return lightTheme()
+}
+
+// ResolveTheme returns the named theme. NO_COLOR and terminals without color support
+// always get the no-color theme; unknown names fall back to auto-detection.
+func ResolveTheme(name string) Theme {
+	if colorDisabled() {
+		return noColorTheme()
 	}
+	switch name {
+	case ThemeDark:
+		return darkTheme()
+	case ThemeLight:
+		return lightTheme()
+	case ThemeHighContrast:
+		return highContrastTheme()
+	case ThemeNoColor:
+		return noColorTheme()
+	default:
+		return DetectTheme()
+	}
+}
 
+// colorDisabled reports whether NO_COLOR (https://no-color.org) is set or the terminal
+// cannot show colors at all
+func colorDisabled() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return true
+	}
+	return lipgloss.ColorProfile() == termenv.Ascii
+}
+
+func darkTheme() Theme {
+	return Theme{
+		Primary:   lipgloss.Color("13"), // Bright Magenta
+		Secondary: lipgloss.Color("14"), // Bright Cyan
+		Success:   lipgloss.Color("10"), // Bright Green
+		Error:     lipgloss.Color("9"),  // Bright Red
+		Warning:   lipgloss.Color("11"), // Bright Yellow
+		Text:      lipgloss.Color("15"), // Bright White
+		Muted:     lipgloss.Color("8"),  // Bright Black (Gray)
+		Border:    lipgloss.Color("8"),  // Bright Black (Gray)
+		OnPrimary: lipgloss.Color("0"),  // Black
+	}
+}
+
+func lightTheme() Theme {
 	return Theme{
 		Primary:   lipgloss.Color("5"), // Magenta
 		Secondary: lipgloss.Color("6"), // Cyan
@@ -44,6 +96,37 @@ func DetectTheme() Theme {
 		Text:      lipgloss.Color("0"), // Black
 		Muted:     lipgloss.Color("8"), // Bright Black (Gray)
 		Border:    lipgloss.Color("8"), // Bright Black (Gray)
+		OnPrimary: lipgloss.Color("0"), // Black
+	}
+}
+
+// highContrastTheme keeps to the brightest 16-color entries and avoids gray text
+func highContrastTheme() Theme {
+	return Theme{
+		Primary:   lipgloss.Color("11"), // Bright Yellow
+		Secondary: lipgloss.Color("14"), // Bright Cyan
+		Success:   lipgloss.Color("10"), // Bright Green
+		Error:     lipgloss.Color("9"),  // Bright Red
+		Warning:   lipgloss.Color("11"), // Bright Yellow
+		Text:      lipgloss.Color("15"), // Bright White
+		Muted:     lipgloss.Color("15"), // Bright White
+		Border:    lipgloss.Color("15"), // Bright White
+		OnPrimary: lipgloss.Color("0"),  // Black
+	}
+}
+
+func noColorTheme() Theme {
+	return Theme{
+		Primary:   lipgloss.NoColor{},
+		Secondary: lipgloss.NoColor{},
+		Success:   lipgloss.NoColor{},
+		Error:     lipgloss.NoColor{},
+		Warning:   lipgloss.NoColor{},
+		Text:      lipgloss.NoColor{},
+		Muted:     lipgloss.NoColor{},
+		Border:    lipgloss.NoColor{},
+		OnPrimary: lipgloss.NoColor{},
+		Plain:     true,
 	}
 }
 
@@ -85,7 +168,7 @@ func isDarkBackground() bool {
 }
 
 func BuildStyles(theme Theme) Styles {
-	return Styles{
+	styles := Styles{
 		Title: lipgloss.NewStyle().
 			Foreground(theme.Primary).
 			Bold(true).
@@ -126,7 +209,7 @@ func BuildStyles(theme Theme) Styles {
 			BorderForeground(theme.Primary),
 
 		ButtonFocused: lipgloss.NewStyle().
-			Foreground(lipgloss.Color("0")).
+			Foreground(theme.OnPrimary).
 			Background(theme.Primary).
 			Padding(0, 2).
 			Bold(true).
@@ -184,6 +267,15 @@ func BuildStyles(theme Theme) Styles {
 		Divider: lipgloss.NewStyle().
 			Foreground(theme.Border),
 	}
+
+	if theme.Plain {
+		// Without color, focus and status must still be distinguishable
+		styles.Focused = styles.Focused.Underline(true)
+		styles.ButtonFocused = styles.ButtonFocused.Reverse(true)
+		styles.ProgressActive = styles.ProgressActive.Underline(true)
+		styles.Blurred = styles.Blurred.Faint(true)
+	}
+	return styles
 }
 
 type Styles struct {
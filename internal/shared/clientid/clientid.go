@@ -0,0 +1,98 @@
+// Package clientid identifies the application calling the API, from the X-Client-Id
+// header or the User-Agent, and carries it through request contexts so logs, metrics
+// and usage records can be broken down per client.
+package clientid
+
+import (
+	"context"
+	"strings"
+)
+
+// Sources of a Client
+const (
+	SourceHeader    = "header"
+	SourceUserAgent = "user_agent"
+)
+
+// Browser is the ID given to clients whose User-Agent is a web browser's
+const Browser = "browser"
+
+const (
+	maxIDLength      = 64
+	maxVersionLength = 32
+)
+
+// Client is the calling application, e.g. {ID: "ios-app", Version: "2.3.1"}. The zero
+// value is an unidentified client.
+type Client struct {
+	ID      string `json:"id"`
+	Version string `json:"version,omitempty"`
+	Source  string `json:"source,omitempty"`
+}
+
+// String returns "id/version", "id", or "" for an unidentified client
+func (c Client) String() string {
+	if c.Version == "" {
+		return c.ID
+	}
+	return c.ID + "/" + c.Version
+}
+
+// Parse identifies the client from header, written "id" or "id/version", and falls back
+// to the first product of userAgent. A malformed header is ignored rather than trusted.
+func Parse(header, userAgent string) Client {
+	if c, ok := parseProduct(header); ok {
+		c.Source = SourceHeader
+		return c
+	}
+	// Browsers all claim to be Mozilla; their exact make is of no use for outreach
+	if strings.HasPrefix(userAgent, "Mozilla/") {
+		return Client{ID: Browser, Source: SourceUserAgent}
+	}
+	product, _, _ := strings.Cut(strings.TrimSpace(userAgent), " ")
+	if c, ok := parseProduct(product); ok {
+		c.Source = SourceUserAgent
+		return c
+	}
+	return Client{}
+}
+
+// parseProduct reads "name" or "name/version" with a lowercased name of letters, digits,
+// '.', '_' and '-', and a version that also allows '+'
+func parseProduct(s string) (Client, bool) {
+	name, version, _ := strings.Cut(strings.TrimSpace(s), "/")
+	name = strings.ToLower(name)
+	if name == "" || len(name) > maxIDLength || !validToken(name, "") {
+		return Client{}, false
+	}
+	if len(version) > maxVersionLength || !validToken(version, "+") {
+		version = ""
+	}
+	return Client{ID: name, Version: version}, true
+}
+
+func validToken(s, extra string) bool {
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '.' || r == '_' || r == '-':
+		case strings.ContainsRune(extra, r):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+type ctxKey struct{}
+
+// With returns a copy of ctx that records c as the calling client
+func With(ctx context.Context, c Client) context.Context {
+	return context.WithValue(ctx, ctxKey{}, c)
+}
+
+// FromContext returns the client recorded in ctx, or the zero Client
+func FromContext(ctx context.Context) Client {
+	c, _ := ctx.Value(ctxKey{}).(Client)
+	return c
+}
@@ -0,0 +1,21 @@
+// Package clock abstracts the current time so expiry and rotation logic can be tested
+// without sleeping. Production code uses System; tests use testutil.FakeClock.
+package clock
+
+import "time"
+
+// Clock reports the current time
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// System returns the wall clock
+func System() Clock {
+	return systemClock{}
+}
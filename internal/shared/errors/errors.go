@@ -1,19 +1,26 @@
 package errors
 
-import "net/http"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
 
 // ErrorType represents the category of error
 type ErrorType string
 
 const (
-	ValidationError    ErrorType = "VALIDATION"
-	NotFoundError      ErrorType = "NOT_FOUND"
-	ConflictError      ErrorType = "CONFLICT"
-	UnauthorizedError  ErrorType = "UNAUTHORIZED"
-	ForbiddenError     ErrorType = "FORBIDDEN"
-	InternalError      ErrorType = "INTERNAL"
-	BadRequestError    ErrorType = "BAD_REQUEST"
-	AlreadyExistsError ErrorType = "ALREADY_EXISTS"
+	ValidationError      ErrorType = "VALIDATION"
+	NotFoundError        ErrorType = "NOT_FOUND"
+	ConflictError        ErrorType = "CONFLICT"
+	UnauthorizedError    ErrorType = "UNAUTHORIZED"
+	ForbiddenError       ErrorType = "FORBIDDEN"
+	InternalError        ErrorType = "INTERNAL"
+	BadRequestError      ErrorType = "BAD_REQUEST"
+	AlreadyExistsError   ErrorType = "ALREADY_EXISTS"
+	TooManyRequestsError ErrorType = "TOO_MANY_REQUESTS"
+	PayloadTooLargeError ErrorType = "PAYLOAD_TOO_LARGE"
 )
 
 // AppError is the unified error type for the application
@@ -22,6 +29,18 @@ type AppError struct {
 	Message    string    `json:"message"`
 	HTTPStatus int       `json:"-"` // Not exposed in JSON
 	Details    string    `json:"details,omitempty"`
+	// RetryAfter is sent as the Retry-After header, in seconds, when positive
+	RetryAfter int `json:"-"`
+	// DetailParts are the parts Details was formatted from, kept so the response can be
+	// translated part by part; nil when Details is free text
+	DetailParts []Detail `json:"-"`
+}
+
+// Detail is a printf format and its arguments. Format, not the formatted text, is what
+// translations are looked up by.
+type Detail struct {
+	Format string
+	Args   []interface{}
 }
 
 // Error implements the error interface
@@ -48,6 +67,18 @@ func NewAppErrorWithDetails(errType ErrorType, message string, details string) *
 	}
 }
 
+// NewAppErrorWithDetailParts creates a new AppError whose Details are parts, formatted
+// and separated by semicolons
+func NewAppErrorWithDetailParts(errType ErrorType, message string, parts ...Detail) *AppError {
+	formatted := make([]string, len(parts))
+	for i, part := range parts {
+		formatted[i] = fmt.Sprintf(part.Format, part.Args...)
+	}
+	appErr := NewAppErrorWithDetails(errType, message, strings.Join(formatted, "; "))
+	appErr.DetailParts = parts
+	return appErr
+}
+
 // mapErrorTypeToStatus maps error types to HTTP status codes
 func mapErrorTypeToStatus(errType ErrorType) int {
 	switch errType {
@@ -63,14 +94,24 @@ func mapErrorTypeToStatus(errType ErrorType) int {
 		return http.StatusForbidden
 	case InternalError:
 		return http.StatusInternalServerError
+	case TooManyRequestsError:
+		return http.StatusTooManyRequests
+	case PayloadTooLargeError:
+		return http.StatusRequestEntityTooLarge
 	default:
 		return http.StatusInternalServerError
 	}
 }
 
-// IsAppError checks if an error is an AppError
+// IsAppError checks if an error is or wraps an AppError. A MultiError holding at least
+// one AppError is reported as the AppError it folds into (see MultiError.AppError).
 func IsAppError(err error) (*AppError, bool) {
-	appErr, ok := err.(*AppError)
+	if multi, ok := err.(*MultiError); ok {
+		appErr := multi.AppError()
+		return appErr, appErr != nil
+	}
+	var appErr *AppError
+	ok := errors.As(err, &appErr)
 	return appErr, ok
 }
 
@@ -82,6 +123,11 @@ var (
 	ErrInvalidRefreshToken    = NewAppError(UnauthorizedError, "invalid or expired refresh token")
 	ErrUsernameAlreadyTaken   = NewAppError(ConflictError, "username already taken")
 	ErrEmailAlreadyRegistered = NewAppError(ConflictError, "email already registered")
+	ErrPhoneAlreadyRegistered = NewAppError(ConflictError, "phone number already registered")
+	ErrInvalidOTP             = NewAppError(UnauthorizedError, "invalid or expired verification code")
+	ErrProfileFieldExists     = NewAppError(ConflictError, "profile field already exists")
+	ErrProfileFieldFromConfig = NewAppError(ConflictError, "profile field is declared in PROFILE_FIELDS")
+	ErrRoleExists             = NewAppError(ConflictError, "role already exists")
 	ErrUserNotFound           = NewAppError(NotFoundError, "user not found")
 	ErrDatabaseError          = NewAppError(InternalError, "database error")
 	ErrInvalidFileExtension   = NewAppError(ValidationError, "file must have a valid extension")
@@ -0,0 +1,119 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestJoin(t *testing.T) {
+	first := errors.New("first")
+	second := errors.New("second")
+	third := errors.New("third")
+
+	tests := []struct {
+		name string
+		errs []error
+		want []error
+	}{
+		{name: "no errors", errs: []error{nil, nil}, want: nil},
+		{name: "one error", errs: []error{nil, first}, want: []error{first}},
+		{name: "several errors", errs: []error{first, nil, second}, want: []error{first, second}},
+		{name: "nested", errs: []error{Join(first, second), third}, want: []error{first, second, third}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			err := Join(tt.errs...)
+
+			// Assert
+			switch len(tt.want) {
+			case 0:
+				if err != nil {
+					t.Fatalf("Join() = %v, want nil", err)
+				}
+			case 1:
+				if err != tt.want[0] {
+					t.Fatalf("Join() = %v, want the error itself", err)
+				}
+			default:
+				multi, ok := err.(*MultiError)
+				if !ok || len(multi.Errs) != len(tt.want) {
+					t.Fatalf("Join() = %#v, want a MultiError of %d errors", err, len(tt.want))
+				}
+				for i, want := range tt.want {
+					if multi.Errs[i] != want || !errors.Is(err, want) {
+						t.Errorf("Errs[%d] = %v, want %v", i, multi.Errs[i], want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestMultiError_Error(t *testing.T) {
+	// Act
+	err := Join(errors.New("close database"), errors.New("stop scheduler"))
+
+	// Assert
+	if got, want := err.Error(), "2 errors: close database; stop scheduler"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestIsAppError_MultiError(t *testing.T) {
+	limited := NewAppError(TooManyRequestsError, "Too many attempts")
+	limited.RetryAfter = 30
+
+	tests := []struct {
+		name        string
+		err         error
+		wantOK      bool
+		wantStatus  int
+		wantMessage string
+		wantDetails string
+	}{
+		{
+			name:        "most severe app error wins",
+			err:         Join(NewAppErrorWithDetails(ValidationError, "Invalid role", "name too long"), fmt.Errorf("role support: %w", ErrRoleExists)),
+			wantOK:      true,
+			wantStatus:  http.StatusConflict,
+			wantMessage: "role already exists",
+			wantDetails: "Invalid role: name too long; role support: role already exists",
+		},
+		{
+			name:        "plain errors count as internal",
+			err:         Join(limited, errors.New("connection reset")),
+			wantOK:      true,
+			wantStatus:  http.StatusInternalServerError,
+			wantMessage: "An unexpected error occurred",
+			wantDetails: "Too many attempts; connection reset",
+		},
+		{
+			name:   "no app errors",
+			err:    Join(errors.New("a"), errors.New("b")),
+			wantOK: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			appErr, ok := IsAppError(tt.err)
+
+			// Assert
+			if ok != tt.wantOK {
+				t.Fatalf("IsAppError() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if appErr.HTTPStatus != tt.wantStatus || appErr.Message != tt.wantMessage || appErr.Details != tt.wantDetails {
+				t.Errorf("IsAppError() = %d %q %q, want %d %q %q", appErr.HTTPStatus, appErr.Message, appErr.Details, tt.wantStatus, tt.wantMessage, tt.wantDetails)
+			}
+		})
+	}
+	if appErr, _ := IsAppError(Join(limited, ErrUserNotFound)); appErr.RetryAfter != 30 {
+		t.Errorf("RetryAfter = %d, want the largest of the errors", appErr.RetryAfter)
+	}
+}
@@ -0,0 +1,96 @@
+package jwk
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+)
+
+func TestParsePrivateKey_RoundTrip(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	edDER, _ := x509.MarshalPKCS8PrivateKey(edKey)
+	tests := []struct {
+		name    string
+		block   *pem.Block
+		wantKty string
+		wantAlg string
+	}{
+		{name: "RSA PKCS#1", block: &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}, wantKty: "RSA", wantAlg: AlgorithmRS256},
+		{name: "Ed25519 PKCS#8", block: &pem.Block{Type: "PRIVATE KEY", Bytes: edDER}, wantKty: "OKP", wantAlg: AlgorithmEdDSA},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			data := pem.EncodeToMemory(tt.block)
+
+			// Act
+			signer, err := ParsePrivateKey(data)
+			if err != nil {
+				t.Fatalf("ParsePrivateKey() error = %v", err)
+			}
+			key, err := New(signer.Public())
+
+			// Assert
+			if err != nil || key.KeyType != tt.wantKty || key.Algorithm != tt.wantAlg {
+				t.Fatalf("New() = %+v, %v; want kty %s alg %s", key, err, tt.wantKty, tt.wantAlg)
+			}
+			if key.KeyID == "" || key.KeyID != KeyID(signer.Public()) {
+				t.Errorf("kid = %q, want the stable KeyID", key.KeyID)
+			}
+		})
+	}
+}
+
+func TestParsePrivateKey_Invalid(t *testing.T) {
+	// Act
+	_, noPEM := ParsePrivateKey([]byte("not a key"))
+	_, public := ParsePrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: []byte{1}}))
+
+	// Assert
+	if noPEM == nil || public == nil {
+		t.Errorf("ParsePrivateKey() errors = %v, %v; want both rejected", noPEM, public)
+	}
+}
+
+func TestKey_PublicKeyRoundTrip(t *testing.T) {
+	// Arrange
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	edPub, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	for _, pub := range []crypto.PublicKey{&rsaKey.PublicKey, edPub} {
+		key, err := New(pub)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+
+		// Act
+		decoded, err := key.PublicKey()
+
+		// Assert
+		if err != nil {
+			t.Fatalf("PublicKey() error = %v", err)
+		}
+		if KeyID(decoded) != key.KeyID {
+			t.Errorf("decoded %s key does not match the original", key.KeyType)
+		}
+	}
+}
+
+func TestKey_PublicKeyRejectsUnsupported(t *testing.T) {
+	// Act
+	_, ecErr := Key{KeyType: "EC", Curve: "P-256"}.PublicKey()
+	_, rsaErr := Key{KeyType: "RSA", N: "AQAB", E: "AQ"}.PublicKey()
+
+	// Assert
+	if ecErr == nil {
+		t.Error("PublicKey() accepted an EC key")
+	}
+	if rsaErr == nil {
+		t.Error("PublicKey() accepted an RSA key with exponent 1")
+	}
+}
@@ -0,0 +1,38 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/MurtadaNazar/go-api-template/internal/shared/bufpool"
+)
+
+var jsonContentType = []string{"application/json; charset=utf-8"}
+
+// PooledJSON renders Data as JSON through a pooled buffer. Use it for large responses
+// such as lists, where c.JSON would marshal into a new slice the size of the body:
+//
+//	c.Render(http.StatusOK, response.PooledJSON{Data: response.NewSuccessResponse(users, requestID)})
+type PooledJSON struct {
+	Data interface{}
+}
+
+// Render implements gin's render.Render
+func (r PooledJSON) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	buf := bufpool.Get()
+	defer bufpool.Put(buf)
+	if err := json.NewEncoder(buf).Encode(r.Data); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// WriteContentType implements gin's render.Render
+func (PooledJSON) WriteContentType(w http.ResponseWriter) {
+	header := w.Header()
+	if val := header["Content-Type"]; len(val) == 0 {
+		header["Content-Type"] = jsonContentType
+	}
+}
@@ -0,0 +1,80 @@
+// Package timing collects how long a request spent in the database, object storage and
+// other dependencies, so ServerTimingMiddleware can report it. Recording is a no-op
+// unless the middleware attached a Recorder to the request context.
+package timing
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Segment is the time spent in one kind of dependency during a request
+type Segment struct {
+	Name     string
+	Duration time.Duration
+	Count    int
+}
+
+// Recorder sums durations per segment name. It is safe for concurrent use, as a
+// handler may query the database from several goroutines.
+type Recorder struct {
+	mu       sync.Mutex
+	segments []Segment
+}
+
+type ctxKey struct{}
+
+// With returns a copy of ctx that records segments into r
+func With(ctx context.Context, r *Recorder) context.Context {
+	return context.WithValue(ctx, ctxKey{}, r)
+}
+
+// FromContext returns the Recorder attached to ctx, or nil
+func FromContext(ctx context.Context) *Recorder {
+	r, _ := ctx.Value(ctxKey{}).(*Recorder)
+	return r
+}
+
+// Add counts one call of d in the named segment; it does nothing on a nil Recorder
+func (r *Recorder) Add(name string, d time.Duration) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.segments {
+		if r.segments[i].Name == name {
+			r.segments[i].Duration += d
+			r.segments[i].Count++
+			return
+		}
+	}
+	r.segments = append(r.segments, Segment{Name: name, Duration: d, Count: 1})
+}
+
+// Segments returns the segments in the order they were first recorded
+func (r *Recorder) Segments() []Segment {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Segment(nil), r.segments...)
+}
+
+func noop() {}
+
+// Start begins timing a call in the named segment and returns the function that ends it:
+//
+//	done := timing.Start(ctx, "storage")
+//	_, err := client.PutObject(ctx, ...)
+//	done()
+func Start(ctx context.Context, name string) func() {
+	r := FromContext(ctx)
+	if r == nil {
+		return noop
+	}
+	start := time.Now()
+	return func() { r.Add(name, time.Since(start)) }
+}
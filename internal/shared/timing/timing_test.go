@@ -0,0 +1,46 @@
+package timing
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRecorder_SumsConcurrentCalls(t *testing.T) {
+	// Arrange
+	r := &Recorder{}
+	ctx := With(context.Background(), r)
+	var wg sync.WaitGroup
+
+	// Act
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			FromContext(ctx).Add("db", time.Millisecond)
+		}()
+	}
+	wg.Wait()
+	Start(ctx, "storage")()
+
+	// Assert
+	segments := r.Segments()
+	if len(segments) != 2 || segments[0] != (Segment{Name: "db", Duration: 10 * time.Millisecond, Count: 10}) {
+		t.Errorf("segments = %+v", segments)
+	}
+	if segments[1].Name != "storage" || segments[1].Count != 1 {
+		t.Errorf("storage segment = %+v", segments[1])
+	}
+}
+
+func TestStart_WithoutRecorder(t *testing.T) {
+	// Act
+	Start(context.Background(), "db")()
+	FromContext(context.Background()).Add("db", time.Second)
+
+	// Assert
+	if got := FromContext(context.Background()).Segments(); got != nil {
+		t.Errorf("Segments() without a recorder = %v, want nil", got)
+	}
+}
@@ -0,0 +1,42 @@
+package testutil
+
+import (
+	"sync"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/shared/clock"
+)
+
+// FakeClock is a clock.Clock that only moves when told to
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// Verify FakeClock implements Clock interface
+var _ clock.Clock = (*FakeClock)(nil)
+
+// NewFakeClock creates a fake clock stopped at now
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set moves the clock to t
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
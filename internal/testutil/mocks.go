@@ -2,8 +2,17 @@ package testutil
 
 import (
 	"context"
-	"go_platform_template/internal/domain/user/model"
-	"go_platform_template/internal/domain/user/repo"
+	activityModel "github.com/MurtadaNazar/go-api-template/internal/domain/activity/model"
+	activityRepo "github.com/MurtadaNazar/go-api-template/internal/domain/activity/repo"
+	authModel "github.com/MurtadaNazar/go-api-template/internal/domain/auth/model"
+	authRepo "github.com/MurtadaNazar/go-api-template/internal/domain/auth/repo"
+	authzModel "github.com/MurtadaNazar/go-api-template/internal/domain/authz/model"
+	authzRepo "github.com/MurtadaNazar/go-api-template/internal/domain/authz/repo"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/repo"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/sms"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,10 +24,19 @@ type MockUserRepo struct {
 	FindByIDFn             func(ctx context.Context, id string) (*model.User, error)
 	UpdateFn               func(ctx context.Context, user *model.User) error
 	DeleteFn               func(ctx context.Context, id string) error
+	ApplyBatchFn           func(ctx context.Context, updated, deleted []*model.User) error
 	GetByEmailFn           func(ctx context.Context, email string) (*model.User, error)
+	GetByPhoneFn           func(ctx context.Context, phone string) (*model.User, error)
 	FindByUsernameFn       func(ctx context.Context, username string) (*model.User, error)
 	GetByEmailOrUsernameFn func(ctx context.Context, emailOrUsername string) (*model.User, error)
 	ListFn                 func(ctx context.Context, offset, limit int, filters map[string]interface{}, sortBy, sortOrder string) ([]*model.User, error)
+	ListAfterFn            func(ctx context.Context, cursor *model.ListCursor, limit int, filters map[string]interface{}, sortOrder string) ([]*model.User, error)
+	ListDueForDeletionFn   func(ctx context.Context, now time.Time, limit int) ([]*model.User, error)
+	RecordLoginFn          func(ctx context.Context, id string, at time.Time, ip string) error
+	StatsFn                func(ctx context.Context, now time.Time) (*model.UserStats, error)
+	CountRegistrationsFn   func(ctx context.Context, since time.Time) ([]model.DailyCount, error)
+	CountByStatusFn        func(ctx context.Context) (map[string]int64, error)
+	CountByRoleFn          func(ctx context.Context) (map[string]int64, error)
 }
 
 // Verify MockUserRepo implements UserRepo interface
@@ -52,6 +70,13 @@ func (m *MockUserRepo) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+func (m *MockUserRepo) ApplyBatch(ctx context.Context, updated, deleted []*model.User) error {
+	if m.ApplyBatchFn != nil {
+		return m.ApplyBatchFn(ctx, updated, deleted)
+	}
+	return nil
+}
+
 func (m *MockUserRepo) GetByEmail(ctx context.Context, email string) (*model.User, error) {
 	if m.GetByEmailFn != nil {
 		return m.GetByEmailFn(ctx, email)
@@ -59,6 +84,13 @@ func (m *MockUserRepo) GetByEmail(ctx context.Context, email string) (*model.Use
 	return nil, nil
 }
 
+func (m *MockUserRepo) GetByPhone(ctx context.Context, phone string) (*model.User, error) {
+	if m.GetByPhoneFn != nil {
+		return m.GetByPhoneFn(ctx, phone)
+	}
+	return nil, nil
+}
+
 func (m *MockUserRepo) FindByUsername(ctx context.Context, username string) (*model.User, error) {
 	if m.FindByUsernameFn != nil {
 		return m.FindByUsernameFn(ctx, username)
@@ -80,6 +112,719 @@ func (m *MockUserRepo) List(ctx context.Context, offset, limit int, filters map[
 	return make([]*model.User, 0), nil
 }
 
+func (m *MockUserRepo) ListAfter(ctx context.Context, cursor *model.ListCursor, limit int, filters map[string]interface{}, sortOrder string) ([]*model.User, error) {
+	if m.ListAfterFn != nil {
+		return m.ListAfterFn(ctx, cursor, limit, filters, sortOrder)
+	}
+	return make([]*model.User, 0), nil
+}
+
+// StreamList passes the users ListFn returns without pagination to fn
+func (m *MockUserRepo) StreamList(ctx context.Context, filters map[string]interface{}, sortBy, sortOrder string, fn func(*model.User) error) error {
+	users, err := m.List(ctx, 0, 0, filters, sortBy, sortOrder)
+	if err != nil {
+		return err
+	}
+	for _, u := range users {
+		if err := fn(u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MockUserRepo) ListDueForDeletion(ctx context.Context, now time.Time, limit int) ([]*model.User, error) {
+	if m.ListDueForDeletionFn != nil {
+		return m.ListDueForDeletionFn(ctx, now, limit)
+	}
+	return nil, nil
+}
+
+func (m *MockUserRepo) RecordLogin(ctx context.Context, id string, at time.Time, ip string) error {
+	if m.RecordLoginFn != nil {
+		return m.RecordLoginFn(ctx, id, at, ip)
+	}
+	return nil
+}
+
+func (m *MockUserRepo) Stats(ctx context.Context, now time.Time) (*model.UserStats, error) {
+	if m.StatsFn != nil {
+		return m.StatsFn(ctx, now)
+	}
+	return &model.UserStats{}, nil
+}
+
+func (m *MockUserRepo) CountRegistrations(ctx context.Context, since time.Time) ([]model.DailyCount, error) {
+	if m.CountRegistrationsFn != nil {
+		return m.CountRegistrationsFn(ctx, since)
+	}
+	return nil, nil
+}
+
+func (m *MockUserRepo) CountByStatus(ctx context.Context) (map[string]int64, error) {
+	if m.CountByStatusFn != nil {
+		return m.CountByStatusFn(ctx)
+	}
+	return map[string]int64{}, nil
+}
+
+func (m *MockUserRepo) CountByRole(ctx context.Context) (map[string]int64, error) {
+	if m.CountByRoleFn != nil {
+		return m.CountByRoleFn(ctx)
+	}
+	return map[string]int64{}, nil
+}
+
+// MockProfileFieldRepo is a mock implementation of ProfileFieldRepo for testing
+type MockProfileFieldRepo struct {
+	ListFn      func(ctx context.Context) ([]model.ProfileField, error)
+	FindByKeyFn func(ctx context.Context, key string) (*model.ProfileField, error)
+	CreateFn    func(ctx context.Context, field *model.ProfileField) error
+	UpdateFn    func(ctx context.Context, field *model.ProfileField) error
+	DeleteFn    func(ctx context.Context, key string) error
+}
+
+// Verify MockProfileFieldRepo implements ProfileFieldRepo interface
+var _ repo.ProfileFieldRepo = (*MockProfileFieldRepo)(nil)
+
+func (m *MockProfileFieldRepo) List(ctx context.Context) ([]model.ProfileField, error) {
+	if m.ListFn != nil {
+		return m.ListFn(ctx)
+	}
+	return nil, nil
+}
+
+func (m *MockProfileFieldRepo) FindByKey(ctx context.Context, key string) (*model.ProfileField, error) {
+	if m.FindByKeyFn != nil {
+		return m.FindByKeyFn(ctx, key)
+	}
+	return nil, nil
+}
+
+func (m *MockProfileFieldRepo) Create(ctx context.Context, field *model.ProfileField) error {
+	if m.CreateFn != nil {
+		return m.CreateFn(ctx, field)
+	}
+	return nil
+}
+
+func (m *MockProfileFieldRepo) Update(ctx context.Context, field *model.ProfileField) error {
+	if m.UpdateFn != nil {
+		return m.UpdateFn(ctx, field)
+	}
+	return nil
+}
+
+func (m *MockProfileFieldRepo) Delete(ctx context.Context, key string) error {
+	if m.DeleteFn != nil {
+		return m.DeleteFn(ctx, key)
+	}
+	return nil
+}
+
+// MockRevisionRepo is a mock implementation of RevisionRepo that keeps revisions in memory
+type MockRevisionRepo struct {
+	CreateFn     func(ctx context.Context, revisions []model.UserRevision) error
+	ListByUserFn func(ctx context.Context, userID string, offset, limit int) ([]model.UserRevision, error)
+	Revisions    []model.UserRevision
+}
+
+// Verify MockRevisionRepo implements RevisionRepo interface
+var _ repo.RevisionRepo = (*MockRevisionRepo)(nil)
+
+func (m *MockRevisionRepo) Create(ctx context.Context, revisions []model.UserRevision) error {
+	if m.CreateFn != nil {
+		return m.CreateFn(ctx, revisions)
+	}
+	m.Revisions = append(m.Revisions, revisions...)
+	return nil
+}
+
+// RedactUser replaces the values of the user's revisions with model.RedactedValue
+func (m *MockRevisionRepo) RedactUser(ctx context.Context, userID string) error {
+	redacted := model.RedactedValue
+	for i := range m.Revisions {
+		if m.Revisions[i].UserID.String() != userID {
+			continue
+		}
+		if m.Revisions[i].OldValue != nil {
+			m.Revisions[i].OldValue = &redacted
+		}
+		if m.Revisions[i].NewValue != nil {
+			m.Revisions[i].NewValue = &redacted
+		}
+	}
+	return nil
+}
+
+func (m *MockRevisionRepo) ListByUser(ctx context.Context, userID string, offset, limit int) ([]model.UserRevision, error) {
+	if m.ListByUserFn != nil {
+		return m.ListByUserFn(ctx, userID, offset, limit)
+	}
+	return m.Revisions, nil
+}
+
+// MockStatusHistoryRepo is a mock implementation of StatusHistoryRepo that keeps changes in memory
+type MockStatusHistoryRepo struct {
+	Changes []model.StatusChange
+}
+
+// Verify MockStatusHistoryRepo implements StatusHistoryRepo interface
+var _ repo.StatusHistoryRepo = (*MockStatusHistoryRepo)(nil)
+
+func (m *MockStatusHistoryRepo) Create(ctx context.Context, change *model.StatusChange) error {
+	change.ID = uuid.New()
+	change.CreatedAt = time.Now()
+	m.Changes = append(m.Changes, *change)
+	return nil
+}
+
+func (m *MockStatusHistoryRepo) ListByUser(ctx context.Context, userID string, offset, limit int) ([]model.StatusChange, error) {
+	changes := []model.StatusChange{}
+	for i := len(m.Changes) - 1; i >= 0; i-- {
+		if m.Changes[i].UserID.String() == userID {
+			changes = append(changes, m.Changes[i])
+		}
+	}
+	return changes, nil
+}
+
+// MockPasswordHistoryRepo is a mock implementation of PasswordHistoryRepo that keeps entries in memory
+type MockPasswordHistoryRepo struct {
+	Entries []model.PasswordHistory
+}
+
+// Verify MockPasswordHistoryRepo implements PasswordHistoryRepo interface
+var _ repo.PasswordHistoryRepo = (*MockPasswordHistoryRepo)(nil)
+
+func (m *MockPasswordHistoryRepo) Create(ctx context.Context, entry *model.PasswordHistory) error {
+	m.Entries = append(m.Entries, *entry)
+	return nil
+}
+
+// Recent returns the entries of userID in reverse insertion order
+func (m *MockPasswordHistoryRepo) Recent(ctx context.Context, userID string, limit int) ([]model.PasswordHistory, error) {
+	var entries []model.PasswordHistory
+	for i := len(m.Entries) - 1; i >= 0 && len(entries) < limit; i-- {
+		if m.Entries[i].UserID.String() == userID {
+			entries = append(entries, m.Entries[i])
+		}
+	}
+	return entries, nil
+}
+
+func (m *MockPasswordHistoryRepo) Prune(ctx context.Context, keep int) (int64, error) {
+	kept := map[uuid.UUID]int{}
+	var remaining []model.PasswordHistory
+	for i := len(m.Entries) - 1; i >= 0; i-- {
+		if kept[m.Entries[i].UserID] < keep {
+			kept[m.Entries[i].UserID]++
+			remaining = append([]model.PasswordHistory{m.Entries[i]}, remaining...)
+		}
+	}
+	deleted := int64(len(m.Entries) - len(remaining))
+	m.Entries = remaining
+	return deleted, nil
+}
+
+func (m *MockPasswordHistoryRepo) DeleteByUser(ctx context.Context, userID string) error {
+	var remaining []model.PasswordHistory
+	for _, entry := range m.Entries {
+		if entry.UserID.String() != userID {
+			remaining = append(remaining, entry)
+		}
+	}
+	m.Entries = remaining
+	return nil
+}
+
+// MockTagRepo is a mock implementation of TagRepo that keeps tags in memory
+type MockTagRepo struct {
+	Tags []model.UserTag
+}
+
+// Verify MockTagRepo implements TagRepo interface
+var _ repo.TagRepo = (*MockTagRepo)(nil)
+
+func (m *MockTagRepo) ListByUser(ctx context.Context, userID string) ([]string, error) {
+	tags := []string{}
+	for _, t := range m.Tags {
+		if t.UserID.String() == userID {
+			tags = append(tags, t.Tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+func (m *MockTagRepo) Add(ctx context.Context, userID uuid.UUID, tags []string) error {
+	for _, tag := range tags {
+		exists := false
+		for _, t := range m.Tags {
+			exists = exists || (t.UserID == userID && t.Tag == tag)
+		}
+		if !exists {
+			m.Tags = append(m.Tags, model.UserTag{UserID: userID, Tag: tag, CreatedAt: time.Now()})
+		}
+	}
+	return nil
+}
+
+func (m *MockTagRepo) Remove(ctx context.Context, userID, tag string) (bool, error) {
+	for i, t := range m.Tags {
+		if t.UserID.String() == userID && t.Tag == tag {
+			m.Tags = append(m.Tags[:i], m.Tags[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Counts counts the users of every tag, deleted or not
+func (m *MockTagRepo) Counts(ctx context.Context) ([]model.TagCount, error) {
+	users := map[string]int64{}
+	for _, t := range m.Tags {
+		users[t.Tag]++
+	}
+	counts := []model.TagCount{}
+	for tag, n := range users {
+		counts = append(counts, model.TagCount{Tag: tag, Users: n})
+	}
+	sort.Slice(counts, func(i, j int) bool { return counts[i].Tag < counts[j].Tag })
+	return counts, nil
+}
+
+// MockNoteRepo is a mock implementation of NoteRepo that keeps notes in memory
+type MockNoteRepo struct {
+	Notes []model.UserNote
+}
+
+// Verify MockNoteRepo implements NoteRepo interface
+var _ repo.NoteRepo = (*MockNoteRepo)(nil)
+
+func (m *MockNoteRepo) ListByUser(ctx context.Context, userID string) ([]model.UserNote, error) {
+	notes := []model.UserNote{}
+	for i := len(m.Notes) - 1; i >= 0; i-- {
+		if m.Notes[i].UserID.String() == userID {
+			notes = append(notes, m.Notes[i])
+		}
+	}
+	return notes, nil
+}
+
+func (m *MockNoteRepo) FindByID(ctx context.Context, id string) (*model.UserNote, error) {
+	for _, note := range m.Notes {
+		if note.ID.String() == id {
+			return &note, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockNoteRepo) Create(ctx context.Context, note *model.UserNote) error {
+	note.ID = uuid.New()
+	note.CreatedAt = time.Now()
+	note.UpdatedAt = note.CreatedAt
+	m.Notes = append(m.Notes, *note)
+	return nil
+}
+
+func (m *MockNoteRepo) Update(ctx context.Context, note *model.UserNote) error {
+	for i := range m.Notes {
+		if m.Notes[i].ID == note.ID {
+			note.UpdatedAt = time.Now()
+			m.Notes[i] = *note
+		}
+	}
+	return nil
+}
+
+func (m *MockNoteRepo) Delete(ctx context.Context, id string) error {
+	for i := range m.Notes {
+		if m.Notes[i].ID.String() == id {
+			m.Notes = append(m.Notes[:i], m.Notes[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (m *MockNoteRepo) DeleteByUser(ctx context.Context, userID string) error {
+	var remaining []model.UserNote
+	for _, note := range m.Notes {
+		if note.UserID.String() != userID {
+			remaining = append(remaining, note)
+		}
+	}
+	m.Notes = remaining
+	return nil
+}
+
+// MockOTPRepo is a mock implementation of OTPRepo for testing
+type MockOTPRepo struct {
+	CreateFn            func(ctx context.Context, code *authModel.OTPCode) error
+	FindActiveFn        func(ctx context.Context, userID uuid.UUID, purpose authModel.OTPPurpose) (*authModel.OTPCode, error)
+	IncrementAttemptsFn func(ctx context.Context, id uuid.UUID) error
+	DeleteForUserFn     func(ctx context.Context, userID uuid.UUID, purpose authModel.OTPPurpose) error
+	DeleteExpiredFn     func(ctx context.Context) error
+}
+
+// Verify MockOTPRepo implements OTPRepo interface
+var _ authRepo.OTPRepo = (*MockOTPRepo)(nil)
+
+func (m *MockOTPRepo) Create(ctx context.Context, code *authModel.OTPCode) error {
+	if m.CreateFn != nil {
+		return m.CreateFn(ctx, code)
+	}
+	return nil
+}
+
+func (m *MockOTPRepo) FindActive(ctx context.Context, userID uuid.UUID, purpose authModel.OTPPurpose) (*authModel.OTPCode, error) {
+	if m.FindActiveFn != nil {
+		return m.FindActiveFn(ctx, userID, purpose)
+	}
+	return nil, nil
+}
+
+func (m *MockOTPRepo) IncrementAttempts(ctx context.Context, id uuid.UUID) error {
+	if m.IncrementAttemptsFn != nil {
+		return m.IncrementAttemptsFn(ctx, id)
+	}
+	return nil
+}
+
+func (m *MockOTPRepo) DeleteForUser(ctx context.Context, userID uuid.UUID, purpose authModel.OTPPurpose) error {
+	if m.DeleteForUserFn != nil {
+		return m.DeleteForUserFn(ctx, userID, purpose)
+	}
+	return nil
+}
+
+func (m *MockOTPRepo) DeleteExpired(ctx context.Context) error {
+	if m.DeleteExpiredFn != nil {
+		return m.DeleteExpiredFn(ctx)
+	}
+	return nil
+}
+
+// MockOAuthRepo is an in-memory implementation of OAuthRepo for testing
+type MockOAuthRepo struct {
+	Identities []authModel.OAuthIdentity
+}
+
+// Verify MockOAuthRepo implements OAuthRepo interface
+var _ authRepo.OAuthRepo = (*MockOAuthRepo)(nil)
+
+func (m *MockOAuthRepo) Create(ctx context.Context, identity *authModel.OAuthIdentity) error {
+	m.Identities = append(m.Identities, *identity)
+	return nil
+}
+
+func (m *MockOAuthRepo) FindBySubject(ctx context.Context, provider, subject string) (*authModel.OAuthIdentity, error) {
+	for i := range m.Identities {
+		if m.Identities[i].Provider == provider && m.Identities[i].Subject == subject {
+			return &m.Identities[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// MockServiceClientRepo is an in-memory implementation of ServiceClientRepo for testing
+type MockServiceClientRepo struct {
+	Clients []authModel.ServiceClient
+}
+
+// Verify MockServiceClientRepo implements ServiceClientRepo interface
+var _ authRepo.ServiceClientRepo = (*MockServiceClientRepo)(nil)
+
+func (m *MockServiceClientRepo) Create(ctx context.Context, client *authModel.ServiceClient) error {
+	for _, c := range m.Clients {
+		if c.ClientID == client.ClientID {
+			return apperrors.NewAppError(apperrors.ConflictError, "client ID already exists")
+		}
+	}
+	if client.ID == uuid.Nil {
+		client.ID = uuid.New()
+	}
+	m.Clients = append(m.Clients, *client)
+	return nil
+}
+
+func (m *MockServiceClientRepo) FindByClientID(ctx context.Context, clientID string) (*authModel.ServiceClient, error) {
+	for i := range m.Clients {
+		if m.Clients[i].ClientID == clientID {
+			c := m.Clients[i]
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockServiceClientRepo) List(ctx context.Context) ([]authModel.ServiceClient, error) {
+	return append([]authModel.ServiceClient(nil), m.Clients...), nil
+}
+
+func (m *MockServiceClientRepo) Delete(ctx context.Context, clientID string) (bool, error) {
+	for i, c := range m.Clients {
+		if c.ClientID == clientID {
+			m.Clients = append(m.Clients[:i], m.Clients[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// MockActionTokenRepo is an in-memory implementation of ActionTokenRepo for testing
+type MockActionTokenRepo struct {
+	Tokens []authModel.ActionToken
+}
+
+// Verify MockActionTokenRepo implements ActionTokenRepo interface
+var _ authRepo.ActionTokenRepo = (*MockActionTokenRepo)(nil)
+
+func (m *MockActionTokenRepo) Create(ctx context.Context, token *authModel.ActionToken) error {
+	if token.ID == uuid.Nil {
+		token.ID = uuid.New()
+	}
+	m.Tokens = append(m.Tokens, *token)
+	return nil
+}
+
+func (m *MockActionTokenRepo) FindByHash(ctx context.Context, hash string) (*authModel.ActionToken, error) {
+	for i := range m.Tokens {
+		if m.Tokens[i].TokenHash == hash {
+			t := m.Tokens[i]
+			return &t, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockActionTokenRepo) Consume(ctx context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	for i := range m.Tokens {
+		t := &m.Tokens[i]
+		if t.ID == id && t.ConsumedAt == nil && t.ExpiresAt.After(now) {
+			t.ConsumedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *MockActionTokenRepo) DeleteForSubject(ctx context.Context, purpose, subject string) error {
+	kept := m.Tokens[:0]
+	for _, t := range m.Tokens {
+		if t.Purpose != purpose || t.Subject != subject {
+			kept = append(kept, t)
+		}
+	}
+	m.Tokens = kept
+	return nil
+}
+
+func (m *MockActionTokenRepo) DeleteExpired(ctx context.Context, cutoff time.Time) error {
+	kept := m.Tokens[:0]
+	for _, t := range m.Tokens {
+		if !t.ExpiresAt.Before(cutoff) && (t.ConsumedAt == nil || !t.ConsumedAt.Before(cutoff)) {
+			kept = append(kept, t)
+		}
+	}
+	m.Tokens = kept
+	return nil
+}
+
+// MockWebAuthnRepo is an in-memory implementation of WebAuthnRepo for testing
+type MockWebAuthnRepo struct {
+	Challenges  []authModel.WebAuthnChallenge
+	Credentials []authModel.WebAuthnCredential
+}
+
+// Verify MockWebAuthnRepo implements WebAuthnRepo interface
+var _ authRepo.WebAuthnRepo = (*MockWebAuthnRepo)(nil)
+
+func (m *MockWebAuthnRepo) CreateChallenge(ctx context.Context, challenge *authModel.WebAuthnChallenge) error {
+	m.Challenges = append(m.Challenges, *challenge)
+	return nil
+}
+
+func (m *MockWebAuthnRepo) ConsumeChallenge(ctx context.Context, challengeHash string) (*authModel.WebAuthnChallenge, error) {
+	for i, c := range m.Challenges {
+		if c.ChallengeHash == challengeHash {
+			m.Challenges = append(m.Challenges[:i], m.Challenges[i+1:]...)
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockWebAuthnRepo) DeleteExpiredChallenges(ctx context.Context) error {
+	return nil
+}
+
+func (m *MockWebAuthnRepo) CreateCredential(ctx context.Context, credential *authModel.WebAuthnCredential) error {
+	if credential.ID == uuid.Nil {
+		credential.ID = uuid.New()
+	}
+	m.Credentials = append(m.Credentials, *credential)
+	return nil
+}
+
+func (m *MockWebAuthnRepo) FindCredential(ctx context.Context, credentialID []byte) (*authModel.WebAuthnCredential, error) {
+	for i := range m.Credentials {
+		if string(m.Credentials[i].CredentialID) == string(credentialID) {
+			c := m.Credentials[i]
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockWebAuthnRepo) ListCredentials(ctx context.Context, userID uuid.UUID) ([]authModel.WebAuthnCredential, error) {
+	var credentials []authModel.WebAuthnCredential
+	for _, c := range m.Credentials {
+		if c.UserID == userID {
+			credentials = append(credentials, c)
+		}
+	}
+	return credentials, nil
+}
+
+func (m *MockWebAuthnRepo) UpdateCredentialUsage(ctx context.Context, id uuid.UUID, signCount uint32, usedAt time.Time) error {
+	for i := range m.Credentials {
+		if m.Credentials[i].ID == id {
+			m.Credentials[i].SignCount = signCount
+			m.Credentials[i].LastUsedAt = &usedAt
+		}
+	}
+	return nil
+}
+
+func (m *MockWebAuthnRepo) DeleteCredential(ctx context.Context, userID, id uuid.UUID) (bool, error) {
+	for i, c := range m.Credentials {
+		if c.ID == id && c.UserID == userID {
+			m.Credentials = append(m.Credentials[:i], m.Credentials[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// MockTokenRepo is an in-memory implementation of TokenRepo for testing
+type MockTokenRepo struct {
+	Tokens map[string]*authModel.RefreshToken
+}
+
+// Verify MockTokenRepo implements TokenRepo interface
+var _ authRepo.TokenRepo = (*MockTokenRepo)(nil)
+
+func (m *MockTokenRepo) Create(ctx context.Context, token *authModel.RefreshToken) error {
+	if m.Tokens == nil {
+		m.Tokens = map[string]*authModel.RefreshToken{}
+	}
+	m.Tokens[token.Token] = token
+	return nil
+}
+
+// FindByToken skips revoked tokens; expiry is left to the caller's clock
+func (m *MockTokenRepo) FindByToken(ctx context.Context, token string) (*authModel.RefreshToken, error) {
+	rt, ok := m.Tokens[token]
+	if !ok || rt.IsRevoked {
+		return nil, apperrors.ErrTokenNotFoundExpired
+	}
+	return rt, nil
+}
+
+func (m *MockTokenRepo) RevokeToken(ctx context.Context, token string) error {
+	if rt, ok := m.Tokens[token]; ok {
+		rt.IsRevoked = true
+	}
+	return nil
+}
+
+func (m *MockTokenRepo) RevokeAllUserTokens(ctx context.Context, userID string) error {
+	for _, rt := range m.Tokens {
+		if rt.UserID.String() == userID {
+			rt.IsRevoked = true
+		}
+	}
+	return nil
+}
+
+func (m *MockTokenRepo) DeleteExpiredTokens(ctx context.Context) error {
+	return nil
+}
+
+// ListActive skips revoked tokens; expiry is left to the caller's clock. Tokens are
+// ordered by creation time, newest first
+func (m *MockTokenRepo) ListActive(ctx context.Context, userID string, offset, limit int) ([]authModel.RefreshToken, error) {
+	var tokens []authModel.RefreshToken
+	for _, rt := range m.Tokens {
+		if !rt.IsRevoked && (userID == "" || rt.UserID.String() == userID) {
+			tokens = append(tokens, *rt)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].CreatedAt.After(tokens[j].CreatedAt) })
+	if offset >= len(tokens) {
+		return nil, nil
+	}
+	tokens = tokens[offset:]
+	if limit < len(tokens) {
+		tokens = tokens[:limit]
+	}
+	return tokens, nil
+}
+
+func (m *MockTokenRepo) RevokeByID(ctx context.Context, id string) (*authModel.RefreshToken, error) {
+	for _, rt := range m.Tokens {
+		if rt.ID.String() == id && !rt.IsRevoked {
+			rt.IsRevoked = true
+			revoked := *rt
+			return &revoked, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockTokenRepo) RevokeUserTokens(ctx context.Context, userID string) ([]authModel.RefreshToken, error) {
+	var revoked []authModel.RefreshToken
+	for _, rt := range m.Tokens {
+		if rt.UserID.String() == userID && !rt.IsRevoked {
+			rt.IsRevoked = true
+			revoked = append(revoked, *rt)
+		}
+	}
+	return revoked, nil
+}
+
+func (m *MockTokenRepo) KnownFingerprints(ctx context.Context, userID string) ([]string, error) {
+	seen := map[string]bool{}
+	var fingerprints []string
+	for _, rt := range m.Tokens {
+		if rt.UserID.String() == userID && rt.Fingerprint != "" && !seen[rt.Fingerprint] {
+			seen[rt.Fingerprint] = true
+			fingerprints = append(fingerprints, rt.Fingerprint)
+		}
+	}
+	return fingerprints, nil
+}
+
+// MockSMSSender records sent messages instead of delivering them
+type MockSMSSender struct {
+	SendFn   func(ctx context.Context, to, message string) error
+	Messages []string
+}
+
+// Verify MockSMSSender implements Sender interface
+var _ sms.Sender = (*MockSMSSender)(nil)
+
+func (m *MockSMSSender) Send(ctx context.Context, to, message string) error {
+	m.Messages = append(m.Messages, message)
+	if m.SendFn != nil {
+		return m.SendFn(ctx, to, message)
+	}
+	return nil
+}
+
 // TestUser creates a test user with default values
 // Password is hashed with bcrypt (cost 10): "password" => "$2a$10$V.1lMHmJnhH7fB8VXBa5Zeq8N/Ygpg0hW.Qvz5OVSvE9A.6MzUqQm"
 func TestUser() *model.User {
@@ -118,3 +863,133 @@ func TestUserAdmin() *model.User {
 	user.UserType = model.UserTypeAdmin
 	return user
 }
+
+// MockRoleRepo is a mock implementation of RoleRepo for testing
+type MockRoleRepo struct {
+	ListFn              func(ctx context.Context) ([]authzModel.Role, error)
+	FindByNameFn        func(ctx context.Context, name string) (*authzModel.Role, error)
+	CreateFn            func(ctx context.Context, role *authzModel.Role) error
+	UpdateFn            func(ctx context.Context, role *authzModel.Role) error
+	DeleteFn            func(ctx context.Context, name string) error
+	CountUsersFn        func(ctx context.Context, name string) (int64, error)
+	ListPermissionsFn   func(ctx context.Context) ([]authzModel.Permission, error)
+	FindPermissionsFn   func(ctx context.Context, keys []string) ([]authzModel.Permission, error)
+	UpsertPermissionsFn func(ctx context.Context, permissions []authzModel.Permission) error
+}
+
+// Verify MockRoleRepo implements RoleRepo interface
+var _ authzRepo.RoleRepo = (*MockRoleRepo)(nil)
+
+func (m *MockRoleRepo) List(ctx context.Context) ([]authzModel.Role, error) {
+	if m.ListFn != nil {
+		return m.ListFn(ctx)
+	}
+	return nil, nil
+}
+
+func (m *MockRoleRepo) FindByName(ctx context.Context, name string) (*authzModel.Role, error) {
+	if m.FindByNameFn != nil {
+		return m.FindByNameFn(ctx, name)
+	}
+	return nil, nil
+}
+
+func (m *MockRoleRepo) Create(ctx context.Context, role *authzModel.Role) error {
+	if m.CreateFn != nil {
+		return m.CreateFn(ctx, role)
+	}
+	return nil
+}
+
+func (m *MockRoleRepo) Update(ctx context.Context, role *authzModel.Role) error {
+	if m.UpdateFn != nil {
+		return m.UpdateFn(ctx, role)
+	}
+	return nil
+}
+
+func (m *MockRoleRepo) Delete(ctx context.Context, name string) error {
+	if m.DeleteFn != nil {
+		return m.DeleteFn(ctx, name)
+	}
+	return nil
+}
+
+func (m *MockRoleRepo) CountUsers(ctx context.Context, name string) (int64, error) {
+	if m.CountUsersFn != nil {
+		return m.CountUsersFn(ctx, name)
+	}
+	return 0, nil
+}
+
+func (m *MockRoleRepo) ListPermissions(ctx context.Context) ([]authzModel.Permission, error) {
+	if m.ListPermissionsFn != nil {
+		return m.ListPermissionsFn(ctx)
+	}
+	return nil, nil
+}
+
+func (m *MockRoleRepo) FindPermissions(ctx context.Context, keys []string) ([]authzModel.Permission, error) {
+	if m.FindPermissionsFn != nil {
+		return m.FindPermissionsFn(ctx, keys)
+	}
+	return nil, nil
+}
+
+func (m *MockRoleRepo) UpsertPermissions(ctx context.Context, permissions []authzModel.Permission) error {
+	if m.UpsertPermissionsFn != nil {
+		return m.UpsertPermissionsFn(ctx, permissions)
+	}
+	return nil
+}
+
+// MockActivityRepo is an in-memory implementation of ActivityRepo for testing
+type MockActivityRepo struct {
+	Activities []activityModel.Activity
+}
+
+// Verify MockActivityRepo implements ActivityRepo interface
+var _ activityRepo.ActivityRepo = (*MockActivityRepo)(nil)
+
+func (m *MockActivityRepo) Create(ctx context.Context, activity *activityModel.Activity) error {
+	if activity.ID == uuid.Nil {
+		activity.ID = uuid.New()
+	}
+	m.Activities = append(m.Activities, *activity)
+	return nil
+}
+
+func (m *MockActivityRepo) ListByUser(ctx context.Context, userID string, offset, limit int) ([]activityModel.Activity, error) {
+	var matched []activityModel.Activity
+	for i := len(m.Activities) - 1; i >= 0; i-- {
+		if m.Activities[i].UserID.String() == userID {
+			matched = append(matched, m.Activities[i])
+		}
+	}
+	if offset >= len(matched) {
+		return nil, nil
+	}
+	return matched[offset:min(offset+limit, len(matched))], nil
+}
+
+func (m *MockActivityRepo) DeleteByUser(ctx context.Context, userID string) error {
+	kept := m.Activities[:0]
+	for _, a := range m.Activities {
+		if a.UserID.String() != userID {
+			kept = append(kept, a)
+		}
+	}
+	m.Activities = kept
+	return nil
+}
+
+func (m *MockActivityRepo) DeleteBefore(ctx context.Context, cutoff time.Time) error {
+	kept := m.Activities[:0]
+	for _, a := range m.Activities {
+		if !a.CreatedAt.Before(cutoff) {
+			kept = append(kept, a)
+		}
+	}
+	m.Activities = kept
+	return nil
+}
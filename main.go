@@ -1,10 +1,11 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 
-	"go_platform_template/internal/scaffold"
+	"github.com/MurtadaNazar/go-api-template/internal/scaffold"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -14,7 +15,20 @@ func init() {
 }
 
 func main() {
-	p := tea.NewProgram(scaffold.NewModel())
+	outputMode := flag.String("output", "dir", "write the project as a directory (dir) or an archive (tar.gz, zip)")
+	archivePath := flag.String("archive", "", "archive file to write (default <path>/<project>.tar.gz or .zip); implies -output from its extension")
+	flag.Parse()
+
+	output, err := scaffold.ParseOutput(*outputMode, *archivePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	model := scaffold.NewModel()
+	model.SetOutput(output)
+
+	p := tea.NewProgram(model)
 	if _, err := p.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
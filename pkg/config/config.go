@@ -0,0 +1,31 @@
+// Package config loads the platform configuration from the environment and an optional
+// .env file, with the variables and defaults documented in .env.example.
+package config
+
+import "github.com/MurtadaNazar/go-api-template/internal/platform/config"
+
+// Config is the full platform configuration; sections a project does not use can be ignored
+type Config = config.Config
+
+// Configuration sections used by the other pkg packages
+type (
+	ClientConfig       = config.ClientConfig
+	CORSConfig         = config.CORSConfig
+	LocalizationConfig = config.LocalizationConfig
+)
+
+// Load reads the configuration once and returns it; later calls return the same value
+func Load() *Config {
+	return config.LoadConfig()
+}
+
+// NewForTest returns a new Config with the defaults and fixed JWT keys, changed by
+// overrides in order; it ignores the environment and does not affect Load or Get
+func NewForTest(overrides ...func(*Config)) *Config {
+	return config.NewForTest(overrides...)
+}
+
+// Get returns the configuration read by Load; it panics when Load has not been called
+func Get() *Config {
+	return config.GetConfig()
+}
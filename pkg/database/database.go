@@ -0,0 +1,65 @@
+// Package database is the platform's GORM integration: request-scoped logging, query
+// timing and connection pool monitoring. Migrations and seeding belong to the template's
+// own models and are not part of this package.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/database"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// GormLogger writes GORM logs through zap with the request ID of the statement's context
+type GormLogger = database.GormLogger
+
+// PoolStats is a JSON view of sql.DBStats, as reported by the health check
+type PoolStats = database.PoolStats
+
+// NewGormLogger returns a GORM logger; maskSensitive masks literal values in logged SQL
+func NewGormLogger(logger *zap.SugaredLogger, maskSensitive bool) *GormLogger {
+	return database.NewGormLogger(logger, maskSensitive)
+}
+
+// WithRequest returns db bound to the request context, so SQL logs carry the request ID
+// and queries stop when the client goes away
+func WithRequest(c *gin.Context, db *gorm.DB) *gorm.DB {
+	return database.WithRequest(c, db)
+}
+
+// RequestLogger returns logger with the request ID from ctx
+func RequestLogger(ctx context.Context, logger *zap.SugaredLogger) *zap.SugaredLogger {
+	return database.WithRequestLogger(ctx, logger)
+}
+
+// RequestID returns the request ID from ctx, or "unknown"
+func RequestID(ctx context.Context) string {
+	return database.ExtractRequestID(ctx)
+}
+
+// RegisterQueryTiming counts statements in the "db" segment of middleware.ServerTiming
+func RegisterQueryTiming(db *gorm.DB) error {
+	return database.RegisterQueryTiming(db)
+}
+
+// NewPoolStats describes s
+func NewPoolStats(s sql.DBStats) PoolStats {
+	return database.NewPoolStats(s)
+}
+
+// WritePoolMetrics writes s in the Prometheus text format
+func WritePoolMetrics(w io.Writer, s sql.DBStats) error {
+	return database.WritePoolMetrics(w, s)
+}
+
+// StartPoolMonitor logs a warning, with tuning advice, whenever connections waited longer
+// than threshold in the last interval
+func StartPoolMonitor(db *sql.DB, interval, threshold time.Duration, logger *zap.SugaredLogger) {
+	database.StartPoolMonitor(db, interval, threshold, logger)
+}
@@ -0,0 +1,9 @@
+// Package pkg holds the platform packages that projects not generated from this template
+// can import on their own: config, logger, errors, response, middleware and database,
+// e.g. github.com/MurtadaNazar/go-api-template/pkg/middleware.
+//
+// Each package re-exports a stable subset of the template's internal/platform and
+// internal/shared code, so generated projects and library consumers run the same
+// implementation. Names and signatures here only change in a major release; anything
+// not listed, including the internal packages themselves, may change at any time.
+package pkg
@@ -0,0 +1,49 @@
+// Package errors is the application error type shared by services and the error handling
+// middleware: a service returns an *AppError and the middleware renders it as a JSON error
+// response with the matching HTTP status.
+package errors
+
+import apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+
+// ErrorType is the category of an error, which decides its HTTP status
+type ErrorType = apperrors.ErrorType
+
+// AppError is the error services return to be rendered by middleware.ErrorHandler
+type AppError = apperrors.AppError
+
+// Error types and the status each maps to
+const (
+	ValidationError      = apperrors.ValidationError      // 400
+	BadRequestError      = apperrors.BadRequestError      // 400
+	UnauthorizedError    = apperrors.UnauthorizedError    // 401
+	ForbiddenError       = apperrors.ForbiddenError       // 403
+	NotFoundError        = apperrors.NotFoundError        // 404
+	ConflictError        = apperrors.ConflictError        // 409
+	AlreadyExistsError   = apperrors.AlreadyExistsError   // 409
+	TooManyRequestsError = apperrors.TooManyRequestsError // 429
+	InternalError        = apperrors.InternalError        // 500
+)
+
+// New returns an error of errType with the HTTP status it maps to
+func New(errType ErrorType, message string) *AppError {
+	return apperrors.NewAppError(errType, message)
+}
+
+// NewWithDetails is New with details, shown to clients next to the message
+func NewWithDetails(errType ErrorType, message, details string) *AppError {
+	return apperrors.NewAppErrorWithDetails(errType, message, details)
+}
+
+// As returns err as an *AppError, if it is one
+func As(err error) (*AppError, bool) {
+	return apperrors.IsAppError(err)
+}
+
+// MultiError holds several errors reported together; see Join
+type MultiError = apperrors.MultiError
+
+// Join combines errs, skipping nils, so every cause is kept. The middleware renders the
+// result with the status of its most severe AppError and the details of all of them.
+func Join(errs ...error) error {
+	return apperrors.Join(errs...)
+}
@@ -0,0 +1,13 @@
+// Package logger builds the structured JSON logger used across the platform, writing at
+// LOG_LEVEL to the outputs in LOG_OUTPUT: stdout, stderr and a rotated log file.
+package logger
+
+import "github.com/MurtadaNazar/go-api-template/internal/platform/logger"
+
+// Logger holds the zap logger and its sugared form
+type Logger = logger.Logger
+
+// New builds the logger from the configuration; call config.Load first
+func New() *Logger {
+	return logger.InitLogger()
+}
@@ -0,0 +1,30 @@
+package middleware_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	apperrors "github.com/MurtadaNazar/go-api-template/pkg/errors"
+	"github.com/MurtadaNazar/go-api-template/pkg/middleware"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// An existing Gin application adopts the request ID, access log, recovery and error
+// rendering middleware and reports failures as app errors
+func Example() {
+	gin.SetMode(gin.TestMode)
+	log := zap.NewNop().Sugar()
+	r := gin.New()
+	r.Use(middleware.RequestID(), middleware.Logger(log), middleware.Recovery(log), middleware.ErrorHandler(log))
+	r.GET("/items/:id", func(c *gin.Context) {
+		_ = c.Error(apperrors.New(apperrors.NotFoundError, "item not found"))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items/42", nil))
+	fmt.Println(w.Code, w.Header().Get(middleware.RequestIDHeader) != "")
+	// Output: 404 true
+}
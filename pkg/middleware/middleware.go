@@ -0,0 +1,69 @@
+// Package middleware is the platform's gin middleware. A typical chain, outermost first:
+//
+//	r.Use(middleware.RequestID(), middleware.Logger(log), middleware.Recovery(log), middleware.ErrorHandler(log))
+//
+// ErrorHandler renders errors added with c.Error as response.ErrorResponse bodies, using
+// the status of an errors.AppError and 500 for anything else. Authentication and
+// permission checks depend on the template's auth and user features and are not part
+// of this package.
+package middleware
+
+import (
+	"github.com/MurtadaNazar/go-api-template/internal/platform/http/middleware"
+	"github.com/MurtadaNazar/go-api-template/pkg/config"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RequestIDHeader carries the request ID; an incoming value is kept, otherwise one is generated
+const RequestIDHeader = "X-Request-Id"
+
+// RequestID stores the request ID as "RequestID" on the gin context and on the request
+// context, and echoes it in the response. Register it first.
+func RequestID() gin.HandlerFunc {
+	return middleware.RequestIDMiddleware()
+}
+
+// ClientIdentification identifies the calling application from cfg.Header or the
+// User-Agent, for the access log and per-client request metrics
+func ClientIdentification(cfg config.ClientConfig) gin.HandlerFunc {
+	return middleware.ClientIdentificationMiddleware(cfg)
+}
+
+// Logger logs each request with its status, latency and request ID
+func Logger(logger *zap.SugaredLogger) gin.HandlerFunc {
+	return middleware.LoggerMiddleware(logger)
+}
+
+// Recovery logs a panic and aborts the request with a 500
+func Recovery(logger *zap.SugaredLogger) gin.HandlerFunc {
+	return middleware.RecoveryMiddleware(logger)
+}
+
+// ErrorHandler writes the last error added with c.Error as the response
+func ErrorHandler(logger *zap.SugaredLogger) gin.HandlerFunc {
+	return middleware.ErrorHandlerMiddleware(logger)
+}
+
+// CORS answers preflight requests and adds CORS headers for the configured origins
+func CORS(cfg config.CORSConfig) gin.HandlerFunc {
+	return middleware.CORSMiddleware(cfg)
+}
+
+// Localization resolves the caller's time zone and locale and applies the zone to JSON
+// responses
+func Localization(cfg config.LocalizationConfig) gin.HandlerFunc {
+	return middleware.LocalizationMiddleware(cfg)
+}
+
+// RateLimit allows 100 requests per second per client IP, kept in memory
+func RateLimit() gin.HandlerFunc {
+	return middleware.RateLimitMiddleware()
+}
+
+// ServerTiming adds a Server-Timing header with the time spent in the database and
+// handler. Register it in development only.
+func ServerTiming(logger *zap.SugaredLogger) gin.HandlerFunc {
+	return middleware.ServerTimingMiddleware(logger)
+}
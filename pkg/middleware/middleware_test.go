@@ -0,0 +1,44 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	apperrors "github.com/MurtadaNazar/go-api-template/pkg/errors"
+	"github.com/MurtadaNazar/go-api-template/pkg/middleware"
+	"github.com/MurtadaNazar/go-api-template/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestChain_RendersAppErrors(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	log := zap.NewNop().Sugar()
+	r := gin.New()
+	r.Use(middleware.RequestID(), middleware.Recovery(log), middleware.ErrorHandler(log))
+	r.GET("/items/:id", func(c *gin.Context) {
+		_ = c.Error(apperrors.NewWithDetails(apperrors.NotFoundError, "item not found", "id:"+c.Param("id")))
+	})
+	req := httptest.NewRequest(http.MethodGet, "/items/42", nil)
+	req.Header.Set(middleware.RequestIDHeader, "req-1")
+	w := httptest.NewRecorder()
+
+	// Act
+	r.ServeHTTP(w, req)
+
+	// Assert
+	var body response.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("body %q: %v", w.Body.String(), err)
+	}
+	if w.Code != http.StatusNotFound || body.Error != "item not found" || body.Details != "id:42" {
+		t.Errorf("response = %d %+v, want 404 with the app error", w.Code, body)
+	}
+	if body.RequestID != "req-1" || w.Header().Get(middleware.RequestIDHeader) != "req-1" {
+		t.Errorf("request ID = %q, header %q; want req-1", body.RequestID, w.Header().Get(middleware.RequestIDHeader))
+	}
+}
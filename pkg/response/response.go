@@ -0,0 +1,46 @@
+// Package response is the JSON envelope every endpoint responds with: data or an error,
+// a timestamp and the request ID.
+package response
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+)
+
+// SuccessResponse wraps the data of a successful response
+type SuccessResponse = response.SuccessResponse
+
+// ErrorResponse is the body of a failed response
+type ErrorResponse = response.ErrorResponse
+
+// PaginatedResponse wraps one page of a list with its total and bounds
+type PaginatedResponse = response.PaginatedResponse
+
+// ArrayStream writes a list as a SuccessResponse one element at a time
+type ArrayStream = response.ArrayStream
+
+// PooledJSON renders a body through pooled buffers; use it with gin's c.Render
+type PooledJSON = response.PooledJSON
+
+// NewSuccessResponse wraps data
+func NewSuccessResponse(data interface{}, requestID string) *SuccessResponse {
+	return response.NewSuccessResponse(data, requestID)
+}
+
+// NewErrorResponse describes a failed request; errType is an errors.ErrorType
+func NewErrorResponse(message, errType, details, requestID string) *ErrorResponse {
+	return response.NewErrorResponse(message, errType, details, requestID)
+}
+
+// NewPaginatedResponse wraps one page of total items
+func NewPaginatedResponse(data interface{}, total int64, offset, limit int, requestID string) *PaginatedResponse {
+	return response.NewPaginatedResponse(data, total, offset, limit, requestID)
+}
+
+// NewArrayStream starts a streamed list on w; nothing is written until the first element
+// or Close
+func NewArrayStream(ctx context.Context, w http.ResponseWriter, requestID string) *ArrayStream {
+	return response.NewArrayStream(ctx, w, requestID)
+}
@@ -0,0 +1,72 @@
+package bootstrap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/database"
+
+	"gorm.io/gorm"
+)
+
+const anonymizeUsage = `usage:
+  anonymize [-salt s] [-password p] [-dry-run] [-yes]
+
+Replaces personal data in the configured database with deterministic fakes.
+Run it only against a copy of production data, never production itself.
+`
+
+// RunAnonymizeCommand runs the "anonymize" subcommand of the service binary with args
+// following "anonymize" and returns the process exit code
+func RunAnonymizeCommand(ctx context.Context, db *gorm.DB, cfg *config.Config, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("anonymize", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprint(stderr, anonymizeUsage)
+		fs.PrintDefaults()
+	}
+	salt := fs.String("salt", os.Getenv("ANONYMIZE_SALT"), "secret keying the fakes; the same salt gives the same fakes (default $ANONYMIZE_SALT)")
+	password := fs.String("password", "", "set every user's password to this (default: unusable passwords)")
+	dryRun := fs.Bool("dry-run", false, "count the rows that would change without changing them")
+	yes := fs.Bool("yes", false, "confirm that the database is not production")
+	batch := fs.Int("batch", 500, "rows read per batch")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 0 {
+		return 2
+	}
+
+	target := fmt.Sprintf("%s on %s:%s", cfg.DBName, cfg.DBHost, cfg.DBPort)
+	if !*dryRun && !*yes {
+		fmt.Fprintf(stderr, "refusing to rewrite %s without -yes; anonymization cannot be undone\n", target)
+		return 2
+	}
+	if db == nil {
+		return commandFailed(stderr, errors.New("database is not available"))
+	}
+
+	report, err := database.Anonymize(ctx, db, database.AnonymizeOptions{
+		Salt:      *salt,
+		Password:  *password,
+		BatchSize: *batch,
+		DryRun:    *dryRun,
+	})
+	if err != nil {
+		return commandFailed(stderr, fmt.Errorf("anonymizing %s: %w", target, err))
+	}
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return commandFailed(stderr, err)
+	}
+	return 0
+}
+
+func commandFailed(stderr io.Writer, err error) int {
+	fmt.Fprintf(stderr, "error: %v\n", err)
+	return 1
+}
@@ -3,8 +3,9 @@ package bootstrap
 import (
 	"database/sql"
 	"fmt"
-	"go_platform_template/internal/platform/config"
-	"go_platform_template/internal/platform/database"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/database"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/id"
 
 	"time"
 
@@ -21,10 +22,18 @@ func InitDB(cfg *config.Config, log *zap.SugaredLogger) *gorm.DB {
 		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword)
 
 	dbName := cfg.DBName
+	component := Component{Name: "database", Status: ComponentDegraded, Endpoint: fmt.Sprintf("postgres://%s:%s/%s", cfg.DBHost, cfg.DBPort, dbName)}
+	defer func() { ReportComponent(component) }()
+
+	// Primary keys created by model hooks; LoadConfig only lets v4 and v7 through
+	if generator, err := id.ForVersion(cfg.IDVersion); err == nil {
+		id.SetGenerator(generator)
+	}
 
 	// Ensure database exists before GORM connects
 	if err := ensureDatabaseExists(baseDSN, dbName, log); err != nil {
 		log.Errorf("failed to ensure database exists: %v", err)
+		component.Detail = err.Error()
 		return nil
 	}
 
@@ -41,9 +50,16 @@ func InitDB(cfg *config.Config, log *zap.SugaredLogger) *gorm.DB {
 
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
 		Logger: gormLogger,
+		// Every repository write is a single statement, so GORM's implicit transaction
+		// around it only adds a BEGIN/COMMIT round trip; use db.Transaction where needed
+		SkipDefaultTransaction: true,
+		// Cache prepared statements per connection; disable behind PgBouncer in
+		// transaction pooling mode
+		PrepareStmt: cfg.DBPrepareStmt,
 	})
 	if err != nil {
 		log.Errorf("failed to connect database: %v", err)
+		component.Detail = err.Error()
 		return nil
 	}
 
@@ -52,21 +68,45 @@ func InitDB(cfg *config.Config, log *zap.SugaredLogger) *gorm.DB {
 	sqlDB.SetMaxIdleConns(cfg.DBMaxIdleConns)
 	sqlDB.SetConnMaxLifetime(time.Duration(cfg.DBConnMaxLifetime) * time.Second)
 
+	// Warn when requests queue for connections; DB_POOL_CHECK_INTERVAL=0 disables it
+	if cfg.DBPoolCheckInterval > 0 {
+		database.StartPoolMonitor(sqlDB, cfg.DBPoolCheckInterval, cfg.DBPoolWaitWarn, log)
+	}
+
 	log.Info("Database connected successfully")
 
 	// Run migrations and indexes
 	if err := database.MigrateDB(db, log); err != nil {
 		log.Errorf("Database migration failed: %v", err)
+		component.Detail = "migration failed: " + err.Error()
 		return nil
 	}
 
-	// Seed admin user
+	// Backfill normalized emails for rows created before the column existed
+	if err := database.BackfillNormalizedEmails(db, cfg.EmailFoldGmail, log); err != nil {
+		log.Errorf("Normalized email backfill failed: %v", err)
+	}
+
+	// Seed default roles and permissions, then the admin user
+	database.SeedRoles(db, log)
 	database.SeedAdminUser(db, log)
 	log.Info("Database seeding completed")
 
+	// Query time per request for the Server-Timing header (see SetupMiddleware)
+	if cfg.GinMode == "debug" || cfg.GinMode == "development" {
+		if err := database.RegisterQueryTiming(db); err != nil {
+			log.Warnf("Query timing unavailable: %v", err)
+		}
+	}
+
 	// Apply global scopes
 	db = database.ApplyGlobalScopes(db)
 
+	component.Status = ComponentActive
+	if err := db.Raw("SHOW server_version").Scan(&component.Version).Error; err != nil {
+		log.Warnf("Reading the database version failed: %v", err)
+	}
+
 	return db
 }
 
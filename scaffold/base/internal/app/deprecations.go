@@ -0,0 +1,26 @@
+package bootstrap
+
+import (
+	"net/http"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/deprecation"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListDeprecationsHandler godoc
+// @Summary List deprecated endpoints and the clients still calling them (requires deprecations:view)
+// @Description Counts are kept in memory per instance since it started; query every instance for the full picture.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} deprecation.Endpoint
+// @Failure 403 {object} response.ErrorResponse
+// @Router /admin/deprecations [get]
+func ListDeprecationsHandler(tracker *deprecation.Tracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetString("RequestID")
+		c.JSON(http.StatusOK, response.NewSuccessResponse(tracker.Report(), requestID))
+	}
+}
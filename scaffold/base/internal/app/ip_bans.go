@@ -0,0 +1,68 @@
+package bootstrap
+
+import (
+	"net/http"
+	"net/netip"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/ipban"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ListIPBansHandler godoc
+// @Summary List banned client IPs (requires ip_bans:manage)
+// @Description Bans in force, the most recent first, with the event that triggered each one.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} response.SuccessResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Router /admin/ip-bans [get]
+func ListIPBansHandler(bans *ipban.Guard, log *zap.SugaredLogger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		list, err := bans.List(c.Request.Context())
+		if err != nil {
+			log.Errorw("Listing IP bans failed", "error", err)
+			_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to list IP bans"))
+			return
+		}
+		c.JSON(http.StatusOK, response.NewSuccessResponse(list, c.GetString("RequestID")))
+	}
+}
+
+// LiftIPBanHandler godoc
+// @Summary Lift the ban of a client IP (requires ip_bans:manage)
+// @Description Ends the ban early and forgets the IP's suspicious events.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param ip path string true "Banned IP address"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /admin/ip-bans/{ip} [delete]
+func LiftIPBanHandler(bans *ipban.Guard, log *zap.SugaredLogger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ip := c.Param("ip")
+		if _, err := netip.ParseAddr(ip); err != nil {
+			_ = c.Error(apperrors.NewAppError(apperrors.BadRequestError, "Invalid IP address"))
+			return
+		}
+		lifted, err := bans.Lift(c.Request.Context(), ip)
+		if err != nil {
+			log.Errorw("Lifting IP ban failed", "ip", ip, "error", err)
+			_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to lift IP ban"))
+			return
+		}
+		if !lifted {
+			_ = c.Error(apperrors.NewAppError(apperrors.NotFoundError, "IP address is not banned"))
+			return
+		}
+		log.Infow("IP ban lifted", "ip", ip, "by", c.GetString("userID"))
+		c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{"message": "ban lifted", "ip": ip}, c.GetString("RequestID")))
+	}
+}
@@ -0,0 +1,58 @@
+package bootstrap
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/jobs"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListJobsHandler godoc
+// @Summary List background jobs with their last run on this instance (requires jobs:manage)
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} response.SuccessResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Router /admin/jobs [get]
+func ListJobsHandler(scheduler *jobs.Scheduler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetString("RequestID")
+		c.JSON(http.StatusOK, response.NewSuccessResponse(scheduler.List(), requestID))
+	}
+}
+
+// RunJobHandler godoc
+// @Summary Start a background job now (requires jobs:manage)
+// @Description The job runs in the background; poll GET /admin/jobs for its result.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param name path string true "Job name"
+// @Success 202 {object} response.SuccessResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
+// @Router /admin/jobs/{name}/run [post]
+func RunJobHandler(scheduler *jobs.Scheduler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetString("RequestID")
+		name := c.Param("name")
+		if err := scheduler.Run(name); err != nil {
+			switch {
+			case errors.Is(err, jobs.ErrUnknownJob):
+				_ = c.Error(apperrors.NewAppError(apperrors.NotFoundError, "Job not found"))
+			case errors.Is(err, jobs.ErrJobRunning):
+				_ = c.Error(apperrors.NewAppError(apperrors.ConflictError, "Job is already running"))
+			default:
+				_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to start job"))
+			}
+			return
+		}
+		c.JSON(http.StatusAccepted, response.NewSuccessResponse(gin.H{"message": "job started", "job": name}, requestID))
+	}
+}
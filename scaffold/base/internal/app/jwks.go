@@ -0,0 +1,37 @@
+package bootstrap
+
+import (
+	"net/http"
+
+	"github.com/MurtadaNazar/go-api-template/internal/shared/jwk"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKSHandler serves the public keys that verify tokens issued by this service, read from
+// sources on each request so rotated keys show up. Keys are listed once even when access
+// tokens and OIDC ID tokens share a key pair.
+//
+// @Summary Token signing keys
+// @Description Public keys for RS256/EdDSA access tokens and OIDC ID tokens. Refetch the set when a token names an unknown kid: keys are rotated.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} jwk.Set
+// @Router /.well-known/jwks.json [get]
+func JWKSHandler(sources ...func() []jwk.Key) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		set := jwk.Set{Keys: []jwk.Key{}}
+		seen := make(map[string]bool)
+		for _, source := range sources {
+			for _, key := range source() {
+				if seen[key.KeyID] {
+					continue
+				}
+				seen[key.KeyID] = true
+				set.Keys = append(set.Keys, key)
+			}
+		}
+		c.Header("Cache-Control", "public, max-age=3600")
+		c.JSON(http.StatusOK, set)
+	}
+}
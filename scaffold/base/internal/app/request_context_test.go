@@ -0,0 +1,76 @@
+package bootstrap
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// requestPathDirs hold code that runs while serving a request. Work started there must
+// use the request context so request IDs reach the SQL log and cancellation stops queries.
+var requestPathDirs = []string{"../domain", "../platform/http"}
+
+// detachedContextAllowed lists functions that legitimately run outside any request,
+// keyed by "<dir relative to internal>.<func>"
+var detachedContextAllowed = map[string]bool{
+	"domain/file/service.NewFileService": true, // startup bucket check
+}
+
+// TestRequestPathsUseRequestContext flags context.Background() and context.TODO() in
+// request paths; pass the caller's ctx (c.Request.Context() in handlers) instead.
+func TestRequestPathsUseRequestContext(t *testing.T) {
+	fset := token.NewFileSet()
+	for _, root := range requestPathDirs {
+		if _, err := os.Stat(root); os.IsNotExist(err) {
+			continue
+		}
+		err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+				return err
+			}
+			file, err := parser.ParseFile(fset, path, nil, 0)
+			if err != nil {
+				return err
+			}
+			dir, _ := filepath.Rel("..", filepath.Dir(path))
+			for _, decl := range file.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Body == nil || detachedContextAllowed[filepath.ToSlash(dir)+"."+fn.Name.Name] {
+					continue
+				}
+				ast.Inspect(fn.Body, func(n ast.Node) bool {
+					if name := detachedContextCall(n); name != "" {
+						t.Errorf("%s: %s in %s; pass the request context instead", fset.Position(n.Pos()), name, fn.Name.Name)
+					}
+					return true
+				})
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("scanning %s: %v", root, err)
+		}
+	}
+}
+
+// detachedContextCall returns "context.Background()" or "context.TODO()" when n is such a call
+func detachedContextCall(n ast.Node) string {
+	call, ok := n.(*ast.CallExpr)
+	if !ok {
+		return ""
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	if !ok || pkg.Name != "context" || (sel.Sel.Name != "Background" && sel.Sel.Name != "TODO") {
+		return ""
+	}
+	return "context." + sel.Sel.Name + "()"
+}
@@ -1,24 +1,71 @@
 package bootstrap
 
 import (
+	"context"
+	"io"
+	"strconv"
 	"time"
 
-	"go_platform_template/internal/platform/config"
-	"go_platform_template/internal/platform/http/middleware"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/cache"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/database"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/deprecation"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/email"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/events"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/examples"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/http/middleware"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/ipban"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/jobs"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/quota"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/risk"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/sms"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/storage"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/validation"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/webhook"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/jwk"
 
-	authApi "go_platform_template/internal/domain/auth/api"
-	authRepo "go_platform_template/internal/domain/auth/repo"
-	authService "go_platform_template/internal/domain/auth/service"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/actiontoken"
+	authApi "github.com/MurtadaNazar/go-api-template/internal/domain/auth/api"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/oauth"
+	authRepo "github.com/MurtadaNazar/go-api-template/internal/domain/auth/repo"
+	authService "github.com/MurtadaNazar/go-api-template/internal/domain/auth/service"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/webauthn"
 
-	userApi "go_platform_template/internal/domain/user/api"
-	userRepo "go_platform_template/internal/domain/user/repo"
-	userService "go_platform_template/internal/domain/user/service"
+	authzApi "github.com/MurtadaNazar/go-api-template/internal/domain/authz/api"
+	authzModel "github.com/MurtadaNazar/go-api-template/internal/domain/authz/model"
+	authzRepo "github.com/MurtadaNazar/go-api-template/internal/domain/authz/repo"
+	authzService "github.com/MurtadaNazar/go-api-template/internal/domain/authz/service"
 
-	fileApi "go_platform_template/internal/domain/file/api"
-	fileRepo "go_platform_template/internal/domain/file/repo"
-	fileService "go_platform_template/internal/domain/file/service"
+	userApi "github.com/MurtadaNazar/go-api-template/internal/domain/user/api"
+	userDto "github.com/MurtadaNazar/go-api-template/internal/domain/user/dto"
+	userRepo "github.com/MurtadaNazar/go-api-template/internal/domain/user/repo"
+	userService "github.com/MurtadaNazar/go-api-template/internal/domain/user/service"
+
+	oidcApi "github.com/MurtadaNazar/go-api-template/internal/domain/oidc/api"
+	oidcModel "github.com/MurtadaNazar/go-api-template/internal/domain/oidc/model"
+	oidcRepo "github.com/MurtadaNazar/go-api-template/internal/domain/oidc/repo"
+	oidcService "github.com/MurtadaNazar/go-api-template/internal/domain/oidc/service"
+
+	settingsApi "github.com/MurtadaNazar/go-api-template/internal/domain/settings/api"
+	settingsService "github.com/MurtadaNazar/go-api-template/internal/domain/settings/service"
+
+	activityApi "github.com/MurtadaNazar/go-api-template/internal/domain/activity/api"
+	activityRepo "github.com/MurtadaNazar/go-api-template/internal/domain/activity/repo"
+	activityService "github.com/MurtadaNazar/go-api-template/internal/domain/activity/service"
+	announcementApi "github.com/MurtadaNazar/go-api-template/internal/domain/announcement/api"
+	announcementRepo "github.com/MurtadaNazar/go-api-template/internal/domain/announcement/repo"
+	announcementService "github.com/MurtadaNazar/go-api-template/internal/domain/announcement/service"
+
+	exportApi "github.com/MurtadaNazar/go-api-template/internal/domain/export/api"
+	exportRepo "github.com/MurtadaNazar/go-api-template/internal/domain/export/repo"
+	exportService "github.com/MurtadaNazar/go-api-template/internal/domain/export/service"
+
+	fileApi "github.com/MurtadaNazar/go-api-template/internal/domain/file/api"
+	fileRepo "github.com/MurtadaNazar/go-api-template/internal/domain/file/repo"
+	fileService "github.com/MurtadaNazar/go-api-template/internal/domain/file/service"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
@@ -28,9 +75,12 @@ import (
 DEVELOPER NOTES: AUTH & ROLES
 ===========================
 
-1. Roles (UserType):
-    - "admin"      : System admin, full access.
+1. Roles (UserType) and permissions:
+    - "admin"      : System admin, every permission.
     - "user"       : Regular user with standard access.
+    - Admins can define further roles under /api/v1/roles and grant them
+      permissions such as "users:list"; users are assigned a role through user_type.
+    - Permission keys live in internal/domain/authz/model and are seeded on start.
 
 2. JWT Authentication Flow:
    - User logs in via `/login` and receives:
@@ -40,19 +90,32 @@ DEVELOPER NOTES: AUTH & ROLES
        - user_id
        - role (matches UserType)
        - exp (expiration timestamp)
+       - ext (claims added by jwtManager.SetClaimsEnricher, e.g. a tenant ID)
+       - impersonator_id (only on tokens from /admin/impersonate; the admin acting as user_id)
    - Protected endpoints require a valid access token in the header:
        Authorization: Bearer <access_token>
 
 3. Protecting Endpoints:
-   - Use `middleware.JWTAuth(jwtManager)` in your route group.
+   - Use `requireAuth` (middleware.JWTAuth with the account check) in your route group.
+   - AUTH_ACCOUNT_CHECK=status|strict re-checks the account on every request, so
+     suspended or demoted users lose access before their token expires; "role" is
+     then the current role rather than the one in the token.
    - Handlers can get user info via context:
        userID := c.GetString("userID")
        role   := c.GetString("role")
-   - Optional: enforce role-based access in handlers:
-       if role != "admin" {
-           c.JSON(403, gin.H{"error": "forbidden"})
-           return
-       }
+       tenant := middleware.ExtraClaims(c)["tenant_id"]
+   - Restrict a route to a permission with middleware.RequirePermission after the
+     auth middleware, or to fixed roles with middleware.RequireRole:
+       users.DELETE("/:id", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersDelete), uHandler.Delete)
+     Requests without it get 403 and never reach the handler.
+   - Check a permission inside a handler or service with authz.Can(ctx, role, permission).
+   - Use `requireAuthOrGuest` on read-only routes that anonymous clients may call with a
+     token from `/auth/guest` (GUEST_TOKENS_ENABLED=true). Guest tokens carry role
+     "guest" and a random user_id that names no account; tell them apart with
+     middleware.IsGuest(c). Every other route refuses them with 401.
+   - Use `requireAuthOrClient` on routes backend services may call with a token from
+     `/auth/token` (client credentials grant). Such requests act as "client:<client_id>"
+     and RequirePermission admits them only for permissions in the token's scope.
 
 4. Token Rotation & Logout:
    - Refresh tokens are stored in DB (tokenStore) and can be revoked.
@@ -60,10 +123,13 @@ DEVELOPER NOTES: AUTH & ROLES
    - Refresh endpoint rotates refresh tokens for better security.
 
 5. Example Usage:
-    - Admin-only route:
-        protected.GET("/users", requireRole("admin"), uHandler.ListUsers)
-    - User route:
-        protected.GET("/profile", requireRole("user"), uHandler.GetUser)
+    - Route group guarded by a permission:
+        roles := v1.Group("/roles")
+        roles.Use(requireAuth, middleware.RequirePermission(authz, authzModel.PermRolesManage))
+    - Route for several roles:
+        protected.GET("/reports", middleware.RequireRole("admin", "user"), handler)
+    - Route for a role and every role inheriting it (ROLE_HIERARCHY=admin>moderator>user):
+        protected.POST("/posts/:id/hide", middleware.RequireRole("moderator"), handler)
 
 6. Notes:
    - Always pass JWTManager to middleware and AuthService to handlers.
@@ -71,6 +137,14 @@ DEVELOPER NOTES: AUTH & ROLES
 */
 
 func RegisterRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config, log *zap.SugaredLogger) {
+	// Validation rules shared by all handlers; register custom tags here, before the
+	// handlers are created (see internal/platform/validation)
+	if len(cfg.ReservedUsernames) > 0 {
+		if err := validation.Register(validation.UsernamePolicy(cfg.ReservedUsernames...)); err != nil {
+			log.Fatalf("Registering validation rules failed: %v", err)
+		}
+	}
+
 	// -----------------------
 	// JWT & Auth setup
 	// -----------------------
@@ -80,34 +154,495 @@ func RegisterRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config, log *zap.Sug
 		cfg.JWT.AccessExpiresIn,
 		cfg.JWT.RefreshExpiresIn,
 	)
+	jwtManager.SetImpersonationExpiry(cfg.JWT.ImpersonationExpiresIn)
+	jwtManager.SetSessionLifetimes(cfg.JWT.RememberMeExpiresIn, cfg.JWT.SessionMaxLifetime)
+	jwtManager.SetGuestExpiry(cfg.JWT.GuestExpiresIn)
+	// Tokens carry and must carry JWT_ISSUER and JWT_AUDIENCE; JWT_LEEWAY absorbs clock skew
+	jwtManager.SetIssuerAndAudience(cfg.JWT.Issuer, cfg.JWT.Audience)
+	jwtManager.SetLeeway(cfg.JWT.Leeway)
+	// Access tokens are signed with JWT_SIGNING_KEY (HS256) unless JWT_ALGORITHM selects key
+	// pairs; JWT_KEYS_DIR adds more for rotation, read again by the signing-key-reload job
+	jwtManager.SetPreviousSecrets(cfg.JWT.PreviousSigningKeys)
+	// Validated access tokens are remembered when JWT_TOKEN_CACHE_SIZE > 0 (nil otherwise);
+	// they are dropped when the keys change, so tokens of a removed key fail at once
+	tokenCache := middleware.NewTokenCache(cfg.JWT.TokenCacheSize, cfg.JWT.TokenCacheTTL)
+	reloadSigningKeys := func() error {
+		keys, err := authService.LoadSigningKeys(cfg.JWT)
+		if err != nil || len(keys) == 0 {
+			return err
+		}
+		if err := jwtManager.SetSigningKeys(keys); err != nil {
+			return err
+		}
+		tokenCache.Purge()
+		return nil
+	}
+	err := reloadSigningKeys()
+	if err != nil {
+		log.Fatalf("Loading the JWT signing keys failed: %v", err)
+	}
+	// Public keys served at /.well-known/jwks.json, joined by the OIDC key when enabled
+	var jwks []func() []jwk.Key
+	if cfg.JWT.Algorithm != "HS256" {
+		jwks = append(jwks, jwtManager.PublicKeys)
+	}
 
-	uRepo := userRepo.NewUserRepo(db)
-	uService := userService.NewUserService(uRepo, log)
-	uHandler := userApi.NewUserHandler(uService, log)
+	// Calls to routes marked with middleware.Deprecated, reported at /admin/deprecations
+	deprecations := deprecation.NewTracker(log)
 
+	// Auth events (user.registered, user.login, token.revoked) posted to the endpoints in
+	// WEBHOOKS, signed and retried in the background; none are sent when it is empty
+	var webhooks *webhook.Dispatcher
+	if len(cfg.Webhooks.Endpoints) > 0 {
+		d, err := webhook.NewDispatcher(cfg.Webhooks, log)
+		if err != nil {
+			log.Warnf("Webhook initialization failed, webhooks disabled: %v", err)
+			ReportComponent(Component{Name: "webhooks", Status: ComponentDegraded, Detail: err.Error()})
+		} else {
+			webhooks = d
+			OnShutdown("webhooks", webhooks.Close)
+			ReportComponent(Component{Name: "webhooks", Status: ComponentActive, Detail: strconv.Itoa(len(cfg.Webhooks.Endpoints)) + " endpoints"})
+		}
+	} else {
+		ReportComponent(Component{Name: "webhooks", Status: ComponentDisabled, Detail: "WEBHOOKS is empty"})
+	}
+
+	// Short-lived cache of user role/status and role permission lookups, dropped on change
+	accountCache := cache.NewMemory()
+	if cfg.UserCacheTTL > 0 {
+		ReportComponent(Component{Name: "cache", Status: ComponentActive, Endpoint: "memory", Detail: "TTL " + cfg.UserCacheTTL.String()})
+	} else {
+		ReportComponent(Component{Name: "cache", Status: ComponentDisabled, Detail: "USER_CACHE_TTL is 0"})
+	}
+
+	authz := authzService.NewService(authzRepo.NewRoleRepo(db), log,
+		authzService.WithCache(accountCache, cfg.UserCacheTTL),
+		authzService.WithHierarchy(cfg.RoleHierarchy),
+	)
+	roleHandler := authzApi.NewRoleHandler(authz, log)
+
+	// Disposable email domains, refreshed from DISPOSABLE_EMAIL_LIST_URL by a background job.
+	// DISPOSABLE_EMAIL_BLOCK=false leaves them to risk scoring instead of rejecting them.
+	disposableDomains := risk.NewDisposableDomains(cfg.Disposable, log)
+	var blockedDomains *risk.DisposableDomains
+	if cfg.Disposable.Block {
+		blockedDomains = disposableDomains
+	}
+
+	// Abuse scoring of registrations and logins; RISK_ENABLED=false turns it off
+	riskEngine, err := risk.NewDefaultEngine(cfg.Risk, disposableDomains, log)
+	if err != nil {
+		log.Warnf("Risk scoring initialization failed, abuse detection disabled: %v", err)
+	}
+
+	// SIGNUP_CAPTCHA=true asks every signup for a CAPTCHA, not only suspicious ones
+	var signupCaptcha risk.CaptchaVerifier
+	if cfg.Signup.RequireCaptcha {
+		signupCaptcha, err = risk.NewCaptchaVerifier(cfg.Risk)
+		if err != nil {
+			log.Fatalf("Signup CAPTCHA initialization failed: %v", err)
+		}
+	}
+
+	// Outgoing email (signup verification, login alerts, announcements); disabled when EMAIL_PROVIDER=none
+	emailSender, err := email.NewSender(cfg.Email, log)
+	switch {
+	case err != nil:
+		log.Warnf("Email provider initialization failed: %v", err)
+		ReportComponent(Component{Name: "email", Status: ComponentDegraded, Detail: err.Error()})
+	case emailSender == nil:
+		ReportComponent(Component{Name: "email", Status: ComponentDisabled, Detail: "EMAIL_PROVIDER is none"})
+	default:
+		ReportComponent(Component{Name: "email", Status: ComponentActive, Endpoint: cfg.Email.Provider})
+	}
+
+	// Single-use tokens behind emailed links, such as email verification
+	actionTokens := actiontoken.NewService(authRepo.NewActionTokenRepo(db))
+
+	// Profile, password and upload events, recorded in each user's activity feed
+	domainEvents := events.NewBus()
+	activities := activityService.NewService(activityRepo.NewActivityRepo(db), cfg.ActivityRetention, log)
+	activities.Subscribe(domainEvents)
+	activityHandler := activityApi.NewActivityHandler(activities, log)
+
+	uRepo := userRepo.NewUserRepo(db, userRepo.WithGmailFolding(cfg.EmailFoldGmail))
 	tRepo := authRepo.NewTokenRepo(db)
 	tStore := authService.NewTokenStore(tRepo, log)
 	aService := authService.NewAuthService(uRepo, jwtManager, tStore, log)
+	declaredFields, err := userService.DeclaredFields(cfg.ProfileFields)
+	if err != nil {
+		log.Fatalf("Invalid PROFILE_FIELDS: %v", err)
+	}
+	pfRepo := userRepo.WithDeclaredFields(userRepo.NewProfileFieldRepo(db), declaredFields)
+	uService := userService.NewUserService(uRepo, log,
+		userService.WithDefaultPhoneRegion(cfg.PhoneRegion),
+		userService.WithProfileFields(pfRepo),
+		userService.WithRevisions(userRepo.NewRevisionRepo(db)),
+		userService.WithAccountCache(accountCache, cfg.UserCacheTTL),
+		userService.WithRoles(authz.RoleExists),
+		userService.WithRiskEngine(riskEngine),
+		userService.WithDisposableEmailBlocking(blockedDomains),
+		userService.WithPasswordHistory(userRepo.NewPasswordHistoryRepo(db), cfg.PasswordHistory),
+		userService.WithSignupCaptcha(signupCaptcha),
+		userService.WithEmailVerification(emailSender, actionTokens, cfg.Signup),
+		userService.WithInvitations(emailSender, actionTokens, cfg.Invite),
+		userService.WithWebhooks(webhooks),
+		userService.WithEvents(domainEvents),
+		userService.WithSessionRevoker(func(ctx context.Context, userID string) error {
+			_, err := aService.RevokeUserRefreshTokens(ctx, userID)
+			return err
+		}),
+		userService.WithAccountMerger(database.NewUserMerger(db)),
+		userService.WithTags(userRepo.NewTagRepo(db)),
+		userService.WithNotes(userRepo.NewNoteRepo(db)),
+		userService.WithStatusHistory(userRepo.NewStatusHistoryRepo(db)),
+	)
+	uHandler := userApi.NewUserHandler(uService, log)
+	// The admin view of single-user responses is for holders of users:list
+	uHandler.SetAdminView(func(ctx context.Context, role string) bool {
+		allowed, err := authz.Can(ctx, role, authzModel.PermUsersList)
+		return err == nil && allowed
+	})
+	pfService := userService.NewProfileFieldService(pfRepo, log)
+	pfHandler := userApi.NewProfileFieldHandler(pfService, log)
+	settingsHandler := settingsApi.NewSettingsHandler(settingsService.NewService(pfService, authz, log), log)
+
 	aHandler := authApi.NewAuthHandler(aService, log)
+	aHandler.SetRefreshCookie(cfg.RefreshCookie, cfg.JWT.RefreshExpiresIn)
 
-	// Start background job to clean up expired tokens every 24 hours
-	go authService.StartTokenCleanupJob(tStore, 24*time.Hour)
+	// Requests per user and day or month, by role (QUOTA_DAILY, QUOTA_MONTHLY); counted by
+	// every JWTAuth below
+	var quotas *quota.Limiter
+	if cfg.Quota.Enabled() {
+		quotas = quota.NewLimiter(quota.NewMemoryStore(), cfg.Quota, log)
+		ReportComponent(Component{Name: "quotas", Status: ComponentActive, Detail: "memory store"})
+	} else {
+		ReportComponent(Component{Name: "quotas", Status: ComponentDisabled, Detail: "QUOTA_DAILY and QUOTA_MONTHLY are empty"})
+	}
+	withQuota := middleware.WithQuota(quotas)
+	// Current role and status of an account, for the JWT account check and client certificates
+	accountLookup := func(ctx context.Context, userID string) (string, bool, error) {
+		account, err := uService.AccountStatus(ctx, userID)
+		if err != nil {
+			return "", false, err
+		}
+		return string(account.Role), account.IsActive(), nil
+	}
+	// Protected routes re-check the account behind the token unless AUTH_ACCOUNT_CHECK=off
+	accountCheck := middleware.WithAccountCheck(middleware.AccountCheckMode(cfg.JWT.AccountCheck), accountLookup, log)
+	// RequireRole also admits roles inheriting the required one (ROLE_HIERARCHY, roles' inherits)
+	roleHierarchy := middleware.WithRoleHierarchy(authz)
+	requireAuth := middleware.JWTAuth(jwtManager, accountCheck, roleHierarchy, withQuota, middleware.WithTokenCache(tokenCache))
+	// Read-only routes open to anonymous clients also accept guest tokens from /auth/guest
+	requireAuthOrGuest := middleware.JWTAuth(jwtManager, accountCheck, roleHierarchy, withQuota, middleware.WithTokenCache(tokenCache), middleware.AllowGuests())
+	// Routes backend services may call with client credentials tokens from /auth/token
+	requireAuthOrClient := middleware.JWTAuth(jwtManager, accountCheck, roleHierarchy, withQuota, middleware.WithTokenCache(tokenCache), middleware.AllowServiceClients())
+	// Signing a user out everywhere also forgets their cached access tokens
+	aService.OnUserTokensRevoked(tokenCache.InvalidateUser)
+	if tokenCache != nil {
+		ReportComponent(Component{Name: "token-cache", Status: ComponentActive, Endpoint: "memory", Detail: strconv.Itoa(cfg.JWT.TokenCacheSize) + " tokens, TTL " + cfg.JWT.TokenCacheTTL.String()})
+	} else {
+		ReportComponent(Component{Name: "token-cache", Status: ComponentDisabled, Detail: "JWT_TOKEN_CACHE_SIZE is 0"})
+	}
+
+	// SMS one-time codes (passwordless login and two-factor); disabled when SMS_PROVIDER=none
+	smsSender, err := sms.NewSender(cfg.SMS, log)
+	if err != nil {
+		log.Warnf("SMS provider initialization failed: %v", err)
+		ReportComponent(Component{Name: "sms", Status: ComponentDegraded, Detail: err.Error()})
+	} else if smsSender != nil {
+		aService.SetOTPService(authService.NewOTPService(authRepo.NewOTPRepo(db), smsSender, cfg.SMS, log))
+		ReportComponent(Component{Name: "sms", Status: ComponentActive, Endpoint: cfg.SMS.Provider})
+	} else {
+		ReportComponent(Component{Name: "sms", Status: ComponentDisabled, Detail: "SMS_PROVIDER is none"})
+	}
+
+	// Social login; a provider is enabled by setting its OAUTH_<PROVIDER>_CLIENT_ID or
+	// listing it in OAUTH_OIDC_PROVIDERS
+	if providers := oauth.NewProviders(cfg.OAuth); len(providers) > 0 {
+		aService.SetOAuth(providers, authRepo.NewOAuthRepo(db), cfg.OAuth.AllowSignup)
+	}
+
+	// Login backoff and lockout per account and client IP; LOGIN_MAX_FAILURES=0 turns them off
+	if cfg.LoginLimit.MaxFailures > 0 {
+		aService.SetLoginLimiter(authService.NewLoginLimiter(authService.NewMemoryLoginAttemptStore(), cfg.LoginLimit, log))
+	}
+	aService.SetRiskEngine(riskEngine)
+
+	// Client IPs reaching IP_BAN_THRESHOLD failed logins and invalid refresh tokens within
+	// IP_BAN_WINDOW are banned from the API; IP_BAN_THRESHOLD=0 turns bans off
+	var ipBans *ipban.Guard
+	if cfg.IPBan.Threshold > 0 {
+		bans, err := ipban.NewGuard(ipban.NewMemoryStore(), cfg.IPBan, log)
+		if err != nil {
+			log.Warnf("IP ban initialization failed, bans disabled: %v", err)
+			ReportComponent(Component{Name: "ip-bans", Status: ComponentDegraded, Detail: err.Error()})
+		} else {
+			ipBans = bans
+			ReportComponent(Component{Name: "ip-bans", Status: ComponentActive, Detail: "memory store"})
+		}
+	} else {
+		ReportComponent(Component{Name: "ip-bans", Status: ComponentDisabled, Detail: "IP_BAN_THRESHOLD is 0"})
+	}
+	aService.SetIPBans(ipBans)
+	aService.SetWebhooks(webhooks)
+	// Client credentials grant for the service clients registered under /admin/service-clients
+	aService.SetServiceClients(authRepo.NewServiceClientRepo(db))
+	aService.SetAccountDeletionGrace(cfg.AccountDeletionGrace)
+
+	// New-device login emails go through the email provider; users can opt out
+	switch {
+	case !cfg.LoginAlerts:
+		ReportComponent(Component{Name: "login-alerts", Status: ComponentDisabled, Detail: "LOGIN_ALERTS_ENABLED is false"})
+	case emailSender == nil:
+		ReportComponent(Component{Name: "login-alerts", Status: ComponentDisabled, Detail: "EMAIL_PROVIDER is none"})
+	default:
+		aService.SetLoginAlerts(emailSender)
+		ReportComponent(Component{Name: "login-alerts", Status: ComponentActive})
+	}
+
+	// Passkey login; enabled by setting WEBAUTHN_RP_ID to the site's domain
+	if cfg.WebAuthn.RPID != "" {
+		rp := &webauthn.RelyingParty{ID: cfg.WebAuthn.RPID, Name: cfg.WebAuthn.RPName, Origins: cfg.WebAuthn.Origins}
+		aService.SetWebAuthn(rp, authRepo.NewWebAuthnRepo(db), cfg.WebAuthn.ChallengeTTL)
+	}
 
 	fRepo := fileRepo.NewFileRepo(db)
 	var fileHandler *fileApi.FileHandler
+	var exportHandler *exportApi.ExportHandler
 	fSvc, err := fileService.NewFileService(fRepo, cfg, log)
+	objectStorage := Component{Name: "storage", Endpoint: storage.Location(cfg.Storage, cfg.MinIO), Detail: cfg.Storage.Backend}
 	if err != nil {
-		log.Warnf("FileService initialization failed (MinIO unavailable): %v", err)
-		log.Warn("File upload/download endpoints will be unavailable")
+		objectStorage.Status, objectStorage.Detail = ComponentDegraded, cfg.Storage.Backend+": "+err.Error()
+		log.Warnf("FileService initialization failed (object storage unavailable): %v", err)
+		log.Warn("File upload/download and export endpoints will be unavailable")
 		// Continue without file service - file endpoints won't be registered
 	} else {
+		objectStorage.Status = ComponentActive
+		fSvc.SetEvents(domainEvents)
+		fSvc.SetResumableUploads(fileRepo.NewUploadRepo(db))
+		fSvc.SetFolders(fileRepo.NewFolderRepo(db))
+		fSvc.SetShareLinks(fileRepo.NewShareLinkRepo(db))
 		fileHandler = fileApi.NewFileHandler(fSvc, log)
 	}
+	ReportComponent(objectStorage)
+
+	// Background jobs; list and trigger them under /api/v1/admin/jobs
+	scheduler := jobs.NewScheduler(log)
+	if err := scheduler.Register(jobs.Job{
+		Name:     "token-cleanup",
+		Interval: 24 * time.Hour,
+		Timeout:  5 * time.Minute,
+		Run:      tStore.CleanupExpiredTokens,
+	}); err != nil {
+		log.Errorf("Failed to register job: %v", err)
+	}
+	if cfg.JWT.KeysDir != "" && cfg.JWT.KeysReloadInterval > 0 {
+		if err := scheduler.Register(jobs.Job{
+			Name:     "signing-key-reload",
+			Interval: cfg.JWT.KeysReloadInterval,
+			Timeout:  time.Minute,
+			Run:      func(context.Context) error { return reloadSigningKeys() },
+		}); err != nil {
+			log.Errorf("Failed to register job: %v", err)
+		}
+	}
+	if cfg.WebAuthn.RPID != "" {
+		if err := scheduler.Register(jobs.Job{
+			Name:     "webauthn-challenge-cleanup",
+			Interval: time.Hour,
+			Timeout:  time.Minute,
+			Run:      aService.CleanupExpiredChallenges,
+		}); err != nil {
+			log.Errorf("Failed to register job: %v", err)
+		}
+	}
+	if cfg.PasswordHistory > 0 {
+		if err := scheduler.Register(jobs.Job{
+			Name:     "password-history-cleanup",
+			Interval: 24 * time.Hour,
+			Timeout:  5 * time.Minute,
+			Run:      uService.CleanupPasswordHistory,
+		}); err != nil {
+			log.Errorf("Failed to register job: %v", err)
+		}
+	}
+	if err := scheduler.Register(jobs.Job{
+		Name:     "action-token-cleanup",
+		Interval: time.Hour,
+		Timeout:  5 * time.Minute,
+		Run:      actionTokens.Cleanup,
+	}); err != nil {
+		log.Errorf("Failed to register job: %v", err)
+	}
+	// Accounts deleted through DELETE /me are anonymized once their grace period ends
+	if err := scheduler.Register(jobs.Job{
+		Name:     "account-deletion",
+		Interval: time.Hour,
+		Timeout:  10 * time.Minute,
+		Run:      uService.AnonymizeDeletedAccounts,
+	}); err != nil {
+		log.Errorf("Failed to register job: %v", err)
+	}
+	if cfg.ActivityRetention > 0 {
+		if err := scheduler.Register(jobs.Job{
+			Name:     "activity-cleanup",
+			Interval: 24 * time.Hour,
+			Timeout:  5 * time.Minute,
+			Run:      activities.Cleanup,
+		}); err != nil {
+			log.Errorf("Failed to register job: %v", err)
+		}
+	}
+	// Large exports produced by background workers and downloaded from MinIO
+	var exports exportService.ExportService
+	if fSvc != nil {
+		exports = exportService.NewExportService(exportRepo.NewExportRepo(db), fSvc, cfg.Export, log,
+			exportService.WithPermissionCheck(authz.Can),
+		)
+		exports.Register("users", exportService.Producer{
+			ContentType: "text/csv",
+			Extension:   "csv",
+			Permission:  authzModel.PermUsersList,
+			Validate:    uHandler.ValidateExportParams,
+			Write: func(ctx context.Context, req exportService.Request, w io.Writer) (int64, error) {
+				return uHandler.WriteCSV(ctx, req.Params, w)
+			},
+		})
+		exportHandler = exportApi.NewExportHandler(exports, log)
+		if err := scheduler.Register(jobs.Job{
+			Name:     "export-cleanup",
+			Interval: 15 * time.Minute,
+			Timeout:  5 * time.Minute,
+			Run:      exports.Cleanup,
+		}); err != nil {
+			log.Errorf("Failed to register job: %v", err)
+		}
+		if fSvc.ThumbnailsEnabled() {
+			if err := scheduler.Register(jobs.Job{
+				Name:     "thumbnail-backfill",
+				Interval: 15 * time.Minute,
+				Timeout:  time.Minute,
+				Run:      fSvc.BackfillThumbnails,
+			}); err != nil {
+				log.Errorf("Failed to register job: %v", err)
+			}
+		}
+		if err := scheduler.Register(jobs.Job{
+			Name:     "upload-cleanup",
+			Interval: time.Hour,
+			Timeout:  5 * time.Minute,
+			Run:      fSvc.CleanupUploads,
+		}); err != nil {
+			log.Errorf("Failed to register job: %v", err)
+		}
+	}
+	if cfg.Disposable.ListURL != "" {
+		if err := scheduler.Register(jobs.Job{
+			Name:     "disposable-email-refresh",
+			Interval: cfg.Disposable.RefreshInterval,
+			Timeout:  time.Minute,
+			Run:      disposableDomains.Refresh,
+		}); err != nil {
+			log.Errorf("Failed to register job: %v", err)
+		}
+	}
+	// Email announcements to segments of users; disabled when EMAIL_PROVIDER=none
+	var announcementHandler *announcementApi.AnnouncementHandler
+	if emailSender != nil {
+		optOut := func(ctx context.Context, userID uuid.UUID) error {
+			optedOut := true
+			_, err := uService.Update(ctx, userID.String(), &userDto.UserUpdateRequest{AnnouncementsOptOut: &optedOut})
+			return err
+		}
+		announcements := announcementService.NewAnnouncementService(announcementRepo.NewAnnouncementRepo(db), emailSender, cfg.Announcement, optOut, log,
+			announcementService.WithDeliveryTrigger(func() { _ = scheduler.Run("announcement-delivery") }),
+		)
+		announcementHandler = announcementApi.NewAnnouncementHandler(announcements, log)
+		if err := scheduler.Register(jobs.Job{
+			Name:     "announcement-delivery",
+			Interval: time.Minute,
+			Timeout:  10 * time.Minute,
+			Run:      announcements.Deliver,
+		}); err != nil {
+			log.Errorf("Failed to register job: %v", err)
+		}
+	}
+
+	// OpenID Connect provider; clients are registered through OIDC_CLIENTS
+	var oidcHandler *oidcApi.OIDCHandler
+	var oidcKey *oidcService.SigningKey
+	if cfg.OIDC.SigningKeyFile != "" {
+		oidcKey, err = oidcService.LoadSigningKey(cfg.OIDC.SigningKeyFile)
+	} else {
+		log.Warn("OIDC_SIGNING_KEY_FILE is not set; using a temporary signing key, issued tokens stop validating on restart")
+		oidcKey, err = oidcService.GenerateSigningKey()
+	}
+	if err == nil {
+		err = db.AutoMigrate(&oidcModel.AuthCode{})
+	}
+	if err != nil {
+		log.Errorf("OIDC provider initialization failed: %v", err)
+		log.Warn("OIDC provider endpoints will be unavailable")
+		ReportComponent(Component{Name: "oidc-provider", Status: ComponentDegraded, Detail: err.Error()})
+	} else {
+		ReportComponent(Component{Name: "oidc-provider", Status: ComponentActive, Endpoint: cfg.OIDC.Issuer})
+		oidcSvc := oidcService.NewService(oidcRepo.NewCodeRepo(db), uRepo, oidcKey, cfg.OIDC, log)
+		oidcHandler = oidcApi.NewOIDCHandler(oidcSvc, aService.Authenticate, log)
+		jwks = append(jwks, func() []jwk.Key { return oidcSvc.JWKS().Keys })
+		if err := scheduler.Register(jobs.Job{
+			Name:     "oidc-code-cleanup",
+			Interval: time.Hour,
+			Timeout:  time.Minute,
+			Run:      oidcSvc.CleanupExpiredCodes,
+		}); err != nil {
+			log.Errorf("Failed to register job: %v", err)
+		}
+	}
+	scheduler.Start()
+	ReportComponent(Component{Name: "jobs", Status: ComponentActive, Detail: strconv.Itoa(len(scheduler.List())) + " jobs"})
+	OnShutdown("job scheduler", func(context.Context) error {
+		scheduler.Stop()
+		return nil
+	})
+	if exports != nil {
+		exports.Start(context.Background())
+	}
+	if fSvc != nil {
+		fSvc.StartThumbnails(context.Background())
+	}
+	// The downloaded list replaces the embedded one right away rather than after an interval
+	if cfg.Disposable.ListURL != "" {
+		_ = scheduler.Run("disposable-email-refresh")
+	}
+	if len(jwks) > 0 {
+		r.GET("/.well-known/jwks.json", JWKSHandler(jwks...))
+	}
+
+	// -----------------------
+	// OpenID Connect provider (served from the issuer root)
+	// -----------------------
+	if oidcHandler != nil {
+		r.GET("/.well-known/openid-configuration", oidcHandler.Discovery)
+		oauth2 := r.Group("/oauth2")
+		{
+			oauth2.GET("/authorize", oidcHandler.AuthorizeForm)
+			oauth2.POST("/authorize", oidcHandler.Authorize)
+			oauth2.POST("/token", oidcHandler.Token)
+			oauth2.GET("/userinfo", oidcHandler.UserInfo)
+			oauth2.POST("/userinfo", oidcHandler.UserInfo)
+		}
+	}
 
 	// -----------------------
 	// API Versioning: v1
 	// -----------------------
 	v1 := r.Group("/api/v1")
+	v1.Use(middleware.BlockBannedIPs(ipBans))
+	// Routes under MTLS_ROUTES authenticate with client certificates instead of JWTs
+	if len(cfg.TLS.ClientCertRoutes) > 0 {
+		v1.Use(middleware.ClientCertAuth(cfg.TLS, accountLookup, log))
+	}
 	{
 		// -----------------------
 		// Auth routes
@@ -115,6 +650,16 @@ func RegisterRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config, log *zap.Sug
 		auth := v1.Group("/")
 		{
 			auth.POST("/login", aHandler.Login)
+			auth.POST("/login/otp/request", aHandler.RequestLoginOTP)
+			auth.POST("/login/otp/verify", aHandler.LoginWithOTP)
+			auth.GET("/auth/oauth/:provider", aHandler.OAuthStart)
+			auth.GET("/auth/oauth/:provider/callback", aHandler.OAuthCallback)
+			auth.POST("/auth/webauthn/login/begin", aHandler.PasskeyLoginBegin)
+			auth.POST("/auth/webauthn/login/finish", aHandler.PasskeyLoginFinish)
+			if cfg.JWT.GuestEnabled {
+				auth.POST("/auth/guest", aHandler.IssueGuestToken)
+			}
+			auth.POST("/auth/token", aHandler.ClientToken)
 			auth.POST("/refresh", aHandler.Refresh)
 			auth.POST("/logout", aHandler.Logout)
 		}
@@ -122,37 +667,278 @@ func RegisterRoutes(r *gin.Engine, db *gorm.DB, cfg *config.Config, log *zap.Sug
 		// -----------------------
 		// User routes
 		// -----------------------
+		checkLimit, err := middleware.RateLimitByIP(cfg.AvailabilityCheckRate)
+		if err != nil {
+			log.Fatalf("Invalid AVAILABILITY_CHECK_RATE: %v", err)
+		}
 		users := v1.Group("/users")
 		{
-			users.POST("/", uHandler.Register)
-			users.GET("/", middleware.JWTAuth(jwtManager), uHandler.ListUsers)
-			users.GET("/:id", middleware.JWTAuth(jwtManager), uHandler.GetUser)
-			users.PUT("/:id", middleware.JWTAuth(jwtManager), uHandler.Update)
-			users.DELETE("/:id", middleware.JWTAuth(jwtManager), uHandler.Delete)
+			users.GET("/check", checkLimit, uHandler.CheckAvailability)
+			users.POST("/", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersCreate), uHandler.Register)
+			users.GET("/", requireAuthOrClient, middleware.RequirePermission(authz, authzModel.PermUsersList), uHandler.ListUsers)
+			users.GET("/export", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersList), uHandler.ExportUsers)
+			users.GET("/stats", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersList), uHandler.Stats)
+			users.POST("/merge", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersMerge), uHandler.Merge)
+			users.POST("/batch", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersBatch), uHandler.Batch)
+			users.GET("/tags", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersTags), uHandler.ListTags)
+			users.GET("/:id", requireAuth, uHandler.GetUser)
+			users.PUT("/:id", requireAuth, uHandler.Update)
+			users.DELETE("/:id", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersDelete), uHandler.Delete)
+			users.GET("/:id/history", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersHistory), uHandler.History)
+			users.GET("/:id/status-history", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersHistory), uHandler.StatusHistory)
+			users.GET("/:id/tags", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersTags), uHandler.UserTags)
+			users.POST("/:id/tags", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersTags), uHandler.AddTags)
+			users.DELETE("/:id/tags/:tag", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersTags), uHandler.RemoveTag)
+			users.GET("/:id/notes", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersNotes), uHandler.ListNotes)
+			users.POST("/:id/notes", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersNotes), uHandler.AddNote)
+			users.PUT("/:id/notes/:noteId", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersNotes), uHandler.UpdateNote)
+			users.DELETE("/:id/notes/:noteId", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersNotes), uHandler.DeleteNote)
+			users.POST("/:id/unlock", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersUnlock), aHandler.UnlockAccount)
+			users.DELETE("/:id/review", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersReview), uHandler.ClearReview)
+			users.POST("/:id/suspend", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersStatus), uHandler.Suspend)
+			users.POST("/:id/deactivate", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersStatus), uHandler.Deactivate)
+			users.POST("/:id/activate", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersStatus), uHandler.Activate)
 		}
 
+		// -----------------------
+		// Invitations, the way in when signup is closed
+		// -----------------------
+		invitations := v1.Group("/invitations")
+		invitations.Use(requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersCreate))
+		{
+			invitations.POST("/", uHandler.Invite)
+			invitations.DELETE("/:email", uHandler.RevokeInvitation)
+		}
+
+		// -----------------------
+		// Self-service signup; SIGNUP_ENABLED=false leaves account creation to admins
+		// -----------------------
+		if cfg.Signup.Enabled {
+			v1.POST("/auth/register", uHandler.Signup)
+		}
+		v1.GET("/auth/verify-email", uHandler.VerifyEmail)
+		v1.POST("/auth/verify-email", uHandler.VerifyEmail)
+		v1.POST("/auth/accept-invite", uHandler.AcceptInvite)
+
+		// -----------------------
+		// Custom profile fields (schema for user metadata)
+		// -----------------------
+		profileFields := v1.Group("/profile-fields")
+		{
+			profileFields.GET("/", requireAuthOrGuest, pfHandler.List)
+			manageFields := middleware.RequirePermission(authz, authzModel.PermProfileFieldsManage)
+			profileFields.POST("/", requireAuth, manageFields, pfHandler.Create)
+			profileFields.PUT("/:key", requireAuth, manageFields, pfHandler.Update)
+			profileFields.DELETE("/:key", requireAuth, manageFields, pfHandler.Delete)
+		}
+
+		// -----------------------
+		// Role management (RBAC)
+		// -----------------------
+		roles := v1.Group("/roles")
+		roles.Use(requireAuth, middleware.RequirePermission(authz, authzModel.PermRolesManage))
+		{
+			roles.GET("/", roleHandler.List)
+			roles.GET("/:name", roleHandler.Get)
+			roles.POST("/", roleHandler.Create)
+			roles.PUT("/:name", roleHandler.Update)
+			roles.DELETE("/:name", roleHandler.Delete)
+		}
+		v1.GET("/permissions", requireAuth, middleware.RequirePermission(authz, authzModel.PermRolesManage), roleHandler.ListPermissions)
+
 		// -----------------------
 		// Protected routes
 		// -----------------------
 		protected := v1.Group("/")
-		protected.Use(middleware.JWTAuth(jwtManager))
+		protected.Use(requireAuth)
 		{
 			protected.GET("/me", aHandler.Me)
+			protected.DELETE("/me", aHandler.DeleteMe)
+			protected.POST("/me/phone/verify", aHandler.RequestPhoneVerification)
+			protected.POST("/me/phone/confirm", aHandler.ConfirmPhoneVerification)
+			protected.GET("/me/activity", activityHandler.ListMine)
+			protected.GET("/me/profile", uHandler.GetProfile)
+			protected.PATCH("/me/profile", uHandler.UpdateProfile)
+			protected.POST("/auth/webauthn/register/begin", aHandler.PasskeyRegisterBegin)
+			protected.POST("/auth/webauthn/register/finish", aHandler.PasskeyRegisterFinish)
+			protected.GET("/auth/webauthn/credentials", aHandler.ListPasskeys)
+			protected.DELETE("/auth/webauthn/credentials/:id", aHandler.DeletePasskey)
 		}
 
 		// -----------------------
-		// File routes (only if MinIO available)
+		// Admin: background jobs
+		// -----------------------
+		admin := v1.Group("/admin")
+		admin.Use(requireAuth, middleware.RequirePermission(authz, authzModel.PermJobsManage))
+		{
+			admin.GET("/jobs", ListJobsHandler(scheduler))
+			admin.POST("/jobs/:name/run", RunJobHandler(scheduler))
+		}
+
+		// -----------------------
+		// Admin: access token signing keys
+		// -----------------------
+		signingKeys := v1.Group("/admin/signing-keys")
+		signingKeys.Use(requireAuth, middleware.RequirePermission(authz, authzModel.PermSigningKeysManage))
+		{
+			signingKeys.GET("/", ListSigningKeysHandler(jwtManager))
+			signingKeys.POST("/reload", ReloadSigningKeysHandler(jwtManager, reloadSigningKeys, log))
+		}
+
+		// -----------------------
+		// Admin: client IPs banned for repeated authentication failures
+		// -----------------------
+		ipBanAdmin := v1.Group("/admin/ip-bans")
+		ipBanAdmin.Use(requireAuth, middleware.RequirePermission(authz, authzModel.PermIPBansManage))
+		{
+			ipBanAdmin.GET("/", ListIPBansHandler(ipBans, log))
+			ipBanAdmin.DELETE("/:ip", LiftIPBanHandler(ipBans, log))
+		}
+
+		// -----------------------
+		// Admin: settings snapshots (promote profile fields and roles between environments)
+		// -----------------------
+		adminConfig := v1.Group("/admin/config")
+		adminConfig.Use(requireAuth, middleware.RequirePermission(authz, authzModel.PermConfigManage))
+		{
+			adminConfig.GET("/export", settingsHandler.Export)
+			adminConfig.POST("/import", settingsHandler.Import)
+		}
+
+		// -----------------------
+		// Admin: clients still calling deprecated endpoints
+		// -----------------------
+		v1.GET("/admin/deprecations", requireAuth, middleware.RequirePermission(authz, authzModel.PermDeprecationsView), ListDeprecationsHandler(deprecations))
+		v1.GET("/admin/system", requireAuth, middleware.RequirePermission(authz, authzModel.PermSystemView), SystemHandler())
+
+		// -----------------------
+		// Admin: refresh token inventory and revocation (incident response)
+		// -----------------------
+		manageTokens := middleware.RequirePermission(authz, authzModel.PermTokensManage)
+		v1.GET("/admin/tokens", requireAuth, manageTokens, aHandler.ListTokens)
+		v1.DELETE("/admin/tokens/:id", requireAuth, manageTokens, aHandler.RevokeToken)
+		v1.DELETE("/admin/users/:id/tokens", requireAuth, manageTokens, aHandler.RevokeUserTokens)
+
+		// -----------------------
+		// Admin: service clients of the client credentials grant
+		// -----------------------
+		manageClients := middleware.RequirePermission(authz, authzModel.PermServiceClientsManage)
+		v1.GET("/admin/service-clients", requireAuth, manageClients, aHandler.ListServiceClients)
+		v1.POST("/admin/service-clients", requireAuth, manageClients, aHandler.CreateServiceClient)
+		v1.DELETE("/admin/service-clients/:clientID", requireAuth, manageClients, aHandler.DeleteServiceClient)
+
+		// -----------------------
+		// Admin: impersonation (short-lived tokens acting as a user, for support)
+		// -----------------------
+		v1.POST("/admin/impersonate/:userID", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersImpersonate), aHandler.Impersonate)
+
+		// -----------------------
+		// Admin: user metrics (registrations per day, accounts per status and role)
+		// -----------------------
+		v1.GET("/admin/metrics/users", requireAuth, middleware.RequirePermission(authz, authzModel.PermUsersMetrics), uHandler.Metrics)
+
+		// -----------------------
+		// Admin: email announcements (only if an email provider is configured)
+		// -----------------------
+		if announcementHandler != nil {
+			adminAnnouncements := v1.Group("/admin/announcements")
+			adminAnnouncements.Use(requireAuth, middleware.RequirePermission(authz, authzModel.PermAnnouncementsManage))
+			{
+				adminAnnouncements.POST("/", announcementHandler.Create)
+				adminAnnouncements.GET("/", announcementHandler.List)
+				adminAnnouncements.GET("/:id", announcementHandler.Get)
+				adminAnnouncements.GET("/:id/deliveries", announcementHandler.Deliveries)
+				adminAnnouncements.POST("/:id/cancel", announcementHandler.Cancel)
+			}
+			// Unsubscribe links are opened from the email, without signing in
+			v1.GET("/announcements/unsubscribe", announcementHandler.Unsubscribe)
+			v1.POST("/announcements/unsubscribe", announcementHandler.Unsubscribe)
+		}
+
+		// -----------------------
+		// File routes (only if object storage is available)
 		// -----------------------
 		if fSvc != nil {
 			files := v1.Group("/files")
-			files.Use(middleware.JWTAuth(jwtManager))
+			files.Use(requireAuth)
 			{
 				files.POST("/upload", fileHandler.Upload)
 				files.GET("/:filename", fileHandler.GetFile)
+				files.GET("/:filename/verify", fileHandler.VerifyFile)
 				files.DELETE("/:filename", fileHandler.DeleteFile)
 				files.GET("/", fileHandler.GetUserFiles)
+				files.POST("/share", fileHandler.ShareFile)
+				files.POST("/presign-upload", fileHandler.PresignUpload)
+				files.POST("/complete", fileHandler.CompleteUpload)
+				files.PUT("/:filename/visibility", fileHandler.SetVisibility)
+				files.POST("/archive", fileHandler.CreateArchive)
+				if fSvc.FoldersEnabled() {
+					files.POST("/folders", fileHandler.CreateFolder)
+					files.GET("/folders", fileHandler.ListFolder)
+					files.POST("/:filename/move", fileHandler.MoveFile)
+				}
+				if fSvc.ShareLinksEnabled() {
+					files.POST("/:filename/share", fileHandler.CreateShareLink)
+					files.GET("/:filename/shares", fileHandler.ListShareLinks)
+					files.DELETE("/shares/:id", fileHandler.RevokeShareLink)
+				}
+				if fSvc.ResumableEnabled() {
+					files.POST("/uploads", fileHandler.CreateUpload)
+					files.GET("/uploads/:id", fileHandler.GetUpload)
+					files.HEAD("/uploads/:id", fileHandler.GetUpload)
+					files.PATCH("/uploads/:id", fileHandler.WriteChunk)
+					files.DELETE("/uploads/:id", fileHandler.DeleteUpload)
+				}
+			}
+			// Share links are opened by people without an account; the token is the credential
+			v1.GET("/files/shared", fileHandler.DownloadShared)
+			if fSvc.ShareLinksEnabled() {
+				shareLimit, err := middleware.RateLimitByIP(cfg.FileShare.LinkRate)
+				if err != nil {
+					log.Fatalf("Invalid FILE_SHARE_LINK_RATE: %v", err)
+				}
+				v1.GET("/share/:token", shareLimit, fileHandler.OpenShareLink)
 			}
+			// Public files need no sign-in; their URLs are stable and may be cached
+			v1.GET("/public/files/:id", fileHandler.GetPublicFile)
+			v1.HEAD("/public/files/:id", fileHandler.GetPublicFile)
+			// Signed URLs of the local storage driver; the token is the credential
+			if server, ok := fSvc.Storage().(storage.Server); ok {
+				v1.GET("/files/raw/:token", server.ServeSigned)
+				v1.HEAD("/files/raw/:token", server.ServeSigned)
+				v1.PUT("/files/raw/:token", server.ServeSigned)
+			}
+
+			// -----------------------
+			// Export routes (produced in the background, downloaded through signed URLs)
+			// -----------------------
+			exportRoutes := v1.Group("/exports")
+			exportRoutes.Use(requireAuth)
+			{
+				exportRoutes.POST("/", exportHandler.Create)
+				exportRoutes.GET("/:id", exportHandler.Get)
+			}
+		}
+	}
+
+	// -----------------------
+	// Example bodies derived from the DTOs, served next to Swagger in development
+	// -----------------------
+	if swaggerEnabled(cfg) {
+		var routeExamples []examples.Route
+		routeExamples = append(routeExamples, authApi.Examples...)
+		routeExamples = append(routeExamples, userApi.Examples...)
+		routeExamples = append(routeExamples, authzApi.Examples...)
+		routeExamples = append(routeExamples, activityApi.Examples...)
+		routeExamples = append(routeExamples, settingsApi.Examples...)
+		if announcementHandler != nil {
+			routeExamples = append(routeExamples, announcementApi.Examples...)
+		}
+		if fSvc != nil {
+			routeExamples = append(routeExamples, fileApi.Examples...)
+			routeExamples = append(routeExamples, exportApi.Examples...)
 		}
+		r.GET("/docs/examples", examples.Handler("/api/v1", routeExamples...))
 	}
 
 	log.Info("Routes registered successfully under /api/v1")
@@ -1,9 +1,20 @@
 package bootstrap
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
 
 	"go.uber.org/zap"
 
@@ -11,10 +22,92 @@ import (
 	"gorm.io/gorm"
 )
 
-// StartServer runs the Gin server and handles graceful shutdown
-func StartServer(r *gin.Engine, addr string, db *gorm.DB, log *zap.SugaredLogger) {
+// shutdownTimeout bounds how long in-flight requests and shutdown hooks may take
+const shutdownTimeout = 15 * time.Second
+
+type shutdownHook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+var (
+	hooksMu sync.Mutex
+	hooks   []shutdownHook
+)
+
+// OnShutdown registers fn to run after the server stops accepting requests. Hooks run
+// in reverse order of registration; a failing hook does not stop the ones after it.
+func OnShutdown(name string, fn func(ctx context.Context) error) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	hooks = append(hooks, shutdownHook{name: name, fn: fn})
+}
+
+// runShutdownHooks runs the registered hooks and returns every failure
+func runShutdownHooks(ctx context.Context) error {
+	hooksMu.Lock()
+	registered := hooks
+	hooks = nil
+	hooksMu.Unlock()
+
+	var errs []error
+	for i := len(registered) - 1; i >= 0; i-- {
+		if err := registered[i].fn(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", registered[i].name, err))
+		}
+	}
+	return apperrors.Join(errs...)
+}
+
+// ServerTLSConfig loads the server certificate and the client CAs of cfg. It returns nil
+// when TLS is not configured, so the server speaks plain HTTP.
+func ServerTLSConfig(cfg config.TLSConfig) (*tls.Config, error) {
+	if cfg.CertFile == "" {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading TLS_CERT_FILE and TLS_KEY_FILE: %w", err)
+	}
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	if cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading MTLS_CLIENT_CA_FILE: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("MTLS_CLIENT_CA_FILE %s holds no PEM certificates", cfg.ClientCAFile)
+		}
+		// Clients without a certificate still connect for the JWT routes; ClientCertAuth
+		// refuses them on the routes that need one
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return tlsConfig, nil
+}
+
+// StartServer runs the Gin server and handles graceful shutdown. It serves HTTPS when
+// tlsConfig is not nil (see ServerTLSConfig).
+func StartServer(r *gin.Engine, addr string, tlsConfig *tls.Config, db *gorm.DB, log *zap.SugaredLogger) {
+	srv := &http.Server{Addr: addr, Handler: r, TLSConfig: tlsConfig}
+	detail := "HTTP"
+	if tlsConfig != nil {
+		detail = "HTTPS"
+		if tlsConfig.ClientCAs != nil {
+			detail = "HTTPS, client certificates verified"
+		}
+	}
+	ReportComponent(Component{Name: "http", Status: ComponentActive, Endpoint: addr, Detail: detail})
+	LogComponents(log)
 	go func() {
-		if err := r.Run(addr); err != nil {
+		var err error
+		if tlsConfig != nil {
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Errorf("Server error: %v", err)
 		}
 	}()
@@ -24,7 +117,26 @@ func StartServer(r *gin.Engine, addr string, db *gorm.DB, log *zap.SugaredLogger
 	<-quit
 
 	log.Info("Shutting down server...")
-	sqlDB, _ := db.DB()
-	_ = sqlDB.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	// The database closes first in registration order so it runs last
+	if db != nil {
+		hooksMu.Lock()
+		hooks = append([]shutdownHook{{name: "database", fn: func(context.Context) error {
+			sqlDB, err := db.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.Close()
+		}}}, hooks...)
+		hooksMu.Unlock()
+	}
+
+	err := apperrors.Join(srv.Shutdown(ctx), runShutdownHooks(ctx))
+	if err != nil {
+		log.Errorw("Server stopped with errors", "error", err)
+		return
+	}
 	log.Info("Server stopped gracefully")
 }
@@ -0,0 +1,36 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRunShutdownHooks_RunsAllInReverseAndJoinsFailures(t *testing.T) {
+	// Arrange
+	var order []string
+	hook := func(name string, err error) {
+		OnShutdown(name, func(context.Context) error {
+			order = append(order, name)
+			return err
+		})
+	}
+	hook("cache", errors.New("flush failed"))
+	hook("scheduler", nil)
+	hook("queue", errors.New("drain timed out"))
+
+	// Act
+	err := runShutdownHooks(context.Background())
+
+	// Assert
+	if strings.Join(order, ",") != "queue,scheduler,cache" {
+		t.Errorf("order = %v, want reverse registration order", order)
+	}
+	if err == nil || !strings.Contains(err.Error(), "queue: drain timed out") || !strings.Contains(err.Error(), "cache: flush failed") {
+		t.Errorf("err = %v, want both failures", err)
+	}
+	if err := runShutdownHooks(context.Background()); err != nil {
+		t.Errorf("second run err = %v, want hooks to run once", err)
+	}
+}
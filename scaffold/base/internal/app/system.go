@@ -0,0 +1,110 @@
+package bootstrap
+
+import (
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ComponentStatus says whether a subsystem is running
+type ComponentStatus string
+
+const (
+	// ComponentActive subsystems are configured and running
+	ComponentActive ComponentStatus = "active"
+	// ComponentDisabled subsystems are turned off by configuration
+	ComponentDisabled ComponentStatus = "disabled"
+	// ComponentDegraded subsystems are configured but failed to start; the service runs without them
+	ComponentDegraded ComponentStatus = "degraded"
+)
+
+// Component is one subsystem in the startup summary and at GET /admin/system
+type Component struct {
+	// example: database
+	Name   string          `json:"name" example:"database"`
+	Status ComponentStatus `json:"status" example:"active"`
+	// Where the subsystem is reached or served, without credentials
+	// example: postgres://localhost:5432/app
+	Endpoint string `json:"endpoint,omitempty" example:"postgres://localhost:5432/app"`
+	// example: 16.2
+	Version string `json:"version,omitempty" example:"16.2"`
+	// What is missing or failed for disabled and degraded subsystems
+	// example: MINIO_ENDPOINT is not reachable
+	Detail string `json:"detail,omitempty" example:"MINIO_ENDPOINT is not reachable"`
+}
+
+// SystemInfo is the body of GET /admin/system
+type SystemInfo struct {
+	StartedAt  time.Time   `json:"started_at"`
+	GoVersion  string      `json:"go_version" example:"go1.24.0"`
+	Components []Component `json:"components"`
+}
+
+var (
+	componentsMu sync.Mutex
+	components   []Component
+	startedAt    = time.Now()
+)
+
+// ReportComponent records the state of a subsystem, replacing an earlier report with the
+// same name. Call it where the subsystem is set up; degraded components are logged as
+// warnings in the startup summary.
+func ReportComponent(c Component) {
+	componentsMu.Lock()
+	defer componentsMu.Unlock()
+	for i := range components {
+		if components[i].Name == c.Name {
+			components[i] = c
+			return
+		}
+	}
+	components = append(components, c)
+}
+
+// Components returns the reported subsystems in the order they were first reported
+func Components() []Component {
+	componentsMu.Lock()
+	defer componentsMu.Unlock()
+	return append([]Component(nil), components...)
+}
+
+// LogComponents logs the reported subsystems as one structured entry, plus a warning for
+// each degraded one
+func LogComponents(log *zap.SugaredLogger) {
+	reported := Components()
+	summary := make([]interface{}, 0, 2*len(reported))
+	for _, c := range reported {
+		summary = append(summary, c.Name, c)
+	}
+	log.Infow("System summary", summary...)
+	for _, c := range reported {
+		if c.Status == ComponentDegraded {
+			log.Warnw("Component degraded", "component", c.Name, "detail", c.Detail)
+		}
+	}
+}
+
+// SystemHandler godoc
+// @Summary Show which subsystems are running (requires system:view)
+// @Description Lists each subsystem as active, disabled or degraded, with its endpoint and version where known. This is the summary logged at startup, as seen by the instance answering.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} response.SuccessResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Router /admin/system [get]
+func SystemHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, response.NewSuccessResponse(SystemInfo{
+			StartedAt:  startedAt,
+			GoVersion:  runtime.Version(),
+			Components: Components(),
+		}, c.GetString("RequestID")))
+	}
+}
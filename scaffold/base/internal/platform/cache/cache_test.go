@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+)
+
+func TestMemory_Expiry(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	clk := testutil.NewFakeClock(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	c := NewMemory()
+	c.SetClock(clk)
+	_ = c.Set(ctx, "short", []byte("a"), time.Minute)
+	_ = c.Set(ctx, "forever", []byte("b"), 0)
+
+	// Act
+	clk.Advance(59 * time.Second)
+	_, fresh, _ := c.Get(ctx, "short")
+	clk.Advance(time.Second)
+	_, stale, _ := c.Get(ctx, "short")
+	value, kept, _ := c.Get(ctx, "forever")
+
+	// Assert
+	if !fresh {
+		t.Error("entry expired before its TTL")
+	}
+	if stale {
+		t.Error("entry still returned after its TTL")
+	}
+	if !kept || string(value) != "b" {
+		t.Errorf("entry without TTL = %q, %v, want b", value, kept)
+	}
+}
+
+func TestMemory_Delete(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemory()
+	_ = c.Set(ctx, "k", []byte("v"), time.Minute)
+
+	_ = c.Delete(ctx, "k")
+
+	if _, ok, _ := c.Get(ctx, "k"); ok {
+		t.Error("deleted entry still returned")
+	}
+}
+
+func TestRequestScope(t *testing.T) {
+	if FromRequest(context.Background()) != nil {
+		t.Fatal("expected no request cache on a plain context")
+	}
+
+	ctx := WithRequestScope(context.Background())
+	_ = FromRequest(ctx).Set(ctx, "k", []byte("v"), 0)
+
+	if _, ok, _ := FromRequest(ctx).Get(ctx, "k"); !ok {
+		t.Error("request cache lost a value within the same request")
+	}
+	if _, ok, _ := FromRequest(WithRequestScope(context.Background())).Get(ctx, "k"); ok {
+		t.Error("request caches leaked between requests")
+	}
+}
@@ -3,8 +3,14 @@ package config
 import (
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/json"
+	"fmt"
 	"log"
 	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -17,8 +23,47 @@ type JWTConfig struct {
 	RefreshKey       string
 	AccessExpiresIn  time.Duration
 	RefreshExpiresIn time.Duration
+	// ImpersonationExpiresIn is how long tokens from POST /admin/impersonate are valid
+	ImpersonationExpiresIn time.Duration
+	// GuestEnabled serves POST /auth/guest, which issues guest tokens to anonymous
+	// clients; GuestExpiresIn is how long they are valid
+	GuestEnabled   bool
+	GuestExpiresIn time.Duration
+	// RememberMeExpiresIn is the refresh token lifetime of logins with remember_me set
+	RememberMeExpiresIn time.Duration
+	// SessionMaxLifetime is how long after login refreshes stop extending a session;
+	// 0 lets sessions be refreshed forever
+	SessionMaxLifetime time.Duration
+	// AccountCheck is how protected routes re-check the account behind a token:
+	// off, status or strict (see middleware.AccountCheckMode)
+	AccountCheck string
+	// TokenCacheSize is how many validated access tokens JWTAuth remembers so it skips
+	// parsing them again; 0 disables the cache. TokenCacheTTL bounds how long each is kept.
+	TokenCacheSize int
+	TokenCacheTTL  time.Duration
+	// Algorithm signs access tokens: HS256 with SigningKey, or RS256/EdDSA with the PEM
+	// private key in PrivateKey or PrivateKeyFile, whose public half is served as a JWKS
+	Algorithm      string
+	PrivateKey     string
+	PrivateKeyFile string
+	// KeysDir holds more PEM private keys (*.pem) for rotation. They join PrivateKey in file
+	// name order; the last one signs new tokens and all of them verify.
+	KeysDir string
+	// KeysReloadInterval is how often KeysDir is read again, so added and removed keys take
+	// effect without a restart; 0 only reads it at startup
+	KeysReloadInterval time.Duration
+	// PreviousSigningKeys are earlier HS256 secrets still accepted until their tokens expire
+	PreviousSigningKeys []string
+	// Issuer and Audience are set as "iss" and "aud" on every token and required on the
+	// tokens it accepts; a token passes when it names any of Audience. Empty skips them.
+	Issuer   string
+	Audience []string
+	// Leeway tolerates clock skew between servers when checking token times
+	Leeway time.Duration
 }
 
+// MinIOConfig is the S3-compatible service used by the minio, s3 and gcs storage
+// backends
 type MinIOConfig struct {
 	MinioEndpoint  string
 	MinioAccessKey string
@@ -27,6 +72,457 @@ type MinIOConfig struct {
 	MinioUseSSL    bool
 }
 
+// Storage backends
+const (
+	StorageMinIO = "minio"
+	StorageS3    = "s3"
+	StorageGCS   = "gcs"
+	StorageAzure = "azure"
+	StorageLocal = "local"
+)
+
+// StorageConfig selects where files and exports are stored. The minio, s3 and gcs
+// backends use MinIOConfig; gcs goes through the S3-compatible XML API with HMAC keys.
+type StorageConfig struct {
+	// Backend is one of the Storage* constants, set by STORAGE_DRIVER
+	Backend string
+	// Region of the bucket; empty lets the s3 backend look it up
+	Region string
+	// LocalDir is the directory of the local backend
+	LocalDir string
+	// LocalURL is where the API serves the signed URLs of the local backend, followed by
+	// their token
+	LocalURL string
+	// LocalSecret signs the URLs of the local backend; it defaults to the JWT signing key
+	LocalSecret string
+	// AzureAccount and AzureKey are the storage account name and its base64 access key
+	AzureAccount string
+	AzureKey     string
+	// AzureContainer holds the blobs; it defaults to MINIO_BUCKET
+	AzureContainer string
+	// AzureEndpoint overrides https://<account>.blob.core.windows.net, e.g. for Azurite
+	AzureEndpoint string
+}
+
+type CORSConfig struct {
+	AllowOrigins     []string
+	AllowMethods     []string
+	AllowHeaders     []string
+	ExposeHeaders    []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// LogConfig chooses where logs are written and how the log file is rotated
+type LogConfig struct {
+	// Outputs are any of "stdout", "stderr" and "file"; containers usually want only a stream
+	Outputs []string
+	// FilePath is the log file written when Outputs has "file"
+	FilePath string
+	// MaxSizeMB is the size at which the file is rotated
+	MaxSizeMB int
+	// MaxBackups is how many rotated files are kept, MaxAgeDays how long
+	MaxBackups int
+	MaxAgeDays int
+	Compress   bool
+	// MinFreePercent stops writing the file while less than this share of its volume is
+	// free; 0 turns the guard off
+	MinFreePercent int
+}
+
+// HasOutput reports whether logs are written to output
+func (c LogConfig) HasOutput(output string) bool {
+	for _, o := range c.Outputs {
+		if o == output {
+			return true
+		}
+	}
+	return false
+}
+
+type LocalizationConfig struct {
+	// DefaultLocale is used when neither the request nor the user states a locale
+	DefaultLocale string
+	// TimestampMode is "utc" (timestamps stay RFC3339 UTC, the caller's zone and offset
+	// are added to the envelope) or "local" (timestamps are converted to the caller's zone)
+	TimestampMode string
+}
+
+type SMSConfig struct {
+	Provider         string
+	TwilioAccountSID string
+	TwilioAuthToken  string
+	TwilioFromNumber string
+	OTPLength        int
+	OTPTTL           time.Duration
+	OTPMaxAttempts   int
+}
+
+// EmailConfig selects how email is sent; EMAIL_PROVIDER=none disables it
+type EmailConfig struct {
+	Provider string
+	// From is the sender address, e.g. "Example <no-reply@example.com>"
+	From         string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+}
+
+// AnnouncementConfig controls how announcements are delivered to users
+type AnnouncementConfig struct {
+	// BatchSize is how many deliveries one worker claims at a time
+	BatchSize int
+	// RatePerSecond caps emails sent per second by each instance; 0 means no limit
+	RatePerSecond float64
+	// MaxAttempts is how often a failed delivery is tried before it is given up
+	MaxAttempts int
+	// UnsubscribeURL is the public address of the unsubscribe endpoint, added with a
+	// per-user token to every announcement
+	UnsubscribeURL string
+	// UnsubscribeSecret signs unsubscribe tokens; it defaults to the JWT signing key
+	UnsubscribeSecret string
+}
+
+// SignupConfig controls self-service registration at POST /auth/register
+type SignupConfig struct {
+	// Enabled exposes POST /auth/register; admins can still create users at POST /users
+	Enabled bool
+	// RequireCaptcha makes every signup solve the CAPTCHA_PROVIDER challenge, not only
+	// the ones risk scoring finds suspicious
+	RequireCaptcha bool
+	// VerifyURL is the public address of the email verification endpoint, added with a
+	// single-use token to the email sent after signup
+	VerifyURL string
+	// VerifyTTL is how long verification links stay valid
+	VerifyTTL time.Duration
+}
+
+// InviteConfig controls the invitations admins send at POST /invitations
+type InviteConfig struct {
+	// URL is the page that accepts invitations, added with a single-use token to the
+	// invitation email; it should post the token to POST /auth/accept-invite
+	URL string
+	// TTL is how long invitations stay valid unless the admin sets another expiry
+	TTL time.Duration
+}
+
+// OAuthProviderConfig holds the client credentials of one OAuth2 login provider;
+// a provider without a client ID is disabled
+type OAuthProviderConfig struct {
+	ClientID     string
+	ClientSecret string
+}
+
+// OIDCLoginProvider is an external OpenID Connect identity provider, such as Keycloak,
+// Auth0 or Entra ID, that users can log in with
+type OIDCLoginProvider struct {
+	// Name is the provider key in the login routes, e.g. keycloak
+	Name string `json:"name"`
+	// Issuer is the provider's issuer URL; its endpoints and keys are discovered from it
+	Issuer       string `json:"issuer"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	// Scopes requested at login; openid, email and profile when empty
+	Scopes []string `json:"scopes"`
+	// TrustEmail accepts an email without the email_verified claim, for providers such as
+	// Entra ID that only issue addresses they manage
+	TrustEmail bool `json:"trust_email"`
+	// RolesClaim names the claim with the user's roles or groups, e.g. roles or
+	// realm_access.roles. When set, the provider decides the user's role on every login.
+	RolesClaim string `json:"roles_claim"`
+	// RoleMappings turn values of RolesClaim into roles; the first match wins
+	RoleMappings []OIDCRoleMapping `json:"role_mappings"`
+	// DefaultRole is given when no mapping matches; user when empty
+	DefaultRole string `json:"default_role"`
+}
+
+// OIDCRoleMapping gives Role to users whose roles claim contains Value
+type OIDCRoleMapping struct {
+	Value string `json:"value"`
+	Role  string `json:"role"`
+}
+
+type OAuthConfig struct {
+	Google OAuthProviderConfig
+	GitHub OAuthProviderConfig
+	// OIDC are external identity providers, from OAUTH_OIDC_PROVIDERS
+	OIDC []OIDCLoginProvider
+	// RedirectBaseURL is the public base URL of this service; providers redirect to
+	// <RedirectBaseURL>/api/v1/auth/oauth/<provider>/callback
+	RedirectBaseURL string
+	// AllowSignup creates an account on first login when no user has the provider's
+	// verified email; otherwise only existing accounts can log in
+	AllowSignup bool
+}
+
+// LoginLimitConfig throttles password logins. Every failed attempt doubles the wait
+// before the next one, from BackoffBase up to BackoffMax, and MaxFailures failures lock
+// the account for Lockout. IPMaxFailures applies the same rules per client IP across
+// all accounts. Failures are forgotten Window after the last one. MaxFailures 0
+// disables the limits.
+type LoginLimitConfig struct {
+	MaxFailures   int
+	IPMaxFailures int
+	BackoffBase   time.Duration
+	BackoffMax    time.Duration
+	Lockout       time.Duration
+	Window        time.Duration
+}
+
+// IPBanConfig bans client IPs that reach Threshold suspicious authentication events, such
+// as failed logins and invalid refresh tokens, within Window. A ban refuses every request
+// from the IP for Duration. Allowlist addresses and networks are never banned. Threshold 0
+// disables bans.
+type IPBanConfig struct {
+	Threshold int
+	Window    time.Duration
+	Duration  time.Duration
+	Allowlist []string
+}
+
+// QuotaConfig caps the API requests each user may make per calendar day and month (UTC),
+// by role. The "*" entry applies to roles without their own; a role without a quota, or
+// with 0, is unlimited. No entries disables quotas.
+type QuotaConfig struct {
+	Daily   map[string]int64
+	Monthly map[string]int64
+}
+
+// Enabled reports whether any role has a quota
+func (c QuotaConfig) Enabled() bool {
+	return len(c.Daily) > 0 || len(c.Monthly) > 0
+}
+
+// WebhookConfig posts auth events to Endpoints. Failed deliveries are retried up to
+// MaxAttempts times in all, waiting from BackoffBase, doubling up to BackoffMax, between
+// attempts; each attempt gives up after Timeout. No endpoints disables webhooks.
+type WebhookConfig struct {
+	Endpoints   []WebhookEndpoint
+	MaxAttempts int
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	Timeout     time.Duration
+}
+
+// WebhookEndpoint receives the events it subscribes to, signed with its secret
+type WebhookEndpoint struct {
+	URL string `json:"url"`
+	// Events the endpoint receives, e.g. user.login; empty means all of them
+	Events []string `json:"events"`
+	// Secret keys the HMAC-SHA256 signature of each delivery
+	Secret string `json:"secret"`
+}
+
+// ProfileField declares a custom profile field in code-owned configuration rather than
+// through the admin API; see PROFILE_FIELDS
+type ProfileField struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+	// Type is string, number, boolean or date
+	Type     string   `json:"type"`
+	Required bool     `json:"required"`
+	Choices  []string `json:"choices"`
+}
+
+// TLSConfig serves HTTPS from CertFile and KeyFile when both are set. With ClientCAFile
+// the server also verifies client certificates issued by those CAs, and requests under
+// ClientCertRoutes must authenticate with one whose subject is in ClientSubjects; the
+// rest of the API keeps using JWTs.
+type TLSConfig struct {
+	CertFile     string
+	KeyFile      string
+	ClientCAFile string
+	// ClientCertRoutes are path prefixes, e.g. /api/v1/admin, that require a client certificate
+	ClientCertRoutes []string
+	// ClientSubjects maps certificate common names to the identities they authenticate as
+	ClientSubjects map[string]ClientSubject
+}
+
+// Kinds of ClientSubject
+const (
+	ClientSubjectUser    = "user"
+	ClientSubjectService = "service"
+)
+
+// ClientSubject is the identity a client certificate authenticates as: a user account by
+// ID, which acts with the user's current role, or a service account with a fixed role
+type ClientSubject struct {
+	Kind string
+	// ID is the user's UUID or the service account name
+	ID   string
+	Role string
+}
+
+// RiskConfig scores registrations and logins for signs of abuse. Each rule that fires adds
+// its score, and the total decides the outcome: at FlagScore the account is flagged for
+// review, at CaptchaScore the client must solve a CAPTCHA, and at BlockScore the attempt
+// is rejected. A threshold of 0 disables that outcome.
+type RiskConfig struct {
+	Enabled      bool
+	FlagScore    int
+	CaptchaScore int
+	BlockScore   int
+	// IPDenylist are addresses or CIDRs with a bad reputation, scored DenylistScore
+	IPDenylist    []string
+	DenylistScore int
+	// DisposableEmailScore is added for registrations from a disposable email domain
+	// (see DisposableEmailConfig) when they are not blocked outright
+	DisposableEmailScore int
+	// RegisterPerIP and LoginPerIP attempts from one IP within VelocityWindow are
+	// allowed; every attempt after that scores VelocityScore
+	VelocityWindow time.Duration
+	RegisterPerIP  int
+	LoginPerIP     int
+	VelocityScore  int
+	// CaptchaProvider is none, turnstile, hcaptcha or recaptcha. Without one, attempts
+	// that would need a CAPTCHA are flagged instead.
+	CaptchaProvider string
+	CaptchaSecret   string
+}
+
+// DisposableEmailConfig is the list of throwaway email domains. It starts from a list
+// embedded in the binary, replaced by the one at ListURL every RefreshInterval when set.
+// Allow and Deny override the list, the most specific domain winning. With Block,
+// registrations and email changes to a listed domain are rejected.
+type DisposableEmailConfig struct {
+	Block           bool
+	ListURL         string
+	RefreshInterval time.Duration
+	Allow           []string
+	Deny            []string
+}
+
+// ClientConfig identifies the application behind each request, for per-client logs and
+// metrics (see middleware.ClientIdentificationMiddleware)
+type ClientConfig struct {
+	Enabled bool
+	// Header carries "id" or "id/version"; the User-Agent is used when it is missing
+	Header string
+	// Known are the client IDs reported under their own name in metrics; when empty the
+	// first clients seen are, up to a fixed number, and the rest are counted as "other"
+	Known []string
+}
+
+// RefreshCookieConfig delivers refresh tokens in an HttpOnly cookie instead of the JSON
+// body, for browser apps that should not keep them where JavaScript can read them
+type RefreshCookieConfig struct {
+	Enabled bool
+	Name    string
+	// Path limits which requests carry the cookie; it must cover /refresh and /logout
+	Path   string
+	Domain string
+	// SameSite is strict, lax or none; none also needs Secure and CORS with credentials
+	SameSite string
+	Secure   bool
+}
+
+// ExportConfig controls asynchronous exports, which are written by background workers and
+// downloaded from MinIO through signed URLs
+type ExportConfig struct {
+	// Workers is how many exports each instance produces at once
+	Workers int
+	// Timeout bounds producing and uploading one export; a running export older than
+	// twice this is assumed lost with its instance and marked failed
+	Timeout time.Duration
+	// Retention is how long a finished export can be downloaded before it is deleted
+	Retention time.Duration
+	// URLExpiry is how long each signed download URL is valid
+	URLExpiry time.Duration
+	// MaxPending is how many unfinished exports one user may have
+	MaxPending int
+}
+
+// FileScanConfig enables scanning uploads for malware with a ClamAV daemon; scanning is
+// disabled when ClamdAddr is empty
+type FileScanConfig struct {
+	// ClamdAddr is the host:port of clamd's TCP socket
+	ClamdAddr string
+	// Timeout bounds scanning one upload
+	Timeout time.Duration
+}
+
+// FileShareConfig controls tokens that let anyone holding them download one file without
+// signing in
+type FileShareConfig struct {
+	// Secret signs share tokens; it defaults to the JWT signing key
+	Secret string
+	// DefaultTTL is how long a token stays valid when the request names no expiry
+	DefaultTTL time.Duration
+	// MaxTTL is the longest expiry a request may ask for
+	MaxTTL time.Duration
+	// LinkRate limits downloads through share links per client IP, e.g. "30-M"; it slows
+	// down guessing their passwords
+	LinkRate string
+}
+
+// FileUploadConfig controls uploads that clients send straight to MinIO through a
+// presigned URL, and resumable uploads sent through the API in chunks
+type FileUploadConfig struct {
+	// PresignTTL is how long the presigned URL and its upload ID stay valid
+	PresignTTL time.Duration
+	// ResumableTTL is how long a resumable upload is kept after its last chunk before it
+	// is aborted
+	ResumableTTL time.Duration
+}
+
+// FileArchiveConfig limits the ZIP archives of POST /files/archive
+type FileArchiveConfig struct {
+	// MaxFiles is how many files one archive may hold
+	MaxFiles int
+	// MaxSize is the most bytes, before compression, one archive may hold
+	MaxSize int64
+}
+
+// FilePublicConfig controls the URLs of public files, which never expire
+type FilePublicConfig struct {
+	// BaseURL is followed by the file ID in public URLs; point it at a CDN in front of
+	// the API to serve public files from its cache
+	BaseURL string
+	// MaxAge is how long browsers and CDNs may cache a public file
+	MaxAge time.Duration
+}
+
+// ThumbnailConfig controls the thumbnails generated for uploaded profile images;
+// generation is disabled when Sizes is empty
+type ThumbnailConfig struct {
+	// Sizes are the longest edges of the thumbnails, in pixels
+	Sizes []int
+}
+
+// WebAuthnConfig is the relying party passkeys are registered for; passkey login is
+// disabled when RPID is empty
+type WebAuthnConfig struct {
+	// RPID is the domain passkeys are bound to, e.g. example.com; it must be the origin's
+	// host or a parent domain of it
+	RPID string
+	// RPName is shown by the browser when a passkey is created
+	RPName string
+	// Origins are the web origins allowed to run the ceremonies, e.g. https://app.example.com
+	Origins      []string
+	ChallengeTTL time.Duration
+}
+
+// OIDCClient is an application allowed to sign users in through this service's OIDC
+// provider; a client without a secret is public and must use PKCE
+type OIDCClient struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Secret       string   `json:"secret"`
+	RedirectURIs []string `json:"redirect_uris"`
+}
+
+type OIDCConfig struct {
+	// Issuer is the public base URL of this service as seen by relying parties
+	Issuer string
+	// SigningKeyFile is a PEM RSA private key used to sign tokens; a temporary key is
+	// generated when empty, invalidating issued tokens on restart
+	SigningKeyFile string
+	Clients        []OIDCClient
+	CodeTTL        time.Duration
+	TokenTTL       time.Duration
+}
+
 type Config struct {
 	ServerAddr        string
 	APIVersion        string
@@ -39,9 +535,71 @@ type Config struct {
 	DBMaxOpenConns    int
 	DBMaxIdleConns    int
 	DBConnMaxLifetime int
-	LogLevel          string
+	DBPrepareStmt     bool
+	// DBPoolCheckInterval is how often the connection pool is sampled; a warning is
+	// logged when requests waited DBPoolWaitWarn or longer for connections in between
+	DBPoolCheckInterval time.Duration
+	DBPoolWaitWarn      time.Duration
+	LogLevel            string
+	Log                 LogConfig
+	EmailFoldGmail      bool
+	// ReservedUsernames replace the names the username_policy rule refuses
+	ReservedUsernames []string
+	PhoneRegion       string
+	// ProfileFields are custom profile fields every deployment has, next to the ones
+	// admins define at /profile-fields
+	ProfileFields []ProfileField
+	// AvailabilityCheckRate limits GET /users/check per client IP, e.g. "10-M"
+	AvailabilityCheckRate string
+	IDVersion             string
+	// TrustedProxies are the addresses or CIDRs of load balancers allowed to set the client
+	// IP through X-Forwarded-For; when empty none is, and the client IP is the remote address
+	TrustedProxies []string
+	// UserCacheTTL is how long user role/status and role permission lookups are cached; 0 disables the cache
+	UserCacheTTL time.Duration
+	// RoleHierarchy maps roles to the roles they inherit, on top of the inheritance stored
+	// with each role
+	RoleHierarchy map[string][]string
+	// PasswordHistory is how many recent passwords a user may not reuse; 0 disables the check
+	PasswordHistory int
+	// LoginAlerts emails users about logins from devices they have not used before
+	LoginAlerts bool
+	// AccountDeletionGrace is how long after DELETE /me an account is anonymized; logging
+	// in before then cancels the deletion
+	AccountDeletionGrace time.Duration
+	// ActivityRetention is how long entries of the activity feed (GET /me/activity) are
+	// kept; 0 keeps them forever
+	ActivityRetention time.Duration
 	JWT               JWTConfig
 	MinIO             MinIOConfig
+	Storage           StorageConfig
+	FileScan          FileScanConfig
+	FileShare         FileShareConfig
+	FileUpload        FileUploadConfig
+	FilePublic        FilePublicConfig
+	FileArchive       FileArchiveConfig
+	Thumbnails        ThumbnailConfig
+	Export            ExportConfig
+	CORS              CORSConfig
+	SMS               SMSConfig
+	Email             EmailConfig
+	Announcement      AnnouncementConfig
+	Signup            SignupConfig
+	Invite            InviteConfig
+	OAuth             OAuthConfig
+	LoginLimit        LoginLimitConfig
+	IPBan             IPBanConfig
+	Quota             QuotaConfig
+	Webhooks          WebhookConfig
+	// RefreshCookie switches refresh tokens from the response body to a cookie
+	RefreshCookie RefreshCookieConfig
+	TLS           TLSConfig
+	Client        ClientConfig
+	Risk          RiskConfig
+	Disposable    DisposableEmailConfig
+	WebAuthn      WebAuthnConfig
+	OIDC          OIDCConfig
+	Localization  LocalizationConfig
 }
 
 var (
@@ -49,109 +607,510 @@ var (
 	once      sync.Once
 )
 
+// source is where configuration values are read from: the environment and .env through
+// viper, or fixed values in tests
+type source interface {
+	GetString(key string) string
+}
+
+// LoadConfig reads the configuration once and keeps it for GetConfig; later calls return
+// the same value. It exits the process when the configuration is invalid.
 func LoadConfig() *Config {
 	once.Do(func() {
-		_ = godotenv.Load()
+		cfg, err := Load()
+		if err != nil {
+			log.Fatalf("Invalid configuration: %v", err)
+		}
+		appConfig = cfg
+	})
+
+	return appConfig
+}
+
+// Load reads a new Config from the environment and an optional .env file without touching
+// the one kept by LoadConfig. Unset variables take the defaults in .env.example.
+func Load() (*Config, error) {
+	_ = godotenv.Load()
+
+	v := viper.New()
+	v.SetConfigFile(".env")
+	v.SetConfigType("env")
+	v.AutomaticEnv()
+
+	if err := v.ReadInConfig(); err != nil {
+		log.Println("No .env file found, relying on environment variables")
+	}
+	return fromSource(v)
+}
+
+// fromSource builds a Config from v, warning about and replacing invalid optional values;
+// only values the service cannot start without are returned as errors
+func fromSource(v source) (*Config, error) {
+	var dbHost, dbPort, dbUser, dbPassword, dbName string
 
-		viper.SetConfigFile(".env")
-		viper.SetConfigType("env")
-		viper.AutomaticEnv()
+	dbURL := v.GetString("DATABASE_URL")
+	if dbURL != "" {
+		parsedURL, err := url.Parse(dbURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DATABASE_URL: %w", err)
+		}
+		dbUser = parsedURL.User.Username()
+		dbPassword, _ = parsedURL.User.Password()
+		dbHost = parsedURL.Hostname()
+		dbPort = parsedURL.Port()
+		dbName = parsedURL.Path
+		if len(dbName) > 0 && dbName[0] == '/' {
+			dbName = dbName[1:]
+		}
+	} else {
+		dbHost = getEnvWithDefault(v, "DB_HOST", "localhost")
+		dbPort = getEnvWithDefault(v, "DB_PORT", "5432")
+		dbUser = getEnvWithDefault(v, "DB_USER", "postgres")
+		dbPassword = getEnvWithDefault(v, "DB_PASSWORD", "postgres")
+		dbName = getEnvWithDefault(v, "DB_NAME", "test")
+	}
 
-		if err := viper.ReadInConfig(); err != nil {
-			log.Println("No .env file found, relying on environment variables")
+	serverAddr := getEnvWithDefault(v, "SERVER_ADDR", ":8080")
+	apiVersion := getEnvWithDefault(v, "API_VERSION", "v1")
+	ginMode := getEnvWithDefault(v, "GIN_MODE", "release")
+	logLevel := getEnvWithDefault(v, "LOG_LEVEL", "info")
+	logOutputs := parseListOrDefault(strings.ToLower(v.GetString("LOG_OUTPUT")), []string{"stdout", "file"})
+	for _, output := range logOutputs {
+		if output != "stdout" && output != "stderr" && output != "file" {
+			return nil, fmt.Errorf("invalid LOG_OUTPUT %q: use stdout, stderr or file", output)
 		}
+	}
+	emailFoldGmail := parseBoolOrDefault(v.GetString("EMAIL_FOLD_GMAIL"), false)
+	dbPrepareStmt := parseBoolOrDefault(v.GetString("DB_PREPARE_STMT"), true)
+	dbPoolCheckInterval := parseDurationOrDefault(v.GetString("DB_POOL_CHECK_INTERVAL"), 30*time.Second)
+	dbPoolWaitWarn := parseDurationOrDefault(v.GetString("DB_POOL_WAIT_WARN"), time.Second)
+	phoneRegion := v.GetString("PHONE_DEFAULT_REGION")
+	userCacheTTL := parseDurationOrDefault(v.GetString("USER_CACHE_TTL"), 30*time.Second)
+	idVersion := strings.ToLower(getEnvWithDefault(v, "ID_VERSION", "v7"))
+	if idVersion != "v7" && idVersion != "v4" {
+		log.Printf("[WARN] Invalid ID_VERSION %q, using v7", idVersion)
+		idVersion = "v7"
+	}
 
-		var dbHost, dbPort, dbUser, dbPassword, dbName string
+	dbMaxOpenConns := parseIntOrDefault(v.GetString("DB_MAX_OPEN_CONNS"), 0)
+	if dbMaxOpenConns == 0 {
+		dbMaxOpenConns = 25
+	}
+	dbMaxIdleConns := parseIntOrDefault(v.GetString("DB_MAX_IDLE_CONNS"), 0)
+	if dbMaxIdleConns == 0 {
+		dbMaxIdleConns = 5
+	}
+	dbConnMaxLifetime := parseIntOrDefault(v.GetString("DB_CONN_MAX_LIFETIME"), 0)
+	if dbConnMaxLifetime == 0 {
+		dbConnMaxLifetime = 300
+	}
 
-		dbURL := viper.GetString("DATABASE_URL")
-		if dbURL != "" {
-			parsedURL, err := url.Parse(dbURL)
-			if err != nil {
-				log.Fatalf("Invalid DATABASE_URL: %v", err)
-			}
-			dbUser = parsedURL.User.Username()
-			dbPassword, _ = parsedURL.User.Password()
-			dbHost = parsedURL.Hostname()
-			dbPort = parsedURL.Port()
-			dbName = parsedURL.Path
-			if len(dbName) > 0 && dbName[0] == '/' {
-				dbName = dbName[1:]
-			}
+	jwtSigningKey := v.GetString("JWT_SIGNING_KEY")
+	if jwtSigningKey == "" {
+		jwtSigningKey = generateRandomKey()
+		log.Println("[WARN] JWT_SIGNING_KEY not set, generated temporary key")
+	}
+	jwtRefreshKey := v.GetString("JWT_REFRESH_KEY")
+	if jwtRefreshKey == "" {
+		jwtRefreshKey = generateRandomKey()
+		log.Println("[WARN] JWT_REFRESH_KEY not set, generated temporary key")
+	}
+	jwtAccessExpiry := parseDurationOrDefault(v.GetString("JWT_ACCESS_EXPIRY"), 15*time.Minute)
+	jwtRefreshExpiry := parseDurationOrDefault(v.GetString("JWT_REFRESH_EXPIRY"), 7*24*time.Hour)
+	accountCheck := strings.ToLower(getEnvWithDefault(v, "AUTH_ACCOUNT_CHECK", "off"))
+	if accountCheck != "off" && accountCheck != "status" && accountCheck != "strict" {
+		log.Printf("[WARN] Invalid AUTH_ACCOUNT_CHECK %q, using off", accountCheck)
+		accountCheck = "off"
+	}
+	jwtAlgorithm := getEnvWithDefault(v, "JWT_ALGORITHM", "HS256")
+	switch strings.ToUpper(jwtAlgorithm) {
+	case "HS256", "RS256":
+		jwtAlgorithm = strings.ToUpper(jwtAlgorithm)
+	case "EDDSA":
+		jwtAlgorithm = "EdDSA"
+	default:
+		log.Printf("[WARN] Invalid JWT_ALGORITHM %q, using HS256", jwtAlgorithm)
+		jwtAlgorithm = "HS256"
+	}
+	// An inline key may be written on one line with \n escapes
+	jwtPrivateKey := strings.ReplaceAll(v.GetString("JWT_PRIVATE_KEY"), `\n`, "\n")
+	jwtPrivateKeyFile := v.GetString("JWT_PRIVATE_KEY_FILE")
+	jwtKeysDir := v.GetString("JWT_KEYS_DIR")
+	if jwtAlgorithm != "HS256" && jwtPrivateKey == "" && jwtPrivateKeyFile == "" && jwtKeysDir == "" {
+		return nil, fmt.Errorf("JWT_ALGORITHM %s needs JWT_PRIVATE_KEY, JWT_PRIVATE_KEY_FILE or JWT_KEYS_DIR", jwtAlgorithm)
+	}
+	refreshCookieSameSite := strings.ToLower(getEnvWithDefault(v, "AUTH_REFRESH_COOKIE_SAMESITE", "strict"))
+	if refreshCookieSameSite != "strict" && refreshCookieSameSite != "lax" && refreshCookieSameSite != "none" {
+		log.Printf("[WARN] Invalid AUTH_REFRESH_COOKIE_SAMESITE %q, using strict", refreshCookieSameSite)
+		refreshCookieSameSite = "strict"
+	}
+	refreshCookieSecure := parseBoolOrDefault(v.GetString("AUTH_REFRESH_COOKIE_SECURE"), true)
+	if refreshCookieSameSite == "none" && !refreshCookieSecure {
+		log.Println("[WARN] AUTH_REFRESH_COOKIE_SAMESITE=none requires a Secure cookie, ignoring AUTH_REFRESH_COOKIE_SECURE=false")
+		refreshCookieSecure = true
+	}
+
+	storageConfig, err := parseStorageConfig(v, jwtSigningKey)
+	if err != nil {
+		return nil, err
+	}
+	// The hosted services default to their public endpoints over TLS
+	defaultEndpoint, defaultSecure := "localhost:9000", false
+	switch storageConfig.Backend {
+	case StorageS3:
+		defaultEndpoint, defaultSecure = "s3.amazonaws.com", true
+	case StorageGCS:
+		defaultEndpoint, defaultSecure = "storage.googleapis.com", true
+	}
+	minioEndpoint := getEnvWithDefault(v, "MINIO_ENDPOINT", defaultEndpoint)
+	minioAccessKey := getEnvWithDefault(v, "MINIO_ACCESS_KEY", "minioadmin")
+	minioSecretKey := getEnvWithDefault(v, "MINIO_SECRET_KEY", "minioadmin")
+	minioBucket := getEnvWithDefault(v, "MINIO_BUCKET", "uploads")
+	minioUseSSL := parseBoolOrDefault(v.GetString("MINIO_SECURE"), defaultSecure)
+	if storageConfig.AzureContainer == "" {
+		storageConfig.AzureContainer = minioBucket
+	}
+
+	corsAllowOrigins := parseListOrDefault(v.GetString("CORS_ALLOWED_ORIGINS"), []string{"http://localhost:3000"})
+	corsAllowMethods := parseListOrDefault(v.GetString("CORS_ALLOWED_METHODS"), []string{"GET", "POST", "PUT", "DELETE"})
+	corsAllowHeaders := parseListOrDefault(v.GetString("CORS_ALLOWED_HEADERS"), []string{"Origin", "Content-Type", "Authorization", "X-Captcha-Token", "X-Client-Id"})
+	corsExposeHeaders := parseListOrDefault(v.GetString("CORS_EXPOSED_HEADERS"), []string{"Content-Length"})
+	corsAllowCredentials := parseBoolOrDefault(v.GetString("CORS_ALLOW_CREDENTIALS"), true)
+	corsMaxAge := parseDurationOrDefault(v.GetString("CORS_MAX_AGE"), 12*time.Hour)
+
+	defaultLocale := getEnvWithDefault(v, "DEFAULT_LOCALE", "en")
+	timestampMode := strings.ToLower(getEnvWithDefault(v, "RESPONSE_TIMESTAMPS", "utc"))
+	if timestampMode != "utc" && timestampMode != "local" {
+		log.Printf("[WARN] Invalid RESPONSE_TIMESTAMPS %q, using utc", timestampMode)
+		timestampMode = "utc"
+	}
+
+	smsProvider := getEnvWithDefault(v, "SMS_PROVIDER", "none")
+	smsOTPLength := parseIntOrDefault(v.GetString("SMS_OTP_LENGTH"), 0)
+	if smsOTPLength == 0 {
+		smsOTPLength = 6
+	}
+	smsOTPTTL := parseDurationOrDefault(v.GetString("SMS_OTP_TTL"), 5*time.Minute)
+	smsOTPMaxAttempts := parseIntOrDefault(v.GetString("SMS_OTP_MAX_ATTEMPTS"), 0)
+	if smsOTPMaxAttempts == 0 {
+		smsOTPMaxAttempts = 5
+	}
+
+	roleHierarchy, err := parseRoleHierarchy(v.GetString("ROLE_HIERARCHY"))
+	if err != nil {
+		return nil, err
+	}
+
+	dailyQuotas, err := parseQuotas("QUOTA_DAILY", v.GetString("QUOTA_DAILY"))
+	if err != nil {
+		return nil, err
+	}
+	monthlyQuotas, err := parseQuotas("QUOTA_MONTHLY", v.GetString("QUOTA_MONTHLY"))
+	if err != nil {
+		return nil, err
+	}
+
+	thumbnailSizes, err := parseThumbnailSizes(getEnvWithDefault(v, "FILE_THUMBNAIL_SIZES", "128,512"))
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := parseTLSConfig(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var webhookEndpoints []WebhookEndpoint
+	if raw := v.GetString("WEBHOOKS"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &webhookEndpoints); err != nil {
+			return nil, fmt.Errorf("invalid WEBHOOKS: %w", err)
+		}
+	}
+
+	var profileFields []ProfileField
+	if raw := v.GetString("PROFILE_FIELDS"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &profileFields); err != nil {
+			return nil, fmt.Errorf("invalid PROFILE_FIELDS: %w", err)
+		}
+	}
+
+	captchaProvider := getEnvWithDefault(v, "CAPTCHA_PROVIDER", "none")
+	signupCaptcha := parseBoolOrDefault(v.GetString("SIGNUP_CAPTCHA"), false)
+	if signupCaptcha && strings.EqualFold(captchaProvider, "none") {
+		return nil, fmt.Errorf("SIGNUP_CAPTCHA needs a CAPTCHA_PROVIDER")
+	}
+
+	announcementRate := 10.0
+	if raw := v.GetString("ANNOUNCEMENT_RATE_PER_SECOND"); raw != "" {
+		if rate, err := strconv.ParseFloat(raw, 64); err == nil && rate >= 0 {
+			announcementRate = rate
 		} else {
-			dbHost = getEnvWithDefault("DB_HOST", "localhost")
-			dbPort = getEnvWithDefault("DB_PORT", "5432")
-			dbUser = getEnvWithDefault("DB_USER", "postgres")
-			dbPassword = getEnvWithDefault("DB_PASSWORD", "postgres")
-			dbName = getEnvWithDefault("DB_NAME", "test")
-		}
-
-		serverAddr := getEnvWithDefault("SERVER_ADDR", ":8080")
-		apiVersion := getEnvWithDefault("API_VERSION", "v1")
-		ginMode := getEnvWithDefault("GIN_MODE", "release")
-		logLevel := getEnvWithDefault("LOG_LEVEL", "info")
-
-		dbMaxOpenConns := viper.GetInt("DB_MAX_OPEN_CONNS")
-		if dbMaxOpenConns == 0 {
-			dbMaxOpenConns = 25
-		}
-		dbMaxIdleConns := viper.GetInt("DB_MAX_IDLE_CONNS")
-		if dbMaxIdleConns == 0 {
-			dbMaxIdleConns = 5
-		}
-		dbConnMaxLifetime := viper.GetInt("DB_CONN_MAX_LIFETIME")
-		if dbConnMaxLifetime == 0 {
-			dbConnMaxLifetime = 300
-		}
-
-		jwtSigningKey := viper.GetString("JWT_SIGNING_KEY")
-		if jwtSigningKey == "" {
-			jwtSigningKey = generateRandomKey()
-			log.Println("[WARN] JWT_SIGNING_KEY not set, generated temporary key")
-		}
-		jwtRefreshKey := viper.GetString("JWT_REFRESH_KEY")
-		if jwtRefreshKey == "" {
-			jwtRefreshKey = generateRandomKey()
-			log.Println("[WARN] JWT_REFRESH_KEY not set, generated temporary key")
-		}
-		jwtAccessExpiry := parseDurationOrDefault(viper.GetString("JWT_ACCESS_EXPIRY"), 15*time.Minute)
-		jwtRefreshExpiry := parseDurationOrDefault(viper.GetString("JWT_REFRESH_EXPIRY"), 7*24*time.Hour)
-
-		minioEndpoint := getEnvWithDefault("MINIO_ENDPOINT", "localhost:9000")
-		minioAccessKey := getEnvWithDefault("MINIO_ACCESS_KEY", "minioadmin")
-		minioSecretKey := getEnvWithDefault("MINIO_SECRET_KEY", "minioadmin")
-		minioBucket := getEnvWithDefault("MINIO_BUCKET", "uploads")
-		minioUseSSL := viper.GetBool("MINIO_SECURE")
-
-		appConfig = &Config{
-			ServerAddr:        serverAddr,
-			APIVersion:        apiVersion,
-			DBHost:            dbHost,
-			DBPort:            dbPort,
-			DBUser:            dbUser,
-			DBPassword:        dbPassword,
-			DBName:            dbName,
-			GinMode:           ginMode,
-			DBMaxOpenConns:    dbMaxOpenConns,
-			DBMaxIdleConns:    dbMaxIdleConns,
-			DBConnMaxLifetime: dbConnMaxLifetime,
-			LogLevel:          logLevel,
-			JWT: JWTConfig{
-				SigningKey:       jwtSigningKey,
-				RefreshKey:       jwtRefreshKey,
-				AccessExpiresIn:  jwtAccessExpiry,
-				RefreshExpiresIn: jwtRefreshExpiry,
-			},
-			MinIO: MinIOConfig{
-				MinioEndpoint:  minioEndpoint,
-				MinioAccessKey: minioAccessKey,
-				MinioSecretKey: minioSecretKey,
-				MinioBucket:    minioBucket,
-				MinioUseSSL:    minioUseSSL,
-			},
+			log.Printf("[WARN] Invalid ANNOUNCEMENT_RATE_PER_SECOND %q, using default", raw)
 		}
-	})
+	}
 
-	return appConfig
+	// LOGIN_MAX_FAILURES=0 turns the login limits off, so only an unset value takes the default
+	loginMaxFailures := 5
+	if v.GetString("LOGIN_MAX_FAILURES") != "" {
+		loginMaxFailures = parseIntOrDefault(v.GetString("LOGIN_MAX_FAILURES"), 0)
+	}
+	loginIPMaxFailures := parseIntOrDefault(v.GetString("LOGIN_IP_MAX_FAILURES"), 0)
+	if loginIPMaxFailures == 0 {
+		loginIPMaxFailures = 50
+	}
+
+	var oidcClients []OIDCClient
+	if raw := v.GetString("OIDC_CLIENTS"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &oidcClients); err != nil {
+			log.Printf("[WARN] Invalid OIDC_CLIENTS, no OIDC clients registered: %v", err)
+			oidcClients = nil
+		}
+	}
+
+	var oidcLoginProviders []OIDCLoginProvider
+	if raw := v.GetString("OAUTH_OIDC_PROVIDERS"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &oidcLoginProviders); err != nil {
+			log.Printf("[WARN] Invalid OAUTH_OIDC_PROVIDERS, no OIDC login providers enabled: %v", err)
+			oidcLoginProviders = nil
+		}
+	}
+	oidcLoginProviders = validOIDCLoginProviders(oidcLoginProviders)
+
+	return &Config{
+		ServerAddr:          serverAddr,
+		APIVersion:          apiVersion,
+		DBHost:              dbHost,
+		DBPort:              dbPort,
+		DBUser:              dbUser,
+		DBPassword:          dbPassword,
+		DBName:              dbName,
+		GinMode:             ginMode,
+		DBMaxOpenConns:      dbMaxOpenConns,
+		DBMaxIdleConns:      dbMaxIdleConns,
+		DBConnMaxLifetime:   dbConnMaxLifetime,
+		DBPrepareStmt:       dbPrepareStmt,
+		DBPoolCheckInterval: dbPoolCheckInterval,
+		DBPoolWaitWarn:      dbPoolWaitWarn,
+		LogLevel:            logLevel,
+		Log: LogConfig{
+			Outputs:        logOutputs,
+			FilePath:       getEnvWithDefault(v, "LOG_FILE_PATH", "logs/app.log"),
+			MaxSizeMB:      parseIntOrDefault(v.GetString("LOG_FILE_MAX_SIZE_MB"), 50),
+			MaxBackups:     parseIntOrDefault(v.GetString("LOG_FILE_MAX_BACKUPS"), 7),
+			MaxAgeDays:     parseIntOrDefault(v.GetString("LOG_FILE_MAX_AGE_DAYS"), 30),
+			Compress:       parseBoolOrDefault(v.GetString("LOG_FILE_COMPRESS"), true),
+			MinFreePercent: parseIntOrDefault(v.GetString("LOG_FILE_MIN_FREE_PERCENT"), 5),
+		},
+		EmailFoldGmail:        emailFoldGmail,
+		ReservedUsernames:     parseListOrDefault(v.GetString("RESERVED_USERNAMES"), nil),
+		PhoneRegion:           phoneRegion,
+		ProfileFields:         profileFields,
+		AvailabilityCheckRate: getEnvWithDefault(v, "AVAILABILITY_CHECK_RATE", "10-M"),
+		IDVersion:             idVersion,
+		UserCacheTTL:          userCacheTTL,
+		RoleHierarchy:         roleHierarchy,
+		PasswordHistory:       max(parseIntOrDefault(v.GetString("PASSWORD_HISTORY"), 5), 0),
+		AccountDeletionGrace:  parseDurationOrDefault(v.GetString("ACCOUNT_DELETION_GRACE_PERIOD"), 30*24*time.Hour),
+		ActivityRetention:     parseDurationOrDefault(v.GetString("ACTIVITY_RETENTION"), 90*24*time.Hour),
+		LoginAlerts:           parseBoolOrDefault(v.GetString("LOGIN_ALERTS_ENABLED"), true),
+		TrustedProxies:        parseListOrDefault(v.GetString("TRUSTED_PROXIES"), nil),
+		JWT: JWTConfig{
+			SigningKey:             jwtSigningKey,
+			RefreshKey:             jwtRefreshKey,
+			AccessExpiresIn:        jwtAccessExpiry,
+			RefreshExpiresIn:       jwtRefreshExpiry,
+			ImpersonationExpiresIn: parseDurationOrDefault(v.GetString("JWT_IMPERSONATION_EXPIRY"), 10*time.Minute),
+			GuestEnabled:           parseBoolOrDefault(v.GetString("GUEST_TOKENS_ENABLED"), false),
+			GuestExpiresIn:         parseDurationOrDefault(v.GetString("JWT_GUEST_EXPIRY"), time.Hour),
+			RememberMeExpiresIn:    parseDurationOrDefault(v.GetString("JWT_REMEMBER_ME_EXPIRY"), 30*24*time.Hour),
+			SessionMaxLifetime:     parseDurationOrDefault(v.GetString("JWT_SESSION_MAX_LIFETIME"), 90*24*time.Hour),
+			AccountCheck:           accountCheck,
+			TokenCacheSize:         max(parseIntOrDefault(v.GetString("JWT_TOKEN_CACHE_SIZE"), 0), 0),
+			TokenCacheTTL:          parseDurationOrDefault(v.GetString("JWT_TOKEN_CACHE_TTL"), time.Minute),
+			Algorithm:              jwtAlgorithm,
+			PrivateKey:             jwtPrivateKey,
+			PrivateKeyFile:         jwtPrivateKeyFile,
+			KeysDir:                jwtKeysDir,
+			KeysReloadInterval:     parseDurationOrDefault(v.GetString("JWT_KEYS_RELOAD_INTERVAL"), time.Minute),
+			PreviousSigningKeys:    parseListOrDefault(v.GetString("JWT_PREVIOUS_SIGNING_KEYS"), nil),
+			Issuer:                 v.GetString("JWT_ISSUER"),
+			Audience:               parseListOrDefault(v.GetString("JWT_AUDIENCE"), nil),
+			Leeway:                 parseDurationOrDefault(v.GetString("JWT_LEEWAY"), 0),
+		},
+		MinIO: MinIOConfig{
+			MinioEndpoint:  minioEndpoint,
+			MinioAccessKey: minioAccessKey,
+			MinioSecretKey: minioSecretKey,
+			MinioBucket:    minioBucket,
+			MinioUseSSL:    minioUseSSL,
+		},
+		Storage: storageConfig,
+		CORS: CORSConfig{
+			AllowOrigins:     corsAllowOrigins,
+			AllowMethods:     corsAllowMethods,
+			AllowHeaders:     corsAllowHeaders,
+			ExposeHeaders:    corsExposeHeaders,
+			AllowCredentials: corsAllowCredentials,
+			MaxAge:           corsMaxAge,
+		},
+		SMS: SMSConfig{
+			Provider:         smsProvider,
+			TwilioAccountSID: v.GetString("TWILIO_ACCOUNT_SID"),
+			TwilioAuthToken:  v.GetString("TWILIO_AUTH_TOKEN"),
+			TwilioFromNumber: v.GetString("TWILIO_FROM_NUMBER"),
+			OTPLength:        smsOTPLength,
+			OTPTTL:           smsOTPTTL,
+			OTPMaxAttempts:   smsOTPMaxAttempts,
+		},
+		Email: EmailConfig{
+			Provider:     getEnvWithDefault(v, "EMAIL_PROVIDER", "none"),
+			From:         v.GetString("EMAIL_FROM"),
+			SMTPHost:     v.GetString("SMTP_HOST"),
+			SMTPPort:     parseIntOrDefault(v.GetString("SMTP_PORT"), 587),
+			SMTPUsername: v.GetString("SMTP_USERNAME"),
+			SMTPPassword: v.GetString("SMTP_PASSWORD"),
+		},
+		Announcement: AnnouncementConfig{
+			BatchSize:         parseIntOrDefault(v.GetString("ANNOUNCEMENT_BATCH_SIZE"), 100),
+			RatePerSecond:     announcementRate,
+			MaxAttempts:       parseIntOrDefault(v.GetString("ANNOUNCEMENT_MAX_ATTEMPTS"), 3),
+			UnsubscribeURL:    getEnvWithDefault(v, "ANNOUNCEMENT_UNSUBSCRIBE_URL", "http://localhost:8080/api/v1/announcements/unsubscribe"),
+			UnsubscribeSecret: getEnvWithDefault(v, "ANNOUNCEMENT_UNSUBSCRIBE_SECRET", jwtSigningKey),
+		},
+		Signup: SignupConfig{
+			Enabled:        parseBoolOrDefault(v.GetString("SIGNUP_ENABLED"), true),
+			RequireCaptcha: signupCaptcha,
+			VerifyURL:      getEnvWithDefault(v, "SIGNUP_VERIFY_URL", "http://localhost:8080/api/v1/auth/verify-email"),
+			VerifyTTL:      parseDurationOrDefault(v.GetString("SIGNUP_VERIFY_TTL"), 48*time.Hour),
+		},
+		Invite: InviteConfig{
+			URL: getEnvWithDefault(v, "INVITE_URL", "http://localhost:3000/accept-invite"),
+			TTL: parseDurationOrDefault(v.GetString("INVITE_TTL"), 7*24*time.Hour),
+		},
+		OAuth: OAuthConfig{
+			Google: OAuthProviderConfig{
+				ClientID:     v.GetString("OAUTH_GOOGLE_CLIENT_ID"),
+				ClientSecret: v.GetString("OAUTH_GOOGLE_CLIENT_SECRET"),
+			},
+			GitHub: OAuthProviderConfig{
+				ClientID:     v.GetString("OAUTH_GITHUB_CLIENT_ID"),
+				ClientSecret: v.GetString("OAUTH_GITHUB_CLIENT_SECRET"),
+			},
+			RedirectBaseURL: strings.TrimRight(getEnvWithDefault(v, "OAUTH_REDIRECT_BASE_URL", "http://localhost:8080"), "/"),
+			AllowSignup:     parseBoolOrDefault(v.GetString("OAUTH_ALLOW_SIGNUP"), true),
+			OIDC:            oidcLoginProviders,
+		},
+		LoginLimit: LoginLimitConfig{
+			MaxFailures:   loginMaxFailures,
+			IPMaxFailures: loginIPMaxFailures,
+			BackoffBase:   parseDurationOrDefault(v.GetString("LOGIN_BACKOFF_BASE"), time.Second),
+			BackoffMax:    parseDurationOrDefault(v.GetString("LOGIN_BACKOFF_MAX"), 30*time.Second),
+			Lockout:       parseDurationOrDefault(v.GetString("LOGIN_LOCKOUT_DURATION"), 15*time.Minute),
+			Window:        parseDurationOrDefault(v.GetString("LOGIN_FAILURE_WINDOW"), 15*time.Minute),
+		},
+		TLS: tlsConfig,
+		IPBan: IPBanConfig{
+			Threshold: max(parseIntOrDefault(v.GetString("IP_BAN_THRESHOLD"), 100), 0),
+			Window:    parseDurationOrDefault(v.GetString("IP_BAN_WINDOW"), time.Hour),
+			Duration:  parseDurationOrDefault(v.GetString("IP_BAN_DURATION"), time.Hour),
+			Allowlist: parseListOrDefault(v.GetString("IP_BAN_ALLOWLIST"), nil),
+		},
+		Quota: QuotaConfig{
+			Daily:   dailyQuotas,
+			Monthly: monthlyQuotas,
+		},
+		Webhooks: WebhookConfig{
+			Endpoints:   webhookEndpoints,
+			MaxAttempts: max(parseIntOrDefault(v.GetString("WEBHOOK_MAX_ATTEMPTS"), 5), 1),
+			BackoffBase: parseDurationOrDefault(v.GetString("WEBHOOK_BACKOFF_BASE"), time.Second),
+			BackoffMax:  parseDurationOrDefault(v.GetString("WEBHOOK_BACKOFF_MAX"), 5*time.Minute),
+			Timeout:     parseDurationOrDefault(v.GetString("WEBHOOK_TIMEOUT"), 10*time.Second),
+		},
+		Risk: RiskConfig{
+			Enabled:              parseBoolOrDefault(v.GetString("RISK_ENABLED"), true),
+			FlagScore:            parseIntOrDefault(v.GetString("RISK_FLAG_SCORE"), 30),
+			CaptchaScore:         parseIntOrDefault(v.GetString("RISK_CAPTCHA_SCORE"), 60),
+			BlockScore:           parseIntOrDefault(v.GetString("RISK_BLOCK_SCORE"), 100),
+			IPDenylist:           parseListOrDefault(v.GetString("RISK_IP_DENYLIST"), nil),
+			DenylistScore:        parseIntOrDefault(v.GetString("RISK_DENYLIST_SCORE"), 100),
+			DisposableEmailScore: parseIntOrDefault(v.GetString("RISK_DISPOSABLE_EMAIL_SCORE"), 40),
+			VelocityWindow:       parseDurationOrDefault(v.GetString("RISK_VELOCITY_WINDOW"), time.Hour),
+			RegisterPerIP:        parseIntOrDefault(v.GetString("RISK_REGISTER_PER_IP"), 5),
+			LoginPerIP:           parseIntOrDefault(v.GetString("RISK_LOGIN_PER_IP"), 100),
+			VelocityScore:        parseIntOrDefault(v.GetString("RISK_VELOCITY_SCORE"), 60),
+			CaptchaProvider:      captchaProvider,
+			CaptchaSecret:        v.GetString("CAPTCHA_SECRET"),
+		},
+		Disposable: DisposableEmailConfig{
+			Block:           parseBoolOrDefault(v.GetString("DISPOSABLE_EMAIL_BLOCK"), true),
+			ListURL:         v.GetString("DISPOSABLE_EMAIL_LIST_URL"),
+			RefreshInterval: parseDurationOrDefault(v.GetString("DISPOSABLE_EMAIL_REFRESH_INTERVAL"), 24*time.Hour),
+			Allow:           parseListOrDefault(v.GetString("DISPOSABLE_EMAIL_ALLOW"), nil),
+			Deny:            parseListOrDefault(v.GetString("DISPOSABLE_EMAIL_DENY"), nil),
+		},
+		RefreshCookie: RefreshCookieConfig{
+			Enabled:  parseBoolOrDefault(v.GetString("AUTH_REFRESH_COOKIE"), false),
+			Name:     getEnvWithDefault(v, "AUTH_REFRESH_COOKIE_NAME", "refresh_token"),
+			Path:     getEnvWithDefault(v, "AUTH_REFRESH_COOKIE_PATH", "/api/v1"),
+			Domain:   v.GetString("AUTH_REFRESH_COOKIE_DOMAIN"),
+			SameSite: refreshCookieSameSite,
+			Secure:   refreshCookieSecure,
+		},
+		FileScan: FileScanConfig{
+			ClamdAddr: v.GetString("FILE_SCAN_CLAMD_ADDR"),
+			Timeout:   parseDurationOrDefault(v.GetString("FILE_SCAN_TIMEOUT"), 30*time.Second),
+		},
+		FileShare: FileShareConfig{
+			Secret:     getEnvWithDefault(v, "FILE_SHARE_SECRET", jwtSigningKey),
+			DefaultTTL: parseDurationOrDefault(v.GetString("FILE_SHARE_TTL"), time.Hour),
+			MaxTTL:     parseDurationOrDefault(v.GetString("FILE_SHARE_MAX_TTL"), 7*24*time.Hour),
+			LinkRate:   getEnvWithDefault(v, "FILE_SHARE_LINK_RATE", "30-M"),
+		},
+		FileUpload: FileUploadConfig{
+			PresignTTL:   parseDurationOrDefault(v.GetString("FILE_PRESIGN_TTL"), 15*time.Minute),
+			ResumableTTL: parseDurationOrDefault(v.GetString("FILE_RESUMABLE_TTL"), 24*time.Hour),
+		},
+		FilePublic: FilePublicConfig{
+			BaseURL: strings.TrimSuffix(getEnvWithDefault(v, "FILE_PUBLIC_BASE_URL", "/api/v1/public/files"), "/"),
+			MaxAge:  parseDurationOrDefault(v.GetString("FILE_PUBLIC_MAX_AGE"), 24*time.Hour),
+		},
+		FileArchive: FileArchiveConfig{
+			MaxFiles: parseIntOrDefault(v.GetString("FILE_ARCHIVE_MAX_FILES"), 100),
+			MaxSize:  int64(parseIntOrDefault(v.GetString("FILE_ARCHIVE_MAX_SIZE_MB"), 500)) << 20,
+		},
+		Thumbnails: ThumbnailConfig{
+			Sizes: thumbnailSizes,
+		},
+		Export: ExportConfig{
+			Workers:    parseIntOrDefault(v.GetString("EXPORT_WORKERS"), 2),
+			Timeout:    parseDurationOrDefault(v.GetString("EXPORT_TIMEOUT"), 30*time.Minute),
+			Retention:  parseDurationOrDefault(v.GetString("EXPORT_RETENTION"), 24*time.Hour),
+			URLExpiry:  parseDurationOrDefault(v.GetString("EXPORT_URL_EXPIRY"), 15*time.Minute),
+			MaxPending: parseIntOrDefault(v.GetString("EXPORT_MAX_PENDING"), 3),
+		},
+		Client: ClientConfig{
+			Enabled: parseBoolOrDefault(v.GetString("CLIENT_TRACKING"), true),
+			Header:  getEnvWithDefault(v, "CLIENT_ID_HEADER", "X-Client-Id"),
+			Known:   parseListOrDefault(v.GetString("CLIENT_IDS"), nil),
+		},
+		WebAuthn: WebAuthnConfig{
+			RPID:         v.GetString("WEBAUTHN_RP_ID"),
+			RPName:       getEnvWithDefault(v, "WEBAUTHN_RP_NAME", "Go Platform Template"),
+			Origins:      parseListOrDefault(v.GetString("WEBAUTHN_ORIGINS"), []string{"http://localhost:8080"}),
+			ChallengeTTL: parseDurationOrDefault(v.GetString("WEBAUTHN_CHALLENGE_TTL"), 5*time.Minute),
+		},
+		OIDC: OIDCConfig{
+			Issuer:         strings.TrimRight(getEnvWithDefault(v, "OIDC_ISSUER", "http://localhost:8080"), "/"),
+			SigningKeyFile: v.GetString("OIDC_SIGNING_KEY_FILE"),
+			Clients:        oidcClients,
+			CodeTTL:        parseDurationOrDefault(v.GetString("OIDC_CODE_TTL"), time.Minute),
+			TokenTTL:       parseDurationOrDefault(v.GetString("OIDC_TOKEN_TTL"), 15*time.Minute),
+		},
+		Localization: LocalizationConfig{
+			DefaultLocale: defaultLocale,
+			TimestampMode: timestampMode,
+		},
+	}, nil
 }
 
 func GetConfig() *Config {
@@ -161,8 +1120,8 @@ func GetConfig() *Config {
 	return appConfig
 }
 
-func getEnvWithDefault(key, defaultVal string) string {
-	val := viper.GetString(key)
+func getEnvWithDefault(v source, key, defaultVal string) string {
+	val := v.GetString(key)
 	if val == "" {
 		return defaultVal
 	}
@@ -179,6 +1138,193 @@ func parseDurationOrDefault(val string, def time.Duration) time.Duration {
 	return def
 }
 
+func parseBoolOrDefault(val string, def bool) bool {
+	if val == "" {
+		return def
+	}
+	if b, err := strconv.ParseBool(val); err == nil {
+		return b
+	}
+	return def
+}
+
+// parseIntOrDefault returns def only for an empty or invalid value, so an explicit 0 is kept
+func parseIntOrDefault(val string, def int) int {
+	if val == "" {
+		return def
+	}
+	if n, err := strconv.Atoi(val); err == nil {
+		return n
+	}
+	return def
+}
+
+// parseListOrDefault splits a comma-separated value, dropping empty entries
+func parseListOrDefault(val string, def []string) []string {
+	var items []string
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		return def
+	}
+	return items
+}
+
+// parseRoleHierarchy reads comma separated chains of roles, each inheriting the next:
+// "admin>moderator>user,support>user" makes admin inherit moderator, and moderator and
+// support inherit user
+func parseRoleHierarchy(val string) (map[string][]string, error) {
+	hierarchy := map[string][]string{}
+	for _, chain := range parseListOrDefault(val, nil) {
+		roles := strings.Split(chain, ">")
+		for i := range roles {
+			roles[i] = strings.TrimSpace(roles[i])
+			if roles[i] == "" {
+				return nil, fmt.Errorf("invalid ROLE_HIERARCHY chain %q: empty role name", chain)
+			}
+		}
+		for i := 0; i+1 < len(roles); i++ {
+			if !slices.Contains(hierarchy[roles[i]], roles[i+1]) {
+				hierarchy[roles[i]] = append(hierarchy[roles[i]], roles[i+1])
+			}
+		}
+	}
+	return hierarchy, nil
+}
+
+// parseQuotas reads comma separated role=limit pairs such as "user=1000,partner=50000,*=100".
+// Roles with a limit of 0 are left out, so they are unlimited.
+func parseQuotas(name, val string) (map[string]int64, error) {
+	quotas := map[string]int64{}
+	for _, entry := range parseListOrDefault(val, nil) {
+		role, raw, ok := strings.Cut(entry, "=")
+		role = strings.TrimSpace(role)
+		limit, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+		if !ok || role == "" || err != nil || limit < 0 {
+			return nil, fmt.Errorf("invalid %s entry %q: want role=limit", name, entry)
+		}
+		if limit > 0 {
+			quotas[role] = limit
+		}
+	}
+	return quotas, nil
+}
+
+// parseStorageConfig reads STORAGE_DRIVER (formerly STORAGE_BACKEND) and the settings of
+// the backends that do not use the MINIO_* variables
+func parseStorageConfig(v source, jwtSigningKey string) (StorageConfig, error) {
+	cfg := StorageConfig{
+		Backend:        strings.ToLower(getEnvWithDefault(v, "STORAGE_DRIVER", getEnvWithDefault(v, "STORAGE_BACKEND", StorageMinIO))),
+		Region:         v.GetString("STORAGE_REGION"),
+		LocalDir:       getEnvWithDefault(v, "STORAGE_LOCAL_DIR", "./data/storage"),
+		LocalURL:       strings.TrimSuffix(getEnvWithDefault(v, "STORAGE_LOCAL_URL", "/api/v1/files/raw"), "/"),
+		LocalSecret:    getEnvWithDefault(v, "STORAGE_LOCAL_SECRET", jwtSigningKey),
+		AzureAccount:   v.GetString("AZURE_STORAGE_ACCOUNT"),
+		AzureKey:       v.GetString("AZURE_STORAGE_KEY"),
+		AzureContainer: v.GetString("AZURE_STORAGE_CONTAINER"),
+		AzureEndpoint:  strings.TrimSuffix(v.GetString("AZURE_STORAGE_ENDPOINT"), "/"),
+	}
+	switch cfg.Backend {
+	case StorageMinIO, StorageS3, StorageGCS, StorageLocal:
+	case StorageAzure:
+		if cfg.AzureAccount == "" || cfg.AzureKey == "" {
+			return cfg, fmt.Errorf("STORAGE_DRIVER=azure requires AZURE_STORAGE_ACCOUNT and AZURE_STORAGE_KEY")
+		}
+		if _, err := base64.StdEncoding.DecodeString(cfg.AzureKey); err != nil {
+			return cfg, fmt.Errorf("AZURE_STORAGE_KEY must be base64: %w", err)
+		}
+	default:
+		return cfg, fmt.Errorf("invalid STORAGE_DRIVER %q: want minio, s3, gcs, azure or local", cfg.Backend)
+	}
+	return cfg, nil
+}
+
+// parseThumbnailSizes reads comma separated edge lengths in pixels such as "128,512"
+// and returns them smallest first; "off" turns thumbnails off
+func parseThumbnailSizes(val string) ([]int, error) {
+	if strings.EqualFold(strings.TrimSpace(val), "off") {
+		return nil, nil
+	}
+	var sizes []int
+	for _, entry := range parseListOrDefault(val, nil) {
+		size, err := strconv.Atoi(entry)
+		if err != nil || size < 16 || size > 4096 {
+			return nil, fmt.Errorf("invalid FILE_THUMBNAIL_SIZES entry %q: want a size between 16 and 4096 pixels", entry)
+		}
+		sizes = append(sizes, size)
+	}
+	slices.Sort(sizes)
+	return slices.Compact(sizes), nil
+}
+
+// parseTLSConfig reads the TLS settings. MTLS_SUBJECTS lists "cn=user:<uuid>" and
+// "cn=service:<name>[:<role>]" entries separated by commas; service accounts get the
+// role "service" unless one is given.
+func parseTLSConfig(v source) (TLSConfig, error) {
+	cfg := TLSConfig{
+		CertFile:         v.GetString("TLS_CERT_FILE"),
+		KeyFile:          v.GetString("TLS_KEY_FILE"),
+		ClientCAFile:     v.GetString("MTLS_CLIENT_CA_FILE"),
+		ClientCertRoutes: parseListOrDefault(v.GetString("MTLS_ROUTES"), nil),
+		ClientSubjects:   map[string]ClientSubject{},
+	}
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		return TLSConfig{}, fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if cfg.ClientCAFile != "" && cfg.CertFile == "" {
+		return TLSConfig{}, fmt.Errorf("MTLS_CLIENT_CA_FILE needs TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+	if len(cfg.ClientCertRoutes) > 0 && cfg.ClientCAFile == "" {
+		return TLSConfig{}, fmt.Errorf("MTLS_ROUTES needs MTLS_CLIENT_CA_FILE")
+	}
+	for _, entry := range parseListOrDefault(v.GetString("MTLS_SUBJECTS"), nil) {
+		cn, identity, ok := strings.Cut(entry, "=")
+		cn = strings.TrimSpace(cn)
+		parts := strings.Split(strings.TrimSpace(identity), ":")
+		if !ok || cn == "" || len(parts) < 2 || parts[1] == "" {
+			return TLSConfig{}, fmt.Errorf("invalid MTLS_SUBJECTS entry %q: use cn=user:<id> or cn=service:<name>[:<role>]", entry)
+		}
+		subject := ClientSubject{Kind: parts[0], ID: parts[1]}
+		switch {
+		case subject.Kind == ClientSubjectUser && len(parts) == 2:
+		case subject.Kind == ClientSubjectService && len(parts) == 2:
+			subject.Role = "service"
+		case subject.Kind == ClientSubjectService && len(parts) == 3 && parts[2] != "":
+			subject.Role = parts[2]
+		default:
+			return TLSConfig{}, fmt.Errorf("invalid MTLS_SUBJECTS entry %q: use cn=user:<id> or cn=service:<name>[:<role>]", entry)
+		}
+		cfg.ClientSubjects[cn] = subject
+	}
+	return cfg, nil
+}
+
+var oidcProviderNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// validOIDCLoginProviders drops providers that are incomplete or whose name is taken,
+// logging why, so one bad entry does not disable the others
+func validOIDCLoginProviders(providers []OIDCLoginProvider) []OIDCLoginProvider {
+	seen := map[string]bool{"google": true, "github": true}
+	var valid []OIDCLoginProvider
+	for _, p := range providers {
+		switch {
+		case !oidcProviderNamePattern.MatchString(p.Name):
+			log.Printf("[WARN] OAUTH_OIDC_PROVIDERS: invalid name %q, use lowercase letters, digits and dashes", p.Name)
+		case seen[p.Name]:
+			log.Printf("[WARN] OAUTH_OIDC_PROVIDERS: name %q is already in use", p.Name)
+		case p.Issuer == "" || p.ClientID == "":
+			log.Printf("[WARN] OAUTH_OIDC_PROVIDERS: %q needs an issuer and a client_id", p.Name)
+		default:
+			seen[p.Name] = true
+			valid = append(valid, p)
+		}
+	}
+	return valid
+}
+
 func generateRandomKey() string {
 	b := make([]byte, 32)
 	if _, err := rand.Read(b); err != nil {
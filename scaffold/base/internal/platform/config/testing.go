@@ -0,0 +1,35 @@
+package config
+
+// mapSource serves configuration values from a map instead of the environment
+type mapSource map[string]string
+
+func (m mapSource) GetString(key string) string {
+	return m[key]
+}
+
+// testDefaults are what NewForTest sets on top of the .env.example defaults: fixed JWT
+// keys instead of random ones, Gin's test mode and logs on stdout only
+var testDefaults = mapSource{
+	"JWT_SIGNING_KEY": "test-signing-key-must-be-long-enough-for-jwt",
+	"JWT_REFRESH_KEY": "test-refresh-key-must-be-long-enough",
+	"GIN_MODE":        "test",
+	"LOG_OUTPUT":      "stdout",
+}
+
+// NewForTest returns a fully populated Config with the documented defaults and fixed JWT
+// keys, then applies overrides in order:
+//
+//	cfg := config.NewForTest(func(c *config.Config) {
+//		c.LoginLimit.MaxFailures = 3
+//	})
+//
+// It reads neither the environment nor .env and leaves the Config kept by LoadConfig
+// alone. Every call returns a new value, so tests can change it and run in parallel.
+func NewForTest(overrides ...func(*Config)) *Config {
+	// Cannot fail: testDefaults sets no DATABASE_URL or key pair algorithm, and a valid LOG_OUTPUT
+	cfg, _ := fromSource(testDefaults)
+	for _, override := range overrides {
+		override(cfg)
+	}
+	return cfg
+}
@@ -0,0 +1,264 @@
+package database
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	authModel "github.com/MurtadaNazar/go-api-template/internal/domain/auth/model"
+	fileModel "github.com/MurtadaNazar/go-api-template/internal/domain/file/model"
+	userModel "github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// AnonymizeOptions controls Anonymize
+type AnonymizeOptions struct {
+	// Salt keys the generated values. The same salt maps a value to the same fake on
+	// every run, so related columns (a user's email and their OAuth identity) still match.
+	Salt string
+	// Password becomes every user's password; when empty nobody can sign in with a password
+	Password string
+	// BatchSize is the number of rows read at a time (default 500)
+	BatchSize int
+	// DryRun reports what would change and rolls everything back
+	DryRun bool
+}
+
+// AnonymizeReport counts the rows Anonymize rewrote or deleted
+type AnonymizeReport struct {
+	Users                  int64 `json:"users"`
+	OAuthIdentities        int64 `json:"oauth_identities"`
+	Files                  int64 `json:"files"`
+	RevisionsDeleted       int64 `json:"revisions_deleted"`
+	PasswordHistoryDeleted int64 `json:"password_history_deleted"`
+	NotesDeleted           int64 `json:"notes_deleted"`
+	SessionsDeleted        int64 `json:"sessions_deleted"`
+	DryRun                 bool  `json:"dry_run"`
+}
+
+var errDryRun = errors.New("dry run")
+
+// Anonymize replaces personal data in a non-production copy of the database with
+// deterministic fakes: user names, usernames, emails and phones, OAuth identities and
+// uploaded file names. User metadata is cleared, user and password history are deleted
+// because they hold old values, admin notes are deleted because they are free text, and
+// refresh tokens and one-time codes are deleted.
+// Everything runs in one transaction. Objects in file storage are not touched.
+func Anonymize(ctx context.Context, db *gorm.DB, opts AnonymizeOptions) (*AnonymizeReport, error) {
+	if opts.Salt == "" {
+		return nil, errors.New("a salt is required")
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 500
+	}
+	password := opts.Password
+	if password == "" {
+		random := make([]byte, 32)
+		if _, err := rand.Read(random); err != nil {
+			return nil, err
+		}
+		password = hex.EncodeToString(random)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	f := newFaker(opts.Salt)
+	report := &AnonymizeReport{DryRun: opts.DryRun}
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Session makes the unscoped handle safe to start several statements from
+		tx = tx.Unscoped().Session(&gorm.Session{})
+		if err := anonymizeUsers(tx, f, string(hash), opts.BatchSize, report); err != nil {
+			return fmt.Errorf("users: %w", err)
+		}
+		if err := anonymizeOAuthIdentities(tx, f, opts.BatchSize, report); err != nil {
+			return fmt.Errorf("oauth identities: %w", err)
+		}
+		if err := anonymizeFiles(tx, f, opts.BatchSize, report); err != nil {
+			return fmt.Errorf("files: %w", err)
+		}
+
+		all := tx.Session(&gorm.Session{AllowGlobalUpdate: true})
+		result := all.Delete(&userModel.UserRevision{})
+		if result.Error != nil {
+			return fmt.Errorf("user history: %w", result.Error)
+		}
+		report.RevisionsDeleted = result.RowsAffected
+		result = all.Delete(&userModel.PasswordHistory{})
+		if result.Error != nil {
+			return fmt.Errorf("password history: %w", result.Error)
+		}
+		report.PasswordHistoryDeleted = result.RowsAffected
+		result = all.Delete(&userModel.UserNote{})
+		if result.Error != nil {
+			return fmt.Errorf("notes: %w", result.Error)
+		}
+		report.NotesDeleted = result.RowsAffected
+		for _, session := range []interface{}{&authModel.RefreshToken{}, &authModel.OTPCode{}} {
+			result := all.Delete(session)
+			if result.Error != nil {
+				return fmt.Errorf("sessions: %w", result.Error)
+			}
+			report.SessionsDeleted += result.RowsAffected
+		}
+
+		if opts.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+	return report, nil
+}
+
+func anonymizeUsers(tx *gorm.DB, f faker, passwordHash string, batchSize int, report *AnonymizeReport) error {
+	var batch []userModel.User
+	return tx.Model(&userModel.User{}).
+		Select("id", "email", "username", "phone", "first_name", "second_name", "last_name").
+		FindInBatches(&batch, batchSize, func(_ *gorm.DB, _ int) error {
+			for _, u := range batch {
+				email := f.Email(u.Email)
+				updates := map[string]interface{}{
+					"email":            email,
+					"normalized_email": userModel.EmailNormalizer{}.Normalize(email),
+					"username":         f.Username(u.Username),
+					"first_name":       f.FirstName(u.ID.String()),
+					"last_name":        f.LastName(u.ID.String()),
+					"password":         passwordHash,
+					"metadata":         userModel.Metadata{},
+				}
+				if u.SecondName != "" {
+					updates["second_name"] = f.FirstName("second:" + u.ID.String())
+				}
+				if u.Phone != nil {
+					updates["phone"] = f.Phone(*u.Phone)
+				}
+				if err := tx.Model(&userModel.User{}).Where("id = ?", u.ID).UpdateColumns(updates).Error; err != nil {
+					return err
+				}
+				report.Users++
+			}
+			return nil
+		}).Error
+}
+
+func anonymizeOAuthIdentities(tx *gorm.DB, f faker, batchSize int, report *AnonymizeReport) error {
+	var batch []authModel.OAuthIdentity
+	return tx.Model(&authModel.OAuthIdentity{}).
+		Select("id", "provider", "subject", "email").
+		FindInBatches(&batch, batchSize, func(_ *gorm.DB, _ int) error {
+			for _, identity := range batch {
+				updates := map[string]interface{}{
+					"subject": f.token("subject", identity.Provider+":"+identity.Subject, 32),
+				}
+				if identity.Email != "" {
+					updates["email"] = f.Email(identity.Email)
+				}
+				if err := tx.Model(&authModel.OAuthIdentity{}).Where("id = ?", identity.ID).UpdateColumns(updates).Error; err != nil {
+					return err
+				}
+				report.OAuthIdentities++
+			}
+			return nil
+		}).Error
+}
+
+func anonymizeFiles(tx *gorm.DB, f faker, batchSize int, report *AnonymizeReport) error {
+	var batch []fileModel.File
+	return tx.Model(&fileModel.File{}).
+		Select("id", "original_name").
+		FindInBatches(&batch, batchSize, func(_ *gorm.DB, _ int) error {
+			for _, file := range batch {
+				err := tx.Model(&fileModel.File{}).Where("id = ?", file.ID).
+					UpdateColumn("original_name", f.FileName(file.OriginalName)).Error
+				if err != nil {
+					return err
+				}
+				report.Files++
+			}
+			return nil
+		}).Error
+}
+
+var (
+	fakeFirstNames = []string{
+		"Alex", "Amira", "Ben", "Carla", "Dana", "Elif", "Felix", "Grace", "Hassan", "Ines",
+		"Jonas", "Kenji", "Lena", "Malik", "Nora", "Omar", "Priya", "Quinn", "Rosa", "Sami",
+		"Tara", "Umar", "Vera", "Wei", "Yara", "Zane",
+	}
+	fakeLastNames = []string{
+		"Adams", "Baker", "Chen", "Diaz", "Evans", "Fischer", "Garcia", "Haddad", "Ito", "Jensen",
+		"Khan", "Lopez", "Meyer", "Novak", "Okafor", "Park", "Rossi", "Silva", "Tanaka", "Usman",
+		"Vargas", "Walsh", "Young", "Zhang",
+	}
+)
+
+// faker derives fake values from real ones with an HMAC, so the output is stable for a
+// salt but cannot be reversed without it
+type faker struct {
+	key []byte
+}
+
+func newFaker(salt string) faker {
+	return faker{key: []byte(salt)}
+}
+
+func (f faker) sum(kind, value string) []byte {
+	mac := hmac.New(sha256.New, f.key)
+	mac.Write([]byte(kind))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
+
+// token returns n hex characters (at most 64) derived from value
+func (f faker) token(kind, value string, n int) string {
+	return hex.EncodeToString(f.sum(kind, value))[:n]
+}
+
+func (f faker) pick(kind, value string, list []string) string {
+	return list[binary.BigEndian.Uint64(f.sum(kind, value))%uint64(len(list))]
+}
+
+func (f faker) Email(email string) string {
+	return "user-" + f.token("email", strings.ToLower(strings.TrimSpace(email)), 16) + "@example.com"
+}
+
+func (f faker) Username(username string) string {
+	return "user_" + f.token("username", strings.ToLower(username), 12)
+}
+
+func (f faker) FirstName(seed string) string {
+	return f.pick("first_name", seed, fakeFirstNames)
+}
+
+func (f faker) LastName(seed string) string {
+	return f.pick("last_name", seed, fakeLastNames)
+}
+
+// Phone returns an E.164 number in the +999 range, which is not assigned to any country
+func (f faker) Phone(phone string) string {
+	n := binary.BigEndian.Uint64(f.sum("phone", phone)) % 100_000_000_000
+	return fmt.Sprintf("+999%011d", n)
+}
+
+// FileName keeps the extension so content-type handling on staging still works
+func (f faker) FileName(name string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	if len(ext) > 10 {
+		ext = ""
+	}
+	return "file-" + f.token("file", name, 12) + ext
+}
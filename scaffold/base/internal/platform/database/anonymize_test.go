@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestFaker_IsDeterministicPerSalt(t *testing.T) {
+	a, b, other := newFaker("salt-1"), newFaker("salt-1"), newFaker("salt-2")
+
+	if a.Email("Jane@Example.org") != b.Email(" jane@example.org") {
+		t.Error("the same email with the same salt gave different fakes")
+	}
+	if a.Email("jane@example.org") == other.Email("jane@example.org") {
+		t.Error("different salts gave the same fake email")
+	}
+	if a.Email("jane@example.org") == a.Email("john@example.org") {
+		t.Error("different emails gave the same fake")
+	}
+	if a.FirstName("user-1") != b.FirstName("user-1") || a.LastName("user-1") != b.LastName("user-1") {
+		t.Error("names are not stable for a seed")
+	}
+}
+
+func TestFaker_Formats(t *testing.T) {
+	f := newFaker("salt")
+
+	if got := f.Email("jane@example.org"); !regexp.MustCompile(`^user-[0-9a-f]{16}@example\.com$`).MatchString(got) {
+		t.Errorf("Email() = %q", got)
+	}
+	if got := f.Username("jane_doe"); len(got) > 50 || !strings.HasPrefix(got, "user_") {
+		t.Errorf("Username() = %q, want a user_ prefix within the column size", got)
+	}
+	if got := f.Phone("+14155550123"); !regexp.MustCompile(`^\+999\d{11}$`).MatchString(got) {
+		t.Errorf("Phone() = %q, want an E.164 number in +999", got)
+	}
+	if got := f.FileName("Passport Scan.PDF"); !regexp.MustCompile(`^file-[0-9a-f]{12}\.pdf$`).MatchString(got) {
+		t.Errorf("FileName() = %q, want the extension kept", got)
+	}
+	if got := f.FileName("notes.verylongextension"); strings.Contains(got, "verylong") {
+		t.Errorf("FileName() = %q, want long extensions dropped", got)
+	}
+}
+
+func TestAnonymize_RequiresSalt(t *testing.T) {
+	if _, err := Anonymize(context.Background(), nil, AnonymizeOptions{}); err == nil {
+		t.Error("Anonymize() without a salt succeeded")
+	}
+}
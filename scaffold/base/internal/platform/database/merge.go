@@ -0,0 +1,88 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	activityModel "github.com/MurtadaNazar/go-api-template/internal/domain/activity/model"
+	authModel "github.com/MurtadaNazar/go-api-template/internal/domain/auth/model"
+	exportModel "github.com/MurtadaNazar/go-api-template/internal/domain/export/model"
+	fileModel "github.com/MurtadaNazar/go-api-template/internal/domain/file/model"
+	userModel "github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserMerger moves the records of one user to another across the tables of every domain
+type UserMerger struct {
+	db *gorm.DB
+}
+
+// NewUserMerger returns a UserMerger working on db
+func NewUserMerger(db *gorm.DB) *UserMerger {
+	return &UserMerger{db: db}
+}
+
+// MergeUsers moves the files, exports, activity feed entries, linked social logins, admin
+// notes and tags of sourceID to targetID and marks the source deleted with reason, all in one
+// transaction.
+// Passkeys, password history, change history and sessions stay with the source: they are
+// bound to its ID, and the deleted status already keeps it from signing in.
+func (m *UserMerger) MergeUsers(ctx context.Context, sourceID, targetID uuid.UUID, reason string) (*userModel.MergeReport, error) {
+	report := &userModel.MergeReport{}
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Soft-deleted files move too, so a restore lands with the target
+		tx = tx.Unscoped().Session(&gorm.Session{})
+		moves := []struct {
+			name  string
+			model interface{}
+			count *int64
+		}{
+			{"files", &fileModel.File{}, &report.Files},
+			{"exports", &exportModel.Export{}, &report.Exports},
+			{"activities", &activityModel.Activity{}, &report.Activities},
+			{"oauth identities", &authModel.OAuthIdentity{}, &report.OAuthIdentities},
+			{"notes", &userModel.UserNote{}, &report.Notes},
+		}
+		for _, move := range moves {
+			result := tx.Model(move.model).Where("user_id = ?", sourceID).UpdateColumn("user_id", targetID)
+			if result.Error != nil {
+				return fmt.Errorf("%s: %w", move.name, result.Error)
+			}
+			*move.count = result.RowsAffected
+		}
+
+		// A user has each tag once, so only the tags the target lacks are copied
+		result := tx.Exec(`INSERT INTO user_tags (user_id, tag, created_at)
+			SELECT ?, tag, created_at FROM user_tags WHERE user_id = ?
+			ON CONFLICT DO NOTHING`, targetID, sourceID)
+		if result.Error != nil {
+			return fmt.Errorf("tags: %w", result.Error)
+		}
+		report.Tags = result.RowsAffected
+		if err := tx.Where("user_id = ?", sourceID).Delete(&userModel.UserTag{}).Error; err != nil {
+			return fmt.Errorf("tags: %w", err)
+		}
+
+		result = tx.Model(&userModel.User{}).
+			Where("id = ? AND status <> ?", sourceID, userModel.StatusDeleted).
+			UpdateColumns(map[string]interface{}{
+				"status":        userModel.StatusDeleted,
+				"status_reason": reason,
+				"updated_at":    time.Now(),
+			})
+		if result.Error != nil {
+			return fmt.Errorf("source user: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("source user %s not found or already deleted", sourceID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
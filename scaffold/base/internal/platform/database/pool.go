@@ -0,0 +1,98 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// PoolStats is the JSON view of sql.DBStats reported by the health endpoint
+type PoolStats struct {
+	MaxOpen           int   `json:"max_open"`
+	Open              int   `json:"open"`
+	InUse             int   `json:"in_use"`
+	Idle              int   `json:"idle"`
+	WaitCount         int64 `json:"wait_count"`
+	WaitDurationMs    int64 `json:"wait_duration_ms"`
+	MaxIdleClosed     int64 `json:"max_idle_closed"`
+	MaxLifetimeClosed int64 `json:"max_lifetime_closed"`
+}
+
+// NewPoolStats converts sql.DBStats for JSON output
+func NewPoolStats(s sql.DBStats) PoolStats {
+	return PoolStats{
+		MaxOpen:           s.MaxOpenConnections,
+		Open:              s.OpenConnections,
+		InUse:             s.InUse,
+		Idle:              s.Idle,
+		WaitCount:         s.WaitCount,
+		WaitDurationMs:    s.WaitDuration.Milliseconds(),
+		MaxIdleClosed:     s.MaxIdleClosed,
+		MaxLifetimeClosed: s.MaxLifetimeClosed,
+	}
+}
+
+// WritePoolMetrics writes the pool statistics in the Prometheus text exposition format
+func WritePoolMetrics(w io.Writer, s sql.DBStats) error {
+	metrics := []struct {
+		name, kind, help string
+		value            float64
+	}{
+		{"db_pool_max_open_connections", "gauge", "Maximum number of open connections (DB_MAX_OPEN_CONNS).", float64(s.MaxOpenConnections)},
+		{"db_pool_open_connections", "gauge", "Established connections, in use and idle.", float64(s.OpenConnections)},
+		{"db_pool_in_use_connections", "gauge", "Connections currently in use.", float64(s.InUse)},
+		{"db_pool_idle_connections", "gauge", "Idle connections.", float64(s.Idle)},
+		{"db_pool_wait_count_total", "counter", "Total number of connections waited for.", float64(s.WaitCount)},
+		{"db_pool_wait_duration_seconds_total", "counter", "Total time blocked waiting for a new connection.", s.WaitDuration.Seconds()},
+		{"db_pool_max_idle_closed_total", "counter", "Connections closed due to DB_MAX_IDLE_CONNS.", float64(s.MaxIdleClosed)},
+		{"db_pool_max_lifetime_closed_total", "counter", "Connections closed due to DB_CONN_MAX_LIFETIME.", float64(s.MaxLifetimeClosed)},
+	}
+	for _, m := range metrics {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %g\n", m.name, m.help, m.name, m.kind, m.name, m.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// poolSaturationAdvice explains pool pressure between two samples, or returns "" when the
+// time spent waiting for connections stayed below threshold
+func poolSaturationAdvice(prev, cur sql.DBStats, threshold time.Duration) string {
+	waited := cur.WaitDuration - prev.WaitDuration
+	if waited < threshold {
+		return ""
+	}
+	waits := cur.WaitCount - prev.WaitCount
+	advice := fmt.Sprintf("%d requests waited %v in total for a database connection", waits, waited.Round(time.Millisecond))
+	if cur.MaxOpenConnections > 0 && cur.InUse >= cur.MaxOpenConnections {
+		return advice + fmt.Sprintf("; all %d connections are in use: raise DB_MAX_OPEN_CONNS if Postgres max_connections allows it, or shorten slow queries and transactions", cur.MaxOpenConnections)
+	}
+	return advice + "; look for slow queries or long transactions holding connections, and check DB_MAX_OPEN_CONNS against the request concurrency"
+}
+
+// StartPoolMonitor samples the pool every interval and logs a warning with advice when
+// requests spent at least threshold waiting for a connection since the last sample
+func StartPoolMonitor(db *sql.DB, interval, threshold time.Duration, log *zap.SugaredLogger) {
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		prev := db.Stats()
+		for range ticker.C {
+			cur := db.Stats()
+			if advice := poolSaturationAdvice(prev, cur, threshold); advice != "" {
+				log.Warnw("Database connection pool saturated: "+advice,
+					"max_open", cur.MaxOpenConnections,
+					"in_use", cur.InUse,
+					"wait_count", cur.WaitCount,
+					"wait_duration", cur.WaitDuration,
+				)
+			}
+			prev = cur
+		}
+	}()
+
+	log.Infof("Connection pool monitor started (interval: %v, wait threshold: %v)", interval, threshold)
+}
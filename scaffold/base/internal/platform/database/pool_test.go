@@ -0,0 +1,53 @@
+package database
+
+import (
+	"bytes"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPoolSaturationAdvice(t *testing.T) {
+	prev := sql.DBStats{MaxOpenConnections: 10, WaitCount: 4, WaitDuration: 200 * time.Millisecond}
+
+	quiet := prev
+	quiet.WaitCount, quiet.WaitDuration = 6, 600*time.Millisecond
+	if advice := poolSaturationAdvice(prev, quiet, time.Second); advice != "" {
+		t.Errorf("expected no advice below threshold, got %q", advice)
+	}
+
+	exhausted := prev
+	exhausted.InUse, exhausted.WaitCount, exhausted.WaitDuration = 10, 54, 3*time.Second
+	advice := poolSaturationAdvice(prev, exhausted, time.Second)
+	if !strings.Contains(advice, "50 requests waited 2.8s") || !strings.Contains(advice, "raise DB_MAX_OPEN_CONNS") {
+		t.Errorf("unexpected advice for an exhausted pool: %q", advice)
+	}
+
+	slow := prev
+	slow.InUse, slow.WaitCount, slow.WaitDuration = 3, 5, 2*time.Second
+	if advice := poolSaturationAdvice(prev, slow, time.Second); !strings.Contains(advice, "slow queries") {
+		t.Errorf("unexpected advice for a pool with free connections: %q", advice)
+	}
+}
+
+func TestWritePoolMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	stats := sql.DBStats{MaxOpenConnections: 25, InUse: 3, WaitCount: 7, WaitDuration: 1500 * time.Millisecond}
+
+	if err := WritePoolMetrics(&buf, stats); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{
+		"# TYPE db_pool_max_open_connections gauge",
+		"db_pool_max_open_connections 25",
+		"db_pool_in_use_connections 3",
+		"db_pool_wait_count_total 7",
+		"db_pool_wait_duration_seconds_total 1.5",
+	} {
+		if !strings.Contains(buf.String(), line+"\n") {
+			t.Errorf("metrics output missing %q:\n%s", line, buf.String())
+		}
+	}
+}
@@ -3,20 +3,20 @@ package database
 import (
 	"context"
 
-	authModel "go_platform_template/internal/domain/auth/model"
-	fileModel "go_platform_template/internal/domain/file/model"
-	userModel "go_platform_template/internal/domain/user/model"
+	activityModel "github.com/MurtadaNazar/go-api-template/internal/domain/activity/model"
+	announcementModel "github.com/MurtadaNazar/go-api-template/internal/domain/announcement/model"
+	authModel "github.com/MurtadaNazar/go-api-template/internal/domain/auth/model"
+	authzModel "github.com/MurtadaNazar/go-api-template/internal/domain/authz/model"
+	exportModel "github.com/MurtadaNazar/go-api-template/internal/domain/export/model"
+	fileModel "github.com/MurtadaNazar/go-api-template/internal/domain/file/model"
+	userModel "github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/requestid"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
-// ctxKey is used for passing request-scoped values
-type ctxKey string
-
-const requestIDKey ctxKey = "RequestID"
-
 // MigrateDB handles database migrations and ensures indexes are created
 func MigrateDB(db *gorm.DB, log *zap.SugaredLogger) error {
 	log.Info("Running database migrations...")
@@ -29,62 +29,117 @@ func MigrateDB(db *gorm.DB, log *zap.SugaredLogger) error {
 	// Auto-migrate all models
 	if err := db.AutoMigrate(
 		&userModel.User{},
+		&userModel.ProfileField{},
+		&userModel.UserRevision{},
+		&userModel.PasswordHistory{},
+		&userModel.UserTag{},
+		&userModel.UserNote{},
+		&userModel.StatusChange{},
+		&activityModel.Activity{},
 		&authModel.RefreshToken{},
+		&authModel.OTPCode{},
+		&authModel.OAuthIdentity{},
+		&authModel.WebAuthnCredential{},
+		&authModel.WebAuthnChallenge{},
+		&authModel.ServiceClient{},
+		&authModel.ActionToken{},
+		&authzModel.Permission{},
+		&authzModel.Role{},
 		&fileModel.File{},
+		&fileModel.Upload{},
+		&fileModel.Folder{},
+		&fileModel.ShareLink{},
+		&exportModel.Export{},
+		&announcementModel.Announcement{},
+		&announcementModel.Delivery{},
 	); err != nil {
 		return err
 	}
 
 	log.Info("Database migration completed successfully.")
 
+	if err := dropSupersededIndexes(db); err != nil {
+		return err
+	}
+
 	// Create functional index (case-insensitive username search)
 	if err := userModel.CreateFunctionalIndexes(db); err != nil {
 		return err
 	}
 
+	if err := userModel.CreateEmailIndex(db); err != nil {
+		log.Warnw("case-insensitive email index not created; resolve accounts whose emails differ only in case",
+			"query", "SELECT LOWER(email), COUNT(*) FROM users GROUP BY 1 HAVING COUNT(*) > 1", "error", err)
+	}
+
+	if err := userModel.CreateSearchIndex(db); err != nil {
+		log.Warnw("user search index not created, searches will scan the users table", "error", err)
+	}
+
 	log.Info("Functional indexes created successfully.")
 	return nil
 }
 
+// supersededIndexes are single-column indexes now covered by the leading column of a
+// composite index (idx_refresh_tokens_user_active, idx_files_user_type_uploaded,
+// idx_users_created_id)
+var supersededIndexes = []string{
+	"idx_refresh_tokens_user_id",
+	"idx_files_user_id",
+	"idx_users_created_at",
+}
+
+// dropSupersededIndexes removes indexes left behind by older schemas; AutoMigrate only adds
+func dropSupersededIndexes(db *gorm.DB) error {
+	for _, name := range supersededIndexes {
+		if err := db.Exec("DROP INDEX IF EXISTS " + name).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BackfillNormalizedEmails populates normalized_email for existing users.
+// Accounts that collide after normalization are logged and left for manual review.
+func BackfillNormalizedEmails(db *gorm.DB, foldGmail bool, log *zap.SugaredLogger) error {
+	conflicts, err := userModel.BackfillNormalizedEmails(db, userModel.EmailNormalizer{FoldGmail: foldGmail})
+	if err != nil {
+		return err
+	}
+	for _, id := range conflicts {
+		log.Warnw("user email collides with another account after normalization", "user_id", id)
+	}
+	return nil
+}
+
 // WithRequestLogger returns a SugaredLogger enriched with request_id
 // Extracts request ID from context using the proper custom key
 func WithRequestLogger(ctx context.Context, logger *zap.SugaredLogger) *zap.SugaredLogger {
-	// Try the custom key first
-	requestID, ok := ctx.Value(requestIDKey).(string)
-	if !ok || requestID == "" {
-		// Fallback to the string key
-		requestID, ok = ctx.Value("RequestID").(string)
-		if !ok || requestID == "" {
-			requestID = "unknown"
-		}
-	}
-	return logger.With("request_id", requestID)
+	return logger.With("request_id", ExtractRequestID(ctx))
 }
 
 // ExtractRequestID safely extracts request ID from context
 // Returns the request ID or "unknown" if not found
 func ExtractRequestID(ctx context.Context) string {
-	// Try the custom key first
-	if requestID, ok := ctx.Value(requestIDKey).(string); ok && requestID != "" {
+	// Set on the request context by RequestIDMiddleware
+	if requestID := requestid.FromContext(ctx); requestID != "" {
 		return requestID
 	}
-	// Fallback to the string key
+	// Fallback to the gin context key, for callers passing *gin.Context
 	if requestID, ok := ctx.Value("RequestID").(string); ok && requestID != "" {
 		return requestID
 	}
 	return "unknown"
 }
 
-// WithRequest returns a GORM DB instance with context containing request_id
-// Extracts request ID from Gin context and propagates it to database operations
+// WithRequest returns a GORM DB instance bound to the request context, so SQL logs carry
+// the request ID and queries stop when the client goes away. Repositories get the same
+// by calling db.WithContext(ctx) with the ctx handlers pass down from c.Request.Context().
 func WithRequest(c *gin.Context, db *gorm.DB) *gorm.DB {
-	// Get request ID from Gin context (set by RequestIDMiddleware)
-	requestID := c.GetString("RequestID")
-
-	// Create new context with request ID using the proper custom key
-	ctx := context.WithValue(c.Request.Context(), requestIDKey, requestID)
-
-	// Return DB instance with the enriched context
+	ctx := c.Request.Context()
+	if requestid.FromContext(ctx) == "" {
+		ctx = requestid.With(ctx, c.GetString("RequestID"))
+	}
 	return db.WithContext(ctx)
 }
 
@@ -0,0 +1,164 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	authModel "github.com/MurtadaNazar/go-api-template/internal/domain/auth/model"
+	authRepo "github.com/MurtadaNazar/go-api-template/internal/domain/auth/repo"
+	fileModel "github.com/MurtadaNazar/go-api-template/internal/domain/file/model"
+	fileRepo "github.com/MurtadaNazar/go-api-template/internal/domain/file/repo"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// BenchmarkHotQueries compares the refresh token and file-list queries before and after
+// the query tuning: GORM defaults with single-column user_id indexes versus prepared
+// statements, no implicit write transaction and the composite indexes. It needs a
+// scratch Postgres database (its refresh_tokens and files tables are replaced):
+//
+//	BENCH_DATABASE_DSN="host=localhost user=postgres password=postgres dbname=bench sslmode=disable" \
+//	  go test ./internal/platform/database -run '^$' -bench HotQueries
+//
+// BENCH_USERS sets how many users get 5 refresh tokens and 5 files each (default 20000).
+func BenchmarkHotQueries(b *testing.B) {
+	dsn := os.Getenv("BENCH_DATABASE_DSN")
+	if dsn == "" {
+		b.Skip("BENCH_DATABASE_DSN not set")
+	}
+	users := 20000
+	if v, err := strconv.Atoi(os.Getenv("BENCH_USERS")); err == nil && v > 0 {
+		users = v
+	}
+
+	setup, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		b.Fatal(err)
+	}
+	userIDs, tokens := seedHotQueryTables(b, setup, users)
+
+	ctx := context.Background()
+	for _, scenario := range []struct {
+		name      string
+		config    gorm.Config
+		composite bool
+	}{
+		{"before", gorm.Config{Logger: logger.Discard}, false},
+		{"after", gorm.Config{Logger: logger.Discard, PrepareStmt: true, SkipDefaultTransaction: true}, true},
+	} {
+		useCompositeIndexes(b, setup, scenario.composite)
+
+		cfg := scenario.config
+		db, err := gorm.Open(postgres.Open(dsn), &cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+		tRepo := authRepo.NewTokenRepo(db)
+		fRepo := fileRepo.NewFileRepo(db)
+
+		b.Run(scenario.name+"/FindByToken", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := tRepo.FindByToken(ctx, tokens[rand.Intn(len(tokens))]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(scenario.name+"/RevokeAllUserTokens", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := tRepo.RevokeAllUserTokens(ctx, userIDs[rand.Intn(len(userIDs))].String()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(scenario.name+"/ListFiles", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := fRepo.GetFilesByUserID(ctx, userIDs[rand.Intn(len(userIDs))].String()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		// Revoked tokens would make the next scenario's lookups fail
+		if err := setup.Exec("UPDATE refresh_tokens SET is_revoked = false").Error; err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// seedHotQueryTables recreates refresh_tokens and files with 5 rows per user each
+func seedHotQueryTables(b *testing.B, db *gorm.DB, users int) ([]uuid.UUID, []string) {
+	b.Helper()
+	if err := db.Exec(`CREATE EXTENSION IF NOT EXISTS "uuid-ossp"`).Error; err != nil {
+		b.Fatal(err)
+	}
+	if err := db.Migrator().DropTable(&authModel.RefreshToken{}, &fileModel.File{}); err != nil {
+		b.Fatal(err)
+	}
+	if err := db.AutoMigrate(&authModel.RefreshToken{}, &fileModel.File{}); err != nil {
+		b.Fatal(err)
+	}
+
+	userIDs := make([]uuid.UUID, users)
+	tokens := make([]string, 0, users*5)
+	refreshTokens := make([]authModel.RefreshToken, 0, users*5)
+	files := make([]fileModel.File, 0, users*5)
+	expires := time.Now().Add(24 * time.Hour)
+	for u := range userIDs {
+		userIDs[u] = uuid.New()
+		for i := 0; i < 5; i++ {
+			token := fmt.Sprintf("bench-token-%d-%d", u, i)
+			tokens = append(tokens, token)
+			refreshTokens = append(refreshTokens, authModel.RefreshToken{
+				Token: token, UserID: userIDs[u], Role: "user", ExpiresAt: expires,
+			})
+			fileType := fileModel.FileTypeCV
+			if i == 0 {
+				fileType = fileModel.FileTypeProfileImage
+			}
+			files = append(files, fileModel.File{
+				UserID: userIDs[u], Path: fmt.Sprintf("bench/%d/%d", u, i), Type: fileType,
+				Size: 1024, MimeType: "application/pdf", OriginalName: "bench.pdf",
+			})
+		}
+	}
+	if err := db.CreateInBatches(refreshTokens, 1000).Error; err != nil {
+		b.Fatal(err)
+	}
+	if err := db.CreateInBatches(files, 1000).Error; err != nil {
+		b.Fatal(err)
+	}
+	return userIDs, tokens
+}
+
+// useCompositeIndexes switches between the old single-column user_id indexes and the
+// composite indexes created by the models
+func useCompositeIndexes(b *testing.B, db *gorm.DB, composite bool) {
+	b.Helper()
+	statements := []string{
+		"DROP INDEX IF EXISTS idx_refresh_tokens_user_active",
+		"DROP INDEX IF EXISTS idx_files_user_type_uploaded",
+		"CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens (user_id)",
+		"CREATE INDEX IF NOT EXISTS idx_files_user_id ON files (user_id)",
+	}
+	if composite {
+		statements = []string{
+			"DROP INDEX IF EXISTS idx_refresh_tokens_user_id",
+			"DROP INDEX IF EXISTS idx_files_user_id",
+			"CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_active ON refresh_tokens (user_id, is_revoked, expires_at)",
+			"CREATE INDEX IF NOT EXISTS idx_files_user_type_uploaded ON files (user_id, type, uploaded_at)",
+		}
+	}
+	for _, statement := range append(statements, "ANALYZE refresh_tokens", "ANALYZE files") {
+		if err := db.Exec(statement).Error; err != nil {
+			b.Fatal(err)
+		}
+	}
+}
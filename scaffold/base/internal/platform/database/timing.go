@@ -0,0 +1,50 @@
+package database
+
+import (
+	"time"
+
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/timing"
+
+	"gorm.io/gorm"
+)
+
+// queryStartKey holds a statement's start time between the timing callbacks
+const queryStartKey = "timing:query_start"
+
+// RegisterQueryTiming adds GORM callbacks that count every statement run with a request
+// context in the request's "db" timing segment (see middleware.ServerTimingMiddleware).
+// Statements outside such requests only pay for one context lookup.
+func RegisterQueryTiming(db *gorm.DB) error {
+	before := func(tx *gorm.DB) {
+		if timing.FromContext(tx.Statement.Context) != nil {
+			tx.InstanceSet(queryStartKey, time.Now())
+		}
+	}
+	after := func(tx *gorm.DB) {
+		recorder := timing.FromContext(tx.Statement.Context)
+		if recorder == nil {
+			return
+		}
+		if start, ok := tx.InstanceGet(queryStartKey); ok {
+			recorder.Add("db", time.Since(start.(time.Time)))
+		}
+	}
+
+	cb := db.Callback()
+	registrations := []error{
+		cb.Create().Before("gorm:create").Register("timing:before_create", before),
+		cb.Create().After("gorm:create").Register("timing:after_create", after),
+		cb.Query().Before("gorm:query").Register("timing:before_query", before),
+		cb.Query().After("gorm:query").Register("timing:after_query", after),
+		cb.Update().Before("gorm:update").Register("timing:before_update", before),
+		cb.Update().After("gorm:update").Register("timing:after_update", after),
+		cb.Delete().Before("gorm:delete").Register("timing:before_delete", before),
+		cb.Delete().After("gorm:delete").Register("timing:after_delete", after),
+		cb.Row().Before("gorm:row").Register("timing:before_row", before),
+		cb.Row().After("gorm:row").Register("timing:after_row", after),
+		cb.Raw().Before("gorm:raw").Register("timing:before_raw", before),
+		cb.Raw().After("gorm:raw").Register("timing:after_raw", after),
+	}
+	return apperrors.Join(registrations...)
+}
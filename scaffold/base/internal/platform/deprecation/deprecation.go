@@ -0,0 +1,200 @@
+// Package deprecation announces endpoints that are going away and records which clients
+// still call them, so they can be contacted before the sunset date. Routes are marked
+// with middleware.Deprecated; the report is served at GET /admin/deprecations.
+package deprecation
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/shared/clock"
+
+	"go.uber.org/zap"
+)
+
+// maxClientsPerRoute bounds the clients tracked for one route; later ones are counted
+// together under OtherClients
+const maxClientsPerRoute = 1000
+
+// OtherClients collects calls from clients beyond maxClientsPerRoute
+const OtherClients = "other"
+
+// maxUserAgentLength bounds the user agent kept per client
+const maxUserAgentLength = 200
+
+// logInterval is how often a client's calls to the same route are logged again
+const logInterval = time.Hour
+
+// Notice describes a deprecated endpoint
+type Notice struct {
+	// Since is when the endpoint was deprecated, sent in the Deprecation header (RFC 9745)
+	Since time.Time
+	// Sunset is when the endpoint stops working, sent in the Sunset header (RFC 8594);
+	// zero while no date is set
+	Sunset time.Time
+	// Link points to the replacement or a migration guide, sent as Link rel="deprecation"
+	Link string
+}
+
+// SetHeaders writes the notice to h
+func (n Notice) SetHeaders(h http.Header) {
+	h.Set("Deprecation", "@"+strconv.FormatInt(n.Since.Unix(), 10))
+	if !n.Sunset.IsZero() {
+		h.Set("Sunset", n.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if n.Link != "" {
+		h.Add("Link", "<"+n.Link+`>; rel="deprecation"; type="text/html"`)
+	}
+}
+
+// Call is one request to a deprecated route
+type Call struct {
+	// Route is the method and route pattern, e.g. "GET /api/v1/users/:id"
+	Route string
+	// Client identifies the caller, e.g. "user:<id>" or "ip:203.0.113.7"
+	Client string
+	// App is the calling application as "id/version" (see clientid), or ""
+	App       string
+	UserAgent string
+	RequestID string
+}
+
+// Usage is one client's calls to a deprecated route on this instance
+type Usage struct {
+	Client    string    `json:"client"`
+	App       string    `json:"app,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	Calls     int       `json:"calls"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// Endpoint is a deprecated route and the clients that called it, busiest first
+type Endpoint struct {
+	Route      string     `json:"route"`
+	Deprecated time.Time  `json:"deprecated"`
+	Sunset     *time.Time `json:"sunset,omitempty"`
+	Link       string     `json:"link,omitempty"`
+	Calls      int        `json:"calls"`
+	Clients    []Usage    `json:"clients"`
+}
+
+type clientState struct {
+	usage    Usage
+	loggedAt time.Time
+}
+
+type routeState struct {
+	notice  Notice
+	calls   int
+	clients map[string]*clientState
+}
+
+// Tracker counts calls to deprecated routes per client. Counts are kept in memory since
+// startup, so each instance reports its own share of the traffic.
+type Tracker struct {
+	mu     sync.Mutex
+	routes map[string]*routeState
+	log    *zap.SugaredLogger
+	clock  clock.Clock
+}
+
+// NewTracker returns an empty tracker
+func NewTracker(log *zap.SugaredLogger) *Tracker {
+	if log == nil {
+		log = zap.NewNop().Sugar()
+	}
+	return &Tracker{routes: make(map[string]*routeState), log: log, clock: clock.System()}
+}
+
+// SetClock replaces the clock used to stamp calls
+func (t *Tracker) SetClock(c clock.Clock) {
+	t.clock = c
+}
+
+// Record counts call and logs a warning the first time its client calls the route, and
+// at most hourly after that
+func (t *Tracker) Record(notice Notice, call Call) {
+	now := t.clock.Now()
+
+	t.mu.Lock()
+	route, ok := t.routes[call.Route]
+	if !ok {
+		route = &routeState{clients: make(map[string]*clientState)}
+		t.routes[call.Route] = route
+	}
+	route.notice = notice
+	route.calls++
+
+	key := call.Client
+	if _, known := route.clients[key]; !known && len(route.clients) >= maxClientsPerRoute {
+		key = OtherClients
+	}
+	client, known := route.clients[key]
+	if !known {
+		client = &clientState{usage: Usage{Client: key, FirstSeen: now}}
+		route.clients[key] = client
+	}
+	client.usage.Calls++
+	client.usage.LastSeen = now
+	client.usage.App = call.App
+	client.usage.UserAgent = call.UserAgent
+	if len(client.usage.UserAgent) > maxUserAgentLength {
+		client.usage.UserAgent = client.usage.UserAgent[:maxUserAgentLength]
+	}
+	shouldLog := client.loggedAt.IsZero() || now.Sub(client.loggedAt) >= logInterval
+	if shouldLog {
+		client.loggedAt = now
+	}
+	calls := client.usage.Calls
+	t.mu.Unlock()
+
+	if shouldLog {
+		t.log.Warnw("deprecated endpoint called",
+			"route", call.Route,
+			"client", call.Client,
+			"app", call.App,
+			"user_agent", call.UserAgent,
+			"calls", calls,
+			"sunset", notice.Sunset,
+			"past_sunset", !notice.Sunset.IsZero() && !now.Before(notice.Sunset),
+			"request_id", call.RequestID,
+		)
+	}
+}
+
+// Report lists the deprecated routes called since startup, by route
+func (t *Tracker) Report() []Endpoint {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	report := make([]Endpoint, 0, len(t.routes))
+	for name, route := range t.routes {
+		endpoint := Endpoint{
+			Route:      name,
+			Deprecated: route.notice.Since,
+			Link:       route.notice.Link,
+			Calls:      route.calls,
+			Clients:    make([]Usage, 0, len(route.clients)),
+		}
+		if !route.notice.Sunset.IsZero() {
+			sunset := route.notice.Sunset
+			endpoint.Sunset = &sunset
+		}
+		for _, client := range route.clients {
+			endpoint.Clients = append(endpoint.Clients, client.usage)
+		}
+		sort.Slice(endpoint.Clients, func(i, j int) bool {
+			if endpoint.Clients[i].Calls != endpoint.Clients[j].Calls {
+				return endpoint.Clients[i].Calls > endpoint.Clients[j].Calls
+			}
+			return endpoint.Clients[i].Client < endpoint.Clients[j].Client
+		})
+		report = append(report, endpoint)
+	}
+	sort.Slice(report, func(i, j int) bool { return report[i].Route < report[j].Route })
+	return report
+}
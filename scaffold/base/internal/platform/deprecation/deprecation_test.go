@@ -0,0 +1,107 @@
+package deprecation
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+var testNotice = Notice{
+	Since:  time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+	Sunset: time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC),
+	Link:   "https://docs.example.com/migrations/profile",
+}
+
+func TestNotice_SetHeaders(t *testing.T) {
+	// Arrange
+	h := http.Header{}
+
+	// Act
+	testNotice.SetHeaders(h)
+
+	// Assert
+	if got, want := h.Get("Deprecation"), "@"+strconv.FormatInt(testNotice.Since.Unix(), 10); got != want {
+		t.Errorf("Deprecation = %q, want %q", got, want)
+	}
+	if got := h.Get("Sunset"); got != "Mon, 01 Sep 2025 00:00:00 GMT" {
+		t.Errorf("Sunset = %q", got)
+	}
+	if got := h.Get("Link"); got != `<https://docs.example.com/migrations/profile>; rel="deprecation"; type="text/html"` {
+		t.Errorf("Link = %q", got)
+	}
+}
+
+func TestTracker_Report(t *testing.T) {
+	// Arrange
+	clk := testutil.NewFakeClock(time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC))
+	tracker := NewTracker(nil)
+	tracker.SetClock(clk)
+	route := "GET /api/v1/users/:id/profile"
+
+	// Act
+	tracker.Record(testNotice, Call{Route: route, Client: "user:a", UserAgent: "app/1.0"})
+	clk.Advance(time.Minute)
+	tracker.Record(testNotice, Call{Route: route, Client: "user:a", UserAgent: "app/1.1"})
+	tracker.Record(testNotice, Call{Route: route, Client: "ip:203.0.113.7"})
+	report := tracker.Report()
+
+	// Assert
+	if len(report) != 1 || report[0].Route != route || report[0].Calls != 3 {
+		t.Fatalf("report = %+v, want one route with 3 calls", report)
+	}
+	if report[0].Sunset == nil || !report[0].Sunset.Equal(testNotice.Sunset) {
+		t.Errorf("sunset = %v, want %v", report[0].Sunset, testNotice.Sunset)
+	}
+	clients := report[0].Clients
+	if len(clients) != 2 || clients[0].Client != "user:a" || clients[0].Calls != 2 {
+		t.Fatalf("clients = %+v, want user:a first with 2 calls", clients)
+	}
+	if clients[0].UserAgent != "app/1.1" || !clients[0].LastSeen.After(clients[0].FirstSeen) {
+		t.Errorf("user:a = %+v, want the latest user agent and a later last_seen", clients[0])
+	}
+}
+
+func TestTracker_LogsOncePerClientPerInterval(t *testing.T) {
+	// Arrange
+	core, logs := observer.New(zap.WarnLevel)
+	clk := testutil.NewFakeClock(time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC))
+	tracker := NewTracker(zap.New(core).Sugar())
+	tracker.SetClock(clk)
+	call := Call{Route: "GET /api/v1/legacy", Client: "user:a"}
+
+	// Act
+	tracker.Record(testNotice, call)
+	tracker.Record(testNotice, call)
+	tracker.Record(testNotice, Call{Route: call.Route, Client: "user:b"})
+	clk.Advance(logInterval)
+	tracker.Record(testNotice, call)
+
+	// Assert
+	if got := logs.FilterMessage("deprecated endpoint called").Len(); got != 3 {
+		t.Errorf("logged %d warnings, want 3 (first call per client, then after the interval)", got)
+	}
+}
+
+func TestTracker_BoundsClients(t *testing.T) {
+	// Arrange
+	tracker := NewTracker(nil)
+	route := "GET /api/v1/legacy"
+
+	// Act
+	for i := 0; i < maxClientsPerRoute+5; i++ {
+		tracker.Record(testNotice, Call{Route: route, Client: "ip:" + strconv.Itoa(i)})
+	}
+	report := tracker.Report()
+
+	// Assert
+	clients := report[0].Clients
+	if len(clients) != maxClientsPerRoute+1 || clients[0].Client != OtherClients || clients[0].Calls != 5 {
+		t.Errorf("%d clients, busiest %+v; want %d plus %q with 5 calls", len(clients), clients[0], maxClientsPerRoute, OtherClients)
+	}
+}
@@ -0,0 +1,66 @@
+// Package events is a small in-process hook for domain events. Services publish what
+// happened to a user, and subscribers such as the activity feed react to it without the
+// publishing service knowing about them.
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// Event types
+const (
+	ProfileUpdated  = "profile.updated"
+	PasswordChanged = "password.changed"
+	FileUploaded    = "file.uploaded"
+	// AccountAnonymized is published after a deleted account was anonymized, so
+	// subscribers can drop what they keep about the user
+	AccountAnonymized = "account.anonymized"
+)
+
+// Event is something that happened to a user. Who caused it is the actor in the
+// context passed along with it.
+type Event struct {
+	Type string
+	// UserID is the user the event is about
+	UserID string
+	Data   map[string]any
+}
+
+// Handler reacts to an event. Handlers run in the publisher's goroutine, so they should
+// be quick, and they log their own errors: the change the event describes has already
+// happened.
+type Handler func(ctx context.Context, event Event)
+
+// Bus passes published events to every subscriber. A nil Bus drops events, so services
+// can publish unconditionally.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []Handler
+}
+
+// NewBus returns a Bus without subscribers
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe calls h for every event published from now on
+func (b *Bus) Subscribe(h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, h)
+}
+
+// Publish calls the subscribers with event, in the order they subscribed, and returns
+// once all of them did
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	if b == nil {
+		return
+	}
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+	for _, h := range handlers {
+		h(ctx, event)
+	}
+}
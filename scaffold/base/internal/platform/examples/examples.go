@@ -0,0 +1,264 @@
+// Package examples builds example JSON payloads from DTO structs, so the examples shown
+// next to the Swagger docs always match the code. Values come from `example:` struct tags
+// and, where a field has none, from its validate tag, name and type.
+package examples
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	uuidType = reflect.TypeOf(uuid.UUID{})
+	rawType  = reflect.TypeOf(json.RawMessage{})
+)
+
+// exampleTime and exampleID stand in for timestamps and IDs without an example tag
+var (
+	exampleTime = time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC).Format(time.RFC3339)
+	exampleID   = "0190a8c4-1f2b-7c3d-8e4f-5a6b7c8d9e0f"
+)
+
+// Generate returns an example of v's JSON form. Objects keep the field order of the
+// struct. v is usually the zero value of a DTO; only its type is used.
+func Generate(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	return generate(reflect.TypeOf(v), field{}, map[reflect.Type]bool{})
+}
+
+// field is what a struct field adds to the example of its type
+type field struct {
+	name     string
+	example  string
+	validate []string
+}
+
+func generate(t reflect.Type, f field, seen map[reflect.Type]bool) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if f.example != "" {
+		if value, ok := parseExample(t, f.example); ok {
+			return value
+		}
+	}
+
+	switch t {
+	case timeType:
+		return exampleTime
+	case uuidType:
+		return exampleID
+	case rawType:
+		return object{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return exampleString(f)
+	case reflect.Bool:
+		return true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(exampleNumber(f))
+	case reflect.Float32, reflect.Float64:
+		return exampleNumber(f)
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "aGVsbG8="
+		}
+		return []interface{}{generate(t.Elem(), field{name: f.name}, seen)}
+	case reflect.Map:
+		return object{{name: "key", value: generate(t.Elem(), field{}, seen)}}
+	case reflect.Struct:
+		return generateStruct(t, seen)
+	default:
+		return nil
+	}
+}
+
+func generateStruct(t reflect.Type, seen map[reflect.Type]bool) interface{} {
+	// A type containing itself ends in null rather than recursing forever
+	if seen[t] {
+		return nil
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	out := object{}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		// Embedded structs without a JSON name are flattened, as encoding/json does,
+		// even when their type is unexported
+		if sf.Anonymous && name == "" {
+			if embedded, ok := generate(sf.Type, field{}, seen).(object); ok {
+				out = append(out, embedded...)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		validate, _, _ := strings.Cut(sf.Tag.Get("validate"), ",dive")
+		out = append(out, member{name: name, value: generate(sf.Type, field{
+			name:     name,
+			example:  sf.Tag.Get("example"),
+			validate: strings.Split(validate, ","),
+		}, seen)})
+	}
+	return out
+}
+
+// parseExample reads an example tag for type t. Lists are comma-separated and maps are
+// key:value pairs, as swag reads them; JSON is accepted too.
+func parseExample(t reflect.Type, example string) (interface{}, bool) {
+	switch t.Kind() {
+	case reflect.String:
+		return example, true
+	case reflect.Bool:
+		value, err := strconv.ParseBool(example)
+		return value, err == nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value, err := strconv.ParseInt(example, 10, 64)
+		return value, err == nil
+	case reflect.Float32, reflect.Float64:
+		value, err := strconv.ParseFloat(example, 64)
+		return value, err == nil
+	}
+
+	var decoded interface{}
+	if json.Unmarshal([]byte(example), &decoded) == nil {
+		return decoded, true
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		var items []interface{}
+		for _, item := range strings.Split(example, ",") {
+			value, ok := parseExample(t.Elem(), strings.TrimSpace(item))
+			if !ok {
+				return nil, false
+			}
+			items = append(items, value)
+		}
+		return items, true
+	case reflect.Map:
+		out := object{}
+		for _, pair := range strings.Split(example, ",") {
+			key, raw, ok := strings.Cut(pair, ":")
+			if !ok {
+				return nil, false
+			}
+			value, ok := parseExample(t.Elem(), strings.TrimSpace(raw))
+			if !ok {
+				return nil, false
+			}
+			out = append(out, member{name: strings.TrimSpace(key), value: value})
+		}
+		return out, true
+	case reflect.Interface:
+		return example, true
+	}
+	return nil, false
+}
+
+// exampleString picks a value that passes the field's validate tag
+func exampleString(f field) string {
+	for _, rule := range f.validate {
+		tag, param, _ := strings.Cut(rule, "=")
+		switch tag {
+		case "email":
+			return "jane.doe@example.com"
+		case "e164":
+			return "+14155552671"
+		case "uuid", "uuid4", "uuid7":
+			return exampleID
+		case "url", "http_url", "uri":
+			return "https://example.com"
+		case "timezone":
+			return "Europe/Berlin"
+		case "bcp47_language_tag":
+			return "en-US"
+		case "numeric":
+			return "123456"
+		case "strong_password":
+			return "StrongP@ssw0rd"
+		case "eq":
+			return param
+		case "oneof":
+			first, _, _ := strings.Cut(param, " ")
+			return first
+		}
+	}
+	switch name := strings.ToLower(f.name); {
+	case name == "email" || strings.HasSuffix(name, "_email"):
+		return "jane.doe@example.com"
+	case name == "id" || strings.HasSuffix(name, "_id"):
+		return exampleID
+	case strings.HasSuffix(name, "_at"):
+		return exampleTime
+	case name == "url" || strings.HasSuffix(name, "_url"):
+		return "https://example.com"
+	}
+	return "string"
+}
+
+// exampleNumber is the field's minimum when it has one, otherwise 1
+func exampleNumber(f field) float64 {
+	for _, rule := range f.validate {
+		tag, param, _ := strings.Cut(rule, "=")
+		if tag == "min" || tag == "gte" {
+			if value, err := strconv.ParseFloat(param, 64); err == nil {
+				return value
+			}
+		}
+	}
+	return 1
+}
+
+// object is a JSON object that keeps its members in order
+type object []member
+
+type member struct {
+	name  string
+	value interface{}
+}
+
+// MarshalJSON writes the members in order
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(m.name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(m.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
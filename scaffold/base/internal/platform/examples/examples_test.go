@@ -0,0 +1,87 @@
+package examples
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type address struct {
+	City string `json:"city" example:"Berlin"`
+}
+
+type audit struct {
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type sample struct {
+	audit
+	ID       uuid.UUID         `json:"id"`
+	Name     string            `json:"name" example:"Ada"`
+	Email    string            `json:"email" validate:"required,email"`
+	Kind     string            `json:"kind" validate:"required,oneof=person company"`
+	Age      int               `json:"age" validate:"gte=18"`
+	Tags     []string          `json:"tags" example:"admin,staff"`
+	Labels   map[string]string `json:"labels" example:"team:core"`
+	Active   *bool             `json:"active" example:"true"`
+	Address  address           `json:"address"`
+	Parent   *sample           `json:"parent,omitempty"`
+	Internal string            `json:"-"`
+	secret   string
+}
+
+func TestGenerate(t *testing.T) {
+	// Act
+	data, err := json.Marshal(Generate(sample{}))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want := `{"created_at":"2024-01-15T12:00:00Z","id":"0190a8c4-1f2b-7c3d-8e4f-5a6b7c8d9e0f",` +
+		`"name":"Ada","email":"jane.doe@example.com","kind":"person","age":18,"tags":["admin","staff"],` +
+		`"labels":{"team":"core"},"active":true,"address":{"city":"Berlin"},"parent":null}`
+	if string(data) != want {
+		t.Errorf("Generate() =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestHandler_WrapsResponsesAndFiltersByPath(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/docs/examples", Handler("/api/v1",
+		Route{Method: "post", Path: "/samples", Request: address{}, Response: address{}},
+		Route{Method: http.MethodGet, Path: "/raw", Response: address{}, Bare: true},
+	))
+	w := httptest.NewRecorder()
+
+	// Act
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs/examples?path=/api/v1/samples", nil))
+
+	// Assert
+	var body struct {
+		Data []struct {
+			Method   string
+			Path     string
+			Request  map[string]interface{}
+			Response map[string]interface{}
+		}
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(body.Data) != 1 {
+		t.Fatalf("got %d examples, want the one for /api/v1/samples", len(body.Data))
+	}
+	got := body.Data[0]
+	data, _ := got.Response["data"].(map[string]interface{})
+	if got.Method != http.MethodPost || got.Request["city"] != "Berlin" || data["city"] != "Berlin" || got.Response["timestamp"] == nil {
+		t.Errorf("example = %+v, want the request and the response in a success envelope", got)
+	}
+}
@@ -0,0 +1,379 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/service"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// authRouter serves GET / behind JWTAuth and records the role the handler saw
+func authRouter(jwt *service.JWTManager, role *string, opts ...AuthOption) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestIDMiddleware(), ErrorHandlerMiddleware(zap.NewNop().Sugar()))
+	r.GET("/", JWTAuth(jwt, opts...), func(c *gin.Context) {
+		*role = c.GetString("role")
+		c.Status(http.StatusNoContent)
+	})
+	return r
+}
+
+func authorizedRequest(t *testing.T, jwt *service.JWTManager, role string) *http.Request {
+	t.Helper()
+	access, _, err := jwt.GenerateTokens(context.Background(), uuid.New(), role, service.Preferences{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+access)
+	return req
+}
+
+func TestJWTAuth_AccountCheck(t *testing.T) {
+	jwt := service.NewJWTManager("access-secret", "refresh-secret", time.Minute, time.Hour)
+	lookupErr := errors.New("database unavailable")
+
+	cases := []struct {
+		name     string
+		mode     AccountCheckMode
+		role     string
+		active   bool
+		err      error
+		want     int
+		wantRole string
+	}{
+		{"off trusts claims", AccountCheckOff, "user", false, nil, http.StatusNoContent, "admin"},
+		{"status allows active account", AccountCheckStatus, "user", true, nil, http.StatusNoContent, "user"},
+		{"status rejects suspended account", AccountCheckStatus, "admin", false, nil, http.StatusUnauthorized, ""},
+		{"status rejects deleted account", AccountCheckStatus, "", false, apperrors.NewAppError(apperrors.NotFoundError, "User not found"), http.StatusUnauthorized, ""},
+		{"status falls back to claims on lookup failure", AccountCheckStatus, "", false, lookupErr, http.StatusNoContent, "admin"},
+		{"strict rejects on lookup failure", AccountCheckStrict, "", false, lookupErr, http.StatusInternalServerError, ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			var seenRole string
+			lookup := func(ctx context.Context, userID string) (string, bool, error) {
+				return tc.role, tc.active, tc.err
+			}
+			r := authRouter(jwt, &seenRole, WithAccountCheck(tc.mode, lookup, nil))
+			w := httptest.NewRecorder()
+
+			// Act
+			r.ServeHTTP(w, authorizedRequest(t, jwt, "admin"))
+
+			// Assert
+			if w.Code != tc.want {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, tc.want, w.Body.String())
+			}
+			if seenRole != tc.wantRole {
+				t.Errorf("handler saw role %q, want %q", seenRole, tc.wantRole)
+			}
+		})
+	}
+}
+
+func TestJWTAuth_SetsUserIDString(t *testing.T) {
+	// Arrange
+	jwt := service.NewJWTManager("access-secret", "refresh-secret", time.Minute, time.Hour)
+	userID := uuid.New()
+	access, _, err := jwt.GenerateTokens(context.Background(), userID, "user", service.Preferences{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var seen string
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", JWTAuth(jwt), func(c *gin.Context) { seen = c.GetString("userID") })
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+access)
+
+	// Act
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Assert
+	if seen != userID.String() {
+		t.Errorf("userID = %q, want %q", seen, userID)
+	}
+}
+
+func TestJWTAuth_SetsExtraClaims(t *testing.T) {
+	// Arrange
+	jwt := service.NewJWTManager("access-secret", "refresh-secret", time.Minute, time.Hour)
+	jwt.SetClaimsEnricher(func(ctx context.Context, userID uuid.UUID, role string) (map[string]interface{}, error) {
+		return map[string]interface{}{"tenant_id": "acme"}, nil
+	})
+	var seen map[string]interface{}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", JWTAuth(jwt), func(c *gin.Context) { seen = ExtraClaims(c) })
+
+	// Act
+	r.ServeHTTP(httptest.NewRecorder(), authorizedRequest(t, jwt, "user"))
+
+	// Assert
+	if seen["tenant_id"] != "acme" {
+		t.Errorf("ExtraClaims() = %v, want tenant_id acme", seen)
+	}
+}
+
+func TestJWTAuth_SetsImpersonator(t *testing.T) {
+	// Arrange
+	jwt := service.NewJWTManager("access-secret", "refresh-secret", time.Minute, time.Hour)
+	adminID := uuid.New()
+	token, _, err := jwt.GenerateImpersonationToken(context.Background(), uuid.New(), "user", service.Preferences{}, adminID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromGin, fromCtx string
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", JWTAuth(jwt), func(c *gin.Context) {
+		fromGin = c.GetString("impersonatorID")
+		fromCtx = actor.ImpersonatorID(c.Request.Context())
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	// Act
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Assert
+	if fromGin != adminID.String() || fromCtx != adminID.String() {
+		t.Errorf("impersonator = %q (gin) %q (context), want %s", fromGin, fromCtx, adminID)
+	}
+}
+
+func TestJWTAuth_RejectedRequestsStopBeforeHandler(t *testing.T) {
+	// Arrange
+	jwt := service.NewJWTManager("access-secret", "refresh-secret", time.Minute, time.Hour)
+	reached := false
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestIDMiddleware(), ErrorHandlerMiddleware(zap.NewNop().Sugar()))
+	r.GET("/", JWTAuth(jwt), func(c *gin.Context) { reached = true })
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer not-a-token")
+	w := httptest.NewRecorder()
+
+	// Act
+	r.ServeHTTP(w, req)
+
+	// Assert
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", w.Code)
+	}
+	if reached {
+		t.Error("handler ran for a request with an invalid token")
+	}
+}
+
+// hierarchyFunc adapts a function to RoleHierarchy
+type hierarchyFunc func(ctx context.Context, role, required string) (bool, error)
+
+func (f hierarchyFunc) HasRole(ctx context.Context, role, required string) (bool, error) {
+	return f(ctx, role, required)
+}
+
+func TestRequireRole(t *testing.T) {
+	jwt := service.NewJWTManager("access-secret", "refresh-secret", time.Minute, time.Hour)
+	// owner inherits admin
+	hierarchy := hierarchyFunc(func(ctx context.Context, role, required string) (bool, error) {
+		return role == required || (role == "owner" && required == "admin"), nil
+	})
+
+	cases := []struct {
+		role string
+		want int
+	}{
+		{"admin", http.StatusNoContent},
+		{"owner", http.StatusNoContent},
+		{"user", http.StatusForbidden},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.role, func(t *testing.T) {
+			// Arrange
+			reached := false
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.Use(RequestIDMiddleware(), ErrorHandlerMiddleware(zap.NewNop().Sugar()))
+			r.DELETE("/", JWTAuth(jwt, WithRoleHierarchy(hierarchy)), RequireRole("admin"), func(c *gin.Context) {
+				reached = true
+				c.Status(http.StatusNoContent)
+			})
+			req := authorizedRequest(t, jwt, tc.role)
+			req.Method = http.MethodDelete
+			w := httptest.NewRecorder()
+
+			// Act
+			r.ServeHTTP(w, req)
+
+			// Assert
+			if w.Code != tc.want {
+				t.Errorf("status = %d, want %d", w.Code, tc.want)
+			}
+			if reached != (tc.want == http.StatusNoContent) {
+				t.Errorf("handler reached = %v for role %q", reached, tc.role)
+			}
+		})
+	}
+}
+
+// permissionFunc adapts a function to PermissionChecker
+type permissionFunc func(ctx context.Context, role, permission string) (bool, error)
+
+func (f permissionFunc) Can(ctx context.Context, role, permission string) (bool, error) {
+	return f(ctx, role, permission)
+}
+
+func TestRequirePermission(t *testing.T) {
+	jwt := service.NewJWTManager("access-secret", "refresh-secret", time.Minute, time.Hour)
+	checker := permissionFunc(func(ctx context.Context, role, permission string) (bool, error) {
+		if role == "broken" {
+			return false, errors.New("database unavailable")
+		}
+		return role == "support" && permission == "users:list", nil
+	})
+
+	cases := []struct {
+		role string
+		want int
+	}{
+		{"support", http.StatusNoContent},
+		{"user", http.StatusForbidden},
+		{"broken", http.StatusInternalServerError},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.role, func(t *testing.T) {
+			// Arrange
+			reached := false
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.Use(RequestIDMiddleware(), ErrorHandlerMiddleware(zap.NewNop().Sugar()))
+			group := r.Group("/")
+			group.Use(JWTAuth(jwt), RequirePermission(checker, "users:list"))
+			group.GET("/", func(c *gin.Context) {
+				reached = true
+				c.Status(http.StatusNoContent)
+			})
+			w := httptest.NewRecorder()
+
+			// Act
+			r.ServeHTTP(w, authorizedRequest(t, jwt, tc.role))
+
+			// Assert
+			if w.Code != tc.want {
+				t.Errorf("status = %d, want %d", w.Code, tc.want)
+			}
+			if reached != (tc.want == http.StatusNoContent) {
+				t.Errorf("handler reached = %v for role %q", reached, tc.role)
+			}
+		})
+	}
+}
+
+func TestJWTAuth_GuestTokens(t *testing.T) {
+	jwt := service.NewJWTManager("access-secret", "refresh-secret", time.Minute, time.Hour)
+	guestToken, _, _, err := jwt.GenerateGuestToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The account check would reject every guest, who has no account
+	noAccount := WithAccountCheck(AccountCheckStrict, func(ctx context.Context, userID string) (string, bool, error) {
+		return "", false, apperrors.NewAppError(apperrors.NotFoundError, "User not found")
+	}, nil)
+
+	cases := []struct {
+		name string
+		opts []AuthOption
+		want int
+	}{
+		{"rejected by default", []AuthOption{noAccount}, http.StatusUnauthorized},
+		{"accepted where guests are allowed", []AuthOption{noAccount, AllowGuests()}, http.StatusNoContent},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			var role string
+			r := authRouter(jwt, &role, tc.opts...)
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+guestToken)
+
+			// Act
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			// Assert
+			if w.Code != tc.want {
+				t.Fatalf("status = %d, want %d", w.Code, tc.want)
+			}
+			if tc.want == http.StatusNoContent && role != service.GuestRole {
+				t.Errorf("role = %q, want %q", role, service.GuestRole)
+			}
+		})
+	}
+}
+
+func TestJWTAuth_ServiceClientTokens(t *testing.T) {
+	jwt := service.NewJWTManager("access-secret", "refresh-secret", time.Minute, time.Hour)
+	clientToken, _, err := jwt.GenerateClientToken(uuid.New(), "billing-worker", []string{"users:list"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Even a role granting everything must not widen a client's scope
+	checker := permissionFunc(func(ctx context.Context, role, permission string) (bool, error) {
+		return true, nil
+	})
+
+	cases := []struct {
+		name       string
+		opts       []AuthOption
+		permission string
+		want       int
+	}{
+		{"rejected by default", nil, "users:list", http.StatusUnauthorized},
+		{"permission in scope", []AuthOption{AllowServiceClients()}, "users:list", http.StatusNoContent},
+		{"permission outside scope", []AuthOption{AllowServiceClients()}, "users:delete", http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			var userID string
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.Use(RequestIDMiddleware(), ErrorHandlerMiddleware(zap.NewNop().Sugar()))
+			r.GET("/", JWTAuth(jwt, tc.opts...), RequirePermission(checker, tc.permission), func(c *gin.Context) {
+				userID = c.GetString("userID")
+				c.Status(http.StatusNoContent)
+			})
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+clientToken)
+
+			// Act
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			// Assert
+			if w.Code != tc.want {
+				t.Fatalf("status = %d, want %d", w.Code, tc.want)
+			}
+			if tc.want == http.StatusNoContent && userID != "client:billing-worker" {
+				t.Errorf("userID = %q, want client:billing-worker", userID)
+			}
+		})
+	}
+}
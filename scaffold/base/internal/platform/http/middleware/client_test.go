@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/clientid"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestClientIdentificationMiddleware(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ClientIdentificationMiddleware(config.ClientConfig{Header: "X-Client-Id", Known: []string{"ios-app"}}))
+	var got clientid.Client
+	r.GET("/", func(c *gin.Context) {
+		got = clientid.FromContext(c.Request.Context())
+		c.Status(http.StatusNoContent)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Client-Id", "ios-app/2.3.1")
+	before := clientRequests.Value("ios-app", "2xx")
+	otherBefore := clientRequests.Value(clientLabelOther, "2xx")
+
+	// Act
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	unknown := httptest.NewRequest(http.MethodGet, "/", nil)
+	unknown.Header.Set("User-Agent", "curl/8.5.0")
+	r.ServeHTTP(httptest.NewRecorder(), unknown)
+
+	// Assert
+	if got != (clientid.Client{ID: "curl", Version: "8.5.0", Source: clientid.SourceUserAgent}) {
+		t.Errorf("last client = %+v, want curl from the User-Agent", got)
+	}
+	if n := clientRequests.Value("ios-app", "2xx") - before; n != 1 {
+		t.Errorf("ios-app requests counted %v, want 1", n)
+	}
+	if n := clientRequests.Value(clientLabelOther, "2xx") - otherBefore; n != 1 {
+		t.Errorf("unknown client counted %v times as other, want 1", n)
+	}
+}
+
+func TestClientLabels_Bounded(t *testing.T) {
+	// Arrange
+	labels := newClientLabels(nil)
+	for i := 0; i < maxClientLabels; i++ {
+		labels.label("client-" + strconv.Itoa(i))
+	}
+
+	// Act
+	seen := labels.label("client-0")
+	overflow := labels.label("one-too-many")
+	missing := labels.label("")
+
+	// Assert
+	if seen != "client-0" || overflow != clientLabelOther || missing != clientLabelUnidentified {
+		t.Errorf("labels = %q, %q, %q; want client-0, other, unidentified", seen, overflow, missing)
+	}
+}
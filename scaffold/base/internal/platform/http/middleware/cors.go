@@ -1,21 +0,0 @@
-// internal/shared/middleware/cors.go
-package middleware
-
-import (
-	"time"
-
-	"github.com/gin-contrib/cors"
-	"github.com/gin-gonic/gin"
-)
-
-func CORSMiddleware() gin.HandlerFunc {
-	config := cors.Config{
-		AllowOrigins:     []string{"http://localhost:3000"}, // frontend URL
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-		MaxAge:           12 * time.Hour,
-	}
-	return cors.New(config)
-}
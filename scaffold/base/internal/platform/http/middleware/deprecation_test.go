@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/deprecation"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDeprecated(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	tracker := deprecation.NewTracker(nil)
+	notice := deprecation.Notice{Since: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)}
+	r := gin.New()
+	r.Use(ClientIdentificationMiddleware(config.ClientConfig{Header: "X-Client-Id"}))
+	r.GET("/legacy/:id", Deprecated(tracker, notice), func(c *gin.Context) {
+		// Stands in for JWTAuth running after the deprecation middleware
+		c.Set("userID", "42")
+		c.Status(http.StatusNoContent)
+	})
+
+	// Act
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/legacy/7", nil)
+	req.Header.Set("X-Client-Id", "ios-app/2.3.1")
+	r.ServeHTTP(w, req)
+
+	// Assert
+	if w.Header().Get("Deprecation") == "" || w.Header().Get("Sunset") != "" {
+		t.Errorf("headers = %v, want Deprecation and no Sunset", w.Header())
+	}
+	report := tracker.Report()
+	if len(report) != 1 || report[0].Route != "GET /legacy/:id" {
+		t.Fatalf("report = %+v, want the route pattern", report)
+	}
+	if clients := report[0].Clients; len(clients) != 1 || clients[0].Client != "user:42" || clients[0].App != "ios-app/2.3.1" {
+		t.Errorf("clients = %+v, want user:42 using ios-app/2.3.1", clients)
+	}
+}
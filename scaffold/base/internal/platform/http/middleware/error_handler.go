@@ -1,28 +1,41 @@
 package middleware
 
 import (
-	apperrors "go_platform_template/internal/shared/errors"
-	"go_platform_template/internal/shared/response"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/i18n"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/locale"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
 // ErrorHandlerMiddleware handles errors consistently across the application
-// It intercepts errors, logs them, and returns standardized error responses
+// It intercepts errors, logs them, and returns standardized error responses. Messages
+// and validation details are translated into the caller's locale (see the i18n package);
+// logs stay in English.
 func ErrorHandlerMiddleware(logger *zap.SugaredLogger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Process request
 		c.Next()
 
-		// Handle errors if any occurred
+		// Handle errors if any occurred; several are reported together rather than only the last
 		if len(c.Errors) > 0 {
-			lastErr := c.Errors.Last()
+			err := c.Errors.Last().Err
+			if len(c.Errors) > 1 {
+				errs := make([]error, len(c.Errors))
+				for i, ginErr := range c.Errors {
+					errs[i] = ginErr.Err
+				}
+				err = apperrors.Join(errs...)
+			}
 			requestID, _ := c.Get("RequestID")
 
 			// Check if it's an AppError
-			if appErr, ok := apperrors.IsAppError(lastErr.Err); ok {
+			if appErr, ok := apperrors.IsAppError(err); ok {
 				// Log the error with context
 				logger.Errorw("request error",
 					"request_id", requestID,
@@ -33,11 +46,16 @@ func ErrorHandlerMiddleware(logger *zap.SugaredLogger) gin.HandlerFunc {
 					"method", c.Request.Method,
 				)
 
+				if appErr.RetryAfter > 0 {
+					c.Header("Retry-After", strconv.Itoa(appErr.RetryAfter))
+				}
+
 				// Return standardized error response
+				tag := locale.Locale(c.Request.Context())
 				c.JSON(appErr.HTTPStatus, response.NewErrorResponse(
-					appErr.Message,
+					i18n.Translate(tag, appErr.Message),
 					string(appErr.Type),
-					appErr.Details,
+					localizedDetails(tag, appErr),
 					requestID.(string),
 				))
 				return
@@ -46,17 +64,30 @@ func ErrorHandlerMiddleware(logger *zap.SugaredLogger) gin.HandlerFunc {
 			// Handle generic errors
 			logger.Errorw("unexpected error",
 				"request_id", requestID,
-				"error", lastErr.Err.Error(),
+				"error", err.Error(),
 				"path", c.Request.URL.Path,
 				"method", c.Request.Method,
 			)
 
 			c.JSON(http.StatusInternalServerError, response.NewErrorResponse(
-				"An unexpected error occurred",
+				i18n.Translate(locale.Locale(c.Request.Context()), "An unexpected error occurred"),
 				"INTERNAL",
-				lastErr.Err.Error(),
+				err.Error(),
 				requestID.(string),
 			))
 		}
 	}
 }
+
+// localizedDetails returns the details of appErr in the locale tag, part by part when
+// they were built from apperrors.Detail values and unchanged when they are free text
+func localizedDetails(tag string, appErr *apperrors.AppError) string {
+	if len(appErr.DetailParts) == 0 {
+		return appErr.Details
+	}
+	parts := make([]string, len(appErr.DetailParts))
+	for i, part := range appErr.DetailParts {
+		parts[i] = i18n.Sprintf(tag, part.Format, part.Args...)
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/validation"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestErrorHandlerMiddleware_ReportsEveryError(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestIDMiddleware(), ErrorHandlerMiddleware(zap.NewNop().Sugar()))
+	r.POST("/batch", func(c *gin.Context) {
+		_ = c.Error(apperrors.NewAppError(apperrors.NotFoundError, "Item 3 not found"))
+		_ = c.Error(errors.New("item 7: connection reset"))
+	})
+
+	// Act
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/batch", nil))
+
+	// Assert
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want the most severe error's 500", w.Code)
+	}
+	var body response.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(body.Details, "Item 3 not found") || !strings.Contains(body.Details, "connection reset") {
+		t.Errorf("details = %q, want both causes", body.Details)
+	}
+}
+
+func TestErrorHandlerMiddleware_Localizes(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestIDMiddleware(), LocalizationMiddleware(config.LocalizationConfig{DefaultLocale: "en"}), ErrorHandlerMiddleware(zap.NewNop().Sugar()))
+	r.POST("/signup", func(c *gin.Context) {
+		var req struct {
+			Email string `validate:"required,email"`
+		}
+		_ = c.Error(validation.New().ValidateStruct(&req))
+	})
+	post := func(acceptLanguage string) response.ErrorResponse {
+		req := httptest.NewRequest(http.MethodPost, "/signup", nil)
+		req.Header.Set("Accept-Language", acceptLanguage)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var body response.ErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		return body
+	}
+
+	// Act
+	german := post("de-DE,de;q=0.9,en;q=0.8")
+	english := post("en-US")
+
+	// Assert
+	if german.Error != "Validierung fehlgeschlagen" || german.Details != "Email ist erforderlich" {
+		t.Errorf("German response = %q / %q, want the translated message and details", german.Error, german.Details)
+	}
+	if english.Error != "validation failed" || english.Details != "Email is required" {
+		t.Errorf("English response = %q / %q, want the original message and details", english.Error, english.Details)
+	}
+}
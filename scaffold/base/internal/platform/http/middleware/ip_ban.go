@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/ipban"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BlockBannedIPs refuses every request from a client IP banned by bans with 403 and a
+// Retry-After header for the rest of the ban. A nil Guard lets all requests through.
+func BlockBannedIPs(bans *ipban.Guard) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ban := bans.Banned(c.Request.Context(), c.ClientIP())
+		if ban == nil {
+			c.Next()
+			return
+		}
+		appErr := apperrors.NewAppError(apperrors.ForbiddenError, "Requests from your IP address are temporarily blocked")
+		// Round up so clients that honour Retry-After do not come back a moment too early
+		appErr.RetryAfter = int((time.Until(ban.ExpiresAt) + time.Second - 1) / time.Second)
+		_ = c.Error(appErr)
+		c.Abort()
+	}
+}
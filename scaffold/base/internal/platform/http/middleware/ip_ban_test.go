@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/ipban"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestBlockBannedIPs(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	bans, err := ipban.NewGuard(ipban.NewMemoryStore(), config.IPBanConfig{Threshold: 1, Window: time.Minute, Duration: time.Hour}, zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatal(err)
+	}
+	bans.Record(context.Background(), "203.0.113.7", ipban.ReasonFailedLogin)
+	r := gin.New()
+	r.Use(RequestIDMiddleware(), ErrorHandlerMiddleware(zap.NewNop().Sugar()), BlockBannedIPs(bans))
+	r.GET("/ping", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	for _, tt := range []struct {
+		ip         string
+		wantStatus int
+	}{
+		{ip: "203.0.113.7", wantStatus: http.StatusForbidden},
+		{ip: "198.51.100.1", wantStatus: http.StatusNoContent},
+	} {
+		// Act
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		req.RemoteAddr = tt.ip + ":4000"
+		r.ServeHTTP(w, req)
+
+		// Assert
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tt.ip, w.Code, tt.wantStatus)
+		}
+		if banned := tt.wantStatus == http.StatusForbidden; banned != (w.Header().Get("Retry-After") != "") {
+			t.Errorf("%s: Retry-After = %q, want it only on the banned IP", tt.ip, w.Header().Get("Retry-After"))
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/quota"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WithQuota makes JWTAuth count every request of a user or service client against the
+// daily and monthly quotas of their role. Responses carry X-Quota-Limit,
+// X-Quota-Remaining, X-Quota-Reset (Unix seconds) and X-Quota-Period for the quota that
+// binds first, and requests over it get 429 with Retry-After until it resets. Guests are
+// not counted. A nil Limiter counts nothing.
+func WithQuota(limiter *quota.Limiter) AuthOption {
+	return func(o *authOptions) {
+		o.quota = limiter
+	}
+}
+
+// enforceQuota counts the request against the quota of userID and reports whether it may
+// go on; when it may not, the error is already added to c
+func enforceQuota(c *gin.Context, limiter *quota.Limiter, userID, role string) bool {
+	usage := limiter.Count(c.Request.Context(), userID, role)
+	if usage == nil {
+		return true
+	}
+	c.Header("X-Quota-Limit", strconv.FormatInt(usage.Limit, 10))
+	c.Header("X-Quota-Remaining", strconv.FormatInt(usage.Remaining(), 10))
+	c.Header("X-Quota-Reset", strconv.FormatInt(usage.Reset.Unix(), 10))
+	c.Header("X-Quota-Period", string(usage.Period))
+	if !usage.Exceeded() {
+		return true
+	}
+	appErr := apperrors.NewAppError(apperrors.TooManyRequestsError, "API quota exceeded")
+	// Round up so clients that honour Retry-After do not come back a moment too early
+	appErr.RetryAfter = int((usage.RetryAfter + time.Second - 1) / time.Second)
+	_ = c.Error(appErr)
+	return false
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/service"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/quota"
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+
+	"go.uber.org/zap"
+)
+
+func TestJWTAuth_Quota(t *testing.T) {
+	// Arrange
+	jwt := service.NewJWTManager("access-secret", "refresh-secret", time.Minute, time.Hour)
+	limiter := quota.NewLimiter(quota.NewMemoryStore(), config.QuotaConfig{Daily: map[string]int64{"user": 2}}, zap.NewNop().Sugar())
+	var role string
+	r := authRouter(jwt, &role, WithQuota(limiter))
+	req := authorizedRequest(t, jwt, "user")
+	admin := authorizedRequest(t, jwt, "admin")
+
+	// Act
+	codes := make([]int, 3)
+	var last *httptest.ResponseRecorder
+	for i := range codes {
+		last = httptest.NewRecorder()
+		r.ServeHTTP(last, req)
+		codes[i] = last.Code
+	}
+	adminW := httptest.NewRecorder()
+	r.ServeHTTP(adminW, admin)
+
+	// Assert
+	if codes[0] != http.StatusNoContent || codes[1] != http.StatusNoContent || codes[2] != http.StatusTooManyRequests {
+		t.Errorf("statuses = %v, want two allowed then 429", codes)
+	}
+	if got := last.Header().Get("X-Quota-Remaining"); got != "0" {
+		t.Errorf("X-Quota-Remaining = %q, want 0", got)
+	}
+	if last.Header().Get("X-Quota-Period") != "day" || last.Header().Get("Retry-After") == "" {
+		t.Errorf("headers = %v, want the day period and Retry-After", last.Header())
+	}
+	if adminW.Code != http.StatusNoContent || adminW.Header().Get("X-Quota-Limit") != "" {
+		t.Errorf("admin status = %d, X-Quota-Limit = %q; want no quota for a role without one", adminW.Code, adminW.Header().Get("X-Quota-Limit"))
+	}
+}
+
+func TestJWTAuth_Quota_RetryAfterFollowsClock(t *testing.T) {
+	// Arrange: 30.5 seconds before the daily quota resets, on a clock far from the wall clock
+	jwt := service.NewJWTManager("access-secret", "refresh-secret", time.Minute, time.Hour)
+	clk := testutil.NewFakeClock(time.Date(2024, 1, 15, 23, 59, 29, 500_000_000, time.UTC))
+	store := quota.NewMemoryStore()
+	store.SetClock(clk)
+	limiter := quota.NewLimiter(store, config.QuotaConfig{Daily: map[string]int64{"user": 1}}, zap.NewNop().Sugar())
+	limiter.SetClock(clk)
+	var role string
+	r := authRouter(jwt, &role, WithQuota(limiter))
+	req := authorizedRequest(t, jwt, "user")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Act
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	// Assert
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if got := w.Header().Get("Retry-After"); got != "31" {
+		t.Errorf("Retry-After = %q, want 31", got)
+	}
+	if got := w.Header().Get("X-Quota-Reset"); got != "1705363200" {
+		t.Errorf("X-Quota-Reset = %q, want 1705363200", got)
+	}
+}
@@ -1,19 +1,27 @@
 package middleware
 
 import (
+	"github.com/MurtadaNazar/go-api-template/internal/shared/requestid"
+
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
-// RequestIDMiddleware adds a unique request ID to each request context
+// requestIDHeader is written in canonical form so header lookups need not rewrite it
+const requestIDHeader = "X-Request-Id"
+
+// RequestIDMiddleware adds a unique request ID to each request. It is stored on the gin
+// context as "RequestID" and on the request context (see requestid.FromContext), so it
+// reaches services and SQL logs through c.Request.Context().
 func RequestIDMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		requestID := c.GetHeader("X-Request-ID")
+		requestID := c.GetHeader(requestIDHeader)
 		if requestID == "" {
 			requestID = uuid.New().String()
 		}
 		c.Set("RequestID", requestID)
-		c.Writer.Header().Set("X-Request-ID", requestID)
+		c.Request = c.Request.WithContext(requestid.With(c.Request.Context(), requestID))
+		c.Writer.Header().Set(requestIDHeader, requestID)
 		c.Next()
 	}
 }
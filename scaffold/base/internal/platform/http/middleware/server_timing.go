@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"fmt"
+	"runtime/metrics"
+	"strings"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/shared/timing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// heapAllocsMetric is the running total of bytes allocated on the heap
+const heapAllocsMetric = "/gc/heap/allocs:bytes"
+
+// ServerTimingMiddleware reports where each request spent its time, for attributing
+// latency during development. It adds a Server-Timing header, which browser developer
+// tools show next to the request, and logs the same figures with the request ID:
+//
+//	Server-Timing: db;dur=3.1;desc="4 calls", storage;dur=12.0;desc="1 call", app;dur=1.4, total;dur=16.5, alloc;desc="212 KB"
+//
+// Segments such as "db" and "storage" are recorded through the timing package; "app" is
+// the rest of the time until the response started. "alloc" is the heap allocated in the
+// meantime by the whole process, so it is only per request while requests do not overlap.
+// Register it in debug mode only: it allocates and exposes internals to clients.
+func ServerTimingMiddleware(logger *zap.SugaredLogger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		w := &timingWriter{
+			ResponseWriter: c.Writer,
+			recorder:       &timing.Recorder{},
+			start:          time.Now(),
+			allocStart:     heapAllocs(),
+		}
+		c.Request = c.Request.WithContext(timing.With(c.Request.Context(), w.recorder))
+		c.Writer = w
+		defer func() { c.Writer = w.ResponseWriter }()
+
+		c.Next()
+
+		// Responses without a body, such as c.Status(204), have not sent their header yet
+		w.report()
+		fields := make([]interface{}, 0, 8+4*len(w.segments))
+		fields = append(fields,
+			"request_id", c.GetString("RequestID"),
+			"path", c.Request.URL.Path,
+			"total_ms", milliseconds(w.total),
+			"alloc_bytes", w.allocated,
+		)
+		for _, s := range w.segments {
+			fields = append(fields, s.Name+"_ms", milliseconds(s.Duration), s.Name+"_calls", s.Count)
+		}
+		logger.Infow("request timing", fields...)
+	}
+}
+
+// timingWriter adds the Server-Timing header just before the response starts
+type timingWriter struct {
+	gin.ResponseWriter
+	recorder   *timing.Recorder
+	start      time.Time
+	allocStart uint64
+
+	reported  bool
+	total     time.Duration
+	allocated uint64
+	segments  []timing.Segment
+}
+
+// report takes the figures and sets the header once
+func (w *timingWriter) report() {
+	if w.reported {
+		return
+	}
+	w.reported = true
+	w.total = time.Since(w.start)
+	w.allocated = heapAllocs() - w.allocStart
+	w.segments = w.recorder.Segments()
+	if !w.ResponseWriter.Written() {
+		w.Header().Set("Server-Timing", serverTiming(w.segments, w.total, w.allocated))
+	}
+}
+
+func (w *timingWriter) WriteHeaderNow() {
+	w.report()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *timingWriter) Write(b []byte) (int, error) {
+	w.report()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *timingWriter) WriteString(s string) (int, error) {
+	w.report()
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *timingWriter) Flush() {
+	w.report()
+	w.ResponseWriter.Flush()
+}
+
+// serverTiming formats the Server-Timing header value
+func serverTiming(segments []timing.Segment, total time.Duration, allocated uint64) string {
+	var b strings.Builder
+	attributed := time.Duration(0)
+	for _, s := range segments {
+		calls := "calls"
+		if s.Count == 1 {
+			calls = "call"
+		}
+		fmt.Fprintf(&b, "%s;dur=%.1f;desc=\"%d %s\", ", s.Name, milliseconds(s.Duration), s.Count, calls)
+		attributed += s.Duration
+	}
+	// Segments run concurrently can add up to more than the total
+	fmt.Fprintf(&b, "app;dur=%.1f, total;dur=%.1f, alloc;desc=\"%d KB\"",
+		milliseconds(max(total-attributed, 0)), milliseconds(total), allocated>>10)
+	return b.String()
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// heapAllocs reads the process's cumulative heap allocation without stopping the world
+func heapAllocs() uint64 {
+	sample := []metrics.Sample{{Name: heapAllocsMetric}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return sample[0].Value.Uint64()
+}
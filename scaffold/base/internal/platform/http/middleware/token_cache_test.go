@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/service"
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func TestJWTAuth_TokenCache(t *testing.T) {
+	// Arrange
+	issuer := service.NewJWTManager("access-secret", "refresh-secret", time.Minute, time.Hour)
+	// A manager with other keys only accepts the token while it is cached
+	other := service.NewJWTManager("other-secret", "refresh-secret", time.Minute, time.Hour)
+	cache := NewTokenCache(10, time.Minute)
+	userID := uuid.New()
+	access, _, err := issuer.GenerateTokens(context.Background(), userID, "user", service.Preferences{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var role string
+	serve := func(jwtManager *service.JWTManager) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+access)
+		w := httptest.NewRecorder()
+		authRouter(jwtManager, &role, WithTokenCache(cache)).ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Act
+	first := serve(issuer)
+	cached := serve(other)
+	cache.InvalidateUser(userID.String())
+	invalidated := serve(other)
+
+	// Assert
+	if first != http.StatusNoContent || cached != http.StatusNoContent {
+		t.Errorf("status = %d then %d, want %d from the cache", first, cached, http.StatusNoContent)
+	}
+	if invalidated != http.StatusUnauthorized {
+		t.Errorf("status after InvalidateUser = %d, want %d", invalidated, http.StatusUnauthorized)
+	}
+}
+
+func TestTokenCache_ExpiryAndEviction(t *testing.T) {
+	clk := testutil.NewFakeClock(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+	claims := func(exp time.Duration) *service.Claims {
+		return &service.Claims{UserID: uuid.New(), RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(clk.Now().Add(exp)),
+		}}
+	}
+
+	t.Run("entries end at the token's exp", func(t *testing.T) {
+		cache := NewTokenCache(10, time.Hour)
+		cache.SetClock(clk)
+		cache.add("short", claims(time.Minute))
+		cache.add("long", claims(2*time.Hour))
+
+		clk.Advance(2 * time.Minute)
+
+		if _, ok := cache.get("short"); ok {
+			t.Error("token past its exp was served from the cache")
+		}
+		if _, ok := cache.get("long"); !ok {
+			t.Error("token within the cache TTL was not served")
+		}
+	})
+
+	t.Run("least recently used entry is dropped when full", func(t *testing.T) {
+		cache := NewTokenCache(2, time.Hour)
+		cache.SetClock(clk)
+		cache.add("a", claims(time.Hour))
+		cache.add("b", claims(time.Hour))
+		cache.get("a")
+
+		cache.add("c", claims(time.Hour))
+
+		if _, ok := cache.get("b"); ok {
+			t.Error("least recently used token b is still cached")
+		}
+		if _, ok := cache.get("a"); !ok || cache.Len() != 2 {
+			t.Errorf("cached a = %v, len = %d, want a kept and 2 entries", ok, cache.Len())
+		}
+	})
+
+	t.Run("zero size disables the cache", func(t *testing.T) {
+		cache := NewTokenCache(0, time.Hour)
+		cache.add("a", claims(time.Hour))
+
+		if _, ok := cache.get("a"); ok || cache != nil {
+			t.Error("NewTokenCache(0) cached a token")
+		}
+	})
+}
@@ -0,0 +1,247 @@
+// Package ipban bans client IPs that keep failing authentication, such as password
+// guessing across accounts or replaying stolen refresh tokens, for a while.
+package ipban
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/clock"
+
+	"go.uber.org/zap"
+)
+
+// Reasons passed to Guard.Record
+const (
+	ReasonFailedLogin    = "failed_login"
+	ReasonThrottledLogin = "throttled_login"
+	ReasonInvalidRefresh = "invalid_refresh_token"
+	ReasonInvalidClient  = "invalid_client"
+)
+
+// Ban refuses requests from IP until ExpiresAt
+type Ban struct {
+	IP string `json:"ip" example:"203.0.113.7"`
+	// Reason is the event that reached the threshold
+	Reason    string    `json:"reason" example:"failed_login"`
+	Events    int       `json:"events" example:"100"`
+	BannedAt  time.Time `json:"banned_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Store counts suspicious events and keeps bans. Implementations must be safe for
+// concurrent use, and replicas must share one store for bans to hold across them. In
+// Redis, RecordEvent is INCR plus PEXPIRE NX, Ban is SET with PX, Get is GET, List is
+// SCAN over the ban keys and Clear is DEL of both keys.
+type Store interface {
+	// RecordEvent counts an event for ip in the window started by its first event at or
+	// before now, and returns the count
+	RecordEvent(ctx context.Context, ip string, now time.Time, window time.Duration) (int, error)
+	// Ban stores ban until its ExpiresAt
+	Ban(ctx context.Context, ban Ban) error
+	// Get returns the ban of ip in force at now, or nil
+	Get(ctx context.Context, ip string, now time.Time) (*Ban, error)
+	// List returns the bans in force at now
+	List(ctx context.Context, now time.Time) ([]Ban, error)
+	// Clear lifts the ban of ip and forgets its events
+	Clear(ctx context.Context, ip string) error
+}
+
+// sweepThreshold is the entry count at which the memory store drops expired entries
+const sweepThreshold = 10000
+
+type eventWindow struct {
+	count     int
+	expiresAt time.Time
+}
+
+// MemoryStore keeps events and bans in process memory. Each replica counts and bans on
+// its own.
+type MemoryStore struct {
+	mu     sync.Mutex
+	events map[string]eventWindow
+	bans   map[string]Ban
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{events: make(map[string]eventWindow), bans: make(map[string]Ban)}
+}
+
+func (m *MemoryStore) RecordEvent(_ context.Context, ip string, now time.Time, window time.Duration) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.events) >= sweepThreshold {
+		for k, w := range m.events {
+			if !w.expiresAt.After(now) {
+				delete(m.events, k)
+			}
+		}
+	}
+	w, ok := m.events[ip]
+	if !ok || !w.expiresAt.After(now) {
+		w = eventWindow{expiresAt: now.Add(window)}
+	}
+	w.count++
+	m.events[ip] = w
+	return w.count, nil
+}
+
+func (m *MemoryStore) Ban(_ context.Context, ban Ban) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.bans) >= sweepThreshold {
+		for k, b := range m.bans {
+			if !b.ExpiresAt.After(ban.BannedAt) {
+				delete(m.bans, k)
+			}
+		}
+	}
+	m.bans[ban.IP] = ban
+	return nil
+}
+
+func (m *MemoryStore) Get(_ context.Context, ip string, now time.Time) (*Ban, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ban, ok := m.bans[ip]
+	if !ok || !ban.ExpiresAt.After(now) {
+		return nil, nil
+	}
+	return &ban, nil
+}
+
+func (m *MemoryStore) List(_ context.Context, now time.Time) ([]Ban, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	bans := make([]Ban, 0, len(m.bans))
+	for _, ban := range m.bans {
+		if ban.ExpiresAt.After(now) {
+			bans = append(bans, ban)
+		}
+	}
+	return bans, nil
+}
+
+func (m *MemoryStore) Clear(_ context.Context, ip string) error {
+	m.mu.Lock()
+	delete(m.events, ip)
+	delete(m.bans, ip)
+	m.mu.Unlock()
+	return nil
+}
+
+// Guard counts suspicious events per client IP and bans IPs that reach the threshold. A
+// nil Guard records nothing and bans no one.
+type Guard struct {
+	store     Store
+	cfg       config.IPBanConfig
+	allowlist []netip.Prefix
+	clock     clock.Clock
+	logger    *zap.SugaredLogger
+}
+
+// NewGuard returns a Guard, or an error when an allowlist entry is not an address or CIDR
+func NewGuard(store Store, cfg config.IPBanConfig, logger *zap.SugaredLogger) (*Guard, error) {
+	g := &Guard{store: store, cfg: cfg, clock: clock.System(), logger: logger}
+	for _, entry := range cfg.Allowlist {
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(entry)
+			if addrErr != nil {
+				return nil, fmt.Errorf("invalid IP_BAN_ALLOWLIST entry %q: %w", entry, err)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		g.allowlist = append(g.allowlist, prefix.Masked())
+	}
+	return g, nil
+}
+
+// SetClock replaces the clock used for windows and ban expiry
+func (g *Guard) SetClock(c clock.Clock) {
+	g.clock = c
+}
+
+func (g *Guard) allowed(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range g.allowlist {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Record counts a suspicious event from ip and bans it once Threshold events fall within
+// Window. Store errors are logged only, so an unavailable store bans no one.
+func (g *Guard) Record(ctx context.Context, ip, reason string) {
+	if g == nil || ip == "" || g.allowed(ip) {
+		return
+	}
+	now := g.clock.Now()
+	events, err := g.store.RecordEvent(ctx, ip, now, g.cfg.Window)
+	if err != nil {
+		g.logger.Errorw("failed to record suspicious event", "ip", ip, "reason", reason, "error", err)
+		return
+	}
+	if events < g.cfg.Threshold {
+		return
+	}
+	ban := Ban{IP: ip, Reason: reason, Events: events, BannedAt: now, ExpiresAt: now.Add(g.cfg.Duration)}
+	if err := g.store.Ban(ctx, ban); err != nil {
+		g.logger.Errorw("failed to ban client IP", "ip", ip, "error", err)
+		return
+	}
+	g.logger.Warnw("client IP banned", "ip", ip, "reason", reason, "events", events, "expires_at", ban.ExpiresAt)
+}
+
+// Banned returns the ban in force for ip, or nil. Store errors are logged and let the
+// request through.
+func (g *Guard) Banned(ctx context.Context, ip string) *Ban {
+	if g == nil || ip == "" {
+		return nil
+	}
+	ban, err := g.store.Get(ctx, ip, g.clock.Now())
+	if err != nil {
+		g.logger.Errorw("failed to read IP ban", "ip", ip, "error", err)
+		return nil
+	}
+	return ban
+}
+
+// List returns the bans in force, the most recent first
+func (g *Guard) List(ctx context.Context) ([]Ban, error) {
+	if g == nil {
+		return []Ban{}, nil
+	}
+	bans, err := g.store.List(ctx, g.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(bans, func(i, j int) bool { return bans[i].BannedAt.After(bans[j].BannedAt) })
+	return bans, nil
+}
+
+// Lift ends the ban of ip early and forgets its events. It reports whether ip was banned.
+func (g *Guard) Lift(ctx context.Context, ip string) (bool, error) {
+	if g == nil {
+		return false, nil
+	}
+	ban, err := g.store.Get(ctx, ip, g.clock.Now())
+	if err != nil || ban == nil {
+		return false, err
+	}
+	if err := g.store.Clear(ctx, ip); err != nil {
+		return false, err
+	}
+	return true, nil
+}
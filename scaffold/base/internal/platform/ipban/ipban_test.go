@@ -0,0 +1,118 @@
+package ipban
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+
+	"go.uber.org/zap"
+)
+
+func newTestGuard(t *testing.T, allowlist ...string) (*Guard, *testutil.FakeClock) {
+	t.Helper()
+	guard, err := NewGuard(NewMemoryStore(), config.IPBanConfig{
+		Threshold: 3,
+		Window:    10 * time.Minute,
+		Duration:  time.Hour,
+		Allowlist: allowlist,
+	}, zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatalf("NewGuard() error = %v", err)
+	}
+	clk := testutil.NewFakeClock(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+	guard.SetClock(clk)
+	return guard, clk
+}
+
+func TestGuard_BansAtThreshold(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	guard, clk := newTestGuard(t)
+	guard.Record(ctx, "203.0.113.7", ReasonFailedLogin)
+	guard.Record(ctx, "203.0.113.7", ReasonFailedLogin)
+	if guard.Banned(ctx, "203.0.113.7") != nil {
+		t.Fatal("Banned() below the threshold returned a ban")
+	}
+
+	// Act
+	guard.Record(ctx, "203.0.113.7", ReasonInvalidRefresh)
+
+	// Assert
+	ban := guard.Banned(ctx, "203.0.113.7")
+	if ban == nil || ban.Reason != ReasonInvalidRefresh || ban.Events != 3 || !ban.ExpiresAt.Equal(clk.Now().Add(time.Hour)) {
+		t.Fatalf("Banned() = %+v, want an hour's ban for the third event", ban)
+	}
+	if guard.Banned(ctx, "198.51.100.1") != nil {
+		t.Error("Banned() returned a ban for another IP")
+	}
+	clk.Advance(time.Hour)
+	if guard.Banned(ctx, "203.0.113.7") != nil {
+		t.Error("Banned() after the ban duration returned a ban")
+	}
+}
+
+func TestGuard_EventsOutsideWindowAreForgotten(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	guard, clk := newTestGuard(t)
+	guard.Record(ctx, "203.0.113.7", ReasonFailedLogin)
+	guard.Record(ctx, "203.0.113.7", ReasonFailedLogin)
+
+	// Act
+	clk.Advance(10 * time.Minute)
+	guard.Record(ctx, "203.0.113.7", ReasonFailedLogin)
+
+	// Assert
+	if ban := guard.Banned(ctx, "203.0.113.7"); ban != nil {
+		t.Errorf("Banned() = %+v, want events from an earlier window not counted", ban)
+	}
+}
+
+func TestGuard_AllowlistIsNeverBanned(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	guard, _ := newTestGuard(t, "10.0.0.0/8", "2001:db8::1")
+
+	// Act
+	for range 5 {
+		guard.Record(ctx, "10.1.2.3", ReasonFailedLogin)
+		guard.Record(ctx, "2001:db8::1", ReasonFailedLogin)
+	}
+
+	// Assert
+	if guard.Banned(ctx, "10.1.2.3") != nil || guard.Banned(ctx, "2001:db8::1") != nil {
+		t.Error("Banned() returned a ban for an allowlisted IP")
+	}
+	if _, err := NewGuard(NewMemoryStore(), config.IPBanConfig{Allowlist: []string{"office"}}, zap.NewNop().Sugar()); err == nil {
+		t.Error("NewGuard() with an invalid allowlist entry error = nil")
+	}
+}
+
+func TestGuard_Lift(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	guard, _ := newTestGuard(t)
+	for range 3 {
+		guard.Record(ctx, "203.0.113.7", ReasonFailedLogin)
+	}
+
+	// Act
+	lifted, err := guard.Lift(ctx, "203.0.113.7")
+	again, _ := guard.Lift(ctx, "203.0.113.7")
+
+	// Assert
+	if err != nil || !lifted || again {
+		t.Fatalf("Lift() = %v, %v then %v; want the ban lifted once", lifted, err, again)
+	}
+	if bans, _ := guard.List(ctx); len(bans) != 0 {
+		t.Errorf("List() = %+v, want no bans", bans)
+	}
+	// Events were forgotten with the ban, so one more does not ban again
+	guard.Record(ctx, "203.0.113.7", ReasonFailedLogin)
+	if guard.Banned(ctx, "203.0.113.7") != nil {
+		t.Error("Banned() after Lift() and one event returned a ban")
+	}
+}
@@ -0,0 +1,128 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+)
+
+// waitIdle polls until the named job has finished n runs
+func waitIdle(t *testing.T, s *Scheduler, name string, runs int) Status {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, st := range s.List() {
+			if st.Name == name && !st.Running && st.Runs >= runs {
+				return st
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("job %q did not finish %d run(s)", name, runs)
+	return Status{}
+}
+
+func TestScheduler_RunRecordsOutcome(t *testing.T) {
+	// Arrange
+	clk := testutil.NewFakeClock(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC))
+	s := NewScheduler(nil)
+	s.SetClock(clk)
+	defer s.Stop()
+	fail := errors.New("database unavailable")
+	results := []error{nil, fail}
+	_ = s.Register(Job{Name: "cleanup", Run: func(ctx context.Context) error {
+		clk.Advance(1500 * time.Millisecond)
+		err := results[0]
+		results = results[1:]
+		return err
+	}})
+
+	// Act
+	if err := s.Run("cleanup"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	first := waitIdle(t, s, "cleanup", 1)
+	_ = s.Run("cleanup")
+	second := waitIdle(t, s, "cleanup", 2)
+
+	// Assert
+	if first.LastResult != ResultSuccess || first.LastDuration != "1.5s" || first.LastTrigger != TriggerManual {
+		t.Errorf("first run = %+v, want a 1.5s manual success", first)
+	}
+	if second.LastResult != ResultFailed || second.LastError != fail.Error() || second.Failures != 1 {
+		t.Errorf("second run = %+v, want a recorded failure", second)
+	}
+}
+
+func TestScheduler_RunRejectsUnknownAndOverlapping(t *testing.T) {
+	// Arrange
+	s := NewScheduler(nil)
+	defer s.Stop()
+	release := make(chan struct{})
+	_ = s.Register(Job{Name: "slow", Run: func(ctx context.Context) error {
+		<-release
+		return nil
+	}})
+
+	// Act
+	unknownErr := s.Run("missing")
+	_ = s.Run("slow")
+	overlapErr := s.Run("slow")
+	close(release)
+
+	// Assert
+	if !errors.Is(unknownErr, ErrUnknownJob) {
+		t.Errorf("Run(missing) error = %v, want ErrUnknownJob", unknownErr)
+	}
+	if !errors.Is(overlapErr, ErrJobRunning) {
+		t.Errorf("second Run(slow) error = %v, want ErrJobRunning", overlapErr)
+	}
+	waitIdle(t, s, "slow", 1)
+}
+
+func TestScheduler_RunsOnInterval(t *testing.T) {
+	// Arrange
+	s := NewScheduler(nil)
+	defer s.Stop()
+	_ = s.Register(Job{Name: "tick", Interval: 5 * time.Millisecond, Run: func(ctx context.Context) error { return nil }})
+
+	// Act
+	s.Start()
+	st := waitIdle(t, s, "tick", 2)
+
+	// Assert
+	if st.LastTrigger != TriggerSchedule || st.NextRun == nil {
+		t.Errorf("status = %+v, want scheduled runs with a next run time", st)
+	}
+}
+
+func TestScheduler_RecoversFromPanic(t *testing.T) {
+	// Arrange
+	s := NewScheduler(nil)
+	defer s.Stop()
+	_ = s.Register(Job{Name: "broken", Run: func(ctx context.Context) error { panic("nil map") }})
+
+	// Act
+	_ = s.Run("broken")
+	st := waitIdle(t, s, "broken", 1)
+
+	// Assert
+	if st.LastResult != ResultFailed {
+		t.Errorf("status = %+v, want the panic recorded as a failure", st)
+	}
+}
+
+func TestScheduler_RegisterRejectsDuplicates(t *testing.T) {
+	s := NewScheduler(nil)
+	run := func(ctx context.Context) error { return nil }
+
+	if err := s.Register(Job{Name: "cleanup", Run: run}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := s.Register(Job{Name: "cleanup", Run: run}); err == nil {
+		t.Error("Register() accepted a duplicate name")
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package logger
+
+import "errors"
+
+// diskFree is not implemented on this platform, so the disk guard never pauses file logging
+func diskFree(dir string) (free, total uint64, err error) {
+	return 0, 0, errors.New("disk usage not supported on this platform")
+}
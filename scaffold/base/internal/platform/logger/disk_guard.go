@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"io"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// diskCheckInterval is how often diskGuard looks at the free space of the log volume
+const diskCheckInterval = 30 * time.Second
+
+// diskGuard drops writes to the log file while its volume is nearly full, so logging
+// cannot take the disk the database or uploads need. Writing resumes once space is freed.
+type diskGuard struct {
+	w       io.Writer
+	dir     string
+	minFree float64
+	// free reports the free and total bytes of the volume holding dir
+	free func(dir string) (free, total uint64, err error)
+	// notice logs switching on and off; it must not write to w
+	notice *zap.SugaredLogger
+
+	mu      sync.Mutex
+	checked time.Time
+	full    bool
+}
+
+func newDiskGuard(w io.Writer, path string, minFreePercent int, notice *zap.SugaredLogger) *diskGuard {
+	return &diskGuard{
+		w:       w,
+		dir:     filepath.Dir(path),
+		minFree: float64(minFreePercent) / 100,
+		free:    diskFree,
+		notice:  notice,
+	}
+}
+
+// Write passes p to the file unless the volume is nearly full, in which case p is
+// dropped without an error so the other outputs still get it
+func (g *diskGuard) Write(p []byte) (int, error) {
+	if g.isFull(time.Now()) {
+		return len(p), nil
+	}
+	return g.w.Write(p)
+}
+
+func (g *diskGuard) isFull(now time.Time) bool {
+	g.mu.Lock()
+	if now.Sub(g.checked) < diskCheckInterval {
+		full := g.full
+		g.mu.Unlock()
+		return full
+	}
+	g.checked = now
+	wasFull := g.full
+	free, total, err := g.free(g.dir)
+	// A volume that cannot be inspected, e.g. before the directory exists, is not treated as full
+	g.full = err == nil && total > 0 && float64(free)/float64(total) < g.minFree
+	full := g.full
+	g.mu.Unlock()
+
+	switch {
+	case full && !wasFull:
+		g.notice.Warnw("log volume nearly full, file logging paused", "dir", g.dir, "free_bytes", free, "min_free_percent", g.minFree*100)
+	case !full && wasFull:
+		g.notice.Infow("log volume has space again, file logging resumed", "dir", g.dir)
+	}
+	return full
+}
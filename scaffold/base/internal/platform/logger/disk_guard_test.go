@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+
+	"go.uber.org/zap"
+)
+
+func TestDiskGuard_PausesWhileVolumeIsNearlyFull(t *testing.T) {
+	// Arrange
+	var file bytes.Buffer
+	guard := newDiskGuard(&file, "logs/app.log", 5, zap.NewNop().Sugar())
+	freeBytes := uint64(10)
+	guard.free = func(dir string) (uint64, uint64, error) { return freeBytes, 100, nil }
+
+	// Act
+	_, _ = guard.Write([]byte("kept\n"))
+	freeBytes = 2
+	guard.checked = time.Time{}
+	n, err := guard.Write([]byte("dropped\n"))
+	freeBytes = 50
+	_, _ = guard.Write([]byte("still dropped until the next check\n"))
+	guard.checked = time.Time{}
+	_, _ = guard.Write([]byte("resumed\n"))
+
+	// Assert
+	if err != nil || n != len("dropped\n") {
+		t.Errorf("Write() on a full volume = %d, %v, want the length and no error", n, err)
+	}
+	if got := file.String(); got != "kept\nresumed\n" {
+		t.Errorf("file got %q", got)
+	}
+}
+
+func TestNewLogger_StreamOnlyWritesNoFile(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "app.log")
+	cfg := config.NewForTest(func(c *config.Config) {
+		c.Log.Outputs = []string{"stderr"}
+		c.Log.FilePath = path
+	})
+
+	// Act
+	log := newLogger(cfg)
+	log.Sugar.Info("hello")
+	_ = log.Logger.Sync()
+
+	// Assert
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("log file exists (err = %v), want none without the file output", err)
+	}
+}
@@ -0,0 +1,185 @@
+// Package metrics keeps application counters and gauges and writes them in the
+// Prometheus text exposition format, next to the connection pool statistics on /metrics.
+// Metrics are declared once as package variables:
+//
+//	var rejections = metrics.NewCounter("signup_rejections_total", "Rejected signups.", "reason")
+//	rejections.Inc("disposable_email")
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type metric interface {
+	write(w io.Writer) error
+}
+
+var (
+	mu       sync.Mutex
+	registry = make(map[string]metric)
+)
+
+func register(name string, m metric) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := registry[name]; ok {
+		panic(fmt.Sprintf("metrics: %s registered twice", name))
+	}
+	registry[name] = m
+}
+
+// Write writes every registered metric, sorted by name
+func Write(w io.Writer) error {
+	mu.Lock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	all := make([]metric, len(names))
+	for i, name := range names {
+		all[i] = registry[name]
+	}
+	mu.Unlock()
+
+	for _, m := range all {
+		if err := m.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Counter is a monotonically increasing count, optionally split by label values
+type Counter struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]*counterSeries
+}
+
+type counterSeries struct {
+	labelValues []string
+	value       float64
+}
+
+// NewCounter registers a counter; Inc and Add take one value per label, in order.
+// Keep label values to a small fixed set, such as a result or reason.
+func NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{name: name, help: help, labels: labels, series: make(map[string]*counterSeries)}
+	register(name, c)
+	return c
+}
+
+// Inc adds one to the series for labelValues
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v, which must not be negative, to the series for labelValues
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if len(labelValues) != len(c.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", c.name, len(c.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.series[key]
+	if !ok {
+		s = &counterSeries{labelValues: append([]string(nil), labelValues...)}
+		c.series[key] = s
+	}
+	s.value += v
+}
+
+// Value returns the current count for labelValues
+func (c *Counter) Value(labelValues ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s, ok := c.series[strings.Join(labelValues, "\xff")]; ok {
+		return s.value
+	}
+	return 0
+}
+
+func (c *Counter) write(w io.Writer) error {
+	c.mu.Lock()
+	keys := make([]string, 0, len(c.series))
+	for key := range c.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		s := c.series[key]
+		lines = append(lines, fmt.Sprintf("%s%s %g\n", c.name, labelPairs(c.labels, s.labelValues), s.value))
+	}
+	c.mu.Unlock()
+
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name); err != nil {
+		return err
+	}
+	// An unlabelled counter is reported from the start, so rates work from zero
+	if len(c.labels) == 0 && len(lines) == 0 {
+		lines = append(lines, c.name+" 0\n")
+	}
+	for _, line := range lines {
+		if _, err := io.WriteString(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Gauge is a value that goes up and down
+type Gauge struct {
+	name string
+	help string
+
+	mu    sync.Mutex
+	value float64
+}
+
+// NewGauge registers a gauge starting at 0
+func NewGauge(name, help string) *Gauge {
+	g := &Gauge{name: name, help: help}
+	register(name, g)
+	return g
+}
+
+// Set replaces the gauge's value
+func (g *Gauge) Set(v float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.value = v
+}
+
+// Value returns the gauge's current value
+func (g *Gauge) Value() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.value
+}
+
+func (g *Gauge) write(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %g\n", g.name, g.help, g.name, g.name, g.Value())
+	return err
+}
+
+// labelPairs formats {name="value",...}, escaping values as the text format requires
+func labelPairs(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = fmt.Sprintf("%s=%q", name, values[i])
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var (
+	testCounter = NewCounter("test_events_total", "Events seen by the test.", "result")
+	testGauge   = NewGauge("test_queue_depth", "Queue depth in the test.")
+)
+
+func TestWrite(t *testing.T) {
+	// Arrange
+	testCounter.Inc("success")
+	testCounter.Add(2, "failed")
+	testGauge.Set(7)
+	var buf bytes.Buffer
+
+	// Act
+	err := Write(&buf)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	want := `# HELP test_events_total Events seen by the test.
+# TYPE test_events_total counter
+test_events_total{result="failed"} 2
+test_events_total{result="success"} 1
+# HELP test_queue_depth Queue depth in the test.
+# TYPE test_queue_depth gauge
+test_queue_depth 7
+`
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("Write() =\n%s\nwant it to contain\n%s", buf.String(), want)
+	}
+}
+
+func TestCounter_WrongLabelCountPanics(t *testing.T) {
+	// Arrange
+	defer func() {
+		// Assert
+		if recover() == nil {
+			t.Error("Inc() without the label value did not panic")
+		}
+	}()
+
+	// Act
+	testCounter.Inc()
+}
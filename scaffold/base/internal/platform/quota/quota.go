@@ -0,0 +1,187 @@
+// Package quota counts API requests per user and enforces the daily and monthly quotas
+// of their role, for deployments that sell API access by plan.
+package quota
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/metrics"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/clock"
+
+	"go.uber.org/zap"
+)
+
+// Period is the calendar span a quota counts requests over, in UTC
+type Period string
+
+const (
+	Day   Period = "day"
+	Month Period = "month"
+)
+
+// rejections counts requests refused for an exceeded quota
+var rejections = metrics.NewCounter("quota_rejections_total",
+	"API requests rejected because the user's quota was used up.", "period")
+
+// Store counts requests. Implementations must be safe for concurrent use, and replicas
+// must share one store for quotas to hold across them. In Redis, Increment is INCR plus
+// EXPIREAT on the key.
+type Store interface {
+	// Increment adds one to the counter under key, which is forgotten at expiresAt, and
+	// returns the new count
+	Increment(ctx context.Context, key string, expiresAt time.Time) (int64, error)
+}
+
+// sweepThreshold is the counter count at which the memory store drops expired counters
+const sweepThreshold = 10000
+
+type counter struct {
+	count     int64
+	expiresAt time.Time
+}
+
+// MemoryStore keeps counters in process memory. Each replica counts on its own, so a
+// user can make up to the quota times the number of replicas.
+type MemoryStore struct {
+	mu       sync.Mutex
+	counters map[string]counter
+	clock    clock.Clock
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counters: make(map[string]counter), clock: clock.System()}
+}
+
+// SetClock replaces the clock used to expire counters
+func (m *MemoryStore) SetClock(c clock.Clock) {
+	m.clock = c
+}
+
+func (m *MemoryStore) Increment(_ context.Context, key string, expiresAt time.Time) (int64, error) {
+	now := m.clock.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.counters) >= sweepThreshold {
+		for k, c := range m.counters {
+			if !c.expiresAt.After(now) {
+				delete(m.counters, k)
+			}
+		}
+	}
+	c, ok := m.counters[key]
+	if !ok || !c.expiresAt.After(now) {
+		c = counter{expiresAt: expiresAt}
+	}
+	c.count++
+	m.counters[key] = c
+	return c.count, nil
+}
+
+// Usage is how much of a quota a user has used
+type Usage struct {
+	Period Period
+	Limit  int64
+	Used   int64
+	// Reset is when the period ends and the count starts over
+	Reset time.Time
+	// RetryAfter is how long until Reset, on the Limiter's clock
+	RetryAfter time.Duration
+}
+
+// Remaining returns the requests left in the period
+func (u Usage) Remaining() int64 {
+	return max(u.Limit-u.Used, 0)
+}
+
+// Exceeded reports whether the request that was counted last went over the quota
+func (u Usage) Exceeded() bool {
+	return u.Used > u.Limit
+}
+
+// Limiter counts requests per user against the quotas of their role. A nil Limiter
+// counts nothing and limits no one.
+type Limiter struct {
+	store  Store
+	cfg    config.QuotaConfig
+	clock  clock.Clock
+	logger *zap.SugaredLogger
+}
+
+// NewLimiter returns a Limiter counting in store
+func NewLimiter(store Store, cfg config.QuotaConfig, logger *zap.SugaredLogger) *Limiter {
+	return &Limiter{store: store, cfg: cfg, clock: clock.System(), logger: logger}
+}
+
+// SetClock replaces the clock that decides the current day and month
+func (l *Limiter) SetClock(c clock.Clock) {
+	l.clock = c
+}
+
+// Count records a request by userID with role and returns the usage of the quota that
+// binds first: an exceeded one, the one resetting last if several are, or else the one
+// with the fewest requests left. It returns nil when the role has no quota. Requests
+// over the quota count too. Store errors are logged only, so an unavailable store limits
+// no one.
+func (l *Limiter) Count(ctx context.Context, userID, role string) *Usage {
+	if l == nil || userID == "" {
+		return nil
+	}
+	now := l.clock.Now().UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	periods := []struct {
+		period Period
+		limits map[string]int64
+		start  time.Time
+		reset  time.Time
+	}{
+		{Day, l.cfg.Daily, day, day.AddDate(0, 0, 1)},
+		{Month, l.cfg.Monthly, month, month.AddDate(0, 1, 0)},
+	}
+
+	var binding *Usage
+	for _, p := range periods {
+		limit := limitFor(p.limits, role)
+		if limit == 0 {
+			continue
+		}
+		key := strings.Join([]string{"quota", string(p.period), p.start.Format("2006-01-02"), userID}, ":")
+		used, err := l.store.Increment(ctx, key, p.reset)
+		if err != nil {
+			l.logger.Errorw("failed to count request against quota", "user_id", userID, "period", p.period, "error", err)
+			continue
+		}
+		usage := &Usage{Period: p.period, Limit: limit, Used: used, Reset: p.reset, RetryAfter: p.reset.Sub(now)}
+		if binding == nil || binds(usage, binding) {
+			binding = usage
+		}
+	}
+	if binding != nil && binding.Exceeded() {
+		rejections.Inc(string(binding.Period))
+	}
+	return binding
+}
+
+// limitFor returns the quota of role in limits, falling back to the "*" entry; 0 means
+// no quota
+func limitFor(limits map[string]int64, role string) int64 {
+	if limit, ok := limits[role]; ok {
+		return limit
+	}
+	return limits["*"]
+}
+
+// binds reports whether a restricts the user more than b
+func binds(a, b *Usage) bool {
+	if a.Exceeded() != b.Exceeded() {
+		return a.Exceeded()
+	}
+	if a.Exceeded() {
+		return a.Reset.After(b.Reset)
+	}
+	return a.Remaining() < b.Remaining()
+}
@@ -0,0 +1,165 @@
+package risk
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/metrics"
+
+	"go.uber.org/zap"
+)
+
+// embeddedDisposableDomains is the list used until the first refresh, one domain per line
+//
+//go:embed disposable_domains.txt
+var embeddedDisposableDomains string
+
+// maxDisposableListSize bounds a downloaded list; the public lists are well under 1 MB
+const maxDisposableListSize = 8 << 20
+
+var (
+	disposableListSize = metrics.NewGauge("disposable_email_domains",
+		"Domains in the disposable email list, not counting DISPOSABLE_EMAIL_ALLOW and DISPOSABLE_EMAIL_DENY.")
+	disposableRefreshes = metrics.NewCounter("disposable_email_list_refreshes_total",
+		"Downloads of the disposable email list from DISPOSABLE_EMAIL_LIST_URL.", "result")
+)
+
+// DisposableDomains is the list of throwaway email domains, with overrides. It is safe
+// for concurrent use while Refresh replaces the list.
+type DisposableDomains struct {
+	url    string
+	allow  map[string]struct{}
+	deny   map[string]struct{}
+	client *http.Client
+	logger *zap.SugaredLogger
+
+	mu      sync.RWMutex
+	domains map[string]struct{}
+}
+
+// NewDisposableDomains starts from the embedded list; call Refresh to load cfg.ListURL
+func NewDisposableDomains(cfg config.DisposableEmailConfig, logger *zap.SugaredLogger) *DisposableDomains {
+	if logger == nil {
+		logger = zap.NewNop().Sugar()
+	}
+	d := &DisposableDomains{
+		url:    cfg.ListURL,
+		allow:  domainSet(cfg.Allow),
+		deny:   domainSet(cfg.Deny),
+		client: &http.Client{Timeout: 30 * time.Second},
+		logger: logger,
+	}
+	domains, _ := parseDomainList(strings.NewReader(embeddedDisposableDomains))
+	d.replace(domains)
+	return d
+}
+
+// Match reports whether email's domain, or a parent domain, is disposable and returns the
+// domain that matched. The most specific entry decides: an allowed subdomain of a listed
+// domain is not disposable, and a denied subdomain of an allowed one is.
+func (d *DisposableDomains) Match(email string) (string, bool) {
+	at := strings.LastIndexByte(email, '@')
+	if d == nil || at < 0 {
+		return "", false
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	for domain := strings.ToLower(email[at+1:]); domain != ""; _, domain, _ = strings.Cut(domain, ".") {
+		if _, ok := d.deny[domain]; ok {
+			return domain, true
+		}
+		if _, ok := d.allow[domain]; ok {
+			return "", false
+		}
+		if _, ok := d.domains[domain]; ok {
+			return domain, true
+		}
+	}
+	return "", false
+}
+
+// Len returns the number of listed domains, not counting overrides
+func (d *DisposableDomains) Len() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return len(d.domains)
+}
+
+// Refresh downloads the list from the configured URL and replaces the current one. On
+// failure the current list stays in use. It does nothing when no URL is configured.
+func (d *DisposableDomains) Refresh(ctx context.Context) error {
+	if d.url == "" {
+		return nil
+	}
+	domains, err := d.download(ctx)
+	if err != nil {
+		disposableRefreshes.Inc("failed")
+		return fmt.Errorf("refresh disposable email list: %w", err)
+	}
+	disposableRefreshes.Inc("success")
+	d.replace(domains)
+	d.logger.Infow("disposable email list refreshed", "domains", len(domains))
+	return nil
+}
+
+func (d *DisposableDomains) download(ctx context.Context) (map[string]struct{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %d", d.url, resp.StatusCode)
+	}
+	domains, err := parseDomainList(io.LimitReader(resp.Body, maxDisposableListSize))
+	if err != nil {
+		return nil, err
+	}
+	// An empty or truncated-to-nothing response must not unblock everything
+	if len(domains) == 0 {
+		return nil, errors.New("downloaded list is empty")
+	}
+	return domains, nil
+}
+
+func (d *DisposableDomains) replace(domains map[string]struct{}) {
+	d.mu.Lock()
+	d.domains = domains
+	d.mu.Unlock()
+	disposableListSize.Set(float64(len(domains)))
+}
+
+// parseDomainList reads one domain per line, skipping blank lines and # comments
+func parseDomainList(r io.Reader) (map[string]struct{}, error) {
+	domains := make(map[string]struct{})
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if line = strings.ToLower(strings.TrimSpace(line)); line != "" {
+			domains[line] = struct{}{}
+		}
+	}
+	return domains, scanner.Err()
+}
+
+func domainSet(entries []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(entries))
+	for _, e := range entries {
+		set[strings.ToLower(strings.TrimSpace(e))] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,300 @@
+package risk
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+)
+
+var testThresholds = config.RiskConfig{FlagScore: 30, CaptchaScore: 60, BlockScore: 100}
+
+// fixedRule reports the same score for every attempt
+type fixedRule int
+
+func (r fixedRule) Evaluate(context.Context, Attempt) (Signal, error) {
+	return Signal{Rule: "fixed", Score: int(r), Reason: "test"}, nil
+}
+
+// stubCaptcha accepts the token "solved"
+type stubCaptcha struct{}
+
+func (stubCaptcha) Verify(_ context.Context, token, _ string) (bool, error) {
+	return token == "solved", nil
+}
+
+// details returns the details of an AppError, or "" for any other result
+func details(err error) string {
+	if appErr, ok := apperrors.IsAppError(err); ok {
+		return appErr.Details
+	}
+	return ""
+}
+
+func TestEngine_Check_Actions(t *testing.T) {
+	tests := []struct {
+		name       string
+		score      int
+		captcha    CaptchaVerifier
+		token      string
+		wantAction Action
+		wantErr    string
+	}{
+		{name: "low score", score: 10, wantAction: Allow},
+		{name: "flag", score: 30, wantAction: Flag},
+		{name: "captcha missing", score: 60, captcha: stubCaptcha{}, wantAction: Captcha, wantErr: "captcha_required"},
+		{name: "captcha wrong", score: 60, captcha: stubCaptcha{}, token: "guess", wantAction: Captcha, wantErr: "captcha_required"},
+		{name: "captcha solved", score: 60, captcha: stubCaptcha{}, token: "solved", wantAction: Flag},
+		{name: "captcha not configured", score: 60, wantAction: Flag},
+		{name: "block", score: 100, captcha: stubCaptcha{}, token: "solved", wantAction: Block, wantErr: "blocked"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			engine := NewEngine(testThresholds, tt.captcha, nil, fixedRule(tt.score))
+			ctx := WithCaptchaToken(context.Background(), tt.token)
+
+			// Act
+			assessment, err := engine.Check(ctx, Attempt{Kind: KindRegister, IP: "203.0.113.7"})
+
+			// Assert
+			if assessment.Action != tt.wantAction {
+				t.Errorf("action = %s, want %s", assessment.Action, tt.wantAction)
+			}
+			if got := details(err); !strings.HasPrefix(got, tt.wantErr) || (tt.wantErr == "") != (err == nil) {
+				t.Errorf("error = %v, want details starting %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// singleUseCaptcha accepts the token "solved" once, like the real providers
+type singleUseCaptcha struct{ used bool }
+
+func (c *singleUseCaptcha) Verify(_ context.Context, token, _ string) (bool, error) {
+	if token != "solved" || c.used {
+		return false, nil
+	}
+	c.used = true
+	return true, nil
+}
+
+func TestVerifyCaptcha(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string
+	}{
+		{name: "missing", wantErr: "captcha_required"},
+		{name: "wrong", token: "guess", wantErr: "captcha_required"},
+		{name: "solved", token: "solved"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			captcha := &singleUseCaptcha{}
+			engine := NewEngine(testThresholds, captcha, nil, fixedRule(60))
+			ctx := WithCaptchaToken(context.Background(), tt.token)
+
+			// Act
+			ctx, err := VerifyCaptcha(ctx, captcha, "203.0.113.7")
+
+			// Assert
+			if got := details(err); !strings.HasPrefix(got, tt.wantErr) || (tt.wantErr == "") != (err == nil) {
+				t.Fatalf("VerifyCaptcha() error = %v, want details starting %q", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			// The engine must not spend the token a second time
+			if assessment, err := engine.Check(ctx, Attempt{Kind: KindRegister, IP: "203.0.113.7"}); err != nil || assessment.Action != Flag {
+				t.Errorf("Check() after VerifyCaptcha = %s, %v; want flag, nil", assessment.Action, err)
+			}
+		})
+	}
+}
+
+func TestEngine_Check_NilAllows(t *testing.T) {
+	// Arrange
+	var engine *Engine
+
+	// Act
+	assessment, err := engine.Check(context.Background(), Attempt{Kind: KindLogin})
+
+	// Assert
+	if err != nil || assessment.Action != Allow {
+		t.Errorf("Check() = %s, %v; want allow", assessment.Action, err)
+	}
+}
+
+func TestIPListRule(t *testing.T) {
+	// Arrange
+	rule, err := NewIPListRule([]string{"203.0.113.7", "198.51.100.0/24"}, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Act
+	single, _ := rule.Evaluate(context.Background(), Attempt{IP: "203.0.113.7"})
+	network, _ := rule.Evaluate(context.Background(), Attempt{IP: "198.51.100.42"})
+	other, _ := rule.Evaluate(context.Background(), Attempt{IP: "192.0.2.1"})
+
+	// Assert
+	if single.Score != 100 || network.Score != 100 {
+		t.Errorf("denylisted scores = %d, %d; want 100", single.Score, network.Score)
+	}
+	if other.Score != 0 {
+		t.Errorf("other address scored %d", other.Score)
+	}
+	if _, err := NewIPListRule([]string{"not-an-ip"}, 100); err == nil {
+		t.Error("NewIPListRule() accepted an invalid entry")
+	}
+}
+
+func TestDisposableEmailRule(t *testing.T) {
+	// Arrange
+	rule := NewDisposableEmailRule(NewDisposableDomains(config.DisposableEmailConfig{}, nil), 40)
+	ctx := context.Background()
+
+	// Act
+	disposable, _ := rule.Evaluate(ctx, Attempt{Kind: KindRegister, Email: "bot@Mailinator.com"})
+	regular, _ := rule.Evaluate(ctx, Attempt{Kind: KindRegister, Email: "jane@example.com"})
+	login, _ := rule.Evaluate(ctx, Attempt{Kind: KindLogin, Email: "bot@mailinator.com"})
+
+	// Assert
+	if disposable.Score != 40 {
+		t.Errorf("disposable address scored %d, want 40", disposable.Score)
+	}
+	if regular.Score != 0 || login.Score != 0 {
+		t.Errorf("regular address or login scored %d, %d", regular.Score, login.Score)
+	}
+}
+
+func TestDisposableDomains_Match(t *testing.T) {
+	// Arrange
+	domains := NewDisposableDomains(config.DisposableEmailConfig{
+		Allow: []string{"team.yopmail.com", "example.com"},
+		Deny:  []string{"burner.test", "spam.example.com"},
+	}, nil)
+
+	tests := []struct {
+		email      string
+		wantDomain string
+		want       bool
+	}{
+		{email: "bot@mailinator.com", wantDomain: "mailinator.com", want: true},
+		{email: "bot@eu.mailinator.com", wantDomain: "mailinator.com", want: true},
+		{email: "bot@burner.test", wantDomain: "burner.test", want: true},
+		{email: "jane@team.yopmail.com", want: false},
+		{email: "bot@spam.example.com", wantDomain: "spam.example.com", want: true},
+		{email: "jane@example.com", want: false},
+		{email: "jane@gmail.com", want: false},
+		{email: "not-an-email", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.email, func(t *testing.T) {
+			// Act
+			domain, ok := domains.Match(tt.email)
+
+			// Assert
+			if ok != tt.want || domain != tt.wantDomain {
+				t.Errorf("Match(%q) = %q, %v; want %q, %v", tt.email, domain, ok, tt.wantDomain, tt.want)
+			}
+		})
+	}
+}
+
+func TestDisposableDomains_Refresh(t *testing.T) {
+	// Arrange
+	body := "# test list\nfresh.test\n\nother.test # trailing comment\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+	domains := NewDisposableDomains(config.DisposableEmailConfig{ListURL: server.URL}, nil)
+	successes := disposableRefreshes.Value("success")
+
+	// Act
+	err := domains.Refresh(context.Background())
+	_, fresh := domains.Match("bot@other.test")
+	_, embedded := domains.Match("bot@mailinator.com")
+	body = ""
+	emptyErr := domains.Refresh(context.Background())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if !fresh || embedded || domains.Len() != 2 {
+		t.Errorf("after refresh: downloaded domain matched %v, embedded matched %v, %d domains; want only the 2 downloaded", fresh, embedded, domains.Len())
+	}
+	if emptyErr == nil || domains.Len() != 2 {
+		t.Errorf("empty download: error %v, %d domains; want an error and the previous list kept", emptyErr, domains.Len())
+	}
+	if got := disposableRefreshes.Value("success") - successes; got != 1 {
+		t.Errorf("successful refreshes counted %v, want 1", got)
+	}
+}
+
+func TestVelocityRule(t *testing.T) {
+	// Arrange
+	clk := testutil.NewFakeClock(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+	rule := NewVelocityRule(time.Hour, map[Kind]int{KindRegister: 2}, 60)
+	rule.SetClock(clk)
+	ctx := context.Background()
+	attempt := Attempt{Kind: KindRegister, IP: "203.0.113.7"}
+	_, _ = rule.Evaluate(ctx, attempt)
+	_, _ = rule.Evaluate(ctx, attempt)
+
+	// Act
+	third, _ := rule.Evaluate(ctx, attempt)
+	otherIP, _ := rule.Evaluate(ctx, Attempt{Kind: KindRegister, IP: "192.0.2.1"})
+	login, _ := rule.Evaluate(ctx, Attempt{Kind: KindLogin, IP: "203.0.113.7"})
+	clk.Advance(time.Hour)
+	nextWindow, _ := rule.Evaluate(ctx, attempt)
+
+	// Assert
+	if third.Score != 60 {
+		t.Errorf("third attempt scored %d, want 60", third.Score)
+	}
+	if otherIP.Score != 0 || login.Score != 0 || nextWindow.Score != 0 {
+		t.Errorf("other IP, unlimited kind or next window scored %d, %d, %d", otherIP.Score, login.Score, nextWindow.Score)
+	}
+}
+
+func TestSiteVerifier(t *testing.T) {
+	// Arrange
+	var form string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		form = r.PostForm.Encode()
+		if r.PostForm.Get("response") == "solved" {
+			_, _ = w.Write([]byte(`{"success":true}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"success":false,"error-codes":["invalid-input-response"]}`))
+	}))
+	defer server.Close()
+	verifier := NewSiteVerifier(server.URL, "s3cret")
+
+	// Act
+	ok, err := verifier.Verify(context.Background(), "solved", "203.0.113.7")
+	bad, _ := verifier.Verify(context.Background(), "guess", "")
+
+	// Assert
+	if err != nil || !ok {
+		t.Fatalf("Verify() = %v, %v; want true", ok, err)
+	}
+	if bad {
+		t.Error("Verify() accepted a wrong token")
+	}
+	if !strings.Contains(form, "secret=s3cret") {
+		t.Errorf("request form = %q, want the secret", form)
+	}
+}
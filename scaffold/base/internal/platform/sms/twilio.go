@@ -0,0 +1,64 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const twilioAPIBase = "https://api.twilio.com/2010-04-01"
+
+// TwilioSender sends messages through the Twilio Programmable Messaging REST API
+type TwilioSender struct {
+	accountSID string
+	authToken  string
+	from       string
+	baseURL    string
+	client     *http.Client
+}
+
+func NewTwilioSender(accountSID, authToken, from string) (*TwilioSender, error) {
+	if accountSID == "" || authToken == "" || from == "" {
+		return nil, errors.New("twilio provider requires TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN and TWILIO_FROM_NUMBER")
+	}
+	return &TwilioSender{
+		accountSID: accountSID,
+		authToken:  authToken,
+		from:       from,
+		baseURL:    twilioAPIBase,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (s *TwilioSender) Send(ctx context.Context, to, message string) error {
+	endpoint := fmt.Sprintf("%s/Accounts/%s/Messages.json", s.baseURL, url.PathEscape(s.accountSID))
+	form := url.Values{
+		"To":   {to},
+		"From": {s.from},
+		"Body": {message},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(s.accountSID, s.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("twilio request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("twilio returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
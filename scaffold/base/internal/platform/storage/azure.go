@@ -0,0 +1,339 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// azureVersion is the Blob service REST API version requests and SAS tokens use
+const azureVersion = "2020-12-06"
+
+// AzureStorage stores objects as block blobs in a container of an Azure storage account,
+// through the Blob service REST API with Shared Key authorization
+type AzureStorage struct {
+	account   string
+	key       []byte
+	container string
+	// endpoint is the Blob service URL, e.g. https://<account>.blob.core.windows.net
+	endpoint string
+	client   *http.Client
+	clock    func() time.Time
+}
+
+// NewAzureStorage connects to container of account, creating it when missing. key is the
+// base64 access key of the account; endpoint defaults to the account's public one.
+func NewAzureStorage(ctx context.Context, account, key, container, endpoint string, logger *zap.SugaredLogger) (*AzureStorage, error) {
+	if account == "" || key == "" || container == "" {
+		return nil, errors.New("azure storage requires AZURE_STORAGE_ACCOUNT, AZURE_STORAGE_KEY and a container")
+	}
+	decoded, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("decode AZURE_STORAGE_KEY: %w", err)
+	}
+	if endpoint == "" {
+		endpoint = "https://" + account + ".blob.core.windows.net"
+	}
+	s := &AzureStorage{
+		account:   account,
+		key:       decoded,
+		container: container,
+		endpoint:  strings.TrimSuffix(endpoint, "/"),
+		client:    &http.Client{Timeout: 2 * time.Minute},
+		clock:     time.Now,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	resp, err := s.do(ctx, http.MethodPut, s.endpoint+"/"+container+"?restype=container", nil, -1, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		logger.Infof("Created Azure container: %s", container)
+	case http.StatusConflict:
+		logger.Infof("Using existing Azure container: %s", container)
+	default:
+		return nil, fmt.Errorf("create container %s: %s", container, resp.Status)
+	}
+	return s, nil
+}
+
+func (s *AzureStorage) Put(ctx context.Context, name string, r io.Reader, size int64, opts PutOptions) error {
+	header := azureMetadata(opts.Metadata)
+	header.Set("x-ms-blob-type", "BlockBlob")
+	header.Set("Content-Type", opts.ContentType)
+	resp, err := s.do(ctx, http.MethodPut, s.blobURL(name, nil), r, size, header)
+	if err != nil {
+		return err
+	}
+	return azureResult(resp, http.StatusCreated)
+}
+
+func (s *AzureStorage) Get(ctx context.Context, name string) (io.ReadCloser, ObjectInfo, error) {
+	resp, err := s.do(ctx, http.MethodGet, s.blobURL(name, nil), nil, -1, nil)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, ObjectInfo{}, azureResult(resp, http.StatusOK)
+	}
+	return resp.Body, azureInfo(resp), nil
+}
+
+func (s *AzureStorage) Stat(ctx context.Context, name string) (ObjectInfo, error) {
+	resp, err := s.do(ctx, http.MethodHead, s.blobURL(name, nil), nil, -1, nil)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	if err := azureResult(resp, http.StatusOK); err != nil {
+		return ObjectInfo{}, err
+	}
+	return azureInfo(resp), nil
+}
+
+func (s *AzureStorage) Delete(ctx context.Context, name string) error {
+	resp, err := s.do(ctx, http.MethodDelete, s.blobURL(name, nil), nil, -1, nil)
+	if err != nil {
+		return err
+	}
+	if err := azureResult(resp, http.StatusAccepted); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// Copy reads the blob src and writes it to dst. Copy Blob would avoid the transfer but
+// may finish asynchronously, leaving callers to poll for it.
+func (s *AzureStorage) Copy(ctx context.Context, src, dst string) error {
+	object, info, err := s.Get(ctx, src)
+	if err != nil {
+		return err
+	}
+	defer object.Close()
+	return s.Put(ctx, dst, object, info.Size, PutOptions{ContentType: info.ContentType, Metadata: info.Metadata})
+}
+
+// SignURL returns a URL with a service SAS for the blob. A signed PUT must send
+// x-ms-blob-type; the SAS cannot bind the content type or size, which callers check
+// afterwards.
+func (s *AzureStorage) SignURL(_ context.Context, method, name string, expiry time.Duration, contentType string, _ int64) (*SignedRequest, error) {
+	permissions := "r"
+	if method == http.MethodPut {
+		permissions = "cw"
+	}
+	now := s.clock().UTC()
+	query := url.Values{
+		"sv":  {azureVersion},
+		"sr":  {"b"},
+		"sp":  {permissions},
+		"st":  {now.Add(-5 * time.Minute).Format(time.RFC3339)},
+		"se":  {now.Add(expiry).Format(time.RFC3339)},
+		"spr": {"https,http"},
+	}
+	stringToSign := strings.Join([]string{
+		permissions,
+		query.Get("st"),
+		query.Get("se"),
+		"/blob/" + s.account + "/" + s.container + "/" + name,
+		"", // signed identifier
+		"", // signed IP
+		query.Get("spr"),
+		azureVersion,
+		"b",
+		"",                 // snapshot time
+		"",                 // encryption scope
+		"", "", "", "", "", // response header overrides
+	}, "\n")
+	query.Set("sig", s.sign(stringToSign))
+
+	signed := &SignedRequest{URL: s.blobURL(name, query)}
+	if method == http.MethodPut {
+		signed.Headers = map[string]string{"Content-Type": contentType, "x-ms-blob-type": "BlockBlob"}
+	}
+	return signed, nil
+}
+
+// NewMultipart returns an ID the blocks of the upload are named after; Azure keeps
+// uncommitted blocks with the blob, so nothing is created yet
+func (s *AzureStorage) NewMultipart(_ context.Context, _ string, _ PutOptions) (string, error) {
+	return uuid.NewString(), nil
+}
+
+func (s *AzureStorage) PutPart(ctx context.Context, name, uploadID string, number int, r io.Reader, size int64) (string, error) {
+	blockID := azureBlockID(uploadID, number)
+	query := url.Values{"comp": {"block"}, "blockid": {blockID}}
+	resp, err := s.do(ctx, http.MethodPut, s.blobURL(name, query), r, size, nil)
+	if err != nil {
+		return "", err
+	}
+	if err := azureResult(resp, http.StatusCreated); err != nil {
+		return "", err
+	}
+	return blockID, nil
+}
+
+// CompleteMultipart commits the blocks of the upload as the content of the blob
+func (s *AzureStorage) CompleteMultipart(ctx context.Context, name, uploadID string, parts []Part, opts PutOptions) error {
+	var body bytes.Buffer
+	body.WriteString(xml.Header + "<BlockList>")
+	for _, part := range parts {
+		body.WriteString("<Latest>" + azureBlockID(uploadID, part.Number) + "</Latest>")
+	}
+	body.WriteString("</BlockList>")
+
+	header := azureMetadata(opts.Metadata)
+	header.Set("x-ms-blob-content-type", opts.ContentType)
+	header.Set("Content-Type", "application/xml")
+	resp, err := s.do(ctx, http.MethodPut, s.blobURL(name, url.Values{"comp": {"blocklist"}}), &body, int64(body.Len()), header)
+	if err != nil {
+		return err
+	}
+	return azureResult(resp, http.StatusCreated)
+}
+
+// AbortMultipart does nothing: Azure discards uncommitted blocks after a week
+func (s *AzureStorage) AbortMultipart(context.Context, string, string) error {
+	return nil
+}
+
+// blobURL is the URL of the blob name with query
+func (s *AzureStorage) blobURL(name string, query url.Values) string {
+	u := s.endpoint + "/" + s.container + "/" + (&url.URL{Path: name}).EscapedPath()
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+// do sends a request authorized with the account key. size is the length of body, or -1
+// without one.
+func (s *AzureStorage) do(ctx context.Context, method, rawURL string, body io.Reader, size int64, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, body)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if size >= 0 {
+		req.ContentLength = size
+		if size == 0 {
+			req.Body = http.NoBody
+		}
+	}
+	req.Header.Set("x-ms-date", s.clock().UTC().Format(http.TimeFormat))
+	req.Header.Set("x-ms-version", azureVersion)
+	req.Header.Set("Authorization", "SharedKey "+s.account+":"+s.sign(s.stringToSign(req)))
+	return s.client.Do(req)
+}
+
+// stringToSign builds the Shared Key string to sign of a request
+func (s *AzureStorage) stringToSign(req *http.Request) string {
+	contentLength := ""
+	if req.ContentLength > 0 {
+		contentLength = strconv.FormatInt(req.ContentLength, 10)
+	}
+	lines := []string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		contentLength,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		"", // Date; x-ms-date is used instead
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+	}
+
+	var msHeaders []string
+	for key := range req.Header {
+		if lower := strings.ToLower(key); strings.HasPrefix(lower, "x-ms-") {
+			msHeaders = append(msHeaders, lower+":"+strings.TrimSpace(req.Header.Get(key)))
+		}
+	}
+	sort.Strings(msHeaders)
+
+	resource := "/" + s.account + req.URL.EscapedPath()
+	query := req.URL.Query()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		values := query[key]
+		sort.Strings(values)
+		resource += "\n" + strings.ToLower(key) + ":" + strings.Join(values, ",")
+	}
+
+	return strings.Join(lines, "\n") + "\n" + strings.Join(append(msHeaders, resource), "\n")
+}
+
+// sign returns the base64 HMAC-SHA256 of stringToSign under the account key
+func (s *AzureStorage) sign(stringToSign string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// azureBlockID names part number of an upload; the IDs of a blob's blocks must all have
+// the same length
+func azureBlockID(uploadID string, number int) string {
+	return base64.StdEncoding.EncodeToString(fmt.Appendf(nil, "%s-%05d", uploadID, number))
+}
+
+// azureMetadata turns object metadata into x-ms-meta headers; Azure metadata names must
+// be C# identifiers, so dashes become underscores
+func azureMetadata(metadata map[string]string) http.Header {
+	header := make(http.Header)
+	for key, value := range metadata {
+		header.Set("x-ms-meta-"+strings.ReplaceAll(key, "-", "_"), value)
+	}
+	return header
+}
+
+// azureInfo describes the blob of a GET or HEAD response
+func azureInfo(resp *http.Response) ObjectInfo {
+	metadata := map[string]string{}
+	for key := range resp.Header {
+		if lower := strings.ToLower(key); strings.HasPrefix(lower, "x-ms-meta-") {
+			metadata[strings.ReplaceAll(strings.TrimPrefix(lower, "x-ms-meta-"), "_", "-")] = resp.Header.Get(key)
+		}
+	}
+	return ObjectInfo{Size: resp.ContentLength, ContentType: resp.Header.Get("Content-Type"), Metadata: metadata}
+}
+
+// azureResult closes the response, returning ErrNotFound for 404 and an error for any
+// status other than want
+func azureResult(resp *http.Response, want int) error {
+	defer resp.Body.Close()
+	if resp.StatusCode == want {
+		return nil
+	}
+	code := resp.Header.Get("x-ms-error-code")
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", ErrNotFound, code)
+	}
+	return fmt.Errorf("azure storage: %s %s", resp.Status, code)
+}
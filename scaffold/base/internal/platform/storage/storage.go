@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrNotFound is returned for objects, and multipart uploads, that do not exist
+	ErrNotFound = errors.New("object not found")
+	// ErrInvalidName is returned for object names that are empty, absolute or leave the
+	// storage root
+	ErrInvalidName = errors.New("invalid object name")
+)
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Size        int64
+	ContentType string
+	// Metadata holds the user metadata the object was stored with
+	Metadata map[string]string
+}
+
+// PutOptions describes an object being stored
+type PutOptions struct {
+	ContentType string
+	// Metadata is stored with the object; keys are lower case words separated by dashes
+	Metadata map[string]string
+}
+
+// SignedRequest is a request anyone can send to storage until it expires
+type SignedRequest struct {
+	URL string
+	// Headers must be sent exactly as given; they are part of the signature
+	Headers map[string]string
+}
+
+// ObjectStorage stores objects by name in one bucket or container
+type ObjectStorage interface {
+	// Put stores size bytes of r under name, replacing any object stored there
+	Put(ctx context.Context, name string, r io.Reader, size int64, opts PutOptions) error
+	// Get returns the content of an object, or ErrNotFound. The caller closes the reader.
+	Get(ctx context.Context, name string) (io.ReadCloser, ObjectInfo, error)
+	// Stat describes an object, or returns ErrNotFound
+	Stat(ctx context.Context, name string) (ObjectInfo, error)
+	// Delete removes an object; removing a missing object is not an error
+	Delete(ctx context.Context, name string) error
+	// Copy stores a copy of the object src, with its content type and metadata, under
+	// dst; ErrNotFound when src is missing
+	Copy(ctx context.Context, src, dst string) error
+	// SignURL returns a request for method (GET or PUT) on name that needs no
+	// credentials until expiry. For PUT, contentType is the only one storage accepts and
+	// size the most bytes it should; storages that cannot bind the size leave checking it
+	// to the caller.
+	SignURL(ctx context.Context, method, name string, expiry time.Duration, contentType string, size int64) (*SignedRequest, error)
+}
+
+// Part is a stored part of a multipart upload
+type Part struct {
+	Number int
+	ETag   string
+}
+
+// Multipart is implemented by storages that assemble objects from parts stored
+// separately, which resumable uploads need
+type Multipart interface {
+	// NewMultipart starts a multipart upload to name and returns its ID
+	NewMultipart(ctx context.Context, name string, opts PutOptions) (string, error)
+	// PutPart stores size bytes of r as part number (1 to 10000) and returns its ETag
+	PutPart(ctx context.Context, name, uploadID string, number int, r io.Reader, size int64) (string, error)
+	// CompleteMultipart joins parts, in the given order, into the object name
+	CompleteMultipart(ctx context.Context, name, uploadID string, parts []Part, opts PutOptions) error
+	// AbortMultipart discards the parts of an upload; aborting an unknown upload is not
+	// an error
+	AbortMultipart(ctx context.Context, name, uploadID string) error
+}
+
+// Server is implemented by storages whose signed URLs point at the API itself
+type Server interface {
+	// ServeSigned handles requests to signed URLs; the token at their end is the "token"
+	// path parameter
+	ServeSigned(c *gin.Context)
+}
+
+// New builds the ObjectStorage selected by STORAGE_DRIVER, checking that it can be
+// reached
+func New(ctx context.Context, cfg config.StorageConfig, minioCfg config.MinIOConfig, logger *zap.SugaredLogger) (ObjectStorage, error) {
+	switch cfg.Backend {
+	case config.StorageMinIO, "":
+		return NewS3Storage(ctx, minioCfg, "", true, logger)
+	case config.StorageS3:
+		return NewS3Storage(ctx, minioCfg, cfg.Region, false, logger)
+	case config.StorageGCS:
+		// Cloud Storage speaks the S3 protocol with HMAC keys; its region is "auto"
+		return NewS3Storage(ctx, minioCfg, "auto", false, logger)
+	case config.StorageAzure:
+		return NewAzureStorage(ctx, cfg.AzureAccount, cfg.AzureKey, cfg.AzureContainer, cfg.AzureEndpoint, logger)
+	case config.StorageLocal:
+		return NewLocalStorage(cfg.LocalDir, cfg.LocalURL, cfg.LocalSecret)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+}
+
+// Location describes where the storage selected by cfg keeps objects, for status reports
+func Location(cfg config.StorageConfig, minioCfg config.MinIOConfig) string {
+	switch cfg.Backend {
+	case config.StorageAzure:
+		endpoint := cfg.AzureEndpoint
+		if endpoint == "" {
+			endpoint = "https://" + cfg.AzureAccount + ".blob.core.windows.net"
+		}
+		return endpoint + "/" + cfg.AzureContainer
+	case config.StorageLocal:
+		return cfg.LocalDir
+	default:
+		return minioCfg.MinioEndpoint + "/" + minioCfg.MinioBucket
+	}
+}
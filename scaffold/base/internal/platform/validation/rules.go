@@ -0,0 +1,76 @@
+package validation
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// DefaultReservedUsernames are names users cannot register, since they could pass for
+// staff or system accounts
+var DefaultReservedUsernames = []string{
+	"admin", "administrator", "root", "system", "support", "help", "security",
+	"moderator", "staff", "official", "noreply", "postmaster", "webmaster", "api",
+}
+
+// UsernamePolicy is the username_policy rule: letters, digits and underscores, starting
+// with a letter or digit, and not one of reserved (DefaultReservedUsernames when empty),
+// compared case-insensitively. Length is left to min and max.
+func UsernamePolicy(reserved ...string) Rule {
+	if len(reserved) == 0 {
+		reserved = DefaultReservedUsernames
+	}
+	blocked := make(map[string]bool, len(reserved))
+	for _, name := range reserved {
+		blocked[strings.ToLower(name)] = true
+	}
+
+	return Rule{
+		Tag:     "username_policy",
+		Message: "must contain only letters, digits and underscores, start with a letter or digit and not be a reserved name",
+		Func: func(fl validator.FieldLevel) bool {
+			username := fl.Field().String()
+			if username == "" || username[0] == '_' || blocked[strings.ToLower(username)] {
+				return false
+			}
+			for _, r := range username {
+				if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_') {
+					return false
+				}
+			}
+			return true
+		},
+	}
+}
+
+// StrongPassword is the strong_password rule: at least three of lowercase letters,
+// uppercase letters, digits and other characters. Length is left to min.
+func StrongPassword() Rule {
+	return Rule{
+		Tag:     "strong_password",
+		Message: "must contain at least three of: lowercase letters, uppercase letters, digits and symbols",
+		Func: func(fl validator.FieldLevel) bool {
+			var lower, upper, digit, other bool
+			for _, r := range fl.Field().String() {
+				switch {
+				case unicode.IsLower(r):
+					lower = true
+				case unicode.IsUpper(r):
+					upper = true
+				case unicode.IsDigit(r):
+					digit = true
+				default:
+					other = true
+				}
+			}
+			classes := 0
+			for _, present := range []bool{lower, upper, digit, other} {
+				if present {
+					classes++
+				}
+			}
+			return classes >= 3
+		},
+	}
+}
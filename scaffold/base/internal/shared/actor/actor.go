@@ -0,0 +1,58 @@
+// Package actor carries the authenticated caller through request contexts so that
+// services can attribute changes without depending on the HTTP layer.
+package actor
+
+import "context"
+
+type ctxKey struct{}
+
+type clientIPKey struct{}
+
+type impersonatorKey struct{}
+
+type userAgentKey struct{}
+
+// WithUserID returns a copy of ctx that records userID as the acting user
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, userID)
+}
+
+// UserID returns the acting user recorded in ctx, or "" for anonymous and system calls
+func UserID(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// WithClientIP returns a copy of ctx that records the caller's IP address
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// ClientIP returns the caller's IP address recorded in ctx, or "" outside of requests
+func ClientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
+
+// WithUserAgent returns a copy of ctx that records the caller's User-Agent
+func WithUserAgent(ctx context.Context, userAgent string) context.Context {
+	return context.WithValue(ctx, userAgentKey{}, userAgent)
+}
+
+// UserAgent returns the caller's User-Agent recorded in ctx, or "" outside of requests
+func UserAgent(ctx context.Context) string {
+	ua, _ := ctx.Value(userAgentKey{}).(string)
+	return ua
+}
+
+// WithImpersonatorID returns a copy of ctx that records the admin impersonating the acting user
+func WithImpersonatorID(ctx context.Context, adminID string) context.Context {
+	return context.WithValue(ctx, impersonatorKey{}, adminID)
+}
+
+// ImpersonatorID returns the admin impersonating the acting user, or "" when the user acts
+// for themselves
+func ImpersonatorID(ctx context.Context) string {
+	id, _ := ctx.Value(impersonatorKey{}).(string)
+	return id
+}
@@ -0,0 +1,29 @@
+// Package bufpool shares byte buffers between requests, so hot paths such as upload
+// copying and JSON encoding reuse memory instead of growing a fresh buffer every time.
+package bufpool
+
+import (
+	"bytes"
+	"sync"
+)
+
+// MaxPooledSize is the largest buffer kept for reuse. It covers the biggest accepted
+// upload; larger buffers are left to the garbage collector so one outlier does not pin
+// memory. The pool itself is emptied by the garbage collector when idle.
+const MaxPooledSize = 16 << 20
+
+var pool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+// Get returns an empty buffer; hand it back with Put once nothing references its bytes
+func Get() *bytes.Buffer {
+	return pool.Get().(*bytes.Buffer)
+}
+
+// Put resets b and returns it to the pool
+func Put(b *bytes.Buffer) {
+	if b == nil || b.Cap() > MaxPooledSize {
+		return
+	}
+	b.Reset()
+	pool.Put(b)
+}
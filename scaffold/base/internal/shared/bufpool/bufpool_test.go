@@ -0,0 +1,28 @@
+package bufpool
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPut_ResetsAndDropsOversizedBuffers(t *testing.T) {
+	// Arrange
+	b := Get()
+	b.WriteString("left over")
+
+	// Act
+	Put(b)
+	Put(bytes.NewBuffer(make([]byte, 0, MaxPooledSize+1)))
+	Put(nil)
+
+	// Assert
+	for i := 0; i < 4; i++ {
+		got := Get()
+		if got.Len() != 0 {
+			t.Fatalf("Get() returned a buffer holding %q", got.String())
+		}
+		if got.Cap() > MaxPooledSize {
+			t.Fatalf("Get() returned an oversized buffer (cap %d)", got.Cap())
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package clientid
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    string
+		userAgent string
+		want      Client
+	}{
+		{name: "header with version", header: "iOS-App/2.3.1", userAgent: "CFNetwork/1410", want: Client{ID: "ios-app", Version: "2.3.1", Source: SourceHeader}},
+		{name: "header without version", header: "billing-sync", want: Client{ID: "billing-sync", Source: SourceHeader}},
+		{name: "bad version dropped", header: "cli/1.0 beta", want: Client{ID: "cli", Source: SourceHeader}},
+		{name: "malformed header falls back", header: "<script>", userAgent: "okhttp/4.12.0", want: Client{ID: "okhttp", Version: "4.12.0", Source: SourceUserAgent}},
+		{name: "user agent product", userAgent: "curl/8.5.0", want: Client{ID: "curl", Version: "8.5.0", Source: SourceUserAgent}},
+		{name: "browser", userAgent: "Mozilla/5.0 (X11; Linux x86_64) Firefox/128.0", want: Client{ID: Browser, Source: SourceUserAgent}},
+		{name: "nothing", want: Client{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			got := Parse(tt.header, tt.userAgent)
+
+			// Assert
+			if got != tt.want {
+				t.Errorf("Parse(%q, %q) = %+v, want %+v", tt.header, tt.userAgent, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MultiError holds every failure of an operation that carries on past the first one, such
+// as a batch import or a shutdown, so none of the causes is lost
+type MultiError struct {
+	Errs []error
+}
+
+// Join combines errs, skipping nils and flattening nested MultiErrors. It returns nil when
+// no error is left, the error itself when one is, and a *MultiError otherwise.
+func Join(errs ...error) error {
+	var joined []error
+	for _, err := range errs {
+		if multi, ok := err.(*MultiError); ok {
+			if multi != nil {
+				joined = append(joined, multi.Errs...)
+			}
+		} else if err != nil {
+			joined = append(joined, err)
+		}
+	}
+	switch len(joined) {
+	case 0:
+		return nil
+	case 1:
+		return joined[0]
+	default:
+		return &MultiError{Errs: joined}
+	}
+}
+
+// Error lists every message, separated by semicolons
+func (m *MultiError) Error() string {
+	messages := make([]string, len(m.Errs))
+	for i, err := range m.Errs {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%d errors: %s", len(m.Errs), strings.Join(messages, "; "))
+}
+
+// Unwrap lets errors.Is and errors.As match any of the errors
+func (m *MultiError) Unwrap() []error {
+	return m.Errs
+}
+
+// AppError folds the errors into one AppError for a response: the type, status and
+// message of the most severe AppError among them, with every message in Details. Errors
+// that are not AppErrors count as internal ones. It returns nil when none is an AppError.
+func (m *MultiError) AppError() *AppError {
+	var primary *AppError
+	hasAppError := false
+	retryAfter := 0
+	details := make([]string, len(m.Errs))
+	for i, err := range m.Errs {
+		details[i] = err.Error()
+		var appErr *AppError
+		if !errors.As(err, &appErr) {
+			appErr = NewAppError(InternalError, "An unexpected error occurred")
+		} else {
+			hasAppError = true
+			if appErr.Details != "" {
+				details[i] += ": " + appErr.Details
+			}
+			retryAfter = max(retryAfter, appErr.RetryAfter)
+		}
+		if primary == nil || appErr.HTTPStatus > primary.HTTPStatus {
+			primary = appErr
+		}
+	}
+	if !hasAppError {
+		return nil
+	}
+	folded := NewAppErrorWithDetails(primary.Type, primary.Message, strings.Join(details, "; "))
+	folded.RetryAfter = retryAfter
+	return folded
+}
@@ -0,0 +1,181 @@
+package i18n
+
+// builtIn translates the most common error messages and every validation message of
+// internal/platform/validation. Rows with a lowercase English text cover the predefined
+// errors in internal/shared/errors.
+var builtIn = []struct {
+	en, de, es, fr string
+}{
+	{"validation failed",
+		"Validierung fehlgeschlagen",
+		"La validación ha fallado",
+		"La validation a échoué"},
+	{"Invalid request payload",
+		"Ungültiger Anfrageinhalt",
+		"Cuerpo de la solicitud no válido",
+		"Corps de requête invalide"},
+	{"An unexpected error occurred",
+		"Ein unerwarteter Fehler ist aufgetreten",
+		"Se produjo un error inesperado",
+		"Une erreur inattendue s'est produite"},
+	{"User not found",
+		"Benutzer nicht gefunden",
+		"Usuario no encontrado",
+		"Utilisateur introuvable"},
+	{"user not found",
+		"Benutzer nicht gefunden",
+		"Usuario no encontrado",
+		"Utilisateur introuvable"},
+	{"User authentication required",
+		"Anmeldung erforderlich",
+		"Se requiere autenticación",
+		"Authentification requise"},
+	{"Invalid credentials",
+		"Ungültige Anmeldedaten",
+		"Credenciales no válidas",
+		"Identifiants invalides"},
+	{"Login failed",
+		"Anmeldung fehlgeschlagen",
+		"Error al iniciar sesión",
+		"Échec de la connexion"},
+	{"Account is inactive",
+		"Das Konto ist inaktiv",
+		"La cuenta está inactiva",
+		"Le compte est inactif"},
+	{"insufficient permissions",
+		"Unzureichende Berechtigungen",
+		"Permisos insuficientes",
+		"Autorisations insuffisantes"},
+	{"Email already registered",
+		"Diese E-Mail-Adresse ist bereits registriert",
+		"El correo electrónico ya está registrado",
+		"Cette adresse e-mail est déjà enregistrée"},
+	{"email already registered",
+		"Diese E-Mail-Adresse ist bereits registriert",
+		"El correo electrónico ya está registrado",
+		"Cette adresse e-mail est déjà enregistrée"},
+	{"Username already taken",
+		"Dieser Benutzername ist bereits vergeben",
+		"El nombre de usuario ya está en uso",
+		"Ce nom d'utilisateur est déjà pris"},
+	{"username already taken",
+		"Dieser Benutzername ist bereits vergeben",
+		"El nombre de usuario ya está en uso",
+		"Ce nom d'utilisateur est déjà pris"},
+	{"phone number already registered",
+		"Diese Telefonnummer ist bereits registriert",
+		"El número de teléfono ya está registrado",
+		"Ce numéro de téléphone est déjà enregistré"},
+	{"invalid token",
+		"Ungültiges Token",
+		"Token no válido",
+		"Jeton invalide"},
+	{"invalid or expired refresh token",
+		"Ungültiges oder abgelaufenes Refresh-Token",
+		"Token de actualización no válido o caducado",
+		"Jeton de rafraîchissement invalide ou expiré"},
+	{"invalid or expired verification code",
+		"Ungültiger oder abgelaufener Bestätigungscode",
+		"Código de verificación no válido o caducado",
+		"Code de vérification invalide ou expiré"},
+	{"File not found",
+		"Datei nicht gefunden",
+		"Archivo no encontrado",
+		"Fichier introuvable"},
+
+	// Validation messages
+	{"%s is required",
+		"%s ist erforderlich",
+		"%s es obligatorio",
+		"%s est obligatoire"},
+	{"%s must be a valid email",
+		"%s muss eine gültige E-Mail-Adresse sein",
+		"%s debe ser un correo electrónico válido",
+		"%s doit être une adresse e-mail valide"},
+	{"%s must have a minimum length of %s",
+		"%s muss mindestens %s Zeichen lang sein",
+		"%s debe tener una longitud mínima de %s",
+		"%s doit avoir une longueur minimale de %s"},
+	{"%s must have a maximum length of %s",
+		"%s darf höchstens %s Zeichen lang sein",
+		"%s debe tener una longitud máxima de %s",
+		"%s doit avoir une longueur maximale de %s"},
+	{"%s must be alphanumeric",
+		"%s darf nur Buchstaben und Ziffern enthalten",
+		"%s debe ser alfanumérico",
+		"%s doit être alphanumérique"},
+	{"%s must be one of: %s",
+		"%s muss einer der folgenden Werte sein: %s",
+		"%s debe ser uno de: %s",
+		"%s doit être l'une des valeurs suivantes : %s"},
+	{"%s must have exactly %s characters",
+		"%s muss genau %s Zeichen lang sein",
+		"%s debe tener exactamente %s caracteres",
+		"%s doit comporter exactement %s caractères"},
+	{"%s must be numeric",
+		"%s muss numerisch sein",
+		"%s debe ser numérico",
+		"%s doit être numérique"},
+	{"%s must be a phone number in E.164 format (e.g. +14155552671)",
+		"%s muss eine Telefonnummer im E.164-Format sein (z. B. +14155552671)",
+		"%s debe ser un número de teléfono en formato E.164 (p. ej. +14155552671)",
+		"%s doit être un numéro de téléphone au format E.164 (par ex. +14155552671)"},
+	{"%s must be a valid URL",
+		"%s muss eine gültige URL sein",
+		"%s debe ser una URL válida",
+		"%s doit être une URL valide"},
+	{"%s must be a valid UUID",
+		"%s muss eine gültige UUID sein",
+		"%s debe ser un UUID válido",
+		"%s doit être un UUID valide"},
+	{"%s must be an IANA time zone (e.g. Europe/Berlin)",
+		"%s muss eine IANA-Zeitzone sein (z. B. Europe/Berlin)",
+		"%s debe ser una zona horaria IANA (p. ej. Europe/Berlin)",
+		"%s doit être un fuseau horaire IANA (par ex. Europe/Berlin)"},
+	{"%s must be a BCP 47 language tag (e.g. en-US)",
+		"%s muss ein BCP-47-Sprachtag sein (z. B. en-US)",
+		"%s debe ser una etiqueta de idioma BCP 47 (p. ej. en-US)",
+		"%s doit être une balise de langue BCP 47 (par ex. en-US)"},
+	{"%s must be greater than or equal to %s",
+		"%s muss größer oder gleich %s sein",
+		"%s debe ser mayor o igual que %s",
+		"%s doit être supérieur ou égal à %s"},
+	{"%s must be less than or equal to %s",
+		"%s muss kleiner oder gleich %s sein",
+		"%s debe ser menor o igual que %s",
+		"%s doit être inférieur ou égal à %s"},
+	{"%s must be greater than %s",
+		"%s muss größer als %s sein",
+		"%s debe ser mayor que %s",
+		"%s doit être supérieur à %s"},
+	{"%s must be less than %s",
+		"%s muss kleiner als %s sein",
+		"%s debe ser menor que %s",
+		"%s doit être inférieur à %s"},
+	{"%s validation failed: %s (value: %v)",
+		"Validierung von %s fehlgeschlagen: %s (Wert: %v)",
+		"La validación de %s ha fallado: %s (valor: %v)",
+		"La validation de %s a échoué : %s (valeur : %v)"},
+	{"%s must contain only letters, digits and underscores, start with a letter or digit and not be a reserved name",
+		"%s darf nur Buchstaben, Ziffern und Unterstriche enthalten, muss mit einem Buchstaben oder einer Ziffer beginnen und darf kein reservierter Name sein",
+		"%s solo puede contener letras, dígitos y guiones bajos, debe empezar por una letra o un dígito y no puede ser un nombre reservado",
+		"%s ne peut contenir que des lettres, des chiffres et des tirets bas, doit commencer par une lettre ou un chiffre et ne peut pas être un nom réservé"},
+	{"%s must contain at least three of: lowercase letters, uppercase letters, digits and symbols",
+		"%s muss mindestens drei der folgenden enthalten: Kleinbuchstaben, Großbuchstaben, Ziffern und Sonderzeichen",
+		"%s debe contener al menos tres de: minúsculas, mayúsculas, dígitos y símbolos",
+		"%s doit contenir au moins trois des éléments suivants : minuscules, majuscules, chiffres et symboles"},
+}
+
+func init() {
+	de := make(map[string]string, len(builtIn))
+	es := make(map[string]string, len(builtIn))
+	fr := make(map[string]string, len(builtIn))
+	for _, row := range builtIn {
+		de[row.en], es[row.en], fr[row.en] = row.de, row.es, row.fr
+	}
+	for tag, messages := range map[string]map[string]string{"de": de, "es": es, "fr": fr} {
+		if err := Register(tag, messages); err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// Package i18n translates the English messages of API responses into the caller's
+// locale. The English text is the key, so a message without a translation is returned
+// unchanged and new messages work before anyone translates them. German, Spanish and
+// French ship built in (see catalogs.go); Register adds or overrides translations.
+package i18n
+
+import (
+	"fmt"
+
+	"golang.org/x/text/language"
+)
+
+// catalogs holds the translations per language; tags[0] is English, which needs none
+var (
+	tags     = []language.Tag{language.English}
+	catalogs = []map[string]string{nil}
+	matcher  = language.NewMatcher(tags)
+)
+
+// Register adds translations of English messages for the language of tag, replacing
+// earlier ones for the same message. Printf formats are keyed by the format. Call it at
+// bootstrap, before requests are served; registering is not safe while translating.
+func Register(tag string, messages map[string]string) error {
+	parsed, err := language.Parse(tag)
+	if err != nil {
+		return fmt.Errorf("i18n: invalid language tag %q: %w", tag, err)
+	}
+	base, _ := parsed.Base()
+	parsed, _ = language.Compose(base)
+	for i, existing := range tags {
+		if existing == parsed {
+			if catalogs[i] == nil {
+				catalogs[i] = make(map[string]string, len(messages))
+			}
+			for en, translated := range messages {
+				catalogs[i][en] = translated
+			}
+			return nil
+		}
+	}
+	catalog := make(map[string]string, len(messages))
+	for en, translated := range messages {
+		catalog[en] = translated
+	}
+	tags = append(tags, parsed)
+	catalogs = append(catalogs, catalog)
+	matcher = language.NewMatcher(tags)
+	return nil
+}
+
+// Translate returns text in the registered language closest to locale, a BCP 47 tag
+// such as "de-AT", or text itself when there is no translation
+func Translate(locale, text string) string {
+	if translated, ok := lookup(locale, text); ok {
+		return translated
+	}
+	return text
+}
+
+// Sprintf formats args with the translation of format
+func Sprintf(locale, format string, args ...interface{}) string {
+	return fmt.Sprintf(Translate(locale, format), args...)
+}
+
+func lookup(locale, text string) (string, bool) {
+	if locale == "" {
+		return "", false
+	}
+	_, index, confidence := matcher.Match(language.Make(locale))
+	if confidence == language.No || catalogs[index] == nil {
+		return "", false
+	}
+	translated, ok := catalogs[index][text]
+	return translated, ok
+}
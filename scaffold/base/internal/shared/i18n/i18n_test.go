@@ -0,0 +1,55 @@
+package i18n
+
+import "testing"
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		name   string
+		locale string
+		text   string
+		want   string
+	}{
+		{name: "German", locale: "de", text: "User not found", want: "Benutzer nicht gefunden"},
+		{name: "Regional variant", locale: "es-MX", text: "User not found", want: "Usuario no encontrado"},
+		{name: "English", locale: "en-US", text: "User not found", want: "User not found"},
+		{name: "Unknown language", locale: "ja", text: "User not found", want: "User not found"},
+		{name: "No locale", locale: "", text: "User not found", want: "User not found"},
+		{name: "Untranslated message", locale: "fr", text: "Profile field not found", want: "Profile field not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Translate(tt.locale, tt.text); got != tt.want {
+				t.Errorf("Translate(%q, %q) = %q, want %q", tt.locale, tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSprintf(t *testing.T) {
+	// Act
+	got := Sprintf("de-CH", "%s must have a maximum length of %s", "Username", "50")
+
+	// Assert
+	if want := "Username darf höchstens 50 Zeichen lang sein"; got != want {
+		t.Errorf("Sprintf() = %q, want %q", got, want)
+	}
+}
+
+func TestRegister_Overrides(t *testing.T) {
+	// Arrange
+	if err := Register("de-DE", map[string]string{"File not found": "Die Datei gibt es nicht"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = Register("de", map[string]string{"File not found": "Datei nicht gefunden"}) })
+
+	// Act
+	got := Translate("de", "File not found")
+
+	// Assert
+	if got != "Die Datei gibt es nicht" {
+		t.Errorf("Translate() = %q, want the registered translation", got)
+	}
+	if err := Register("not a tag!", nil); err == nil {
+		t.Error("Register() with an invalid tag error = nil")
+	}
+}
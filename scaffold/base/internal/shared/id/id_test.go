@@ -0,0 +1,55 @@
+package id
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestV7_IsTimeOrdered(t *testing.T) {
+	prev := V7.New()
+	for i := 0; i < 1000; i++ {
+		next := V7.New()
+		if next.Version() != 7 {
+			t.Fatalf("V7 generated version %d", next.Version())
+		}
+		if bytes.Compare(prev[:], next[:]) >= 0 {
+			t.Fatalf("V7 keys not increasing: %s then %s", prev, next)
+		}
+		prev = next
+	}
+}
+
+func TestForVersion(t *testing.T) {
+	for version, want := range map[string]byte{"v4": 4, "v7": 7, "": 7} {
+		g, err := ForVersion(version)
+		if err != nil {
+			t.Fatalf("ForVersion(%q) error = %v", version, err)
+		}
+		if got := g.New().Version(); byte(got) != want {
+			t.Errorf("ForVersion(%q) generated version %d, want %d", version, got, want)
+		}
+	}
+	if _, err := ForVersion("v1"); err == nil {
+		t.Error("ForVersion(v1) error = nil, want unsupported")
+	}
+}
+
+func TestSetGenerator(t *testing.T) {
+	t.Cleanup(func() { SetGenerator(V7) })
+
+	SetGenerator(V4)
+
+	if got := New().Version(); got != 4 {
+		t.Errorf("New() generated version %d after SetGenerator(V4)", got)
+	}
+}
+
+func BenchmarkGenerator(b *testing.B) {
+	for name, g := range map[string]Generator{"v4": V4, "v7": V7} {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_ = g.New()
+			}
+		})
+	}
+}
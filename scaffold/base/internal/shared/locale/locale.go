@@ -0,0 +1,113 @@
+// Package locale carries the caller's time zone and locale through request contexts.
+// Values stated on the request itself (?tz=, ?locale=, Accept-Language) win over the
+// preferences saved on the user, which win over the server defaults. Translation and
+// formatting code should read Locale and Location from the context rather than from
+// the request.
+package locale
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/text/language"
+)
+
+type ctxKey struct{}
+
+type settings struct {
+	location         *time.Location
+	locale           string
+	explicitLocation bool
+	explicitLocale   bool
+}
+
+func fromContext(ctx context.Context) settings {
+	s, _ := ctx.Value(ctxKey{}).(settings)
+	return s
+}
+
+// WithRequested records the zone and locale the request asked for; either may be empty
+// or nil. Requested values are never replaced by saved preferences.
+func WithRequested(ctx context.Context, loc *time.Location, tag string) context.Context {
+	before := fromContext(ctx)
+	s := before
+	if loc != nil {
+		s.location, s.explicitLocation = loc, true
+	}
+	if tag != "" {
+		s.locale, s.explicitLocale = tag, true
+	}
+	if s == before {
+		return ctx
+	}
+	return context.WithValue(ctx, ctxKey{}, s)
+}
+
+// WithPreferences applies a user's saved time zone and locale where the request did not
+// ask for something else. Invalid or empty preferences are ignored.
+func WithPreferences(ctx context.Context, timeZone, tag string) context.Context {
+	before := fromContext(ctx)
+	s := before
+	if !s.explicitLocation && timeZone != "" {
+		if loc, err := LoadLocation(timeZone); err == nil {
+			s.location = loc
+		}
+	}
+	if !s.explicitLocale && tag != "" {
+		s.locale = tag
+	}
+	if s == before {
+		return ctx
+	}
+	return context.WithValue(ctx, ctxKey{}, s)
+}
+
+// locations caches loaded zones; only valid IANA names are stored, so it stays small
+var locations sync.Map
+
+// LoadLocation is time.LoadLocation with a process-wide cache. time.LoadLocation reads
+// and parses the zone file on every call, which is too slow for once per request.
+func LoadLocation(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// Location returns the caller's time zone, or nil when none was requested or saved
+func Location(ctx context.Context) *time.Location {
+	return fromContext(ctx).location
+}
+
+// Locale returns the caller's BCP 47 locale, or "" when none is known
+func Locale(ctx context.Context) string {
+	return fromContext(ctx).locale
+}
+
+// ParseAcceptLanguage returns the preferred tag from an Accept-Language header, or ""
+func ParseAcceptLanguage(header string) string {
+	if strings.TrimSpace(header) == "" {
+		return ""
+	}
+	tags, _, err := language.ParseAcceptLanguage(header)
+	if err != nil || len(tags) == 0 {
+		return ""
+	}
+	return tags[0].String()
+}
+
+// Canonical validates a BCP 47 tag and returns its canonical form
+func Canonical(tag string) (string, bool) {
+	parsed, err := language.Parse(tag)
+	if err != nil {
+		return "", false
+	}
+	return parsed.String(), true
+}
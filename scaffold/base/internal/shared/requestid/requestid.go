@@ -0,0 +1,18 @@
+// Package requestid carries the request ID through request contexts so that services,
+// repositories and the SQL logger can tag their output without depending on gin.
+package requestid
+
+import "context"
+
+type ctxKey struct{}
+
+// With returns a copy of ctx that records id as the request ID
+func With(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the request ID recorded in ctx, or "" outside of requests
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
@@ -0,0 +1,85 @@
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// listPayload resembles a page of users
+func listPayload(n int) *SuccessResponse {
+	type item struct {
+		ID        string    `json:"id"`
+		Email     string    `json:"email"`
+		Username  string    `json:"username"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+	items := make([]item, n)
+	for i := range items {
+		items[i] = item{
+			ID:        fmt.Sprintf("0190a8c4-0000-7000-8000-%012d", i),
+			Email:     fmt.Sprintf("user%d@example.com", i),
+			Username:  fmt.Sprintf("user%d", i),
+			CreatedAt: time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC),
+		}
+	}
+	return &SuccessResponse{Data: items, Timestamp: time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC), RequestID: "req-1"}
+}
+
+func TestPooledJSON_MatchesMarshal(t *testing.T) {
+	// Arrange
+	payload := listPayload(3)
+	want, _ := json.Marshal(payload)
+	w := httptest.NewRecorder()
+
+	// Act
+	err := PooledJSON{Data: payload}.Render(w)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if got := bytes.TrimSpace(w.Body.Bytes()); !bytes.Equal(got, want) {
+		t.Errorf("Render() = %s, want %s", got, want)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json; charset=utf-8" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+}
+
+// BenchmarkListResponse compares encoding a 1000 item list the way c.JSON does with PooledJSON
+func BenchmarkListResponse(b *testing.B) {
+	payload := listPayload(1000)
+	w := &discardWriter{header: http.Header{}}
+
+	b.Run("marshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			body, err := json.Marshal(payload)
+			if err != nil {
+				b.Fatal(err)
+			}
+			_, _ = w.Write(body)
+		}
+	})
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := (PooledJSON{Data: payload}).Render(w); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+type discardWriter struct {
+	header http.Header
+}
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardWriter) WriteHeader(int)             {}
@@ -28,6 +28,16 @@ type PaginatedResponse struct {
 	RequestID string      `json:"request_id,omitempty"`
 }
 
+// CursorPaginatedResponse is used for endpoints paginated with an opaque cursor
+type CursorPaginatedResponse struct {
+	Data interface{} `json:"data"`
+	// NextCursor fetches the following page; empty on the last page
+	NextCursor string    `json:"next_cursor,omitempty"`
+	Limit      int       `json:"limit"`
+	Timestamp  time.Time `json:"timestamp"`
+	RequestID  string    `json:"request_id,omitempty"`
+}
+
 // NewSuccessResponse creates a new success response
 func NewSuccessResponse(data interface{}, requestID string) *SuccessResponse {
 	return &SuccessResponse{
@@ -59,3 +69,14 @@ func NewPaginatedResponse(data interface{}, total int64, offset, limit int, requ
 		RequestID: requestID,
 	}
 }
+
+// NewCursorPaginatedResponse creates a new cursor-paginated response
+func NewCursorPaginatedResponse(data interface{}, nextCursor string, limit int, requestID string) *CursorPaginatedResponse {
+	return &CursorPaginatedResponse{
+		Data:       data,
+		NextCursor: nextCursor,
+		Limit:      limit,
+		Timestamp:  time.Now().UTC(),
+		RequestID:  requestID,
+	}
+}
@@ -0,0 +1,124 @@
+package response
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/shared/bufpool"
+)
+
+// streamFlushSize is how much encoded JSON ArrayStream buffers before sending a chunk
+const streamFlushSize = 32 << 10
+
+// ArrayStream writes a SuccessResponse whose data is a JSON array one element at a time,
+// so a long list is sent in chunks as it is read instead of being held in memory:
+//
+//	stream := response.NewArrayStream(c.Request.Context(), c.Writer, requestID)
+//	err := repo.Each(ctx, func(row *Row) error { return stream.Write(row) })
+//	stream.Close(err)
+//
+// The status and headers are sent with the first chunk, so a failure part way through
+// cannot become an error response. Close leaves the array unterminated instead, and
+// clients see invalid JSON rather than a silently short list.
+type ArrayStream struct {
+	ctx       context.Context
+	w         http.ResponseWriter
+	requestID string
+	buf       *bytes.Buffer
+	enc       *json.Encoder
+	count     int
+	started   bool
+}
+
+// NewArrayStream starts a streamed list response on w. Write fails once ctx is done,
+// which for a request context means the client went away.
+func NewArrayStream(ctx context.Context, w http.ResponseWriter, requestID string) *ArrayStream {
+	buf := bufpool.Get()
+	return &ArrayStream{ctx: ctx, w: w, requestID: requestID, buf: buf, enc: json.NewEncoder(buf)}
+}
+
+// Write appends v to the array
+func (s *ArrayStream) Write(v interface{}) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	if s.count == 0 {
+		s.buf.WriteString(`{"data":[`)
+	} else {
+		s.buf.WriteByte(',')
+	}
+	if err := s.enc.Encode(v); err != nil {
+		return err
+	}
+	// Encode ends every value with a newline; drop it to keep the body compact
+	s.buf.Truncate(s.buf.Len() - 1)
+	s.count++
+	if s.buf.Len() >= streamFlushSize {
+		return s.flush()
+	}
+	return nil
+}
+
+// Count returns how many elements have been written
+func (s *ArrayStream) Count() int {
+	return s.count
+}
+
+// Started reports whether any of the response has been sent. Until then a failed stream
+// can still be answered with an ordinary error response.
+func (s *ArrayStream) Started() bool {
+	return s.started
+}
+
+// Close finishes the response after a successful stream (err == nil) and releases the
+// buffer. After a failure it sends what was buffered without closing the array.
+func (s *ArrayStream) Close(err error) error {
+	defer s.release()
+	if err != nil {
+		if !s.started {
+			return err
+		}
+		_ = s.flush()
+		return err
+	}
+	if s.count == 0 {
+		s.buf.WriteString(`{"data":[`)
+	}
+	s.buf.WriteString(`],"timestamp":`)
+	timestamp, _ := json.Marshal(time.Now().UTC())
+	s.buf.Write(timestamp)
+	if s.requestID != "" {
+		s.buf.WriteString(`,"request_id":`)
+		requestID, _ := json.Marshal(s.requestID)
+		s.buf.Write(requestID)
+	}
+	s.buf.WriteString("}\n")
+	return s.flush()
+}
+
+// flush sends the buffered JSON as one chunk
+func (s *ArrayStream) flush() error {
+	if !s.started {
+		s.started = true
+		s.w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		s.w.WriteHeader(http.StatusOK)
+	}
+	if _, err := s.w.Write(s.buf.Bytes()); err != nil {
+		return err
+	}
+	s.buf.Reset()
+	if f, ok := s.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+func (s *ArrayStream) release() {
+	if s.buf != nil {
+		bufpool.Put(s.buf)
+		s.buf, s.enc = nil, nil
+	}
+}
@@ -0,0 +1,139 @@
+package response
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type streamRow struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestArrayStream_WritesEnvelope(t *testing.T) {
+	// Arrange
+	w := httptest.NewRecorder()
+	stream := NewArrayStream(context.Background(), w, "req-1")
+
+	// Act
+	for i := 0; i < 3; i++ {
+		if err := stream.Write(streamRow{ID: i, Name: fmt.Sprintf("row%d", i)}); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	err := stream.Close(nil)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	var body struct {
+		Data      []streamRow `json:"data"`
+		Timestamp string      `json:"timestamp"`
+		RequestID string      `json:"request_id"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON %q: %v", w.Body.String(), err)
+	}
+	if len(body.Data) != 3 || body.Data[2].Name != "row2" || body.RequestID != "req-1" || body.Timestamp == "" {
+		t.Errorf("body = %+v", body)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json; charset=utf-8" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+}
+
+func TestArrayStream_Empty(t *testing.T) {
+	// Arrange
+	w := httptest.NewRecorder()
+
+	// Act
+	err := NewArrayStream(context.Background(), w, "").Close(nil)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if !strings.HasPrefix(w.Body.String(), `{"data":[],"timestamp":`) || strings.Contains(w.Body.String(), "request_id") {
+		t.Errorf("body = %s", w.Body.String())
+	}
+}
+
+func TestArrayStream_FlushesLargeLists(t *testing.T) {
+	// Arrange
+	w := httptest.NewRecorder()
+	stream := NewArrayStream(context.Background(), w, "req-1")
+	row := streamRow{Name: strings.Repeat("x", 1000)}
+
+	// Act
+	for i := 0; i < 40; i++ {
+		_ = stream.Write(row)
+	}
+
+	// Assert
+	if !w.Flushed || !stream.Started() {
+		t.Fatal("40KB of rows were not flushed before Close")
+	}
+	if err := stream.Close(nil); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if !json.Valid(w.Body.Bytes()) {
+		t.Error("flushed body is not valid JSON")
+	}
+}
+
+func TestArrayStream_Failure(t *testing.T) {
+	t.Run("before the first chunk", func(t *testing.T) {
+		// Arrange
+		w := httptest.NewRecorder()
+		stream := NewArrayStream(context.Background(), w, "req-1")
+		_ = stream.Write(streamRow{ID: 1})
+
+		// Act
+		err := stream.Close(errors.New("query failed"))
+
+		// Assert
+		if err == nil || stream.Started() || w.Body.Len() != 0 {
+			t.Errorf("Close() = %v, started %v, body %q; want nothing sent", err, stream.Started(), w.Body.String())
+		}
+	})
+
+	t.Run("after the first chunk", func(t *testing.T) {
+		// Arrange
+		w := httptest.NewRecorder()
+		stream := NewArrayStream(context.Background(), w, "req-1")
+		for i := 0; i < 40; i++ {
+			_ = stream.Write(streamRow{Name: strings.Repeat("x", 1000)})
+		}
+
+		// Act
+		err := stream.Close(errors.New("connection reset"))
+
+		// Assert
+		if err == nil || json.Valid(w.Body.Bytes()) {
+			t.Errorf("Close() = %v; want an error and an unterminated body", err)
+		}
+	})
+}
+
+func TestArrayStream_StopsWhenContextDone(t *testing.T) {
+	// Arrange
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := NewArrayStream(ctx, httptest.NewRecorder(), "req-1")
+	_ = stream.Write(streamRow{ID: 1})
+
+	// Act
+	cancel()
+	err := stream.Write(streamRow{ID: 2})
+
+	// Assert
+	if !errors.Is(err, context.Canceled) || stream.Count() != 1 {
+		t.Errorf("Write() after cancel = %v, count %d", err, stream.Count())
+	}
+	_ = stream.Close(err)
+}
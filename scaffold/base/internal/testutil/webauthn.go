@@ -0,0 +1,137 @@
+package testutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"sort"
+	"testing"
+)
+
+// FakeAuthenticator is a software ES256 passkey that answers WebAuthn ceremonies
+type FakeAuthenticator struct {
+	RPID         string
+	Origin       string
+	CredentialID []byte
+	Key          *ecdsa.PrivateKey
+	SignCount    uint32
+	// SkipUserVerification clears the UV flag, like a security key without a PIN
+	SkipUserVerification bool
+}
+
+// NewFakeAuthenticator creates an authenticator with a fresh key for rpID, answering as origin
+func NewFakeAuthenticator(t *testing.T, rpID, origin string) *FakeAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate passkey: %v", err)
+	}
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return &FakeAuthenticator{RPID: rpID, Origin: origin, CredentialID: id, Key: key}
+}
+
+// Register answers navigator.credentials.create() and returns clientDataJSON and the
+// attestation object (format "none")
+func (a *FakeAuthenticator) Register(challenge []byte) ([]byte, []byte) {
+	x, y := make([]byte, 32), make([]byte, 32)
+	a.Key.X.FillBytes(x)
+	a.Key.Y.FillBytes(y)
+	coseKey := cborMap(map[interface{}]interface{}{1: 2, 3: -7, -1: 1, -2: x, -3: y})
+
+	attested := make([]byte, 18, 18+len(a.CredentialID)+len(coseKey))
+	binary.BigEndian.PutUint16(attested[16:], uint16(len(a.CredentialID)))
+	attested = append(append(attested, a.CredentialID...), coseKey...)
+	authData := append(a.authData(0x40), attested...)
+
+	attestation := cborMap(map[interface{}]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[interface{}]interface{}{},
+		"authData": authData,
+	})
+	return a.clientData("webauthn.create", challenge), attestation
+}
+
+// Assert answers navigator.credentials.get() and returns clientDataJSON, the
+// authenticator data and the signature; each call increments the sign count
+func (a *FakeAuthenticator) Assert(challenge []byte) ([]byte, []byte, []byte) {
+	a.SignCount++
+	clientData := a.clientData("webauthn.get", challenge)
+	authData := a.authData(0)
+	hash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), hash[:]...))
+	sig, err := ecdsa.SignASN1(rand.Reader, a.Key, digest[:])
+	if err != nil {
+		panic(err)
+	}
+	return clientData, authData, sig
+}
+
+func (a *FakeAuthenticator) clientData(ceremony string, challenge []byte) []byte {
+	raw, _ := json.Marshal(map[string]string{
+		"type":      ceremony,
+		"challenge": base64.RawURLEncoding.EncodeToString(challenge),
+		"origin":    a.Origin,
+	})
+	return raw
+}
+
+func (a *FakeAuthenticator) authData(extraFlags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.RPID))
+	flags := byte(0x01|0x04) | extraFlags
+	if a.SkipUserVerification {
+		flags &^= 0x04
+	}
+	data := append(rpIDHash[:], flags, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(data[33:], a.SignCount)
+	return data
+}
+
+// cborMap encodes a map of int or string keys to int, string, []byte or nested map values
+func cborMap(m map[interface{}]interface{}) []byte {
+	keys := make([]interface{}, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return string(cborItem(keys[i])) < string(cborItem(keys[j])) })
+
+	out := cborHead(5, uint64(len(m)))
+	for _, k := range keys {
+		out = append(out, cborItem(k)...)
+		out = append(out, cborItem(m[k])...)
+	}
+	return out
+}
+
+func cborItem(v interface{}) []byte {
+	switch v := v.(type) {
+	case int:
+		if v < 0 {
+			return cborHead(1, uint64(-1-v))
+		}
+		return cborHead(0, uint64(v))
+	case string:
+		return append(cborHead(3, uint64(len(v))), v...)
+	case []byte:
+		return append(cborHead(2, uint64(len(v))), v...)
+	case map[interface{}]interface{}:
+		return cborMap(v)
+	}
+	panic("cbor: unsupported test value")
+}
+
+func cborHead(major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return []byte{major<<5 | byte(n)}
+	case n < 1<<8:
+		return []byte{major<<5 | 24, byte(n)}
+	case n < 1<<16:
+		return []byte{major<<5 | 25, byte(n >> 8), byte(n)}
+	}
+	return []byte{major<<5 | 26, byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}
+}
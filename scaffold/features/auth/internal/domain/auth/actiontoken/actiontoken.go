@@ -0,0 +1,157 @@
+// Package actiontoken issues the single-use tokens behind links sent to users, such as
+// email verification, password reset, magic login links and invitations. Tokens are
+// random, stored only as SHA-256 hashes, bound to a purpose and a subject, expire, and
+// are accepted once.
+package actiontoken
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/repo"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/clock"
+
+	"github.com/google/uuid"
+)
+
+// Purposes. A token is only accepted for the purpose it was issued for.
+const (
+	PurposeEmailVerification = "email_verification"
+	PurposePasswordReset     = "password_reset"
+	PurposeMagicLink         = "magic_link"
+	PurposeInvitation        = "invitation"
+)
+
+// ErrInvalid is returned for tokens that are unknown, issued for another purpose,
+// expired or already used. Callers should not tell these apart to the user.
+var ErrInvalid = errors.New("invalid or expired token")
+
+// Token is an issued token without its secret value
+type Token struct {
+	ID        uuid.UUID
+	Purpose   string
+	Subject   string
+	Data      map[string]string
+	ExpiresAt time.Time
+}
+
+// Service issues and checks action tokens
+type Service struct {
+	repo  repo.ActionTokenRepo
+	clock clock.Clock
+}
+
+// NewService returns a Service storing tokens in r
+func NewService(r repo.ActionTokenRepo) *Service {
+	return &Service{repo: r, clock: clock.System()}
+}
+
+// SetClock replaces the clock used for expiry, for tests
+func (s *Service) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// Issue creates a token for purpose and subject that is valid for ttl and carries data,
+// and returns the value to send to the user. Only its hash is stored.
+func (s *Service) Issue(ctx context.Context, purpose, subject string, ttl time.Duration, data map[string]string) (string, *Token, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, fmt.Errorf("generate action token: %w", err)
+	}
+	value := base64.RawURLEncoding.EncodeToString(raw)
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return "", nil, fmt.Errorf("encode action token data: %w", err)
+	}
+	stored := &model.ActionToken{
+		Purpose:   purpose,
+		Subject:   subject,
+		TokenHash: hash(value),
+		Data:      string(encoded),
+		ExpiresAt: s.clock.Now().Add(ttl),
+	}
+	if err := s.repo.Create(ctx, stored); err != nil {
+		return "", nil, fmt.Errorf("store action token: %w", err)
+	}
+	return value, toToken(stored, data), nil
+}
+
+// Validate returns the token with value if it is valid for purpose, without using it
+func (s *Service) Validate(ctx context.Context, purpose, value string) (*Token, error) {
+	stored, err := s.find(ctx, purpose, value)
+	if err != nil {
+		return nil, err
+	}
+	var data map[string]string
+	if stored.Data != "" {
+		if err := json.Unmarshal([]byte(stored.Data), &data); err != nil {
+			return nil, fmt.Errorf("decode action token data: %w", err)
+		}
+	}
+	return toToken(stored, data), nil
+}
+
+// Consume validates the token with value for purpose and marks it used, so it is
+// accepted only once even under concurrent requests
+func (s *Service) Consume(ctx context.Context, purpose, value string) (*Token, error) {
+	token, err := s.Validate(ctx, purpose, value)
+	if err != nil {
+		return nil, err
+	}
+	consumed, err := s.repo.Consume(ctx, token.ID, s.clock.Now())
+	if err != nil {
+		return nil, fmt.Errorf("consume action token: %w", err)
+	}
+	if !consumed {
+		return nil, ErrInvalid
+	}
+	return token, nil
+}
+
+// Revoke invalidates every token of purpose issued for subject, for example older links
+// once a new one is sent
+func (s *Service) Revoke(ctx context.Context, purpose, subject string) error {
+	return s.repo.DeleteForSubject(ctx, purpose, subject)
+}
+
+// Cleanup deletes expired and used tokens; run it as a background job
+func (s *Service) Cleanup(ctx context.Context) error {
+	return s.repo.DeleteExpired(ctx, s.clock.Now())
+}
+
+func (s *Service) find(ctx context.Context, purpose, value string) (*model.ActionToken, error) {
+	if value == "" {
+		return nil, ErrInvalid
+	}
+	stored, err := s.repo.FindByHash(ctx, hash(value))
+	if err != nil {
+		return nil, fmt.Errorf("find action token: %w", err)
+	}
+	if stored == nil || stored.Purpose != purpose || stored.ConsumedAt != nil || !s.clock.Now().Before(stored.ExpiresAt) {
+		return nil, ErrInvalid
+	}
+	return stored, nil
+}
+
+func hash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+func toToken(stored *model.ActionToken, data map[string]string) *Token {
+	return &Token{
+		ID:        stored.ID,
+		Purpose:   stored.Purpose,
+		Subject:   stored.Subject,
+		Data:      data,
+		ExpiresAt: stored.ExpiresAt,
+	}
+}
@@ -0,0 +1,105 @@
+package actiontoken
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+)
+
+func TestService_Consume(t *testing.T) {
+	tests := []struct {
+		name    string
+		purpose string
+		advance time.Duration
+		tamper  func(value string) string
+		reuse   bool
+		revoke  bool
+		wantErr bool
+	}{
+		{name: "valid token", purpose: PurposePasswordReset},
+		{name: "other purpose", purpose: PurposeMagicLink, wantErr: true},
+		{name: "expired", purpose: PurposePasswordReset, advance: time.Hour, wantErr: true},
+		{name: "used twice", purpose: PurposePasswordReset, reuse: true, wantErr: true},
+		{name: "revoked", purpose: PurposePasswordReset, revoke: true, wantErr: true},
+		{name: "tampered", purpose: PurposePasswordReset, tamper: func(v string) string { return v[:len(v)-2] + "xx" }, wantErr: true},
+		{name: "empty", purpose: PurposePasswordReset, tamper: func(string) string { return "" }, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			repo := &testutil.MockActionTokenRepo{}
+			clk := testutil.NewFakeClock(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+			svc := NewService(repo)
+			svc.SetClock(clk)
+			value, _, err := svc.Issue(ctx, PurposePasswordReset, "user-1", time.Hour, map[string]string{"email": "jane@example.com"})
+			if err != nil {
+				t.Fatalf("Issue() error = %v", err)
+			}
+			if repo.Tokens[0].TokenHash == value {
+				t.Fatal("Issue() stored the token value, want only its hash")
+			}
+			if tt.reuse {
+				if _, err := svc.Consume(ctx, tt.purpose, value); err != nil {
+					t.Fatalf("first Consume() error = %v", err)
+				}
+			}
+			if tt.revoke {
+				if err := svc.Revoke(ctx, PurposePasswordReset, "user-1"); err != nil {
+					t.Fatalf("Revoke() error = %v", err)
+				}
+			}
+			if tt.tamper != nil {
+				value = tt.tamper(value)
+			}
+			clk.Advance(tt.advance)
+
+			// Act
+			token, err := svc.Consume(ctx, tt.purpose, value)
+
+			// Assert
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalid) {
+					t.Errorf("Consume() error = %v, want ErrInvalid", err)
+				}
+				return
+			}
+			if err != nil || token.Subject != "user-1" || token.Data["email"] != "jane@example.com" {
+				t.Errorf("Consume() = %+v, %v; want the token of user-1 with its data", token, err)
+			}
+			if _, err := svc.Validate(ctx, tt.purpose, value); !errors.Is(err, ErrInvalid) {
+				t.Errorf("Validate() after Consume() error = %v, want ErrInvalid", err)
+			}
+		})
+	}
+}
+
+func TestService_Cleanup(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	repo := &testutil.MockActionTokenRepo{}
+	clk := testutil.NewFakeClock(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+	svc := NewService(repo)
+	svc.SetClock(clk)
+	used, _, _ := svc.Issue(ctx, PurposeInvitation, "a@example.com", 48*time.Hour, nil)
+	_, _, _ = svc.Issue(ctx, PurposeInvitation, "b@example.com", time.Hour, nil)
+	pending, _, _ := svc.Issue(ctx, PurposeInvitation, "c@example.com", 48*time.Hour, nil)
+	if _, err := svc.Consume(ctx, PurposeInvitation, used); err != nil {
+		t.Fatalf("Consume() error = %v", err)
+	}
+	clk.Advance(2 * time.Hour)
+
+	// Act
+	err := svc.Cleanup(ctx)
+
+	// Assert
+	if err != nil || len(repo.Tokens) != 1 {
+		t.Fatalf("Cleanup() error = %v, left %d tokens; want only the pending one", err, len(repo.Tokens))
+	}
+	if token, err := svc.Validate(ctx, PurposeInvitation, pending); err != nil || token.Subject != "c@example.com" {
+		t.Errorf("Validate(pending) = %+v, %v; want it still valid", token, err)
+	}
+}
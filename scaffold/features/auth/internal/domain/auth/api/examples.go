@@ -0,0 +1,30 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/model"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/examples"
+)
+
+// Examples are the bodies of the auth routes, served under /docs/examples
+var Examples = []examples.Route{
+	{Method: http.MethodPost, Path: "/login", Request: dto.LoginRequest{}, Response: model.LoginResponse{}},
+	{Method: http.MethodPost, Path: "/login/otp/request", Request: dto.OTPSendRequest{}},
+	{Method: http.MethodPost, Path: "/login/otp/verify", Request: dto.OTPLoginRequest{}, Response: model.LoginResponse{}},
+	{Method: http.MethodGet, Path: "/auth/oauth/{provider}/callback", Response: model.LoginResponse{}},
+	{Method: http.MethodPost, Path: "/auth/webauthn/login/begin", Response: dto.PasskeyRequestOptions{}},
+	{Method: http.MethodPost, Path: "/auth/webauthn/login/finish", Request: dto.PasskeyLoginRequest{}, Response: model.LoginResponse{}},
+	{Method: http.MethodPost, Path: "/auth/webauthn/register/begin", Response: dto.PasskeyCreationOptions{}},
+	{Method: http.MethodPost, Path: "/auth/webauthn/register/finish", Request: dto.PasskeyRegistrationRequest{}, Response: model.WebAuthnCredential{}},
+	{Method: http.MethodGet, Path: "/auth/webauthn/credentials", Response: []model.WebAuthnCredential{}},
+	{Method: http.MethodPost, Path: "/auth/guest", Response: dto.GuestTokenResponse{}},
+	{Method: http.MethodPost, Path: "/auth/token", Response: dto.ClientTokenResponse{}},
+	{Method: http.MethodDelete, Path: "/me", Response: dto.AccountDeletionResponse{}},
+	{Method: http.MethodPost, Path: "/refresh", Request: dto.RefreshTokenRequest{}, Response: model.RefreshResponse{}},
+	{Method: http.MethodPost, Path: "/logout", Request: dto.RefreshTokenRequest{}},
+	{Method: http.MethodGet, Path: "/admin/tokens", Response: []model.RefreshToken{}},
+	{Method: http.MethodGet, Path: "/admin/service-clients", Response: []model.ServiceClient{}},
+	{Method: http.MethodPost, Path: "/admin/service-clients", Request: dto.CreateServiceClientRequest{}, Response: dto.CreateServiceClientResponse{}},
+}
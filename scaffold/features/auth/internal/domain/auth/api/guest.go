@@ -0,0 +1,33 @@
+package api
+
+import (
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IssueGuestToken godoc
+// @Summary Get a guest token (only when GUEST_TOKENS_ENABLED=true)
+// @Description Returns a short-lived access token with the guest role for clients that have not signed in. It is only accepted on routes open to guests, such as GET /profile-fields, and cannot be refreshed.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} dto.GuestTokenResponse
+// @Failure 403 {object} response.ErrorResponse "Client IP banned"
+// @Router /auth/guest [post]
+func (h *AuthHandler) IssueGuestToken(c *gin.Context) {
+	ctx := actor.WithClientIP(c.Request.Context(), c.ClientIP())
+	token, guestID, expiresAt, err := h.service.IssueGuestToken(ctx)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(dto.GuestTokenResponse{
+		AccessToken: token,
+		ExpiresAt:   expiresAt,
+		GuestID:     guestID.String(),
+	}, c.GetString("RequestID")))
+}
@@ -1,13 +1,18 @@
 package api
 
 import (
-	"go_platform_template/internal/domain/auth/dto"
-	"go_platform_template/internal/domain/auth/model"
-	"go_platform_template/internal/domain/auth/service"
-	"go_platform_template/internal/platform/validation"
-	apperrors "go_platform_template/internal/shared/errors"
-	"go_platform_template/internal/shared/response"
+	"context"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/service"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/risk"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/validation"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -17,12 +22,15 @@ type AuthHandler struct {
 	service   *service.AuthService
 	validator *validation.Validator
 	logger    *zap.SugaredLogger
+	// refreshCookie carries refresh tokens instead of response bodies when enabled
+	refreshCookie       config.RefreshCookieConfig
+	refreshCookieMaxAge time.Duration
 }
 
 func NewAuthHandler(s *service.AuthService, logger *zap.SugaredLogger) *AuthHandler {
 	return &AuthHandler{
 		service:   s,
-		validator: validation.New(),
+		validator: validation.Default(),
 		logger:    logger,
 	}
 }
@@ -31,6 +39,16 @@ func NewAuthHandler(s *service.AuthService, logger *zap.SugaredLogger) *AuthHand
 type MeResponse struct {
 	UserID string `json:"user_id"`
 	Role   string `json:"role"`
+	// Impersonated is true when an admin is acting as this user; ImpersonatorID is the admin
+	Impersonated   bool   `json:"impersonated"`
+	ImpersonatorID string `json:"impersonator_id,omitempty"`
+}
+
+// loginContext returns the request context with the caller's IP and User-Agent, which
+// logins record to recognize the device
+func loginContext(c *gin.Context) context.Context {
+	ctx := actor.WithClientIP(c.Request.Context(), c.ClientIP())
+	return actor.WithUserAgent(ctx, c.Request.UserAgent())
 }
 
 // Login godoc
@@ -41,9 +59,12 @@ type MeResponse struct {
 // @Accept json
 // @Produce json
 // @Param login body model.LoginRequest true "Login credentials"
+// @Param X-Captcha-Token header string false "Solved CAPTCHA token, when a previous attempt returned captcha_required"
 // @Success 200 {object} model.LoginResponse
 // @Failure 400 {object} response.ErrorResponse "Bad request"
 // @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Failure 403 {object} response.ErrorResponse "Blocked, or a CAPTCHA is required"
+// @Failure 429 {object} response.ErrorResponse "Too many failed attempts; see the Retry-After header"
 // @Router /login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
 	requestIDVal, _ := c.Get("RequestID")
@@ -69,7 +90,8 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	access, refresh, err := h.service.Login(c.Request.Context(), req.EmailOrUsername, req.Password)
+	ctx := risk.WithCaptchaToken(loginContext(c), c.GetHeader(risk.CaptchaHeader))
+	access, refresh, err := h.service.LoginWithCode(ctx, req.EmailOrUsername, req.Password, req.OTP, req.RememberMe)
 	if err != nil {
 		if appErr, ok := apperrors.IsAppError(err); ok {
 			_ = c.Error(appErr)
@@ -81,32 +103,30 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response.NewSuccessResponse(model.LoginResponse{
 		AccessToken:  access,
-		RefreshToken: refresh,
+		RefreshToken: h.deliverRefreshToken(c, refresh),
 	}, requestID))
 }
 
-// Refresh godoc
-// @Summary Refresh tokens
-// @Description Rotates refresh token and returns new access & refresh tokens
+// RequestLoginOTP godoc
+// @Summary Request SMS login code
+// @Description Texts a one-time login code to a registered phone number. Always succeeds for unknown numbers.
 // @Tags Auth
-// @Security BearerAuth
 // @Accept json
 // @Produce json
-// @Param refresh body model.RefreshRequest true "Refresh token"
-// @Success 200 {object} model.RefreshResponse
+// @Param request body dto.OTPSendRequest true "Phone number"
+// @Success 200 {object} response.SuccessResponse
 // @Failure 400 {object} response.ErrorResponse "Bad request"
-// @Failure 401 {object} response.ErrorResponse "Unauthorized"
-// @Router /refresh [post]
-func (h *AuthHandler) Refresh(c *gin.Context) {
+// @Router /login/otp/request [post]
+func (h *AuthHandler) RequestLoginOTP(c *gin.Context) {
 	requestIDVal, _ := c.Get("RequestID")
 	requestID, ok := requestIDVal.(string)
 	if !ok {
 		requestID = "unknown"
 	}
 
-	var req dto.RefreshTokenRequest
+	var req dto.OTPSendRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Warnw("invalid refresh request", "error", err, "request_id", requestID)
+		h.logger.Warnw("invalid otp request", "error", err, "request_id", requestID)
 		_ = c.Error(apperrors.NewAppErrorWithDetails(
 			apperrors.BadRequestError,
 			"Invalid request payload",
@@ -116,48 +136,46 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 	}
 
 	if err := h.validator.ValidateStruct(&req); err != nil {
-		h.logger.Warnw("validation error on refresh", "error", err, "request_id", requestID)
+		h.logger.Warnw("validation error on otp request", "error", err, "request_id", requestID)
 		_ = c.Error(err)
 		return
 	}
 
-	access, newRefresh, err := h.service.Refresh(c.Request.Context(), req.RefreshToken)
-	if err != nil {
+	if err := h.service.RequestLoginOTP(c.Request.Context(), req.Phone); err != nil {
 		if appErr, ok := apperrors.IsAppError(err); ok {
 			_ = c.Error(appErr)
 			return
 		}
-		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Token refresh failed"))
+		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to send verification code"))
 		return
 	}
 
-	c.JSON(http.StatusOK, response.NewSuccessResponse(model.RefreshResponse{
-		AccessToken:  access,
-		RefreshToken: newRefresh,
+	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{
+		"message": "if the number is registered, a verification code has been sent",
 	}, requestID))
 }
 
-// Logout godoc
-// @Summary Logout user
-// @Description Revokes a refresh token (blacklist) to log out the user
+// LoginWithOTP godoc
+// @Summary Login with SMS code
+// @Description Exchanges a phone number and the code texted to it for access and refresh tokens
 // @Tags Auth
-// @Security BearerAuth
 // @Accept json
 // @Produce json
-// @Param logout body model.RefreshRequest true "Refresh token to revoke"
-// @Success 200 {object} response.SuccessResponse "Logged out successfully"
+// @Param login body dto.OTPLoginRequest true "Phone number and code"
+// @Success 200 {object} model.LoginResponse
 // @Failure 400 {object} response.ErrorResponse "Bad request"
-// @Router /logout [post]
-func (h *AuthHandler) Logout(c *gin.Context) {
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Router /login/otp/verify [post]
+func (h *AuthHandler) LoginWithOTP(c *gin.Context) {
 	requestIDVal, _ := c.Get("RequestID")
 	requestID, ok := requestIDVal.(string)
 	if !ok {
 		requestID = "unknown"
 	}
 
-	var req dto.RefreshTokenRequest
+	var req dto.OTPLoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Warnw("invalid logout request", "error", err, "request_id", requestID)
+		h.logger.Warnw("invalid otp login request", "error", err, "request_id", requestID)
 		_ = c.Error(apperrors.NewAppErrorWithDetails(
 			apperrors.BadRequestError,
 			"Invalid request payload",
@@ -167,12 +185,98 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 	}
 
 	if err := h.validator.ValidateStruct(&req); err != nil {
-		h.logger.Warnw("validation error on logout", "error", err, "request_id", requestID)
+		h.logger.Warnw("validation error on otp login", "error", err, "request_id", requestID)
 		_ = c.Error(err)
 		return
 	}
 
-	if err := h.service.Logout(c.Request.Context(), req.RefreshToken); err != nil {
+	access, refresh, err := h.service.LoginWithOTP(loginContext(c), req.Phone, req.Code)
+	if err != nil {
+		if appErr, ok := apperrors.IsAppError(err); ok {
+			_ = c.Error(appErr)
+			return
+		}
+		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Login failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(model.LoginResponse{
+		AccessToken:  access,
+		RefreshToken: h.deliverRefreshToken(c, refresh),
+	}, requestID))
+}
+
+// Refresh godoc
+// @Summary Refresh tokens
+// @Description Rotates refresh token and returns new access & refresh tokens. With AUTH_REFRESH_COOKIE the token is read from and rotated in the refresh cookie, and the body may be omitted.
+// @Tags Auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param refresh body model.RefreshRequest false "Refresh token, unless sent in the refresh cookie"
+// @Success 200 {object} model.RefreshResponse
+// @Failure 400 {object} response.ErrorResponse "Bad request"
+// @Failure 401 {object} response.ErrorResponse "Unauthorized"
+// @Router /refresh [post]
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	requestIDVal, _ := c.Get("RequestID")
+	requestID, ok := requestIDVal.(string)
+	if !ok {
+		requestID = "unknown"
+	}
+
+	refreshToken, ok := h.requestRefreshToken(c, requestID, "refresh")
+	if !ok {
+		return
+	}
+
+	ctx := actor.WithClientIP(c.Request.Context(), c.ClientIP())
+	access, newRefresh, err := h.service.Refresh(ctx, refreshToken)
+	if err != nil {
+		if appErr, ok := apperrors.IsAppError(err); ok {
+			if appErr.Type == apperrors.UnauthorizedError {
+				h.clearRefreshCookie(c)
+			}
+			_ = c.Error(appErr)
+			return
+		}
+		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Token refresh failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(model.RefreshResponse{
+		AccessToken:  access,
+		RefreshToken: h.deliverRefreshToken(c, newRefresh),
+	}, requestID))
+}
+
+// Logout godoc
+// @Summary Logout user
+// @Description Revokes a refresh token (blacklist) to log out the user. With AUTH_REFRESH_COOKIE the token is read from the refresh cookie, which is cleared.
+// @Tags Auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param logout body model.RefreshRequest false "Refresh token to revoke, unless sent in the refresh cookie"
+// @Success 200 {object} response.SuccessResponse "Logged out successfully"
+// @Failure 400 {object} response.ErrorResponse "Bad request"
+// @Router /logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+	requestIDVal, _ := c.Get("RequestID")
+	requestID, ok := requestIDVal.(string)
+	if !ok {
+		requestID = "unknown"
+	}
+
+	refreshToken, ok := h.requestRefreshToken(c, requestID, "logout")
+	if !ok {
+		return
+	}
+
+	err := h.service.Logout(c.Request.Context(), refreshToken)
+	// The cookie is dropped even when the token was already revoked or has expired
+	h.clearRefreshCookie(c)
+	if err != nil {
 		if appErr, ok := apperrors.IsAppError(err); ok {
 			_ = c.Error(appErr)
 			return
@@ -186,11 +290,11 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 
 // Me godoc
 // @Summary Get current logged-in user info
-// @Description Returns user ID and role from access token
+// @Description Returns user ID and role from access token, and whether an admin is impersonating the user
 // @Tags Auth
 // @Security BearerAuth
 // @Produce json
-// @Success 200 {object} response.SuccessResponse
+// @Success 200 {object} MeResponse
 // @Failure 401 {object} response.ErrorResponse
 // @Router /me [get]
 func (h *AuthHandler) Me(c *gin.Context) {
@@ -200,11 +304,37 @@ func (h *AuthHandler) Me(c *gin.Context) {
 		requestID = "unknown"
 	}
 
-	userID, _ := c.Get("userID")
-	role, _ := c.Get("role")
-
-	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{
-		"user_id": userID,
-		"role":    role,
+	impersonatorID := c.GetString("impersonatorID")
+	c.JSON(http.StatusOK, response.NewSuccessResponse(MeResponse{
+		UserID:         c.GetString("userID"),
+		Role:           c.GetString("role"),
+		Impersonated:   impersonatorID != "",
+		ImpersonatorID: impersonatorID,
 	}, requestID))
 }
+
+// UnlockAccount godoc
+// @Summary Unlock an account locked after failed logins (requires users:unlock)
+// @Description Clears the account's failed login attempts, ending backoff or lockout. Locks on client IPs expire on their own.
+// @Tags Auth
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "User not found"
+// @Router /users/{id}/unlock [post]
+func (h *AuthHandler) UnlockAccount(c *gin.Context) {
+	requestIDVal, _ := c.Get("RequestID")
+	requestID, ok := requestIDVal.(string)
+	if !ok {
+		requestID = "unknown"
+	}
+
+	if err := h.service.UnlockAccount(c.Request.Context(), c.Param("id")); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{"message": "account unlocked"}, requestID))
+}
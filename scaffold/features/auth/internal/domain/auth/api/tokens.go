@@ -0,0 +1,95 @@
+package api
+
+import (
+	"fmt"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListTokens godoc
+// @Summary List active refresh tokens (requires tokens:manage)
+// @Description Returns token metadata only (ID, owner, role, creation and expiry), newest first. Token values are never returned.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param user_id query string false "Only this user's tokens"
+// @Param offset query int false "Offset for pagination"
+// @Param limit query int false "Limit for pagination (1-100, default 50)"
+// @Success 200 {array} model.RefreshToken
+// @Failure 400 {object} response.ErrorResponse "Bad request"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Router /admin/tokens [get]
+func (h *AuthHandler) ListTokens(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+
+	offset := 0
+	limit := 50
+	if v := c.Query("offset"); v != "" {
+		if _, err := fmt.Sscan(v, &offset); err != nil {
+			_ = c.Error(apperrors.NewAppErrorWithDetails(apperrors.BadRequestError, "Invalid offset value", err.Error()))
+			return
+		}
+	}
+	if v := c.Query("limit"); v != "" {
+		if _, err := fmt.Sscan(v, &limit); err != nil {
+			_ = c.Error(apperrors.NewAppErrorWithDetails(apperrors.BadRequestError, "Invalid limit value", err.Error()))
+			return
+		}
+	}
+
+	tokens, err := h.service.ListRefreshTokens(c.Request.Context(), c.Query("user_id"), offset, limit)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(tokens, requestID))
+}
+
+// RevokeToken godoc
+// @Summary Revoke one refresh token (requires tokens:manage)
+// @Description The token can no longer be refreshed. Access tokens already issued from it stay valid until they expire.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Refresh token ID"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "Token not found or no longer active"
+// @Router /admin/tokens/{id} [delete]
+func (h *AuthHandler) RevokeToken(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+
+	if err := h.service.RevokeRefreshToken(c.Request.Context(), c.Param("id")); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{"message": "token revoked"}, requestID))
+}
+
+// RevokeUserTokens godoc
+// @Summary Revoke all of a user's refresh tokens (requires tokens:manage)
+// @Description Signs the user out on every device once their access tokens expire
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} response.SuccessResponse "Number of tokens revoked"
+// @Failure 403 {object} response.ErrorResponse "Forbidden"
+// @Failure 404 {object} response.ErrorResponse "User not found"
+// @Router /admin/users/{id}/tokens [delete]
+func (h *AuthHandler) RevokeUserTokens(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+
+	revoked, err := h.service.RevokeUserRefreshTokens(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{"revoked": revoked}, requestID))
+}
@@ -1,17 +1,50 @@
 package dto
 
+import "time"
+
 // LoginRequest represents the payload for user login
 // swagger:model
 type LoginRequest struct {
 	// Email or username of the user
 	// Required: true
 	// Example: john.doe@example.com
-	EmailOrUsername string `json:"email_or_username" validate:"required,min=3,max=255"`
+	EmailOrUsername string `json:"email_or_username" validate:"required,min=3,max=255" example:"john.doe@example.com"`
 
 	// Password for the user account
 	// Required: true
 	// Example: StrongP@ssw0rd
-	Password string `json:"password" validate:"required,min=8"`
+	Password string `json:"password" validate:"required,min=8" example:"StrongP@ssw0rd"`
+
+	// One-time SMS code, required only for accounts with SMS two-factor enabled
+	// Example: 123456
+	OTP string `json:"otp,omitempty" validate:"omitempty,numeric,min=4,max=10" example:"123456"`
+
+	// RememberMe keeps the session for JWT_REMEMBER_ME_EXPIRY instead of JWT_REFRESH_EXPIRY
+	// Example: true
+	RememberMe bool `json:"remember_me,omitempty" example:"true"`
+}
+
+// OTPSendRequest asks for a passwordless login code to be texted to a phone
+// swagger:model
+type OTPSendRequest struct {
+	// Phone number in E.164 format
+	// Required: true
+	// Example: +14155552671
+	Phone string `json:"phone" validate:"required,e164" example:"+14155552671"`
+}
+
+// OTPLoginRequest exchanges a texted code for tokens
+// swagger:model
+type OTPLoginRequest struct {
+	// Phone number in E.164 format
+	// Required: true
+	// Example: +14155552671
+	Phone string `json:"phone" validate:"required,e164" example:"+14155552671"`
+
+	// Code received by SMS
+	// Required: true
+	// Example: 123456
+	Code string `json:"code" validate:"required,numeric,min=4,max=10" example:"123456"`
 }
 
 // LoginResponse represents the response after successful login
@@ -19,11 +52,11 @@ type LoginRequest struct {
 type LoginResponse struct {
 	// Access token for API authentication
 	// Example: eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...
-	AccessToken string `json:"access_token"`
+	AccessToken string `json:"access_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
 
 	// Refresh token for obtaining new access tokens
 	// Example: eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...
-	RefreshToken string `json:"refresh_token"`
+	RefreshToken string `json:"refresh_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
 
 	// Type of the token
 	// Example: Bearer
@@ -40,7 +73,7 @@ type RefreshTokenRequest struct {
 	// Refresh token obtained during login
 	// Required: true
 	// Example: eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...
-	RefreshToken string `json:"refresh_token" validate:"required,min=10"`
+	RefreshToken string `json:"refresh_token" validate:"required,min=10" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
 }
 
 // ErrorResponse represents an error response
@@ -54,3 +87,279 @@ type ErrorResponse struct {
 	// Example: invalid credentials
 	Details string `json:"details,omitempty" example:"invalid credentials"`
 }
+
+// PasskeyCreationOptions is the publicKey argument of navigator.credentials.create() in
+// its JSON form; browsers read it with PublicKeyCredential.parseCreationOptionsFromJSON()
+// swagger:model
+type PasskeyCreationOptions struct {
+	// Base64url challenge the new passkey signs; valid once
+	Challenge string `json:"challenge"`
+
+	// Relying party the passkey is bound to
+	RP PasskeyRelyingParty `json:"rp"`
+
+	// Account the passkey is created for
+	User PasskeyUser `json:"user"`
+
+	// Accepted key algorithms (COSE identifiers)
+	PubKeyCredParams []PasskeyCredentialParam `json:"pubKeyCredParams"`
+
+	// Time allowed for the ceremony in milliseconds
+	// Example: 300000
+	Timeout int64 `json:"timeout" example:"300000"`
+
+	// Passkeys the user already has, so the same authenticator is not registered twice
+	ExcludeCredentials []PasskeyCredentialDescriptor `json:"excludeCredentials"`
+
+	// Requires a discoverable credential verified with PIN or biometrics
+	AuthenticatorSelection PasskeyAuthenticatorSelection `json:"authenticatorSelection"`
+
+	// Attestation conveyance
+	// Example: none
+	Attestation string `json:"attestation" example:"none"`
+}
+
+// PasskeyRelyingParty names the site passkeys are registered for
+type PasskeyRelyingParty struct {
+	// Example: example.com
+	ID string `json:"id" example:"example.com"`
+	// Example: Go Platform Template
+	Name string `json:"name" example:"Go Platform Template"`
+}
+
+// PasskeyUser describes the account a passkey is created for
+type PasskeyUser struct {
+	// Base64url user handle, returned on login
+	ID string `json:"id"`
+	// Example: john_doe
+	Name string `json:"name" example:"john_doe"`
+	// Example: John Doe
+	DisplayName string `json:"displayName" example:"John Doe"`
+}
+
+// PasskeyCredentialParam is an accepted key type
+type PasskeyCredentialParam struct {
+	// Example: public-key
+	Type string `json:"type" example:"public-key"`
+	// Example: -7
+	Alg int64 `json:"alg" example:"-7"`
+}
+
+// PasskeyCredentialDescriptor identifies an existing passkey
+type PasskeyCredentialDescriptor struct {
+	// Example: public-key
+	Type string `json:"type" example:"public-key"`
+	// Base64url credential ID
+	ID string `json:"id"`
+}
+
+// PasskeyAuthenticatorSelection states the authenticator requirements
+type PasskeyAuthenticatorSelection struct {
+	ResidentKey        string `json:"residentKey"`
+	RequireResidentKey bool   `json:"requireResidentKey"`
+	UserVerification   string `json:"userVerification"`
+}
+
+// PasskeyRequestOptions is the publicKey argument of navigator.credentials.get() in its
+// JSON form; browsers read it with PublicKeyCredential.parseRequestOptionsFromJSON()
+// swagger:model
+type PasskeyRequestOptions struct {
+	// Base64url challenge the passkey signs; valid once
+	Challenge string `json:"challenge"`
+
+	// Example: example.com
+	RPID string `json:"rpId" example:"example.com"`
+
+	// Time allowed for the ceremony in milliseconds
+	// Example: 300000
+	Timeout int64 `json:"timeout" example:"300000"`
+
+	// Example: required
+	UserVerification string `json:"userVerification" example:"required"`
+}
+
+// PasskeyRegistrationRequest is the JSON form (credential.toJSON()) of a new passkey
+// swagger:model
+type PasskeyRegistrationRequest struct {
+	// Label for the passkey
+	// Example: Work laptop
+	Name string `json:"name" validate:"omitempty,max=100" example:"Work laptop"`
+
+	// Base64url credential ID
+	// Required: true
+	ID string `json:"id" validate:"required"`
+
+	// Required: true
+	// Example: public-key
+	Type string `json:"type" validate:"required,eq=public-key" example:"public-key"`
+
+	// Required: true
+	Response PasskeyAttestationResponse `json:"response"`
+}
+
+// PasskeyAttestationResponse carries the authenticator's answer to a registration
+type PasskeyAttestationResponse struct {
+	// Base64url client data
+	// Required: true
+	ClientDataJSON string `json:"clientDataJSON" validate:"required"`
+
+	// Base64url attestation object
+	// Required: true
+	AttestationObject string `json:"attestationObject" validate:"required"`
+}
+
+// PasskeyLoginRequest is the JSON form (credential.toJSON()) of a passkey assertion
+// swagger:model
+type PasskeyLoginRequest struct {
+	// Base64url credential ID
+	// Required: true
+	ID string `json:"id" validate:"required"`
+
+	// Required: true
+	// Example: public-key
+	Type string `json:"type" validate:"required,eq=public-key" example:"public-key"`
+
+	// Required: true
+	Response PasskeyAssertionResponse `json:"response"`
+}
+
+// PasskeyAssertionResponse carries the authenticator's answer to a login
+type PasskeyAssertionResponse struct {
+	// Base64url client data
+	// Required: true
+	ClientDataJSON string `json:"clientDataJSON" validate:"required"`
+
+	// Base64url authenticator data
+	// Required: true
+	AuthenticatorData string `json:"authenticatorData" validate:"required"`
+
+	// Base64url signature
+	// Required: true
+	Signature string `json:"signature" validate:"required"`
+
+	// Base64url user handle of the passkey owner
+	UserHandle string `json:"userHandle,omitempty"`
+}
+
+// ImpersonationResponse is a short-lived access token acting as another user
+// swagger:model
+type ImpersonationResponse struct {
+	// Access token to send as the impersonated user; it cannot be refreshed
+	// Example: eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...
+	AccessToken string `json:"access_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+
+	// Example: 2024-01-15T12:10:00Z
+	ExpiresAt time.Time `json:"expires_at" example:"2024-01-15T12:10:00Z"`
+
+	// User being impersonated
+	// Example: 123e4567-e89b-12d3-a456-426614174000
+	UserID string `json:"user_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+
+	// Admin who requested the token
+	// Example: 0f8fad5b-d9cb-469f-a165-70867728950e
+	ImpersonatorID string `json:"impersonator_id" example:"0f8fad5b-d9cb-469f-a165-70867728950e"`
+}
+
+// GuestTokenResponse is a short-lived access token for an anonymous client
+// swagger:model
+type GuestTokenResponse struct {
+	// Access token with the guest role; it cannot be refreshed, request a new one instead
+	// Example: eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...
+	AccessToken string `json:"access_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+
+	// Example: 2024-01-15T13:00:00Z
+	ExpiresAt time.Time `json:"expires_at" example:"2024-01-15T13:00:00Z"`
+
+	// Random ID standing in for the user ID in the token; it names no account
+	// Example: 7c9e6679-7425-40de-944b-e07fc1f90ae7
+	GuestID string `json:"guest_id" example:"7c9e6679-7425-40de-944b-e07fc1f90ae7"`
+}
+
+// PhoneVerificationRequest confirms the phone number on the account with the code texted to it
+// swagger:model
+type PhoneVerificationRequest struct {
+	// Code received by SMS
+	// Required: true
+	// Example: 123456
+	Code string `json:"code" validate:"required,numeric,min=4,max=10" example:"123456"`
+}
+
+// PhoneVerificationResponse says when the phone number was verified
+// swagger:model
+type PhoneVerificationResponse struct {
+	// Example: 2024-02-14T12:00:00Z
+	PhoneVerifiedAt time.Time `json:"phone_verified_at" example:"2024-02-14T12:00:00Z"`
+}
+
+// AccountDeletionResponse says when a deleted account will be anonymized
+// swagger:model
+type AccountDeletionResponse struct {
+	// Logging in before this time cancels the deletion
+	// Example: 2024-02-14T12:00:00Z
+	DeletionScheduledAt time.Time `json:"deletion_scheduled_at" example:"2024-02-14T12:00:00Z"`
+}
+
+// ClientTokenRequest is a client credentials grant sent to POST /auth/token as a form.
+// The client authenticates with HTTP Basic or the client_id/client_secret fields.
+type ClientTokenRequest struct {
+	GrantType    string `form:"grant_type"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+	Scope        string `form:"scope"`
+}
+
+// ClientTokenResponse is an access token issued to a service client
+// swagger:model
+type ClientTokenResponse struct {
+	// Access token limited to the granted scopes; there is no refresh token, request a
+	// new one when it expires
+	// Example: eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...
+	AccessToken string `json:"access_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+
+	// Example: Bearer
+	TokenType string `json:"token_type" example:"Bearer"`
+
+	// Lifetime of the token in seconds
+	// Example: 900
+	ExpiresIn int64 `json:"expires_in" example:"900"`
+
+	// Granted scopes, space separated
+	// Example: users:list
+	Scope string `json:"scope" example:"users:list"`
+}
+
+// CreateServiceClientRequest registers a service client
+// swagger:model
+type CreateServiceClientRequest struct {
+	// Public client identifier; letters, digits, dots, dashes and underscores
+	// Required: true
+	// Example: billing-worker
+	ClientID string `json:"client_id" validate:"required,min=3,max=64" example:"billing-worker"`
+
+	// Human-readable name of the service
+	// Required: true
+	// Example: Billing worker
+	Name string `json:"name" validate:"required,max=100" example:"Billing worker"`
+
+	// Permissions the client may request as scopes
+	// Required: true
+	// Example: ["users:list"]
+	Scopes []string `json:"scopes" validate:"required,min=1,dive,required,max=100" example:"users:list"`
+}
+
+// CreateServiceClientResponse is a new client with its secret, which is shown only once
+// swagger:model
+type CreateServiceClientResponse struct {
+	// Example: billing-worker
+	ClientID string `json:"client_id" example:"billing-worker"`
+
+	// Client secret; store it now, it cannot be retrieved again
+	// Example: 3q2-7wKJ0l8xk2pD4vVf1mQeH6sYzTn9rAuCbGiLoEw
+	ClientSecret string `json:"client_secret" example:"3q2-7wKJ0l8xk2pD4vVf1mQeH6sYzTn9rAuCbGiLoEw"`
+
+	// Example: Billing worker
+	Name string `json:"name" example:"Billing worker"`
+
+	// Example: ["users:list"]
+	Scopes []string `json:"scopes" example:"users:list"`
+}
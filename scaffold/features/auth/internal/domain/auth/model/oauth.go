@@ -0,0 +1,49 @@
+package model
+
+import (
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/shared/id"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OAuthIdentity links a user to an account at an OAuth2 login provider
+type OAuthIdentity struct {
+	// ID is the unique identifier for the link
+	// format: uuid
+	// readOnly: true
+	ID uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+
+	// UserID is the UUID of the linked user
+	// format: uuid
+	UserID uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+
+	// Provider key, e.g. google or github
+	Provider string `gorm:"size:20;not null;uniqueIndex:idx_oauth_identities_provider_subject" json:"provider"`
+
+	// Subject is the provider's stable ID for the account
+	Subject string `gorm:"size:255;not null;uniqueIndex:idx_oauth_identities_provider_subject" json:"subject"`
+
+	// Email reported by the provider when the link was made
+	Email string `gorm:"size:100" json:"email"`
+
+	// CreatedAt indicates when the account was linked
+	// format: date-time
+	// readOnly: true
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BeforeCreate hook to generate UUID before inserting
+func (i *OAuthIdentity) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = id.New()
+	}
+	return nil
+}
+
+// TableName overrides the default table name
+func (OAuthIdentity) TableName() string {
+	return "oauth_identities"
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/shared/id"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ServiceClient is a registered backend service that obtains access tokens with the
+// OAuth2 client credentials grant
+type ServiceClient struct {
+	// ID is the unique identifier for the client record
+	// format: uuid
+	// readOnly: true
+	ID uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+
+	// ClientID is the public identifier the service authenticates with
+	ClientID string `gorm:"size:64;not null;uniqueIndex" json:"client_id"`
+
+	// Name describes the service
+	Name string `gorm:"size:100;not null" json:"name"`
+
+	// SecretHash is the bcrypt hash of the client secret
+	SecretHash string `gorm:"size:255;not null" json:"-"`
+
+	// Scopes lists the permissions the client may request, space separated
+	Scopes string `gorm:"type:text;not null;default:''" json:"scopes"`
+
+	// CreatedAt indicates when the client was registered
+	// format: date-time
+	// readOnly: true
+	CreatedAt time.Time `json:"created_at"`
+
+	// UpdatedAt indicates when the client was last changed
+	// format: date-time
+	// readOnly: true
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ScopeList returns the client's scopes as a slice
+func (c *ServiceClient) ScopeList() []string {
+	return strings.Fields(c.Scopes)
+}
+
+// BeforeCreate hook to generate UUID before inserting
+func (c *ServiceClient) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = id.New()
+	}
+	return nil
+}
+
+// TableName overrides the default table name
+func (ServiceClient) TableName() string {
+	return "service_clients"
+}
@@ -0,0 +1,60 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	googleAuthURL     = "https://accounts.google.com/o/oauth2/v2/auth"
+	googleTokenURL    = "https://oauth2.googleapis.com/token"
+	googleUserInfoURL = "https://openidconnect.googleapis.com/v1/userinfo"
+)
+
+// Google signs users in with their Google account through OpenID Connect
+type Google struct {
+	client
+	userInfoURL string
+}
+
+func NewGoogle(clientID, clientSecret, redirectURL string) *Google {
+	return &Google{
+		client:      newClient(clientID, clientSecret, redirectURL, googleAuthURL, googleTokenURL, "openid", "email", "profile"),
+		userInfoURL: googleUserInfoURL,
+	}
+}
+
+func (g *Google) Name() string { return "google" }
+
+func (g *Google) AuthCodeURL(ctx context.Context, state string) (string, error) {
+	return g.authCodeURL(state), nil
+}
+
+func (g *Google) Exchange(ctx context.Context, code string) (*Identity, error) {
+	token, err := g.exchange(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	var info struct {
+		Subject       string `json:"sub"`
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		GivenName     string `json:"given_name"`
+		FamilyName    string `json:"family_name"`
+	}
+	if err := g.get(ctx, g.userInfoURL, token, &info); err != nil {
+		return nil, fmt.Errorf("google userinfo: %w", err)
+	}
+	if info.Email == "" || !info.EmailVerified {
+		return nil, ErrEmailUnverified
+	}
+
+	return &Identity{
+		Provider:  g.Name(),
+		Subject:   info.Subject,
+		Email:     info.Email,
+		FirstName: info.GivenName,
+		LastName:  info.FamilyName,
+	}, nil
+}
@@ -0,0 +1,218 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/jwk"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// fakeIdP is an OpenID Connect provider that answers the code "good-code" with an ID
+// token carrying claims
+type fakeIdP struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	claims jwt.MapClaims
+}
+
+func newFakeIdP(t *testing.T) *fakeIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &fakeIdP{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/realms/acme/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		base := idp.server.URL + "/realms/acme"
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 base,
+			"authorization_endpoint": base + "/auth",
+			"token_endpoint":         base + "/token",
+			"jwks_uri":               base + "/certs",
+		})
+	})
+	mux.HandleFunc("/realms/acme/certs", func(w http.ResponseWriter, r *http.Request) {
+		public, _ := jwk.New(&key.PublicKey)
+		_ = json.NewEncoder(w).Encode(jwk.Set{Keys: []jwk.Key{public}})
+	})
+	mux.HandleFunc("/realms/acme/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.PostForm.Get("code") != "good-code" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, idp.claims)
+		token.Header["kid"] = jwk.KeyID(&idp.key.PublicKey)
+		signed, _ := token.SignedString(idp.key)
+		_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "at", "id_token": signed})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+
+	now := time.Now()
+	idp.claims = jwt.MapClaims{
+		"iss":            idp.issuer(),
+		"aud":            "api",
+		"sub":            "kc-1",
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"iat":            now.Unix(),
+		"email":          "ada@example.com",
+		"email_verified": true,
+		"name":           "Ada Lovelace",
+		"realm_access":   map[string]interface{}{"roles": []string{"offline_access", "api-admin"}},
+	}
+	return idp
+}
+
+func (idp *fakeIdP) issuer() string { return idp.server.URL + "/realms/acme" }
+
+func (idp *fakeIdP) provider(rolesClaim string) *OIDC {
+	return NewOIDC(config.OIDCLoginProvider{
+		Name:         "keycloak",
+		Issuer:       idp.issuer(),
+		ClientID:     "api",
+		ClientSecret: "secret",
+		RolesClaim:   rolesClaim,
+		RoleMappings: []config.OIDCRoleMapping{{Value: "api-admin", Role: "admin"}},
+	}, "http://localhost:8080/api/v1/auth/oauth/keycloak/callback")
+}
+
+func TestOIDC_AuthCodeURLUsesDiscoveredEndpoint(t *testing.T) {
+	// Arrange
+	idp := newFakeIdP(t)
+	p := idp.provider("")
+
+	// Act
+	raw, err := p.AuthCodeURL(context.Background(), "xyz")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("AuthCodeURL() error = %v", err)
+	}
+	u, _ := url.Parse(raw)
+	if u.Path != "/realms/acme/auth" || u.Query().Get("scope") != "openid email profile" || u.Query().Get("state") != "xyz" {
+		t.Errorf("AuthCodeURL() = %s", raw)
+	}
+}
+
+func TestOIDC_ExchangeMapsRoles(t *testing.T) {
+	// Arrange
+	idp := newFakeIdP(t)
+	p := idp.provider("realm_access.roles")
+
+	// Act
+	identity, err := p.Exchange(context.Background(), "good-code")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	want := Identity{Provider: "keycloak", Subject: "kc-1", Email: "ada@example.com", FirstName: "Ada", LastName: "Lovelace", Role: "admin"}
+	if *identity != want {
+		t.Errorf("Exchange() = %+v, want %+v", *identity, want)
+	}
+}
+
+func TestOIDC_ExchangeGivesDefaultRoleWithoutMatch(t *testing.T) {
+	// Arrange
+	idp := newFakeIdP(t)
+	idp.claims["realm_access"] = map[string]interface{}{"roles": []string{"offline_access"}}
+	p := idp.provider("realm_access.roles")
+
+	// Act
+	identity, err := p.Exchange(context.Background(), "good-code")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	if identity.Role != "user" {
+		t.Errorf("Role = %q, want user", identity.Role)
+	}
+}
+
+func TestOIDC_ExchangeRejectsInvalidIDTokens(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(claims jwt.MapClaims)
+		wantErr error
+	}{
+		{name: "other audience", mutate: func(c jwt.MapClaims) { c["aud"] = "another-app" }},
+		{name: "other issuer", mutate: func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }},
+		{name: "expired", mutate: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }},
+		{name: "several audiences without azp", mutate: func(c jwt.MapClaims) { c["aud"] = []string{"api", "another-app"} }},
+		{name: "unverified email", mutate: func(c jwt.MapClaims) { c["email_verified"] = false }, wantErr: ErrEmailUnverified},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			idp := newFakeIdP(t)
+			tt.mutate(idp.claims)
+			p := idp.provider("")
+
+			// Act
+			_, err := p.Exchange(context.Background(), "good-code")
+
+			// Assert
+			if err == nil {
+				t.Fatal("Exchange() accepted the token")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Exchange() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOIDC_ExchangeRejectsTokenSignedByAnotherKey(t *testing.T) {
+	// Arrange
+	idp := newFakeIdP(t)
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	p := idp.provider("")
+	if _, err := p.Exchange(context.Background(), "good-code"); err != nil {
+		t.Fatalf("first Exchange() error = %v", err)
+	}
+	idp.key = other
+
+	// Act
+	_, err := p.Exchange(context.Background(), "good-code")
+
+	// Assert
+	if err == nil {
+		t.Error("Exchange() accepted a token signed by an unpublished key")
+	}
+}
+
+func TestClaimStrings(t *testing.T) {
+	claims := map[string]interface{}{
+		"https://example.com/roles": []interface{}{"editor"},
+		"roles":                     "admin",
+		"realm_access":              map[string]interface{}{"roles": []interface{}{"a", "b"}},
+	}
+
+	if got := claimStrings(claims, "https://example.com/roles"); len(got) != 1 || got[0] != "editor" {
+		t.Errorf("namespaced claim = %v", got)
+	}
+	if got := claimStrings(claims, "roles"); len(got) != 1 || got[0] != "admin" {
+		t.Errorf("string claim = %v", got)
+	}
+	if got := claimStrings(claims, "realm_access.roles"); len(got) != 2 {
+		t.Errorf("nested claim = %v", got)
+	}
+	if got := claimStrings(claims, "missing.roles"); got != nil {
+		t.Errorf("missing claim = %v", got)
+	}
+}
@@ -0,0 +1,96 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// fakeGitHub serves the token and user endpoints with the given emails response
+func fakeGitHub(t *testing.T, emails string) *GitHub {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.PostForm.Get("code") != "good-code" {
+			_, _ = w.Write([]byte(`{"error":"bad_verification_code","error_description":"The code is incorrect"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"gho_token","token_type":"bearer"}`))
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer gho_token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":4242,"name":"Ada Lovelace","email":"unverified@example.com"}`))
+	})
+	mux.HandleFunc("/user/emails", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(emails))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	g := NewGitHub("client-id", "client-secret", "http://localhost:8080/api/v1/auth/oauth/github/callback")
+	g.tokenURL = server.URL + "/token"
+	g.apiBase = server.URL
+	return g
+}
+
+func TestGitHub_Exchange(t *testing.T) {
+	g := fakeGitHub(t, `[{"email":"old@example.com","primary":false,"verified":true},{"email":"ada@example.com","primary":true,"verified":true}]`)
+
+	identity, err := g.Exchange(context.Background(), "good-code")
+
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	want := Identity{Provider: "github", Subject: "4242", Email: "ada@example.com", FirstName: "Ada", LastName: "Lovelace"}
+	if *identity != want {
+		t.Errorf("Exchange() = %+v, want %+v", *identity, want)
+	}
+}
+
+func TestGitHub_ExchangeRejectsUnverifiedPrimaryEmail(t *testing.T) {
+	g := fakeGitHub(t, `[{"email":"ada@example.com","primary":true,"verified":false}]`)
+
+	_, err := g.Exchange(context.Background(), "good-code")
+
+	if !errors.Is(err, ErrEmailUnverified) {
+		t.Errorf("Exchange() error = %v, want ErrEmailUnverified", err)
+	}
+}
+
+func TestGitHub_ExchangeReportsBadCode(t *testing.T) {
+	g := fakeGitHub(t, `[]`)
+
+	_, err := g.Exchange(context.Background(), "stale-code")
+
+	if err == nil || errors.Is(err, ErrEmailUnverified) {
+		t.Errorf("Exchange() error = %v, want a token exchange error", err)
+	}
+}
+
+func TestGoogle_AuthCodeURL(t *testing.T) {
+	g := NewGoogle("client-id", "client-secret", "https://api.example.com/api/v1/auth/oauth/google/callback")
+
+	raw, err := g.AuthCodeURL(context.Background(), "xyz")
+	if err != nil {
+		t.Fatalf("AuthCodeURL() error = %v", err)
+	}
+	u, err := url.Parse(raw)
+
+	if err != nil {
+		t.Fatalf("AuthCodeURL() is not a URL: %v", err)
+	}
+	q := u.Query()
+	if q.Get("state") != "xyz" || q.Get("response_type") != "code" || q.Get("scope") != "openid email profile" {
+		t.Errorf("AuthCodeURL() query = %v", q)
+	}
+	if q.Get("redirect_uri") != "https://api.example.com/api/v1/auth/oauth/google/callback" {
+		t.Errorf("redirect_uri = %q", q.Get("redirect_uri"))
+	}
+}
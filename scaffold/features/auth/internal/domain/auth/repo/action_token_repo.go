@@ -0,0 +1,70 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/model"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ActionTokenRepo interface {
+	Create(ctx context.Context, token *model.ActionToken) error
+	// FindByHash returns the token with the hash, or nil if none exists
+	FindByHash(ctx context.Context, hash string) (*model.ActionToken, error)
+	// Consume marks the token used if it is unused and unexpired at now, and reports
+	// whether it did. Only one of several concurrent calls succeeds.
+	Consume(ctx context.Context, id uuid.UUID, now time.Time) (bool, error)
+	// DeleteForSubject removes every token of the purpose issued for subject
+	DeleteForSubject(ctx context.Context, purpose, subject string) error
+	// DeleteExpired removes tokens that expired or were consumed before cutoff
+	DeleteExpired(ctx context.Context, cutoff time.Time) error
+}
+
+type actionTokenRepo struct {
+	db *gorm.DB
+}
+
+func NewActionTokenRepo(db *gorm.DB) ActionTokenRepo {
+	return &actionTokenRepo{db: db}
+}
+
+func (r *actionTokenRepo) Create(ctx context.Context, token *model.ActionToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *actionTokenRepo) FindByHash(ctx context.Context, hash string) (*model.ActionToken, error) {
+	var token model.ActionToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *actionTokenRepo) Consume(ctx context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.ActionToken{}).
+		Where("id = ? AND consumed_at IS NULL AND expires_at > ?", id, now).
+		UpdateColumn("consumed_at", now)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *actionTokenRepo) DeleteForSubject(ctx context.Context, purpose, subject string) error {
+	return r.db.WithContext(ctx).
+		Where("purpose = ? AND subject = ?", purpose, subject).
+		Delete(&model.ActionToken{}).Error
+}
+
+func (r *actionTokenRepo) DeleteExpired(ctx context.Context, cutoff time.Time) error {
+	return r.db.WithContext(ctx).
+		Where("expires_at < ? OR consumed_at < ?", cutoff, cutoff).
+		Delete(&model.ActionToken{}).Error
+}
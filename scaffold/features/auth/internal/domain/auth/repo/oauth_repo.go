@@ -0,0 +1,42 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/model"
+
+	"gorm.io/gorm"
+)
+
+type OAuthRepo interface {
+	Create(ctx context.Context, identity *model.OAuthIdentity) error
+	FindBySubject(ctx context.Context, provider, subject string) (*model.OAuthIdentity, error)
+}
+
+type oauthRepo struct {
+	db *gorm.DB
+}
+
+func NewOAuthRepo(db *gorm.DB) OAuthRepo {
+	return &oauthRepo{db: db}
+}
+
+func (r *oauthRepo) Create(ctx context.Context, identity *model.OAuthIdentity) error {
+	return r.db.WithContext(ctx).Create(identity).Error
+}
+
+// FindBySubject returns the link for a provider account, or nil if it is not linked
+func (r *oauthRepo) FindBySubject(ctx context.Context, provider, subject string) (*model.OAuthIdentity, error) {
+	var identity model.OAuthIdentity
+	err := r.db.WithContext(ctx).
+		Where("provider = ? AND subject = ?", provider, subject).
+		First(&identity).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
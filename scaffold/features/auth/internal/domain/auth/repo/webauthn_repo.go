@@ -0,0 +1,101 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/model"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebAuthnRepo interface {
+	CreateChallenge(ctx context.Context, challenge *model.WebAuthnChallenge) error
+	ConsumeChallenge(ctx context.Context, challengeHash string) (*model.WebAuthnChallenge, error)
+	DeleteExpiredChallenges(ctx context.Context) error
+
+	CreateCredential(ctx context.Context, credential *model.WebAuthnCredential) error
+	FindCredential(ctx context.Context, credentialID []byte) (*model.WebAuthnCredential, error)
+	ListCredentials(ctx context.Context, userID uuid.UUID) ([]model.WebAuthnCredential, error)
+	UpdateCredentialUsage(ctx context.Context, id uuid.UUID, signCount uint32, usedAt time.Time) error
+	DeleteCredential(ctx context.Context, userID, id uuid.UUID) (bool, error)
+}
+
+type webAuthnRepo struct {
+	db *gorm.DB
+}
+
+func NewWebAuthnRepo(db *gorm.DB) WebAuthnRepo {
+	return &webAuthnRepo{db: db}
+}
+
+func (r *webAuthnRepo) CreateChallenge(ctx context.Context, challenge *model.WebAuthnChallenge) error {
+	return r.db.WithContext(ctx).Create(challenge).Error
+}
+
+// ConsumeChallenge deletes the challenge with the given hash and returns it, or nil if
+// there is none, so a challenge is answered at most once
+func (r *webAuthnRepo) ConsumeChallenge(ctx context.Context, challengeHash string) (*model.WebAuthnChallenge, error) {
+	var challenges []model.WebAuthnChallenge
+	result := r.db.WithContext(ctx).
+		Clauses(clause.Returning{}).
+		Where("challenge_hash = ?", challengeHash).
+		Delete(&challenges)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if len(challenges) == 0 {
+		return nil, nil
+	}
+	return &challenges[0], nil
+}
+
+func (r *webAuthnRepo) DeleteExpiredChallenges(ctx context.Context) error {
+	return r.db.WithContext(ctx).Where("expires_at < ?", time.Now()).
+		Delete(&model.WebAuthnChallenge{}).Error
+}
+
+func (r *webAuthnRepo) CreateCredential(ctx context.Context, credential *model.WebAuthnCredential) error {
+	return r.db.WithContext(ctx).Create(credential).Error
+}
+
+// FindCredential returns the passkey with the authenticator's credential ID, or nil if none exists
+func (r *webAuthnRepo) FindCredential(ctx context.Context, credentialID []byte) (*model.WebAuthnCredential, error) {
+	var credential model.WebAuthnCredential
+	err := r.db.WithContext(ctx).
+		Where("credential_id = ?", credentialID).
+		First(&credential).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+func (r *webAuthnRepo) ListCredentials(ctx context.Context, userID uuid.UUID) ([]model.WebAuthnCredential, error) {
+	var credentials []model.WebAuthnCredential
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at").
+		Find(&credentials).Error
+	return credentials, err
+}
+
+func (r *webAuthnRepo) UpdateCredentialUsage(ctx context.Context, id uuid.UUID, signCount uint32, usedAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.WebAuthnCredential{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{"sign_count": signCount, "last_used_at": usedAt}).Error
+}
+
+// DeleteCredential removes one of the user's passkeys and reports whether it existed
+func (r *webAuthnRepo) DeleteCredential(ctx context.Context, userID, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", id, userID).
+		Delete(&model.WebAuthnCredential{})
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	userModel "github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+
+	"github.com/google/uuid"
+)
+
+// SetAccountDeletionGrace sets how long after ScheduleAccountDeletion an account is
+// anonymized (see UserService.AnonymizeDeletedAccounts)
+func (s *AuthService) SetAccountDeletionGrace(d time.Duration) {
+	s.deletionGrace = d
+}
+
+// ScheduleAccountDeletion schedules the deletion of userID's account after the grace
+// period and signs the user out everywhere. Logging in again before the deletion runs
+// cancels it. Asking again keeps the date first scheduled.
+func (s *AuthService) ScheduleAccountDeletion(ctx context.Context, userID string) (time.Time, error) {
+	if actor.ImpersonatorID(ctx) != "" {
+		return time.Time{}, apperrors.NewAppError(apperrors.ForbiddenError, "Accounts cannot be deleted while impersonating")
+	}
+	if _, err := uuid.Parse(userID); err != nil {
+		return time.Time{}, apperrors.ErrUserNotFound
+	}
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		s.logger.Errorw("failed to fetch user", "user_id", userID, "error", err)
+		return time.Time{}, apperrors.NewAppError(apperrors.InternalError, "Failed to delete account")
+	}
+	if user == nil {
+		return time.Time{}, apperrors.ErrUserNotFound
+	}
+
+	if !user.DeletionScheduled() {
+		scheduledAt := s.jwt.clock.Now().Add(s.deletionGrace).Truncate(time.Second)
+		user.DeletionScheduledAt = &scheduledAt
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			s.logger.Errorw("failed to schedule account deletion", "user_id", userID, "error", err)
+			return time.Time{}, apperrors.NewAppError(apperrors.InternalError, "Failed to delete account")
+		}
+		s.logger.Infow("account deletion scheduled", "audit", true, "user_id", userID,
+			"scheduled_at", scheduledAt, "ip", actor.ClientIP(ctx))
+	}
+
+	tokens, err := s.tokenStore.RevokeUser(ctx, userID)
+	if err != nil {
+		// The deletion is scheduled either way; the sessions end when it runs
+		s.logger.Errorw("failed to revoke refresh tokens of deleted account", "user_id", userID, "error", err)
+	}
+	for _, token := range tokens {
+		s.auditRevocation(ctx, "user", token)
+	}
+	for _, hook := range s.revokeHooks {
+		hook(userID)
+	}
+	return *user.DeletionScheduledAt, nil
+}
+
+// cancelDeletion clears a scheduled deletion when its owner logs in again
+func (s *AuthService) cancelDeletion(ctx context.Context, user *userModel.User) error {
+	scheduledAt := user.DeletionScheduledAt
+	user.DeletionScheduledAt = nil
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		user.DeletionScheduledAt = scheduledAt
+		s.logger.Errorw("failed to cancel account deletion", "user_id", user.ID, "error", err)
+		return apperrors.NewAppError(apperrors.InternalError, "Failed to restore account")
+	}
+	s.logger.Infow("account deletion cancelled by login", "audit", true, "user_id", user.ID, "scheduled_at", scheduledAt)
+	return nil
+}
@@ -2,8 +2,16 @@ package service
 
 import (
 	"context"
-	"go_platform_template/internal/domain/user/repo"
-	apperrors "go_platform_template/internal/shared/errors"
+	authModel "github.com/MurtadaNazar/go-api-template/internal/domain/auth/model"
+	authRepo "github.com/MurtadaNazar/go-api-template/internal/domain/auth/repo"
+	userModel "github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/repo"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/email"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/ipban"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/risk"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/webhook"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
 	"time"
 
 	"go.uber.org/zap"
@@ -14,68 +22,310 @@ type AuthService struct {
 	userRepo   repo.UserRepo
 	jwt        *JWTManager
 	tokenStore *TokenStore
-	logger     *zap.SugaredLogger
+	otp        *OTPService
+	oauth      *oauthLogin
+	passkeys   *passkeyLogin
+	limiter    *LoginLimiter
+	risk       *risk.Engine
+	bans       *ipban.Guard
+	// revokeHooks run after a user's refresh tokens are all revoked
+	revokeHooks []func(userID string)
+	// deletionGrace is how long after DELETE /me an account is anonymized
+	deletionGrace time.Duration
+	// loginAlerts emails users about logins from new devices; nil sends none
+	loginAlerts email.Sender
+	// webhooks receive login and token revocation events; nil sends none
+	webhooks *webhook.Dispatcher
+	// clients are the service clients of the client credentials grant; nil disables it
+	clients authRepo.ServiceClientRepo
+	logger  *zap.SugaredLogger
 }
 
 func NewAuthService(userRepo repo.UserRepo, jwt *JWTManager, store *TokenStore, logger *zap.SugaredLogger) *AuthService {
 	return &AuthService{userRepo: userRepo, jwt: jwt, tokenStore: store, logger: logger}
 }
 
+// SetOTPService enables SMS one-time code login and SMS two-factor verification
+func (s *AuthService) SetOTPService(otp *OTPService) {
+	s.otp = otp
+}
+
+// SetRiskEngine scores password logins for abuse: risky ones need a CAPTCHA or are
+// rejected, and accounts that log in despite a suspicious score are flagged for review
+func (s *AuthService) SetRiskEngine(e *risk.Engine) {
+	s.risk = e
+}
+
+// SetIPBans reports failed and throttled password logins and invalid refresh tokens to
+// bans, which bans client IPs that keep failing
+func (s *AuthService) SetIPBans(g *ipban.Guard) {
+	s.bans = g
+}
+
+// SetWebhooks publishes user.login and token.revoked events to webhooks
+func (s *AuthService) SetWebhooks(d *webhook.Dispatcher) {
+	s.webhooks = d
+}
+
 func (s *AuthService) Login(ctx context.Context, emailOrUsername, password string) (string, string, error) {
+	return s.LoginWithCode(ctx, emailOrUsername, password, "", false)
+}
+
+// LoginWithCode authenticates with a password and, for users with SMS two-factor
+// enabled, the one-time code texted to them. Calling it without a code for such a
+// user sends the code and returns an "otp required" error. rememberMe issues a
+// refresh token with the longer remember-me lifetime.
+func (s *AuthService) LoginWithCode(ctx context.Context, emailOrUsername, password, code string, rememberMe bool) (string, string, error) {
+	user, err := s.Authenticate(ctx, emailOrUsername, password, code)
+	if err != nil {
+		return "", "", err
+	}
+	return s.issueTokens(ctx, user, rememberMe)
+}
+
+// Authenticate checks credentials like LoginWithCode and returns the user without
+// issuing tokens, for flows that hand out their own (e.g. the OIDC provider)
+func (s *AuthService) Authenticate(ctx context.Context, emailOrUsername, password, code string) (*userModel.User, error) {
+	// A client IP guessing across accounts is stopped before any lookup
+	if err := s.limiter.CheckIP(ctx); err != nil {
+		s.logger.Warnw("login attempt from throttled client", "ip", actor.ClientIP(ctx))
+		s.bans.Record(ctx, actor.ClientIP(ctx), ipban.ReasonThrottledLogin)
+		return nil, err
+	}
+	assessment, err := s.risk.Check(ctx, risk.Attempt{Kind: risk.KindLogin, IP: actor.ClientIP(ctx)})
+	if err != nil {
+		return nil, err
+	}
+
 	// Try to find user by email OR username
 	user, err := s.userRepo.GetByEmailOrUsername(ctx, emailOrUsername)
 	if err != nil {
 		s.logger.Errorw("failed to fetch user", "email_or_username", emailOrUsername, "error", err)
-		return "", "", apperrors.NewAppError(apperrors.UnauthorizedError, "Invalid credentials")
+		return nil, apperrors.NewAppError(apperrors.UnauthorizedError, "Invalid credentials")
 	}
 	if user == nil {
 		s.logger.Warnw("user not found", "email_or_username", emailOrUsername)
-		return "", "", apperrors.NewAppError(apperrors.UnauthorizedError, "Invalid credentials")
+		s.limiter.Fail(ctx, "")
+		s.bans.Record(ctx, actor.ClientIP(ctx), ipban.ReasonFailedLogin)
+		return nil, apperrors.NewAppError(apperrors.UnauthorizedError, "Invalid credentials")
 	}
 
 	// Check if user is active
 	if !user.IsActive() {
 		s.logger.Warnw("inactive user login attempt", "user_id", user.ID)
-		return "", "", apperrors.NewAppError(apperrors.ForbiddenError, "Account is inactive")
+		return nil, apperrors.NewAppError(apperrors.ForbiddenError, "Account is inactive")
+	}
+
+	// The password is not even compared while the account is backing off or locked
+	if err := s.limiter.CheckAccount(ctx, user.ID.String()); err != nil {
+		s.logger.Warnw("login attempt on throttled account", "user_id", user.ID)
+		return nil, err
 	}
 
 	// Compare passwords
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
 		s.logger.Warnw("invalid password", "user_id", user.ID)
+		s.limiter.Fail(ctx, user.ID.String())
+		s.bans.Record(ctx, actor.ClientIP(ctx), ipban.ReasonFailedLogin)
+		return nil, apperrors.NewAppError(apperrors.UnauthorizedError, "Invalid credentials")
+	}
+	s.limiter.Succeed(ctx, user.ID.String())
+	if assessment.Action == risk.Flag {
+		s.flagForReview(ctx, user, assessment.Reason())
+	}
+
+	if user.SMSTwoFactor {
+		if err := s.verifySecondFactor(ctx, user, code); err != nil {
+			return nil, err
+		}
+	}
+
+	return user, nil
+}
+
+// flagForReview marks user for review unless it already is; a failure is logged and
+// does not stop the login
+func (s *AuthService) flagForReview(ctx context.Context, user *userModel.User, reason string) {
+	if user.FlaggedForReview() {
+		return
+	}
+	user.ReviewReason = &reason
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		s.logger.Errorw("failed to flag account for review", "user_id", user.ID, "error", err)
+		return
+	}
+	s.logger.Warnw("account flagged for review", "user_id", user.ID, "reason", reason)
+}
+
+// RequestLoginOTP texts a passwordless login code to phone. Unknown, unverified or
+// inactive numbers are ignored so the endpoint cannot be used to discover accounts.
+func (s *AuthService) RequestLoginOTP(ctx context.Context, phone string) error {
+	if s.otp == nil {
+		return apperrors.NewAppError(apperrors.BadRequestError, "SMS login is not enabled")
+	}
+
+	user, err := s.userRepo.GetByPhone(ctx, phone)
+	if err != nil {
+		s.logger.Errorw("failed to fetch user by phone", "error", err)
+		return apperrors.NewAppError(apperrors.InternalError, "Failed to send verification code")
+	}
+	if user == nil || !user.PhoneVerified() || !user.IsActive() {
+		s.logger.Warnw("otp requested for unknown, unverified or inactive phone")
+		return nil
+	}
+
+	if err := s.otp.Send(ctx, user.ID, phone, authModel.OTPPurposeLogin); err != nil {
+		s.logger.Errorw("failed to send login otp", "user_id", user.ID, "error", err)
+		return apperrors.NewAppError(apperrors.InternalError, "Failed to send verification code")
+	}
+	return nil
+}
+
+// LoginWithOTP exchanges a verified phone number and the code sent to it for tokens
+func (s *AuthService) LoginWithOTP(ctx context.Context, phone, code string) (string, string, error) {
+	if s.otp == nil {
+		return "", "", apperrors.NewAppError(apperrors.BadRequestError, "SMS login is not enabled")
+	}
+
+	user, err := s.userRepo.GetByPhone(ctx, phone)
+	if err != nil {
+		s.logger.Errorw("failed to fetch user by phone", "error", err)
 		return "", "", apperrors.NewAppError(apperrors.UnauthorizedError, "Invalid credentials")
 	}
+	if user == nil {
+		return "", "", apperrors.ErrInvalidOTP
+	}
+	// A number set without confirming it may belong to someone else; answer as for an
+	// unknown number
+	if !user.PhoneVerified() {
+		s.logger.Warnw("otp login attempt with unverified phone", "user_id", user.ID)
+		return "", "", apperrors.ErrInvalidOTP
+	}
+	if !user.IsActive() {
+		s.logger.Warnw("inactive user otp login attempt", "user_id", user.ID)
+		return "", "", apperrors.NewAppError(apperrors.ForbiddenError, "Account is inactive")
+	}
+
+	if err := s.otp.Verify(ctx, user.ID, authModel.OTPPurposeLogin, code); err != nil {
+		return "", "", s.otpError(user, err)
+	}
+
+	return s.issueTokens(ctx, user, false)
+}
+
+// verifySecondFactor sends or checks the SMS code for users with two-factor enabled
+func (s *AuthService) verifySecondFactor(ctx context.Context, user *userModel.User, code string) error {
+	if s.otp == nil || !user.PhoneVerified() {
+		s.logger.Errorw("sms two-factor enabled but unavailable", "user_id", user.ID)
+		return apperrors.NewAppError(apperrors.InternalError, "Two-factor verification is unavailable")
+	}
+
+	if code == "" {
+		if err := s.otp.Send(ctx, user.ID, *user.Phone, authModel.OTPPurposeTwoFactor); err != nil {
+			s.logger.Errorw("failed to send two-factor otp", "user_id", user.ID, "error", err)
+			return apperrors.NewAppError(apperrors.InternalError, "Failed to send verification code")
+		}
+		return apperrors.NewAppErrorWithDetails(
+			apperrors.UnauthorizedError,
+			"Two-factor verification required",
+			"otp_required: a verification code was sent to your phone",
+		)
+	}
+
+	if err := s.otp.Verify(ctx, user.ID, authModel.OTPPurposeTwoFactor, code); err != nil {
+		return s.otpError(user, err)
+	}
+	return nil
+}
+
+func (s *AuthService) otpError(user *userModel.User, err error) error {
+	if appErr, ok := apperrors.IsAppError(err); ok {
+		s.logger.Warnw("otp verification failed", "user_id", user.ID)
+		return appErr
+	}
+	s.logger.Errorw("otp verification error", "user_id", user.ID, "error", err)
+	return apperrors.NewAppError(apperrors.InternalError, "Failed to verify code")
+}
+
+// issueTokens generates and persists a token pair for an authenticated user, starting a
+// new session
+func (s *AuthService) issueTokens(ctx context.Context, user *userModel.User, rememberMe bool) (string, string, error) {
+	// Logging in is how users take back a deletion they asked for
+	if user.DeletionScheduled() {
+		if err := s.cancelDeletion(ctx, user); err != nil {
+			return "", "", err
+		}
+	}
+
+	session := Session{
+		StartedAt:   s.jwt.clock.Now(),
+		RememberMe:  rememberMe,
+		Fingerprint: DeviceFingerprint(actor.UserAgent(ctx), actor.ClientIP(ctx)),
+	}
+	expiresAt := s.jwt.refreshExpiry(session)
 
 	// Generate tokens
-	access, refresh, err := s.jwt.GenerateTokens(user.ID, string(user.UserType))
+	prefs := Preferences{TimeZone: user.TimeZone, Locale: user.Locale}
+	access, refresh, err := s.jwt.generateTokens(ctx, user.ID, string(user.UserType), prefs, expiresAt)
 	if err != nil {
 		s.logger.Errorw("failed to generate tokens", "user_id", user.ID, "error", err)
 		return "", "", apperrors.NewAppError(apperrors.InternalError, "Failed to generate authentication tokens")
 	}
 
+	// Check the device before the saved token makes it known
+	newDevice := s.isNewDevice(ctx, user, session.Fingerprint)
+
 	// Save refresh token
-	if err := s.tokenStore.Save(ctx, refresh, user.ID, string(user.UserType), time.Now().Add(s.jwt.refreshExpires)); err != nil {
+	if err := s.tokenStore.Save(ctx, refresh, user.ID, string(user.UserType), expiresAt, session); err != nil {
 		s.logger.Errorw("failed to save refresh token", "user_id", user.ID, "error", err)
 		return "", "", apperrors.NewAppError(apperrors.InternalError, "Failed to save authentication token")
 	}
+	if newDevice {
+		s.sendLoginAlert(ctx, user, session.StartedAt)
+	}
+	// Best effort: a failure only leaves the previous login on record
+	if err := s.userRepo.RecordLogin(ctx, user.ID.String(), session.StartedAt, actor.ClientIP(ctx)); err != nil {
+		s.logger.Warnw("failed to record last login", "user_id", user.ID, "error", err)
+	}
+	s.webhooks.Publish(webhook.EventUserLogin, map[string]any{
+		"user_id":    user.ID,
+		"ip":         actor.ClientIP(ctx),
+		"user_agent": actor.UserAgent(ctx),
+	})
 
-	s.logger.Infow("user logged in", "user_id", user.ID)
+	s.logger.Infow("user logged in", "user_id", user.ID, "remember_me", rememberMe)
 	return access, refresh, nil
 }
 
+// Refresh rotates a refresh token. The new token extends the session by another refresh
+// lifetime, up to the session's maximum lifetime; after that the user has to log in again.
 func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (string, string, error) {
 	data, err := s.tokenStore.Validate(ctx, refreshToken, true)
 	if err != nil {
 		s.logger.Errorw("failed to validate refresh token", "error", err)
+		s.bans.Record(ctx, actor.ClientIP(ctx), ipban.ReasonInvalidRefresh)
 		return "", "", apperrors.NewAppError(apperrors.UnauthorizedError, "Invalid or expired refresh token")
 	}
 
-	access, newRefresh, err := s.jwt.GenerateTokens(data.UserID, data.Role)
+	expiresAt := s.jwt.refreshExpiry(data.Session)
+	if expiresAt.IsZero() {
+		s.logger.Infow("session reached its maximum lifetime", "user_id", data.UserID)
+		return "", "", apperrors.NewAppError(apperrors.UnauthorizedError, "Session expired, please log in again")
+	}
+
+	// Pick up preferences changed since the last login; tokens still refresh without them
+	var prefs Preferences
+	if user, err := s.userRepo.FindByID(ctx, data.UserID.String()); err == nil && user != nil {
+		prefs = Preferences{TimeZone: user.TimeZone, Locale: user.Locale}
+	}
+
+	access, newRefresh, err := s.jwt.generateTokens(ctx, data.UserID, data.Role, prefs, expiresAt)
 	if err != nil {
 		s.logger.Errorw("failed to generate new tokens", "user_id", data.UserID, "error", err)
 		return "", "", apperrors.NewAppError(apperrors.InternalError, "Failed to generate new tokens")
 	}
 
-	if err := s.tokenStore.Save(ctx, newRefresh, data.UserID, data.Role, time.Now().Add(s.jwt.refreshExpires)); err != nil {
+	if err := s.tokenStore.Save(ctx, newRefresh, data.UserID, data.Role, expiresAt, data.Session); err != nil {
 		s.logger.Errorw("failed to save new refresh token", "user_id", data.UserID, "error", err)
 		return "", "", apperrors.NewAppError(apperrors.InternalError, "Failed to save new token")
 	}
@@ -84,6 +334,15 @@ func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (string,
 	return access, newRefresh, nil
 }
 
+// RefreshExpiry returns when a refresh token issued by this service expires
+func (s *AuthService) RefreshExpiry(refreshToken string) (time.Time, error) {
+	claims, err := s.jwt.ValidateRefreshToken(refreshToken)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return claims.ExpiresAt.Time, nil
+}
+
 func (s *AuthService) Logout(ctx context.Context, refreshToken string) error {
 	if err := s.tokenStore.Delete(ctx, refreshToken); err != nil {
 		s.logger.Errorw("failed to logout", "error", err)
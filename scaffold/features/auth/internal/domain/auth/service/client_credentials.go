@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/dto"
+	authModel "github.com/MurtadaNazar/go-api-template/internal/domain/auth/model"
+	authRepo "github.com/MurtadaNazar/go-api-template/internal/domain/auth/repo"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/ipban"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ServiceClientRole is the role of client credentials tokens. No account has it; what a
+// client may do is limited to the scopes in its token.
+const ServiceClientRole = "client"
+
+var clientIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{3,64}$`)
+
+// TokenError is an OAuth2 error of the token endpoint (RFC 6749 section 5.2)
+type TokenError struct {
+	Code        string
+	Description string
+}
+
+func (e *TokenError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+// SetServiceClients enables the client credentials grant for the registered clients
+func (s *AuthService) SetServiceClients(clients authRepo.ServiceClientRepo) {
+	s.clients = clients
+}
+
+// IssueClientToken authenticates a service client by its secret and returns an access
+// token limited to scope, or to all of the client's scopes when scope is empty. Failures
+// are *TokenError.
+func (s *AuthService) IssueClientToken(ctx context.Context, req dto.ClientTokenRequest) (*dto.ClientTokenResponse, error) {
+	if req.GrantType != "client_credentials" {
+		return nil, &TokenError{Code: "unsupported_grant_type", Description: "only client_credentials is supported"}
+	}
+	if s.clients == nil {
+		return nil, &TokenError{Code: "invalid_client", Description: "client authentication failed"}
+	}
+	client, err := s.clients.FindByClientID(ctx, req.ClientID)
+	if err != nil {
+		s.logger.Errorw("failed to look up service client", "client_id", req.ClientID, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to issue token")
+	}
+	if client == nil || bcrypt.CompareHashAndPassword([]byte(client.SecretHash), []byte(req.ClientSecret)) != nil {
+		s.bans.Record(ctx, actor.ClientIP(ctx), ipban.ReasonInvalidClient)
+		s.logger.Warnw("service client authentication failed", "client_id", req.ClientID, "ip", actor.ClientIP(ctx))
+		return nil, &TokenError{Code: "invalid_client", Description: "client authentication failed"}
+	}
+
+	allowed := client.ScopeList()
+	scopes := strings.Fields(req.Scope)
+	if len(scopes) == 0 {
+		scopes = allowed
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(allowed, scope) {
+			return nil, &TokenError{Code: "invalid_scope", Description: "scope " + scope + " is not allowed for this client"}
+		}
+	}
+	scopes = slices.Compact(slices.Sorted(slices.Values(scopes)))
+
+	token, expiresAt, err := s.jwt.GenerateClientToken(client.ID, client.ClientID, scopes)
+	if err != nil {
+		s.logger.Errorw("failed to generate client token", "client_id", client.ClientID, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to issue token")
+	}
+	s.logger.Infow("client token issued", "client_id", client.ClientID, "scope", scopes, "ip", actor.ClientIP(ctx))
+	return &dto.ClientTokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(expiresAt.Sub(s.jwt.clock.Now()).Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	}, nil
+}
+
+// CreateServiceClient registers a service client and returns it with its secret, which
+// is stored only as a hash and cannot be shown again
+func (s *AuthService) CreateServiceClient(ctx context.Context, req dto.CreateServiceClientRequest) (*dto.CreateServiceClientResponse, error) {
+	if s.clients == nil {
+		return nil, apperrors.NewAppError(apperrors.BadRequestError, "Service clients are not enabled")
+	}
+	if !clientIDPattern.MatchString(req.ClientID) {
+		return nil, apperrors.NewAppError(apperrors.ValidationError, "client_id may only contain letters, digits, dots, dashes and underscores")
+	}
+	for _, scope := range req.Scopes {
+		if strings.ContainsAny(scope, " \t\n") {
+			return nil, apperrors.NewAppError(apperrors.ValidationError, "scopes must not contain spaces")
+		}
+	}
+	existing, err := s.clients.FindByClientID(ctx, req.ClientID)
+	if err != nil {
+		s.logger.Errorw("failed to look up service client", "client_id", req.ClientID, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to create service client")
+	}
+	if existing != nil {
+		return nil, apperrors.NewAppError(apperrors.ConflictError, "client_id already exists")
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to create service client")
+	}
+	secret := base64.RawURLEncoding.EncodeToString(raw)
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to create service client")
+	}
+	scopes := slices.Compact(slices.Sorted(slices.Values(req.Scopes)))
+	client := &authModel.ServiceClient{
+		ClientID:   req.ClientID,
+		Name:       req.Name,
+		SecretHash: string(hash),
+		Scopes:     strings.Join(scopes, " "),
+	}
+	if err := s.clients.Create(ctx, client); err != nil {
+		s.logger.Errorw("failed to create service client", "client_id", req.ClientID, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to create service client")
+	}
+	s.logger.Infow("service client created",
+		"audit", true,
+		"client_id", client.ClientID,
+		"scopes", scopes,
+		"by", actor.UserID(ctx),
+		"ip", actor.ClientIP(ctx),
+	)
+	return &dto.CreateServiceClientResponse{
+		ClientID:     client.ClientID,
+		ClientSecret: secret,
+		Name:         client.Name,
+		Scopes:       scopes,
+	}, nil
+}
+
+// ListServiceClients returns the registered service clients without their secrets
+func (s *AuthService) ListServiceClients(ctx context.Context) ([]authModel.ServiceClient, error) {
+	if s.clients == nil {
+		return []authModel.ServiceClient{}, nil
+	}
+	clients, err := s.clients.List(ctx)
+	if err != nil {
+		s.logger.Errorw("failed to list service clients", "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to fetch service clients")
+	}
+	if clients == nil {
+		clients = []authModel.ServiceClient{}
+	}
+	return clients, nil
+}
+
+// DeleteServiceClient removes a service client. Tokens already issued to it stay valid
+// until they expire.
+func (s *AuthService) DeleteServiceClient(ctx context.Context, clientID string) error {
+	if s.clients == nil {
+		return apperrors.NewAppError(apperrors.NotFoundError, "service client not found")
+	}
+	deleted, err := s.clients.Delete(ctx, clientID)
+	if err != nil {
+		s.logger.Errorw("failed to delete service client", "client_id", clientID, "error", err)
+		return apperrors.NewAppError(apperrors.InternalError, "Failed to delete service client")
+	}
+	if !deleted {
+		return apperrors.NewAppError(apperrors.NotFoundError, "service client not found")
+	}
+	s.logger.Infow("service client deleted",
+		"audit", true,
+		"client_id", clientID,
+		"by", actor.UserID(ctx),
+		"ip", actor.ClientIP(ctx),
+	)
+	return nil
+}
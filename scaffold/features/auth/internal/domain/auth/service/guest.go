@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+
+	"github.com/google/uuid"
+)
+
+// GuestRole is the role of guest tokens. No account has it, so guests only get the
+// permissions an admin grants a role of that name.
+const GuestRole = "guest"
+
+// IssueGuestToken returns an access token for an anonymous client under a new guest ID,
+// with its expiry. It cannot be refreshed, and it is only accepted on routes that allow
+// guests (see middleware.AllowGuests).
+func (s *AuthService) IssueGuestToken(ctx context.Context) (string, uuid.UUID, time.Time, error) {
+	token, guestID, expiresAt, err := s.jwt.GenerateGuestToken()
+	if err != nil {
+		s.logger.Errorw("failed to generate guest token", "error", err)
+		return "", uuid.Nil, time.Time{}, apperrors.NewAppError(apperrors.InternalError, "Failed to issue guest token")
+	}
+	s.logger.Infow("guest token issued", "guest_id", guestID, "ip", actor.ClientIP(ctx))
+	return token, guestID, expiresAt, nil
+}
@@ -0,0 +1,410 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func TestJWTManager_AccessTokenExpires(t *testing.T) {
+	// Arrange
+	clk := testutil.NewFakeClock(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+	manager := NewJWTManager("test-signing-key-must-be-long-enough-for-jwt", "test-refresh-key-must-be-long-enough", 15*time.Minute, 7*24*time.Hour)
+	manager.SetClock(clk)
+	userID := uuid.New()
+	access, refresh, err := manager.GenerateTokens(context.Background(), userID, "user", Preferences{TimeZone: "Europe/Berlin"})
+	if err != nil {
+		t.Fatalf("GenerateTokens() error = %v", err)
+	}
+
+	// Act
+	clk.Advance(15*time.Minute - time.Second)
+	claims, err := manager.ValidateAccessToken(access)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("ValidateAccessToken() before expiry error = %v, want nil", err)
+	}
+	if claims.UserID != userID || claims.TimeZone != "Europe/Berlin" {
+		t.Errorf("claims = %+v, want the issued user and preferences", claims)
+	}
+
+	// Act - one more second reaches the expiry
+	clk.Advance(time.Second)
+	if _, err := manager.ValidateAccessToken(access); err == nil {
+		t.Error("ValidateAccessToken() after expiry error = nil, want expired")
+	}
+	if _, err := manager.ValidateRefreshToken(refresh); err != nil {
+		t.Errorf("ValidateRefreshToken() error = %v, want the refresh token still valid", err)
+	}
+}
+
+func TestJWTManager_IssuerAndAudience(t *testing.T) {
+	// Arrange
+	newManager := func(issuer string, audience ...string) *JWTManager {
+		m := NewJWTManager("test-signing-key-must-be-long-enough-for-jwt", "test-refresh-key-must-be-long-enough", 15*time.Minute, 7*24*time.Hour)
+		m.SetIssuerAndAudience(issuer, audience)
+		return m
+	}
+	issuing := newManager("https://auth.example.com", "api", "admin-ui")
+	access, refresh, err := issuing.GenerateTokens(context.Background(), uuid.New(), "user", Preferences{})
+	if err != nil {
+		t.Fatalf("GenerateTokens() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		validator *JWTManager
+		wantErr   bool
+	}{
+		{name: "same issuer and audience", validator: issuing},
+		{name: "one of the audiences", validator: newManager("https://auth.example.com", "admin-ui")},
+		{name: "other issuer", validator: newManager("https://other.example.com", "api"), wantErr: true},
+		{name: "other audience", validator: newManager("https://auth.example.com", "billing"), wantErr: true},
+		{name: "no checks configured", validator: newManager("")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			_, accessErr := tt.validator.ValidateAccessToken(access)
+			_, refreshErr := tt.validator.ValidateRefreshToken(refresh)
+
+			// Assert
+			if (accessErr != nil) != tt.wantErr || (refreshErr != nil) != tt.wantErr {
+				t.Errorf("access error = %v, refresh error = %v, want error %v", accessErr, refreshErr, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJWTManager_Leeway(t *testing.T) {
+	// Arrange
+	clk := testutil.NewFakeClock(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+	manager := NewJWTManager("test-signing-key-must-be-long-enough-for-jwt", "test-refresh-key-must-be-long-enough", 15*time.Minute, 7*24*time.Hour)
+	manager.SetClock(clk)
+	manager.SetLeeway(30 * time.Second)
+	access, _, err := manager.GenerateTokens(context.Background(), uuid.New(), "user", Preferences{})
+	if err != nil {
+		t.Fatalf("GenerateTokens() error = %v", err)
+	}
+
+	// Act
+	clk.Advance(15*time.Minute + 20*time.Second)
+	_, withinLeeway := manager.ValidateAccessToken(access)
+	clk.Advance(20 * time.Second)
+	_, pastLeeway := manager.ValidateAccessToken(access)
+
+	// Assert
+	if withinLeeway != nil {
+		t.Errorf("ValidateAccessToken() 20s after expiry error = %v, want accepted within the leeway", withinLeeway)
+	}
+	if pastLeeway == nil {
+		t.Error("ValidateAccessToken() 40s after expiry error = nil, want expired")
+	}
+}
+
+func TestJWTManager_ClaimsEnricher(t *testing.T) {
+	// Arrange
+	manager := NewJWTManager("test-signing-key-must-be-long-enough-for-jwt", "test-refresh-key-must-be-long-enough", 15*time.Minute, 7*24*time.Hour)
+	userID := uuid.New()
+	manager.SetClaimsEnricher(func(ctx context.Context, id uuid.UUID, role string) (map[string]interface{}, error) {
+		if id != userID || role != "admin" {
+			t.Errorf("enricher called with %s %q", id, role)
+		}
+		return map[string]interface{}{"tenant_id": "acme", "seats": 5}, nil
+	})
+
+	// Act
+	access, refresh, err := manager.GenerateTokens(context.Background(), userID, "admin", Preferences{})
+	if err != nil {
+		t.Fatalf("GenerateTokens() error = %v", err)
+	}
+	claims, err := manager.ValidateAccessToken(access)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("ValidateAccessToken() error = %v", err)
+	}
+	if claims.UserID != userID || claims.Role != "admin" || claims.Extra["tenant_id"] != "acme" || claims.Extra["seats"] != float64(5) {
+		t.Errorf("claims = %+v, want the standard and the extra claims", claims)
+	}
+	if refreshClaims, _ := manager.ValidateRefreshToken(refresh); refreshClaims == nil || refreshClaims.Extra != nil {
+		t.Errorf("refresh claims = %+v, want no extra claims", refreshClaims)
+	}
+}
+
+func TestJWTManager_GenerateImpersonationToken(t *testing.T) {
+	// Arrange
+	clk := testutil.NewFakeClock(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+	manager := NewJWTManager("test-signing-key-must-be-long-enough-for-jwt", "test-refresh-key-must-be-long-enough", 15*time.Minute, 7*24*time.Hour)
+	manager.SetClock(clk)
+	manager.SetImpersonationExpiry(5 * time.Minute)
+	userID, adminID := uuid.New(), uuid.New()
+
+	// Act
+	token, expiresAt, err := manager.GenerateImpersonationToken(context.Background(), userID, "user", Preferences{}, adminID)
+	if err != nil {
+		t.Fatalf("GenerateImpersonationToken() error = %v", err)
+	}
+	claims, err := manager.ValidateAccessToken(token)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("ValidateAccessToken() error = %v", err)
+	}
+	if claims.UserID != userID || claims.ImpersonatorID != adminID.String() {
+		t.Errorf("claims = %+v, want user %s impersonated by %s", claims, userID, adminID)
+	}
+	if want := clk.Now().Add(5 * time.Minute); !expiresAt.Equal(want) || !claims.ExpiresAt.Time.Equal(want) {
+		t.Errorf("expires at %v (claim %v), want %v", expiresAt, claims.ExpiresAt.Time, want)
+	}
+}
+
+func TestJWTManager_GenerateGuestToken(t *testing.T) {
+	// Arrange
+	clk := testutil.NewFakeClock(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+	manager := NewJWTManager("test-signing-key-must-be-long-enough-for-jwt", "test-refresh-key-must-be-long-enough", 15*time.Minute, 7*24*time.Hour)
+	manager.SetClock(clk)
+	manager.SetGuestExpiry(time.Hour)
+
+	// Act
+	token, guestID, expiresAt, err := manager.GenerateGuestToken()
+	if err != nil {
+		t.Fatalf("GenerateGuestToken() error = %v", err)
+	}
+	claims, err := manager.ValidateAccessToken(token)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("ValidateAccessToken() error = %v", err)
+	}
+	if !claims.Guest || claims.Role != GuestRole || claims.UserID != guestID {
+		t.Errorf("claims = %+v, want guest %s with role %q", claims, guestID, GuestRole)
+	}
+	if want := clk.Now().Add(time.Hour); !expiresAt.Equal(want) || !claims.ExpiresAt.Time.Equal(want) {
+		t.Errorf("expires at %v (claim %v), want %v", expiresAt, claims.ExpiresAt.Time, want)
+	}
+}
+
+func TestJWTManager_ClaimsEnricherErrorFailsTokens(t *testing.T) {
+	// Arrange
+	manager := NewJWTManager("test-signing-key-must-be-long-enough-for-jwt", "test-refresh-key-must-be-long-enough", 15*time.Minute, 7*24*time.Hour)
+	manager.SetClaimsEnricher(func(context.Context, uuid.UUID, string) (map[string]interface{}, error) {
+		return nil, errors.New("tenant lookup failed")
+	})
+
+	// Act
+	_, _, err := manager.GenerateTokens(context.Background(), uuid.New(), "user", Preferences{})
+
+	// Assert
+	if err == nil {
+		t.Error("GenerateTokens() error = nil, want the enricher's error")
+	}
+}
+
+func TestJWTManager_AsymmetricSigning(t *testing.T) {
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	tests := []struct {
+		name    string
+		key     crypto.Signer
+		wantAlg string
+	}{
+		{name: "RS256", key: rsaKey, wantAlg: "RS256"},
+		{name: "EdDSA", key: edKey, wantAlg: "EdDSA"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			manager := NewJWTManager("test-signing-key-must-be-long-enough-for-jwt", "test-refresh-key-must-be-long-enough", 15*time.Minute, 7*24*time.Hour)
+			if err := manager.SetSigningKey(tt.key); err != nil {
+				t.Fatalf("SetSigningKey() error = %v", err)
+			}
+			userID := uuid.New()
+
+			// Act
+			access, refresh, err := manager.GenerateTokens(context.Background(), userID, "user", Preferences{})
+			if err != nil {
+				t.Fatalf("GenerateTokens() error = %v", err)
+			}
+			claims, err := manager.ValidateAccessToken(access)
+
+			// Assert
+			if err != nil || claims.UserID != userID {
+				t.Fatalf("ValidateAccessToken() = %+v, %v; want the issued user", claims, err)
+			}
+			keys := manager.PublicKeys()
+			if len(keys) != 1 || keys[0].Algorithm != tt.wantAlg {
+				t.Fatalf("PublicKeys() = %+v, want one %s key", keys, tt.wantAlg)
+			}
+			token, _, _ := jwt.NewParser().ParseUnverified(access, &Claims{})
+			if token.Method.Alg() != tt.wantAlg || token.Header["kid"] != keys[0].KeyID {
+				t.Errorf("token header = %v, want alg %s and the published kid", token.Header, tt.wantAlg)
+			}
+			if _, err := manager.ValidateRefreshToken(refresh); err != nil {
+				t.Errorf("ValidateRefreshToken() error = %v, want refresh tokens unchanged", err)
+			}
+		})
+	}
+}
+
+func TestJWTManager_RejectsOtherAlgorithms(t *testing.T) {
+	// Arrange
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	manager := NewJWTManager("test-signing-key-must-be-long-enough-for-jwt", "test-refresh-key-must-be-long-enough", 15*time.Minute, 7*24*time.Hour)
+	if err := manager.SetSigningKey(rsaKey); err != nil {
+		t.Fatal(err)
+	}
+	claims := Claims{UserID: uuid.New(), Role: "admin", RegisteredClaims: jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+	}}
+	// An HS256 token keyed with the published public key, and one with the old shared secret
+	publicDER := x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)
+	confused, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(publicDER)
+	legacy, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test-signing-key-must-be-long-enough-for-jwt"))
+
+	// Act
+	_, confusedErr := manager.ValidateAccessToken(confused)
+	_, legacyErr := manager.ValidateAccessToken(legacy)
+
+	// Assert
+	if confusedErr == nil || legacyErr == nil {
+		t.Errorf("ValidateAccessToken() accepted an HS256 token: %v, %v", confusedErr, legacyErr)
+	}
+}
+
+func TestLoadSigningKey(t *testing.T) {
+	// Arrange
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	der, _ := x509.MarshalPKCS8PrivateKey(edKey)
+	edPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+
+	// Act
+	hmac, hmacErr := LoadSigningKey(config.JWTConfig{Algorithm: "HS256"})
+	ed, edErr := LoadSigningKey(config.JWTConfig{Algorithm: "EdDSA", PrivateKey: edPEM})
+	_, mismatchErr := LoadSigningKey(config.JWTConfig{Algorithm: "RS256", PrivateKey: edPEM})
+
+	// Assert
+	if hmac != nil || hmacErr != nil {
+		t.Errorf("HS256: LoadSigningKey() = %v, %v; want no key", hmac, hmacErr)
+	}
+	if _, ok := ed.(ed25519.PrivateKey); !ok || edErr != nil {
+		t.Errorf("EdDSA: LoadSigningKey() = %T, %v; want the Ed25519 key", ed, edErr)
+	}
+	if mismatchErr == nil {
+		t.Error("RS256 with an Ed25519 key: LoadSigningKey() error = nil")
+	}
+}
+
+func TestJWTManager_SigningKeyRotation(t *testing.T) {
+	// Arrange
+	_, oldKey, _ := ed25519.GenerateKey(rand.Reader)
+	_, newKey, _ := ed25519.GenerateKey(rand.Reader)
+	manager := NewJWTManager("test-signing-key-must-be-long-enough-for-jwt", "test-refresh-key-must-be-long-enough", 15*time.Minute, 7*24*time.Hour)
+	if err := manager.SetSigningKeys([]crypto.Signer{oldKey}); err != nil {
+		t.Fatalf("SetSigningKeys() error = %v", err)
+	}
+	oldToken, _, _ := manager.GenerateTokens(context.Background(), uuid.New(), "user", Preferences{})
+
+	// Act - the new key is added and signs from now on
+	if err := manager.SetSigningKeys([]crypto.Signer{oldKey, newKey}); err != nil {
+		t.Fatalf("SetSigningKeys() error = %v", err)
+	}
+	newToken, _, _ := manager.GenerateTokens(context.Background(), uuid.New(), "user", Preferences{})
+
+	// Assert
+	keys := manager.SigningKeys()
+	if len(keys) != 2 || !keys[0].Current || keys[1].Current {
+		t.Fatalf("SigningKeys() = %+v, want the current key first of two", keys)
+	}
+	token, _, _ := jwt.NewParser().ParseUnverified(newToken, &Claims{})
+	if token.Header["kid"] != keys[0].KeyID {
+		t.Errorf("new token kid = %v, want the current key %s", token.Header["kid"], keys[0].KeyID)
+	}
+	for name, access := range map[string]string{"old": oldToken, "new": newToken} {
+		if _, err := manager.ValidateAccessToken(access); err != nil {
+			t.Errorf("ValidateAccessToken(%s token) error = %v, want both keys accepted", name, err)
+		}
+	}
+	if got := len(manager.PublicKeys()); got != 2 {
+		t.Errorf("PublicKeys() returned %d keys, want both", got)
+	}
+
+	// Act - the old key is retired
+	if err := manager.SetSigningKeys([]crypto.Signer{newKey}); err != nil {
+		t.Fatalf("SetSigningKeys() error = %v", err)
+	}
+
+	// Assert
+	if _, err := manager.ValidateAccessToken(oldToken); err == nil {
+		t.Error("ValidateAccessToken() accepted a token signed with a retired key")
+	}
+	if _, err := manager.ValidateAccessToken(newToken); err != nil {
+		t.Errorf("ValidateAccessToken() error = %v, want the current key accepted", err)
+	}
+}
+
+func TestJWTManager_SetPreviousSecrets(t *testing.T) {
+	// Arrange
+	before := NewJWTManager("old-signing-key-must-be-long-enough-for-jwt", "test-refresh-key-must-be-long-enough", 15*time.Minute, 7*24*time.Hour)
+	access, _, _ := before.GenerateTokens(context.Background(), uuid.New(), "user", Preferences{})
+	rotated := NewJWTManager("new-signing-key-must-be-long-enough-for-jwt", "test-refresh-key-must-be-long-enough", 15*time.Minute, 7*24*time.Hour)
+
+	// Act
+	_, withoutErr := rotated.ValidateAccessToken(access)
+	rotated.SetPreviousSecrets([]string{"old-signing-key-must-be-long-enough-for-jwt"})
+	_, withErr := rotated.ValidateAccessToken(access)
+
+	// Assert
+	if withoutErr == nil {
+		t.Error("ValidateAccessToken() accepted a token signed with an unknown secret")
+	}
+	if withErr != nil {
+		t.Errorf("ValidateAccessToken() error = %v, want the previous secret accepted", withErr)
+	}
+}
+
+func TestLoadSigningKeys_Dir(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	var want []ed25519.PrivateKey
+	for _, name := range []string{"2024-01.pem", "2024-07.pem"} {
+		_, key, _ := ed25519.GenerateKey(rand.Reader)
+		der, _ := x509.MarshalPKCS8PrivateKey(key)
+		if err := os.WriteFile(filepath.Join(dir, name), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, key)
+	}
+
+	// Act
+	keys, err := LoadSigningKeys(config.JWTConfig{Algorithm: "EdDSA", KeysDir: dir})
+	_, emptyErr := LoadSigningKeys(config.JWTConfig{Algorithm: "EdDSA", KeysDir: t.TempDir()})
+
+	// Assert
+	if err != nil || len(keys) != 2 {
+		t.Fatalf("LoadSigningKeys() = %d keys, %v; want 2", len(keys), err)
+	}
+	for i := range want {
+		if !want[i].Equal(keys[i]) {
+			t.Errorf("keys[%d] is not %s in name order", i, []string{"2024-01.pem", "2024-07.pem"}[i])
+		}
+	}
+	if emptyErr == nil {
+		t.Error("LoadSigningKeys() of an empty JWT_KEYS_DIR error = nil")
+	}
+}
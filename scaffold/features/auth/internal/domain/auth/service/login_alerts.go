@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"time"
+
+	userModel "github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/email"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+)
+
+// SetLoginAlerts emails users when they log in from a device and IP they have not logged
+// in from before, unless they opted out (LoginAlertsOptOut). A nil sender sends nothing.
+func (s *AuthService) SetLoginAlerts(sender email.Sender) {
+	s.loginAlerts = sender
+}
+
+// DeviceFingerprint identifies a device and IP pair by a hash of the User-Agent and the
+// client IP, or returns "" when neither is known
+func DeviceFingerprint(userAgent, ip string) string {
+	if userAgent == "" && ip == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(userAgent + "\x00" + ip))
+	return hex.EncodeToString(sum[:])
+}
+
+// isNewDevice reports whether user has logged in before, but never with fingerprint.
+// Lookup failures are logged and count as a known device, so they send no alert.
+func (s *AuthService) isNewDevice(ctx context.Context, user *userModel.User, fingerprint string) bool {
+	if s.loginAlerts == nil || fingerprint == "" || user.LoginAlertsOptOut || user.Email == "" {
+		return false
+	}
+	known, err := s.tokenStore.KnownFingerprints(ctx, user.ID.String())
+	if err != nil {
+		s.logger.Errorw("failed to look up known devices", "user_id", user.ID, "error", err)
+		return false
+	}
+	// The first login of an account is not news to its owner
+	return len(known) > 0 && !slices.Contains(known, fingerprint)
+}
+
+// sendLoginAlert emails user about a login from a new device. Failures are logged only:
+// the login succeeded either way.
+func (s *AuthService) sendLoginAlert(ctx context.Context, user *userModel.User, at time.Time) {
+	device := actor.UserAgent(ctx)
+	if device == "" {
+		device = "unknown device"
+	}
+	ip := actor.ClientIP(ctx)
+	if ip == "" {
+		ip = "unknown"
+	}
+	msg := email.Message{
+		To:      user.Email,
+		Subject: "New sign-in to your account",
+		Body: "Hi " + user.FirstName + ",\n\n" +
+			"your account was just signed in to from a device we have not seen before.\n\n" +
+			"Time: " + at.UTC().Format(time.RFC1123) + "\n" +
+			"Device: " + device + "\n" +
+			"IP address: " + ip + "\n\n" +
+			"If this was you, there is nothing to do. If it was not, change your password " +
+			"right away and contact support.",
+	}
+	if err := s.loginAlerts.Send(ctx, msg); err != nil {
+		s.logger.Errorw("failed to send new device login alert", "user_id", user.ID, "error", err)
+		return
+	}
+	s.logger.Infow("new device login alert sent", "user_id", user.ID, "ip", actor.ClientIP(ctx))
+}
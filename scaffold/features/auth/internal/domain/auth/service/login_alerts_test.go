@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	authModel "github.com/MurtadaNazar/go-api-template/internal/domain/auth/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/email"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// alertSender keeps the emails it is asked to send
+type alertSender struct {
+	sent []email.Message
+}
+
+func (s *alertSender) Send(ctx context.Context, msg email.Message) error {
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+func loginFrom(userAgent, ip string) context.Context {
+	return actor.WithUserAgent(actor.WithClientIP(context.Background(), ip), userAgent)
+}
+
+func TestAuthService_LoginAlerts(t *testing.T) {
+	// Arrange
+	logger := zap.NewNop().Sugar()
+	jwtManager := NewJWTManager("test-signing-key-must-be-long-enough-for-jwt", "test-refresh-key-must-be-long-enough", 15*time.Minute, 7*day)
+	user := &model.User{ID: uuid.New(), UserType: model.UserTypeRegular, Status: "active", Email: "alice@example.com", FirstName: "Alice"}
+	tokens := &testutil.MockTokenRepo{Tokens: map[string]*authModel.RefreshToken{}}
+	service := NewAuthService(&testutil.MockUserRepo{}, jwtManager, NewTokenStore(tokens, logger), logger)
+	sender := &alertSender{}
+	service.SetLoginAlerts(sender)
+	login := func(ctx context.Context) {
+		t.Helper()
+		if _, _, err := service.issueTokens(ctx, user, false); err != nil {
+			t.Fatalf("issueTokens() error = %v", err)
+		}
+	}
+
+	// Act
+	login(loginFrom("laptop", "198.51.100.1")) // first login of the account
+	login(loginFrom("laptop", "198.51.100.1")) // known device
+	login(loginFrom("phone", "203.0.113.7"))   // new device
+	user.LoginAlertsOptOut = true
+	login(loginFrom("tablet", "203.0.113.8")) // new device, opted out
+
+	// Assert
+	if len(sender.sent) != 1 {
+		t.Fatalf("sent %d alerts, want 1 for the phone", len(sender.sent))
+	}
+	msg := sender.sent[0]
+	if msg.To != user.Email || !strings.Contains(msg.Body, "phone") || !strings.Contains(msg.Body, "203.0.113.7") {
+		t.Errorf("alert = %+v, want one to %s naming the phone and its IP", msg, user.Email)
+	}
+	for _, rt := range tokens.Tokens {
+		if rt.Fingerprint == "" {
+			t.Errorf("refresh token saved without a fingerprint")
+		}
+	}
+}
+
+func TestAuthService_RecordsLastLogin(t *testing.T) {
+	// Arrange
+	logger := zap.NewNop().Sugar()
+	jwtManager := NewJWTManager("test-signing-key-must-be-long-enough-for-jwt", "test-refresh-key-must-be-long-enough", 15*time.Minute, 7*day)
+	clk := testutil.NewFakeClock(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+	jwtManager.SetClock(clk)
+	user := &model.User{ID: uuid.New(), UserType: model.UserTypeRegular, Status: "active"}
+	var recordedID, recordedIP string
+	var recordedAt time.Time
+	users := &testutil.MockUserRepo{
+		RecordLoginFn: func(ctx context.Context, id string, at time.Time, ip string) error {
+			recordedID, recordedAt, recordedIP = id, at, ip
+			return nil
+		},
+	}
+	tokens := &testutil.MockTokenRepo{Tokens: map[string]*authModel.RefreshToken{}}
+	service := NewAuthService(users, jwtManager, NewTokenStore(tokens, logger), logger)
+
+	// Act
+	_, _, err := service.issueTokens(loginFrom("laptop", "198.51.100.1"), user, false)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("issueTokens() error = %v", err)
+	}
+	if recordedID != user.ID.String() || !recordedAt.Equal(clk.Now()) || recordedIP != "198.51.100.1" {
+		t.Errorf("RecordLogin(%q, %v, %q), want the user, now and the client IP", recordedID, recordedAt, recordedIP)
+	}
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/clock"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+
+	"go.uber.org/zap"
+)
+
+// LoginAttempts is the failure record of one account or client IP
+type LoginAttempts struct {
+	Failures    int
+	LastFailure time.Time
+}
+
+// LoginAttemptStore counts failed logins per key. Implementations must be safe for
+// concurrent use, and replicas must share one store for the limits to hold across them.
+// In Redis, RecordFailure is HINCRBY, HSET and PEXPIRE in one MULTI, Get is HGETALL and
+// Reset is DEL.
+type LoginAttemptStore interface {
+	// Get returns the record for key, or a zero record when there is none
+	Get(ctx context.Context, key string) (LoginAttempts, error)
+	// RecordFailure adds a failure at now, keeps the record until ttl after it and returns
+	// the updated record
+	RecordFailure(ctx context.Context, key string, now time.Time, ttl time.Duration) (LoginAttempts, error)
+	Reset(ctx context.Context, key string) error
+}
+
+// attemptSweepThreshold is the record count at which RecordFailure drops expired records
+const attemptSweepThreshold = 10000
+
+type memoryAttempts struct {
+	LoginAttempts
+	expiresAt time.Time
+}
+
+// MemoryLoginAttemptStore keeps failures in process memory. Each replica counts on its
+// own, so the effective limit grows with the replica count.
+type MemoryLoginAttemptStore struct {
+	mu      sync.Mutex
+	records map[string]memoryAttempts
+	clock   clock.Clock
+}
+
+func NewMemoryLoginAttemptStore() *MemoryLoginAttemptStore {
+	return &MemoryLoginAttemptStore{records: make(map[string]memoryAttempts), clock: clock.System()}
+}
+
+// SetClock replaces the clock used for expiry
+func (m *MemoryLoginAttemptStore) SetClock(c clock.Clock) {
+	m.clock = c
+}
+
+func (m *MemoryLoginAttemptStore) Get(_ context.Context, key string) (LoginAttempts, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.records[key]
+	if !ok || !r.expiresAt.After(m.clock.Now()) {
+		return LoginAttempts{}, nil
+	}
+	return r.LoginAttempts, nil
+}
+
+func (m *MemoryLoginAttemptStore) RecordFailure(_ context.Context, key string, now time.Time, ttl time.Duration) (LoginAttempts, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.records) >= attemptSweepThreshold {
+		for k, r := range m.records {
+			if !r.expiresAt.After(m.clock.Now()) {
+				delete(m.records, k)
+			}
+		}
+	}
+	r, ok := m.records[key]
+	if !ok || !r.expiresAt.After(m.clock.Now()) {
+		r = memoryAttempts{}
+	}
+	r.Failures++
+	r.LastFailure = now
+	r.expiresAt = now.Add(ttl)
+	m.records[key] = r
+	return r.LoginAttempts, nil
+}
+
+func (m *MemoryLoginAttemptStore) Reset(_ context.Context, key string) error {
+	m.mu.Lock()
+	delete(m.records, key)
+	m.mu.Unlock()
+	return nil
+}
+
+// LoginLimiter applies exponential backoff and temporary lockout to password logins,
+// per account and per client IP. A nil LoginLimiter allows every attempt.
+type LoginLimiter struct {
+	store  LoginAttemptStore
+	cfg    config.LoginLimitConfig
+	clock  clock.Clock
+	logger *zap.SugaredLogger
+}
+
+func NewLoginLimiter(store LoginAttemptStore, cfg config.LoginLimitConfig, logger *zap.SugaredLogger) *LoginLimiter {
+	return &LoginLimiter{store: store, cfg: cfg, clock: clock.System(), logger: logger}
+}
+
+// SetClock replaces the clock used for backoff and lockout
+func (l *LoginLimiter) SetClock(c clock.Clock) {
+	l.clock = c
+}
+
+func accountAttemptKey(userID string) string { return "login:account:" + userID }
+func ipAttemptKey(ip string) string          { return "login:ip:" + ip }
+
+// retryAt returns when the next attempt is allowed after a, given the lockout threshold
+func (l *LoginLimiter) retryAt(a LoginAttempts, maxFailures int) time.Time {
+	if a.Failures == 0 || maxFailures <= 0 {
+		return time.Time{}
+	}
+	if a.Failures >= maxFailures {
+		return a.LastFailure.Add(l.cfg.Lockout)
+	}
+	delay := l.cfg.BackoffMax
+	if shift := a.Failures - 1; shift < 30 && l.cfg.BackoffBase<<shift < l.cfg.BackoffMax {
+		delay = l.cfg.BackoffBase << shift
+	}
+	return a.LastFailure.Add(delay)
+}
+
+// check returns a TooManyRequestsError while key has to wait before the next attempt.
+// Store errors let the attempt through, so an unavailable store does not block logins.
+func (l *LoginLimiter) check(ctx context.Context, key string, maxFailures int) error {
+	a, err := l.store.Get(ctx, key)
+	if err != nil {
+		l.logger.Errorw("failed to read login attempts", "key", key, "error", err)
+		return nil
+	}
+	wait := l.retryAt(a, maxFailures).Sub(l.clock.Now())
+	if wait <= 0 {
+		return nil
+	}
+	// Round up so clients that honour Retry-After do not come back a moment too early
+	seconds := int((wait + time.Second - 1) / time.Second)
+	appErr := apperrors.NewAppErrorWithDetails(apperrors.TooManyRequestsError,
+		"Too many failed login attempts", fmt.Sprintf("Try again in %d seconds", seconds))
+	appErr.RetryAfter = seconds
+	return appErr
+}
+
+// CheckIP rejects attempts from the client IP in ctx while it is backing off or locked out
+func (l *LoginLimiter) CheckIP(ctx context.Context) error {
+	ip := actor.ClientIP(ctx)
+	if l == nil || ip == "" {
+		return nil
+	}
+	return l.check(ctx, ipAttemptKey(ip), l.cfg.IPMaxFailures)
+}
+
+// CheckAccount rejects attempts on userID while it is backing off or locked out
+func (l *LoginLimiter) CheckAccount(ctx context.Context, userID string) error {
+	if l == nil {
+		return nil
+	}
+	return l.check(ctx, accountAttemptKey(userID), l.cfg.MaxFailures)
+}
+
+// Fail records a failed attempt for the client IP in ctx and, when known, the account
+func (l *LoginLimiter) Fail(ctx context.Context, userID string) {
+	if l == nil {
+		return
+	}
+	now := l.clock.Now()
+	ttl := max(l.cfg.Window, l.cfg.Lockout)
+	if ip := actor.ClientIP(ctx); ip != "" {
+		if a, err := l.store.RecordFailure(ctx, ipAttemptKey(ip), now, ttl); err != nil {
+			l.logger.Errorw("failed to record login failure", "ip", ip, "error", err)
+		} else if a.Failures == l.cfg.IPMaxFailures {
+			l.logger.Warnw("client IP locked out after failed logins", "ip", ip, "failures", a.Failures)
+		}
+	}
+	if userID == "" {
+		return
+	}
+	if a, err := l.store.RecordFailure(ctx, accountAttemptKey(userID), now, ttl); err != nil {
+		l.logger.Errorw("failed to record login failure", "user_id", userID, "error", err)
+	} else if a.Failures == l.cfg.MaxFailures {
+		l.logger.Warnw("account locked out after failed logins", "user_id", userID, "failures", a.Failures)
+	}
+}
+
+// Succeed clears the account's failures after a successful login. The client IP keeps
+// its record, so one valid account cannot be used to reset guessing on others.
+func (l *LoginLimiter) Succeed(ctx context.Context, userID string) {
+	if l == nil {
+		return
+	}
+	if err := l.store.Reset(ctx, accountAttemptKey(userID)); err != nil {
+		l.logger.Errorw("failed to reset login failures", "user_id", userID, "error", err)
+	}
+}
+
+// Unlock clears the account's failures, ending a lockout early
+func (l *LoginLimiter) Unlock(ctx context.Context, userID string) error {
+	if l == nil {
+		return nil
+	}
+	return l.store.Reset(ctx, accountAttemptKey(userID))
+}
+
+// SetLoginLimiter enables login backoff and lockout
+func (s *AuthService) SetLoginLimiter(l *LoginLimiter) {
+	s.limiter = l
+}
+
+// UnlockAccount ends a login lockout of the user early
+func (s *AuthService) UnlockAccount(ctx context.Context, userID string) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		s.logger.Errorw("failed to fetch user", "user_id", userID, "error", err)
+		return apperrors.NewAppError(apperrors.InternalError, "Failed to unlock account")
+	}
+	if user == nil {
+		return apperrors.ErrUserNotFound
+	}
+	if err := s.limiter.Unlock(ctx, userID); err != nil {
+		s.logger.Errorw("failed to unlock account", "user_id", userID, "error", err)
+		return apperrors.NewAppError(apperrors.InternalError, "Failed to unlock account")
+	}
+	s.logger.Infow("account unlocked", "user_id", userID, "by", actor.UserID(ctx))
+	return nil
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	authModel "github.com/MurtadaNazar/go-api-template/internal/domain/auth/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// newTokenAdminService returns a service whose only known user owns two active tokens
+func newTokenAdminService(t *testing.T) (*AuthService, *testutil.MockTokenRepo, uuid.UUID) {
+	t.Helper()
+	userID := uuid.New()
+	userRepo := &testutil.MockUserRepo{
+		FindByIDFn: func(ctx context.Context, id string) (*model.User, error) {
+			if id == userID.String() {
+				return &model.User{ID: userID}, nil
+			}
+			return nil, nil
+		},
+	}
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	tokens := &testutil.MockTokenRepo{Tokens: map[string]*authModel.RefreshToken{}}
+	for i, owner := range []uuid.UUID{userID, userID, uuid.New()} {
+		rt := &authModel.RefreshToken{
+			ID:        uuid.New(),
+			Token:     uuid.NewString(),
+			UserID:    owner,
+			Role:      "user",
+			ExpiresAt: now.Add(time.Hour),
+			CreatedAt: now.Add(time.Duration(i) * time.Minute),
+		}
+		tokens.Tokens[rt.Token] = rt
+	}
+	logger := zap.NewNop().Sugar()
+	jwtManager := NewJWTManager("test-signing-key-must-be-long-enough-for-jwt", "test-refresh-key-must-be-long-enough", 15*time.Minute, 7*24*time.Hour)
+	return NewAuthService(userRepo, jwtManager, NewTokenStore(tokens, logger), logger), tokens, userID
+}
+
+func TestAuthService_ListRefreshTokens(t *testing.T) {
+	tests := []struct {
+		name     string
+		userID   func(owner uuid.UUID) string
+		limit    int
+		want     int
+		wantType apperrors.ErrorType
+	}{
+		{name: "all users", userID: func(uuid.UUID) string { return "" }, limit: 50, want: 3},
+		{name: "one user", userID: uuid.UUID.String, limit: 50, want: 2},
+		{name: "limited", userID: func(uuid.UUID) string { return "" }, limit: 1, want: 1},
+		{name: "invalid user id", userID: func(uuid.UUID) string { return "nope" }, limit: 50, wantType: apperrors.BadRequestError},
+		{name: "limit too large", userID: func(uuid.UUID) string { return "" }, limit: 1000, wantType: apperrors.BadRequestError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			service, _, owner := newTokenAdminService(t)
+
+			// Act
+			tokens, err := service.ListRefreshTokens(context.Background(), tt.userID(owner), 0, tt.limit)
+
+			// Assert
+			if tt.wantType != "" {
+				if appErr, ok := apperrors.IsAppError(err); !ok || appErr.Type != tt.wantType {
+					t.Fatalf("ListRefreshTokens() error = %v, want %s", err, tt.wantType)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ListRefreshTokens() error = %v", err)
+			}
+			if len(tokens) != tt.want {
+				t.Errorf("ListRefreshTokens() returned %d tokens, want %d", len(tokens), tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthService_RevokeRefreshToken(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	service, tokens, owner := newTokenAdminService(t)
+	listed, err := service.ListRefreshTokens(ctx, owner.String(), 0, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := listed[0]
+
+	// Act
+	err = service.RevokeRefreshToken(ctx, target.ID.String())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("RevokeRefreshToken() error = %v", err)
+	}
+	for _, rt := range tokens.Tokens {
+		if rt.IsRevoked != (rt.ID == target.ID) {
+			t.Errorf("token %s revoked = %v", rt.ID, rt.IsRevoked)
+		}
+	}
+	if err := service.RevokeRefreshToken(ctx, target.ID.String()); err != apperrors.ErrTokenNotFound {
+		t.Errorf("RevokeRefreshToken() again error = %v, want ErrTokenNotFound", err)
+	}
+	if err := service.RevokeRefreshToken(ctx, "not-a-uuid"); err != apperrors.ErrTokenNotFound {
+		t.Errorf("RevokeRefreshToken(invalid id) error = %v, want ErrTokenNotFound", err)
+	}
+}
+
+func TestAuthService_RevokeUserRefreshTokens(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	service, tokens, owner := newTokenAdminService(t)
+	var hooked []string
+	service.OnUserTokensRevoked(func(userID string) { hooked = append(hooked, userID) })
+
+	// Act
+	revoked, err := service.RevokeUserRefreshTokens(ctx, owner.String())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("RevokeUserRefreshTokens() error = %v", err)
+	}
+	if revoked != 2 {
+		t.Errorf("RevokeUserRefreshTokens() = %d, want 2", revoked)
+	}
+	for _, rt := range tokens.Tokens {
+		if rt.IsRevoked != (rt.UserID == owner) {
+			t.Errorf("token of %s revoked = %v", rt.UserID, rt.IsRevoked)
+		}
+	}
+	if len(hooked) != 1 || hooked[0] != owner.String() {
+		t.Errorf("revocation hooks ran for %v, want [%s]", hooked, owner)
+	}
+	if _, err := service.RevokeUserRefreshTokens(ctx, uuid.NewString()); err != apperrors.ErrUserNotFound {
+		t.Errorf("RevokeUserRefreshTokens(unknown user) error = %v, want ErrUserNotFound", err)
+	}
+}
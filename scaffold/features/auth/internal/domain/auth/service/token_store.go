@@ -2,9 +2,10 @@ package service
 
 import (
 	"context"
-	"go_platform_template/internal/domain/auth/model"
-	"go_platform_template/internal/domain/auth/repo"
-	apperrors "go_platform_template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/repo"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/clock"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,6 +14,7 @@ import (
 
 type TokenStore struct {
 	repo   repo.TokenRepo
+	clock  clock.Clock
 	logger *zap.SugaredLogger
 }
 
@@ -20,19 +22,44 @@ type RefreshTokenData struct {
 	UserID    uuid.UUID
 	Role      string
 	ExpiresAt time.Time
+	Session   Session
+}
+
+// Session is what the refresh tokens rotated from one login share
+type Session struct {
+	// StartedAt is when the user logged in; refreshes cannot extend the session past
+	// StartedAt plus the maximum session lifetime
+	StartedAt time.Time
+	// RememberMe is set for logins that asked to stay signed in for longer
+	RememberMe bool
+	// Fingerprint identifies the device and IP the user logged in from (see
+	// DeviceFingerprint); empty when neither was known
+	Fingerprint string
 }
 
 func NewTokenStore(r repo.TokenRepo, logger *zap.SugaredLogger) *TokenStore {
-	return &TokenStore{repo: r, logger: logger}
+	return &TokenStore{repo: r, clock: clock.System(), logger: logger}
+}
+
+// SetClock replaces the clock used to check refresh token expiry
+func (s *TokenStore) SetClock(c clock.Clock) {
+	s.clock = c
 }
 
-func (s *TokenStore) Save(ctx context.Context, token string, userID uuid.UUID, role string, expiresAt time.Time) error {
+// Save stores a refresh token of session; a zero session start means the session starts now
+func (s *TokenStore) Save(ctx context.Context, token string, userID uuid.UUID, role string, expiresAt time.Time, session Session) error {
+	if session.StartedAt.IsZero() {
+		session.StartedAt = s.clock.Now()
+	}
 	rt := &model.RefreshToken{
-		Token:     token,
-		UserID:    userID,
-		Role:      role,
-		ExpiresAt: expiresAt,
-		IsRevoked: false,
+		Token:            token,
+		UserID:           userID,
+		Role:             role,
+		ExpiresAt:        expiresAt,
+		IsRevoked:        false,
+		SessionStartedAt: session.StartedAt,
+		RememberMe:       session.RememberMe,
+		Fingerprint:      session.Fingerprint,
 	}
 	if err := s.repo.Create(ctx, rt); err != nil {
 		s.logger.Errorf("Save refresh token failed: %v", err)
@@ -47,6 +74,10 @@ func (s *TokenStore) Validate(ctx context.Context, token string, rotate bool) (*
 	if err != nil {
 		return nil, apperrors.ErrInvalidRefreshToken
 	}
+	// The repository filters by the database clock; check again against ours
+	if !rt.ExpiresAt.After(s.clock.Now()) {
+		return nil, apperrors.ErrInvalidRefreshToken
+	}
 
 	if rotate {
 		if err := s.repo.RevokeToken(ctx, token); err != nil {
@@ -58,6 +89,7 @@ func (s *TokenStore) Validate(ctx context.Context, token string, rotate bool) (*
 		UserID:    rt.UserID,
 		Role:      rt.Role,
 		ExpiresAt: rt.ExpiresAt,
+		Session:   Session{StartedAt: rt.SessionStartedAt, RememberMe: rt.RememberMe, Fingerprint: rt.Fingerprint},
 	}, nil
 }
 
@@ -68,3 +100,23 @@ func (s *TokenStore) Delete(ctx context.Context, token string) error {
 func (s *TokenStore) CleanupExpiredTokens(ctx context.Context) error {
 	return s.repo.DeleteExpiredTokens(ctx)
 }
+
+// ListActive returns active refresh tokens, newest first; an empty userID lists every user's
+func (s *TokenStore) ListActive(ctx context.Context, userID string, offset, limit int) ([]model.RefreshToken, error) {
+	return s.repo.ListActive(ctx, userID, offset, limit)
+}
+
+// RevokeByID revokes an active token by its record ID; nil means there was none
+func (s *TokenStore) RevokeByID(ctx context.Context, id string) (*model.RefreshToken, error) {
+	return s.repo.RevokeByID(ctx, id)
+}
+
+// KnownFingerprints returns the distinct device fingerprints userID has logged in with
+func (s *TokenStore) KnownFingerprints(ctx context.Context, userID string) ([]string, error) {
+	return s.repo.KnownFingerprints(ctx, userID)
+}
+
+// RevokeUser revokes all of a user's active tokens and returns them
+func (s *TokenStore) RevokeUser(ctx context.Context, userID string) ([]model.RefreshToken, error) {
+	return s.repo.RevokeUserTokens(ctx, userID)
+}
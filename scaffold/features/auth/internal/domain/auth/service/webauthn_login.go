@@ -0,0 +1,336 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/dto"
+	authModel "github.com/MurtadaNazar/go-api-template/internal/domain/auth/model"
+	authRepo "github.com/MurtadaNazar/go-api-template/internal/domain/auth/repo"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/webauthn"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+
+	"github.com/google/uuid"
+)
+
+// passkeyLogin holds the WebAuthn setup of an AuthService
+type passkeyLogin struct {
+	rp           *webauthn.RelyingParty
+	store        authRepo.WebAuthnRepo
+	challengeTTL time.Duration
+}
+
+// SetWebAuthn enables passkey registration for signed-in users and passkey login
+func (s *AuthService) SetWebAuthn(rp *webauthn.RelyingParty, store authRepo.WebAuthnRepo, challengeTTL time.Duration) {
+	s.passkeys = &passkeyLogin{rp: rp, store: store, challengeTTL: challengeTTL}
+}
+
+// BeginPasskeyRegistration starts adding a passkey to the user's account
+func (s *AuthService) BeginPasskeyRegistration(ctx context.Context, userID string) (*dto.PasskeyCreationOptions, error) {
+	if s.passkeys == nil {
+		return nil, errPasskeysDisabled
+	}
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		s.logger.Errorw("failed to fetch user", "user_id", userID, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to start passkey registration")
+	}
+	if user == nil {
+		return nil, apperrors.NewAppError(apperrors.NotFoundError, "User not found")
+	}
+
+	existing, err := s.passkeys.store.ListCredentials(ctx, user.ID)
+	if err != nil {
+		s.logger.Errorw("failed to list passkeys", "user_id", user.ID, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to start passkey registration")
+	}
+	exclude := make([]dto.PasskeyCredentialDescriptor, 0, len(existing))
+	for _, c := range existing {
+		exclude = append(exclude, dto.PasskeyCredentialDescriptor{Type: "public-key", ID: base64.RawURLEncoding.EncodeToString(c.CredentialID)})
+	}
+
+	challenge, err := s.newPasskeyChallenge(ctx, authModel.WebAuthnRegister, &user.ID)
+	if err != nil {
+		return nil, err
+	}
+	params := make([]dto.PasskeyCredentialParam, 0, len(webauthn.SupportedAlgorithms))
+	for _, alg := range webauthn.SupportedAlgorithms {
+		params = append(params, dto.PasskeyCredentialParam{Type: "public-key", Alg: alg})
+	}
+	return &dto.PasskeyCreationOptions{
+		Challenge: challenge,
+		RP:        dto.PasskeyRelyingParty{ID: s.passkeys.rp.ID, Name: s.passkeys.rp.Name},
+		User: dto.PasskeyUser{
+			ID:          base64.RawURLEncoding.EncodeToString(user.ID[:]),
+			Name:        user.Username,
+			DisplayName: user.FullName(),
+		},
+		PubKeyCredParams:   params,
+		Timeout:            s.passkeys.challengeTTL.Milliseconds(),
+		ExcludeCredentials: exclude,
+		AuthenticatorSelection: dto.PasskeyAuthenticatorSelection{
+			ResidentKey:        "required",
+			RequireResidentKey: true,
+			UserVerification:   "required",
+		},
+		Attestation: "none",
+	}, nil
+}
+
+// FinishPasskeyRegistration verifies the browser's answer and stores the new passkey
+func (s *AuthService) FinishPasskeyRegistration(ctx context.Context, userID string, req *dto.PasskeyRegistrationRequest) (*authModel.WebAuthnCredential, error) {
+	if s.passkeys == nil {
+		return nil, errPasskeysDisabled
+	}
+	clientData, attestation, err := decodePasskeyFields(req.Response.ClientDataJSON, req.Response.AttestationObject)
+	if err != nil {
+		return nil, err
+	}
+	challenge, err := s.consumePasskeyChallenge(ctx, clientData, authModel.WebAuthnRegister)
+	if err != nil {
+		return nil, err
+	}
+	if challenge.stored.UserID == nil || challenge.stored.UserID.String() != userID {
+		s.logger.Warnw("passkey registration answered by another user", "user_id", userID)
+		return nil, errPasskeyRejected
+	}
+
+	cred, err := s.passkeys.rp.VerifyRegistration(clientData, attestation, challenge.raw)
+	if err != nil {
+		s.logger.Warnw("passkey registration rejected", "user_id", userID, "error", err)
+		return nil, errPasskeyRejected
+	}
+	existing, err := s.passkeys.store.FindCredential(ctx, cred.ID)
+	if err != nil {
+		s.logger.Errorw("failed to check passkey", "user_id", userID, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to register passkey")
+	}
+	if existing != nil {
+		return nil, apperrors.NewAppError(apperrors.ConflictError, "This passkey is already registered")
+	}
+
+	credential := &authModel.WebAuthnCredential{
+		UserID:       *challenge.stored.UserID,
+		Name:         req.Name,
+		CredentialID: cred.ID,
+		PublicKey:    cred.PublicKey,
+		Algorithm:    cred.Algorithm,
+		SignCount:    cred.SignCount,
+	}
+	if err := s.passkeys.store.CreateCredential(ctx, credential); err != nil {
+		s.logger.Errorw("failed to store passkey", "user_id", userID, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to register passkey")
+	}
+	s.logger.Infow("passkey registered", "user_id", userID, "passkey_id", credential.ID)
+	return credential, nil
+}
+
+// BeginPasskeyLogin starts a login; any of the site's passkeys can answer it
+func (s *AuthService) BeginPasskeyLogin(ctx context.Context) (*dto.PasskeyRequestOptions, error) {
+	if s.passkeys == nil {
+		return nil, errPasskeysDisabled
+	}
+	challenge, err := s.newPasskeyChallenge(ctx, authModel.WebAuthnLogin, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.PasskeyRequestOptions{
+		Challenge:        challenge,
+		RPID:             s.passkeys.rp.ID,
+		Timeout:          s.passkeys.challengeTTL.Milliseconds(),
+		UserVerification: "required",
+	}, nil
+}
+
+// LoginWithPasskey verifies a passkey assertion and issues tokens. The passkey verified
+// the user on the device, so SMS two-factor is not asked for.
+func (s *AuthService) LoginWithPasskey(ctx context.Context, req *dto.PasskeyLoginRequest) (string, string, error) {
+	if s.passkeys == nil {
+		return "", "", errPasskeysDisabled
+	}
+	clientData, authData, err := decodePasskeyFields(req.Response.ClientDataJSON, req.Response.AuthenticatorData)
+	if err != nil {
+		return "", "", err
+	}
+	signature, err := webauthn.DecodeBase64URL(req.Response.Signature)
+	if err != nil {
+		return "", "", apperrors.NewAppError(apperrors.BadRequestError, "Invalid passkey response encoding")
+	}
+	credentialID, err := webauthn.DecodeBase64URL(req.ID)
+	if err != nil {
+		return "", "", apperrors.NewAppError(apperrors.BadRequestError, "Invalid passkey response encoding")
+	}
+	challenge, err := s.consumePasskeyChallenge(ctx, clientData, authModel.WebAuthnLogin)
+	if err != nil {
+		return "", "", err
+	}
+
+	cred, err := s.passkeys.store.FindCredential(ctx, credentialID)
+	if err != nil {
+		s.logger.Errorw("failed to fetch passkey", "error", err)
+		return "", "", apperrors.NewAppError(apperrors.InternalError, "Login failed")
+	}
+	if cred == nil {
+		s.logger.Warnw("login with unknown passkey")
+		return "", "", errPasskeyRejected
+	}
+	if req.Response.UserHandle != "" {
+		handle, err := webauthn.DecodeBase64URL(req.Response.UserHandle)
+		if err != nil || !bytes.Equal(handle, cred.UserID[:]) {
+			s.logger.Warnw("passkey user handle does not match its owner", "passkey_id", cred.ID)
+			return "", "", errPasskeyRejected
+		}
+	}
+
+	signCount, err := s.passkeys.rp.VerifyAssertion(cred.PublicKey, cred.Algorithm, clientData, authData, signature, challenge.raw)
+	if err != nil {
+		s.logger.Warnw("passkey login rejected", "passkey_id", cred.ID, "error", err)
+		return "", "", errPasskeyRejected
+	}
+	// Counters only move forward; a counter that did not means the key may have been copied
+	if (signCount != 0 || cred.SignCount != 0) && signCount <= cred.SignCount {
+		s.logger.Warnw("passkey sign count went backwards, possible cloned authenticator", "passkey_id", cred.ID, "stored", cred.SignCount, "received", signCount)
+		return "", "", errPasskeyRejected
+	}
+
+	user, err := s.userRepo.FindByID(ctx, cred.UserID.String())
+	if err != nil {
+		s.logger.Errorw("failed to fetch passkey owner", "user_id", cred.UserID, "error", err)
+		return "", "", apperrors.NewAppError(apperrors.InternalError, "Login failed")
+	}
+	if user == nil {
+		return "", "", errPasskeyRejected
+	}
+	if !user.IsActive() {
+		s.logger.Warnw("inactive user passkey login attempt", "user_id", user.ID)
+		return "", "", apperrors.NewAppError(apperrors.ForbiddenError, "Account is inactive")
+	}
+
+	if err := s.passkeys.store.UpdateCredentialUsage(ctx, cred.ID, signCount, s.jwt.clock.Now()); err != nil {
+		s.logger.Errorw("failed to record passkey use", "passkey_id", cred.ID, "error", err)
+		return "", "", apperrors.NewAppError(apperrors.InternalError, "Login failed")
+	}
+	return s.issueTokens(ctx, user, false)
+}
+
+// ListPasskeys returns the passkeys registered to the user
+func (s *AuthService) ListPasskeys(ctx context.Context, userID string) ([]authModel.WebAuthnCredential, error) {
+	if s.passkeys == nil {
+		return nil, errPasskeysDisabled
+	}
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, apperrors.NewAppError(apperrors.BadRequestError, "Invalid user ID")
+	}
+	credentials, err := s.passkeys.store.ListCredentials(ctx, id)
+	if err != nil {
+		s.logger.Errorw("failed to list passkeys", "user_id", userID, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to list passkeys")
+	}
+	return credentials, nil
+}
+
+// DeletePasskey removes one of the user's passkeys
+func (s *AuthService) DeletePasskey(ctx context.Context, userID, passkeyID string) error {
+	if s.passkeys == nil {
+		return errPasskeysDisabled
+	}
+	owner, err := uuid.Parse(userID)
+	if err != nil {
+		return apperrors.NewAppError(apperrors.BadRequestError, "Invalid user ID")
+	}
+	id, err := uuid.Parse(passkeyID)
+	if err != nil {
+		return apperrors.NewAppError(apperrors.BadRequestError, "Invalid passkey ID")
+	}
+	deleted, err := s.passkeys.store.DeleteCredential(ctx, owner, id)
+	if err != nil {
+		s.logger.Errorw("failed to delete passkey", "user_id", userID, "passkey_id", passkeyID, "error", err)
+		return apperrors.NewAppError(apperrors.InternalError, "Failed to delete passkey")
+	}
+	if !deleted {
+		return apperrors.NewAppError(apperrors.NotFoundError, "Passkey not found")
+	}
+	s.logger.Infow("passkey deleted", "user_id", userID, "passkey_id", passkeyID)
+	return nil
+}
+
+// CleanupExpiredChallenges removes passkey ceremonies that were never finished
+func (s *AuthService) CleanupExpiredChallenges(ctx context.Context) error {
+	if s.passkeys == nil {
+		return nil
+	}
+	return s.passkeys.store.DeleteExpiredChallenges(ctx)
+}
+
+var (
+	errPasskeysDisabled = apperrors.NewAppError(apperrors.BadRequestError, "Passkey login is not enabled")
+	errPasskeyRejected  = apperrors.NewAppError(apperrors.UnauthorizedError, "Passkey verification failed")
+)
+
+// passkeyChallenge is a consumed challenge with the raw bytes the browser signed
+type passkeyChallenge struct {
+	raw    []byte
+	stored *authModel.WebAuthnChallenge
+}
+
+func (s *AuthService) newPasskeyChallenge(ctx context.Context, ceremony authModel.WebAuthnCeremony, userID *uuid.UUID) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", apperrors.NewAppError(apperrors.InternalError, "Failed to start passkey ceremony")
+	}
+	if err := s.passkeys.store.CreateChallenge(ctx, &authModel.WebAuthnChallenge{
+		ChallengeHash: hashChallenge(raw),
+		Ceremony:      ceremony,
+		UserID:        userID,
+		ExpiresAt:     s.jwt.clock.Now().Add(s.passkeys.challengeTTL),
+	}); err != nil {
+		s.logger.Errorw("failed to store passkey challenge", "ceremony", ceremony, "error", err)
+		return "", apperrors.NewAppError(apperrors.InternalError, "Failed to start passkey ceremony")
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// consumePasskeyChallenge redeems the challenge named in clientDataJSON, once
+func (s *AuthService) consumePasskeyChallenge(ctx context.Context, clientDataJSON []byte, ceremony authModel.WebAuthnCeremony) (*passkeyChallenge, error) {
+	clientData, err := webauthn.ParseClientData(clientDataJSON)
+	if err != nil {
+		return nil, apperrors.NewAppError(apperrors.BadRequestError, "Invalid passkey client data")
+	}
+	raw, err := clientData.ChallengeBytes()
+	if err != nil {
+		return nil, apperrors.NewAppError(apperrors.BadRequestError, "Invalid passkey client data")
+	}
+
+	stored, err := s.passkeys.store.ConsumeChallenge(ctx, hashChallenge(raw))
+	if err != nil {
+		s.logger.Errorw("failed to redeem passkey challenge", "ceremony", ceremony, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Passkey verification failed")
+	}
+	if stored == nil || stored.Ceremony != ceremony || !stored.ExpiresAt.After(s.jwt.clock.Now()) {
+		return nil, apperrors.NewAppErrorWithDetails(apperrors.UnauthorizedError, "Passkey verification failed", "challenge is unknown, expired or already used")
+	}
+	return &passkeyChallenge{raw: raw, stored: stored}, nil
+}
+
+func decodePasskeyFields(clientDataJSON, data string) ([]byte, []byte, error) {
+	clientData, err := webauthn.DecodeBase64URL(clientDataJSON)
+	if err != nil {
+		return nil, nil, apperrors.NewAppError(apperrors.BadRequestError, "Invalid passkey response encoding")
+	}
+	decoded, err := webauthn.DecodeBase64URL(data)
+	if err != nil {
+		return nil, nil, apperrors.NewAppError(apperrors.BadRequestError, "Invalid passkey response encoding")
+	}
+	return clientData, decoded, nil
+}
+
+func hashChallenge(challenge []byte) string {
+	sum := sha256.Sum256(challenge)
+	return hex.EncodeToString(sum[:])
+}
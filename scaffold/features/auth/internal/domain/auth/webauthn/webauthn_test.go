@@ -0,0 +1,110 @@
+package webauthn
+
+import (
+	"testing"
+
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+)
+
+const testOrigin = "https://app.example.com"
+
+func newTestRP() *RelyingParty {
+	return &RelyingParty{ID: "example.com", Name: "Example", Origins: []string{testOrigin}}
+}
+
+func TestRelyingParty_RegisterThenAssert(t *testing.T) {
+	rp := newTestRP()
+	authenticator := testutil.NewFakeAuthenticator(t, "example.com", testOrigin)
+	challenge := []byte("registration-challenge-32-bytes!")
+
+	clientData, attestation := authenticator.Register(challenge)
+	cred, err := rp.VerifyRegistration(clientData, attestation, challenge)
+	if err != nil {
+		t.Fatalf("VerifyRegistration() error = %v", err)
+	}
+	if string(cred.ID) != string(authenticator.CredentialID) || cred.Algorithm != AlgES256 {
+		t.Fatalf("credential = %+v", cred)
+	}
+
+	login := []byte("login-challenge-32-bytes-long!!!")
+	clientData, authData, sig := authenticator.Assert(login)
+	count, err := rp.VerifyAssertion(cred.PublicKey, cred.Algorithm, clientData, authData, sig, login)
+	if err != nil {
+		t.Fatalf("VerifyAssertion() error = %v", err)
+	}
+	if count != 1 {
+		t.Errorf("sign count = %d, want 1", count)
+	}
+}
+
+func TestRelyingParty_RejectsBadCeremonies(t *testing.T) {
+	rp := newTestRP()
+	challenge := []byte("registration-challenge-32-bytes!")
+	registered := testutil.NewFakeAuthenticator(t, "example.com", testOrigin)
+	clientData, attestation := registered.Register(challenge)
+	cred, err := rp.VerifyRegistration(clientData, attestation, challenge)
+	if err != nil {
+		t.Fatalf("VerifyRegistration() error = %v", err)
+	}
+
+	cases := []struct {
+		name   string
+		mutate func(a *testutil.FakeAuthenticator)
+		check  func(a *testutil.FakeAuthenticator) error
+	}{
+		{"other origin", func(a *testutil.FakeAuthenticator) { a.Origin = "https://evil.example.net" }, nil},
+		{"other relying party", func(a *testutil.FakeAuthenticator) { a.RPID = "evil.example.net" }, nil},
+		{"no user verification", func(a *testutil.FakeAuthenticator) { a.SkipUserVerification = true }, nil},
+		{"other challenge", nil, func(a *testutil.FakeAuthenticator) error {
+			clientData, authData, sig := a.Assert([]byte("stale"))
+			_, err := rp.VerifyAssertion(cred.PublicKey, cred.Algorithm, clientData, authData, sig, challenge)
+			return err
+		}},
+		{"other key", nil, func(a *testutil.FakeAuthenticator) error {
+			other := testutil.NewFakeAuthenticator(t, "example.com", testOrigin)
+			clientData, authData, sig := other.Assert(challenge)
+			_, err := rp.VerifyAssertion(cred.PublicKey, cred.Algorithm, clientData, authData, sig, challenge)
+			return err
+		}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := *registered
+			if tc.mutate != nil {
+				tc.mutate(&a)
+			}
+			var err error
+			if tc.check != nil {
+				err = tc.check(&a)
+			} else {
+				clientData, authData, sig := a.Assert(challenge)
+				_, err = rp.VerifyAssertion(cred.PublicKey, cred.Algorithm, clientData, authData, sig, challenge)
+			}
+			if err == nil {
+				t.Error("ceremony was accepted")
+			}
+		})
+	}
+}
+
+func TestDecodeCBOR(t *testing.T) {
+	// {1: 2, 3: -7, "k": h'0102', "t": true}
+	data := []byte{0xa4, 0x01, 0x02, 0x03, 0x26, 0x61, 'k', 0x42, 0x01, 0x02, 0x61, 't', 0xf5, 0xff}
+
+	v, rest, err := decodeCBOR(data)
+
+	if err != nil {
+		t.Fatalf("decodeCBOR() error = %v", err)
+	}
+	m := v.(map[interface{}]interface{})
+	if m[int64(1)] != int64(2) || m[int64(3)] != int64(-7) || string(m["k"].([]byte)) != "\x01\x02" || m["t"] != true {
+		t.Errorf("decodeCBOR() = %v", m)
+	}
+	if len(rest) != 1 {
+		t.Errorf("rest = %x, want the trailing byte", rest)
+	}
+	if _, _, err := decodeCBOR([]byte{0x5a, 0xff, 0xff, 0xff, 0xff}); err == nil {
+		t.Error("decodeCBOR() accepted a byte string longer than the input")
+	}
+}
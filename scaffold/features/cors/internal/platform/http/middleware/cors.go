@@ -0,0 +1,33 @@
+// internal/shared/middleware/cors.go
+package middleware
+
+import (
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// CORSMiddleware builds the CORS policy from the CORS_* settings in config
+func CORSMiddleware(cfg config.CORSConfig) gin.HandlerFunc {
+	corsConfig := cors.Config{
+		AllowOrigins:     cfg.AllowOrigins,
+		AllowMethods:     cfg.AllowMethods,
+		AllowHeaders:     cfg.AllowHeaders,
+		ExposeHeaders:    cfg.ExposeHeaders,
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           cfg.MaxAge,
+	}
+
+	// A wildcard origin cannot be combined with credentials, so honour "*" explicitly
+	for _, origin := range cfg.AllowOrigins {
+		if origin == "*" {
+			corsConfig.AllowOrigins = nil
+			corsConfig.AllowAllOrigins = true
+			corsConfig.AllowCredentials = false
+			break
+		}
+	}
+
+	return cors.New(corsConfig)
+}
@@ -0,0 +1,14 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/export/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/examples"
+)
+
+// Examples are the bodies of the export routes, served under /docs/examples
+var Examples = []examples.Route{
+	{Method: http.MethodPost, Path: "/exports/", Request: dto.CreateExportRequest{}, Response: dto.ExportResponse{}},
+	{Method: http.MethodGet, Path: "/exports/{id}", Response: dto.ExportResponse{}},
+}
@@ -0,0 +1,403 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/export/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/export/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/export/repo"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/metrics"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/clock"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// queueSize bounds exports waiting in memory for a worker; beyond it they stay pending
+	// in the database until the next cleanup run queues them again
+	queueSize = 100
+	// cleanupBatch bounds how many exports one cleanup run queues or deletes
+	cleanupBatch = 500
+	// staleReason is recorded on exports whose worker stopped without finishing them
+	staleReason = "Export did not finish in time"
+	// failedReason is recorded on exports whose producer or upload returned an error
+	failedReason = "Export failed"
+)
+
+var exportsFinished = metrics.NewCounter("exports_total",
+	"Asynchronous exports finished, by kind and result.", "kind", "result")
+
+// Storage keeps export files; the file service's MinIO client implements it
+type Storage interface {
+	PutObject(ctx context.Context, objectName string, reader io.Reader, size int64, contentType string) error
+	GetSignedURL(ctx context.Context, objectName string, expiry time.Duration) (string, error)
+	RemoveObject(ctx context.Context, objectName string) error
+}
+
+// Request is what a producer writes: the requester and the params they asked with
+type Request struct {
+	UserID uuid.UUID
+	Params map[string]string
+}
+
+// Producer writes the file for one kind of export
+type Producer struct {
+	// ContentType and Extension describe the file, e.g. text/csv and csv
+	ContentType string
+	Extension   string
+	// Permission is required to request the kind; empty lets any signed-in user
+	Permission string
+	// Validate rejects bad params before the export is queued; optional
+	Validate func(params map[string]string) error
+	// Write writes the file and returns the number of records written
+	Write func(ctx context.Context, req Request, w io.Writer) (int64, error)
+}
+
+// PermissionChecker reports whether a role holds a permission, like the authz service's Can
+type PermissionChecker func(ctx context.Context, role, permission string) (bool, error)
+
+// ExportService produces large exports in the background and hands them out as signed
+// download URLs until they expire
+type ExportService interface {
+	// Register makes a kind of export available; call it before Start
+	Register(kind string, producer Producer)
+	// Create queues an export for the user
+	Create(ctx context.Context, userID uuid.UUID, role string, req dto.CreateExportRequest) (*model.Export, error)
+	// Get returns one of the user's exports, with a download URL once it has completed
+	Get(ctx context.Context, userID, id uuid.UUID) (*dto.ExportResponse, error)
+	// Start runs the workers until ctx is cancelled and queues exports left pending by a
+	// previous run
+	Start(ctx context.Context)
+	// Cleanup fails exports whose worker was lost, queues pending ones again and deletes
+	// expired exports with their files
+	Cleanup(ctx context.Context) error
+}
+
+type exportService struct {
+	repo      repo.ExportRepo
+	storage   Storage
+	cfg       config.ExportConfig
+	producers map[string]Producer
+	can       PermissionChecker
+	queue     chan uuid.UUID
+	clock     clock.Clock
+	logger    *zap.SugaredLogger
+}
+
+// ServiceOption customizes an ExportService created by NewExportService
+type ServiceOption func(*exportService)
+
+// WithPermissionCheck checks Producer.Permission with can; without it only admins may
+// request kinds that need a permission
+func WithPermissionCheck(can PermissionChecker) ServiceOption {
+	return func(s *exportService) {
+		s.can = can
+	}
+}
+
+// WithClock replaces the time source used for retention and stale exports
+func WithClock(c clock.Clock) ServiceOption {
+	return func(s *exportService) {
+		s.clock = c
+	}
+}
+
+func NewExportService(exportRepo repo.ExportRepo, storage Storage, cfg config.ExportConfig, logger *zap.SugaredLogger, opts ...ServiceOption) ExportService {
+	s := &exportService{
+		repo:      exportRepo,
+		storage:   storage,
+		cfg:       cfg,
+		producers: make(map[string]Producer),
+		queue:     make(chan uuid.UUID, queueSize),
+		clock:     clock.System(),
+		logger:    logger,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *exportService) Register(kind string, producer Producer) {
+	s.producers[kind] = producer
+}
+
+func (s *exportService) Create(ctx context.Context, userID uuid.UUID, role string, req dto.CreateExportRequest) (*model.Export, error) {
+	producer, ok := s.producers[req.Kind]
+	if !ok {
+		return nil, apperrors.NewAppErrorWithDetails(
+			apperrors.ValidationError,
+			"Unknown export kind",
+			"Available kinds: "+strings.Join(s.kinds(), ", "),
+		)
+	}
+
+	if producer.Permission != "" {
+		allowed, err := s.allowed(ctx, role, producer.Permission)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, apperrors.NewAppError(apperrors.ForbiddenError, "Insufficient permissions")
+		}
+	}
+
+	if producer.Validate != nil {
+		if err := producer.Validate(req.Params); err != nil {
+			return nil, err
+		}
+	}
+
+	unfinished, err := s.repo.CountUnfinished(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if s.cfg.MaxPending > 0 && unfinished >= int64(s.cfg.MaxPending) {
+		return nil, apperrors.NewAppErrorWithDetails(
+			apperrors.TooManyRequestsError,
+			"Too many unfinished exports",
+			fmt.Sprintf("Wait for one of your %d pending exports to finish", unfinished),
+		)
+	}
+
+	export := &model.Export{
+		UserID: userID,
+		Kind:   req.Kind,
+		Params: req.Params,
+		Status: model.StatusPending,
+	}
+	if err := s.repo.Create(ctx, export); err != nil {
+		return nil, err
+	}
+	s.enqueue(export.ID)
+	return export, nil
+}
+
+func (s *exportService) Get(ctx context.Context, userID, id uuid.UUID) (*dto.ExportResponse, error) {
+	export, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// Other users' exports are reported as missing rather than forbidden
+	if export == nil || export.UserID != userID {
+		return nil, apperrors.NewAppError(apperrors.NotFoundError, "Export not found")
+	}
+
+	resp := &dto.ExportResponse{Export: *export}
+	now := s.clock.Now()
+	if export.Status != model.StatusCompleted || export.ExpiresAt == nil || !export.ExpiresAt.After(now) {
+		return resp, nil
+	}
+
+	// The URL stops working when the export is deleted, even if URLExpiry is longer
+	expiry := s.cfg.URLExpiry
+	if remaining := export.ExpiresAt.Sub(now); remaining < expiry {
+		expiry = remaining
+	}
+	url, err := s.storage.GetSignedURL(ctx, export.ObjectName, expiry)
+	if err != nil {
+		return nil, err
+	}
+	urlExpiresAt := now.Add(expiry)
+	resp.DownloadURL = url
+	resp.DownloadURLExpiresAt = &urlExpiresAt
+	return resp, nil
+}
+
+func (s *exportService) Start(ctx context.Context) {
+	workers := s.cfg.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	for i := 0; i < workers; i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case id := <-s.queue:
+					s.run(ctx, id)
+				}
+			}
+		}()
+	}
+
+	queueCtx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	if err := s.queuePending(queueCtx); err != nil {
+		s.logger.Warnw("failed to queue pending exports", "error", err)
+	}
+}
+
+func (s *exportService) Cleanup(ctx context.Context) error {
+	now := s.clock.Now()
+	stale, err := s.repo.FailStale(ctx, now.Add(-2*s.cfg.Timeout), now, now.Add(s.cfg.Retention), staleReason)
+	if err != nil {
+		return err
+	}
+	if stale > 0 {
+		s.logger.Warnw("marked stale exports as failed", "count", stale)
+	}
+
+	if err := s.queuePending(ctx); err != nil {
+		return err
+	}
+
+	expired, err := s.repo.ListExpired(ctx, now, cleanupBatch)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, export := range expired {
+		if export.ObjectName != "" {
+			if err := s.storage.RemoveObject(ctx, export.ObjectName); err != nil {
+				errs = append(errs, fmt.Errorf("remove %s: %w", export.ObjectName, err))
+				continue
+			}
+		}
+		if err := s.repo.Delete(ctx, export.ID); err != nil {
+			errs = append(errs, fmt.Errorf("delete export %s: %w", export.ID, err))
+		}
+	}
+	if len(expired) > 0 {
+		s.logger.Infow("deleted expired exports", "count", len(expired)-len(errs))
+	}
+	return apperrors.Join(errs...)
+}
+
+// queuePending hands pending exports to the workers; exports already queued are skipped
+// by Claim when they come up a second time
+func (s *exportService) queuePending(ctx context.Context) error {
+	pending, err := s.repo.ListPending(ctx, cleanupBatch)
+	if err != nil {
+		return err
+	}
+	for _, export := range pending {
+		s.enqueue(export.ID)
+	}
+	return nil
+}
+
+func (s *exportService) enqueue(id uuid.UUID) {
+	select {
+	case s.queue <- id:
+	default:
+		s.logger.Warnw("export queue full, export stays pending until the next cleanup", "export_id", id)
+	}
+}
+
+// run produces one export if no other worker has claimed it
+func (s *exportService) run(ctx context.Context, id uuid.UUID) {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+
+	claimed, err := s.repo.Claim(ctx, id, s.clock.Now())
+	if err != nil || !claimed {
+		if err != nil {
+			s.logger.Errorw("failed to claim export", "export_id", id, "error", err)
+		}
+		return
+	}
+	export, err := s.repo.FindByID(ctx, id)
+	if err != nil || export == nil {
+		s.logger.Errorw("failed to load claimed export", "export_id", id, "error", err)
+		return
+	}
+
+	started := s.clock.Now()
+	err = s.produce(ctx, export)
+	now := s.clock.Now()
+	expiresAt := now.Add(s.cfg.Retention)
+	export.CompletedAt = &now
+	export.ExpiresAt = &expiresAt
+	if err != nil {
+		export.Status = model.StatusFailed
+		export.Error = failedReason
+		if appErr, ok := apperrors.IsAppError(err); ok {
+			export.Error = appErr.Message
+		}
+		exportsFinished.Inc(export.Kind, "failed")
+		s.logger.Errorw("export failed", "export_id", id, "kind", export.Kind, "error", err)
+	} else {
+		export.Status = model.StatusCompleted
+		exportsFinished.Inc(export.Kind, "completed")
+		s.logger.Infow("export completed", "export_id", id, "kind", export.Kind,
+			"rows", export.Rows, "size", export.Size, "duration", now.Sub(started))
+	}
+
+	// The outcome is saved even when the export ran out of time or the workers stopped
+	finishCtx, finishCancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer finishCancel()
+	if err := s.repo.Finish(finishCtx, export); err != nil {
+		s.logger.Errorw("failed to save export result", "export_id", id, "error", err)
+	}
+}
+
+// produce writes the export to a temporary file, so its size is known, and uploads it
+func (s *exportService) produce(ctx context.Context, export *model.Export) error {
+	producer, ok := s.producers[export.Kind]
+	if !ok {
+		return fmt.Errorf("no producer registered for export kind %q", export.Kind)
+	}
+
+	file, err := os.CreateTemp("", "export-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}()
+
+	buffered := bufio.NewWriter(file)
+	rows, err := producer.Write(ctx, Request{UserID: export.UserID, Params: export.Params}, buffered)
+	if err != nil {
+		return err
+	}
+	if err := buffered.Flush(); err != nil {
+		return err
+	}
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	objectName := fmt.Sprintf("exports/%s/%s.%s", export.UserID, export.ID, producer.Extension)
+	if err := s.storage.PutObject(ctx, objectName, file, size, producer.ContentType); err != nil {
+		return err
+	}
+	export.ObjectName = objectName
+	export.ContentType = producer.ContentType
+	export.Size = size
+	export.Rows = rows
+	return nil
+}
+
+func (s *exportService) allowed(ctx context.Context, role, permission string) (bool, error) {
+	if s.can == nil {
+		return role == "admin", nil
+	}
+	return s.can(ctx, role, permission)
+}
+
+func (s *exportService) kinds() []string {
+	kinds := make([]string, 0, len(s.producers))
+	for kind := range s.producers {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
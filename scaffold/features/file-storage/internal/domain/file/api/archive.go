@@ -0,0 +1,88 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/service"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateArchive godoc
+// @Summary Download several files as one ZIP archive
+// @Description Streams a ZIP archive of the caller's files with the given IDs, assembled from storage while it is sent, so large archives start downloading at once. Files are named by their folder and original name. At most FILE_ARCHIVE_MAX_FILES files adding up to FILE_ARCHIVE_MAX_SIZE_MB are accepted. Every file is checked before anything is sent; a failure once streaming has begun ends the download with a truncated archive.
+// @Tags files
+// @Security BearerAuth
+// @Accept json
+// @Produce application/zip
+// @Param request body dto.ArchiveRequest true "Files to archive"
+// @Success 200 {file} file
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /files/archive [post]
+func (h *FileHandler) CreateArchive(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+	userID, err := uuid.Parse(c.GetString("userID"))
+	if err != nil {
+		_ = c.Error(apperrors.NewAppError(apperrors.UnauthorizedError, "User authentication required"))
+		return
+	}
+
+	var req dto.ArchiveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.NewAppErrorWithDetails(
+			apperrors.BadRequestError,
+			"Invalid request payload",
+			err.Error(),
+		))
+		return
+	}
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	ids := make([]uuid.UUID, len(req.FileIDs))
+	for i, id := range req.FileIDs {
+		ids[i] = uuid.MustParse(id)
+	}
+
+	files, err := h.service.PrepareArchive(c.Request.Context(), userID, ids)
+	switch {
+	case errors.Is(err, service.ErrArchiveTooManyFiles), errors.Is(err, service.ErrArchiveTooLarge):
+		_ = c.Error(apperrors.NewAppError(apperrors.BadRequestError, err.Error()))
+		return
+	case errors.Is(err, service.ErrArchiveFileNotFound):
+		_ = c.Error(apperrors.NewAppError(apperrors.NotFoundError, err.Error()))
+		return
+	case errors.Is(err, service.ErrArchiveForbidden):
+		h.logger.Warnw("archive of another user's file denied", "user_id", userID, "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.ForbiddenError, err.Error()))
+		return
+	case err != nil:
+		h.logger.Errorw("failed to prepare archive", "user_id", userID, "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to create archive"))
+		return
+	}
+
+	// The status is sent with the first bytes of the archive; errors after that can only
+	// be logged
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="files-%s.zip"`, time.Now().UTC().Format("20060102-150405")))
+	c.Header("Cache-Control", "private, no-store")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Status(http.StatusOK)
+	if err := h.service.WriteArchive(c.Request.Context(), c.Writer, files); err != nil {
+		h.logger.Errorw("archive download interrupted", "user_id", userID, "files", len(files), "error", err, "request_id", requestID)
+		return
+	}
+	h.logger.Infow("archive downloaded", "user_id", userID, "files", len(files), "request_id", requestID)
+}
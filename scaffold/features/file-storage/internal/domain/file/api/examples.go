@@ -0,0 +1,31 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/examples"
+)
+
+// Examples are the bodies of the file routes, served under /docs/examples. Uploads are
+// multipart forms and chunks are raw bytes, so only their responses are shown; archives
+// are ZIP files, so only their requests are.
+var Examples = []examples.Route{
+	{Method: http.MethodPost, Path: "/files/upload", Response: dto.UploadResponse{}},
+	{Method: http.MethodGet, Path: "/files/{filename}", Response: dto.GetFileResponse{}},
+	{Method: http.MethodGet, Path: "/files/{id}/verify", Response: dto.VerifyFileResponse{}},
+	{Method: http.MethodGet, Path: "/files/", Response: dto.UserFilesResponse{}},
+	{Method: http.MethodPost, Path: "/files/share", Request: dto.ShareFileRequest{}, Response: dto.ShareFileResponse{}},
+	{Method: http.MethodPost, Path: "/files/presign-upload", Request: dto.PresignUploadRequest{}, Response: dto.PresignUploadResponse{}},
+	{Method: http.MethodPost, Path: "/files/complete", Request: dto.CompleteUploadRequest{}, Response: dto.UploadResponse{}},
+	{Method: http.MethodPost, Path: "/files/uploads", Request: dto.CreateUploadRequest{}, Response: dto.ResumableUploadResponse{}},
+	{Method: http.MethodGet, Path: "/files/uploads/{id}", Response: dto.ResumableUploadResponse{}},
+	{Method: http.MethodPost, Path: "/files/folders", Request: dto.CreateFolderRequest{}, Response: dto.FolderInfo{}},
+	{Method: http.MethodGet, Path: "/files/folders", Response: dto.FolderContentsResponse{}},
+	{Method: http.MethodPost, Path: "/files/{id}/move", Request: dto.MoveFileRequest{}, Response: dto.FileInfo{}},
+	{Method: http.MethodPut, Path: "/files/{id}/visibility", Request: dto.SetVisibilityRequest{}, Response: dto.FileInfo{}},
+	{Method: http.MethodPost, Path: "/files/{id}/share", Request: dto.CreateShareLinkRequest{}, Response: dto.ShareLinkResponse{}},
+	{Method: http.MethodGet, Path: "/files/{id}/shares", Response: []dto.ShareLinkResponse{}},
+	{Method: http.MethodDelete, Path: "/files/shares/{id}", Response: dto.ShareLinkResponse{}},
+	{Method: http.MethodPost, Path: "/files/archive", Request: dto.ArchiveRequest{}},
+}
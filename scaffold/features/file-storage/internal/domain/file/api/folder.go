@@ -0,0 +1,242 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/service"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateFolder godoc
+// @Summary Create a folder
+// @Description Creates a folder, and the folders containing it, for organizing your files. Folders are prefixes of the paths files are stored under. Creating a folder that exists succeeds.
+// @Tags files
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateFolderRequest true "Folder to create"
+// @Success 201 {object} dto.FolderInfo
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /files/folders [post]
+func (h *FileHandler) CreateFolder(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+	userID, err := uuid.Parse(c.GetString("userID"))
+	if err != nil {
+		_ = c.Error(apperrors.NewAppError(apperrors.UnauthorizedError, "User authentication required"))
+		return
+	}
+
+	var req dto.CreateFolderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.NewAppErrorWithDetails(
+			apperrors.BadRequestError,
+			"Invalid request payload",
+			err.Error(),
+		))
+		return
+	}
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	folder, err := h.service.CreateFolder(c.Request.Context(), userID, req.Path)
+	if errors.Is(err, model.ErrInvalidFolder) {
+		_ = c.Error(invalidFolderError())
+		return
+	}
+	if err != nil {
+		h.logger.Errorw("failed to create folder", "user_id", userID, "path", req.Path, "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to create folder"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.NewSuccessResponse(folderInfo(folder), requestID))
+}
+
+// ListFolder godoc
+// @Summary List a folder
+// @Description Lists the folders and a page of the files directly in one of your folders; total counts the files. Omit path for the top level.
+// @Tags files
+// @Security BearerAuth
+// @Produce json
+// @Param path query string false "Folder path, e.g. projects/2024"
+// @Param offset query int false "Offset for pagination"
+// @Param limit query int false "Limit for pagination (1-100, default 20)"
+// @Success 200 {object} response.PaginatedResponse{data=dto.FolderContentsResponse}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /files/folders [get]
+func (h *FileHandler) ListFolder(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+	userID, err := uuid.Parse(c.GetString("userID"))
+	if err != nil {
+		_ = c.Error(apperrors.NewAppError(apperrors.UnauthorizedError, "User authentication required"))
+		return
+	}
+
+	offset := 0
+	limit := 20
+	if v := c.Query("offset"); v != "" {
+		if _, err := fmt.Sscan(v, &offset); err != nil {
+			_ = c.Error(apperrors.NewAppErrorWithDetails(apperrors.BadRequestError, "Invalid offset value", err.Error()))
+			return
+		}
+	}
+	if v := c.Query("limit"); v != "" {
+		if _, err := fmt.Sscan(v, &limit); err != nil {
+			_ = c.Error(apperrors.NewAppErrorWithDetails(apperrors.BadRequestError, "Invalid limit value", err.Error()))
+			return
+		}
+	}
+
+	folder, err := model.CleanFolder(c.Query("path"))
+	if err != nil {
+		_ = c.Error(invalidFolderError())
+		return
+	}
+	folders, files, total, err := h.service.ListFolder(c.Request.Context(), userID, folder, offset, limit)
+	if errors.Is(err, service.ErrFolderNotFound) {
+		_ = c.Error(apperrors.NewAppError(apperrors.NotFoundError, "Folder not found"))
+		return
+	}
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) {
+		_ = c.Error(err)
+		return
+	}
+	if err != nil {
+		h.logger.Errorw("failed to list folder", "user_id", userID, "path", folder, "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to list folder"))
+		return
+	}
+
+	contents := dto.FolderContentsResponse{
+		Path:    folder,
+		Folders: make([]dto.FolderInfo, len(folders)),
+		Files:   make([]dto.FileInfo, len(files)),
+	}
+	for i, f := range folders {
+		contents.Folders[i] = folderInfo(f.Path)
+	}
+	for i := range files {
+		contents.Files[i] = h.fileInfo(c, &files[i])
+	}
+	c.Render(http.StatusOK, response.PooledJSON{Data: response.NewPaginatedResponse(contents, total, offset, limit, requestID)})
+}
+
+// MoveFile godoc
+// @Summary Move or rename a file
+// @Description Moves one of your files to another folder, renames it, or both. Its content and thumbnails are copied to the new path and then removed from the old one, so signed URLs and share links issued before the move stop working.
+// @Tags files
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "File ID"
+// @Param request body dto.MoveFileRequest true "Destination folder and new name"
+// @Success 200 {object} dto.FileInfo
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /files/{id}/move [post]
+func (h *FileHandler) MoveFile(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+	userID, err := uuid.Parse(c.GetString("userID"))
+	if err != nil {
+		_ = c.Error(apperrors.NewAppError(apperrors.UnauthorizedError, "User authentication required"))
+		return
+	}
+	// Registered as /:filename/move because gin needs one wildcard name per segment
+	fileID := c.Param("filename")
+	if _, err := uuid.Parse(fileID); err != nil {
+		_ = c.Error(apperrors.NewAppError(apperrors.BadRequestError, "Invalid file ID"))
+		return
+	}
+
+	var req dto.MoveFileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.NewAppErrorWithDetails(
+			apperrors.BadRequestError,
+			"Invalid request payload",
+			err.Error(),
+		))
+		return
+	}
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if req.Folder == nil && req.Name == "" {
+		_ = c.Error(apperrors.NewAppError(apperrors.BadRequestError, "Either folder or name is required"))
+		return
+	}
+
+	file, err := h.service.GetFileByID(c.Request.Context(), fileID)
+	if err != nil {
+		h.logger.Warnw("file not found for move", "file_id", fileID, "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.NotFoundError, "File not found"))
+		return
+	}
+	if file.UserID != userID {
+		h.logger.Warnw("unauthorized file move attempt", "user_id", userID, "file_owner", file.UserID, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.ForbiddenError, "You do not have permission to move this file"))
+		return
+	}
+
+	moved, err := h.service.MoveFile(c.Request.Context(), file, req.Folder, req.Name)
+	switch {
+	case errors.Is(err, model.ErrInvalidFolder):
+		_ = c.Error(invalidFolderError())
+		return
+	case errors.Is(err, service.ErrInvalidFileName):
+		_ = c.Error(apperrors.NewAppErrorWithDetails(apperrors.BadRequestError, "Invalid file name", "Names must not be empty or contain slashes or control characters"))
+		return
+	case errors.Is(err, service.ErrRenameExtension):
+		_ = c.Error(apperrors.NewAppErrorWithDetails(apperrors.BadRequestError, "Invalid file name", err.Error()))
+		return
+	case errors.Is(err, service.ErrDestinationExists), errors.Is(err, service.ErrMoveConflict):
+		_ = c.Error(apperrors.NewAppError(apperrors.ConflictError, err.Error()))
+		return
+	case errors.Is(err, service.ErrObjectNotFound):
+		h.logger.Errorw("stored file missing", "file_id", file.ID, "path", file.Path, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.NotFoundError, "Stored file not found"))
+		return
+	case err != nil:
+		h.logger.Errorw("failed to move file", "file_id", file.ID, "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to move file"))
+		return
+	}
+
+	h.logger.Infow("file moved", "file_id", file.ID, "from", file.Path, "to", moved.Path, "request_id", requestID)
+	c.JSON(http.StatusOK, response.NewSuccessResponse(h.fileInfo(c, moved), requestID))
+}
+
+// folderInfo describes the folder at p
+func folderInfo(p string) dto.FolderInfo {
+	return dto.FolderInfo{Path: p, Name: path.Base(p)}
+}
+
+// invalidFolderError explains which folder paths are accepted
+func invalidFolderError() error {
+	return apperrors.NewAppErrorWithDetails(
+		apperrors.BadRequestError,
+		"Invalid folder path",
+		fmt.Sprintf("Segments are separated by slashes and must not be empty, . or .., contain backslashes or control characters, or exceed %d characters; at most %d segments", model.MaxFolderSegment, model.MaxFolderDepth),
+	)
+}
@@ -0,0 +1,186 @@
+package api
+
+import (
+	"errors"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/service"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// PresignUpload godoc
+// @Summary Start an upload straight to storage
+// @Description Checks the announced file like POST /files/upload does and returns a presigned URL to PUT its content to, without the API in between. Send the returned headers with the PUT, then call POST /files/complete with the upload ID.
+// @Tags files
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body dto.PresignUploadRequest true "File to upload"
+// @Success 200 {object} dto.PresignUploadResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Router /files/presign-upload [post]
+func (h *FileHandler) PresignUpload(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+	userID, err := uuid.Parse(c.GetString("userID"))
+	if err != nil {
+		_ = c.Error(apperrors.NewAppError(apperrors.UnauthorizedError, "User authentication required"))
+		return
+	}
+
+	var req dto.PresignUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.NewAppErrorWithDetails(
+			apperrors.BadRequestError,
+			"Invalid request payload",
+			err.Error(),
+		))
+		return
+	}
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	filename := filepath.Base(req.Filename)
+	fType := model.FileType(req.Type)
+	if err := h.validateAnnounced(filename, req.Size, req.ContentType, fType); err != nil {
+		h.logger.Warnw("presigned upload validation failed", "filename", filename, "error", err, "request_id", requestID)
+		_ = c.Error(err)
+		return
+	}
+
+	upload, err := h.service.PresignUpload(c.Request.Context(), userID, fType, newObjectName(userID, filename), req.Size, req.ContentType, filename)
+	if err != nil {
+		h.logger.Errorw("failed to presign upload", "user_id", userID, "filename", filename, "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to start upload"))
+		return
+	}
+
+	h.logger.Infow("presigned upload started", "user_id", userID, "path", upload.Path, "size", req.Size, "request_id", requestID)
+	c.JSON(http.StatusOK, response.NewSuccessResponse(dto.PresignUploadResponse{
+		UploadID:  upload.UploadID,
+		URL:       upload.URL,
+		Method:    http.MethodPut,
+		Headers:   upload.Headers,
+		Path:      upload.Path,
+		ExpiresAt: upload.ExpiresAt,
+	}, requestID))
+}
+
+// validateAnnounced checks a file the client announced before sending it, like Upload
+// checks a file it received
+func (h *FileHandler) validateAnnounced(filename string, size int64, contentType string, fType model.FileType) error {
+	if err := h.service.ValidateUpload(filename, size, contentType, fType); err != nil {
+		return apperrors.NewAppErrorWithDetails(
+			apperrors.BadRequestError,
+			"File validation failed",
+			err.Error(),
+		)
+	}
+	return nil
+}
+
+// CompleteUpload godoc
+// @Summary Complete an upload sent straight to storage
+// @Description Checks that the object uploaded to the presigned URL has the announced size and content type, scans it when scanning is enabled, and records the file. Objects that fail the checks are removed. Completing an upload again returns the same file.
+// @Tags files
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body dto.CompleteUploadRequest true "Upload to complete"
+// @Success 200 {object} dto.UploadResponse
+// @Failure 400 {object} response.ErrorResponse "Invalid or expired upload ID, or the object does not match the announced file"
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse "Nothing was uploaded yet"
+// @Router /files/complete [post]
+func (h *FileHandler) CompleteUpload(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+	userID, err := uuid.Parse(c.GetString("userID"))
+	if err != nil {
+		_ = c.Error(apperrors.NewAppError(apperrors.UnauthorizedError, "User authentication required"))
+		return
+	}
+
+	var req dto.CompleteUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.NewAppErrorWithDetails(
+			apperrors.BadRequestError,
+			"Invalid request payload",
+			err.Error(),
+		))
+		return
+	}
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	file, err := h.service.CompleteUpload(c.Request.Context(), userID, req.UploadID)
+	switch {
+	case errors.Is(err, service.ErrInvalidUploadID):
+		_ = c.Error(apperrors.NewAppError(apperrors.BadRequestError, "Invalid or expired upload ID"))
+		return
+	case errors.Is(err, service.ErrUploadNotOwned):
+		h.logger.Warnw("completion of another user's upload", "user_id", userID, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.ForbiddenError, "You do not have permission to complete this upload"))
+		return
+	case errors.Is(err, service.ErrObjectNotFound):
+		_ = c.Error(apperrors.NewAppError(apperrors.ConflictError, "The file has not been uploaded yet"))
+		return
+	case errors.Is(err, service.ErrUploadMismatch), errors.Is(err, service.ErrInfected):
+		_ = c.Error(rejectedUpload(err))
+		return
+	case err != nil:
+		h.logger.Errorw("failed to complete upload", "user_id", userID, "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to complete upload"))
+		return
+	}
+
+	uploaded, err := h.uploadResponse(c, file)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	h.logger.Infow("presigned upload completed", "user_id", userID, "file_id", file.ID, "request_id", requestID)
+	c.JSON(http.StatusOK, response.NewSuccessResponse(uploaded, requestID))
+}
+
+// rejectedUpload explains why an uploaded object was rejected and removed
+func rejectedUpload(err error) error {
+	reason := "The uploaded file does not have the announced size or content type"
+	if errors.Is(err, service.ErrInfected) {
+		reason = "The virus scan found malware in the file"
+	}
+	return apperrors.NewAppErrorWithDetails(apperrors.ValidationError, "File rejected", reason)
+}
+
+// uploadResponse describes an uploaded file with a signed URL valid for 15 minutes
+func (h *FileHandler) uploadResponse(c *gin.Context, file *model.File) (*dto.UploadResponse, error) {
+	url, err := h.service.GetSignedURL(c.Request.Context(), file.Path, 15*time.Minute)
+	if err != nil {
+		h.logger.Errorw("failed to generate signed URL", "file_path", file.Path, "error", err, "request_id", c.GetString("RequestID"))
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to generate access URL")
+	}
+	return &dto.UploadResponse{
+		FileID:       file.ID.String(),
+		URL:          url,
+		Path:         file.Path,
+		Type:         string(file.Type),
+		Size:         file.Size,
+		OriginalName: file.OriginalName,
+		MimeType:     file.MimeType,
+		SHA256:       file.SHA256,
+		ScanStatus:   string(file.ScanStatus),
+		UploadedAt:   file.UploadedAt,
+		ExpiresIn:    "15 minutes",
+	}, nil
+}
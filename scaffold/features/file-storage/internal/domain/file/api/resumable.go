@@ -0,0 +1,219 @@
+package api
+
+import (
+	"errors"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/service"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// chunkContentType is the content type of chunks, as in the tus protocol
+const chunkContentType = "application/offset+octet-stream"
+
+// CreateUpload godoc
+// @Summary Start a resumable upload
+// @Description Checks the announced file like POST /files/upload does and starts an upload its content is sent to in chunks with PATCH /files/uploads/{id}. The Location header is the URL of the upload.
+// @Tags files
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateUploadRequest true "File to upload"
+// @Success 201 {object} dto.ResumableUploadResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Router /files/uploads [post]
+func (h *FileHandler) CreateUpload(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+	userID, err := uuid.Parse(c.GetString("userID"))
+	if err != nil {
+		_ = c.Error(apperrors.NewAppError(apperrors.UnauthorizedError, "User authentication required"))
+		return
+	}
+
+	var req dto.CreateUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.NewAppErrorWithDetails(
+			apperrors.BadRequestError,
+			"Invalid request payload",
+			err.Error(),
+		))
+		return
+	}
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	filename := filepath.Base(req.Filename)
+	fType := model.FileType(req.Type)
+	if err := h.validateAnnounced(filename, req.Size, req.ContentType, fType); err != nil {
+		h.logger.Warnw("resumable upload validation failed", "filename", filename, "error", err, "request_id", requestID)
+		_ = c.Error(err)
+		return
+	}
+
+	upload, err := h.service.CreateUpload(c.Request.Context(), userID, fType, newObjectName(userID, filename), req.Size, req.ContentType, filename)
+	if err != nil {
+		h.logger.Errorw("failed to start resumable upload", "user_id", userID, "filename", filename, "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to start upload"))
+		return
+	}
+
+	h.logger.Infow("resumable upload started", "user_id", userID, "upload_id", upload.ID, "size", upload.Size, "request_id", requestID)
+	c.Header("Location", "/api/v1/files/uploads/"+upload.ID.String())
+	c.JSON(http.StatusCreated, response.NewSuccessResponse(uploadState(c, upload), requestID))
+}
+
+// GetUpload godoc
+// @Summary Get the offset of a resumable upload
+// @Description Returns how many bytes were received, also in the Upload-Offset header, so an interrupted upload can continue from there. HEAD returns only the headers.
+// @Tags files
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Upload ID"
+// @Success 200 {object} dto.ResumableUploadResponse
+// @Header 200 {integer} Upload-Offset "Bytes received"
+// @Header 200 {integer} Upload-Length "Size of the file"
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse "Unknown, expired or completed upload"
+// @Router /files/uploads/{id} [get]
+func (h *FileHandler) GetUpload(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("userID"))
+	if err != nil {
+		_ = c.Error(apperrors.NewAppError(apperrors.UnauthorizedError, "User authentication required"))
+		return
+	}
+
+	upload, err := h.service.GetUpload(c.Request.Context(), userID, c.Param("id"))
+	if err != nil {
+		_ = c.Error(h.uploadError(c, err))
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, response.NewSuccessResponse(uploadState(c, upload), c.GetString("RequestID")))
+}
+
+// WriteChunk godoc
+// @Summary Send a chunk of a resumable upload
+// @Description Stores the body at the offset in the Upload-Offset header, which must equal the bytes received so far. Chunks before the last one must be at least min_chunk_size bytes. The last chunk assembles the file, which is checked and scanned like POST /files/complete and returned as file. If assembly failed, send an empty chunk at the final offset to retry it.
+// @Tags files
+// @Security BearerAuth
+// @Accept application/offset+octet-stream
+// @Produce json
+// @Param id path string true "Upload ID"
+// @Param Upload-Offset header integer true "Offset of the chunk"
+// @Success 200 {object} dto.ResumableUploadResponse
+// @Header 200 {integer} Upload-Offset "Bytes received"
+// @Failure 400 {object} response.ErrorResponse "Invalid chunk or headers, or the assembled file was rejected"
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse "Unknown, expired or completed upload"
+// @Failure 409 {object} response.ErrorResponse "The offset is not the upload's; Upload-Offset has the right one"
+// @Router /files/uploads/{id} [patch]
+func (h *FileHandler) WriteChunk(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+	userID, err := uuid.Parse(c.GetString("userID"))
+	if err != nil {
+		_ = c.Error(apperrors.NewAppError(apperrors.UnauthorizedError, "User authentication required"))
+		return
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type")); mediaType != chunkContentType {
+		_ = c.Error(apperrors.NewAppError(apperrors.BadRequestError, "Chunks must be sent as "+chunkContentType))
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		_ = c.Error(apperrors.NewAppError(apperrors.BadRequestError, "The Upload-Offset header must be a byte offset"))
+		return
+	}
+	if c.Request.ContentLength < 0 {
+		_ = c.Error(apperrors.NewAppError(apperrors.BadRequestError, "The Content-Length header is required"))
+		return
+	}
+
+	upload, file, err := h.service.WriteChunk(c.Request.Context(), userID, c.Param("id"), offset, c.Request.Body, c.Request.ContentLength)
+	if upload != nil {
+		c.Header("Upload-Offset", strconv.FormatInt(upload.Received, 10))
+	}
+	if err != nil {
+		_ = c.Error(h.uploadError(c, err))
+		return
+	}
+
+	state := uploadState(c, upload)
+	if file != nil {
+		if state.File, err = h.uploadResponse(c, file); err != nil {
+			_ = c.Error(err)
+			return
+		}
+		h.logger.Infow("resumable upload completed", "user_id", userID, "upload_id", upload.ID, "file_id", file.ID, "request_id", requestID)
+	}
+	c.JSON(http.StatusOK, response.NewSuccessResponse(state, requestID))
+}
+
+// DeleteUpload godoc
+// @Summary Cancel a resumable upload
+// @Description Discards the chunks received so far.
+// @Tags files
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Upload ID"
+// @Success 200 {object} dto.SuccessResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /files/uploads/{id} [delete]
+func (h *FileHandler) DeleteUpload(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("userID"))
+	if err != nil {
+		_ = c.Error(apperrors.NewAppError(apperrors.UnauthorizedError, "User authentication required"))
+		return
+	}
+
+	if err := h.service.AbortUpload(c.Request.Context(), userID, c.Param("id")); err != nil {
+		_ = c.Error(h.uploadError(c, err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Upload deleted successfully"})
+}
+
+// uploadState describes a resumable upload and sets its tus-style headers
+func uploadState(c *gin.Context, upload *model.Upload) dto.ResumableUploadResponse {
+	c.Header("Upload-Offset", strconv.FormatInt(upload.Received, 10))
+	c.Header("Upload-Length", strconv.FormatInt(upload.Size, 10))
+	c.Header("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	return dto.ResumableUploadResponse{
+		ID:           upload.ID.String(),
+		Offset:       upload.Received,
+		Size:         upload.Size,
+		MinChunkSize: service.MinChunkSize,
+		ExpiresAt:    upload.ExpiresAt,
+	}
+}
+
+// uploadError maps the errors of resumable uploads to responses
+func (h *FileHandler) uploadError(c *gin.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrUploadNotFound):
+		return apperrors.NewAppError(apperrors.NotFoundError, "Upload not found")
+	case errors.Is(err, service.ErrOffsetMismatch):
+		return apperrors.NewAppError(apperrors.ConflictError, "The chunk does not start at the upload offset")
+	case errors.Is(err, service.ErrChunkSize):
+		return apperrors.NewAppError(apperrors.BadRequestError, "Chunks must not go past the file size, and all but the last must be at least 5 MiB")
+	case errors.Is(err, service.ErrUploadParts):
+		return apperrors.NewAppError(apperrors.ConflictError, "The upload has too many chunk attempts; start a new one")
+	case errors.Is(err, service.ErrUploadMismatch), errors.Is(err, service.ErrInfected):
+		return rejectedUpload(err)
+	default:
+		h.logger.Errorw("resumable upload failed", "upload_id", c.Param("id"), "error", err, "request_id", c.GetString("RequestID"))
+		return apperrors.NewAppError(apperrors.InternalError, "Failed to process upload")
+	}
+}
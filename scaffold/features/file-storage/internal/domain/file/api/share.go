@@ -0,0 +1,129 @@
+package api
+
+import (
+	"errors"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/service"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ShareFile godoc
+// @Summary Share a file through a link
+// @Description Returns a short-lived token scoped to reading one of your files (scope files:read:<path>). Anyone holding it can download that file from GET /files/shared without signing in, until it expires.
+// @Tags files
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body dto.ShareFileRequest true "File to share"
+// @Success 200 {object} dto.ShareFileResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /files/share [post]
+func (h *FileHandler) ShareFile(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+	userID, err := uuid.Parse(c.GetString("userID"))
+	if err != nil {
+		_ = c.Error(apperrors.NewAppError(apperrors.UnauthorizedError, "User authentication required"))
+		return
+	}
+
+	var req dto.ShareFileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.NewAppErrorWithDetails(
+			apperrors.BadRequestError,
+			"Invalid request payload",
+			err.Error(),
+		))
+		return
+	}
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	file, err := h.service.GetFileByPath(c.Request.Context(), req.Path)
+	if err != nil {
+		h.logger.Warnw("file not found for sharing", "filename", req.Path, "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.NotFoundError, "File not found"))
+		return
+	}
+	if file.UserID != userID {
+		h.logger.Warnw("unauthorized file share attempt", "user_id", userID, "file_owner", file.UserID, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.ForbiddenError, "You do not have permission to share this file"))
+		return
+	}
+
+	token, expiresAt, err := h.service.ShareToken(userID, file.Path, time.Duration(req.ExpiresIn)*time.Second)
+	if errors.Is(err, service.ErrShareTTL) {
+		_ = c.Error(apperrors.NewAppError(apperrors.BadRequestError, "expires_in exceeds the maximum share lifetime"))
+		return
+	}
+	if err != nil {
+		h.logger.Errorw("failed to issue share token", "file_id", file.ID, "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to share file"))
+		return
+	}
+
+	h.logger.Infow("file shared", "file_id", file.ID, "user_id", userID, "expires_at", expiresAt, "request_id", requestID)
+	c.JSON(http.StatusOK, response.NewSuccessResponse(dto.ShareFileResponse{
+		Token:     token,
+		URL:       strings.TrimSuffix(c.Request.URL.Path, "/share") + "/shared?token=" + url.QueryEscape(token),
+		Scope:     service.ScopeReadPrefix + file.Path,
+		ExpiresAt: expiresAt,
+	}, requestID))
+}
+
+// DownloadShared godoc
+// @Summary Download a shared file
+// @Description Streams the file named by a share token from POST /files/share. No sign-in is needed; the token only reads the one file it was issued for.
+// @Tags files
+// @Produce octet-stream
+// @Param token query string true "Share token"
+// @Success 200 {file} file
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /files/shared [get]
+func (h *FileHandler) DownloadShared(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+	claims, path, err := h.service.ParseShareToken(c.Query("token"))
+	if err != nil {
+		_ = c.Error(apperrors.NewAppError(apperrors.UnauthorizedError, "Invalid or expired share link"))
+		return
+	}
+
+	// The file must still exist; deleting it ends every link to it
+	file, err := h.service.GetFileByPath(c.Request.Context(), path)
+	if err != nil {
+		_ = c.Error(apperrors.NewAppError(apperrors.NotFoundError, "File not found"))
+		return
+	}
+	content, size, contentType, err := h.service.OpenObject(c.Request.Context(), file.Path)
+	if errors.Is(err, service.ErrObjectNotFound) {
+		h.logger.Errorw("stored file missing", "file_id", file.ID, "path", file.Path, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.NotFoundError, "Stored file not found"))
+		return
+	}
+	if err != nil {
+		h.logger.Errorw("failed to open shared file", "file_id", file.ID, "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to download file"))
+		return
+	}
+	defer content.Close()
+
+	h.logger.Infow("shared file downloaded", "file_id", file.ID, "shared_by", claims.Subject, "token_id", claims.ID, "ip", c.ClientIP(), "request_id", requestID)
+	c.DataFromReader(http.StatusOK, size, contentType, content, map[string]string{
+		"Content-Disposition": mime.FormatMediaType("attachment", map[string]string{"filename": file.OriginalName}),
+		"Cache-Control":       "private, no-store",
+	})
+}
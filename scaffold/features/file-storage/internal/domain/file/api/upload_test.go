@@ -0,0 +1,123 @@
+package api
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"testing"
+)
+
+// uploadRequest builds a multipart request with a note field and, when size is positive,
+// a PDF of size bytes in the file field
+func uploadRequest(t testing.TB, size int) *http.Request {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	_ = form.WriteField("note", "quarterly report")
+	if size > 0 {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", `form-data; name="file"; filename="report.pdf"`)
+		header.Set("Content-Type", "application/pdf")
+		part, err := form.CreatePart(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = part.Write(bytes.Repeat([]byte("x"), size))
+	}
+	_ = form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/files/upload", bytes.NewReader(body.Bytes()))
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
+
+func TestReadFilePart(t *testing.T) {
+	// Arrange
+	req := uploadRequest(t, 1024)
+
+	// Act
+	file, err := readFilePart(req, "file", 2048)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("readFilePart() error = %v", err)
+	}
+	defer file.release()
+	if file.filename != "report.pdf" || file.contentType != "application/pdf" || file.size != 1024 {
+		t.Errorf("file = %s %s %d bytes", file.filename, file.contentType, file.size)
+	}
+	data, _ := io.ReadAll(file.reader())
+	if len(data) != 1024 {
+		t.Errorf("reader returned %d bytes, want 1024", len(data))
+	}
+}
+
+func TestReadFilePart_StopsPastLimit(t *testing.T) {
+	// Act
+	file, err := readFilePart(uploadRequest(t, 4096), "file", 1000)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("readFilePart() error = %v", err)
+	}
+	defer file.release()
+	if file.size != 1001 {
+		t.Errorf("size = %d, want the limit plus one byte", file.size)
+	}
+}
+
+func TestReadFilePart_Missing(t *testing.T) {
+	if _, err := readFilePart(uploadRequest(t, 0), "file", 1000); err != errNoFilePart {
+		t.Errorf("readFilePart() without a file error = %v, want errNoFilePart", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/files/upload", bytes.NewReader([]byte("{}")))
+	req.Header.Set("Content-Type", "application/json")
+	if _, err := readFilePart(req, "file", 1000); err == nil {
+		t.Error("readFilePart() accepted a non-multipart body")
+	}
+}
+
+// BenchmarkUploadRead compares reading a 2MB upload with ParseMultipartForm (what
+// c.FormFile does) against readFilePart
+func BenchmarkUploadRead(b *testing.B) {
+	const size = 2 << 20
+	body := uploadRequest(b, size)
+	raw, _ := io.ReadAll(body.Body)
+	contentType := body.Header.Get("Content-Type")
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/files/upload", bytes.NewReader(raw))
+		req.Header.Set("Content-Type", contentType)
+		return req
+	}
+
+	b.Run("form_file", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			req := newRequest()
+			if err := req.ParseMultipartForm(32 << 20); err != nil {
+				b.Fatal(err)
+			}
+			f, _, err := req.FormFile("file")
+			if err != nil {
+				b.Fatal(err)
+			}
+			_, _ = io.Copy(io.Discard, f)
+			_ = f.Close()
+			_ = req.MultipartForm.RemoveAll()
+		}
+	})
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			file, err := readFilePart(newRequest(), "file", 10<<20)
+			if err != nil {
+				b.Fatal(err)
+			}
+			_, _ = io.Copy(io.Discard, file.reader())
+			file.release()
+		}
+	})
+}
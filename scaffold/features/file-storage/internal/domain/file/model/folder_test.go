@@ -0,0 +1,52 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCleanFolder(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "Top level", input: "", want: ""},
+		{name: "Root slash", input: "/", want: ""},
+		{name: "Nested", input: "projects/2024", want: "projects/2024"},
+		{name: "Surrounding slashes", input: "/projects/2024/", want: "projects/2024"},
+		{name: "Spaces kept", input: "My Documents", want: "My Documents"},
+		{name: "Empty segment", input: "projects//2024", wantErr: true},
+		{name: "Dot segment", input: "projects/./2024", wantErr: true},
+		{name: "Parent segment", input: "projects/../other-user", wantErr: true},
+		{name: "Backslash", input: `projects\2024`, wantErr: true},
+		{name: "Control character", input: "projects\n2024", wantErr: true},
+		{name: "Segment too long", input: strings.Repeat("a", MaxFolderSegment+1), wantErr: true},
+		{name: "Too deep", input: strings.Repeat("a/", MaxFolderDepth) + "a", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CleanFolder(tt.input)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidFolder) {
+					t.Fatalf("CleanFolder(%q) error = %v, want ErrInvalidFolder", tt.input, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("CleanFolder(%q) = %q, %v, want %q", tt.input, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParentFolder(t *testing.T) {
+	for folder, want := range map[string]string{"projects": "", "projects/2024": "projects", "a/b/c": "a/b"} {
+		if got := ParentFolder(folder); got != want {
+			t.Errorf("ParentFolder(%q) = %q, want %q", folder, got, want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/shared/id"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ShareLink lets anyone holding its token download one file without signing in, until
+// it expires, is revoked or used up its downloads. Unlike share tokens, links are stored,
+// so they can be revoked, protected with a password and limited in downloads; they
+// follow the file when it is moved.
+type ShareLink struct {
+	ID     uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	FileID uuid.UUID `gorm:"type:uuid;not null;index" json:"file_id"`
+	// UserID is the owner of the file, who created the link
+	UserID uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+
+	// TokenHash is the SHA-256 hash of the token; the token itself is never stored
+	TokenHash string `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	// PasswordHash is the bcrypt hash of the password downloads need; empty for none
+	PasswordHash string `gorm:"size:255" json:"-"`
+
+	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
+	// MaxDownloads is how many downloads the link allows; 0 for no limit
+	MaxDownloads int `gorm:"not null;default:0" json:"max_downloads"`
+	Downloads    int `gorm:"not null;default:0" json:"downloads"`
+	// RevokedAt is when the owner revoked the link; revoked links are never accepted again
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BeforeCreate generates the ID of the link if not already set
+func (l *ShareLink) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == uuid.Nil {
+		l.ID = id.New()
+	}
+	return nil
+}
+
+// TableName specifies the table of share links
+func (ShareLink) TableName() string {
+	return "file_share_links"
+}
+
+// Active reports whether the link still accepts downloads at now
+func (l *ShareLink) Active(now time.Time) bool {
+	return l.RevokedAt == nil && now.Before(l.ExpiresAt) && (l.MaxDownloads == 0 || l.Downloads < l.MaxDownloads)
+}
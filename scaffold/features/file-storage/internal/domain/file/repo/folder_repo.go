@@ -0,0 +1,45 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FolderRepo stores the folders users created
+type FolderRepo interface {
+	// Create stores folders, skipping the ones the user already has
+	Create(ctx context.Context, folders []model.Folder) error
+	// Exists reports whether the user has the folder
+	Exists(ctx context.Context, userID uuid.UUID, path string) (bool, error)
+	// ListChildren returns the folders directly in parent, by path
+	ListChildren(ctx context.Context, userID uuid.UUID, parent string) ([]model.Folder, error)
+}
+
+type folderRepo struct {
+	db *gorm.DB
+}
+
+func NewFolderRepo(db *gorm.DB) FolderRepo {
+	return &folderRepo{db: db}
+}
+
+func (r *folderRepo) Create(ctx context.Context, folders []model.Folder) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&folders).Error
+}
+
+func (r *folderRepo) Exists(ctx context.Context, userID uuid.UUID, path string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.Folder{}).Where("user_id = ? AND path = ?", userID, path).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *folderRepo) ListChildren(ctx context.Context, userID uuid.UUID, parent string) ([]model.Folder, error) {
+	var folders []model.Folder
+	err := r.db.WithContext(ctx).Where("user_id = ? AND parent = ?", userID, parent).Order("path").Find(&folders).Error
+	return folders, err
+}
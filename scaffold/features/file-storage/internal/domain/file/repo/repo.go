@@ -2,8 +2,10 @@ package repo
 
 import (
 	"context"
-	"go_platform_template/internal/domain/file/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/model"
+	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -13,6 +15,21 @@ type FileRepo interface {
 	DeleteFileMeta(ctx context.Context, objectPath string) error
 	GetFileByPath(ctx context.Context, objectPath string) (*model.File, error)
 	GetFilesByUserID(ctx context.Context, userID string) ([]model.File, error)
+	// GetFilesByIDs returns the files with the given IDs that exist, in no particular order
+	GetFilesByIDs(ctx context.Context, ids []uuid.UUID) ([]model.File, error)
+	// SetThumbnails records the thumbnails generated for a file at the given time
+	SetThumbnails(ctx context.Context, id uuid.UUID, thumbnails []model.Thumbnail, at time.Time) error
+	// ListMissingThumbnails returns profile images uploaded before the given time that
+	// thumbnails were not generated for yet
+	ListMissingThumbnails(ctx context.Context, before time.Time, limit int) ([]model.File, error)
+	// ListFolder returns a page of the files directly in a folder of the user, by name,
+	// and how many there are
+	ListFolder(ctx context.Context, userID uuid.UUID, folder string, offset, limit int) ([]model.File, int64, error)
+	// MoveFile records the path, folder, name and thumbnails of file if it is still
+	// stored at from, and reports whether it was
+	MoveFile(ctx context.Context, from string, file *model.File) (bool, error)
+	// SetVisibility makes a file public or private
+	SetVisibility(ctx context.Context, id uuid.UUID, visibility model.Visibility) error
 }
 
 type fileRepo struct {
@@ -60,3 +77,48 @@ func (r *fileRepo) GetFilesByUserID(ctx context.Context, userID string) ([]model
 	}
 	return files, nil
 }
+
+func (r *fileRepo) GetFilesByIDs(ctx context.Context, ids []uuid.UUID) ([]model.File, error) {
+	var files []model.File
+	err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&files).Error
+	return files, err
+}
+
+func (r *fileRepo) SetThumbnails(ctx context.Context, id uuid.UUID, thumbnails []model.Thumbnail, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.File{ID: id}).
+		Select("thumbnails", "thumbnails_at").
+		Updates(&model.File{Thumbnails: thumbnails, ThumbnailsAt: &at}).Error
+}
+
+func (r *fileRepo) ListMissingThumbnails(ctx context.Context, before time.Time, limit int) ([]model.File, error) {
+	var files []model.File
+	err := r.db.WithContext(ctx).
+		Where("type = ? AND thumbnails_at IS NULL AND uploaded_at < ?", model.FileTypeProfileImage, before).
+		Order("uploaded_at").
+		Limit(limit).
+		Find(&files).Error
+	return files, err
+}
+
+func (r *fileRepo) ListFolder(ctx context.Context, userID uuid.UUID, folder string, offset, limit int) ([]model.File, int64, error) {
+	query := r.db.WithContext(ctx).Model(&model.File{}).Where("user_id = ? AND folder = ?", userID, folder)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var files []model.File
+	err := query.Order("original_name, id").Offset(offset).Limit(limit).Find(&files).Error
+	return files, total, err
+}
+
+func (r *fileRepo) MoveFile(ctx context.Context, from string, file *model.File) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.File{}).
+		Where("id = ? AND path = ?", file.ID, from).
+		Select("path", "folder", "original_name", "thumbnails").
+		Updates(&model.File{Path: file.Path, Folder: file.Folder, OriginalName: file.OriginalName, Thumbnails: file.Thumbnails})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *fileRepo) SetVisibility(ctx context.Context, id uuid.UUID, visibility model.Visibility) error {
+	return r.db.WithContext(ctx).Model(&model.File{ID: id}).Update("visibility", visibility).Error
+}
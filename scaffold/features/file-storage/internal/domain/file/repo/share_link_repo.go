@@ -0,0 +1,79 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ShareLinkRepo stores the share links of files
+type ShareLinkRepo interface {
+	Create(ctx context.Context, link *model.ShareLink) error
+	// FindByID returns nil, nil when the link does not exist
+	FindByID(ctx context.Context, id uuid.UUID) (*model.ShareLink, error)
+	// FindByHash returns the link whose token has the given hash; nil, nil when none
+	FindByHash(ctx context.Context, hash string) (*model.ShareLink, error)
+	// ListByFile returns the links of a file, newest first
+	ListByFile(ctx context.Context, fileID uuid.UUID) ([]model.ShareLink, error)
+	// ClaimDownload counts a download of the link if it is still active at now, and
+	// reports whether it was
+	ClaimDownload(ctx context.Context, id uuid.UUID, now time.Time) (bool, error)
+	// Revoke revokes the link at the given time; revoking it again is not an error
+	Revoke(ctx context.Context, id uuid.UUID, at time.Time) error
+}
+
+type shareLinkRepo struct {
+	db *gorm.DB
+}
+
+func NewShareLinkRepo(db *gorm.DB) ShareLinkRepo {
+	return &shareLinkRepo{db: db}
+}
+
+func (r *shareLinkRepo) Create(ctx context.Context, link *model.ShareLink) error {
+	return r.db.WithContext(ctx).Create(link).Error
+}
+
+func (r *shareLinkRepo) FindByID(ctx context.Context, id uuid.UUID) (*model.ShareLink, error) {
+	return r.find(ctx, "id = ?", id)
+}
+
+func (r *shareLinkRepo) FindByHash(ctx context.Context, hash string) (*model.ShareLink, error) {
+	return r.find(ctx, "token_hash = ?", hash)
+}
+
+func (r *shareLinkRepo) find(ctx context.Context, query string, arg any) (*model.ShareLink, error) {
+	var link model.ShareLink
+	err := r.db.WithContext(ctx).First(&link, query, arg).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (r *shareLinkRepo) ListByFile(ctx context.Context, fileID uuid.UUID) ([]model.ShareLink, error) {
+	var links []model.ShareLink
+	err := r.db.WithContext(ctx).Where("file_id = ?", fileID).Order("created_at DESC").Find(&links).Error
+	return links, err
+}
+
+func (r *shareLinkRepo) ClaimDownload(ctx context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.ShareLink{}).
+		Where("id = ? AND revoked_at IS NULL AND expires_at > ? AND (max_downloads = 0 OR downloads < max_downloads)", id, now).
+		UpdateColumn("downloads", gorm.Expr("downloads + 1"))
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *shareLinkRepo) Revoke(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.ShareLink{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		UpdateColumn("revoked_at", at).Error
+}
@@ -0,0 +1,90 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UploadRepo stores resumable uploads in progress
+type UploadRepo interface {
+	Create(ctx context.Context, upload *model.Upload) error
+	// FindByID returns nil, nil when the upload does not exist
+	FindByID(ctx context.Context, id uuid.UUID) (*model.Upload, error)
+	// ClaimPart hands out the next part number of the upload
+	ClaimPart(ctx context.Context, id uuid.UUID) (int, error)
+	// RecordPart stores the received bytes, parts and expiry of upload if nothing was
+	// received since from, and reports whether it did
+	RecordPart(ctx context.Context, upload *model.Upload, from int64) (bool, error)
+	// ListExpired returns uploads that expired at or before now
+	ListExpired(ctx context.Context, now time.Time, limit int) ([]model.Upload, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type uploadRepo struct {
+	db *gorm.DB
+}
+
+func NewUploadRepo(db *gorm.DB) UploadRepo {
+	return &uploadRepo{db: db}
+}
+
+func (r *uploadRepo) Create(ctx context.Context, upload *model.Upload) error {
+	return r.db.WithContext(ctx).Create(upload).Error
+}
+
+func (r *uploadRepo) FindByID(ctx context.Context, id uuid.UUID) (*model.Upload, error) {
+	var upload model.Upload
+	err := r.db.WithContext(ctx).First(&upload, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+func (r *uploadRepo) ClaimPart(ctx context.Context, id uuid.UUID) (int, error) {
+	var part int
+	err := r.db.WithContext(ctx).
+		Raw("UPDATE file_uploads SET next_part = next_part + 1 WHERE id = ? RETURNING next_part", id).
+		Scan(&part).Error
+	if err != nil {
+		return 0, err
+	}
+	if part == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return part, nil
+}
+
+func (r *uploadRepo) RecordPart(ctx context.Context, upload *model.Upload, from int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(upload).
+		Where("received = ?", from).
+		Select("received", "parts", "expires_at").
+		Updates(upload)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *uploadRepo) ListExpired(ctx context.Context, now time.Time, limit int) ([]model.Upload, error) {
+	var uploads []model.Upload
+	err := r.db.WithContext(ctx).
+		Where("expires_at <= ?", now).
+		Order("expires_at").
+		Limit(limit).
+		Find(&uploads).Error
+	return uploads, err
+}
+
+func (r *uploadRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&model.Upload{}, "id = ?", id).Error
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/model"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/storage"
+
+	"github.com/google/uuid"
+)
+
+// addArchiveTestFile stores a file of owner with content under objectName
+func addArchiveTestFile(t *testing.T, svc *FileService, files *memoryFileRepo, owner uuid.UUID, objectName, folder, name, content string) *model.File {
+	t.Helper()
+	ctx := context.Background()
+	if err := svc.storage.Put(ctx, objectName, strings.NewReader(content), int64(len(content)), storage.PutOptions{ContentType: "text/plain"}); err != nil {
+		t.Fatalf("Put(%s) error = %v", objectName, err)
+	}
+	file := &model.File{UserID: owner, Path: objectName, Folder: folder, OriginalName: name, MimeType: "text/plain", Size: int64(len(content))}
+	if err := files.SaveFileMeta(ctx, file); err != nil {
+		t.Fatalf("SaveFileMeta() error = %v", err)
+	}
+	return file
+}
+
+func TestFileService_Archive(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	owner := uuid.New()
+	svc, files, avatar := newFolderTestService(t, owner)
+	svc.archive = config.FileArchiveConfig{MaxFiles: 10, MaxSize: 1 << 20}
+	notes := addArchiveTestFile(t, svc, files, owner, owner.String()+"/docs/notes_1.txt", "docs", "notes.txt", "first")
+	again := addArchiveTestFile(t, svc, files, owner, owner.String()+"/docs/notes_2.txt", "docs", "notes.txt", "second")
+
+	// Act
+	prepared, err := svc.PrepareArchive(ctx, owner, []uuid.UUID{notes.ID, avatar.ID, notes.ID, again.ID})
+	if err != nil {
+		t.Fatalf("PrepareArchive() error = %v", err)
+	}
+	var buf bytes.Buffer
+	err = svc.WriteArchive(ctx, &buf, prepared)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("WriteArchive() error = %v", err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("zip.NewReader() error = %v", err)
+	}
+	want := []struct{ name, content string }{
+		{"docs/notes.txt", "first"},
+		{"avatar.png", "png"},
+		{"docs/notes (2).txt", "second"},
+	}
+	if len(archive.File) != len(want) {
+		t.Fatalf("archive has %d files, want %d", len(archive.File), len(want))
+	}
+	for i, entry := range archive.File {
+		r, err := entry.Open()
+		if err != nil {
+			t.Fatalf("Open(%s) error = %v", entry.Name, err)
+		}
+		content, _ := io.ReadAll(r)
+		r.Close()
+		if entry.Name != want[i].name || string(content) != want[i].content {
+			t.Errorf("entry %d = %q with %q, want %q with %q", i, entry.Name, content, want[i].name, want[i].content)
+		}
+	}
+}
+
+func TestFileService_PrepareArchive_Rejects(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	owner := uuid.New()
+	svc, files, avatar := newFolderTestService(t, owner)
+	svc.archive = config.FileArchiveConfig{MaxFiles: 2, MaxSize: 8}
+	other := addArchiveTestFile(t, svc, files, uuid.New(), "other/notes.txt", "", "notes.txt", "x")
+	large := addArchiveTestFile(t, svc, files, owner, owner.String()+"/large.txt", "", "large.txt", "0123456789")
+
+	tests := []struct {
+		name string
+		ids  []uuid.UUID
+		want error
+	}{
+		{"another user's file", []uuid.UUID{avatar.ID, other.ID}, ErrArchiveForbidden},
+		{"unknown file", []uuid.UUID{uuid.New()}, ErrArchiveFileNotFound},
+		{"too many files", []uuid.UUID{avatar.ID, large.ID, uuid.New()}, ErrArchiveTooManyFiles},
+		{"too large", []uuid.UUID{large.ID}, ErrArchiveTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			_, err := svc.PrepareArchive(ctx, owner, tt.ids)
+
+			// Assert
+			if !errors.Is(err, tt.want) {
+				t.Errorf("PrepareArchive() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
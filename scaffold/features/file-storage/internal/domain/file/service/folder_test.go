@@ -0,0 +1,306 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/model"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/storage"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// memoryFileRepo keeps file metadata in a map keyed by ID
+type memoryFileRepo struct {
+	files map[uuid.UUID]*model.File
+}
+
+func (r *memoryFileRepo) SaveFileMeta(_ context.Context, file *model.File) error {
+	if file.ID == uuid.Nil {
+		file.ID = uuid.New()
+	}
+	copied := *file
+	r.files[file.ID] = &copied
+	return nil
+}
+
+func (r *memoryFileRepo) GetFileByID(_ context.Context, id string) (*model.File, error) {
+	for _, file := range r.files {
+		if file.ID.String() == id {
+			copied := *file
+			return &copied, nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+func (r *memoryFileRepo) DeleteFileMeta(_ context.Context, objectPath string) error {
+	for id, file := range r.files {
+		if file.Path == objectPath {
+			delete(r.files, id)
+		}
+	}
+	return nil
+}
+
+func (r *memoryFileRepo) GetFileByPath(_ context.Context, objectPath string) (*model.File, error) {
+	for _, file := range r.files {
+		if file.Path == objectPath {
+			copied := *file
+			return &copied, nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+func (r *memoryFileRepo) GetFilesByUserID(_ context.Context, userID string) ([]model.File, error) {
+	var files []model.File
+	for _, file := range r.files {
+		if file.UserID.String() == userID {
+			files = append(files, *file)
+		}
+	}
+	return files, nil
+}
+
+func (r *memoryFileRepo) GetFilesByIDs(_ context.Context, ids []uuid.UUID) ([]model.File, error) {
+	var files []model.File
+	for _, id := range ids {
+		if file, ok := r.files[id]; ok {
+			files = append(files, *file)
+		}
+	}
+	return files, nil
+}
+
+func (r *memoryFileRepo) SetThumbnails(_ context.Context, id uuid.UUID, thumbnails []model.Thumbnail, at time.Time) error {
+	r.files[id].Thumbnails, r.files[id].ThumbnailsAt = thumbnails, &at
+	return nil
+}
+
+func (r *memoryFileRepo) ListMissingThumbnails(context.Context, time.Time, int) ([]model.File, error) {
+	return nil, nil
+}
+
+func (r *memoryFileRepo) ListFolder(_ context.Context, userID uuid.UUID, folder string, offset, limit int) ([]model.File, int64, error) {
+	var files []model.File
+	for _, file := range r.files {
+		if file.UserID == userID && file.Folder == folder {
+			files = append(files, *file)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].OriginalName < files[j].OriginalName })
+	total := int64(len(files))
+	files = files[min(offset, len(files)):]
+	return files[:min(limit, len(files))], total, nil
+}
+
+func (r *memoryFileRepo) SetVisibility(_ context.Context, id uuid.UUID, visibility model.Visibility) error {
+	r.files[id].Visibility = visibility
+	return nil
+}
+
+func (r *memoryFileRepo) MoveFile(_ context.Context, from string, file *model.File) (bool, error) {
+	stored, ok := r.files[file.ID]
+	if !ok || stored.Path != from {
+		return false, nil
+	}
+	stored.Path, stored.Folder, stored.OriginalName, stored.Thumbnails = file.Path, file.Folder, file.OriginalName, file.Thumbnails
+	return true, nil
+}
+
+// memoryFolderRepo keeps folders in a map keyed by user and path
+type memoryFolderRepo struct {
+	folders map[string]model.Folder
+}
+
+func (r *memoryFolderRepo) Create(_ context.Context, folders []model.Folder) error {
+	for _, folder := range folders {
+		r.folders[folder.UserID.String()+"/"+folder.Path] = folder
+	}
+	return nil
+}
+
+func (r *memoryFolderRepo) Exists(_ context.Context, userID uuid.UUID, path string) (bool, error) {
+	_, ok := r.folders[userID.String()+"/"+path]
+	return ok, nil
+}
+
+func (r *memoryFolderRepo) ListChildren(_ context.Context, userID uuid.UUID, parent string) ([]model.Folder, error) {
+	var folders []model.Folder
+	for _, folder := range r.folders {
+		if folder.UserID == userID && folder.Parent == parent {
+			folders = append(folders, folder)
+		}
+	}
+	sort.Slice(folders, func(i, j int) bool { return folders[i].Path < folders[j].Path })
+	return folders, nil
+}
+
+// newFolderTestService returns a service over local storage holding a profile image of
+// owner, with one thumbnail, at the top level
+func newFolderTestService(t *testing.T, owner uuid.UUID) (*FileService, *memoryFileRepo, *model.File) {
+	t.Helper()
+	ctx := context.Background()
+	objects, err := storage.NewLocalStorage(t.TempDir(), "/api/v1/files/raw", "secret")
+	if err != nil {
+		t.Fatalf("NewLocalStorage() error = %v", err)
+	}
+	files := &memoryFileRepo{files: map[uuid.UUID]*model.File{}}
+	svc := &FileService{storage: objects, repo: files, logger: zap.NewNop().Sugar()}
+	svc.SetFolders(&memoryFolderRepo{folders: map[string]model.Folder{}})
+
+	file := &model.File{
+		UserID:       owner,
+		Path:         owner.String() + "/avatar_20240115-120000.png",
+		Type:         model.FileTypeProfileImage,
+		OriginalName: "avatar.png",
+		Thumbnails:   []model.Thumbnail{{Size: 64, Path: "thumbnails/" + owner.String() + "/avatar_20240115-120000_64.png"}},
+	}
+	for _, objectName := range []string{file.Path, file.Thumbnails[0].Path} {
+		if err := objects.Put(ctx, objectName, strings.NewReader("png"), 3, storage.PutOptions{ContentType: "image/png"}); err != nil {
+			t.Fatalf("Put(%s) error = %v", objectName, err)
+		}
+	}
+	if err := files.SaveFileMeta(ctx, file); err != nil {
+		t.Fatalf("SaveFileMeta() error = %v", err)
+	}
+	return svc, files, file
+}
+
+func TestFileService_MoveFile(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	owner := uuid.New()
+	svc, files, file := newFolderTestService(t, owner)
+	folder := "/projects/2024/"
+
+	// Act
+	moved, err := svc.MoveFile(ctx, file, &folder, "")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("MoveFile() error = %v", err)
+	}
+	wantPath := owner.String() + "/projects/2024/avatar_20240115-120000.png"
+	if moved.Path != wantPath || moved.Folder != "projects/2024" || moved.OriginalName != "avatar.png" {
+		t.Errorf("MoveFile() = %s in %q named %q", moved.Path, moved.Folder, moved.OriginalName)
+	}
+	if stored := files.files[file.ID]; stored.Path != wantPath || stored.Thumbnails[0].Path != "thumbnails/"+owner.String()+"/projects/2024/avatar_20240115-120000_64.png" {
+		t.Errorf("stored file = %s with thumbnails %+v", stored.Path, stored.Thumbnails)
+	}
+	for _, objectName := range []string{file.Path, file.Thumbnails[0].Path} {
+		if exists, _ := svc.FileExists(ctx, objectName); exists {
+			t.Errorf("object %s still stored after the move", objectName)
+		}
+	}
+	object, _, _, err := svc.OpenObject(ctx, moved.Thumbnails[0].Path)
+	if err != nil {
+		t.Fatalf("OpenObject() of the moved thumbnail error = %v", err)
+	}
+	data, _ := io.ReadAll(object)
+	object.Close()
+	if string(data) != "png" {
+		t.Errorf("moved thumbnail = %q", data)
+	}
+	folders, _, _, err := svc.ListFolder(ctx, owner, "projects", 0, 10)
+	if err != nil || len(folders) != 1 || folders[0].Path != "projects/2024" {
+		t.Errorf("ListFolder(projects) = %+v, %v, want the folder created by the move", folders, err)
+	}
+}
+
+func TestFileService_MoveFile_Rename(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	owner := uuid.New()
+	svc, _, file := newFolderTestService(t, owner)
+
+	// Act
+	moved, err := svc.MoveFile(ctx, file, nil, "portrait.PNG")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("MoveFile() error = %v", err)
+	}
+	if moved.OriginalName != "portrait.PNG" || moved.Folder != "" || !strings.HasPrefix(moved.Path, owner.String()+"/portrait_") {
+		t.Errorf("MoveFile() = %s in %q named %q", moved.Path, moved.Folder, moved.OriginalName)
+	}
+	_, files, total, err := svc.ListFolder(ctx, owner, "", 0, 10)
+	if err != nil || total != 1 || files[0].OriginalName != "portrait.PNG" {
+		t.Errorf("ListFolder() = %+v, %d, %v", files, total, err)
+	}
+}
+
+func TestFileService_MoveFile_Rejects(t *testing.T) {
+	owner := uuid.New()
+	parent := "../other"
+	tests := []struct {
+		name    string
+		folder  *string
+		newName string
+		wantErr error
+	}{
+		{name: "Folder outside the user's", folder: &parent, wantErr: model.ErrInvalidFolder},
+		{name: "Other extension", newName: "avatar.svg", wantErr: ErrRenameExtension},
+		{name: "Slash in the name", newName: "a/avatar.png", wantErr: ErrInvalidFileName},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			svc, files, file := newFolderTestService(t, owner)
+
+			// Act
+			_, err := svc.MoveFile(context.Background(), file, tt.folder, tt.newName)
+
+			// Assert
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("MoveFile() error = %v, want %v", err, tt.wantErr)
+			}
+			if stored := files.files[file.ID]; stored.Path != file.Path {
+				t.Errorf("stored path = %s after a rejected move", stored.Path)
+			}
+		})
+	}
+}
+
+func TestFileService_MoveFile_Conflict(t *testing.T) {
+	// Arrange: the file is moved elsewhere while this move copies it
+	ctx := context.Background()
+	owner := uuid.New()
+	svc, files, file := newFolderTestService(t, owner)
+	files.files[file.ID].Path = owner.String() + "/elsewhere/avatar_20240115-120000.png"
+	folder := "archive"
+
+	// Act
+	_, err := svc.MoveFile(ctx, file, &folder, "")
+
+	// Assert
+	if !errors.Is(err, ErrMoveConflict) {
+		t.Fatalf("MoveFile() error = %v, want ErrMoveConflict", err)
+	}
+	if exists, _ := svc.FileExists(ctx, owner.String()+"/archive/avatar_20240115-120000.png"); exists {
+		t.Error("copy left behind after a conflicting move")
+	}
+	if exists, _ := svc.FileExists(ctx, file.Path); !exists {
+		t.Error("original removed after a conflicting move")
+	}
+}
+
+func TestFileService_ListFolder_Missing(t *testing.T) {
+	// Arrange
+	svc, _, _ := newFolderTestService(t, uuid.New())
+
+	// Act
+	_, _, _, err := svc.ListFolder(context.Background(), uuid.New(), "projects", 0, 10)
+
+	// Assert
+	if !errors.Is(err, ErrFolderNotFound) {
+		t.Errorf("ListFolder() error = %v, want ErrFolderNotFound", err)
+	}
+}
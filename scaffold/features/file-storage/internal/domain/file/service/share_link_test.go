@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/model"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// memoryShareLinkRepo keeps share links in a map
+type memoryShareLinkRepo struct {
+	links map[uuid.UUID]*model.ShareLink
+}
+
+func (r *memoryShareLinkRepo) Create(_ context.Context, link *model.ShareLink) error {
+	if link.ID == uuid.Nil {
+		link.ID = uuid.New()
+	}
+	copied := *link
+	r.links[link.ID] = &copied
+	return nil
+}
+
+func (r *memoryShareLinkRepo) FindByID(_ context.Context, id uuid.UUID) (*model.ShareLink, error) {
+	link, ok := r.links[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *link
+	return &copied, nil
+}
+
+func (r *memoryShareLinkRepo) FindByHash(_ context.Context, hash string) (*model.ShareLink, error) {
+	for _, link := range r.links {
+		if link.TokenHash == hash {
+			copied := *link
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryShareLinkRepo) ListByFile(_ context.Context, fileID uuid.UUID) ([]model.ShareLink, error) {
+	var links []model.ShareLink
+	for _, link := range r.links {
+		if link.FileID == fileID {
+			links = append(links, *link)
+		}
+	}
+	return links, nil
+}
+
+func (r *memoryShareLinkRepo) ClaimDownload(_ context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	link := r.links[id]
+	if !link.Active(now) {
+		return false, nil
+	}
+	link.Downloads++
+	return true, nil
+}
+
+func (r *memoryShareLinkRepo) Revoke(_ context.Context, id uuid.UUID, at time.Time) error {
+	if link := r.links[id]; link.RevokedAt == nil {
+		link.RevokedAt = &at
+	}
+	return nil
+}
+
+// newShareLinkTestService returns a service with share links enabled and one stored file
+func newShareLinkTestService(t *testing.T) (*FileService, *testutil.FakeClock, *model.File) {
+	t.Helper()
+	clk := testutil.NewFakeClock(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+	shares := NewShareTokens(config.FileShareConfig{Secret: "test-share-secret", DefaultTTL: time.Hour, MaxTTL: 24 * time.Hour})
+	shares.SetClock(clk)
+	files := &memoryFileRepo{files: map[uuid.UUID]*model.File{}}
+	svc := &FileService{repo: files, shares: shares, logger: zap.NewNop().Sugar()}
+	svc.SetShareLinks(&memoryShareLinkRepo{links: map[uuid.UUID]*model.ShareLink{}})
+
+	owner := uuid.New()
+	file := &model.File{UserID: owner, Path: owner.String() + "/report_20240115-120000.pdf", OriginalName: "report.pdf"}
+	if err := files.SaveFileMeta(context.Background(), file); err != nil {
+		t.Fatalf("SaveFileMeta() error = %v", err)
+	}
+	return svc, clk, file
+}
+
+func TestFileService_ShareLink_PasswordAndDownloadLimit(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	svc, _, file := newShareLinkTestService(t)
+	token, link, err := svc.CreateShareLink(ctx, file, 0, "correct-horse", 2)
+	if err != nil {
+		t.Fatalf("CreateShareLink() error = %v", err)
+	}
+
+	// Act
+	_, _, noPassword := svc.OpenShareLink(ctx, token, "")
+	_, _, wrongPassword := svc.OpenShareLink(ctx, token, "wrong-horse")
+	_, opened, first := svc.OpenShareLink(ctx, token, "correct-horse")
+	second, _, _ := svc.OpenShareLink(ctx, token, "correct-horse")
+	_, _, third := svc.OpenShareLink(ctx, token, "correct-horse")
+
+	// Assert
+	if !errors.Is(noPassword, ErrSharePassword) || !errors.Is(wrongPassword, ErrSharePassword) {
+		t.Errorf("OpenShareLink() without or with a wrong password error = %v, %v, want ErrSharePassword", noPassword, wrongPassword)
+	}
+	if first != nil || opened.ID != file.ID {
+		t.Fatalf("OpenShareLink() = %+v, %v, want the shared file", opened, first)
+	}
+	if second == nil || second.Downloads != 2 {
+		t.Errorf("OpenShareLink() second download = %+v, want 2 downloads", second)
+	}
+	if !errors.Is(third, ErrShareLinkNotFound) {
+		t.Errorf("OpenShareLink() past the download limit error = %v, want ErrShareLinkNotFound", third)
+	}
+	if link.PasswordHash == "" || link.PasswordHash == "correct-horse" || link.TokenHash == token {
+		t.Error("share link stores its password or token in the clear")
+	}
+}
+
+func TestFileService_ShareLink_ExpiryAndRevocation(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	svc, clk, file := newShareLinkTestService(t)
+	expiring, _, err := svc.CreateShareLink(ctx, file, 30*time.Minute, "", 0)
+	if err != nil {
+		t.Fatalf("CreateShareLink() error = %v", err)
+	}
+	revoked, link, err := svc.CreateShareLink(ctx, file, 0, "", 0)
+	if err != nil {
+		t.Fatalf("CreateShareLink() error = %v", err)
+	}
+
+	// Act
+	_, _, beforeExpiry := svc.OpenShareLink(ctx, expiring, "")
+	_, revokeErr := svc.RevokeShareLink(ctx, file.UserID, link.ID)
+	_, _, afterRevoke := svc.OpenShareLink(ctx, revoked, "")
+	clk.Advance(31 * time.Minute)
+	_, _, afterExpiry := svc.OpenShareLink(ctx, expiring, "")
+
+	// Assert
+	if beforeExpiry != nil || revokeErr != nil {
+		t.Fatalf("OpenShareLink() = %v, RevokeShareLink() = %v", beforeExpiry, revokeErr)
+	}
+	if !errors.Is(afterRevoke, ErrShareLinkNotFound) || !errors.Is(afterExpiry, ErrShareLinkNotFound) {
+		t.Errorf("OpenShareLink() after revocation = %v, after expiry = %v, want ErrShareLinkNotFound", afterRevoke, afterExpiry)
+	}
+	if _, err := svc.RevokeShareLink(ctx, uuid.New(), link.ID); !errors.Is(err, ErrShareLinkNotFound) {
+		t.Errorf("RevokeShareLink() by another user error = %v, want ErrShareLinkNotFound", err)
+	}
+	if _, _, err := svc.CreateShareLink(ctx, file, 48*time.Hour, "", 0); !errors.Is(err, ErrShareTTL) {
+		t.Errorf("CreateShareLink() past MaxTTL error = %v, want ErrShareTTL", err)
+	}
+}
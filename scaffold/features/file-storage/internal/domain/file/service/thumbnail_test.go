@@ -0,0 +1,66 @@
+package service
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestScaleDown(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		size          int
+		wantW, wantH  int
+	}{
+		{"landscape", 800, 600, 128, 128, 96},
+		{"portrait", 600, 800, 128, 96, 128},
+		{"square", 512, 512, 128, 128, 128},
+		{"already small", 100, 50, 128, 100, 50},
+		{"thin strip", 4000, 10, 128, 128, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			img := image.NewRGBA(image.Rect(0, 0, tt.width, tt.height))
+
+			// Act
+			scaled := scaleDown(img, tt.size)
+
+			// Assert
+			if b := scaled.Bounds(); b.Dx() != tt.wantW || b.Dy() != tt.wantH {
+				t.Errorf("scaleDown() = %dx%d, want %dx%d", b.Dx(), b.Dy(), tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestScaleDown_AveragesPixels(t *testing.T) {
+	// Arrange: black and white columns of one pixel each, offset from the origin
+	img := image.NewRGBA(image.Rect(10, 10, 14, 12))
+	for x := 10; x < 14; x++ {
+		for y := 10; y < 12; y++ {
+			if x%2 == 0 {
+				img.Set(x, y, color.White)
+			} else {
+				img.Set(x, y, color.Black)
+			}
+		}
+	}
+
+	// Act
+	scaled := scaleDown(img, 2)
+
+	// Assert
+	r, g, b, a := scaled.At(0, 0).RGBA()
+	if r != 0x7fff || g != 0x7fff || b != 0x7fff || a != 0xffff {
+		t.Errorf("scaleDown() pixel = %x,%x,%x,%x, want mid grey", r, g, b, a)
+	}
+}
+
+func TestThumbnailPath(t *testing.T) {
+	got := thumbnailPath("user-123/photo_20231201-143052.png", 128, ".png")
+	if want := "thumbnails/user-123/photo_20231201-143052_128.png"; got != want {
+		t.Errorf("thumbnailPath() = %q, want %q", got, want)
+	}
+}
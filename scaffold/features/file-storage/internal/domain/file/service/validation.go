@@ -2,8 +2,8 @@ package service
 
 import (
 	"fmt"
-	"go_platform_template/internal/domain/file/model"
-	apperrors "go_platform_template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/model"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
 	"mime"
 	"path/filepath"
 	"strings"
@@ -199,3 +199,16 @@ func (s *FileService) ValidateUpload(fileName string, fileSize int64, contentTyp
 	}
 	return ValidateFileType(req, config)
 }
+
+// MaxUploadSize returns the largest accepted file of the given type, or 0 for unknown types
+func (s *FileService) MaxUploadSize(fileType model.FileType) int64 {
+	config := DefaultFileValidationConfig()
+	switch fileType {
+	case model.FileTypeProfileImage:
+		return config.MaxProfileImageSize
+	case model.FileTypeCV:
+		return config.MaxCVSize
+	default:
+		return 0
+	}
+}
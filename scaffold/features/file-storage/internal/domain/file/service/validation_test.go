@@ -1,7 +1,7 @@
 package service
 
 import (
-	"go_platform_template/internal/domain/file/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/model"
 	"testing"
 )
 
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/model"
+)
+
+var (
+	// ErrInvalidVisibility is returned by SetVisibility for values other than public and
+	// private
+	ErrInvalidVisibility = errors.New("visibility must be public or private")
+	// ErrPublicFileNotFound is returned by GetPublicFile for files that do not exist or
+	// are private; the two are not told apart
+	ErrPublicFileNotFound = errors.New("public file not found")
+)
+
+// SetVisibility makes file public or private and returns it updated
+func (s *FileService) SetVisibility(ctx context.Context, file *model.File, visibility model.Visibility) (*model.File, error) {
+	if visibility != model.VisibilityPublic && visibility != model.VisibilityPrivate {
+		return nil, ErrInvalidVisibility
+	}
+	if err := s.repo.SetVisibility(ctx, file.ID, visibility); err != nil {
+		return nil, err
+	}
+	updated := *file
+	updated.Visibility = visibility
+	return &updated, nil
+}
+
+// PublicURL returns the stable URL of a public file, FILE_PUBLIC_BASE_URL followed by its
+// ID; it keeps working when the file is moved
+func (s *FileService) PublicURL(file *model.File) string {
+	return s.publicBaseURL + "/" + file.ID.String()
+}
+
+// PublicMaxAge is how long browsers and CDNs may cache public files
+func (s *FileService) PublicMaxAge() time.Duration {
+	return s.publicMaxAge
+}
+
+// FileURL returns the URL to hand out for file: its public URL when it is public,
+// otherwise a signed URL valid for expiry
+func (s *FileService) FileURL(ctx context.Context, file *model.File, expiry time.Duration) (string, error) {
+	if file.Visibility == model.VisibilityPublic {
+		return s.PublicURL(file), nil
+	}
+	return s.GetSignedURL(ctx, file.Path, expiry)
+}
+
+// GetPublicFile returns the file with the given ID if it is public
+func (s *FileService) GetPublicFile(ctx context.Context, id string) (*model.File, error) {
+	file, err := s.repo.GetFileByID(ctx, id)
+	if err != nil || file.Visibility != model.VisibilityPublic {
+		return nil, ErrPublicFileNotFound
+	}
+	return file, nil
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/file/model"
+
+	"github.com/google/uuid"
+)
+
+func TestFileService_SetVisibility(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	owner := uuid.New()
+	svc, files, file := newFolderTestService(t, owner)
+	svc.publicBaseURL = "https://cdn.example.com/files"
+	file.Visibility = model.VisibilityPrivate
+
+	// Act
+	public, err := svc.SetVisibility(ctx, file, model.VisibilityPublic)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("SetVisibility() error = %v", err)
+	}
+	if files.files[file.ID].Visibility != model.VisibilityPublic {
+		t.Errorf("stored visibility = %q, want public", files.files[file.ID].Visibility)
+	}
+	if url, err := svc.FileURL(ctx, public, 0); err != nil || url != "https://cdn.example.com/files/"+file.ID.String() {
+		t.Errorf("FileURL() of a public file = %q, %v, want the stable URL", url, err)
+	}
+	if _, err := svc.GetPublicFile(ctx, file.ID.String()); err != nil {
+		t.Errorf("GetPublicFile() error = %v", err)
+	}
+	if url, err := svc.FileURL(ctx, file, 0); err != nil || !strings.HasPrefix(url, "/api/v1/files/raw/") {
+		t.Errorf("FileURL() of a private file = %q, %v, want a signed URL", url, err)
+	}
+}
+
+func TestFileService_GetPublicFile_Private(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	svc, _, file := newFolderTestService(t, uuid.New())
+	if _, err := svc.SetVisibility(ctx, file, model.VisibilityPrivate); err != nil {
+		t.Fatalf("SetVisibility() error = %v", err)
+	}
+
+	// Act
+	_, privateErr := svc.GetPublicFile(ctx, file.ID.String())
+	_, unknownErr := svc.GetPublicFile(ctx, uuid.NewString())
+	_, invalidErr := svc.SetVisibility(ctx, file, "friends")
+
+	// Assert
+	if !errors.Is(privateErr, ErrPublicFileNotFound) || !errors.Is(unknownErr, ErrPublicFileNotFound) {
+		t.Errorf("GetPublicFile() of private and unknown files error = %v, %v, want ErrPublicFileNotFound", privateErr, unknownErr)
+	}
+	if !errors.Is(invalidErr, ErrInvalidVisibility) {
+		t.Errorf("SetVisibility(friends) error = %v, want ErrInvalidVisibility", invalidErr)
+	}
+}
@@ -0,0 +1,229 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/oidc/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/oidc/service"
+	userModel "github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Authenticator checks a user's credentials; code is the SMS two-factor code, if any
+type Authenticator func(ctx context.Context, emailOrUsername, password, code string) (*userModel.User, error)
+
+// OIDCHandler serves the OpenID Connect provider endpoints. Responses follow the OAuth 2.0
+// and OpenID Connect specifications rather than the API's response envelope.
+type OIDCHandler struct {
+	service      service.Service
+	authenticate Authenticator
+	logger       *zap.SugaredLogger
+}
+
+func NewOIDCHandler(s service.Service, authenticate Authenticator, logger *zap.SugaredLogger) *OIDCHandler {
+	return &OIDCHandler{service: s, authenticate: authenticate, logger: logger}
+}
+
+// loginForm is the credential part of the sign-in form
+type loginForm struct {
+	EmailOrUsername string `form:"email_or_username"`
+	Password        string `form:"password"`
+	OTP             string `form:"otp"`
+}
+
+// Discovery godoc
+// @Summary OpenID Provider metadata
+// @Tags OIDC
+// @Produce json
+// @Success 200 {object} dto.Discovery
+// @Router /.well-known/openid-configuration [get]
+func (h *OIDCHandler) Discovery(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.Discovery())
+}
+
+// AuthorizeForm godoc
+// @Summary Start an OpenID Connect sign-in
+// @Description Validates the authentication request and shows the sign-in form. Errors other than an unknown client or redirect URI are sent to the redirect URI.
+// @Tags OIDC
+// @Produce html
+// @Param response_type query string true "Must be code"
+// @Param client_id query string true "Registered client ID"
+// @Param redirect_uri query string true "Registered redirect URI"
+// @Param scope query string true "Space separated, must include openid"
+// @Param state query string false "Returned unchanged to the client"
+// @Param nonce query string false "Copied into the ID token"
+// @Param code_challenge query string false "PKCE challenge, required for public clients"
+// @Param code_challenge_method query string false "Must be S256"
+// @Success 200 "Sign-in form"
+// @Failure 302 "Redirect to the client with an error"
+// @Failure 400 {object} response.ErrorResponse "Unknown client or redirect URI"
+// @Router /oauth2/authorize [get]
+func (h *OIDCHandler) AuthorizeForm(c *gin.Context) {
+	var req dto.AuthorizeRequest
+	_ = c.ShouldBindQuery(&req)
+
+	clientName, err := h.service.ValidateAuthorize(&req)
+	if err != nil {
+		h.authorizeError(c, &req, err)
+		return
+	}
+	h.renderLogin(c, http.StatusOK, clientName, &req, loginForm{}, "", false)
+}
+
+// Authorize godoc
+// @Summary Sign in and authorize
+// @Description Checks the credentials from the sign-in form and redirects to the client with an authorization code.
+// @Tags OIDC
+// @Accept x-www-form-urlencoded
+// @Produce html
+// @Success 302 "Redirect to the client with a code"
+// @Failure 401 "Sign-in form with an error"
+// @Router /oauth2/authorize [post]
+func (h *OIDCHandler) Authorize(c *gin.Context) {
+	var req dto.AuthorizeRequest
+	var form loginForm
+	_ = c.ShouldBind(&req)
+	_ = c.ShouldBind(&form)
+
+	clientName, err := h.service.ValidateAuthorize(&req)
+	if err != nil {
+		h.authorizeError(c, &req, err)
+		return
+	}
+
+	ctx := actor.WithClientIP(c.Request.Context(), c.ClientIP())
+	user, err := h.authenticate(ctx, form.EmailOrUsername, form.Password, form.OTP)
+	if err != nil {
+		message, askOTP := "Sign-in failed, please try again", form.OTP != ""
+		if appErr, ok := apperrors.IsAppError(err); ok {
+			message = appErr.Message
+			askOTP = askOTP || strings.HasPrefix(appErr.Details, "otp_required")
+		}
+		h.renderLogin(c, http.StatusUnauthorized, clientName, &req, form, message, askOTP)
+		return
+	}
+
+	redirect, err := h.service.IssueCode(c.Request.Context(), &req, user.ID)
+	if err != nil {
+		h.authorizeError(c, &req, err)
+		return
+	}
+	c.Redirect(http.StatusFound, redirect)
+}
+
+// Token godoc
+// @Summary Redeem an authorization code
+// @Description Exchanges an authorization code for an access token and an ID token. Confidential clients authenticate with HTTP Basic or client_secret; public clients send code_verifier.
+// @Tags OIDC
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "Must be authorization_code"
+// @Param code formData string true "Authorization code"
+// @Param redirect_uri formData string true "Redirect URI of the authorization request"
+// @Param client_id formData string false "Client ID when not using HTTP Basic"
+// @Param client_secret formData string false "Client secret when not using HTTP Basic"
+// @Param code_verifier formData string false "PKCE verifier"
+// @Success 200 {object} dto.TokenResponse
+// @Failure 400 "OAuth error response"
+// @Failure 401 "invalid_client"
+// @Router /oauth2/token [post]
+func (h *OIDCHandler) Token(c *gin.Context) {
+	var req dto.TokenRequest
+	_ = c.ShouldBind(&req)
+	if id, secret, ok := c.Request.BasicAuth(); ok {
+		// Basic credentials are form-encoded (RFC 6749 section 2.3.1)
+		req.ClientID, _ = url.QueryUnescape(id)
+		req.ClientSecret, _ = url.QueryUnescape(secret)
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	resp, err := h.service.Exchange(c.Request.Context(), &req)
+	if err != nil {
+		var oauthErr *service.Error
+		if !errors.As(err, &oauthErr) {
+			_ = c.Error(err)
+			return
+		}
+		status := http.StatusBadRequest
+		if oauthErr.Code == "invalid_client" {
+			status = http.StatusUnauthorized
+			c.Header("WWW-Authenticate", `Basic realm="oauth2"`)
+		}
+		h.logger.Warnw("oidc token request rejected", "client_id", req.ClientID, "error", oauthErr.Code)
+		c.JSON(status, gin.H{"error": oauthErr.Code, "error_description": oauthErr.Description})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// UserInfo godoc
+// @Summary Claims about the signed-in user
+// @Description Returns the claims allowed by the scopes of the access token.
+// @Tags OIDC
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 "invalid_token"
+// @Router /oauth2/userinfo [get]
+func (h *OIDCHandler) UserInfo(c *gin.Context) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		c.Header("WWW-Authenticate", `Bearer realm="oauth2"`)
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+
+	claims, err := h.service.UserInfo(c.Request.Context(), token)
+	if err != nil {
+		var oauthErr *service.Error
+		if !errors.As(err, &oauthErr) {
+			_ = c.Error(err)
+			return
+		}
+		c.Header("WWW-Authenticate", `Bearer error="`+oauthErr.Code+`", error_description="`+oauthErr.Description+`"`)
+		c.AbortWithStatus(http.StatusUnauthorized)
+		return
+	}
+	c.JSON(http.StatusOK, claims)
+}
+
+// authorizeError sends protocol errors back to the client; an unknown client or redirect
+// URI is shown here instead, since redirecting to it could leak the response
+func (h *OIDCHandler) authorizeError(c *gin.Context, req *dto.AuthorizeRequest, err error) {
+	var oauthErr *service.Error
+	if !errors.As(err, &oauthErr) {
+		h.logger.Warnw("oidc authorization request rejected", "client_id", req.ClientID, "error", err)
+		_ = c.Error(err)
+		return
+	}
+	c.Redirect(http.StatusFound, service.RedirectURL(req.RedirectURI, url.Values{
+		"error":             {oauthErr.Code},
+		"error_description": {oauthErr.Description},
+	}, req.State))
+}
+
+func (h *OIDCHandler) renderLogin(c *gin.Context, status int, clientName string, req *dto.AuthorizeRequest, form loginForm, message string, askOTP bool) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Frame-Options", "DENY")
+	c.Header("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors 'none'")
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(status)
+	if err := loginPage.Execute(c.Writer, gin.H{
+		"ClientName":      clientName,
+		"Request":         req,
+		"EmailOrUsername": form.EmailOrUsername,
+		"Error":           message,
+		"AskOTP":          askOTP,
+	}); err != nil {
+		h.logger.Errorw("failed to render sign-in page", "error", err)
+	}
+}
@@ -0,0 +1,68 @@
+package dto
+
+import "github.com/MurtadaNazar/go-api-template/internal/shared/jwk"
+
+// AuthorizeRequest is an OpenID Connect authentication request, sent as query
+// parameters to GET /oauth2/authorize and repeated as form fields by the login page
+type AuthorizeRequest struct {
+	ResponseType        string `form:"response_type"`
+	ClientID            string `form:"client_id"`
+	RedirectURI         string `form:"redirect_uri"`
+	Scope               string `form:"scope"`
+	State               string `form:"state"`
+	Nonce               string `form:"nonce"`
+	CodeChallenge       string `form:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method"`
+}
+
+// TokenRequest is an authorization code grant sent to POST /oauth2/token. The client
+// authenticates with HTTP Basic or the client_id/client_secret fields.
+type TokenRequest struct {
+	GrantType    string `form:"grant_type"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+	CodeVerifier string `form:"code_verifier"`
+}
+
+// TokenResponse is returned by the token endpoint
+// swagger:model
+type TokenResponse struct {
+	// Access token for the userinfo endpoint
+	AccessToken string `json:"access_token"`
+	// Signed ID token describing the user
+	IDToken string `json:"id_token"`
+	// Example: Bearer
+	TokenType string `json:"token_type" example:"Bearer"`
+	// Lifetime of both tokens in seconds
+	// Example: 900
+	ExpiresIn int64 `json:"expires_in" example:"900"`
+	// Granted scopes, space separated
+	// Example: openid email profile
+	Scope string `json:"scope" example:"openid email profile"`
+}
+
+// Discovery is the OpenID Provider metadata served at /.well-known/openid-configuration
+// swagger:model
+type Discovery struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+// JWK is a public key in JSON Web Key form
+type JWK = jwk.Key
+
+// JWKS is the key set served at /.well-known/jwks.json
+type JWKS = jwk.Set
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/shared/id"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Scopes this provider understands; others are dropped from requests
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+)
+
+// SupportedScopes lists the scopes advertised in the discovery document
+var SupportedScopes = []string{ScopeOpenID, ScopeProfile, ScopeEmail}
+
+// AuthCode is a hashed, single-use authorization code issued after a user signs in
+type AuthCode struct {
+	// ID is the unique identifier for the code record
+	// format: uuid
+	// readOnly: true
+	ID uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" json:"id"`
+
+	// CodeHash is the SHA-256 hash of the code; the code itself is never stored
+	// writeOnly: true
+	CodeHash string `gorm:"size:64;not null;uniqueIndex" json:"-"`
+
+	// ClientID of the application the code was issued to
+	ClientID string `gorm:"size:100;not null" json:"client_id"`
+
+	// UserID is the UUID of the user who signed in
+	// format: uuid
+	UserID uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
+
+	// RedirectURI the code was sent to; the token request must repeat it
+	RedirectURI string `gorm:"size:2048;not null" json:"redirect_uri"`
+
+	// Scope granted, space separated
+	Scope string `gorm:"size:255;not null" json:"scope"`
+
+	// Nonce from the authorization request, copied into the ID token
+	Nonce string `gorm:"size:255" json:"nonce,omitempty"`
+
+	// CodeChallenge is the PKCE S256 challenge, if the client sent one
+	CodeChallenge string `gorm:"size:128" json:"-"`
+
+	// ExpiresAt indicates when the code becomes invalid
+	// format: date-time
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+
+	// CreatedAt indicates when the code was issued
+	// format: date-time
+	// readOnly: true
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BeforeCreate hook to generate UUID before inserting
+func (c *AuthCode) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = id.New()
+	}
+	return nil
+}
+
+// TableName overrides the default table name
+func (AuthCode) TableName() string {
+	return "oidc_auth_codes"
+}
+
+// HasScope reports whether scope was granted with the code
+func (c *AuthCode) HasScope(scope string) bool {
+	for _, s := range strings.Fields(c.Scope) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/oidc/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CodeRepo interface {
+	Create(ctx context.Context, code *model.AuthCode) error
+	Consume(ctx context.Context, codeHash string) (*model.AuthCode, error)
+	DeleteExpired(ctx context.Context) error
+}
+
+type codeRepo struct {
+	db *gorm.DB
+}
+
+func NewCodeRepo(db *gorm.DB) CodeRepo {
+	return &codeRepo{db: db}
+}
+
+func (r *codeRepo) Create(ctx context.Context, code *model.AuthCode) error {
+	return r.db.WithContext(ctx).Create(code).Error
+}
+
+// Consume deletes the code with the given hash and returns it, or nil if there is none.
+// Deleting and reading in one statement makes a code usable once even under concurrent
+// token requests.
+func (r *codeRepo) Consume(ctx context.Context, codeHash string) (*model.AuthCode, error) {
+	var codes []model.AuthCode
+	result := r.db.WithContext(ctx).
+		Clauses(clause.Returning{}).
+		Where("code_hash = ?", codeHash).
+		Delete(&codes)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if len(codes) == 0 {
+		return nil, nil
+	}
+	return &codes[0], nil
+}
+
+func (r *codeRepo) DeleteExpired(ctx context.Context) error {
+	return r.db.WithContext(ctx).Where("expires_at < ?", time.Now()).
+		Delete(&model.AuthCode{}).Error
+}
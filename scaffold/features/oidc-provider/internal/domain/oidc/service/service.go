@@ -0,0 +1,364 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/oidc/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/oidc/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/oidc/repo"
+	userModel "github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	userRepo "github.com/MurtadaNazar/go-api-template/internal/domain/user/repo"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/clock"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+)
+
+// accessTokenType marks access tokens so an ID token cannot be replayed at userinfo (RFC 9068)
+const accessTokenType = "at+jwt"
+
+// Error is an OAuth 2.0 protocol error such as "invalid_grant" (RFC 6749 section 5.2)
+type Error struct {
+	Code        string
+	Description string
+}
+
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+// Service lets other applications sign users in with accounts from the user store
+type Service interface {
+	Discovery() dto.Discovery
+	JWKS() dto.JWKS
+	// ValidateAuthorize checks a request before the user signs in, drops unsupported
+	// scopes and returns the client's display name. An unknown client or redirect URI is
+	// an *apperrors.AppError and must not be redirected to; other problems are an *Error
+	// for the client's redirect URI.
+	ValidateAuthorize(req *dto.AuthorizeRequest) (string, error)
+	// IssueCode creates an authorization code for the signed-in user and returns the
+	// client redirect URI carrying it
+	IssueCode(ctx context.Context, req *dto.AuthorizeRequest, userID uuid.UUID) (string, error)
+	// Exchange redeems an authorization code for an access token and an ID token
+	Exchange(ctx context.Context, req *dto.TokenRequest) (*dto.TokenResponse, error)
+	// UserInfo returns the claims of the user an access token was issued for
+	UserInfo(ctx context.Context, accessToken string) (map[string]interface{}, error)
+	CleanupExpiredCodes(ctx context.Context) error
+}
+
+type oidcService struct {
+	codes   repo.CodeRepo
+	users   userRepo.UserRepo
+	key     *SigningKey
+	cfg     config.OIDCConfig
+	clients map[string]config.OIDCClient
+	clock   clock.Clock
+	logger  *zap.SugaredLogger
+}
+
+// ServiceOption customizes a Service created by NewService
+type ServiceOption func(*oidcService)
+
+// WithClock replaces the time source used for code and token expiry
+func WithClock(c clock.Clock) ServiceOption {
+	return func(s *oidcService) {
+		s.clock = c
+	}
+}
+
+func NewService(codes repo.CodeRepo, users userRepo.UserRepo, key *SigningKey, cfg config.OIDCConfig, logger *zap.SugaredLogger, opts ...ServiceOption) Service {
+	if logger == nil {
+		logger = zap.NewNop().Sugar()
+	}
+	s := &oidcService{
+		codes:   codes,
+		users:   users,
+		key:     key,
+		cfg:     cfg,
+		clients: make(map[string]config.OIDCClient, len(cfg.Clients)),
+		clock:   clock.System(),
+		logger:  logger,
+	}
+	for _, c := range cfg.Clients {
+		s.clients[c.ID] = c
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *oidcService) Discovery() dto.Discovery {
+	return dto.Discovery{
+		Issuer:                            s.cfg.Issuer,
+		AuthorizationEndpoint:             s.cfg.Issuer + "/oauth2/authorize",
+		TokenEndpoint:                     s.cfg.Issuer + "/oauth2/token",
+		UserinfoEndpoint:                  s.cfg.Issuer + "/oauth2/userinfo",
+		JWKSURI:                           s.cfg.Issuer + "/.well-known/jwks.json",
+		ScopesSupported:                   model.SupportedScopes,
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{"authorization_code"},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{"RS256"},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+		ClaimsSupported: []string{
+			"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "email",
+			"name", "given_name", "middle_name", "family_name", "preferred_username", "zoneinfo", "locale", "updated_at",
+		},
+	}
+}
+
+func (s *oidcService) JWKS() dto.JWKS {
+	return dto.JWKS{Keys: []dto.JWK{s.key.JWK()}}
+}
+
+func (s *oidcService) ValidateAuthorize(req *dto.AuthorizeRequest) (string, error) {
+	client, ok := s.clients[req.ClientID]
+	if !ok {
+		return "", apperrors.NewAppError(apperrors.BadRequestError, "Unknown client_id")
+	}
+	if !slices.Contains(client.RedirectURIs, req.RedirectURI) {
+		return "", apperrors.NewAppError(apperrors.BadRequestError, "redirect_uri is not registered for this client")
+	}
+
+	if req.ResponseType != "code" {
+		return "", &Error{Code: "unsupported_response_type", Description: "only the authorization code flow (response_type=code) is supported"}
+	}
+	var scopes []string
+	for _, scope := range strings.Fields(req.Scope) {
+		if slices.Contains(model.SupportedScopes, scope) && !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	if !slices.Contains(scopes, model.ScopeOpenID) {
+		return "", &Error{Code: "invalid_scope", Description: "the openid scope is required"}
+	}
+	req.Scope = strings.Join(scopes, " ")
+
+	if req.CodeChallenge != "" && req.CodeChallengeMethod != "S256" {
+		return "", &Error{Code: "invalid_request", Description: "code_challenge_method must be S256"}
+	}
+	if client.Secret == "" && req.CodeChallenge == "" {
+		return "", &Error{Code: "invalid_request", Description: "public clients must use PKCE (code_challenge)"}
+	}
+	if client.Name != "" {
+		return client.Name, nil
+	}
+	return client.ID, nil
+}
+
+func (s *oidcService) IssueCode(ctx context.Context, req *dto.AuthorizeRequest, userID uuid.UUID) (string, error) {
+	if _, err := s.ValidateAuthorize(req); err != nil {
+		return "", err
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	code := base64.RawURLEncoding.EncodeToString(raw)
+	if err := s.codes.Create(ctx, &model.AuthCode{
+		CodeHash:      hashCode(code),
+		ClientID:      req.ClientID,
+		UserID:        userID,
+		RedirectURI:   req.RedirectURI,
+		Scope:         req.Scope,
+		Nonce:         req.Nonce,
+		CodeChallenge: req.CodeChallenge,
+		ExpiresAt:     s.clock.Now().Add(s.cfg.CodeTTL),
+	}); err != nil {
+		s.logger.Errorw("failed to store authorization code", "client_id", req.ClientID, "error", err)
+		return "", apperrors.NewAppError(apperrors.InternalError, "Failed to complete sign-in")
+	}
+
+	s.logger.Infow("oidc authorization code issued", "client_id", req.ClientID, "user_id", userID)
+	return RedirectURL(req.RedirectURI, url.Values{"code": {code}}, req.State), nil
+}
+
+func (s *oidcService) Exchange(ctx context.Context, req *dto.TokenRequest) (*dto.TokenResponse, error) {
+	if req.GrantType != "authorization_code" {
+		return nil, &Error{Code: "unsupported_grant_type", Description: "only authorization_code is supported"}
+	}
+	client, ok := s.clients[req.ClientID]
+	if !ok || (client.Secret != "" && subtle.ConstantTimeCompare([]byte(client.Secret), []byte(req.ClientSecret)) != 1) {
+		return nil, &Error{Code: "invalid_client", Description: "client authentication failed"}
+	}
+
+	code, err := s.codes.Consume(ctx, hashCode(req.Code))
+	if err != nil {
+		s.logger.Errorw("failed to redeem authorization code", "client_id", client.ID, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to redeem authorization code")
+	}
+	invalid := &Error{Code: "invalid_grant", Description: "authorization code is invalid, expired or already used"}
+	if code == nil || !code.ExpiresAt.After(s.clock.Now()) || code.ClientID != client.ID {
+		return nil, invalid
+	}
+	if code.RedirectURI != req.RedirectURI {
+		return nil, &Error{Code: "invalid_grant", Description: "redirect_uri does not match the authorization request"}
+	}
+	if code.CodeChallenge != "" && !verifyPKCE(code.CodeChallenge, req.CodeVerifier) {
+		return nil, &Error{Code: "invalid_grant", Description: "code_verifier does not match the code_challenge"}
+	}
+
+	user, err := s.users.FindByID(ctx, code.UserID.String())
+	if err != nil {
+		s.logger.Errorw("failed to fetch user", "user_id", code.UserID, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to redeem authorization code")
+	}
+	if user == nil || !user.IsActive() {
+		return nil, invalid
+	}
+
+	now := s.clock.Now()
+	expires := now.Add(s.cfg.TokenTTL)
+	access, err := s.sign(jwt.MapClaims{
+		"iss":       s.cfg.Issuer,
+		"sub":       user.ID.String(),
+		"aud":       client.ID,
+		"client_id": client.ID,
+		"scope":     code.Scope,
+		"iat":       now.Unix(),
+		"exp":       expires.Unix(),
+		"jti":       uuid.NewString(),
+	}, accessTokenType)
+	if err != nil {
+		return nil, err
+	}
+
+	idClaims := jwt.MapClaims{
+		"iss":       s.cfg.Issuer,
+		"aud":       client.ID,
+		"azp":       client.ID,
+		"iat":       now.Unix(),
+		"exp":       expires.Unix(),
+		"auth_time": code.CreatedAt.Unix(),
+	}
+	if code.Nonce != "" {
+		idClaims["nonce"] = code.Nonce
+	}
+	for k, v := range userClaims(user, code.HasScope) {
+		idClaims[k] = v
+	}
+	idToken, err := s.sign(idClaims, "JWT")
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Infow("oidc tokens issued", "client_id", client.ID, "user_id", user.ID)
+	return &dto.TokenResponse{
+		AccessToken: access,
+		IDToken:     idToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.cfg.TokenTTL.Seconds()),
+		Scope:       code.Scope,
+	}, nil
+}
+
+func (s *oidcService) UserInfo(ctx context.Context, accessToken string) (map[string]interface{}, error) {
+	invalid := &Error{Code: "invalid_token", Description: "access token is invalid or expired"}
+	token, err := jwt.Parse(accessToken, func(t *jwt.Token) (interface{}, error) {
+		return &s.key.private.PublicKey, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithIssuer(s.cfg.Issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(s.clock.Now),
+	)
+	if err != nil || token.Header["typ"] != accessTokenType {
+		return nil, invalid
+	}
+	claims := token.Claims.(jwt.MapClaims)
+	sub, _ := claims["sub"].(string)
+	scope, _ := claims["scope"].(string)
+
+	user, err := s.users.FindByID(ctx, sub)
+	if err != nil {
+		s.logger.Errorw("failed to fetch user", "user_id", sub, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to fetch user info")
+	}
+	if user == nil || !user.IsActive() {
+		return nil, invalid
+	}
+	code := model.AuthCode{Scope: scope}
+	return userClaims(user, code.HasScope), nil
+}
+
+func (s *oidcService) CleanupExpiredCodes(ctx context.Context) error {
+	return s.codes.DeleteExpired(ctx)
+}
+
+func (s *oidcService) sign(claims jwt.MapClaims, typ string) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = s.key.id
+	token.Header["typ"] = typ
+	signed, err := token.SignedString(s.key.private)
+	if err != nil {
+		s.logger.Errorw("failed to sign token", "error", err)
+		return "", apperrors.NewAppError(apperrors.InternalError, "Failed to issue tokens")
+	}
+	return signed, nil
+}
+
+// userClaims returns the standard claims of user allowed by the granted scopes
+func userClaims(user *userModel.User, granted func(string) bool) map[string]interface{} {
+	claims := map[string]interface{}{"sub": user.ID.String()}
+	if granted(model.ScopeEmail) {
+		claims["email"] = user.Email
+	}
+	if granted(model.ScopeProfile) {
+		claims["name"] = user.FullName()
+		claims["given_name"] = user.FirstName
+		claims["family_name"] = user.LastName
+		claims["preferred_username"] = user.Username
+		claims["updated_at"] = user.UpdatedAt.Unix()
+		if user.SecondName != "" {
+			claims["middle_name"] = user.SecondName
+		}
+		if user.TimeZone != "" {
+			claims["zoneinfo"] = user.TimeZone
+		}
+		if user.Locale != "" {
+			claims["locale"] = user.Locale
+		}
+	}
+	return claims
+}
+
+// RedirectURL adds params and state to the client's redirect URI
+func RedirectURL(redirectURI string, params url.Values, state string) string {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+	q := u.Query()
+	for k, v := range params {
+		q[k] = v
+	}
+	if state != "" {
+		q.Set("state", state)
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func verifyPKCE(challenge, verifier string) bool {
+	sum := sha256.Sum256([]byte(verifier))
+	return subtle.ConstantTimeCompare([]byte(base64.RawURLEncoding.EncodeToString(sum[:])), []byte(challenge)) == 1
+}
+
+func hashCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/oidc/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/oidc/model"
+	userModel "github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	testVerifier    = "dBjftJeZ4CVP-mJ92K1ylsFolHRnbTGMfb2xE1wPsFg"
+	testRedirectURI = "https://app.example.com/callback"
+)
+
+// codeStore is an in-memory CodeRepo
+type codeStore struct {
+	mu    sync.Mutex
+	codes map[string]model.AuthCode
+}
+
+func (s *codeStore) Create(ctx context.Context, code *model.AuthCode) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.codes == nil {
+		s.codes = map[string]model.AuthCode{}
+	}
+	code.CreatedAt = time.Now()
+	s.codes[code.CodeHash] = *code
+	return nil
+}
+
+func (s *codeStore) Consume(ctx context.Context, codeHash string) (*model.AuthCode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	code, ok := s.codes[codeHash]
+	if !ok {
+		return nil, nil
+	}
+	delete(s.codes, codeHash)
+	return &code, nil
+}
+
+func (s *codeStore) DeleteExpired(ctx context.Context) error { return nil }
+
+// newTestService returns a Service with a public client "spa" and a confidential client "web"
+func newTestService(t *testing.T, user *userModel.User) (Service, *testutil.FakeClock) {
+	t.Helper()
+	key, err := GenerateSigningKey()
+	if err != nil {
+		t.Fatalf("GenerateSigningKey() error = %v", err)
+	}
+	users := &testutil.MockUserRepo{
+		FindByIDFn: func(ctx context.Context, id string) (*userModel.User, error) {
+			if id == user.ID.String() {
+				return user, nil
+			}
+			return nil, nil
+		},
+	}
+	cfg := config.OIDCConfig{
+		Issuer: "https://id.example.com",
+		Clients: []config.OIDCClient{
+			{ID: "spa", Name: "Single Page App", RedirectURIs: []string{testRedirectURI}},
+			{ID: "web", Secret: "s3cret", RedirectURIs: []string{testRedirectURI}},
+		},
+		CodeTTL:  time.Minute,
+		TokenTTL: 15 * time.Minute,
+	}
+	clk := testutil.NewFakeClock(time.Now())
+	return NewService(&codeStore{}, users, key, cfg, zap.NewNop().Sugar(), WithClock(clk)), clk
+}
+
+func challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// issueCode runs the authorization step for the public client and returns the code
+func issueCode(t *testing.T, s Service, user *userModel.User) string {
+	t.Helper()
+	req := &dto.AuthorizeRequest{
+		ResponseType:        "code",
+		ClientID:            "spa",
+		RedirectURI:         testRedirectURI,
+		Scope:               "openid email offline_access",
+		State:               "xyz",
+		Nonce:               "n-0S6",
+		CodeChallenge:       challenge(testVerifier),
+		CodeChallengeMethod: "S256",
+	}
+	redirect, err := s.IssueCode(context.Background(), req, user.ID)
+	if err != nil {
+		t.Fatalf("IssueCode() error = %v", err)
+	}
+	u, _ := url.Parse(redirect)
+	if u.Query().Get("state") != "xyz" || u.Query().Get("code") == "" {
+		t.Fatalf("IssueCode() redirect = %q, want code and state", redirect)
+	}
+	return u.Query().Get("code")
+}
+
+func TestOIDCService_CodeFlowWithPKCE(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	user := testutil.TestUser()
+	s, _ := newTestService(t, user)
+	code := issueCode(t, s, user)
+
+	// Act
+	resp, err := s.Exchange(ctx, &dto.TokenRequest{
+		GrantType:    "authorization_code",
+		Code:         code,
+		RedirectURI:  testRedirectURI,
+		ClientID:     "spa",
+		CodeVerifier: testVerifier,
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	if resp.Scope != "openid email" {
+		t.Errorf("Scope = %q, want unsupported scopes dropped", resp.Scope)
+	}
+	idClaims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(resp.IDToken, idClaims); err != nil {
+		t.Fatalf("ID token is not a JWT: %v", err)
+	}
+	if idClaims["sub"] != user.ID.String() || idClaims["aud"] != "spa" || idClaims["nonce"] != "n-0S6" || idClaims["email"] != user.Email {
+		t.Errorf("ID token claims = %v", idClaims)
+	}
+	if _, ok := idClaims["name"]; ok {
+		t.Error("ID token has profile claims without the profile scope")
+	}
+
+	info, err := s.UserInfo(ctx, resp.AccessToken)
+	if err != nil {
+		t.Fatalf("UserInfo() error = %v", err)
+	}
+	if info["sub"] != user.ID.String() || info["email"] != user.Email {
+		t.Errorf("UserInfo() = %v", info)
+	}
+}
+
+func TestOIDCService_ExchangeRejections(t *testing.T) {
+	cases := []struct {
+		name   string
+		mutate func(req *dto.TokenRequest)
+		want   string
+	}{
+		{"wrong verifier", func(req *dto.TokenRequest) { req.CodeVerifier = "not-the-verifier" }, "invalid_grant"},
+		{"wrong redirect uri", func(req *dto.TokenRequest) { req.RedirectURI = "https://evil.example.com/cb" }, "invalid_grant"},
+		{"other client", func(req *dto.TokenRequest) { req.ClientID, req.ClientSecret = "web", "s3cret" }, "invalid_grant"},
+		{"bad secret", func(req *dto.TokenRequest) { req.ClientID, req.ClientSecret = "web", "guess" }, "invalid_client"},
+		{"unknown code", func(req *dto.TokenRequest) { req.Code = "made-up" }, "invalid_grant"},
+		{"wrong grant type", func(req *dto.TokenRequest) { req.GrantType = "password" }, "unsupported_grant_type"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			user := testutil.TestUser()
+			s, _ := newTestService(t, user)
+			req := &dto.TokenRequest{
+				GrantType:    "authorization_code",
+				Code:         issueCode(t, s, user),
+				RedirectURI:  testRedirectURI,
+				ClientID:     "spa",
+				CodeVerifier: testVerifier,
+			}
+			tc.mutate(req)
+
+			// Act
+			_, err := s.Exchange(context.Background(), req)
+
+			// Assert
+			var oauthErr *Error
+			if !errors.As(err, &oauthErr) || oauthErr.Code != tc.want {
+				t.Errorf("Exchange() error = %v, want %s", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestOIDCService_CodeIsSingleUseAndExpires(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	user := testutil.TestUser()
+	s, clk := newTestService(t, user)
+	req := &dto.TokenRequest{GrantType: "authorization_code", Code: issueCode(t, s, user), RedirectURI: testRedirectURI, ClientID: "spa", CodeVerifier: testVerifier}
+	if _, err := s.Exchange(ctx, req); err != nil {
+		t.Fatalf("first Exchange() error = %v", err)
+	}
+	stale := *req
+	stale.Code = issueCode(t, s, user)
+	clk.Advance(2 * time.Minute)
+
+	// Act
+	_, replayErr := s.Exchange(ctx, req)
+	_, staleErr := s.Exchange(ctx, &stale)
+
+	// Assert
+	var oauthErr *Error
+	if !errors.As(replayErr, &oauthErr) || oauthErr.Code != "invalid_grant" {
+		t.Errorf("replayed code error = %v, want invalid_grant", replayErr)
+	}
+	if !errors.As(staleErr, &oauthErr) || oauthErr.Code != "invalid_grant" {
+		t.Errorf("expired code error = %v, want invalid_grant", staleErr)
+	}
+}
+
+func TestOIDCService_UserInfoRejectsIDToken(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	user := testutil.TestUser()
+	s, _ := newTestService(t, user)
+	resp, err := s.Exchange(ctx, &dto.TokenRequest{GrantType: "authorization_code", Code: issueCode(t, s, user), RedirectURI: testRedirectURI, ClientID: "spa", CodeVerifier: testVerifier})
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+
+	// Act
+	_, err = s.UserInfo(ctx, resp.IDToken)
+
+	// Assert
+	var oauthErr *Error
+	if !errors.As(err, &oauthErr) || oauthErr.Code != "invalid_token" {
+		t.Errorf("UserInfo(id_token) error = %v, want invalid_token", err)
+	}
+}
+
+func TestOIDCService_ValidateAuthorize(t *testing.T) {
+	cases := []struct {
+		name      string
+		mutate    func(req *dto.AuthorizeRequest)
+		wantApp   bool
+		wantOAuth string
+	}{
+		{"unknown client", func(req *dto.AuthorizeRequest) { req.ClientID = "other" }, true, ""},
+		{"unregistered redirect", func(req *dto.AuthorizeRequest) { req.RedirectURI = "https://evil.example.com/cb" }, true, ""},
+		{"missing openid scope", func(req *dto.AuthorizeRequest) { req.Scope = "email" }, false, "invalid_scope"},
+		{"implicit flow", func(req *dto.AuthorizeRequest) { req.ResponseType = "token" }, false, "unsupported_response_type"},
+		{"public client without pkce", func(req *dto.AuthorizeRequest) { req.CodeChallenge = "" }, false, "invalid_request"},
+		{"plain pkce", func(req *dto.AuthorizeRequest) { req.CodeChallengeMethod = "plain" }, false, "invalid_request"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			s, _ := newTestService(t, testutil.TestUser())
+			req := &dto.AuthorizeRequest{
+				ResponseType:        "code",
+				ClientID:            "spa",
+				RedirectURI:         testRedirectURI,
+				Scope:               "openid",
+				CodeChallenge:       challenge(testVerifier),
+				CodeChallengeMethod: "S256",
+			}
+			tc.mutate(req)
+
+			// Act
+			_, err := s.ValidateAuthorize(req)
+
+			// Assert
+			_, isApp := apperrors.IsAppError(err)
+			var oauthErr *Error
+			switch {
+			case tc.wantApp && !isApp:
+				t.Errorf("ValidateAuthorize() error = %v, want an AppError that is not redirected", err)
+			case !tc.wantApp && (!errors.As(err, &oauthErr) || oauthErr.Code != tc.wantOAuth):
+				t.Errorf("ValidateAuthorize() error = %v, want %s", err, tc.wantOAuth)
+			}
+		})
+	}
+}
+
+func TestRedirectURL_KeepsExistingQuery(t *testing.T) {
+	got := RedirectURL("https://app.example.com/cb?tenant=a", url.Values{"code": {"abc"}}, "s 1")
+
+	if !strings.HasPrefix(got, "https://app.example.com/cb?") {
+		t.Fatalf("RedirectURL() = %q", got)
+	}
+	u, _ := url.Parse(got)
+	if q := u.Query(); q.Get("tenant") != "a" || q.Get("code") != "abc" || q.Get("state") != "s 1" {
+		t.Errorf("RedirectURL() query = %v", q)
+	}
+}
@@ -0,0 +1,13 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/activity/model"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/examples"
+)
+
+// Examples are the bodies of the activity feed routes, served under /docs/examples
+var Examples = []examples.Route{
+	{Method: http.MethodGet, Path: "/me/activity", Response: []model.Activity{}},
+}
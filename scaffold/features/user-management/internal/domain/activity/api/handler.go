@@ -0,0 +1,61 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/activity/service"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ActivityHandler struct {
+	service *service.Service
+	logger  *zap.SugaredLogger
+}
+
+func NewActivityHandler(s *service.Service, logger *zap.SugaredLogger) *ActivityHandler {
+	return &ActivityHandler{service: s, logger: logger}
+}
+
+// ListMine godoc
+// @Summary Get the caller's activity feed
+// @Description Significant events on the caller's account, newest first: profile updates (with the names of the changed fields), password changes and file uploads. actor_id is set when someone else, such as an admin, caused the event.
+// @Tags Users
+// @Security BearerAuth
+// @Produce json
+// @Param offset query int false "Offset for pagination"
+// @Param limit query int false "Limit for pagination (1-100, default 20)"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Router /me/activity [get]
+func (h *ActivityHandler) ListMine(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+
+	offset := 0
+	limit := 20
+	if v := c.Query("offset"); v != "" {
+		if _, err := fmt.Sscan(v, &offset); err != nil {
+			_ = c.Error(apperrors.NewAppErrorWithDetails(apperrors.BadRequestError, "Invalid offset value", err.Error()))
+			return
+		}
+	}
+	if v := c.Query("limit"); v != "" {
+		if _, err := fmt.Sscan(v, &limit); err != nil {
+			_ = c.Error(apperrors.NewAppErrorWithDetails(apperrors.BadRequestError, "Invalid limit value", err.Error()))
+			return
+		}
+	}
+
+	activities, err := h.service.List(c.Request.Context(), c.GetString("userID"), offset, limit)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.Render(http.StatusOK, response.PooledJSON{Data: response.NewSuccessResponse(activities, requestID)})
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/platform/events"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func TestService_RecordAndList(t *testing.T) {
+	// Arrange
+	repo := &testutil.MockActivityRepo{}
+	clk := testutil.NewFakeClock(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+	svc := NewService(repo, 0, zap.NewNop().Sugar())
+	svc.SetClock(clk)
+	bus := events.NewBus()
+	svc.Subscribe(bus)
+	user, admin := uuid.New(), uuid.New()
+	self := actor.WithUserID(context.Background(), user.String())
+
+	// Act
+	bus.Publish(self, events.Event{Type: events.PasswordChanged, UserID: user.String()})
+	clk.Advance(time.Minute)
+	bus.Publish(actor.WithUserID(context.Background(), admin.String()),
+		events.Event{Type: events.ProfileUpdated, UserID: user.String(), Data: map[string]any{"fields": []string{"email"}}})
+	bus.Publish(self, events.Event{Type: "something.else", UserID: user.String()})
+	bus.Publish(self, events.Event{Type: events.FileUploaded, UserID: uuid.NewString()})
+	feed, err := svc.List(context.Background(), user.String(), 0, 20)
+
+	// Assert
+	if err != nil || len(feed) != 2 {
+		t.Fatalf("List() = %d activities, %v; want 2", len(feed), err)
+	}
+	if feed[0].Type != events.ProfileUpdated || feed[0].ActorID == nil || *feed[0].ActorID != admin {
+		t.Errorf("newest = %+v, want the profile update by the admin", feed[0])
+	}
+	if feed[1].Type != events.PasswordChanged || feed[1].ActorID != nil {
+		t.Errorf("oldest = %+v, want the user's own password change without an actor", feed[1])
+	}
+}
+
+func TestService_AnonymizedAccountAndCleanup(t *testing.T) {
+	// Arrange
+	repo := &testutil.MockActivityRepo{}
+	clk := testutil.NewFakeClock(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+	svc := NewService(repo, 24*time.Hour, zap.NewNop().Sugar())
+	svc.SetClock(clk)
+	ctx := context.Background()
+	deleted, kept := uuid.NewString(), uuid.NewString()
+	svc.Record(ctx, events.Event{Type: events.PasswordChanged, UserID: deleted})
+	svc.Record(ctx, events.Event{Type: events.PasswordChanged, UserID: kept})
+	clk.Advance(25 * time.Hour)
+	svc.Record(ctx, events.Event{Type: events.ProfileUpdated, UserID: kept})
+
+	// Act
+	svc.Record(ctx, events.Event{Type: events.AccountAnonymized, UserID: deleted})
+	err := svc.Cleanup(ctx)
+
+	// Assert
+	if err != nil || len(repo.Activities) != 1 || repo.Activities[0].Type != events.ProfileUpdated {
+		t.Errorf("left %+v, %v; want only the recent profile update", repo.Activities, err)
+	}
+}
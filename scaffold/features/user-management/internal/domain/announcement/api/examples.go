@@ -0,0 +1,17 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/announcement/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/announcement/model"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/examples"
+)
+
+// Examples are the bodies of the announcement routes, served under /docs/examples
+var Examples = []examples.Route{
+	{Method: http.MethodPost, Path: "/admin/announcements/", Request: dto.CreateAnnouncementRequest{}, Response: model.Announcement{}},
+	{Method: http.MethodGet, Path: "/admin/announcements/", Response: []model.Announcement{}},
+	{Method: http.MethodGet, Path: "/admin/announcements/{id}", Response: dto.AnnouncementResponse{}},
+	{Method: http.MethodGet, Path: "/admin/announcements/{id}/deliveries", Response: []model.Delivery{}},
+}
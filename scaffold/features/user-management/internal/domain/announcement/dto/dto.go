@@ -0,0 +1,34 @@
+package dto
+
+import (
+	"github.com/MurtadaNazar/go-api-template/internal/domain/announcement/model"
+)
+
+// CreateAnnouncementRequest composes an announcement for a segment of users
+// swagger:model CreateAnnouncementRequest
+type CreateAnnouncementRequest struct {
+	// example: Scheduled maintenance on Saturday
+	Subject string `json:"subject" validate:"required,max=200" example:"Scheduled maintenance on Saturday"`
+	// Plain text body; an unsubscribe link is appended
+	// example: The service will be unavailable from 02:00 to 04:00 UTC.
+	Body string `json:"body" validate:"required,max=20000" example:"The service will be unavailable from 02:00 to 04:00 UTC."`
+	// Segment selects the recipients; users who opted out are always left out
+	Segment model.Segment `json:"segment"`
+	// DryRun only counts the recipients without sending anything
+	DryRun bool `json:"dry_run"`
+}
+
+// RecipientsResponse is the number of users a dry run would send to
+// swagger:model AnnouncementRecipientsResponse
+type RecipientsResponse struct {
+	// example: 1200
+	Recipients int64 `json:"recipients" example:"1200"`
+}
+
+// AnnouncementResponse is an announcement with its deliveries counted by status
+// swagger:model AnnouncementResponse
+type AnnouncementResponse struct {
+	model.Announcement
+	// example: {"sent": 1180, "pending": 15, "failed": 3, "skipped": 2}
+	Deliveries map[model.DeliveryStatus]int64 `json:"deliveries" example:"sent:1180,pending:15,failed:3,skipped:2"`
+}
@@ -0,0 +1,117 @@
+package model
+
+import (
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/shared/id"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Status is where an announcement is in its delivery
+type Status string
+
+const (
+	// StatusQueued announcements wait for the delivery job
+	StatusQueued Status = "queued"
+	// StatusSending announcements have deliveries in progress
+	StatusSending Status = "sending"
+	// StatusCompleted announcements have no deliveries left to attempt
+	StatusCompleted Status = "completed"
+	// StatusCancelled announcements were stopped; undelivered recipients are not sent to
+	StatusCancelled Status = "cancelled"
+)
+
+// DeliveryStatus is the outcome for one recipient
+type DeliveryStatus string
+
+const (
+	DeliveryPending DeliveryStatus = "pending"
+	// DeliverySending deliveries are claimed by a worker
+	DeliverySending DeliveryStatus = "sending"
+	DeliverySent    DeliveryStatus = "sent"
+	// DeliveryFailed deliveries failed every attempt
+	DeliveryFailed DeliveryStatus = "failed"
+	// DeliverySkipped recipients opted out or were deleted before their turn
+	DeliverySkipped   DeliveryStatus = "skipped"
+	DeliveryCancelled DeliveryStatus = "cancelled"
+)
+
+// Segment selects the users an announcement goes to; an empty field matches everyone
+// swagger:model AnnouncementSegment
+type Segment struct {
+	// Roles (user types) to include
+	// example: ["user"]
+	Roles []string `json:"roles,omitempty" example:"user"`
+	// Account statuses to include; only active users when empty
+	// example: ["active"]
+	Statuses []string `json:"statuses,omitempty" validate:"omitempty,dive,oneof=active inactive suspended" example:"active"`
+	// Only users registered at or after this time
+	RegisteredAfter *time.Time `json:"registered_after,omitempty"`
+	// Only users registered before this time
+	RegisteredBefore *time.Time `json:"registered_before,omitempty"`
+}
+
+// Announcement is a message emailed by an admin to a segment of users
+// swagger:model Announcement
+type Announcement struct {
+	// format: uuid
+	ID uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+
+	// example: Scheduled maintenance on Saturday
+	Subject string `gorm:"type:varchar(200);not null" json:"subject" example:"Scheduled maintenance on Saturday"`
+
+	// Plain text body; an unsubscribe link is appended
+	Body string `gorm:"type:text;not null" json:"body"`
+
+	Segment Segment `gorm:"type:text;serializer:json" json:"segment"`
+
+	// example: sending
+	Status Status `gorm:"type:varchar(20);not null;index" json:"status" example:"sending"`
+
+	// Recipients is the number of users in the segment when the announcement was created
+	// example: 1200
+	Recipients int64 `gorm:"not null;default:0" json:"recipients" example:"1200"`
+
+	// CreatedBy is the admin who sent the announcement
+	// format: uuid
+	CreatedBy uuid.UUID `gorm:"type:uuid" json:"created_by"`
+
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// BeforeCreate is a GORM hook that generates a UUID for the announcement if not already set
+func (a *Announcement) BeforeCreate(tx *gorm.DB) (err error) {
+	if a.ID == uuid.Nil {
+		a.ID = id.New()
+	}
+	return
+}
+
+// TableName specifies the table name for the Announcement model
+func (Announcement) TableName() string {
+	return "announcements"
+}
+
+// Delivery is one recipient of an announcement. The address is copied when the
+// announcement is created, so later email changes do not move it.
+// swagger:model AnnouncementDelivery
+type Delivery struct {
+	AnnouncementID uuid.UUID      `gorm:"type:uuid;primaryKey" json:"announcement_id"`
+	UserID         uuid.UUID      `gorm:"type:uuid;primaryKey" json:"user_id"`
+	Email          string         `gorm:"type:varchar(100);not null" json:"email"`
+	Status         DeliveryStatus `gorm:"type:varchar(20);not null;index:idx_announcement_deliveries_status" json:"status"`
+	// Attempts counts failed sends
+	Attempts int `gorm:"not null;default:0" json:"attempts"`
+	// Error is the last send error
+	Error     string     `gorm:"type:varchar(512)" json:"error,omitempty"`
+	SentAt    *time.Time `json:"sent_at,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name for the Delivery model
+func (Delivery) TableName() string {
+	return "announcement_deliveries"
+}
@@ -0,0 +1,237 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/announcement/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AnnouncementRepo interface {
+	// CountRecipients counts the users in the segment who have not opted out
+	CountRecipients(ctx context.Context, segment model.Segment) (int64, error)
+	// Create stores the announcement with a pending delivery for every user in its segment
+	// who has not opted out, and sets Recipients to their number
+	Create(ctx context.Context, announcement *model.Announcement) error
+	// FindByID returns nil, nil when the announcement does not exist
+	FindByID(ctx context.Context, id uuid.UUID) (*model.Announcement, error)
+	// List returns announcements newest first
+	List(ctx context.Context, offset, limit int) ([]model.Announcement, error)
+	// Cancel stops a queued or sending announcement and cancels its pending deliveries; it
+	// reports false when the announcement had already finished
+	Cancel(ctx context.Context, id uuid.UUID, now time.Time) (bool, error)
+	// MarkSending moves queued announcements to sending
+	MarkSending(ctx context.Context, ids []uuid.UUID) error
+	// CompleteFinished completes announcements with no pending or sending deliveries left
+	CompleteFinished(ctx context.Context, now time.Time) (int64, error)
+
+	// DeliveryCounts counts an announcement's deliveries by status
+	DeliveryCounts(ctx context.Context, id uuid.UUID) (map[model.DeliveryStatus]int64, error)
+	// ListDeliveries pages through an announcement's deliveries, optionally of one status
+	ListDeliveries(ctx context.Context, id uuid.UUID, status model.DeliveryStatus, offset, limit int) ([]model.Delivery, error)
+	// ClaimDeliveries moves up to limit pending deliveries last updated before
+	// updatedBefore to sending, oldest first; rows claimed by another instance are skipped
+	ClaimDeliveries(ctx context.Context, updatedBefore time.Time, limit int, now time.Time) ([]model.Delivery, error)
+	// UpdateDelivery stores the outcome of a delivery attempt
+	UpdateDelivery(ctx context.Context, delivery *model.Delivery) error
+	// ReleaseStale returns deliveries claimed before the given time to pending
+	ReleaseStale(ctx context.Context, claimedBefore time.Time) (int64, error)
+	// Subscribed returns which of the users still exist and have not opted out
+	Subscribed(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]bool, error)
+}
+
+type announcementRepo struct {
+	db *gorm.DB
+}
+
+func NewAnnouncementRepo(db *gorm.DB) AnnouncementRepo {
+	return &announcementRepo{db: db}
+}
+
+// recipients scopes a users query to the segment, leaving out users who opted out
+func recipients(segment model.Segment) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where("announcements_opt_out = ?", false)
+		if len(segment.Roles) > 0 {
+			db = db.Where("user_type IN ?", segment.Roles)
+		}
+		if len(segment.Statuses) > 0 {
+			db = db.Where("status IN ?", segment.Statuses)
+		}
+		if segment.RegisteredAfter != nil {
+			db = db.Where("created_at >= ?", *segment.RegisteredAfter)
+		}
+		if segment.RegisteredBefore != nil {
+			db = db.Where("created_at < ?", *segment.RegisteredBefore)
+		}
+		return db
+	}
+}
+
+func (r *announcementRepo) CountRecipients(ctx context.Context, segment model.Segment) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Table("users").Scopes(recipients(segment)).Count(&count).Error
+	return count, err
+}
+
+func (r *announcementRepo) Create(ctx context.Context, announcement *model.Announcement) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(announcement).Error; err != nil {
+			return err
+		}
+		users := tx.Table("users").
+			Select("?, id, email, ?, 0, ?", announcement.ID, model.DeliveryPending, announcement.CreatedAt).
+			Scopes(recipients(announcement.Segment))
+		result := tx.Exec("INSERT INTO announcement_deliveries (announcement_id, user_id, email, status, attempts, updated_at) ?", users)
+		if result.Error != nil {
+			return result.Error
+		}
+		announcement.Recipients = result.RowsAffected
+		return tx.Model(announcement).Update("recipients", announcement.Recipients).Error
+	})
+}
+
+func (r *announcementRepo) FindByID(ctx context.Context, id uuid.UUID) (*model.Announcement, error) {
+	var announcement model.Announcement
+	err := r.db.WithContext(ctx).First(&announcement, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &announcement, nil
+}
+
+func (r *announcementRepo) List(ctx context.Context, offset, limit int) ([]model.Announcement, error) {
+	var announcements []model.Announcement
+	err := r.db.WithContext(ctx).
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&announcements).Error
+	return announcements, err
+}
+
+func (r *announcementRepo) Cancel(ctx context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	cancelled := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Announcement{}).
+			Where("id = ? AND status IN ?", id, []model.Status{model.StatusQueued, model.StatusSending}).
+			Updates(map[string]interface{}{"status": model.StatusCancelled, "completed_at": now})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		cancelled = true
+		return tx.Model(&model.Delivery{}).
+			Where("announcement_id = ? AND status = ?", id, model.DeliveryPending).
+			Updates(map[string]interface{}{"status": model.DeliveryCancelled, "updated_at": now}).Error
+	})
+	return cancelled, err
+}
+
+func (r *announcementRepo) MarkSending(ctx context.Context, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&model.Announcement{}).
+		Where("id IN ? AND status = ?", ids, model.StatusQueued).
+		Update("status", model.StatusSending).Error
+}
+
+func (r *announcementRepo) CompleteFinished(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&model.Announcement{}).
+		Where("status IN ?", []model.Status{model.StatusQueued, model.StatusSending}).
+		Where("NOT EXISTS (SELECT 1 FROM announcement_deliveries d WHERE d.announcement_id = announcements.id AND d.status IN ?)",
+			[]model.DeliveryStatus{model.DeliveryPending, model.DeliverySending}).
+		Updates(map[string]interface{}{"status": model.StatusCompleted, "completed_at": now})
+	return result.RowsAffected, result.Error
+}
+
+func (r *announcementRepo) DeliveryCounts(ctx context.Context, id uuid.UUID) (map[model.DeliveryStatus]int64, error) {
+	var rows []struct {
+		Status model.DeliveryStatus
+		Count  int64
+	}
+	err := r.db.WithContext(ctx).Model(&model.Delivery{}).
+		Select("status, COUNT(*) AS count").
+		Where("announcement_id = ?", id).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[model.DeliveryStatus]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *announcementRepo) ListDeliveries(ctx context.Context, id uuid.UUID, status model.DeliveryStatus, offset, limit int) ([]model.Delivery, error) {
+	query := r.db.WithContext(ctx).Where("announcement_id = ?", id)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var deliveries []model.Delivery
+	err := query.Order("email").Offset(offset).Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *announcementRepo) ClaimDeliveries(ctx context.Context, updatedBefore time.Time, limit int, now time.Time) ([]model.Delivery, error) {
+	var deliveries []model.Delivery
+	err := r.db.WithContext(ctx).Raw(`
+		UPDATE announcement_deliveries SET status = ?, updated_at = ?
+		WHERE (announcement_id, user_id) IN (
+			SELECT announcement_id, user_id FROM announcement_deliveries
+			WHERE status = ? AND updated_at < ?
+			ORDER BY updated_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		model.DeliverySending, now, model.DeliveryPending, updatedBefore, limit,
+	).Scan(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *announcementRepo) UpdateDelivery(ctx context.Context, delivery *model.Delivery) error {
+	return r.db.WithContext(ctx).Model(&model.Delivery{}).
+		Where("announcement_id = ? AND user_id = ?", delivery.AnnouncementID, delivery.UserID).
+		Updates(map[string]interface{}{
+			"status":     delivery.Status,
+			"attempts":   delivery.Attempts,
+			"error":      delivery.Error,
+			"sent_at":    delivery.SentAt,
+			"updated_at": delivery.UpdatedAt,
+		}).Error
+}
+
+func (r *announcementRepo) ReleaseStale(ctx context.Context, claimedBefore time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&model.Delivery{}).
+		Where("status = ? AND updated_at < ?", model.DeliverySending, claimedBefore).
+		UpdateColumn("status", model.DeliveryPending)
+	return result.RowsAffected, result.Error
+}
+
+func (r *announcementRepo) Subscribed(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	subscribed := make(map[uuid.UUID]bool, len(userIDs))
+	if len(userIDs) == 0 {
+		return subscribed, nil
+	}
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Table("users").
+		Where("id IN ? AND announcements_opt_out = ?", userIDs, false).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		subscribed[id] = true
+	}
+	return subscribed, nil
+}
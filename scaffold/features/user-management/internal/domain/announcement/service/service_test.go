@@ -0,0 +1,487 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/announcement/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/announcement/model"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/email"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var testConfig = config.AnnouncementConfig{
+	BatchSize:         2,
+	MaxAttempts:       2,
+	UnsubscribeURL:    "https://api.example.com/api/v1/announcements/unsubscribe",
+	UnsubscribeSecret: "test-secret",
+}
+
+type testUser struct {
+	id       uuid.UUID
+	email    string
+	role     string
+	status   string
+	optedOut bool
+}
+
+// announcementStore is an in-memory AnnouncementRepo over a fixed list of users
+type announcementStore struct {
+	mu            sync.Mutex
+	users         []testUser
+	announcements map[uuid.UUID]model.Announcement
+	deliveries    []model.Delivery
+}
+
+func (s *announcementStore) matches(u testUser, segment model.Segment) bool {
+	return !u.optedOut &&
+		(len(segment.Roles) == 0 || slices.Contains(segment.Roles, u.role)) &&
+		(len(segment.Statuses) == 0 || slices.Contains(segment.Statuses, u.status))
+}
+
+func (s *announcementStore) CountRecipients(ctx context.Context, segment model.Segment) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var count int64
+	for _, u := range s.users {
+		if s.matches(u, segment) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *announcementStore) Create(ctx context.Context, announcement *model.Announcement) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.announcements == nil {
+		s.announcements = map[uuid.UUID]model.Announcement{}
+	}
+	announcement.ID = uuid.New()
+	for _, u := range s.users {
+		if s.matches(u, announcement.Segment) {
+			s.deliveries = append(s.deliveries, model.Delivery{
+				AnnouncementID: announcement.ID,
+				UserID:         u.id,
+				Email:          u.email,
+				Status:         model.DeliveryPending,
+				UpdatedAt:      announcement.CreatedAt,
+			})
+			announcement.Recipients++
+		}
+	}
+	s.announcements[announcement.ID] = *announcement
+	return nil
+}
+
+func (s *announcementStore) FindByID(ctx context.Context, id uuid.UUID) (*model.Announcement, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	announcement, ok := s.announcements[id]
+	if !ok {
+		return nil, nil
+	}
+	return &announcement, nil
+}
+
+func (s *announcementStore) List(ctx context.Context, offset, limit int) ([]model.Announcement, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var announcements []model.Announcement
+	for _, a := range s.announcements {
+		announcements = append(announcements, a)
+	}
+	return announcements, nil
+}
+
+func (s *announcementStore) Cancel(ctx context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.announcements[id]
+	if a.Status != model.StatusQueued && a.Status != model.StatusSending {
+		return false, nil
+	}
+	a.Status, a.CompletedAt = model.StatusCancelled, &now
+	s.announcements[id] = a
+	for i, d := range s.deliveries {
+		if d.AnnouncementID == id && d.Status == model.DeliveryPending {
+			s.deliveries[i].Status = model.DeliveryCancelled
+		}
+	}
+	return true, nil
+}
+
+func (s *announcementStore) MarkSending(ctx context.Context, ids []uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range ids {
+		if a := s.announcements[id]; a.Status == model.StatusQueued {
+			a.Status = model.StatusSending
+			s.announcements[id] = a
+		}
+	}
+	return nil
+}
+
+func (s *announcementStore) CompleteFinished(ctx context.Context, now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var count int64
+	for id, a := range s.announcements {
+		if a.Status != model.StatusQueued && a.Status != model.StatusSending {
+			continue
+		}
+		if slices.ContainsFunc(s.deliveries, func(d model.Delivery) bool {
+			return d.AnnouncementID == id && (d.Status == model.DeliveryPending || d.Status == model.DeliverySending)
+		}) {
+			continue
+		}
+		a.Status, a.CompletedAt = model.StatusCompleted, &now
+		s.announcements[id] = a
+		count++
+	}
+	return count, nil
+}
+
+func (s *announcementStore) DeliveryCounts(ctx context.Context, id uuid.UUID) (map[model.DeliveryStatus]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := map[model.DeliveryStatus]int64{}
+	for _, d := range s.deliveries {
+		if d.AnnouncementID == id {
+			counts[d.Status]++
+		}
+	}
+	return counts, nil
+}
+
+func (s *announcementStore) ListDeliveries(ctx context.Context, id uuid.UUID, status model.DeliveryStatus, offset, limit int) ([]model.Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var deliveries []model.Delivery
+	for _, d := range s.deliveries {
+		if d.AnnouncementID == id && (status == "" || d.Status == status) {
+			deliveries = append(deliveries, d)
+		}
+	}
+	return deliveries, nil
+}
+
+func (s *announcementStore) ClaimDeliveries(ctx context.Context, updatedBefore time.Time, limit int, now time.Time) ([]model.Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var claimed []model.Delivery
+	for i, d := range s.deliveries {
+		if len(claimed) == limit {
+			break
+		}
+		if d.Status == model.DeliveryPending && d.UpdatedAt.Before(updatedBefore) {
+			s.deliveries[i].Status, s.deliveries[i].UpdatedAt = model.DeliverySending, now
+			claimed = append(claimed, s.deliveries[i])
+		}
+	}
+	return claimed, nil
+}
+
+func (s *announcementStore) UpdateDelivery(ctx context.Context, delivery *model.Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, d := range s.deliveries {
+		if d.AnnouncementID == delivery.AnnouncementID && d.UserID == delivery.UserID {
+			s.deliveries[i] = *delivery
+		}
+	}
+	return nil
+}
+
+func (s *announcementStore) ReleaseStale(ctx context.Context, claimedBefore time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var count int64
+	for i, d := range s.deliveries {
+		if d.Status == model.DeliverySending && d.UpdatedAt.Before(claimedBefore) {
+			s.deliveries[i].Status = model.DeliveryPending
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *announcementStore) Subscribed(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	subscribed := map[uuid.UUID]bool{}
+	for _, u := range s.users {
+		if !u.optedOut && slices.Contains(userIDs, u.id) {
+			subscribed[u.id] = true
+		}
+	}
+	return subscribed, nil
+}
+
+func (s *announcementStore) optOut(id uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, u := range s.users {
+		if u.id == id {
+			s.users[i].optedOut = true
+		}
+	}
+}
+
+func (s *announcementStore) delivery(userID uuid.UUID) model.Delivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range s.deliveries {
+		if d.UserID == userID {
+			return d
+		}
+	}
+	return model.Delivery{}
+}
+
+// fakeSender records sent messages and fails for the addresses in fail
+type fakeSender struct {
+	sent []email.Message
+	fail map[string]bool
+}
+
+func (f *fakeSender) Send(ctx context.Context, msg email.Message) error {
+	if f.fail[msg.To] {
+		return errors.New("mailbox unavailable")
+	}
+	f.sent = append(f.sent, msg)
+	return nil
+}
+
+// newTestService returns a service over three active users (two with the user role, one
+// admin) and an inactive user, with the clock started at 2024-01-15 12:00 UTC
+func newTestService() (*announcementService, *announcementStore, *fakeSender, *testutil.FakeClock) {
+	store := &announcementStore{users: []testUser{
+		{id: uuid.New(), email: "ann@example.com", role: "user", status: "active"},
+		{id: uuid.New(), email: "bob@example.com", role: "user", status: "active"},
+		{id: uuid.New(), email: "root@example.com", role: "admin", status: "active"},
+		{id: uuid.New(), email: "gone@example.com", role: "user", status: "inactive"},
+	}}
+	sender := &fakeSender{fail: map[string]bool{}}
+	clk := testutil.NewFakeClock(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+	optOut := func(ctx context.Context, userID uuid.UUID) error {
+		store.optOut(userID)
+		return nil
+	}
+	s := NewAnnouncementService(store, sender, testConfig, optOut, zap.NewNop().Sugar(), WithClock(clk)).(*announcementService)
+	return s, store, sender, clk
+}
+
+func errorType(err error) apperrors.ErrorType {
+	if appErr, ok := apperrors.IsAppError(err); ok {
+		return appErr.Type
+	}
+	return ""
+}
+
+func TestCreate_SendsToActiveUsersInSegment(t *testing.T) {
+	// Arrange
+	s, _, sender, clk := newTestService()
+	ctx := context.Background()
+
+	// Act
+	announcement, err := s.Create(ctx, uuid.New(), dto.CreateAnnouncementRequest{
+		Subject: "Maintenance",
+		Body:    "Down on Saturday",
+		Segment: model.Segment{Roles: []string{"user"}},
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	clk.Advance(time.Minute)
+	err = s.Deliver(ctx)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	if announcement.Recipients != 2 {
+		t.Errorf("Recipients = %d, want 2 (inactive and admin users left out)", announcement.Recipients)
+	}
+	var to []string
+	for _, msg := range sender.sent {
+		to = append(to, msg.To)
+	}
+	slices.Sort(to)
+	if !slices.Equal(to, []string{"ann@example.com", "bob@example.com"}) {
+		t.Errorf("sent to %v", to)
+	}
+	got, err := s.Get(ctx, announcement.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Status != model.StatusCompleted || got.Deliveries[model.DeliverySent] != 2 {
+		t.Errorf("status = %s, deliveries = %v; want completed with 2 sent", got.Status, got.Deliveries)
+	}
+}
+
+func TestCreate_RejectsEmptySegment(t *testing.T) {
+	// Arrange
+	s, _, _, _ := newTestService()
+
+	// Act
+	_, err := s.Create(context.Background(), uuid.New(), dto.CreateAnnouncementRequest{
+		Subject: "Hello",
+		Body:    "Hi",
+		Segment: model.Segment{Roles: []string{"auditor"}},
+	})
+
+	// Assert
+	if errorType(err) != apperrors.ValidationError {
+		t.Errorf("error = %v, want a validation error", err)
+	}
+}
+
+func TestDeliver_SkipsUsersWhoOptedOutAfterCreate(t *testing.T) {
+	// Arrange
+	s, store, sender, clk := newTestService()
+	ctx := context.Background()
+	announcement, err := s.Create(ctx, uuid.New(), dto.CreateAnnouncementRequest{Subject: "News", Body: "Hi"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	bob := store.users[1].id
+	store.optOut(bob)
+	clk.Advance(time.Minute)
+
+	// Act
+	err = s.Deliver(ctx)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	if announcement.Recipients != 3 {
+		t.Fatalf("Recipients = %d, want 3", announcement.Recipients)
+	}
+	if len(sender.sent) != 2 {
+		t.Errorf("sent %d messages, want 2", len(sender.sent))
+	}
+	if got := store.delivery(bob).Status; got != model.DeliverySkipped {
+		t.Errorf("opted-out delivery status = %s, want skipped", got)
+	}
+}
+
+func TestDeliver_RetriesOnLaterRunThenFails(t *testing.T) {
+	// Arrange
+	s, store, sender, clk := newTestService()
+	ctx := context.Background()
+	sender.fail["ann@example.com"] = true
+	announcement, err := s.Create(ctx, uuid.New(), dto.CreateAnnouncementRequest{
+		Subject: "News",
+		Body:    "Hi",
+		Segment: model.Segment{Roles: []string{"user"}},
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	ann := store.users[0].id
+
+	// Act
+	clk.Advance(time.Minute)
+	firstErr := s.Deliver(ctx)
+	afterFirst := store.delivery(ann)
+	clk.Advance(time.Minute)
+	secondErr := s.Deliver(ctx)
+
+	// Assert
+	if firstErr != nil || secondErr != nil {
+		t.Fatalf("Deliver() errors = %v, %v", firstErr, secondErr)
+	}
+	if afterFirst.Status != model.DeliveryPending || afterFirst.Attempts != 1 {
+		t.Errorf("after first run: status = %s, attempts = %d; want pending after 1 attempt", afterFirst.Status, afterFirst.Attempts)
+	}
+	final := store.delivery(ann)
+	if final.Status != model.DeliveryFailed || final.Attempts != 2 || final.Error == "" {
+		t.Errorf("after second run: status = %s, attempts = %d, error = %q; want failed after 2", final.Status, final.Attempts, final.Error)
+	}
+	got, _ := s.Get(ctx, announcement.ID)
+	if got.Status != model.StatusCompleted {
+		t.Errorf("announcement status = %s, want completed", got.Status)
+	}
+}
+
+func TestCancel_StopsPendingDeliveries(t *testing.T) {
+	// Arrange
+	s, _, sender, clk := newTestService()
+	ctx := context.Background()
+	announcement, err := s.Create(ctx, uuid.New(), dto.CreateAnnouncementRequest{Subject: "News", Body: "Hi"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// Act
+	cancelErr := s.Cancel(ctx, announcement.ID)
+	clk.Advance(time.Minute)
+	deliverErr := s.Deliver(ctx)
+	againErr := s.Cancel(ctx, announcement.ID)
+
+	// Assert
+	if cancelErr != nil || deliverErr != nil {
+		t.Fatalf("Cancel() error = %v, Deliver() error = %v", cancelErr, deliverErr)
+	}
+	if len(sender.sent) != 0 {
+		t.Errorf("sent %d messages after cancel", len(sender.sent))
+	}
+	if errorType(againErr) != apperrors.ConflictError {
+		t.Errorf("second Cancel() error = %v, want conflict", againErr)
+	}
+}
+
+func TestUnsubscribe_UsesLinkFromMessage(t *testing.T) {
+	// Arrange
+	s, store, sender, clk := newTestService()
+	ctx := context.Background()
+	if _, err := s.Create(ctx, uuid.New(), dto.CreateAnnouncementRequest{
+		Subject: "News",
+		Body:    "Hi",
+		Segment: model.Segment{Roles: []string{"admin"}},
+	}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	clk.Advance(time.Minute)
+	if err := s.Deliver(ctx); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	header := sender.sent[0].Headers["List-Unsubscribe"]
+	link, err := url.Parse(strings.Trim(header, "<>"))
+	if err != nil {
+		t.Fatalf("List-Unsubscribe = %q: %v", header, err)
+	}
+	token := link.Query().Get("token")
+
+	// Act
+	tamperedErr := s.Unsubscribe(ctx, token[:len(token)-2]+"xx")
+	err = s.Unsubscribe(ctx, token)
+
+	// Assert
+	if errorType(tamperedErr) != apperrors.BadRequestError {
+		t.Errorf("Unsubscribe(tampered) error = %v, want bad request", tamperedErr)
+	}
+	if err != nil {
+		t.Fatalf("Unsubscribe() error = %v", err)
+	}
+	if !store.users[2].optedOut {
+		t.Error("admin user was not opted out")
+	}
+	if !strings.Contains(sender.sent[0].Body, link.String()) {
+		t.Error("body does not carry the unsubscribe link")
+	}
+}
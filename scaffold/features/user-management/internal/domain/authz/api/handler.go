@@ -0,0 +1,219 @@
+package api
+
+import (
+	"github.com/MurtadaNazar/go-api-template/internal/domain/authz/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/authz/service"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/validation"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type RoleHandler struct {
+	service   service.Service
+	validator *validation.Validator
+	logger    *zap.SugaredLogger
+}
+
+func NewRoleHandler(s service.Service, logger *zap.SugaredLogger) *RoleHandler {
+	return &RoleHandler{
+		service:   s,
+		validator: validation.Default(),
+		logger:    logger,
+	}
+}
+
+// List godoc
+// @Summary List roles and their permissions (requires roles:manage)
+// @Tags Roles
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} response.SuccessResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /roles [get]
+func (h *RoleHandler) List(c *gin.Context) {
+	requestIDVal, _ := c.Get("RequestID")
+	requestID, ok := requestIDVal.(string)
+	if !ok {
+		requestID = "unknown"
+	}
+
+	roles, err := h.service.ListRoles(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(roles, requestID))
+}
+
+// Get godoc
+// @Summary Get a role and its permissions (requires roles:manage)
+// @Tags Roles
+// @Security BearerAuth
+// @Produce json
+// @Param name path string true "Role name"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /roles/{name} [get]
+func (h *RoleHandler) Get(c *gin.Context) {
+	requestIDVal, _ := c.Get("RequestID")
+	requestID, ok := requestIDVal.(string)
+	if !ok {
+		requestID = "unknown"
+	}
+
+	role, err := h.service.GetRole(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(role, requestID))
+}
+
+// Create godoc
+// @Summary Create a role (requires roles:manage)
+// @Tags Roles
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param role body dto.RoleCreateRequest true "Role definition"
+// @Success 201 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
+// @Router /roles [post]
+func (h *RoleHandler) Create(c *gin.Context) {
+	requestIDVal, _ := c.Get("RequestID")
+	requestID, ok := requestIDVal.(string)
+	if !ok {
+		requestID = "unknown"
+	}
+
+	var req dto.RoleCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warnw("invalid role request", "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppErrorWithDetails(
+			apperrors.BadRequestError,
+			"Invalid request payload",
+			err.Error(),
+		))
+		return
+	}
+
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	role, err := h.service.CreateRole(c.Request.Context(), &req)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.NewSuccessResponse(role, requestID))
+}
+
+// Update godoc
+// @Summary Change a role's description or permissions (requires roles:manage)
+// @Tags Roles
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param name path string true "Role name"
+// @Param role body dto.RoleUpdateRequest true "Changes"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /roles/{name} [put]
+func (h *RoleHandler) Update(c *gin.Context) {
+	requestIDVal, _ := c.Get("RequestID")
+	requestID, ok := requestIDVal.(string)
+	if !ok {
+		requestID = "unknown"
+	}
+
+	name := c.Param("name")
+	var req dto.RoleUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warnw("invalid role request", "role", name, "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppErrorWithDetails(
+			apperrors.BadRequestError,
+			"Invalid request payload",
+			err.Error(),
+		))
+		return
+	}
+
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	role, err := h.service.UpdateRole(c.Request.Context(), name, &req)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(role, requestID))
+}
+
+// Delete godoc
+// @Summary Delete a role that no user has (requires roles:manage)
+// @Tags Roles
+// @Security BearerAuth
+// @Produce json
+// @Param name path string true "Role name"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
+// @Router /roles/{name} [delete]
+func (h *RoleHandler) Delete(c *gin.Context) {
+	requestIDVal, _ := c.Get("RequestID")
+	requestID, ok := requestIDVal.(string)
+	if !ok {
+		requestID = "unknown"
+	}
+
+	if err := h.service.DeleteRole(c.Request.Context(), c.Param("name")); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{"message": "role deleted successfully"}, requestID))
+}
+
+// ListPermissions godoc
+// @Summary List the permissions roles can grant (requires roles:manage)
+// @Tags Roles
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} response.SuccessResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /permissions [get]
+func (h *RoleHandler) ListPermissions(c *gin.Context) {
+	requestIDVal, _ := c.Get("RequestID")
+	requestID, ok := requestIDVal.(string)
+	if !ok {
+		requestID = "unknown"
+	}
+
+	permissions, err := h.service.ListPermissions(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(permissions, requestID))
+}
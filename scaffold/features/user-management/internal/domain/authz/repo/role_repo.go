@@ -0,0 +1,128 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/authz/model"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RoleRepo stores roles, permissions and which permissions each role grants
+type RoleRepo interface {
+	List(ctx context.Context) ([]model.Role, error)
+	FindByName(ctx context.Context, name string) (*model.Role, error)
+	Create(ctx context.Context, role *model.Role) error
+	Update(ctx context.Context, role *model.Role) error
+	Delete(ctx context.Context, name string) error
+	CountUsers(ctx context.Context, name string) (int64, error)
+	ListPermissions(ctx context.Context) ([]model.Permission, error)
+	FindPermissions(ctx context.Context, keys []string) ([]model.Permission, error)
+	UpsertPermissions(ctx context.Context, permissions []model.Permission) error
+}
+
+type roleRepo struct {
+	db *gorm.DB
+}
+
+func NewRoleRepo(db *gorm.DB) RoleRepo {
+	return &roleRepo{db: db}
+}
+
+// List returns every role with its permissions, ordered by name
+func (r *roleRepo) List(ctx context.Context) ([]model.Role, error) {
+	var roles []model.Role
+	if err := r.db.WithContext(ctx).Preload("Permissions").Order("name asc").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (r *roleRepo) FindByName(ctx context.Context, name string) (*model.Role, error) {
+	var role model.Role
+	if err := r.db.WithContext(ctx).Preload("Permissions").First(&role, "name = ?", name).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+// Create inserts a role and links it to its (existing) permissions
+func (r *roleRepo) Create(ctx context.Context, role *model.Role) error {
+	err := r.db.WithContext(ctx).Omit("Permissions.*").Create(role).Error
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return apperrors.ErrRoleExists
+	}
+	return err
+}
+
+// Update saves the role and replaces its permission set
+func (r *roleRepo) Update(ctx context.Context, role *model.Role) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Permissions").Save(role).Error; err != nil {
+			return err
+		}
+		return tx.Model(role).Omit("Permissions.*").Association("Permissions").Replace(role.Permissions)
+	})
+}
+
+func (r *roleRepo) Delete(ctx context.Context, name string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var role model.Role
+		if err := tx.First(&role, "name = ?", name).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+		if err := tx.Model(&role).Association("Permissions").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(&role).Error
+	})
+}
+
+// CountUsers returns how many users currently have the role
+func (r *roleRepo) CountUsers(ctx context.Context, name string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Table("users").Where("user_type = ?", name).Count(&count).Error
+	return count, err
+}
+
+func (r *roleRepo) ListPermissions(ctx context.Context) ([]model.Permission, error) {
+	var permissions []model.Permission
+	if err := r.db.WithContext(ctx).Order("key asc").Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+// FindPermissions returns the permissions with the given keys; unknown keys are skipped
+func (r *roleRepo) FindPermissions(ctx context.Context, keys []string) ([]model.Permission, error) {
+	var permissions []model.Permission
+	if len(keys) == 0 {
+		return permissions, nil
+	}
+	if err := r.db.WithContext(ctx).Where("key IN ?", keys).Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+// UpsertPermissions inserts missing permissions and refreshes the description of existing ones
+func (r *roleRepo) UpsertPermissions(ctx context.Context, permissions []model.Permission) error {
+	if len(permissions) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"description"}),
+	}).Create(&permissions).Error
+}
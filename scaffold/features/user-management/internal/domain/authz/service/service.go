@@ -0,0 +1,439 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/authz/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/authz/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/authz/repo"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/cache"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+)
+
+// Service answers permission checks and manages roles.
+// The admin role is a superuser: Can always allows it and its permissions cannot be
+// narrowed, so a bad edit can never lock every administrator out.
+//
+// Roles can inherit other roles, through Role.Inherits in the database and through
+// WithHierarchy. A role has the permissions of every role it inherits, directly or
+// further down, and HasRole reports it as having those roles.
+type Service interface {
+	Can(ctx context.Context, role, permission string) (bool, error)
+	HasRole(ctx context.Context, role, required string) (bool, error)
+	EffectiveRoles(ctx context.Context, role string) ([]string, error)
+	RoleExists(ctx context.Context, name string) (bool, error)
+	ListRoles(ctx context.Context) ([]model.Role, error)
+	GetRole(ctx context.Context, name string) (*model.Role, error)
+	CreateRole(ctx context.Context, req *dto.RoleCreateRequest) (*model.Role, error)
+	UpdateRole(ctx context.Context, name string, req *dto.RoleUpdateRequest) (*model.Role, error)
+	DeleteRole(ctx context.Context, name string) error
+	ListPermissions(ctx context.Context) ([]model.Permission, error)
+	SeedDefaults(ctx context.Context) error
+}
+
+type authzService struct {
+	repo     repo.RoleRepo
+	logger   *zap.SugaredLogger
+	cache    cache.Cache
+	cacheTTL time.Duration
+	// hierarchy maps roles to the roles they inherit on top of Role.Inherits
+	hierarchy map[string][]string
+}
+
+// ServiceOption customizes a Service created by NewService
+type ServiceOption func(*authzService)
+
+// WithCache caches the permissions of each role in c for ttl. Role changes drop the
+// entry, so they apply immediately on this instance and after at most ttl on others.
+// A ttl of zero or less disables the cache.
+func WithCache(c cache.Cache, ttl time.Duration) ServiceOption {
+	return func(s *authzService) {
+		if ttl <= 0 {
+			return
+		}
+		s.cache = c
+		s.cacheTTL = ttl
+	}
+}
+
+// WithHierarchy adds inheritance from configuration (ROLE_HIERARCHY) to the Inherits of
+// stored roles; hierarchy maps each role to the roles it inherits
+func WithHierarchy(hierarchy map[string][]string) ServiceOption {
+	return func(s *authzService) {
+		s.hierarchy = hierarchy
+	}
+}
+
+func NewService(r repo.RoleRepo, logger *zap.SugaredLogger, opts ...ServiceOption) Service {
+	if logger == nil {
+		logger = zap.NewNop().Sugar()
+	}
+	s := &authzService{repo: r, logger: logger}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func roleCacheKey(name string) string {
+	return "authz:role:" + name
+}
+
+// Can reports whether users with role may perform permission, granted to role or to a
+// role it inherits. Unknown roles have no permissions.
+func (s *authzService) Can(ctx context.Context, role, permission string) (bool, error) {
+	if role == model.RoleAdmin {
+		return true, nil
+	}
+	found := false
+	err := s.walk(ctx, role, func(_ string, entry roleEntry) bool {
+		found = slices.Contains(entry.Permissions, permission)
+		return !found
+	})
+	return found, err
+}
+
+// HasRole reports whether role is required or inherits it, directly or further down
+func (s *authzService) HasRole(ctx context.Context, role, required string) (bool, error) {
+	if role == "" {
+		return false, nil
+	}
+	found := false
+	err := s.walk(ctx, role, func(name string, _ roleEntry) bool {
+		found = name == required
+		return !found
+	})
+	return found, err
+}
+
+// EffectiveRoles returns role followed by every role it inherits, nearest first
+func (s *authzService) EffectiveRoles(ctx context.Context, role string) ([]string, error) {
+	var roles []string
+	err := s.walk(ctx, role, func(name string, _ roleEntry) bool {
+		roles = append(roles, name)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// roleEntry is what permission checks need of a role; it is what the cache keeps
+type roleEntry struct {
+	Permissions []string `json:"permissions"`
+	Inherits    string   `json:"inherits,omitempty"`
+}
+
+// walk visits role and the roles it inherits breadth first, each once, so a cycle in the
+// configuration cannot loop. It stops when visit returns false.
+func (s *authzService) walk(ctx context.Context, role string, visit func(name string, entry roleEntry) bool) error {
+	seen := map[string]bool{role: true}
+	queue := []string{role}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		entry, err := s.loadRole(ctx, name)
+		if err != nil {
+			return err
+		}
+		if !visit(name, entry) {
+			return nil
+		}
+		parents := s.hierarchy[name]
+		if entry.Inherits != "" {
+			parents = append([]string{entry.Inherits}, parents...)
+		}
+		for _, parent := range parents {
+			if !seen[parent] {
+				seen[parent] = true
+				queue = append(queue, parent)
+			}
+		}
+	}
+	return nil
+}
+
+// loadRole returns the permission keys and inherited role of a role, served from the
+// cache when configured. Unknown roles have neither.
+func (s *authzService) loadRole(ctx context.Context, name string) (roleEntry, error) {
+	key := roleCacheKey(name)
+	if s.cache != nil {
+		encoded, ok, err := s.cache.Get(ctx, key)
+		if err != nil {
+			s.logger.Warnw("role permission cache read failed", "role", name, "error", err)
+		}
+		var entry roleEntry
+		if ok && json.Unmarshal(encoded, &entry) == nil {
+			return entry, nil
+		}
+	}
+
+	role, err := s.repo.FindByName(ctx, name)
+	if err != nil {
+		s.logger.Errorw("failed to fetch role", "role", name, "error", err)
+		return roleEntry{}, apperrors.NewAppError(apperrors.InternalError, "Failed to check permissions")
+	}
+	entry := roleEntry{Permissions: []string{}}
+	if role != nil {
+		entry = roleEntry{Permissions: role.PermissionKeys(), Inherits: role.Inherits}
+	}
+
+	if s.cache != nil {
+		if encoded, err := json.Marshal(entry); err == nil {
+			if err := s.cache.Set(ctx, key, encoded, s.cacheTTL); err != nil {
+				s.logger.Warnw("failed to cache role permissions", "role", name, "error", err)
+			}
+		}
+	}
+	return entry, nil
+}
+
+// checkInherits rejects making name inherit parent when parent is unknown or already
+// includes name, which would make a cycle
+func (s *authzService) checkInherits(ctx context.Context, name, parent string) error {
+	if parent == "" {
+		return nil
+	}
+	exists, err := s.RoleExists(ctx, parent)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return apperrors.NewAppErrorWithDetails(apperrors.ValidationError, "Invalid role", "inherited role "+parent+" does not exist")
+	}
+	cycle, err := s.HasRole(ctx, parent, name)
+	if err != nil {
+		return err
+	}
+	if cycle {
+		return apperrors.NewAppErrorWithDetails(apperrors.ValidationError, "Invalid role", "role "+parent+" already inherits "+name)
+	}
+	return nil
+}
+
+// invalidate drops the cached permissions of a role after a change
+func (s *authzService) invalidate(ctx context.Context, name string) {
+	if s.cache == nil {
+		return
+	}
+	if err := s.cache.Delete(ctx, roleCacheKey(name)); err != nil {
+		s.logger.Errorw("failed to invalidate cached role permissions", "role", name, "error", err)
+	}
+}
+
+// RoleExists reports whether a role with the given name is defined
+func (s *authzService) RoleExists(ctx context.Context, name string) (bool, error) {
+	role, err := s.repo.FindByName(ctx, name)
+	if err != nil {
+		s.logger.Errorw("failed to fetch role", "role", name, "error", err)
+		return false, apperrors.NewAppError(apperrors.InternalError, "Failed to fetch role")
+	}
+	return role != nil, nil
+}
+
+// ListRoles returns every role with its permissions ordered by name
+func (s *authzService) ListRoles(ctx context.Context) ([]model.Role, error) {
+	roles, err := s.repo.List(ctx)
+	if err != nil {
+		s.logger.Errorw("failed to list roles", "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to fetch roles")
+	}
+	return roles, nil
+}
+
+// GetRole returns a single role with its permissions
+func (s *authzService) GetRole(ctx context.Context, name string) (*model.Role, error) {
+	role, err := s.repo.FindByName(ctx, name)
+	if err != nil {
+		s.logger.Errorw("failed to fetch role", "role", name, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to fetch role")
+	}
+	if role == nil {
+		return nil, apperrors.NewAppError(apperrors.NotFoundError, "Role not found")
+	}
+	return role, nil
+}
+
+// CreateRole defines a new role
+func (s *authzService) CreateRole(ctx context.Context, req *dto.RoleCreateRequest) (*model.Role, error) {
+	name := strings.TrimSpace(req.Name)
+	if err := model.ValidateRoleName(name); err != nil {
+		return nil, apperrors.NewAppErrorWithDetails(apperrors.ValidationError, "Invalid role", err.Error())
+	}
+	permissions, err := s.resolvePermissions(ctx, req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	inherits := strings.TrimSpace(req.Inherits)
+	if err := s.checkInherits(ctx, name, inherits); err != nil {
+		return nil, err
+	}
+
+	role := &model.Role{Name: name, Description: req.Description, Permissions: permissions, Inherits: inherits}
+	if err := s.repo.Create(ctx, role); err != nil {
+		if errors.Is(err, apperrors.ErrRoleExists) {
+			return nil, apperrors.NewAppError(apperrors.ConflictError, "Role already exists")
+		}
+		s.logger.Errorw("failed to create role", "role", name, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to create role")
+	}
+
+	// A check for this name may have cached "no permissions" before the role existed
+	s.invalidate(ctx, name)
+	s.logger.Infow("role created", "role", name, "permissions", role.PermissionKeys())
+	return role, nil
+}
+
+// UpdateRole changes the description or replaces the permissions of a role
+func (s *authzService) UpdateRole(ctx context.Context, name string, req *dto.RoleUpdateRequest) (*model.Role, error) {
+	role, err := s.GetRole(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Description != nil {
+		role.Description = *req.Description
+	}
+	if req.Permissions != nil {
+		if role.Name == model.RoleAdmin {
+			return nil, apperrors.NewAppError(apperrors.ValidationError, "The admin role always has every permission")
+		}
+		permissions, err := s.resolvePermissions(ctx, *req.Permissions)
+		if err != nil {
+			return nil, err
+		}
+		role.Permissions = permissions
+	}
+	if req.Inherits != nil {
+		inherits := strings.TrimSpace(*req.Inherits)
+		if err := s.checkInherits(ctx, role.Name, inherits); err != nil {
+			return nil, err
+		}
+		role.Inherits = inherits
+	}
+
+	if err := s.repo.Update(ctx, role); err != nil {
+		s.logger.Errorw("failed to update role", "role", name, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to update role")
+	}
+
+	s.invalidate(ctx, name)
+	s.logger.Infow("role updated", "role", name, "permissions", role.PermissionKeys())
+	return role, nil
+}
+
+// DeleteRole removes a role that is neither built in nor assigned to any user
+func (s *authzService) DeleteRole(ctx context.Context, name string) error {
+	role, err := s.GetRole(ctx, name)
+	if err != nil {
+		return err
+	}
+	if role.System {
+		return apperrors.NewAppError(apperrors.ForbiddenError, "Built-in roles cannot be deleted")
+	}
+
+	count, err := s.repo.CountUsers(ctx, name)
+	if err != nil {
+		s.logger.Errorw("failed to count users with role", "role", name, "error", err)
+		return apperrors.NewAppError(apperrors.InternalError, "Failed to delete role")
+	}
+	if count > 0 {
+		return apperrors.NewAppError(apperrors.ConflictError, "Role is still assigned to users")
+	}
+	roles, err := s.repo.List(ctx)
+	if err != nil {
+		s.logger.Errorw("failed to list roles", "error", err)
+		return apperrors.NewAppError(apperrors.InternalError, "Failed to delete role")
+	}
+	for _, other := range roles {
+		if other.Inherits == name {
+			return apperrors.NewAppError(apperrors.ConflictError, "Role is inherited by role "+other.Name)
+		}
+	}
+
+	if err := s.repo.Delete(ctx, name); err != nil {
+		s.logger.Errorw("failed to delete role", "role", name, "error", err)
+		return apperrors.NewAppError(apperrors.InternalError, "Failed to delete role")
+	}
+
+	s.invalidate(ctx, name)
+	s.logger.Infow("role deleted", "role", name)
+	return nil
+}
+
+// ListPermissions returns every known permission ordered by key
+func (s *authzService) ListPermissions(ctx context.Context) ([]model.Permission, error) {
+	permissions, err := s.repo.ListPermissions(ctx)
+	if err != nil {
+		s.logger.Errorw("failed to list permissions", "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to fetch permissions")
+	}
+	return permissions, nil
+}
+
+// SeedDefaults stores model.DefaultPermissions, grants all of them to the admin role and
+// creates the user role without permissions. Existing custom grants on the user role are
+// kept, so it is safe to run on every start.
+func (s *authzService) SeedDefaults(ctx context.Context) error {
+	if err := s.repo.UpsertPermissions(ctx, slices.Clone(model.DefaultPermissions)); err != nil {
+		return err
+	}
+	all, err := s.repo.ListPermissions(ctx)
+	if err != nil {
+		return err
+	}
+
+	admin, err := s.repo.FindByName(ctx, model.RoleAdmin)
+	if err != nil {
+		return err
+	}
+	if admin == nil {
+		err = s.repo.Create(ctx, &model.Role{Name: model.RoleAdmin, Description: "Full access", System: true, Permissions: all})
+	} else {
+		admin.Permissions = all
+		err = s.repo.Update(ctx, admin)
+	}
+	if err != nil && !errors.Is(err, apperrors.ErrRoleExists) {
+		return err
+	}
+
+	user, err := s.repo.FindByName(ctx, model.RoleUser)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		err = s.repo.Create(ctx, &model.Role{Name: model.RoleUser, Description: "Regular user", System: true})
+		if err != nil && !errors.Is(err, apperrors.ErrRoleExists) {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolvePermissions maps permission keys to stored permissions, rejecting unknown keys
+func (s *authzService) resolvePermissions(ctx context.Context, keys []string) ([]model.Permission, error) {
+	keys = slices.Compact(slices.Sorted(slices.Values(keys)))
+	permissions, err := s.repo.FindPermissions(ctx, keys)
+	if err != nil {
+		s.logger.Errorw("failed to fetch permissions", "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to fetch permissions")
+	}
+	if len(permissions) != len(keys) {
+		var unknown []string
+		for _, key := range keys {
+			if !slices.ContainsFunc(permissions, func(p model.Permission) bool { return p.Key == key }) {
+				unknown = append(unknown, key)
+			}
+		}
+		return nil, apperrors.NewAppErrorWithDetails(apperrors.ValidationError, "Unknown permission", strings.Join(unknown, ", "))
+	}
+	return permissions, nil
+}
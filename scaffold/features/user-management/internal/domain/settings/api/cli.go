@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/settings/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/settings/service"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/validation"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+)
+
+const cliUsage = `usage:
+  config export [-o file]           write a settings snapshot (default: stdout)
+  config import [-dry-run] <file>   apply a snapshot ("-" reads stdin)
+`
+
+// RunCommand runs the "config" subcommand of the service binary with args following
+// "config" and returns the process exit code
+func RunCommand(ctx context.Context, s service.Service, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, cliUsage)
+		return 2
+	}
+
+	switch args[0] {
+	case "export":
+		fs := flag.NewFlagSet("config export", flag.ContinueOnError)
+		fs.SetOutput(stderr)
+		out := fs.String("o", "", "write the snapshot to this file instead of stdout")
+		if err := fs.Parse(args[1:]); err != nil || fs.NArg() > 0 {
+			fmt.Fprint(stderr, cliUsage)
+			return 2
+		}
+		snapshot, err := s.Export(ctx)
+		if err != nil {
+			return fail(stderr, err)
+		}
+		w := stdout
+		if *out != "" {
+			f, err := os.Create(*out)
+			if err != nil {
+				return fail(stderr, err)
+			}
+			defer f.Close()
+			w = f
+		}
+		return writeJSON(w, stderr, snapshot)
+
+	case "import":
+		fs := flag.NewFlagSet("config import", flag.ContinueOnError)
+		fs.SetOutput(stderr)
+		dryRun := fs.Bool("dry-run", false, "report the changes without applying them")
+		if err := fs.Parse(args[1:]); err != nil || fs.NArg() != 1 {
+			fmt.Fprint(stderr, cliUsage)
+			return 2
+		}
+		r := stdin
+		if path := fs.Arg(0); path != "-" {
+			f, err := os.Open(path)
+			if err != nil {
+				return fail(stderr, err)
+			}
+			defer f.Close()
+			r = f
+		}
+		var snapshot model.Snapshot
+		if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+			return fail(stderr, fmt.Errorf("reading snapshot: %w", err))
+		}
+		if err := validation.Default().ValidateStruct(&snapshot); err != nil {
+			return fail(stderr, err)
+		}
+		result, err := s.Import(ctx, &snapshot, *dryRun)
+		if err != nil {
+			return fail(stderr, err)
+		}
+		return writeJSON(stdout, stderr, result)
+	}
+
+	fmt.Fprint(stderr, cliUsage)
+	return 2
+}
+
+func writeJSON(w, stderr io.Writer, v interface{}) int {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fail(stderr, err)
+	}
+	return 0
+}
+
+func fail(stderr io.Writer, err error) int {
+	if appErr, ok := apperrors.IsAppError(err); ok && appErr.Details != "" {
+		fmt.Fprintf(stderr, "error: %s: %s\n", appErr.Message, appErr.Details)
+		return 1
+	}
+	fmt.Fprintf(stderr, "error: %v\n", err)
+	return 1
+}
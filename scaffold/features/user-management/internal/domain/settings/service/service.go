@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	authzDto "github.com/MurtadaNazar/go-api-template/internal/domain/authz/dto"
+	authzModel "github.com/MurtadaNazar/go-api-template/internal/domain/authz/model"
+	authzService "github.com/MurtadaNazar/go-api-template/internal/domain/authz/service"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/settings/model"
+	userDto "github.com/MurtadaNazar/go-api-template/internal/domain/user/dto"
+	userModel "github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	userService "github.com/MurtadaNazar/go-api-template/internal/domain/user/service"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+)
+
+// Service exports runtime settings as a snapshot and applies snapshots taken elsewhere
+type Service interface {
+	Export(ctx context.Context) (*model.Snapshot, error)
+	Import(ctx context.Context, snapshot *model.Snapshot, dryRun bool) (*model.ImportResult, error)
+}
+
+type settingsService struct {
+	fields userService.ProfileFieldService
+	authz  authzService.Service
+	logger *zap.SugaredLogger
+}
+
+func NewService(fields userService.ProfileFieldService, authz authzService.Service, logger *zap.SugaredLogger) Service {
+	if logger == nil {
+		logger = zap.NewNop().Sugar()
+	}
+	return &settingsService{fields: fields, authz: authz, logger: logger}
+}
+
+// Export returns the current profile fields, except those declared in PROFILE_FIELDS, and roles
+func (s *settingsService) Export(ctx context.Context) (*model.Snapshot, error) {
+	fields, err := s.fields.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := s.authz.ListRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &model.Snapshot{
+		Version:       model.SnapshotVersion,
+		ExportedAt:    time.Now().UTC(),
+		ProfileFields: make([]userDto.ProfileFieldRequest, 0, len(fields)),
+		Roles:         make([]authzDto.RoleCreateRequest, 0, len(roles)),
+	}
+	for _, f := range fields {
+		// Fields from PROFILE_FIELDS come with each environment's configuration
+		if f.Config {
+			continue
+		}
+		snapshot.ProfileFields = append(snapshot.ProfileFields, fieldRequest(&f))
+	}
+	for _, r := range roles {
+		snapshot.Roles = append(snapshot.Roles, roleRequest(&r))
+	}
+	return snapshot, nil
+}
+
+// Import creates and updates profile fields and roles to match snapshot. The whole
+// snapshot is checked before anything is written; records that exist here but not in
+// the snapshot are left alone. With dryRun nothing is written. A record that fails to
+// write does not stop the others; the failures are returned together.
+func (s *settingsService) Import(ctx context.Context, snapshot *model.Snapshot, dryRun bool) (*model.ImportResult, error) {
+	if snapshot.Version < 1 || snapshot.Version > model.SnapshotVersion {
+		return nil, apperrors.NewAppError(apperrors.ValidationError, fmt.Sprintf("Unsupported snapshot version %d (this service reads up to %d)", snapshot.Version, model.SnapshotVersion))
+	}
+	if err := s.check(ctx, snapshot); err != nil {
+		return nil, err
+	}
+
+	currentFields, err := s.fields.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	currentRoles, err := s.authz.ListRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.ImportResult{DryRun: dryRun}
+	var failures []error
+	for i := range snapshot.ProfileFields {
+		req := &snapshot.ProfileFields[i]
+		idx := slices.IndexFunc(currentFields, func(f userModel.ProfileField) bool { return f.Key == req.Key })
+		switch {
+		case idx < 0:
+			result.ProfileFields.Created = append(result.ProfileFields.Created, req.Key)
+			if !dryRun {
+				if _, err := s.fields.Create(ctx, req); err != nil {
+					failures = append(failures, fmt.Errorf("create profile field %s: %w", req.Key, err))
+				}
+			}
+		case fieldEqual(fieldRequest(&currentFields[idx]), *req):
+			result.ProfileFields.Unchanged = append(result.ProfileFields.Unchanged, req.Key)
+		default:
+			result.ProfileFields.Updated = append(result.ProfileFields.Updated, req.Key)
+			if !dryRun {
+				if _, err := s.fields.Update(ctx, req.Key, req); err != nil {
+					failures = append(failures, fmt.Errorf("update profile field %s: %w", req.Key, err))
+				}
+			}
+		}
+	}
+
+	for i := range snapshot.Roles {
+		req := &snapshot.Roles[i]
+		idx := slices.IndexFunc(currentRoles, func(r authzModel.Role) bool { return r.Name == req.Name })
+		switch {
+		case idx < 0:
+			result.Roles.Created = append(result.Roles.Created, req.Name)
+			if !dryRun {
+				if _, err := s.authz.CreateRole(ctx, req); err != nil {
+					failures = append(failures, fmt.Errorf("create role %s: %w", req.Name, err))
+				}
+			}
+		case roleEqual(roleRequest(&currentRoles[idx]), *req):
+			result.Roles.Unchanged = append(result.Roles.Unchanged, req.Name)
+		default:
+			result.Roles.Updated = append(result.Roles.Updated, req.Name)
+			if !dryRun {
+				update := &authzDto.RoleUpdateRequest{Description: &req.Description}
+				if req.Name != authzModel.RoleAdmin {
+					permissions := req.Permissions
+					update.Permissions = &permissions
+				}
+				if _, err := s.authz.UpdateRole(ctx, req.Name, update); err != nil {
+					failures = append(failures, fmt.Errorf("update role %s: %w", req.Name, err))
+				}
+			}
+		}
+	}
+
+	if err := apperrors.Join(failures...); err != nil {
+		s.logger.Errorw("settings snapshot import failed", "failed_records", len(failures), "error", err)
+		return nil, err
+	}
+	if !dryRun {
+		s.logger.Infow("settings snapshot imported",
+			"snapshot_exported_at", snapshot.ExportedAt,
+			"profile_fields_created", len(result.ProfileFields.Created),
+			"profile_fields_updated", len(result.ProfileFields.Updated),
+			"roles_created", len(result.Roles.Created),
+			"roles_updated", len(result.Roles.Updated),
+		)
+	}
+	return result, nil
+}
+
+// check validates every record of a snapshot against this environment, so an import
+// either passes completely or writes nothing
+func (s *settingsService) check(ctx context.Context, snapshot *model.Snapshot) error {
+	var problems []string
+
+	seenFields := make(map[string]bool, len(snapshot.ProfileFields))
+	for _, req := range snapshot.ProfileFields {
+		if seenFields[req.Key] {
+			problems = append(problems, "profile field "+req.Key+" appears twice")
+		}
+		seenFields[req.Key] = true
+		field := userModel.ProfileField{Key: req.Key, Label: req.Label, Type: userModel.FieldType(req.Type), Required: req.Required, Choices: req.Choices}
+		if err := field.Validate(); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	permissions, err := s.authz.ListPermissions(ctx)
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool, len(permissions))
+	for _, p := range permissions {
+		known[p.Key] = true
+	}
+	seenRoles := make(map[string]bool, len(snapshot.Roles))
+	for _, req := range snapshot.Roles {
+		if seenRoles[req.Name] {
+			problems = append(problems, "role "+req.Name+" appears twice")
+		}
+		seenRoles[req.Name] = true
+		if err := authzModel.ValidateRoleName(req.Name); err != nil {
+			problems = append(problems, err.Error())
+		}
+		for _, key := range req.Permissions {
+			if !known[key] {
+				problems = append(problems, "role "+req.Name+" grants unknown permission "+key)
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return apperrors.NewAppErrorWithDetails(apperrors.ValidationError, "Invalid settings snapshot", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func fieldRequest(f *userModel.ProfileField) userDto.ProfileFieldRequest {
+	return userDto.ProfileFieldRequest{
+		Key:      f.Key,
+		Label:    f.Label,
+		Type:     string(f.Type),
+		Required: f.Required,
+		Choices:  slices.Clone([]string(f.Choices)),
+	}
+}
+
+func fieldEqual(a, b userDto.ProfileFieldRequest) bool {
+	return a.Label == b.Label && a.Type == b.Type && a.Required == b.Required && slices.Equal(a.Choices, b.Choices)
+}
+
+func roleRequest(r *authzModel.Role) authzDto.RoleCreateRequest {
+	req := authzDto.RoleCreateRequest{Name: r.Name, Description: r.Description}
+	if r.Name != authzModel.RoleAdmin {
+		req.Permissions = r.PermissionKeys()
+		slices.Sort(req.Permissions)
+	}
+	return req
+}
+
+func roleEqual(current, incoming authzDto.RoleCreateRequest) bool {
+	if current.Description != incoming.Description {
+		return false
+	}
+	if current.Name == authzModel.RoleAdmin {
+		return true
+	}
+	wanted := slices.Compact(slices.Sorted(slices.Values(incoming.Permissions)))
+	return slices.Equal(current.Permissions, wanted)
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Batch godoc
+// @Summary Delete users and change their role or status in bulk (requires users:batch)
+// @Description Applies up to 100 operations in one transaction. Every operation is checked first with the rules of the single-user routes; if any fails, nothing is written, applied is false and the failed results say why. Each user may appear once, and the caller's own account cannot be changed.
+// @Tags Users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body dto.BatchRequest true "Operations"
+// @Success 200 {object} dto.BatchResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Router /users/batch [post]
+func (h *UserHandler) Batch(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+
+	var req dto.BatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.NewAppErrorWithDetails(apperrors.BadRequestError, "Invalid request payload", err.Error()))
+		return
+	}
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	ctx := actor.WithClientIP(c.Request.Context(), c.ClientIP())
+	result, err := h.service.Batch(ctx, req.Operations)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, response.NewSuccessResponse(result, requestID))
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/examples"
+)
+
+// Examples are the bodies of the user and profile field routes, served under /docs/examples
+var Examples = []examples.Route{
+	{Method: http.MethodPost, Path: "/users/", Request: dto.UserCreateRequest{}, Response: dto.AdminUserResponse{}},
+	{Method: http.MethodPost, Path: "/auth/register", Request: dto.SignupRequest{}, Response: dto.UserResponse{}},
+	{Method: http.MethodPost, Path: "/invitations/", Request: dto.InvitationRequest{}, Response: dto.InvitationResponse{}},
+	{Method: http.MethodPost, Path: "/auth/accept-invite", Request: dto.AcceptInviteRequest{}, Response: dto.UserResponse{}},
+	{Method: http.MethodGet, Path: "/users/check", Response: dto.AvailabilityResponse{}},
+	{Method: http.MethodGet, Path: "/users/stats", Response: model.UserStats{}},
+	{Method: http.MethodGet, Path: "/admin/metrics/users", Response: dto.UserMetricsResponse{}},
+	{Method: http.MethodGet, Path: "/users/", Response: []dto.AdminUserResponse{}},
+	{Method: http.MethodGet, Path: "/users/{id}", Response: dto.AdminUserResponse{}},
+	{Method: http.MethodPut, Path: "/users/{id}", Request: dto.UserUpdateRequest{}, Response: dto.AdminUserResponse{}},
+	{Method: http.MethodGet, Path: "/me/profile", Response: dto.UserResponse{}},
+	{Method: http.MethodPatch, Path: "/me/profile", Request: dto.ProfileUpdateRequest{}, Response: dto.UserResponse{}},
+	{Method: http.MethodGet, Path: "/users/{id}/history", Response: []model.UserRevision{}},
+	{Method: http.MethodGet, Path: "/users/{id}/status-history", Response: []model.StatusChange{}},
+	{Method: http.MethodPost, Path: "/users/merge", Request: dto.MergeRequest{}, Response: dto.MergeResponse{}},
+	{Method: http.MethodPost, Path: "/users/batch", Request: dto.BatchRequest{}, Response: dto.BatchResponse{}},
+	{Method: http.MethodGet, Path: "/users/tags", Response: []model.TagCount{}},
+	{Method: http.MethodGet, Path: "/users/{id}/tags", Response: []string{}},
+	{Method: http.MethodPost, Path: "/users/{id}/tags", Request: dto.TagsRequest{}, Response: []string{}},
+	{Method: http.MethodDelete, Path: "/users/{id}/tags/{tag}", Response: []string{}},
+	{Method: http.MethodGet, Path: "/users/{id}/notes", Response: []model.UserNote{}},
+	{Method: http.MethodPost, Path: "/users/{id}/notes", Request: dto.NoteRequest{}, Response: model.UserNote{}},
+	{Method: http.MethodPut, Path: "/users/{id}/notes/{noteId}", Request: dto.NoteRequest{}, Response: model.UserNote{}},
+	{Method: http.MethodDelete, Path: "/users/{id}/review", Response: dto.AdminUserResponse{}},
+	{Method: http.MethodPost, Path: "/users/{id}/suspend", Request: dto.StatusChangeRequest{}, Response: dto.AdminUserResponse{}},
+	{Method: http.MethodPost, Path: "/users/{id}/deactivate", Request: dto.StatusChangeRequest{}, Response: dto.AdminUserResponse{}},
+	{Method: http.MethodPost, Path: "/users/{id}/activate", Request: dto.StatusChangeRequest{}, Response: dto.AdminUserResponse{}},
+	{Method: http.MethodGet, Path: "/profile-fields/", Response: []model.ProfileField{}},
+	{Method: http.MethodPost, Path: "/profile-fields/", Request: dto.ProfileFieldRequest{}, Response: model.ProfileField{}},
+	{Method: http.MethodPut, Path: "/profile-fields/{key}", Request: dto.ProfileFieldRequest{}, Response: model.ProfileField{}},
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+)
+
+// csvHeader are the columns of a users CSV export
+var csvHeader = []string{
+	"id", "username", "email", "first_name", "second_name", "last_name", "phone",
+	"user_type", "status", "metadata", "created_at", "updated_at",
+}
+
+// ValidateExportParams checks the params of a users export, which are the filters and sort
+// of GET /users/export, before it is queued
+func (h *UserHandler) ValidateExportParams(params map[string]string) error {
+	_, _, _, err := parseListParams(params)
+	return err
+}
+
+// WriteCSV writes the users matching params as CSV, one row per user, and returns the
+// number of rows. Custom profile fields are written as one JSON column.
+func (h *UserHandler) WriteCSV(ctx context.Context, params map[string]string, w io.Writer) (int64, error) {
+	filters, sortBy, sortOrder, err := parseListParams(params)
+	if err != nil {
+		return 0, err
+	}
+
+	out := csv.NewWriter(w)
+	if err := out.Write(csvHeader); err != nil {
+		return 0, err
+	}
+	var rows int64
+	err = h.service.Stream(ctx, filters, sortBy, sortOrder, func(u *model.User) error {
+		record, err := csvRecord(u)
+		if err != nil {
+			return err
+		}
+		rows++
+		return out.Write(record)
+	})
+	if err != nil {
+		return rows, err
+	}
+	out.Flush()
+	return rows, out.Error()
+}
+
+func csvRecord(u *model.User) ([]string, error) {
+	metadata, err := json.Marshal(u.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	phone := ""
+	if u.Phone != nil {
+		phone = *u.Phone
+	}
+	return []string{
+		u.ID.String(), u.Username, csvText(u.Email), csvText(u.FirstName), csvText(u.SecondName), csvText(u.LastName), phone,
+		string(u.UserType), u.Status, string(metadata),
+		u.CreatedAt.UTC().Format(time.RFC3339), u.UpdatedAt.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// csvText keeps user-entered text from being read as a formula by spreadsheet apps
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
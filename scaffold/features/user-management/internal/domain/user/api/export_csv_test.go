@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/service"
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func TestUserHandler_WriteCSV(t *testing.T) {
+	// Arrange
+	created := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	var gotFilters map[string]interface{}
+	var gotSort string
+	users := &testutil.MockUserRepo{
+		ListFn: func(ctx context.Context, offset, limit int, filters map[string]interface{}, sortBy, sortOrder string) ([]*model.User, error) {
+			gotFilters, gotSort = filters, sortBy+" "+sortOrder
+			return []*model.User{{
+				ID:        uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+				Username:  "jdoe",
+				Email:     "jane@example.com",
+				FirstName: "=HYPERLINK(\"x\")",
+				LastName:  "Doe, Jr.",
+				UserType:  model.UserTypeRegular,
+				Status:    "active",
+				Metadata:  model.Metadata{"department": "sales"},
+				CreatedAt: created,
+				UpdatedAt: created,
+			}}, nil
+		},
+	}
+	h := NewUserHandler(service.NewUserService(users, zap.NewNop().Sugar()), zap.NewNop().Sugar())
+	var out strings.Builder
+
+	// Act
+	rows, err := h.WriteCSV(context.Background(), map[string]string{"user_type": "user", "sort_by": "username", "sort_order": "DESC"}, &out)
+
+	// Assert
+	if err != nil || rows != 1 {
+		t.Fatalf("WriteCSV() = %d, %v; want 1 row", rows, err)
+	}
+	want := "id,username,email,first_name,second_name,last_name,phone,user_type,status,metadata,created_at,updated_at\n" +
+		`123e4567-e89b-12d3-a456-426614174000,jdoe,jane@example.com,"'=HYPERLINK(""x"")",,"Doe, Jr.",,user,active,"{""department"":""sales""}",2024-01-15T12:00:00Z,2024-01-15T12:00:00Z` + "\n"
+	if out.String() != want {
+		t.Errorf("CSV =\n%s\nwant\n%s", out.String(), want)
+	}
+	if gotFilters["user_type"] != "user" || gotSort != "username desc" {
+		t.Errorf("queried with filters %v, sort %q", gotFilters, gotSort)
+	}
+}
+
+func TestUserHandler_ValidateExportParams(t *testing.T) {
+	// Arrange
+	h := NewUserHandler(nil, zap.NewNop().Sugar())
+
+	// Act
+	valid := h.ValidateExportParams(map[string]string{"flagged": "true", "metadata.department": "sales", "q": "jane doe"})
+	badSort := h.ValidateExportParams(map[string]string{"sort_by": "password"})
+	badFlag := h.ValidateExportParams(map[string]string{"flagged": "maybe"})
+	longSearch := h.ValidateExportParams(map[string]string{"q": strings.Repeat("a", maxSearchLength+1)})
+
+	// Assert
+	if valid != nil {
+		t.Errorf("valid params rejected: %v", valid)
+	}
+	if badSort == nil || badFlag == nil || longSearch == nil {
+		t.Errorf("invalid params accepted: sort %v, flagged %v, q %v", badSort, badFlag, longSearch)
+	}
+}
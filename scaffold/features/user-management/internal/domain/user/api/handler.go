@@ -1,19 +1,28 @@
 package api
 
 import (
+	"context"
 	"fmt"
-	"go_platform_template/internal/domain/user/dto"
-	"go_platform_template/internal/domain/user/service"
-	"go_platform_template/internal/platform/validation"
-	apperrors "go_platform_template/internal/shared/errors"
-	"go_platform_template/internal/shared/response"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/repo"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/service"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/risk"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/validation"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
+// maxSearchLength bounds the q parameter of user lists
+const maxSearchLength = 100
+
 // allowedSortFields defines the valid fields for sorting
 var allowedSortFields = map[string]struct{}{
 	"created_at": {},
@@ -40,30 +49,104 @@ type UserHandler struct {
 	service   service.UserService
 	validator *validation.Validator
 	logger    *zap.SugaredLogger
+	// isManager decides who gets the admin view in single-user responses
+	isManager func(ctx context.Context, role string) bool
 }
 
 func NewUserHandler(s service.UserService, logger *zap.SugaredLogger) *UserHandler {
 	return &UserHandler{
 		service:   s,
-		validator: validation.New(),
+		validator: validation.Default(),
 		logger:    logger,
 	}
 }
 
+// SetAdminView gives callers whose role isManager accepts dto.AdminUserResponse, with
+// status and review reasons and last login details, in single-user responses. List,
+// export and admin routes always use it, since their permissions imply it. Without it
+// single-user responses are dto.UserResponse.
+func (h *UserHandler) SetAdminView(isManager func(ctx context.Context, role string) bool) {
+	h.isManager = isManager
+}
+
+// userView returns the view of user the caller may see
+func (h *UserHandler) userView(c *gin.Context, user *model.User) any {
+	if h.isManager != nil && h.isManager(c.Request.Context(), c.GetString("role")) {
+		return dto.NewAdminUserResponse(user)
+	}
+	return dto.NewUserResponse(user)
+}
+
+// Stats godoc
+// @Summary Count users and recently active users (requires users:list)
+// @Description Counts accounts that are not deleted, and those of them that logged in within the last 1, 7 and 30 days.
+// @Tags Users
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} model.UserStats
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /users/stats [get]
+func (h *UserHandler) Stats(c *gin.Context) {
+	stats, err := h.service.Stats(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, response.NewSuccessResponse(stats, c.GetString("RequestID")))
+}
+
+// Metrics godoc
+// @Summary User metrics for admin dashboards (requires users:metrics)
+// @Description Registrations per UTC day over the last days (today included), accounts per status and per role, and the 10 newest accounts. Counted in the database.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param days query int false "Days of registrations, 1 to 365 (default 30)"
+// @Success 200 {object} dto.UserMetricsResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /admin/metrics/users [get]
+func (h *UserHandler) Metrics(c *gin.Context) {
+	days := 30
+	if v := c.Query("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 365 {
+			_ = c.Error(apperrors.NewAppError(apperrors.BadRequestError, "days must be between 1 and 365"))
+			return
+		}
+		days = n
+	}
+
+	metrics, err := h.service.Metrics(c.Request.Context(), days)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, response.NewSuccessResponse(dto.NewUserMetricsResponse(metrics), c.GetString("RequestID")))
+}
+
 // ListUsers godoc
-// @Summary List users with pagination, filters, and sorting
+// @Summary List users with pagination, filters, and sorting (requires users:list)
 // @Tags Users
 // @Security BearerAuth
 // @Produce json
 // @Param offset query int false "Offset for pagination"
-// @Param limit query int false "Limit for pagination"
+// @Param limit query int false "Limit for pagination; at most 100 with a cursor"
+// @Param cursor query string false "Page with a keyset instead of an offset: empty for the first page, then the next_cursor of the previous response. Users are ordered by created_at and ID; sort_order applies, sort_by must be created_at and offset is not allowed."
+// @Param q query string false "Search username, email, first and last name; every space-separated term must match part of one, ignoring case"
 // @Param username query string false "Filter by username"
 // @Param email query string false "Filter by email"
 // @Param user_type query string false "Filter by user type"
+// @Param metadata.{key} query string false "Filter by a custom profile field, e.g. metadata.department=sales"
+// @Param tag query string false "Filter by a tag, e.g. tag=beta"
+// @Param flagged query bool false "Filter by whether abuse detection flagged the user for review"
 // @Param sort_by query string false "Sort by field (created_at or username)"
 // @Param sort_order query string false "Sort order (asc or desc)"
-// @Success 200 {object} response.SuccessResponse
+// @Success 200 {array} dto.AdminUserResponse "Wrapped in response.CursorPaginatedResponse when cursor is given"
 // @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
 // @Router /users [get]
 func (h *UserHandler) ListUsers(c *gin.Context) {
@@ -99,52 +182,201 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 		}
 	}
 
+	filters, sortBy, sortOrder, err := h.listQuery(c, requestID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		h.listUsersAfter(c, cursor, limit, filters, sortBy, sortOrder, requestID)
+		return
+	}
+
+	users, err := h.service.List(c.Request.Context(), offset, limit, filters, sortBy, sortOrder)
+	if err != nil {
+		if appErr, ok := apperrors.IsAppError(err); ok {
+			_ = c.Error(appErr)
+			return
+		}
+		h.logger.Errorw("failed to list users", "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to fetch users"))
+		return
+	}
+
+	c.Render(http.StatusOK, response.PooledJSON{Data: response.NewSuccessResponse(dto.NewAdminUserResponses(users), requestID)})
+}
+
+// maxCursorLimit bounds the page size of keyset user lists
+const maxCursorLimit = 100
+
+// listUsersAfter serves ListUsers when a cursor is given
+func (h *UserHandler) listUsersAfter(c *gin.Context, cursor string, limit int, filters map[string]interface{}, sortBy, sortOrder, requestID string) {
+	switch {
+	case c.Query("offset") != "":
+		_ = c.Error(apperrors.NewAppError(apperrors.BadRequestError, "offset cannot be combined with cursor"))
+		return
+	case sortBy != "created_at":
+		_ = c.Error(apperrors.NewAppError(apperrors.BadRequestError, "Cursor pages are sorted by created_at"))
+		return
+	case limit < 1 || limit > maxCursorLimit:
+		_ = c.Error(apperrors.NewAppError(apperrors.BadRequestError, fmt.Sprintf("limit must be between 1 and %d", maxCursorLimit)))
+		return
+	}
+
+	users, next, err := h.service.ListPage(c.Request.Context(), cursor, limit, filters, sortOrder)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.Render(http.StatusOK, response.PooledJSON{Data: response.NewCursorPaginatedResponse(dto.NewAdminUserResponses(users), next, limit, requestID)})
+}
+
+// listQuery reads the filter and sort parameters shared by ListUsers and ExportUsers
+func (h *UserHandler) listQuery(c *gin.Context, requestID string) (map[string]interface{}, string, string, error) {
+	params := make(map[string]string)
+	for key, values := range c.Request.URL.Query() {
+		if len(values) > 0 {
+			params[key] = values[0]
+		}
+	}
+	filters, sortBy, sortOrder, err := parseListParams(params)
+	if err != nil {
+		h.logger.Warnw("invalid user list query", "error", err, "request_id", requestID)
+	}
+	return filters, sortBy, sortOrder, err
+}
+
+// parseListParams turns list parameters, from a query string or an export's params, into
+// repository filters and a validated sort
+func parseListParams(params map[string]string) (map[string]interface{}, string, string, error) {
 	filters := make(map[string]interface{})
-	if v := c.Query("username"); v != "" {
+	if v := strings.TrimSpace(params["q"]); v != "" {
+		if len(v) > maxSearchLength {
+			return nil, "", "", apperrors.NewAppError(
+				apperrors.BadRequestError,
+				fmt.Sprintf("q must be at most %d characters", maxSearchLength),
+			)
+		}
+		filters[repo.SearchFilter] = v
+	}
+	if v := params["username"]; v != "" {
 		filters["username"] = v
 	}
-	if v := c.Query("email"); v != "" {
+	if v := params["email"]; v != "" {
 		filters["email"] = v
 	}
-	if v := c.Query("user_type"); v != "" {
+	if v := params["user_type"]; v != "" {
 		filters["user_type"] = v
 	}
+	if v := params["tag"]; v != "" {
+		tag, ok := model.NormalizeTag(v)
+		if !ok {
+			return nil, "", "", apperrors.NewAppError(apperrors.BadRequestError, "Invalid tag value")
+		}
+		filters[repo.TagFilter] = tag
+	}
+	if v := params["flagged"]; v != "" {
+		flagged, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, "", "", apperrors.NewAppErrorWithDetails(
+				apperrors.BadRequestError,
+				"Invalid flagged value",
+				err.Error(),
+			)
+		}
+		filters[repo.FlaggedFilter] = flagged
+	}
+	// Custom profile fields filter as metadata.<key>=<value>
+	metadata := make(map[string]string)
+	for key, value := range params {
+		if field, ok := strings.CutPrefix(key, "metadata."); ok {
+			metadata[field] = value
+		}
+	}
+	if len(metadata) > 0 {
+		filters[repo.MetadataFilter] = metadata
+	}
 
-	sortBy := strings.TrimSpace(c.DefaultQuery("sort_by", "created_at"))
-	sortOrder := strings.ToLower(strings.TrimSpace(c.DefaultQuery("sort_order", "asc")))
+	sortBy, sortOrder := "created_at", "asc"
+	if v, ok := params["sort_by"]; ok {
+		sortBy = strings.TrimSpace(v)
+	}
+	if v, ok := params["sort_order"]; ok {
+		sortOrder = strings.ToLower(strings.TrimSpace(v))
+	}
 
 	// Validate sortBy field
 	if _, ok := allowedSortFields[sortBy]; !ok {
-		h.logger.Warnw("invalid sort_by field", "sort_by", sortBy, "request_id", requestID)
-		_ = c.Error(apperrors.NewAppError(
+		return nil, "", "", apperrors.NewAppError(
 			apperrors.BadRequestError,
 			fmt.Sprintf("Invalid sort_by field. Allowed fields: %s", getKeysList(allowedSortFields)),
-		))
-		return
+		)
 	}
 
 	// Validate sortOrder
 	if _, ok := allowedSortOrders[sortOrder]; !ok {
-		h.logger.Warnw("invalid sort_order value", "sort_order", sortOrder, "request_id", requestID)
-		_ = c.Error(apperrors.NewAppError(
+		return nil, "", "", apperrors.NewAppError(
 			apperrors.BadRequestError,
 			fmt.Sprintf("Invalid sort_order. Allowed values: %s", getKeysList(allowedSortOrders)),
-		))
-		return
+		)
 	}
+	return filters, sortBy, sortOrder, nil
+}
 
-	users, err := h.service.List(c.Request.Context(), offset, limit, filters, sortBy, sortOrder)
+// ExportUsers godoc
+// @Summary Export every matching user (requires users:list)
+// @Description Streams all users matching the filters as one JSON array, without pagination. Rows are sent as they are read from the database, so the response uses chunked transfer encoding and is not localized. If the export fails part way, the array is left unterminated and the body is not valid JSON.
+// @Tags Users
+// @Security BearerAuth
+// @Produce json
+// @Param q query string false "Search username, email, first and last name; every space-separated term must match part of one, ignoring case"
+// @Param username query string false "Filter by username"
+// @Param email query string false "Filter by email"
+// @Param user_type query string false "Filter by user type"
+// @Param metadata.{key} query string false "Filter by a custom profile field, e.g. metadata.department=sales"
+// @Param tag query string false "Filter by a tag, e.g. tag=beta"
+// @Param flagged query bool false "Filter by whether abuse detection flagged the user for review"
+// @Param sort_by query string false "Sort by field (created_at or username)"
+// @Param sort_order query string false "Sort order (asc or desc)"
+// @Success 200 {array} dto.AdminUserResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /users/export [get]
+func (h *UserHandler) ExportUsers(c *gin.Context) {
+	requestIDVal, _ := c.Get("RequestID")
+	requestID, ok := requestIDVal.(string)
+	if !ok {
+		requestID = "unknown"
+	}
+
+	filters, sortBy, sortOrder, err := h.listQuery(c, requestID)
 	if err != nil {
-		h.logger.Errorw("failed to list users", "error", err, "request_id", requestID)
-		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to fetch users"))
+		_ = c.Error(err)
 		return
 	}
 
-	for _, u := range users {
-		u.Password = ""
+	ctx := c.Request.Context()
+	stream := response.NewArrayStream(ctx, c.Writer, requestID)
+	err = stream.Close(h.service.Stream(ctx, filters, sortBy, sortOrder, func(u *model.User) error {
+		return stream.Write(dto.NewAdminUserResponse(u))
+	}))
+	switch {
+	case err == nil:
+		return
+	case ctx.Err() != nil:
+		h.logger.Infow("user export cancelled by client", "rows", stream.Count(), "request_id", requestID)
+	case stream.Started():
+		h.logger.Errorw("user export failed", "error", err, "rows", stream.Count(), "request_id", requestID)
+	default:
+		if appErr, ok := apperrors.IsAppError(err); ok {
+			_ = c.Error(appErr)
+			return
+		}
+		h.logger.Errorw("failed to export users", "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to export users"))
 	}
-
-	c.JSON(http.StatusOK, response.NewSuccessResponse(users, requestID))
 }
 
 // GetUser godoc
@@ -153,7 +385,7 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 // @Security BearerAuth
 // @Produce json
 // @Param id path string true "User ID"
-// @Success 200 {object} response.SuccessResponse
+// @Success 200 {object} dto.UserResponse "dto.AdminUserResponse for callers with users:list"
 // @Failure 404 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
 // @Router /users/{id} [get]
@@ -175,19 +407,20 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 		return
 	}
 
-	user.Password = ""
-	c.JSON(http.StatusOK, response.NewSuccessResponse(user, requestID))
+	c.JSON(http.StatusOK, response.NewSuccessResponse(h.userView(c, user), requestID))
 }
 
 // Register godoc
-// @Summary Register a new user
+// @Summary Create a user
+// @Description Creates a user with any role. Requires users:create; people sign themselves up at POST /auth/register.
 // @Tags Users
 // @Security BearerAuth
 // @Accept json
 // @Produce json
 // @Param user body dto.UserCreateRequest true "User to create"
-// @Success 201 {object} response.SuccessResponse
+// @Success 201 {object} dto.UserResponse "dto.AdminUserResponse for callers with users:list"
 // @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
 // @Failure 409 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
 // @Router /users/ [post]
@@ -225,8 +458,117 @@ func (h *UserHandler) Register(c *gin.Context) {
 		return
 	}
 
-	user.Password = ""
-	c.JSON(http.StatusCreated, response.NewSuccessResponse(user, requestID))
+	c.JSON(http.StatusCreated, response.NewSuccessResponse(h.userView(c, user), requestID))
+}
+
+// Signup godoc
+// @Summary Sign up
+// @Description Creates an account with the default role and emails a link to verify its address. Suspicious signups, or all of them with SIGNUP_CAPTCHA=true, need a solved CAPTCHA.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param user body dto.SignupRequest true "Account to create"
+// @Param X-Captcha-Token header string false "Solved CAPTCHA token"
+// @Success 201 {object} dto.UserResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse "Blocked, or a CAPTCHA is required"
+// @Failure 409 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /auth/register [post]
+func (h *UserHandler) Signup(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+
+	var req dto.SignupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warnw("invalid signup request", "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppErrorWithDetails(
+			apperrors.BadRequestError,
+			"Invalid request payload",
+			err.Error(),
+		))
+		return
+	}
+
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		h.logger.Warnw("validation error on signup", "error", err, "request_id", requestID)
+		_ = c.Error(err)
+		return
+	}
+
+	ctx := actor.WithClientIP(c.Request.Context(), c.ClientIP())
+	ctx = risk.WithCaptchaToken(ctx, c.GetHeader(risk.CaptchaHeader))
+	user, err := h.service.Signup(ctx, &req)
+	if err != nil {
+		if appErr, ok := apperrors.IsAppError(err); ok {
+			_ = c.Error(appErr)
+			return
+		}
+		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Registration failed"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.NewSuccessResponse(dto.NewUserResponse(user), requestID))
+}
+
+// CheckAvailability godoc
+// @Summary Check whether a username or email is available
+// @Description Reports whether each given value could be used to sign up, without creating anything. Rate limited per client IP (AVAILABILITY_CHECK_RATE).
+// @Tags Auth
+// @Produce json
+// @Param username query string false "Username to check"
+// @Param email query string false "Email to check"
+// @Success 200 {object} dto.AvailabilityResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 429 "Too many checks"
+// @Router /users/check [get]
+func (h *UserHandler) CheckAvailability(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+
+	var q dto.AvailabilityQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		_ = c.Error(apperrors.NewAppErrorWithDetails(apperrors.BadRequestError, "Invalid query", err.Error()))
+		return
+	}
+	q.Username, q.Email = strings.TrimSpace(q.Username), strings.TrimSpace(q.Email)
+	if q.Username == "" && q.Email == "" {
+		_ = c.Error(apperrors.NewAppError(apperrors.BadRequestError, "username or email is required"))
+		return
+	}
+	if err := h.validator.ValidateStruct(&q); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	availability, err := h.service.CheckAvailability(c.Request.Context(), &q)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, response.NewSuccessResponse(availability, requestID))
+}
+
+// VerifyEmail godoc
+// @Summary Verify an email address
+// @Description Opens the link from the email sent after signup and marks the address as verified.
+// @Tags Auth
+// @Produce json
+// @Param token query string true "Token from the verification link"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /auth/verify-email [get]
+// @Router /auth/verify-email [post]
+func (h *UserHandler) VerifyEmail(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+	if _, err := h.service.VerifyEmail(c.Request.Context(), c.Query("token")); err != nil {
+		if appErr, ok := apperrors.IsAppError(err); ok {
+			_ = c.Error(appErr)
+			return
+		}
+		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to verify email"))
+		return
+	}
+	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{"message": "Email address verified"}, requestID))
 }
 
 // UpdateUser godoc
@@ -237,7 +579,7 @@ func (h *UserHandler) Register(c *gin.Context) {
 // @Produce json
 // @Param id path string true "User ID"
 // @Param user body dto.UserUpdateRequest true "Updated user data"
-// @Success 200 {object} response.SuccessResponse
+// @Success 200 {object} dto.UserResponse "dto.AdminUserResponse for callers with users:list"
 // @Failure 400 {object} response.ErrorResponse
 // @Failure 404 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
@@ -277,17 +619,17 @@ func (h *UserHandler) Update(c *gin.Context) {
 		return
 	}
 
-	updated.Password = ""
-	c.JSON(http.StatusOK, response.NewSuccessResponse(updated, requestID))
+	c.JSON(http.StatusOK, response.NewSuccessResponse(h.userView(c, updated), requestID))
 }
 
 // DeleteUser godoc
-// @Summary Delete a user
+// @Summary Delete a user (requires users:delete)
 // @Tags Users
 // @Security BearerAuth
 // @Produce json
 // @Param id path string true "User ID"
 // @Success 204 "No Content"
+// @Failure 403 {object} response.ErrorResponse
 // @Failure 404 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
 // @Router /users/{id} [delete]
@@ -311,3 +653,206 @@ func (h *UserHandler) Delete(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{"message": "user deleted successfully"}, requestID))
 }
+
+// ClearReview godoc
+// @Summary Clear a user's abuse review flag (requires users:review)
+// @Tags Users
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} dto.AdminUserResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /users/{id}/review [delete]
+func (h *UserHandler) ClearReview(c *gin.Context) {
+	requestIDVal, _ := c.Get("RequestID")
+	requestID, ok := requestIDVal.(string)
+	if !ok {
+		requestID = "unknown"
+	}
+
+	user, err := h.service.ClearReview(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if appErr, ok := apperrors.IsAppError(err); ok {
+			_ = c.Error(appErr)
+			return
+		}
+		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to clear review flag"))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(dto.NewAdminUserResponse(user), requestID))
+}
+
+// Suspend godoc
+// @Summary Suspend a user (requires users:status)
+// @Description Blocks the account and signs the user out everywhere. The reason is kept on the user and in the audit log.
+// @Tags Users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body dto.StatusChangeRequest true "Reason"
+// @Success 200 {object} dto.AdminUserResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse "Account was deleted"
+// @Router /users/{id}/suspend [post]
+func (h *UserHandler) Suspend(c *gin.Context) {
+	h.changeStatus(c, model.StatusSuspended)
+}
+
+// Deactivate godoc
+// @Summary Deactivate a user (requires users:status)
+// @Description Marks the account inactive and signs the user out everywhere, e.g. when someone leaves the organization. The reason is kept on the user and in the audit log.
+// @Tags Users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body dto.StatusChangeRequest true "Reason"
+// @Success 200 {object} dto.AdminUserResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse "Account was deleted, or is suspended and has to be reactivated first"
+// @Router /users/{id}/deactivate [post]
+func (h *UserHandler) Deactivate(c *gin.Context) {
+	h.changeStatus(c, model.StatusInactive)
+}
+
+// Activate godoc
+// @Summary Reactivate a suspended or deactivated user (requires users:status)
+// @Tags Users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body dto.StatusChangeRequest false "Optional reason"
+// @Success 200 {object} dto.AdminUserResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse "Account was deleted"
+// @Router /users/{id}/activate [post]
+func (h *UserHandler) Activate(c *gin.Context) {
+	h.changeStatus(c, model.StatusActive)
+}
+
+// changeStatus moves the user in the path to status with the reason in the body
+func (h *UserHandler) changeStatus(c *gin.Context, status string) {
+	requestID := c.GetString("RequestID")
+
+	var req dto.StatusChangeRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			_ = c.Error(apperrors.NewAppErrorWithDetails(apperrors.BadRequestError, "Invalid request payload", err.Error()))
+			return
+		}
+	}
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	user, err := h.service.ChangeStatus(c.Request.Context(), c.Param("id"), status, strings.TrimSpace(req.Reason))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(dto.NewAdminUserResponse(user), requestID))
+}
+
+// History godoc
+// @Summary Get the change history of a user (requires users:history)
+// @Tags Users
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Param offset query int false "Offset for pagination"
+// @Param limit query int false "Limit for pagination"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /users/{id}/history [get]
+func (h *UserHandler) History(c *gin.Context) {
+	requestIDVal, _ := c.Get("RequestID")
+	requestID, ok := requestIDVal.(string)
+	if !ok {
+		requestID = "unknown"
+	}
+
+	offset := 0
+	limit := 50
+
+	if v := c.Query("offset"); v != "" {
+		if _, err := fmt.Sscan(v, &offset); err != nil {
+			_ = c.Error(apperrors.NewAppErrorWithDetails(
+				apperrors.BadRequestError,
+				"Invalid offset value",
+				err.Error(),
+			))
+			return
+		}
+	}
+	if v := c.Query("limit"); v != "" {
+		if _, err := fmt.Sscan(v, &limit); err != nil {
+			_ = c.Error(apperrors.NewAppErrorWithDetails(
+				apperrors.BadRequestError,
+				"Invalid limit value",
+				err.Error(),
+			))
+			return
+		}
+	}
+
+	id := c.Param("id")
+	revisions, err := h.service.History(c.Request.Context(), id, offset, limit)
+	if err != nil {
+		if appErr, ok := apperrors.IsAppError(err); ok {
+			_ = c.Error(appErr)
+			return
+		}
+		h.logger.Errorw("failed to fetch user history", "user_id", id, "error", err, "request_id", requestID)
+		_ = c.Error(apperrors.NewAppError(apperrors.InternalError, "Failed to fetch user history"))
+		return
+	}
+
+	c.Render(http.StatusOK, response.PooledJSON{Data: response.NewSuccessResponse(revisions, requestID)})
+}
+
+// StatusHistory godoc
+// @Summary Get the status changes of a user (requires users:history)
+// @Description Lists every move between active, inactive, suspended and deleted, newest first, with its reason and the admin who made it.
+// @Tags Users
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Param offset query int false "Offset for pagination"
+// @Param limit query int false "Limit for pagination"
+// @Success 200 {array} model.StatusChange
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Router /users/{id}/status-history [get]
+func (h *UserHandler) StatusHistory(c *gin.Context) {
+	offset, limit := 0, 50
+	for name, target := range map[string]*int{"offset": &offset, "limit": &limit} {
+		if v := c.Query(name); v != "" {
+			if _, err := fmt.Sscan(v, target); err != nil {
+				_ = c.Error(apperrors.NewAppErrorWithDetails(apperrors.BadRequestError, "Invalid "+name+" value", err.Error()))
+				return
+			}
+		}
+	}
+
+	changes, err := h.service.StatusHistory(c.Request.Context(), c.Param("id"), offset, limit)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, response.NewSuccessResponse(changes, c.GetString("RequestID")))
+}
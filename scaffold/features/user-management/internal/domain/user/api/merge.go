@@ -0,0 +1,51 @@
+package api
+
+import (
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Merge godoc
+// @Summary Merge a duplicate account into another (requires users:merge)
+// @Description Moves the files, exports, activity feed, linked social logins and tags of the source account to the target, marks the source deleted and signs it out everywhere. Both accounts' change histories and the audit log record the merge. Passkeys, password history and the source's own change history stay with the source.
+// @Tags Users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body dto.MergeRequest true "Source and target accounts"
+// @Success 200 {object} dto.MergeResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse "An account was deleted"
+// @Router /users/merge [post]
+func (h *UserHandler) Merge(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+
+	var req dto.MergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.NewAppErrorWithDetails(apperrors.BadRequestError, "Invalid request payload", err.Error()))
+		return
+	}
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	ctx := actor.WithClientIP(c.Request.Context(), c.ClientIP())
+	target, moved, err := h.service.Merge(ctx, req.SourceID, req.TargetID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewSuccessResponse(dto.MergeResponse{
+		Target: dto.NewAdminUserResponse(target),
+		Moved:  moved,
+	}, requestID))
+}
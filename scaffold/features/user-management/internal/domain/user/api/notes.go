@@ -0,0 +1,122 @@
+package api
+
+import (
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListNotes godoc
+// @Summary List the notes on a user (requires users:notes)
+// @Description Returns the notes admins wrote on the account, newest first. The user never sees them.
+// @Tags Users
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {array} model.UserNote
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /users/{id}/notes [get]
+func (h *UserHandler) ListNotes(c *gin.Context) {
+	notes, err := h.service.Notes(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, response.NewSuccessResponse(notes, c.GetString("RequestID")))
+}
+
+// AddNote godoc
+// @Summary Write a note on a user (requires users:notes)
+// @Tags Users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body dto.NoteRequest true "Note"
+// @Success 201 {object} model.UserNote
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /users/{id}/notes [post]
+func (h *UserHandler) AddNote(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+
+	var req dto.NoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.NewAppErrorWithDetails(apperrors.BadRequestError, "Invalid request payload", err.Error()))
+		return
+	}
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	ctx := actor.WithClientIP(c.Request.Context(), c.ClientIP())
+	note, err := h.service.AddNote(ctx, c.Param("id"), req.Text)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, response.NewSuccessResponse(note, requestID))
+}
+
+// UpdateNote godoc
+// @Summary Edit a note on a user (requires users:notes)
+// @Description Replaces the text of the note. Only its author can edit it.
+// @Tags Users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param noteId path string true "Note ID"
+// @Param request body dto.NoteRequest true "Note"
+// @Success 200 {object} model.UserNote
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse "Missing permission, or the caller did not write the note"
+// @Failure 404 {object} response.ErrorResponse
+// @Router /users/{id}/notes/{noteId} [put]
+func (h *UserHandler) UpdateNote(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+
+	var req dto.NoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.NewAppErrorWithDetails(apperrors.BadRequestError, "Invalid request payload", err.Error()))
+		return
+	}
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	ctx := actor.WithClientIP(c.Request.Context(), c.ClientIP())
+	note, err := h.service.UpdateNote(ctx, c.Param("id"), c.Param("noteId"), req.Text)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, response.NewSuccessResponse(note, requestID))
+}
+
+// DeleteNote godoc
+// @Summary Delete a note on a user (requires users:notes)
+// @Tags Users
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Param noteId path string true "Note ID"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /users/{id}/notes/{noteId} [delete]
+func (h *UserHandler) DeleteNote(c *gin.Context) {
+	ctx := actor.WithClientIP(c.Request.Context(), c.ClientIP())
+	if err := h.service.DeleteNote(ctx, c.Param("id"), c.Param("noteId")); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, response.NewSuccessResponse(gin.H{"message": "note deleted successfully"}, c.GetString("RequestID")))
+}
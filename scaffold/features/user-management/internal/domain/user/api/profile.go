@@ -0,0 +1,65 @@
+package api
+
+import (
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetProfile godoc
+// @Summary Get your profile
+// @Description Returns the account of the caller.
+// @Tags Users
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} dto.UserResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /me/profile [get]
+func (h *UserHandler) GetProfile(c *gin.Context) {
+	user, err := h.service.GetByID(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, response.NewSuccessResponse(dto.NewUserResponse(user), c.GetString("RequestID")))
+}
+
+// UpdateProfile godoc
+// @Summary Update your profile
+// @Description Changes the given fields of the caller's account; omitted fields are kept. The role, status and password cannot be changed here. A new email or phone number has to be verified again.
+// @Tags Users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param profile body dto.ProfileUpdateRequest true "Fields to change"
+// @Success 200 {object} dto.UserResponse
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
+// @Router /me/profile [patch]
+func (h *UserHandler) UpdateProfile(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+
+	var req dto.ProfileUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.NewAppErrorWithDetails(apperrors.BadRequestError, "Invalid request payload", err.Error()))
+		return
+	}
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	ctx := actor.WithClientIP(c.Request.Context(), c.ClientIP())
+	user, err := h.service.Update(ctx, c.GetString("userID"), req.UpdateRequest())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, response.NewSuccessResponse(dto.NewUserResponse(user), requestID))
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListTags godoc
+// @Summary List the tags in use (requires users:tags)
+// @Description Returns every tag with how many users that are not deleted have it, in alphabetical order.
+// @Tags Users
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} model.TagCount
+// @Failure 403 {object} response.ErrorResponse
+// @Router /users/tags [get]
+func (h *UserHandler) ListTags(c *gin.Context) {
+	counts, err := h.service.TagCounts(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, response.NewSuccessResponse(counts, c.GetString("RequestID")))
+}
+
+// UserTags godoc
+// @Summary List the tags of a user (requires users:tags)
+// @Tags Users
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {array} string
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /users/{id}/tags [get]
+func (h *UserHandler) UserTags(c *gin.Context) {
+	tags, err := h.service.Tags(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, response.NewSuccessResponse(tags, c.GetString("RequestID")))
+}
+
+// AddTags godoc
+// @Summary Tag a user (requires users:tags)
+// @Description Puts the tags on the user, lowercased, and returns all of its tags. Tags the user already has are kept.
+// @Tags Users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param request body dto.TagsRequest true "Tags to add"
+// @Success 200 {array} string
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /users/{id}/tags [post]
+func (h *UserHandler) AddTags(c *gin.Context) {
+	requestID := c.GetString("RequestID")
+
+	var req dto.TagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(apperrors.NewAppErrorWithDetails(apperrors.BadRequestError, "Invalid request payload", err.Error()))
+		return
+	}
+	if err := h.validator.ValidateStruct(&req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	ctx := actor.WithClientIP(c.Request.Context(), c.ClientIP())
+	tags, err := h.service.AddTags(ctx, c.Param("id"), req.Tags)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, response.NewSuccessResponse(tags, requestID))
+}
+
+// RemoveTag godoc
+// @Summary Take a tag off a user (requires users:tags)
+// @Tags Users
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "User ID"
+// @Param tag path string true "Tag"
+// @Success 200 {array} string "The remaining tags"
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse "Unknown user, or the user does not have the tag"
+// @Router /users/{id}/tags/{tag} [delete]
+func (h *UserHandler) RemoveTag(c *gin.Context) {
+	ctx := actor.WithClientIP(c.Request.Context(), c.ClientIP())
+	tags, err := h.service.RemoveTag(ctx, c.Param("id"), c.Param("tag"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, response.NewSuccessResponse(tags, c.GetString("RequestID")))
+}
@@ -1,41 +1,310 @@
 package dto
 
+import "time"
+
 // UserCreateRequest represents the payload for creating a user
 // swagger:model
 type UserCreateRequest struct {
 	// FirstName of the user
 	// Required: true
 	// Example: John
-	FirstName string `json:"first_name" validate:"required,min=2,max=100"`
+	FirstName string `json:"first_name" validate:"required,min=2,max=100" example:"John"`
 
 	// SecondName of the user
 	// Example: Michael
-	SecondName string `json:"second_name" validate:"omitempty,min=2,max=100"`
+	SecondName string `json:"second_name" validate:"omitempty,min=2,max=100" example:"Michael"`
 
 	// LastName of the user
 	// Required: true
 	// Example: Doe
-	LastName string `json:"last_name" validate:"required,min=2,max=100"`
+	LastName string `json:"last_name" validate:"required,min=2,max=100" example:"Doe"`
 
 	// Username of the user
 	// Required: true
 	// Example: johndoe123
-	Username string `json:"username" validate:"required,alphanum,min=3,max=50"`
+	Username string `json:"username" validate:"required,username_policy,min=3,max=50" example:"johndoe123"`
 
 	// Email of the user
 	// Required: true
 	// Example: john.doe@example.com
-	Email string `json:"email" validate:"required,email"`
+	Email string `json:"email" validate:"required,email" example:"john.doe@example.com"`
+
+	// Phone number, international format preferred
+	// Example: +14155552671
+	Phone string `json:"phone" validate:"omitempty,max=32" example:"+14155552671"`
+
+	// TimeZone is the preferred IANA time zone for response timestamps
+	// Example: Europe/Berlin
+	TimeZone string `json:"timezone" validate:"omitempty,max=64,timezone" example:"Europe/Berlin"`
+
+	// Locale is the preferred BCP 47 language tag
+	// Example: de-DE
+	Locale string `json:"locale" validate:"omitempty,max=35,bcp47_language_tag" example:"de-DE"`
+
+	// Metadata holds custom profile fields defined by an admin
+	// Example: {"department":"engineering"}
+	Metadata map[string]interface{} `json:"metadata,omitempty" example:"department:engineering"`
+
+	// Password for the user account
+	// Required: true
+	// Example: StrongP@ssw0rd
+	Password string `json:"password" validate:"required,min=8,strong_password" example:"StrongP@ssw0rd"`
+
+	// UserType defines the role of the user: user, admin or a role defined under /roles
+	// Example: user
+	UserType string `json:"user_type" validate:"omitempty,max=20" example:"user"`
+}
+
+// SignupRequest is the payload for self-service registration. Unlike UserCreateRequest it
+// has no user_type: new accounts always get the default "user" role.
+// swagger:model
+type SignupRequest struct {
+	// FirstName of the user
+	// Required: true
+	// Example: John
+	FirstName string `json:"first_name" validate:"required,min=2,max=100" example:"John"`
+
+	// SecondName of the user
+	// Example: Michael
+	SecondName string `json:"second_name" validate:"omitempty,min=2,max=100" example:"Michael"`
+
+	// LastName of the user
+	// Required: true
+	// Example: Doe
+	LastName string `json:"last_name" validate:"required,min=2,max=100" example:"Doe"`
+
+	// Username of the user
+	// Required: true
+	// Example: johndoe123
+	Username string `json:"username" validate:"required,username_policy,min=3,max=50" example:"johndoe123"`
+
+	// Email of the user; a verification link is sent to it
+	// Required: true
+	// Example: john.doe@example.com
+	Email string `json:"email" validate:"required,email" example:"john.doe@example.com"`
+
+	// Phone number, international format preferred
+	// Example: +14155552671
+	Phone string `json:"phone" validate:"omitempty,max=32" example:"+14155552671"`
+
+	// TimeZone is the preferred IANA time zone for response timestamps
+	// Example: Europe/Berlin
+	TimeZone string `json:"timezone" validate:"omitempty,max=64,timezone" example:"Europe/Berlin"`
+
+	// Locale is the preferred BCP 47 language tag
+	// Example: de-DE
+	Locale string `json:"locale" validate:"omitempty,max=35,bcp47_language_tag" example:"de-DE"`
+
+	// Metadata holds custom profile fields defined by an admin
+	// Example: {"department":"engineering"}
+	Metadata map[string]interface{} `json:"metadata,omitempty" example:"department:engineering"`
 
 	// Password for the user account
 	// Required: true
 	// Example: StrongP@ssw0rd
-	Password string `json:"password" validate:"required,min=8"`
+	Password string `json:"password" validate:"required,min=8,strong_password" example:"StrongP@ssw0rd"`
+}
+
+// CreateRequest returns the equivalent UserCreateRequest with the default role
+func (r *SignupRequest) CreateRequest() *UserCreateRequest {
+	return &UserCreateRequest{
+		FirstName:  r.FirstName,
+		SecondName: r.SecondName,
+		LastName:   r.LastName,
+		Username:   r.Username,
+		Email:      r.Email,
+		Phone:      r.Phone,
+		TimeZone:   r.TimeZone,
+		Locale:     r.Locale,
+		Metadata:   r.Metadata,
+		Password:   r.Password,
+	}
+}
+
+// MergeRequest names the duplicate account to fold into another
+// swagger:model
+type MergeRequest struct {
+	// Account whose records move; it is marked deleted
+	// Required: true
+	// Example: 7c9e6679-7425-40de-944b-e07fc1f90ae7
+	SourceID string `json:"source_id" validate:"required,uuid" example:"7c9e6679-7425-40de-944b-e07fc1f90ae7"`
+
+	// Account that receives the records and stays
+	// Required: true
+	// Example: 123e4567-e89b-12d3-a456-426614174000
+	TargetID string `json:"target_id" validate:"required,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+}
+
+// Operations of a BatchRequest
+const (
+	BatchOpDelete       = "delete"
+	BatchOpChangeRole   = "change_role"
+	BatchOpChangeStatus = "change_status"
+)
+
+// BatchOperation is one change of a BatchRequest
+// swagger:model
+type BatchOperation struct {
+	// One of delete, change_role and change_status
+	// Required: true
+	// Example: change_status
+	Op string `json:"op" validate:"required,oneof=delete change_role change_status" example:"change_status"`
+
+	// Required: true
+	// Example: 123e4567-e89b-12d3-a456-426614174000
+	UserID string `json:"user_id" validate:"required,uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+
+	// New role, for change_role
+	// Example: admin
+	Role string `json:"role,omitempty" validate:"required_if=Op change_role,omitempty,max=20" example:"admin"`
+
+	// New status, for change_status: active, inactive or suspended
+	// Example: suspended
+	Status string `json:"status,omitempty" validate:"required_if=Op change_status,omitempty,oneof=active inactive suspended" example:"suspended"`
+
+	// Reason for a status change; required to suspend or deactivate
+	// Example: Chargeback under investigation
+	Reason string `json:"reason,omitempty" validate:"omitempty,max=500" example:"Chargeback under investigation"`
+}
+
+// BatchRequest is a list of user changes applied together
+// swagger:model
+type BatchRequest struct {
+	// Required: true
+	Operations []BatchOperation `json:"operations" validate:"required,min=1,max=100,dive"`
+}
+
+// NoteRequest is the text of a note on a user account
+// swagger:model
+type NoteRequest struct {
+	// Required: true
+	// Example: Called about a double charge, refund issued
+	Text string `json:"text" validate:"required,max=5000" example:"Called about a double charge, refund issued"`
+}
+
+// TagsRequest lists tags to put on a user
+// swagger:model
+type TagsRequest struct {
+	// Up to 50 letters, digits, '_', '.', ':' and '-' each; stored lowercased
+	// Required: true
+	// Example: ["beta","rollout:new-checkout"]
+	Tags []string `json:"tags" validate:"required,min=1,max=20,dive,required,max=50" example:"beta,rollout:new-checkout"`
+}
+
+// AvailabilityQuery holds the username and email to check before signing up; at least
+// one is required
+type AvailabilityQuery struct {
+	Username string `form:"username" validate:"omitempty,username_policy,min=3,max=50"`
+	Email    string `form:"email" validate:"omitempty,email,max=254"`
+}
+
+// Availability reports whether a username or email can still be registered
+type Availability struct {
+	Available bool `json:"available" example:"false"`
+	// Reason is "taken", or "blocked" for a disposable email domain
+	Reason string `json:"reason,omitempty" example:"taken"`
+}
+
+// AvailabilityResponse has an entry for each value checked
+// swagger:model
+type AvailabilityResponse struct {
+	Username *Availability `json:"username,omitempty"`
+	Email    *Availability `json:"email,omitempty"`
+}
 
-	// UserType defines the role of the user
-	// Enum: user, admin
+// InvitationRequest is the payload for inviting someone to register
+// swagger:model
+type InvitationRequest struct {
+	// Email the invitation is sent to; the account is created with this address
+	// Required: true
+	// Example: jane.roe@example.com
+	Email string `json:"email" validate:"required,email" example:"jane.roe@example.com"`
+
+	// Role the account gets: user, admin or a role defined under /roles
 	// Example: user
-	UserType string `json:"user_type" validate:"omitempty,oneof=user admin"`
+	Role string `json:"role" validate:"omitempty,max=20" example:"user"`
+
+	// ExpiresInHours overrides INVITE_TTL for this invitation, up to 30 days
+	// Example: 72
+	ExpiresInHours int `json:"expires_in_hours" validate:"omitempty,min=1,max=720" example:"72"`
+}
+
+// InvitationResponse describes a sent invitation
+// swagger:model
+type InvitationResponse struct {
+	Email     string    `json:"email" example:"jane.roe@example.com"`
+	Role      string    `json:"role" example:"user"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// URL is the invitation link, for sharing it another way when email is off
+	URL string `json:"url" example:"http://localhost:3000/accept-invite?token=..."`
+	// EmailSent reports whether the link was emailed to the invitee
+	EmailSent bool `json:"email_sent"`
+}
+
+// AcceptInviteRequest is the payload that completes an invited registration. Email and
+// role come from the invitation.
+// swagger:model
+type AcceptInviteRequest struct {
+	// Token from the invitation link
+	// Required: true
+	Token string `json:"token" validate:"required,max=128"`
+
+	// FirstName of the user
+	// Required: true
+	// Example: Jane
+	FirstName string `json:"first_name" validate:"required,min=2,max=100" example:"Jane"`
+
+	// SecondName of the user
+	// Example: Marie
+	SecondName string `json:"second_name" validate:"omitempty,min=2,max=100" example:"Marie"`
+
+	// LastName of the user
+	// Required: true
+	// Example: Roe
+	LastName string `json:"last_name" validate:"required,min=2,max=100" example:"Roe"`
+
+	// Username of the user
+	// Required: true
+	// Example: janeroe
+	Username string `json:"username" validate:"required,username_policy,min=3,max=50" example:"janeroe"`
+
+	// Phone number, international format preferred
+	// Example: +14155552671
+	Phone string `json:"phone" validate:"omitempty,max=32" example:"+14155552671"`
+
+	// TimeZone is the preferred IANA time zone for response timestamps
+	// Example: Europe/Berlin
+	TimeZone string `json:"timezone" validate:"omitempty,max=64,timezone" example:"Europe/Berlin"`
+
+	// Locale is the preferred BCP 47 language tag
+	// Example: de-DE
+	Locale string `json:"locale" validate:"omitempty,max=35,bcp47_language_tag" example:"de-DE"`
+
+	// Metadata holds custom profile fields defined by an admin
+	// Example: {"department":"engineering"}
+	Metadata map[string]interface{} `json:"metadata,omitempty" example:"department:engineering"`
+
+	// Password for the user account
+	// Required: true
+	// Example: StrongP@ssw0rd
+	Password string `json:"password" validate:"required,min=8,strong_password" example:"StrongP@ssw0rd"`
+}
+
+// CreateRequest returns the equivalent UserCreateRequest for the invited email and role
+func (r *AcceptInviteRequest) CreateRequest(email, role string) *UserCreateRequest {
+	return &UserCreateRequest{
+		FirstName:  r.FirstName,
+		SecondName: r.SecondName,
+		LastName:   r.LastName,
+		Username:   r.Username,
+		Email:      email,
+		Phone:      r.Phone,
+		TimeZone:   r.TimeZone,
+		Locale:     r.Locale,
+		Metadata:   r.Metadata,
+		Password:   r.Password,
+		UserType:   role,
+	}
 }
 
 // UserUpdateRequest represents the payload for updating a user
@@ -43,30 +312,154 @@ type UserCreateRequest struct {
 type UserUpdateRequest struct {
 	// FirstName of the user
 	// Example: John
-	FirstName string `json:"first_name" validate:"omitempty,min=2,max=100"`
+	FirstName string `json:"first_name" validate:"omitempty,min=2,max=100" example:"John"`
 
 	// SecondName of the user
 	// Example: Michael
-	SecondName string `json:"second_name" validate:"omitempty,min=2,max=100"`
+	SecondName string `json:"second_name" validate:"omitempty,min=2,max=100" example:"Michael"`
 
 	// LastName of the user
 	// Example: Doe
-	LastName string `json:"last_name" validate:"omitempty,min=2,max=100"`
+	LastName string `json:"last_name" validate:"omitempty,min=2,max=100" example:"Doe"`
 
 	// Username of the user
 	// Example: johndoe123
-	Username string `json:"username" validate:"omitempty,alphanum,min=3,max=50"`
+	Username string `json:"username" validate:"omitempty,username_policy,min=3,max=50" example:"johndoe123"`
 
 	// Email of the user
 	// Example: john.doe@example.com
-	Email string `json:"email" validate:"omitempty,email"`
+	Email string `json:"email" validate:"omitempty,email" example:"john.doe@example.com"`
+
+	// Phone number, international format preferred
+	// Example: +14155552671
+	Phone string `json:"phone" validate:"omitempty,max=32" example:"+14155552671"`
+
+	// SMSTwoFactor requires an SMS code on password login (needs a verified phone number)
+	// Example: true
+	SMSTwoFactor *bool `json:"sms_two_factor,omitempty" example:"true"`
+
+	// AnnouncementsOptOut stops announcements sent by admins
+	// Example: true
+	AnnouncementsOptOut *bool `json:"announcements_opt_out,omitempty" example:"true"`
+
+	// LoginAlertsOptOut stops emails about logins from new devices
+	// Example: true
+	LoginAlertsOptOut *bool `json:"login_alerts_opt_out,omitempty" example:"true"`
+
+	// TimeZone is the preferred IANA time zone for response timestamps
+	// Example: Europe/Berlin
+	TimeZone string `json:"timezone" validate:"omitempty,max=64,timezone" example:"Europe/Berlin"`
+
+	// Locale is the preferred BCP 47 language tag
+	// Example: de-DE
+	Locale string `json:"locale" validate:"omitempty,max=35,bcp47_language_tag" example:"de-DE"`
+
+	// Metadata updates custom profile fields; omitted keys are kept and null removes a key
+	// Example: {"department":"sales"}
+	Metadata map[string]interface{} `json:"metadata,omitempty" example:"department:sales"`
 
 	// Password for the user account
 	// Example: StrongP@ssw0rd
-	Password string `json:"password" validate:"omitempty,min=8"`
+	Password string `json:"password" validate:"omitempty,min=8,strong_password" example:"StrongP@ssw0rd"`
 
-	// UserType defines the role of the user
-	// Enum: user, admin
+	// UserType defines the role of the user: user, admin or a role defined under /roles
 	// Example: user
-	UserType string `json:"user_type" validate:"omitempty,oneof=user admin"`
+	UserType string `json:"user_type" validate:"omitempty,max=20" example:"user"`
+}
+
+// ProfileUpdateRequest is what users may change on their own account through
+// PATCH /me/profile. It has no role, status or password, so those cannot be changed there.
+// swagger:model
+type ProfileUpdateRequest struct {
+	// Example: John
+	FirstName string `json:"first_name" validate:"omitempty,min=2,max=100" example:"John"`
+
+	// Example: Michael
+	SecondName string `json:"second_name" validate:"omitempty,min=2,max=100" example:"Michael"`
+
+	// Example: Doe
+	LastName string `json:"last_name" validate:"omitempty,min=2,max=100" example:"Doe"`
+
+	// Example: johndoe123
+	Username string `json:"username" validate:"omitempty,username_policy,min=3,max=50" example:"johndoe123"`
+
+	// A new email has to be verified again
+	// Example: john.doe@example.com
+	Email string `json:"email" validate:"omitempty,email" example:"john.doe@example.com"`
+
+	// A new phone number has to be verified again
+	// Example: +14155552671
+	Phone string `json:"phone" validate:"omitempty,max=32" example:"+14155552671"`
+
+	// Example: true
+	SMSTwoFactor *bool `json:"sms_two_factor,omitempty" example:"true"`
+
+	// Example: true
+	AnnouncementsOptOut *bool `json:"announcements_opt_out,omitempty" example:"true"`
+
+	// Example: true
+	LoginAlertsOptOut *bool `json:"login_alerts_opt_out,omitempty" example:"true"`
+
+	// Example: Europe/Berlin
+	TimeZone string `json:"timezone" validate:"omitempty,max=64,timezone" example:"Europe/Berlin"`
+
+	// Example: de-DE
+	Locale string `json:"locale" validate:"omitempty,max=35,bcp47_language_tag" example:"de-DE"`
+
+	// Custom profile fields; omitted keys are kept and null removes a key
+	// Example: {"department":"sales"}
+	Metadata map[string]interface{} `json:"metadata,omitempty" example:"department:sales"`
+}
+
+// UpdateRequest returns the equivalent UserUpdateRequest
+func (r *ProfileUpdateRequest) UpdateRequest() *UserUpdateRequest {
+	return &UserUpdateRequest{
+		FirstName:           r.FirstName,
+		SecondName:          r.SecondName,
+		LastName:            r.LastName,
+		Username:            r.Username,
+		Email:               r.Email,
+		Phone:               r.Phone,
+		SMSTwoFactor:        r.SMSTwoFactor,
+		AnnouncementsOptOut: r.AnnouncementsOptOut,
+		LoginAlertsOptOut:   r.LoginAlertsOptOut,
+		TimeZone:            r.TimeZone,
+		Locale:              r.Locale,
+		Metadata:            r.Metadata,
+	}
+}
+
+// StatusChangeRequest is the payload for suspending, deactivating or reactivating a user
+// swagger:model
+type StatusChangeRequest struct {
+	// Reason for the change, kept on the user and in the audit log; required to suspend
+	// or deactivate
+	// Example: Chargeback under investigation
+	Reason string `json:"reason" validate:"omitempty,max=500" example:"Chargeback under investigation"`
+}
+
+// ProfileFieldRequest represents the payload for defining a custom profile field
+// swagger:model
+type ProfileFieldRequest struct {
+	// Key under which values are stored in user metadata
+	// Required: true
+	// Example: department
+	Key string `json:"key" validate:"required,max=64" example:"department"`
+
+	// Label shown to people editing the profile
+	// Example: Department
+	Label string `json:"label" validate:"omitempty,max=100" example:"Department"`
+
+	// Type of the value
+	// Enum: string, number, boolean, date
+	// Example: string
+	Type string `json:"type" validate:"required,oneof=string number boolean date" example:"string"`
+
+	// Required makes the field mandatory whenever metadata is written
+	// Example: false
+	Required bool `json:"required" example:"false"`
+
+	// Choices restricts string values to a fixed list
+	// Example: ["engineering","sales"]
+	Choices []string `json:"choices" validate:"omitempty,dive,min=1,max=100" example:"engineering,sales"`
 }
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidCursor is returned for a list cursor that was not produced by ListCursor.Encode
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ListCursor is the position of the last user of a keyset page: users are ordered by
+// (created_at, id), and the next page starts after this pair
+type ListCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"id"`
+}
+
+// CursorAfter returns the cursor that continues a list after u
+func CursorAfter(u *User) *ListCursor {
+	return &ListCursor{CreatedAt: u.CreatedAt, ID: u.ID}
+}
+
+// Encode returns the opaque form of c handed to clients
+func (c *ListCursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeListCursor parses a cursor returned by Encode
+func DecodeListCursor(s string) (*ListCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c ListCursor
+	if err := json.Unmarshal(b, &c); err != nil || c.ID == uuid.Nil || c.CreatedAt.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
@@ -0,0 +1,44 @@
+package model
+
+import "strings"
+
+// EmailNormalizer canonicalizes email addresses so that addresses which only
+// differ in case, surrounding whitespace or (optionally) Gmail aliasing resolve
+// to the same account
+type EmailNormalizer struct {
+	// FoldGmail strips dots and "+tag" suffixes from gmail.com / googlemail.com local parts
+	FoldGmail bool
+}
+
+// Normalize returns the canonical form of addr used for uniqueness checks and lookups
+func (n EmailNormalizer) Normalize(addr string) string {
+	addr = strings.ToLower(strings.TrimSpace(addr))
+
+	at := strings.LastIndex(addr, "@")
+	if at <= 0 || at == len(addr)-1 {
+		return addr
+	}
+	local, domain := addr[:at], addr[at+1:]
+
+	if n.FoldGmail && (domain == "gmail.com" || domain == "googlemail.com") {
+		if plus := strings.Index(local, "+"); plus >= 0 {
+			local = local[:plus]
+		}
+		local = strings.ReplaceAll(local, ".", "")
+		domain = "gmail.com"
+	}
+
+	return local + "@" + domain
+}
+
+// CanonicalEmail returns addr as it is stored: trimmed, with the domain lowercased. The
+// local part keeps its case, since mail servers may treat it as case-sensitive; the
+// unique index on LOWER(email) still keeps addresses differing only in case apart.
+func CanonicalEmail(addr string) string {
+	addr = strings.TrimSpace(addr)
+	at := strings.LastIndex(addr, "@")
+	if at <= 0 {
+		return addr
+	}
+	return addr[:at+1] + strings.ToLower(addr[at+1:])
+}
@@ -0,0 +1,23 @@
+package model
+
+// MergeReport counts the records moved from a merged account to the account it was
+// merged into
+// swagger:model
+type MergeReport struct {
+	// example: 12
+	Files int64 `json:"files" example:"12"`
+	// example: 2
+	Exports int64 `json:"exports" example:"2"`
+	// Activity feed entries
+	// example: 40
+	Activities int64 `json:"activities" example:"40"`
+	// Linked social logins
+	// example: 1
+	OAuthIdentities int64 `json:"oauth_identities" example:"1"`
+	// Admin notes on the account
+	// example: 3
+	Notes int64 `json:"notes" example:"3"`
+	// Tags the target did not have yet
+	// example: 2
+	Tags int64 `json:"tags" example:"2"`
+}
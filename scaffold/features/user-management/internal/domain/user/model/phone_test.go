@@ -0,0 +1,41 @@
+package model
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		defaultRegion string
+		want          string
+		wantErr       bool
+	}{
+		{name: "Already E.164", input: "+14155552671", want: "+14155552671"},
+		{name: "Formatted international", input: "+44 20 7946 0958", want: "+442079460958"},
+		{name: "National with default region", input: "(415) 555-2671", defaultRegion: "us", want: "+14155552671"},
+		{name: "National without region", input: "4155552671", wantErr: true},
+		{name: "Too short", input: "+1415", wantErr: true},
+		{name: "Empty", input: "  ", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizePhone(tt.input, tt.defaultRegion)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidPhoneNumber) {
+					t.Fatalf("NormalizePhone(%q) error = %v, want ErrInvalidPhoneNumber", tt.input, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NormalizePhone(%q) error = %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizePhone(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package model
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/shared/id"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RevisionAction describes what happened to the user record
+// swagger:enum RevisionAction
+type RevisionAction string
+
+const (
+	// RevisionCreated the user was registered
+	RevisionCreated RevisionAction = "created"
+	// RevisionUpdated one field of the user changed
+	RevisionUpdated RevisionAction = "updated"
+	// RevisionDeleted the user was deleted
+	RevisionDeleted RevisionAction = "deleted"
+	// RevisionMerged the user was merged into another one, or another one into it
+	RevisionMerged RevisionAction = "merged"
+)
+
+// RedactedValue replaces secrets, and the values of anonymized users, in revision history
+const RedactedValue = "[redacted]"
+
+// UserRevision is one field-level change to a user, recorded append-only
+// swagger:model UserRevision
+type UserRevision struct {
+	// format: uuid
+	ID uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+
+	// User the change applies to
+	// format: uuid
+	UserID uuid.UUID `gorm:"type:uuid;not null;index:idx_user_revisions_user_created,priority:1" json:"user_id"`
+
+	// What happened
+	// enum: created,updated,deleted,merged
+	Action RevisionAction `gorm:"type:varchar(20);not null" json:"action"`
+
+	// Changed field (JSON name; metadata keys appear as metadata.<key>); empty for
+	// deletions, merged_into or merged_from for merges
+	// example: email
+	Field string `gorm:"size:100" json:"field,omitempty" example:"email"`
+
+	// Value before the change; null when the field was unset
+	OldValue *string `gorm:"type:text" json:"old_value"`
+
+	// Value after the change; null when the field was cleared
+	NewValue *string `gorm:"type:text" json:"new_value"`
+
+	// User who made the change; null for self-registration and system changes
+	// format: uuid
+	ActorID *uuid.UUID `gorm:"type:uuid" json:"actor_id,omitempty"`
+
+	// format: date-time
+	CreatedAt time.Time `gorm:"autoCreateTime;index:idx_user_revisions_user_created,priority:2" json:"created_at"`
+}
+
+// BeforeCreate hook to generate UUID before inserting
+func (r *UserRevision) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = id.New()
+	}
+	return nil
+}
+
+// TableName sets the insert table name for this struct type
+func (UserRevision) TableName() string {
+	return "user_revisions"
+}
+
+// revisionFields lists the tracked user fields by JSON name
+func revisionFields(u *User) map[string]*string {
+	str := func(s string) *string {
+		if s == "" {
+			return nil
+		}
+		return &s
+	}
+	fields := map[string]*string{
+		"first_name":            str(u.FirstName),
+		"second_name":           str(u.SecondName),
+		"last_name":             str(u.LastName),
+		"username":              str(u.Username),
+		"email":                 str(u.Email),
+		"phone":                 nil,
+		"sms_two_factor":        str(strconv.FormatBool(u.SMSTwoFactor)),
+		"announcements_opt_out": str(strconv.FormatBool(u.AnnouncementsOptOut)),
+		"login_alerts_opt_out":  str(strconv.FormatBool(u.LoginAlertsOptOut)),
+		"timezone":              str(u.TimeZone),
+		"locale":                str(u.Locale),
+		"password":              nil,
+		"user_type":             str(string(u.UserType)),
+		"status":                str(u.Status),
+		"status_reason":         u.StatusReason,
+		"review_reason":         u.ReviewReason,
+	}
+	if u.HasPhone() {
+		fields["phone"] = str(*u.Phone)
+	}
+	if u.Password != "" {
+		fields["password"] = str(u.Password)
+	}
+	for key, value := range u.Metadata {
+		if value == nil {
+			continue
+		}
+		b, err := json.Marshal(value)
+		if err != nil {
+			continue
+		}
+		fields["metadata."+key] = str(string(b))
+	}
+	return fields
+}
+
+// DiffUser returns one revision per field that differs between before and after.
+// A nil before produces a creation record of every set field. Password values are
+// never stored; only the fact that the password changed is.
+func DiffUser(before, after *User, actorID *uuid.UUID) []UserRevision {
+	action := RevisionUpdated
+	old := map[string]*string{}
+	if before == nil {
+		action = RevisionCreated
+	} else {
+		old = revisionFields(before)
+	}
+	current := revisionFields(after)
+
+	keys := make(map[string]struct{}, len(current))
+	for k := range old {
+		keys[k] = struct{}{}
+	}
+	for k := range current {
+		keys[k] = struct{}{}
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var revisions []UserRevision
+	for _, field := range sorted {
+		oldValue, newValue := old[field], current[field]
+		if equalValues(oldValue, newValue) {
+			continue
+		}
+		if field == "password" {
+			oldValue, newValue = redact(oldValue), redact(newValue)
+		}
+		revisions = append(revisions, UserRevision{
+			UserID:   after.ID,
+			Action:   action,
+			Field:    field,
+			OldValue: oldValue,
+			NewValue: newValue,
+			ActorID:  actorID,
+		})
+	}
+	return revisions
+}
+
+func equalValues(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func redact(v *string) *string {
+	if v == nil {
+		return nil
+	}
+	r := RedactedValue
+	return &r
+}
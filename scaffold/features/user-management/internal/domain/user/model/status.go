@@ -0,0 +1,65 @@
+package model
+
+import (
+	"slices"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/shared/id"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// statusTransitions lists the statuses each account status may move to. Deleted is final,
+// and a suspension has to be lifted before the account can be deactivated, so its reason
+// is not silently replaced.
+var statusTransitions = map[string][]string{
+	StatusActive:    {StatusInactive, StatusSuspended, StatusDeleted},
+	StatusInactive:  {StatusActive, StatusSuspended, StatusDeleted},
+	StatusSuspended: {StatusActive, StatusDeleted},
+	StatusDeleted:   {},
+}
+
+// CanTransition reports whether an account may move from status from to status to
+func CanTransition(from, to string) bool {
+	return slices.Contains(statusTransitions[from], to)
+}
+
+// StatusChange is one move of an account between statuses, recorded append-only
+// swagger:model StatusChange
+type StatusChange struct {
+	// format: uuid
+	ID uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+
+	// format: uuid
+	UserID uuid.UUID `gorm:"type:uuid;not null;index:idx_user_status_history_user_created,priority:1" json:"user_id"`
+
+	// example: active
+	FromStatus string `gorm:"size:20;not null" json:"from" example:"active"`
+
+	// example: suspended
+	ToStatus string `gorm:"size:20;not null" json:"to" example:"suspended"`
+
+	// Reason given for the change; null when none was needed
+	// example: Chargeback fraud under investigation
+	Reason *string `gorm:"type:text" json:"reason" example:"Chargeback fraud under investigation"`
+
+	// User who made the change; null for system changes such as anonymization
+	// format: uuid
+	ActorID *uuid.UUID `gorm:"type:uuid" json:"actor_id,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime;index:idx_user_status_history_user_created,priority:2" json:"created_at"`
+}
+
+// BeforeCreate hook to generate UUID before inserting
+func (c *StatusChange) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = id.New()
+	}
+	return nil
+}
+
+// TableName sets the insert table name for this struct type
+func (StatusChange) TableName() string {
+	return "user_status_history"
+}
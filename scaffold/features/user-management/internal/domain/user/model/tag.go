@@ -0,0 +1,40 @@
+package model
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserTag puts a free-form tag on a user, e.g. to group them into cohorts or feature
+// rollouts. A user has each tag at most once.
+type UserTag struct {
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Tag       string    `gorm:"size:50;primaryKey;index:idx_user_tags_tag"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// TableName sets the insert table name for this struct type
+func (UserTag) TableName() string {
+	return "user_tags"
+}
+
+// TagCount is a tag and how many users that are not deleted have it
+// swagger:model
+type TagCount struct {
+	// example: beta
+	Tag string `json:"tag" example:"beta"`
+	// example: 42
+	Users int64 `json:"users" example:"42"`
+}
+
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.:-]{0,49}$`)
+
+// NormalizeTag trims and lowercases tag and reports whether the result is a valid tag:
+// up to 50 letters, digits, '_', '.', ':' and '-', starting with a letter or digit
+func NormalizeTag(tag string) (string, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	return tag, tagPattern.MatchString(tag)
+}
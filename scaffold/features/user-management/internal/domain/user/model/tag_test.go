@@ -0,0 +1,32 @@
+package model
+
+import "testing"
+
+func TestNormalizeTag(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		want   string
+		wantOK bool
+	}{
+		{name: "Plain", input: "beta", want: "beta", wantOK: true},
+		{name: "Case and spaces", input: "  Early-Adopter ", want: "early-adopter", wantOK: true},
+		{name: "Namespaced", input: "rollout:new_checkout.v2", want: "rollout:new_checkout.v2", wantOK: true},
+		{name: "Empty", input: "   ", wantOK: false},
+		{name: "Inner space", input: "beta tester", wantOK: false},
+		{name: "Leading punctuation", input: "-beta", wantOK: false},
+		{name: "Too long", input: "a123456789b123456789c123456789d123456789e123456789f", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := NormalizeTag(tt.input)
+			if ok != tt.wantOK {
+				t.Fatalf("NormalizeTag(%q) ok = %v, want %v", tt.input, ok, tt.wantOK)
+			}
+			if ok && got != tt.want {
+				t.Errorf("NormalizeTag(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
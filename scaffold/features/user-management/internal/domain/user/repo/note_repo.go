@@ -0,0 +1,69 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+
+	"gorm.io/gorm"
+)
+
+// NoteRepo stores the notes admins keep on user accounts
+type NoteRepo interface {
+	// ListByUser returns the notes on a user, newest first
+	ListByUser(ctx context.Context, userID string) ([]model.UserNote, error)
+	// FindByID returns a note, or nil when there is none
+	FindByID(ctx context.Context, id string) (*model.UserNote, error)
+	Create(ctx context.Context, note *model.UserNote) error
+	Update(ctx context.Context, note *model.UserNote) error
+	Delete(ctx context.Context, id string) error
+	// DeleteByUser deletes every note on a user
+	DeleteByUser(ctx context.Context, userID string) error
+}
+
+type noteRepo struct {
+	db *gorm.DB
+}
+
+func NewNoteRepo(db *gorm.DB) NoteRepo {
+	return &noteRepo{db: db}
+}
+
+func (r *noteRepo) ListByUser(ctx context.Context, userID string) ([]model.UserNote, error) {
+	notes := []model.UserNote{}
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at desc").
+		Find(&notes).Error
+	if err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+func (r *noteRepo) FindByID(ctx context.Context, id string) (*model.UserNote, error) {
+	var note model.UserNote
+	if err := r.db.WithContext(ctx).First(&note, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &note, nil
+}
+
+func (r *noteRepo) Create(ctx context.Context, note *model.UserNote) error {
+	return r.db.WithContext(ctx).Create(note).Error
+}
+
+func (r *noteRepo) Update(ctx context.Context, note *model.UserNote) error {
+	return r.db.WithContext(ctx).Save(note).Error
+}
+
+func (r *noteRepo) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&model.UserNote{}).Error
+}
+
+func (r *noteRepo) DeleteByUser(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&model.UserNote{}).Error
+}
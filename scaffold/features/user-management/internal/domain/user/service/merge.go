@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+
+	"github.com/google/uuid"
+)
+
+// AccountMerger moves the records of sourceID to targetID and marks the source deleted
+// with reason in one step, e.g. database.UserMerger
+type AccountMerger interface {
+	MergeUsers(ctx context.Context, sourceID, targetID uuid.UUID, reason string) (*model.MergeReport, error)
+}
+
+// WithAccountMerger enables Merge for folding duplicate accounts into one
+func WithAccountMerger(m AccountMerger) ServiceOption {
+	return func(s *userService) {
+		s.merger = m
+	}
+}
+
+// Merge folds the duplicate account sourceID into targetID: the source's records move to
+// the target, the source is marked deleted and signed out everywhere, and both histories
+// record the merge. It returns the target and what was moved.
+func (s *userService) Merge(ctx context.Context, sourceID, targetID string) (*model.User, *model.MergeReport, error) {
+	if s.merger == nil {
+		return nil, nil, apperrors.NewAppError(apperrors.BadRequestError, "Account merging is not enabled")
+	}
+	if sourceID == targetID {
+		return nil, nil, apperrors.NewAppError(apperrors.BadRequestError, "Cannot merge an account into itself")
+	}
+	if sourceID == actor.UserID(ctx) {
+		return nil, nil, apperrors.NewAppError(apperrors.ForbiddenError, "You cannot merge your own account into another")
+	}
+	source, err := s.mergeParty(ctx, sourceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	target, err := s.mergeParty(ctx, targetID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reason := "merged into " + target.ID.String()
+	report, err := s.merger.MergeUsers(ctx, source.ID, target.ID, reason)
+	if err != nil {
+		s.logger.Errorw("failed to merge users", "source_id", source.ID, "target_id", target.ID, "error", err)
+		return nil, nil, apperrors.NewAppError(apperrors.InternalError, "Failed to merge accounts")
+	}
+	s.invalidateAccount(ctx, source.ID.String())
+
+	before := *source
+	source.Status = model.StatusDeleted
+	source.StatusReason = &reason
+	by := actorID(ctx)
+	revisions := model.DiffUser(&before, source, by)
+	mergedInto, mergedFrom := target.ID.String(), source.ID.String()
+	revisions = append(revisions, model.UserRevision{
+		UserID: source.ID, Action: model.RevisionMerged, Field: "merged_into", NewValue: &mergedInto, ActorID: by,
+	})
+	s.recordRevisions(ctx, source.ID, revisions)
+	s.recordStatusChange(ctx, source, before.Status)
+	s.recordRevisions(ctx, target.ID, []model.UserRevision{{
+		UserID: target.ID, Action: model.RevisionMerged, Field: "merged_from", NewValue: &mergedFrom, ActorID: by,
+	}})
+	s.logger.Infow("users merged",
+		"audit", true,
+		"source_id", source.ID,
+		"target_id", target.ID,
+		"files", report.Files,
+		"exports", report.Exports,
+		"activities", report.Activities,
+		"oauth_identities", report.OAuthIdentities,
+		"tags", report.Tags,
+		"by", actor.UserID(ctx),
+		"ip", actor.ClientIP(ctx),
+	)
+
+	// The deleted status is what keeps the source out; revoking only ends sessions sooner
+	if s.revokeSessions != nil {
+		if err := s.revokeSessions(ctx, source.ID.String()); err != nil {
+			s.logger.Errorw("failed to revoke sessions of merged user", "user_id", source.ID, "error", err)
+		}
+	}
+	return target, report, nil
+}
+
+// mergeParty loads one side of a merge, which must exist and not be deleted
+func (s *userService) mergeParty(ctx context.Context, id string) (*model.User, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, apperrors.ErrUserNotFound
+	}
+	user, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		s.logger.Errorw("failed to fetch user for merge", "user_id", id, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to merge accounts")
+	}
+	if user == nil {
+		return nil, apperrors.ErrUserNotFound
+	}
+	if user.Status == model.StatusDeleted {
+		return nil, apperrors.NewAppError(apperrors.ConflictError, "Deleted accounts cannot be merged")
+	}
+	return user, nil
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+)
+
+// recentSignups is how many of the newest accounts Metrics returns
+const recentSignups = 10
+
+// Metrics summarizes the accounts: registrations per UTC day over the last days days
+// (today included), accounts per status and role, and the newest signups. Everything is
+// counted in the database.
+func (s *userService) Metrics(ctx context.Context, days int) (*model.UserMetrics, error) {
+	failed := apperrors.NewAppError(apperrors.InternalError, "Failed to fetch user metrics")
+	now := s.clock.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	first := today.AddDate(0, 0, 1-days)
+
+	counted, err := s.repo.CountRegistrations(ctx, first)
+	if err != nil {
+		s.logger.Errorw("failed to count registrations", "error", err)
+		return nil, failed
+	}
+	perDay := make(map[string]int64, len(counted))
+	for _, c := range counted {
+		perDay[c.Date] = c.Count
+	}
+	registrations := make([]model.DailyCount, 0, days)
+	for day := first; !day.After(today); day = day.AddDate(0, 0, 1) {
+		date := day.Format(time.DateOnly)
+		registrations = append(registrations, model.DailyCount{Date: date, Count: perDay[date]})
+	}
+
+	byStatus, err := s.repo.CountByStatus(ctx)
+	if err != nil {
+		s.logger.Errorw("failed to count users by status", "error", err)
+		return nil, failed
+	}
+	byRole, err := s.repo.CountByRole(ctx)
+	if err != nil {
+		s.logger.Errorw("failed to count users by role", "error", err)
+		return nil, failed
+	}
+	recent, err := s.repo.List(ctx, 0, recentSignups, nil, "created_at", "desc")
+	if err != nil {
+		s.logger.Errorw("failed to list recent signups", "error", err)
+		return nil, failed
+	}
+	for _, u := range recent {
+		u.Password = ""
+	}
+
+	return &model.UserMetrics{
+		Registrations: registrations,
+		ByStatus:      byStatus,
+		ByRole:        byRole,
+		RecentSignups: recent,
+	}, nil
+}
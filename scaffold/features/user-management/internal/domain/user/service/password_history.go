@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"strconv"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/repo"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+)
+
+// WithPasswordHistory refuses a new password that matches one of the user's last n
+// passwords, the current one included. Hashes of set passwords are kept in r.
+func WithPasswordHistory(r repo.PasswordHistoryRepo, n int) ServiceOption {
+	return func(s *userService) {
+		s.passwords = r
+		s.passwordHistory = n
+	}
+}
+
+// CleanupPasswordHistory deletes the hashes beyond the last n passwords of every user,
+// including entries left over after n was lowered
+func (s *userService) CleanupPasswordHistory(ctx context.Context) error {
+	if s.passwords == nil {
+		return nil
+	}
+	deleted, err := s.passwords.Prune(ctx, s.passwordHistory)
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		s.logger.Infow("pruned password history", "deleted", deleted)
+	}
+	return nil
+}
+
+// checkPasswordReuse rejects password if it matches one of the user's last n passwords
+func (s *userService) checkPasswordReuse(ctx context.Context, user *model.User, password string) error {
+	if s.passwords == nil || s.passwordHistory <= 0 {
+		return nil
+	}
+	entries, err := s.passwords.Recent(ctx, user.ID.String(), s.passwordHistory)
+	if err != nil {
+		s.logger.Errorw("failed to fetch password history", "user_id", user.ID, "error", err)
+		return apperrors.NewAppError(apperrors.InternalError, "Failed to update user password")
+	}
+	// The current password is normally the newest entry, but users who have not changed
+	// it since history was enabled have none
+	hashes := []string{user.Password}
+	for _, entry := range entries {
+		if entry.Hash != user.Password {
+			hashes = append(hashes, entry.Hash)
+		}
+	}
+	if len(hashes) > s.passwordHistory {
+		hashes = hashes[:s.passwordHistory]
+	}
+	for _, hash := range hashes {
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil {
+			s.logger.Warnw("password reuse rejected", "user_id", user.ID)
+			return apperrors.NewAppErrorWithDetails(apperrors.ValidationError,
+				"Password was used recently", "Choose a password different from your last "+pluralPasswords(s.passwordHistory))
+		}
+	}
+	return nil
+}
+
+// recordPassword keeps the hash of the password user now has. A failure is logged rather
+// than returned, since the password change itself already succeeded.
+func (s *userService) recordPassword(ctx context.Context, user *model.User) {
+	if s.passwords == nil || s.passwordHistory <= 0 {
+		return
+	}
+	if err := s.passwords.Create(ctx, &model.PasswordHistory{UserID: user.ID, Hash: user.Password}); err != nil {
+		s.logger.Errorw("failed to record password history", "user_id", user.ID, "error", err)
+	}
+}
+
+func pluralPasswords(n int) string {
+	if n == 1 {
+		return "password"
+	}
+	return strconv.Itoa(n) + " passwords"
+}
@@ -2,15 +2,30 @@ package service
 
 import (
 	"context"
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 
-	"go_platform_template/internal/domain/user/dto"
-	"go_platform_template/internal/domain/user/model"
-	apperrors "go_platform_template/internal/shared/errors"
-	"go_platform_template/internal/testutil"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/actiontoken"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/repo"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/cache"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/email"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/events"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/risk"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+	"github.com/MurtadaNazar/go-api-template/internal/testutil"
 )
 
 func TestUserService_Register_Success(t *testing.T) {
@@ -130,6 +145,93 @@ func TestUserService_Register_DuplicateUsername(t *testing.T) {
 	}
 }
 
+func TestUserService_Register_ConcurrentDuplicate(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockRepo := &testutil.MockUserRepo{}
+	logger := zap.NewNop().Sugar()
+	service := NewUserService(mockRepo, logger)
+
+	// Every caller passes the pre-checks, only the insert enforces uniqueness
+	var mu sync.Mutex
+	taken := make(map[string]bool)
+	mockRepo.CreateFn = func(ctx context.Context, user *model.User) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if taken[user.Username] {
+			return apperrors.ErrUsernameAlreadyTaken
+		}
+		taken[user.Username] = true
+		return nil
+	}
+
+	const workers = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+
+	// Act
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := service.Register(ctx, &dto.UserCreateRequest{
+				Email:     "race@example.com",
+				Username:  "raceuser",
+				Password:  "password123",
+				FirstName: "Race",
+				LastName:  "User",
+			})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	// Assert
+	successes := 0
+	for err := range errs {
+		if err == nil {
+			successes++
+			continue
+		}
+		appErr, ok := apperrors.IsAppError(err)
+		if !ok || appErr.Type != apperrors.ConflictError {
+			t.Errorf("Register() error = %v, want ConflictError", err)
+		}
+	}
+	if successes != 1 {
+		t.Errorf("Register() succeeded %d times, want exactly 1", successes)
+	}
+}
+
+func TestUserService_Register_ConstraintViolationIsConflict(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockRepo := &testutil.MockUserRepo{}
+	logger := zap.NewNop().Sugar()
+	service := NewUserService(mockRepo, logger)
+
+	mockRepo.CreateFn = func(ctx context.Context, user *model.User) error {
+		return apperrors.ErrEmailAlreadyRegistered
+	}
+
+	// Act
+	result, err := service.Register(ctx, &dto.UserCreateRequest{
+		Email:    "existing@example.com",
+		Username: "newuser",
+		Password: "password123",
+	})
+
+	// Assert
+	if result != nil {
+		t.Error("Register() should return nil user on error")
+	}
+	appErr, ok := apperrors.IsAppError(err)
+	if !ok || appErr.Type != apperrors.ConflictError {
+		t.Errorf("Register() error = %v, want ConflictError", err)
+	}
+}
+
 func TestUserService_GetByID_Success(t *testing.T) {
 	// Arrange
 	ctx := context.Background()
@@ -225,6 +327,34 @@ func TestUserService_Update_Success(t *testing.T) {
 	}
 }
 
+func TestUserService_Update_PublishesEvents(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	testUser := testutil.TestUser()
+	mockRepo := &testutil.MockUserRepo{
+		FindByIDFn: func(ctx context.Context, id string) (*model.User, error) { return testUser, nil },
+		UpdateFn:   func(ctx context.Context, user *model.User) error { return nil },
+	}
+	bus := events.NewBus()
+	var published []events.Event
+	bus.Subscribe(func(ctx context.Context, e events.Event) { published = append(published, e) })
+	service := NewUserService(mockRepo, zap.NewNop().Sugar(), WithEvents(bus))
+
+	// Act
+	_, err := service.Update(ctx, testUser.ID.String(), &dto.UserUpdateRequest{FirstName: "Updated", Password: "N3w-Passw0rd!"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if len(published) != 2 || published[0].Type != events.PasswordChanged || published[1].Type != events.ProfileUpdated {
+		t.Fatalf("published %+v, want a password change then a profile update", published)
+	}
+	if fields, _ := published[1].Data["fields"].([]string); len(fields) != 1 || fields[0] != "first_name" {
+		t.Errorf("profile update fields = %v, want only first_name", published[1].Data["fields"])
+	}
+}
+
 func TestUserService_Update_NotFound(t *testing.T) {
 	// Arrange
 	ctx := context.Background()
@@ -252,6 +382,35 @@ func TestUserService_Update_NotFound(t *testing.T) {
 	}
 }
 
+func TestUserService_Update_EmailConflict(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockRepo := &testutil.MockUserRepo{}
+	logger := zap.NewNop().Sugar()
+	service := NewUserService(mockRepo, logger)
+
+	testUser := testutil.TestUser()
+	mockRepo.FindByIDFn = func(ctx context.Context, id string) (*model.User, error) {
+		return testUser, nil
+	}
+	mockRepo.UpdateFn = func(ctx context.Context, user *model.User) error {
+		// Another account already owns the normalized form of this address
+		return apperrors.ErrEmailAlreadyRegistered
+	}
+
+	// Act
+	result, err := service.Update(ctx, testUser.ID.String(), &dto.UserUpdateRequest{Email: "Other.User@Example.com"})
+
+	// Assert
+	if result != nil {
+		t.Error("Update() should return nil user on conflict")
+	}
+	appErr, ok := apperrors.IsAppError(err)
+	if !ok || appErr.Type != apperrors.ConflictError {
+		t.Fatalf("Update() error = %v, want ConflictError", err)
+	}
+}
+
 func TestUserService_Delete_Success(t *testing.T) {
 	// Arrange
 	ctx := context.Background()
@@ -355,3 +514,1219 @@ func TestUserService_List_Success(t *testing.T) {
 		t.Errorf("List() returned %d users, want %d", len(result), len(users))
 	}
 }
+
+func TestUserService_Register_InvalidMetadata(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockRepo := &testutil.MockUserRepo{}
+	fieldRepo := &testutil.MockProfileFieldRepo{
+		ListFn: func(ctx context.Context) ([]model.ProfileField, error) {
+			return []model.ProfileField{{Key: "department", Type: model.FieldTypeString, Required: true}}, nil
+		},
+	}
+	service := NewUserService(mockRepo, zap.NewNop().Sugar(), WithProfileFields(fieldRepo))
+
+	created := false
+	mockRepo.CreateFn = func(ctx context.Context, user *model.User) error {
+		created = true
+		return nil
+	}
+
+	req := &dto.UserCreateRequest{
+		Email:     "newuser@example.com",
+		Username:  "newuser",
+		Password:  "password123",
+		FirstName: "New",
+		LastName:  "User",
+		Metadata:  map[string]interface{}{"favourite_colour": "blue"},
+	}
+
+	// Act
+	_, err := service.Register(ctx, req)
+
+	// Assert
+	appErr, ok := apperrors.IsAppError(err)
+	if !ok || appErr.Type != apperrors.ValidationError {
+		t.Fatalf("Register() error = %v, want ValidationError", err)
+	}
+	if created {
+		t.Error("Register() created a user with invalid metadata")
+	}
+}
+
+func TestUserService_DeclaredProfileFields(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	declared, err := DeclaredFields([]config.ProfileField{
+		{Key: "employee_id", Type: "string", Required: true},
+		{Key: "department", Type: "string", Choices: []string{"sales"}},
+	})
+	if err != nil {
+		t.Fatalf("DeclaredFields() error = %v", err)
+	}
+	stored := &testutil.MockProfileFieldRepo{
+		ListFn: func(ctx context.Context) ([]model.ProfileField, error) {
+			return []model.ProfileField{
+				{Key: "department", Type: model.FieldTypeString},
+				{Key: "nickname", Type: model.FieldTypeString},
+			}, nil
+		},
+	}
+	fieldRepo := repo.WithDeclaredFields(stored, declared)
+	service := NewUserService(&testutil.MockUserRepo{}, zap.NewNop().Sugar(), WithProfileFields(fieldRepo))
+	fields := NewProfileFieldService(fieldRepo, zap.NewNop().Sugar())
+	req := &dto.UserCreateRequest{
+		Email:     "newuser@example.com",
+		Username:  "newuser",
+		Password:  "password123",
+		FirstName: "New",
+		LastName:  "User",
+		Metadata:  map[string]interface{}{"department": "engineering", "nickname": "nu"},
+	}
+
+	// Act
+	_, registerErr := service.Register(ctx, req)
+	list, listErr := fields.List(ctx)
+	deleteErr := fields.Delete(ctx, "employee_id")
+
+	// Assert
+	appErr, ok := apperrors.IsAppError(registerErr)
+	if !ok || appErr.Type != apperrors.ValidationError ||
+		!strings.Contains(appErr.Details, "employee_id is required") || !strings.Contains(appErr.Details, "department must be one of") {
+		t.Errorf("Register() error = %v, want the declared fields enforced", registerErr)
+	}
+	if listErr != nil || len(list) != 3 || list[0].Key != "department" || !list[0].Config || list[2].Key != "nickname" || list[2].Config {
+		t.Errorf("List() = %+v, %v; want declared department and employee_id, stored nickname", list, listErr)
+	}
+	if appErr, ok := apperrors.IsAppError(deleteErr); !ok || appErr.Type != apperrors.ConflictError {
+		t.Errorf("Delete() error = %v, want a conflict for a declared field", deleteErr)
+	}
+	if _, err := DeclaredFields([]config.ProfileField{{Key: "Bad Key", Type: "string"}}); err == nil {
+		t.Error("DeclaredFields() accepted an invalid key")
+	}
+}
+
+func TestUserService_Update_MergesMetadata(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockRepo := &testutil.MockUserRepo{}
+	fieldRepo := &testutil.MockProfileFieldRepo{
+		ListFn: func(ctx context.Context) ([]model.ProfileField, error) {
+			return []model.ProfileField{
+				{Key: "department", Type: model.FieldTypeString},
+				{Key: "remote", Type: model.FieldTypeBoolean},
+			}, nil
+		},
+	}
+	service := NewUserService(mockRepo, zap.NewNop().Sugar(), WithProfileFields(fieldRepo))
+
+	user := testutil.TestUser()
+	user.Metadata = model.Metadata{"department": "sales", "remote": true}
+	mockRepo.FindByIDFn = func(ctx context.Context, id string) (*model.User, error) {
+		return user, nil
+	}
+
+	// Act
+	result, err := service.Update(ctx, user.ID.String(), &dto.UserUpdateRequest{
+		Metadata: map[string]interface{}{"remote": false},
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Update() error = %v, want nil", err)
+	}
+	if result.Metadata["department"] != "sales" || result.Metadata["remote"] != false {
+		t.Errorf("Update() metadata = %v, want department kept and remote=false", result.Metadata)
+	}
+}
+
+func TestUserService_List_MetadataFilter(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockRepo := &testutil.MockUserRepo{}
+	fieldRepo := &testutil.MockProfileFieldRepo{
+		ListFn: func(ctx context.Context) ([]model.ProfileField, error) {
+			return []model.ProfileField{{Key: "seniority", Type: model.FieldTypeNumber}}, nil
+		},
+	}
+	service := NewUserService(mockRepo, zap.NewNop().Sugar(), WithProfileFields(fieldRepo))
+
+	var got interface{}
+	mockRepo.ListFn = func(ctx context.Context, offset, limit int, filters map[string]interface{}, sortBy, sortOrder string) ([]*model.User, error) {
+		got = filters["metadata"]
+		return nil, nil
+	}
+
+	// Act
+	_, err := service.List(ctx, 0, 10, map[string]interface{}{"metadata": map[string]string{"seniority": "3"}}, "created_at", "asc")
+	_, unknownErr := service.List(ctx, 0, 10, map[string]interface{}{"metadata": map[string]string{"team": "a"}}, "created_at", "asc")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("List() error = %v, want nil", err)
+	}
+	if md, ok := got.(model.Metadata); !ok || md["seniority"] != 3.0 {
+		t.Errorf("List() metadata filter = %#v, want typed seniority=3", got)
+	}
+	if appErr, ok := apperrors.IsAppError(unknownErr); !ok || appErr.Type != apperrors.BadRequestError {
+		t.Errorf("List() unknown filter error = %v, want BadRequestError", unknownErr)
+	}
+}
+
+func TestUserService_Stream_ClearsPasswords(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockRepo := &testutil.MockUserRepo{}
+	service := NewUserService(mockRepo, zap.NewNop().Sugar())
+	mockRepo.ListFn = func(ctx context.Context, offset, limit int, filters map[string]interface{}, sortBy, sortOrder string) ([]*model.User, error) {
+		return []*model.User{testutil.TestUser(), testutil.TestUserAdmin()}, nil
+	}
+
+	// Act
+	var seen []*model.User
+	err := service.Stream(ctx, map[string]interface{}{}, "created_at", "asc", func(u *model.User) error {
+		seen = append(seen, u)
+		return nil
+	})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Stream() error = %v, want nil", err)
+	}
+	if len(seen) != 2 {
+		t.Fatalf("Stream() passed %d users, want 2", len(seen))
+	}
+	for _, u := range seen {
+		if u.Password != "" {
+			t.Errorf("Stream() passed %s with a password hash", u.Username)
+		}
+	}
+}
+
+func TestUserService_Update_RecordsRevisions(t *testing.T) {
+	// Arrange
+	mockRepo := &testutil.MockUserRepo{}
+	revisions := &testutil.MockRevisionRepo{}
+	service := NewUserService(mockRepo, zap.NewNop().Sugar(), WithRevisions(revisions))
+
+	user := testutil.TestUser()
+	mockRepo.FindByIDFn = func(ctx context.Context, id string) (*model.User, error) {
+		return user, nil
+	}
+	admin := testutil.TestUserAdmin()
+	admin.ID = uuid.New()
+	ctx := actor.WithUserID(context.Background(), admin.ID.String())
+
+	// Act
+	_, err := service.Update(ctx, user.ID.String(), &dto.UserUpdateRequest{FirstName: "Renamed"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Update() error = %v, want nil", err)
+	}
+	if len(revisions.Revisions) != 1 {
+		t.Fatalf("recorded %d revisions, want 1: %+v", len(revisions.Revisions), revisions.Revisions)
+	}
+	rev := revisions.Revisions[0]
+	if rev.Field != "first_name" || *rev.OldValue != "Test" || *rev.NewValue != "Renamed" {
+		t.Errorf("revision = %s: %v -> %v, want first_name: Test -> Renamed", rev.Field, rev.OldValue, rev.NewValue)
+	}
+	if rev.ActorID == nil || *rev.ActorID != admin.ID {
+		t.Errorf("revision actor = %v, want %s", rev.ActorID, admin.ID)
+	}
+}
+
+func TestUserService_AccountStatus_CachedUntilUpdate(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockRepo := &testutil.MockUserRepo{}
+	service := NewUserService(mockRepo, zap.NewNop().Sugar(), WithAccountCache(cache.NewMemory(), time.Minute))
+
+	user := testutil.TestUser()
+	lookups := 0
+	mockRepo.FindByIDFn = func(ctx context.Context, id string) (*model.User, error) {
+		lookups++
+		return user, nil
+	}
+
+	// Act
+	first, _ := service.AccountStatus(ctx, user.ID.String())
+	_, _ = service.AccountStatus(ctx, user.ID.String())
+	lookupsBeforeUpdate := lookups
+	_, err := service.Update(ctx, user.ID.String(), &dto.UserUpdateRequest{UserType: string(model.UserTypeAdmin)})
+	lookups = 0
+	after, _ := service.AccountStatus(ctx, user.ID.String())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Update() error = %v, want nil", err)
+	}
+	if first == nil || first.Role != model.UserTypeRegular || !first.IsActive() {
+		t.Fatalf("AccountStatus() = %+v, want active regular user", first)
+	}
+	if lookupsBeforeUpdate != 1 {
+		t.Errorf("repository queried %d times for two lookups, want 1", lookupsBeforeUpdate)
+	}
+	if lookups != 1 || after.Role != model.UserTypeAdmin {
+		t.Errorf("after update: %d queries, role %q; want a fresh lookup returning admin", lookups, after.Role)
+	}
+}
+
+func TestUserService_AccountStatus_RequestScope(t *testing.T) {
+	// Arrange
+	mockRepo := &testutil.MockUserRepo{}
+	service := NewUserService(mockRepo, zap.NewNop().Sugar())
+
+	user := testutil.TestUser()
+	lookups := 0
+	mockRepo.FindByIDFn = func(ctx context.Context, id string) (*model.User, error) {
+		lookups++
+		return user, nil
+	}
+	request := cache.WithRequestScope(context.Background())
+
+	// Act
+	_, _ = service.AccountStatus(request, user.ID.String())
+	_, _ = service.AccountStatus(request, user.ID.String())
+	_, _ = service.AccountStatus(cache.WithRequestScope(context.Background()), user.ID.String())
+
+	// Assert
+	if lookups != 2 {
+		t.Errorf("repository queried %d times, want once per request (2)", lookups)
+	}
+}
+
+func TestUserService_Register_RejectsUnknownRole(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockRepo := &testutil.MockUserRepo{}
+	roles := map[string]bool{"user": true, "admin": true, "support": true}
+	service := NewUserService(mockRepo, zap.NewNop().Sugar(), WithRoles(func(ctx context.Context, role string) (bool, error) {
+		return roles[role], nil
+	}))
+	mockRepo.CreateFn = func(ctx context.Context, user *model.User) error { return nil }
+
+	// Act
+	custom, customErr := service.Register(ctx, &dto.UserCreateRequest{
+		Email: "support@example.com", Username: "support", Password: "password123", UserType: "support",
+	})
+	_, unknownErr := service.Register(ctx, &dto.UserCreateRequest{
+		Email: "other@example.com", Username: "other", Password: "password123", UserType: "superuser",
+	})
+
+	// Assert
+	if customErr != nil || custom.UserType != "support" {
+		t.Errorf("Register(support) = %v, %v, want a user with the custom role", custom, customErr)
+	}
+	if appErr, ok := apperrors.IsAppError(unknownErr); !ok || appErr.Type != apperrors.ValidationError {
+		t.Errorf("Register(superuser) error = %v, want validation error", unknownErr)
+	}
+}
+
+func TestUserService_Signup_RiskScoring(t *testing.T) {
+	// Arrange
+	denylist, err := risk.NewIPListRule([]string{"203.0.113.0/24"}, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := risk.NewEngine(config.RiskConfig{FlagScore: 30, BlockScore: 100}, nil, nil,
+		denylist,
+		risk.NewDisposableEmailRule(risk.NewDisposableDomains(config.DisposableEmailConfig{}, nil), 40),
+	)
+	mockRepo := &testutil.MockUserRepo{}
+	created := 0
+	mockRepo.CreateFn = func(ctx context.Context, user *model.User) error {
+		created++
+		return nil
+	}
+	service := NewUserService(mockRepo, zap.NewNop().Sugar(), WithRiskEngine(engine))
+	request := func(email string) *dto.SignupRequest {
+		return &dto.SignupRequest{Email: email, Username: strings.Split(email, "@")[0], Password: "password123"}
+	}
+
+	// Act
+	regular, regularErr := service.Signup(actor.WithClientIP(context.Background(), "192.0.2.1"), request("jane@example.com"))
+	disposable, disposableErr := service.Signup(actor.WithClientIP(context.Background(), "192.0.2.1"), request("bot@mailinator.com"))
+	_, blockedErr := service.Signup(actor.WithClientIP(context.Background(), "203.0.113.7"), request("joe@example.com"))
+
+	// Assert
+	if regularErr != nil || regular.FlaggedForReview() {
+		t.Errorf("regular signup = %v, flagged %v; want an unflagged user", regularErr, regular != nil && regular.FlaggedForReview())
+	}
+	if disposableErr != nil || !disposable.FlaggedForReview() || !strings.Contains(*disposable.ReviewReason, "mailinator.com") {
+		t.Errorf("disposable signup = %v, %+v; want a user flagged for its email domain", disposableErr, disposable)
+	}
+	if appErr, ok := apperrors.IsAppError(blockedErr); !ok || appErr.Type != apperrors.ForbiddenError {
+		t.Errorf("denylisted signup error = %v, want forbidden", blockedErr)
+	}
+	if created != 2 {
+		t.Errorf("created %d users, want 2", created)
+	}
+}
+
+func TestUserService_ClearReview(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	user := testutil.TestUser()
+	reason := "velocity: 6 register attempts from 192.0.2.1 within 1h0m0s"
+	user.ReviewReason = &reason
+	var saved *model.User
+	mockRepo := &testutil.MockUserRepo{
+		FindByIDFn: func(ctx context.Context, id string) (*model.User, error) { return user, nil },
+		UpdateFn: func(ctx context.Context, u *model.User) error {
+			saved = u
+			return nil
+		},
+	}
+	service := NewUserService(mockRepo, zap.NewNop().Sugar())
+
+	// Act
+	result, err := service.ClearReview(ctx, user.ID.String())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("ClearReview() error = %v", err)
+	}
+	if result.FlaggedForReview() || saved == nil || saved.ReviewReason != nil {
+		t.Errorf("ClearReview() left the flag: result %v, saved %+v", result.ReviewReason, saved)
+	}
+}
+
+func TestUserService_ChangeStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		from        string
+		to          string
+		reason      string
+		self        bool
+		wantErr     apperrors.ErrorType
+		wantRevoked bool
+	}{
+		{name: "suspend", from: model.StatusActive, to: model.StatusSuspended, reason: "fraud", wantRevoked: true},
+		{name: "deactivate", from: model.StatusActive, to: model.StatusInactive, reason: "left the company", wantRevoked: true},
+		{name: "reactivate without reason", from: model.StatusSuspended, to: model.StatusActive},
+		{name: "suspend without reason", from: model.StatusActive, to: model.StatusSuspended, wantErr: apperrors.ValidationError},
+		{name: "own account", from: model.StatusActive, to: model.StatusSuspended, reason: "oops", self: true, wantErr: apperrors.ForbiddenError},
+		{name: "deleted account", from: model.StatusDeleted, to: model.StatusActive, wantErr: apperrors.ConflictError},
+		{name: "deactivate suspended account", from: model.StatusSuspended, to: model.StatusInactive, reason: "left the company", wantErr: apperrors.ConflictError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			user := testutil.TestUser()
+			user.Status = tt.from
+			admin := uuid.New().String()
+			if tt.self {
+				admin = user.ID.String()
+			}
+			ctx := actor.WithUserID(context.Background(), admin)
+			var saved *model.User
+			mockRepo := &testutil.MockUserRepo{
+				FindByIDFn: func(ctx context.Context, id string) (*model.User, error) { return user, nil },
+				UpdateFn: func(ctx context.Context, u *model.User) error {
+					saved = u
+					return nil
+				},
+			}
+			var revoked string
+			history := &testutil.MockStatusHistoryRepo{}
+			service := NewUserService(mockRepo, zap.NewNop().Sugar(),
+				WithSessionRevoker(func(ctx context.Context, userID string) error {
+					revoked = userID
+					return nil
+				}),
+				WithStatusHistory(history),
+			)
+
+			// Act
+			result, err := service.ChangeStatus(ctx, user.ID.String(), tt.to, tt.reason)
+
+			// Assert
+			if tt.wantErr != "" {
+				if appErr, ok := apperrors.IsAppError(err); !ok || appErr.Type != tt.wantErr || saved != nil || len(history.Changes) != 0 {
+					t.Errorf("ChangeStatus() error = %v, saved %v; want %s and no change", err, saved != nil, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || result.Status != tt.to || saved == nil {
+				t.Fatalf("ChangeStatus() = %+v, %v; want status %s saved", result, err, tt.to)
+			}
+			if tt.reason != "" && (saved.StatusReason == nil || *saved.StatusReason != tt.reason) {
+				t.Errorf("status reason = %v, want %q", saved.StatusReason, tt.reason)
+			}
+			if (revoked == user.ID.String()) != tt.wantRevoked {
+				t.Errorf("revoked sessions of %q, want revoked %v", revoked, tt.wantRevoked)
+			}
+			changes, _ := service.StatusHistory(ctx, user.ID.String(), 0, 0)
+			if len(changes) != 1 || changes[0].FromStatus != tt.from || changes[0].ToStatus != tt.to || changes[0].ActorID == nil || changes[0].ActorID.String() != admin {
+				t.Errorf("status history = %+v, want one change from %s to %s by the admin", changes, tt.from, tt.to)
+			}
+		})
+	}
+}
+
+func TestUserService_DisposableEmailBlocking(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	user := testutil.TestUser()
+	mockRepo := &testutil.MockUserRepo{
+		CreateFn:   func(ctx context.Context, u *model.User) error { return nil },
+		FindByIDFn: func(ctx context.Context, id string) (*model.User, error) { return user, nil },
+		UpdateFn:   func(ctx context.Context, u *model.User) error { return nil },
+	}
+	domains := risk.NewDisposableDomains(config.DisposableEmailConfig{Allow: []string{"yopmail.com"}}, nil)
+	service := NewUserService(mockRepo, zap.NewNop().Sugar(), WithDisposableEmailBlocking(domains))
+	rejected := disposableRejections.Value("register")
+
+	// Act
+	_, registerErr := service.Register(ctx, &dto.UserCreateRequest{Email: "bot@mailinator.com", Username: "bot", Password: "password123"})
+	_, allowedErr := service.Register(ctx, &dto.UserCreateRequest{Email: "jane@yopmail.com", Username: "jane", Password: "password123"})
+	_, updateErr := service.Update(ctx, user.ID.String(), &dto.UserUpdateRequest{Email: "bot@guerrillamail.com"})
+
+	// Assert
+	for name, err := range map[string]error{"register": registerErr, "update": updateErr} {
+		if appErr, ok := apperrors.IsAppError(err); !ok || appErr.Type != apperrors.ValidationError {
+			t.Errorf("%s with a disposable email error = %v, want validation error", name, err)
+		}
+	}
+	if allowedErr != nil {
+		t.Errorf("Register() with an allowed domain error = %v", allowedErr)
+	}
+	if got := disposableRejections.Value("register") - rejected; got != 1 {
+		t.Errorf("rejected registrations counted %v, want 1", got)
+	}
+}
+
+func TestUserService_Update_RejectsRecentPasswords(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	mockRepo := &testutil.MockUserRepo{}
+	history := &testutil.MockPasswordHistoryRepo{}
+	service := NewUserService(mockRepo, zap.NewNop().Sugar(), WithPasswordHistory(history, 2))
+
+	user := testutil.TestUser()
+	hash, _ := bcrypt.GenerateFromPassword([]byte("0riginal-Passw0rd!"), bcrypt.MinCost)
+	user.Password = string(hash)
+	mockRepo.FindByIDFn = func(ctx context.Context, id string) (*model.User, error) {
+		return user, nil
+	}
+	change := func(password string) error {
+		_, err := service.Update(ctx, user.ID.String(), &dto.UserUpdateRequest{Password: password})
+		return err
+	}
+
+	// Act
+	errCurrent := change("0riginal-Passw0rd!")
+	errFirst := change("N3w-Passw0rd!")
+	errSecond := change("An0ther-Passw0rd!")
+	errPrevious := change("N3w-Passw0rd!")
+	errOldest := change("0riginal-Passw0rd!")
+
+	// Assert
+	if appErr, ok := apperrors.IsAppError(errCurrent); !ok || appErr.Type != apperrors.ValidationError {
+		t.Errorf("reusing the current password: error = %v, want a validation error", errCurrent)
+	}
+	if errFirst != nil || errSecond != nil {
+		t.Fatalf("new passwords rejected: %v, %v", errFirst, errSecond)
+	}
+	if errPrevious == nil {
+		t.Error("the password before the current one was accepted")
+	}
+	if errOldest != nil {
+		t.Errorf("a password older than the last 2 was rejected: %v", errOldest)
+	}
+	if len(history.Entries) != 3 {
+		t.Errorf("recorded %d passwords, want 3", len(history.Entries))
+	}
+}
+
+func TestUserService_CleanupPasswordHistory_KeepsLastN(t *testing.T) {
+	// Arrange
+	history := &testutil.MockPasswordHistoryRepo{}
+	service := NewUserService(&testutil.MockUserRepo{}, zap.NewNop().Sugar(), WithPasswordHistory(history, 2))
+	alice, bob := uuid.New(), uuid.New()
+	for _, userID := range []uuid.UUID{alice, alice, alice, bob} {
+		history.Entries = append(history.Entries, model.PasswordHistory{UserID: userID})
+	}
+
+	// Act
+	err := service.CleanupPasswordHistory(context.Background())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("CleanupPasswordHistory() error = %v", err)
+	}
+	if len(history.Entries) != 3 {
+		t.Errorf("%d entries left, want 2 of alice and 1 of bob", len(history.Entries))
+	}
+}
+
+// recordingSender keeps the emails it is asked to send
+type recordingSender struct {
+	sent []email.Message
+}
+
+func (s *recordingSender) Send(ctx context.Context, msg email.Message) error {
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+// solvedCaptcha accepts the token "solved"
+type solvedCaptcha struct{}
+
+func (solvedCaptcha) Verify(_ context.Context, token, _ string) (bool, error) {
+	return token == "solved", nil
+}
+
+func TestUserService_Signup_RequiresCaptcha(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "no token", wantErr: true},
+		{name: "wrong token", token: "guess", wantErr: true},
+		{name: "solved", token: "solved"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			var created *model.User
+			mockRepo := &testutil.MockUserRepo{
+				CreateFn: func(ctx context.Context, u *model.User) error {
+					created = u
+					return nil
+				},
+			}
+			service := NewUserService(mockRepo, zap.NewNop().Sugar(), WithSignupCaptcha(solvedCaptcha{}))
+			ctx := risk.WithCaptchaToken(context.Background(), tt.token)
+
+			// Act
+			_, err := service.Signup(ctx, &dto.SignupRequest{Email: "jane@example.com", Username: "jane", Password: "password123"})
+
+			// Assert
+			if tt.wantErr {
+				if appErr, ok := apperrors.IsAppError(err); !ok || appErr.Type != apperrors.ForbiddenError || created != nil {
+					t.Errorf("Signup() error = %v, created %v; want a forbidden error and no user", err, created != nil)
+				}
+				return
+			}
+			if err != nil || created == nil {
+				t.Fatalf("Signup() error = %v, want a created user", err)
+			}
+			if created.UserType != "" {
+				t.Errorf("signup user type = %q, want the default role", created.UserType)
+			}
+		})
+	}
+}
+
+func TestUserService_CheckAvailability(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        dto.AvailabilityQuery
+		wantUsername *dto.Availability
+		wantEmail    *dto.Availability
+	}{
+		{name: "free username", query: dto.AvailabilityQuery{Username: "newname"}, wantUsername: &dto.Availability{Available: true}},
+		{name: "taken username", query: dto.AvailabilityQuery{Username: "taken"}, wantUsername: &dto.Availability{Reason: "taken"}},
+		{name: "taken email", query: dto.AvailabilityQuery{Email: "taken@example.com"}, wantEmail: &dto.Availability{Reason: "taken"}},
+		{name: "disposable email", query: dto.AvailabilityQuery{Email: "someone@mailinator.com"}, wantEmail: &dto.Availability{Reason: "blocked"}},
+		{
+			name:         "both",
+			query:        dto.AvailabilityQuery{Username: "taken", Email: "free@example.com"},
+			wantUsername: &dto.Availability{Reason: "taken"},
+			wantEmail:    &dto.Availability{Available: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			mockRepo := &testutil.MockUserRepo{
+				FindByUsernameFn: func(ctx context.Context, username string) (*model.User, error) {
+					if username == "taken" {
+						return &model.User{Username: username}, nil
+					}
+					return nil, nil
+				},
+				GetByEmailFn: func(ctx context.Context, email string) (*model.User, error) {
+					if email == "taken@example.com" {
+						return &model.User{Email: email}, nil
+					}
+					return nil, nil
+				},
+			}
+			service := NewUserService(mockRepo, zap.NewNop().Sugar(),
+				WithDisposableEmailBlocking(risk.NewDisposableDomains(config.DisposableEmailConfig{}, nil)))
+
+			// Act
+			got, err := service.CheckAvailability(context.Background(), &tt.query)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("CheckAvailability() error = %v", err)
+			}
+			if !reflect.DeepEqual(got.Username, tt.wantUsername) || !reflect.DeepEqual(got.Email, tt.wantEmail) {
+				t.Errorf("CheckAvailability() = %+v, %+v; want %+v, %+v", got.Username, got.Email, tt.wantUsername, tt.wantEmail)
+			}
+		})
+	}
+}
+
+func TestUserService_Metrics(t *testing.T) {
+	// Arrange
+	clk := testutil.NewFakeClock(time.Date(2024, 1, 15, 23, 30, 0, 0, time.UTC))
+	var since time.Time
+	var listLimit int
+	var listSort string
+	mockRepo := &testutil.MockUserRepo{
+		CountRegistrationsFn: func(ctx context.Context, from time.Time) ([]model.DailyCount, error) {
+			since = from
+			return []model.DailyCount{{Date: "2024-01-13", Count: 4}, {Date: "2024-01-15", Count: 2}}, nil
+		},
+		CountByStatusFn: func(ctx context.Context) (map[string]int64, error) {
+			return map[string]int64{model.StatusActive: 5, model.StatusDeleted: 1}, nil
+		},
+		ListFn: func(ctx context.Context, offset, limit int, filters map[string]interface{}, sortBy, sortOrder string) ([]*model.User, error) {
+			listLimit, listSort = limit, sortBy+" "+sortOrder
+			return []*model.User{{Username: "newest", Password: "hash"}}, nil
+		},
+	}
+	service := NewUserService(mockRepo, zap.NewNop().Sugar(), WithClock(clk))
+
+	// Act
+	metrics, err := service.Metrics(context.Background(), 3)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Metrics() error = %v", err)
+	}
+	if want := time.Date(2024, 1, 13, 0, 0, 0, 0, time.UTC); !since.Equal(want) {
+		t.Errorf("counted registrations since %v, want %v", since, want)
+	}
+	want := []model.DailyCount{{Date: "2024-01-13", Count: 4}, {Date: "2024-01-14"}, {Date: "2024-01-15", Count: 2}}
+	if !reflect.DeepEqual(metrics.Registrations, want) {
+		t.Errorf("Registrations = %+v, want %+v", metrics.Registrations, want)
+	}
+	if metrics.ByStatus[model.StatusActive] != 5 || metrics.ByRole == nil {
+		t.Errorf("ByStatus = %v, ByRole = %v", metrics.ByStatus, metrics.ByRole)
+	}
+	if listLimit != 10 || listSort != "created_at desc" || len(metrics.RecentSignups) != 1 || metrics.RecentSignups[0].Password != "" {
+		t.Errorf("recent signups listed with limit %d, sort %q: %+v; want the 10 newest without passwords", listLimit, listSort, metrics.RecentSignups)
+	}
+}
+
+func TestUserService_VerifyEmail(t *testing.T) {
+	tests := []struct {
+		name    string
+		tamper  func(token string) string
+		advance time.Duration
+		email   string
+		reuse   bool
+		wantErr bool
+	}{
+		{name: "valid link"},
+		{name: "expired link", advance: 49 * time.Hour, wantErr: true},
+		{name: "email changed since", email: "other@example.com", wantErr: true},
+		{name: "tampered token", tamper: func(token string) string { return token[:len(token)-2] + "xx" }, wantErr: true},
+		{name: "garbage", tamper: func(string) string { return "not-a-token" }, wantErr: true},
+		{name: "used link", reuse: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := context.Background()
+			var user *model.User
+			mockRepo := &testutil.MockUserRepo{
+				CreateFn: func(ctx context.Context, u *model.User) error {
+					u.ID = uuid.New()
+					user = u
+					return nil
+				},
+				FindByIDFn: func(ctx context.Context, id string) (*model.User, error) { return user, nil },
+				UpdateFn:   func(ctx context.Context, u *model.User) error { return nil },
+			}
+			sender := &recordingSender{}
+			clk := testutil.NewFakeClock(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+			tokens := actiontoken.NewService(&testutil.MockActionTokenRepo{})
+			tokens.SetClock(clk)
+			service := NewUserService(mockRepo, zap.NewNop().Sugar(),
+				WithEmailVerification(sender, tokens, config.SignupConfig{
+					VerifyURL: "https://app.example.com/verify",
+					VerifyTTL: 48 * time.Hour,
+				}),
+				WithClock(clk),
+			)
+			if _, err := service.Signup(ctx, &dto.SignupRequest{FirstName: "Jane", Email: "jane@example.com", Username: "jane", Password: "password123"}); err != nil {
+				t.Fatalf("Signup() error = %v", err)
+			}
+			if len(sender.sent) != 1 || sender.sent[0].To != "jane@example.com" {
+				t.Fatalf("sent %+v, want one verification email to jane@example.com", sender.sent)
+			}
+			token := verificationToken(t, sender.sent[0].Body, "https://app.example.com/verify?token=")
+			if tt.tamper != nil {
+				token = tt.tamper(token)
+			}
+			if tt.email != "" {
+				user.Email = tt.email
+			}
+			if tt.reuse {
+				if _, err := service.VerifyEmail(ctx, token); err != nil {
+					t.Fatalf("first VerifyEmail() error = %v", err)
+				}
+				user.EmailVerifiedAt = nil
+			}
+			clk.Advance(tt.advance)
+
+			// Act
+			verified, err := service.VerifyEmail(ctx, token)
+
+			// Assert
+			if tt.wantErr {
+				if appErr, ok := apperrors.IsAppError(err); !ok || appErr.Type != apperrors.BadRequestError || user.EmailVerified() {
+					t.Errorf("VerifyEmail() error = %v, verified %v; want a bad request and an unverified email", err, user.EmailVerified())
+				}
+				return
+			}
+			if err != nil || !verified.EmailVerified() || !verified.EmailVerifiedAt.Equal(clk.Now()) {
+				t.Errorf("VerifyEmail() = %+v, %v; want the email verified now", verified, err)
+			}
+		})
+	}
+}
+
+// verificationToken returns the token of the link starting with prefix in body
+func verificationToken(t *testing.T, body, prefix string) string {
+	t.Helper()
+	_, rest, ok := strings.Cut(body, prefix)
+	if !ok {
+		t.Fatalf("email body %q has no link starting %q", body, prefix)
+	}
+	token, err := url.QueryUnescape(strings.Fields(rest)[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestUserService_AcceptInvitation(t *testing.T) {
+	tests := []struct {
+		name     string
+		advance  time.Duration
+		reinvite bool
+		reuse    bool
+		wantErr  bool
+	}{
+		{name: "valid invitation"},
+		{name: "expired invitation", advance: 73 * time.Hour, wantErr: true},
+		{name: "replaced by a newer invitation", reinvite: true, wantErr: true},
+		{name: "used invitation", reuse: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			ctx := actor.WithUserID(context.Background(), "admin-1")
+			var created []*model.User
+			mockRepo := &testutil.MockUserRepo{
+				CreateFn: func(ctx context.Context, u *model.User) error {
+					u.ID = uuid.New()
+					created = append(created, u)
+					return nil
+				},
+			}
+			sender := &recordingSender{}
+			clk := testutil.NewFakeClock(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+			tokens := actiontoken.NewService(&testutil.MockActionTokenRepo{})
+			tokens.SetClock(clk)
+			service := NewUserService(mockRepo, zap.NewNop().Sugar(),
+				WithInvitations(sender, tokens, config.InviteConfig{URL: "https://app.example.com/invite", TTL: 7 * 24 * time.Hour}),
+				WithClock(clk),
+			)
+			invitation, err := service.Invite(ctx, &dto.InvitationRequest{Email: "Jane@Example.com", Role: "admin", ExpiresInHours: 72})
+			if err != nil {
+				t.Fatalf("Invite() error = %v", err)
+			}
+			if !invitation.EmailSent || len(sender.sent) != 1 || sender.sent[0].To != "Jane@Example.com" {
+				t.Fatalf("Invite() = %+v, sent %+v; want one invitation email to Jane@Example.com", invitation, sender.sent)
+			}
+			if want := clk.Now().Add(72 * time.Hour); !invitation.ExpiresAt.Equal(want) {
+				t.Errorf("ExpiresAt = %v, want %v", invitation.ExpiresAt, want)
+			}
+			token := verificationToken(t, sender.sent[0].Body, "https://app.example.com/invite?token=")
+			if tt.reinvite {
+				if _, err := service.Invite(ctx, &dto.InvitationRequest{Email: "jane@example.com"}); err != nil {
+					t.Fatalf("second Invite() error = %v", err)
+				}
+			}
+			req := &dto.AcceptInviteRequest{Token: token, FirstName: "Jane", LastName: "Roe", Username: "janeroe", Password: "password123"}
+			if tt.reuse {
+				if _, err := service.AcceptInvitation(ctx, req); err != nil {
+					t.Fatalf("first AcceptInvitation() error = %v", err)
+				}
+				created = nil
+			}
+			clk.Advance(tt.advance)
+
+			// Act
+			user, err := service.AcceptInvitation(ctx, req)
+
+			// Assert
+			if tt.wantErr {
+				if appErr, ok := apperrors.IsAppError(err); !ok || appErr.Type != apperrors.BadRequestError || len(created) != 0 {
+					t.Errorf("AcceptInvitation() error = %v, created %d users; want a bad request and no account", err, len(created))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AcceptInvitation() error = %v", err)
+			}
+			if user.Email != "Jane@Example.com" || user.UserType != model.UserTypeAdmin || !user.EmailVerified() {
+				t.Errorf("AcceptInvitation() = email %q, role %q, verified %v; want the invited email and role, verified",
+					user.Email, user.UserType, user.EmailVerified())
+			}
+		})
+	}
+}
+
+func TestUserService_AnonymizeDeletedAccounts(t *testing.T) {
+	// Arrange
+	clk := testutil.NewFakeClock(time.Date(2024, 2, 14, 12, 0, 0, 0, time.UTC))
+	scheduledAt := clk.Now().Add(-time.Hour)
+	phone := "+14155552671"
+	user := &model.User{
+		ID: uuid.New(), FirstName: "John", LastName: "Doe", Username: "johndoe",
+		Email: "john@example.com", Phone: &phone, Password: "hash", Status: "active",
+		Metadata: model.Metadata{"department": "engineering"}, DeletionScheduledAt: &scheduledAt,
+	}
+	oldEmail := "john@example.com"
+	revisions := &testutil.MockRevisionRepo{Revisions: []model.UserRevision{
+		{UserID: user.ID, Action: model.RevisionUpdated, Field: "email", NewValue: &oldEmail},
+	}}
+	passwords := &testutil.MockPasswordHistoryRepo{Entries: []model.PasswordHistory{{UserID: user.ID, Hash: "hash"}}}
+	var updated *model.User
+	mockRepo := &testutil.MockUserRepo{
+		ListDueForDeletionFn: func(ctx context.Context, now time.Time, limit int) ([]*model.User, error) {
+			if updated != nil || now.Before(scheduledAt) {
+				return nil, nil
+			}
+			return []*model.User{user}, nil
+		},
+		UpdateFn: func(ctx context.Context, u *model.User) error {
+			updated = u
+			return nil
+		},
+	}
+	service := NewUserService(mockRepo, zap.NewNop().Sugar(), WithClock(clk),
+		WithRevisions(revisions), WithPasswordHistory(passwords, 5))
+
+	// Act
+	err := service.AnonymizeDeletedAccounts(context.Background())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("AnonymizeDeletedAccounts() error = %v", err)
+	}
+	if updated == nil || updated.Status != "deleted" {
+		t.Fatalf("updated user = %+v, want status deleted", updated)
+	}
+	for field, value := range map[string]string{"first_name": updated.FirstName, "username": updated.Username, "email": updated.Email, "password": updated.Password} {
+		if strings.Contains(value, "john") || strings.Contains(value, "John") || value == "hash" {
+			t.Errorf("%s = %q still identifies the user", field, value)
+		}
+	}
+	if updated.Phone != nil || len(updated.Metadata) != 0 {
+		t.Errorf("phone = %v, metadata = %v, want both cleared", updated.Phone, updated.Metadata)
+	}
+	if len(passwords.Entries) != 0 {
+		t.Errorf("password history = %v, want it deleted", passwords.Entries)
+	}
+	if v := revisions.Revisions[0].NewValue; v == nil || *v != model.RedactedValue {
+		t.Errorf("revision value = %v, want %q", v, model.RedactedValue)
+	}
+	if last := revisions.Revisions[len(revisions.Revisions)-1]; last.Action != model.RevisionDeleted {
+		t.Errorf("last revision = %+v, want a deletion", last)
+	}
+}
+
+// fakeMerger records the merge it was asked for
+type fakeMerger struct {
+	source, target uuid.UUID
+	reason         string
+}
+
+func (m *fakeMerger) MergeUsers(ctx context.Context, sourceID, targetID uuid.UUID, reason string) (*model.MergeReport, error) {
+	m.source, m.target, m.reason = sourceID, targetID, reason
+	return &model.MergeReport{Files: 3, OAuthIdentities: 1}, nil
+}
+
+func TestUserService_Merge(t *testing.T) {
+	// Arrange
+	source := testutil.TestUser()
+	target := testutil.TestUser()
+	target.ID = uuid.New()
+	target.Username = "testuser2"
+	mockRepo := &testutil.MockUserRepo{
+		FindByIDFn: func(ctx context.Context, id string) (*model.User, error) {
+			switch id {
+			case source.ID.String():
+				return source, nil
+			case target.ID.String():
+				return target, nil
+			}
+			return nil, nil
+		},
+	}
+	merger := &fakeMerger{}
+	revisions := &testutil.MockRevisionRepo{}
+	var revoked string
+	service := NewUserService(mockRepo, zap.NewNop().Sugar(),
+		WithAccountMerger(merger),
+		WithRevisions(revisions),
+		WithSessionRevoker(func(ctx context.Context, userID string) error {
+			revoked = userID
+			return nil
+		}),
+	)
+	ctx := actor.WithUserID(context.Background(), uuid.New().String())
+
+	// Act
+	_, _, selfErr := service.Merge(ctx, source.ID.String(), source.ID.String())
+	merged, report, err := service.Merge(ctx, source.ID.String(), target.ID.String())
+
+	// Assert
+	if appErr, ok := apperrors.IsAppError(selfErr); !ok || appErr.Type != apperrors.BadRequestError {
+		t.Errorf("Merge() into itself error = %v, want BadRequestError", selfErr)
+	}
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+	if merged.ID != target.ID || report.Files != 3 {
+		t.Errorf("Merge() = %s, %+v; want the target and the merger's report", merged.ID, report)
+	}
+	if merger.source != source.ID || merger.target != target.ID || merger.reason != "merged into "+target.ID.String() {
+		t.Errorf("merger got %s -> %s (%q), want %s -> %s", merger.source, merger.target, merger.reason, source.ID, target.ID)
+	}
+	if revoked != source.ID.String() {
+		t.Errorf("revoked sessions of %q, want the source", revoked)
+	}
+	var mergedInto, mergedFrom bool
+	for _, r := range revisions.Revisions {
+		if r.Action != model.RevisionMerged {
+			continue
+		}
+		mergedInto = mergedInto || (r.UserID == source.ID && r.Field == "merged_into" && *r.NewValue == target.ID.String())
+		mergedFrom = mergedFrom || (r.UserID == target.ID && r.Field == "merged_from" && *r.NewValue == source.ID.String())
+	}
+	if !mergedInto || !mergedFrom {
+		t.Errorf("revisions = %+v, want the merge in both histories", revisions.Revisions)
+	}
+}
+
+func TestUserService_Batch(t *testing.T) {
+	// Arrange
+	users := map[string]*model.User{}
+	ids := make([]string, 3)
+	for i := range ids {
+		user := testutil.TestUserWithID(uuid.New())
+		ids[i] = user.ID.String()
+		users[ids[i]] = user
+	}
+	var calls int
+	var updated, deleted []*model.User
+	mockRepo := &testutil.MockUserRepo{
+		FindByIDFn: func(ctx context.Context, id string) (*model.User, error) {
+			if user, ok := users[id]; ok {
+				copied := *user
+				return &copied, nil
+			}
+			return nil, nil
+		},
+		ApplyBatchFn: func(ctx context.Context, u, d []*model.User) error {
+			calls++
+			updated, deleted = u, d
+			return nil
+		},
+	}
+	var revoked []string
+	service := NewUserService(mockRepo, zap.NewNop().Sugar(),
+		WithSessionRevoker(func(ctx context.Context, userID string) error {
+			revoked = append(revoked, userID)
+			return nil
+		}),
+	)
+	ctx := actor.WithUserID(context.Background(), uuid.New().String())
+
+	// Act
+	rejected, rejectErr := service.Batch(ctx, []dto.BatchOperation{
+		{Op: dto.BatchOpDelete, UserID: ids[0]},
+		{Op: dto.BatchOpChangeStatus, UserID: ids[1], Status: model.StatusSuspended},
+		{Op: dto.BatchOpChangeRole, UserID: ids[2], Role: string(model.UserTypeAdmin)},
+		{Op: dto.BatchOpDelete, UserID: ids[2]},
+	})
+	applied, err := service.Batch(ctx, []dto.BatchOperation{
+		{Op: dto.BatchOpDelete, UserID: ids[0]},
+		{Op: dto.BatchOpChangeRole, UserID: ids[1], Role: string(model.UserTypeAdmin)},
+		{Op: dto.BatchOpChangeStatus, UserID: ids[2], Status: model.StatusSuspended, Reason: "Chargeback"},
+	})
+
+	// Assert
+	if rejectErr != nil || rejected.Applied {
+		t.Fatalf("Batch() with invalid operations = %+v, %v; want not applied", rejected, rejectErr)
+	}
+	want := []string{dto.BatchSkipped, dto.BatchFailed, dto.BatchSkipped, dto.BatchFailed}
+	for i, result := range rejected.Results {
+		if result.Result != want[i] {
+			t.Errorf("rejected result %d = %+v, want %s", i, result, want[i])
+		}
+	}
+	if err != nil || !applied.Applied {
+		t.Fatalf("Batch() = %+v, %v; want applied", applied, err)
+	}
+	if calls != 1 {
+		t.Errorf("ApplyBatch called %d times, want once for the valid batch", calls)
+	}
+	if len(deleted) != 1 || deleted[0].ID.String() != ids[0] {
+		t.Errorf("deleted = %v, want the first user", deleted)
+	}
+	if len(updated) != 2 || updated[0].UserType != model.UserTypeAdmin ||
+		updated[1].Status != model.StatusSuspended || *updated[1].StatusReason != "Chargeback" {
+		t.Errorf("updated = %+v, want the second user promoted and the third suspended", updated)
+	}
+	if !reflect.DeepEqual(revoked, []string{ids[2]}) {
+		t.Errorf("revoked sessions of %v, want the suspended user only", revoked)
+	}
+}
+
+func TestUserService_Tags(t *testing.T) {
+	// Arrange
+	user := testutil.TestUser()
+	mockRepo := &testutil.MockUserRepo{
+		FindByIDFn: func(ctx context.Context, id string) (*model.User, error) {
+			if id == user.ID.String() {
+				return user, nil
+			}
+			return nil, nil
+		},
+	}
+	tags := &testutil.MockTagRepo{}
+	service := NewUserService(mockRepo, zap.NewNop().Sugar(), WithTags(tags))
+	ctx := context.Background()
+	id := user.ID.String()
+
+	// Act
+	_, invalidErr := service.AddTags(ctx, id, []string{"beta", "two words"})
+	added, err := service.AddTags(ctx, id, []string{" Beta ", "rollout:checkout", "beta"})
+	remaining, removeErr := service.RemoveTag(ctx, id, "BETA")
+	_, missingErr := service.RemoveTag(ctx, id, "beta")
+	_, unknownErr := service.Tags(ctx, uuid.New().String())
+	counts, _ := service.TagCounts(ctx)
+
+	// Assert
+	if appErr, ok := apperrors.IsAppError(invalidErr); !ok || appErr.Type != apperrors.ValidationError {
+		t.Errorf("AddTags() with an invalid tag error = %v, want ValidationError", invalidErr)
+	}
+	if err != nil || !reflect.DeepEqual(added, []string{"beta", "rollout:checkout"}) {
+		t.Errorf("AddTags() = %v, %v; want the normalized tags once each", added, err)
+	}
+	if removeErr != nil || !reflect.DeepEqual(remaining, []string{"rollout:checkout"}) {
+		t.Errorf("RemoveTag() = %v, %v; want the other tag left", remaining, removeErr)
+	}
+	if appErr, ok := apperrors.IsAppError(missingErr); !ok || appErr.Type != apperrors.NotFoundError {
+		t.Errorf("RemoveTag() of a missing tag error = %v, want NotFoundError", missingErr)
+	}
+	if !errors.Is(unknownErr, apperrors.ErrUserNotFound) {
+		t.Errorf("Tags() of an unknown user error = %v, want ErrUserNotFound", unknownErr)
+	}
+	if !reflect.DeepEqual(counts, []model.TagCount{{Tag: "rollout:checkout", Users: 1}}) {
+		t.Errorf("TagCounts() = %+v, want the remaining tag with one user", counts)
+	}
+}
+
+func TestUserService_Notes(t *testing.T) {
+	// Arrange
+	user := testutil.TestUser()
+	mockRepo := &testutil.MockUserRepo{
+		FindByIDFn: func(ctx context.Context, id string) (*model.User, error) {
+			if id == user.ID.String() {
+				return user, nil
+			}
+			return nil, nil
+		},
+	}
+	notes := &testutil.MockNoteRepo{}
+	service := NewUserService(mockRepo, zap.NewNop().Sugar(), WithNotes(notes))
+	author := actor.WithUserID(context.Background(), uuid.New().String())
+	other := actor.WithUserID(context.Background(), uuid.New().String())
+	id := user.ID.String()
+
+	// Act
+	note, err := service.AddNote(author, id, "Called about a double charge")
+	_, otherErr := service.UpdateNote(other, id, note.ID.String(), "Rewritten")
+	updated, updateErr := service.UpdateNote(author, id, note.ID.String(), "Refund issued")
+	wrongUserErr := service.DeleteNote(author, uuid.New().String(), note.ID.String())
+	listed, _ := service.Notes(other, id)
+	deleteErr := service.DeleteNote(other, id, note.ID.String())
+	remaining, _ := service.Notes(other, id)
+
+	// Assert
+	if err != nil || note.AuthorID == nil || note.AuthorID.String() != actor.UserID(author) {
+		t.Fatalf("AddNote() = %+v, %v; want a note by the caller", note, err)
+	}
+	if appErr, ok := apperrors.IsAppError(otherErr); !ok || appErr.Type != apperrors.ForbiddenError {
+		t.Errorf("UpdateNote() by another admin error = %v, want ForbiddenError", otherErr)
+	}
+	if updateErr != nil || updated.Text != "Refund issued" {
+		t.Errorf("UpdateNote() by the author = %+v, %v; want the new text", updated, updateErr)
+	}
+	if !errors.Is(wrongUserErr, apperrors.ErrUserNotFound) {
+		t.Errorf("DeleteNote() under an unknown user error = %v, want ErrUserNotFound", wrongUserErr)
+	}
+	if len(listed) != 1 || listed[0].Text != "Refund issued" {
+		t.Errorf("Notes() = %+v, want the updated note", listed)
+	}
+	if deleteErr != nil || len(remaining) != 0 {
+		t.Errorf("DeleteNote() = %v, notes left %d; want none left", deleteErr, len(remaining))
+	}
+}
+
+func TestUserService_ListPage(t *testing.T) {
+	// Arrange
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	users := make([]*model.User, 5)
+	for i := range users {
+		users[i] = testutil.TestUserWithID(uuid.New())
+		users[i].CreatedAt = base.Add(time.Duration(i) * time.Hour)
+	}
+	mockRepo := &testutil.MockUserRepo{
+		ListAfterFn: func(ctx context.Context, cursor *model.ListCursor, limit int, filters map[string]interface{}, sortOrder string) ([]*model.User, error) {
+			start := 0
+			if cursor != nil {
+				for i, u := range users {
+					if u.ID == cursor.ID {
+						start = i + 1
+					}
+				}
+			}
+			end := min(start+limit, len(users))
+			return users[start:end], nil
+		},
+	}
+	service := NewUserService(mockRepo, zap.NewNop().Sugar())
+	ctx := context.Background()
+
+	// Act
+	first, next, err := service.ListPage(ctx, "", 3, map[string]interface{}{}, "asc")
+	second, last, secondErr := service.ListPage(ctx, next, 3, map[string]interface{}{}, "asc")
+	_, _, invalidErr := service.ListPage(ctx, "garbage", 3, map[string]interface{}{}, "asc")
+
+	// Assert
+	if err != nil || len(first) != 3 || next == "" {
+		t.Fatalf("ListPage() first page = %d users, cursor %q, %v; want 3 and a cursor", len(first), next, err)
+	}
+	if secondErr != nil || len(second) != 2 || second[0].ID != users[3].ID || last != "" {
+		t.Errorf("ListPage() second page = %d users, cursor %q, %v; want the last 2 and no cursor", len(second), last, secondErr)
+	}
+	if appErr, ok := apperrors.IsAppError(invalidErr); !ok || appErr.Type != apperrors.BadRequestError {
+		t.Errorf("ListPage() with an invalid cursor error = %v, want BadRequestError", invalidErr)
+	}
+}
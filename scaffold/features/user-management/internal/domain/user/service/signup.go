@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/auth/actiontoken"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/dto"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/config"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/email"
+	"github.com/MurtadaNazar/go-api-template/internal/platform/risk"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/clock"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+)
+
+// WithSignupCaptcha makes every Signup solve a CAPTCHA checked by v, whatever its risk score
+func WithSignupCaptcha(v risk.CaptchaVerifier) ServiceOption {
+	return func(s *userService) {
+		s.signupCaptcha = v
+	}
+}
+
+// WithEmailVerification sends accounts created by Signup a link to cfg.VerifyURL that
+// confirms their email address, carrying a single-use token from tokens. Without it
+// Signup sends nothing.
+func WithEmailVerification(sender email.Sender, tokens *actiontoken.Service, cfg config.SignupConfig) ServiceOption {
+	return func(s *userService) {
+		s.verifySender = sender
+		s.actionTokens = tokens
+		s.signup = cfg
+	}
+}
+
+// WithClock replaces the clock used for verification and deletion times
+func WithClock(c clock.Clock) ServiceOption {
+	return func(s *userService) {
+		s.clock = c
+	}
+}
+
+// Signup registers a user from the public signup endpoint: always with the default role,
+// scored for abuse, after a CAPTCHA when WithSignupCaptcha is set, and followed by a
+// verification email
+func (s *userService) Signup(ctx context.Context, req *dto.SignupRequest) (*model.User, error) {
+	if s.signupCaptcha != nil {
+		var err error
+		ctx, err = risk.VerifyCaptcha(ctx, s.signupCaptcha, actor.ClientIP(ctx))
+		if err != nil {
+			if appErr, ok := apperrors.IsAppError(err); ok {
+				s.logger.Warnw("signup without a solved captcha", "ip", actor.ClientIP(ctx))
+				return nil, appErr
+			}
+			s.logger.Errorw("captcha verification failed", "ip", actor.ClientIP(ctx), "error", err)
+			return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to verify CAPTCHA")
+		}
+	}
+
+	// Scored before the uniqueness checks, so probing for taken names counts too
+	assessment, err := s.risk.Check(ctx, risk.Attempt{Kind: risk.KindRegister, IP: actor.ClientIP(ctx), Email: req.Email})
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.create(ctx, req.CreateRequest(), assessment)
+	if err != nil {
+		return nil, err
+	}
+	s.sendVerification(ctx, user)
+	return user, nil
+}
+
+// CheckAvailability reports whether q's username and email could be used to sign up,
+// without creating anything. It reveals which accounts exist, so its route is rate
+// limited.
+func (s *userService) CheckAvailability(ctx context.Context, q *dto.AvailabilityQuery) (*dto.AvailabilityResponse, error) {
+	resp := &dto.AvailabilityResponse{}
+	if q.Username != "" {
+		existing, err := s.repo.FindByUsername(ctx, q.Username)
+		if err != nil {
+			s.logger.Errorw("failed to check username availability", "error", err)
+			return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to check availability")
+		}
+		resp.Username = availability(existing != nil, "taken")
+	}
+	if q.Email != "" {
+		if _, blocked := s.disposable.Match(q.Email); blocked {
+			resp.Email = availability(true, "blocked")
+		} else {
+			existing, err := s.repo.GetByEmail(ctx, q.Email)
+			if err != nil {
+				s.logger.Errorw("failed to check email availability", "error", err)
+				return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to check availability")
+			}
+			resp.Email = availability(existing != nil, "taken")
+		}
+	}
+	return resp, nil
+}
+
+func availability(unavailable bool, reason string) *dto.Availability {
+	if unavailable {
+		return &dto.Availability{Reason: reason}
+	}
+	return &dto.Availability{Available: true}
+}
+
+// VerifyEmail marks the email of the user named by a verification link token as verified.
+// Each link works once, and stops working when it expires or the user changes their email.
+func (s *userService) VerifyEmail(ctx context.Context, token string) (*model.User, error) {
+	invalid := apperrors.NewAppError(apperrors.BadRequestError, "Invalid or expired verification link")
+	if s.actionTokens == nil {
+		return nil, invalid
+	}
+
+	issued, err := s.actionTokens.Consume(ctx, actiontoken.PurposeEmailVerification, token)
+	if errors.Is(err, actiontoken.ErrInvalid) {
+		return nil, invalid
+	}
+	if err != nil {
+		s.logger.Errorw("failed to consume verification token", "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to verify email")
+	}
+	user, err := s.repo.FindByID(ctx, issued.Subject)
+	if err != nil {
+		s.logger.Errorw("failed to fetch user for email verification", "user_id", issued.Subject, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to verify email")
+	}
+	// The link confirms the address it was sent to, not one the user has switched to since
+	if user == nil || !strings.EqualFold(user.Email, issued.Data["email"]) {
+		return nil, invalid
+	}
+	if user.EmailVerified() {
+		return user, nil
+	}
+
+	now := s.clock.Now()
+	user.EmailVerifiedAt = &now
+	if err := s.repo.Update(ctx, user); err != nil {
+		s.logger.Errorw("failed to mark email verified", "user_id", user.ID, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to verify email")
+	}
+	s.logger.Infow("email verified", "user_id", user.ID)
+	return user, nil
+}
+
+// sendVerification emails user a verification link. Failures are logged only: the
+// account exists either way.
+func (s *userService) sendVerification(ctx context.Context, user *model.User) {
+	if s.verifySender == nil || s.actionTokens == nil {
+		return
+	}
+	token, issued, err := s.actionTokens.Issue(ctx, actiontoken.PurposeEmailVerification, user.ID.String(),
+		s.signup.VerifyTTL, map[string]string{"email": strings.ToLower(user.Email)})
+	if err != nil {
+		s.logger.Errorw("failed to issue verification token", "user_id", user.ID, "error", err)
+		return
+	}
+	msg := email.Message{
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Body: "Hi " + user.FirstName + ",\n\n" +
+			"please confirm your email address by visiting " + s.verifyURL(token) + "\n\n" +
+			"The link is valid until " + issued.ExpiresAt.UTC().Format(time.RFC1123) + ". " +
+			"If you did not sign up, you can ignore this email.",
+	}
+	if err := s.verifySender.Send(ctx, msg); err != nil {
+		s.logger.Errorw("failed to send verification email", "user_id", user.ID, "error", err)
+	}
+}
+
+func (s *userService) verifyURL(token string) string {
+	separator := "?"
+	if strings.Contains(s.signup.VerifyURL, "?") {
+		separator = "&"
+	}
+	return s.signup.VerifyURL + separator + "token=" + url.QueryEscape(token)
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/repo"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+
+	"github.com/google/uuid"
+)
+
+// WithSessionRevoker makes ChangeStatus and Merge sign suspended, deactivated and merged
+// users out everywhere through revoke, e.g. AuthService.RevokeUserRefreshTokens
+func WithSessionRevoker(revoke func(ctx context.Context, userID string) error) ServiceOption {
+	return func(s *userService) {
+		s.revokeSessions = revoke
+	}
+}
+
+// WithStatusHistory records every status change of a user, with its reason and actor, in r
+func WithStatusHistory(r repo.StatusHistoryRepo) ServiceOption {
+	return func(s *userService) {
+		s.statusHistory = r
+	}
+}
+
+// ChangeStatus moves a user to status, one of model.StatusActive, StatusInactive and
+// StatusSuspended, and keeps reason on the account. Suspending or deactivating requires a
+// reason and ends the user's sessions. Deleted accounts and the caller's own account
+// cannot be changed, and moves model.CanTransition rejects fail with a ConflictError.
+func (s *userService) ChangeStatus(ctx context.Context, id, status, reason string) (*model.User, error) {
+	switch status {
+	case model.StatusActive, model.StatusInactive, model.StatusSuspended:
+	default:
+		return nil, apperrors.NewAppError(apperrors.BadRequestError, "Unknown account status")
+	}
+	if status != model.StatusActive && reason == "" {
+		return nil, apperrors.NewAppError(apperrors.ValidationError, "A reason is required to "+statusVerb(status)+" an account")
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, apperrors.ErrUserNotFound
+	}
+	if id == actor.UserID(ctx) {
+		return nil, apperrors.NewAppError(apperrors.ForbiddenError, "You cannot change the status of your own account")
+	}
+
+	user, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		s.logger.Errorw("failed to fetch user for status change", "user_id", id, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to change account status")
+	}
+	if user == nil {
+		return nil, apperrors.ErrUserNotFound
+	}
+	if user.Status == model.StatusDeleted {
+		return nil, apperrors.NewAppError(apperrors.ConflictError, "Deleted accounts cannot change status")
+	}
+	if user.Status == status {
+		return user, nil
+	}
+	if err := checkTransition(user.Status, status); err != nil {
+		return nil, err
+	}
+	before := *user
+
+	user.Status = status
+	user.StatusReason = nil
+	if reason != "" {
+		user.StatusReason = &reason
+	}
+	if err := s.repo.Update(ctx, user); err != nil {
+		s.logger.Errorw("failed to change account status", "user_id", id, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to change account status")
+	}
+	s.invalidateAccount(ctx, id)
+	s.recordRevisions(ctx, user.ID, model.DiffUser(&before, user, actorID(ctx)))
+	s.recordStatusChange(ctx, user, before.Status)
+	s.logger.Infow("account status changed",
+		"audit", true,
+		"user_id", id,
+		"from", before.Status,
+		"to", status,
+		"reason", reason,
+		"by", actor.UserID(ctx),
+		"ip", actor.ClientIP(ctx),
+	)
+
+	// The status is what keeps the user out; revoking only ends sessions sooner
+	if status != model.StatusActive && s.revokeSessions != nil {
+		if err := s.revokeSessions(ctx, id); err != nil {
+			s.logger.Errorw("failed to revoke sessions after status change", "user_id", id, "status", status, "error", err)
+		}
+	}
+	return user, nil
+}
+
+// StatusHistory returns the status changes of a user, newest first
+func (s *userService) StatusHistory(ctx context.Context, id string, offset, limit int) ([]model.StatusChange, error) {
+	if s.statusHistory == nil {
+		return nil, apperrors.NewAppError(apperrors.NotFoundError, "Status history is not enabled")
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, apperrors.NewAppError(apperrors.BadRequestError, "Invalid user ID")
+	}
+
+	changes, err := s.statusHistory.ListByUser(ctx, id, offset, limit)
+	if err != nil {
+		s.logger.Errorw("failed to fetch status history", "user_id", id, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to fetch status history")
+	}
+	return changes, nil
+}
+
+// recordStatusChange stores the move of user from status from to its current status,
+// with its status reason, after the change itself succeeded. Like recordRevisions it only
+// logs failures.
+func (s *userService) recordStatusChange(ctx context.Context, user *model.User, from string) {
+	if s.statusHistory == nil || user.Status == from {
+		return
+	}
+	change := &model.StatusChange{
+		UserID:     user.ID,
+		FromStatus: from,
+		ToStatus:   user.Status,
+		Reason:     user.StatusReason,
+		ActorID:    actorID(ctx),
+	}
+	if err := s.statusHistory.Create(ctx, change); err != nil {
+		s.logger.Errorw("failed to record status change", "user_id", user.ID, "from", from, "to", user.Status, "error", err)
+	}
+}
+
+// checkTransition returns a ConflictError when an account may not move from status from
+// to status to
+func checkTransition(from, to string) error {
+	if model.CanTransition(from, to) {
+		return nil
+	}
+	if from == model.StatusSuspended && to == model.StatusInactive {
+		return apperrors.NewAppError(apperrors.ConflictError, "A suspended account must be reactivated before it can be deactivated")
+	}
+	return apperrors.NewAppError(apperrors.ConflictError, fmt.Sprintf("An account cannot move from %s to %s", from, to))
+}
+
+func statusVerb(status string) string {
+	if status == model.StatusSuspended {
+		return "suspend"
+	}
+	return "deactivate"
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/model"
+	"github.com/MurtadaNazar/go-api-template/internal/domain/user/repo"
+	"github.com/MurtadaNazar/go-api-template/internal/shared/actor"
+	apperrors "github.com/MurtadaNazar/go-api-template/internal/shared/errors"
+
+	"github.com/google/uuid"
+)
+
+// WithTags enables tagging users, stored in r
+func WithTags(r repo.TagRepo) ServiceOption {
+	return func(s *userService) {
+		s.tags = r
+	}
+}
+
+// Tags returns the tags of a user in alphabetical order
+func (s *userService) Tags(ctx context.Context, id string) ([]string, error) {
+	if _, err := s.taggedUser(ctx, id); err != nil {
+		return nil, err
+	}
+	tags, err := s.tags.ListByUser(ctx, id)
+	if err != nil {
+		s.logger.Errorw("failed to list user tags", "user_id", id, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to fetch tags")
+	}
+	return tags, nil
+}
+
+// AddTags puts tags on a user, lowercased, and returns all of its tags. Tags the user
+// already has are kept as they are.
+func (s *userService) AddTags(ctx context.Context, id string, tags []string) ([]string, error) {
+	user, err := s.taggedUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		t, ok := model.NormalizeTag(tag)
+		if !ok {
+			return nil, apperrors.NewAppErrorWithDetails(apperrors.ValidationError, "Invalid tag",
+				"tags: "+tag+" must be up to 50 letters, digits, '_', '.', ':' and '-', starting with a letter or digit")
+		}
+		normalized = append(normalized, t)
+	}
+	if err := s.tags.Add(ctx, user.ID, normalized); err != nil {
+		s.logger.Errorw("failed to add user tags", "user_id", id, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to add tags")
+	}
+	s.logger.Infow("user tags added",
+		"audit", true,
+		"user_id", id,
+		"tags", normalized,
+		"by", actor.UserID(ctx),
+		"ip", actor.ClientIP(ctx),
+	)
+	return s.Tags(ctx, id)
+}
+
+// RemoveTag takes tag off a user and returns its remaining tags
+func (s *userService) RemoveTag(ctx context.Context, id, tag string) ([]string, error) {
+	if _, err := s.taggedUser(ctx, id); err != nil {
+		return nil, err
+	}
+	tag, _ = model.NormalizeTag(tag)
+	removed, err := s.tags.Remove(ctx, id, tag)
+	if err != nil {
+		s.logger.Errorw("failed to remove user tag", "user_id", id, "tag", tag, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to remove tag")
+	}
+	if !removed {
+		return nil, apperrors.NewAppError(apperrors.NotFoundError, "The user does not have this tag")
+	}
+	s.logger.Infow("user tag removed",
+		"audit", true,
+		"user_id", id,
+		"tag", tag,
+		"by", actor.UserID(ctx),
+		"ip", actor.ClientIP(ctx),
+	)
+	return s.Tags(ctx, id)
+}
+
+// TagCounts returns every tag in use with how many users that are not deleted have it
+func (s *userService) TagCounts(ctx context.Context) ([]model.TagCount, error) {
+	if s.tags == nil {
+		return nil, apperrors.NewAppError(apperrors.BadRequestError, "User tags are not enabled")
+	}
+	counts, err := s.tags.Counts(ctx)
+	if err != nil {
+		s.logger.Errorw("failed to count user tags", "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to fetch tags")
+	}
+	return counts, nil
+}
+
+// taggedUser loads the user whose tags are read or changed
+func (s *userService) taggedUser(ctx context.Context, id string) (*model.User, error) {
+	if s.tags == nil {
+		return nil, apperrors.NewAppError(apperrors.BadRequestError, "User tags are not enabled")
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, apperrors.ErrUserNotFound
+	}
+	user, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		s.logger.Errorw("failed to fetch user for tags", "user_id", id, "error", err)
+		return nil, apperrors.NewAppError(apperrors.InternalError, "Failed to fetch tags")
+	}
+	if user == nil {
+		return nil, apperrors.ErrUserNotFound
+	}
+	return user, nil
+}